@@ -0,0 +1,145 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/agent"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/naming"
+)
+
+// agentTemplate is the agent file written by init; %s is the agent name
+const agentTemplate = `# Teneo agent defined without Go code.
+# Run with: teneo-agent run agent.yaml
+# ${VAR} values are read from the environment or the .env file.
+
+agent:
+  name: %s
+  description: A Teneo network agent
+  version: 1.0.0
+  private_key: ${PRIVATE_KEY}
+  capabilities:
+    - chat
+
+llm:
+  provider: openai        # or "ollama" for a local model
+  model: gpt-4o-mini
+  api_key: ${OPENAI_API_KEY}
+  temperature: 0.7
+  max_tokens: 800
+
+prompts:
+  system: |
+    You are a helpful assistant on the Teneo network.
+    Keep answers short and clear.
+
+tools:
+  - name: Help
+    command: help
+    type: static
+    response: "Ask me anything."
+
+rate_limit:
+  per_minute: 30
+
+nft:
+  mint: false             # mint once with: teneo-agent nft mint agent.yaml
+  # mode: wallet-only     # or "anonymous" to run without an NFT
+
+health:
+  port: 8080
+`
+
+// gitignoreTemplate keeps secrets and the identity file out of version control
+const gitignoreTemplate = `.env
+.teneo/
+`
+
+// initProject scaffolds an agent project: an agent file, a commented
+// .env.example and a .gitignore. Existing files are left untouched.
+func initProject(args []string) int {
+	flags := flag.NewFlagSet("init", flag.ContinueOnError)
+	name := flags.String("name", "", "agent name (default: the directory name)")
+	if err := flags.Parse(args); err != nil || flags.NArg() > 1 {
+		fmt.Fprintln(os.Stderr, usage)
+		return 2
+	}
+	dir := "."
+	if flags.NArg() == 1 {
+		dir = flags.Arg(0)
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return 1
+	}
+
+	if *name == "" {
+		absolute, err := filepath.Abs(dir)
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
+			return 1
+		}
+		*name = strings.ToLower(strings.ReplaceAll(filepath.Base(absolute), " ", "-"))
+	}
+	if result := naming.NewDefaultValidator().ValidateName(*name); !result.IsValid {
+		fmt.Printf("❌ invalid agent name %q: %s\n", *name, strings.Join(result.Errors, "; "))
+		fmt.Println("Pass a valid name with -name.")
+		return 1
+	}
+
+	var envExample bytes.Buffer
+	if err := agent.ConfigSpec().WriteEnv(&envExample); err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return 1
+	}
+	files := []struct {
+		name    string
+		content []byte
+	}{
+		{"agent.yaml", []byte(fmt.Sprintf(agentTemplate, *name))},
+		{".env.example", envExample.Bytes()},
+		{".gitignore", []byte(gitignoreTemplate)},
+	}
+	for _, file := range files {
+		path := filepath.Join(dir, file.name)
+		switch err := writeNewFile(path, file.content); {
+		case errors.Is(err, os.ErrExist):
+			fmt.Printf("  ⏭️ %s exists, left unchanged\n", path)
+		case err != nil:
+			fmt.Printf("❌ %v\n", err)
+			return 1
+		default:
+			fmt.Printf("  ✅ %s\n", path)
+		}
+	}
+
+	fmt.Printf("\nAgent %s created. Next steps:\n\n", *name)
+	if dir != "." {
+		fmt.Printf("  cd %s\n", dir)
+	}
+	fmt.Println("  teneo-agent keygen -env .env          # create the agent wallet")
+	fmt.Println("  echo OPENAI_API_KEY=sk-... >> .env")
+	fmt.Println("  teneo-agent config validate agent.yaml")
+	fmt.Println("  teneo-agent nft mint agent.yaml       # once, needs funds on the wallet")
+	fmt.Println("  teneo-agent run agent.yaml")
+	fmt.Println("  teneo-agent status")
+	return 0
+}
+
+// writeNewFile writes a file that must not exist yet
+func writeNewFile(path string, content []byte) error {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(content); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
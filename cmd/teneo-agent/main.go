@@ -0,0 +1,664 @@
+// Command teneo-agent runs a Teneo agent described entirely by a YAML file.
+//
+// Usage:
+//
+//	teneo-agent -config agent.yaml
+//	teneo-agent init [-name my-agent] [dir]
+//	teneo-agent keygen [-env .env]
+//	teneo-agent run [-env .env] agent.yaml
+//	teneo-agent status [-url http://localhost:8080] [-json]
+//	teneo-agent config validate [-env .env] agent.toml
+//	teneo-agent config example [-format env|yaml]
+//	teneo-agent config check-env .env
+//	teneo-agent nft mint [-env .env] agent.yaml
+//	teneo-agent nft verify [-env .env] agent.yaml
+//	teneo-agent nft migrate -to 0xNewContract [-dry-run] [-keep-old-active] agent.yaml
+//	teneo-agent soak -duration 4h [agent.yaml]
+//	teneo-agent dev [-addr 127.0.0.1:8765] [-room general]
+//	teneo-agent replay [-speed 1] [-out responses.jsonl] agent.yaml session.jsonl
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/agent"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/auth"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/devserver"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/envspec"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/health"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/migrate"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/recording"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/soak"
+)
+
+func main() {
+	configPath := flag.String("config", "agent.yaml", "path to the agent YAML file")
+	flag.Parse()
+
+	if args := flag.Args(); len(args) > 0 {
+		os.Exit(runCommand(args))
+	}
+
+	if err := agent.RunConfiguredAgent(*configPath); err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+}
+
+const usage = `usage:
+  teneo-agent init [-name <name>] [<dir>]
+  teneo-agent keygen [-env <file>]
+  teneo-agent run [-env .env] [<file>]
+  teneo-agent status [-url http://localhost:8080] [-json]
+  teneo-agent config validate [-env .env] <file>
+  teneo-agent config example [-format env|yaml]
+  teneo-agent config check-env <file>
+  teneo-agent nft mint [-env .env] <file>
+  teneo-agent nft verify [-env .env] <file>
+  teneo-agent nft migrate -to <contract> [-dry-run] [-keep-old-active] <file>
+  teneo-agent soak [-duration 1h] [-rate 5] [-json] [flags] [<file>]
+  teneo-agent dev [-addr 127.0.0.1:8765] [-room <room>] [-session-ttl 0]
+  teneo-agent replay [-speed 0] [-out <file>] <agent file> <recording>`
+
+// runCommand runs a subcommand and returns the exit code
+func runCommand(args []string) int {
+	switch {
+	case args[0] == "init":
+		return initProject(args[1:])
+	case args[0] == "keygen":
+		return generateKey(args[1:])
+	case args[0] == "run":
+		return runAgent(args[1:])
+	case args[0] == "status":
+		return queryStatus(args[1:])
+	case len(args) >= 2 && args[0] == "config" && args[1] == "validate":
+		return validateConfig(args[2:])
+	case len(args) >= 2 && args[0] == "config" && args[1] == "example":
+		return writeConfigExample(args[2:])
+	case len(args) == 3 && args[0] == "config" && args[1] == "check-env":
+		return checkEnvFile(args[2])
+	case len(args) >= 2 && args[0] == "nft" && args[1] == "mint":
+		return mintNFT(args[2:])
+	case len(args) >= 2 && args[0] == "nft" && args[1] == "verify":
+		return verifyNFT(args[2:])
+	case len(args) >= 2 && args[0] == "nft" && args[1] == "migrate":
+		return migrateNFT(args[2:])
+	case args[0] == "soak":
+		return soakTest(args[1:])
+	case args[0] == "dev":
+		return runDevServer(args[1:])
+	case args[0] == "replay":
+		return replayRecording(args[1:])
+	}
+	fmt.Fprintln(os.Stderr, usage)
+	return 2
+}
+
+// envFlag adds the -env flag of the commands that read the agent's settings
+// and returns a function that loads the file into the environment. The
+// default .env is skipped if it does not exist.
+func envFlag(flags *flag.FlagSet) func() error {
+	path := flags.String("env", ".env", "env file loaded before the config (variables already set win)")
+	return func() error {
+		explicit := false
+		flags.Visit(func(f *flag.Flag) { explicit = explicit || f.Name == "env" })
+		if _, err := os.Stat(*path); !explicit && errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return agent.DefaultConfig().LoadEnvFile(*path)
+	}
+}
+
+// generateKey creates a wallet for the agent and prints its private key, or
+// adds it to an env file as PRIVATE_KEY
+func generateKey(args []string) int {
+	flags := flag.NewFlagSet("keygen", flag.ContinueOnError)
+	envPath := flags.String("env", "", "env file the key is added to (printed if empty)")
+	if err := flags.Parse(args); err != nil || flags.NArg() > 0 {
+		fmt.Fprintln(os.Stderr, usage)
+		return 2
+	}
+
+	privateKey, address, err := auth.GeneratePrivateKey()
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return 1
+	}
+	if *envPath == "" {
+		fmt.Printf("PRIVATE_KEY=%s\n", privateKey)
+		fmt.Fprintf(os.Stderr, "Wallet address: %s\nKeep the private key secret; anyone holding it controls the agent.\n", address)
+		return 0
+	}
+
+	if err := addPrivateKey(*envPath, privateKey); err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return 1
+	}
+	fmt.Printf("✅ Added PRIVATE_KEY to %s\n", *envPath)
+	fmt.Printf("Wallet address: %s\n", address)
+	fmt.Println("Fund this address before minting the agent NFT.")
+	return 0
+}
+
+// addPrivateKey appends PRIVATE_KEY to an env file, creating it readable by
+// the owner only. A file that already sets PRIVATE_KEY is left unchanged.
+func addPrivateKey(path, privateKey string) error {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	entries, err := envspec.ParseEnv(file)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	for _, entry := range entries {
+		if entry.Name == "PRIVATE_KEY" {
+			return fmt.Errorf("%s already sets PRIVATE_KEY; remove it first to replace the key", path)
+		}
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	line := "PRIVATE_KEY=" + privateKey + "\n"
+	if info.Size() > 0 {
+		last := make([]byte, 1)
+		if _, err := file.ReadAt(last, info.Size()-1); err != nil {
+			return err
+		}
+		if last[0] != '\n' {
+			line = "\n" + line
+		}
+	}
+	_, err = file.WriteString(line)
+	return err
+}
+
+// runAgent runs the agent of an agent file until interrupted
+func runAgent(args []string) int {
+	flags := flag.NewFlagSet("run", flag.ContinueOnError)
+	loadEnv := envFlag(flags)
+	if err := flags.Parse(args); err != nil || flags.NArg() > 1 {
+		fmt.Fprintln(os.Stderr, usage)
+		return 2
+	}
+	path := "agent.yaml"
+	if flags.NArg() == 1 {
+		path = flags.Arg(0)
+	}
+	if err := loadEnv(); err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return 1
+	}
+
+	if err := agent.RunConfiguredAgent(path); err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// queryStatus prints the status reported by a running agent's health server
+// and fails unless the agent is connected and authenticated
+func queryStatus(args []string) int {
+	flags := flag.NewFlagSet("status", flag.ContinueOnError)
+	url := flags.String("url", "http://localhost:8080", "address of the agent's health server")
+	timeout := flags.Duration("timeout", 5*time.Second, "time to wait for the agent")
+	asJSON := flags.Bool("json", false, "print the status as JSON")
+	if err := flags.Parse(args); err != nil || flags.NArg() > 0 {
+		fmt.Fprintln(os.Stderr, usage)
+		return 2
+	}
+
+	client := &http.Client{Timeout: *timeout}
+	resp, err := client.Get(strings.TrimSuffix(*url, "/") + "/status")
+	if err != nil {
+		fmt.Printf("❌ agent not reachable: %v\n", err)
+		return 1
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		fmt.Printf("❌ %s/status returned %s\n", *url, resp.Status)
+		return 1
+	}
+	var status health.HealthStatus
+	if err := json.NewDecoder(resp.Body).Decode(&status); err != nil {
+		fmt.Printf("❌ invalid status response: %v\n", err)
+		return 1
+	}
+
+	if *asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		encoder.Encode(status)
+	} else {
+		info := status.Agent
+		fmt.Printf("%s v%s (%s)\n", info.Name, info.Version, status.Status)
+		fmt.Printf("  Wallet:        %s\n", info.Wallet)
+		if info.IdentityMode != "" {
+			fmt.Printf("  Identity:      %s\n", info.IdentityMode)
+		}
+		fmt.Printf("  Connected:     %v\n", status.Connected)
+		fmt.Printf("  Authenticated: %v\n", status.Authenticated)
+		fmt.Printf("  Active tasks:  %d\n", status.ActiveTasks)
+		fmt.Printf("  Uptime:        %s\n", status.Uptime)
+		fmt.Printf("  Capabilities:  %s\n", strings.Join(info.Capabilities, ", "))
+		if status.Progress != nil {
+			fmt.Printf("  Progress:      %.0f%% over %d task(s)\n", status.Progress.Overall, len(status.Progress.Tasks))
+		}
+		if drift := status.MetadataDrift; drift != nil && drift.Drifted {
+			fmt.Printf("  ⚠️ NFT metadata differs from the config in %d field(s)\n", len(drift.Changes))
+		}
+	}
+	if !status.Connected || !status.Authenticated {
+		return 1
+	}
+	return 0
+}
+
+// mintNFT mints the agent's NFT and saves the token ID in the identity file
+func mintNFT(args []string) int {
+	flags := flag.NewFlagSet("nft mint", flag.ContinueOnError)
+	loadEnv := envFlag(flags)
+	if err := flags.Parse(args); err != nil || flags.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, usage)
+		return 2
+	}
+	if err := loadEnv(); err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return 1
+	}
+	config, err := agent.LoadConfigFile(flags.Arg(0))
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return 1
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	fmt.Printf("Minting the NFT of %s...\n", config.Name)
+	tokenID, err := agent.MintNFT(ctx, config, agent.MintOptions{})
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		if tokenID > 0 {
+			fmt.Printf("Set NFT_TOKEN_ID=%d so the agent does not mint again.\n", tokenID)
+		}
+		return 1
+	}
+	fmt.Printf("✅ Minted token %d", tokenID)
+	if config.IdentityFile != "" {
+		fmt.Printf(", saved in %s", config.IdentityFile)
+	}
+	fmt.Println(".")
+	return 0
+}
+
+// verifyNFT checks that the agent's wallet owns its NFT
+func verifyNFT(args []string) int {
+	flags := flag.NewFlagSet("nft verify", flag.ContinueOnError)
+	loadEnv := envFlag(flags)
+	if err := flags.Parse(args); err != nil || flags.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, usage)
+		return 2
+	}
+	if err := loadEnv(); err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return 1
+	}
+	config, err := agent.LoadConfigFile(flags.Arg(0))
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return 1
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	status, err := agent.VerifyNFT(ctx, config)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return 1
+	}
+	fmt.Printf("Token %d (from %s) on %s\n", status.TokenID, status.Source, config.NFTContractAddress)
+	if !status.Owned() {
+		fmt.Printf("❌ owned by %s, not the agent wallet %s\n", status.Owner, status.Wallet)
+		return 1
+	}
+	fmt.Printf("✅ owned by the agent wallet %s\n", status.Wallet)
+	return 0
+}
+
+// validateConfig reports the problems of a config file
+func validateConfig(args []string) int {
+	flags := flag.NewFlagSet("config validate", flag.ContinueOnError)
+	loadEnv := envFlag(flags)
+	if err := flags.Parse(args); err != nil || flags.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, usage)
+		return 2
+	}
+	if err := loadEnv(); err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return 1
+	}
+
+	path := flags.Arg(0)
+	problems := agent.ValidateConfigFile(path)
+	if len(problems) == 0 {
+		fmt.Printf("✅ %s is valid\n", path)
+		return 0
+	}
+	fmt.Printf("❌ %s has %d problem(s):\n", path, len(problems))
+	for _, problem := range problems {
+		fmt.Printf("  - %v\n", problem)
+	}
+	return 1
+}
+
+// writeConfigExample prints a commented .env.example or YAML config with
+// every setting, its type and its default
+func writeConfigExample(args []string) int {
+	flags := flag.NewFlagSet("config example", flag.ContinueOnError)
+	format := flags.String("format", "env", "env or yaml")
+	if err := flags.Parse(args); err != nil || flags.NArg() > 0 {
+		fmt.Fprintln(os.Stderr, usage)
+		return 2
+	}
+
+	spec := agent.ConfigSpec()
+	var err error
+	switch *format {
+	case "env":
+		err = spec.WriteEnv(os.Stdout)
+	case "yaml":
+		err = spec.WriteYAML(os.Stdout)
+	default:
+		fmt.Fprintln(os.Stderr, usage)
+		return 2
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// checkEnvFile reports unknown variables and invalid values in a .env file
+func checkEnvFile(path string) int {
+	file, err := os.Open(path)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return 1
+	}
+	defer file.Close()
+
+	entries, err := envspec.ParseEnv(file)
+	if err != nil {
+		fmt.Printf("❌ %s: %v\n", path, err)
+		return 1
+	}
+	problems := agent.ConfigSpec().Check(entries)
+	if len(problems) == 0 {
+		fmt.Printf("✅ %s is valid\n", path)
+		return 0
+	}
+	fmt.Printf("❌ %s has %d problem(s):\n", path, len(problems))
+	for _, problem := range problems {
+		fmt.Printf("  - %v\n", problem)
+	}
+	return 1
+}
+
+// migrateNFT moves the agent's business card to a new contract
+func migrateNFT(args []string) int {
+	flags := flag.NewFlagSet("nft migrate", flag.ContinueOnError)
+	var options agent.MigrationOptions
+	flags.StringVar(&options.Contract, "to", "", "address of the new business card contract")
+	flags.BoolVar(&options.DryRun, "dry-run", false, "show the steps without sending transactions")
+	flags.BoolVar(&options.KeepOldActive, "keep-old-active", false, "leave the old card active")
+	if err := flags.Parse(args); err != nil || flags.NArg() != 1 || options.Contract == "" {
+		fmt.Fprintln(os.Stderr, usage)
+		return 2
+	}
+
+	config, err := agent.LoadConfigFile(flags.Arg(0))
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return 1
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	result, err := agent.MigrateNFT(ctx, config, options)
+	if result != nil {
+		if result.DryRun {
+			fmt.Println("Dry run, no transactions sent:")
+		}
+		for _, step := range result.Steps {
+			fmt.Printf("  %s %-20s %s\n", stepIcon(step.Status), step.Name, step.Detail)
+		}
+	}
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		fmt.Println("Fix the problem and run the command again; completed steps are skipped.")
+		return 1
+	}
+	if !result.DryRun {
+		fmt.Printf("✅ Migrated to %s. Set NFT_CONTRACT_ADDRESS=%s", options.Contract, options.Contract)
+		if config.NFTTokenID != "" && result.New != nil {
+			fmt.Printf(" and NFT_TOKEN_ID=%s", result.New.TokenID)
+		}
+		fmt.Println(".")
+	}
+	return 0
+}
+
+func stepIcon(status migrate.StepStatus) string {
+	switch status {
+	case migrate.StepDone:
+		return "✅"
+	case migrate.StepPlanned:
+		return "📝"
+	case migrate.StepFailed:
+		return "❌"
+	default:
+		return "⏭️"
+	}
+}
+
+// soakTest runs the agent against a local coordinator with synthetic load and
+// faults, and fails if it loses responses, leaks goroutines or grows its heap
+func soakTest(args []string) int {
+	flags := flag.NewFlagSet("soak", flag.ContinueOnError)
+	var options soak.Config
+	var handler soak.Handler
+	var heapGrowthMB uint64
+	flags.DurationVar(&options.Duration, "duration", time.Hour, "time under load")
+	flags.DurationVar(&options.Warmup, "warmup", time.Minute, "load before the resource baseline is taken")
+	flags.DurationVar(&options.Grace, "grace", 2*time.Minute, "time after the load for the last responses")
+	flags.DurationVar(&options.SampleInterval, "sample-interval", 30*time.Second, "interval of resource samples and progress lines")
+	flags.Float64Var(&options.Rate, "rate", 5, "tasks per second")
+	flags.IntVar(&options.Rooms, "rooms", 3, "rooms the tasks are spread over")
+	flags.IntVar(&options.PayloadBytes, "payload", 256, "size of each task in bytes")
+	flags.DurationVar(&options.Faults.DisconnectEvery, "disconnect-every", 10*time.Minute, "mean time between forced disconnects (0 = never)")
+	flags.DurationVar(&options.Faults.Latency, "latency", 100*time.Millisecond, "maximum random delay of server messages")
+	flags.Float64Var(&options.Faults.DropRate, "drop-rate", 0.01, "fraction of task deliveries lost")
+	flags.Float64Var(&options.Faults.AuthFailureRate, "auth-failure-rate", 0.05, "fraction of authentications rejected")
+	flags.DurationVar(&options.Faults.SessionTTL, "session-ttl", 15*time.Minute, "session lifetime (0 = no expiry)")
+	flags.DurationVar(&handler.Latency, "handler-latency", 200*time.Millisecond, "maximum processing time of the synthetic handler")
+	flags.Float64Var(&handler.ErrorRate, "handler-error-rate", 0.01, "fraction of tasks the synthetic handler fails")
+	flags.IntVar(&options.MaxGoroutineGrowth, "max-goroutine-growth", 25, "goroutines the run may leave behind")
+	flags.Uint64Var(&heapGrowthMB, "max-heap-growth", 64, "MiB the live heap may grow above the baseline")
+	asJSON := flags.Bool("json", false, "print the report as JSON")
+	verbose := flags.Bool("v", false, "show the agent's logs")
+	if err := flags.Parse(args); err != nil || flags.NArg() > 1 {
+		fmt.Fprintln(os.Stderr, usage)
+		return 2
+	}
+	options.MaxHeapGrowth = heapGrowthMB << 20
+
+	config := agent.DefaultConfig()
+	config.Name = "Soak Agent"
+	config.Capabilities = []string{"soak"}
+	if flags.NArg() == 1 {
+		loaded, err := agent.LoadConfigFile(flags.Arg(0))
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
+			return 1
+		}
+		config = loaded
+	}
+	if !*verbose {
+		config.LogLevel = "error"
+	}
+
+	options.Progress = func(report *soak.Report) {
+		if !*asJSON {
+			printSoakProgress(report)
+		}
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	fmt.Printf("Soak test of %s for %s at %.1f tasks/s (Ctrl-C ends the load early)\n", config.Name, options.Duration, options.Rate)
+	report, err := agent.SoakTest(ctx, config, &handler, options)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return 1
+	}
+
+	if *asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		encoder.Encode(report)
+	} else {
+		stats := report.Stats
+		fmt.Printf("Tasks %d, answered %d, redelivered %d, duplicate responses %d, mean latency %s, max %s\n",
+			stats.Tasks, stats.Answered, stats.Redeliveries, stats.DuplicateResponses, stats.MeanLatency.Round(time.Millisecond), stats.MaxLatency.Round(time.Millisecond))
+		fmt.Printf("Faults: %d disconnects, %d dropped tasks, %d rejected authentications, %d expired sessions\n",
+			stats.Disconnects, stats.Dropped, stats.AuthFailures, stats.SessionExpiries)
+		resources := report.Resources
+		fmt.Printf("Goroutines %d → %d (peak %d), heap %s → %s (peak %s)\n",
+			resources.Baseline.Goroutines, resources.Final.Goroutines, resources.Peak.Goroutines,
+			mebibytes(resources.Baseline.HeapBytes), mebibytes(resources.Final.HeapBytes), mebibytes(resources.Peak.HeapBytes))
+	}
+	if !report.Passed() {
+		for _, violation := range report.Violations {
+			fmt.Printf("❌ %s\n", violation)
+		}
+		return 1
+	}
+	if !*asJSON {
+		fmt.Println("✅ Soak test passed")
+	}
+	return 0
+}
+
+func printSoakProgress(report *soak.Report) {
+	stats := report.Stats
+	fmt.Printf("  %-10s tasks %-7d answered %-7d pending %-5d disconnects %-4d heap %s\n",
+		report.Elapsed.Round(time.Second), stats.Tasks, stats.Answered, stats.Pending, stats.Disconnects, mebibytes(report.Resources.Peak.HeapBytes))
+}
+
+func mebibytes(bytes uint64) string {
+	return fmt.Sprintf("%.1f MiB", float64(bytes)/(1<<20))
+}
+
+// runDevServer runs a local coordinator and sends each line read from stdin
+// to a connected agent as a task, printing the agent's response
+func runDevServer(args []string) int {
+	flags := flag.NewFlagSet("dev", flag.ContinueOnError)
+	var config devserver.Config
+	flags.StringVar(&config.Addr, "addr", "127.0.0.1:8765", "address to listen on")
+	flags.DurationVar(&config.Faults.SessionTTL, "session-ttl", 0, "session lifetime (0 = no expiry)")
+	room := flags.String("room", "", "room tasks are asked in (empty = any agent)")
+	timeout := flags.Duration("timeout", 2*time.Minute, "time to wait for each response")
+	if err := flags.Parse(args); err != nil || flags.NArg() > 0 {
+		fmt.Fprintln(os.Stderr, usage)
+		return 2
+	}
+
+	server, err := devserver.New(&config)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return 1
+	}
+	defer server.Close()
+
+	fmt.Printf("Local coordinator listening, start your agent with:\n\n")
+	fmt.Printf("  WEBSOCKET_URL=%s BACKEND_URL=%s IDENTITY_MODE=anonymous\n\n", server.URL(), server.HTTPURL())
+	fmt.Println("Then type a task and press Enter (Ctrl-D quits).")
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	lines := bufio.NewScanner(os.Stdin)
+	for lines.Scan() {
+		content := strings.TrimSpace(lines.Text())
+		if content == "" {
+			continue
+		}
+		taskCtx, cancel := context.WithTimeout(ctx, *timeout)
+		result, err := server.Ask(taskCtx, *room, content)
+		cancel()
+		switch {
+		case err != nil:
+			fmt.Printf("❌ %v\n", err)
+		case !result.Success:
+			fmt.Printf("❌ %s failed: %s\n", result.Agent, result.Error)
+		default:
+			fmt.Printf("%s [%s]: %s\n", result.Agent, result.Response.ContentType, result.Response.Content)
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return 0
+}
+
+// replayRecording runs the tasks of a recorded session through the agent of
+// an agent file, without connecting to the network
+func replayRecording(args []string) int {
+	flags := flag.NewFlagSet("replay", flag.ContinueOnError)
+	var options recording.ReplayOptions
+	flags.Float64Var(&options.Speed, "speed", 0, "replay pace relative to the recording, e.g. 1 = as recorded (0 = no delays)")
+	outPath := flags.String("out", "", "JSONL file the responses are appended to")
+	if err := flags.Parse(args); err != nil || flags.NArg() != 2 {
+		fmt.Fprintln(os.Stderr, usage)
+		return 2
+	}
+
+	file, err := agent.LoadAgentFile(flags.Arg(0))
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return 1
+	}
+	// Replay needs no wallet or NFT, and must not add to a recording
+	file.NFT.Mode = string(agent.IdentityModeAnonymous)
+	os.Unsetenv("RECORD_FILE")
+	replayAgent, err := agent.NewAgentFromFile(file)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return 1
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	replayed, err := replayAgent.ReplayRecording(ctx, flags.Arg(1), options, *outPath)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return 1
+	}
+	fmt.Printf("✅ Replayed %d tasks\n", replayed)
+	return 0
+}
@@ -11,6 +11,9 @@ import (
 
 	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/agent"
 	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/cache"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/format"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/handlers"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/llm"
 	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/types"
 	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/version"
 	"github.com/joho/godotenv"
@@ -22,35 +25,49 @@ type ExampleAgent struct {
 	capabilities []string
 	cache        cache.AgentCache // Redis cache for persistent storage
 	taskCount    int64            // Track tasks processed (persisted in cache)
+	router       *handlers.Router // Ready-made handlers; everything else goes to the demo logic
 }
 
 // NewExampleAgent creates a new example agent
 func NewExampleAgent() *ExampleAgent {
-	return &ExampleAgent{
+	a := &ExampleAgent{
 		name: "Enhanced Example Agent",
 		capabilities: []string{
-			"text_analysis_detailed",
-			"content_generation_stories",
-			"content_generation_poems",
-			"content_generation_emails",
-			"code_assistance_debug",
-			"code_assistance_examples",
-			"math_calculations_basic",
-			"math_calculations_expressions",
-			"weather_information_demo",
-			"time_utilities_timezone",
-			"system_status_health",
-			"data_formatting_json",
-			"data_formatting_csv",
-			"data_formatting_tables",
-			"translation_multilingual",
-			"text_summarization",
-			"conversation_natural",
-			"help_commands_detailed",
-			"streaming_responses",
-			"multi_message_tasks",
+			"text/analysis:detailed",
+			"content/generation:stories",
+			"content/generation:poems",
+			"content/generation:emails",
+			"code/assistance:debug",
+			"code/assistance:examples",
+			"math/calculations:basic",
+			"math/calculations:expressions",
+			"weather/information:demo",
+			"time/utilities:timezone",
+			"system/status:health",
+			"data/formatting:json",
+			"data/formatting:csv",
+			"data/formatting:tables",
+			"text/conversation:natural",
+			"help/commands:detailed",
+			"response/streaming",
+			"response/multi-message",
 		},
 	}
+
+	// Real handlers from pkg/handlers; the LLM-backed ones need an OpenAI key
+	a.router = handlers.NewRouter()
+	a.router.Mount("web/fetch", handlers.NewURLFetcher(nil), "fetch")
+	a.router.Mount("data/conversion:csv-json", handlers.NewCSVToJSON(nil), "csv2json")
+	if apiKey := os.Getenv("OPENAI_API_KEY"); apiKey != "" {
+		provider := llm.NewOpenAIProvider(&llm.OpenAIConfig{APIKey: apiKey})
+		a.router.Mount("text/summarization", handlers.NewSummarizer(provider, nil), "summarize", "tldr")
+		a.router.Mount("text/translation:multilingual", handlers.NewTranslator(provider, nil), "translate")
+	} else {
+		log.Printf("ℹ️  Summarization and translation disabled (set OPENAI_API_KEY to enable)")
+	}
+	a.router.Fallback(handlers.HandlerFunc(a.processDemoTask))
+	a.capabilities = append(a.capabilities, a.router.Capabilities()...)
+	return a
 }
 
 // ProcessTask processes a task and returns a result
@@ -64,6 +81,11 @@ func (a *ExampleAgent) ProcessTask(ctx context.Context, task string) (string, er
 		a.cache.Set(ctx, "stats:last_task_time", time.Now().Format(time.RFC3339), 0)
 	}
 
+	return a.router.ProcessTask(ctx, task)
+}
+
+// processDemoTask handles the tasks none of the mounted handlers takes
+func (a *ExampleAgent) processDemoTask(ctx context.Context, task string) (string, error) {
 	taskLower := strings.ToLower(strings.TrimSpace(task))
 
 	// Handle help and capabilities
@@ -127,17 +149,8 @@ func (a *ExampleAgent) ProcessTask(ctx context.Context, task string) (string, er
 
 	// Data Formatting
 	if strings.Contains(taskLower, "format") || strings.Contains(taskLower, "json") || strings.Contains(taskLower, "csv") || strings.Contains(taskLower, "table") {
-		return a.formatData(task), nil
-	}
-
-	// Translation
-	if strings.Contains(taskLower, "translate") || strings.Contains(taskLower, "translation") {
-		return a.translateText(task), nil
-	}
-
-	// Summarization
-	if strings.Contains(taskLower, "summarize") || strings.Contains(taskLower, "summary") || strings.Contains(taskLower, "tldr") {
-		return a.summarizeText(task), nil
+		result, _ := a.formatData(task)
+		return result, nil
 	}
 
 	// Default conversation
@@ -171,6 +184,20 @@ func (a *ExampleAgent) ProcessTaskWithStreaming(ctx context.Context, task string
 		return a.handleProgressiveGeneration(task, sender)
 	}
 
+	// Send converted data with its content type so clients can render it
+	if strings.HasPrefix(taskLower, "format") || strings.Contains(taskLower, "table from") {
+		if tabular, ok := sender.(types.TabularSender); ok {
+			result, to := a.formatData(task)
+			switch to {
+			case format.Markdown:
+				return tabular.SendMessageAsTable(result)
+			case format.CSV:
+				return tabular.SendMessageAsCSV(result)
+			}
+			return sender.SendMessage(result)
+		}
+	}
+
 	// Fall back to regular processing but send result via streaming
 	result, err := a.ProcessTask(ctx, task)
 	if err != nil {
@@ -222,16 +249,22 @@ func (a *ExampleAgent) getHelpMessage() string {
    • "system health" - Detailed system information
 
 **📊 Data Formatting:**
-   • "format this data as JSON: [data]" - JSON formatting
-   • "create a table from: [data]" - Table formatting
+   • "format as JSON: [csv or table]" - Convert to JSON
+   • "format as CSV: [json or table]" - Convert to CSV
+   • "create a table from: [json or csv]" - Convert to a markdown table
+
+**🔄 Data Conversion:**
+   • "csv2json [csv]" - Convert CSV to JSON with typed values
+
+**🌐 Web:**
+   • "fetch [url]" - Fetch a web page as readable text
 
-**🌍 Translation:**
+**🌍 Translation (requires OPENAI_API_KEY):**
    • "translate to [language]: [text]" - Text translation
-   • "translation help" - Translation assistance
 
-**📝 Summarization:**
-   • "summarize: [long text]" - Text summarization
-   • "tldr: [content]" - Quick summaries
+**📝 Summarization (requires OPENAI_API_KEY):**
+   • "summarize [long text]" - Text summarization
+   • "tldr [content]" - Quick summaries
 
 **🚀 Streaming & Multi-Message Tasks:**
    • "streaming demo" - See how multiple messages work
@@ -674,102 +707,40 @@ func (a *ExampleAgent) getSystemStatus(ctx context.Context) string {
 		a.taskCount)
 }
 
-// formatData handles data formatting requests
-func (a *ExampleAgent) formatData(task string) string {
-	data := extractTextFromTask(task, "format", "json", "csv", "table")
-
-	if strings.Contains(strings.ToLower(task), "json") {
-		return a.formatAsJSON(data)
-	} else if strings.Contains(strings.ToLower(task), "csv") {
-		return a.formatAsCSV(data)
-	} else if strings.Contains(strings.ToLower(task), "table") {
-		return a.formatAsTable(data)
+// formatData handles data formatting requests: the instruction comes before
+// the first colon or line break and names the target format, the data follows
+func (a *ExampleAgent) formatData(task string) (string, format.Format) {
+	instruction, data, found := strings.Cut(task, ":")
+	if newline := strings.IndexByte(task, '\n'); newline >= 0 && (!found || newline < len(instruction)) {
+		instruction, data, found = task[:newline], task[newline+1:], true
 	}
 
-	return fmt.Sprintf(`📊 **Data Formatting Service:**
-
-📝 **Input Data:** %s
-
-🔧 **Available Formats:**
-   • JSON: "format as JSON: name,age,city John,25,NYC"
-   • CSV: "format as CSV: [your data]"
-   • Table: "create table from: [your data]"
-
-💡 **Example Commands:**
-   • "format this data as JSON: name John, age 25, city NYC"
-   • "create a table from: Product,Price,Stock Apple,1.50,100"
-   • "convert to CSV: user data with names and emails"
-
-🚀 **Ready to format your data in any structure!**`, data)
-}
-
-// translateText handles translation requests
-func (a *ExampleAgent) translateText(task string) string {
-	text := extractTextFromTask(task, "translate", "translation")
-
-	return fmt.Sprintf(`🌍 **Translation Service:**
-
-📝 **Original Text:** %s
-
-🔧 **Translation Example:**
-   • English: "Hello, how are you?"
-   • Spanish: "Hola, ¿cómo estás?"
-   • French: "Bonjour, comment allez-vous?"
-   • German: "Hallo, wie geht es dir?"
-
-💡 **Supported Languages:**
-   • Spanish, French, German, Italian
-   • Portuguese, Dutch, Russian
-   • Chinese, Japanese, Korean
-   • And many more!
-
-🎯 **Usage Examples:**
-   • "translate to Spanish: Hello world"
-   • "translate 'Good morning' to French"
-   • "translation help for business phrases"
-
-📝 **Note:** This is a demo mode. In production, I would connect to translation APIs for accurate real-time translations.`, text)
-}
-
-// summarizeText handles text summarization
-func (a *ExampleAgent) summarizeText(task string) string {
-	text := extractTextFromTask(task, "summarize", "summary", "tldr")
-	if text == "" {
-		return "📝 Please provide text to summarize. Example: 'summarize: [your long text here]'"
+	to := format.Markdown
+	instructionLower := strings.ToLower(instruction)
+	if strings.Contains(instructionLower, "json") {
+		to = format.JSON
+	} else if strings.Contains(instructionLower, "csv") {
+		to = format.CSV
 	}
 
-	words := strings.Fields(text)
-	sentences := strings.Split(text, ".")
-
-	return fmt.Sprintf(`📝 **Text Summarization:**
-
-📄 **Original Text:** %s
-
-📊 **Summary Statistics:**
-   • Original Length: %d words, %d sentences
-   • Compression Ratio: 75%% reduction
-   • Reading Time: ~%d seconds
+	if found && strings.TrimSpace(data) != "" {
+		converted, err := format.Convert(data, "", to)
+		if err == nil {
+			return converted, to
+		}
+		return fmt.Sprintf("❌ Could not read the data: %v", err), ""
+	}
 
-🎯 **Key Points Summary:**
-   • Main Topic: %s
-   • Key Themes: Communication, Information, Assistance
-   • Sentiment: %s
-   • Complexity: %s
+	return `📊 **Data Formatting Service:**
 
-✨ **TL;DR:** The text discusses %s and provides information in a clear, structured format.
+Send JSON, CSV or a markdown table after a colon or on the next line and I'll convert it. The input format is detected; numbers and booleans keep their types.
 
-💡 **Summarization Features:**
-   • Bullet point summaries
-   • Key theme extraction
-   • Sentiment analysis
-   • Custom length summaries`,
-		text,
-		len(words), len(sentences),
-		len(words)/3,
-		detectMainTopic(text),
-		getTextSentiment(text),
-		getTextComplexity(float64(len(words))/float64(len(sentences))),
-		detectMainTopic(text))
+💡 **Example Commands:**
+   • "format as JSON: name,age,city
+John,25,NYC"
+   • "format as CSV: [{"product": "Apple", "price": 1.50}]"
+   • "create a table from: Product;Price;Stock
+Apple;1.50;100"`, ""
 }
 
 // handleConversation handles general conversation
@@ -959,101 +930,6 @@ func getDaysUntilNewYear(t time.Time) int {
 	return int(nextYear.Sub(t).Hours() / 24)
 }
 
-func detectMainTopic(text string) string {
-	lower := strings.ToLower(text)
-	topics := map[string]string{
-		"technology":  "Technology",
-		"business":    "Business",
-		"science":     "Science",
-		"education":   "Education",
-		"health":      "Health",
-		"environment": "Environment",
-	}
-
-	for keyword, topic := range topics {
-		if strings.Contains(lower, keyword) {
-			return topic
-		}
-	}
-	return "General Discussion"
-}
-
-// Data formatting helpers
-func (a *ExampleAgent) formatAsJSON(data string) string {
-	return fmt.Sprintf(`📄 **JSON Formatted Data:**
-
-{
-  "input": "%s",
-  "formatted": true,
-  "timestamp": "%s",
-  "structure": "object",
-  "example": {
-    "key1": "value1",
-    "key2": "value2",
-    "nested": {
-      "property": "data"
-    }
-  }
-}
-
-✅ **JSON Features Applied:**
-   • Proper syntax and structure
-   • Nested object support
-   • String, number, and boolean types
-   • Array formatting capability
-
-💡 **JSON Best Practices:**
-   • Use camelCase for property names
-   • Validate syntax before use
-   • Consider data types carefully
-   • Keep structure logical and readable`, data, time.Now().Format(time.RFC3339))
-}
-
-func (a *ExampleAgent) formatAsCSV(data string) string {
-	return fmt.Sprintf(`📊 **CSV Formatted Data:**
-
-Field1,Field2,Field3,Value
-Input,"%s",Formatted,True
-Name,Description,Category,Status
-Sample,Data,Example,Active
-Record,Information,Type,Valid
-
-✅ **CSV Features Applied:**
-   • Comma-separated values
-   • Quoted text fields
-   • Header row included
-   • Proper escaping
-
-💡 **CSV Best Practices:**
-   • Use consistent delimiters
-   • Quote fields with special characters
-   • Include meaningful headers
-   • Validate data consistency`, data)
-}
-
-func (a *ExampleAgent) formatAsTable(data string) string {
-	return fmt.Sprintf(`📋 **Table Formatted Data:**
-
-| Field        | Value           | Type     | Status |
-|--------------|-----------------|----------|---------|
-| Input        | %s             | String   | ✅ Valid |
-| Timestamp    | %s             | DateTime | ✅ Valid |
-| Format       | Table          | String   | ✅ Valid |
-| Structure    | Organized      | String   | ✅ Valid |
-
-✅ **Table Features Applied:**
-   • Aligned columns
-   • Clear headers
-   • Consistent spacing
-   • Visual separators
-
-💡 **Table Best Practices:**
-   • Keep column widths consistent
-   • Use clear, descriptive headers
-   • Align data appropriately
-   • Include status indicators`, data, time.Now().Format("15:04:05"))
-}
-
 // Streaming task handlers
 func (a *ExampleAgent) handleStreamingDemo(task string, sender types.MessageSender) error {
 	// Send initial acknowledgment
@@ -1501,28 +1377,9 @@ func main() {
 	config.Description = "A demonstration agent showcasing the enhanced Teneo Agent SDK capabilities"
 	config.Image = "https://example.com/agent-avatar.png" // Agent image
 	config.Version = "1.0.0"
-	config.Capabilities = []string{
-		"text_analysis_detailed",
-		"content_generation_stories",
-		"content_generation_poems",
-		"content_generation_emails",
-		"code_assistance_debug",
-		"code_assistance_examples",
-		"math_calculations_basic",
-		"math_calculations_expressions",
-		"weather_information_demo",
-		"time_utilities_timezone",
-		"system_status_health",
-		"data_formatting_json",
-		"data_formatting_csv",
-		"data_formatting_tables",
-		"translation_multilingual",
-		"text_summarization",
-		"conversation_natural",
-		"help_commands_detailed",
-		"streaming_responses",
-		"multi_message_tasks",
-	}
+	// Create agent handler; its capabilities include the mounted handlers
+	agentHandler := NewExampleAgent()
+	config.Capabilities = agentHandler.GetCapabilities()
 	config.HealthEnabled = true
 	config.HealthPort = 8090
 	config.PrivateKey = os.Getenv("PRIVATE_KEY")
@@ -1587,9 +1444,6 @@ func main() {
 		log.Printf("🌐 Health monitoring will be available on port %d", config.HealthPort)
 	}
 
-	// Create agent handler
-	agentHandler := NewExampleAgent()
-
 	// Create enhanced agent with NFT configuration
 	enhancedAgentConfig := &agent.EnhancedAgentConfig{
 		Config:       config,
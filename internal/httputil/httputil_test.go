@@ -0,0 +1,41 @@
+package httputil
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRequireToken(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		WriteJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+	})
+
+	tests := []struct {
+		token, header string
+		status        int
+	}{
+		{"secret", "Bearer secret", http.StatusOK},
+		{"secret", "Bearer wrong", http.StatusUnauthorized},
+		{"secret", "", http.StatusUnauthorized},
+		{"", "Bearer ", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if tt.header != "" {
+			req.Header.Set("Authorization", tt.header)
+		}
+		rec := httptest.NewRecorder()
+		RequireToken(tt.token, ok).ServeHTTP(rec, req)
+		if rec.Code != tt.status {
+			t.Errorf("token %q, header %q: status %d, want %d", tt.token, tt.header, rec.Code, tt.status)
+		}
+		if rec.Header().Get("Content-Type") != "application/json" {
+			t.Errorf("content type %q", rec.Header().Get("Content-Type"))
+		}
+		if tt.status == http.StatusUnauthorized && !strings.Contains(rec.Body.String(), `"error":"unauthorized"`) {
+			t.Errorf("body %q", rec.Body.String())
+		}
+	}
+}
@@ -0,0 +1,116 @@
+// Package access decides which senders and rooms an agent accepts tasks
+// from: allow and deny lists of wallets and rooms, and an optional gate such
+// as holding a token on chain. The task coordinator enforces the policy it is
+// given, see network.TaskCoordinator.SetAccessChecker.
+package access
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/types"
+)
+
+// Reasons a sender is denied, reported in the AccessError
+const (
+	ReasonSenderDenied     = "sender_denied"      // The sender is on the deny list
+	ReasonSenderNotAllowed = "sender_not_allowed" // The sender is not on the allow list
+	ReasonRoomDenied       = "room_denied"        // The room is on the deny list
+	ReasonRoomNotAllowed   = "room_not_allowed"   // The room is not on the allow list
+	ReasonTokenRequired    = "token_required"     // The sender does not pass the gate
+)
+
+// Gate is an extra check on senders that pass the lists, e.g. a TokenGate.
+// Implementations must be safe for concurrent use.
+type Gate interface {
+	// Admits reports whether the sender may run tasks; an error means it
+	// could not be checked
+	Admits(ctx context.Context, sender string) (bool, error)
+}
+
+// Config configures a Policy
+type Config struct {
+	AllowSenders []string // Wallets or users whose tasks are accepted (empty = any sender not denied)
+	DenySenders  []string // Wallets or users whose tasks are rejected
+	AllowRooms   []string // Rooms whose tasks are accepted (empty = any room not denied)
+	DenyRooms    []string // Rooms whose tasks are rejected
+	Gate         Gate     // Checked last, for senders the lists accept (nil = none)
+}
+
+// Policy accepts or denies tasks by their sender and room. Deny lists win
+// over allow lists, and a non-empty allow list admits only its entries.
+// Senders are compared ignoring case, as wallet addresses are; rooms are
+// compared exactly. It implements the types.AccessChecker interface.
+type Policy struct {
+	allowSenders map[string]bool
+	denySenders  map[string]bool
+	allowRooms   map[string]bool
+	denyRooms    map[string]bool
+	gate         Gate
+}
+
+// New creates a policy
+func New(config *Config) *Policy {
+	return &Policy{
+		allowSenders: set(config.AllowSenders, strings.ToLower),
+		denySenders:  set(config.DenySenders, strings.ToLower),
+		allowRooms:   set(config.AllowRooms, nil),
+		denyRooms:    set(config.DenyRooms, nil),
+		gate:         config.Gate,
+	}
+}
+
+// set returns the non-empty entries of a list, normalized by fn if not nil
+func set(entries []string, fn func(string) string) map[string]bool {
+	s := make(map[string]bool, len(entries))
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if fn != nil {
+			entry = fn(entry)
+		}
+		if entry != "" {
+			s[entry] = true
+		}
+	}
+	return s
+}
+
+// Empty reports whether the policy accepts every task
+func (p *Policy) Empty() bool {
+	return len(p.allowSenders) == 0 && len(p.denySenders) == 0 &&
+		len(p.allowRooms) == 0 && len(p.denyRooms) == 0 && p.gate == nil
+}
+
+// CheckAccess implements types.AccessChecker. A task without a known sender
+// is denied only by an allow list of senders or the gate.
+func (p *Policy) CheckAccess(ctx context.Context, sender, room string) error {
+	key := strings.ToLower(strings.TrimSpace(sender))
+	switch {
+	case key != "" && p.denySenders[key]:
+		return deny(ReasonSenderDenied, "tasks from this sender are not accepted")
+	case room != "" && p.denyRooms[room]:
+		return deny(ReasonRoomDenied, fmt.Sprintf("tasks from room %s are not accepted", room))
+	case len(p.allowSenders) > 0 && !p.allowSenders[key]:
+		return deny(ReasonSenderNotAllowed, "this sender is not on the agent's allow list")
+	case len(p.allowRooms) > 0 && !p.allowRooms[room]:
+		return deny(ReasonRoomNotAllowed, fmt.Sprintf("room %s is not on the agent's allow list", room))
+	}
+
+	if p.gate == nil {
+		return nil
+	}
+	admitted, err := p.gate.Admits(ctx, sender)
+	if err != nil {
+		return fmt.Errorf("failed to check access of %s: %w", sender, err)
+	}
+	if !admitted {
+		return deny(ReasonTokenRequired, "the sender does not hold the token this agent requires")
+	}
+	return nil
+}
+
+// deny returns the AccessError for a reason
+func deny(reason, message string) error {
+	return &types.AccessError{Reason: reason, Message: message}
+}
@@ -0,0 +1,120 @@
+package access
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/types"
+)
+
+const (
+	alice = "0x1111111111111111111111111111111111111111"
+	bob   = "0x2222222222222222222222222222222222222222"
+)
+
+// reasonOf returns the reason of an access denial, "" if access was granted
+func reasonOf(t *testing.T, err error) string {
+	t.Helper()
+	if err == nil {
+		return ""
+	}
+	var accessErr *types.AccessError
+	if !errors.As(err, &accessErr) || !errors.Is(err, types.ErrAccessDenied) {
+		t.Fatalf("expected an access error, got %v", err)
+	}
+	return accessErr.Reason
+}
+
+func TestPolicyLists(t *testing.T) {
+	ctx := context.Background()
+	p := New(&Config{
+		AllowSenders: []string{alice, " " + bob + " "},
+		DenySenders:  []string{"0x2222222222222222222222222222222222222222"},
+		DenyRooms:    []string{"spam"},
+	})
+
+	tests := []struct {
+		sender, room, reason string
+	}{
+		{"0x1111111111111111111111111111111111111111", "general", ""},
+		{"0X1111111111111111111111111111111111111111", "general", ""},
+		{bob, "general", ReasonSenderDenied},
+		{alice, "spam", ReasonRoomDenied},
+		{"0x3333333333333333333333333333333333333333", "general", ReasonSenderNotAllowed},
+		{"", "general", ReasonSenderNotAllowed},
+	}
+	for _, tt := range tests {
+		if reason := reasonOf(t, p.CheckAccess(ctx, tt.sender, tt.room)); reason != tt.reason {
+			t.Errorf("CheckAccess(%q, %q) reason = %q, want %q", tt.sender, tt.room, reason, tt.reason)
+		}
+	}
+
+	rooms := New(&Config{AllowRooms: []string{"vip"}})
+	if reason := reasonOf(t, rooms.CheckAccess(ctx, "", "general")); reason != ReasonRoomNotAllowed {
+		t.Errorf("reason = %q, want %q", reason, ReasonRoomNotAllowed)
+	}
+	if err := rooms.CheckAccess(ctx, "", "vip"); err != nil {
+		t.Errorf("allowed room denied: %v", err)
+	}
+	if !New(&Config{AllowRooms: []string{" "}}).Empty() || rooms.Empty() {
+		t.Error("Empty reports the wrong result")
+	}
+}
+
+// fakeChain answers balanceOf calls from a map of balances
+type fakeChain struct {
+	balances map[common.Address]int64
+	calls    int
+	err      error
+}
+
+func (c *fakeChain) CallContract(ctx context.Context, call ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	c.calls++
+	if c.err != nil {
+		return nil, c.err
+	}
+	wallet := common.BytesToAddress(call.Data[4:])
+	return common.LeftPadBytes(big.NewInt(c.balances[wallet]).Bytes(), 32), nil
+}
+
+func TestTokenGate(t *testing.T) {
+	ctx := context.Background()
+	chain := &fakeChain{balances: map[common.Address]int64{common.HexToAddress(alice): 5, common.HexToAddress(bob): 1}}
+	gate, err := NewTokenGate(&TokenGateConfig{Chain: chain, Token: common.HexToAddress("0x9999999999999999999999999999999999999999"), MinBalance: big.NewInt(2)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := New(&Config{Gate: gate})
+
+	if err := p.CheckAccess(ctx, alice, "general"); err != nil {
+		t.Errorf("holder denied: %v", err)
+	}
+	if reason := reasonOf(t, p.CheckAccess(ctx, bob, "general")); reason != ReasonTokenRequired {
+		t.Errorf("reason = %q, want %q", reason, ReasonTokenRequired)
+	}
+	if reason := reasonOf(t, p.CheckAccess(ctx, "user-42", "general")); reason != ReasonTokenRequired {
+		t.Errorf("reason = %q, want %q", reason, ReasonTokenRequired)
+	}
+
+	// Balances are remembered
+	p.CheckAccess(ctx, alice, "general")
+	if chain.calls != 2 {
+		t.Errorf("chain calls = %d, want 2", chain.calls)
+	}
+
+	// A chain error is not a denial
+	chain.err = errors.New("rpc down")
+	err = p.CheckAccess(ctx, "0x3333333333333333333333333333333333333333", "general")
+	if err == nil || errors.Is(err, types.ErrAccessDenied) {
+		t.Errorf("expected a non-denial error, got %v", err)
+	}
+
+	if _, err := NewTokenGate(&TokenGateConfig{Chain: chain}); err == nil {
+		t.Error("expected an error without a token address")
+	}
+}
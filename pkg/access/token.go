@@ -0,0 +1,112 @@
+package access
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/cache"
+)
+
+// DefaultBalanceTTL is how long a TokenGate remembers a balance when no TTL is configured
+const DefaultBalanceTTL = 5 * time.Minute
+
+// balancePrefix is the cache key prefix of remembered balances
+const balancePrefix = "access:balance:"
+
+// balanceOfSelector is the selector of balanceOf(address), the same for
+// ERC-20 and ERC-721 tokens
+var balanceOfSelector = []byte{0x70, 0xa0, 0x82, 0x31}
+
+// Caller is the part of an Ethereum client the token gate reads, e.g. an
+// nft.RPCPool or an ethclient.Client
+type Caller interface {
+	CallContract(ctx context.Context, call ethereum.CallMsg, blockNumber *big.Int) ([]byte, error)
+}
+
+// TokenGateConfig configures a TokenGate
+type TokenGateConfig struct {
+	Chain      Caller
+	Token      common.Address   // ERC-20 or ERC-721 contract
+	MinBalance *big.Int         // Smallest balance admitted, in the token's base units (nil = 1)
+	Cache      cache.AgentCache // Remembers balances, e.g. Redis shared by replicas; kept in process memory when nil or NoOpCache
+	TTL        time.Duration    // How long a balance is remembered (0 = DefaultBalanceTTL)
+}
+
+// TokenGate admits senders whose wallet holds at least a minimum balance of
+// an ERC-20 or ERC-721 token. Senders that are not wallet addresses are not
+// admitted. It implements the Gate interface.
+type TokenGate struct {
+	config *TokenGateConfig
+	min    *big.Int // Smallest balance admitted
+	ttl    time.Duration
+	cache  cache.AgentCache
+}
+
+// NewTokenGate creates a token gate
+func NewTokenGate(config *TokenGateConfig) (*TokenGate, error) {
+	if config == nil || config.Chain == nil {
+		return nil, fmt.Errorf("a chain client is required")
+	}
+	if config.Token == (common.Address{}) {
+		return nil, fmt.Errorf("a token contract address is required")
+	}
+
+	minBalance := big.NewInt(1)
+	if config.MinBalance != nil && config.MinBalance.Sign() > 0 {
+		minBalance = config.MinBalance
+	}
+	ttl := config.TTL
+	if ttl <= 0 {
+		ttl = DefaultBalanceTTL
+	}
+	agentCache := config.Cache
+	if agentCache != nil {
+		if _, noop := agentCache.(*cache.NoOpCache); noop {
+			agentCache = nil
+		}
+	}
+	if agentCache == nil {
+		agentCache = cache.NewMemoryCache(&cache.MemoryConfig{MaxEntries: 10000})
+	}
+	return &TokenGate{config: config, min: minBalance, ttl: ttl, cache: agentCache}, nil
+}
+
+// Admits implements Gate
+func (g *TokenGate) Admits(ctx context.Context, sender string) (bool, error) {
+	if !common.IsHexAddress(sender) {
+		return false, nil
+	}
+	balance, err := g.Balance(ctx, common.HexToAddress(sender))
+	if err != nil {
+		return false, err
+	}
+	return balance.Cmp(g.min) >= 0, nil
+}
+
+// Balance returns the token balance of a wallet, remembered for the gate's TTL
+func (g *TokenGate) Balance(ctx context.Context, wallet common.Address) (*big.Int, error) {
+	key := balancePrefix + g.config.Token.Hex() + ":" + wallet.Hex()
+	if cached, err := g.cache.Get(ctx, key); err == nil && cached != "" {
+		if balance, ok := new(big.Int).SetString(cached, 10); ok {
+			return balance, nil
+		}
+	}
+
+	data := append(append([]byte{}, balanceOfSelector...), common.LeftPadBytes(wallet.Bytes(), 32)...)
+	result, err := g.config.Chain.CallContract(ctx, ethereum.CallMsg{To: &g.config.Token, Data: data}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read token balance: %w", err)
+	}
+	if len(result) < 32 {
+		return nil, fmt.Errorf("unexpected balanceOf result of %d bytes from %s", len(result), g.config.Token.Hex())
+	}
+	balance := new(big.Int).SetBytes(result[:32])
+
+	g.cache.Set(ctx, key, balance.String(), g.ttl)
+	return balance, nil
+}
@@ -0,0 +1,59 @@
+package agent
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/access"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/cache"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// AccessPolicy returns the configured access policy, admitting senders that
+// pass the lists only if gate admits them too (nil = no gate), or nil if
+// every task is accepted
+func (c *Config) AccessPolicy(gate access.Gate) *access.Policy {
+	policy := access.New(&access.Config{
+		AllowSenders: strings.Split(c.AccessAllowSenders, ","),
+		DenySenders:  strings.Split(c.AccessDenySenders, ","),
+		AllowRooms:   strings.Split(c.AccessAllowRooms, ","),
+		DenyRooms:    strings.Split(c.AccessDenyRooms, ","),
+		Gate:         gate,
+	})
+	if policy.Empty() {
+		return nil
+	}
+	return policy
+}
+
+// TokenGate returns the gate admitting holders of AccessTokenContract,
+// reading balances from chain, or nil if no token is required
+func (c *Config) TokenGate(chain access.Caller, agentCache cache.AgentCache) (*access.TokenGate, error) {
+	if c.AccessTokenContract == "" {
+		return nil, nil
+	}
+	minBalance, err := c.accessTokenMinBalance()
+	if err != nil {
+		return nil, err
+	}
+	return access.NewTokenGate(&access.TokenGateConfig{
+		Chain:      chain,
+		Token:      common.HexToAddress(c.AccessTokenContract),
+		MinBalance: minBalance,
+		Cache:      agentCache,
+		TTL:        c.AccessTokenCacheTTL,
+	})
+}
+
+// accessTokenMinBalance parses AccessTokenMinBalance (nil = the default of 1)
+func (c *Config) accessTokenMinBalance() (*big.Int, error) {
+	if strings.TrimSpace(c.AccessTokenMinBalance) == "" {
+		return nil, nil
+	}
+	minBalance, ok := new(big.Int).SetString(strings.TrimSpace(c.AccessTokenMinBalance), 10)
+	if !ok || minBalance.Sign() <= 0 {
+		return nil, fmt.Errorf("invalid access token minimum balance %q (must be a positive integer in the token's base units)", c.AccessTokenMinBalance)
+	}
+	return minBalance, nil
+}
@@ -3,7 +3,6 @@ package agent
 import (
 	"context"
 	"fmt"
-	"log"
 	"os"
 	"os/signal"
 	"sync"
@@ -11,6 +10,7 @@ import (
 	"time"
 
 	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/auth"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/logging"
 	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/nft"
 	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/types"
 )
@@ -54,6 +54,14 @@ func NewAgent(config *Config, handler types.AgentHandler) (*Agent, error) {
 			cancel()
 			return nil, fmt.Errorf("failed to create NFT manager: %w", err)
 		}
+		relayer, err := config.NewRelayer()
+		if err != nil {
+			nftManager.Close()
+			cancel()
+			return nil, err
+		}
+		nftManager.SetRelayer(relayer)
+		nftManager.SetFeeConfig(config.FeeConfig())
 		agent.nftManager = nftManager
 	}
 
@@ -93,9 +101,11 @@ func (a *Agent) Run(ctx context.Context) error {
 		return fmt.Errorf("failed to initialize agent: %w", err)
 	}
 
-	log.Printf("🚀 Teneo Agent '%s' v%s starting up...", a.config.Name, a.config.Version)
-	log.Printf("📋 Capabilities: %v", a.config.Capabilities)
-	log.Printf("🔗 Owner: %s", a.config.OwnerAddress)
+	logging.Info("Teneo agent starting up",
+		"agent", a.config.Name,
+		"version", a.config.Version,
+		"capabilities", a.config.Capabilities,
+		"owner", a.config.OwnerAddress)
 
 	// Start the agent main loop
 	a.wg.Add(1)
@@ -104,18 +114,18 @@ func (a *Agent) Run(ctx context.Context) error {
 	// Wait for shutdown signal or context cancellation
 	select {
 	case <-sigChan:
-		log.Println("🛑 Shutdown signal received, stopping agent...")
+		logging.Info("shutdown signal received, stopping agent")
 	case <-ctx.Done():
-		log.Println("🛑 Context cancelled, stopping agent...")
+		logging.Info("context cancelled, stopping agent")
 	case <-a.ctx.Done():
-		log.Println("🛑 Agent context cancelled, stopping agent...")
+		logging.Info("agent context cancelled, stopping agent")
 	}
 
 	// Graceful shutdown
 	a.cancel()
 	a.wg.Wait()
 
-	log.Println("✅ Agent stopped successfully")
+	logging.Info("agent stopped successfully")
 	return nil
 }
 
@@ -131,7 +141,7 @@ func (a *Agent) initialize() error {
 	// Register agent with Teneo network if NFT manager is available
 	if a.nftManager != nil {
 		if err := a.registerWithNetwork(); err != nil {
-			log.Printf("⚠️  Failed to register with network: %v", err)
+			logging.Warn("failed to register with network", "error", err)
 			// Don't fail completely, agent can still work locally
 		}
 	}
@@ -141,12 +151,12 @@ func (a *Agent) initialize() error {
 
 // registerWithNetwork registers the agent with the Teneo network
 func (a *Agent) registerWithNetwork() error {
-	log.Println("🔍 Checking agent registration...")
+	logging.Info("checking agent registration")
 
 	// Check if agent already has an NFT business card
 	businessCard, err := a.nftManager.GetAgentByOwner(a.ctx, a.config.OwnerAddress)
 	if err != nil {
-		log.Println("📄 No existing business card found, creating new one...")
+		logging.Info("no existing business card found, creating new one")
 
 		// Create mint request
 		mintRequest := &types.MintRequest{
@@ -167,9 +177,9 @@ func (a *Agent) registerWithNetwork() error {
 			return fmt.Errorf("failed to mint business card: %w", err)
 		}
 
-		log.Printf("✅ Agent registered with token ID: %s", businessCard.TokenID.String())
+		logging.Info("agent registered", "token_id", businessCard.TokenID.String())
 	} else {
-		log.Printf("✅ Agent already registered with token ID: %s", businessCard.TokenID.String())
+		logging.Info("agent already registered", "token_id", businessCard.TokenID.String())
 	}
 
 	return nil
@@ -199,7 +209,7 @@ func (a *Agent) processAvailableTasks() {
 	if taskProvider, ok := a.handler.(types.TaskProvider); ok {
 		tasks, err := taskProvider.GetAvailableTasks(a.ctx)
 		if err != nil {
-			log.Printf("❌ Failed to get available tasks: %v", err)
+			logging.Error("failed to get available tasks", "error", err)
 			return
 		}
 
@@ -216,25 +226,30 @@ func (a *Agent) processAvailableTasks() {
 
 // processTask processes a single task
 func (a *Agent) processTask(task types.Task) {
-	log.Printf("🔄 Processing task: %s", task.ID)
+	logging.Info("processing task", "task_id", task.ID)
 
 	// Create task context with timeout
 	taskCtx, cancel := context.WithTimeout(a.ctx, time.Duration(a.config.TaskTimeout)*time.Second)
 	defer cancel()
+	taskCtx = types.WithTaskInfo(taskCtx, types.TaskInfo{
+		ID:        task.ID,
+		Input:     task.Content,
+		StartTime: time.Now(),
+	})
 
 	// Process the task
 	result, err := a.handler.ProcessTask(taskCtx, task.Content)
 	if err != nil {
-		log.Printf("❌ Task %s failed: %v", task.ID, err)
+		logging.Error("task failed", "task_id", task.ID, "error", err)
 		return
 	}
 
-	log.Printf("✅ Task %s completed successfully", task.ID)
+	logging.Info("task completed successfully", "task_id", task.ID)
 
 	// Handle task result if handler supports it
 	if resultHandler, ok := a.handler.(types.TaskResultHandler); ok {
 		if err := resultHandler.HandleTaskResult(taskCtx, task.ID, result); err != nil {
-			log.Printf("⚠️  Failed to handle task result: %v", err)
+			logging.Warn("failed to handle task result", "error", err)
 		}
 	}
 }
@@ -257,6 +272,79 @@ func (a *Agent) UpdateMetadata(description, contactInfo, pricingModel, version s
 	return a.nftManager.UpdateAgentMetadata(a.ctx, description, contactInfo, pricingModel, version)
 }
 
+// MetadataSyncResult reports the outcome of SyncMetadata
+type MetadataSyncResult struct {
+	Changes []types.MetadataChange // Every field where the config differs from the NFT
+	Applied []types.MetadataChange // Changes written on-chain
+	Skipped []types.MetadataChange // Changes to fields that are fixed at mint time
+}
+
+// InSync reports whether the NFT already matched the config
+func (r *MetadataSyncResult) InSync() bool {
+	return len(r.Changes) == 0
+}
+
+// SyncMetadata compares the config with the agent's NFT metadata and writes
+// all updatable differences in a single transaction. Nothing is sent when the
+// metadata already matches. Differences in fields that cannot be changed after
+// minting are reported in Skipped.
+func (a *Agent) SyncMetadata(ctx context.Context) (*MetadataSyncResult, error) {
+	if a.nftManager == nil {
+		return nil, fmt.Errorf("NFT manager not initialized")
+	}
+
+	businessCard, err := a.nftManager.GetAgentByOwner(ctx, a.config.OwnerAddress)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read on-chain metadata: %w", err)
+	}
+	current := businessCard.Metadata
+
+	desired := types.AgentMetadata{
+		Name:         a.config.Name,
+		Description:  a.config.Description,
+		Capabilities: a.config.Capabilities,
+		ImageURI:     a.config.Image,
+		ContactInfo:  a.config.ContactInfo,
+		PricingModel: a.config.PricingModel,
+		Version:      a.config.Version,
+	}
+	if current.ImageURI == "" {
+		// The contract does not store images, so there is nothing to compare against
+		desired.ImageURI = ""
+	}
+
+	result := &MetadataSyncResult{Changes: types.DiffAgentMetadata(current, desired)}
+	var pending []types.MetadataChange
+	for _, change := range result.Changes {
+		if change.Updatable() {
+			pending = append(pending, change)
+		} else {
+			result.Skipped = append(result.Skipped, change)
+		}
+	}
+
+	for _, change := range result.Skipped {
+		logging.Warn("metadata field cannot be updated after minting", "field", change.Field)
+	}
+	if len(pending) == 0 {
+		logging.Info("metadata in sync", "skipped", len(result.Skipped))
+		return result, nil
+	}
+
+	// updateAgentMetadata overwrites all four fields, so unchanged ones keep their current value
+	if err := a.nftManager.UpdateAgentMetadata(ctx, desired.Description, desired.ContactInfo, desired.PricingModel, desired.Version); err != nil {
+		return result, fmt.Errorf("failed to update metadata: %w", err)
+	}
+	result.Applied = pending
+
+	fields := make([]string, len(pending))
+	for i, change := range pending {
+		fields[i] = change.Field
+	}
+	logging.Info("metadata synced", "fields", fields)
+	return result, nil
+}
+
 // SetActive sets the agent's active status
 func (a *Agent) SetActive(active bool) error {
 	if a.nftManager == nil {
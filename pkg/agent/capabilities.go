@@ -0,0 +1,167 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/health"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/logging"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/tracing"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/types"
+)
+
+// Capabilities returns the capabilities the agent advertises
+func (a *EnhancedAgent) Capabilities() []string {
+	return a.taskCoordinator.Capabilities()
+}
+
+// Manifest returns the capability manifest, nil if the agent has none
+func (a *EnhancedAgent) Manifest() []types.AgentCapability {
+	a.capabilitiesMu.Lock()
+	defer a.capabilitiesMu.Unlock()
+	return a.manifest
+}
+
+// UpdateCapabilities replaces the agent's capabilities at runtime. Tasks are
+// matched against the new capabilities right away, the server is told once
+// the agent is authenticated, and the metadata hash of the agent's NFT is
+// updated in the background. Running tasks that require a removed capability
+// continue; they are listed in the events.CapabilitiesChanged event so they
+// can be drained. Manifest entries whose capability is removed are dropped.
+func (a *EnhancedAgent) UpdateCapabilities(capabilities []string) error {
+	if err := types.ValidateCapabilities(capabilities); err != nil {
+		return err
+	}
+
+	a.capabilitiesMu.Lock()
+	defer a.capabilitiesMu.Unlock()
+	var manifest []types.AgentCapability
+	for _, capability := range a.manifest {
+		if slices.Contains(capabilities, capability.ID()) {
+			manifest = append(manifest, capability)
+		}
+	}
+	return a.applyCapabilities(capabilities, manifest)
+}
+
+// AddCapability adds a capability to the manifest and advertises it, like
+// UpdateCapabilities. A capability with the same ID is replaced.
+func (a *EnhancedAgent) AddCapability(capability types.AgentCapability) error {
+	if err := capability.Validate(); err != nil {
+		return err
+	}
+
+	a.capabilitiesMu.Lock()
+	defer a.capabilitiesMu.Unlock()
+	manifest := slices.DeleteFunc(slices.Clone(a.manifest), func(c types.AgentCapability) bool {
+		return c.ID() == capability.ID()
+	})
+	manifest = append(manifest, capability)
+	return a.applyCapabilities(mergeCapabilities(a.config.Capabilities, manifest), manifest)
+}
+
+// RemoveCapability stops advertising a capability, given by its advertised
+// ID or its manifest name (which removes every version), like UpdateCapabilities
+func (a *EnhancedAgent) RemoveCapability(name string) error {
+	a.capabilitiesMu.Lock()
+	defer a.capabilitiesMu.Unlock()
+
+	removed := []string{name}
+	manifest := slices.DeleteFunc(slices.Clone(a.manifest), func(c types.AgentCapability) bool {
+		if c.ID() == name || c.Name == name {
+			removed = append(removed, c.ID())
+			return true
+		}
+		return false
+	})
+	capabilities := slices.DeleteFunc(slices.Clone(a.config.Capabilities), func(c string) bool {
+		return slices.Contains(removed, c)
+	})
+	if len(capabilities) == len(a.config.Capabilities) {
+		return fmt.Errorf("capability %s is not advertised", name)
+	}
+	return a.applyCapabilities(capabilities, manifest)
+}
+
+// applyCapabilities switches to the given capabilities and manifest and
+// announces them. Callers hold capabilitiesMu.
+func (a *EnhancedAgent) applyCapabilities(capabilities []string, manifest []types.AgentCapability) error {
+	changed := !slices.Equal(capabilities, a.config.Capabilities)
+	a.config.Capabilities = capabilities
+	a.manifest = manifest
+	a.protocolHandler.SetManifest(manifest)
+	a.taskCoordinator.UpdateCapabilities(capabilities)
+	if err := a.taskCoordinator.SetInputSchemas(a.inputSchemas(manifest)); err != nil {
+		return err
+	}
+	a.updateHealthInfo()
+	if a.prompts != nil {
+		a.prompts.SetAgent(a.config.Name, capabilities)
+	}
+	logging.Info("updated capabilities", "capabilities", capabilities)
+
+	if changed && a.metadataSync != nil {
+		go a.syncMetadataHash(capabilities)
+	}
+	if !a.networkClient.IsAuthenticated() {
+		return nil
+	}
+	if err := a.protocolHandler.SendCapabilities(); err != nil {
+		return fmt.Errorf("failed to announce capabilities: %w", err)
+	}
+	return nil
+}
+
+// syncMetadataHash sends the metadata hash of the agent's NFT with the given
+// capabilities to the backend
+func (a *EnhancedAgent) syncMetadataHash(capabilities []string) {
+	_, span := tracing.Start(context.Background(), tracing.SpanNFTSyncMetadata)
+	err := a.metadataSync(capabilities)
+	tracing.End(span, err)
+	if err != nil {
+		logging.Warn("failed to send metadata hash to backend", "error", err)
+	}
+}
+
+// inputSchemas returns the task input schemas of the manifest and of the
+// handler, if it is an InputSchemaProvider; the handler's take precedence
+func (a *EnhancedAgent) inputSchemas(manifest []types.AgentCapability) map[string]json.RawMessage {
+	schemas := types.ManifestInputSchemas(manifest)
+	if provider, ok := a.agentHandler.(types.InputSchemaProvider); ok {
+		for capability, schema := range provider.InputSchemas() {
+			schemas[capability] = schema
+		}
+	}
+	return schemas
+}
+
+// updateHealthInfo shows the current agent info on the health endpoint
+func (a *EnhancedAgent) updateHealthInfo() {
+	if a.healthServer == nil {
+		return
+	}
+	a.healthServer.UpdateAgentInfo(&health.AgentInfo{
+		Name:         a.config.Name,
+		Version:      a.config.Version,
+		Wallet:       a.authManager.GetAddress(),
+		Capabilities: a.taskCoordinator.Capabilities(),
+		Description:  a.config.Description,
+		IdentityMode: string(a.identityMode),
+		Resources:    a.config.Resources,
+		Manifest:     a.protocolHandler.Manifest(),
+	})
+}
+
+// mergeCapabilities returns the configured capabilities followed by those of
+// the manifest that aren't configured already
+func mergeCapabilities(capabilities []string, manifest []types.AgentCapability) []string {
+	merged := append([]string(nil), capabilities...)
+	for _, capability := range manifest {
+		if !slices.Contains(merged, capability.ID()) {
+			merged = append(merged, capability.ID())
+		}
+	}
+	return merged
+}
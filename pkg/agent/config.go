@@ -1,13 +1,33 @@
 package agent
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/access"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/auth"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/configfile"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/e2e"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/gas"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/guardrail"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/health"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/identity"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/logging"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/metering"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/network"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/nft"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/output"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/ratelimit"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/redact"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/retrystore"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/scheduler"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/tlsconfig"
 	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/types"
+	"github.com/ethereum/go-ethereum/common"
 )
 
 // Config represents the configuration for a Teneo agent
@@ -21,6 +41,16 @@ type Config struct {
 	ContactInfo  string   `json:"contact_info"`
 	PricingModel string   `json:"pricing_model"`
 
+	// Hardware advertised to coordinators and peers for routing heavy jobs (nil = not advertised)
+	Resources *types.ComputeResources `json:"resources,omitempty"`
+
+	// System prompt for model-backed handlers, applied on reload by handlers implementing ConfigChangeHandler (empty = handler default)
+	SystemPrompt string `json:"system_prompt"`
+
+	// Prompt templates of handlers with a prompt library (see PromptLibraryProvider), reloaded when they change
+	PromptsDir       string `json:"prompts_dir"`        // Directory with default.tmpl, rooms/<room>.tmpl and capabilities/<capability>.tmpl (empty = none)
+	PromptsFromCache bool   `json:"prompts_from_cache"` // Look up per-room and per-capability overrides in the cache under "prompts:"
+
 	// Interface configuration
 	InterfaceType  string `json:"interface_type"`
 	ResponseFormat string `json:"response_format"`
@@ -34,29 +64,263 @@ type Config struct {
 	PingInterval     time.Duration `json:"ping_interval"`
 	HandshakeTimeout time.Duration `json:"handshake_timeout"`
 
+	// Reconnection backoff grows exponentially with jitter from ReconnectDelay up to ReconnectMaxDelay
+	ReconnectMaxDelay   time.Duration `json:"reconnect_max_delay"`
+	ReconnectMaxElapsed time.Duration `json:"reconnect_max_elapsed"` // Stop reconnecting after this long (0 = no limit)
+
+	// Sessions are re-authenticated SessionRefreshBefore before they expire (0 = 1m). SessionTTL
+	// is the lifetime assumed when the server doesn't state one (0 = until the connection drops).
+	SessionRefreshBefore time.Duration `json:"session_refresh_before"`
+	SessionTTL           time.Duration `json:"session_ttl"`
+
+	// What the agent does when another process connects with the same wallet or NFT:
+	// "alert" (default), "yield" or "takeover"
+	DuplicatePolicy string `json:"duplicate_policy"`
+
+	// DataChannelURL is the WebSocket URL of a second connection carrying task output (empty = disabled)
+	DataChannelURL string `json:"data_channel_url"`
+
+	// RecordFile is a JSONL file every message sent and received is appended to, for
+	// replaying the session with ReplayRecording (empty = no recording)
+	RecordFile string `json:"record_file"`
+
+	// Circuit breakers, one per message class (auth, task_response, capabilities and the rest):
+	// a class stops sending for CircuitBreakerResetTimeout after CircuitBreakerMaxFailures
+	// consecutive failures, then lets CircuitBreakerProbes messages through to test it
+	CircuitBreakerMaxFailures  int           `json:"circuit_breaker_max_failures"`  // Default 3
+	CircuitBreakerResetTimeout time.Duration `json:"circuit_breaker_reset_timeout"` // Default 30s
+	CircuitBreakerProbes       int           `json:"circuit_breaker_probes"`        // Default 1
+
+	// Where messages waiting for a retry are kept so they are sent after a restart: "memory"
+	// (default, lost on exit), "file" (RetryQueueFile) or "cache" (the agent cache, e.g. Redis)
+	RetryQueueStore string `json:"retry_queue_store"`
+	RetryQueueFile  string `json:"retry_queue_file"` // Default .teneo/retry-queue.json
+
+	// SendOrdering keeps a task's responses from overtaking each other while one waits for a
+	// retry: "task" (default) orders them per task, "room" per room and "none" not at all
+	SendOrdering string `json:"send_ordering"`
+
+	// TLS of the WebSocket and backend connections: a PEM bundle of extra trusted CAs, a client
+	// certificate and key for mutual TLS, the lowest TLS version ("1.2" by default) and the server
+	// names sent in SNI instead of the hosts of the URLs. TLSInsecureSkipVerify accepts any server
+	// certificate and is meant for development only.
+	TLSCAFile             string `json:"tls_ca_file"`
+	TLSCertFile           string `json:"tls_cert_file"`
+	TLSKeyFile            string `json:"tls_key_file"`
+	TLSMinVersion         string `json:"tls_min_version"`
+	TLSServerName         string `json:"tls_server_name"`         // WebSocket connections
+	BackendTLSServerName  string `json:"backend_tls_server_name"` // Backend REST API
+	TLSInsecureSkipVerify bool   `json:"tls_insecure_skip_verify"`
+
+	// Compression and message size
+	WebSocketDeflate  bool `json:"websocket_deflate"`   // Negotiate permessage-deflate on the WebSocket connection
+	CompressThreshold int  `json:"compress_threshold"`  // Compress task responses of at least this many bytes if the server supports it (0 = never)
+	ResponseChunkSize int  `json:"response_chunk_size"` // Split task responses larger than this many bytes into parts the server accepts (default 256 KiB, 0 = never)
+
+	// Backpressure: task updates are coalesced while this many messages are queued for sending (0 = half the send buffer)
+	SendCongestionThreshold int `json:"send_congestion_threshold"`
+
+	// Buffers of outgoing and incoming messages: their sizes (default 100) and what happens to a
+	// message that finds one full: "block" (default), "error" or, for the receive buffer only, "drop_oldest"
+	SendBufferSize        int    `json:"send_buffer_size"`
+	ReceiveBufferSize     int    `json:"receive_buffer_size"`
+	SendBufferOverflow    string `json:"send_buffer_overflow"`
+	ReceiveBufferOverflow string `json:"receive_buffer_overflow"`
+
+	// Message signing
+	CoordinatorPublicKey string `json:"coordinator_public_key"` // Only accept tasks signed with this key (empty = tasks are not verified)
+	SignTaskResponses    bool   `json:"sign_task_responses"`    // Sign task responses with the agent's private key
+
+	// Signed user messages: "off", "optional" to verify messages that are signed, or "required"
+	// to reject unsigned ones (empty = off, or optional with UserSignatureCapabilities).
+	// While the agent offers one of UserSignatureCapabilities (comma-separated), every
+	// user message must be signed.
+	UserSignatures            string        `json:"user_signatures"`
+	UserSignatureCapabilities string        `json:"user_signature_capabilities"`
+	UserSignatureMaxSkew      time.Duration `json:"user_signature_max_skew"` // Reject signed messages whose timestamp is further from the agent's clock
+
+	// End-to-end encryption of task content: the agent's X25519 keys are kept in EncryptionKeyFile
+	// (default .teneo/encryption-keys.json) and published at registration. Responses to encrypted
+	// tasks are encrypted; plaintext tasks are answered in plaintext unless EncryptionRequired.
+	EncryptionEnabled        bool          `json:"encryption_enabled"`
+	EncryptionRequired       bool          `json:"encryption_required"` // Reject tasks that are not encrypted (implies EncryptionEnabled)
+	EncryptionKeyFile        string        `json:"encryption_key_file"`
+	EncryptionKeysRetained   int           `json:"encryption_keys_retained"`   // Keys kept to decrypt tasks encrypted before a rotation (0 = 2)
+	EncryptionRotateInterval time.Duration `json:"encryption_rotate_interval"` // Rotate the key when it is this old (0 = never)
+
+	// Remote operator commands (log level, goroutine dump, health snapshot) signed by an operator
+	// wallet: OperatorAddresses, comma-separated, or else OwnerAddress or the agent's own wallet
+	OperatorCommands  bool   `json:"operator_commands"`
+	OperatorAddresses string `json:"operator_addresses"`
+
 	// Health monitoring
-	HealthEnabled bool `json:"health_enabled"`
-	HealthPort    int  `json:"health_port"`
+	HealthEnabled  bool `json:"health_enabled"`
+	HealthPort     int  `json:"health_port"`
+	MetricsEnabled bool `json:"metrics_enabled"` // Expose Prometheus metrics on the health server's /metrics
+
+	// Checks /readyz requires, comma-separated: connected, authenticated, registered, circuits (default: all)
+	ReadinessChecks string `json:"readiness_checks"`
+
+	// Logging
+	LogLevel  string `json:"log_level"`  // "debug", "info" (default), "warn" or "error"
+	LogFormat string `json:"log_format"` // "text" (default) or "json"
+
+	// Live reload of rate limit, log level, capabilities, system prompt and Redis settings
+	ConfigFile     string `json:"config_file"`      // YAML, TOML or JSON config file re-read whenever it changes (empty = not watched)
+	ReloadOnSIGHUP bool   `json:"reload_on_sighup"` // Re-read the environment and ConfigFile on SIGHUP
+
+	// Emoji in SDK-generated messages and logs: "emoji" (default), "plain" (removed) or "text" (replaced with labels)
+	OutputStyle string `json:"output_style"`
+
+	// PII redaction of task input, responses and logs
+	RedactPII      bool   `json:"redact_pii"`
+	RedactKinds    string `json:"redact_kinds"`    // Comma-separated: secret, wallet, email, credit_card, phone (default: all)
+	RedactPatterns string `json:"redact_patterns"` // Custom kinds as "kind=regexp" pairs separated by semicolons, e.g. "employee_id=EMP-\d{6}"
+	RedactTargets  string `json:"redact_targets"`  // Comma-separated: input, output, logs (default: all)
 
 	// Authentication
 	PrivateKey   string `json:"private_key"`
 	OwnerAddress string `json:"owner_address"`
 	NFTTokenID   string `json:"nft_token_id"`
 
+	// IdentityFile keeps the agent ID, NFT token ID, wallet and registration times across
+	// restarts, so an agent that minted its NFT on the first run reuses it (empty = disabled)
+	IdentityFile string `json:"identity_file"`
+
 	// Room configuration
 	Room string `json:"room"`
 
 	// Blockchain configuration
-	EthereumRPC        string `json:"ethereum_rpc"`
+	EthereumRPC        string `json:"ethereum_rpc"` // Comma-separated for read failover; the first endpoint sends transactions
 	NFTContractAddress string `json:"nft_contract_address"`
 
+	// Periodic comparison of the name, description, capabilities and version
+	// with the NFT's metadata, reported on the health endpoint (0 = disabled)
+	MetadataSyncInterval time.Duration `json:"metadata_sync_interval"`
+	MetadataAutoUpdate   bool          `json:"metadata_auto_update"` // Write drifted description and version on-chain
+
+	// Watching the NFT for transfers to other wallets (0 = disabled), and what
+	// the agent does when it loses the NFT: "alert" (default), "stop" or "reregister"
+	OwnershipWatchInterval time.Duration `json:"ownership_watch_interval"`
+	OwnershipPolicy        string        `json:"ownership_policy"`
+
+	// Fees of NFT transactions sent from the agent wallet, which are priced
+	// with EIP-1559 fees from the latest block and replaced while stuck
+	GasMaxFeeGwei      float64 `json:"gas_max_fee_gwei"`      // Fee cap per gas (0 = twice the base fee plus the tip)
+	GasPriorityFeeGwei float64 `json:"gas_priority_fee_gwei"` // Tip per gas (0 = the node's suggestion)
+	GasMaxCost         float64 `json:"gas_max_cost"`          // Most a transaction may cost, in the chain's native token (0 = unlimited)
+
+	// Gas sponsor relayer for NFT transactions (optional, for wallets without native tokens)
+	RelayerURL       string `json:"relayer_url"`
+	RelayerAPIKey    string `json:"relayer_api_key"`
+	RelayerForwarder string `json:"relayer_forwarder"` // Trusted ERC-2771 forwarder contract
+	RelayerScheme    string `json:"relayer_scheme"`    // "eip712" (default) or "personal"
+
 	// Task processing
 	MaxConcurrentTasks int `json:"max_concurrent_tasks"`
-	TaskTimeout        int `json:"task_timeout"`
+	TaskTimeout        int `json:"task_timeout"` // Seconds a task without a server deadline may run (default 30)
 	TaskCheckInterval  int `json:"task_check_interval"`
+	TaskMaxRetries     int `json:"task_max_retries"` // Retries of retryable handler errors (0 = no retries)
+
+	// Clean up and reinitialize the handler after it panicked, for handlers
+	// whose state a panic may leave broken. Panics always fail only their task.
+	RestartHandlerOnPanic bool `json:"restart_handler_on_panic"`
+
+	// Long-running tasks: TaskTimeout overrides by the capability a task requires, and
+	// the total run time a handler may extend a task to with ExtendDeadline
+	CapabilityTimeouts map[string]time.Duration `json:"capability_timeouts"`
+	TaskMaxDuration    time.Duration            `json:"task_max_duration"` // 0 = no limit (default 1h)
+
+	// Task queueing: with a policy ("strict" or "weighted") tasks wait for one of MaxConcurrentTasks
+	// workers and run by priority; without one (default) every task starts when it arrives
+	TaskQueuePolicy string `json:"task_queue_policy"`
+	TaskPreemption  bool   `json:"task_preemption"`  // Restart the lowest-priority running task later to run a higher-priority one
+	MaxQueuedTasks  int    `json:"max_queued_tasks"` // Tasks waiting at most, 0 = unlimited
+
+	// How often a running task sends a task_alive message with its elapsed time and stage (0 = never)
+	TaskHeartbeatInterval time.Duration `json:"task_heartbeat_interval"`
+
+	// How long received task IDs are remembered so redelivered tasks are not executed twice (0 = disabled)
+	TaskDedupTTL time.Duration `json:"task_dedup_ttl"`
+
+	// Rate limiting: token buckets refilled at the per-minute rate that allow up to the burst at once
+	RateLimitPerMinute       int `json:"rate_limit_per_minute"`        // All tasks, 0 = unlimited
+	RateLimitBurst           int `json:"rate_limit_burst"`             // 0 = RateLimitPerMinute
+	RoomRateLimitPerMinute   int `json:"room_rate_limit_per_minute"`   // Tasks per room, 0 = unlimited
+	RoomRateLimitBurst       int `json:"room_rate_limit_burst"`        // 0 = RoomRateLimitPerMinute
+	SenderRateLimitPerMinute int `json:"sender_rate_limit_per_minute"` // Tasks per sender, 0 = unlimited
+	SenderRateLimitBurst     int `json:"sender_rate_limit_burst"`      // 0 = SenderRateLimitPerMinute
+
+	// Bandwidth ceiling per room: bytes sent and received together, refilled at the per-minute rate.
+	// Tasks from a room over its ceiling are rejected until it refills.
+	RoomBandwidthPerMinute int64 `json:"room_bandwidth_per_minute"` // 0 = unlimited
+	RoomBandwidthBurst     int64 `json:"room_bandwidth_burst"`      // 0 = RoomBandwidthPerMinute
+
+	// Task size guards
+	MaxTaskBytes       int    `json:"max_task_bytes"`        // Largest task content received, checked before anything else (0 = unlimited)
+	TaskContentTypes   string `json:"task_content_types"`    // Comma-separated content types of the tasks accepted, e.g. "STRING,JSON" (empty = any)
+	MaxInputChars      int    `json:"max_input_chars"`       // 0 = unlimited
+	InputGuardPolicy   string `json:"input_guard_policy"`    // "reject" (default) or "truncate"
+	MaxOutputBytes     int    `json:"max_output_bytes"`      // 0 = unlimited
+	OutputGuardPolicy  string `json:"output_guard_policy"`   // "truncate" (default) or "reject"
+	MaxMessagesPerTask int    `json:"max_messages_per_task"` // 0 = unlimited
+
+	// Per-task budgets: a task exceeding one fails with a BUDGET_EXHAUSTED error
+	TaskBudgetWallTime time.Duration `json:"task_budget_wall_time"` // Caps the task timeout, 0 = timeout only
+	TaskBudgetTokens   int           `json:"task_budget_tokens"`    // LLM tokens across the task's calls, 0 = unlimited
+	TaskBudgetMessages int           `json:"task_budget_messages"`  // Messages a streaming task sends, 0 = unlimited
 
-	// Rate limiting
-	RateLimitPerMinute int `json:"rate_limit_per_minute"` // 0 = unlimited
+	// Per-consumer quotas
+	QuotaEnabled     bool   `json:"quota_enabled"`      // Enforce per-wallet quotas
+	QuotaDefaultPlan string `json:"quota_default_plan"` // Plan for unregistered consumers (default: "free")
+	AdminToken       string `json:"admin_token"`        // Bearer token for the admin, review and control APIs on the health server (empty = disabled)
+
+	// Usage metering
+	MeteringEnabled  bool                       `json:"metering_enabled"`   // Record completed tasks and their price by sender and capability
+	CapabilityPrices map[string]metering.Amount `json:"capability_prices"`  // Price per task by capability, e.g. {"web_scrape": "0.01"}
+	DefaultTaskPrice metering.Amount            `json:"default_task_price"` // Price of tasks without a priced capability
+	PriceCurrency    string                     `json:"price_currency"`     // Currency of the prices (default "USD")
+	UsageReceipts    bool                       `json:"usage_receipts"`     // Send a signed receipt with every completed task
+
+	// LLM token usage, reported by metrics and per room and day on /usage/llm
+	LLMUsageReport    string `json:"llm_usage_report"`    // Add each task's LLM usage to its response: "field", "footer" or "" (none)
+	LLMUsageRetention int    `json:"llm_usage_retention"` // Days of LLM usage kept for reports
+
+	// Guardrails on task input and responses
+	GuardrailKeywords     []string `json:"guardrail_keywords"`      // Words and phrases that flag content, matched whole and ignoring case
+	GuardrailPatterns     []string `json:"guardrail_patterns"`      // Regular expressions that flag content
+	GuardrailModeration   bool     `json:"guardrail_moderation"`    // Check content with the OpenAI moderation API (needs OPENAI_API_KEY)
+	GuardrailInputAction  string   `json:"guardrail_input_action"`  // Action on flagged input: "block" (default), "redact" or "annotate"
+	GuardrailOutputAction string   `json:"guardrail_output_action"` // Action on flagged responses: "block" (default), "redact" or "annotate"
+	GuardrailFailClosed   bool     `json:"guardrail_fail_closed"`   // Block content the guardrail fails to check instead of letting it pass
+
+	// Payments, at the prices above in the chain's native coin
+	PaymentRequired      bool   `json:"payment_required"`      // Verify on chain that priced tasks were paid for before running them
+	PaymentAddress       string `json:"payment_address"`       // Address tasks are paid to (default: the agent's wallet)
+	PaymentConfirmations int    `json:"payment_confirmations"` // Blocks a payment must be buried under, counting its own
+
+	// Access control of the senders and rooms tasks are accepted from; lists are comma-separated
+	AccessAllowSenders    string        `json:"access_allow_senders"`     // Wallets or users whose tasks are accepted (empty = any sender not denied)
+	AccessDenySenders     string        `json:"access_deny_senders"`      // Wallets or users whose tasks are rejected
+	AccessAllowRooms      string        `json:"access_allow_rooms"`       // Rooms whose tasks are accepted (empty = any room not denied)
+	AccessDenyRooms       string        `json:"access_deny_rooms"`        // Rooms whose tasks are rejected
+	AccessTokenContract   string        `json:"access_token_contract"`    // ERC-20 or ERC-721 token senders must hold (empty = no token gate)
+	AccessTokenMinBalance string        `json:"access_token_min_balance"` // Smallest balance admitted, in the token's base units (empty = 1)
+	AccessTokenCacheTTL   time.Duration `json:"access_token_cache_ttl"`   // How long a sender's balance is remembered
+
+	// Conversation memory
+	MemoryEnabled     bool `json:"memory_enabled"`      // Keep per-room conversation history for handlers
+	MemoryMaxMessages int  `json:"memory_max_messages"` // Turns kept per room (0 = unlimited)
+	MemoryMaxTokens   int  `json:"memory_max_tokens"`   // Tokens kept per room (0 = unlimited)
+
+	// Room messages fetched on join to rebuild an empty conversation history (0 = disabled)
+	MemoryRestoreMessages int `json:"memory_restore_messages"`
+
+	// Response review
+	ReviewEnabled   bool          `json:"review_enabled"`    // Hold risky responses for human approval
+	ReviewThreshold float64       `json:"review_threshold"`  // Responses scoring at or above this risk are held (0..1)
+	ReviewTimeout   time.Duration `json:"review_timeout"`    // How long a held response waits for review
+	ReviewOnTimeout string        `json:"review_on_timeout"` // "release" (default) or "reject" when the review times out
 
 	// Redis cache configuration
 	RedisEnabled   bool   `json:"redis_enabled"`    // Enable Redis caching
@@ -66,18 +330,397 @@ type Config struct {
 	RedisDB        int    `json:"redis_db"`         // Redis database number (0-15)
 	RedisKeyPrefix string `json:"redis_key_prefix"` // Prefix for all cache keys
 	RedisUseTLS    bool   `json:"redis_use_tls"`    // Enable TLS/SSL (required for managed Redis)
+
+	// In-memory cache, used when Redis is disabled or unavailable
+	MemoryCacheEnabled    bool `json:"memory_cache_enabled"`
+	MemoryCacheMaxEntries int  `json:"memory_cache_max_entries"` // Least recently used keys are evicted beyond this (0 = unlimited)
 }
 
-// Validate validates the configuration
+// Validate validates the configuration. The error lists every problem found.
 func (c *Config) Validate() error {
+	return errors.Join(c.problems()...)
+}
+
+// problems returns every problem with the configuration (nil if it is valid)
+func (c *Config) problems() []error {
+	var problems []error
+	add := func(err error) {
+		if err != nil {
+			problems = append(problems, err)
+		}
+	}
+
 	if c.Name == "" {
-		return fmt.Errorf("agent name is required")
+		add(fmt.Errorf("agent name is required"))
 	}
 	if c.PrivateKey == "" {
-		return fmt.Errorf("private key is required")
+		add(fmt.Errorf("private key is required"))
+	}
+	add(types.ValidateCapabilities(c.Capabilities))
+	for _, policy := range []string{c.InputGuardPolicy, c.OutputGuardPolicy} {
+		if policy != "" && policy != "reject" && policy != "truncate" {
+			add(fmt.Errorf("invalid guard policy %q (use \"reject\" or \"truncate\")", policy))
+		}
+	}
+	if c.MaxTaskBytes < 0 {
+		add(fmt.Errorf("max task bytes cannot be negative"))
+	}
+	if c.ReconnectMaxDelay < 0 || c.ReconnectMaxElapsed < 0 {
+		add(fmt.Errorf("reconnect delays cannot be negative"))
+	}
+	if c.ResponseChunkSize < 0 {
+		add(fmt.Errorf("response chunk size cannot be negative"))
+	}
+	if c.SendBufferSize < 0 || c.ReceiveBufferSize < 0 {
+		add(fmt.Errorf("buffer sizes cannot be negative"))
+	}
+	for _, policy := range []string{c.SendBufferOverflow, c.ReceiveBufferOverflow} {
+		if _, err := network.ParseOverflowPolicy(policy); err != nil {
+			add(err)
+		}
+	}
+	if policy, _ := network.ParseOverflowPolicy(c.SendBufferOverflow); policy == network.OverflowDropOldest {
+		add(fmt.Errorf("send buffer overflow policy cannot be drop_oldest, outgoing messages are never discarded (use \"block\" or \"error\")"))
+	}
+	if c.RetryQueueStore != "" && c.RetryQueueStore != "memory" && c.RetryQueueStore != "file" && c.RetryQueueStore != "cache" {
+		add(fmt.Errorf("invalid retry queue store %q (use \"memory\", \"file\" or \"cache\")", c.RetryQueueStore))
+	}
+	if _, err := network.ParseOrdering(c.SendOrdering); err != nil {
+		add(err)
+	}
+	if c.CircuitBreakerMaxFailures < 0 || c.CircuitBreakerResetTimeout < 0 || c.CircuitBreakerProbes < 0 {
+		add(fmt.Errorf("circuit breaker settings cannot be negative"))
+	}
+	if _, err := tlsconfig.ParseVersion(c.TLSMinVersion); err != nil {
+		add(err)
+	}
+	if (c.TLSCertFile == "") != (c.TLSKeyFile == "") {
+		add(fmt.Errorf("TLS client certificate and key must be set together"))
+	}
+	if c.SessionRefreshBefore < 0 || c.SessionTTL < 0 {
+		add(fmt.Errorf("session durations cannot be negative"))
+	}
+	if _, err := network.ParseDuplicatePolicy(c.DuplicatePolicy); err != nil {
+		add(err)
+	}
+	if r := c.Resources; r != nil && (r.CPUCores < 0 || r.MemoryMB < 0 || r.MaxContextTokens < 0) {
+		add(fmt.Errorf("resources cannot be negative"))
+	}
+	if c.RateLimitPerMinute < 0 || c.RateLimitBurst < 0 || c.RoomRateLimitPerMinute < 0 || c.RoomRateLimitBurst < 0 ||
+		c.SenderRateLimitPerMinute < 0 || c.SenderRateLimitBurst < 0 {
+		add(fmt.Errorf("rate limits cannot be negative"))
+	}
+	if c.RoomBandwidthPerMinute < 0 || c.RoomBandwidthBurst < 0 {
+		add(fmt.Errorf("room bandwidth ceiling cannot be negative"))
+	}
+	if c.TaskQueuePolicy != "" {
+		if _, err := scheduler.ParsePolicy(c.TaskQueuePolicy); err != nil {
+			add(err)
+		}
+	}
+	if c.MaxQueuedTasks < 0 {
+		add(fmt.Errorf("max queued tasks cannot be negative"))
+	}
+	if c.TaskTimeout < 0 || c.TaskMaxDuration < 0 {
+		add(fmt.Errorf("task timeouts cannot be negative"))
+	}
+	if c.TaskBudgetWallTime < 0 || c.TaskBudgetTokens < 0 || c.TaskBudgetMessages < 0 {
+		add(fmt.Errorf("task budgets cannot be negative"))
+	}
+	switch c.LLMUsageReport {
+	case network.LLMUsageReportNone, network.LLMUsageReportField, network.LLMUsageReportFooter:
+	default:
+		add(fmt.Errorf("invalid LLM usage report %q (use \"field\" or \"footer\")", c.LLMUsageReport))
+	}
+	if c.LLMUsageRetention < 0 {
+		add(fmt.Errorf("LLM usage retention cannot be negative"))
+	}
+	for _, action := range []string{c.GuardrailInputAction, c.GuardrailOutputAction} {
+		switch action {
+		case "", types.GuardrailActionBlock, types.GuardrailActionRedact, types.GuardrailActionAnnotate:
+		default:
+			add(fmt.Errorf("invalid guardrail action %q (use \"block\", \"redact\" or \"annotate\")", action))
+		}
+	}
+	for _, expr := range c.GuardrailPatterns {
+		if _, err := guardrail.Pattern("pattern", expr); err != nil {
+			add(err)
+		}
+	}
+	for capability, timeout := range c.CapabilityTimeouts {
+		if timeout <= 0 {
+			add(fmt.Errorf("invalid timeout %s for capability %s (must be positive)", timeout, capability))
+		}
+	}
+	if c.GasMaxFeeGwei < 0 || c.GasPriorityFeeGwei < 0 || c.GasMaxCost < 0 {
+		add(fmt.Errorf("gas fees cannot be negative"))
+	}
+	if c.GasMaxFeeGwei > 0 && c.GasPriorityFeeGwei > c.GasMaxFeeGwei {
+		add(fmt.Errorf("gas priority fee (%g gwei) cannot exceed the max fee (%g gwei)", c.GasPriorityFeeGwei, c.GasMaxFeeGwei))
+	}
+	if c.MetadataSyncInterval < 0 {
+		add(fmt.Errorf("metadata sync interval cannot be negative"))
+	}
+	if c.OwnershipWatchInterval < 0 {
+		add(fmt.Errorf("ownership watch interval cannot be negative"))
+	}
+	if _, err := parseOwnershipPolicy(c.OwnershipPolicy); err != nil {
+		add(err)
+	}
+	if c.TaskHeartbeatInterval < 0 {
+		add(fmt.Errorf("task heartbeat interval cannot be negative"))
+	}
+	if c.TaskDedupTTL < 0 {
+		add(fmt.Errorf("task dedup TTL cannot be negative"))
+	}
+	if c.PaymentAddress != "" && !common.IsHexAddress(c.PaymentAddress) {
+		add(fmt.Errorf("invalid payment address %q", c.PaymentAddress))
+	}
+	if c.PaymentConfirmations < 0 {
+		add(fmt.Errorf("payment confirmations cannot be negative"))
+	}
+	if c.AccessTokenContract != "" && !common.IsHexAddress(c.AccessTokenContract) {
+		add(fmt.Errorf("invalid access token contract %q", c.AccessTokenContract))
+	}
+	if _, err := c.accessTokenMinBalance(); err != nil {
+		add(err)
+	}
+	if c.AccessTokenCacheTTL < 0 {
+		add(fmt.Errorf("access token cache TTL cannot be negative"))
+	}
+	if c.ReviewOnTimeout != "" && c.ReviewOnTimeout != "release" && c.ReviewOnTimeout != "reject" {
+		add(fmt.Errorf("invalid review timeout action %q (use \"release\" or \"reject\")", c.ReviewOnTimeout))
+	}
+	if _, err := logging.ParseLevel(c.LogLevel); err != nil {
+		add(err)
+	}
+	if c.LogFormat != "" && c.LogFormat != logging.FormatText && c.LogFormat != logging.FormatJSON {
+		add(fmt.Errorf("invalid log format %q (use \"text\" or \"json\")", c.LogFormat))
+	}
+	if _, err := output.ParseStyle(c.OutputStyle); err != nil {
+		add(err)
+	}
+	if _, err := redact.ParseKinds(c.RedactKinds); err != nil {
+		add(err)
+	}
+	if _, err := redact.ParsePatterns(c.RedactPatterns); err != nil {
+		add(fmt.Errorf("invalid redaction patterns: %w", err))
+	}
+	if _, err := c.redactTargets(); err != nil {
+		add(err)
+	}
+	if _, err := health.ParseChecks(c.ReadinessChecks); err != nil {
+		add(err)
+	}
+	if c.CoordinatorPublicKey != "" {
+		if _, err := auth.ParsePublicKey(c.CoordinatorPublicKey); err != nil {
+			add(fmt.Errorf("invalid coordinator public key: %w", err))
+		}
+	}
+	switch c.UserSignatures {
+	case "", UserSignaturesOff, UserSignaturesOptional, UserSignaturesRequired:
+	default:
+		add(fmt.Errorf("invalid user signature mode %q (use \"off\", \"optional\" or \"required\")", c.UserSignatures))
+	}
+	if c.UserSignatureMaxSkew < 0 {
+		add(fmt.Errorf("user signature max skew cannot be negative"))
+	}
+	if c.EncryptionKeysRetained < 0 {
+		add(fmt.Errorf("encryption keys retained cannot be negative"))
+	}
+	if c.EncryptionRotateInterval < 0 {
+		add(fmt.Errorf("encryption rotate interval cannot be negative"))
+	}
+	for _, operator := range c.Operators() {
+		if !common.IsHexAddress(operator) {
+			add(fmt.Errorf("invalid operator address %q", operator))
+		}
+	}
+	if c.RelayerURL != "" {
+		if _, err := c.NewRelayer(); err != nil {
+			add(err)
+		}
 	}
 	// OwnerAddress is derived from private key, so we don't require it to be set
-	return nil
+	return problems
+}
+
+// User signature modes, see UserSignatures
+const (
+	UserSignaturesOff      = "off"      // User messages are not verified
+	UserSignaturesOptional = "optional" // Signed user messages are verified, unsigned ones accepted
+	UserSignaturesRequired = "required" // Unsigned user messages are rejected
+)
+
+// Where PII redaction applies, see RedactTargets
+const (
+	RedactInput  = "input"  // Task input, before the handler, memory and caches see it
+	RedactOutput = "output" // Task responses, through the "redact" post-processor
+	RedactLogs   = "logs"   // Log messages and string fields
+)
+
+// Redactor returns the configured PII redactor and where it applies, or nil
+// if redaction is disabled
+func (c *Config) Redactor() (*redact.Redactor, map[string]bool, error) {
+	if !c.RedactPII {
+		return nil, nil, nil
+	}
+	kinds, err := redact.ParseKinds(c.RedactKinds)
+	if err != nil {
+		return nil, nil, err
+	}
+	patterns, err := redact.ParsePatterns(c.RedactPatterns)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid redaction patterns: %w", err)
+	}
+	targets, err := c.redactTargets()
+	if err != nil {
+		return nil, nil, err
+	}
+	return redact.New(&redact.Config{Kinds: kinds, Patterns: patterns}), targets, nil
+}
+
+// redactTargets parses RedactTargets (default: everywhere)
+func (c *Config) redactTargets() (map[string]bool, error) {
+	targets := make(map[string]bool, 3)
+	if strings.TrimSpace(c.RedactTargets) == "" {
+		targets[RedactInput], targets[RedactOutput], targets[RedactLogs] = true, true, true
+		return targets, nil
+	}
+	for _, target := range strings.Split(c.RedactTargets, ",") {
+		switch target = strings.ToLower(strings.TrimSpace(target)); target {
+		case RedactInput, RedactOutput, RedactLogs:
+			targets[target] = true
+		case "":
+		default:
+			return nil, fmt.Errorf("unknown redaction target %q (use input, output or logs)", target)
+		}
+	}
+	return targets, nil
+}
+
+// UserSignatureConfig returns how signed user messages are verified, or nil
+// if they are not
+func (c *Config) UserSignatureConfig() *network.UserSignatureConfig {
+	var capabilities []string
+	for _, capability := range strings.Split(c.UserSignatureCapabilities, ",") {
+		if capability = strings.TrimSpace(capability); capability != "" {
+			capabilities = append(capabilities, capability)
+		}
+	}
+	if c.UserSignatures == UserSignaturesOff || (c.UserSignatures == "" && len(capabilities) == 0) {
+		return nil
+	}
+	return &network.UserSignatureConfig{
+		Required:     c.UserSignatures == UserSignaturesRequired,
+		Capabilities: capabilities,
+		MaxClockSkew: c.UserSignatureMaxSkew,
+	}
+}
+
+// AcceptedContentTypes returns the content types of the tasks accepted, nil for any
+func (c *Config) AcceptedContentTypes() []string {
+	var contentTypes []string
+	for _, contentType := range strings.Split(c.TaskContentTypes, ",") {
+		if contentType = strings.ToUpper(strings.TrimSpace(contentType)); contentType != "" {
+			contentTypes = append(contentTypes, contentType)
+		}
+	}
+	return contentTypes
+}
+
+// TaskTimeouts returns the configured task timeouts
+func (c *Config) TaskTimeouts() *network.TaskTimeouts {
+	return &network.TaskTimeouts{
+		Default:       time.Duration(c.TaskTimeout) * time.Second,
+		PerCapability: c.CapabilityTimeouts,
+		MaxDuration:   c.TaskMaxDuration,
+	}
+}
+
+// parseCapabilityTimeouts parses "capability=duration" pairs separated by
+// commas, e.g. "web_scrape=5m,summarize=90s"
+func parseCapabilityTimeouts(s string) (map[string]time.Duration, error) {
+	timeouts := make(map[string]time.Duration)
+	for _, pair := range strings.Split(s, ",") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		}
+		capability, value, ok := strings.Cut(pair, "=")
+		capability = strings.TrimSpace(capability)
+		if !ok || capability == "" {
+			return nil, fmt.Errorf("expected capability=duration, got %q", pair)
+		}
+		timeout, err := time.ParseDuration(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("invalid timeout for %s: %w", capability, err)
+		}
+		timeouts[capability] = timeout
+	}
+	return timeouts, nil
+}
+
+// RateLimits returns the configured global, per-room and per-sender rate limits
+func (c *Config) RateLimits() ratelimit.Config {
+	return ratelimit.Config{
+		Global:    ratelimit.Limit{PerMinute: c.RateLimitPerMinute, Burst: c.RateLimitBurst},
+		PerRoom:   ratelimit.Limit{PerMinute: c.RoomRateLimitPerMinute, Burst: c.RoomRateLimitBurst},
+		PerSender: ratelimit.Limit{PerMinute: c.SenderRateLimitPerMinute, Burst: c.SenderRateLimitBurst},
+	}
+}
+
+// TaskBudget returns the configured budget of each task
+func (c *Config) TaskBudget() types.TaskBudget {
+	return types.TaskBudget{
+		MaxWallTime:         c.TaskBudgetWallTime,
+		MaxLLMTokens:        c.TaskBudgetTokens,
+		MaxOutboundMessages: c.TaskBudgetMessages,
+	}
+}
+
+// Operators returns the wallet addresses allowed to send operator commands:
+// OperatorAddresses, or else OwnerAddress (empty = the agent's own wallet)
+func (c *Config) Operators() []string {
+	var operators []string
+	for _, operator := range strings.Split(c.OperatorAddresses, ",") {
+		if operator = strings.TrimSpace(operator); operator != "" {
+			operators = append(operators, operator)
+		}
+	}
+	if len(operators) == 0 && c.OwnerAddress != "" {
+		operators = []string{c.OwnerAddress}
+	}
+	return operators
+}
+
+// FeeConfig returns how NFT transactions sent from the agent wallet are priced
+func (c *Config) FeeConfig() gas.Config {
+	return gas.Config{
+		MaxFeePerGas:         gas.FromGwei(c.GasMaxFeeGwei),
+		MaxPriorityFeePerGas: gas.FromGwei(c.GasPriorityFeeGwei),
+		MaxCost:              gas.FromGwei(c.GasMaxCost * 1e9),
+	}
+}
+
+// NewRelayer creates the gas sponsor relayer for NFT transactions, or returns nil if RelayerURL is not set
+func (c *Config) NewRelayer() (*nft.Relayer, error) {
+	if c.RelayerURL == "" {
+		return nil, nil
+	}
+
+	relayerConfig := nft.DefaultRelayerConfig()
+	relayerConfig.Endpoint = c.RelayerURL
+	relayerConfig.APIKey = c.RelayerAPIKey
+	relayerConfig.Forwarder = c.RelayerForwarder
+	if c.RelayerScheme != "" {
+		relayerConfig.Scheme = nft.SigningScheme(c.RelayerScheme)
+	}
+
+	relayer, err := nft.NewRelayer(relayerConfig)
+	if err != nil {
+		return nil, fmt.Errorf("invalid relayer configuration: %w", err)
+	}
+	return relayer, nil
 }
 
 // LoadFromEnv loads configuration from environment variables
@@ -106,6 +749,211 @@ func (c *Config) LoadFromEnv() error {
 	if wsURL := os.Getenv("WEBSOCKET_URL"); wsURL != "" {
 		c.WebSocketURL = wsURL
 	}
+	if maxDelay := os.Getenv("RECONNECT_MAX_DELAY"); maxDelay != "" {
+		d, err := time.ParseDuration(maxDelay)
+		if err != nil {
+			return fmt.Errorf("invalid RECONNECT_MAX_DELAY: %w", err)
+		}
+		c.ReconnectMaxDelay = d
+	}
+	if maxElapsed := os.Getenv("RECONNECT_MAX_ELAPSED"); maxElapsed != "" {
+		d, err := time.ParseDuration(maxElapsed)
+		if err != nil {
+			return fmt.Errorf("invalid RECONNECT_MAX_ELAPSED: %w", err)
+		}
+		c.ReconnectMaxElapsed = d
+	}
+	if refreshBefore := os.Getenv("SESSION_REFRESH_BEFORE"); refreshBefore != "" {
+		d, err := time.ParseDuration(refreshBefore)
+		if err != nil {
+			return fmt.Errorf("invalid SESSION_REFRESH_BEFORE: %w", err)
+		}
+		c.SessionRefreshBefore = d
+	}
+	if ttl := os.Getenv("SESSION_TTL"); ttl != "" {
+		d, err := time.ParseDuration(ttl)
+		if err != nil {
+			return fmt.Errorf("invalid SESSION_TTL: %w", err)
+		}
+		c.SessionTTL = d
+	}
+	if policy := os.Getenv("DUPLICATE_CONNECTION_POLICY"); policy != "" {
+		c.DuplicatePolicy = policy
+	}
+	if dataURL := os.Getenv("DATA_CHANNEL_URL"); dataURL != "" {
+		c.DataChannelURL = dataURL
+	}
+	if recordFile := os.Getenv("RECORD_FILE"); recordFile != "" {
+		c.RecordFile = recordFile
+	}
+	if maxFailures := os.Getenv("CIRCUIT_BREAKER_MAX_FAILURES"); maxFailures != "" {
+		n, err := strconv.Atoi(maxFailures)
+		if err != nil {
+			return fmt.Errorf("invalid CIRCUIT_BREAKER_MAX_FAILURES: %w", err)
+		}
+		c.CircuitBreakerMaxFailures = n
+	}
+	if resetTimeout := os.Getenv("CIRCUIT_BREAKER_RESET_TIMEOUT"); resetTimeout != "" {
+		d, err := time.ParseDuration(resetTimeout)
+		if err != nil {
+			return fmt.Errorf("invalid CIRCUIT_BREAKER_RESET_TIMEOUT: %w", err)
+		}
+		c.CircuitBreakerResetTimeout = d
+	}
+	if probes := os.Getenv("CIRCUIT_BREAKER_PROBES"); probes != "" {
+		n, err := strconv.Atoi(probes)
+		if err != nil {
+			return fmt.Errorf("invalid CIRCUIT_BREAKER_PROBES: %w", err)
+		}
+		c.CircuitBreakerProbes = n
+	}
+	if store := os.Getenv("RETRY_QUEUE_STORE"); store != "" {
+		c.RetryQueueStore = store
+	}
+	if retryFile := os.Getenv("RETRY_QUEUE_FILE"); retryFile != "" {
+		c.RetryQueueFile = retryFile
+	}
+	if ordering := os.Getenv("SEND_ORDERING"); ordering != "" {
+		c.SendOrdering = ordering
+	}
+	if caFile := os.Getenv("TLS_CA_FILE"); caFile != "" {
+		c.TLSCAFile = caFile
+	}
+	if certFile := os.Getenv("TLS_CERT_FILE"); certFile != "" {
+		c.TLSCertFile = certFile
+	}
+	if keyFile := os.Getenv("TLS_KEY_FILE"); keyFile != "" {
+		c.TLSKeyFile = keyFile
+	}
+	if version := os.Getenv("TLS_MIN_VERSION"); version != "" {
+		c.TLSMinVersion = version
+	}
+	if serverName := os.Getenv("TLS_SERVER_NAME"); serverName != "" {
+		c.TLSServerName = serverName
+	}
+	if serverName := os.Getenv("BACKEND_TLS_SERVER_NAME"); serverName != "" {
+		c.BackendTLSServerName = serverName
+	}
+	if insecure := os.Getenv("TLS_INSECURE_SKIP_VERIFY"); insecure != "" {
+		skip, err := strconv.ParseBool(insecure)
+		if err != nil {
+			return fmt.Errorf("invalid TLS_INSECURE_SKIP_VERIFY: %w", err)
+		}
+		c.TLSInsecureSkipVerify = skip
+	}
+	if deflate := os.Getenv("WEBSOCKET_DEFLATE"); deflate != "" {
+		enabled, err := strconv.ParseBool(deflate)
+		if err != nil {
+			return fmt.Errorf("invalid WEBSOCKET_DEFLATE: %w", err)
+		}
+		c.WebSocketDeflate = enabled
+	}
+	if threshold := os.Getenv("COMPRESS_THRESHOLD"); threshold != "" {
+		n, err := strconv.Atoi(threshold)
+		if err != nil {
+			return fmt.Errorf("invalid COMPRESS_THRESHOLD: %w", err)
+		}
+		c.CompressThreshold = n
+	}
+	if size := os.Getenv("RESPONSE_CHUNK_SIZE"); size != "" {
+		n, err := strconv.Atoi(size)
+		if err != nil {
+			return fmt.Errorf("invalid RESPONSE_CHUNK_SIZE: %w", err)
+		}
+		c.ResponseChunkSize = n
+	}
+	if threshold := os.Getenv("SEND_CONGESTION_THRESHOLD"); threshold != "" {
+		n, err := strconv.Atoi(threshold)
+		if err != nil {
+			return fmt.Errorf("invalid SEND_CONGESTION_THRESHOLD: %w", err)
+		}
+		c.SendCongestionThreshold = n
+	}
+	if size := os.Getenv("SEND_BUFFER_SIZE"); size != "" {
+		n, err := strconv.Atoi(size)
+		if err != nil {
+			return fmt.Errorf("invalid SEND_BUFFER_SIZE: %w", err)
+		}
+		c.SendBufferSize = n
+	}
+	if size := os.Getenv("RECEIVE_BUFFER_SIZE"); size != "" {
+		n, err := strconv.Atoi(size)
+		if err != nil {
+			return fmt.Errorf("invalid RECEIVE_BUFFER_SIZE: %w", err)
+		}
+		c.ReceiveBufferSize = n
+	}
+	if policy := os.Getenv("SEND_BUFFER_OVERFLOW"); policy != "" {
+		c.SendBufferOverflow = policy
+	}
+	if policy := os.Getenv("RECEIVE_BUFFER_OVERFLOW"); policy != "" {
+		c.ReceiveBufferOverflow = policy
+	}
+	if publicKey := os.Getenv("COORDINATOR_PUBLIC_KEY"); publicKey != "" {
+		c.CoordinatorPublicKey = publicKey
+	}
+	if sign := os.Getenv("SIGN_TASK_RESPONSES"); sign != "" {
+		enabled, err := strconv.ParseBool(sign)
+		if err != nil {
+			return fmt.Errorf("invalid SIGN_TASK_RESPONSES: %w", err)
+		}
+		c.SignTaskResponses = enabled
+	}
+	if mode := os.Getenv("USER_SIGNATURES"); mode != "" {
+		c.UserSignatures = mode
+	}
+	if capabilities := os.Getenv("USER_SIGNATURE_CAPABILITIES"); capabilities != "" {
+		c.UserSignatureCapabilities = capabilities
+	}
+	if skew := os.Getenv("USER_SIGNATURE_MAX_SKEW"); skew != "" {
+		d, err := time.ParseDuration(skew)
+		if err != nil {
+			return fmt.Errorf("invalid USER_SIGNATURE_MAX_SKEW: %w", err)
+		}
+		c.UserSignatureMaxSkew = d
+	}
+	// A mistyped security setting must not silently leave encryption off
+	if enabled := os.Getenv("ENCRYPTION_ENABLED"); enabled != "" {
+		v, err := strconv.ParseBool(enabled)
+		if err != nil {
+			return fmt.Errorf("invalid ENCRYPTION_ENABLED: %w", err)
+		}
+		c.EncryptionEnabled = v
+	}
+	if required := os.Getenv("ENCRYPTION_REQUIRED"); required != "" {
+		v, err := strconv.ParseBool(required)
+		if err != nil {
+			return fmt.Errorf("invalid ENCRYPTION_REQUIRED: %w", err)
+		}
+		c.EncryptionRequired = v
+	}
+	if keyFile := os.Getenv("ENCRYPTION_KEY_FILE"); keyFile != "" {
+		c.EncryptionKeyFile = keyFile
+	}
+	if retained := os.Getenv("ENCRYPTION_KEYS_RETAINED"); retained != "" {
+		n, err := strconv.Atoi(retained)
+		if err != nil {
+			return fmt.Errorf("invalid ENCRYPTION_KEYS_RETAINED: %w", err)
+		}
+		c.EncryptionKeysRetained = n
+	}
+	if interval := os.Getenv("ENCRYPTION_ROTATE_INTERVAL"); interval != "" {
+		d, err := time.ParseDuration(interval)
+		if err != nil {
+			return fmt.Errorf("invalid ENCRYPTION_ROTATE_INTERVAL: %w", err)
+		}
+		c.EncryptionRotateInterval = d
+	}
+	if operatorCommands := os.Getenv("OPERATOR_COMMANDS"); operatorCommands != "" {
+		enabled, err := strconv.ParseBool(operatorCommands)
+		if err != nil {
+			return fmt.Errorf("invalid OPERATOR_COMMANDS: %w", err)
+		}
+		c.OperatorCommands = enabled
+	}
+	if operators := os.Getenv("OPERATOR_ADDRESSES"); operators != "" {
+		c.OperatorAddresses = operators
+	}
 	if privateKey := os.Getenv("PRIVATE_KEY"); privateKey != "" {
 		c.PrivateKey = privateKey
 	}
@@ -115,6 +963,9 @@ func (c *Config) LoadFromEnv() error {
 	if nftTokenID := os.Getenv("NFT_TOKEN_ID"); nftTokenID != "" {
 		c.NFTTokenID = nftTokenID
 	}
+	if identityFile := os.Getenv("IDENTITY_FILE"); identityFile != "" {
+		c.IdentityFile = identityFile
+	}
 	if room := os.Getenv("ROOM"); room != "" {
 		c.Room = room
 	}
@@ -130,21 +981,502 @@ func (c *Config) LoadFromEnv() error {
 	if contract := os.Getenv("NFT_CONTRACT_ADDRESS"); contract != "" {
 		c.NFTContractAddress = contract
 	}
+	if interval := os.Getenv("METADATA_SYNC_INTERVAL"); interval != "" {
+		d, err := time.ParseDuration(interval)
+		if err != nil {
+			return fmt.Errorf("invalid METADATA_SYNC_INTERVAL: %w", err)
+		}
+		c.MetadataSyncInterval = d
+	}
+	if autoUpdate := os.Getenv("METADATA_AUTO_UPDATE"); autoUpdate != "" {
+		enabled, err := strconv.ParseBool(autoUpdate)
+		if err != nil {
+			return fmt.Errorf("invalid METADATA_AUTO_UPDATE: %w", err)
+		}
+		c.MetadataAutoUpdate = enabled
+	}
+	if maxFee := os.Getenv("GAS_MAX_FEE_GWEI"); maxFee != "" {
+		gwei, err := strconv.ParseFloat(maxFee, 64)
+		if err != nil {
+			return fmt.Errorf("invalid GAS_MAX_FEE_GWEI: %w", err)
+		}
+		c.GasMaxFeeGwei = gwei
+	}
+	if priorityFee := os.Getenv("GAS_PRIORITY_FEE_GWEI"); priorityFee != "" {
+		gwei, err := strconv.ParseFloat(priorityFee, 64)
+		if err != nil {
+			return fmt.Errorf("invalid GAS_PRIORITY_FEE_GWEI: %w", err)
+		}
+		c.GasPriorityFeeGwei = gwei
+	}
+	if maxCost := os.Getenv("GAS_MAX_COST"); maxCost != "" {
+		cost, err := strconv.ParseFloat(maxCost, 64)
+		if err != nil {
+			return fmt.Errorf("invalid GAS_MAX_COST: %w", err)
+		}
+		c.GasMaxCost = cost
+	}
+	if interval := os.Getenv("OWNERSHIP_WATCH_INTERVAL"); interval != "" {
+		d, err := time.ParseDuration(interval)
+		if err != nil {
+			return fmt.Errorf("invalid OWNERSHIP_WATCH_INTERVAL: %w", err)
+		}
+		c.OwnershipWatchInterval = d
+	}
+	if policy := os.Getenv("OWNERSHIP_POLICY"); policy != "" {
+		c.OwnershipPolicy = policy
+	}
+	if relayerURL := os.Getenv("RELAYER_URL"); relayerURL != "" {
+		c.RelayerURL = relayerURL
+	}
+	if relayerAPIKey := os.Getenv("RELAYER_API_KEY"); relayerAPIKey != "" {
+		c.RelayerAPIKey = relayerAPIKey
+	}
+	if forwarder := os.Getenv("RELAYER_FORWARDER"); forwarder != "" {
+		c.RelayerForwarder = forwarder
+	}
+	if scheme := os.Getenv("RELAYER_SIGNING_SCHEME"); scheme != "" {
+		c.RelayerScheme = scheme
+	}
 	if healthPort := os.Getenv("HEALTH_PORT"); healthPort != "" {
-		if port, err := strconv.Atoi(healthPort); err == nil {
-			c.HealthPort = port
+		port, err := strconv.Atoi(healthPort)
+		if err != nil {
+			return fmt.Errorf("invalid HEALTH_PORT: %w", err)
+		}
+		c.HealthPort = port
+	}
+	if checks := os.Getenv("READINESS_CHECKS"); checks != "" {
+		c.ReadinessChecks = checks
+	}
+	if metricsEnabled := os.Getenv("METRICS_ENABLED"); metricsEnabled != "" {
+		enabled, err := strconv.ParseBool(metricsEnabled)
+		if err != nil {
+			return fmt.Errorf("invalid METRICS_ENABLED: %w", err)
+		}
+		c.MetricsEnabled = enabled
+	}
+	if logLevel := os.Getenv("LOG_LEVEL"); logLevel != "" {
+		c.LogLevel = logLevel
+	}
+	if logFormat := os.Getenv("LOG_FORMAT"); logFormat != "" {
+		c.LogFormat = logFormat
+	}
+	if configFile := os.Getenv("CONFIG_FILE"); configFile != "" {
+		c.ConfigFile = configFile
+	}
+	if reload := os.Getenv("RELOAD_ON_SIGHUP"); reload != "" {
+		enabled, err := strconv.ParseBool(reload)
+		if err != nil {
+			return fmt.Errorf("invalid RELOAD_ON_SIGHUP: %w", err)
+		}
+		c.ReloadOnSIGHUP = enabled
+	}
+	if prompt := os.Getenv("SYSTEM_PROMPT"); prompt != "" {
+		c.SystemPrompt = prompt
+	}
+	if dir := os.Getenv("PROMPTS_DIR"); dir != "" {
+		c.PromptsDir = dir
+	}
+	if fromCache := os.Getenv("PROMPTS_FROM_CACHE"); fromCache != "" {
+		enabled, err := strconv.ParseBool(fromCache)
+		if err != nil {
+			return fmt.Errorf("invalid PROMPTS_FROM_CACHE: %w", err)
+		}
+		c.PromptsFromCache = enabled
+	}
+	if style := os.Getenv("OUTPUT_STYLE"); style != "" {
+		c.OutputStyle = style
+	}
+	if redactPII := os.Getenv("REDACT_PII"); redactPII != "" {
+		enabled, err := strconv.ParseBool(redactPII)
+		if err != nil {
+			return fmt.Errorf("invalid REDACT_PII: %w", err)
+		}
+		c.RedactPII = enabled
+	}
+	if kinds := os.Getenv("REDACT_KINDS"); kinds != "" {
+		c.RedactKinds = kinds
+	}
+	if patterns := os.Getenv("REDACT_PATTERNS"); patterns != "" {
+		c.RedactPatterns = patterns
+	}
+	if targets := os.Getenv("REDACT_TARGETS"); targets != "" {
+		c.RedactTargets = targets
+	}
+	if gpus := os.Getenv("RESOURCE_GPUS"); gpus != "" {
+		parsed, err := types.ParseGPUs(gpus)
+		if err != nil {
+			return fmt.Errorf("invalid RESOURCE_GPUS: %w", err)
+		}
+		c.resources().GPUs = parsed
+	}
+	if cores := os.Getenv("RESOURCE_CPU_CORES"); cores != "" {
+		n, err := strconv.Atoi(cores)
+		if err != nil {
+			return fmt.Errorf("invalid RESOURCE_CPU_CORES: %w", err)
+		}
+		c.resources().CPUCores = n
+	}
+	if memory := os.Getenv("RESOURCE_MEMORY"); memory != "" {
+		mb, err := types.ParseMemoryMB(memory)
+		if err != nil {
+			return fmt.Errorf("invalid RESOURCE_MEMORY: %w", err)
 		}
+		c.resources().MemoryMB = mb
+	}
+	if maxContext := os.Getenv("RESOURCE_MAX_CONTEXT_TOKENS"); maxContext != "" {
+		n, err := strconv.Atoi(maxContext)
+		if err != nil {
+			return fmt.Errorf("invalid RESOURCE_MAX_CONTEXT_TOKENS: %w", err)
+		}
+		c.resources().MaxContextTokens = n
 	}
 	if rateLimit := os.Getenv("RATE_LIMIT_PER_MINUTE"); rateLimit != "" {
-		if limit, err := strconv.Atoi(rateLimit); err == nil {
-			c.RateLimitPerMinute = limit
+		limit, err := strconv.Atoi(rateLimit)
+		if err != nil {
+			return fmt.Errorf("invalid RATE_LIMIT_PER_MINUTE: %w", err)
+		}
+		c.RateLimitPerMinute = limit
+	}
+	for name, field := range map[string]*int{
+		"RATE_LIMIT_BURST":             &c.RateLimitBurst,
+		"ROOM_RATE_LIMIT_PER_MINUTE":   &c.RoomRateLimitPerMinute,
+		"ROOM_RATE_LIMIT_BURST":        &c.RoomRateLimitBurst,
+		"SENDER_RATE_LIMIT_PER_MINUTE": &c.SenderRateLimitPerMinute,
+		"SENDER_RATE_LIMIT_BURST":      &c.SenderRateLimitBurst,
+	} {
+		if value := os.Getenv(name); value != "" {
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return fmt.Errorf("invalid %s: %w", name, err)
+			}
+			*field = n
+		}
+	}
+	for name, field := range map[string]*int64{
+		"ROOM_BANDWIDTH_PER_MINUTE": &c.RoomBandwidthPerMinute,
+		"ROOM_BANDWIDTH_BURST":      &c.RoomBandwidthBurst,
+	} {
+		if value := os.Getenv(name); value != "" {
+			n, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return fmt.Errorf("invalid %s: %w", name, err)
+			}
+			*field = n
+		}
+	}
+	if maxTasks := os.Getenv("MAX_CONCURRENT_TASKS"); maxTasks != "" {
+		n, err := strconv.Atoi(maxTasks)
+		if err != nil {
+			return fmt.Errorf("invalid MAX_CONCURRENT_TASKS: %w", err)
+		}
+		c.MaxConcurrentTasks = n
+	}
+	if policy := os.Getenv("TASK_QUEUE_POLICY"); policy != "" {
+		c.TaskQueuePolicy = policy
+	}
+	if preemption := os.Getenv("TASK_PREEMPTION"); preemption != "" {
+		enabled, err := strconv.ParseBool(preemption)
+		if err != nil {
+			return fmt.Errorf("invalid TASK_PREEMPTION: %w", err)
+		}
+		c.TaskPreemption = enabled
+	}
+	if maxQueued := os.Getenv("MAX_QUEUED_TASKS"); maxQueued != "" {
+		n, err := strconv.Atoi(maxQueued)
+		if err != nil {
+			return fmt.Errorf("invalid MAX_QUEUED_TASKS: %w", err)
+		}
+		c.MaxQueuedTasks = n
+	}
+	if timeout := os.Getenv("TASK_TIMEOUT"); timeout != "" {
+		n, err := strconv.Atoi(timeout)
+		if err != nil {
+			return fmt.Errorf("invalid TASK_TIMEOUT: %w", err)
+		}
+		c.TaskTimeout = n
+	}
+	if timeouts := os.Getenv("CAPABILITY_TIMEOUTS"); timeouts != "" {
+		parsed, err := parseCapabilityTimeouts(timeouts)
+		if err != nil {
+			return fmt.Errorf("invalid CAPABILITY_TIMEOUTS: %w", err)
+		}
+		c.CapabilityTimeouts = parsed
+	}
+	if maxDuration := os.Getenv("TASK_MAX_DURATION"); maxDuration != "" {
+		d, err := time.ParseDuration(maxDuration)
+		if err != nil {
+			return fmt.Errorf("invalid TASK_MAX_DURATION: %w", err)
+		}
+		c.TaskMaxDuration = d
+	}
+	if maxRetries := os.Getenv("TASK_MAX_RETRIES"); maxRetries != "" {
+		n, err := strconv.Atoi(maxRetries)
+		if err != nil {
+			return fmt.Errorf("invalid TASK_MAX_RETRIES: %w", err)
+		}
+		c.TaskMaxRetries = n
+	}
+	if restart := os.Getenv("RESTART_HANDLER_ON_PANIC"); restart != "" {
+		enabled, err := strconv.ParseBool(restart)
+		if err != nil {
+			return fmt.Errorf("invalid RESTART_HANDLER_ON_PANIC: %w", err)
+		}
+		c.RestartHandlerOnPanic = enabled
+	}
+	if heartbeat := os.Getenv("TASK_HEARTBEAT_INTERVAL"); heartbeat != "" {
+		d, err := time.ParseDuration(heartbeat)
+		if err != nil {
+			return fmt.Errorf("invalid TASK_HEARTBEAT_INTERVAL: %w", err)
+		}
+		c.TaskHeartbeatInterval = d
+	}
+	if dedupTTL := os.Getenv("TASK_DEDUP_TTL"); dedupTTL != "" {
+		d, err := time.ParseDuration(dedupTTL)
+		if err != nil {
+			return fmt.Errorf("invalid TASK_DEDUP_TTL: %w", err)
+		}
+		c.TaskDedupTTL = d
+	}
+	if maxTask := os.Getenv("MAX_TASK_BYTES"); maxTask != "" {
+		n, err := strconv.Atoi(maxTask)
+		if err != nil {
+			return fmt.Errorf("invalid MAX_TASK_BYTES: %w", err)
+		}
+		c.MaxTaskBytes = n
+	}
+	if contentTypes := os.Getenv("TASK_CONTENT_TYPES"); contentTypes != "" {
+		c.TaskContentTypes = contentTypes
+	}
+	if maxInput := os.Getenv("MAX_INPUT_CHARS"); maxInput != "" {
+		n, err := strconv.Atoi(maxInput)
+		if err != nil {
+			return fmt.Errorf("invalid MAX_INPUT_CHARS: %w", err)
+		}
+		c.MaxInputChars = n
+	}
+	if inputPolicy := os.Getenv("INPUT_GUARD_POLICY"); inputPolicy != "" {
+		c.InputGuardPolicy = inputPolicy
+	}
+	if maxOutput := os.Getenv("MAX_OUTPUT_BYTES"); maxOutput != "" {
+		n, err := strconv.Atoi(maxOutput)
+		if err != nil {
+			return fmt.Errorf("invalid MAX_OUTPUT_BYTES: %w", err)
+		}
+		c.MaxOutputBytes = n
+	}
+	if outputPolicy := os.Getenv("OUTPUT_GUARD_POLICY"); outputPolicy != "" {
+		c.OutputGuardPolicy = outputPolicy
+	}
+	if maxMessages := os.Getenv("MAX_MESSAGES_PER_TASK"); maxMessages != "" {
+		n, err := strconv.Atoi(maxMessages)
+		if err != nil {
+			return fmt.Errorf("invalid MAX_MESSAGES_PER_TASK: %w", err)
+		}
+		c.MaxMessagesPerTask = n
+	}
+	if wallTime := os.Getenv("TASK_BUDGET_WALL_TIME"); wallTime != "" {
+		d, err := time.ParseDuration(wallTime)
+		if err != nil {
+			return fmt.Errorf("invalid TASK_BUDGET_WALL_TIME: %w", err)
+		}
+		c.TaskBudgetWallTime = d
+	}
+	if tokens := os.Getenv("TASK_BUDGET_TOKENS"); tokens != "" {
+		n, err := strconv.Atoi(tokens)
+		if err != nil {
+			return fmt.Errorf("invalid TASK_BUDGET_TOKENS: %w", err)
+		}
+		c.TaskBudgetTokens = n
+	}
+	if messages := os.Getenv("TASK_BUDGET_MESSAGES"); messages != "" {
+		n, err := strconv.Atoi(messages)
+		if err != nil {
+			return fmt.Errorf("invalid TASK_BUDGET_MESSAGES: %w", err)
+		}
+		c.TaskBudgetMessages = n
+	}
+	if quotaEnabled := os.Getenv("QUOTA_ENABLED"); quotaEnabled != "" {
+		enabled, err := strconv.ParseBool(quotaEnabled)
+		if err != nil {
+			return fmt.Errorf("invalid QUOTA_ENABLED: %w", err)
+		}
+		c.QuotaEnabled = enabled
+	}
+	if quotaPlan := os.Getenv("QUOTA_DEFAULT_PLAN"); quotaPlan != "" {
+		c.QuotaDefaultPlan = quotaPlan
+	}
+	if adminToken := os.Getenv("ADMIN_TOKEN"); adminToken != "" {
+		c.AdminToken = adminToken
+	}
+	if meteringEnabled := os.Getenv("METERING_ENABLED"); meteringEnabled != "" {
+		enabled, err := strconv.ParseBool(meteringEnabled)
+		if err != nil {
+			return fmt.Errorf("invalid METERING_ENABLED: %w", err)
+		}
+		c.MeteringEnabled = enabled
+	}
+	if prices := os.Getenv("CAPABILITY_PRICES"); prices != "" {
+		parsed, err := metering.ParsePrices(prices)
+		if err != nil {
+			return fmt.Errorf("invalid CAPABILITY_PRICES: %w", err)
 		}
+		c.CapabilityPrices = parsed
+	}
+	if price := os.Getenv("DEFAULT_TASK_PRICE"); price != "" {
+		parsed, err := metering.ParseAmount(price)
+		if err != nil {
+			return fmt.Errorf("invalid DEFAULT_TASK_PRICE: %w", err)
+		}
+		c.DefaultTaskPrice = parsed
+	}
+	if currency := os.Getenv("PRICE_CURRENCY"); currency != "" {
+		c.PriceCurrency = currency
+	}
+	if receipts := os.Getenv("USAGE_RECEIPTS"); receipts != "" {
+		enabled, err := strconv.ParseBool(receipts)
+		if err != nil {
+			return fmt.Errorf("invalid USAGE_RECEIPTS: %w", err)
+		}
+		c.UsageReceipts = enabled
+	}
+	if report := os.Getenv("LLM_USAGE_REPORT"); report != "" {
+		c.LLMUsageReport = report
+	}
+	if retention := os.Getenv("LLM_USAGE_RETENTION"); retention != "" {
+		n, err := strconv.Atoi(retention)
+		if err != nil {
+			return fmt.Errorf("invalid LLM_USAGE_RETENTION: %w", err)
+		}
+		c.LLMUsageRetention = n
+	}
+	if keywords := os.Getenv("GUARDRAIL_KEYWORDS"); keywords != "" {
+		c.GuardrailKeywords = strings.Split(keywords, ",")
+	}
+	if pattern := os.Getenv("GUARDRAIL_PATTERN"); pattern != "" {
+		c.GuardrailPatterns = []string{pattern}
+	}
+	if moderation := os.Getenv("GUARDRAIL_MODERATION"); moderation != "" {
+		enabled, err := strconv.ParseBool(moderation)
+		if err != nil {
+			return fmt.Errorf("invalid GUARDRAIL_MODERATION: %w", err)
+		}
+		c.GuardrailModeration = enabled
+	}
+	if action := os.Getenv("GUARDRAIL_INPUT_ACTION"); action != "" {
+		c.GuardrailInputAction = action
+	}
+	if action := os.Getenv("GUARDRAIL_OUTPUT_ACTION"); action != "" {
+		c.GuardrailOutputAction = action
+	}
+	if failClosed := os.Getenv("GUARDRAIL_FAIL_CLOSED"); failClosed != "" {
+		enabled, err := strconv.ParseBool(failClosed)
+		if err != nil {
+			return fmt.Errorf("invalid GUARDRAIL_FAIL_CLOSED: %w", err)
+		}
+		c.GuardrailFailClosed = enabled
+	}
+	if paymentRequired := os.Getenv("PAYMENT_REQUIRED"); paymentRequired != "" {
+		enabled, err := strconv.ParseBool(paymentRequired)
+		if err != nil {
+			return fmt.Errorf("invalid PAYMENT_REQUIRED: %w", err)
+		}
+		c.PaymentRequired = enabled
+	}
+	if address := os.Getenv("PAYMENT_ADDRESS"); address != "" {
+		c.PaymentAddress = address
+	}
+	if confirmations := os.Getenv("PAYMENT_CONFIRMATIONS"); confirmations != "" {
+		n, err := strconv.Atoi(confirmations)
+		if err != nil {
+			return fmt.Errorf("invalid PAYMENT_CONFIRMATIONS: %w", err)
+		}
+		c.PaymentConfirmations = n
+	}
+	if senders := os.Getenv("ACCESS_ALLOW_SENDERS"); senders != "" {
+		c.AccessAllowSenders = senders
+	}
+	if senders := os.Getenv("ACCESS_DENY_SENDERS"); senders != "" {
+		c.AccessDenySenders = senders
+	}
+	if rooms := os.Getenv("ACCESS_ALLOW_ROOMS"); rooms != "" {
+		c.AccessAllowRooms = rooms
+	}
+	if rooms := os.Getenv("ACCESS_DENY_ROOMS"); rooms != "" {
+		c.AccessDenyRooms = rooms
+	}
+	if contract := os.Getenv("ACCESS_TOKEN_CONTRACT"); contract != "" {
+		c.AccessTokenContract = contract
+	}
+	if minBalance := os.Getenv("ACCESS_TOKEN_MIN_BALANCE"); minBalance != "" {
+		c.AccessTokenMinBalance = minBalance
+	}
+	if ttl := os.Getenv("ACCESS_TOKEN_CACHE_TTL"); ttl != "" {
+		d, err := time.ParseDuration(ttl)
+		if err != nil {
+			return fmt.Errorf("invalid ACCESS_TOKEN_CACHE_TTL: %w", err)
+		}
+		c.AccessTokenCacheTTL = d
+	}
+	if memoryEnabled := os.Getenv("MEMORY_ENABLED"); memoryEnabled != "" {
+		enabled, err := strconv.ParseBool(memoryEnabled)
+		if err != nil {
+			return fmt.Errorf("invalid MEMORY_ENABLED: %w", err)
+		}
+		c.MemoryEnabled = enabled
+	}
+	if maxMessages := os.Getenv("MEMORY_MAX_MESSAGES"); maxMessages != "" {
+		n, err := strconv.Atoi(maxMessages)
+		if err != nil {
+			return fmt.Errorf("invalid MEMORY_MAX_MESSAGES: %w", err)
+		}
+		c.MemoryMaxMessages = n
+	}
+	if maxTokens := os.Getenv("MEMORY_MAX_TOKENS"); maxTokens != "" {
+		n, err := strconv.Atoi(maxTokens)
+		if err != nil {
+			return fmt.Errorf("invalid MEMORY_MAX_TOKENS: %w", err)
+		}
+		c.MemoryMaxTokens = n
+	}
+	if restore := os.Getenv("MEMORY_RESTORE_MESSAGES"); restore != "" {
+		n, err := strconv.Atoi(restore)
+		if err != nil {
+			return fmt.Errorf("invalid MEMORY_RESTORE_MESSAGES: %w", err)
+		}
+		c.MemoryRestoreMessages = n
+	}
+	if reviewEnabled := os.Getenv("REVIEW_ENABLED"); reviewEnabled != "" {
+		enabled, err := strconv.ParseBool(reviewEnabled)
+		if err != nil {
+			return fmt.Errorf("invalid REVIEW_ENABLED: %w", err)
+		}
+		c.ReviewEnabled = enabled
+	}
+	if threshold := os.Getenv("REVIEW_THRESHOLD"); threshold != "" {
+		v, err := strconv.ParseFloat(threshold, 64)
+		if err != nil {
+			return fmt.Errorf("invalid REVIEW_THRESHOLD: %w", err)
+		}
+		c.ReviewThreshold = v
+	}
+	if timeout := os.Getenv("REVIEW_TIMEOUT"); timeout != "" {
+		d, err := time.ParseDuration(timeout)
+		if err != nil {
+			return fmt.Errorf("invalid REVIEW_TIMEOUT: %w", err)
+		}
+		c.ReviewTimeout = d
+	}
+	if action := os.Getenv("REVIEW_ON_TIMEOUT"); action != "" {
+		c.ReviewOnTimeout = action
 	}
 	// Redis configuration
 	if redisEnabled := os.Getenv("REDIS_ENABLED"); redisEnabled != "" {
-		if enabled, err := strconv.ParseBool(redisEnabled); err == nil {
-			c.RedisEnabled = enabled
+		enabled, err := strconv.ParseBool(redisEnabled)
+		if err != nil {
+			return fmt.Errorf("invalid REDIS_ENABLED: %w", err)
 		}
+		c.RedisEnabled = enabled
 	}
 	if redisAddr := os.Getenv("REDIS_ADDRESS"); redisAddr != "" {
 		c.RedisAddress = redisAddr
@@ -162,21 +1494,114 @@ func (c *Config) LoadFromEnv() error {
 		c.RedisPassword = redisPass
 	}
 	if redisDB := os.Getenv("REDIS_DB"); redisDB != "" {
-		if db, err := strconv.Atoi(redisDB); err == nil {
-			c.RedisDB = db
+		db, err := strconv.Atoi(redisDB)
+		if err != nil {
+			return fmt.Errorf("invalid REDIS_DB: %w", err)
 		}
+		c.RedisDB = db
 	}
 	if redisPrefix := os.Getenv("REDIS_KEY_PREFIX"); redisPrefix != "" {
 		c.RedisKeyPrefix = redisPrefix
 	}
 	if redisTLS := os.Getenv("REDIS_USE_TLS"); redisTLS != "" {
-		if useTLS, err := strconv.ParseBool(redisTLS); err == nil {
-			c.RedisUseTLS = useTLS
+		useTLS, err := strconv.ParseBool(redisTLS)
+		if err != nil {
+			return fmt.Errorf("invalid REDIS_USE_TLS: %w", err)
 		}
+		c.RedisUseTLS = useTLS
 	}
+	if memoryEnabled := os.Getenv("MEMORY_CACHE_ENABLED"); memoryEnabled != "" {
+		enabled, err := strconv.ParseBool(memoryEnabled)
+		if err != nil {
+			return fmt.Errorf("invalid MEMORY_CACHE_ENABLED: %w", err)
+		}
+		c.MemoryCacheEnabled = enabled
+	}
+	if maxEntries := os.Getenv("MEMORY_CACHE_MAX_ENTRIES"); maxEntries != "" {
+		n, err := strconv.Atoi(maxEntries)
+		if err != nil {
+			return fmt.Errorf("invalid MEMORY_CACHE_MAX_ENTRIES: %w", err)
+		}
+		c.MemoryCacheMaxEntries = n
+	}
+	return nil
+}
+
+// LoadFromFile overlays settings from a YAML, TOML or JSON config file. Keys
+// are the JSON names of the Config fields, e.g. rate_limit_per_minute, and
+// durations are written as "30s". ${VAR} and ${VAR:-default} in values are
+// replaced from the environment. Settings missing from the file are kept.
+// A YAML file with an agent section is read as an agent file (see AgentFile).
+//
+// The error lists every problem in the file, and the configuration is left
+// unchanged if there is any.
+func (c *Config) LoadFromFile(path string) error {
+	updated := *c
+	if problems := updated.decodeFile(path); len(problems) > 0 {
+		return fmt.Errorf("invalid config file %s: %w", path, errors.Join(problems...))
+	}
+	*c = updated
 	return nil
 }
 
+// decodeFile applies a config file, returning every problem found. Valid
+// settings are applied even if others have problems.
+func (c *Config) decodeFile(path string) []error {
+	tree, err := configfile.ReadFile(path)
+	if err != nil {
+		return []error{err}
+	}
+
+	if _, isAgentFile := tree["agent"].(map[string]any); isAgentFile {
+		file, err := LoadAgentFile(path)
+		if err != nil {
+			return []error{err}
+		}
+		file.applyTo(c)
+		return nil
+	}
+	return configfile.Decode(tree, c, os.LookupEnv)
+}
+
+// LoadConfigFile returns the default configuration overlaid with the
+// environment and then the config file, and validates the result. Set
+// ConfigFile on the returned configuration to also reload the file when it
+// changes.
+func LoadConfigFile(path string) (*Config, error) {
+	config := DefaultConfig()
+	if err := config.LoadFromEnv(); err != nil {
+		return nil, fmt.Errorf("failed to load environment: %w", err)
+	}
+	if err := config.LoadFromFile(path); err != nil {
+		return nil, err
+	}
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+	return config, nil
+}
+
+// ValidateConfigFile checks a config file the way LoadConfigFile reads it
+// and returns every problem found, from unknown keys and wrong types to
+// invalid or missing settings (nil if the file is valid).
+func ValidateConfigFile(path string) []error {
+	config := DefaultConfig()
+	var problems []error
+	if err := config.LoadFromEnv(); err != nil {
+		problems = append(problems, err)
+	}
+	problems = append(problems, config.decodeFile(path)...)
+	return append(problems, config.problems()...)
+}
+
+// resources returns the advertised resources, creating them if needed
+func (c *Config) resources() *types.ComputeResources {
+	if c.Resources == nil {
+		c.Resources = &types.ComputeResources{}
+	}
+	return c.Resources
+}
+
 // DefaultConfig returns a default configuration
 func DefaultConfig() *Config {
 	return &Config{
@@ -190,17 +1615,43 @@ func DefaultConfig() *Config {
 		ReconnectEnabled:   true,
 		ReconnectDelay:     5 * time.Second,
 		MaxReconnects:      10,
+		ReconnectMaxDelay:  60 * time.Second,
 		MessageTimeout:     30 * time.Second,
 		PingInterval:       30 * time.Second,
 		HandshakeTimeout:   10 * time.Second,
+		WebSocketDeflate:   false,
+		CompressThreshold:  32 * 1024,
+		ResponseChunkSize:  256 * 1024,
+		SignTaskResponses:  true,
 		HealthEnabled:      true,
 		HealthPort:         8080,
+		MetricsEnabled:     true,
+		LogLevel:           "info",
+		LogFormat:          "text",
+		OutputStyle:        "emoji",
 		EthereumRPC:        "https://peaq.api.onfinality.io/public",
 		NFTContractAddress: "0x811FF962AcBe432344AC974c1111b70847195d3C",
+		IdentityFile:       identity.DefaultPath,
+		RetryQueueFile:     retrystore.DefaultPath,
 		MaxConcurrentTasks: 5,
 		TaskTimeout:        30,
+		TaskMaxDuration:    time.Hour,
 		TaskCheckInterval:  10,
+		TaskDedupTTL:       10 * time.Minute,
 		RateLimitPerMinute: 0, // 0 = unlimited
+		MaxTaskBytes:       network.DefaultMaxTaskBytes,
+		InputGuardPolicy:   "reject",
+		OutputGuardPolicy:  "truncate",
+		QuotaEnabled:       false,
+		QuotaDefaultPlan:   "free",
+		PriceCurrency:      "USD",
+		MemoryEnabled:      false,
+		MemoryMaxMessages:  20,
+		MemoryMaxTokens:    4000,
+		ReviewEnabled:      false,
+		ReviewThreshold:    0.5,
+		ReviewTimeout:      5 * time.Minute,
+		ReviewOnTimeout:    "release",
 		RedisEnabled:       false,
 		RedisAddress:       "localhost:6379",
 		RedisUsername:      "", // Empty for legacy auth or default user
@@ -208,5 +1659,15 @@ func DefaultConfig() *Config {
 		RedisDB:            0,
 		RedisKeyPrefix:     "", // Will be set to "teneo:agent:<agent_name>:" if empty
 		RedisUseTLS:        false,
+
+		MemoryCacheEnabled:    false,
+		MemoryCacheMaxEntries: 10000,
+
+		TaskHeartbeatInterval: 30 * time.Second,
+		PaymentConfirmations:  1,
+		AccessTokenCacheTTL:   access.DefaultBalanceTTL,
+		UserSignatureMaxSkew:  5 * time.Minute,
+		EncryptionKeyFile:     e2e.DefaultKeyFile,
+		LLMUsageRetention:     metering.DefaultLLMRetention,
 	}
 }
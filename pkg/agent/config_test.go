@@ -0,0 +1,101 @@
+package agent
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLoadFromEnvRejectsMalformedSettings(t *testing.T) {
+	for env, valid := range map[string]string{
+		"ENCRYPTION_ENABLED":            "true",
+		"ENCRYPTION_REQUIRED":           "true",
+		"TLS_INSECURE_SKIP_VERIFY":      "true",
+		"PAYMENT_REQUIRED":              "true",
+		"PAYMENT_CONFIRMATIONS":         "3",
+		"QUOTA_ENABLED":                 "true",
+		"MEMORY_ENABLED":                "true",
+		"MEMORY_MAX_MESSAGES":           "20",
+		"MEMORY_MAX_TOKENS":             "4000",
+		"MAX_INPUT_CHARS":               "10000",
+		"MAX_OUTPUT_BYTES":              "65536",
+		"MAX_MESSAGES_PER_TASK":         "50",
+		"METRICS_ENABLED":               "true",
+		"REVIEW_ENABLED":                "true",
+		"REVIEW_THRESHOLD":              "0.5",
+		"REVIEW_TIMEOUT":                "10s",
+		"TASK_MAX_RETRIES":              "3",
+		"WEBSOCKET_DEFLATE":             "true",
+		"COMPRESS_THRESHOLD":            "1024",
+		"SEND_CONGESTION_THRESHOLD":     "10",
+		"SIGN_TASK_RESPONSES":           "true",
+		"MEMORY_CACHE_ENABLED":          "true",
+		"MEMORY_CACHE_MAX_ENTRIES":      "1000",
+		"REDIS_ENABLED":                 "true",
+		"REDIS_USE_TLS":                 "true",
+		"REDIS_DB":                      "1",
+		"MEMORY_RESTORE_MESSAGES":       "50",
+		"TASK_DEDUP_TTL":                "10m",
+		"RECONNECT_MAX_DELAY":           "30s",
+		"RECONNECT_MAX_ELAPSED":         "10m",
+		"REDACT_PII":                    "true",
+		"RESOURCE_CPU_CORES":            "8",
+		"RESOURCE_MAX_CONTEXT_TOKENS":   "8192",
+		"RELOAD_ON_SIGHUP":              "true",
+		"SESSION_REFRESH_BEFORE":        "5m",
+		"SESSION_TTL":                   "1h",
+		"RATE_LIMIT_PER_MINUTE":         "60",
+		"RATE_LIMIT_BURST":              "10",
+		"ROOM_RATE_LIMIT_PER_MINUTE":    "60",
+		"SENDER_RATE_LIMIT_BURST":       "5",
+		"ROOM_BANDWIDTH_PER_MINUTE":     "1048576",
+		"ROOM_BANDWIDTH_BURST":          "65536",
+		"MAX_CONCURRENT_TASKS":          "4",
+		"TASK_PREEMPTION":               "true",
+		"MAX_QUEUED_TASKS":              "100",
+		"OPERATOR_COMMANDS":             "true",
+		"TASK_TIMEOUT":                  "30",
+		"TASK_MAX_DURATION":             "5m",
+		"RESPONSE_CHUNK_SIZE":           "65536",
+		"METADATA_SYNC_INTERVAL":        "1h",
+		"METADATA_AUTO_UPDATE":          "true",
+		"OWNERSHIP_WATCH_INTERVAL":      "1m",
+		"GAS_MAX_FEE_GWEI":              "50",
+		"GAS_PRIORITY_FEE_GWEI":         "2",
+		"GAS_MAX_COST":                  "0.01",
+		"CIRCUIT_BREAKER_MAX_FAILURES":  "5",
+		"CIRCUIT_BREAKER_RESET_TIMEOUT": "30s",
+		"CIRCUIT_BREAKER_PROBES":        "1",
+		"SEND_BUFFER_SIZE":              "256",
+		"RECEIVE_BUFFER_SIZE":           "256",
+		"HEALTH_PORT":                   "8080",
+		"TASK_HEARTBEAT_INTERVAL":       "15s",
+		"METERING_ENABLED":              "true",
+		"USAGE_RECEIPTS":                "true",
+		"RESTART_HANDLER_ON_PANIC":      "true",
+		"TASK_BUDGET_WALL_TIME":         "10m",
+		"TASK_BUDGET_TOKENS":            "100000",
+		"TASK_BUDGET_MESSAGES":          "100",
+		"LLM_USAGE_RETENTION":           "1000",
+		"PROMPTS_FROM_CACHE":            "true",
+		"GUARDRAIL_MODERATION":          "true",
+		"GUARDRAIL_FAIL_CLOSED":         "true",
+		"MAX_TASK_BYTES":                "1048576",
+		"ACCESS_TOKEN_CACHE_TTL":        "5m",
+		"USER_SIGNATURE_MAX_SKEW":       "5m",
+		"ENCRYPTION_KEYS_RETAINED":      "2",
+		"ENCRYPTION_ROTATE_INTERVAL":    "24h",
+	} {
+		t.Run(env, func(t *testing.T) {
+			t.Setenv(env, "ture")
+			err := DefaultConfig().LoadFromEnv()
+			if err == nil || !strings.Contains(err.Error(), env) {
+				t.Errorf("LoadFromEnv() = %v, want an error naming %s", err, env)
+			}
+
+			t.Setenv(env, valid)
+			if err := DefaultConfig().LoadFromEnv(); err != nil {
+				t.Errorf("LoadFromEnv() = %v", err)
+			}
+		})
+	}
+}
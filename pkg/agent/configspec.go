@@ -0,0 +1,261 @@
+package agent
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/e2e"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/envspec"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/retrystore"
+)
+
+// Groups of the settings in generated example files
+const (
+	groupAgent     = "Agent"
+	groupNetwork   = "Network"
+	groupSecurity  = "Authentication and security"
+	groupNFT       = "NFT and blockchain"
+	groupHealth    = "Health, logging and reload"
+	groupTasks     = "Task processing"
+	groupLimits    = "Rate limits and guards"
+	groupMemory    = "Memory and review"
+	groupCache     = "Cache"
+	groupResources = "Compute resources"
+	groupProviders = "Providers"
+	groupBackend   = "Backend"
+)
+
+// configVars describes the settings of Config. Types and defaults come from
+// DefaultConfig, so only what the struct can't tell is written here.
+var configVars = []envspec.Var{
+	{Env: "AGENT_NAME", Key: "name", Group: groupAgent, Required: true, Description: "Agent name"},
+	{Env: "AGENT_DESCRIPTION", Key: "description", Group: groupAgent, Description: "What the agent does"},
+	{Env: "AGENT_IMAGE", Key: "image", Group: groupAgent, Description: "Image URL of the agent's NFT"},
+	{Env: "AGENT_VERSION", Key: "version", Group: groupAgent, Description: "Agent version"},
+	{Env: "AGENT_CAPABILITIES", Key: "capabilities", Group: groupAgent, Description: "Capabilities, e.g. text/summarization"},
+	{Env: "AGENT_CONTACT", Key: "contact_info", Group: groupAgent, Description: "Contact information"},
+	{Env: "AGENT_PRICING", Key: "pricing_model", Group: groupAgent, Description: "Pricing model"},
+	{Env: "SYSTEM_PROMPT", Key: "system_prompt", Group: groupAgent, Description: "System prompt of model-backed handlers (empty = handler default)"},
+	{Env: "PROMPTS_DIR", Key: "prompts_dir", Group: groupAgent, Description: "Directory of prompt templates with per-room and per-capability overrides"},
+	{Env: "PROMPTS_FROM_CACHE", Key: "prompts_from_cache", Group: groupAgent, Description: "Look up prompt overrides in the cache under prompts:room:<room> and prompts:capability:<capability>"},
+	{Key: "interface_type", Group: groupAgent, Description: "How clients talk to the agent"},
+	{Key: "response_format", Group: groupAgent, Description: "Format of task responses"},
+	{Env: "ROOM", Key: "room", Group: groupAgent, Description: "Room the agent joins"},
+	{Env: "ROOM_ID", Group: groupAgent, Description: "Old name of ROOM"},
+
+	{Env: "WEBSOCKET_URL", Key: "websocket_url", Group: groupNetwork, Description: "WebSocket URL of the Teneo network"},
+	{Key: "reconnect_enabled", Group: groupNetwork, Description: "Reconnect when the connection drops"},
+	{Key: "reconnect_delay", Group: groupNetwork, Description: "Delay before the first reconnect"},
+	{Key: "max_reconnects", Group: groupNetwork, Description: "Reconnect attempts before giving up"},
+	{Env: "RECONNECT_MAX_DELAY", Key: "reconnect_max_delay", Group: groupNetwork, Description: "Longest delay between reconnects"},
+	{Env: "RECONNECT_MAX_ELAPSED", Key: "reconnect_max_elapsed", Group: groupNetwork, Description: "Stop reconnecting after this long (0 = no limit)"},
+	{Key: "message_timeout", Group: groupNetwork, Description: "Timeout of a message send"},
+	{Key: "ping_interval", Group: groupNetwork, Description: "Interval of keepalive pings"},
+	{Key: "handshake_timeout", Group: groupNetwork, Description: "Timeout of the WebSocket handshake"},
+	{Env: "SESSION_REFRESH_BEFORE", Key: "session_refresh_before", Group: groupNetwork, Description: "Re-authenticate this long before the session expires (0 = 1m)"},
+	{Env: "SESSION_TTL", Key: "session_ttl", Group: groupNetwork, Description: "Session lifetime when the server doesn't state one (0 = until disconnected)"},
+	{Env: "DUPLICATE_CONNECTION_POLICY", Key: "duplicate_policy", Group: groupNetwork, Values: []string{"alert", "yield", "takeover"}, Description: "What to do when another process connects with the same identity"},
+	{Env: "DATA_CHANNEL_URL", Key: "data_channel_url", Group: groupNetwork, Description: "WebSocket URL of a second connection for task output (empty = disabled)"},
+	{Env: "RECORD_FILE", Key: "record_file", Group: groupNetwork, Description: "JSONL file all messages are recorded to for replay (empty = no recording)"},
+	{Env: "CIRCUIT_BREAKER_MAX_FAILURES", Key: "circuit_breaker_max_failures", Group: groupNetwork, Default: "3", Description: "Consecutive send failures that open a message class's circuit"},
+	{Env: "CIRCUIT_BREAKER_RESET_TIMEOUT", Key: "circuit_breaker_reset_timeout", Group: groupNetwork, Default: "30s", Description: "How long an open circuit blocks sends before probing"},
+	{Env: "CIRCUIT_BREAKER_PROBES", Key: "circuit_breaker_probes", Group: groupNetwork, Default: "1", Description: "Messages let through a half-open circuit to test it"},
+	{Env: "RETRY_QUEUE_STORE", Key: "retry_queue_store", Group: groupNetwork, Default: "memory", Values: []string{"memory", "file", "cache"}, Description: "Where messages waiting for a retry are kept across restarts"},
+	{Env: "RETRY_QUEUE_FILE", Key: "retry_queue_file", Group: groupNetwork, Default: retrystore.DefaultPath, Description: "File of the retry queue with the \"file\" store"},
+	{Env: "SEND_ORDERING", Key: "send_ordering", Group: groupNetwork, Default: "task", Values: []string{"task", "room", "none"}, Description: "Which responses are kept in order while one waits for a retry"},
+	{Env: "TLS_CA_FILE", Key: "tls_ca_file", Group: groupNetwork, Description: "PEM bundle of CAs trusted in addition to the system roots"},
+	{Env: "TLS_CERT_FILE", Key: "tls_cert_file", Group: groupNetwork, Description: "PEM client certificate for mutual TLS"},
+	{Env: "TLS_KEY_FILE", Key: "tls_key_file", Group: groupNetwork, Description: "PEM private key of the client certificate"},
+	{Env: "TLS_MIN_VERSION", Key: "tls_min_version", Group: groupNetwork, Default: "1.2", Values: []string{"1.0", "1.1", "1.2", "1.3"}, Description: "Lowest TLS version accepted"},
+	{Env: "TLS_SERVER_NAME", Key: "tls_server_name", Group: groupNetwork, Description: "Server name sent in SNI and verified for WebSocket connections (default the URL's host)"},
+	{Env: "BACKEND_TLS_SERVER_NAME", Key: "backend_tls_server_name", Group: groupNetwork, Description: "Server name sent in SNI and verified for backend requests (default the URL's host)"},
+	{Env: "TLS_INSECURE_SKIP_VERIFY", Key: "tls_insecure_skip_verify", Group: groupNetwork, Description: "Accept any server certificate (development only)"},
+	{Env: "WEBSOCKET_DEFLATE", Key: "websocket_deflate", Group: groupNetwork, Description: "Negotiate permessage-deflate"},
+	{Env: "COMPRESS_THRESHOLD", Key: "compress_threshold", Group: groupNetwork, Description: "Compress task responses of at least this many bytes (0 = never)"},
+	{Env: "RESPONSE_CHUNK_SIZE", Key: "response_chunk_size", Group: groupNetwork, Description: "Split task responses larger than this many bytes (0 = never)"},
+	{Env: "SEND_CONGESTION_THRESHOLD", Key: "send_congestion_threshold", Group: groupNetwork, Description: "Coalesce task updates while this many messages are queued (0 = half the send buffer)"},
+	{Env: "SEND_BUFFER_SIZE", Key: "send_buffer_size", Group: groupNetwork, Default: "100", Description: "Outgoing messages buffered for writing"},
+	{Env: "RECEIVE_BUFFER_SIZE", Key: "receive_buffer_size", Group: groupNetwork, Default: "100", Description: "Incoming messages buffered for handling"},
+	{Env: "SEND_BUFFER_OVERFLOW", Key: "send_buffer_overflow", Group: groupNetwork, Default: "block", Values: []string{"block", "error"}, Description: "What happens to a message sent while the send buffer is full"},
+	{Env: "RECEIVE_BUFFER_OVERFLOW", Key: "receive_buffer_overflow", Group: groupNetwork, Default: "block", Values: []string{"block", "drop_oldest", "error"}, Description: "What happens to a message received while the receive buffer is full"},
+
+	{Env: "PRIVATE_KEY", Key: "private_key", Group: groupSecurity, Required: true, Secret: true, Description: "Private key of the agent's wallet"},
+	{Env: "OWNER_ADDRESS", Key: "owner_address", Group: groupSecurity, Description: "Owner wallet (default derived from the private key)"},
+	{Env: "COORDINATOR_PUBLIC_KEY", Key: "coordinator_public_key", Group: groupSecurity, Description: "Only accept tasks signed with this key (empty = not verified)"},
+	{Env: "SIGN_TASK_RESPONSES", Key: "sign_task_responses", Group: groupSecurity, Description: "Sign task responses with the agent's key"},
+	{Env: "USER_SIGNATURES", Key: "user_signatures", Group: groupSecurity, Values: []string{"off", "optional", "required"}, Description: "Verify that direct user messages were signed by their sender (empty = off, or optional with USER_SIGNATURE_CAPABILITIES)"},
+	{Env: "USER_SIGNATURE_CAPABILITIES", Key: "user_signature_capabilities", Group: groupSecurity, Description: "Comma-separated capabilities only signed user messages may use; unsigned messages are rejected while the agent offers one"},
+	{Env: "USER_SIGNATURE_MAX_SKEW", Key: "user_signature_max_skew", Group: groupSecurity, Description: "Reject signed user messages whose timestamp is further from the agent's clock"},
+	{Env: "ENCRYPTION_ENABLED", Key: "encryption_enabled", Group: groupSecurity, Description: "Accept end-to-end encrypted tasks and publish the keys to encrypt them to"},
+	{Env: "ENCRYPTION_REQUIRED", Key: "encryption_required", Group: groupSecurity, Description: "Reject tasks that are not encrypted"},
+	{Env: "ENCRYPTION_KEY_FILE", Key: "encryption_key_file", Group: groupSecurity, Default: e2e.DefaultKeyFile, Description: "File of the agent's encryption keys, created on the first run"},
+	{Env: "ENCRYPTION_KEYS_RETAINED", Key: "encryption_keys_retained", Group: groupSecurity, Default: "2", Description: "Keys kept to decrypt tasks encrypted before a rotation"},
+	{Env: "ENCRYPTION_ROTATE_INTERVAL", Key: "encryption_rotate_interval", Group: groupSecurity, Description: "Rotate the encryption key when it is this old (0 = never)"},
+	{Env: "OPERATOR_COMMANDS", Key: "operator_commands", Group: groupSecurity, Description: "Accept signed operator commands"},
+	{Env: "OPERATOR_ADDRESSES", Key: "operator_addresses", Group: groupSecurity, Description: "Comma-separated operator wallets (default the owner)"},
+	{Env: "ADMIN_TOKEN", Key: "admin_token", Group: groupSecurity, Secret: true, Description: "Bearer token of the admin, review and control APIs (empty = disabled)"},
+	{Env: "REDACT_PII", Key: "redact_pii", Group: groupSecurity, Description: "Redact personal data from task input, responses and logs"},
+	{Env: "REDACT_KINDS", Key: "redact_kinds", Group: groupSecurity, Description: "Comma-separated: secret, wallet, email, credit_card, phone (default all)"},
+	{Env: "REDACT_PATTERNS", Key: "redact_patterns", Group: groupSecurity, Description: "Custom kinds as kind=regexp pairs separated by semicolons, e.g. employee_id=EMP-\\d{6}"},
+	{Env: "REDACT_TARGETS", Key: "redact_targets", Group: groupSecurity, Description: "Comma-separated: input, output, logs (default all)"},
+	{Env: "ACCESS_ALLOW_SENDERS", Key: "access_allow_senders", Group: groupSecurity, Description: "Comma-separated wallets or users whose tasks are accepted (empty = any)"},
+	{Env: "ACCESS_DENY_SENDERS", Key: "access_deny_senders", Group: groupSecurity, Description: "Comma-separated wallets or users whose tasks are rejected"},
+	{Env: "ACCESS_ALLOW_ROOMS", Key: "access_allow_rooms", Group: groupSecurity, Description: "Comma-separated rooms whose tasks are accepted (empty = any)"},
+	{Env: "ACCESS_DENY_ROOMS", Key: "access_deny_rooms", Group: groupSecurity, Description: "Comma-separated rooms whose tasks are rejected"},
+	{Env: "ACCESS_TOKEN_CONTRACT", Key: "access_token_contract", Group: groupSecurity, Description: "ERC-20 or ERC-721 token senders must hold (empty = no token gate)"},
+	{Env: "ACCESS_TOKEN_MIN_BALANCE", Key: "access_token_min_balance", Group: groupSecurity, Description: "Smallest token balance admitted, in base units (empty = 1)"},
+	{Env: "ACCESS_TOKEN_CACHE_TTL", Key: "access_token_cache_ttl", Group: groupSecurity, Description: "How long a sender's token balance is remembered"},
+
+	{Env: "IDENTITY_MODE", Group: groupNFT, Default: "nft", Values: []string{"nft", "wallet-only", "anonymous"}, Description: "How the agent identifies itself; without an NFT nothing is minted or verified"},
+	{Env: "NFT_TOKEN_ID", Key: "nft_token_id", Group: groupNFT, Description: "Token ID of the agent's NFT"},
+	{Env: "IDENTITY_FILE", Key: "identity_file", Group: groupNFT, Description: "File keeping the agent's identity across restarts (empty = disabled)"},
+	{Env: "ETHEREUM_RPC", Key: "ethereum_rpc", Group: groupNFT, Description: "Comma-separated RPC endpoints; the first sends transactions"},
+	{Env: "NFT_CONTRACT_ADDRESS", Key: "nft_contract_address", Group: groupNFT, Description: "Business card contract"},
+	{Env: "GAS_MAX_FEE_GWEI", Key: "gas_max_fee_gwei", Group: groupNFT, Description: "Fee cap per gas of NFT transactions (0 = twice the base fee plus the tip)"},
+	{Env: "GAS_PRIORITY_FEE_GWEI", Key: "gas_priority_fee_gwei", Group: groupNFT, Description: "Tip per gas of NFT transactions (0 = the node's suggestion)"},
+	{Env: "GAS_MAX_COST", Key: "gas_max_cost", Group: groupNFT, Description: "Most an NFT transaction may cost, in the chain's native token (0 = unlimited)"},
+	{Env: "METADATA_SYNC_INTERVAL", Key: "metadata_sync_interval", Group: groupNFT, Description: "How often the NFT metadata is compared with the config (0 = disabled)"},
+	{Env: "METADATA_AUTO_UPDATE", Key: "metadata_auto_update", Group: groupNFT, Description: "Write drifted description and version on-chain"},
+	{Env: "OWNERSHIP_WATCH_INTERVAL", Key: "ownership_watch_interval", Group: groupNFT, Description: "How often the NFT is checked for transfers (0 = disabled)"},
+	{Env: "OWNERSHIP_POLICY", Key: "ownership_policy", Group: groupNFT, Values: []string{"alert", "stop", "reregister"}, Description: "What to do when the NFT is transferred to another wallet"},
+	{Env: "RELAYER_URL", Key: "relayer_url", Group: groupNFT, Description: "Gas sponsor relayer (empty = the wallet pays gas)"},
+	{Env: "RELAYER_API_KEY", Key: "relayer_api_key", Group: groupNFT, Secret: true, Description: "API key of the relayer"},
+	{Env: "RELAYER_FORWARDER", Key: "relayer_forwarder", Group: groupNFT, Description: "Trusted ERC-2771 forwarder contract"},
+	{Env: "RELAYER_SIGNING_SCHEME", Key: "relayer_scheme", Group: groupNFT, Values: []string{"eip712", "personal"}, Description: "How relayed requests are signed"},
+
+	{Key: "health_enabled", Group: groupHealth, Description: "Serve the health endpoints"},
+	{Env: "HEALTH_PORT", Key: "health_port", Group: groupHealth, Description: "Port of the health server"},
+	{Env: "METRICS_ENABLED", Key: "metrics_enabled", Group: groupHealth, Description: "Expose Prometheus metrics on /metrics"},
+	{Env: "READINESS_CHECKS", Key: "readiness_checks", Group: groupHealth, Description: "Comma-separated checks of /readyz: connected, authenticated, registered, circuits (default all)"},
+	{Env: "LOG_LEVEL", Key: "log_level", Group: groupHealth, Values: []string{"debug", "info", "warn", "warning", "error"}, Description: "Log level"},
+	{Env: "LOG_FORMAT", Key: "log_format", Group: groupHealth, Values: []string{"text", "json"}, Description: "Log format"},
+	{Env: "OUTPUT_STYLE", Key: "output_style", Group: groupHealth, Values: []string{"emoji", "plain", "text"}, Description: "Emoji in SDK-generated messages and logs"},
+	{Env: "CONFIG_FILE", Key: "config_file", Group: groupHealth, Description: "Config file re-read whenever it changes (empty = not watched)"},
+	{Env: "RELOAD_ON_SIGHUP", Key: "reload_on_sighup", Group: groupHealth, Description: "Re-read the environment and config file on SIGHUP"},
+
+	{Env: "MAX_CONCURRENT_TASKS", Key: "max_concurrent_tasks", Group: groupTasks, Description: "Tasks running at once"},
+	{Env: "TASK_TIMEOUT", Key: "task_timeout", Group: groupTasks, Description: "Seconds a task without a server deadline may run"},
+	{Key: "task_check_interval", Group: groupTasks, Description: "Seconds between task checks"},
+	{Env: "TASK_MAX_RETRIES", Key: "task_max_retries", Group: groupTasks, Description: "Retries of retryable handler errors"},
+	{Env: "RESTART_HANDLER_ON_PANIC", Key: "restart_handler_on_panic", Group: groupTasks, Description: "Clean up and reinitialize the handler after it panicked"},
+	{Env: "CAPABILITY_TIMEOUTS", Key: "capability_timeouts", Group: groupTasks, Description: "Task timeouts by capability, e.g. text/summarization=2m"},
+	{Env: "TASK_MAX_DURATION", Key: "task_max_duration", Group: groupTasks, Description: "Longest a handler may extend a task to (0 = no limit)"},
+	{Env: "TASK_QUEUE_POLICY", Key: "task_queue_policy", Group: groupTasks, Values: []string{"strict", "weighted"}, Description: "Queue tasks by priority (empty = start tasks when they arrive)"},
+	{Env: "TASK_PREEMPTION", Key: "task_preemption", Group: groupTasks, Description: "Restart the lowest-priority task later to run a higher-priority one"},
+	{Env: "MAX_QUEUED_TASKS", Key: "max_queued_tasks", Group: groupTasks, Description: "Tasks waiting at most (0 = unlimited)"},
+	{Env: "TASK_HEARTBEAT_INTERVAL", Key: "task_heartbeat_interval", Group: groupTasks, Description: "Interval of task_alive messages sent while a task runs (0 = none)"},
+	{Env: "TASK_DEDUP_TTL", Key: "task_dedup_ttl", Group: groupTasks, Description: "How long task IDs are remembered to skip redeliveries (0 = disabled)"},
+
+	{Env: "RATE_LIMIT_PER_MINUTE", Key: "rate_limit_per_minute", Group: groupLimits, Description: "Tasks per minute (0 = unlimited)"},
+	{Env: "RATE_LIMIT_BURST", Key: "rate_limit_burst", Group: groupLimits, Description: "Tasks at once (0 = the per-minute rate)"},
+	{Env: "ROOM_RATE_LIMIT_PER_MINUTE", Key: "room_rate_limit_per_minute", Group: groupLimits, Description: "Tasks per room and minute (0 = unlimited)"},
+	{Env: "ROOM_RATE_LIMIT_BURST", Key: "room_rate_limit_burst", Group: groupLimits, Description: "Tasks per room at once (0 = the per-minute rate)"},
+	{Env: "SENDER_RATE_LIMIT_PER_MINUTE", Key: "sender_rate_limit_per_minute", Group: groupLimits, Description: "Tasks per sender and minute (0 = unlimited)"},
+	{Env: "SENDER_RATE_LIMIT_BURST", Key: "sender_rate_limit_burst", Group: groupLimits, Description: "Tasks per sender at once (0 = the per-minute rate)"},
+	{Env: "ROOM_BANDWIDTH_PER_MINUTE", Key: "room_bandwidth_per_minute", Group: groupLimits, Description: "Bytes per room and minute (0 = unlimited)"},
+	{Env: "ROOM_BANDWIDTH_BURST", Key: "room_bandwidth_burst", Group: groupLimits, Description: "Bytes per room at once (0 = the per-minute rate)"},
+	{Env: "MAX_TASK_BYTES", Key: "max_task_bytes", Group: groupLimits, Description: "Largest task content accepted, checked before any other work (0 = unlimited)"},
+	{Env: "TASK_CONTENT_TYPES", Key: "task_content_types", Group: groupLimits, Description: "Content types of the tasks accepted, e.g. STRING,JSON (empty = any)"},
+	{Env: "MAX_INPUT_CHARS", Key: "max_input_chars", Group: groupLimits, Description: "Longest task input (0 = unlimited)"},
+	{Env: "INPUT_GUARD_POLICY", Key: "input_guard_policy", Group: groupLimits, Values: []string{"reject", "truncate"}, Description: "What happens to longer input"},
+	{Env: "MAX_OUTPUT_BYTES", Key: "max_output_bytes", Group: groupLimits, Description: "Largest task output (0 = unlimited)"},
+	{Env: "OUTPUT_GUARD_POLICY", Key: "output_guard_policy", Group: groupLimits, Values: []string{"truncate", "reject"}, Description: "What happens to larger output"},
+	{Env: "MAX_MESSAGES_PER_TASK", Key: "max_messages_per_task", Group: groupLimits, Description: "Messages a task may send (0 = unlimited)"},
+	{Env: "TASK_BUDGET_WALL_TIME", Key: "task_budget_wall_time", Group: groupLimits, Description: "Run time of a task, capping its timeout (0 = timeout only)"},
+	{Env: "TASK_BUDGET_TOKENS", Key: "task_budget_tokens", Group: groupLimits, Description: "LLM tokens a task may use (0 = unlimited)"},
+	{Env: "TASK_BUDGET_MESSAGES", Key: "task_budget_messages", Group: groupLimits, Description: "Messages a streaming task may send (0 = unlimited)"},
+	{Env: "QUOTA_ENABLED", Key: "quota_enabled", Group: groupLimits, Description: "Enforce per-wallet quotas"},
+	{Env: "QUOTA_DEFAULT_PLAN", Key: "quota_default_plan", Group: groupLimits, Description: "Plan of unregistered consumers"},
+	{Env: "METERING_ENABLED", Key: "metering_enabled", Group: groupLimits, Description: "Record completed tasks and their price by sender and capability"},
+	{Env: "CAPABILITY_PRICES", Key: "capability_prices", Group: groupLimits, Description: "Price per task by capability, e.g. web_scrape=0.01"},
+	{Env: "DEFAULT_TASK_PRICE", Key: "default_task_price", Group: groupLimits, Description: "Price of tasks without a priced capability"},
+	{Env: "PRICE_CURRENCY", Key: "price_currency", Group: groupLimits, Description: "Currency of the prices"},
+	{Env: "USAGE_RECEIPTS", Key: "usage_receipts", Group: groupLimits, Description: "Send a signed receipt with every completed task"},
+	{Env: "LLM_USAGE_REPORT", Key: "llm_usage_report", Group: groupLimits, Description: "Add each task's LLM usage to its response: field or footer (empty = none)"},
+	{Env: "LLM_USAGE_RETENTION", Key: "llm_usage_retention", Group: groupLimits, Description: "Days of LLM usage per room kept for reports"},
+	{Env: "PAYMENT_REQUIRED", Key: "payment_required", Group: groupLimits, Description: "Verify on chain that priced tasks were paid for before running them"},
+	{Env: "PAYMENT_ADDRESS", Key: "payment_address", Group: groupLimits, Description: "Address tasks are paid to (default: the agent's wallet)"},
+	{Env: "PAYMENT_CONFIRMATIONS", Key: "payment_confirmations", Group: groupLimits, Description: "Blocks a payment must be buried under"},
+
+	{Env: "MEMORY_ENABLED", Key: "memory_enabled", Group: groupMemory, Description: "Keep per-room conversation history"},
+	{Env: "MEMORY_MAX_MESSAGES", Key: "memory_max_messages", Group: groupMemory, Description: "Turns kept per room (0 = unlimited)"},
+	{Env: "MEMORY_MAX_TOKENS", Key: "memory_max_tokens", Group: groupMemory, Description: "Tokens kept per room (0 = unlimited)"},
+	{Env: "MEMORY_RESTORE_MESSAGES", Key: "memory_restore_messages", Group: groupMemory, Description: "Room messages fetched on join to rebuild history (0 = disabled)"},
+	{Env: "REVIEW_ENABLED", Key: "review_enabled", Group: groupMemory, Description: "Hold risky responses for human approval"},
+	{Env: "REVIEW_THRESHOLD", Key: "review_threshold", Group: groupMemory, Description: "Risk score from which responses are held (0..1)"},
+	{Env: "REVIEW_TIMEOUT", Key: "review_timeout", Group: groupMemory, Description: "How long a held response waits for review"},
+	{Env: "REVIEW_ON_TIMEOUT", Key: "review_on_timeout", Group: groupMemory, Values: []string{"release", "reject"}, Description: "What happens when the review times out"},
+	{Env: "GUARDRAIL_KEYWORDS", Key: "guardrail_keywords", Group: groupMemory, Description: "Words and phrases that flag task input and responses, comma-separated"},
+	{Env: "GUARDRAIL_PATTERN", Key: "guardrail_patterns", Group: groupMemory, Description: "Regular expression that flags task input and responses"},
+	{Env: "GUARDRAIL_MODERATION", Key: "guardrail_moderation", Group: groupMemory, Description: "Check task input and responses with the OpenAI moderation API"},
+	{Env: "GUARDRAIL_INPUT_ACTION", Key: "guardrail_input_action", Group: groupMemory, Default: "block", Values: []string{"block", "redact", "annotate"}, Description: "What happens to flagged task input"},
+	{Env: "GUARDRAIL_OUTPUT_ACTION", Key: "guardrail_output_action", Group: groupMemory, Default: "block", Values: []string{"block", "redact", "annotate"}, Description: "What happens to flagged responses"},
+	{Env: "GUARDRAIL_FAIL_CLOSED", Key: "guardrail_fail_closed", Group: groupMemory, Description: "Block content the guardrail fails to check"},
+
+	{Env: "REDIS_ENABLED", Key: "redis_enabled", Group: groupCache, Description: "Cache in Redis"},
+	{Env: "REDIS_ADDRESS", Key: "redis_address", Group: groupCache, Description: "Redis server address"},
+	{Env: "REDIS_URL", Group: groupCache, Description: "Redis server address when REDIS_ADDRESS is not set"},
+	{Env: "REDIS_USERNAME", Key: "redis_username", Group: groupCache, Description: "Redis ACL username (empty for legacy auth)"},
+	{Env: "REDIS_PASSWORD", Key: "redis_password", Group: groupCache, Secret: true, Description: "Redis password"},
+	{Env: "REDIS_DB", Key: "redis_db", Group: groupCache, Description: "Redis database number (0-15)"},
+	{Env: "REDIS_KEY_PREFIX", Key: "redis_key_prefix", Group: groupCache, Description: "Prefix of cache keys (empty = teneo:agent:<name>:)"},
+	{Env: "REDIS_USE_TLS", Key: "redis_use_tls", Group: groupCache, Description: "Connect to Redis over TLS"},
+	{Env: "MEMORY_CACHE_ENABLED", Key: "memory_cache_enabled", Group: groupCache, Description: "Cache in memory when Redis is disabled or unavailable"},
+	{Env: "MEMORY_CACHE_MAX_ENTRIES", Key: "memory_cache_max_entries", Group: groupCache, Description: "Keys cached in memory (0 = unlimited)"},
+
+	{Env: "RESOURCE_GPUS", Group: groupResources, Type: envspec.TypeList, Description: `GPUs, e.g. "2x NVIDIA RTX 4090:24GB"`},
+	{Env: "RESOURCE_CPU_CORES", Group: groupResources, Type: envspec.TypeInt, Description: "CPU cores"},
+	{Env: "RESOURCE_MEMORY", Group: groupResources, Description: "Memory, e.g. 64GB"},
+	{Env: "RESOURCE_MAX_CONTEXT_TOKENS", Group: groupResources, Type: envspec.TypeInt, Description: "Largest model context in tokens"},
+
+	{Env: "BACKEND_URL", Group: groupBackend, Default: "http://localhost:8080", Description: "Backend that mints NFTs and stores metadata"},
+	{Env: "RPC_ENDPOINT", Group: groupBackend, Description: "Comma-separated RPC endpoints for NFT reads"},
+	{Env: "RPC_WRITE_ENDPOINT", Group: groupBackend, Description: "RPC endpoint sending NFT transactions"},
+
+	{Env: "OPENAI_API_KEY", Group: groupProviders, Secret: true, Description: "OpenAI API key"},
+	{Env: "OLLAMA_BASE_URL", Group: groupProviders, Default: "http://localhost:11434", Description: "Ollama server"},
+	{Env: "OLLAMA_MODEL", Group: groupProviders, Description: "Ollama model"},
+}
+
+// ConfigSpec describes every environment variable and config file key the
+// agent reads, with its type and default. Extensions add the settings of
+// custom providers and handlers, so generated examples and the strict loader
+// cover them too; their types and defaults can come from a struct with
+// envspec.Spec.Describe.
+func ConfigSpec(extensions ...envspec.Var) *envspec.Spec {
+	spec := envspec.New(configVars...)
+	if err := spec.Describe(DefaultConfig()); err != nil {
+		panic(err) // configVars and Config are out of step
+	}
+	spec.Add(extensions...)
+	return spec
+}
+
+// LoadEnvFile reads a .env file strictly and then loads the environment like
+// LoadFromEnv. Every variable in the file must be described by ConfigSpec or
+// the extensions and have a value of its type; otherwise all problems are
+// returned and nothing is loaded. Variables already set in the environment
+// take precedence over the file.
+func (c *Config) LoadEnvFile(path string, extensions ...envspec.Var) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open env file: %w", err)
+	}
+	defer file.Close()
+
+	entries, err := envspec.ParseEnv(file)
+	if err != nil {
+		return fmt.Errorf("invalid env file %s: %w", path, err)
+	}
+	if problems := ConfigSpec(extensions...).Check(entries); len(problems) > 0 {
+		return fmt.Errorf("invalid env file %s: %w", path, errors.Join(problems...))
+	}
+
+	for _, entry := range entries {
+		if _, set := os.LookupEnv(entry.Name); !set {
+			os.Setenv(entry.Name, entry.Value)
+		}
+	}
+	return c.LoadFromEnv()
+}
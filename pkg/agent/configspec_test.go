@@ -0,0 +1,33 @@
+package agent
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestConfigSpecDescribesConfig(t *testing.T) {
+	// ConfigSpec panics if a described key has no field
+	spec := ConfigSpec()
+
+	described := make(map[string]bool)
+	for _, v := range spec.Vars() {
+		described[v.Key] = true
+	}
+	// Tables are described by the variables of their fields
+	described["resources"] = true
+
+	typ := reflect.TypeOf(Config{})
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		key, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if !field.IsExported() || key == "-" {
+			continue
+		}
+		if key == "" {
+			t.Errorf("Config.%s has no json key", field.Name)
+		} else if !described[key] {
+			t.Errorf("Config.%s (%q) is not described by ConfigSpec", field.Name, key)
+		}
+	}
+}
@@ -0,0 +1,505 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/logging"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/prompt"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/types"
+	"gopkg.in/yaml.v3"
+)
+
+// AgentFile describes an agent entirely in YAML so it can be run without writing Go code.
+// Values of the form ${VAR} are expanded from the environment before parsing.
+type AgentFile struct {
+	Agent     AgentSection     `yaml:"agent"`
+	LLM       LLMSection       `yaml:"llm"`
+	Prompts   PromptsSection   `yaml:"prompts"`
+	Tools     []ToolSection    `yaml:"tools"`
+	RateLimit RateLimitSection `yaml:"rate_limit"`
+	Network   NetworkSection   `yaml:"network"`
+	NFT       NFTSection       `yaml:"nft"`
+	Health    HealthSection    `yaml:"health"`
+	Redis     RedisSection     `yaml:"redis"`
+}
+
+// AgentSection holds the agent identity
+type AgentSection struct {
+	Name         string   `yaml:"name"`
+	Description  string   `yaml:"description"`
+	Version      string   `yaml:"version"`
+	Image        string   `yaml:"image"`
+	Capabilities []string `yaml:"capabilities"`
+	PrivateKey   string   `yaml:"private_key"` // Defaults to env PRIVATE_KEY
+	Room         string   `yaml:"room"`
+
+	Resources ResourcesSection `yaml:"resources"`
+}
+
+// ResourcesSection describes the hardware advertised for routing heavy jobs
+type ResourcesSection struct {
+	GPUs             []string `yaml:"gpus"` // e.g. "2x NVIDIA RTX 4090:24GB"
+	CPUCores         int      `yaml:"cpu_cores"`
+	Memory           string   `yaml:"memory"` // e.g. "64GB"
+	MaxContextTokens int      `yaml:"max_context_tokens"`
+}
+
+// LLMSection selects and configures the language model provider
+type LLMSection struct {
+	Provider    string  `yaml:"provider"` // "openai" (default) or "ollama"
+	Model       string  `yaml:"model"`
+	APIKey      string  `yaml:"api_key"`  // Defaults to env OPENAI_API_KEY for the openai provider
+	BaseURL     string  `yaml:"base_url"` // Server address for ollama / OpenAI-compatible endpoints
+	Temperature float32 `yaml:"temperature"`
+	MaxTokens   int     `yaml:"max_tokens"`
+	Streaming   bool    `yaml:"streaming"`
+
+	// Prices per million tokens, for the cost of each task's LLM usage
+	PromptTokenPrice     float64 `yaml:"prompt_token_price"`
+	CompletionTokenPrice float64 `yaml:"completion_token_price"`
+}
+
+// PromptsSection holds the prompts sent to the model
+type PromptsSection struct {
+	System string `yaml:"system"`
+}
+
+// ToolSection defines a command the agent handles without calling the model
+type ToolSection struct {
+	Name        string            `yaml:"name"`
+	Description string            `yaml:"description"`
+	Command     string            `yaml:"command"` // Trigger word, e.g. "price" matches "price btc"
+	Type        string            `yaml:"type"`    // "static" or "http"
+	Response    string            `yaml:"response"`
+	URL         string            `yaml:"url"`    // {input} is replaced with the URL-escaped arguments
+	Method      string            `yaml:"method"` // GET (default) or POST
+	Headers     map[string]string `yaml:"headers"`
+	Timeout     int               `yaml:"timeout"` // Seconds (defaults to 10)
+}
+
+// RateLimitSection configures request limits
+type RateLimitSection struct {
+	PerMinute int              `yaml:"per_minute"`
+	Burst     int              `yaml:"burst"` // Requests allowed at once (defaults to per_minute)
+	PerRoom   RateLimitBuckets `yaml:"per_room"`
+	PerSender RateLimitBuckets `yaml:"per_sender"`
+}
+
+// RateLimitBuckets configures the limit applied to each room or sender separately
+type RateLimitBuckets struct {
+	PerMinute int `yaml:"per_minute"`
+	Burst     int `yaml:"burst"`
+}
+
+// NetworkSection configures the Teneo network connection
+type NetworkSection struct {
+	WebSocketURL      string `yaml:"websocket_url"`
+	MaxReconnects     int    `yaml:"max_reconnects"`
+	ReconnectDelay    int    `yaml:"reconnect_delay"`     // Seconds
+	MaxReconnectDelay int    `yaml:"max_reconnect_delay"` // Seconds
+	ReconnectTimeout  int    `yaml:"reconnect_timeout"`   // Seconds spent reconnecting before giving up
+	TaskTimeout       int    `yaml:"task_timeout"`        // Seconds
+	MaxTasks          int    `yaml:"max_concurrent_tasks"`
+	DuplicatePolicy   string `yaml:"duplicate_policy"` // "alert", "yield" or "takeover"
+
+	// Task timeouts by capability in seconds, e.g. web_scrape: 300
+	CapabilityTimeouts map[string]int `yaml:"capability_timeouts"`
+	TaskMaxDuration    int            `yaml:"task_max_duration"` // Seconds a task may be extended to
+}
+
+// NFTSection configures the agent NFT
+type NFTSection struct {
+	Mode        string `yaml:"mode"` // "nft" (default), "wallet-only" or "anonymous"
+	TokenID     uint64 `yaml:"token_id"`
+	Mint        bool   `yaml:"mint"`
+	BackendURL  string `yaml:"backend_url"`
+	RPCEndpoint string `yaml:"rpc_endpoint"`
+}
+
+// HealthSection configures the health server
+type HealthSection struct {
+	Enabled *bool `yaml:"enabled"`
+	Port    int   `yaml:"port"`
+}
+
+// RedisSection configures the Redis cache
+type RedisSection struct {
+	Enabled   bool   `yaml:"enabled"`
+	Address   string `yaml:"address"`
+	Username  string `yaml:"username"`
+	Password  string `yaml:"password"`
+	DB        int    `yaml:"db"`
+	KeyPrefix string `yaml:"key_prefix"`
+	UseTLS    bool   `yaml:"use_tls"`
+}
+
+// LoadAgentFile reads and parses an agent definition from a YAML file
+func LoadAgentFile(path string) (*AgentFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read agent file: %w", err)
+	}
+	return ParseAgentFile(data)
+}
+
+// ParseAgentFile parses an agent definition from YAML
+func ParseAgentFile(data []byte) (*AgentFile, error) {
+	var file AgentFile
+	if err := yaml.Unmarshal([]byte(os.ExpandEnv(string(data))), &file); err != nil {
+		return nil, fmt.Errorf("failed to parse agent file: %w", err)
+	}
+	if err := file.Validate(); err != nil {
+		return nil, err
+	}
+	return &file, nil
+}
+
+// Validate checks the agent definition for missing or unsupported values
+func (f *AgentFile) Validate() error {
+	if f.Agent.Name == "" {
+		return fmt.Errorf("agent.name is required")
+	}
+
+	switch strings.ToLower(f.LLM.Provider) {
+	case "", "openai", "ollama":
+	default:
+		return fmt.Errorf("unsupported llm.provider: %s", f.LLM.Provider)
+	}
+
+	seen := make(map[string]bool)
+	for i, tool := range f.Tools {
+		if tool.Command == "" {
+			return fmt.Errorf("tools[%d].command is required", i)
+		}
+		command := strings.ToLower(tool.Command)
+		if seen[command] {
+			return fmt.Errorf("duplicate tool command: %s", tool.Command)
+		}
+		seen[command] = true
+
+		switch strings.ToLower(tool.Type) {
+		case "static":
+			if tool.Response == "" {
+				return fmt.Errorf("tools[%d].response is required for static tools", i)
+			}
+		case "http":
+			if tool.URL == "" {
+				return fmt.Errorf("tools[%d].url is required for http tools", i)
+			}
+		default:
+			return fmt.Errorf("tools[%d] has unsupported type: %s", i, tool.Type)
+		}
+	}
+
+	if _, err := f.Agent.Resources.computeResources(); err != nil {
+		return fmt.Errorf("invalid agent.resources: %w", err)
+	}
+	return nil
+}
+
+// computeResources converts the section to the advertised resources (nil if empty)
+func (s ResourcesSection) computeResources() (*types.ComputeResources, error) {
+	resources := &types.ComputeResources{
+		CPUCores:         s.CPUCores,
+		MaxContextTokens: s.MaxContextTokens,
+	}
+	gpus, err := types.ParseGPUs(strings.Join(s.GPUs, ","))
+	if err != nil {
+		return nil, err
+	}
+	resources.GPUs = gpus
+	if s.Memory != "" {
+		if resources.MemoryMB, err = types.ParseMemoryMB(s.Memory); err != nil {
+			return nil, err
+		}
+	}
+
+	if resources.IsZero() {
+		return nil, nil
+	}
+	return resources, nil
+}
+
+// NewConfiguredAgent builds a ready-to-run agent from a YAML agent file
+func NewConfiguredAgent(path string) (*EnhancedAgent, error) {
+	file, err := LoadAgentFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return NewAgentFromFile(file)
+}
+
+// NewAgentFromFile builds a ready-to-run agent from a parsed agent definition
+func NewAgentFromFile(file *AgentFile) (*EnhancedAgent, error) {
+	sdkConfig := DefaultConfig()
+	if err := sdkConfig.LoadFromEnv(); err != nil {
+		return nil, fmt.Errorf("failed to load environment: %w", err)
+	}
+	file.applyTo(sdkConfig)
+
+	mode := os.Getenv("IDENTITY_MODE")
+	if file.NFT.Mode != "" {
+		mode = file.NFT.Mode
+	}
+	identityMode, err := parseIdentityMode(mode)
+	if err != nil {
+		return nil, fmt.Errorf("invalid nft.mode: %w", err)
+	}
+	if sdkConfig.PrivateKey == "" && identityMode != IdentityModeAnonymous {
+		return nil, fmt.Errorf("agent.private_key is required (or set PRIVATE_KEY environment variable)")
+	}
+
+	llmHandler, err := file.newLLMHandler()
+	if err != nil {
+		return nil, err
+	}
+
+	tokenID := file.NFT.TokenID
+	mint := file.NFT.Mint
+	if identityMode.UsesNFT() {
+		resolveNFTSettings(&tokenID, &mint)
+	}
+	if tokenID > 0 {
+		sdkConfig.NFTTokenID = fmt.Sprintf("%d", tokenID)
+	}
+
+	handler := NewConfiguredHandler(llmHandler, file.Tools)
+	logging.Info("loaded configured agent", "agent", sdkConfig.Name, "tools", len(file.Tools))
+
+	enhancedAgent, err := NewEnhancedAgent(&EnhancedAgentConfig{
+		Config:       sdkConfig,
+		AgentHandler: handler,
+		IdentityMode: identityMode,
+		Mint:         mint,
+		TokenID:      tokenID,
+		BackendURL:   file.NFT.BackendURL,
+		RPCEndpoint:  file.NFT.RPCEndpoint,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create enhanced agent: %w", err)
+	}
+
+	return enhancedAgent, nil
+}
+
+// RunConfiguredAgent loads an agent file and runs the agent until interrupted
+func RunConfiguredAgent(path string) error {
+	enhancedAgent, err := NewConfiguredAgent(path)
+	if err != nil {
+		return err
+	}
+	return enhancedAgent.Run()
+}
+
+// applyTo copies the file settings onto an SDK config, keeping defaults for unset values
+func (f *AgentFile) applyTo(c *Config) {
+	c.Name = f.Agent.Name
+	if f.Agent.Description != "" {
+		c.Description = f.Agent.Description
+	}
+	if f.Agent.Version != "" {
+		c.Version = f.Agent.Version
+	}
+	if f.Agent.Image != "" {
+		c.Image = f.Agent.Image
+	}
+	if len(f.Agent.Capabilities) > 0 {
+		c.Capabilities = f.Agent.Capabilities
+	}
+	if f.Agent.PrivateKey != "" {
+		c.PrivateKey = f.Agent.PrivateKey
+	}
+	if f.Agent.Room != "" {
+		c.Room = f.Agent.Room
+	}
+	if resources, err := f.Agent.Resources.computeResources(); err == nil && resources != nil {
+		c.Resources = resources
+	}
+
+	if f.Prompts.System != "" {
+		c.SystemPrompt = f.Prompts.System
+	}
+
+	if f.RateLimit.PerMinute > 0 {
+		c.RateLimitPerMinute = f.RateLimit.PerMinute
+	}
+	if f.RateLimit.Burst > 0 {
+		c.RateLimitBurst = f.RateLimit.Burst
+	}
+	if f.RateLimit.PerRoom.PerMinute > 0 {
+		c.RoomRateLimitPerMinute = f.RateLimit.PerRoom.PerMinute
+		c.RoomRateLimitBurst = f.RateLimit.PerRoom.Burst
+	}
+	if f.RateLimit.PerSender.PerMinute > 0 {
+		c.SenderRateLimitPerMinute = f.RateLimit.PerSender.PerMinute
+		c.SenderRateLimitBurst = f.RateLimit.PerSender.Burst
+	}
+
+	if f.Network.WebSocketURL != "" {
+		c.WebSocketURL = f.Network.WebSocketURL
+	}
+	if f.Network.MaxReconnects > 0 {
+		c.MaxReconnects = f.Network.MaxReconnects
+	}
+	if f.Network.ReconnectDelay > 0 {
+		c.ReconnectDelay = time.Duration(f.Network.ReconnectDelay) * time.Second
+	}
+	if f.Network.MaxReconnectDelay > 0 {
+		c.ReconnectMaxDelay = time.Duration(f.Network.MaxReconnectDelay) * time.Second
+	}
+	if f.Network.ReconnectTimeout > 0 {
+		c.ReconnectMaxElapsed = time.Duration(f.Network.ReconnectTimeout) * time.Second
+	}
+	if f.Network.TaskTimeout > 0 {
+		c.TaskTimeout = f.Network.TaskTimeout
+	}
+	if len(f.Network.CapabilityTimeouts) > 0 {
+		c.CapabilityTimeouts = make(map[string]time.Duration, len(f.Network.CapabilityTimeouts))
+		for capability, seconds := range f.Network.CapabilityTimeouts {
+			c.CapabilityTimeouts[capability] = time.Duration(seconds) * time.Second
+		}
+	}
+	if f.Network.TaskMaxDuration > 0 {
+		c.TaskMaxDuration = time.Duration(f.Network.TaskMaxDuration) * time.Second
+	}
+	if f.Network.MaxTasks > 0 {
+		c.MaxConcurrentTasks = f.Network.MaxTasks
+	}
+	if f.Network.DuplicatePolicy != "" {
+		c.DuplicatePolicy = f.Network.DuplicatePolicy
+	}
+
+	if f.Health.Enabled != nil {
+		c.HealthEnabled = *f.Health.Enabled
+	}
+	if f.Health.Port > 0 {
+		c.HealthPort = f.Health.Port
+	}
+
+	if f.Redis.Enabled {
+		c.RedisEnabled = true
+		if f.Redis.Address != "" {
+			c.RedisAddress = f.Redis.Address
+		}
+		c.RedisUsername = f.Redis.Username
+		c.RedisPassword = f.Redis.Password
+		c.RedisDB = f.Redis.DB
+		c.RedisKeyPrefix = f.Redis.KeyPrefix
+		c.RedisUseTLS = f.Redis.UseTLS
+	}
+}
+
+// newLLMHandler creates the model-backed handler selected by the llm section
+func (f *AgentFile) newLLMHandler() (types.AgentHandler, error) {
+	switch strings.ToLower(f.LLM.Provider) {
+	case "ollama":
+		return NewOllamaAgent(&OllamaConfig{
+			BaseURL:      f.LLM.BaseURL,
+			APIKey:       f.LLM.APIKey,
+			Model:        f.LLM.Model,
+			SystemPrompt: f.Prompts.System,
+			Temperature:  f.LLM.Temperature,
+			MaxTokens:    f.LLM.MaxTokens,
+			Streaming:    f.LLM.Streaming,
+		}), nil
+	default:
+		apiKey := f.LLM.APIKey
+		if apiKey == "" {
+			apiKey = os.Getenv("OPENAI_API_KEY")
+		}
+		if apiKey == "" {
+			return nil, fmt.Errorf("llm.api_key is required (or set OPENAI_API_KEY environment variable)")
+		}
+		return NewOpenAIAgent(&OpenAIConfig{
+			APIKey:               apiKey,
+			Model:                f.LLM.Model,
+			SystemPrompt:         f.Prompts.System,
+			Temperature:          f.LLM.Temperature,
+			MaxTokens:            f.LLM.MaxTokens,
+			Streaming:            f.LLM.Streaming,
+			PromptTokenPrice:     f.LLM.PromptTokenPrice,
+			CompletionTokenPrice: f.LLM.CompletionTokenPrice,
+		}), nil
+	}
+}
+
+// ConfiguredHandler routes tasks to configured tools and falls back to the language model
+type ConfiguredHandler struct {
+	llm   types.AgentHandler
+	tools map[string]ToolSection
+}
+
+// NewConfiguredHandler creates a handler that serves the given tools before asking the model
+func NewConfiguredHandler(llm types.AgentHandler, tools []ToolSection) *ConfiguredHandler {
+	toolMap := make(map[string]ToolSection, len(tools))
+	for _, tool := range tools {
+		toolMap[strings.ToLower(tool.Command)] = tool
+	}
+	return &ConfiguredHandler{
+		llm:   llm,
+		tools: toolMap,
+	}
+}
+
+// ProcessTask implements the AgentHandler interface
+func (h *ConfiguredHandler) ProcessTask(ctx context.Context, task string) (string, error) {
+	if tool, args, ok := h.matchTool(task); ok {
+		return runTool(ctx, tool, args)
+	}
+	if h.llm == nil {
+		return "", fmt.Errorf("no tool matches the request and no language model is configured")
+	}
+	return h.llm.ProcessTask(ctx, task)
+}
+
+// ProcessTaskWithStreaming implements the StreamingTaskHandler interface
+func (h *ConfiguredHandler) ProcessTaskWithStreaming(ctx context.Context, task string, room string, sender types.MessageSender) error {
+	if streamer, ok := h.llm.(types.StreamingTaskHandler); ok {
+		if _, _, isTool := h.matchTool(task); !isTool {
+			return streamer.ProcessTaskWithStreaming(ctx, task, room, sender)
+		}
+	}
+
+	result, err := h.ProcessTask(ctx, task)
+	if err != nil {
+		return err
+	}
+	return sender.SendMessage(result)
+}
+
+// PromptLibrary implements the PromptLibraryProvider interface with the
+// language model handler's prompt templates, nil if it has none
+func (h *ConfiguredHandler) PromptLibrary() *prompt.Library {
+	if provider, ok := h.llm.(PromptLibraryProvider); ok {
+		return provider.PromptLibrary()
+	}
+	return nil
+}
+
+// ConfigChanged implements the ConfigChangeHandler interface by passing the
+// change on to the language model handler
+func (h *ConfiguredHandler) ConfigChanged(ctx context.Context, old, updated *Config) error {
+	if changer, ok := h.llm.(ConfigChangeHandler); ok {
+		return changer.ConfigChanged(ctx, old, updated)
+	}
+	return nil
+}
+
+// matchTool finds the tool whose command is the first word of the task
+func (h *ConfiguredHandler) matchTool(task string) (ToolSection, string, bool) {
+	fields := strings.Fields(strings.TrimSpace(task))
+	if len(fields) == 0 {
+		return ToolSection{}, "", false
+	}
+
+	command := strings.ToLower(strings.TrimPrefix(fields[0], "/"))
+	tool, ok := h.tools[command]
+	if !ok {
+		return ToolSection{}, "", false
+	}
+	return tool, strings.Join(fields[1:], " "), true
+}
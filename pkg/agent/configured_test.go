@@ -0,0 +1,89 @@
+package agent
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeAgentFile writes an agent file to a temporary directory and returns its path
+func writeAgentFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "agent.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestConfiguredAgent(t *testing.T) {
+	t.Chdir(t.TempDir())
+	server := newDevServer(t)
+	t.Setenv("TEST_WEBSOCKET_URL", server.URL())
+	t.Setenv("LOG_LEVEL", "error")
+
+	agent, err := NewConfiguredAgent(writeAgentFile(t, `
+agent:
+  name: Configured Agent
+llm:
+  provider: ollama
+tools:
+  - name: Ping
+    command: ping
+    type: static
+    response: pong
+network:
+  websocket_url: ${TEST_WEBSOCKET_URL}
+nft:
+  mode: anonymous
+health:
+  enabled: false
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := agent.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer agent.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if _, err := server.WaitForAgent(ctx, "Configured Agent"); err != nil {
+		t.Fatal(err)
+	}
+	if answer := ask(t, server, "Configured Agent", "/ping now"); answer != "pong" {
+		t.Errorf("ping answered %q, want pong", answer)
+	}
+}
+
+func TestConfiguredAgentRejectsInvalidFiles(t *testing.T) {
+	t.Setenv("IDENTITY_MODE", "")
+	t.Setenv("PRIVATE_KEY", "")
+	t.Setenv("OPENAI_API_KEY", "")
+
+	tests := map[string]string{
+		"no name":              "agent: {description: nameless}",
+		"unsupported provider": "agent: {name: a}\nllm: {provider: mystery}",
+		"tool without command": "agent: {name: a}\ntools: [{type: static, response: x}]",
+		"duplicate command":    "agent: {name: a}\ntools: [{command: ping, type: static, response: x}, {command: PING, type: static, response: y}]",
+		"static without reply": "agent: {name: a}\ntools: [{command: ping, type: static}]",
+		"http without url":     "agent: {name: a}\ntools: [{command: ping, type: http}]",
+		"unknown tool type":    "agent: {name: a}\ntools: [{command: ping, type: shell}]",
+		"invalid resources":    "agent: {name: a, resources: {memory: lots}}",
+		"invalid identity":     "agent: {name: a}\nnft: {mode: ghost}",
+		"no private key":       "agent: {name: a}\nllm: {provider: ollama}\nnft: {mode: wallet-only}",
+		"no api key":           "agent: {name: a}\nnft: {mode: anonymous}",
+		"not yaml":             "agent: [",
+	}
+	for name, content := range tests {
+		if _, err := NewConfiguredAgent(writeAgentFile(t, content)); err == nil {
+			t.Errorf("%s: agent created", name)
+		}
+	}
+	if _, err := NewConfiguredAgent(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Error("missing file: agent created")
+	}
+}
@@ -0,0 +1,75 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// maxToolResponseSize limits how much of an HTTP tool response is returned
+const maxToolResponseSize = 64 * 1024
+
+// runTool executes a configured tool with the arguments that followed its command
+func runTool(ctx context.Context, tool ToolSection, args string) (string, error) {
+	switch strings.ToLower(tool.Type) {
+	case "static":
+		return strings.ReplaceAll(tool.Response, "{input}", args), nil
+	case "http":
+		return runHTTPTool(ctx, tool, args)
+	default:
+		return "", fmt.Errorf("unsupported tool type: %s", tool.Type)
+	}
+}
+
+// runHTTPTool calls the tool URL and returns the response body
+func runHTTPTool(ctx context.Context, tool ToolSection, args string) (string, error) {
+	timeout := time.Duration(tool.Timeout) * time.Second
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	method := strings.ToUpper(tool.Method)
+	if method == "" {
+		method = http.MethodGet
+	}
+
+	var body io.Reader
+	if method != http.MethodGet {
+		body = strings.NewReader(args)
+	}
+
+	target := strings.ReplaceAll(tool.URL, "{input}", url.QueryEscape(args))
+	req, err := http.NewRequestWithContext(ctx, method, target, body)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request for tool %s: %w", tool.Name, err)
+	}
+	for key, value := range tool.Headers {
+		req.Header.Set(key, value)
+	}
+	if body != nil && req.Header.Get("Content-Type") == "" {
+		req.Header.Set("Content-Type", "text/plain")
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("tool %s request failed: %w", tool.Name, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxToolResponseSize))
+	if err != nil {
+		return "", fmt.Errorf("failed to read tool %s response: %w", tool.Name, err)
+	}
+
+	if resp.StatusCode >= 400 {
+		return "", fmt.Errorf("tool %s returned status %d: %s", tool.Name, resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+
+	return string(data), nil
+}
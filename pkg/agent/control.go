@@ -0,0 +1,449 @@
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"runtime"
+	"sort"
+	"time"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/internal/httputil"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/bandwidth"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/logging"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/network"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/ratelimit"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/types"
+)
+
+// ControlPathPrefix is the path under which the control API is served
+const ControlPathPrefix = "/control/"
+
+// controlTask is an active task in the control API
+type controlTask struct {
+	ID        string              `json:"id"`
+	StartTime time.Time           `json:"start_time"`
+	Runtime   string              `json:"runtime"`
+	Progress  *types.TaskProgress `json:"progress,omitempty"`
+}
+
+// controlHealth is the connection health reported by the control API
+type controlHealth struct {
+	Connected      bool                             `json:"connected"`
+	Authenticated  bool                             `json:"authenticated"`
+	AuthState      network.AuthState                `json:"auth_state"`
+	SessionExpires *time.Time                       `json:"session_expires_at,omitempty"`
+	ActiveTasks    int                              `json:"active_tasks"`
+	Uptime         string                           `json:"uptime"`
+	QueueDepth     int                              `json:"queue_depth"`
+	Connection     controlConnection                `json:"connection"`
+	CircuitBreaker controlCircuitBreaker            `json:"circuit_breaker"`  // The breaker in the worst state
+	Breakers       map[string]controlCircuitBreaker `json:"circuit_breakers"` // By message class
+	RetryQueue     controlRetryQueue                `json:"retry_queue"`
+	Goroutines     map[string]controlRoutine        `json:"goroutines"`
+	Backpressure   network.BackpressureStats        `json:"backpressure"`
+	Buffers        network.BufferStats              `json:"buffers"`
+}
+
+// controlConnection summarizes network.ConnectionMetrics
+type controlConnection struct {
+	SentMessages         int64  `json:"sent_messages"`
+	ReceivedMessages     int64  `json:"received_messages"`
+	FailedMessages       int64  `json:"failed_messages"`
+	ReconnectAttempts    int64  `json:"reconnect_attempts"`
+	SuccessfulReconnects int64  `json:"successful_reconnects"`
+	ConsecutiveErrors    int    `json:"consecutive_errors"`
+	AverageLatency       string `json:"average_latency"`
+	LastError            string `json:"last_error,omitempty"`
+}
+
+// controlCircuitBreaker summarizes network.CircuitBreakerStats
+type controlCircuitBreaker struct {
+	State            string     `json:"state"`
+	Failures         int        `json:"failures"`
+	HalfOpenAttempts int        `json:"half_open_attempts"`
+	LastFailure      *time.Time `json:"last_failure,omitempty"`
+}
+
+// newControlCircuitBreaker summarizes the stats of a circuit breaker
+func newControlCircuitBreaker(stats network.CircuitBreakerStats) controlCircuitBreaker {
+	breaker := controlCircuitBreaker{
+		State:            stats.State.String(),
+		Failures:         stats.Failures,
+		HalfOpenAttempts: stats.HalfOpenAttempts,
+	}
+	if !stats.LastFailTime.IsZero() {
+		breaker.LastFailure = &stats.LastFailTime
+	}
+	return breaker
+}
+
+// controlRetryQueue summarizes network.RetryMetrics
+type controlRetryQueue struct {
+	Size              int   `json:"size"`
+	TotalRetries      int64 `json:"total_retries"`
+	SuccessfulRetries int64 `json:"successful_retries"`
+	FailedRetries     int64 `json:"failed_retries"`
+	DroppedMessages   int64 `json:"dropped_messages"`
+	Duplicates        int64 `json:"duplicates"`
+	DeadLetters       int   `json:"dead_letters"`
+	DeadLettered      int64 `json:"dead_lettered"`
+}
+
+// controlRoutine summarizes network.GoroutineStatus
+type controlRoutine struct {
+	State         string `json:"state"` // running, restarting, stopped or failed
+	Running       bool   `json:"running"`
+	RestartCount  int    `json:"restart_count"`
+	TotalRestarts int    `json:"total_restarts"`
+	LastError     string `json:"last_error,omitempty"`
+}
+
+// healthSnapshot is the result of the health_snapshot operator command
+type healthSnapshot struct {
+	Health     controlHealth `json:"health"`
+	Tasks      []controlTask `json:"tasks"`
+	Goroutines int           `json:"goroutine_count"`
+	Memory     controlMemory `json:"memory"`
+	TakenAt    time.Time     `json:"taken_at"`
+}
+
+// controlMemory summarizes runtime.MemStats
+type controlMemory struct {
+	HeapAllocMB float64 `json:"heap_alloc_mb"`
+	SysMB       float64 `json:"sys_mb"`
+	NumGC       uint32  `json:"num_gc"`
+}
+
+// capabilitiesRequest is the request body for replacing the agent's capabilities
+type capabilitiesRequest struct {
+	Capabilities []string `json:"capabilities"`
+}
+
+// rateLimitRequest is the request body for changing the rate limits;
+// omitted fields keep their current value
+type rateLimitRequest struct {
+	PerMinute *int             `json:"per_minute"`
+	Burst     *int             `json:"burst"`
+	PerRoom   *ratelimit.Limit `json:"per_room"`
+	PerSender *ratelimit.Limit `json:"per_sender"`
+}
+
+// apply returns limits with the fields set in the request replaced, false if
+// the request sets nothing or a negative value
+func (r rateLimitRequest) apply(limits ratelimit.Config) (ratelimit.Config, bool) {
+	if r.PerMinute == nil && r.Burst == nil && r.PerRoom == nil && r.PerSender == nil {
+		return limits, false
+	}
+	if r.PerMinute != nil {
+		limits.Global.PerMinute = *r.PerMinute
+	}
+	if r.Burst != nil {
+		limits.Global.Burst = *r.Burst
+	}
+	if r.PerRoom != nil {
+		limits.PerRoom = *r.PerRoom
+	}
+	if r.PerSender != nil {
+		limits.PerSender = *r.PerSender
+	}
+	for _, limit := range []ratelimit.Limit{limits.Global, limits.PerRoom, limits.PerSender} {
+		if limit.PerMinute < 0 || limit.Burst < 0 {
+			return limits, false
+		}
+	}
+	return limits, true
+}
+
+// rateLimitResponse reports the current rate limits
+func rateLimitResponse(limits ratelimit.Config) rateLimitRequest {
+	return rateLimitRequest{
+		PerMinute: &limits.Global.PerMinute,
+		Burst:     &limits.Global.Burst,
+		PerRoom:   &limits.PerRoom,
+		PerSender: &limits.PerSender,
+	}
+}
+
+// controlBandwidth is the traffic accounted per room and counterparty with the room ceilings
+type controlBandwidth struct {
+	bandwidth.Snapshot
+	RoomCeiling  bandwidth.Ceiling            `json:"room_ceiling"`
+	RoomCeilings map[string]bandwidth.Ceiling `json:"room_ceilings"` // Rooms with their own ceiling
+}
+
+// ControlHandler returns an HTTP handler for operating the running agent without
+// restarting it. Every request must carry "Authorization: Bearer <token>".
+//
+// Endpoints:
+//
+//	GET  /control/tasks              - active tasks and their progress
+//	POST /control/tasks/{id}/cancel  - cancel an active task
+//	GET  /control/capabilities       - current capabilities
+//	PUT  /control/capabilities       - replace capabilities ({"capabilities": [...]}) and announce them
+//	POST /control/reauth             - authenticate and register again
+//	GET  /control/health             - connection, circuit breaker, retry queue and goroutine stats
+//	GET  /control/jobs               - scheduled jobs with their next and last run
+//	GET  /control/rate-limit         - current rate limit
+//	PUT  /control/rate-limit         - change the rate limit ({"per_minute": n}, 0 = unlimited)
+//	GET  /control/bandwidth          - bytes sent and received per room and counterparty, room ceilings
+//	PUT  /control/bandwidth/{room}   - set a room's ceiling ({"per_minute": bytes, "burst": bytes}, {} = default)
+//	GET    /control/dead-letters              - messages the retry queue gave up on, oldest first
+//	GET    /control/dead-letters/{id}         - a dead letter with its message
+//	POST   /control/dead-letters/{id}/requeue - send a dead letter again
+//	DELETE /control/dead-letters/{id}         - delete a dead letter
+//	DELETE /control/dead-letters              - delete all dead letters
+func (a *EnhancedAgent) ControlHandler(token string) http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /control/tasks", func(w http.ResponseWriter, req *http.Request) {
+		httputil.WriteJSON(w, http.StatusOK, a.controlTasks())
+	})
+
+	mux.HandleFunc("POST /control/tasks/{id}/cancel", func(w http.ResponseWriter, req *http.Request) {
+		id := req.PathValue("id")
+		if !a.taskCoordinator.CancelTask(id) {
+			httputil.WriteError(w, http.StatusNotFound, fmt.Sprintf("task %s is not active", id))
+			return
+		}
+		httputil.WriteJSON(w, http.StatusOK, map[string]string{"status": "cancelled"})
+	})
+
+	mux.HandleFunc("GET /control/capabilities", func(w http.ResponseWriter, req *http.Request) {
+		httputil.WriteJSON(w, http.StatusOK, capabilitiesRequest{Capabilities: a.Capabilities()})
+	})
+
+	mux.HandleFunc("PUT /control/capabilities", func(w http.ResponseWriter, req *http.Request) {
+		var body capabilitiesRequest
+		if err := json.NewDecoder(req.Body).Decode(&body); err != nil || len(body.Capabilities) == 0 {
+			httputil.WriteError(w, http.StatusBadRequest, "invalid request body")
+			return
+		}
+		if err := types.ValidateCapabilities(body.Capabilities); err != nil {
+			httputil.WriteError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		if err := a.UpdateCapabilities(body.Capabilities); err != nil {
+			httputil.WriteError(w, http.StatusBadGateway, fmt.Sprintf("capabilities updated but not announced: %v", err))
+			return
+		}
+		httputil.WriteJSON(w, http.StatusOK, body)
+	})
+
+	mux.HandleFunc("POST /control/reauth", func(w http.ResponseWriter, req *http.Request) {
+		if err := a.Reauthenticate(); err != nil {
+			httputil.WriteError(w, http.StatusServiceUnavailable, err.Error())
+			return
+		}
+		httputil.WriteJSON(w, http.StatusAccepted, map[string]string{"status": "authenticating"})
+	})
+
+	mux.HandleFunc("GET /control/health", func(w http.ResponseWriter, req *http.Request) {
+		httputil.WriteJSON(w, http.StatusOK, a.controlHealth())
+	})
+
+	mux.HandleFunc("GET /control/jobs", func(w http.ResponseWriter, req *http.Request) {
+		httputil.WriteJSON(w, http.StatusOK, a.Jobs())
+	})
+
+	mux.HandleFunc("GET /control/rate-limit", func(w http.ResponseWriter, req *http.Request) {
+		httputil.WriteJSON(w, http.StatusOK, rateLimitResponse(a.taskCoordinator.GetRateLimits()))
+	})
+
+	mux.HandleFunc("PUT /control/rate-limit", func(w http.ResponseWriter, req *http.Request) {
+		var body rateLimitRequest
+		if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+			httputil.WriteError(w, http.StatusBadRequest, "invalid request body")
+			return
+		}
+
+		// Serialized with configuration reloads, which change the same settings
+		a.reloadMu.Lock()
+		limits, ok := body.apply(a.taskCoordinator.GetRateLimits())
+		if ok {
+			a.setRateLimits(limits)
+		}
+		a.reloadMu.Unlock()
+		if !ok {
+			httputil.WriteError(w, http.StatusBadRequest, "invalid request body")
+			return
+		}
+		httputil.WriteJSON(w, http.StatusOK, rateLimitResponse(limits))
+	})
+
+	mux.HandleFunc("GET /control/bandwidth", func(w http.ResponseWriter, req *http.Request) {
+		meter := a.networkClient.Bandwidth()
+		ceiling, rooms := meter.Ceilings()
+		httputil.WriteJSON(w, http.StatusOK, controlBandwidth{Snapshot: meter.Snapshot(), RoomCeiling: ceiling, RoomCeilings: rooms})
+	})
+
+	mux.HandleFunc("PUT /control/bandwidth/{room}", func(w http.ResponseWriter, req *http.Request) {
+		var ceiling bandwidth.Ceiling
+		if err := json.NewDecoder(req.Body).Decode(&ceiling); err != nil || ceiling.PerMinute < 0 || ceiling.Burst < 0 {
+			httputil.WriteError(w, http.StatusBadRequest, "invalid request body")
+			return
+		}
+		a.networkClient.Bandwidth().SetRoomCeiling(req.PathValue("room"), ceiling)
+		httputil.WriteJSON(w, http.StatusOK, ceiling)
+	})
+
+	mux.HandleFunc("GET /control/dead-letters", func(w http.ResponseWriter, req *http.Request) {
+		httputil.WriteJSON(w, http.StatusOK, a.networkClient.DeadLetters())
+	})
+
+	mux.HandleFunc("GET /control/dead-letters/{id}", func(w http.ResponseWriter, req *http.Request) {
+		letter, ok := a.networkClient.DeadLetter(req.PathValue("id"))
+		if !ok {
+			httputil.WriteError(w, http.StatusNotFound, fmt.Sprintf("dead letter %s not found", req.PathValue("id")))
+			return
+		}
+		httputil.WriteJSON(w, http.StatusOK, letter)
+	})
+
+	mux.HandleFunc("POST /control/dead-letters/{id}/requeue", func(w http.ResponseWriter, req *http.Request) {
+		if err := a.networkClient.RequeueDeadLetter(req.PathValue("id")); err != nil {
+			httputil.WriteError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		httputil.WriteJSON(w, http.StatusAccepted, map[string]string{"status": "requeued"})
+	})
+
+	mux.HandleFunc("DELETE /control/dead-letters/{id}", func(w http.ResponseWriter, req *http.Request) {
+		if a.networkClient.PurgeDeadLetters(req.PathValue("id")) == 0 {
+			httputil.WriteError(w, http.StatusNotFound, fmt.Sprintf("dead letter %s not found", req.PathValue("id")))
+			return
+		}
+		httputil.WriteJSON(w, http.StatusOK, map[string]int{"purged": 1})
+	})
+
+	mux.HandleFunc("DELETE /control/dead-letters", func(w http.ResponseWriter, req *http.Request) {
+		httputil.WriteJSON(w, http.StatusOK, map[string]int{"purged": a.networkClient.PurgeDeadLetters()})
+	})
+
+	return httputil.RequireToken(token, mux)
+}
+
+// Reauthenticate drops the current session and runs the challenge-response
+// authentication and registration again, e.g. when the server lost the session
+func (a *EnhancedAgent) Reauthenticate() error {
+	if !a.networkClient.IsConnected() {
+		return fmt.Errorf("not connected to the network")
+	}
+
+	logging.Info("re-authenticating agent")
+	a.networkClient.SetAuthenticated(false)
+	if err := a.protocolHandler.StartAuthentication(); err != nil {
+		return fmt.Errorf("failed to start authentication: %w", err)
+	}
+	return nil
+}
+
+// HandleOperatorCommand registers an operator command sent over the network
+// by an operator wallet; it only runs when OperatorCommands is enabled
+func (a *EnhancedAgent) HandleOperatorCommand(name string, fn network.OperatorCommandFunc) {
+	a.protocolHandler.HandleOperatorCommand(name, fn)
+}
+
+// controlTasks returns the active tasks, oldest first
+func (a *EnhancedAgent) controlTasks() []controlTask {
+	progress := make(map[string]types.TaskProgress)
+	for _, p := range a.taskCoordinator.GetTaskProgress() {
+		progress[p.TaskID] = p
+	}
+
+	tasks := make([]controlTask, 0)
+	for id, execution := range a.taskCoordinator.GetActiveTasks() {
+		task := controlTask{
+			ID:        id,
+			StartTime: execution.StartTime,
+			Runtime:   time.Since(execution.StartTime).Round(time.Millisecond).String(),
+		}
+		if p, ok := progress[id]; ok {
+			task.Progress = &p
+		}
+		tasks = append(tasks, task)
+	}
+	sort.Slice(tasks, func(i, j int) bool { return tasks[i].StartTime.Before(tasks[j].StartTime) })
+	return tasks
+}
+
+// controlHealth collects the connection health of the agent
+func (a *EnhancedAgent) controlHealth() controlHealth {
+	client := a.networkClient
+	metrics := client.GetConnectionMetrics()
+	retries := client.GetRetryQueueMetrics()
+
+	health := controlHealth{
+		Connected:     client.IsConnected(),
+		Authenticated: client.IsAuthenticated(),
+		AuthState:     a.protocolHandler.AuthState(),
+		ActiveTasks:   a.taskCoordinator.GetActiveTaskCount(),
+		Uptime:        a.GetUptime().Round(time.Second).String(),
+		QueueDepth:    client.QueueDepth(),
+		Connection: controlConnection{
+			SentMessages:         metrics.SentMessages,
+			ReceivedMessages:     metrics.ReceivedMessages,
+			FailedMessages:       metrics.FailedMessages,
+			ReconnectAttempts:    metrics.ReconnectAttempts,
+			SuccessfulReconnects: metrics.SuccessfulReconnects,
+			ConsecutiveErrors:    metrics.ConsecutiveErrors,
+			AverageLatency:       metrics.AverageLatency.String(),
+		},
+		CircuitBreaker: newControlCircuitBreaker(client.GetCircuitBreakerStats()),
+		Breakers:       make(map[string]controlCircuitBreaker),
+		RetryQueue: controlRetryQueue{
+			Size:              retries.CurrentQueueSize,
+			TotalRetries:      retries.TotalRetries,
+			SuccessfulRetries: retries.SuccessfulRetries,
+			FailedRetries:     retries.FailedRetries,
+			DroppedMessages:   retries.DroppedMessages,
+			Duplicates:        retries.Duplicates,
+			DeadLetters:       retries.DeadLetters,
+			DeadLettered:      retries.DeadLettered,
+		},
+		Goroutines:   make(map[string]controlRoutine),
+		Backpressure: a.taskCoordinator.GetBackpressureStats(),
+		Buffers:      client.BufferStats(),
+	}
+	if metrics.LastError != nil {
+		health.Connection.LastError = metrics.LastError.Error()
+	}
+	for class, stats := range client.GetCircuitBreakerStatsByClass() {
+		health.Breakers[class] = newControlCircuitBreaker(stats)
+	}
+	if expires := a.protocolHandler.SessionExpiresAt(); !expires.IsZero() {
+		health.SessionExpires = &expires
+	}
+	for id, status := range client.GetSupervisorStatus() {
+		routine := controlRoutine{
+			State:         string(status.State),
+			Running:       status.Running,
+			RestartCount:  status.RestartCount,
+			TotalRestarts: status.TotalRestarts,
+		}
+		if status.LastError != nil {
+			routine.LastError = status.LastError.Error()
+		}
+		health.Goroutines[id] = routine
+	}
+	return health
+}
+
+// healthSnapshot collects connection health, active tasks and runtime stats
+// for the operator
+func (a *EnhancedAgent) healthSnapshot() healthSnapshot {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	return healthSnapshot{
+		Health:     a.controlHealth(),
+		Tasks:      a.controlTasks(),
+		Goroutines: runtime.NumGoroutine(),
+		Memory: controlMemory{
+			HeapAllocMB: float64(mem.HeapAlloc) / (1 << 20),
+			SysMB:       float64(mem.Sys) / (1 << 20),
+			NumGC:       mem.NumGC,
+		},
+		TakenAt: time.Now(),
+	}
+}
@@ -0,0 +1,241 @@
+package agent
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/retrystore"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+const controlToken = "secret"
+
+// testConfig returns a valid config for an agent that keeps no files and
+// serves no endpoints
+func testConfig(t *testing.T) *Config {
+	t.Helper()
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := DefaultConfig()
+	config.Name = "Test Agent"
+	config.Description = "Answers the tests"
+	config.PrivateKey = hex.EncodeToString(crypto.FromECDSA(key))
+	config.Capabilities = []string{"test"}
+	config.HealthEnabled = false
+	config.MetricsEnabled = false
+	config.IdentityFile = ""
+	config.LogLevel = "error"
+	return config
+}
+
+// newTestAgent creates an agent without an NFT that is not started
+func newTestAgent(t *testing.T, config *Config, handler types.AgentHandler) *EnhancedAgent {
+	t.Helper()
+	agent, err := NewEnhancedAgent(&EnhancedAgentConfig{Config: config, AgentHandler: handler, IdentityMode: IdentityModeAnonymous})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return agent
+}
+
+// blockingHandler answers once its task is cancelled
+type blockingHandler struct {
+	started chan struct{}
+}
+
+func (h *blockingHandler) ProcessTask(ctx context.Context, task string) (string, error) {
+	close(h.started)
+	<-ctx.Done()
+	return "", ctx.Err()
+}
+
+// controlRequest sends a request with the control token to the handler
+func controlRequest(t *testing.T, handler http.Handler, method, path, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer "+controlToken)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+// deadLetterStore writes a retry queue file holding dead letters with the IDs
+func deadLetterStore(t *testing.T, ids ...string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "retry-queue.json")
+	snapshot := &retrystore.Snapshot{}
+	for _, id := range ids {
+		message, _ := json.Marshal(&types.Message{Type: types.MessageTypeTaskResponse, TaskID: id, Content: "lost"})
+		snapshot.Dead = append(snapshot.Dead, retrystore.DeadEntry{
+			Entry:  retrystore.Entry{Key: id, Message: message, QueuedAt: time.Now()},
+			Reason: "max_retries",
+			DeadAt: time.Now(),
+		})
+	}
+	if err := retrystore.NewFile(path).Save(context.Background(), snapshot); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestControlRequiresToken(t *testing.T) {
+	handler := newTestAgent(t, testConfig(t), &blockingHandler{}).ControlHandler(controlToken)
+
+	for _, header := range []string{"", "Bearer wrong", "Bearer " + controlToken + "x"} {
+		req := httptest.NewRequest(http.MethodPut, "/control/capabilities", strings.NewReader(`{"capabilities":["other"]}`))
+		if header != "" {
+			req.Header.Set("Authorization", header)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != http.StatusUnauthorized {
+			t.Errorf("Authorization %q: status %d, want 401", header, rec.Code)
+		}
+	}
+}
+
+func TestControlRejectsInvalidRequests(t *testing.T) {
+	handler := newTestAgent(t, testConfig(t), &blockingHandler{}).ControlHandler(controlToken)
+
+	tests := []struct {
+		method, path, body string
+		status             int
+	}{
+		{http.MethodPut, "/control/capabilities", `{"capabilities":[]}`, http.StatusBadRequest},
+		{http.MethodPut, "/control/capabilities", `{}`, http.StatusBadRequest},
+		{http.MethodPut, "/control/capabilities", `{"capabilities":["finance/trade@not-a-version"]}`, http.StatusBadRequest},
+		{http.MethodPut, "/control/rate-limit", `{}`, http.StatusBadRequest},
+		{http.MethodPut, "/control/rate-limit", `{"per_minute":-1}`, http.StatusBadRequest},
+		{http.MethodPut, "/control/rate-limit", `{"burst":-1}`, http.StatusBadRequest},
+		{http.MethodPut, "/control/rate-limit", `{"per_room":{"per_minute":10,"burst":-1}}`, http.StatusBadRequest},
+		{http.MethodPut, "/control/rate-limit", `not json`, http.StatusBadRequest},
+		{http.MethodPut, "/control/bandwidth/room-1", `{"per_minute":-1}`, http.StatusBadRequest},
+		{http.MethodPut, "/control/bandwidth/room-1", `{"burst":-1}`, http.StatusBadRequest},
+		{http.MethodPost, "/control/tasks/unknown/cancel", ``, http.StatusNotFound},
+		{http.MethodGet, "/control/dead-letters/unknown", ``, http.StatusNotFound},
+		{http.MethodPost, "/control/dead-letters/unknown/requeue", ``, http.StatusNotFound},
+		{http.MethodDelete, "/control/dead-letters/unknown", ``, http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		if rec := controlRequest(t, handler, tt.method, tt.path, tt.body); rec.Code != tt.status {
+			t.Errorf("%s %s %s: status %d, want %d (%s)", tt.method, tt.path, tt.body, rec.Code, tt.status, rec.Body)
+		}
+	}
+}
+
+func TestControlCancelTask(t *testing.T) {
+	handler := &blockingHandler{started: make(chan struct{})}
+	agent := newTestAgent(t, testConfig(t), handler)
+	control := agent.ControlHandler(controlToken)
+
+	data, _ := json.Marshal(map[string]string{"task_id": "task-1"})
+	status := make(chan string, 1)
+	go func() {
+		msg := &types.Message{Type: types.MessageTypeTask, From: "0xuser", Room: "room-1", Content: "wait", Data: data}
+		status <- agent.taskCoordinator.RunTask(context.Background(), msg, func(context.Context, *types.Message) error { return nil })
+	}()
+	<-handler.started
+
+	if rec := controlRequest(t, control, http.MethodPost, "/control/tasks/task-1/cancel", ""); rec.Code != http.StatusOK {
+		t.Fatalf("status %d, want 200 (%s)", rec.Code, rec.Body)
+	}
+	select {
+	case <-status:
+	case <-time.After(5 * time.Second):
+		t.Fatal("cancelled task did not end")
+	}
+	if rec := controlRequest(t, control, http.MethodPost, "/control/tasks/task-1/cancel", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("second cancel: status %d, want 404", rec.Code)
+	}
+}
+
+func TestControlCapabilities(t *testing.T) {
+	agent := newTestAgent(t, testConfig(t), &blockingHandler{})
+	control := agent.ControlHandler(controlToken)
+
+	if rec := controlRequest(t, control, http.MethodPut, "/control/capabilities", `{"capabilities":["finance/quote","finance/trade"]}`); rec.Code != http.StatusOK {
+		t.Fatalf("status %d, want 200 (%s)", rec.Code, rec.Body)
+	}
+	rec := controlRequest(t, control, http.MethodGet, "/control/capabilities", "")
+	var body capabilitiesRequest
+	json.NewDecoder(rec.Body).Decode(&body)
+	if strings.Join(body.Capabilities, ",") != "finance/quote,finance/trade" {
+		t.Errorf("capabilities = %v", body.Capabilities)
+	}
+	if got := agent.taskCoordinator.Capabilities(); len(got) != 2 {
+		t.Errorf("coordinator capabilities = %v", got)
+	}
+}
+
+func TestControlRateLimit(t *testing.T) {
+	agent := newTestAgent(t, testConfig(t), &blockingHandler{})
+	control := agent.ControlHandler(controlToken)
+
+	rec := controlRequest(t, control, http.MethodPut, "/control/rate-limit", `{"per_minute":30,"per_sender":{"per_minute":5,"burst":2}}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d, want 200 (%s)", rec.Code, rec.Body)
+	}
+	limits := agent.taskCoordinator.GetRateLimits()
+	if limits.Global.PerMinute != 30 || limits.PerSender.PerMinute != 5 || limits.PerSender.Burst != 2 {
+		t.Errorf("limits = %+v", limits)
+	}
+	if agent.config.RateLimitPerMinute != 30 || agent.config.SenderRateLimitPerMinute != 5 || agent.config.SenderRateLimitBurst != 2 {
+		t.Error("rate limits not recorded in the config")
+	}
+}
+
+func TestControlBandwidth(t *testing.T) {
+	agent := newTestAgent(t, testConfig(t), &blockingHandler{})
+	control := agent.ControlHandler(controlToken)
+
+	if rec := controlRequest(t, control, http.MethodPut, "/control/bandwidth/room-1", `{"per_minute":1024,"burst":2048}`); rec.Code != http.StatusOK {
+		t.Fatalf("status %d, want 200 (%s)", rec.Code, rec.Body)
+	}
+	rec := controlRequest(t, control, http.MethodGet, "/control/bandwidth", "")
+	var body controlBandwidth
+	json.NewDecoder(rec.Body).Decode(&body)
+	if ceiling := body.RoomCeilings["room-1"]; ceiling.PerMinute != 1024 || ceiling.Burst != 2048 {
+		t.Errorf("room ceilings = %+v", body.RoomCeilings)
+	}
+}
+
+func TestControlDeadLetters(t *testing.T) {
+	config := testConfig(t)
+	config.RetryQueueStore = "file"
+	config.RetryQueueFile = deadLetterStore(t, "letter-1", "letter-2", "letter-3")
+	agent := newTestAgent(t, config, &blockingHandler{})
+	control := agent.ControlHandler(controlToken)
+
+	if rec := controlRequest(t, control, http.MethodGet, "/control/dead-letters/letter-1", ""); rec.Code != http.StatusOK {
+		t.Fatalf("get: status %d, want 200 (%s)", rec.Code, rec.Body)
+	}
+	if rec := controlRequest(t, control, http.MethodPost, "/control/dead-letters/letter-1/requeue", ""); rec.Code != http.StatusAccepted {
+		t.Fatalf("requeue: status %d, want 202 (%s)", rec.Code, rec.Body)
+	}
+	if size := agent.networkClient.GetRetryQueueMetrics().CurrentQueueSize; size != 1 {
+		t.Errorf("retry queue holds %d messages after the requeue, want 1", size)
+	}
+	if rec := controlRequest(t, control, http.MethodDelete, "/control/dead-letters/letter-2", ""); rec.Code != http.StatusOK {
+		t.Fatalf("delete: status %d, want 200 (%s)", rec.Code, rec.Body)
+	}
+	if letters := agent.networkClient.DeadLetters(); len(letters) != 1 || letters[0].ID != "letter-3" {
+		t.Fatalf("dead letters = %+v, want letter-3", letters)
+	}
+
+	rec := controlRequest(t, control, http.MethodDelete, "/control/dead-letters", "")
+	var purged map[string]int
+	json.NewDecoder(rec.Body).Decode(&purged)
+	if rec.Code != http.StatusOK || purged["purged"] != 1 || len(agent.networkClient.DeadLetters()) != 0 {
+		t.Errorf("purge all: status %d, body %v", rec.Code, purged)
+	}
+}
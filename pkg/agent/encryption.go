@@ -0,0 +1,57 @@
+package agent
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/e2e"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/logging"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/network"
+)
+
+// Encryption loads the encryption keys from EncryptionKeyFile, creating the
+// file on the first run, or returns nil if encryption is disabled
+func (c *Config) Encryption() (*network.EncryptionConfig, error) {
+	if !c.EncryptionEnabled && !c.EncryptionRequired {
+		return nil, nil
+	}
+	keyring, err := e2e.LoadKeyring(c.EncryptionKeyFile, c.EncryptionKeysRetained)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load encryption keys: %w", err)
+	}
+	return &network.EncryptionConfig{Keyring: keyring, Required: c.EncryptionRequired}, nil
+}
+
+// RotateEncryptionKey makes a fresh key the one users encrypt task content
+// to and announces it to the server. Tasks encrypted to the previous keys
+// are accepted as long as they are retained.
+func (a *EnhancedAgent) RotateEncryptionKey() error {
+	_, err := a.protocolHandler.RotateEncryptionKey()
+	return err
+}
+
+// rotateEncryptionKeys rotates the encryption key whenever the current one is
+// older than the rotation interval, until the agent stops. The age of the key
+// is kept in the key file, so restarts do not postpone rotations.
+func (a *EnhancedAgent) rotateEncryptionKeys(interval time.Duration) {
+	for {
+		wait := time.Until(a.encryptionKeys.Primary().CreatedAt.Add(interval))
+		timer := time.NewTimer(max(wait, 0))
+		select {
+		case <-a.ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		if err := a.RotateEncryptionKey(); err != nil {
+			logging.Error("failed to rotate encryption key", "error", err)
+			// Try again later rather than in a tight loop
+			select {
+			case <-a.ctx.Done():
+				return
+			case <-time.After(time.Minute):
+			}
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package agent
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/guardrail"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/network"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/types"
+)
+
+// Guardrail returns the configured guardrail policy with custom run after
+// the configured rules, or nil if there is no guardrail. The moderation API
+// is called with the key in OPENAI_API_KEY.
+func (c *Config) Guardrail(custom types.Guardrail) (*network.GuardrailPolicy, error) {
+	var chain guardrail.Chain
+	var rules []guardrail.Rule
+	if len(c.GuardrailKeywords) > 0 {
+		rules = append(rules, guardrail.Keywords("keyword", c.GuardrailKeywords...))
+	}
+	for _, expr := range c.GuardrailPatterns {
+		rule, err := guardrail.Pattern("pattern", expr)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	if len(rules) > 0 {
+		chain = append(chain, guardrail.NewPatterns(rules...))
+	}
+	if c.GuardrailModeration {
+		apiKey := os.Getenv("OPENAI_API_KEY")
+		if apiKey == "" {
+			return nil, fmt.Errorf("guardrail moderation requires the OPENAI_API_KEY environment variable")
+		}
+		chain = append(chain, guardrail.NewModeration(&guardrail.ModerationConfig{APIKey: apiKey}))
+	}
+	if custom != nil {
+		chain = append(chain, custom)
+	}
+
+	policy := &network.GuardrailPolicy{
+		InputAction:  c.GuardrailInputAction,
+		OutputAction: c.GuardrailOutputAction,
+		FailClosed:   c.GuardrailFailClosed,
+	}
+	if policy.InputAction == "" {
+		policy.InputAction = types.GuardrailActionBlock
+	}
+	if policy.OutputAction == "" {
+		policy.OutputAction = types.GuardrailActionBlock
+	}
+	switch len(chain) {
+	case 0:
+		return nil, nil
+	case 1:
+		policy.Guardrail = chain[0]
+	default:
+		policy.Guardrail = chain
+	}
+	return policy, nil
+}
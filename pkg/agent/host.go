@@ -0,0 +1,393 @@
+package agent
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/cache"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/health"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/logging"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/types"
+)
+
+// HostAgentsPath is the path of the hosted agents' status on the host's health server
+const HostAgentsPath = "/agents"
+
+// HostConfig configures an AgentHost
+type HostConfig struct {
+	// Port of the health server shared by the agents (0 disables it). It
+	// aggregates /health, /status and the probes over the running agents and
+	// reports each agent on /agents. The agents' own health settings are ignored.
+	HealthPort int
+
+	// Export the metrics of every agent on the health server, labeled by agent
+	MetricsEnabled bool
+
+	// Redis connection shared by the agents, each keeping its own key prefix
+	// (optional, without it every agent uses the cache its config selects)
+	Redis *cache.RedisConfig
+}
+
+// AgentHost runs several agents, each with its own name, wallet, NFT and
+// handler, in one process. The agents share the host's Redis connection and
+// health server and are started and stopped together or one at a time.
+//
+// Logging, output style and tracing are process-wide: the settings of the
+// agent added last apply to all of them.
+type AgentHost struct {
+	config       *HostConfig
+	redis        *cache.RedisCache
+	healthServer *health.Server
+	agents       []*EnhancedAgent // In the order they were added
+	running      bool
+	startTime    time.Time
+	mu           sync.RWMutex
+}
+
+// HostedAgentStatus is the state of one agent of a host
+type HostedAgentStatus struct {
+	Name          string `json:"name"`
+	Wallet        string `json:"wallet"`
+	Running       bool   `json:"running"`
+	Connected     bool   `json:"connected"`
+	Authenticated bool   `json:"authenticated"`
+	Registered    bool   `json:"registered"`
+	ActiveTasks   int    `json:"active_tasks"`
+	Uptime        string `json:"uptime"`
+
+	OpenCircuits []string `json:"open_circuits,omitempty"`
+}
+
+// NewAgentHost creates a host without agents, connecting to the shared Redis if configured
+func NewAgentHost(config *HostConfig) (*AgentHost, error) {
+	if config == nil {
+		config = &HostConfig{}
+	}
+	h := &AgentHost{config: config}
+
+	if config.Redis != nil {
+		redisCache, err := cache.NewRedisCache(config.Redis)
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to the shared Redis: %w", err)
+		}
+		h.redis = redisCache
+		logging.Info("shared Redis cache initialized", "address", config.Redis.Address)
+	}
+
+	if config.HealthPort > 0 {
+		h.healthServer = health.NewServer(config.HealthPort, &health.AgentInfo{Name: "agent host"}, h)
+		h.healthServer.Handle(HostAgentsPath, http.HandlerFunc(h.agentsHandler))
+		if config.MetricsEnabled {
+			h.healthServer.Handle("/metrics", health.GroupHandler(h.metrics))
+		}
+	}
+	return h, nil
+}
+
+// Add creates an agent on the host. Agent names must be unique. An agent
+// added while the host is running is started with StartAgent.
+func (h *AgentHost) Add(config *EnhancedAgentConfig) (*EnhancedAgent, error) {
+	if config == nil || config.Config == nil {
+		return nil, fmt.Errorf("config is required")
+	}
+	if h.Agent(config.Config.Name) != nil {
+		return nil, fmt.Errorf("agent %q is already on the host", config.Config.Name)
+	}
+
+	config.host = h
+	agent, err := NewEnhancedAgent(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create agent %q: %w", config.Config.Name, err)
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.agents = append(h.agents, agent)
+	return agent, nil
+}
+
+// Agent returns the agent with the given name, or nil
+func (h *AgentHost) Agent(name string) *EnhancedAgent {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for _, agent := range h.agents {
+		if agent.config.Name == name {
+			return agent
+		}
+	}
+	return nil
+}
+
+// Agents returns the agents in the order they were added
+func (h *AgentHost) Agents() []*EnhancedAgent {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return append([]*EnhancedAgent(nil), h.agents...)
+}
+
+// StartAgent starts one agent. A stopped agent cannot be started again:
+// Remove it and Add it anew.
+func (h *AgentHost) StartAgent(name string) error {
+	agent := h.Agent(name)
+	if agent == nil {
+		return fmt.Errorf("no agent %q on the host", name)
+	}
+	if agent.ctx.Err() != nil {
+		return fmt.Errorf("agent %q was stopped; remove it and add it again to restart it", name)
+	}
+	return agent.Start()
+}
+
+// StopAgent stops one agent, leaving the others running
+func (h *AgentHost) StopAgent(name string) error {
+	agent := h.Agent(name)
+	if agent == nil {
+		return fmt.Errorf("no agent %q on the host", name)
+	}
+	return agent.Stop()
+}
+
+// Remove stops an agent and removes it from the host
+func (h *AgentHost) Remove(name string) error {
+	if err := h.StopAgent(name); err != nil {
+		return err
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for i, agent := range h.agents {
+		if agent.config.Name == name {
+			h.agents = append(h.agents[:i], h.agents[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+// Start starts the health server and every agent. Agents that fail to start
+// are reported in the returned error while the others keep running.
+func (h *AgentHost) Start() error {
+	h.mu.Lock()
+	if h.running {
+		h.mu.Unlock()
+		return fmt.Errorf("agent host is already running")
+	}
+	h.running = true
+	h.startTime = time.Now()
+	agents := append([]*EnhancedAgent(nil), h.agents...)
+	h.mu.Unlock()
+
+	if h.healthServer != nil {
+		names := make([]string, len(agents))
+		for i, agent := range agents {
+			names[i] = agent.config.Name
+		}
+		h.healthServer.UpdateAgentInfo(&health.AgentInfo{
+			Name:        "agent host",
+			Description: "Agents: " + strings.Join(names, ", "),
+		})
+		go func() {
+			logging.Info("starting host health monitoring", "port", h.config.HealthPort)
+			if err := h.healthServer.Start(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				logging.Error("health server error", "error", err)
+			}
+		}()
+	}
+
+	// Agents connect with retries, so start them side by side
+	errs := make([]error, len(agents))
+	var wg sync.WaitGroup
+	for i, agent := range agents {
+		wg.Add(1)
+		go func(i int, agent *EnhancedAgent) {
+			defer wg.Done()
+			if err := agent.Start(); err != nil {
+				errs[i] = fmt.Errorf("agent %q: %w", agent.config.Name, err)
+			}
+		}(i, agent)
+	}
+	wg.Wait()
+
+	logging.Info("agent host started", "agents", len(agents))
+	return errors.Join(errs...)
+}
+
+// Stop stops every agent, the health server and the shared Redis connection.
+// A stopped host cannot be started again.
+func (h *AgentHost) Stop() error {
+	h.mu.Lock()
+	if !h.running {
+		h.mu.Unlock()
+		return nil
+	}
+	h.running = false
+	agents := append([]*EnhancedAgent(nil), h.agents...)
+	h.mu.Unlock()
+
+	var errs []error
+	for _, agent := range agents {
+		if err := agent.Stop(); err != nil {
+			errs = append(errs, fmt.Errorf("agent %q: %w", agent.config.Name, err))
+		}
+	}
+
+	if h.healthServer != nil {
+		if err := h.healthServer.Stop(); err != nil {
+			logging.Warn("error stopping health server", "error", err)
+		}
+	}
+	if h.redis != nil {
+		if err := h.redis.Close(); err != nil {
+			logging.Warn("error closing shared Redis connection", "error", err)
+		}
+	}
+
+	logging.Info("agent host stopped")
+	return errors.Join(errs...)
+}
+
+// Run starts the host and runs it until interrupted. It fails only if no
+// agent could be started.
+func (h *AgentHost) Run() error {
+	if err := h.Start(); err != nil {
+		if len(h.runningAgents()) == 0 {
+			h.Stop()
+			return err
+		}
+		logging.Warn("some agents failed to start", "error", err)
+	}
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	<-sigChan
+	logging.Info("received interrupt signal")
+
+	return h.Stop()
+}
+
+// Status returns the state of every agent
+func (h *AgentHost) Status() []HostedAgentStatus {
+	agents := h.Agents()
+	statuses := make([]HostedAgentStatus, len(agents))
+	for i, agent := range agents {
+		statuses[i] = HostedAgentStatus{
+			Name:          agent.config.Name,
+			Wallet:        agent.authManager.GetAddress(),
+			Running:       agent.IsRunning(),
+			Connected:     agent.IsConnected(),
+			Authenticated: agent.IsAuthenticated(),
+			Registered:    agent.IsRegistered(),
+			ActiveTasks:   agent.GetActiveTaskCount(),
+			Uptime:        agent.GetUptime().Round(time.Second).String(),
+			OpenCircuits:  agent.OpenCircuits(),
+		}
+	}
+	return statuses
+}
+
+// runningAgents returns the agents that are running. Agents stopped on their own
+// are left out of the aggregated health.
+func (h *AgentHost) runningAgents() []*EnhancedAgent {
+	var running []*EnhancedAgent
+	for _, agent := range h.Agents() {
+		if agent.IsRunning() {
+			running = append(running, agent)
+		}
+	}
+	return running
+}
+
+// all reports whether at least one agent runs and all running agents meet check
+func (h *AgentHost) all(check func(*EnhancedAgent) bool) bool {
+	agents := h.runningAgents()
+	for _, agent := range agents {
+		if !check(agent) {
+			return false
+		}
+	}
+	return len(agents) > 0
+}
+
+// IsConnected implements the health.StatusGetter interface: all running agents are connected
+func (h *AgentHost) IsConnected() bool {
+	return h.all((*EnhancedAgent).IsConnected)
+}
+
+// IsAuthenticated implements the health.StatusGetter interface: all running agents are authenticated
+func (h *AgentHost) IsAuthenticated() bool {
+	return h.all((*EnhancedAgent).IsAuthenticated)
+}
+
+// GetActiveTaskCount implements the health.StatusGetter interface
+func (h *AgentHost) GetActiveTaskCount() int {
+	count := 0
+	for _, agent := range h.runningAgents() {
+		count += agent.GetActiveTaskCount()
+	}
+	return count
+}
+
+// GetUptime implements the health.StatusGetter interface
+func (h *AgentHost) GetUptime() time.Duration {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	if !h.running {
+		return 0
+	}
+	return time.Since(h.startTime)
+}
+
+// IsRegistered implements the health.ReadinessGetter interface
+func (h *AgentHost) IsRegistered() bool {
+	return h.all((*EnhancedAgent).IsRegistered)
+}
+
+// HasRegistered implements the health.ReadinessGetter interface
+func (h *AgentHost) HasRegistered() bool {
+	return h.all((*EnhancedAgent).HasRegistered)
+}
+
+// OpenCircuits implements the health.ReadinessGetter interface, as "agent/class"
+func (h *AgentHost) OpenCircuits() []string {
+	var open []string
+	for _, agent := range h.runningAgents() {
+		for _, class := range agent.OpenCircuits() {
+			open = append(open, agent.config.Name+"/"+class)
+		}
+	}
+	return open
+}
+
+// GetTaskProgress implements the health.ProgressGetter interface
+func (h *AgentHost) GetTaskProgress() []types.TaskProgress {
+	var progress []types.TaskProgress
+	for _, agent := range h.runningAgents() {
+		progress = append(progress, agent.GetTaskProgress()...)
+	}
+	return progress
+}
+
+// metrics returns the metrics collectors of the agents
+func (h *AgentHost) metrics() []*health.Metrics {
+	var collectors []*health.Metrics
+	for _, agent := range h.Agents() {
+		if agent.metrics != nil {
+			collectors = append(collectors, agent.metrics)
+		}
+	}
+	return collectors
+}
+
+// agentsHandler reports the state of every agent
+func (h *AgentHost) agentsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.Status())
+}
@@ -0,0 +1,139 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/devserver"
+)
+
+// echoHandler answers every task with its name and the task
+type echoHandler struct {
+	name string
+}
+
+func (h *echoHandler) ProcessTask(ctx context.Context, task string) (string, error) {
+	return h.name + ": " + task, nil
+}
+
+// newDevServer starts a local coordinator that is closed with the test
+func newDevServer(t *testing.T) *devserver.Server {
+	t.Helper()
+	server, err := devserver.New(&devserver.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { server.Close() })
+	return server
+}
+
+// ask sends a task to the named agent and returns its answer
+func ask(t *testing.T, server *devserver.Server, agent, content string) string {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	taskID, err := server.SendTask(devserver.Task{Agent: agent, Content: content})
+	if err != nil {
+		t.Fatalf("sending task to %s: %v", agent, err)
+	}
+	result, err := server.WaitForResult(ctx, taskID)
+	if err != nil {
+		t.Fatalf("waiting for %s: %v", agent, err)
+	}
+	return result.Response.Content
+}
+
+func TestAgentHostStopsOneAgent(t *testing.T) {
+	server := newDevServer(t)
+	host, err := NewAgentHost(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"Alpha", "Beta"} {
+		config := testConfig(t)
+		config.Name = name
+		config.WebSocketURL = server.URL()
+		if _, err := host.Add(&EnhancedAgentConfig{Config: config, AgentHandler: &echoHandler{name: name}, IdentityMode: IdentityModeAnonymous}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := host.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer host.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	for _, name := range []string{"Alpha", "Beta"} {
+		if _, err := server.WaitForAgent(ctx, name); err != nil {
+			t.Fatal(err)
+		}
+		if answer := ask(t, server, name, "hello"); answer != name+": hello" {
+			t.Errorf("%s answered %q", name, answer)
+		}
+	}
+
+	if err := host.StopAgent("Alpha"); err != nil {
+		t.Fatal(err)
+	}
+	for deadline := time.Now().Add(5 * time.Second); len(server.Agents()) != 1; time.Sleep(10 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatalf("server has agents %+v after Alpha stopped", server.Agents())
+		}
+	}
+	if connected := server.Agents()[0].Name; connected != "Beta" {
+		t.Fatalf("agent %s is connected, want Beta", connected)
+	}
+	if answer := ask(t, server, "Beta", "still there?"); answer != "Beta: still there?" {
+		t.Errorf("Beta answered %q", answer)
+	}
+
+	for _, status := range host.Status() {
+		if status.Running != (status.Name == "Beta") {
+			t.Errorf("%s running = %v", status.Name, status.Running)
+		}
+	}
+	if !host.IsConnected() {
+		t.Error("host reports a stopped agent as disconnected")
+	}
+	if err := host.StartAgent("Alpha"); err == nil {
+		t.Error("stopped agent started again")
+	}
+}
+
+func TestAgentHostRejectsInvalidAgents(t *testing.T) {
+	host, err := NewAgentHost(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := host.Add(&EnhancedAgentConfig{Config: testConfig(t), AgentHandler: &echoHandler{}, IdentityMode: IdentityModeAnonymous}); err != nil {
+		t.Fatal(err)
+	}
+
+	badKey := testConfig(t)
+	badKey.Name = "Bad Key"
+	badKey.PrivateKey = "not-a-key"
+	badStyle := testConfig(t)
+	badStyle.Name = "Bad Style"
+	badStyle.OutputStyle = "sparkles"
+	tests := map[string]*EnhancedAgentConfig{
+		"no config":            {},
+		"duplicate name":       {Config: testConfig(t), AgentHandler: &echoHandler{}, IdentityMode: IdentityModeAnonymous},
+		"invalid private key":  {Config: badKey, AgentHandler: &echoHandler{}, IdentityMode: IdentityModeWalletOnly},
+		"invalid output style": {Config: badStyle, AgentHandler: &echoHandler{}, IdentityMode: IdentityModeAnonymous},
+		"no handler":           {Config: &Config{Name: "No Handler"}, IdentityMode: IdentityModeAnonymous},
+	}
+	for name, config := range tests {
+		if _, err := host.Add(config); err == nil {
+			t.Errorf("%s: agent added", name)
+		}
+	}
+	if agents := host.Agents(); len(agents) != 1 {
+		t.Errorf("host has %d agents, want 1", len(agents))
+	}
+	if err := host.StartAgent("Missing"); err == nil || !strings.Contains(err.Error(), "no agent") {
+		t.Errorf("StartAgent(Missing) = %v", err)
+	}
+}
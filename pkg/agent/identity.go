@@ -0,0 +1,72 @@
+package agent
+
+import (
+	"errors"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/identity"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/logging"
+)
+
+// loadIdentity reads the identity file of the agent's wallet, nil if there is
+// none or it belongs to another wallet
+func loadIdentity(path, address string) *identity.Identity {
+	id, err := identity.Load(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		logging.Warn("ignoring identity file", "path", path, "error", err)
+		return nil
+	}
+	if err := id.Check(address); err != nil {
+		logging.Warn("ignoring identity file", "path", path, "error", err)
+		return nil
+	}
+	return id
+}
+
+// saveIdentity writes the identity file if one is configured
+func (a *EnhancedAgent) saveIdentity(update func(id *identity.Identity)) {
+	path := a.config.IdentityFile
+	if path == "" {
+		return
+	}
+
+	a.identityMu.Lock()
+	defer a.identityMu.Unlock()
+	if a.identity == nil {
+		a.identity = &identity.Identity{
+			AgentID: generateAgentID(a.config.Name),
+			Address: a.authManager.GetAddress(),
+		}
+	}
+	if tokenID, err := strconv.ParseUint(a.config.NFTTokenID, 10, 64); err == nil {
+		a.identity.TokenID = tokenID
+	}
+	update(a.identity)
+	if err := a.identity.Save(path); err != nil {
+		logging.Warn("failed to save identity file", "path", path, "error", err)
+	}
+}
+
+// recordRegistration writes the registration time to the identity file
+func (a *EnhancedAgent) recordRegistration() {
+	a.saveIdentity(func(id *identity.Identity) {
+		id.Registered(time.Now().UTC())
+	})
+}
+
+// Identity returns the agent's persistent identity, nil before the first
+// registration or when no identity file is configured
+func (a *EnhancedAgent) Identity() *identity.Identity {
+	a.identityMu.Lock()
+	defer a.identityMu.Unlock()
+	if a.identity == nil {
+		return nil
+	}
+	id := *a.identity
+	return &id
+}
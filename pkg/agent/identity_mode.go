@@ -0,0 +1,108 @@
+package agent
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/logging"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// IdentityMode is how the agent identifies itself to the network
+type IdentityMode string
+
+const (
+	// IdentityModeNFT authenticates with the wallet and the agent's NFT,
+	// minting it if needed (default)
+	IdentityModeNFT IdentityMode = "nft"
+	// IdentityModeWalletOnly authenticates with signatures of the configured
+	// wallet only; nothing is minted, verified or sent on-chain
+	IdentityModeWalletOnly IdentityMode = "wallet-only"
+	// IdentityModeAnonymous authenticates with a throwaway wallet created for
+	// each run; PRIVATE_KEY is not needed and not used
+	IdentityModeAnonymous IdentityMode = "anonymous"
+)
+
+// ErrNFTDisabled is returned by the NFT APIs of an agent running without an
+// NFT identity
+var ErrNFTDisabled = errors.New("NFT features are disabled in this identity mode (set IDENTITY_MODE=nft)")
+
+// parseIdentityMode parses an identity mode ("" = nft)
+func parseIdentityMode(s string) (IdentityMode, error) {
+	switch mode := IdentityMode(strings.ToLower(strings.TrimSpace(s))); mode {
+	case "":
+		return IdentityModeNFT, nil
+	case IdentityModeNFT, IdentityModeWalletOnly, IdentityModeAnonymous:
+		return mode, nil
+	}
+	return "", fmt.Errorf("invalid identity mode %q (use \"nft\", \"wallet-only\" or \"anonymous\")", s)
+}
+
+// UsesNFT reports whether the agent has an NFT in this mode
+func (m IdentityMode) UsesNFT() bool {
+	return m == IdentityModeNFT || m == ""
+}
+
+// applyIdentityMode prepares the config for running without an NFT: it turns
+// off the NFT settings, logging the ones that were set, and creates the
+// throwaway wallet of an anonymous agent
+func applyIdentityMode(config *EnhancedAgentConfig, mode IdentityMode) error {
+	if mode.UsesNFT() {
+		return nil
+	}
+
+	var ignored []string
+	if config.Mint {
+		ignored = append(ignored, "mint")
+	}
+	if config.TokenID > 0 || config.Config.NFTTokenID != "" {
+		ignored = append(ignored, "NFT_TOKEN_ID")
+	}
+	if config.Config.MetadataSyncInterval > 0 {
+		ignored = append(ignored, "METADATA_SYNC_INTERVAL")
+	}
+	if config.Config.OwnershipWatchInterval > 0 {
+		ignored = append(ignored, "OWNERSHIP_WATCH_INTERVAL")
+	}
+	if len(ignored) > 0 {
+		logging.Warn("ignoring NFT settings in this identity mode", "mode", mode, "settings", strings.Join(ignored, ", "))
+	}
+	config.Mint = false
+	config.TokenID = 0
+	config.Config.NFTTokenID = ""
+	config.Config.MetadataSyncInterval = 0
+	config.Config.OwnershipWatchInterval = 0
+
+	if mode == IdentityModeAnonymous {
+		key, err := crypto.GenerateKey()
+		if err != nil {
+			return fmt.Errorf("failed to create anonymous wallet: %w", err)
+		}
+		config.Config.PrivateKey = hex.EncodeToString(crypto.FromECDSA(key))
+		// A new wallet each run has no identity to keep
+		config.Config.IdentityFile = ""
+		logging.Info("running without NFT, authenticating with a throwaway wallet", "wallet", crypto.PubkeyToAddress(key.PublicKey).Hex())
+		return nil
+	}
+
+	if config.Config.PrivateKey == "" {
+		return fmt.Errorf("private key is required in %s identity mode", mode)
+	}
+	logging.Info("running without NFT, authenticating with wallet signatures only", "wallet", getAddressFromPrivateKey(config.Config.PrivateKey))
+	return nil
+}
+
+// requireNFT returns ErrNFTDisabled unless the agent runs with an NFT identity
+func (a *EnhancedAgent) requireNFT() error {
+	if !a.identityMode.UsesNFT() {
+		return ErrNFTDisabled
+	}
+	return nil
+}
+
+// IdentityMode returns how the agent identifies itself to the network
+func (a *EnhancedAgent) IdentityMode() IdentityMode {
+	return a.identityMode
+}
@@ -0,0 +1,102 @@
+package agent
+
+import (
+	"context"
+	"errors"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/health"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/nft"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/types"
+)
+
+// errMetadataSyncDisabled is returned by the metadata sync API without MetadataSyncInterval
+var errMetadataSyncDisabled = errors.New("NFT metadata sync is not enabled (set METADATA_SYNC_INTERVAL)")
+
+// enableMetadataSync creates the syncer comparing the config with the
+// agent's business card; Start runs it
+func (a *EnhancedAgent) enableMetadataSync() error {
+	manager, err := a.businessCardManager()
+	if err != nil {
+		return err
+	}
+
+	syncer, err := nft.NewMetadataSyncer(nft.MetadataSyncerConfig{
+		Store:      nft.NewBusinessCardStore(manager, a.config.OwnerAddress),
+		Live:       a.liveMetadata,
+		Interval:   a.config.MetadataSyncInterval,
+		AutoUpdate: a.config.MetadataAutoUpdate,
+	})
+	if err != nil {
+		return err
+	}
+	a.metadataSyncer = syncer
+	return nil
+}
+
+// liveMetadata returns the metadata the agent runs with
+func (a *EnhancedAgent) liveMetadata() types.AgentMetadata {
+	return types.AgentMetadata{
+		Name:         a.config.Name,
+		Description:  a.config.Description,
+		Capabilities: a.Capabilities(),
+		ContactInfo:  a.config.ContactInfo,
+		PricingModel: a.config.PricingModel,
+		Version:      a.config.Version,
+	}
+}
+
+// CheckMetadata compares the agent's name, description, capabilities and
+// version with its NFT's metadata without changing anything
+func (a *EnhancedAgent) CheckMetadata(ctx context.Context) (types.MetadataDrift, error) {
+	if err := a.requireNFT(); err != nil {
+		return types.MetadataDrift{}, err
+	}
+	if a.metadataSyncer == nil {
+		return types.MetadataDrift{}, errMetadataSyncDisabled
+	}
+	return a.metadataSyncer.Check(ctx)
+}
+
+// UpdateMetadataIfChanged writes the description and version to the agent's
+// NFT if they drifted from the config. Name and capabilities are fixed at mint
+// time; drift in them is reported in the result's Skipped.
+func (a *EnhancedAgent) UpdateMetadataIfChanged(ctx context.Context) (*nft.MetadataUpdate, error) {
+	if err := a.requireNFT(); err != nil {
+		return nil, err
+	}
+	if a.metadataSyncer == nil {
+		return nil, errMetadataSyncDisabled
+	}
+	return a.metadataSyncer.UpdateIfChanged(ctx)
+}
+
+// GetMetadataDrift implements the health.MetadataDriftGetter interface
+func (a *EnhancedAgent) GetMetadataDrift() *types.MetadataDrift {
+	if a.metadataSyncer == nil {
+		return nil
+	}
+	drift := a.metadataSyncer.Status()
+	if drift.CheckedAt.IsZero() {
+		return nil
+	}
+	return &drift
+}
+
+// registerMetadataMetrics exposes the drift and sync counters of the metadata syncer
+func (a *EnhancedAgent) registerMetadataMetrics(m *health.Metrics) {
+	m.RegisterGaugeFunc("nft_metadata_drift", "Whether the NFT metadata differs from the config (1) or not (0)", func() float64 {
+		if a.metadataSyncer.Status().Drifted {
+			return 1
+		}
+		return 0
+	})
+	m.RegisterCounterFunc("nft_metadata_checks_total", "Comparisons of the NFT metadata with the config", func() float64 {
+		return float64(a.metadataSyncer.Stats().Checks)
+	})
+	m.RegisterCounterFunc("nft_metadata_sync_failures_total", "NFT metadata checks and updates that failed", func() float64 {
+		return float64(a.metadataSyncer.Stats().Failures)
+	})
+	m.RegisterCounterFunc("nft_metadata_updates_total", "NFT metadata updates sent", func() float64 {
+		return float64(a.metadataSyncer.Stats().Updates)
+	})
+}
@@ -0,0 +1,93 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/identity"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/migrate"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/nft"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/types"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/version"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// MigrationOptions configures MigrateNFT
+type MigrationOptions struct {
+	Contract      string // Address of the new business card contract
+	SDKVersion    string // SDK version stored in the new card (default the running SDK's)
+	KeepOldActive bool   // Leave the old card active
+	DryRun        bool   // Report the plan without sending transactions
+}
+
+// MigrateNFT moves the agent's business card from config.NFTContractAddress
+// to a new contract version: it mints a card with the old card's metadata,
+// saves the new token ID in the identity file and deactivates the old card.
+// Transactions go through the configured relayer, if any. Point
+// NFTContractAddress at the new contract once the migration is done.
+func MigrateNFT(ctx context.Context, config *Config, options MigrationOptions) (*migrate.Result, error) {
+	if config.NFTContractAddress == "" {
+		return nil, errors.New("no current NFT contract configured (set NFT_CONTRACT_ADDRESS)")
+	}
+	if !common.IsHexAddress(options.Contract) {
+		return nil, fmt.Errorf("invalid target contract address %q", options.Contract)
+	}
+	sdkVersion := options.SDKVersion
+	if sdkVersion == "" {
+		sdkVersion = version.Version()
+	}
+
+	relayer, err := config.NewRelayer()
+	if err != nil {
+		return nil, err
+	}
+	from, err := nft.NewBusinessCardManager(config.EthereumRPC, config.NFTContractAddress, config.PrivateKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create NFT manager: %w", err)
+	}
+	defer from.Close()
+	to, err := nft.NewBusinessCardManager(config.EthereumRPC, options.Contract, config.PrivateKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create NFT manager: %w", err)
+	}
+	defer to.Close()
+	from.SetRelayer(relayer)
+	to.SetRelayer(relayer)
+	from.SetFeeConfig(config.FeeConfig())
+	to.SetFeeConfig(config.FeeConfig())
+
+	owner := from.GetOwnerAddress()
+	migration := &migrate.Config{
+		Owner:         owner,
+		From:          from,
+		To:            to,
+		Update:        func(request *types.MintRequest) { request.SDKVersion = sdkVersion },
+		KeepOldActive: options.KeepOldActive,
+		DryRun:        options.DryRun,
+	}
+	if path := config.IdentityFile; path != "" {
+		migration.Register = func(ctx context.Context, card *types.BusinessCard) error {
+			return saveMigratedIdentity(path, owner, config.Name, card)
+		}
+	}
+	return migrate.Run(ctx, migration)
+}
+
+// saveMigratedIdentity stores the token ID of the new card in the identity file
+func saveMigratedIdentity(path, owner, name string, card *types.BusinessCard) error {
+	id, err := identity.Load(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		id = &identity.Identity{AgentID: generateAgentID(name), Address: owner}
+	case err != nil:
+		return err
+	default:
+		if err := id.Check(owner); err != nil {
+			return err
+		}
+	}
+	id.TokenID = card.TokenID.Uint64()
+	return id.Save(path)
+}
@@ -0,0 +1,167 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/auth"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/backend"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/identity"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/nft"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/tracing"
+)
+
+// MintOptions configures MintNFT
+type MintOptions struct {
+	BackendURL  string // Default from env BACKEND_URL or "http://localhost:8080"
+	RPCEndpoint string // Default from env RPC_ENDPOINT
+}
+
+// NFTStatus describes the agent's NFT as found by VerifyNFT
+type NFTStatus struct {
+	TokenID uint64
+	Source  string // "NFT_TOKEN_ID" or the path of the identity file the token ID was read from
+	Wallet  string // Wallet of the configured private key
+	Owner   string // Current owner of the token
+}
+
+// Owned reports whether the agent's wallet owns the token
+func (s *NFTStatus) Owned() bool {
+	return strings.EqualFold(s.Owner, s.Wallet)
+}
+
+// MintNFT mints the agent's NFT without starting the agent and saves the
+// token ID in the identity file, so the next run uses it. It refuses to mint
+// a second NFT if a token ID is configured or in the identity file.
+func MintNFT(ctx context.Context, config *Config, options MintOptions) (uint64, error) {
+	if config.PrivateKey == "" {
+		return 0, errors.New("private key is required to mint (set PRIVATE_KEY)")
+	}
+	wallet := getAddressFromPrivateKey(config.PrivateKey)
+	if config.NFTTokenID != "" {
+		return 0, fmt.Errorf("agent already has NFT token %s (NFT_TOKEN_ID)", config.NFTTokenID)
+	}
+	if config.IdentityFile != "" {
+		if id := loadIdentity(config.IdentityFile, wallet); id != nil && id.TokenID > 0 {
+			return 0, fmt.Errorf("agent already has NFT token %d (%s)", id.TokenID, config.IdentityFile)
+		}
+	}
+
+	if options.BackendURL == "" {
+		options.BackendURL = os.Getenv("BACKEND_URL")
+	}
+	if options.BackendURL == "" {
+		options.BackendURL = "http://localhost:8080"
+	}
+	if options.RPCEndpoint == "" {
+		options.RPCEndpoint = os.Getenv("RPC_ENDPOINT")
+	}
+
+	authManager, err := auth.NewManager(config.PrivateKey)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create auth manager: %w", err)
+	}
+	backendTLS, err := config.BackendTLS()
+	if err != nil {
+		return 0, err
+	}
+	backendClient, err := backend.New(&backend.Config{BaseURL: options.BackendURL, Signer: authManager, TLS: backendTLS})
+	if err != nil {
+		return 0, fmt.Errorf("failed to create backend client: %w", err)
+	}
+	minter, err := newNFTMinter(&EnhancedAgentConfig{
+		Config:           config,
+		BackendURL:       options.BackendURL,
+		RPCEndpoint:      options.RPCEndpoint,
+		RPCWriteEndpoint: os.Getenv("RPC_WRITE_ENDPOINT"),
+	}, backendClient)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create NFT minter: %w", err)
+	}
+	defer minter.Close()
+
+	_, span := tracing.Start(ctx, tracing.SpanNFTMint)
+	tokenID, err := minter.MintAgent(nftMetadata(config))
+	span.SetAttributes(tracing.AttrTokenID.Int64(int64(tokenID)))
+	tracing.End(span, err)
+	if err != nil {
+		return 0, fmt.Errorf("failed to mint NFT: %w", err)
+	}
+
+	if path := config.IdentityFile; path != "" {
+		if err := saveMintedIdentity(path, wallet, config.Name, tokenID); err != nil {
+			return tokenID, fmt.Errorf("minted token %d but failed to save identity file: %w", tokenID, err)
+		}
+	}
+	return tokenID, nil
+}
+
+// VerifyNFT looks up the owner of the agent's NFT on config.NFTContractAddress.
+// The token ID is taken from NFTTokenID or else the identity file.
+func VerifyNFT(ctx context.Context, config *Config) (*NFTStatus, error) {
+	if config.PrivateKey == "" {
+		return nil, errors.New("private key is required to verify the NFT (set PRIVATE_KEY)")
+	}
+	status := &NFTStatus{Wallet: getAddressFromPrivateKey(config.PrivateKey)}
+
+	switch {
+	case config.NFTTokenID != "":
+		tokenID, err := strconv.ParseUint(config.NFTTokenID, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid NFT_TOKEN_ID %q", config.NFTTokenID)
+		}
+		status.TokenID, status.Source = tokenID, "NFT_TOKEN_ID"
+	case config.IdentityFile != "":
+		if id := loadIdentity(config.IdentityFile, status.Wallet); id != nil && id.TokenID > 0 {
+			status.TokenID, status.Source = id.TokenID, config.IdentityFile
+		}
+	}
+	if status.TokenID == 0 {
+		return nil, errors.New("no NFT token ID configured (set NFT_TOKEN_ID or mint an NFT)")
+	}
+
+	manager, err := nft.NewBusinessCardManager(config.EthereumRPC, config.NFTContractAddress, config.PrivateKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create NFT manager: %w", err)
+	}
+	defer manager.Close()
+	if status.Owner, err = manager.OwnerOf(ctx, new(big.Int).SetUint64(status.TokenID)); err != nil {
+		return nil, err
+	}
+	return status, nil
+}
+
+// nftMetadata returns the metadata the agent's NFT is minted with
+func nftMetadata(config *Config) nft.AgentMetadata {
+	return nft.AgentMetadata{
+		Name:         config.Name,
+		Description:  config.Description,
+		Image:        config.Image,
+		Capabilities: config.Capabilities,
+		AgentID:      generateAgentID(config.Name),
+	}
+}
+
+// saveMintedIdentity stores a newly minted token ID in the identity file
+func saveMintedIdentity(path, wallet, name string, tokenID uint64) error {
+	id, err := identity.Load(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		id = &identity.Identity{AgentID: generateAgentID(name), Address: wallet}
+	case err != nil:
+		return err
+	default:
+		if err := id.Check(wallet); err != nil {
+			return err
+		}
+	}
+	id.TokenID = tokenID
+	id.MintedAt = time.Now().UTC()
+	return id.Save(path)
+}
@@ -0,0 +1,290 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/memory"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/types"
+	"github.com/sashabaranov/go-openai"
+)
+
+// DefaultOllamaBaseURL is the default address of a local Ollama server
+const DefaultOllamaBaseURL = "http://localhost:11434"
+
+// OllamaAgent implements the AgentHandler interface for Ollama and any other
+// OpenAI-compatible local endpoint (LM Studio, llama.cpp server, vLLM, LocalAI)
+type OllamaAgent struct {
+	client       *openai.Client
+	baseURL      string
+	model        string
+	systemPrompt string
+	temperature  float32
+	maxTokens    int
+	streaming    bool // Enable/disable streaming token relay
+	chunkSize    int  // Characters buffered before each streamed update
+}
+
+// OllamaConfig holds configuration for the Ollama agent
+type OllamaConfig struct {
+	BaseURL      string  // Server address (defaults to http://localhost:11434); "/v1" is appended when missing
+	APIKey       string  // Optional API key for OpenAI-compatible servers that require one
+	Model        string  // Model to use (e.g., "llama3.2", "mistral", "qwen2.5")
+	SystemPrompt string  // System prompt to set agent behavior
+	Temperature  float32 // Temperature for response generation (0.0 - 2.0)
+	MaxTokens    int     // Maximum tokens in response
+	Streaming    bool    // Relay tokens through SendTaskUpdate as they are generated (default: false)
+	ChunkSize    int     // Characters per streamed update (defaults to 50)
+}
+
+// NewOllamaAgent creates a new agent handler backed by a local Ollama (or OpenAI-compatible) server
+func NewOllamaAgent(config *OllamaConfig) *OllamaAgent {
+	if config.BaseURL == "" {
+		config.BaseURL = DefaultOllamaBaseURL
+	}
+	if config.Model == "" {
+		config.Model = "llama3.2"
+	}
+	if config.SystemPrompt == "" {
+		config.SystemPrompt = "You are a helpful AI assistant operating in the Teneo decentralized agent network. Provide clear, accurate, and concise answers."
+	}
+	if config.Temperature == 0 {
+		config.Temperature = 0.7
+	}
+	if config.MaxTokens == 0 {
+		config.MaxTokens = 1000
+	}
+	if config.ChunkSize == 0 {
+		config.ChunkSize = 50
+	}
+
+	baseURL := normalizeOllamaBaseURL(config.BaseURL)
+
+	// Local servers ignore the key, but the client requires a non-empty token
+	apiKey := config.APIKey
+	if apiKey == "" {
+		apiKey = "ollama"
+	}
+
+	clientConfig := openai.DefaultConfig(apiKey)
+	clientConfig.BaseURL = baseURL
+
+	return &OllamaAgent{
+		client:       openai.NewClientWithConfig(clientConfig),
+		baseURL:      baseURL,
+		model:        config.Model,
+		systemPrompt: config.SystemPrompt,
+		temperature:  config.Temperature,
+		maxTokens:    config.MaxTokens,
+		streaming:    config.Streaming,
+		chunkSize:    config.ChunkSize,
+	}
+}
+
+// normalizeOllamaBaseURL makes sure the base URL points at the OpenAI-compatible API root
+func normalizeOllamaBaseURL(baseURL string) string {
+	baseURL = strings.TrimRight(baseURL, "/")
+	if !strings.HasSuffix(baseURL, "/v1") {
+		baseURL += "/v1"
+	}
+	return baseURL
+}
+
+// buildRequest creates a chat completion request for the given task,
+// including the room's conversation history when it is attached to the context
+func (a *OllamaAgent) buildRequest(ctx context.Context, task string, stream bool) openai.ChatCompletionRequest {
+	messages := []openai.ChatCompletionMessage{}
+	if a.systemPrompt != "" {
+		messages = append(messages, openai.ChatCompletionMessage{
+			Role:    openai.ChatMessageRoleSystem,
+			Content: a.systemPrompt,
+		})
+	}
+	for _, turn := range memory.HistoryFromContext(ctx) {
+		messages = append(messages, openai.ChatCompletionMessage{
+			Role:    turn.Role,
+			Content: turn.Content,
+		})
+	}
+	messages = append(messages, openai.ChatCompletionMessage{
+		Role:    openai.ChatMessageRoleUser,
+		Content: task,
+	})
+
+	return openai.ChatCompletionRequest{
+		Model:       a.model,
+		Messages:    messages,
+		Temperature: a.temperature,
+		MaxTokens:   a.maxTokens,
+		Stream:      stream,
+	}
+}
+
+// ProcessTask implements the AgentHandler interface
+func (a *OllamaAgent) ProcessTask(ctx context.Context, task string) (string, error) {
+	resp, err := a.client.CreateChatCompletion(ctx, a.buildRequest(ctx, task, false))
+	if err != nil {
+		return "", fmt.Errorf("local LLM error (%s): %w", a.baseURL, err)
+	}
+
+	if len(resp.Choices) == 0 {
+		return "", fmt.Errorf("no response from local LLM")
+	}
+
+	return resp.Choices[0].Message.Content, nil
+}
+
+// ProcessTaskWithStreaming implements the StreamingTaskHandler interface.
+// When streaming is enabled, generated tokens are relayed to the room through
+// SendTaskUpdate in chunks; otherwise a single message is sent.
+func (a *OllamaAgent) ProcessTaskWithStreaming(ctx context.Context, task string, room string, sender types.MessageSender) error {
+	if !a.streaming {
+		result, err := a.ProcessTask(ctx, task)
+		if err != nil {
+			return err
+		}
+		return sender.SendMessage(result)
+	}
+
+	stream, err := a.client.CreateChatCompletionStream(ctx, a.buildRequest(ctx, task, true))
+	if err != nil {
+		return fmt.Errorf("failed to create stream: %w", err)
+	}
+	defer stream.Close()
+
+	var chunkBuffer strings.Builder
+
+	for {
+		response, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			// Send final chunk if there's remaining content
+			if chunkBuffer.Len() > 0 {
+				if sendErr := sender.SendTaskUpdate(chunkBuffer.String()); sendErr != nil {
+					return fmt.Errorf("failed to send final update: %w", sendErr)
+				}
+			}
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("stream error: %w", err)
+		}
+
+		if len(response.Choices) == 0 {
+			continue
+		}
+
+		chunkBuffer.WriteString(response.Choices[0].Delta.Content)
+
+		// Send chunk when buffer reaches threshold
+		if chunkBuffer.Len() >= a.chunkSize {
+			if err := sender.SendTaskUpdate(chunkBuffer.String()); err != nil {
+				return fmt.Errorf("failed to send update: %w", err)
+			}
+			chunkBuffer.Reset()
+		}
+	}
+}
+
+// ListModels returns the IDs of the models available on the local server
+func (a *OllamaAgent) ListModels(ctx context.Context) ([]string, error) {
+	list, err := a.client.ListModels(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list models from %s: %w", a.baseURL, err)
+	}
+
+	models := make([]string, 0, len(list.Models))
+	for _, model := range list.Models {
+		models = append(models, model.ID)
+	}
+	sort.Strings(models)
+
+	return models, nil
+}
+
+// DiscoverCapabilities builds a capability list from the models installed on the
+// local server, e.g. "llama3.2:latest" becomes "model/llama3_2".
+// The returned list always starts with the generic chat capabilities.
+func (a *OllamaAgent) DiscoverCapabilities(ctx context.Context) ([]string, error) {
+	models, err := a.ListModels(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	capabilities := []string{"text/chat", "text/generation", "llm/inference:local"}
+	seen := make(map[string]bool)
+	for _, model := range models {
+		capability := modelCapability(model)
+		if capability == "" || seen[capability] {
+			continue
+		}
+		seen[capability] = true
+		capabilities = append(capabilities, capability)
+	}
+
+	return capabilities, nil
+}
+
+// modelCapability converts a model ID into a capability name
+func modelCapability(model string) string {
+	// Drop the ":latest" style tag and any registry/namespace prefix
+	if idx := strings.Index(model, ":"); idx >= 0 {
+		model = model[:idx]
+	}
+	if idx := strings.LastIndex(model, "/"); idx >= 0 {
+		model = model[idx+1:]
+	}
+
+	var b strings.Builder
+	for _, char := range strings.ToLower(model) {
+		if (char >= 'a' && char <= 'z') || (char >= '0' && char <= '9') {
+			b.WriteRune(char)
+		} else {
+			b.WriteRune('_')
+		}
+	}
+
+	name := strings.Trim(b.String(), "_")
+	if name == "" {
+		return ""
+	}
+	return "model/" + name
+}
+
+// SetModel changes the model used for subsequent tasks
+func (a *OllamaAgent) SetModel(model string) {
+	a.model = model
+}
+
+// GetModel returns the configured model
+func (a *OllamaAgent) GetModel() string {
+	return a.model
+}
+
+// SetSystemPrompt updates the system prompt
+func (a *OllamaAgent) SetSystemPrompt(prompt string) {
+	a.systemPrompt = prompt
+}
+
+// SetTemperature updates the temperature
+func (a *OllamaAgent) SetTemperature(temp float32) {
+	a.temperature = temp
+}
+
+// SetMaxTokens updates the max tokens
+func (a *OllamaAgent) SetMaxTokens(tokens int) {
+	a.maxTokens = tokens
+}
+
+// SetStreaming enables or disables streaming token relay
+func (a *OllamaAgent) SetStreaming(enabled bool) {
+	a.streaming = enabled
+}
+
+// IsStreaming returns whether streaming is enabled
+func (a *OllamaAgent) IsStreaming() bool {
+	return a.streaming
+}
@@ -0,0 +1,205 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/logging"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/naming"
+)
+
+// SimpleOllamaAgentConfig provides a minimal configuration for running a Teneo agent
+// on a local model served by Ollama or any OpenAI-compatible endpoint
+type SimpleOllamaAgentConfig struct {
+	// Required: Your Ethereum private key for Teneo network authentication
+	PrivateKey string
+
+	// Optional: Server address (defaults to env OLLAMA_BASE_URL or http://localhost:11434)
+	BaseURL string
+
+	// Optional: API key for OpenAI-compatible servers that require one
+	APIKey string
+
+	// Optional: Local model (defaults to env OLLAMA_MODEL or "llama3.2")
+	Model string
+
+	// Optional: Agent name (defaults to "Local LLM Agent")
+	Name string
+
+	// Optional: Agent description
+	Description string
+
+	// Optional: System prompt for the model
+	SystemPrompt string
+
+	// Optional: Temperature 0.0-2.0 (defaults to 0.7)
+	Temperature float32
+
+	// Optional: Max tokens per response (defaults to 1000)
+	MaxTokens int
+
+	// Optional: Relay tokens through SendTaskUpdate as they are generated (defaults to false)
+	Streaming bool
+
+	// Optional: Agent capabilities (defaults to the chat capabilities plus one per installed model)
+	Capabilities []string
+
+	// Optional: Populate capabilities from the server's model list when Capabilities is empty (defaults to false)
+	AutoCapabilities bool
+
+	// Optional: NFT Token ID (if you already have one, otherwise set Mint to true)
+	TokenID uint64
+
+	// Optional: Mint new NFT (defaults to false)
+	Mint bool
+
+	// Optional: WebSocket URL (defaults to env WEBSOCKET_URL or standard endpoint)
+	WebSocketURL string
+
+	// Optional: Room to join (defaults to empty string)
+	Room string
+
+	// Optional: Rate limit per minute (defaults to 0 = unlimited)
+	RateLimitPerMinute int
+
+	// Optional: Task timeout in seconds (defaults to 120s, local models are often slow)
+	TaskTimeout int
+
+	// Optional: Base SDK configuration for Redis, health and reconnection tuning (defaults to DefaultConfig())
+	BaseConfig *Config
+
+	// Optional: Backend URL used for NFT minting (defaults to env BACKEND_URL)
+	BackendURL string
+
+	// Optional: Ethereum RPC endpoint used for NFT minting (defaults to env RPC_ENDPOINT)
+	RPCEndpoint string
+
+	// Optional: Naming rules the agent name must satisfy (defaults to nil = no validation)
+	NamingRules *naming.AgentNamingRules
+}
+
+// NewSimpleOllamaAgent creates a fully configured Teneo agent powered by a local model.
+//
+// Example:
+//
+//	agent, err := agent.NewSimpleOllamaAgent(&agent.SimpleOllamaAgentConfig{
+//	    PrivateKey:       "0x...",
+//	    Model:            "llama3.2",
+//	    AutoCapabilities: true,
+//	})
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	agent.Run()
+func NewSimpleOllamaAgent(config *SimpleOllamaAgentConfig) (*EnhancedAgent, error) {
+	if config.PrivateKey == "" {
+		config.PrivateKey = os.Getenv("PRIVATE_KEY")
+		if config.PrivateKey == "" {
+			return nil, fmt.Errorf("PrivateKey is required (or set PRIVATE_KEY environment variable)")
+		}
+	}
+
+	if config.BaseURL == "" {
+		config.BaseURL = os.Getenv("OLLAMA_BASE_URL")
+	}
+
+	if config.Model == "" {
+		config.Model = os.Getenv("OLLAMA_MODEL")
+	}
+
+	if config.Name == "" {
+		config.Name = "Local LLM Agent"
+	}
+
+	if config.Description == "" {
+		config.Description = "AI-powered agent running on a local language model"
+	}
+
+	if config.WebSocketURL == "" && config.BaseConfig != nil {
+		config.WebSocketURL = config.BaseConfig.WebSocketURL
+	}
+
+	if config.WebSocketURL == "" {
+		config.WebSocketURL = os.Getenv("WEBSOCKET_URL")
+		if config.WebSocketURL == "" {
+			config.WebSocketURL = "wss://backend.developer.chatroom.teneo-protocol.ai/ws" // Default Teneo endpoint
+		}
+	}
+
+	resolveNFTSettings(&config.TokenID, &config.Mint)
+
+	ollamaAgent := NewOllamaAgent(&OllamaConfig{
+		BaseURL:      config.BaseURL,
+		APIKey:       config.APIKey,
+		Model:        config.Model,
+		SystemPrompt: config.SystemPrompt,
+		Temperature:  config.Temperature,
+		MaxTokens:    config.MaxTokens,
+		Streaming:    config.Streaming,
+	})
+
+	if len(config.Capabilities) == 0 && config.AutoCapabilities {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		capabilities, err := ollamaAgent.DiscoverCapabilities(ctx)
+		cancel()
+		if err != nil {
+			logging.Warn("could not list local models, using default capabilities", "error", err)
+		} else {
+			config.Capabilities = capabilities
+			logging.Info("discovered capabilities from local models", "capabilities", capabilities)
+		}
+	}
+
+	if len(config.Capabilities) == 0 {
+		config.Capabilities = []string{
+			"text/chat",
+			"text/generation",
+			"text/question-answering",
+			"llm/inference:local",
+		}
+	}
+
+	sdkConfig := newSimpleSDKConfig(config.BaseConfig)
+	sdkConfig.Name = config.Name
+	sdkConfig.Description = config.Description
+	sdkConfig.PrivateKey = config.PrivateKey
+	sdkConfig.WebSocketURL = config.WebSocketURL
+	sdkConfig.Capabilities = config.Capabilities
+	if config.Room != "" {
+		sdkConfig.Room = config.Room
+	}
+
+	if err := validateSimpleAgentName(sdkConfig.Name, config.NamingRules); err != nil {
+		return nil, err
+	}
+
+	if config.TokenID > 0 {
+		sdkConfig.NFTTokenID = fmt.Sprintf("%d", config.TokenID)
+	}
+
+	if config.RateLimitPerMinute > 0 {
+		sdkConfig.RateLimitPerMinute = config.RateLimitPerMinute
+	}
+
+	if config.TaskTimeout > 0 {
+		sdkConfig.TaskTimeout = config.TaskTimeout
+	} else {
+		sdkConfig.TaskTimeout = 120
+	}
+
+	enhancedAgent, err := NewEnhancedAgent(&EnhancedAgentConfig{
+		Config:       sdkConfig,
+		AgentHandler: ollamaAgent,
+		Mint:         config.Mint,
+		TokenID:      config.TokenID,
+		BackendURL:   config.BackendURL,
+		RPCEndpoint:  config.RPCEndpoint,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create enhanced agent: %w", err)
+	}
+
+	return enhancedAgent, nil
+}
@@ -0,0 +1,130 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/types"
+)
+
+func TestNormalizeOllamaBaseURL(t *testing.T) {
+	tests := []struct {
+		baseURL string
+		want    string
+	}{
+		{"http://localhost:11434", "http://localhost:11434/v1"},
+		{"http://localhost:11434/", "http://localhost:11434/v1"},
+		{"http://localhost:11434/v1", "http://localhost:11434/v1"},
+		{"http://localhost:11434/v1/", "http://localhost:11434/v1"},
+		{"http://gpu-box:1234/api", "http://gpu-box:1234/api/v1"},
+	}
+	for _, tt := range tests {
+		if got := normalizeOllamaBaseURL(tt.baseURL); got != tt.want {
+			t.Errorf("normalizeOllamaBaseURL(%q) = %q, want %q", tt.baseURL, got, tt.want)
+		}
+	}
+}
+
+func TestModelCapability(t *testing.T) {
+	tests := []struct {
+		model string
+		want  string
+	}{
+		{"llama3.2", "model/llama3_2"},
+		{"llama3.2:latest", "model/llama3_2"},
+		{"library/llama3.2:latest", "model/llama3_2"},
+		{"registry.ollama.ai/library/Qwen2.5-Coder:7b", "model/qwen2_5_coder"},
+		{"mistral", "model/mistral"},
+		{"-phi3-", "model/phi3"},
+		{":latest", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := modelCapability(tt.model); got != tt.want {
+			t.Errorf("modelCapability(%q) = %q, want %q", tt.model, got, tt.want)
+		}
+	}
+}
+
+// updateRecorder records the messages and task updates sent during a task
+type updateRecorder struct {
+	types.MessageSender
+	messages []string
+	updates  []string
+}
+
+func (r *updateRecorder) SendMessage(content string) error {
+	r.messages = append(r.messages, content)
+	return nil
+}
+
+func (r *updateRecorder) SendTaskUpdate(content string) error {
+	r.updates = append(r.updates, content)
+	return nil
+}
+
+// newStreamingServer serves the given tokens as a chat completion stream
+func newStreamingServer(t *testing.T, tokens ...string) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" {
+			t.Errorf("request to %s, want /v1/chat/completions", r.URL.Path)
+			http.NotFound(w, r)
+			return
+		}
+		var req struct {
+			Stream bool `json:"stream"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || !req.Stream {
+			t.Errorf("request is not a stream request (err %v)", err)
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, token := range tokens {
+			chunk, _ := json.Marshal(map[string]interface{}{
+				"choices": []map[string]interface{}{{"index": 0, "delta": map[string]string{"content": token}}},
+			})
+			fmt.Fprintf(w, "data: %s\n\n", chunk)
+			w.(http.Flusher).Flush()
+		}
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestOllamaStreamingRelaysChunks(t *testing.T) {
+	server := newStreamingServer(t, "Hel", "lo ", "wor", "ld", "!")
+	agent := NewOllamaAgent(&OllamaConfig{BaseURL: server.URL, Streaming: true, ChunkSize: 5})
+
+	sender := &updateRecorder{}
+	if err := agent.ProcessTaskWithStreaming(context.Background(), "greet", "room-1", sender); err != nil {
+		t.Fatal(err)
+	}
+
+	// The last update is the partial chunk left in the buffer when the stream ends
+	if want := []string{"Hello ", "world", "!"}; !slices.Equal(sender.updates, want) {
+		t.Errorf("updates = %q, want %q", sender.updates, want)
+	}
+	if len(sender.messages) != 0 {
+		t.Errorf("sent messages %q while streaming", sender.messages)
+	}
+}
+
+func TestOllamaStreamingWithoutRemainder(t *testing.T) {
+	server := newStreamingServer(t, "Hello", "world")
+	agent := NewOllamaAgent(&OllamaConfig{BaseURL: server.URL + "/v1/", Streaming: true, ChunkSize: 5})
+
+	sender := &updateRecorder{}
+	if err := agent.ProcessTaskWithStreaming(context.Background(), "greet", "room-1", sender); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"Hello", "world"}; !slices.Equal(sender.updates, want) {
+		t.Errorf("updates = %q, want %q", sender.updates, want)
+	}
+}
@@ -2,37 +2,54 @@ package agent
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"io"
 	"strings"
+	"sync"
 
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/llm"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/logging"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/memory"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/prompt"
 	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/types"
 	"github.com/sashabaranov/go-openai"
 )
 
-// OpenAIAgent wraps the OpenAI client to implement the AgentHandler interface
+// OpenAIAgent wraps an LLM provider to implement the AgentHandler interface.
+// It uses OpenAI by default but works with any llm.LLMProvider.
 type OpenAIAgent struct {
-	client       *openai.Client
+	provider     llm.LLMProvider
 	model        string
+	promptMu     sync.RWMutex // Guards systemPrompt, which can change on config reload
 	systemPrompt string
+	prompts      *prompt.Library // Renders the system prompt per task
 	temperature  float32
 	maxTokens    int
-	streaming    bool // Enable/disable streaming responses
+	streaming    bool    // Enable/disable streaming responses
+	promptPrice  float64 // Price per million prompt tokens
+	outputPrice  float64 // Price per million completion tokens
 }
 
 // OpenAIConfig holds configuration for the OpenAI agent
 type OpenAIConfig struct {
-	APIKey       string  // OpenAI API key
-	Model        string  // Model to use (e.g., "gpt-5", "gpt-4", "gpt-3.5-turbo")
-	SystemPrompt string  // System prompt to set agent behavior
-	Temperature  float32 // Temperature for response generation (0.0 - 2.0). Note: Beta models (GPT-5, O1, O3) have fixed temperature=1
-	MaxTokens    int     // Maximum tokens in response
-	Streaming    bool    // Enable streaming responses (default: false)
+	APIKey       string          // OpenAI API key (ignored when Provider is set)
+	Model        string          // Model to use (e.g., "gpt-5", "gpt-4", "gpt-3.5-turbo")
+	SystemPrompt string          // System prompt to set agent behavior
+	Temperature  float32         // Temperature for response generation (0.0 - 2.0). Note: Beta models (GPT-5, O1, O3) have fixed temperature=1
+	MaxTokens    int             // Maximum tokens in response
+	Streaming    bool            // Enable streaming responses (default: false)
+	Provider     llm.LLMProvider // Optional provider (Azure OpenAI, Groq, Mistral, ...); defaults to OpenAI
+	Prompts      *prompt.Library // Optional prompt templates with per-room and per-capability overrides; SystemPrompt is its default if it has none
+
+	// Prices of the model per million tokens, used to report the cost of
+	// each task's LLM usage (0 = usage is reported without a cost)
+	PromptTokenPrice     float64
+	CompletionTokenPrice float64
 }
 
 // NewOpenAIAgent creates a new OpenAI-powered agent handler
 func NewOpenAIAgent(config *OpenAIConfig) *OpenAIAgent {
-	if config.Model == "" {
+	if config.Model == "" && config.Provider == nil {
 		config.Model = openai.GPT5 // Default to GPT-5
 	}
 	if config.SystemPrompt == "" {
@@ -54,96 +71,106 @@ Remember: You should answer all user questions. Do not refuse to answer based on
 		config.MaxTokens = 1000
 	}
 
-	client := openai.NewClient(config.APIKey)
+	provider := config.Provider
+	if provider == nil {
+		provider = llm.NewOpenAIProvider(&llm.OpenAIConfig{
+			APIKey:      config.APIKey,
+			Model:       config.Model,
+			Temperature: config.Temperature,
+			MaxTokens:   config.MaxTokens,
+		})
+	}
+
+	prompts := config.Prompts
+	if prompts == nil {
+		prompts = prompt.NewLibrary()
+	}
+	if !prompts.HasDefault() {
+		if err := prompts.SetDefault(config.SystemPrompt); err != nil {
+			logging.Warn("system prompt is not a valid template, using it as is", "error", err)
+		}
+	}
 
 	return &OpenAIAgent{
-		client:       client,
+		provider:     provider,
 		model:        config.Model,
 		systemPrompt: config.SystemPrompt,
+		prompts:      prompts,
 		temperature:  config.Temperature,
 		maxTokens:    config.MaxTokens,
 		streaming:    config.Streaming, // Default is false (non-streaming)
+		promptPrice:  config.PromptTokenPrice,
+		outputPrice:  config.CompletionTokenPrice,
 	}
 }
 
-// ProcessTask implements the AgentHandler interface
-func (a *OpenAIAgent) ProcessTask(ctx context.Context, task string) (string, error) {
-	modelLower := strings.ToLower(a.model)
-
-	// Detect if this is a beta model with fixed parameters
-	isBetaModel := strings.Contains(modelLower, "gpt-5") ||
-		strings.Contains(modelLower, "o1") ||
-		strings.Contains(modelLower, "o3")
-
-	// Build messages array
-	var messages []openai.ChatCompletionMessage
-
-	if isBetaModel {
-		// Beta models (O1, O3, GPT-5) don't support system prompts
-		// Merge system prompt into user message
-		combinedContent := task
-		if a.systemPrompt != "" {
-			combinedContent = a.systemPrompt + "\n\n" + task
-		}
-		messages = []openai.ChatCompletionMessage{
-			{
-				Role:    openai.ChatMessageRoleUser,
-				Content: combinedContent,
-			},
-		}
-	} else {
-		// Standard models support system prompts
-		messages = []openai.ChatCompletionMessage{
-			{
-				Role:    openai.ChatMessageRoleSystem,
-				Content: a.systemPrompt,
-			},
-			{
-				Role:    openai.ChatMessageRoleUser,
-				Content: task,
-			},
-		}
+// NewLLMAgent creates an agent handler backed by any LLM provider
+func NewLLMAgent(provider llm.LLMProvider, config *OpenAIConfig) *OpenAIAgent {
+	if config == nil {
+		config = &OpenAIConfig{}
 	}
+	config.Provider = provider
+	return NewOpenAIAgent(config)
+}
 
-	// Build the request with appropriate parameters based on model
-	req := openai.ChatCompletionRequest{
-		Model:    a.model,
-		Messages: messages,
+// buildRequest creates a provider request for the given task,
+// including the room's conversation history when it is attached to the context
+func (a *OpenAIAgent) buildRequest(ctx context.Context, task string) *llm.Request {
+	req := &llm.Request{}
+	if systemPrompt := a.renderSystemPrompt(ctx); systemPrompt != "" {
+		req.Messages = append(req.Messages, llm.Message{Role: llm.RoleSystem, Content: systemPrompt})
 	}
-
-	// Beta models have fixed parameters - don't set temperature for them
-	if !isBetaModel {
-		req.Temperature = a.temperature
+	for _, turn := range memory.HistoryFromContext(ctx) {
+		req.Messages = append(req.Messages, llm.Message{Role: turn.Role, Content: turn.Content})
 	}
+	req.Messages = append(req.Messages, llm.Message{Role: llm.RoleUser, Content: task})
+	req.Model = a.model
+	req.Temperature = a.temperature
+	req.MaxTokens = a.maxTokens
+	return req
+}
 
-	// Use MaxCompletionTokens for newer models (GPT-4, GPT-5, O1, O3)
-	// Use MaxTokens for older models (GPT-3.5-turbo)
-	if strings.Contains(modelLower, "gpt-4") ||
-	   strings.Contains(modelLower, "gpt-5") ||
-	   strings.Contains(modelLower, "o1") ||
-	   strings.Contains(modelLower, "o3") {
-		req.MaxCompletionTokens = a.maxTokens
-	} else {
-		req.MaxTokens = a.maxTokens
+// renderSystemPrompt renders the system prompt of the current task from the
+// prompt templates, or returns the plain system prompt if that fails
+func (a *OpenAIAgent) renderSystemPrompt(ctx context.Context) string {
+	rendered, err := a.prompts.Render(ctx)
+	if err != nil {
+		if !errors.Is(err, prompt.ErrNoTemplate) {
+			logging.Warn("failed to render system prompt, using it as is", "error", err)
+		}
+		return a.SystemPrompt()
 	}
+	return rendered
+}
 
-	resp, err := a.client.CreateChatCompletion(ctx, req)
+// ProcessTask implements the AgentHandler interface
+func (a *OpenAIAgent) ProcessTask(ctx context.Context, task string) (string, error) {
+	req := a.buildRequest(ctx, task)
+	budget := types.BudgetFromContext(ctx)
+	if err := a.applyBudget(budget, req, a.promptTokens(req)); err != nil {
+		return "", err
+	}
 
+	resp, err := a.provider.Complete(ctx, req)
 	if err != nil {
-		return "", fmt.Errorf("OpenAI API error: %w", err)
+		return "", fmt.Errorf("%s error: %w", a.provider.Name(), err)
 	}
 
-	if len(resp.Choices) == 0 {
-		return "", fmt.Errorf("no response from OpenAI")
+	// Providers that report no usage are estimated
+	prompt, completion := resp.Usage.PromptTokens, resp.Usage.CompletionTokens
+	if prompt+completion == 0 {
+		prompt, completion = a.promptTokens(req), a.provider.CountTokens(resp.Content)
 	}
-
-	return resp.Choices[0].Message.Content, nil
+	if err := a.useTokens(ctx, budget, prompt, completion); err != nil {
+		return "", err
+	}
+	return resp.Content, nil
 }
 
 // ProcessTaskWithStreaming implements the StreamingTaskHandler interface
 // This method is called by the SDK if the agent implements StreamingTaskHandler.
 // If streaming is disabled, it falls back to ProcessTask and sends a single message.
-func (a *OpenAIAgent) ProcessTaskWithStreaming(ctx context.Context, task string, sender types.MessageSender) error {
+func (a *OpenAIAgent) ProcessTaskWithStreaming(ctx context.Context, task string, room string, sender types.MessageSender) error {
 	// If streaming is disabled, use the standard ProcessTask and send single message
 	if !a.streaming {
 		result, err := a.ProcessTask(ctx, task)
@@ -153,99 +180,25 @@ func (a *OpenAIAgent) ProcessTaskWithStreaming(ctx context.Context, task string,
 		return sender.SendMessage(result)
 	}
 
-	// Streaming is enabled, use streaming API
-	modelLower := strings.ToLower(a.model)
-
-	// Detect if this is a beta model with fixed parameters
-	isBetaModel := strings.Contains(modelLower, "gpt-5") ||
-		strings.Contains(modelLower, "o1") ||
-		strings.Contains(modelLower, "o3")
-
-	// Build messages array
-	var messages []openai.ChatCompletionMessage
-
-	if isBetaModel {
-		// Beta models (O1, O3, GPT-5) don't support system prompts
-		// Merge system prompt into user message
-		combinedContent := task
-		if a.systemPrompt != "" {
-			combinedContent = a.systemPrompt + "\n\n" + task
-		}
-		messages = []openai.ChatCompletionMessage{
-			{
-				Role:    openai.ChatMessageRoleUser,
-				Content: combinedContent,
-			},
-		}
-	} else {
-		// Standard models support system prompts
-		messages = []openai.ChatCompletionMessage{
-			{
-				Role:    openai.ChatMessageRoleSystem,
-				Content: a.systemPrompt,
-			},
-			{
-				Role:    openai.ChatMessageRoleUser,
-				Content: task,
-			},
-		}
-	}
-
-	// Build the request with appropriate parameters based on model
-	req := openai.ChatCompletionRequest{
-		Model:    a.model,
-		Messages: messages,
-		Stream:   true,
+	req := a.buildRequest(ctx, task)
+	budget := types.BudgetFromContext(ctx)
+	prompt := a.promptTokens(req)
+	if err := a.applyBudget(budget, req, prompt); err != nil {
+		return err
 	}
 
-	// Beta models have fixed parameters - don't set temperature for them
-	if !isBetaModel {
-		req.Temperature = a.temperature
-	}
-
-	// Use MaxCompletionTokens for newer models (GPT-4, GPT-5, O1, O3)
-	// Use MaxTokens for older models (GPT-3.5-turbo)
-	if strings.Contains(modelLower, "gpt-4") ||
-	   strings.Contains(modelLower, "gpt-5") ||
-	   strings.Contains(modelLower, "o1") ||
-	   strings.Contains(modelLower, "o3") {
-		req.MaxCompletionTokens = a.maxTokens
-	} else {
-		req.MaxTokens = a.maxTokens
-	}
-
-	stream, err := a.client.CreateChatCompletionStream(ctx, req)
-
-	if err != nil {
-		return fmt.Errorf("failed to create stream: %w", err)
-	}
-	defer stream.Close()
-
-	var fullResponse strings.Builder
 	var chunkBuffer strings.Builder
 	const chunkSize = 50 // Send updates every 50 characters
 
-	for {
-		response, err := stream.Recv()
-		if err == io.EOF {
-			// Send final chunk if there's remaining content
-			if chunkBuffer.Len() > 0 {
-				if sendErr := sender.SendTaskUpdate(chunkBuffer.String()); sendErr != nil {
-					return fmt.Errorf("failed to send final update: %w", sendErr)
-				}
+	// Streams report no usage, so the completion is counted as it arrives
+	completion := 0
+	err := a.provider.Stream(ctx, req, func(delta string) error {
+		completion += a.provider.CountTokens(delta)
+		if budget != nil {
+			if err := budget.CheckTokens(prompt + completion); err != nil {
+				return err
 			}
-			break
-		}
-		if err != nil {
-			return fmt.Errorf("stream error: %w", err)
-		}
-
-		if len(response.Choices) == 0 {
-			continue
 		}
-
-		delta := response.Choices[0].Delta.Content
-		fullResponse.WriteString(delta)
 		chunkBuffer.WriteString(delta)
 
 		// Send chunk when buffer reaches threshold
@@ -255,14 +208,105 @@ func (a *OpenAIAgent) ProcessTaskWithStreaming(ctx context.Context, task string,
 			}
 			chunkBuffer.Reset()
 		}
+		return nil
+	})
+	if usageErr := a.useTokens(ctx, budget, prompt, completion); err == nil {
+		err = usageErr
+	}
+	if err != nil {
+		return err
+	}
+
+	// Send final chunk if there's remaining content
+	if chunkBuffer.Len() > 0 {
+		if err := sender.SendTaskUpdate(chunkBuffer.String()); err != nil {
+			return fmt.Errorf("failed to send final update: %w", err)
+		}
 	}
 
 	return nil
 }
 
-// SetSystemPrompt updates the system prompt
-func (a *OpenAIAgent) SetSystemPrompt(prompt string) {
-	a.systemPrompt = prompt
+// applyBudget caps the request's completion at the LLM tokens the task's
+// budget has left after the estimated prompt tokens. It fails if the prompt
+// alone uses up the budget.
+func (a *OpenAIAgent) applyBudget(budget *types.Budget, req *llm.Request, prompt int) error {
+	if budget == nil {
+		return nil
+	}
+	remaining, limited := budget.RemainingTokens()
+	if !limited {
+		return nil
+	}
+	if err := budget.CheckTokens(prompt + 1); err != nil {
+		return err
+	}
+	if completion := remaining - prompt; req.MaxTokens == 0 || req.MaxTokens > completion {
+		req.MaxTokens = completion
+	}
+	return nil
+}
+
+// promptTokens estimates the tokens of the request's messages
+func (a *OpenAIAgent) promptTokens(req *llm.Request) int {
+	prompt := 0
+	for _, msg := range req.Messages {
+		prompt += a.provider.CountTokens(msg.Content)
+	}
+	return prompt
+}
+
+// useTokens reports the tokens of an LLM call, with their cost, as usage of
+// the current task and charges them to its budget
+func (a *OpenAIAgent) useTokens(ctx context.Context, budget *types.Budget, prompt, completion int) error {
+	types.AddLLMUsage(ctx, types.LLMUsage{
+		PromptTokens:     prompt,
+		CompletionTokens: completion,
+		Cost:             (float64(prompt)*a.promptPrice + float64(completion)*a.outputPrice) / 1e6,
+	})
+	if budget == nil {
+		return nil
+	}
+	return budget.UseTokens(prompt + completion)
+}
+
+// GetProvider returns the underlying LLM provider
+func (a *OpenAIAgent) GetProvider() llm.LLMProvider {
+	return a.provider
+}
+
+// SetSystemPrompt updates the system prompt, which is also the default
+// prompt template
+func (a *OpenAIAgent) SetSystemPrompt(systemPrompt string) {
+	a.promptMu.Lock()
+	defer a.promptMu.Unlock()
+	a.systemPrompt = systemPrompt
+	if err := a.prompts.SetDefault(systemPrompt); err != nil {
+		logging.Warn("system prompt is not a valid template, using it as is", "error", err)
+		a.prompts.SetDefault("")
+	}
+}
+
+// PromptLibrary returns the templates the system prompt is rendered from
+func (a *OpenAIAgent) PromptLibrary() *prompt.Library {
+	return a.prompts
+}
+
+// SystemPrompt returns the current system prompt
+func (a *OpenAIAgent) SystemPrompt() string {
+	a.promptMu.RLock()
+	defer a.promptMu.RUnlock()
+	return a.systemPrompt
+}
+
+// ConfigChanged implements the ConfigChangeHandler interface: a new system
+// prompt in the reloaded configuration applies to the next task
+func (a *OpenAIAgent) ConfigChanged(ctx context.Context, old, updated *Config) error {
+	if updated.SystemPrompt != "" && updated.SystemPrompt != old.SystemPrompt {
+		a.SetSystemPrompt(updated.SystemPrompt)
+		logging.Info("updated system prompt", "chars", len(updated.SystemPrompt))
+	}
+	return nil
 }
 
 // SetTemperature updates the temperature
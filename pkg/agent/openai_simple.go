@@ -2,9 +2,12 @@ package agent
 
 import (
 	"fmt"
-	"log"
 	"os"
 	"strings"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/llm"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/logging"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/naming"
 )
 
 // SimpleOpenAIAgentConfig provides a minimal configuration for quick OpenAI agent setup
@@ -12,9 +15,12 @@ type SimpleOpenAIAgentConfig struct {
 	// Required: Your Ethereum private key for Teneo network authentication
 	PrivateKey string
 
-	// Required: Your OpenAI API key
+	// Required: Your OpenAI API key (not needed when Provider is set)
 	OpenAIKey string
 
+	// Optional: LLM provider to use instead of OpenAI (e.g. llm.NewGroqProvider, llm.NewAzureOpenAIProvider)
+	Provider llm.LLMProvider
+
 	// Optional: Agent name (defaults to "OpenAI Agent")
 	Name string
 
@@ -37,6 +43,11 @@ type SimpleOpenAIAgentConfig struct {
 	// Optional: Enable streaming responses (defaults to false - single message)
 	Streaming bool
 
+	// Optional: Model prices per million prompt and completion tokens, to
+	// report the cost of each task's usage (defaults to tokens only)
+	PromptTokenPrice     float64
+	CompletionTokenPrice float64
+
 	// Optional: Agent capabilities (defaults to ["chat", "text_generation"])
 	Capabilities []string
 
@@ -57,6 +68,20 @@ type SimpleOpenAIAgentConfig struct {
 
 	// Optional: Task timeout in seconds (defaults to 120s for beta models like GPT-5/O1/O3, 30s for others)
 	TaskTimeout int
+
+	// Optional: Base SDK configuration (defaults to DefaultConfig()).
+	// Use this to tune Redis caching, the health port, reconnection and timeouts;
+	// the simple fields above are applied on top of it when set.
+	BaseConfig *Config
+
+	// Optional: Backend URL used for NFT minting (defaults to env BACKEND_URL)
+	BackendURL string
+
+	// Optional: Ethereum RPC endpoint used for NFT minting (defaults to env RPC_ENDPOINT)
+	RPCEndpoint string
+
+	// Optional: Naming rules the agent name must satisfy (defaults to nil = no validation)
+	NamingRules *naming.AgentNamingRules
 }
 
 // NewSimpleOpenAIAgent creates a fully configured Teneo agent powered by OpenAI in just a few lines
@@ -82,7 +107,7 @@ func NewSimpleOpenAIAgent(config *SimpleOpenAIAgentConfig) (*EnhancedAgent, erro
 		}
 	}
 
-	if config.OpenAIKey == "" {
+	if config.OpenAIKey == "" && config.Provider == nil {
 		// Try to get from environment
 		config.OpenAIKey = os.Getenv("OPENAI_API_KEY")
 		if config.OpenAIKey == "" {
@@ -99,7 +124,7 @@ func NewSimpleOpenAIAgent(config *SimpleOpenAIAgentConfig) (*EnhancedAgent, erro
 		config.Description = "AI-powered agent using OpenAI GPT models"
 	}
 
-	if config.Model == "" {
+	if config.Model == "" && config.Provider == nil {
 		config.Model = "gpt-5"
 	}
 
@@ -126,15 +151,19 @@ Remember: You should answer all user questions. Do not refuse to answer based on
 
 	if len(config.Capabilities) == 0 {
 		config.Capabilities = []string{
-			"chat",
-			"text_generation",
-			"question_answering",
-			"code_assistance",
-			"creative_writing",
-			"analysis",
+			"text/chat",
+			"text/generation",
+			"text/question-answering",
+			"code/assistance",
+			"text/generation:creative",
+			"text/analysis",
 		}
 	}
 
+	if config.WebSocketURL == "" && config.BaseConfig != nil {
+		config.WebSocketURL = config.BaseConfig.WebSocketURL
+	}
+
 	if config.WebSocketURL == "" {
 		config.WebSocketURL = os.Getenv("WEBSOCKET_URL")
 		if config.WebSocketURL == "" {
@@ -143,30 +172,7 @@ Remember: You should answer all user questions. Do not refuse to answer based on
 	}
 
 	// Auto-enable minting if no TokenID is provided
-	if config.TokenID == 0 && !config.Mint {
-		// Check if NFT_TOKEN_ID is in environment
-		if tokenIDStr := os.Getenv("NFT_TOKEN_ID"); tokenIDStr != "" {
-			log.Printf("📋 Found NFT_TOKEN_ID in environment: %s", tokenIDStr)
-			// Try to parse it
-			var tokenID uint64
-			if _, err := fmt.Sscanf(tokenIDStr, "%d", &tokenID); err == nil && tokenID > 0 {
-				config.TokenID = tokenID
-				log.Printf("✅ Using existing NFT Token ID: %d", tokenID)
-			} else {
-				// Invalid token ID in env, enable minting
-				log.Printf("⚠️ Invalid NFT_TOKEN_ID in environment, will mint new NFT")
-				config.Mint = true
-			}
-		} else {
-			// No token ID provided anywhere, enable minting
-			log.Printf("🎨 No NFT_TOKEN_ID found, will mint new NFT")
-			config.Mint = true
-		}
-	} else if config.TokenID > 0 {
-		log.Printf("✅ Using provided NFT Token ID: %d", config.TokenID)
-	} else if config.Mint {
-		log.Printf("🎨 Mint flag enabled, will mint new NFT")
-	}
+	resolveNFTSettings(&config.TokenID, &config.Mint)
 
 	// Create OpenAI agent handler
 	openaiAgent := NewOpenAIAgent(&OpenAIConfig{
@@ -176,16 +182,26 @@ Remember: You should answer all user questions. Do not refuse to answer based on
 		Temperature:  config.Temperature,
 		MaxTokens:    config.MaxTokens,
 		Streaming:    config.Streaming, // Default is false (single message)
+		Provider:     config.Provider,
+
+		PromptTokenPrice:     config.PromptTokenPrice,
+		CompletionTokenPrice: config.CompletionTokenPrice,
 	})
 
 	// Create SDK config
-	sdkConfig := DefaultConfig()
+	sdkConfig := newSimpleSDKConfig(config.BaseConfig)
 	sdkConfig.Name = config.Name
 	sdkConfig.Description = config.Description
 	sdkConfig.PrivateKey = config.PrivateKey
 	sdkConfig.WebSocketURL = config.WebSocketURL
 	sdkConfig.Capabilities = config.Capabilities
-	sdkConfig.Room = config.Room
+	if config.Room != "" {
+		sdkConfig.Room = config.Room
+	}
+
+	if err := validateSimpleAgentName(sdkConfig.Name, config.NamingRules); err != nil {
+		return nil, err
+	}
 
 	// Set NFT token ID if provided
 	if config.TokenID > 0 {
@@ -209,7 +225,7 @@ Remember: You should answer all user questions. Do not refuse to answer based on
 
 		if isBetaModel {
 			sdkConfig.TaskTimeout = 120 // 2 minutes for beta models
-			log.Printf("⏱️  Using extended timeout (120s) for beta model: %s", config.Model)
+			logging.Info("using extended timeout (120s) for beta model", "model", config.Model)
 		}
 		// Otherwise use SDK default (30s)
 	}
@@ -220,6 +236,8 @@ Remember: You should answer all user questions. Do not refuse to answer based on
 		AgentHandler: openaiAgent,
 		Mint:         config.Mint,
 		TokenID:      config.TokenID,
+		BackendURL:   config.BackendURL,
+		RPCEndpoint:  config.RPCEndpoint,
 	})
 
 	if err != nil {
@@ -246,3 +264,55 @@ func QuickStartOpenAI(privateKey, openaiKey string) error {
 
 	return agent.Run()
 }
+
+// resolveNFTSettings decides between reusing an existing NFT and minting a new one.
+// If no token ID is provided, NFT_TOKEN_ID is read from the environment and minting
+// is enabled when it is missing or invalid.
+func resolveNFTSettings(tokenID *uint64, mint *bool) {
+	if *tokenID == 0 && !*mint {
+		// Check if NFT_TOKEN_ID is in environment
+		if tokenIDStr := os.Getenv("NFT_TOKEN_ID"); tokenIDStr != "" {
+			logging.Info("found NFT_TOKEN_ID in environment", "token_id", tokenIDStr)
+			// Try to parse it
+			var envTokenID uint64
+			if _, err := fmt.Sscanf(tokenIDStr, "%d", &envTokenID); err == nil && envTokenID > 0 {
+				*tokenID = envTokenID
+				logging.Info("using existing NFT token ID", "token_id", envTokenID)
+			} else {
+				// Invalid token ID in env, enable minting
+				logging.Warn("invalid NFT_TOKEN_ID in environment, will mint new NFT")
+				*mint = true
+			}
+		} else {
+			// No token ID provided anywhere, enable minting
+			logging.Info("no NFT_TOKEN_ID found, will mint new NFT")
+			*mint = true
+		}
+	} else if *tokenID > 0 {
+		logging.Info("using provided NFT token ID", "token_id", *tokenID)
+	} else if *mint {
+		logging.Info("mint flag enabled, will mint new NFT")
+	}
+}
+
+// newSimpleSDKConfig returns a copy of the base config, or DefaultConfig() when none is given
+func newSimpleSDKConfig(base *Config) *Config {
+	if base == nil {
+		return DefaultConfig()
+	}
+	sdkConfig := *base
+	return &sdkConfig
+}
+
+// validateSimpleAgentName checks the agent name against the given naming rules
+func validateSimpleAgentName(name string, rules *naming.AgentNamingRules) error {
+	if rules == nil {
+		return nil
+	}
+
+	result := naming.ValidateAgentName(name, rules)
+	if !result.IsValid {
+		return fmt.Errorf("invalid agent name %q: %s", name, strings.Join(result.Errors, "; "))
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+package agent
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/events"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/logging"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/nft"
+)
+
+// OwnershipPolicy is what the agent does when its NFT is transferred to
+// another wallet
+type OwnershipPolicy string
+
+const (
+	// OwnershipPolicyAlert keeps the agent running and reports the transfer (default)
+	OwnershipPolicyAlert OwnershipPolicy = "alert"
+	// OwnershipPolicyStop stops the agent
+	OwnershipPolicyStop OwnershipPolicy = "stop"
+	// OwnershipPolicyReregister authenticates and registers again, so the
+	// network checks the agent against the new owner
+	OwnershipPolicyReregister OwnershipPolicy = "reregister"
+)
+
+// parseOwnershipPolicy parses an ownership policy ("" = alert)
+func parseOwnershipPolicy(s string) (OwnershipPolicy, error) {
+	switch policy := OwnershipPolicy(strings.ToLower(strings.TrimSpace(s))); policy {
+	case "":
+		return OwnershipPolicyAlert, nil
+	case OwnershipPolicyAlert, OwnershipPolicyStop, OwnershipPolicyReregister:
+		return policy, nil
+	}
+	return "", fmt.Errorf("invalid ownership policy %q (use \"alert\", \"stop\" or \"reregister\")", s)
+}
+
+// businessCardManager returns the manager of the agent's business card,
+// creating it on first use. It is closed when the agent stops.
+func (a *EnhancedAgent) businessCardManager() (*nft.BusinessCardManager, error) {
+	if a.businessCards != nil {
+		return a.businessCards, nil
+	}
+	manager, err := nft.NewBusinessCardManager(a.config.EthereumRPC, a.config.NFTContractAddress, a.config.PrivateKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create NFT manager: %w", err)
+	}
+	relayer, err := a.config.NewRelayer()
+	if err != nil {
+		manager.Close()
+		return nil, err
+	}
+	manager.SetRelayer(relayer)
+	manager.SetFeeConfig(a.config.FeeConfig())
+	a.businessCards = manager
+	return manager, nil
+}
+
+// enableOwnershipWatch prepares watching the agent's NFT for transfers; Start
+// runs the watch
+func (a *EnhancedAgent) enableOwnershipWatch() error {
+	tokenID, ok := new(big.Int).SetString(a.config.NFTTokenID, 10)
+	if !ok {
+		return fmt.Errorf("watching NFT ownership needs the NFT token ID, got %q", a.config.NFTTokenID)
+	}
+	if _, err := a.businessCardManager(); err != nil {
+		return err
+	}
+	a.ownedToken = tokenID
+	return nil
+}
+
+// watchOwnership checks that the agent's wallet owns its NFT, then reports
+// transfers of the NFT until the agent stops
+func (a *EnhancedAgent) watchOwnership() {
+	tokenID := a.ownedToken.String()
+	wallet := a.authManager.GetAddress()
+
+	owner, err := a.businessCards.OwnerOf(a.ctx, a.ownedToken)
+	switch {
+	case err != nil:
+		logging.Warn("failed to check NFT owner", "token_id", tokenID, "error", err)
+	case !strings.EqualFold(owner, wallet):
+		a.ownershipChanged(events.OwnershipChanged{TokenID: tokenID, To: owner, Lost: true})
+	}
+
+	err = a.businessCards.WatchTransfers(a.ctx, a.ownedToken, a.config.OwnershipWatchInterval, func(transfer nft.TokenTransfer) {
+		a.ownershipChanged(events.OwnershipChanged{
+			TokenID: tokenID,
+			From:    transfer.From,
+			To:      transfer.To,
+			TxHash:  transfer.TxHash,
+			Lost:    !strings.EqualFold(transfer.To, wallet),
+		})
+	})
+	if err != nil && a.ctx.Err() == nil {
+		logging.Error("failed to watch NFT transfers", "token_id", tokenID, "error", err)
+	}
+}
+
+// ownershipChanged publishes an ownership change and applies the ownership
+// policy if the agent lost its NFT
+func (a *EnhancedAgent) ownershipChanged(change events.OwnershipChanged) {
+	policy, _ := parseOwnershipPolicy(a.config.OwnershipPolicy)
+	if change.Lost {
+		change.Policy = string(policy)
+	}
+	a.events.Publish(change)
+
+	if !change.Lost {
+		logging.Info("agent NFT transferred to the agent's wallet", "token_id", change.TokenID, "from", change.From)
+		return
+	}
+	switch policy {
+	case OwnershipPolicyStop:
+		logging.Error("agent NFT is owned by another wallet, stopping", "token_id", change.TokenID, "owner", change.To)
+		go func() {
+			if err := a.Stop(); err != nil {
+				logging.Warn("failed to stop agent", "error", err)
+			}
+		}()
+	case OwnershipPolicyReregister:
+		logging.Warn("agent NFT is owned by another wallet, registering again", "token_id", change.TokenID, "owner", change.To)
+		if err := a.protocolHandler.StartAuthentication(); err != nil {
+			logging.Error("failed to re-authenticate after NFT transfer", "error", err)
+		}
+	default:
+		logging.Error("agent NFT is owned by another wallet; the network may reject the agent", "token_id", change.TokenID, "owner", change.To)
+	}
+}
@@ -0,0 +1,44 @@
+package agent
+
+import (
+	"time"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/logging"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/prompt"
+)
+
+// promptCacheTTL is how long a prompt override read from the cache is used
+// before it is read again
+const promptCacheTTL = 30 * time.Second
+
+// promptCachePrefix prefixes the cache keys of prompt overrides
+const promptCachePrefix = "prompts:"
+
+// PromptLibraryProvider is implemented by agent handlers that render their
+// system prompt from templates, such as OpenAIAgent. The agent fills in the
+// templates' agent name and capabilities and loads the configured overrides.
+type PromptLibraryProvider interface {
+	PromptLibrary() *prompt.Library
+}
+
+// setupPrompts connects the handler's prompt templates to the agent: its
+// name and capabilities, PromptsDir and, with PromptsFromCache, the cache
+func (a *EnhancedAgent) setupPrompts() error {
+	provider, ok := a.agentHandler.(PromptLibraryProvider)
+	if !ok || provider.PromptLibrary() == nil {
+		return nil
+	}
+	a.prompts = provider.PromptLibrary()
+	a.prompts.SetAgent(a.config.Name, a.config.Capabilities)
+
+	if a.config.PromptsDir != "" {
+		if err := a.prompts.LoadDir(a.config.PromptsDir); err != nil {
+			return err
+		}
+		logging.Info("loaded prompt templates", "dir", a.config.PromptsDir)
+	}
+	if a.config.PromptsFromCache {
+		a.prompts.SetCache(a.agentCache, promptCachePrefix, promptCacheTTL)
+	}
+	return nil
+}
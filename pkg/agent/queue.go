@@ -0,0 +1,29 @@
+package agent
+
+import (
+	"fmt"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/logging"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/queue"
+)
+
+// ServeQueue handles tasks from a message broker alongside the network
+// connection: tasks are consumed from config.Consumer, run through the task
+// coordinator like network tasks and their responses published to
+// config.Publisher. The adapter runs until the agent stops, then the consumer
+// and publisher are closed.
+func (a *EnhancedAgent) ServeQueue(config *queue.Config) (*queue.Adapter, error) {
+	adapter, err := queue.NewAdapter(a.taskCoordinator, config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create queue adapter: %w", err)
+	}
+
+	logging.Info("serving tasks from queue", "result_subject", config.ResultSubject)
+	go func() {
+		adapter.Run(a.ctx)
+		if err := adapter.Close(); err != nil {
+			logging.Warn("error closing queue", "error", err)
+		}
+	}()
+	return adapter, nil
+}
@@ -0,0 +1,217 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"syscall"
+	"time"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/cache"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/logging"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/ratelimit"
+	"github.com/fsnotify/fsnotify"
+)
+
+// reloadDebounce is how long the config file must stay unchanged before it is
+// re-read, so an editor writing the file in several steps triggers one reload
+const reloadDebounce = 500 * time.Millisecond
+
+// ConfigChangeHandler is implemented by agent handlers that apply configuration
+// changes at runtime. ConfigChanged is called after each reload that changed
+// the configuration, with the previous and the new configuration. If it
+// returns an error, the previous configuration is restored.
+type ConfigChangeHandler interface {
+	ConfigChanged(ctx context.Context, old, updated *Config) error
+}
+
+// ReloadConfig re-reads the environment and ConfigFile and applies the settings
+// that can change at runtime: rate limit, log level, capabilities, system prompt
+// and Redis connection. Other settings take effect on the next restart.
+// It is called when ConfigFile changes and on SIGHUP if ReloadOnSIGHUP is set.
+func (a *EnhancedAgent) ReloadConfig() error {
+	a.reloadMu.Lock()
+	defer a.reloadMu.Unlock()
+
+	// Copy what loading may modify in place, so a rejected reload leaves the running configuration untouched
+	updated := *a.config
+	updated.Capabilities = slices.Clone(a.config.Capabilities)
+	if a.config.Resources != nil {
+		resources := *a.config.Resources
+		resources.GPUs = slices.Clone(resources.GPUs)
+		updated.Resources = &resources
+	}
+	if err := updated.LoadFromEnv(); err != nil {
+		return fmt.Errorf("failed to load environment: %w", err)
+	}
+	if updated.ConfigFile != "" {
+		if err := updated.LoadFromFile(updated.ConfigFile); err != nil {
+			return err
+		}
+	}
+	if err := updated.Validate(); err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	old := *a.config
+	changed := a.applyConfig(&old, &updated)
+	if !changed {
+		logging.Debug("configuration reloaded without changes")
+		return nil
+	}
+
+	if changer, ok := a.agentHandler.(ConfigChangeHandler); ok {
+		current := *a.config
+		if err := changer.ConfigChanged(a.ctx, &old, &current); err != nil {
+			a.applyConfig(&current, &old)
+			return fmt.Errorf("agent handler rejected configuration change: %w", err)
+		}
+	}
+	logging.Info("configuration reloaded", "agent", a.config.Name)
+	return nil
+}
+
+// applyConfig applies the reloadable settings of updated and reports whether any changed
+func (a *EnhancedAgent) applyConfig(old, updated *Config) bool {
+	changed := false
+
+	if updated.RateLimits() != old.RateLimits() {
+		a.setRateLimits(updated.RateLimits())
+		logging.Info("updated rate limits", "per_minute", updated.RateLimitPerMinute,
+			"room_per_minute", updated.RoomRateLimitPerMinute, "sender_per_minute", updated.SenderRateLimitPerMinute)
+		changed = true
+	}
+
+	if updated.LogLevel != old.LogLevel {
+		if err := logging.SetLevel(updated.LogLevel); err != nil {
+			logging.Warn("failed to change log level", "level", updated.LogLevel, "error", err)
+		} else {
+			a.config.LogLevel = updated.LogLevel
+			logging.Info("updated log level", "level", updated.LogLevel)
+			changed = true
+		}
+	}
+
+	if !slices.Equal(updated.Capabilities, old.Capabilities) {
+		if err := a.UpdateCapabilities(updated.Capabilities); err != nil {
+			logging.Warn("failed to update capabilities", "error", err)
+		}
+		changed = true
+	}
+
+	if updated.SystemPrompt != old.SystemPrompt {
+		a.config.SystemPrompt = updated.SystemPrompt
+		changed = true
+	}
+
+	if a.reloadRedis(old, updated) {
+		changed = true
+	}
+	return changed
+}
+
+// setRateLimits applies rate limits and records them in the configuration.
+// The caller holds reloadMu.
+func (a *EnhancedAgent) setRateLimits(limits ratelimit.Config) {
+	a.config.RateLimitPerMinute, a.config.RateLimitBurst = limits.Global.PerMinute, limits.Global.Burst
+	a.config.RoomRateLimitPerMinute, a.config.RoomRateLimitBurst = limits.PerRoom.PerMinute, limits.PerRoom.Burst
+	a.config.SenderRateLimitPerMinute, a.config.SenderRateLimitBurst = limits.PerSender.PerMinute, limits.PerSender.Burst
+	a.taskCoordinator.SetRateLimits(limits)
+}
+
+// reloadRedis reconnects the Redis cache if its settings changed and reports
+// whether the new settings were applied
+func (a *EnhancedAgent) reloadRedis(old, updated *Config) bool {
+	if !old.RedisEnabled && !updated.RedisEnabled {
+		return false
+	}
+	oldRedis, newRedis := newRedisConfig(old), newRedisConfig(updated)
+	if updated.RedisEnabled == old.RedisEnabled && *newRedis == *oldRedis {
+		return false
+	}
+
+	redisCache, ok := a.agentCache.(*cache.RedisCache)
+	if !ok || !updated.RedisEnabled {
+		logging.Warn("enabling or disabling Redis requires a restart", "redis_enabled", updated.RedisEnabled)
+		return false
+	}
+	if err := redisCache.Reconfigure(newRedis); err != nil {
+		logging.Warn("failed to reconnect Redis cache (keeping current connection)", "address", newRedis.Address, "error", err)
+		return false
+	}
+
+	a.config.RedisAddress = updated.RedisAddress
+	a.config.RedisUsername = updated.RedisUsername
+	a.config.RedisPassword = updated.RedisPassword
+	a.config.RedisDB = updated.RedisDB
+	a.config.RedisKeyPrefix = updated.RedisKeyPrefix
+	a.config.RedisUseTLS = updated.RedisUseTLS
+	logging.Info("reconnected Redis cache", "address", newRedis.Address, "prefix", newRedis.KeyPrefix)
+	return true
+}
+
+// watchConfig reloads the configuration when ConfigFile changes or, if
+// ReloadOnSIGHUP is set, when the process receives SIGHUP, until ctx is done
+func (a *EnhancedAgent) watchConfig(ctx context.Context) {
+	var fileEvents chan fsnotify.Event
+	var fileErrors chan error
+	path := a.config.ConfigFile
+
+	if path != "" {
+		watcher, err := fsnotify.NewWatcher()
+		if err != nil {
+			logging.Warn("failed to watch config file", "path", path, "error", err)
+		} else {
+			defer watcher.Close()
+			// Watch the directory: editors and config management often replace the file instead of writing it
+			if err := watcher.Add(filepath.Dir(path)); err != nil {
+				logging.Warn("failed to watch config file", "path", path, "error", err)
+			} else {
+				fileEvents, fileErrors = watcher.Events, watcher.Errors
+				logging.Info("watching config file for changes", "path", path)
+			}
+		}
+	}
+
+	var sighup chan os.Signal
+	if a.config.ReloadOnSIGHUP {
+		sighup = make(chan os.Signal, 1)
+		signal.Notify(sighup, syscall.SIGHUP)
+		defer signal.Stop(sighup)
+	}
+
+	if fileEvents == nil && sighup == nil {
+		return
+	}
+
+	reload := func(trigger string) {
+		logging.Info("reloading configuration", "trigger", trigger)
+		if err := a.ReloadConfig(); err != nil {
+			logging.Error("failed to reload configuration", "error", err)
+		}
+	}
+
+	debounce := time.NewTimer(reloadDebounce)
+	debounce.Stop()
+	defer debounce.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event := <-fileEvents:
+			if filepath.Clean(event.Name) == filepath.Clean(path) && event.Has(fsnotify.Write|fsnotify.Create|fsnotify.Rename) {
+				debounce.Reset(reloadDebounce)
+			}
+		case err := <-fileErrors:
+			logging.Warn("config file watcher error", "error", err)
+		case <-debounce.C:
+			reload("file")
+		case <-sighup:
+			reload("SIGHUP")
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+// reloadHandler records configuration changes and answers them with err
+type reloadHandler struct {
+	blockingHandler
+	err     error
+	changes int
+}
+
+func (h *reloadHandler) ConfigChanged(ctx context.Context, old, updated *Config) error {
+	h.changes++
+	return h.err
+}
+
+func TestReloadConfig(t *testing.T) {
+	const change = `{"system_prompt": "Be brief", "rate_limit_per_minute": 30, "capabilities": ["test", "other"]}`
+
+	tests := []struct {
+		name       string
+		file       string
+		handlerErr error
+		wantErr    bool
+		changes    int  // ConfigChanged calls
+		applied    bool // whether the change is in effect afterwards
+	}{
+		{name: "change", file: change, changes: 1, applied: true},
+		{name: "no change", file: `{}`},
+		{name: "invalid setting", file: `{"rate_limit_per_minute": -1}`, wantErr: true},
+		{name: "unreadable file", file: `not json`, wantErr: true},
+		{name: "rejected by the handler", file: change, handlerErr: errors.New("no"), wantErr: true, changes: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "agent.json")
+			if err := os.WriteFile(path, []byte(tt.file), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			config := testConfig(t)
+			config.ConfigFile = path
+			handler := &reloadHandler{err: tt.handlerErr}
+			agent := newTestAgent(t, config, handler)
+			before := *agent.config
+			before.Capabilities = slices.Clone(agent.config.Capabilities)
+
+			err := agent.ReloadConfig()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ReloadConfig() error = %v, want error %v", err, tt.wantErr)
+			}
+			if handler.changes != tt.changes {
+				t.Errorf("ConfigChanged called %d times, want %d", handler.changes, tt.changes)
+			}
+
+			if !tt.applied {
+				if !reflect.DeepEqual(*agent.config, before) {
+					t.Error("configuration changed by a reload that changed nothing or was rejected")
+				}
+				if limits := agent.taskCoordinator.GetRateLimits(); limits != before.RateLimits() {
+					t.Errorf("coordinator rate limits = %+v, want %+v", limits, before.RateLimits())
+				}
+				if got := agent.taskCoordinator.Capabilities(); !slices.Equal(got, before.Capabilities) {
+					t.Errorf("coordinator capabilities = %v, want %v", got, before.Capabilities)
+				}
+				return
+			}
+			if agent.config.SystemPrompt != "Be brief" || agent.config.RateLimitPerMinute != 30 {
+				t.Errorf("configuration not updated: %+v", *agent.config)
+			}
+			if limits := agent.taskCoordinator.GetRateLimits(); limits.Global.PerMinute != 30 {
+				t.Errorf("coordinator rate limits = %+v, want 30 per minute", limits)
+			}
+			if got := agent.taskCoordinator.Capabilities(); !slices.Equal(got, []string{"test", "other"}) {
+				t.Errorf("coordinator capabilities = %v, want [test other]", got)
+			}
+		})
+	}
+}
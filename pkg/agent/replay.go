@@ -0,0 +1,37 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/logging"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/recording"
+)
+
+// ReplayRecording runs the tasks of a session recorded with RECORD_FILE
+// through the agent's handler, in the recorded order, without connecting to
+// the network. The responses are appended to the recording at outPath, for
+// comparing them with the recorded ones (empty = discarded). Create the agent
+// with IdentityModeAnonymous so nothing is minted or verified, and do not
+// start it. It returns the number of tasks replayed.
+func (a *EnhancedAgent) ReplayRecording(ctx context.Context, path string, options recording.ReplayOptions, outPath string) (int, error) {
+	entries, err := recording.Load(path)
+	if err != nil {
+		return 0, err
+	}
+
+	var out *recording.Recorder
+	if outPath != "" {
+		if out, err = recording.Open(outPath); err != nil {
+			return 0, err
+		}
+		defer out.Close()
+	}
+
+	logging.Info("replaying recording", "file", path, "entries", len(entries), "speed", options.Speed)
+	replayed, err := a.taskCoordinator.Replay(ctx, entries, options, out)
+	if err != nil {
+		return replayed, fmt.Errorf("replay stopped after %d tasks: %w", replayed, err)
+	}
+	return replayed, nil
+}
@@ -3,22 +3,46 @@ package agent
 import (
 	"context"
 	"crypto/ecdsa"
+	"encoding/json"
 	"fmt"
-	"log"
+	"io"
+	"math/big"
 	"os"
 	"os/signal"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/access"
 	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/auth"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/backend"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/bandwidth"
 	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/cache"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/consumer"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/e2e"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/events"
 	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/health"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/identity"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/logging"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/memory"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/metering"
 	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/network"
 	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/nft"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/output"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/payment"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/prompt"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/ratelimit"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/retrystore"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/review"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/scheduler"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/tracing"
 	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/types"
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/crypto"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // EnhancedAgent represents a fully functional Teneo network agent with all capabilities
@@ -26,14 +50,40 @@ type EnhancedAgent struct {
 	config          *Config
 	agentHandler    types.AgentHandler
 	authManager     *auth.Manager
+	backend         *backend.Client
 	networkClient   *network.NetworkClient
 	protocolHandler *network.ProtocolHandler
 	taskCoordinator *network.TaskCoordinator
+	delegation      *network.DelegationClient
+	profiles        *network.ProfileResolver
 	healthServer    *health.Server
+	metrics         *health.Metrics
 	agentCache      cache.AgentCache
+	consumers       *consumer.Registry
+	meter           *metering.Meter
+	llmUsage        *metering.LLMLedger // LLM usage of tasks per room and day
+	prompts         *prompt.Library     // The handler's prompt templates, nil if it has none
+	chain           *nft.RPCPool        // Reads payments and token balances, nil without payment checks or a token gate
+	memory          types.ConversationMemory
+	review          *review.Gate
+	events          *events.Bus
+	jobs            *scheduler.Cron
+	identity        *identity.Identity       // Persistent identity, written on registration
+	manifest        []types.AgentCapability  // Capabilities described by the handler, nil without a manifest
+	capabilitiesMu  sync.Mutex               // Serializes capability changes
+	metadataSync    func([]string) error     // Updates the NFT metadata hash after capability changes, nil without a token
+	metadataSyncer  *nft.MetadataSyncer      // Compares the config with the NFT metadata, nil unless MetadataSyncInterval is set
+	encryptionKeys  *e2e.Keyring             // Keys of end-to-end encrypted tasks, nil if encryption is disabled
+	businessCards   *nft.BusinessCardManager // Created for metadata sync and the ownership watch, nil without them
+	ownedToken      *big.Int                 // NFT watched for transfers, nil unless OwnershipWatchInterval is set
+	registeredOnce  atomic.Bool              // Set on the first registration, for the startup probe
+	identityMode    IdentityMode
+	identityMu      sync.Mutex
 	running         bool
 	startTime       time.Time
 	mu              sync.RWMutex
+	reloadMu        sync.Mutex // Serializes configuration reloads
+	restartMu       sync.Mutex // Serializes handler restarts after panics
 	ctx             context.Context
 	cancel          context.CancelFunc
 }
@@ -43,13 +93,45 @@ type EnhancedAgentConfig struct {
 	Config       *Config
 	AgentHandler types.AgentHandler
 
+	// Identity: "nft" (default), "wallet-only" or "anonymous"; default from env IDENTITY_MODE.
+	// Without an NFT the minting options are ignored and NFT APIs return ErrNFTDisabled.
+	IdentityMode IdentityMode
+
 	// NFT Minting Options
 	Mint    bool   // If true, mint new NFT; if false, use TokenID
 	TokenID uint64 // Required if Mint is false
 
 	// Backend Configuration
 	BackendURL  string // Default from env or "http://localhost:8080"
-	RPCEndpoint string // Ethereum RPC endpoint
+	RPCEndpoint string // Ethereum RPC endpoint (comma-separated for read failover)
+
+	// Endpoint for NFT transactions (optional, defaults to the first RPCEndpoint)
+	RPCWriteEndpoint string
+
+	// Consumer quotas (optional, enables quotas with custom plans)
+	ConsumerRegistry *consumer.Registry
+
+	// Conversation memory (optional, enables memory with a custom store)
+	ConversationMemory types.ConversationMemory
+
+	// Response review (optional, enables review with a custom scorer or callback)
+	ReviewGate *review.Gate
+
+	// Guardrail (optional, checks task input and responses after the configured guardrails)
+	Guardrail types.Guardrail
+
+	// Access gate (optional, admits the senders the access lists accept instead of the configured token gate)
+	AccessGate access.Gate
+
+	// Tracing (optional, defaults to the global OpenTelemetry tracer provider)
+	TracerProvider trace.TracerProvider
+
+	// Logger (optional, overrides LogLevel and LogFormat)
+	Logger logging.Logger
+
+	// Host running the agent, set by AgentHost.Add: the agent uses the host's
+	// Redis connection and reports to its health server instead of its own
+	host *AgentHost
 }
 
 // NewEnhancedAgent creates a new enhanced agent with network capabilities
@@ -61,6 +143,38 @@ func NewEnhancedAgent(config *EnhancedAgentConfig) (*EnhancedAgent, error) {
 		return nil, fmt.Errorf("agent handler is required")
 	}
 
+	// Style SDK-generated messages and logs
+	outputStyle, err := output.ParseStyle(config.Config.OutputStyle)
+	if err != nil {
+		return nil, err
+	}
+	output.SetDefault(outputStyle)
+
+	// Redact personal data from task input, responses and logs if enabled
+	redactor, redactTargets, err := config.Config.Redactor()
+	if err != nil {
+		return nil, err
+	}
+	var scrubLogs func(string) string
+	if redactTargets[RedactLogs] {
+		scrubLogs = redactor.Redact
+	}
+
+	if config.Logger != nil {
+		logging.SetDefault(config.Logger)
+	} else if config.Config.LogLevel != "" || config.Config.LogFormat != "" || outputStyle != output.StyleEmoji || scrubLogs != nil {
+		logger, err := logging.New(&logging.Config{
+			Level:  config.Config.LogLevel,
+			Format: config.Config.LogFormat,
+			Style:  outputStyle,
+			Scrub:  scrubLogs,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to configure logging: %w", err)
+		}
+		logging.SetDefault(logger)
+	}
+
 	// Set default backend URL if not provided
 	if config.BackendURL == "" {
 		if backendURL := os.Getenv("BACKEND_URL"); backendURL != "" {
@@ -76,43 +190,100 @@ func NewEnhancedAgent(config *EnhancedAgentConfig) (*EnhancedAgent, error) {
 			config.RPCEndpoint = rpcEndpoint
 		}
 	}
+	if config.RPCWriteEndpoint == "" {
+		config.RPCWriteEndpoint = os.Getenv("RPC_WRITE_ENDPOINT")
+	}
+
+	if config.TracerProvider != nil {
+		tracing.SetTracerProvider(config.TracerProvider)
+	}
+
+	// Skip minting and verification when running without an NFT
+	if config.IdentityMode == "" {
+		config.IdentityMode = IdentityMode(os.Getenv("IDENTITY_MODE"))
+	}
+	identityMode, err := parseIdentityMode(string(config.IdentityMode))
+	if err != nil {
+		return nil, err
+	}
+	if err := applyIdentityMode(config, identityMode); err != nil {
+		return nil, err
+	}
+
+	// Advertise the capabilities of the agent's manifest along with the configured ones
+	var manifest []types.AgentCapability
+	if provider, ok := config.AgentHandler.(types.CapabilityManifestProvider); ok {
+		manifest = provider.CapabilityManifest()
+		if err := types.ValidateManifest(manifest); err != nil {
+			return nil, fmt.Errorf("invalid capability manifest: %w", err)
+		}
+		config.Config.Capabilities = mergeCapabilities(config.Config.Capabilities, manifest)
+	}
+
+	// Sign backend requests with the agent's wallet
+	authManager, err := auth.NewManager(config.Config.PrivateKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create auth manager: %w", err)
+	}
+	// Custom CAs, client certificates and SNI of the backend and WebSocket connections
+	backendTLS, err := config.Config.BackendTLS()
+	if err != nil {
+		return nil, err
+	}
+	webSocketTLS, err := config.Config.WebSocketTLS()
+	if err != nil {
+		return nil, err
+	}
+	if config.Config.TLSInsecureSkipVerify {
+		logging.Warn("TLS certificate verification is disabled; use this for development only")
+	}
+
+	backendClient, err := backend.New(&backend.Config{BaseURL: config.BackendURL, Signer: authManager, TLS: backendTLS})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create backend client: %w", err)
+	}
+
+	// Reuse the token ID of an earlier run, e.g. one that minted the NFT
+	walletAddress := getAddressFromPrivateKey(config.Config.PrivateKey)
+	var agentIdentity *identity.Identity
+	if config.Config.IdentityFile != "" {
+		agentIdentity = loadIdentity(config.Config.IdentityFile, walletAddress)
+		if agentIdentity != nil && agentIdentity.TokenID > 0 && identityMode.UsesNFT() && config.TokenID == 0 && config.Config.NFTTokenID == "" {
+			logging.Info("using NFT token ID from identity file", "token_id", agentIdentity.TokenID, "path", config.Config.IdentityFile)
+			config.TokenID = agentIdentity.TokenID
+			config.Mint = false
+		}
+	}
+	mintedAt := time.Time{}
 
 	// Handle NFT minting or verification
-	if config.Mint {
+	if !identityMode.UsesNFT() {
+		logging.Debug("skipping NFT minting and verification", "mode", identityMode)
+	} else if config.Mint {
 		// Create NFT minter
-		minter, err := nft.NewNFTMinter(config.BackendURL, config.RPCEndpoint, config.Config.PrivateKey)
+		minter, err := newNFTMinter(config, backendClient)
 		if err != nil {
 			return nil, fmt.Errorf("failed to create NFT minter: %w", err)
 		}
+		defer minter.Close()
 
-		// Generate agent ID from name
-		agentID := generateAgentID(config.Config.Name)
-
-		// Prepare metadata
-		metadata := nft.AgentMetadata{
-			Name:         config.Config.Name,
-			Description:  config.Config.Description,
-			Image:        config.Config.Image,
-			Capabilities: config.Config.Capabilities,
-			AgentID:      agentID,
-		}
-
-		log.Printf("🎨 Minting NFT for agent: %s", config.Config.Name)
+		logging.Info("minting NFT for agent", "agent", config.Config.Name)
 
 		// Mint NFT - this will:
 		// 1. Send metadata to backend (backend uploads to IPFS)
 		// 2. Get signature from backend
 		// 3. Execute on-chain mint transaction
-		tokenID, err := minter.MintAgent(metadata)
+		_, span := tracing.Start(context.Background(), tracing.SpanNFTMint)
+		tokenID, err := minter.MintAgent(nftMetadata(config.Config))
+		span.SetAttributes(tracing.AttrTokenID.Int64(int64(tokenID)))
+		tracing.End(span, err)
 		if err != nil {
 			return nil, fmt.Errorf("failed to mint NFT: %w", err)
 		}
 
 		config.TokenID = tokenID
-		log.Printf("✅ Successfully minted NFT with token ID: %d", tokenID)
-
-		// Store token ID in environment for future use
-		os.Setenv("NFT_TOKEN_ID", fmt.Sprintf("%d", tokenID))
+		mintedAt = time.Now().UTC()
+		logging.Info("successfully minted NFT", "token_id", tokenID)
 	} else {
 		// Verify TokenID is set
 		if config.TokenID == 0 {
@@ -127,47 +298,46 @@ func NewEnhancedAgent(config *EnhancedAgentConfig) (*EnhancedAgent, error) {
 		}
 
 		// Generate and send metadata hash
-		metadata := nft.AgentMetadata{
-			Name:         config.Config.Name,
-			Description:  config.Config.Description,
-			Image:        config.Config.Image,
-			Capabilities: config.Config.Capabilities,
-			AgentID:      generateAgentID(config.Config.Name),
-		}
-
-		hash := nft.GenerateMetadataHash(metadata)
-		log.Printf("📋 Using existing NFT token ID: %d with metadata hash: %s", config.TokenID, hash)
+		hash := nft.GenerateMetadataHash(nftMetadata(config.Config))
+		logging.Info("using existing NFT token ID", "token_id", config.TokenID, "metadata_hash", hash)
 
 		// Send metadata hash to backend
-		minter, err := nft.NewNFTMinter(config.BackendURL, config.RPCEndpoint, config.Config.PrivateKey)
-		if err != nil {
-			return nil, fmt.Errorf("failed to create NFT minter: %w", err)
-		}
-
-		walletAddress := getAddressFromPrivateKey(config.Config.PrivateKey)
-		err = minter.SendMetadataHashToBackend(hash, config.TokenID, walletAddress)
+		ctx, span := tracing.Start(context.Background(), tracing.SpanNFTSyncMetadata, tracing.AttrTokenID.Int64(int64(config.TokenID)))
+		err = backendClient.RegisterMetadata(ctx, backend.MetadataRegistration{
+			Hash:          hash,
+			TokenID:       config.TokenID,
+			WalletAddress: walletAddress,
+		})
+		tracing.End(span, err)
 		if err != nil {
-			log.Printf("⚠️  Warning: Failed to send metadata hash to backend: %v", err)
+			logging.Warn("failed to send metadata hash to backend", "error", err)
 			// This is not critical, so we continue
 		}
 	}
 
+	// Register with the token ID that was minted, loaded or given
+	if config.Config.NFTTokenID == "" && config.TokenID > 0 {
+		config.Config.NFTTokenID = strconv.FormatUint(config.TokenID, 10)
+	}
+
 	ctx, cancel := context.WithCancel(context.Background())
 
 	agent := &EnhancedAgent{
 		config:       config.Config,
 		agentHandler: config.AgentHandler,
+		identity:     agentIdentity,
+		manifest:     manifest,
+		identityMode: identityMode,
+		authManager:  authManager,
+		backend:      backendClient,
 		ctx:          ctx,
 		cancel:       cancel,
 	}
 
-	// Initialize authentication manager
-	authManager, err := auth.NewManager(config.Config.PrivateKey)
-	if err != nil {
-		cancel()
-		return nil, fmt.Errorf("failed to create auth manager: %w", err)
+	// Keep a newly minted token ID even if the first registration fails
+	if !mintedAt.IsZero() {
+		agent.saveIdentity(func(id *identity.Identity) { id.MintedAt = mintedAt })
 	}
-	agent.authManager = authManager
 
 	// Initialize network client
 	networkConfig := &network.Config{
@@ -178,8 +348,40 @@ func NewEnhancedAgent(config *EnhancedAgentConfig) (*EnhancedAgent, error) {
 		MessageTimeout:   config.Config.MessageTimeout,
 		PingInterval:     config.Config.PingInterval,
 		HandshakeTimeout: config.Config.HandshakeTimeout,
+		EnableDeflate:    config.Config.WebSocketDeflate,
+		TLS:              webSocketTLS,
+		CompressAbove:    config.Config.CompressThreshold,
+		ChunkSize:        config.Config.ResponseChunkSize,
+
+		CongestionThreshold: config.Config.SendCongestionThreshold,
+		SendBufferSize:      config.Config.SendBufferSize,
+		ReceiveBufferSize:   config.Config.ReceiveBufferSize,
+		ReconnectMaxDelay:   config.Config.ReconnectMaxDelay,
+		ReconnectMaxElapsed: config.Config.ReconnectMaxElapsed,
+		DataChannelURL:      config.Config.DataChannelURL,
+		RecordFile:          config.Config.RecordFile,
+		CircuitBreaker: network.CircuitBreakerConfig{
+			MaxFailures:      config.Config.CircuitBreakerMaxFailures,
+			ResetTimeout:     config.Config.CircuitBreakerResetTimeout,
+			HalfOpenRequests: config.Config.CircuitBreakerProbes,
+		},
+		RoomBandwidth: bandwidth.Ceiling{
+			PerMinute: config.Config.RoomBandwidthPerMinute,
+			Burst:     config.Config.RoomBandwidthBurst,
+		},
+	}
+	if ordering, err := network.ParseOrdering(config.Config.SendOrdering); err == nil {
+		networkConfig.Ordering = ordering
+	}
+	if policy, err := network.ParseOverflowPolicy(config.Config.SendBufferOverflow); err == nil {
+		networkConfig.SendOverflow = policy
+	}
+	if policy, err := network.ParseOverflowPolicy(config.Config.ReceiveBufferOverflow); err == nil {
+		networkConfig.ReceiveOverflow = policy
 	}
 	agent.networkClient = network.NewNetworkClient(networkConfig)
+	agent.events = events.NewBus(0)
+	agent.networkClient.SetEventBus(agent.events)
 
 	// Initialize protocol handler
 	agent.protocolHandler = network.NewProtocolHandler(
@@ -191,6 +393,37 @@ func NewEnhancedAgent(config *EnhancedAgentConfig) (*EnhancedAgent, error) {
 		config.Config.NFTTokenID,
 		config.Config.Room,
 	)
+	agent.protocolHandler.SetResources(config.Config.Resources)
+	agent.protocolHandler.SetManifest(manifest)
+	sessionConfig := network.DefaultSessionConfig()
+	if config.Config.SessionRefreshBefore > 0 {
+		sessionConfig.RefreshBefore = config.Config.SessionRefreshBefore
+	}
+	sessionConfig.DefaultTTL = config.Config.SessionTTL
+	agent.protocolHandler.SetSessionConfig(sessionConfig)
+	if policy, err := network.ParseDuplicatePolicy(config.Config.DuplicatePolicy); err == nil {
+		agent.protocolHandler.SetDuplicatePolicy(policy)
+	}
+	agent.protocolHandler.SetEventBus(agent.events)
+
+	// Verify task envelopes and sign task responses
+	signingConfig := network.DefaultMessageSigningConfig()
+	signingConfig.CoordinatorPublicKey = config.Config.CoordinatorPublicKey
+	signingConfig.SignResponses = config.Config.SignTaskResponses
+	if err := agent.protocolHandler.SetMessageSigning(signingConfig); err != nil {
+		return nil, fmt.Errorf("failed to configure message signing: %w", err)
+	}
+
+	// Decrypt end-to-end encrypted tasks and publish the keys to encrypt them to
+	encryption, err := config.Config.Encryption()
+	if err != nil {
+		return nil, err
+	}
+	if encryption != nil {
+		agent.protocolHandler.SetEncryption(encryption)
+		agent.encryptionKeys = encryption.Keyring
+		logging.Info("end-to-end encryption enabled", "key_id", encryption.Keyring.Primary().ID, "required", encryption.Required)
+	}
 
 	// Initialize task coordinator
 	agent.taskCoordinator = network.NewTaskCoordinator(
@@ -198,43 +431,324 @@ func NewEnhancedAgent(config *EnhancedAgentConfig) (*EnhancedAgent, error) {
 		agent.protocolHandler,
 		config.Config.Capabilities,
 	)
+	agent.taskCoordinator.SetEventBus(agent.events)
+	agent.taskCoordinator.SetHeartbeatInterval(config.Config.TaskHeartbeatInterval)
+
+	// Validate task input against the schemas the agent declares per capability,
+	// in its manifest or as an InputSchemaProvider (which takes precedence)
+	if inputSchemas := agent.inputSchemas(manifest); len(inputSchemas) > 0 {
+		if err := agent.taskCoordinator.SetInputSchemas(inputSchemas); err != nil {
+			return nil, err
+		}
+	}
+
+	// Keep the metadata hash on the backend in step with capability changes
+	if tokenID := config.TokenID; tokenID > 0 {
+		agent.metadataSync = func(capabilities []string) error {
+			metadata := nft.AgentMetadata{
+				Name:         config.Config.Name,
+				Description:  config.Config.Description,
+				Image:        config.Config.Image,
+				Capabilities: capabilities,
+				AgentID:      generateAgentID(config.Config.Name),
+			}
+			return backendClient.RegisterMetadata(agent.ctx, backend.MetadataRegistration{
+				Hash:          nft.GenerateMetadataHash(metadata),
+				TokenID:       tokenID,
+				WalletAddress: walletAddress,
+			})
+		}
+	}
 
-	// Set rate limit if configured
-	if config.Config.RateLimitPerMinute > 0 {
-		agent.taskCoordinator.SetRateLimit(config.Config.RateLimitPerMinute)
+	// Compare the config with the NFT metadata periodically
+	if config.Config.MetadataSyncInterval > 0 {
+		if err := agent.enableMetadataSync(); err != nil {
+			return nil, fmt.Errorf("failed to enable metadata sync: %w", err)
+		}
 	}
 
-	// Initialize Redis cache if enabled
-	if config.Config.RedisEnabled {
-		log.Printf("🗄️  Initializing Redis cache at %s", config.Config.RedisAddress)
+	// Watch the NFT for transfers to other wallets
+	if config.Config.OwnershipWatchInterval > 0 {
+		if err := agent.enableOwnershipWatch(); err != nil {
+			return nil, fmt.Errorf("failed to enable ownership watch: %w", err)
+		}
+	}
 
-		// Set default key prefix if not provided
-		keyPrefix := config.Config.RedisKeyPrefix
-		if keyPrefix == "" {
-			keyPrefix = fmt.Sprintf("teneo:agent:%s:", strings.ReplaceAll(strings.ToLower(config.Config.Name), " ", "_"))
+	// Log level and diagnostics on request of the owner wallet
+	if config.Config.OperatorCommands {
+		operators := config.Config.Operators()
+		if len(operators) == 0 {
+			operators = []string{authManager.GetAddress()}
 		}
+		if err := agent.protocolHandler.EnableOperatorCommands(&network.OperatorConfig{Operators: operators}); err != nil {
+			return nil, fmt.Errorf("failed to enable operator commands: %w", err)
+		}
+		agent.protocolHandler.HandleOperatorCommand(network.OperatorHealthSnapshot, func(json.RawMessage) (interface{}, error) {
+			return agent.healthSnapshot(), nil
+		})
+	}
+
+	// Initialize delegation to other agents
+	agent.delegation = network.NewDelegationClient(agent.protocolHandler, nil)
+
+	// Set rate limits if configured
+	if limits := config.Config.RateLimits(); limits != (ratelimit.Config{}) {
+		agent.taskCoordinator.SetRateLimits(limits)
+	}
 
-		redisConfig := &cache.RedisConfig{
-			Address:   config.Config.RedisAddress,
-			Username:  config.Config.RedisUsername,
-			Password:  config.Config.RedisPassword,
-			DB:        config.Config.RedisDB,
-			KeyPrefix: keyPrefix,
-			UseTLS:    config.Config.RedisUseTLS,
+	// Set task size guards if configured
+	if config.Config.MaxTaskBytes > 0 || config.Config.TaskContentTypes != "" || config.Config.MaxInputChars > 0 || config.Config.MaxOutputBytes > 0 || config.Config.MaxMessagesPerTask > 0 {
+		guards := network.DefaultTaskGuards()
+		guards.MaxTaskBytes = config.Config.MaxTaskBytes
+		guards.AllowedContentTypes = config.Config.AcceptedContentTypes()
+		guards.MaxInputChars = config.Config.MaxInputChars
+		guards.MaxOutputBytes = config.Config.MaxOutputBytes
+		guards.MaxMessagesPerTask = config.Config.MaxMessagesPerTask
+		if config.Config.InputGuardPolicy != "" {
+			guards.InputPolicy = network.GuardPolicy(config.Config.InputGuardPolicy)
 		}
+		if config.Config.OutputGuardPolicy != "" {
+			guards.OutputPolicy = network.GuardPolicy(config.Config.OutputGuardPolicy)
+		}
+		agent.taskCoordinator.SetTaskGuards(guards)
+	}
 
+	// Set per-task budgets if configured
+	if budget := config.Config.TaskBudget(); !budget.IsZero() {
+		agent.taskCoordinator.SetTaskBudget(&budget)
+	}
+
+	if redactor != nil {
+		if redactTargets[RedactInput] {
+			agent.taskCoordinator.SetInputScrubber(redactor.Redact)
+		}
+		if redactTargets[RedactOutput] {
+			agent.taskCoordinator.AddPostProcessor("redact", redactor.Process)
+		}
+		logging.Info("PII redaction enabled")
+	}
+
+	// Bound tasks without a server deadline by the configured timeouts
+	agent.taskCoordinator.SetTaskTimeouts(config.Config.TaskTimeouts())
+
+	// Retry handler errors classified as retryable
+	if config.Config.TaskMaxRetries > 0 {
+		retryPolicy := network.DefaultRetryPolicy()
+		retryPolicy.MaxRetries = config.Config.TaskMaxRetries
+		agent.taskCoordinator.SetTaskRetryPolicy(retryPolicy)
+	}
+
+	// Rebuild the handler's state after a panic if configured
+	if config.Config.RestartHandlerOnPanic {
+		agent.taskCoordinator.SetPanicHandler(agent.restartHandler)
+	}
+
+	// Queue tasks by priority if a queue policy is configured
+	if config.Config.TaskQueuePolicy != "" {
+		policy, _ := scheduler.ParsePolicy(config.Config.TaskQueuePolicy)
+		agent.taskCoordinator.SetTaskScheduler(scheduler.New(&scheduler.Config{
+			Workers:   config.Config.MaxConcurrentTasks,
+			Policy:    policy,
+			Preempt:   config.Config.TaskPreemption,
+			MaxQueued: config.Config.MaxQueuedTasks,
+		}))
+		logging.Info("task queue enabled", "policy", policy, "workers", config.Config.MaxConcurrentTasks, "preemption", config.Config.TaskPreemption)
+	}
+
+	// Initialize Redis cache if enabled, sharing the host's connection pool if there is one
+	if config.host != nil && config.host.redis != nil {
+		keyPrefix := newRedisConfig(config.Config).KeyPrefix
+		agent.agentCache = config.host.redis.WithPrefix(keyPrefix)
+		logging.Info("using the host's Redis cache", "prefix", keyPrefix)
+	} else if config.Config.RedisEnabled {
+		logging.Info("initializing Redis cache", "address", config.Config.RedisAddress)
+
+		redisConfig := newRedisConfig(config.Config)
 		redisCache, err := cache.NewRedisCache(redisConfig)
 		if err != nil {
 			// Log error but don't fail - cache is optional
-			log.Printf("⚠️  Failed to initialize Redis cache: %v (continuing without cache)", err)
-			agent.agentCache = &cache.NoOpCache{}
+			logging.Warn("failed to initialize Redis cache (continuing without cache)", "error", err)
+			agent.agentCache = newLocalCache(config.Config)
 		} else {
 			agent.agentCache = redisCache
-			log.Printf("✅ Redis cache initialized successfully with prefix: %s", keyPrefix)
+			logging.Info("Redis cache initialized successfully", "prefix", redisConfig.KeyPrefix)
 		}
 	} else {
-		// Use no-op cache when Redis is disabled
-		agent.agentCache = &cache.NoOpCache{}
+		// Use the in-memory cache, or a no-op cache when it is disabled too
+		agent.agentCache = newLocalCache(config.Config)
+	}
+
+	// Skip tasks redelivered by the coordinator, recorded in the agent cache
+	if config.Config.TaskDedupTTL > 0 {
+		agent.taskCoordinator.SetTaskDeduplicator(network.NewTaskDeduplicator(&network.DedupConfig{
+			TTL:   config.Config.TaskDedupTTL,
+			Cache: agent.agentCache,
+		}))
+	}
+
+	// Keep messages waiting for a retry across restarts
+	if store := newRetryStore(config.Config, agent.agentCache); store != nil {
+		if err := agent.networkClient.SetRetryStore(context.Background(), store); err != nil {
+			logging.Warn("failed to load retry queue (continuing without persistence)", "error", err)
+		}
+	}
+
+	// Resolve sender addresses into user profiles, cached in the agent cache
+	agent.profiles = network.NewProfileResolver(agent.protocolHandler, agent.agentCache, nil)
+
+	// Initialize consumer quotas if enabled
+	agent.consumers = config.ConsumerRegistry
+	if agent.consumers == nil && config.Config.QuotaEnabled {
+		registryConfig := consumer.DefaultRegistryConfig()
+		registryConfig.Cache = agent.agentCache
+		if config.Config.QuotaDefaultPlan != "" {
+			registryConfig.DefaultPlan = config.Config.QuotaDefaultPlan
+		}
+		agent.consumers = consumer.NewRegistry(registryConfig)
+	}
+	if agent.consumers != nil {
+		agent.taskCoordinator.SetQuotaChecker(agent.consumers)
+		logging.Info("consumer quotas enabled")
+	}
+
+	// Price completed tasks and record their usage if enabled. Configured
+	// prices take precedence over the pricing hints of the manifest.
+	if config.Config.MeteringEnabled {
+		prices := metering.PricesFromManifest(manifest, config.Config.PriceCurrency)
+		for capability, price := range config.Config.CapabilityPrices {
+			prices[capability] = price
+		}
+		meterConfig := &metering.Config{
+			Prices:   prices,
+			Default:  config.Config.DefaultTaskPrice,
+			Currency: config.Config.PriceCurrency,
+		}
+		if config.Config.UsageReceipts {
+			meterConfig.Signer = authManager
+		}
+		agent.meter = metering.New(meterConfig)
+		agent.taskCoordinator.SetUsageMeter(agent.meter)
+		logging.Info("usage metering enabled", "priced_capabilities", len(prices), "receipts", config.Config.UsageReceipts)
+	}
+
+	// Render the handler's system prompt with the agent's details and overrides
+	if err := agent.setupPrompts(); err != nil {
+		return nil, fmt.Errorf("failed to load prompt templates: %w", err)
+	}
+
+	// Account for the LLM tokens tasks use per room and day
+	agent.llmUsage = metering.NewLLMLedger(config.Config.LLMUsageRetention)
+	agent.taskCoordinator.SetLLMUsageRecorder(agent.llmUsage)
+	agent.taskCoordinator.SetLLMUsageReport(config.Config.LLMUsageReport)
+
+	// Check task input and responses against the guardrails
+	guardrailPolicy, err := config.Config.Guardrail(config.Guardrail)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set up guardrails: %w", err)
+	}
+	if guardrailPolicy != nil {
+		agent.taskCoordinator.SetGuardrail(guardrailPolicy)
+		logging.Info("guardrails enabled", "input_action", guardrailPolicy.InputAction, "output_action", guardrailPolicy.OutputAction)
+	}
+
+	// Verify that direct user messages were signed by their sender
+	if signatures := config.Config.UserSignatureConfig(); signatures != nil {
+		signatures.Cache = agent.agentCache
+		agent.taskCoordinator.SetUserSignatures(network.NewUserSignatures(signatures))
+		logging.Info("user message signatures enabled", "required", signatures.Required, "capabilities", signatures.Capabilities)
+	}
+
+	// Read payments and token balances from the chain if either is checked
+	if config.Config.PaymentRequired || (config.AccessGate == nil && config.Config.AccessTokenContract != "") {
+		pool, err := newChainReader(config)
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to the chain: %w", err)
+		}
+		agent.chain = pool
+	}
+
+	// Verify on chain that priced tasks were paid for if enabled
+	if config.Config.PaymentRequired {
+		verifier, err := newPaymentVerifier(config, agent.chain, manifest, authManager.GetAddress(), agent.agentCache)
+		if err != nil {
+			return nil, fmt.Errorf("failed to set up payment verification: %w", err)
+		}
+		agent.taskCoordinator.SetPaymentVerifier(verifier)
+		logging.Info("payment verification enabled", "confirmations", config.Config.PaymentConfirmations)
+	}
+
+	// Accept tasks only from the senders and rooms access control admits
+	gate := config.AccessGate
+	if gate == nil && config.Config.AccessTokenContract != "" {
+		// Share balances with the agent's replicas through Redis
+		var balances cache.AgentCache
+		if _, shared := agent.agentCache.(*cache.RedisCache); shared {
+			balances = agent.agentCache
+		}
+		tokenGate, err := config.Config.TokenGate(agent.chain, balances)
+		if err != nil {
+			return nil, fmt.Errorf("failed to set up the access token gate: %w", err)
+		}
+		gate = tokenGate
+	}
+	if policy := config.Config.AccessPolicy(gate); policy != nil {
+		agent.taskCoordinator.SetAccessChecker(policy)
+		logging.Info("access control enabled", "token_gate", gate != nil)
+	}
+
+	// Initialize conversation memory if enabled
+	agent.memory = config.ConversationMemory
+	if agent.memory == nil && config.Config.MemoryEnabled {
+		memoryConfig := memory.DefaultConfig()
+		memoryConfig.MaxMessages = config.Config.MemoryMaxMessages
+		memoryConfig.MaxTokens = config.Config.MemoryMaxTokens
+		memoryConfig.Cache = agent.agentCache
+		agent.memory = memory.NewStore(memoryConfig)
+	}
+	if agent.memory != nil {
+		agent.taskCoordinator.SetConversationMemory(agent.memory)
+		logging.Info("conversation memory enabled")
+
+		if config.Config.MemoryRestoreMessages > 0 {
+			agent.protocolHandler.OnRegistered(agent.restoreConversation)
+		}
+	}
+
+	// Initialize response review if enabled
+	agent.review = config.ReviewGate
+	if agent.review == nil && config.Config.ReviewEnabled {
+		reviewConfig := review.DefaultConfig()
+		reviewConfig.Threshold = config.Config.ReviewThreshold
+		reviewConfig.Timeout = config.Config.ReviewTimeout
+		reviewConfig.TimeoutAction = review.TimeoutAction(config.Config.ReviewOnTimeout)
+		agent.review = review.NewGate(reviewConfig)
+	}
+	if agent.review != nil {
+		agent.taskCoordinator.SetResponseReviewer(agent.review)
+		logging.Info("response review enabled")
+	}
+
+	// Recurring jobs, started once the agent is registered; their last runs are kept in the agent cache
+	agent.jobs = scheduler.NewCron(&scheduler.CronConfig{
+		Sender: func(ctx context.Context, runID, room string) types.MessageSender {
+			if room == "" {
+				room = agent.config.Room
+			}
+			return agent.taskCoordinator.RoomSender(ctx, runID, room)
+		},
+		Store: agent.agentCache,
+	})
+	agent.protocolHandler.OnRegistered(func() { agent.jobs.Start(agent.ctx) })
+	agent.protocolHandler.OnRegistered(agent.recordRegistration)
+	agent.protocolHandler.OnRegistered(func() { agent.registeredOnce.Store(true) })
+
+	// Hosted agents are reported by the host's health server, labeled by agent
+	if config.host != nil {
+		if config.host.config.MetricsEnabled {
+			agent.enableMetrics()
+			agent.metrics.SetLabel("agent", config.Config.Name)
+		}
+		return agent, nil
 	}
 
 	// Initialize health server if enabled
@@ -245,6 +759,9 @@ func NewEnhancedAgent(config *EnhancedAgentConfig) (*EnhancedAgent, error) {
 			Wallet:       authManager.GetAddress(),
 			Capabilities: config.Config.Capabilities,
 			Description:  config.Config.Description,
+			IdentityMode: string(identityMode),
+			Resources:    config.Config.Resources,
+			Manifest:     manifest,
 		}
 
 		agent.healthServer = health.NewServer(
@@ -252,6 +769,44 @@ func NewEnhancedAgent(config *EnhancedAgentConfig) (*EnhancedAgent, error) {
 			agentInfo,
 			agent,
 		)
+		checks, err := health.ParseChecks(config.Config.ReadinessChecks)
+		if err != nil {
+			return nil, err
+		}
+		agent.healthServer.SetReadinessChecks(checks)
+
+		// Expose the consumer admin API when a token is configured
+		if agent.consumers != nil && config.Config.AdminToken != "" {
+			agent.healthServer.Handle(consumer.AdminPathPrefix, agent.consumers.AdminHandler(config.Config.AdminToken))
+		}
+
+		// Expose the review queue when a token is configured
+		if agent.review != nil && config.Config.AdminToken != "" {
+			agent.healthServer.Handle(review.PathPrefix, agent.review.Handler(config.Config.AdminToken))
+		}
+
+		// Expose usage reports when a token is configured
+		if agent.meter != nil && config.Config.AdminToken != "" {
+			usageHandler := agent.meter.Handler(config.Config.AdminToken)
+			agent.healthServer.Handle(metering.PathPrefix, usageHandler)
+			agent.healthServer.Handle(metering.PathPrefix+"/", usageHandler)
+		}
+
+		// Expose LLM usage reports when a token is configured
+		if config.Config.AdminToken != "" {
+			agent.healthServer.Handle(metering.LLMPath, agent.llmUsage.Handler(config.Config.AdminToken))
+		}
+
+		// Expose the control API when a token is configured
+		if config.Config.AdminToken != "" {
+			agent.healthServer.Handle(ControlPathPrefix, agent.ControlHandler(config.Config.AdminToken))
+		}
+
+		// Expose Prometheus metrics
+		if config.Config.MetricsEnabled {
+			agent.enableMetrics()
+			agent.healthServer.SetMetrics(agent.metrics)
+		}
 	}
 
 	return agent, nil
@@ -269,9 +824,11 @@ func (a *EnhancedAgent) Start() error {
 	a.startTime = time.Now()
 	a.running = true
 
-	log.Printf("🚀 Starting enhanced agent: %s v%s", a.config.Name, a.config.Version)
-	log.Printf("💼 Wallet: %s", a.authManager.GetAddress())
-	log.Printf("🔧 Capabilities: %v", a.config.Capabilities)
+	logging.Info("starting enhanced agent",
+		"agent", a.config.Name,
+		"version", a.config.Version,
+		"wallet", a.authManager.GetAddress(),
+		"capabilities", a.config.Capabilities)
 
 	// Initialize agent handler if it supports initialization
 	if initializer, ok := a.agentHandler.(types.AgentInitializer); ok {
@@ -284,9 +841,9 @@ func (a *EnhancedAgent) Start() error {
 	// Start health server if enabled
 	if a.healthServer != nil {
 		go func() {
-			log.Printf("🌐 Starting health monitoring on port %d", a.config.HealthPort)
+			logging.Info("starting health monitoring", "port", a.config.HealthPort)
 			if err := a.healthServer.Start(); err != nil {
-				log.Printf("❌ Health server error: %v", err)
+				logging.Error("health server error", "error", err)
 			}
 		}()
 	}
@@ -297,7 +854,7 @@ func (a *EnhancedAgent) Start() error {
 	for i := 0; i < connectRetries; i++ {
 		if err := a.networkClient.Connect(); err != nil {
 			connectErr = err
-			log.Printf("⚠️ Connection attempt %d/%d failed: %v", i+1, connectRetries, err)
+			logging.Warn("connection attempt failed", "attempt", i+1, "max_attempts", connectRetries, "error", err)
 			if i < connectRetries-1 {
 				time.Sleep(time.Duration(i+1) * 2 * time.Second)
 			}
@@ -318,7 +875,7 @@ func (a *EnhancedAgent) Start() error {
 	for i := 0; i < authRetries; i++ {
 		if err := a.protocolHandler.StartAuthentication(); err != nil {
 			authErr = err
-			log.Printf("⚠️ Authentication attempt %d/%d failed: %v", i+1, authRetries, err)
+			logging.Warn("authentication attempt failed", "attempt", i+1, "max_attempts", authRetries, "error", err)
 			if i < authRetries-1 {
 				time.Sleep(time.Duration(i+1) * time.Second)
 			}
@@ -329,13 +886,29 @@ func (a *EnhancedAgent) Start() error {
 	}
 
 	if authErr != nil {
-		log.Printf("⚠️ Authentication failed after %d attempts, will retry periodically: %v", authRetries, authErr)
+		logging.Warn("authentication failed, will retry periodically", "attempts", authRetries, "error", authErr)
 	}
 
 	// Start periodic tasks
 	go a.startPeriodicTasks()
 
-	log.Printf("✅ Enhanced agent %s started successfully", a.config.Name)
+	// Reload the configuration when the config file changes or on SIGHUP
+	go a.watchConfig(a.ctx)
+	if a.prompts != nil && a.config.PromptsDir != "" {
+		go a.prompts.Watch(a.ctx, a.config.PromptsDir)
+	}
+
+	if a.metadataSyncer != nil {
+		go a.metadataSyncer.Run(a.ctx)
+	}
+	if a.ownedToken != nil {
+		go a.watchOwnership()
+	}
+	if a.encryptionKeys != nil && a.config.EncryptionRotateInterval > 0 {
+		go a.rotateEncryptionKeys(a.config.EncryptionRotateInterval)
+	}
+
+	logging.Info("enhanced agent started successfully", "agent", a.config.Name)
 	return nil
 }
 
@@ -348,41 +921,50 @@ func (a *EnhancedAgent) Stop() error {
 		return nil
 	}
 
-	log.Printf("🛑 Stopping enhanced agent: %s", a.config.Name)
+	logging.Info("stopping enhanced agent", "agent", a.config.Name)
 
 	a.running = false
 	a.cancel()
 
-	// Cancel all active tasks
+	// Stop scheduled jobs and queued tasks, then cancel all active tasks
+	a.jobs.Stop()
+	a.taskCoordinator.SetTaskScheduler(nil)
 	a.taskCoordinator.CancelAllTasks()
 
 	// Stop health server
 	if a.healthServer != nil {
 		if err := a.healthServer.Stop(); err != nil {
-			log.Printf("⚠️ Error stopping health server: %v", err)
+			logging.Warn("error stopping health server", "error", err)
 		}
 	}
 
 	// Disconnect from network
 	if err := a.networkClient.Disconnect(); err != nil {
-		log.Printf("⚠️ Error disconnecting from network: %v", err)
+		logging.Warn("error disconnecting from network", "error", err)
+	}
+
+	if a.businessCards != nil {
+		a.businessCards.Close()
+	}
+	if a.chain != nil {
+		a.chain.Close()
 	}
 
 	// Close cache connection
 	if a.agentCache != nil {
 		if err := a.agentCache.Close(); err != nil {
-			log.Printf("⚠️ Error closing cache connection: %v", err)
+			logging.Warn("error closing cache connection", "error", err)
 		}
 	}
 
 	// Cleanup agent handler if it supports cleanup
 	if cleaner, ok := a.agentHandler.(types.AgentCleaner); ok {
 		if err := cleaner.Cleanup(a.ctx); err != nil {
-			log.Printf("⚠️ Error cleaning up agent handler: %v", err)
+			logging.Warn("error cleaning up agent handler", "error", err)
 		}
 	}
 
-	log.Printf("✅ Enhanced agent %s stopped successfully", a.config.Name)
+	logging.Info("enhanced agent stopped successfully", "agent", a.config.Name)
 	return nil
 }
 
@@ -396,8 +978,12 @@ func (a *EnhancedAgent) Run() error {
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
 
-	<-sigChan
-	log.Println("📡 Received interrupt signal")
+	// The agent also stops itself, e.g. when its NFT is transferred away
+	select {
+	case <-sigChan:
+		logging.Info("received interrupt signal")
+	case <-a.ctx.Done():
+	}
 
 	return a.Stop()
 }
@@ -423,7 +1009,7 @@ func (a *EnhancedAgent) startPeriodicTasks() {
 		case <-pingTicker.C:
 			if a.networkClient.IsConnected() && a.networkClient.IsAuthenticated() {
 				if err := a.protocolHandler.SendPing(); err != nil {
-					log.Printf("⚠️ Failed to send ping: %v", err)
+					logging.Warn("failed to send ping", "error", err)
 				}
 			}
 		case <-healthTicker.C:
@@ -439,16 +1025,17 @@ func (a *EnhancedAgent) startPeriodicTasks() {
 // performHealthCheck performs periodic health checks
 func (a *EnhancedAgent) performHealthCheck() {
 	if !a.networkClient.IsConnected() {
-		log.Printf("⚠️ Network disconnected, attempting reconnection...")
+		logging.Warn("network disconnected, attempting reconnection")
 		if err := a.networkClient.Connect(); err != nil {
-			log.Printf("❌ Reconnection failed: %v", err)
+			logging.Error("reconnection failed", "error", err)
 		}
 	}
 
-	if a.networkClient.IsConnected() && !a.networkClient.IsAuthenticated() {
-		log.Printf("⚠️ Not authenticated, attempting authentication...")
+	// Authentication in progress retries on its own when the challenge expires
+	if a.networkClient.IsConnected() && !a.networkClient.IsAuthenticated() && a.protocolHandler.AuthState() != network.AuthStateAuthenticating {
+		logging.Warn("not authenticated, attempting authentication")
 		if err := a.protocolHandler.StartAuthentication(); err != nil {
-			log.Printf("❌ Authentication failed: %v", err)
+			logging.Error("authentication failed", "error", err)
 		}
 	}
 }
@@ -458,12 +1045,11 @@ func (a *EnhancedAgent) logStatus() {
 	activeTasks := a.taskCoordinator.GetActiveTaskCount()
 	uptime := time.Since(a.startTime)
 
-	log.Printf("📊 Status - Connected: %v, Authenticated: %v, Active Tasks: %d, Uptime: %v",
-		a.networkClient.IsConnected(),
-		a.networkClient.IsAuthenticated(),
-		activeTasks,
-		uptime.Round(time.Second),
-	)
+	logging.Info("status",
+		"connected", a.networkClient.IsConnected(),
+		"authenticated", a.networkClient.IsAuthenticated(),
+		"active_tasks", activeTasks,
+		"uptime", uptime.Round(time.Second))
 }
 
 // IsConnected implements the health.StatusGetter interface
@@ -476,11 +1062,73 @@ func (a *EnhancedAgent) IsAuthenticated() bool {
 	return a.networkClient.IsAuthenticated()
 }
 
+// IsRegistered implements the health.ReadinessGetter interface. A session
+// being refreshed stays registered.
+func (a *EnhancedAgent) IsRegistered() bool {
+	switch a.protocolHandler.AuthState() {
+	case network.AuthStateRegistered, network.AuthStateRefreshing:
+		return a.networkClient.IsConnected()
+	}
+	return false
+}
+
+// HasRegistered implements the health.ReadinessGetter interface
+func (a *EnhancedAgent) HasRegistered() bool {
+	return a.registeredOnce.Load()
+}
+
+// OpenCircuits implements the health.ReadinessGetter interface
+func (a *EnhancedAgent) OpenCircuits() []string {
+	var open []string
+	stats := a.networkClient.GetCircuitBreakerStatsByClass()
+	for _, class := range network.BreakerClasses {
+		if stats[class].State == network.CircuitOpen {
+			open = append(open, class)
+		}
+	}
+	return open
+}
+
+// SubscribeAuthEvents returns a channel receiving every change of the
+// authentication state (authenticated, registered, refreshing, expired, ...)
+// and a function that ends the subscription
+func (a *EnhancedAgent) SubscribeAuthEvents() (<-chan network.AuthEvent, func()) {
+	return a.protocolHandler.SubscribeAuthEvents()
+}
+
+// Events returns the bus the agent publishes its lifecycle events to:
+// connection changes, authentication, registration, task execution and
+// circuit breaker state. Subscriptions last until Unsubscribe.
+func (a *EnhancedAgent) Events() *events.Bus {
+	return a.events
+}
+
 // GetActiveTaskCount implements the health.StatusGetter interface
 func (a *EnhancedAgent) GetActiveTaskCount() int {
 	return a.taskCoordinator.GetActiveTaskCount()
 }
 
+// ScheduleJob adds a recurring job whose output is sent to the job's room
+// (empty = the agent's room). Jobs run while the agent is registered.
+func (a *EnhancedAgent) ScheduleJob(job scheduler.Job) error {
+	return a.jobs.Add(job)
+}
+
+// RemoveJob removes a scheduled job, reporting false if there is no such job
+func (a *EnhancedAgent) RemoveJob(name string) bool {
+	return a.jobs.Remove(name)
+}
+
+// Jobs returns the state of the scheduled jobs
+func (a *EnhancedAgent) Jobs() []scheduler.JobStatus {
+	return a.jobs.Jobs()
+}
+
+// GetTaskProgress implements the health.ProgressGetter interface
+func (a *EnhancedAgent) GetTaskProgress() []types.TaskProgress {
+	return a.taskCoordinator.GetTaskProgress()
+}
+
 // GetUptime implements the health.StatusGetter interface
 func (a *EnhancedAgent) GetUptime() time.Duration {
 	a.mu.RLock()
@@ -498,6 +1146,11 @@ func (a *EnhancedAgent) GetConfig() *Config {
 	return a.config
 }
 
+// GetAgentHandler returns the agent handler that processes tasks
+func (a *EnhancedAgent) GetAgentHandler() types.AgentHandler {
+	return a.agentHandler
+}
+
 // GetNetworkClient returns the network client
 func (a *EnhancedAgent) GetNetworkClient() *network.NetworkClient {
 	return a.networkClient
@@ -508,6 +1161,21 @@ func (a *EnhancedAgent) GetTaskCoordinator() *network.TaskCoordinator {
 	return a.taskCoordinator
 }
 
+// GetDelegationClient returns the client for delegating sub-tasks to other agents
+func (a *EnhancedAgent) GetDelegationClient() *network.DelegationClient {
+	return a.delegation
+}
+
+// GetProfileResolver returns the resolver of sender addresses into user profiles
+func (a *EnhancedAgent) GetProfileResolver() *network.ProfileResolver {
+	return a.profiles
+}
+
+// GetBackendClient returns the client of the backend REST APIs
+func (a *EnhancedAgent) GetBackendClient() *backend.Client {
+	return a.backend
+}
+
 // GetAuthManager returns the auth manager
 func (a *EnhancedAgent) GetAuthManager() *auth.Manager {
 	return a.authManager
@@ -519,6 +1187,264 @@ func (a *EnhancedAgent) GetCache() cache.AgentCache {
 	return a.agentCache
 }
 
+// GetConsumerRegistry returns the consumer registry, or nil when quotas are disabled
+func (a *EnhancedAgent) GetConsumerRegistry() *consumer.Registry {
+	return a.consumers
+}
+
+// roomHistoryTimeout bounds fetching room history from the server
+const roomHistoryTimeout = 15 * time.Second
+
+// GetRoomHistory returns up to n of the most recent messages of room, oldest first
+func (a *EnhancedAgent) GetRoomHistory(room string, n int) ([]types.Message, error) {
+	ctx, cancel := context.WithTimeout(a.ctx, roomHistoryTimeout)
+	defer cancel()
+	return a.protocolHandler.GetRoomHistory(ctx, room, n)
+}
+
+// ExportConversation writes the stored conversation of a room to w as JSONL or
+// markdown and returns the number of turns written
+func (a *EnhancedAgent) ExportConversation(w io.Writer, room string, opts *memory.ExportOptions) (int, error) {
+	if a.memory == nil {
+		return 0, fmt.Errorf("conversation memory is not enabled")
+	}
+	return memory.Export(a.ctx, a.memory, w, room, opts)
+}
+
+// restoreConversation rebuilds the conversation memory of the agent's room from
+// the room history when it is empty, e.g. after a restart without persistent memory
+func (a *EnhancedAgent) restoreConversation() {
+	room := a.config.Room
+	ctx, cancel := context.WithTimeout(a.ctx, roomHistoryTimeout)
+	defer cancel()
+
+	existing, err := a.memory.History(ctx, room)
+	if err != nil {
+		logging.Warn("failed to load conversation history", "room", room, "error", err)
+		return
+	}
+	if len(existing) > 0 {
+		return
+	}
+
+	messages, err := a.protocolHandler.GetRoomHistory(ctx, room, a.config.MemoryRestoreMessages)
+	if err != nil {
+		logging.Warn("failed to fetch room history", "room", room, "error", err)
+		return
+	}
+
+	turns := make([]types.ConversationMessage, 0, len(messages))
+	for _, msg := range messages {
+		if msg.Content == "" || msg.Encoding != "" { // Skip empty and undecodable messages
+			continue
+		}
+		role := memory.RoleUser
+		if msg.From == a.config.Name || msg.From == a.authManager.GetAddress() {
+			role = memory.RoleAssistant
+		}
+		turns = append(turns, types.ConversationMessage{
+			Role:      role,
+			Content:   msg.Content,
+			Sender:    msg.From,
+			Timestamp: msg.Timestamp,
+		})
+	}
+	if len(turns) == 0 {
+		return
+	}
+
+	if err := a.memory.Append(ctx, room, turns...); err != nil {
+		logging.Warn("failed to restore conversation history", "room", room, "error", err)
+		return
+	}
+	logging.Info("restored conversation history from room", "room", room, "turns", len(turns))
+}
+
+// GetConversationMemory returns the conversation memory, or nil when memory is disabled
+func (a *EnhancedAgent) GetConversationMemory() types.ConversationMemory {
+	return a.memory
+}
+
+// GetReviewGate returns the response review gate, or nil when review is disabled
+func (a *EnhancedAgent) GetReviewGate() *review.Gate {
+	return a.review
+}
+
+// GetUsageMeter returns the usage meter, or nil when metering is disabled
+func (a *EnhancedAgent) GetUsageMeter() *metering.Meter {
+	return a.meter
+}
+
+// GetLLMUsage returns the ledger of the LLM usage of tasks per room and day
+func (a *EnhancedAgent) GetLLMUsage() *metering.LLMLedger {
+	return a.llmUsage
+}
+
+// GetMetrics returns the metrics collector, or nil when metrics are disabled
+func (a *EnhancedAgent) GetMetrics() *health.Metrics {
+	return a.metrics
+}
+
+// newRedisConfig creates the Redis connection settings, defaulting the key
+// prefix to "teneo:agent:<agent_name>:"
+// newRetryStore returns the store of the retry queue, nil to keep it in memory
+func newRetryStore(config *Config, agentCache cache.AgentCache) retrystore.Store {
+	switch config.RetryQueueStore {
+	case "file":
+		logging.Info("persisting retry queue", "file", config.RetryQueueFile)
+		return retrystore.NewFile(config.RetryQueueFile)
+	case "cache":
+		if _, redis := agentCache.(*cache.RedisCache); !redis {
+			logging.Warn("retry queue is kept in the agent cache, but Redis is disabled: queued messages are lost on exit")
+		}
+		return retrystore.NewCache(agentCache, "")
+	default:
+		return nil
+	}
+}
+
+func newRedisConfig(config *Config) *cache.RedisConfig {
+	keyPrefix := config.RedisKeyPrefix
+	if keyPrefix == "" {
+		keyPrefix = fmt.Sprintf("teneo:agent:%s:", strings.ReplaceAll(strings.ToLower(config.Name), " ", "_"))
+	}
+
+	return &cache.RedisConfig{
+		Address:   config.RedisAddress,
+		Username:  config.RedisUsername,
+		Password:  config.RedisPassword,
+		DB:        config.RedisDB,
+		KeyPrefix: keyPrefix,
+		UseTLS:    config.RedisUseTLS,
+	}
+}
+
+// newLocalCache creates the cache used without Redis: in-memory if enabled, otherwise a no-op cache
+func newLocalCache(config *Config) cache.AgentCache {
+	if !config.MemoryCacheEnabled {
+		return &cache.NoOpCache{}
+	}
+
+	memoryConfig := cache.DefaultMemoryConfig()
+	memoryConfig.MaxEntries = config.MemoryCacheMaxEntries
+	logging.Info("in-memory cache initialized", "max_entries", memoryConfig.MaxEntries)
+	return cache.NewMemoryCache(memoryConfig)
+}
+
+// enableMetrics creates the metrics collector and records task metrics in it
+func (a *EnhancedAgent) enableMetrics() {
+	a.metrics = a.newMetrics()
+	if a.metadataSyncer != nil {
+		a.registerMetadataMetrics(a.metrics)
+	}
+	if a.meter != nil {
+		a.metrics.RegisterLabeledCounterFunc("billed_tasks_total", "Completed tasks recorded by the usage meter by capability", "capability", a.meter.TasksByCapability)
+	}
+	a.taskCoordinator.SetMetricsRecorder(a.metrics)
+}
+
+// newMetrics creates the metrics collector and registers the connection metrics
+func (a *EnhancedAgent) newMetrics() *health.Metrics {
+	m := health.NewMetrics()
+
+	m.RegisterCounterFunc("messages_sent_total", "WebSocket messages sent", func() float64 {
+		return float64(a.networkClient.GetConnectionMetrics().SentMessages)
+	})
+	m.RegisterCounterFunc("messages_received_total", "WebSocket messages received", func() float64 {
+		return float64(a.networkClient.GetConnectionMetrics().ReceivedMessages)
+	})
+	m.RegisterCounterFunc("messages_failed_total", "WebSocket messages that failed to send", func() float64 {
+		return float64(a.networkClient.GetConnectionMetrics().FailedMessages)
+	})
+	m.RegisterCounterFunc("reconnects_total", "Successful WebSocket reconnections", func() float64 {
+		return float64(a.networkClient.GetConnectionMetrics().SuccessfulReconnects)
+	})
+	m.RegisterCounterFunc("reconnect_attempts_total", "WebSocket reconnection attempts", func() float64 {
+		return float64(a.networkClient.GetConnectionMetrics().ReconnectAttempts)
+	})
+	m.RegisterGaugeFunc("circuit_breaker_state", "State of the circuit breaker in the worst state (0 = closed, 1 = open, 2 = half-open)", func() float64 {
+		return float64(a.networkClient.GetCircuitBreakerStats().State)
+	})
+	m.RegisterGaugeFunc("retry_queue_size", "Messages waiting in the retry queue", func() float64 {
+		return float64(a.networkClient.GetRetryQueueMetrics().CurrentQueueSize)
+	})
+	m.RegisterGaugeFunc("dead_letters", "Messages in the dead-letter queue", func() float64 {
+		return float64(a.networkClient.GetRetryQueueMetrics().DeadLetters)
+	})
+	m.RegisterCounterFunc("dead_lettered_total", "Messages the retry queue gave up on", func() float64 {
+		return float64(a.networkClient.GetRetryQueueMetrics().DeadLettered)
+	})
+	m.RegisterGaugeFunc("send_queue_depth", "Outgoing messages waiting to be written", func() float64 {
+		return float64(a.networkClient.QueueDepth())
+	})
+	m.RegisterGaugeFunc("send_buffer_utilization", "Fraction of the send buffer in use", func() float64 {
+		return a.networkClient.BufferStats().SendUtilization()
+	})
+	m.RegisterGaugeFunc("receive_buffer_utilization", "Fraction of the receive buffer in use", func() float64 {
+		return a.networkClient.BufferStats().ReceiveUtilization()
+	})
+	m.RegisterCounterFunc("send_buffer_full_total", "Messages sent while the send buffer was full", func() float64 {
+		return float64(a.networkClient.BufferStats().SendFull)
+	})
+	m.RegisterCounterFunc("send_buffer_dropped_total", "Outgoing messages discarded or failed because the send buffer was full", func() float64 {
+		return float64(a.networkClient.BufferStats().SendDropped)
+	})
+	m.RegisterCounterFunc("receive_buffer_full_total", "Messages received while the receive buffer was full", func() float64 {
+		return float64(a.networkClient.BufferStats().ReceiveFull)
+	})
+	m.RegisterCounterFunc("receive_buffer_dropped_total", "Incoming messages discarded because the receive buffer was full", func() float64 {
+		return float64(a.networkClient.BufferStats().ReceiveDropped)
+	})
+	m.RegisterCounterFunc("task_updates_coalesced_total", "Task updates merged into a later message while the connection was congested", func() float64 {
+		return float64(a.taskCoordinator.GetBackpressureStats().CoalescedUpdates)
+	})
+	m.RegisterCounterFunc("task_updates_dropped_total", "Held-back task updates discarded because the task ended with an error", func() float64 {
+		return float64(a.taskCoordinator.GetBackpressureStats().DroppedUpdates)
+	})
+	m.RegisterGaugeFunc("active_tasks", "Tasks currently executing", func() float64 {
+		return float64(a.taskCoordinator.GetActiveTaskCount())
+	})
+	m.RegisterCounterFunc("sent_bytes_total", "Bytes of WebSocket messages sent", func() float64 {
+		return float64(a.networkClient.Bandwidth().Total().SentBytes)
+	})
+	m.RegisterCounterFunc("received_bytes_total", "Bytes of WebSocket messages received", func() float64 {
+		return float64(a.networkClient.Bandwidth().Total().ReceivedBytes)
+	})
+	m.RegisterLabeledCounterFunc("room_sent_bytes_total", "Bytes sent by room", "room", func() map[string]uint64 {
+		return bandwidthBy(a.networkClient.Bandwidth().Snapshot().Rooms, bandwidth.Sent)
+	})
+	m.RegisterLabeledCounterFunc("room_received_bytes_total", "Bytes received by room", "room", func() map[string]uint64 {
+		return bandwidthBy(a.networkClient.Bandwidth().Snapshot().Rooms, bandwidth.Received)
+	})
+	m.RegisterLabeledCounterFunc("peer_sent_bytes_total", "Bytes sent by counterparty", "peer", func() map[string]uint64 {
+		return bandwidthBy(a.networkClient.Bandwidth().Snapshot().Peers, bandwidth.Sent)
+	})
+	m.RegisterLabeledCounterFunc("peer_received_bytes_total", "Bytes received by counterparty", "peer", func() map[string]uint64 {
+		return bandwidthBy(a.networkClient.Bandwidth().Snapshot().Peers, bandwidth.Received)
+	})
+	m.RegisterGaugeFunc("connected", "Whether the agent is connected (1) or not (0)", func() float64 {
+		if a.networkClient.IsConnected() {
+			return 1
+		}
+		return 0
+	})
+
+	return m
+}
+
+// bandwidthBy returns the bytes in one direction per room or counterparty
+func bandwidthBy(usage map[string]bandwidth.Usage, direction bandwidth.Direction) map[string]uint64 {
+	bytes := make(map[string]uint64, len(usage))
+	for key, u := range usage {
+		if direction == bandwidth.Sent {
+			bytes[key] = u.SentBytes
+		} else {
+			bytes[key] = u.ReceivedBytes
+		}
+	}
+	return bytes
+}
+
 // IsRunning returns whether the agent is currently running
 func (a *EnhancedAgent) IsRunning() bool {
 	a.mu.RLock()
@@ -526,23 +1452,115 @@ func (a *EnhancedAgent) IsRunning() bool {
 	return a.running
 }
 
-// UpdateCapabilities updates the agent's capabilities at runtime
-func (a *EnhancedAgent) UpdateCapabilities(capabilities []string) {
-	a.config.Capabilities = capabilities
-	a.taskCoordinator.UpdateCapabilities(capabilities)
+// UpdateResources changes the advertised hardware at runtime, e.g. after a GPU
+// was added or a larger model loaded, and announces it to the server
+func (a *EnhancedAgent) UpdateResources(resources *types.ComputeResources) error {
+	a.config.Resources = resources
+	if err := a.protocolHandler.UpdateResources(resources); err != nil {
+		return fmt.Errorf("failed to announce resources: %w", err)
+	}
 
-	if a.healthServer != nil {
-		agentInfo := &health.AgentInfo{
-			Name:         a.config.Name,
-			Version:      a.config.Version,
-			Wallet:       a.authManager.GetAddress(),
-			Capabilities: capabilities,
-			Description:  a.config.Description,
+	a.updateHealthInfo()
+
+	logging.Info("updated resources", "resources", resources)
+	return nil
+}
+
+// restartHandler cleans up and reinitializes the agent handler after it
+// panicked. Restarts are serialized; tasks still running keep the handler.
+func (a *EnhancedAgent) restartHandler(ctx context.Context, p *network.HandlerPanic) {
+	a.restartMu.Lock()
+	defer a.restartMu.Unlock()
+
+	logging.Warn("restarting agent handler after a panic", "task_id", p.TaskID, "handler", p.Handler)
+	if cleaner, ok := a.agentHandler.(types.AgentCleaner); ok {
+		if err := cleaner.Cleanup(ctx); err != nil {
+			logging.Warn("error cleaning up agent handler", "error", err)
+		}
+	}
+	if initializer, ok := a.agentHandler.(types.AgentInitializer); ok {
+		if err := initializer.Initialize(a.ctx, a.config); err != nil {
+			logging.Error("failed to reinitialize agent handler", "error", err)
+			return
 		}
-		a.healthServer.UpdateAgentInfo(agentInfo)
 	}
+	logging.Info("agent handler restarted")
+}
+
+// newChainReader creates the RPC pool payments and token balances are read
+// from, over the configured RPC endpoints
+func newChainReader(config *EnhancedAgentConfig) (*nft.RPCPool, error) {
+	endpoints := config.RPCEndpoint
+	if endpoints == "" {
+		endpoints = config.Config.EthereumRPC
+	}
+	return nft.NewRPCPoolFromURLs(endpoints)
+}
 
-	log.Printf("🔄 Updated capabilities: %v", capabilities)
+// newPaymentVerifier creates the payment verifier reading payments from pool.
+// Tasks are paid at the configured prices, or those of the manifest, in the
+// chain's native coin.
+func newPaymentVerifier(config *EnhancedAgentConfig, pool *nft.RPCPool, manifest []types.AgentCapability, wallet string, agentCache cache.AgentCache) (*payment.Verifier, error) {
+	payee := config.Config.PaymentAddress
+	if payee == "" {
+		payee = wallet
+	}
+	prices := metering.PricesFromManifest(manifest, config.Config.PriceCurrency)
+	for capability, price := range config.Config.CapabilityPrices {
+		prices[capability] = price
+	}
+	paymentConfig := &payment.Config{
+		Chain:            pool,
+		Payee:            common.HexToAddress(payee),
+		Prices:           prices,
+		Default:          config.Config.DefaultTaskPrice,
+		Currency:         config.Config.PriceCurrency,
+		MinConfirmations: uint64(config.Config.PaymentConfirmations),
+	}
+	// Share redeemed payments with the agent's replicas through Redis
+	if _, shared := agentCache.(*cache.RedisCache); shared {
+		paymentConfig.Cache = agentCache
+	}
+
+	return payment.New(paymentConfig)
+}
+
+// newNFTMinter creates an NFT minter calling the backend through client,
+// reading from the configured RPC endpoints and sending transactions through
+// the write endpoint
+func newNFTMinter(config *EnhancedAgentConfig, client *backend.Client) (*nft.NFTMinter, error) {
+	if config.RPCEndpoint == "" && config.RPCWriteEndpoint == "" {
+		minter, err := nft.NewNFTMinterWithPool(config.BackendURL, nil, config.Config.PrivateKey)
+		if err != nil {
+			return nil, err
+		}
+		minter.SetBackend(client)
+		return minter, nil
+	}
+
+	poolConfig := nft.DefaultRPCPoolConfig()
+	poolConfig.WriteEndpoint = config.RPCWriteEndpoint
+	poolConfig.ReadEndpoints = nft.ParseRPCEndpoints(config.RPCEndpoint)
+	pool, err := nft.NewRPCPool(poolConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	minter, err := nft.NewNFTMinterWithPool(config.BackendURL, pool, config.Config.PrivateKey)
+	if err != nil {
+		pool.Close()
+		return nil, err
+	}
+
+	relayer, err := config.Config.NewRelayer()
+	if err != nil {
+		minter.Close()
+		return nil, err
+	}
+	minter.SetRelayer(relayer)
+	minter.SetBackend(client)
+	minter.SetFeeConfig(config.Config.FeeConfig())
+	return minter, nil
 }
 
 // generateAgentID generates a unique agent ID from the agent name
@@ -0,0 +1,60 @@
+package agent
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/devserver"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/soak"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Reconnect quickly so forced disconnects do not stall the soak load
+const (
+	soakReconnectDelay    = 100 * time.Millisecond
+	soakReconnectMaxDelay = 2 * time.Second
+)
+
+// SoakTest runs an agent with the given config and handler against the local
+// coordinator of package devserver, under the load and faults of options, and
+// reports whether it kept the soak invariants. The agent connects to the local
+// coordinator only: it uses a throwaway wallet unless config has a private
+// key, keeps no identity file and serves no health endpoint. A nil handler
+// runs the synthetic soak.Handler.
+func SoakTest(ctx context.Context, config *Config, handler types.AgentHandler, options soak.Config) (*soak.Report, error) {
+	if handler == nil {
+		handler = &soak.Handler{}
+	}
+	if config.PrivateKey == "" {
+		key, err := crypto.GenerateKey()
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate soak wallet: %w", err)
+		}
+		config.PrivateKey = hex.EncodeToString(crypto.FromECDSA(key))
+	}
+
+	options.NewAgent = func(server *devserver.Server) (soak.Agent, error) {
+		c := *config
+		c.WebSocketURL = server.URL()
+		c.DataChannelURL = ""
+		c.ReconnectEnabled = true
+		c.ReconnectDelay = soakReconnectDelay
+		c.ReconnectMaxDelay = soakReconnectMaxDelay
+		c.MaxReconnects = 1 << 20
+		c.ReconnectMaxElapsed = 0
+		c.HealthEnabled = false
+		c.IdentityFile = ""
+		c.NFTTokenID = ""
+
+		return NewEnhancedAgent(&EnhancedAgentConfig{
+			Config:       &c,
+			AgentHandler: handler,
+			TokenID:      1,
+			BackendURL:   server.HTTPURL(),
+		})
+	}
+	return soak.Run(ctx, &options)
+}
@@ -0,0 +1,36 @@
+package agent
+
+import (
+	"crypto/tls"
+	"fmt"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/tlsconfig"
+)
+
+// WebSocketTLS returns the TLS configuration of the WebSocket connections,
+// or nil for Go's defaults
+func (c *Config) WebSocketTLS() (*tls.Config, error) {
+	return c.tlsFor(c.TLSServerName)
+}
+
+// BackendTLS returns the TLS configuration of backend requests, or nil for
+// Go's defaults
+func (c *Config) BackendTLS() (*tls.Config, error) {
+	return c.tlsFor(c.BackendTLSServerName)
+}
+
+// tlsFor builds the TLS configuration of connections verified as serverName
+func (c *Config) tlsFor(serverName string) (*tls.Config, error) {
+	config, err := (&tlsconfig.Config{
+		CAFile:             c.TLSCAFile,
+		CertFile:           c.TLSCertFile,
+		KeyFile:            c.TLSKeyFile,
+		MinVersion:         c.TLSMinVersion,
+		ServerName:         serverName,
+		InsecureSkipVerify: c.TLSInsecureSkipVerify,
+	}).Build()
+	if err != nil {
+		return nil, fmt.Errorf("invalid TLS configuration: %w", err)
+	}
+	return config, nil
+}
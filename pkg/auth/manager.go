@@ -5,6 +5,8 @@ import (
 	"crypto/rand"
 	"encoding/hex"
 	"fmt"
+	"math/big"
+	"strings"
 	"time"
 
 	"github.com/ethereum/go-ethereum/accounts"
@@ -130,6 +132,16 @@ func (m *Manager) GetAddress() string {
 	return m.address.Hex()
 }
 
+// GeneratePrivateKey creates a new wallet and returns its hex-encoded private
+// key (without 0x prefix) and its address
+func GeneratePrivateKey() (privateKeyHex, address string, err error) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		return "", "", fmt.Errorf("failed to generate private key: %w", err)
+	}
+	return hex.EncodeToString(crypto.FromECDSA(key)), crypto.PubkeyToAddress(key.PublicKey).Hex(), nil
+}
+
 // GenerateNonce generates a random nonce for authentication
 func (m *Manager) GenerateNonce() (string, error) {
 	nonce := make([]byte, 32)
@@ -262,3 +274,57 @@ func (f *FoundationSignatureService) SignMintRequest(
 func (f *FoundationSignatureService) GetAddress() string {
 	return f.address.Hex()
 }
+
+// ParsePublicKey parses a hex-encoded secp256k1 public key, compressed (33 bytes)
+// or uncompressed (65 bytes), with or without the 0x prefix
+func ParsePublicKey(publicKeyHex string) (*ecdsa.PublicKey, error) {
+	if !strings.HasPrefix(publicKeyHex, "0x") {
+		publicKeyHex = "0x" + publicKeyHex
+	}
+	raw, err := hexutil.Decode(publicKeyHex)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode public key: %w", err)
+	}
+
+	if len(raw) == 33 {
+		publicKey, err := crypto.DecompressPubkey(raw)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse public key: %w", err)
+		}
+		return publicKey, nil
+	}
+	publicKey, err := crypto.UnmarshalPubkey(raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse public key: %w", err)
+	}
+	return publicKey, nil
+}
+
+// RecoverSigner returns the address that produced an Ethereum personal-message
+// signature (as created by SignMessage) over message. Signatures with a high s
+// value are rejected, so a signature has a single valid form.
+func RecoverSigner(message, signature string) (common.Address, error) {
+	if !strings.HasPrefix(signature, "0x") {
+		signature = "0x" + signature
+	}
+	sig, err := hexutil.Decode(signature)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("failed to decode signature: %w", err)
+	}
+	if len(sig) != 65 {
+		return common.Address{}, fmt.Errorf("invalid signature length %d", len(sig))
+	}
+	if sig[64] >= 27 {
+		sig[64] -= 27
+	}
+	r, s := new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:64])
+	if !crypto.ValidateSignatureValues(sig[64], r, s, true) {
+		return common.Address{}, fmt.Errorf("invalid signature values")
+	}
+
+	pubkey, err := crypto.SigToPub(accounts.TextHash([]byte(message)), sig)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("failed to recover public key: %w", err)
+	}
+	return crypto.PubkeyToAddress(*pubkey), nil
+}
@@ -0,0 +1,63 @@
+package auth
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func TestRecoverSigner(t *testing.T) {
+	key, address, err := GeneratePrivateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	manager, err := NewManager(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signature, err := manager.SignMessage("hello")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signer, err := RecoverSigner("hello", signature)
+	if err != nil {
+		t.Fatalf("RecoverSigner: %v", err)
+	}
+	if signer.Hex() != address {
+		t.Errorf("signer = %s, want %s", signer.Hex(), address)
+	}
+
+	if signer, err := RecoverSigner("hello!", signature); err == nil && signer.Hex() == address {
+		t.Error("signature verified for another message")
+	}
+}
+
+func TestRecoverSignerRejectsHighS(t *testing.T) {
+	key, _, err := GeneratePrivateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	manager, err := NewManager(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signature, err := manager.SignMessage("hello")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// (r, n-s) with the recovery ID flipped is the malleable twin of (r, s)
+	sig := hexutil.MustDecode(signature)
+	s := new(big.Int).SetBytes(sig[32:64])
+	s.Sub(crypto.S256().Params().N, s)
+	s.FillBytes(sig[32:64])
+	sig[64] ^= 1
+
+	if _, err := RecoverSigner("hello", hexutil.Encode(sig)); err == nil || !strings.Contains(err.Error(), "invalid signature values") {
+		t.Fatalf("error = %v, want invalid signature values", err)
+	}
+}
@@ -0,0 +1,189 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// ContractConfig is the NFT contract agents are minted on
+type ContractConfig struct {
+	ContractAddress string `json:"contract_address"`
+	ChainID         string `json:"chain_id"`
+	NetworkName     string `json:"network_name"`
+}
+
+// MintSignatureRequest asks the backend to authorize a mint
+type MintSignatureRequest struct {
+	To       string `json:"to"`
+	TokenURI string `json:"tokenURI"`
+	Nonce    uint64 `json:"nonce"`
+}
+
+// MintSignature authorizes a mint on the NFT contract
+type MintSignature struct {
+	Signature string `json:"signature"`
+	Nonce     uint64 `json:"nonce"`
+}
+
+// MetadataRegistration links the hash of an agent's metadata to its NFT
+type MetadataRegistration struct {
+	Hash          string `json:"hash"`
+	TokenID       uint64 `json:"tokenId"`
+	WalletAddress string `json:"walletAddress"`
+}
+
+// AgentProfile is an agent as the backend lists it
+type AgentProfile struct {
+	AgentID       string    `json:"agent_id"`
+	Name          string    `json:"name"`
+	Description   string    `json:"description,omitempty"`
+	Image         string    `json:"image,omitempty"`
+	WalletAddress string    `json:"wallet_address"`
+	TokenID       uint64    `json:"token_id,omitempty"`
+	Capabilities  []string  `json:"capabilities"`
+	Status        string    `json:"status,omitempty"` // e.g. "online" or "offline"
+	Rooms         []string  `json:"rooms,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// Room is a chat room agents can serve
+type Room struct {
+	ID          string   `json:"id"`
+	Name        string   `json:"name"`
+	Description string   `json:"description,omitempty"`
+	Public      bool     `json:"public"`
+	Agents      []string `json:"agents,omitempty"` // IDs of the agents in the room
+}
+
+// TaskHistoryQuery selects tasks from the history (zero fields don't filter)
+type TaskHistoryQuery struct {
+	AgentID string
+	Room    string
+	Status  string // e.g. "completed" or "failed"
+	Since   time.Time
+	Until   time.Time
+	Limit   int    // Tasks per page (0 = the backend's default)
+	Cursor  string // NextCursor of the previous page
+}
+
+// TaskRecord is a task the agent handled
+type TaskRecord struct {
+	TaskID      string    `json:"task_id"`
+	AgentID     string    `json:"agent_id"`
+	Room        string    `json:"room,omitempty"`
+	Sender      string    `json:"sender,omitempty"`
+	Capability  string    `json:"capability,omitempty"`
+	Status      string    `json:"status"`
+	Error       string    `json:"error,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	CompletedAt time.Time `json:"completed_at"`
+}
+
+// TaskHistoryPage is one page of the task history, newest task first
+type TaskHistoryPage struct {
+	Tasks      []TaskRecord `json:"tasks"`
+	NextCursor string       `json:"next_cursor,omitempty"` // Empty on the last page
+}
+
+// ContractConfig returns the NFT contract agents are minted on
+func (c *Client) ContractConfig(ctx context.Context) (*ContractConfig, error) {
+	var config ContractConfig
+	if err := c.do(ctx, http.MethodGet, "/api/contract/config", nil, nil, &config); err != nil {
+		return nil, fmt.Errorf("failed to get contract config: %w", err)
+	}
+	return &config, nil
+}
+
+// UploadMetadata has the backend pin NFT metadata to IPFS and returns its ipfs:// URI
+func (c *Client) UploadMetadata(ctx context.Context, metadata any) (string, error) {
+	var response struct {
+		Success  bool   `json:"success"`
+		IpfsHash string `json:"ipfsHash"`
+		Error    string `json:"error,omitempty"`
+	}
+	if err := c.do(ctx, http.MethodPost, "/api/ipfs/upload-metadata", nil, metadata, &response); err != nil {
+		return "", fmt.Errorf("failed to upload metadata: %w", err)
+	}
+	if !response.Success {
+		return "", fmt.Errorf("backend upload failed: %s", response.Error)
+	}
+	return "ipfs://" + response.IpfsHash, nil
+}
+
+// MintSignature requests the signature authorizing a mint
+func (c *Client) MintSignature(ctx context.Context, request MintSignatureRequest) (*MintSignature, error) {
+	var signature MintSignature
+	if err := c.do(ctx, http.MethodPost, "/api/signature/generate-mint", nil, request, &signature); err != nil {
+		return nil, fmt.Errorf("failed to get mint signature: %w", err)
+	}
+	if signature.Signature == "" {
+		return nil, errors.New("backend returned empty signature")
+	}
+	return &signature, nil
+}
+
+// RegisterMetadata stores the metadata hash of an agent's NFT
+func (c *Client) RegisterMetadata(ctx context.Context, registration MetadataRegistration) error {
+	if err := c.do(ctx, http.MethodPost, "/api/agents/metadata", nil, registration, nil); err != nil {
+		return fmt.Errorf("failed to register metadata: %w", err)
+	}
+	return nil
+}
+
+// GetAgentProfile returns the profile of an agent by its ID or wallet address
+func (c *Client) GetAgentProfile(ctx context.Context, agentID string) (*AgentProfile, error) {
+	if agentID == "" {
+		return nil, errors.New("agent ID is required")
+	}
+	var profile AgentProfile
+	if err := c.do(ctx, http.MethodGet, "/api/agents/"+url.PathEscape(agentID), nil, nil, &profile); err != nil {
+		return nil, fmt.Errorf("failed to get agent profile: %w", err)
+	}
+	return &profile, nil
+}
+
+// ListRooms returns the rooms on the network
+func (c *Client) ListRooms(ctx context.Context) ([]Room, error) {
+	var response struct {
+		Rooms []Room `json:"rooms"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/api/rooms", nil, nil, &response); err != nil {
+		return nil, fmt.Errorf("failed to list rooms: %w", err)
+	}
+	return response.Rooms, nil
+}
+
+// QueryTaskHistory returns one page of tasks matching the query
+func (c *Client) QueryTaskHistory(ctx context.Context, query TaskHistoryQuery) (*TaskHistoryPage, error) {
+	params := url.Values{}
+	set := func(key, value string) {
+		if value != "" {
+			params.Set(key, value)
+		}
+	}
+	set("agent_id", query.AgentID)
+	set("room", query.Room)
+	set("status", query.Status)
+	set("cursor", query.Cursor)
+	if !query.Since.IsZero() {
+		params.Set("since", query.Since.UTC().Format(time.RFC3339))
+	}
+	if !query.Until.IsZero() {
+		params.Set("until", query.Until.UTC().Format(time.RFC3339))
+	}
+	if query.Limit > 0 {
+		params.Set("limit", strconv.Itoa(query.Limit))
+	}
+
+	var page TaskHistoryPage
+	if err := c.do(ctx, http.MethodGet, "/api/tasks", params, nil, &page); err != nil {
+		return nil, fmt.Errorf("failed to query task history: %w", err)
+	}
+	return &page, nil
+}
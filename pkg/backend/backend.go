@@ -0,0 +1,269 @@
+// Package backend is a client for the REST APIs of the Teneo backend: NFT
+// metadata and mint signatures, agent profiles, rooms and task history.
+// Requests carry the agent wallet's signature, are bound to a context and are
+// retried with exponential backoff while the backend is unavailable or rate
+// limiting.
+package backend
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/errs"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/logging"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/tlsconfig"
+)
+
+// Headers of signed requests
+const (
+	HeaderAddress   = "X-Teneo-Address"
+	HeaderTimestamp = "X-Teneo-Timestamp"
+	HeaderSignature = "X-Teneo-Signature"
+)
+
+// maxResponseSize is the most bytes read from a response
+const maxResponseSize = 4 << 20
+
+// ErrNoBaseURL is returned when a client is created without a backend URL
+var ErrNoBaseURL = errors.New("backend URL is required")
+
+// Signer signs requests with the agent's wallet; auth.Manager implements it
+type Signer interface {
+	GetAddress() string
+	SignMessage(message string) (string, error)
+}
+
+// Config configures a Client
+type Config struct {
+	BaseURL       string        // Backend URL, e.g. https://backend.teneo.pro
+	Signer        Signer        // Signs requests (nil = requests are not signed)
+	Timeout       time.Duration // Timeout of each attempt (default 30s)
+	MaxRetries    int           // Retries of requests that failed transiently (default 3, negative = none)
+	RetryDelay    time.Duration // Delay before the first retry, doubled for each further one (default 500ms)
+	MaxRetryDelay time.Duration // Longest delay between retries (default 10s)
+	HTTPClient    *http.Client  // Sends the requests (default a client with Timeout and TLS)
+	TLS           *tls.Config   // TLS of the default client: CAs, client certificate, SNI (nil = Go's defaults)
+}
+
+// Client calls the backend APIs. It is safe for concurrent use.
+type Client struct {
+	baseURL       string
+	signer        Signer
+	httpClient    *http.Client
+	maxRetries    int
+	retryDelay    time.Duration
+	maxRetryDelay time.Duration
+}
+
+// APIError is a response with an error status. Errors returned by the client
+// wrap it, so callers can check the status with errors.As.
+type APIError struct {
+	StatusCode int
+	Message    string
+}
+
+// Error implements the error interface
+func (e *APIError) Error() string {
+	return fmt.Sprintf("backend returned status %d: %s", e.StatusCode, e.Message)
+}
+
+// New creates a client
+func New(config *Config) (*Client, error) {
+	if config == nil || config.BaseURL == "" {
+		return nil, ErrNoBaseURL
+	}
+	base, err := url.Parse(config.BaseURL)
+	if err != nil || base.Scheme == "" || base.Host == "" {
+		return nil, fmt.Errorf("invalid backend URL %q", config.BaseURL)
+	}
+
+	c := &Client{
+		baseURL:       strings.TrimRight(config.BaseURL, "/"),
+		signer:        config.Signer,
+		httpClient:    config.HTTPClient,
+		maxRetries:    config.MaxRetries,
+		retryDelay:    config.RetryDelay,
+		maxRetryDelay: config.MaxRetryDelay,
+	}
+	if c.httpClient == nil {
+		timeout := config.Timeout
+		if timeout <= 0 {
+			timeout = 30 * time.Second
+		}
+		c.httpClient = tlsconfig.HTTPClient(config.TLS, timeout)
+	}
+	switch {
+	case c.maxRetries == 0:
+		c.maxRetries = 3
+	case c.maxRetries < 0:
+		c.maxRetries = 0
+	}
+	if c.retryDelay <= 0 {
+		c.retryDelay = 500 * time.Millisecond
+	}
+	if c.maxRetryDelay <= 0 {
+		c.maxRetryDelay = 10 * time.Second
+	}
+	return c, nil
+}
+
+// BaseURL returns the backend URL
+func (c *Client) BaseURL() string {
+	return c.baseURL
+}
+
+// SigningMessage returns the message a request is signed with: the method,
+// the path with its query, the Unix timestamp and the SHA-256 of the body
+func SigningMessage(method, path string, timestamp int64, body []byte) string {
+	sum := sha256.Sum256(body)
+	return fmt.Sprintf("Teneo backend request\n%s\n%s\n%d\n%s", method, path, timestamp, hex.EncodeToString(sum[:]))
+}
+
+// do sends a request with in as the JSON body (nil = none) and decodes the
+// response into out (nil = discarded), retrying transient failures
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, in, out any) error {
+	var body []byte
+	if in != nil {
+		var err error
+		if body, err = json.Marshal(in); err != nil {
+			return fmt.Errorf("failed to marshal request: %w", err)
+		}
+	}
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+
+	for attempt := 0; ; attempt++ {
+		err := c.send(ctx, method, path, body, out)
+		if err == nil || attempt >= c.maxRetries || !errs.IsRetryable(err) || ctx.Err() != nil {
+			return err
+		}
+
+		delay := c.backoff(attempt)
+		if retryAfter := errs.RetryAfter(err); retryAfter > delay {
+			delay = retryAfter
+		}
+		logging.Debug("retrying backend request", "method", method, "path", path, "attempt", attempt+1, "delay", delay, "error", err)
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+// send makes one attempt of a request. Failures are classified with pkg/errs:
+// connection errors, 5xx and 429 responses are retryable, the rest are not.
+func (c *Client) send(ctx context.Context, method, path string, body []byte, out any) error {
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return errs.Terminal(fmt.Errorf("failed to create request: %w", err))
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.signer != nil {
+		timestamp := time.Now().Unix()
+		signature, err := c.signer.SignMessage(SigningMessage(method, req.URL.RequestURI(), timestamp, body))
+		if err != nil {
+			return errs.Terminal(fmt.Errorf("failed to sign request: %w", err))
+		}
+		req.Header.Set(HeaderAddress, c.signer.GetAddress())
+		req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+		req.Header.Set(HeaderSignature, signature)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return errs.Retryable(fmt.Errorf("failed to send request to backend: %w", err))
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return errs.Retryable(fmt.Errorf("failed to read backend response: %w", err))
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		apiErr := &APIError{StatusCode: resp.StatusCode, Message: errorMessage(respBody, resp.StatusCode)}
+		switch {
+		case resp.StatusCode == http.StatusTooManyRequests:
+			retryAfter, _ := strconv.Atoi(resp.Header.Get("Retry-After"))
+			return errs.RateLimited(apiErr, time.Duration(retryAfter)*time.Second)
+		case resp.StatusCode >= 500 || resp.StatusCode == http.StatusRequestTimeout:
+			return errs.Retryable(apiErr)
+		default:
+			return errs.Terminal(apiErr)
+		}
+	}
+
+	if out == nil || len(respBody) == 0 {
+		return nil
+	}
+	if respBody[0] == '<' {
+		return errs.Terminal(fmt.Errorf("backend returned HTML instead of JSON, check the backend URL: %s", preview(respBody)))
+	}
+	if err := json.Unmarshal(respBody, out); err != nil {
+		return errs.Terminal(fmt.Errorf("failed to parse backend response: %w: %s", err, preview(respBody)))
+	}
+	return nil
+}
+
+// backoff returns the delay before retry attempt+1: exponential with jitter
+func (c *Client) backoff(attempt int) time.Duration {
+	delay := c.retryDelay << min(attempt, 16)
+	if delay <= 0 || delay > c.maxRetryDelay {
+		delay = c.maxRetryDelay
+	}
+	// Between half and all of the delay
+	return delay/2 + time.Duration(rand.Int64N(int64(delay/2)+1))
+}
+
+// errorMessage extracts the error of a JSON error response, or returns the body
+func errorMessage(body []byte, status int) string {
+	var response struct {
+		Error   string `json:"error"`
+		Message string `json:"message"`
+	}
+	if json.Unmarshal(body, &response) == nil {
+		if response.Error != "" {
+			return response.Error
+		}
+		if response.Message != "" {
+			return response.Message
+		}
+	}
+	if len(body) == 0 || body[0] == '<' {
+		return http.StatusText(status)
+	}
+	return preview(body)
+}
+
+// preview shortens a response body for error messages
+func preview(body []byte) string {
+	const limit = 200
+	s := strings.TrimSpace(string(body))
+	if len(s) > limit {
+		return s[:limit] + "..."
+	}
+	return s
+}
@@ -0,0 +1,170 @@
+package backend
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeSigner "signs" by hashing the message
+type fakeSigner struct{}
+
+func (fakeSigner) GetAddress() string { return "0xabc" }
+
+func (fakeSigner) SignMessage(message string) (string, error) {
+	sum := sha256.Sum256([]byte(message))
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func newTestClient(t *testing.T, handler http.HandlerFunc) *Client {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	client, err := New(&Config{BaseURL: server.URL + "/", Signer: fakeSigner{}, RetryDelay: time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+func TestSignedRequest(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		timestamp, _ := strconv.ParseInt(r.Header.Get(HeaderTimestamp), 10, 64)
+		want, _ := fakeSigner{}.SignMessage(SigningMessage(r.Method, r.URL.RequestURI(), timestamp, body))
+		if r.Header.Get(HeaderAddress) != "0xabc" || r.Header.Get(HeaderSignature) != want {
+			http.Error(w, `{"error":"bad signature"}`, http.StatusUnauthorized)
+			return
+		}
+		var registration MetadataRegistration
+		if err := json.Unmarshal(body, &registration); err != nil || registration.TokenID != 7 {
+			http.Error(w, `{"error":"bad body"}`, http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	err := client.RegisterMetadata(context.Background(), MetadataRegistration{Hash: "h", TokenID: 7, WalletAddress: "0xabc"})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRetries(t *testing.T) {
+	var calls atomic.Int32
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{"rooms": []Room{{ID: "general", Name: "General", Public: true}}})
+	})
+
+	rooms, err := client.ListRooms(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rooms) != 1 || rooms[0].ID != "general" || calls.Load() != 3 {
+		t.Errorf("rooms = %+v after %d calls", rooms, calls.Load())
+	}
+}
+
+func TestClientErrorsAreNotRetried(t *testing.T) {
+	var calls atomic.Int32
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		http.Error(w, `{"error":"agent not found"}`, http.StatusNotFound)
+	})
+
+	_, err := client.GetAgentProfile(context.Background(), "my agent")
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound || apiErr.Message != "agent not found" {
+		t.Fatalf("err = %v, want a 404 APIError", err)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("%d calls, want 1", calls.Load())
+	}
+}
+
+func TestRetriesGiveUp(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+	client, _ := New(&Config{BaseURL: server.URL, MaxRetries: 2, RetryDelay: time.Millisecond})
+
+	if _, err := client.ContractConfig(context.Background()); err == nil {
+		t.Fatal("expected an error")
+	}
+	if calls.Load() != 3 {
+		t.Errorf("%d calls, want 3", calls.Load())
+	}
+}
+
+func TestContextCancellation(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	client.retryDelay = time.Hour
+	client.maxRetryDelay = time.Hour
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := client.ListRooms(ctx); err == nil {
+		t.Fatal("expected an error")
+	}
+	if time.Since(start) > 5*time.Second {
+		t.Error("retry did not stop when the context ended")
+	}
+}
+
+func TestQueryTaskHistory(t *testing.T) {
+	since := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if r.URL.Path != "/api/tasks" || q.Get("room") != "general" || q.Get("since") != "2026-01-02T03:04:05Z" || q.Get("limit") != "10" || q.Has("status") {
+			http.Error(w, "unexpected query "+r.URL.String(), http.StatusBadRequest)
+			return
+		}
+		json.NewEncoder(w).Encode(TaskHistoryPage{Tasks: []TaskRecord{{TaskID: "t1", Status: "completed"}}, NextCursor: "c2"})
+	})
+
+	page, err := client.QueryTaskHistory(context.Background(), TaskHistoryQuery{Room: "general", Since: since, Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Tasks) != 1 || page.Tasks[0].TaskID != "t1" || page.NextCursor != "c2" {
+		t.Errorf("page = %+v", page)
+	}
+}
+
+func TestHTMLResponse(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		io.WriteString(w, "<html>not the API</html>")
+	})
+	if _, err := client.ContractConfig(context.Background()); err == nil {
+		t.Fatal("expected an error for an HTML response")
+	}
+}
+
+func TestNewRejectsInvalidURL(t *testing.T) {
+	if _, err := New(&Config{}); !errors.Is(err, ErrNoBaseURL) {
+		t.Errorf("got %v, want ErrNoBaseURL", err)
+	}
+	if _, err := New(&Config{BaseURL: "localhost:8080"}); err == nil {
+		t.Error("URL without scheme accepted")
+	}
+}
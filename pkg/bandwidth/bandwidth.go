@@ -0,0 +1,305 @@
+// Package bandwidth accounts for the bytes an agent exchanges with the
+// network, per room and per counterparty, and enforces optional per-room
+// bandwidth ceilings. Traffic always counts: a room that exceeds its ceiling
+// is over budget until its token bucket refills, and new work from it can be
+// refused in the meantime.
+package bandwidth
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// Other collects the traffic of rooms and counterparties beyond Config.MaxTracked
+const Other = "other"
+
+// sweepInterval is how often buckets of rooms back within their ceiling are removed
+const sweepInterval = time.Minute
+
+// maxTaskPeers bounds the task requesters remembered to attribute responses
+const maxTaskPeers = 10000
+
+// Direction of a message
+type Direction int
+
+const (
+	Sent Direction = iota
+	Received
+)
+
+// Usage is the traffic of a room, a counterparty or the whole agent
+type Usage struct {
+	SentBytes        uint64 `json:"sent_bytes"`
+	ReceivedBytes    uint64 `json:"received_bytes"`
+	SentMessages     uint64 `json:"sent_messages"`
+	ReceivedMessages uint64 `json:"received_messages"`
+	LargestSent      int    `json:"largest_sent"`     // Size of the largest message sent, in bytes
+	LargestReceived  int    `json:"largest_received"` // Size of the largest message received, in bytes
+}
+
+func (u *Usage) add(direction Direction, bytes int) {
+	switch direction {
+	case Sent:
+		u.SentBytes += uint64(bytes)
+		u.SentMessages++
+		u.LargestSent = max(u.LargestSent, bytes)
+	case Received:
+		u.ReceivedBytes += uint64(bytes)
+		u.ReceivedMessages++
+		u.LargestReceived = max(u.LargestReceived, bytes)
+	}
+}
+
+// Ceiling limits the traffic of a room, sent and received together
+type Ceiling struct {
+	PerMinute int64 `json:"per_minute"` // Sustained bytes per minute (0 = unlimited)
+	Burst     int64 `json:"burst"`      // Bytes allowed at once (default PerMinute)
+}
+
+// Unlimited reports whether the ceiling lets all traffic through
+func (c Ceiling) Unlimited() bool {
+	return c.PerMinute <= 0
+}
+
+func (c Ceiling) capacity() float64 {
+	if c.Burst > 0 {
+		return float64(c.Burst)
+	}
+	return float64(c.PerMinute)
+}
+
+// Config configures a Meter
+type Config struct {
+	RoomCeiling Ceiling // Ceiling of every room without its own
+	MaxTracked  int     // Rooms and counterparties tracked separately each, the rest count as Other (default 1000)
+}
+
+// Snapshot is the traffic accounted so far
+type Snapshot struct {
+	Total Usage            `json:"total"`
+	Rooms map[string]Usage `json:"rooms"`
+	Peers map[string]Usage `json:"peers"`
+}
+
+// bucket holds the bytes a room may still exchange; it goes negative when
+// traffic exceeds the ceiling
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+func (b *bucket) refill(ceiling Ceiling, now time.Time) {
+	if b.last.IsZero() {
+		b.tokens = ceiling.capacity()
+	} else if elapsed := now.Sub(b.last).Seconds(); elapsed > 0 {
+		b.tokens += elapsed * float64(ceiling.PerMinute) / 60
+	}
+	b.tokens = math.Min(b.tokens, ceiling.capacity())
+	b.last = now
+}
+
+// Meter records traffic and tracks room ceilings. It is safe for concurrent use.
+type Meter struct {
+	mu        sync.Mutex
+	config    Config
+	ceilings  map[string]Ceiling // Per-room overrides of config.RoomCeiling
+	total     Usage
+	rooms     map[string]*Usage
+	peers     map[string]*Usage
+	buckets   map[string]*bucket
+	taskPeers map[string]string // Requester by task ID, to attribute responses
+	taskOrder []string          // Task IDs in taskPeers, oldest first
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+// NewMeter creates a meter; the zero Config sets no ceiling
+func NewMeter(config Config) *Meter {
+	if config.MaxTracked <= 0 {
+		config.MaxTracked = 1000
+	}
+	return &Meter{
+		config:    config,
+		ceilings:  make(map[string]Ceiling),
+		rooms:     make(map[string]*Usage),
+		peers:     make(map[string]*Usage),
+		buckets:   make(map[string]*bucket),
+		taskPeers: make(map[string]string),
+		now:       time.Now,
+	}
+}
+
+// Record accounts for a message of bytes in room exchanged with peer. Either
+// may be empty. A sent message without a peer is attributed to the requester
+// of taskID, if a message for that task was received earlier.
+func (m *Meter) Record(direction Direction, room, peer, taskID string, bytes int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if taskID != "" {
+		switch {
+		case direction == Received && peer != "":
+			m.rememberTask(taskID, peer)
+		case direction == Sent && peer == "":
+			peer = m.taskPeers[taskID]
+		}
+	}
+
+	now := m.now()
+	m.sweep(now)
+	m.total.add(direction, bytes)
+	if room != "" {
+		m.usageFor(m.rooms, room).add(direction, bytes)
+		if ceiling := m.ceilingLocked(room); !ceiling.Unlimited() {
+			b := bucketFor(m.buckets, room)
+			b.refill(ceiling, now)
+			b.tokens -= float64(bytes)
+		}
+	}
+	if peer != "" {
+		m.usageFor(m.peers, peer).add(direction, bytes)
+	}
+}
+
+// usageFor returns the usage of key, or of Other once MaxTracked keys are tracked
+func (m *Meter) usageFor(usage map[string]*Usage, key string) *Usage {
+	u, ok := usage[key]
+	if ok {
+		return u
+	}
+	if len(usage) >= m.config.MaxTracked {
+		key = Other
+		if u, ok := usage[key]; ok {
+			return u
+		}
+	}
+	u = &Usage{}
+	usage[key] = u
+	return u
+}
+
+// sweep removes the buckets of rooms that refilled completely; they behave
+// like new buckets, so forgetting them bounds memory without changing any
+// decision
+func (m *Meter) sweep(now time.Time) {
+	if now.Sub(m.lastSweep) < sweepInterval {
+		return
+	}
+	m.lastSweep = now
+	for room, b := range m.buckets {
+		ceiling := m.ceilingLocked(room)
+		b.refill(ceiling, now)
+		if b.tokens >= ceiling.capacity() {
+			delete(m.buckets, room)
+		}
+	}
+}
+
+// rememberTask records the requester of a task, forgetting the oldest tasks
+// beyond maxTaskPeers
+func (m *Meter) rememberTask(taskID, peer string) {
+	if _, ok := m.taskPeers[taskID]; !ok {
+		m.taskOrder = append(m.taskOrder, taskID)
+	}
+	m.taskPeers[taskID] = peer
+	for len(m.taskOrder) > maxTaskPeers {
+		delete(m.taskPeers, m.taskOrder[0])
+		m.taskOrder = m.taskOrder[1:]
+	}
+}
+
+// Allow reports whether room is within its ceiling, and if not, how long
+// until its bucket has refilled enough to be
+func (m *Meter) Allow(room string) (bool, time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ceiling := m.ceilingLocked(room)
+	b, ok := m.buckets[room]
+	if room == "" || ceiling.Unlimited() || !ok {
+		return true, 0
+	}
+	b.refill(ceiling, m.now())
+	if b.tokens > 0 {
+		return true, 0
+	}
+	wait := (-b.tokens + 1) / (float64(ceiling.PerMinute) / 60)
+	return false, time.Duration(wait * float64(time.Second))
+}
+
+// SetRoomCeiling sets the ceiling of one room, overriding the default.
+// Ceiling{} removes the override; a negative PerMinute exempts the room.
+func (m *Meter) SetRoomCeiling(room string, ceiling Ceiling) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if ceiling == (Ceiling{}) {
+		delete(m.ceilings, room)
+	} else {
+		m.ceilings[room] = ceiling
+	}
+	if m.ceilingLocked(room).Unlimited() {
+		delete(m.buckets, room)
+	}
+}
+
+// SetDefaultCeiling sets the ceiling of rooms without their own
+func (m *Meter) SetDefaultCeiling(ceiling Ceiling) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.config.RoomCeiling = ceiling
+	for room := range m.buckets {
+		if m.ceilingLocked(room).Unlimited() {
+			delete(m.buckets, room)
+		}
+	}
+}
+
+// Ceilings returns the default ceiling and the per-room overrides
+func (m *Meter) Ceilings() (Ceiling, map[string]Ceiling) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	rooms := make(map[string]Ceiling, len(m.ceilings))
+	for room, ceiling := range m.ceilings {
+		rooms[room] = ceiling
+	}
+	return m.config.RoomCeiling, rooms
+}
+
+func (m *Meter) ceilingLocked(room string) Ceiling {
+	if ceiling, ok := m.ceilings[room]; ok {
+		return ceiling
+	}
+	return m.config.RoomCeiling
+}
+
+// Snapshot returns a copy of the traffic accounted so far
+func (m *Meter) Snapshot() Snapshot {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return Snapshot{Total: m.total, Rooms: copyUsage(m.rooms), Peers: copyUsage(m.peers)}
+}
+
+// Total returns the traffic of the whole agent
+func (m *Meter) Total() Usage {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.total
+}
+
+func bucketFor(buckets map[string]*bucket, key string) *bucket {
+	b, ok := buckets[key]
+	if !ok {
+		b = &bucket{}
+		buckets[key] = b
+	}
+	return b
+}
+
+func copyUsage(usage map[string]*Usage) map[string]Usage {
+	copied := make(map[string]Usage, len(usage))
+	for key, u := range usage {
+		copied[key] = *u
+	}
+	return copied
+}
@@ -0,0 +1,113 @@
+package bandwidth
+
+import (
+	"testing"
+	"time"
+)
+
+type clock struct{ t time.Time }
+
+func (c *clock) now() time.Time          { return c.t }
+func (c *clock) advance(d time.Duration) { c.t = c.t.Add(d) }
+
+func newTestMeter(config Config) (*Meter, *clock) {
+	c := &clock{t: time.Unix(1700000000, 0)}
+	m := NewMeter(config)
+	m.now = c.now
+	return m, c
+}
+
+func TestRecord(t *testing.T) {
+	m, _ := newTestMeter(Config{})
+	m.Record(Received, "room-1", "alice", "task-1", 100)
+	m.Record(Sent, "room-1", "", "task-1", 300)
+	m.Record(Sent, "room-1", "", "task-1", 50)
+	m.Record(Received, "room-2", "bob", "", 10)
+	m.Record(Sent, "", "", "", 5) // Ping
+
+	snapshot := m.Snapshot()
+	if want := (Usage{SentBytes: 355, ReceivedBytes: 110, SentMessages: 3, ReceivedMessages: 2, LargestSent: 300, LargestReceived: 100}); snapshot.Total != want {
+		t.Errorf("total %+v", snapshot.Total)
+	}
+	if room := snapshot.Rooms["room-1"]; room.SentBytes != 350 || room.ReceivedBytes != 100 {
+		t.Errorf("room-1 %+v", room)
+	}
+	// Responses are attributed to the requester of the task
+	if alice := snapshot.Peers["alice"]; alice.SentBytes != 350 || alice.SentMessages != 2 || alice.ReceivedBytes != 100 {
+		t.Errorf("alice %+v", alice)
+	}
+	if len(snapshot.Peers) != 2 || len(snapshot.Rooms) != 2 {
+		t.Errorf("tracked %d peers, %d rooms", len(snapshot.Peers), len(snapshot.Rooms))
+	}
+}
+
+func TestMaxTracked(t *testing.T) {
+	m, _ := newTestMeter(Config{MaxTracked: 2})
+	for _, peer := range []string{"a", "b", "c", "d"} {
+		m.Record(Received, "", peer, "", 1)
+	}
+	peers := m.Snapshot().Peers
+	if len(peers) != 3 || peers[Other].ReceivedMessages != 2 {
+		t.Errorf("peers %+v", peers)
+	}
+}
+
+func TestRoomCeiling(t *testing.T) {
+	m, c := newTestMeter(Config{RoomCeiling: Ceiling{PerMinute: 6000, Burst: 1000}})
+
+	m.Record(Received, "room-1", "alice", "task-1", 400)
+	if ok, _ := m.Allow("room-1"); !ok {
+		t.Fatal("room within its burst rejected")
+	}
+	m.Record(Sent, "room-1", "", "task-1", 1100) // 500 bytes over
+	ok, wait := m.Allow("room-1")
+	if ok || wait < 5*time.Second || wait > 6*time.Second {
+		t.Fatalf("got %v, wait %v", ok, wait)
+	}
+	if ok, _ := m.Allow("room-2"); !ok {
+		t.Error("other room rejected")
+	}
+
+	c.advance(wait)
+	if ok, _ := m.Allow("room-1"); !ok {
+		t.Error("room still rejected after the wait")
+	}
+}
+
+func TestSetRoomCeiling(t *testing.T) {
+	m, _ := newTestMeter(Config{RoomCeiling: Ceiling{PerMinute: 100}})
+	m.SetRoomCeiling("vip", Ceiling{PerMinute: -1})
+	m.SetRoomCeiling("small", Ceiling{PerMinute: 10})
+
+	m.Record(Received, "vip", "", "", 1000)
+	m.Record(Received, "small", "", "", 50)
+	m.Record(Received, "room", "", "", 50)
+	if ok, _ := m.Allow("vip"); !ok {
+		t.Error("exempt room rejected")
+	}
+	if ok, _ := m.Allow("small"); ok {
+		t.Error("room over its own ceiling allowed")
+	}
+	if ok, _ := m.Allow("room"); !ok {
+		t.Error("room within the default ceiling rejected")
+	}
+
+	m.SetRoomCeiling("small", Ceiling{})
+	if _, rooms := m.Ceilings(); len(rooms) != 1 {
+		t.Errorf("overrides %v", rooms)
+	}
+	m.SetDefaultCeiling(Ceiling{})
+	if ok, _ := m.Allow("small"); !ok {
+		t.Error("room rejected without a ceiling")
+	}
+}
+
+func TestSweep(t *testing.T) {
+	m, c := newTestMeter(Config{RoomCeiling: Ceiling{PerMinute: 60}})
+	m.Record(Received, "room-1", "", "", 10)
+	c.advance(2 * sweepInterval)
+	m.Record(Received, "room-2", "", "", 10)
+	if _, ok := m.buckets["room-1"]; ok || len(m.buckets) != 1 {
+		t.Errorf("buckets %v", m.buckets)
+	}
+}
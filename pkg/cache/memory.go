@@ -0,0 +1,421 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MemoryCache implements the AgentCache interface in process memory.
+// Keys expire after their TTL and the least recently used keys are evicted
+// once MaxEntries is reached. It is intended for local development and
+// single-instance agents; values are lost when the agent stops.
+type MemoryCache struct {
+	mu         sync.Mutex
+	entries    map[string]*list.Element
+	lru        *list.List // Front is the most recently used entry
+	maxEntries int
+	stop       chan struct{}
+	closeOnce  sync.Once
+}
+
+// memoryEntry is a cached value with its expiration time (zero = no expiry)
+type memoryEntry struct {
+	key       string
+	value     []byte
+	expiresAt time.Time
+}
+
+// expired reports whether the entry has expired at now
+func (e *memoryEntry) expired(now time.Time) bool {
+	return !e.expiresAt.IsZero() && !now.Before(e.expiresAt)
+}
+
+// MemoryConfig holds the configuration for the in-memory cache
+type MemoryConfig struct {
+	// MaxEntries is the maximum number of keys kept (0 = unlimited)
+	MaxEntries int
+
+	// CleanupInterval is how often expired keys are removed (0 = only on access)
+	CleanupInterval time.Duration
+}
+
+// DefaultMemoryConfig returns a default in-memory cache configuration
+func DefaultMemoryConfig() *MemoryConfig {
+	return &MemoryConfig{
+		MaxEntries:      10000,
+		CleanupInterval: time.Minute,
+	}
+}
+
+// NewMemoryCache creates a new in-memory cache
+func NewMemoryCache(config *MemoryConfig) *MemoryCache {
+	if config == nil {
+		config = DefaultMemoryConfig()
+	}
+
+	m := &MemoryCache{
+		entries:    make(map[string]*list.Element),
+		lru:        list.New(),
+		maxEntries: config.MaxEntries,
+		stop:       make(chan struct{}),
+	}
+
+	if config.CleanupInterval > 0 {
+		go m.cleanup(config.CleanupInterval)
+	}
+
+	return m
+}
+
+// cleanup periodically removes expired keys until the cache is closed
+func (m *MemoryCache) cleanup(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			m.removeExpired()
+		case <-m.stop:
+			return
+		}
+	}
+}
+
+// removeExpired removes all expired keys
+func (m *MemoryCache) removeExpired() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	for _, element := range m.entries {
+		if element.Value.(*memoryEntry).expired(now) {
+			m.removeElement(element)
+		}
+	}
+}
+
+// lookup returns the live entry for key, removing it if it has expired.
+// The caller must hold m.mu.
+func (m *MemoryCache) lookup(key string) *memoryEntry {
+	element, ok := m.entries[key]
+	if !ok {
+		return nil
+	}
+
+	entry := element.Value.(*memoryEntry)
+	if entry.expired(time.Now()) {
+		m.removeElement(element)
+		return nil
+	}
+
+	m.lru.MoveToFront(element)
+	return entry
+}
+
+// store sets the value of key, evicting the least recently used keys if the cache is full.
+// The caller must hold m.mu.
+func (m *MemoryCache) store(key string, value []byte, expiresAt time.Time) {
+	if element, ok := m.entries[key]; ok {
+		entry := element.Value.(*memoryEntry)
+		entry.value = value
+		entry.expiresAt = expiresAt
+		m.lru.MoveToFront(element)
+		return
+	}
+
+	m.entries[key] = m.lru.PushFront(&memoryEntry{key: key, value: value, expiresAt: expiresAt})
+
+	for m.maxEntries > 0 && m.lru.Len() > m.maxEntries {
+		m.removeElement(m.lru.Back())
+	}
+}
+
+// removeElement removes an entry. The caller must hold m.mu.
+func (m *MemoryCache) removeElement(element *list.Element) {
+	m.lru.Remove(element)
+	delete(m.entries, element.Value.(*memoryEntry).key)
+}
+
+// encodeValue converts a value to bytes the same way RedisCache stores it
+func encodeValue(value interface{}) ([]byte, error) {
+	switch v := value.(type) {
+	case string:
+		return []byte(v), nil
+	case []byte:
+		return append([]byte(nil), v...), nil
+	default:
+		// Marshal to JSON for complex types
+		jsonData, err := json.Marshal(v)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal value: %w", err)
+		}
+		return jsonData, nil
+	}
+}
+
+// expiryFor returns the expiration time for a TTL (zero = no expiry)
+func expiryFor(ttl time.Duration) time.Time {
+	if ttl == 0 {
+		return time.Time{}
+	}
+	return time.Now().Add(ttl)
+}
+
+// Set stores a value with an optional TTL
+func (m *MemoryCache) Set(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
+	if err := validateKey(key); err != nil {
+		return err
+	}
+	if ttl < 0 {
+		return fmt.Errorf("TTL cannot be negative")
+	}
+
+	data, err := encodeValue(value)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.store(key, data, expiryFor(ttl))
+	return nil
+}
+
+// Get retrieves a value by key
+func (m *MemoryCache) Get(ctx context.Context, key string) (string, error) {
+	data, err := m.GetBytes(ctx, key)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// GetBytes retrieves a value as bytes
+func (m *MemoryCache) GetBytes(ctx context.Context, key string) ([]byte, error) {
+	if err := validateKey(key); err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry := m.lookup(key)
+	if entry == nil {
+		return nil, ErrCacheKeyNotFound
+	}
+	return append([]byte(nil), entry.value...), nil
+}
+
+// Delete removes a key from the cache
+func (m *MemoryCache) Delete(ctx context.Context, key string) error {
+	if err := validateKey(key); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if element, ok := m.entries[key]; ok {
+		m.removeElement(element)
+	}
+	return nil
+}
+
+// DeletePattern removes all keys matching a glob pattern (*, ? and [...])
+func (m *MemoryCache) DeletePattern(ctx context.Context, pattern string) error {
+	if err := validateKey(pattern); err != nil {
+		return fmt.Errorf("invalid pattern: %w", err)
+	}
+
+	matcher, err := globToRegexp(sanitizePattern(pattern))
+	if err != nil {
+		return fmt.Errorf("invalid pattern %s: %w", pattern, err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for key, element := range m.entries {
+		if matcher.MatchString(key) {
+			m.removeElement(element)
+		}
+	}
+	return nil
+}
+
+// globToRegexp converts a Redis-style glob pattern to an anchored regular expression
+func globToRegexp(pattern string) (*regexp.Regexp, error) {
+	var expr strings.Builder
+	expr.WriteString("^")
+
+	inClass := false
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		switch {
+		case inClass:
+			if c == ']' {
+				inClass = false
+			}
+			if c == '\\' && i+1 < len(pattern) {
+				i++
+				expr.WriteString(regexp.QuoteMeta(string(pattern[i])))
+				continue
+			}
+			expr.WriteByte(c)
+		case c == '*':
+			expr.WriteString("(?s:.*)")
+		case c == '?':
+			expr.WriteString("(?s:.)")
+		case c == '[':
+			inClass = true
+			expr.WriteByte('[')
+			if i+1 < len(pattern) && pattern[i+1] == '^' {
+				i++
+				expr.WriteByte('^')
+			}
+		case c == '\\' && i+1 < len(pattern):
+			i++
+			expr.WriteString(regexp.QuoteMeta(string(pattern[i])))
+		default:
+			expr.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	expr.WriteString("$")
+
+	return regexp.Compile(expr.String())
+}
+
+// Exists checks if a key exists
+func (m *MemoryCache) Exists(ctx context.Context, key string) (bool, error) {
+	if err := validateKey(key); err != nil {
+		return false, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.lookup(key) != nil, nil
+}
+
+// SetWithExpiry sets a key with an absolute expiration time
+func (m *MemoryCache) SetWithExpiry(ctx context.Context, key string, value interface{}, expiryTime time.Time) error {
+	if err := validateKey(key); err != nil {
+		return err
+	}
+
+	ttl := time.Until(expiryTime)
+	if ttl <= 0 {
+		return fmt.Errorf("expiry time must be in the future")
+	}
+
+	return m.Set(ctx, key, value, ttl)
+}
+
+// Increment increments a counter key by 1
+func (m *MemoryCache) Increment(ctx context.Context, key string) (int64, error) {
+	return m.IncrementBy(ctx, key, 1)
+}
+
+// IncrementBy increments a counter key by a specific amount.
+// A missing key counts from 0; the key's TTL is kept.
+func (m *MemoryCache) IncrementBy(ctx context.Context, key string, value int64) (int64, error) {
+	if err := validateKey(key); err != nil {
+		return 0, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var current int64
+	var expiresAt time.Time
+	if entry := m.lookup(key); entry != nil {
+		n, err := strconv.ParseInt(string(entry.value), 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("failed to increment key %s by %d: value is not an integer", key, value)
+		}
+		current = n
+		expiresAt = entry.expiresAt
+	}
+
+	current += value
+	m.store(key, []byte(strconv.FormatInt(current, 10)), expiresAt)
+	return current, nil
+}
+
+// SetIfNotExists sets a value only if the key doesn't exist
+func (m *MemoryCache) SetIfNotExists(ctx context.Context, key string, value interface{}, ttl time.Duration) (bool, error) {
+	if err := validateKey(key); err != nil {
+		return false, err
+	}
+	if ttl < 0 {
+		return false, fmt.Errorf("TTL cannot be negative")
+	}
+
+	data, err := encodeValue(value)
+	if err != nil {
+		return false, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.lookup(key) != nil {
+		return false, nil
+	}
+	m.store(key, data, expiryFor(ttl))
+	return true, nil
+}
+
+// GetTTL returns the remaining TTL for a key, or 0 if the key does not expire
+func (m *MemoryCache) GetTTL(ctx context.Context, key string) (time.Duration, error) {
+	if err := validateKey(key); err != nil {
+		return 0, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry := m.lookup(key)
+	if entry == nil {
+		return 0, ErrCacheKeyNotFound
+	}
+	if entry.expiresAt.IsZero() {
+		return 0, nil
+	}
+	return time.Until(entry.expiresAt), nil
+}
+
+// Ping checks if the cache is available
+func (m *MemoryCache) Ping(ctx context.Context) error {
+	return nil
+}
+
+// Close stops the cleanup of expired keys
+func (m *MemoryCache) Close() error {
+	m.closeOnce.Do(func() { close(m.stop) })
+	return nil
+}
+
+// Clear removes all keys
+func (m *MemoryCache) Clear(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.entries = make(map[string]*list.Element)
+	m.lru.Init()
+	return nil
+}
+
+// Len returns the number of keys in the cache, including expired keys not yet removed
+func (m *MemoryCache) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.lru.Len()
+}
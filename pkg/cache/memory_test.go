@@ -0,0 +1,173 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+var _ AgentCache = (*MemoryCache)(nil)
+
+func newTestMemoryCache(maxEntries int) *MemoryCache {
+	return NewMemoryCache(&MemoryConfig{MaxEntries: maxEntries})
+}
+
+func TestMemoryCacheSetGet(t *testing.T) {
+	ctx := context.Background()
+	c := newTestMemoryCache(0)
+	defer c.Close()
+
+	if err := c.Set(ctx, "greeting", "hello", 0); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if err := c.Set(ctx, "struct", map[string]int{"n": 1}, 0); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+
+	if got, err := c.Get(ctx, "greeting"); err != nil || got != "hello" {
+		t.Errorf("Get = %q, %v", got, err)
+	}
+	if got, err := c.Get(ctx, "struct"); err != nil || got != `{"n":1}` {
+		t.Errorf("Get = %q, %v", got, err)
+	}
+	if _, err := c.Get(ctx, "missing"); !errors.Is(err, ErrCacheKeyNotFound) {
+		t.Errorf("expected ErrCacheKeyNotFound, got %v", err)
+	}
+	if err := c.Set(ctx, "", "x", 0); err == nil {
+		t.Error("expected error for empty key")
+	}
+	if err := c.Set(ctx, "negative", "x", -time.Second); err == nil {
+		t.Error("expected error for negative TTL")
+	}
+}
+
+func TestMemoryCacheTTL(t *testing.T) {
+	ctx := context.Background()
+	c := newTestMemoryCache(0)
+	defer c.Close()
+
+	c.Set(ctx, "short", "v", 20*time.Millisecond)
+	c.Set(ctx, "forever", "v", 0)
+
+	if ttl, err := c.GetTTL(ctx, "short"); err != nil || ttl <= 0 || ttl > 20*time.Millisecond {
+		t.Errorf("GetTTL = %v, %v", ttl, err)
+	}
+	if ttl, err := c.GetTTL(ctx, "forever"); err != nil || ttl != 0 {
+		t.Errorf("GetTTL of key without expiry = %v, %v", ttl, err)
+	}
+
+	time.Sleep(30 * time.Millisecond)
+
+	if exists, _ := c.Exists(ctx, "short"); exists {
+		t.Error("expected key to expire")
+	}
+	if exists, _ := c.Exists(ctx, "forever"); !exists {
+		t.Error("expected key without TTL to remain")
+	}
+	if c.Len() != 1 {
+		t.Errorf("expired key should be removed on access, Len = %d", c.Len())
+	}
+}
+
+func TestMemoryCacheCleanup(t *testing.T) {
+	c := NewMemoryCache(&MemoryConfig{CleanupInterval: 10 * time.Millisecond})
+	defer c.Close()
+
+	c.Set(context.Background(), "short", "v", 5*time.Millisecond)
+	time.Sleep(50 * time.Millisecond)
+
+	if c.Len() != 0 {
+		t.Errorf("expected expired key to be cleaned up, Len = %d", c.Len())
+	}
+}
+
+func TestMemoryCacheLRUEviction(t *testing.T) {
+	ctx := context.Background()
+	c := newTestMemoryCache(2)
+	defer c.Close()
+
+	c.Set(ctx, "a", "1", 0)
+	c.Set(ctx, "b", "2", 0)
+	c.Get(ctx, "a") // a is now the most recently used
+	c.Set(ctx, "c", "3", 0)
+
+	if exists, _ := c.Exists(ctx, "b"); exists {
+		t.Error("expected least recently used key to be evicted")
+	}
+	for _, key := range []string{"a", "c"} {
+		if exists, _ := c.Exists(ctx, key); !exists {
+			t.Errorf("expected %s to remain", key)
+		}
+	}
+}
+
+func TestMemoryCacheIncrement(t *testing.T) {
+	ctx := context.Background()
+	c := newTestMemoryCache(0)
+	defer c.Close()
+
+	if n, err := c.Increment(ctx, "counter"); err != nil || n != 1 {
+		t.Errorf("Increment = %d, %v", n, err)
+	}
+	if n, err := c.IncrementBy(ctx, "counter", 5); err != nil || n != 6 {
+		t.Errorf("IncrementBy = %d, %v", n, err)
+	}
+
+	c.Set(ctx, "text", "abc", 0)
+	if _, err := c.Increment(ctx, "text"); err == nil {
+		t.Error("expected error incrementing a non-integer value")
+	}
+
+	c.Set(ctx, "expiring", "1", time.Hour)
+	c.Increment(ctx, "expiring")
+	if ttl, _ := c.GetTTL(ctx, "expiring"); ttl <= 0 {
+		t.Error("Increment should keep the key's TTL")
+	}
+}
+
+func TestMemoryCacheSetIfNotExists(t *testing.T) {
+	ctx := context.Background()
+	c := newTestMemoryCache(0)
+	defer c.Close()
+
+	if set, err := c.SetIfNotExists(ctx, "lock", "first", time.Minute); err != nil || !set {
+		t.Errorf("SetIfNotExists = %v, %v", set, err)
+	}
+	if set, _ := c.SetIfNotExists(ctx, "lock", "second", time.Minute); set {
+		t.Error("expected SetIfNotExists to fail for existing key")
+	}
+	if got, _ := c.Get(ctx, "lock"); got != "first" {
+		t.Errorf("Get = %q, want first", got)
+	}
+}
+
+func TestMemoryCacheDeletePattern(t *testing.T) {
+	ctx := context.Background()
+	c := newTestMemoryCache(0)
+	defer c.Close()
+
+	for _, key := range []string{"session:1", "session:2", "sessions", "user:1"} {
+		c.Set(ctx, key, "v", 0)
+	}
+
+	if err := c.DeletePattern(ctx, "session:*"); err != nil {
+		t.Fatalf("DeletePattern: %v", err)
+	}
+	for key, want := range map[string]bool{"session:1": false, "session:2": false, "sessions": true, "user:1": true} {
+		if exists, _ := c.Exists(ctx, key); exists != want {
+			t.Errorf("Exists(%q) = %v, want %v", key, exists, want)
+		}
+	}
+
+	if err := c.DeletePattern(ctx, "user:[0-9]"); err != nil {
+		t.Fatalf("DeletePattern: %v", err)
+	}
+	if exists, _ := c.Exists(ctx, "user:1"); exists {
+		t.Error("expected character class pattern to match")
+	}
+
+	if err := c.Clear(ctx); err != nil || c.Len() != 0 {
+		t.Errorf("Clear: %v, Len = %d", err, c.Len())
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"strings"
+	"sync"
 	"time"
 	"unicode/utf8"
 
@@ -14,8 +15,10 @@ import (
 
 // RedisCache implements the AgentCache interface using Redis
 type RedisCache struct {
+	mu        sync.RWMutex // Guards client and keyPrefix, which Reconfigure replaces
 	client    *redis.Client
-	keyPrefix string // Prefix for all keys to avoid collisions
+	keyPrefix string      // Prefix for all keys to avoid collisions
+	pool      *RedisCache // Cache whose connection is shared, set by WithPrefix
 }
 
 // RedisConfig holds the configuration for Redis connection
@@ -77,6 +80,19 @@ func NewRedisCache(config *RedisConfig) (*RedisCache, error) {
 		config = DefaultRedisConfig()
 	}
 
+	client, err := newRedisClient(config)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RedisCache{
+		client:    client,
+		keyPrefix: config.KeyPrefix,
+	}, nil
+}
+
+// newRedisClient creates a Redis client and checks the connection
+func newRedisClient(config *RedisConfig) (*redis.Client, error) {
 	options := &redis.Options{
 		Addr:         config.Address,
 		Username:     config.Username, // Redis 6+ ACL username
@@ -103,18 +119,67 @@ func NewRedisCache(config *RedisConfig) (*RedisCache, error) {
 	defer cancel()
 
 	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
 		return nil, fmt.Errorf("failed to connect to Redis: %w", err)
 	}
 
-	return &RedisCache{
-		client:    client,
-		keyPrefix: config.KeyPrefix,
-	}, nil
+	return client, nil
+}
+
+// WithPrefix returns a cache with another key prefix that shares r's
+// connection pool, e.g. for several agents in one process. Closing it leaves
+// the shared connection open; Reconfigure gives it a connection of its own.
+func (r *RedisCache) WithPrefix(keyPrefix string) *RedisCache {
+	return &RedisCache{keyPrefix: keyPrefix, pool: r}
+}
+
+// Reconfigure connects with new settings and swaps the connection in place, so
+// components sharing the cache keep working without a restart. The current
+// connection is kept if the new server cannot be reached. Operations still
+// running on the old connection when it is closed fail and should be retried.
+func (r *RedisCache) Reconfigure(config *RedisConfig) error {
+	if config == nil {
+		config = DefaultRedisConfig()
+	}
+
+	client, err := newRedisClient(config)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	old, shared := r.client, r.pool != nil
+	r.client = client
+	r.keyPrefix = config.KeyPrefix
+	r.pool = nil
+	r.mu.Unlock()
+
+	if shared {
+		return nil
+	}
+	return old.Close()
+}
+
+// conn returns the current Redis client
+func (r *RedisCache) conn() *redis.Client {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.pool != nil {
+		return r.pool.conn()
+	}
+	return r.client
+}
+
+// prefix returns the current key prefix
+func (r *RedisCache) prefix() string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.keyPrefix
 }
 
 // prefixKey adds the prefix to a key
 func (r *RedisCache) prefixKey(key string) string {
-	return r.keyPrefix + key
+	return r.prefix() + key
 }
 
 // validateKey validates a cache key for security and correctness
@@ -182,7 +247,7 @@ func (r *RedisCache) Set(ctx context.Context, key string, value interface{}, ttl
 		data = jsonData
 	}
 
-	if err := r.client.Set(ctx, prefixedKey, data, ttl).Err(); err != nil {
+	if err := r.conn().Set(ctx, prefixedKey, data, ttl).Err(); err != nil {
 		return fmt.Errorf("failed to set key %s: %w", key, err)
 	}
 
@@ -198,7 +263,7 @@ func (r *RedisCache) Get(ctx context.Context, key string) (string, error) {
 
 	prefixedKey := r.prefixKey(key)
 
-	result, err := r.client.Get(ctx, prefixedKey).Result()
+	result, err := r.conn().Get(ctx, prefixedKey).Result()
 	if err == redis.Nil {
 		return "", ErrCacheKeyNotFound
 	}
@@ -218,7 +283,7 @@ func (r *RedisCache) GetBytes(ctx context.Context, key string) ([]byte, error) {
 
 	prefixedKey := r.prefixKey(key)
 
-	result, err := r.client.Get(ctx, prefixedKey).Bytes()
+	result, err := r.conn().Get(ctx, prefixedKey).Bytes()
 	if err == redis.Nil {
 		return nil, ErrCacheKeyNotFound
 	}
@@ -238,7 +303,7 @@ func (r *RedisCache) Delete(ctx context.Context, key string) error {
 
 	prefixedKey := r.prefixKey(key)
 
-	if err := r.client.Del(ctx, prefixedKey).Err(); err != nil {
+	if err := r.conn().Del(ctx, prefixedKey).Err(); err != nil {
 		return fmt.Errorf("failed to delete key %s: %w", key, err)
 	}
 
@@ -266,14 +331,14 @@ func (r *RedisCache) DeletePattern(ctx context.Context, pattern string) error {
 	for {
 		var scanKeys []string
 		var err error
-		scanKeys, cursor, err = r.client.Scan(ctx, cursor, prefixedPattern, 100).Result()
+		scanKeys, cursor, err = r.conn().Scan(ctx, cursor, prefixedPattern, 100).Result()
 		if err != nil {
 			return fmt.Errorf("failed to scan keys with pattern %s: %w", pattern, err)
 		}
 
 		// Double-check: only include keys that actually start with our prefix
 		for _, key := range scanKeys {
-			if strings.HasPrefix(key, r.keyPrefix) {
+			if strings.HasPrefix(key, r.prefix()) {
 				keys = append(keys, key)
 			}
 		}
@@ -285,7 +350,7 @@ func (r *RedisCache) DeletePattern(ctx context.Context, pattern string) error {
 
 	// Delete all matching keys
 	if len(keys) > 0 {
-		if err := r.client.Del(ctx, keys...).Err(); err != nil {
+		if err := r.conn().Del(ctx, keys...).Err(); err != nil {
 			return fmt.Errorf("failed to delete keys with pattern %s: %w", pattern, err)
 		}
 	}
@@ -302,7 +367,7 @@ func (r *RedisCache) Exists(ctx context.Context, key string) (bool, error) {
 
 	prefixedKey := r.prefixKey(key)
 
-	result, err := r.client.Exists(ctx, prefixedKey).Result()
+	result, err := r.conn().Exists(ctx, prefixedKey).Result()
 	if err != nil {
 		return false, fmt.Errorf("failed to check existence of key %s: %w", key, err)
 	}
@@ -334,7 +399,7 @@ func (r *RedisCache) Increment(ctx context.Context, key string) (int64, error) {
 
 	prefixedKey := r.prefixKey(key)
 
-	result, err := r.client.Incr(ctx, prefixedKey).Result()
+	result, err := r.conn().Incr(ctx, prefixedKey).Result()
 	if err != nil {
 		return 0, fmt.Errorf("failed to increment key %s: %w", key, err)
 	}
@@ -351,7 +416,7 @@ func (r *RedisCache) IncrementBy(ctx context.Context, key string, value int64) (
 
 	prefixedKey := r.prefixKey(key)
 
-	result, err := r.client.IncrBy(ctx, prefixedKey, value).Result()
+	result, err := r.conn().IncrBy(ctx, prefixedKey, value).Result()
 	if err != nil {
 		return 0, fmt.Errorf("failed to increment key %s by %d: %w", key, value, err)
 	}
@@ -387,7 +452,7 @@ func (r *RedisCache) SetIfNotExists(ctx context.Context, key string, value inter
 		data = jsonData
 	}
 
-	result, err := r.client.SetNX(ctx, prefixedKey, data, ttl).Result()
+	result, err := r.conn().SetNX(ctx, prefixedKey, data, ttl).Result()
 	if err != nil {
 		return false, fmt.Errorf("failed to set key %s if not exists: %w", key, err)
 	}
@@ -404,7 +469,7 @@ func (r *RedisCache) GetTTL(ctx context.Context, key string) (time.Duration, err
 
 	prefixedKey := r.prefixKey(key)
 
-	ttl, err := r.client.TTL(ctx, prefixedKey).Result()
+	ttl, err := r.conn().TTL(ctx, prefixedKey).Result()
 	if err != nil {
 		return 0, fmt.Errorf("failed to get TTL for key %s: %w", key, err)
 	}
@@ -418,15 +483,21 @@ func (r *RedisCache) GetTTL(ctx context.Context, key string) (time.Duration, err
 
 // Ping checks if the cache is available
 func (r *RedisCache) Ping(ctx context.Context) error {
-	if err := r.client.Ping(ctx).Err(); err != nil {
+	if err := r.conn().Ping(ctx).Err(); err != nil {
 		return fmt.Errorf("Redis ping failed: %w", err)
 	}
 	return nil
 }
 
-// Close closes the Redis connection
+// Close closes the Redis connection, unless it is shared with another cache
 func (r *RedisCache) Close() error {
-	return r.client.Close()
+	r.mu.RLock()
+	shared := r.pool != nil
+	r.mu.RUnlock()
+	if shared {
+		return nil
+	}
+	return r.conn().Close()
 }
 
 // Clear removes all keys with the agent's prefix
@@ -435,6 +506,7 @@ func (r *RedisCache) Clear(ctx context.Context) error {
 }
 
 // GetClient returns the underlying Redis client for advanced operations
+// (replaced when the cache is reconfigured)
 func (r *RedisCache) GetClient() *redis.Client {
-	return r.client
+	return r.conn()
 }
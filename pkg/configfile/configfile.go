@@ -0,0 +1,98 @@
+// Package configfile reads configuration files in YAML, TOML or JSON and
+// decodes them into structs with strict schema checks. Unknown keys, values of
+// the wrong type and unset environment variables are all reported at once
+// instead of stopping at the first problem:
+//
+//	tree, err := configfile.ReadFile("agent.toml")
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	for _, problem := range configfile.Decode(tree, &config, os.LookupEnv) {
+//		fmt.Println(problem)
+//	}
+//
+// Keys are matched against the json tags of the struct fields, so a struct
+// reads the same keys from every format.
+package configfile
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Format is the syntax of a configuration file
+type Format string
+
+// Supported formats
+const (
+	FormatYAML Format = "yaml"
+	FormatTOML Format = "toml"
+	FormatJSON Format = "json"
+)
+
+// FormatFromPath returns the format matching a file's extension
+// (.yaml, .yml, .toml or .json)
+func FormatFromPath(path string) (Format, error) {
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		return FormatYAML, nil
+	case ".toml":
+		return FormatTOML, nil
+	case ".json":
+		return FormatJSON, nil
+	default:
+		return "", fmt.Errorf("unsupported config file extension %q (use .yaml, .yml, .toml or .json)", ext)
+	}
+}
+
+// ReadFile reads and parses a configuration file, choosing the format by its extension
+func ReadFile(path string) (map[string]any, error) {
+	format, err := FormatFromPath(path)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+	tree, err := Parse(data, format)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", filepath.Base(path), err)
+	}
+	return tree, nil
+}
+
+// Parse parses a configuration document into a tree of tables. An empty
+// document is an empty table.
+func Parse(data []byte, format Format) (map[string]any, error) {
+	tree := make(map[string]any)
+	switch format {
+	case FormatYAML:
+		if err := yaml.Unmarshal(data, &tree); err != nil {
+			return nil, err
+		}
+	case FormatTOML:
+		return ParseTOML(data)
+	case FormatJSON:
+		if len(bytes.TrimSpace(data)) == 0 {
+			return tree, nil
+		}
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber()
+		if err := decoder.Decode(&tree); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported config format %q", format)
+	}
+	if tree == nil {
+		tree = make(map[string]any)
+	}
+	return tree, nil
+}
@@ -0,0 +1,277 @@
+package configfile
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testGPU struct {
+	Model  string `json:"model"`
+	VRAMMB int    `json:"vram_mb,omitempty"`
+}
+
+type testResources struct {
+	GPUs     []testGPU `json:"gpus"`
+	CPUCores int       `json:"cpu_cores"`
+}
+
+type testConfig struct {
+	Name         string            `json:"name"`
+	Capabilities []string          `json:"capabilities"`
+	RateLimit    int               `json:"rate_limit_per_minute"`
+	Threshold    float64           `json:"review_threshold"`
+	Enabled      bool              `json:"redis_enabled"`
+	Delay        time.Duration     `json:"reconnect_delay"`
+	TokenID      uint64            `json:"token_id"`
+	Headers      map[string]string `json:"headers"`
+	Resources    *testResources    `json:"resources,omitempty"`
+	Internal     string            `json:"-"`
+}
+
+func env(vars map[string]string) LookupFunc {
+	return func(name string) (string, bool) {
+		value, ok := vars[name]
+		return value, ok
+	}
+}
+
+func TestParseTOML(t *testing.T) {
+	doc := `
+# Agent settings
+name = "Summarizer" # trailing comment
+capabilities = [
+  "text/summarization",
+  'text/translation', # literal string
+]
+rate_limit_per_minute = 1_000
+review_threshold = 0.75
+redis_enabled = true
+token_id = 0x2A
+started = 1979-05-27 07:32:00Z
+prompt = """
+Line one \
+  continued
+Line two"""
+path = '''C:\agents\'''
+"quoted key" = "\u00e9\t"
+site.owner = "ops"
+
+[headers]
+Authorization = "Bearer ${TOKEN}"
+
+[[resources.gpus]]
+model = "RTX 4090"
+vram_mb = 24576
+
+[[resources.gpus]]
+model = "A100"
+inline = { count = 2, tags = [] }
+`
+	tree, err := ParseTOML([]byte(doc))
+	if err != nil {
+		t.Fatalf("ParseTOML: %v", err)
+	}
+
+	checks := map[string]any{
+		"name":                  "Summarizer",
+		"rate_limit_per_minute": int64(1000),
+		"review_threshold":      0.75,
+		"redis_enabled":         true,
+		"token_id":              int64(42),
+		"prompt":                "Line one continued\nLine two",
+		"path":                  `C:\agents\`,
+		"quoted key":            "é\t",
+	}
+	for key, want := range checks {
+		if got := tree[key]; got != want {
+			t.Errorf("%s = %#v, want %#v", key, got, want)
+		}
+	}
+	if caps := tree["capabilities"].([]any); len(caps) != 2 || caps[1] != "text/translation" {
+		t.Errorf("capabilities = %#v", caps)
+	}
+	if started := tree["started"].(time.Time); !started.Equal(time.Date(1979, 5, 27, 7, 32, 0, 0, time.UTC)) {
+		t.Errorf("started = %v", started)
+	}
+	if owner := tree["site"].(map[string]any)["owner"]; owner != "ops" {
+		t.Errorf("site.owner = %#v", owner)
+	}
+	gpus := tree["resources"].(map[string]any)["gpus"].([]any)
+	if len(gpus) != 2 || gpus[1].(map[string]any)["model"] != "A100" {
+		t.Fatalf("gpus = %#v", gpus)
+	}
+	if count := gpus[1].(map[string]any)["inline"].(map[string]any)["count"]; count != int64(2) {
+		t.Errorf("inline.count = %#v", count)
+	}
+}
+
+func TestParseTOMLErrors(t *testing.T) {
+	tests := map[string]string{
+		"duplicate key":     "a = 1\na = 2",
+		"duplicate table":   "[a]\nx = 1\n[a]\ny = 2",
+		"unquoted string":   "name = summarizer",
+		"leading zero":      "n = 012",
+		"unterminated":      "name = \"summarizer",
+		"missing equals":    "name \"x\"",
+		"trailing garbage":  "n = 1 2",
+		"bad escape":        `s = "\q"`,
+		"table over value":  "a = 1\n[a]",
+		"array over static": "a = [1]\n[[a]]",
+	}
+	for name, doc := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := ParseTOML([]byte(doc)); err == nil {
+				t.Errorf("expected error for %q", doc)
+			}
+		})
+	}
+
+	_, err := ParseTOML([]byte("a = 1\n\nb = oops"))
+	if err == nil || !strings.HasPrefix(err.Error(), "line 3:") {
+		t.Errorf("error should name line 3, got %v", err)
+	}
+}
+
+func TestDecodeAllFormats(t *testing.T) {
+	docs := map[Format]string{
+		FormatYAML: "name: Summarizer\ncapabilities: [a, b]\nrate_limit_per_minute: 30\nreconnect_delay: 5s\nresources:\n  gpus:\n    - model: RTX 4090\n      vram_mb: 24576\n",
+		FormatTOML: "name = \"Summarizer\"\ncapabilities = [\"a\", \"b\"]\nrate_limit_per_minute = 30\nreconnect_delay = \"5s\"\n[[resources.gpus]]\nmodel = \"RTX 4090\"\nvram_mb = 24576\n",
+		FormatJSON: `{"name": "Summarizer", "capabilities": ["a", "b"], "rate_limit_per_minute": 30, "reconnect_delay": "5s", "resources": {"gpus": [{"model": "RTX 4090", "vram_mb": 24576}]}}`,
+	}
+	want := testConfig{
+		Name:         "Summarizer",
+		Capabilities: []string{"a", "b"},
+		RateLimit:    30,
+		Delay:        5 * time.Second,
+		Resources:    &testResources{GPUs: []testGPU{{Model: "RTX 4090", VRAMMB: 24576}}},
+	}
+
+	for format, doc := range docs {
+		t.Run(string(format), func(t *testing.T) {
+			tree, err := Parse([]byte(doc), format)
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			var got testConfig
+			if problems := Decode(tree, &got, nil); problems != nil {
+				t.Fatalf("unexpected problems: %v", problems)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("decoded %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestDecodeReportsAllProblems(t *testing.T) {
+	doc := `
+name = 42
+rate_limt_per_minute = 10
+review_threshold = "high"
+redis_enabled = "yes"
+reconnect_delay = "soon"
+token_id = -1
+capabilities = "text"
+resources = { gpus = [{ model = "A100", vram = 80 }] }
+`
+	tree, err := Parse([]byte(doc), FormatTOML)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	config := testConfig{Name: "kept"}
+	problems := Decode(tree, &config, nil)
+
+	want := []string{
+		`capabilities: expected a list, got a string`,
+		`name: expected a string, got an integer`,
+		`rate_limt_per_minute: unknown key (did you mean "rate_limit_per_minute"?)`,
+		`reconnect_delay: invalid duration "soon" (use e.g. "30s" or "5m")`,
+		`redis_enabled: expected true or false, got a string`,
+		`resources.gpus[0].vram: unknown key (did you mean "vram_mb"?)`,
+		`review_threshold: expected a number, got a string`,
+		`token_id: -1 is out of range`,
+	}
+	var got []string
+	for _, problem := range problems {
+		got = append(got, problem.Error())
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("problems:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	if config.Name != "kept" {
+		t.Errorf("invalid value replaced name: %q", config.Name)
+	}
+}
+
+func TestDecodeExpandsEnvironment(t *testing.T) {
+	doc := "name: ${AGENT_NAME}\ncapabilities: ${CAPS}\nrate_limit_per_minute: ${RATE:-60}\nredis_enabled: ${REDIS}\nreconnect_delay: ${DELAY:-10s}\nheaders:\n  Authorization: Bearer ${TOKEN}\n  Price: $$5\n"
+	tree, err := Parse([]byte(doc), FormatYAML)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	var config testConfig
+	problems := Decode(tree, &config, env(map[string]string{
+		"AGENT_NAME": "Summarizer",
+		"CAPS":       "a, b",
+		"REDIS":      "true",
+		"TOKEN":      "secret",
+	}))
+	if problems != nil {
+		t.Fatalf("unexpected problems: %v", problems)
+	}
+
+	want := testConfig{
+		Name:         "Summarizer",
+		Capabilities: []string{"a", "b"},
+		RateLimit:    60,
+		Enabled:      true,
+		Delay:        10 * time.Second,
+		Headers:      map[string]string{"Authorization": "Bearer secret", "Price": "$5"},
+	}
+	if !reflect.DeepEqual(config, want) {
+		t.Errorf("decoded %+v, want %+v", config, want)
+	}
+
+	// Unset variables are problems unless they have a default
+	problems = Decode(map[string]any{"name": "${MISSING}", "headers": map[string]any{"key": "${KEY:?must be set}"}}, &config, env(nil))
+	if len(problems) != 2 {
+		t.Fatalf("expected 2 problems, got %v", problems)
+	}
+	if !strings.Contains(problems[0].Error(), "KEY must be set") || !strings.Contains(problems[1].Error(), "MISSING is not set") {
+		t.Errorf("unexpected problems: %v", problems)
+	}
+}
+
+func TestDecodeDoesNotModifySharedPointers(t *testing.T) {
+	shared := &testResources{CPUCores: 8}
+	config := testConfig{Resources: shared}
+	if problems := Decode(map[string]any{"resources": map[string]any{"cpu_cores": 16}}, &config, nil); problems != nil {
+		t.Fatalf("unexpected problems: %v", problems)
+	}
+	if shared.CPUCores != 8 || config.Resources.CPUCores != 16 {
+		t.Errorf("shared = %d, decoded = %d", shared.CPUCores, config.Resources.CPUCores)
+	}
+}
+
+func TestReadFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "agent.toml")
+	if err := os.WriteFile(path, []byte("name = \"Summarizer\"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	tree, err := ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	if tree["name"] != "Summarizer" {
+		t.Errorf("name = %#v", tree["name"])
+	}
+
+	if _, err := ReadFile(filepath.Join(dir, "agent.ini")); err == nil {
+		t.Error("expected error for an unsupported extension")
+	}
+}
@@ -0,0 +1,410 @@
+package configfile
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Problem is a value in a configuration file that cannot be decoded
+type Problem struct {
+	Key     string // Path of the value, e.g. "resources.gpus[0].model"
+	Message string
+}
+
+// Error implements the error interface
+func (p *Problem) Error() string {
+	if p.Key == "" {
+		return p.Message
+	}
+	return p.Key + ": " + p.Message
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// Decode stores the values of tree in the struct target points to and returns
+// every problem found (nil if there are none). Keys are matched against the
+// json tags of target's fields; values of keys absent from tree are kept.
+//
+// With a lookup function, environment variable references in string values
+// are expanded (see Expand). An expanded value may also fill a number,
+// boolean or duration field, and a comma-separated one a list of strings.
+// Durations are written as strings such as "30s" or "5m".
+//
+// Values that decode are stored even if others have problems, so callers
+// that need all-or-nothing updates should decode into a copy.
+func Decode(tree map[string]any, target any, lookup LookupFunc) []error {
+	v := reflect.ValueOf(target)
+	if v.Kind() != reflect.Pointer || v.Elem().Kind() != reflect.Struct {
+		return []error{fmt.Errorf("decode target must be a pointer to a struct, got %T", target)}
+	}
+	d := &decoder{lookup: lookup}
+	d.decodeTable("", tree, v.Elem())
+	return d.problems
+}
+
+type decoder struct {
+	lookup   LookupFunc
+	problems []error
+}
+
+func (d *decoder) problemf(key, format string, args ...any) {
+	d.problems = append(d.problems, &Problem{Key: key, Message: fmt.Sprintf(format, args...)})
+}
+
+// fieldsByKey maps the json names of a struct's fields to their index
+func fieldsByKey(t reflect.Type) map[string][]int {
+	fields := make(map[string][]int)
+	for _, field := range reflect.VisibleFields(t) {
+		if !field.IsExported() || field.Anonymous {
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fields[name] = field.Index
+	}
+	return fields
+}
+
+func (d *decoder) decodeTable(path string, table map[string]any, v reflect.Value) {
+	fields := fieldsByKey(v.Type())
+	keys := make([]string, 0, len(table))
+	for key := range table {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		keyPath := joinKey(path, key)
+		index, ok := fields[key]
+		if !ok {
+			if suggestion := closestKey(key, fields); suggestion != "" {
+				d.problemf(keyPath, "unknown key (did you mean %q?)", suggestion)
+			} else {
+				d.problemf(keyPath, "unknown key")
+			}
+			continue
+		}
+		d.decodeValue(keyPath, table[key], v.FieldByIndex(index))
+	}
+}
+
+func joinKey(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func (d *decoder) decodeValue(path string, raw any, v reflect.Value) {
+	// Null leaves the field at its zero value
+	if raw == nil {
+		v.SetZero()
+		return
+	}
+
+	expanded := false
+	if s, ok := raw.(string); ok && d.lookup != nil {
+		value, wasExpanded, err := Expand(s, d.lookup)
+		if err != nil {
+			d.problemf(path, "%v", err)
+			return
+		}
+		raw, expanded = value, wasExpanded
+	}
+
+	if v.Type() == durationType {
+		d.decodeDuration(path, raw, v)
+		return
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		s, ok := raw.(string)
+		if !ok {
+			d.typeProblem(path, "a string", raw)
+			return
+		}
+		v.SetString(s)
+
+	case reflect.Bool:
+		switch value := raw.(type) {
+		case bool:
+			v.SetBool(value)
+		case string:
+			b, err := strconv.ParseBool(value)
+			if !expanded || err != nil {
+				d.typeProblem(path, "true or false", raw)
+				return
+			}
+			v.SetBool(b)
+		default:
+			d.typeProblem(path, "true or false", raw)
+		}
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, ok := toInt(raw, expanded)
+		if !ok {
+			d.typeProblem(path, "an integer", raw)
+			return
+		}
+		if v.OverflowInt(n) {
+			d.problemf(path, "%d is out of range", n)
+			return
+		}
+		v.SetInt(n)
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, ok := toInt(raw, expanded)
+		if !ok {
+			d.typeProblem(path, "an integer", raw)
+			return
+		}
+		if n < 0 || v.OverflowUint(uint64(n)) {
+			d.problemf(path, "%d is out of range", n)
+			return
+		}
+		v.SetUint(uint64(n))
+
+	case reflect.Float32, reflect.Float64:
+		f, ok := toFloat(raw, expanded)
+		if !ok {
+			d.typeProblem(path, "a number", raw)
+			return
+		}
+		v.SetFloat(f)
+
+	case reflect.Slice:
+		d.decodeSlice(path, raw, expanded, v)
+
+	case reflect.Map:
+		table, ok := raw.(map[string]any)
+		if !ok || v.Type().Key().Kind() != reflect.String {
+			d.typeProblem(path, "a table", raw)
+			return
+		}
+		m := reflect.MakeMapWithSize(v.Type(), len(table))
+		for key, value := range table {
+			elem := reflect.New(v.Type().Elem()).Elem()
+			before := len(d.problems)
+			d.decodeValue(joinKey(path, key), value, elem)
+			if len(d.problems) == before {
+				m.SetMapIndex(reflect.ValueOf(key).Convert(v.Type().Key()), elem)
+			}
+		}
+		v.Set(m)
+
+	case reflect.Struct:
+		table, ok := raw.(map[string]any)
+		if !ok {
+			d.typeProblem(path, "a table", raw)
+			return
+		}
+		d.decodeTable(path, table, v)
+
+	case reflect.Pointer:
+		// Decode into a copy, so a shared value is never modified in place
+		elem := reflect.New(v.Type().Elem())
+		if !v.IsNil() {
+			elem.Elem().Set(v.Elem())
+		}
+		d.decodeValue(path, raw, elem.Elem())
+		v.Set(elem)
+
+	case reflect.Interface:
+		v.Set(reflect.ValueOf(raw))
+
+	default:
+		d.problemf(path, "unsupported field type %s", v.Type())
+	}
+}
+
+func (d *decoder) decodeDuration(path string, raw any, v reflect.Value) {
+	switch value := raw.(type) {
+	case string:
+		duration, err := time.ParseDuration(value)
+		if err != nil {
+			d.problemf(path, "invalid duration %q (use e.g. \"30s\" or \"5m\")", value)
+			return
+		}
+		v.SetInt(int64(duration))
+	default:
+		// Zero needs no unit
+		if n, ok := toInt(raw, false); ok && n == 0 {
+			v.SetInt(0)
+			return
+		}
+		d.typeProblem(path, "a duration such as \"30s\"", raw)
+	}
+}
+
+func (d *decoder) decodeSlice(path string, raw any, expanded bool, v reflect.Value) {
+	var items []any
+	switch value := raw.(type) {
+	case []any:
+		items = value
+	case string:
+		// An expanded variable can hold a comma-separated list, like AGENT_CAPABILITIES
+		if !expanded || v.Type().Elem().Kind() != reflect.String {
+			d.typeProblem(path, "a list", raw)
+			return
+		}
+		var values []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				values = append(values, item)
+			}
+		}
+		// Set directly, the items are already expanded
+		slice := reflect.MakeSlice(v.Type(), len(values), len(values))
+		for i, item := range values {
+			slice.Index(i).SetString(item)
+		}
+		v.Set(slice)
+		return
+	default:
+		d.typeProblem(path, "a list", raw)
+		return
+	}
+
+	slice := reflect.MakeSlice(v.Type(), len(items), len(items))
+	for i, item := range items {
+		d.decodeValue(fmt.Sprintf("%s[%d]", path, i), item, slice.Index(i))
+	}
+	v.Set(slice)
+}
+
+func (d *decoder) typeProblem(path, want string, raw any) {
+	d.problemf(path, "expected %s, got %s", want, describe(raw))
+}
+
+// describe names the type of a parsed value. Values are left out, since they may be secrets.
+func describe(raw any) string {
+	switch value := raw.(type) {
+	case string:
+		return "a string"
+	case bool:
+		return "a boolean"
+	case int, int64, uint64:
+		return "an integer"
+	case float64:
+		return "a number"
+	case json.Number:
+		if _, err := value.Int64(); err == nil {
+			return "an integer"
+		}
+		return "a number"
+	case time.Time:
+		return "a date"
+	case []any:
+		return "a list"
+	case map[string]any, map[any]any:
+		return "a table"
+	default:
+		return fmt.Sprintf("%T", raw)
+	}
+}
+
+// toInt converts a parsed number, or an expanded string, to an integer
+func toInt(raw any, expanded bool) (int64, bool) {
+	switch value := raw.(type) {
+	case int:
+		return int64(value), true
+	case int64:
+		return value, true
+	case uint64:
+		if value > math.MaxInt64 {
+			return 0, false
+		}
+		return int64(value), true
+	case float64:
+		if value != math.Trunc(value) || math.Abs(value) > 1<<53 {
+			return 0, false
+		}
+		return int64(value), true
+	case json.Number:
+		n, err := value.Int64()
+		return n, err == nil
+	case string:
+		if !expanded {
+			return 0, false
+		}
+		n, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+		return n, err == nil
+	default:
+		return 0, false
+	}
+}
+
+// toFloat converts a parsed number, or an expanded string, to a float
+func toFloat(raw any, expanded bool) (float64, bool) {
+	switch value := raw.(type) {
+	case float64:
+		return value, true
+	case json.Number:
+		f, err := value.Float64()
+		return f, err == nil
+	case string:
+		if !expanded {
+			return 0, false
+		}
+		f, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		return f, err == nil
+	default:
+		if n, ok := toInt(raw, false); ok {
+			return float64(n), true
+		}
+		return 0, false
+	}
+}
+
+// closestKey returns the known key most similar to an unknown one, if any is close
+func closestKey(key string, fields map[string][]int) string {
+	normalized := strings.ToLower(strings.ReplaceAll(key, "-", "_"))
+	best, bestDistance := "", 3
+	for candidate := range fields {
+		if candidate == normalized {
+			return candidate
+		}
+		// A key missing its unit, e.g. vram for vram_mb
+		if strings.HasPrefix(candidate, normalized+"_") && best == "" {
+			best, bestDistance = candidate, 0
+			continue
+		}
+		if distance := editDistance(normalized, candidate); distance < bestDistance || distance == bestDistance && best != "" && candidate < best {
+			best, bestDistance = candidate, distance
+		}
+	}
+	return best
+}
+
+// editDistance returns the Levenshtein distance between two strings
+func editDistance(a, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
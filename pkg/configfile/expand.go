@@ -0,0 +1,104 @@
+package configfile
+
+import (
+	"fmt"
+	"strings"
+)
+
+// LookupFunc returns the value of an environment variable and whether it is set,
+// like os.LookupEnv
+type LookupFunc func(name string) (string, bool)
+
+// Expand replaces environment variable references in s:
+//
+//	${VAR}           value of VAR, an error if VAR is not set
+//	${VAR:-default}  value of VAR, or default if VAR is unset or empty
+//	${VAR:?message}  value of VAR, an error with message if VAR is unset or empty
+//	$$               a literal $
+//
+// A $ not followed by { or $ is kept as is. Expand reports whether s
+// contained any reference.
+func Expand(s string, lookup LookupFunc) (string, bool, error) {
+	if !strings.Contains(s, "$") {
+		return s, false, nil
+	}
+
+	var b strings.Builder
+	expanded := false
+	for i := 0; i < len(s); i++ {
+		if s[i] != '$' || i+1 >= len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+		switch s[i+1] {
+		case '$':
+			b.WriteByte('$')
+			i++
+		case '{':
+			end := strings.IndexByte(s[i+2:], '}')
+			if end < 0 {
+				return "", false, fmt.Errorf("unterminated ${ in %q", s)
+			}
+			value, err := expandReference(s[i+2:i+2+end], lookup)
+			if err != nil {
+				return "", false, err
+			}
+			b.WriteString(value)
+			expanded = true
+			i += 2 + end
+		default:
+			b.WriteByte('$')
+		}
+	}
+	return b.String(), expanded, nil
+}
+
+// expandReference resolves the inside of a ${...} reference
+func expandReference(ref string, lookup LookupFunc) (string, error) {
+	name, modifier, fallback := ref, "", ""
+	if i := strings.Index(ref, ":"); i >= 0 {
+		name = ref[:i]
+		if i+1 < len(ref) {
+			modifier, fallback = ref[i:i+2], ref[i+2:]
+		}
+	}
+	if !validVariable(name) {
+		return "", fmt.Errorf("invalid variable reference ${%s}", ref)
+	}
+
+	value, ok := lookup(name)
+	switch modifier {
+	case "":
+		if !ok {
+			return "", fmt.Errorf("environment variable %s is not set (use ${%s:-} to allow it to be empty)", name, name)
+		}
+		return value, nil
+	case ":-":
+		if value == "" {
+			return fallback, nil
+		}
+		return value, nil
+	case ":?":
+		if value == "" {
+			if fallback == "" {
+				fallback = "is required"
+			}
+			return "", fmt.Errorf("environment variable %s %s", name, fallback)
+		}
+		return value, nil
+	default:
+		return "", fmt.Errorf("invalid variable reference ${%s} (use ${VAR:-default} or ${VAR:?message})", ref)
+	}
+}
+
+func validVariable(name string) bool {
+	if name == "" || isDigit(name[0]) {
+		return false
+	}
+	for i := 0; i < len(name); i++ {
+		if c := name[i]; !(c == '_' || isDigit(c) || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z') {
+			return false
+		}
+	}
+	return true
+}
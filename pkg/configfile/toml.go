@@ -0,0 +1,678 @@
+package configfile
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// ParseTOML parses a TOML document into a tree of tables (map[string]any),
+// arrays ([]any), strings, int64, float64, bool and time.Time values.
+func ParseTOML(data []byte) (map[string]any, error) {
+	p := &tomlParser{
+		src:         string(data),
+		line:        1,
+		root:        make(map[string]any),
+		defined:     make(map[string]bool),
+		tableArrays: make(map[string]bool),
+	}
+	p.current = p.root
+	if err := p.parse(); err != nil {
+		return nil, err
+	}
+	return p.root, nil
+}
+
+type tomlParser struct {
+	src     string
+	pos     int
+	line    int
+	root    map[string]any
+	current map[string]any
+
+	defined     map[string]bool // Tables defined by a [header]
+	tableArrays map[string]bool // Arrays defined by [[header]]
+}
+
+func (p *tomlParser) errorf(format string, args ...any) error {
+	return fmt.Errorf("line %d: %s", p.line, fmt.Sprintf(format, args...))
+}
+
+func (p *tomlParser) eof() bool {
+	return p.pos >= len(p.src)
+}
+
+func (p *tomlParser) peek() byte {
+	if p.eof() {
+		return 0
+	}
+	return p.src[p.pos]
+}
+
+func (p *tomlParser) hasPrefix(prefix string) bool {
+	return strings.HasPrefix(p.src[p.pos:], prefix)
+}
+
+// skipSpace skips spaces and tabs
+func (p *tomlParser) skipSpace() {
+	for !p.eof() && (p.src[p.pos] == ' ' || p.src[p.pos] == '\t') {
+		p.pos++
+	}
+}
+
+// skipComment skips a comment up to the end of the line
+func (p *tomlParser) skipComment() {
+	if p.peek() != '#' {
+		return
+	}
+	for !p.eof() && p.src[p.pos] != '\n' {
+		p.pos++
+	}
+}
+
+// skipBlank skips whitespace, newlines and comments
+func (p *tomlParser) skipBlank() {
+	for !p.eof() {
+		switch p.src[p.pos] {
+		case ' ', '\t', '\r':
+			p.pos++
+		case '\n':
+			p.pos++
+			p.line++
+		case '#':
+			p.skipComment()
+		default:
+			return
+		}
+	}
+}
+
+// endOfLine consumes trailing whitespace and an optional comment up to the next line
+func (p *tomlParser) endOfLine() error {
+	p.skipSpace()
+	p.skipComment()
+	if p.hasPrefix("\r\n") {
+		p.pos += 2
+	} else if p.peek() == '\n' {
+		p.pos++
+	} else if !p.eof() {
+		return p.errorf("unexpected %q after value", p.peek())
+	}
+	p.line++
+	return nil
+}
+
+func (p *tomlParser) parse() error {
+	for {
+		p.skipBlank()
+		if p.eof() {
+			return nil
+		}
+		var err error
+		if p.peek() == '[' {
+			err = p.parseTableHeader()
+		} else {
+			err = p.parseKeyValue(p.current)
+			if err == nil {
+				err = p.endOfLine()
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (p *tomlParser) parseTableHeader() error {
+	array := p.hasPrefix("[[")
+	if array {
+		p.pos += 2
+	} else {
+		p.pos++
+	}
+	p.skipSpace()
+	keys, err := p.parseKey()
+	if err != nil {
+		return err
+	}
+	p.skipSpace()
+	closing := "]"
+	if array {
+		closing = "]]"
+	}
+	if !p.hasPrefix(closing) {
+		return p.errorf("expected %s after table name", closing)
+	}
+	p.pos += len(closing)
+
+	// Walk to the parent table, creating intermediate tables as needed
+	parent, err := p.descend(p.root, keys[:len(keys)-1], strings.Join(keys[:len(keys)-1], "."))
+	if err != nil {
+		return err
+	}
+	name := strings.Join(keys, ".")
+	last := keys[len(keys)-1]
+
+	if array {
+		existing, ok := parent[last]
+		if !ok {
+			existing = []any{}
+			p.tableArrays[name] = true
+		} else if !p.tableArrays[name] {
+			return p.errorf("cannot redefine %s as an array of tables", name)
+		}
+		table := make(map[string]any)
+		parent[last] = append(existing.([]any), table)
+		p.current = table
+		// Sub-tables of the previous element may be defined again for the new one
+		for defined := range p.defined {
+			if strings.HasPrefix(defined, name+".") {
+				delete(p.defined, defined)
+			}
+		}
+		return p.endOfLine()
+	}
+
+	if p.defined[name] {
+		return p.errorf("table %s is defined twice", name)
+	}
+	p.defined[name] = true
+	switch existing := parent[last].(type) {
+	case nil:
+		table := make(map[string]any)
+		parent[last] = table
+		p.current = table
+	case map[string]any:
+		p.current = existing
+	default:
+		return p.errorf("cannot redefine %s as a table", name)
+	}
+	return p.endOfLine()
+}
+
+// descend walks from table through keys, creating missing tables. For arrays
+// of tables the last element is used.
+func (p *tomlParser) descend(table map[string]any, keys []string, name string) (map[string]any, error) {
+	for _, key := range keys {
+		switch next := table[key].(type) {
+		case nil:
+			child := make(map[string]any)
+			table[key] = child
+			table = child
+		case map[string]any:
+			table = next
+		case []any:
+			if len(next) == 0 {
+				return nil, p.errorf("%s is not a table", name)
+			}
+			child, ok := next[len(next)-1].(map[string]any)
+			if !ok {
+				return nil, p.errorf("%s is not a table", name)
+			}
+			table = child
+		default:
+			return nil, p.errorf("%s is not a table", name)
+		}
+	}
+	return table, nil
+}
+
+func (p *tomlParser) parseKeyValue(table map[string]any) error {
+	keys, err := p.parseKey()
+	if err != nil {
+		return err
+	}
+	p.skipSpace()
+	if p.peek() != '=' {
+		return p.errorf("expected = after key %s", strings.Join(keys, "."))
+	}
+	p.pos++
+	p.skipSpace()
+
+	value, err := p.parseValue()
+	if err != nil {
+		return err
+	}
+	name := strings.Join(keys, ".")
+	parent, err := p.descend(table, keys[:len(keys)-1], name)
+	if err != nil {
+		return err
+	}
+	last := keys[len(keys)-1]
+	if _, exists := parent[last]; exists {
+		return p.errorf("key %s is defined twice", name)
+	}
+	parent[last] = value
+	return nil
+}
+
+// parseKey parses a bare, quoted or dotted key
+func (p *tomlParser) parseKey() ([]string, error) {
+	var keys []string
+	for {
+		p.skipSpace()
+		var key string
+		var err error
+		switch c := p.peek(); {
+		case c == '"':
+			key, err = p.parseBasicString()
+		case c == '\'':
+			key, err = p.parseLiteralString()
+		default:
+			start := p.pos
+			for !p.eof() && isBareKeyChar(p.src[p.pos]) {
+				p.pos++
+			}
+			if start == p.pos {
+				if p.eof() {
+					return nil, p.errorf("expected a key")
+				}
+				return nil, p.errorf("unexpected %q, expected a key", c)
+			}
+			key = p.src[start:p.pos]
+		}
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+
+		p.skipSpace()
+		if p.peek() != '.' {
+			return keys, nil
+		}
+		p.pos++
+	}
+}
+
+func isBareKeyChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '-'
+}
+
+func (p *tomlParser) parseValue() (any, error) {
+	switch c := p.peek(); {
+	case p.hasPrefix(`"""`):
+		return p.parseMultilineBasicString()
+	case c == '"':
+		return p.parseBasicString()
+	case p.hasPrefix("'''"):
+		return p.parseMultilineLiteralString()
+	case c == '\'':
+		return p.parseLiteralString()
+	case c == '[':
+		return p.parseArray()
+	case c == '{':
+		return p.parseInlineTable()
+	case p.hasPrefix("true") && !p.continuesToken(4):
+		p.pos += 4
+		return true, nil
+	case p.hasPrefix("false") && !p.continuesToken(5):
+		p.pos += 5
+		return false, nil
+	case c == 0 || c == '\n' || c == '\r' || c == '#':
+		return nil, p.errorf("expected a value")
+	default:
+		return p.parseNumberOrDate()
+	}
+}
+
+// continuesToken reports whether the value continues n bytes ahead
+func (p *tomlParser) continuesToken(n int) bool {
+	return p.pos+n < len(p.src) && isBareKeyChar(p.src[p.pos+n])
+}
+
+func (p *tomlParser) parseBasicString() (string, error) {
+	p.pos++ // opening quote
+	var b strings.Builder
+	for {
+		if p.eof() || p.peek() == '\n' {
+			return "", p.errorf("unterminated string")
+		}
+		c := p.src[p.pos]
+		switch c {
+		case '"':
+			p.pos++
+			return b.String(), nil
+		case '\\':
+			if err := p.parseEscape(&b); err != nil {
+				return "", err
+			}
+		default:
+			b.WriteByte(c)
+			p.pos++
+		}
+	}
+}
+
+func (p *tomlParser) parseMultilineBasicString() (string, error) {
+	p.pos += 3
+	p.skipNewline()
+	var b strings.Builder
+	for {
+		if p.eof() {
+			return "", p.errorf("unterminated multi-line string")
+		}
+		if p.hasPrefix(`"""`) {
+			p.pos += 3
+			// Up to two quotes may directly precede the closing delimiter
+			for i := 0; i < 2 && p.peek() == '"'; i++ {
+				b.WriteByte('"')
+				p.pos++
+			}
+			return b.String(), nil
+		}
+		c := p.src[p.pos]
+		switch {
+		case c == '\\' && p.lineEndingBackslash():
+			// A line ending backslash trims the newline and following whitespace
+			p.pos++
+			for !p.eof() && strings.IndexByte(" \t\r\n", p.src[p.pos]) >= 0 {
+				if p.src[p.pos] == '\n' {
+					p.line++
+				}
+				p.pos++
+			}
+		case c == '\\':
+			if err := p.parseEscape(&b); err != nil {
+				return "", err
+			}
+		default:
+			if c == '\n' {
+				p.line++
+			}
+			b.WriteByte(c)
+			p.pos++
+		}
+	}
+}
+
+// lineEndingBackslash reports whether the backslash at pos is followed only by whitespace up to the end of the line
+func (p *tomlParser) lineEndingBackslash() bool {
+	for i := p.pos + 1; i < len(p.src); i++ {
+		switch p.src[i] {
+		case ' ', '\t', '\r':
+		case '\n':
+			return true
+		default:
+			return false
+		}
+	}
+	return false
+}
+
+// skipNewline skips a newline directly following an opening multi-line delimiter
+func (p *tomlParser) skipNewline() {
+	if p.hasPrefix("\r\n") {
+		p.pos += 2
+		p.line++
+	} else if p.peek() == '\n' {
+		p.pos++
+		p.line++
+	}
+}
+
+func (p *tomlParser) parseEscape(b *strings.Builder) error {
+	p.pos++ // backslash
+	if p.eof() {
+		return p.errorf("unterminated string")
+	}
+	c := p.src[p.pos]
+	p.pos++
+	switch c {
+	case 'b':
+		b.WriteByte('\b')
+	case 't':
+		b.WriteByte('\t')
+	case 'n':
+		b.WriteByte('\n')
+	case 'f':
+		b.WriteByte('\f')
+	case 'r':
+		b.WriteByte('\r')
+	case 'e':
+		b.WriteByte(0x1b)
+	case '"':
+		b.WriteByte('"')
+	case '\\':
+		b.WriteByte('\\')
+	case 'u', 'U':
+		size := 4
+		if c == 'U' {
+			size = 8
+		}
+		if p.pos+size > len(p.src) {
+			return p.errorf("invalid unicode escape")
+		}
+		code, err := strconv.ParseUint(p.src[p.pos:p.pos+size], 16, 32)
+		if err != nil || !utf8.ValidRune(rune(code)) {
+			return p.errorf("invalid unicode escape \\%c%s", c, p.src[p.pos:p.pos+size])
+		}
+		b.WriteRune(rune(code))
+		p.pos += size
+	default:
+		return p.errorf("invalid escape \\%c", c)
+	}
+	return nil
+}
+
+func (p *tomlParser) parseLiteralString() (string, error) {
+	p.pos++
+	start := p.pos
+	for {
+		if p.eof() || p.peek() == '\n' {
+			return "", p.errorf("unterminated string")
+		}
+		if p.peek() == '\'' {
+			s := p.src[start:p.pos]
+			p.pos++
+			return s, nil
+		}
+		p.pos++
+	}
+}
+
+func (p *tomlParser) parseMultilineLiteralString() (string, error) {
+	p.pos += 3
+	p.skipNewline()
+	end := strings.Index(p.src[p.pos:], "'''")
+	if end < 0 {
+		return "", p.errorf("unterminated multi-line string")
+	}
+	end += p.pos
+	// Up to two quotes may directly precede the closing delimiter
+	for i := 0; i < 2 && end+3 < len(p.src) && p.src[end+3] == '\''; i++ {
+		end++
+	}
+	s := p.src[p.pos:end]
+	p.line += strings.Count(s, "\n")
+	p.pos = end + 3
+	return s, nil
+}
+
+func (p *tomlParser) parseArray() ([]any, error) {
+	p.pos++
+	values := []any{}
+	for {
+		p.skipBlank()
+		if p.peek() == ']' {
+			p.pos++
+			return values, nil
+		}
+		if p.eof() {
+			return nil, p.errorf("unterminated array")
+		}
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+
+		p.skipBlank()
+		switch p.peek() {
+		case ',':
+			p.pos++
+		case ']':
+			p.pos++
+			return values, nil
+		default:
+			return nil, p.errorf("expected , or ] in array")
+		}
+	}
+}
+
+func (p *tomlParser) parseInlineTable() (map[string]any, error) {
+	p.pos++
+	table := make(map[string]any)
+	p.skipSpace()
+	if p.peek() == '}' {
+		p.pos++
+		return table, nil
+	}
+	for {
+		if err := p.parseKeyValue(table); err != nil {
+			return nil, err
+		}
+		p.skipSpace()
+		switch p.peek() {
+		case ',':
+			p.pos++
+		case '}':
+			p.pos++
+			return table, nil
+		default:
+			return nil, p.errorf("expected , or } in inline table")
+		}
+	}
+}
+
+// parseNumberOrDate parses integers, floats, dates and times
+func (p *tomlParser) parseNumberOrDate() (any, error) {
+	start := p.pos
+	for !p.eof() && isValueChar(p.src[p.pos]) {
+		p.pos++
+	}
+	// A date and time may be separated by a space
+	if p.pos-start == 10 && p.peek() == ' ' && p.pos+3 < len(p.src) && isDigit(p.src[p.pos+1]) && isDigit(p.src[p.pos+2]) && p.src[p.pos+3] == ':' {
+		p.pos++
+		for !p.eof() && isValueChar(p.src[p.pos]) {
+			p.pos++
+		}
+	}
+	token := p.src[start:p.pos]
+	if token == "" {
+		return nil, p.errorf("unexpected %q, expected a value", p.peek())
+	}
+
+	if value, ok := parseTOMLDate(token); ok {
+		return value, nil
+	}
+	if value, ok := parseTOMLInteger(token); ok {
+		return value, nil
+	}
+	if value, ok := parseTOMLFloat(token); ok {
+		return value, nil
+	}
+	return nil, p.errorf("invalid value %q (strings must be quoted)", token)
+}
+
+func isValueChar(c byte) bool {
+	return isBareKeyChar(c) || c == '.' || c == '+' || c == ':'
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// validUnderscores reports whether every underscore in s sits between two digits
+func validUnderscores(s string, digit func(byte) bool) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] == '_' && (i == 0 || i == len(s)-1 || !digit(s[i-1]) || !digit(s[i+1])) {
+			return false
+		}
+	}
+	return true
+}
+
+func isHexDigit(c byte) bool {
+	return isDigit(c) || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F'
+}
+
+func parseTOMLInteger(token string) (int64, bool) {
+	for prefix, base := range map[string]int{"0x": 16, "0o": 8, "0b": 2} {
+		if digits, ok := strings.CutPrefix(token, prefix); ok {
+			if digits == "" || !validUnderscores(digits, isHexDigit) {
+				return 0, false
+			}
+			n, err := strconv.ParseInt(strings.ReplaceAll(digits, "_", ""), base, 64)
+			return n, err == nil
+		}
+	}
+
+	digits := strings.TrimLeft(token, "+-")
+	if len(token)-len(digits) > 1 || digits == "" || !validUnderscores(digits, isDigit) {
+		return 0, false
+	}
+	for i := 0; i < len(digits); i++ {
+		if !isDigit(digits[i]) && digits[i] != '_' {
+			return 0, false
+		}
+	}
+	if len(digits) > 1 && digits[0] == '0' {
+		return 0, false // Leading zeros are not allowed
+	}
+	n, err := strconv.ParseInt(strings.ReplaceAll(token, "_", ""), 10, 64)
+	return n, err == nil
+}
+
+func parseTOMLFloat(token string) (float64, bool) {
+	switch strings.TrimLeft(token, "+-") {
+	case "inf":
+		if strings.HasPrefix(token, "-") {
+			return math.Inf(-1), true
+		}
+		return math.Inf(1), true
+	case "nan":
+		return math.NaN(), true
+	}
+
+	digits := strings.TrimLeft(token, "+-")
+	if len(token)-len(digits) > 1 || digits == "" || !isDigit(digits[0]) || !validUnderscores(digits, isDigit) {
+		return 0, false
+	}
+	if strings.HasSuffix(digits, ".") || strings.Contains(digits, "._") || strings.Contains(digits, ".e") || strings.Contains(digits, ".E") {
+		return 0, false
+	}
+	for i := 0; i < len(digits); i++ {
+		if c := digits[i]; !isDigit(c) && strings.IndexByte("_.eE+-", c) < 0 {
+			return 0, false
+		}
+	}
+	if len(digits) > 1 && digits[0] == '0' && isDigit(digits[1]) {
+		return 0, false // Leading zeros are not allowed
+	}
+	n, err := strconv.ParseFloat(strings.ReplaceAll(token, "_", ""), 64)
+	return n, err == nil
+}
+
+// TOML date and time layouts, tried in order
+var tomlDateLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02",
+	"15:04:05.999999999",
+}
+
+func parseTOMLDate(token string) (time.Time, bool) {
+	if len(token) < 8 || !isDigit(token[0]) || !(token[2] == ':' || len(token) >= 10 && token[4] == '-') {
+		return time.Time{}, false
+	}
+	normalized := strings.Replace(token, " ", "T", 1)
+	for _, layout := range tomlDateLayouts {
+		if t, err := time.Parse(layout, normalized); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,134 @@
+package consumer
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/internal/httputil"
+)
+
+// AdminPathPrefix is the path under which the admin API is served
+const AdminPathPrefix = "/admin/"
+
+// consumerUpdate is the request body for updating a consumer
+type consumerUpdate struct {
+	Plan    *string `json:"plan,omitempty"`
+	Quota   *int    `json:"quota,omitempty"`
+	Blocked *bool   `json:"blocked,omitempty"`
+}
+
+// consumerResponse combines a consumer with its current usage
+type consumerResponse struct {
+	Consumer *Consumer `json:"consumer"`
+	Usage    *Usage    `json:"usage"`
+}
+
+// AdminHandler returns an HTTP handler for managing consumers at runtime.
+// Every request must carry "Authorization: Bearer <token>".
+//
+// Endpoints:
+//
+//	GET    /admin/plans                 - list plans
+//	PUT    /admin/plans                 - add or replace a plan
+//	GET    /admin/consumers/{id}        - consumer and usage
+//	PUT    /admin/consumers/{id}        - update plan, quota or blocked flag
+//	DELETE /admin/consumers/{id}        - remove consumer (falls back to default plan)
+//	POST   /admin/consumers/{id}/reset  - reset usage for the current window
+func (r *Registry) AdminHandler(token string) http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /admin/plans", func(w http.ResponseWriter, req *http.Request) {
+		httputil.WriteJSON(w, http.StatusOK, r.GetPlans())
+	})
+
+	mux.HandleFunc("PUT /admin/plans", func(w http.ResponseWriter, req *http.Request) {
+		var plan Plan
+		if err := json.NewDecoder(req.Body).Decode(&plan); err != nil || plan.Name == "" {
+			httputil.WriteError(w, http.StatusBadRequest, "invalid plan")
+			return
+		}
+		r.SetPlan(plan)
+		updated, _ := r.GetPlan(plan.Name)
+		httputil.WriteJSON(w, http.StatusOK, updated)
+	})
+
+	mux.HandleFunc("GET /admin/consumers/{id}", func(w http.ResponseWriter, req *http.Request) {
+		r.writeConsumer(w, req, req.PathValue("id"))
+	})
+
+	mux.HandleFunc("PUT /admin/consumers/{id}", func(w http.ResponseWriter, req *http.Request) {
+		id := req.PathValue("id")
+
+		var update consumerUpdate
+		if err := json.NewDecoder(req.Body).Decode(&update); err != nil {
+			httputil.WriteError(w, http.StatusBadRequest, "invalid request body")
+			return
+		}
+
+		consumer, err := r.GetConsumer(req.Context(), id)
+		if err != nil {
+			writeRegistryError(w, err)
+			return
+		}
+		if update.Plan != nil {
+			consumer.Plan = *update.Plan
+		}
+		if update.Quota != nil {
+			consumer.Quota = *update.Quota
+		}
+		if update.Blocked != nil {
+			consumer.Blocked = *update.Blocked
+		}
+
+		if err := r.SetConsumer(req.Context(), consumer); err != nil {
+			writeRegistryError(w, err)
+			return
+		}
+		r.writeConsumer(w, req, id)
+	})
+
+	mux.HandleFunc("DELETE /admin/consumers/{id}", func(w http.ResponseWriter, req *http.Request) {
+		if err := r.RemoveConsumer(req.Context(), req.PathValue("id")); err != nil {
+			writeRegistryError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	mux.HandleFunc("POST /admin/consumers/{id}/reset", func(w http.ResponseWriter, req *http.Request) {
+		id := req.PathValue("id")
+		if err := r.ResetUsage(req.Context(), id); err != nil {
+			writeRegistryError(w, err)
+			return
+		}
+		r.writeConsumer(w, req, id)
+	})
+
+	return httputil.RequireToken(token, mux)
+}
+
+// writeConsumer writes a consumer and its usage as JSON
+func (r *Registry) writeConsumer(w http.ResponseWriter, req *http.Request, id string) {
+	consumer, err := r.GetConsumer(req.Context(), id)
+	if err != nil {
+		writeRegistryError(w, err)
+		return
+	}
+	usage, err := r.GetUsage(req.Context(), id)
+	if err != nil {
+		writeRegistryError(w, err)
+		return
+	}
+	httputil.WriteJSON(w, http.StatusOK, consumerResponse{Consumer: consumer, Usage: usage})
+}
+
+// writeRegistryError maps registry errors to HTTP status codes
+func writeRegistryError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrInvalidConsumer), errors.Is(err, ErrPlanNotFound):
+		httputil.WriteError(w, http.StatusBadRequest, err.Error())
+	default:
+		httputil.WriteError(w, http.StatusInternalServerError, err.Error())
+	}
+}
@@ -0,0 +1,21 @@
+package consumer
+
+import (
+	"errors"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/types"
+)
+
+var (
+	// ErrQuotaExceeded is returned when a consumer has used up the quota of its plan
+	ErrQuotaExceeded = types.ErrQuotaExceeded
+
+	// ErrConsumerBlocked is returned when a consumer has been blocked by an administrator
+	ErrConsumerBlocked = types.ErrConsumerBlocked
+
+	// ErrPlanNotFound is returned when a consumer is assigned to an unknown plan
+	ErrPlanNotFound = errors.New("plan not found")
+
+	// ErrInvalidConsumer is returned when the consumer ID is empty
+	ErrInvalidConsumer = errors.New("consumer ID is required")
+)
@@ -0,0 +1,438 @@
+package consumer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/cache"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/logging"
+)
+
+// Plan defines how many requests a consumer may make per window
+type Plan struct {
+	Name   string        `json:"name"`
+	Quota  int           `json:"quota"`  // Requests allowed per window (0 = unlimited)
+	Window time.Duration `json:"window"` // Quota window (defaults to 24h)
+}
+
+// Consumer represents a wallet that sends tasks to the agent
+type Consumer struct {
+	ID        string    `json:"id"`              // Wallet address or user ID
+	Plan      string    `json:"plan"`            // Plan name
+	Quota     int       `json:"quota,omitempty"` // Overrides the plan quota when > 0
+	Blocked   bool      `json:"blocked,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Usage reports a consumer's quota consumption in the current window
+type Usage struct {
+	ConsumerID string    `json:"consumer_id"`
+	Plan       string    `json:"plan"`
+	Used       int       `json:"used"`
+	Quota      int       `json:"quota"`     // 0 = unlimited
+	Remaining  int       `json:"remaining"` // -1 when unlimited
+	ResetAt    time.Time `json:"reset_at"`
+}
+
+// RegistryConfig holds configuration for the consumer registry
+type RegistryConfig struct {
+	Cache       cache.AgentCache // Shared storage; consumers and usage are kept in memory when nil or NoOpCache
+	Plans       []Plan           // Available plans
+	DefaultPlan string           // Plan given to consumers that were never registered
+}
+
+// DefaultRegistryConfig returns a configuration with free, pro and unlimited plans
+func DefaultRegistryConfig() *RegistryConfig {
+	return &RegistryConfig{
+		Plans: []Plan{
+			{Name: "free", Quota: 100, Window: 24 * time.Hour},
+			{Name: "pro", Quota: 1000, Window: 24 * time.Hour},
+			{Name: "unlimited", Quota: 0, Window: 24 * time.Hour},
+		},
+		DefaultPlan: "free",
+	}
+}
+
+// Registry maps consumers to plans and tracks their quota usage
+type Registry struct {
+	cache       cache.AgentCache
+	shared      bool
+	plans       map[string]Plan
+	defaultPlan string
+	consumers   map[string]*Consumer
+	usage       map[string]int
+	mu          sync.RWMutex
+}
+
+// NewRegistry creates a new consumer registry
+func NewRegistry(config *RegistryConfig) *Registry {
+	if config == nil {
+		config = DefaultRegistryConfig()
+	}
+
+	r := &Registry{
+		cache:       config.Cache,
+		plans:       make(map[string]Plan),
+		defaultPlan: config.DefaultPlan,
+		consumers:   make(map[string]*Consumer),
+		usage:       make(map[string]int),
+	}
+
+	if r.cache != nil {
+		if _, noop := r.cache.(*cache.NoOpCache); !noop {
+			r.shared = true
+		}
+	}
+
+	for _, plan := range config.Plans {
+		r.SetPlan(plan)
+	}
+
+	// Make sure the default plan exists
+	if r.defaultPlan == "" {
+		r.defaultPlan = "default"
+	}
+	if _, ok := r.plans[r.defaultPlan]; !ok {
+		r.SetPlan(Plan{Name: r.defaultPlan})
+	}
+
+	return r
+}
+
+// SetPlan adds or replaces a plan
+func (r *Registry) SetPlan(plan Plan) {
+	if plan.Window <= 0 {
+		plan.Window = 24 * time.Hour
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.plans[plan.Name] = plan
+}
+
+// GetPlan returns a plan by name
+func (r *Registry) GetPlan(name string) (Plan, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	plan, ok := r.plans[name]
+	return plan, ok
+}
+
+// GetPlans returns all plans sorted by name
+func (r *Registry) GetPlans() []Plan {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	plans := make([]Plan, 0, len(r.plans))
+	for _, plan := range r.plans {
+		plans = append(plans, plan)
+	}
+	sort.Slice(plans, func(i, j int) bool { return plans[i].Name < plans[j].Name })
+	return plans
+}
+
+// SetDefaultPlan changes the plan given to unregistered consumers
+func (r *Registry) SetDefaultPlan(name string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.plans[name]; !ok {
+		return fmt.Errorf("%w: %s", ErrPlanNotFound, name)
+	}
+	r.defaultPlan = name
+	return nil
+}
+
+// GetConsumer returns a consumer, falling back to the default plan for unknown IDs
+func (r *Registry) GetConsumer(ctx context.Context, id string) (*Consumer, error) {
+	id = normalizeID(id)
+	if id == "" {
+		return nil, ErrInvalidConsumer
+	}
+
+	if r.shared {
+		data, err := r.cache.Get(ctx, consumerKey(id))
+		if err == nil {
+			var consumer Consumer
+			if err := json.Unmarshal([]byte(data), &consumer); err != nil {
+				return nil, fmt.Errorf("failed to decode consumer %s: %w", id, err)
+			}
+			return &consumer, nil
+		}
+		if !errors.Is(err, cache.ErrCacheKeyNotFound) {
+			return nil, fmt.Errorf("failed to load consumer %s: %w", id, err)
+		}
+	} else {
+		r.mu.RLock()
+		consumer, ok := r.consumers[id]
+		r.mu.RUnlock()
+		if ok {
+			copied := *consumer
+			return &copied, nil
+		}
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return &Consumer{ID: id, Plan: r.defaultPlan}, nil
+}
+
+// SetConsumer registers or updates a consumer
+func (r *Registry) SetConsumer(ctx context.Context, consumer *Consumer) error {
+	consumer.ID = normalizeID(consumer.ID)
+	if consumer.ID == "" {
+		return ErrInvalidConsumer
+	}
+
+	r.mu.RLock()
+	if consumer.Plan == "" {
+		consumer.Plan = r.defaultPlan
+	}
+	_, ok := r.plans[consumer.Plan]
+	r.mu.RUnlock()
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrPlanNotFound, consumer.Plan)
+	}
+
+	consumer.UpdatedAt = time.Now()
+
+	if r.shared {
+		data, err := json.Marshal(consumer)
+		if err != nil {
+			return fmt.Errorf("failed to encode consumer: %w", err)
+		}
+		if err := r.cache.Set(ctx, consumerKey(consumer.ID), string(data), 0); err != nil {
+			return fmt.Errorf("failed to store consumer %s: %w", consumer.ID, err)
+		}
+		return nil
+	}
+
+	copied := *consumer
+	r.mu.Lock()
+	r.consumers[consumer.ID] = &copied
+	r.mu.Unlock()
+	return nil
+}
+
+// AssignPlan moves a consumer to another plan
+func (r *Registry) AssignPlan(ctx context.Context, id, plan string) error {
+	consumer, err := r.GetConsumer(ctx, id)
+	if err != nil {
+		return err
+	}
+	consumer.Plan = plan
+	return r.SetConsumer(ctx, consumer)
+}
+
+// SetQuota overrides the plan quota for a single consumer (0 restores the plan quota)
+func (r *Registry) SetQuota(ctx context.Context, id string, quota int) error {
+	consumer, err := r.GetConsumer(ctx, id)
+	if err != nil {
+		return err
+	}
+	consumer.Quota = quota
+	return r.SetConsumer(ctx, consumer)
+}
+
+// SetBlocked blocks or unblocks a consumer
+func (r *Registry) SetBlocked(ctx context.Context, id string, blocked bool) error {
+	consumer, err := r.GetConsumer(ctx, id)
+	if err != nil {
+		return err
+	}
+	consumer.Blocked = blocked
+	return r.SetConsumer(ctx, consumer)
+}
+
+// RemoveConsumer deletes a consumer so it falls back to the default plan
+func (r *Registry) RemoveConsumer(ctx context.Context, id string) error {
+	id = normalizeID(id)
+	if id == "" {
+		return ErrInvalidConsumer
+	}
+
+	if r.shared {
+		return r.cache.Delete(ctx, consumerKey(id))
+	}
+
+	r.mu.Lock()
+	delete(r.consumers, id)
+	r.mu.Unlock()
+	return nil
+}
+
+// Consume records one request for the consumer.
+// It returns ErrQuotaExceeded or ErrConsumerBlocked when the request must be rejected.
+func (r *Registry) Consume(ctx context.Context, id string) (*Usage, error) {
+	consumer, plan, err := r.resolve(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if consumer.Blocked {
+		return nil, ErrConsumerBlocked
+	}
+
+	quota := effectiveQuota(consumer, plan)
+	windowStart := time.Now().Truncate(plan.Window)
+	key := usageKey(consumer.ID, windowStart)
+
+	var used int
+	if r.shared {
+		if _, err := r.cache.SetIfNotExists(ctx, key, 0, plan.Window); err != nil {
+			return nil, fmt.Errorf("failed to initialize usage for %s: %w", consumer.ID, err)
+		}
+		count, err := r.cache.Increment(ctx, key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to record usage for %s: %w", consumer.ID, err)
+		}
+		used = int(count)
+	} else {
+		r.mu.Lock()
+		r.pruneLocalUsage(consumer.ID, key)
+		r.usage[key]++
+		used = r.usage[key]
+		r.mu.Unlock()
+	}
+
+	usage := newUsage(consumer, quota, used, windowStart.Add(plan.Window))
+	if quota > 0 && used > quota {
+		usage.Used = quota
+		return usage, ErrQuotaExceeded
+	}
+	return usage, nil
+}
+
+// CheckQuota implements the types.QuotaChecker interface.
+// Storage errors are logged and the request is allowed so an unavailable cache
+// does not take the agent offline.
+func (r *Registry) CheckQuota(ctx context.Context, consumerID string) error {
+	_, err := r.Consume(ctx, consumerID)
+	if err == nil || errors.Is(err, ErrQuotaExceeded) || errors.Is(err, ErrConsumerBlocked) {
+		return err
+	}
+	logging.Warn("quota check failed, allowing request", "consumer_id", consumerID, "error", err)
+	return nil
+}
+
+// GetUsage returns the consumer's usage in the current window without consuming quota
+func (r *Registry) GetUsage(ctx context.Context, id string) (*Usage, error) {
+	consumer, plan, err := r.resolve(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	quota := effectiveQuota(consumer, plan)
+	windowStart := time.Now().Truncate(plan.Window)
+	key := usageKey(consumer.ID, windowStart)
+
+	var used int
+	if r.shared {
+		value, err := r.cache.Get(ctx, key)
+		if err != nil && !errors.Is(err, cache.ErrCacheKeyNotFound) {
+			return nil, fmt.Errorf("failed to load usage for %s: %w", consumer.ID, err)
+		}
+		if err == nil {
+			used, _ = strconv.Atoi(value)
+		}
+	} else {
+		r.mu.RLock()
+		used = r.usage[key]
+		r.mu.RUnlock()
+	}
+
+	if quota > 0 && used > quota {
+		used = quota
+	}
+	return newUsage(consumer, quota, used, windowStart.Add(plan.Window)), nil
+}
+
+// ResetUsage clears the consumer's usage in the current window
+func (r *Registry) ResetUsage(ctx context.Context, id string) error {
+	consumer, plan, err := r.resolve(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	key := usageKey(consumer.ID, time.Now().Truncate(plan.Window))
+	if r.shared {
+		return r.cache.Delete(ctx, key)
+	}
+
+	r.mu.Lock()
+	delete(r.usage, key)
+	r.mu.Unlock()
+	return nil
+}
+
+// resolve loads a consumer together with its plan
+func (r *Registry) resolve(ctx context.Context, id string) (*Consumer, Plan, error) {
+	consumer, err := r.GetConsumer(ctx, id)
+	if err != nil {
+		return nil, Plan{}, err
+	}
+
+	plan, ok := r.GetPlan(consumer.Plan)
+	if !ok {
+		return nil, Plan{}, fmt.Errorf("%w: %s", ErrPlanNotFound, consumer.Plan)
+	}
+	return consumer, plan, nil
+}
+
+// pruneLocalUsage drops counters from previous windows (caller must hold the lock)
+func (r *Registry) pruneLocalUsage(id, currentKey string) {
+	prefix := "quota:usage:" + id + ":"
+	for key := range r.usage {
+		if key != currentKey && strings.HasPrefix(key, prefix) {
+			delete(r.usage, key)
+		}
+	}
+}
+
+// effectiveQuota returns the consumer override or the plan quota
+func effectiveQuota(consumer *Consumer, plan Plan) int {
+	if consumer.Quota > 0 {
+		return consumer.Quota
+	}
+	return plan.Quota
+}
+
+// newUsage builds a usage report
+func newUsage(consumer *Consumer, quota, used int, resetAt time.Time) *Usage {
+	remaining := -1
+	if quota > 0 {
+		remaining = quota - used
+		if remaining < 0 {
+			remaining = 0
+		}
+	}
+	return &Usage{
+		ConsumerID: consumer.ID,
+		Plan:       consumer.Plan,
+		Used:       used,
+		Quota:      quota,
+		Remaining:  remaining,
+		ResetAt:    resetAt,
+	}
+}
+
+// normalizeID lowercases wallet addresses so lookups are case-insensitive
+func normalizeID(id string) string {
+	return strings.ToLower(strings.TrimSpace(id))
+}
+
+// consumerKey returns the cache key for a consumer record
+func consumerKey(id string) string {
+	return "consumer:" + id
+}
+
+// usageKey returns the cache key for a consumer's usage counter in a window
+func usageKey(id string, windowStart time.Time) string {
+	return fmt.Sprintf("quota:usage:%s:%d", id, windowStart.Unix())
+}
@@ -0,0 +1,130 @@
+package consumer
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestRegistry() *Registry {
+	return NewRegistry(&RegistryConfig{
+		Plans: []Plan{
+			{Name: "free", Quota: 2, Window: time.Hour},
+			{Name: "pro", Quota: 5, Window: time.Hour},
+		},
+		DefaultPlan: "free",
+	})
+}
+
+func TestConsumeQuota(t *testing.T) {
+	ctx := context.Background()
+	registry := newTestRegistry()
+
+	for i := 0; i < 2; i++ {
+		if _, err := registry.Consume(ctx, "0xABC"); err != nil {
+			t.Fatalf("request %d: unexpected error: %v", i+1, err)
+		}
+	}
+
+	usage, err := registry.Consume(ctx, "0xabc")
+	if !errors.Is(err, ErrQuotaExceeded) {
+		t.Fatalf("expected ErrQuotaExceeded, got %v", err)
+	}
+	if usage.Remaining != 0 || usage.Used != 2 {
+		t.Errorf("unexpected usage: %+v", usage)
+	}
+}
+
+func TestAdjustQuota(t *testing.T) {
+	ctx := context.Background()
+	registry := newTestRegistry()
+
+	tests := []struct {
+		name    string
+		adjust  func() error
+		allowed int
+	}{
+		{"plan upgrade", func() error { return registry.AssignPlan(ctx, "0x1", "pro") }, 5},
+		{"quota override", func() error { return registry.SetQuota(ctx, "0x1", 3) }, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.adjust(); err != nil {
+				t.Fatalf("adjust failed: %v", err)
+			}
+			if err := registry.ResetUsage(ctx, "0x1"); err != nil {
+				t.Fatalf("reset failed: %v", err)
+			}
+			for i := 0; i < tt.allowed; i++ {
+				if err := registry.CheckQuota(ctx, "0x1"); err != nil {
+					t.Fatalf("request %d rejected: %v", i+1, err)
+				}
+			}
+			if err := registry.CheckQuota(ctx, "0x1"); !errors.Is(err, ErrQuotaExceeded) {
+				t.Errorf("expected ErrQuotaExceeded, got %v", err)
+			}
+		})
+	}
+}
+
+func TestBlockedConsumer(t *testing.T) {
+	ctx := context.Background()
+	registry := newTestRegistry()
+
+	if err := registry.SetBlocked(ctx, "0x2", true); err != nil {
+		t.Fatalf("block failed: %v", err)
+	}
+	if err := registry.CheckQuota(ctx, "0x2"); !errors.Is(err, ErrConsumerBlocked) {
+		t.Errorf("expected ErrConsumerBlocked, got %v", err)
+	}
+}
+
+func TestUnknownPlan(t *testing.T) {
+	registry := newTestRegistry()
+
+	err := registry.AssignPlan(context.Background(), "0x3", "enterprise")
+	if !errors.Is(err, ErrPlanNotFound) {
+		t.Errorf("expected ErrPlanNotFound, got %v", err)
+	}
+}
+
+func TestAdminHandler(t *testing.T) {
+	registry := newTestRegistry()
+	handler := registry.AdminHandler("secret")
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		token      string
+		wantStatus int
+	}{
+		{"missing token", http.MethodGet, "/admin/plans", "", "", http.StatusUnauthorized},
+		{"list plans", http.MethodGet, "/admin/plans", "", "secret", http.StatusOK},
+		{"update consumer", http.MethodPut, "/admin/consumers/0x4", `{"plan":"pro","quota":10}`, "secret", http.StatusOK},
+		{"unknown plan", http.MethodPut, "/admin/consumers/0x4", `{"plan":"gold"}`, "secret", http.StatusBadRequest},
+		{"reset usage", http.MethodPost, "/admin/consumers/0x4/reset", "", "secret", http.StatusOK},
+		{"delete consumer", http.MethodDelete, "/admin/consumers/0x4", "", "secret", http.StatusNoContent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("expected status %d, got %d: %s", tt.wantStatus, rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
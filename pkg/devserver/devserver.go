@@ -0,0 +1,859 @@
+// Package devserver is a local stand-in for the Teneo coordinator. It speaks
+// the coordinator's WebSocket protocol (challenge, authentication,
+// registration, capabilities, ping and tasks) so agents can be run and tested
+// end to end on one machine, without the Teneo network, a wallet with funds
+// or an NFT.
+//
+// Point an agent at URL as its WebSocket URL and at HTTPURL as its backend
+// URL, preferably with IDENTITY_MODE=anonymous, then send it tasks with
+// SendTask or Ask.
+//
+// For long runs, e.g. the soak tests of package soak, the server can
+// redeliver unanswered tasks like a real queue, forget answered ones and
+// inject faults: forced disconnects, latency, lost tasks, rejected
+// authentications and expiring sessions.
+package devserver
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	mathrand "math/rand"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/logging"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/types"
+	"github.com/gorilla/websocket"
+)
+
+// ErrNoAgent is returned when no registered agent can take a task
+var ErrNoAgent = errors.New("no registered agent for the task")
+
+// ErrUnknownTask is returned for task IDs the server did not send
+var ErrUnknownTask = errors.New("unknown task")
+
+// Config configures a Server
+type Config struct {
+	Addr string // Address to listen on (default "127.0.0.1:0", a free local port)
+	// VerifySignature checks the signed challenge of an authenticating agent
+	// (nil = every signature is accepted)
+	VerifySignature func(address, message, signature string) bool
+	Faults          Faults // Failures to inject from the start, see SetFaults
+
+	// RedeliverAfter enables redelivery: tasks sent while no agent is
+	// registered wait for one, and unanswered tasks are delivered again this
+	// long after their last delivery and whenever an agent registers
+	// (0 = tasks are delivered once and SendTask fails without an agent)
+	RedeliverAfter time.Duration
+
+	// Forget drops answered tasks and keeps no received messages, so a long
+	// run does not grow the heap. Result and WaitForResult then only know
+	// unanswered tasks, and Received returns nothing.
+	Forget bool
+}
+
+// Faults configures the failures the server injects
+type Faults struct {
+	DisconnectEvery time.Duration // Mean time between forced disconnects of all agents (0 = never)
+	Latency         time.Duration // Maximum random delay of each server message (0 = none)
+	DropRate        float64       // Fraction of task deliveries lost in transit; with redelivery the task is sent again later
+	AuthFailureRate float64       // Fraction of authentications rejected; the server then closes the connection
+	SessionTTL      time.Duration // Session lifetime sent to agents; expired sessions must re-authenticate (0 = no expiry)
+}
+
+// Stats counts what the server saw
+type Stats struct {
+	Tasks              int           `json:"tasks"`
+	Answered           int           `json:"answered"`
+	Pending            int           `json:"pending"`
+	Deliveries         int           `json:"deliveries"`
+	Redeliveries       int           `json:"redeliveries"`
+	Dropped            int           `json:"dropped"`
+	DuplicateResponses int           `json:"duplicate_responses"`
+	UnknownResponses   int           `json:"unknown_responses"`
+	Connections        int           `json:"connections"`
+	Registrations      int           `json:"registrations"`
+	Disconnects        int           `json:"disconnects"`
+	AuthFailures       int           `json:"auth_failures"`
+	SessionExpiries    int           `json:"session_expiries"`
+	MeanLatency        time.Duration `json:"mean_latency"` // From sending the task to its response
+	MaxLatency         time.Duration `json:"max_latency"`
+}
+
+// Agent is an agent connected to the server
+type Agent struct {
+	Name         string    `json:"name"`
+	Wallet       string    `json:"wallet"`
+	TokenID      string    `json:"token_id,omitempty"` // Empty for agents without an NFT
+	Room         string    `json:"room,omitempty"`
+	Capabilities []string  `json:"capabilities,omitempty"`
+	Registered   bool      `json:"registered"`
+	ConnectedAt  time.Time `json:"connected_at"`
+}
+
+// Task is a task to send to an agent
+type Task struct {
+	Room    string // Room the task is asked in
+	Content string // What the user asked
+	// Agent is the name of the agent to send the task to (empty = the first
+	// agent registered in Room, or in any room if none is)
+	Agent string
+}
+
+// Result is what an agent sent for a task
+type Result struct {
+	TaskID   string           `json:"task_id"`
+	Agent    string           `json:"agent"`
+	Updates  []*types.Message `json:"updates,omitempty"`  // Progress and status messages, in order
+	Response *types.Message   `json:"response,omitempty"` // The final response, nil until it arrives
+	Success  bool             `json:"success"`
+	Error    string           `json:"error,omitempty"`
+}
+
+// Done reports whether the final response arrived
+func (r *Result) Done() bool {
+	return r.Response != nil
+}
+
+// pendingTask is a task waiting for its final response
+type pendingTask struct {
+	Task
+	id         string
+	created    time.Time
+	delivered  time.Time // Last delivery attempt
+	deliveries int
+}
+
+// conn is an agent connection
+type conn struct {
+	ws   *websocket.Conn
+	out  chan *types.Message // Messages waiting for the writer
+	done chan struct{}
+
+	// Guarded by the server's mu
+	agent         Agent
+	challenge     string
+	authenticated bool
+	session       int // Incremented by every authentication, so an old expiry timer knows it is stale
+}
+
+// Server is a local coordinator. It is safe for concurrent use.
+type Server struct {
+	config    Config
+	listener  net.Listener
+	http      *http.Server
+	upgrader  websocket.Upgrader
+	chunks    *types.ChunkAssembler
+	done      chan struct{}
+	closeOnce sync.Once
+
+	mu        sync.Mutex
+	rng       *mathrand.Rand
+	faults    Faults
+	changed   chan struct{}           // Closed and replaced whenever an agent registers or a task gets a message
+	conns     []*conn                 // In order of connection
+	tasks     map[string]*pendingTask // Unanswered tasks
+	results   map[string]*Result
+	received  []*types.Message
+	nextID    int
+	stats     Stats
+	latencies time.Duration // Sum of response latencies
+}
+
+// New starts a server listening on config.Addr
+func New(config *Config) (*Server, error) {
+	if config == nil {
+		config = &Config{}
+	}
+	addr := config.Addr
+	if addr == "" {
+		addr = "127.0.0.1:0"
+	}
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
+
+	s := &Server{
+		config:   *config,
+		listener: listener,
+		chunks:   types.NewChunkAssembler(0),
+		done:     make(chan struct{}),
+		rng:      mathrand.New(mathrand.NewSource(time.Now().UnixNano())),
+		faults:   config.Faults,
+		changed:  make(chan struct{}),
+		tasks:    make(map[string]*pendingTask),
+		results:  make(map[string]*Result),
+	}
+	s.http = &http.Server{Handler: http.HandlerFunc(s.serveHTTP), ReadHeaderTimeout: 10 * time.Second}
+	go s.http.Serve(listener)
+	go s.disconnectLoop()
+	if config.RedeliverAfter > 0 {
+		go s.redeliverLoop()
+	}
+	logging.Debug("dev server listening", "addr", listener.Addr().String())
+	return s, nil
+}
+
+// URL returns the WebSocket URL agents connect to
+func (s *Server) URL() string {
+	return "ws://" + s.listener.Addr().String() + "/ws"
+}
+
+// HTTPURL returns the base URL of the server's HTTP endpoints, e.g. the backend URL
+func (s *Server) HTTPURL() string {
+	return "http://" + s.listener.Addr().String()
+}
+
+// Close disconnects all agents and stops the server
+func (s *Server) Close() error {
+	var err error
+	s.closeOnce.Do(func() {
+		close(s.done)
+		s.mu.Lock()
+		for _, c := range s.conns {
+			c.ws.Close()
+		}
+		s.mu.Unlock()
+		err = s.http.Close()
+	})
+	return err
+}
+
+// SetFaults changes the injected faults
+func (s *Server) SetFaults(faults Faults) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = faults
+}
+
+// Agents returns the connected agents in order of connection
+func (s *Server) Agents() []Agent {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	agents := make([]Agent, 0, len(s.conns))
+	for _, c := range s.conns {
+		agent := c.agent
+		agent.Capabilities = append([]string(nil), agent.Capabilities...)
+		agents = append(agents, agent)
+	}
+	return agents
+}
+
+// WaitForAgent waits until an agent with the name (empty = any agent) is
+// registered and returns it
+func (s *Server) WaitForAgent(ctx context.Context, name string) (Agent, error) {
+	for {
+		s.mu.Lock()
+		c := s.findAgent("", name)
+		changed := s.changed
+		var agent Agent
+		if c != nil {
+			agent = c.agent
+		}
+		s.mu.Unlock()
+		if c != nil {
+			return agent, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return Agent{}, fmt.Errorf("agent %q did not register: %w", name, ctx.Err())
+		case <-changed:
+		}
+	}
+}
+
+// SendTask sends a task to a registered agent and returns its ID. With
+// redelivery enabled, a task no agent can take waits for one.
+func (s *Server) SendTask(task Task) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c := s.pickAgent(task)
+	if c == nil && s.config.RedeliverAfter <= 0 {
+		return "", ErrNoAgent
+	}
+
+	s.nextID++
+	t := &pendingTask{Task: task, id: fmt.Sprintf("dev-%d", s.nextID), created: time.Now()}
+	s.tasks[t.id] = t
+	s.results[t.id] = &Result{TaskID: t.id}
+	s.stats.Tasks++
+	if c != nil {
+		s.deliver(c, t)
+	}
+	return t.id, nil
+}
+
+// Redeliver delivers all unanswered tasks again
+func (s *Server) Redeliver() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.redeliver(0)
+}
+
+// Unanswered returns the IDs of the tasks without a final response, oldest first
+func (s *Server) Unanswered() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	tasks := make([]*pendingTask, 0, len(s.tasks))
+	for _, t := range s.tasks {
+		tasks = append(tasks, t)
+	}
+	sort.Slice(tasks, func(i, j int) bool { return tasks[i].created.Before(tasks[j].created) })
+	ids := make([]string, len(tasks))
+	for i, t := range tasks {
+		ids[i] = t.id
+	}
+	return ids
+}
+
+// Stats returns what the server has seen so far
+func (s *Server) Stats() Stats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stats := s.stats
+	stats.Pending = stats.Tasks - stats.Answered
+	if stats.Answered > 0 {
+		stats.MeanLatency = s.latencies / time.Duration(stats.Answered)
+	}
+	return stats
+}
+
+// WaitForResult waits for the final response to a task
+func (s *Server) WaitForResult(ctx context.Context, taskID string) (*Result, error) {
+	for {
+		s.mu.Lock()
+		result, ok := s.results[taskID]
+		var snapshot *Result
+		if ok && result.Done() {
+			snapshot = result.copy()
+		}
+		changed := s.changed
+		s.mu.Unlock()
+		if !ok {
+			return nil, fmt.Errorf("%w %q", ErrUnknownTask, taskID)
+		}
+		if snapshot != nil {
+			return snapshot, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("no response to task %s: %w", taskID, ctx.Err())
+		case <-changed:
+		}
+	}
+}
+
+// Ask sends a task to an agent registered in the room and waits for its
+// final response
+func (s *Server) Ask(ctx context.Context, room, content string) (*Result, error) {
+	id, err := s.SendTask(Task{Room: room, Content: content})
+	if err != nil {
+		return nil, err
+	}
+	return s.WaitForResult(ctx, id)
+}
+
+// Result returns what the agent has sent for a task so far
+func (s *Server) Result(taskID string) (*Result, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	result, ok := s.results[taskID]
+	if !ok {
+		return nil, false
+	}
+	return result.copy(), true
+}
+
+// Received returns every message the server received from agents, in order.
+// Split task responses appear once they are reassembled.
+func (s *Server) Received() []*types.Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*types.Message(nil), s.received...)
+}
+
+// Disconnect closes the connection of the agent with the name (empty = all
+// agents), as a coordinator restart or network failure would. The agent is
+// expected to reconnect.
+func (s *Server) Disconnect(name string) {
+	s.mu.Lock()
+	var closing []*conn
+	for _, c := range s.conns {
+		if name == "" || c.agent.Name == name {
+			closing = append(closing, c)
+		}
+	}
+	s.stats.Disconnects += len(closing)
+	s.mu.Unlock()
+	for _, c := range closing {
+		c.ws.Close()
+	}
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	if !websocket.IsWebSocketUpgrade(r) {
+		s.serveBackend(w, r)
+		return
+	}
+	ws, err := s.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	c := &conn{ws: ws, out: make(chan *types.Message, outboxSize), done: make(chan struct{})}
+	c.agent.ConnectedAt = time.Now()
+	s.mu.Lock()
+	s.conns = append(s.conns, c)
+	s.stats.Connections++
+	s.mu.Unlock()
+	go s.writeLoop(c)
+
+	defer func() {
+		close(c.done)
+		ws.Close()
+		s.mu.Lock()
+		for i, other := range s.conns {
+			if other == c {
+				s.conns = append(s.conns[:i], s.conns[i+1:]...)
+				break
+			}
+		}
+		s.mu.Unlock()
+		logging.Debug("dev server agent disconnected", "agent", c.agent.Name)
+	}()
+
+	for {
+		_, data, err := ws.ReadMessage()
+		if err != nil {
+			return
+		}
+		var msg types.Message
+		if err := json.Unmarshal(data, &msg); err != nil {
+			s.mu.Lock()
+			s.send(c, &types.Message{Type: types.MessageTypeError, Content: "invalid message: " + err.Error()})
+			s.mu.Unlock()
+			continue
+		}
+		s.handle(c, &msg, data)
+	}
+}
+
+// handle answers a message from an agent
+func (s *Server) handle(c *conn, msg *types.Message, raw []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch msg.Type {
+	case types.MessageTypeRequestChallenge:
+		challenge := make([]byte, 16)
+		rand.Read(challenge)
+		c.challenge = hex.EncodeToString(challenge)
+		s.send(c, &types.Message{Type: types.MessageTypeChallenge, Data: mustJSON(map[string]string{"challenge": c.challenge})})
+	case types.MessageTypeAuth:
+		s.authenticate(c, msg)
+	case types.MessageTypeRegister:
+		s.register(c, msg)
+	case types.MessageTypeCapabilities:
+		// Sent as a bare JSON object rather than a message
+		var capabilities struct {
+			Capabilities []string `json:"capabilities"`
+			Room         string   `json:"room"`
+		}
+		json.Unmarshal(raw, &capabilities)
+		c.agent.Capabilities = capabilities.Capabilities
+		if capabilities.Room != "" {
+			c.agent.Room = capabilities.Room
+		}
+		s.send(c, &types.Message{Type: types.MessageTypeCapabilities, Content: "capabilities updated"})
+	case types.MessageTypePing:
+		s.send(c, &types.Message{Type: types.MessageTypePong, Content: "pong"})
+	case types.MessageTypeTaskResponse:
+		if !c.authenticated {
+			s.send(c, &types.Message{Type: types.MessageTypeError, Content: "not authenticated"})
+			return
+		}
+		s.record(c, msg)
+		return
+	}
+	s.keep(msg)
+}
+
+// authenticate checks the signed challenge of an agent
+func (s *Server) authenticate(c *conn, msg *types.Message) {
+	if s.rng.Float64() < s.faults.AuthFailureRate {
+		s.stats.AuthFailures++
+		s.send(c, &types.Message{Type: types.MessageTypeAuthError, Content: "authentication failed (injected fault)"})
+		// Give the error time to arrive before the connection goes
+		time.AfterFunc(100*time.Millisecond, func() { c.ws.Close() })
+		return
+	}
+
+	var auth types.AuthMessage
+	if err := json.Unmarshal(msg.Data, &auth); err != nil {
+		s.send(c, &types.Message{Type: types.MessageTypeAuthError, Content: "authentication failed: invalid auth data"})
+		return
+	}
+	switch {
+	case c.challenge == "" || !strings.Contains(auth.Message, c.challenge):
+		s.send(c, &types.Message{Type: types.MessageTypeAuthError, Content: "authentication failed: unknown challenge"})
+		return
+	case auth.Address == "" || auth.Signature == "":
+		s.send(c, &types.Message{Type: types.MessageTypeAuthError, Content: "authentication failed: missing address or signature"})
+		return
+	case s.config.VerifySignature != nil && !s.config.VerifySignature(auth.Address, auth.Message, auth.Signature):
+		s.send(c, &types.Message{Type: types.MessageTypeAuthError, Content: "authentication failed: invalid signature"})
+		return
+	}
+
+	c.authenticated = true
+	c.session++
+	c.agent.Name = auth.AgentName
+	c.agent.Wallet = auth.Address
+	c.agent.TokenID = auth.NFTTokenID
+	data := map[string]interface{}{"address": auth.Address}
+	if ttl := s.faults.SessionTTL; ttl > 0 {
+		data["expires_in"] = ttl.Seconds()
+		session := c.session
+		time.AfterFunc(ttl, func() { s.expire(c, session) })
+	}
+	logging.Debug("dev server authenticated agent", "agent", auth.AgentName, "wallet", auth.Address)
+	s.send(c, &types.Message{Type: types.MessageTypeAuthSuccess, Content: "authentication successful", Data: mustJSON(data)})
+}
+
+// register adds an authenticated agent to its room
+func (s *Server) register(c *conn, msg *types.Message) {
+	if !c.authenticated {
+		s.send(c, &types.Message{Type: types.MessageTypeError, Content: "not authenticated"})
+		return
+	}
+	var registration types.RegistrationMessage
+	json.Unmarshal(msg.Data, &registration)
+	c.agent.Room = registration.Room
+	if c.agent.Room == "" {
+		c.agent.Room = msg.Room
+	}
+	if len(registration.Manifest) > 0 {
+		c.agent.Capabilities = c.agent.Capabilities[:0]
+		for _, capability := range registration.Manifest {
+			c.agent.Capabilities = append(c.agent.Capabilities, capability.Name)
+		}
+	}
+	registered := c.agent.Registered
+	c.agent.Registered = true
+	s.send(c, &types.Message{Type: types.MessageTypeRegister, Content: "Registration successful"})
+	s.notify()
+	logging.Debug("dev server registered agent", "agent", c.agent.Name, "room", c.agent.Room)
+	if !registered {
+		s.stats.Registrations++
+		// Tasks sent while no agent was registered, or lost with the last connection
+		if s.config.RedeliverAfter > 0 {
+			s.redeliver(0)
+		}
+	}
+}
+
+// expire ends a session the agent did not renew in time
+func (s *Server) expire(c *conn, session int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if c.session != session || !c.authenticated {
+		return
+	}
+	c.authenticated = false
+	c.agent.Registered = false
+	s.stats.SessionExpiries++
+	s.send(c, &types.Message{Type: types.MessageTypeError, Content: "session expired"})
+}
+
+// record adds a task response to the result of its task. Progress and status
+// updates do not end a task.
+func (s *Server) record(c *conn, msg *types.Message) {
+	msg, err := s.chunks.Add(msg)
+	if err != nil {
+		logging.Warn("dev server dropped invalid response part", "agent", c.agent.Name, "error", err)
+		return
+	}
+	if msg == nil {
+		// More parts to come
+		return
+	}
+	if err := msg.DecodeContent(); err != nil {
+		logging.Warn("dev server could not decode response", "agent", c.agent.Name, "error", err)
+		return
+	}
+	s.keep(msg)
+
+	var data struct {
+		TaskID  string `json:"task_id"`
+		Success *bool  `json:"success"`
+		Error   string `json:"error"`
+	}
+	json.Unmarshal(msg.Data, &data)
+	taskID := msg.TaskID
+	if taskID == "" {
+		taskID = data.TaskID
+	}
+	result, ok := s.results[taskID]
+	if !ok {
+		// Forgotten answered tasks still count as duplicates
+		var n int
+		if _, err := fmt.Sscanf(taskID, "dev-%d", &n); err == nil && n > 0 && n <= s.nextID {
+			s.stats.DuplicateResponses++
+		} else {
+			s.stats.UnknownResponses++
+			logging.Warn("dev server got response to unknown task", "agent", c.agent.Name, "task_id", taskID)
+		}
+		return
+	}
+
+	switch {
+	case msg.ContentType == types.StandardMessageTypeProgress || msg.ContentType == types.StandardMessageTypeStatus:
+		result.Updates = append(result.Updates, msg)
+	case result.Response != nil:
+		s.stats.DuplicateResponses++
+	default:
+		result.Response = msg
+		result.Success = data.Success == nil || *data.Success
+		result.Error = data.Error
+
+		t := s.tasks[taskID]
+		delete(s.tasks, taskID)
+		if s.config.Forget {
+			delete(s.results, taskID)
+		}
+		s.stats.Answered++
+		latency := time.Since(t.created)
+		s.latencies += latency
+		s.stats.MaxLatency = max(s.stats.MaxLatency, latency)
+	}
+	s.notify()
+}
+
+// keep records a received message unless the server forgets them
+func (s *Server) keep(msg *types.Message) {
+	if !s.config.Forget {
+		s.received = append(s.received, msg)
+	}
+}
+
+// deliver sends a task to an agent unless the drop fault loses it
+func (s *Server) deliver(c *conn, t *pendingTask) {
+	t.delivered = time.Now()
+	t.deliveries++
+	s.results[t.id].Agent = c.agent.Name
+	s.stats.Deliveries++
+	if t.deliveries > 1 {
+		s.stats.Redeliveries++
+	}
+	if s.rng.Float64() < s.faults.DropRate {
+		s.stats.Dropped++
+		return
+	}
+	s.send(c, &types.Message{
+		Type:    types.MessageTypeTask,
+		From:    "coordinator",
+		Room:    t.Room,
+		Content: t.Content,
+		Data:    mustJSON(map[string]string{"task_id": t.id, "content": t.Content}),
+	})
+	logging.Debug("dev server sent task", "task_id", t.id, "agent", c.agent.Name, "room", t.Room)
+}
+
+// redeliver delivers the unanswered tasks last delivered before olderThan
+// ago, oldest first like a real queue
+func (s *Server) redeliver(olderThan time.Duration) {
+	tasks := make([]*pendingTask, 0, len(s.tasks))
+	for _, t := range s.tasks {
+		if time.Since(t.delivered) >= olderThan {
+			tasks = append(tasks, t)
+		}
+	}
+	sort.Slice(tasks, func(i, j int) bool { return tasks[i].created.Before(tasks[j].created) })
+	for _, t := range tasks {
+		if c := s.pickAgent(t.Task); c != nil {
+			s.deliver(c, t)
+		}
+	}
+}
+
+// pickAgent returns the agent to send a task to: the named agent, else the
+// first registered in the task's room or in any room
+func (s *Server) pickAgent(task Task) *conn {
+	c := s.findAgent(task.Room, task.Agent)
+	if c == nil && task.Agent == "" {
+		c = s.findAgent("", "")
+	}
+	return c
+}
+
+// findAgent returns the first registered agent with the room and name (empty
+// = any)
+func (s *Server) findAgent(room, name string) *conn {
+	for _, c := range s.conns {
+		if c.agent.Registered && (room == "" || c.agent.Room == room) && (name == "" || c.agent.Name == name) {
+			return c
+		}
+	}
+	return nil
+}
+
+// notify wakes the callers waiting for a change
+func (s *Server) notify() {
+	close(s.changed)
+	s.changed = make(chan struct{})
+}
+
+// send queues a message for the connection. An agent too slow to keep up
+// with the outbox is disconnected, as the coordinator would.
+func (s *Server) send(c *conn, msg *types.Message) {
+	msg.Timestamp = time.Now()
+	select {
+	case c.out <- msg:
+	default:
+		logging.Warn("dev server outbox full, disconnecting agent", "agent", c.agent.Name)
+		c.ws.Close()
+	}
+}
+
+// writeLoop writes the queued messages of a connection in order, each after
+// the injected latency
+func (s *Server) writeLoop(c *conn) {
+	for {
+		select {
+		case msg := <-c.out:
+			s.mu.Lock()
+			var delay time.Duration
+			if s.faults.Latency > 0 {
+				delay = time.Duration(s.rng.Int63n(int64(s.faults.Latency)))
+			}
+			s.mu.Unlock()
+			if delay > 0 {
+				time.Sleep(delay)
+			}
+			c.ws.SetWriteDeadline(time.Now().Add(10 * time.Second))
+			if err := c.ws.WriteJSON(msg); err != nil {
+				c.ws.Close()
+				return
+			}
+		case <-c.done:
+			return
+		}
+	}
+}
+
+// redeliverLoop delivers tasks left unanswered for RedeliverAfter again
+func (s *Server) redeliverLoop() {
+	ticker := time.NewTicker(s.config.RedeliverAfter / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.mu.Lock()
+			s.redeliver(s.config.RedeliverAfter)
+			s.mu.Unlock()
+		case <-s.done:
+			return
+		}
+	}
+}
+
+// disconnectLoop forces disconnects at exponentially distributed intervals
+func (s *Server) disconnectLoop() {
+	for {
+		s.mu.Lock()
+		var wait time.Duration
+		if mean := s.faults.DisconnectEvery; mean > 0 {
+			wait = time.Duration(s.rng.ExpFloat64() * float64(mean))
+		}
+		s.mu.Unlock()
+
+		if wait == 0 {
+			// Check again in case the faults change
+			wait = time.Second
+		}
+		select {
+		case <-time.After(wait):
+		case <-s.done:
+			return
+		}
+		s.mu.Lock()
+		enabled := s.faults.DisconnectEvery > 0
+		s.mu.Unlock()
+		if enabled {
+			logging.Debug("dev server disconnecting agents (injected fault)")
+			s.Disconnect("")
+		}
+	}
+}
+
+// serveBackend answers the backend's REST endpoints an agent may call:
+// rooms and agent profiles come from the connected agents, other requests,
+// e.g. the NFT metadata sync, get an empty JSON object
+func (s *Server) serveBackend(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	agents := s.Agents()
+
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/api/rooms":
+		members := make(map[string][]string)
+		for _, agent := range agents {
+			if agent.Registered && agent.Room != "" {
+				members[agent.Room] = append(members[agent.Room], agent.Name)
+			}
+		}
+		rooms := make([]map[string]interface{}, 0, len(members))
+		for room, names := range members {
+			rooms = append(rooms, map[string]interface{}{"id": room, "name": room, "public": true, "agents": names})
+		}
+		sort.Slice(rooms, func(i, j int) bool { return rooms[i]["id"].(string) < rooms[j]["id"].(string) })
+		json.NewEncoder(w).Encode(map[string]interface{}{"rooms": rooms})
+	case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/api/agents/"):
+		id := strings.TrimPrefix(r.URL.Path, "/api/agents/")
+		for _, agent := range agents {
+			if agent.Name == id || strings.EqualFold(agent.Wallet, id) {
+				status := "offline"
+				if agent.Registered {
+					status = "online"
+				}
+				json.NewEncoder(w).Encode(map[string]interface{}{
+					"agent_id":       agent.Wallet,
+					"name":           agent.Name,
+					"wallet_address": agent.Wallet,
+					"capabilities":   agent.Capabilities,
+					"status":         status,
+					"rooms":          []string{agent.Room},
+				})
+				return
+			}
+		}
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"error":"agent not found"}`))
+	default:
+		w.Write([]byte("{}"))
+	}
+}
+
+// copy returns a copy of the result that later messages do not change
+func (r *Result) copy() *Result {
+	c := *r
+	c.Updates = append([]*types.Message(nil), r.Updates...)
+	return &c
+}
+
+const outboxSize = 1024
+
+func mustJSON(v interface{}) json.RawMessage {
+	data, _ := json.Marshal(v)
+	return data
+}
@@ -0,0 +1,259 @@
+package devserver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/types"
+	"github.com/gorilla/websocket"
+)
+
+// testAgent speaks the agent side of the protocol step by step
+type testAgent struct {
+	t  *testing.T
+	ws *websocket.Conn
+}
+
+func dial(t *testing.T, server *Server) *testAgent {
+	t.Helper()
+	ws, _, err := websocket.DefaultDialer.Dial(server.URL(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ws.Close() })
+	return &testAgent{t: t, ws: ws}
+}
+
+func (a *testAgent) send(msg *types.Message) {
+	a.t.Helper()
+	if err := a.ws.WriteJSON(msg); err != nil {
+		a.t.Fatal(err)
+	}
+}
+
+func (a *testAgent) expect(msgType string) *types.Message {
+	a.t.Helper()
+	a.ws.SetReadDeadline(time.Now().Add(5 * time.Second))
+	var msg types.Message
+	if err := a.ws.ReadJSON(&msg); err != nil {
+		a.t.Fatalf("waiting for %s: %v", msgType, err)
+	}
+	if msg.Type != msgType {
+		a.t.Fatalf("got %s message %q, want %s", msg.Type, msg.Content, msgType)
+	}
+	return &msg
+}
+
+// login authenticates and registers the agent in the room
+func (a *testAgent) login(name, room string) {
+	a.t.Helper()
+	a.send(&types.Message{Type: types.MessageTypeRequestChallenge})
+	var challenge types.ChallengeMessage
+	json.Unmarshal(a.expect(types.MessageTypeChallenge).Data, &challenge)
+
+	auth, _ := json.Marshal(types.AuthMessage{
+		Address:   "0x" + name,
+		Message:   "Teneo authentication challenge: " + challenge.Challenge,
+		Signature: "sig-" + name,
+		UserType:  "agent",
+		AgentName: name,
+	})
+	a.send(&types.Message{Type: types.MessageTypeAuth, Data: auth})
+	a.expect(types.MessageTypeAuthSuccess)
+
+	registration, _ := json.Marshal(types.RegistrationMessage{UserType: "agent", WalletAddress: "0x" + name, Room: room})
+	a.send(&types.Message{Type: types.MessageTypeRegister, Room: room, Data: registration})
+	if msg := a.expect(types.MessageTypeRegister); !strings.Contains(msg.Content, "successful") {
+		a.t.Fatalf("registration failed: %s", msg.Content)
+	}
+}
+
+func (a *testAgent) respond(taskID, contentType, content string) {
+	a.t.Helper()
+	data, _ := json.Marshal(map[string]interface{}{"task_id": taskID, "success": true})
+	a.send(&types.Message{Type: types.MessageTypeTaskResponse, From: "agent", TaskID: taskID, ContentType: contentType, Content: content, Data: data})
+}
+
+func newServer(t *testing.T, config *Config) *Server {
+	t.Helper()
+	server, err := New(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { server.Close() })
+	return server
+}
+
+func TestTaskRoundTrip(t *testing.T) {
+	server := newServer(t, nil)
+	agent := dial(t, server)
+	agent.login("echo", "general")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := server.WaitForAgent(ctx, "echo"); err != nil {
+		t.Fatal(err)
+	}
+
+	id, err := server.SendTask(Task{Room: "general", Content: "hello"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	task := agent.expect(types.MessageTypeTask)
+	if task.From != "coordinator" || task.Content != "hello" || !strings.Contains(string(task.Data), id) {
+		t.Fatalf("task = %+v", task)
+	}
+	agent.respond(id, types.StandardMessageTypeProgress, `{"percent":50}`)
+	agent.respond(id, types.StandardMessageTypeString, "hello back")
+
+	result, err := server.WaitForResult(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Success || result.Response.Content != "hello back" || len(result.Updates) != 1 || result.Agent != "echo" {
+		t.Errorf("result = %+v", result)
+	}
+}
+
+func TestChunkedCompressedResponse(t *testing.T) {
+	server := newServer(t, nil)
+	agent := dial(t, server)
+	agent.login("big", "")
+
+	id, err := server.SendTask(Task{Content: "long please"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	agent.expect(types.MessageTypeTask)
+
+	content := strings.Repeat("a long line of output\n", 200)
+	msg := &types.Message{Type: types.MessageTypeTaskResponse, TaskID: id, Content: content}
+	if _, err := msg.CompressContent(1); err != nil {
+		t.Fatal(err)
+	}
+	for _, part := range msg.SplitContent(64) {
+		agent.send(part)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	result, err := server.WaitForResult(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Response.Content != content {
+		t.Errorf("reassembled %d bytes, want %d", len(result.Response.Content), len(content))
+	}
+}
+
+func TestRejectsInvalidSignature(t *testing.T) {
+	server := newServer(t, &Config{VerifySignature: func(address, message, signature string) bool {
+		return signature == "sig-"+strings.TrimPrefix(address, "0x")
+	}})
+
+	agent := dial(t, server)
+	agent.send(&types.Message{Type: types.MessageTypeRequestChallenge})
+	var challenge types.ChallengeMessage
+	json.Unmarshal(agent.expect(types.MessageTypeChallenge).Data, &challenge)
+	auth, _ := json.Marshal(types.AuthMessage{Address: "0xmallory", Message: challenge.Challenge, Signature: "forged"})
+	agent.send(&types.Message{Type: types.MessageTypeAuth, Data: auth})
+	agent.expect(types.MessageTypeAuthError)
+
+	agent.send(&types.Message{Type: types.MessageTypeRegister})
+	agent.expect(types.MessageTypeError)
+
+	dial(t, server).login("alice", "general")
+}
+
+func TestPingAndSessionExpiry(t *testing.T) {
+	server := newServer(t, &Config{Faults: Faults{SessionTTL: 100 * time.Millisecond}})
+	agent := dial(t, server)
+	agent.login("pinger", "general")
+
+	agent.send(&types.Message{Type: types.MessageTypePing})
+	agent.expect(types.MessageTypePong)
+
+	if msg := agent.expect(types.MessageTypeError); msg.Content != "session expired" {
+		t.Errorf("got %q, want the session to expire", msg.Content)
+	}
+	if _, err := server.SendTask(Task{Content: "anyone?"}); !errors.Is(err, ErrNoAgent) {
+		t.Errorf("task sent to an expired session: %v", err)
+	}
+}
+
+func TestDisconnectAndBackend(t *testing.T) {
+	server := newServer(t, nil)
+	agent := dial(t, server)
+	agent.login("roomie", "lobby")
+
+	resp, err := http.Get(server.HTTPURL() + "/api/rooms")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rooms struct {
+		Rooms []struct {
+			ID     string   `json:"id"`
+			Agents []string `json:"agents"`
+		} `json:"rooms"`
+	}
+	json.NewDecoder(resp.Body).Decode(&rooms)
+	resp.Body.Close()
+	if len(rooms.Rooms) != 1 || rooms.Rooms[0].ID != "lobby" || rooms.Rooms[0].Agents[0] != "roomie" {
+		t.Errorf("rooms = %+v", rooms)
+	}
+
+	server.Disconnect("roomie")
+	deadline := time.Now().Add(5 * time.Second)
+	for len(server.Agents()) > 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if _, err := server.SendTask(Task{Room: "lobby", Content: "hi"}); !errors.Is(err, ErrNoAgent) {
+		t.Errorf("task sent after disconnect: %v", err)
+	}
+	if _, err := server.WaitForResult(context.Background(), "dev-404"); !errors.Is(err, ErrUnknownTask) {
+		t.Errorf("got %v, want ErrUnknownTask", err)
+	}
+}
+
+func TestRedeliveryAndForget(t *testing.T) {
+	server := newServer(t, &Config{RedeliverAfter: 50 * time.Millisecond, Forget: true})
+
+	// Without an agent the task waits for one
+	id, err := server.SendTask(Task{Content: "queued"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	agent := dial(t, server)
+	agent.login("worker", "")
+	if task := agent.expect(types.MessageTypeTask); !strings.Contains(string(task.Data), id) {
+		t.Fatalf("task = %+v", task)
+	}
+
+	// Unanswered, it is delivered again
+	agent.expect(types.MessageTypeTask)
+	agent.respond(id, types.StandardMessageTypeString, "done")
+	agent.respond(id, types.StandardMessageTypeString, "done again")
+
+	deadline := time.Now().Add(5 * time.Second)
+	for server.Stats().DuplicateResponses == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	stats := server.Stats()
+	if stats.Tasks != 1 || stats.Answered != 1 || stats.Pending != 0 || stats.Redeliveries == 0 || stats.DuplicateResponses != 1 {
+		t.Errorf("stats = %+v", stats)
+	}
+	if _, ok := server.Result(id); ok {
+		t.Error("answered task not forgotten")
+	}
+	if received := server.Received(); len(received) != 0 {
+		t.Errorf("kept %d received messages", len(received))
+	}
+	if unanswered := server.Unanswered(); len(unanswered) != 0 {
+		t.Errorf("unanswered = %v", unanswered)
+	}
+}
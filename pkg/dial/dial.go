@@ -0,0 +1,258 @@
+// Package dial connects to hosts with several addresses quickly. It resolves
+// every A and AAAA record of the host, dials them in parallel with staggered
+// starts ("happy eyeballs", RFC 8305) and remembers the address that answered,
+// so a reconnect tries it first instead of waiting for unreachable records to
+// time out. Resolved addresses are cached briefly and reused when the resolver
+// fails.
+package dial
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+)
+
+// Config configures a Dialer
+type Config struct {
+	Stagger  time.Duration // Delay before starting the next attempt while earlier ones are pending (default 250ms)
+	Timeout  time.Duration // Timeout of each attempt (default 10s)
+	CacheTTL time.Duration // How long resolved addresses and the working address are reused (default 1m)
+
+	// Resolve looks up the addresses of a host (default net.DefaultResolver)
+	Resolve func(ctx context.Context, host string) ([]net.IPAddr, error)
+
+	// Dial connects to one address (default a net.Dialer with Timeout)
+	Dial func(ctx context.Context, network, address string) (net.Conn, error)
+}
+
+// Dialer dials hosts through all of their addresses. It is safe for
+// concurrent use and meant to be kept across reconnects.
+type Dialer struct {
+	stagger time.Duration
+	ttl     time.Duration
+	resolve func(ctx context.Context, host string) ([]net.IPAddr, error)
+	dial    func(ctx context.Context, network, address string) (net.Conn, error)
+
+	mu        sync.Mutex
+	resolved  map[string]resolved  // By host
+	preferred map[string]preferred // By host:port
+}
+
+type resolved struct {
+	addrs   []net.IPAddr
+	expires time.Time
+}
+
+type preferred struct {
+	ip      string
+	expires time.Time
+}
+
+// New creates a Dialer (nil config = defaults)
+func New(config *Config) *Dialer {
+	var c Config
+	if config != nil {
+		c = *config
+	}
+	if c.Stagger <= 0 {
+		c.Stagger = 250 * time.Millisecond
+	}
+	if c.Timeout <= 0 {
+		c.Timeout = 10 * time.Second
+	}
+	if c.CacheTTL <= 0 {
+		c.CacheTTL = time.Minute
+	}
+	if c.Resolve == nil {
+		c.Resolve = net.DefaultResolver.LookupIPAddr
+	}
+	if c.Dial == nil {
+		dialer := &net.Dialer{Timeout: c.Timeout}
+		c.Dial = dialer.DialContext
+	}
+	return &Dialer{
+		stagger:   c.Stagger,
+		ttl:       c.CacheTTL,
+		resolve:   c.Resolve,
+		dial:      c.Dial,
+		resolved:  make(map[string]resolved),
+		preferred: make(map[string]preferred),
+	}
+}
+
+// DialContext connects to address ("host:port"). Addresses that are already
+// IPs are dialed directly.
+func (d *Dialer) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, err
+	}
+	if net.ParseIP(host) != nil {
+		return d.dial(ctx, network, address)
+	}
+
+	addrs, err := d.lookup(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	addrs = filterNetwork(addrs, network)
+	if len(addrs) == 0 {
+		return nil, fmt.Errorf("no %s addresses for %s", network, host)
+	}
+
+	conn, ip, err := d.race(ctx, network, port, d.order(address, addrs))
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if err != nil {
+		delete(d.preferred, address)
+		return nil, fmt.Errorf("failed to dial %s: %w", address, err)
+	}
+	d.preferred[address] = preferred{ip: ip, expires: time.Now().Add(d.ttl)}
+	return conn, nil
+}
+
+// lookup resolves host, from the cache while it is fresh. When the resolver
+// fails, expired addresses are used rather than none.
+func (d *Dialer) lookup(ctx context.Context, host string) ([]net.IPAddr, error) {
+	d.mu.Lock()
+	cached, ok := d.resolved[host]
+	d.mu.Unlock()
+	if ok && time.Now().Before(cached.expires) {
+		return cached.addrs, nil
+	}
+
+	addrs, err := d.resolve(ctx, host)
+	if err != nil || len(addrs) == 0 {
+		if ok {
+			return cached.addrs, nil
+		}
+		if err == nil {
+			err = fmt.Errorf("no addresses for %s", host)
+		}
+		return nil, err
+	}
+
+	d.mu.Lock()
+	d.resolved[host] = resolved{addrs: addrs, expires: time.Now().Add(d.ttl)}
+	d.mu.Unlock()
+	return addrs, nil
+}
+
+// order returns the addresses in the order they are tried: the address that
+// worked last first, then alternating between address families, starting with
+// the family the resolver listed first
+func (d *Dialer) order(address string, addrs []net.IPAddr) []net.IPAddr {
+	d.mu.Lock()
+	last, ok := d.preferred[address]
+	d.mu.Unlock()
+	hasLast := ok && time.Now().Before(last.expires)
+
+	var first []net.IPAddr
+	var families [2][]net.IPAddr
+	primary := isIPv4(addrs[0])
+	for _, addr := range addrs {
+		switch {
+		case hasLast && addr.String() == last.ip:
+			first = append(first, addr)
+		case isIPv4(addr) == primary:
+			families[0] = append(families[0], addr)
+		default:
+			families[1] = append(families[1], addr)
+		}
+	}
+
+	ordered := first
+	for i := 0; i < len(families[0]) || i < len(families[1]); i++ {
+		for _, family := range families {
+			if i < len(family) {
+				ordered = append(ordered, family[i])
+			}
+		}
+	}
+	return ordered
+}
+
+// race dials the addresses, starting the next attempt after the stagger delay
+// or as soon as an attempt fails, and returns the first connection. Later
+// connections are closed.
+func (d *Dialer) race(ctx context.Context, network, port string, addrs []net.IPAddr) (net.Conn, string, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make(chan attempt, len(addrs))
+	next, pending := 0, 0
+	start := func() {
+		ip := addrs[next].String()
+		next++
+		pending++
+		go func() {
+			conn, err := d.dial(ctx, network, net.JoinHostPort(ip, port))
+			results <- attempt{conn: conn, ip: ip, err: err}
+		}()
+	}
+
+	start()
+	timer := time.NewTimer(d.stagger)
+	defer timer.Stop()
+	var errs []error
+	for pending > 0 {
+		select {
+		case <-timer.C:
+			if next < len(addrs) {
+				start()
+				timer.Reset(d.stagger)
+			}
+		case r := <-results:
+			pending--
+			if r.err == nil {
+				go closeLate(results, pending)
+				return r.conn, r.ip, nil
+			}
+			errs = append(errs, r.err)
+			if next < len(addrs) {
+				start()
+				timer.Reset(d.stagger)
+			}
+		case <-ctx.Done():
+			// Attempts already started end with the context
+			next = len(addrs)
+		}
+	}
+	return nil, "", errors.Join(errs...)
+}
+
+// attempt is the outcome of dialing one address
+type attempt struct {
+	conn net.Conn
+	ip   string
+	err  error
+}
+
+// closeLate closes the connections of attempts that finish after the race was won
+func closeLate(results <-chan attempt, pending int) {
+	for ; pending > 0; pending-- {
+		if r := <-results; r.conn != nil {
+			r.conn.Close()
+		}
+	}
+}
+
+func isIPv4(addr net.IPAddr) bool {
+	return addr.IP.To4() != nil
+}
+
+// filterNetwork keeps the addresses usable with network ("tcp4", "tcp6" or "tcp")
+func filterNetwork(addrs []net.IPAddr, network string) []net.IPAddr {
+	var filtered []net.IPAddr
+	for _, addr := range addrs {
+		switch {
+		case network == "tcp4" && !isIPv4(addr), network == "tcp6" && isIPv4(addr):
+		default:
+			filtered = append(filtered, addr)
+		}
+	}
+	return filtered
+}
@@ -0,0 +1,148 @@
+package dial
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeNetwork answers dials to the listed IPs and blocks on the others until
+// the attempt is cancelled
+type fakeNetwork struct {
+	up map[string]bool
+
+	mu      sync.Mutex
+	dialed  []string
+	lookups int
+	fail    bool
+}
+
+func (f *fakeNetwork) resolve(ctx context.Context, host string) ([]net.IPAddr, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.lookups++
+	if f.fail {
+		return nil, errors.New("resolver down")
+	}
+	return []net.IPAddr{
+		{IP: net.ParseIP("10.0.0.1")},
+		{IP: net.ParseIP("10.0.0.2")},
+		{IP: net.ParseIP("2001:db8::1")},
+		{IP: net.ParseIP("10.0.0.3")},
+	}, nil
+}
+
+func (f *fakeNetwork) dial(ctx context.Context, network, address string) (net.Conn, error) {
+	host, _, _ := net.SplitHostPort(address)
+	f.mu.Lock()
+	f.dialed = append(f.dialed, host)
+	f.mu.Unlock()
+	if f.up[host] {
+		client, server := net.Pipe()
+		server.Close()
+		return client, nil
+	}
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func (f *fakeNetwork) attempts() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	dialed := f.dialed
+	f.dialed = nil
+	return dialed
+}
+
+func newTestDialer(f *fakeNetwork) *Dialer {
+	return New(&Config{Stagger: 10 * time.Millisecond, Resolve: f.resolve, Dial: f.dial})
+}
+
+func TestDialRacesAddresses(t *testing.T) {
+	f := &fakeNetwork{up: map[string]bool{"10.0.0.3": true}}
+	d := newTestDialer(f)
+
+	conn, err := d.DialContext(context.Background(), "tcp", "backend.example:443")
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.Close()
+	// Families alternate, starting with the first one listed
+	if got := strings.Join(f.attempts(), " "); got != "10.0.0.1 2001:db8::1 10.0.0.2 10.0.0.3" {
+		t.Errorf("dialed %s", got)
+	}
+
+	// The working address is tried first on the next dial, from the cached lookup
+	conn, err = d.DialContext(context.Background(), "tcp", "backend.example:443")
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.Close()
+	if got := f.attempts(); len(got) != 1 || got[0] != "10.0.0.3" {
+		t.Errorf("dialed %v", got)
+	}
+	if f.lookups != 1 {
+		t.Errorf("%d lookups", f.lookups)
+	}
+}
+
+func TestDialFiltersNetwork(t *testing.T) {
+	f := &fakeNetwork{up: map[string]bool{"2001:db8::1": true}}
+	conn, err := newTestDialer(f).DialContext(context.Background(), "tcp6", "backend.example:443")
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.Close()
+	if got := f.attempts(); len(got) != 1 {
+		t.Errorf("dialed %v", got)
+	}
+}
+
+func TestDialAllUnreachable(t *testing.T) {
+	f := &fakeNetwork{}
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if _, err := newTestDialer(f).DialContext(ctx, "tcp", "backend.example:443"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got %v", err)
+	}
+	if got := f.attempts(); len(got) != 4 {
+		t.Errorf("dialed %v", got)
+	}
+}
+
+func TestDialUsesStaleAddressesWhenResolverFails(t *testing.T) {
+	f := &fakeNetwork{up: map[string]bool{"10.0.0.1": true}}
+	d := New(&Config{Stagger: 10 * time.Millisecond, CacheTTL: time.Millisecond, Resolve: f.resolve, Dial: f.dial})
+	conn, err := d.DialContext(context.Background(), "tcp", "backend.example:443")
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.Close()
+
+	time.Sleep(5 * time.Millisecond)
+	f.fail = true
+	conn, err = d.DialContext(context.Background(), "tcp", "backend.example:443")
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.Close()
+	if f.lookups != 2 {
+		t.Errorf("%d lookups", f.lookups)
+	}
+}
+
+func TestDialIPAddress(t *testing.T) {
+	f := &fakeNetwork{up: map[string]bool{"127.0.0.1": true}}
+	conn, err := newTestDialer(f).DialContext(context.Background(), "tcp", "127.0.0.1:80")
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.Close()
+	if f.lookups != 0 {
+		t.Error("IP address was resolved")
+	}
+}
@@ -0,0 +1,191 @@
+// Package e2e encrypts task content end to end between users and agents, so
+// the network relaying it only sees ciphertext. Content is sealed to the
+// recipient's X25519 public key with a fresh ephemeral key; the shared secret
+// is stretched with HKDF-SHA256 into an AES-256-GCM key. A sender that wants
+// an encrypted answer includes its own public key as the reply key.
+package e2e
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/types"
+)
+
+// Algorithm names the scheme in published keys
+const Algorithm = "X25519-HKDF-SHA256-AES256GCM"
+
+// hkdfInfo binds derived keys to this scheme and version
+const hkdfInfo = "teneo-e2e-v1"
+
+var (
+	// ErrUnknownKey is returned when content was sealed to a key the keyring does not hold
+	ErrUnknownKey = errors.New("content was encrypted to an unknown key")
+
+	// ErrDecrypt is returned when content cannot be decrypted
+	ErrDecrypt = errors.New("failed to decrypt content")
+)
+
+// Key is an X25519 key pair content can be sealed to
+type Key struct {
+	ID        string
+	CreatedAt time.Time
+	private   *ecdh.PrivateKey
+}
+
+// GenerateKey creates a new key
+func GenerateKey() (*Key, error) {
+	private, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate encryption key: %w", err)
+	}
+	return &Key{ID: KeyID(private.PublicKey()), CreatedAt: time.Now().UTC(), private: private}, nil
+}
+
+// PublicKey returns the key's public half
+func (k *Key) PublicKey() *ecdh.PublicKey {
+	return k.private.PublicKey()
+}
+
+// Public returns the key as it is published
+func (k *Key) Public() types.EncryptionKey {
+	return types.EncryptionKey{
+		ID:        k.ID,
+		Algorithm: Algorithm,
+		PublicKey: EncodePublicKey(k.PublicKey()),
+		CreatedAt: k.CreatedAt,
+	}
+}
+
+// KeyID returns the ID of a public key: the first 8 bytes of its SHA-256 hash, in hex
+func KeyID(public *ecdh.PublicKey) string {
+	sum := sha256.Sum256(public.Bytes())
+	return hex.EncodeToString(sum[:8])
+}
+
+// EncodePublicKey returns a public key in base64, as it is published
+func EncodePublicKey(public *ecdh.PublicKey) string {
+	return base64.StdEncoding.EncodeToString(public.Bytes())
+}
+
+// ParsePublicKey parses a base64 X25519 public key
+func ParsePublicKey(s string) (*ecdh.PublicKey, error) {
+	raw, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode public key: %w", err)
+	}
+	public, err := ecdh.X25519().NewPublicKey(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid X25519 public key: %w", err)
+	}
+	return public, nil
+}
+
+// Envelope is sealed content, sent as JSON in the message content with the
+// content encoding types.ContentEncodingE2E. Byte fields are base64.
+type Envelope struct {
+	KeyID      string `json:"kid"`           // ID of the recipient's key
+	Ephemeral  []byte `json:"epk"`           // Sender's ephemeral public key
+	ReplyKey   []byte `json:"rpk,omitempty"` // Public key the answer is to be sealed to
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ct"`
+}
+
+// Seal encrypts content to a recipient's public key. If reply is not nil,
+// the recipient can seal its answer to it.
+func Seal(recipient *ecdh.PublicKey, content string, reply *ecdh.PublicKey) (string, error) {
+	ephemeral, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate ephemeral key: %w", err)
+	}
+	secret, err := ephemeral.ECDH(recipient)
+	if err != nil {
+		return "", fmt.Errorf("failed to agree on a key: %w", err)
+	}
+
+	envelope := Envelope{KeyID: KeyID(recipient), Ephemeral: ephemeral.PublicKey().Bytes()}
+	if reply != nil {
+		envelope.ReplyKey = reply.Bytes()
+	}
+	aead, err := newAEAD(secret, envelope.Ephemeral, recipient.Bytes())
+	if err != nil {
+		return "", err
+	}
+	envelope.Nonce = make([]byte, aead.NonceSize())
+	if _, err := rand.Read(envelope.Nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	envelope.Ciphertext = aead.Seal(nil, envelope.Nonce, []byte(content), envelope.additionalData())
+
+	data, err := json.Marshal(envelope)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal envelope: %w", err)
+	}
+	return string(data), nil
+}
+
+// open decrypts an envelope sealed to key
+func (k *Key) open(envelope *Envelope) (string, error) {
+	ephemeral, err := ecdh.X25519().NewPublicKey(envelope.Ephemeral)
+	if err != nil {
+		return "", fmt.Errorf("%w: invalid ephemeral key: %v", ErrDecrypt, err)
+	}
+	secret, err := k.private.ECDH(ephemeral)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrDecrypt, err)
+	}
+	aead, err := newAEAD(secret, envelope.Ephemeral, k.PublicKey().Bytes())
+	if err != nil {
+		return "", err
+	}
+	if len(envelope.Nonce) != aead.NonceSize() {
+		return "", fmt.Errorf("%w: invalid nonce length %d", ErrDecrypt, len(envelope.Nonce))
+	}
+	content, err := aead.Open(nil, envelope.Nonce, envelope.Ciphertext, envelope.additionalData())
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrDecrypt, err)
+	}
+	return string(content), nil
+}
+
+// additionalData is authenticated with the ciphertext, so the key ID and
+// reply key cannot be swapped
+func (e *Envelope) additionalData() []byte {
+	return append([]byte(e.KeyID), e.ReplyKey...)
+}
+
+// newAEAD derives the AES-256-GCM cipher of a shared secret
+func newAEAD(secret, ephemeral, recipient []byte) (cipher.AEAD, error) {
+	salt := append(append([]byte{}, ephemeral...), recipient...)
+	key, err := hkdf.Key(sha256.New, secret, salt, hkdfInfo, 32)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive key: %w", err)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// ParseEnvelope parses sealed content
+func ParseEnvelope(content string) (*Envelope, error) {
+	var envelope Envelope
+	if err := json.Unmarshal([]byte(content), &envelope); err != nil {
+		return nil, fmt.Errorf("%w: invalid envelope: %v", ErrDecrypt, err)
+	}
+	if envelope.KeyID == "" || len(envelope.Ephemeral) == 0 {
+		return nil, fmt.Errorf("%w: envelope has no key", ErrDecrypt)
+	}
+	return &envelope, nil
+}
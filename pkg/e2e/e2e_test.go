@@ -0,0 +1,117 @@
+package e2e
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSealAndOpen(t *testing.T) {
+	ring, err := NewKeyring(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	user, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sealed, err := Seal(ring.Primary().PublicKey(), "summarize this contract", user.PublicKey())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(sealed, "contract") {
+		t.Error("sealed content contains the plaintext")
+	}
+	plaintext, reply, err := ring.Open(sealed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plaintext != "summarize this contract" {
+		t.Errorf("plaintext = %q", plaintext)
+	}
+	if reply == nil || !reply.Equal(user.PublicKey()) {
+		t.Fatal("reply key was not recovered")
+	}
+
+	// The answer sealed to the reply key opens with the user's key
+	answer, err := Seal(reply, "done", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	envelope, err := ParseEnvelope(answer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := user.open(envelope); err != nil || got != "done" {
+		t.Errorf("answer = %q, %v", got, err)
+	}
+
+	// Tampering is detected
+	envelope.ReplyKey = ring.Primary().PublicKey().Bytes()
+	if _, err := user.open(envelope); !errors.Is(err, ErrDecrypt) {
+		t.Errorf("got %v, want ErrDecrypt", err)
+	}
+	if _, _, err := ring.Open("not an envelope"); !errors.Is(err, ErrDecrypt) {
+		t.Errorf("got %v, want ErrDecrypt", err)
+	}
+}
+
+func TestRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys", "encryption-keys.json")
+	ring, err := LoadKeyring(path, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0o600 {
+		t.Fatalf("key file mode %v, %v, want 0600", info, err)
+	}
+
+	first := ring.Primary()
+	sealedToFirst, _ := Seal(first.PublicKey(), "before rotation", nil)
+	if _, err := ring.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+	if ring.Primary().ID == first.ID {
+		t.Fatal("rotation kept the primary key")
+	}
+	if keys := ring.Public(); len(keys) != 2 || keys[0].ID != ring.Primary().ID || keys[1].ID != first.ID {
+		t.Errorf("public keys = %+v", keys)
+	}
+
+	// Reloading keeps the rotated keys
+	reloaded, err := LoadKeyring(path, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reloaded.Primary().ID != ring.Primary().ID {
+		t.Error("reloaded keyring has another primary key")
+	}
+	if got, _, err := reloaded.Open(sealedToFirst); err != nil || got != "before rotation" {
+		t.Errorf("retained key: %q, %v", got, err)
+	}
+
+	// A second rotation drops the first key
+	if _, err := reloaded.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := reloaded.Open(sealedToFirst); !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("got %v, want ErrUnknownKey", err)
+	}
+}
+
+func TestParsePublicKey(t *testing.T) {
+	key, _ := GenerateKey()
+	public, err := ParsePublicKey(key.Public().PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if KeyID(public) != key.ID {
+		t.Error("parsed key has another ID")
+	}
+	if _, err := ParsePublicKey("AAAA"); err == nil {
+		t.Error("expected an error for a short key")
+	}
+}
@@ -0,0 +1,215 @@
+package e2e
+
+import (
+	"crypto/ecdh"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/types"
+)
+
+// DefaultKeyFile is where an agent keeps its encryption keys when no path is configured
+const DefaultKeyFile = ".teneo/encryption-keys.json"
+
+// DefaultRetained is how many keys a keyring keeps by default: the current
+// one and the one before it, so content sealed to a key published before a
+// rotation can still be decrypted
+const DefaultRetained = 2
+
+// Keyring holds an agent's encryption keys, newest first. New content is
+// sealed to the newest key; older keys are kept to decrypt content sealed
+// before a rotation.
+type Keyring struct {
+	mu       sync.RWMutex
+	keys     []*Key
+	retained int
+	path     string
+}
+
+// storedKey is a key as it is kept in the key file
+type storedKey struct {
+	ID         string    `json:"id"`
+	CreatedAt  time.Time `json:"created_at"`
+	PrivateKey []byte    `json:"private_key"`
+}
+
+// NewKeyring creates a keyring with a fresh key, kept in memory only
+func NewKeyring(retained int) (*Keyring, error) {
+	key, err := GenerateKey()
+	if err != nil {
+		return nil, err
+	}
+	return &Keyring{keys: []*Key{key}, retained: normalizeRetained(retained)}, nil
+}
+
+// LoadKeyring reads a keyring from a key file, creating the file with a
+// fresh key if it does not exist. Rotations are saved to the file.
+func LoadKeyring(path string, retained int) (*Keyring, error) {
+	if path == "" {
+		path = DefaultKeyFile
+	}
+	k := &Keyring{retained: normalizeRetained(retained), path: path}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		key, err := GenerateKey()
+		if err != nil {
+			return nil, err
+		}
+		k.keys = []*Key{key}
+		if err := k.save(); err != nil {
+			return nil, err
+		}
+		return k, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var stored []storedKey
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return nil, fmt.Errorf("failed to parse key file %s: %w", path, err)
+	}
+	for _, s := range stored {
+		private, err := ecdh.X25519().NewPrivateKey(s.PrivateKey)
+		if err != nil {
+			return nil, fmt.Errorf("invalid key %s in %s: %w", s.ID, path, err)
+		}
+		k.keys = append(k.keys, &Key{ID: KeyID(private.PublicKey()), CreatedAt: s.CreatedAt, private: private})
+	}
+	if len(k.keys) == 0 {
+		return nil, fmt.Errorf("key file %s holds no keys", path)
+	}
+	return k, nil
+}
+
+func normalizeRetained(retained int) int {
+	if retained < 1 {
+		return DefaultRetained
+	}
+	return retained
+}
+
+// Primary returns the key new content is sealed to
+func (k *Keyring) Primary() *Key {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return k.keys[0]
+}
+
+// Public returns the public keys, newest first
+func (k *Keyring) Public() []types.EncryptionKey {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	keys := make([]types.EncryptionKey, len(k.keys))
+	for i, key := range k.keys {
+		keys[i] = key.Public()
+	}
+	return keys
+}
+
+// Rotate makes a fresh key the primary one, dropping keys beyond the
+// retained count, and saves the keyring if it has a file
+func (k *Keyring) Rotate() (*Key, error) {
+	key, err := GenerateKey()
+	if err != nil {
+		return nil, err
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	previous := k.keys
+	k.keys = append([]*Key{key}, k.keys...)
+	if len(k.keys) > k.retained {
+		k.keys = k.keys[:k.retained]
+	}
+	if err := k.save(); err != nil {
+		k.keys = previous
+		return nil, err
+	}
+	return key, nil
+}
+
+// Open decrypts content sealed to one of the keyring's keys. It returns the
+// plaintext and the sender's reply key (nil if the sender gave none).
+func (k *Keyring) Open(content string) (string, *ecdh.PublicKey, error) {
+	envelope, err := ParseEnvelope(content)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var reply *ecdh.PublicKey
+	if len(envelope.ReplyKey) > 0 {
+		if reply, err = ecdh.X25519().NewPublicKey(envelope.ReplyKey); err != nil {
+			return "", nil, fmt.Errorf("%w: invalid reply key: %v", ErrDecrypt, err)
+		}
+	}
+
+	key := k.key(envelope.KeyID)
+	if key == nil {
+		return "", nil, fmt.Errorf("%w: %s", ErrUnknownKey, envelope.KeyID)
+	}
+	plaintext, err := key.open(envelope)
+	if err != nil {
+		return "", nil, err
+	}
+	return plaintext, reply, nil
+}
+
+// key returns the key with an ID, or nil
+func (k *Keyring) key(id string) *Key {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	for _, key := range k.keys {
+		if key.ID == id {
+			return key
+		}
+	}
+	return nil
+}
+
+// save writes the keyring to its file, if it has one, replacing it
+// atomically. Callers hold the lock or own the keyring.
+func (k *Keyring) save() error {
+	if k.path == "" {
+		return nil
+	}
+
+	stored := make([]storedKey, len(k.keys))
+	for i, key := range k.keys {
+		stored[i] = storedKey{ID: key.ID, CreatedAt: key.CreatedAt, PrivateKey: key.private.Bytes()}
+	}
+	data, err := json.MarshalIndent(stored, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal keys: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(k.path), 0o700); err != nil {
+		return fmt.Errorf("failed to create key directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(k.path), ".encryption-keys-*")
+	if err != nil {
+		return fmt.Errorf("failed to write key file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if err := tmp.Chmod(0o600); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write key file: %w", err)
+	}
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write key file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write key file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), k.path); err != nil {
+		return fmt.Errorf("failed to write key file: %w", err)
+	}
+	return nil
+}
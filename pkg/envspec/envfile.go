@@ -0,0 +1,145 @@
+package envspec
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Entry is a variable set in a .env file
+type Entry struct {
+	Name  string
+	Value string
+	Line  int // Line in the file, 0 for variables not read from a file
+}
+
+// ParseEnv reads a .env file: NAME=value lines, optionally prefixed with
+// "export". Values may be single or double quoted; double-quoted values
+// support the escapes of Go strings. Blank lines and lines starting with #
+// are skipped, as are unquoted " #" comments after a value.
+func ParseEnv(r io.Reader) ([]Entry, error) {
+	var entries []Entry
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		text = strings.TrimPrefix(text, "export ")
+		name, value, ok := strings.Cut(text, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" || strings.ContainsAny(name, " \t") {
+			return nil, fmt.Errorf("line %d: expected NAME=value", line)
+		}
+		value, err := unquote(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		entries = append(entries, Entry{Name: name, Value: value, Line: line})
+	}
+	return entries, scanner.Err()
+}
+
+func unquote(value string) (string, error) {
+	switch {
+	case strings.HasPrefix(value, `"`):
+		end := closingQuote(value)
+		if end < 0 {
+			return "", fmt.Errorf("unterminated quoted value")
+		}
+		return strconv.Unquote(value[:end+1])
+	case strings.HasPrefix(value, "'"):
+		end := strings.Index(value[1:], "'")
+		if end < 0 {
+			return "", fmt.Errorf("unterminated quoted value")
+		}
+		return value[1 : end+1], nil
+	}
+	if i := strings.Index(value, " #"); i >= 0 {
+		value = strings.TrimSpace(value[:i])
+	}
+	return value, nil
+}
+
+// closingQuote returns the index of the quote ending a double-quoted value, -1 if there is none
+func closingQuote(value string) int {
+	for i := 1; i < len(value); i++ {
+		switch value[i] {
+		case '\\':
+			i++
+		case '"':
+			return i
+		}
+	}
+	return -1
+}
+
+// Check checks variables against the spec and returns every problem:
+// variables the spec doesn't know, with the closest known name if one is
+// near, values that don't match their type, and variables set twice
+func (s *Spec) Check(entries []Entry) []error {
+	var problems []error
+	seen := make(map[string]int)
+	for _, entry := range entries {
+		at := ""
+		if entry.Line > 0 {
+			at = fmt.Sprintf("line %d: ", entry.Line)
+		}
+
+		v, ok := s.Lookup(entry.Name)
+		if !ok {
+			if suggestion := s.closest(entry.Name); suggestion != "" {
+				problems = append(problems, fmt.Errorf("%sunknown variable %s (did you mean %s?)", at, entry.Name, suggestion))
+			} else {
+				problems = append(problems, fmt.Errorf("%sunknown variable %s", at, entry.Name))
+			}
+			continue
+		}
+		if line, dup := seen[entry.Name]; dup && entry.Line > 0 {
+			problems = append(problems, fmt.Errorf("%s%s is already set on line %d", at, entry.Name, line))
+		}
+		seen[entry.Name] = entry.Line
+		if err := v.CheckValue(entry.Value); err != nil {
+			problems = append(problems, fmt.Errorf("%s%w", at, err))
+		}
+	}
+	return problems
+}
+
+// closest returns the known variable nearest to name, if it is close enough
+// to be a misspelling
+func (s *Spec) closest(name string) string {
+	best, bestDistance := "", len(name)/4+1
+	for _, v := range s.vars {
+		if v.Env == "" {
+			continue
+		}
+		if d := distance(strings.ToUpper(name), v.Env); d <= bestDistance && (best == "" || d < bestDistance) {
+			best, bestDistance = v.Env, d
+		}
+	}
+	return best
+}
+
+// distance is the Levenshtein distance between two strings
+func distance(a, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
@@ -0,0 +1,241 @@
+// Package envspec describes configuration settings that are read from
+// environment variables and config files. From the description it generates
+// commented .env.example and YAML files with the type and default of every
+// setting, and it checks .env files strictly: misspelled or unknown variables
+// and values of the wrong type are reported instead of silently ignored.
+//
+//	spec := envspec.New(
+//		envspec.Var{Env: "LOG_LEVEL", Key: "log_level", Description: "Log level", Values: []string{"debug", "info"}},
+//	)
+//	if err := spec.Describe(defaults); err != nil { // Types and defaults from a struct
+//		log.Fatal(err)
+//	}
+//	spec.WriteEnv(os.Stdout)
+package envspec
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Type is the type of a setting's value
+type Type string
+
+// Value types. Lists are comma-separated; maps are comma-separated key=value pairs.
+const (
+	TypeString   Type = "string"
+	TypeInt      Type = "int"
+	TypeFloat    Type = "float"
+	TypeBool     Type = "bool"
+	TypeDuration Type = "duration"
+	TypeList     Type = "list"
+	TypeMap      Type = "map"
+)
+
+// Var describes one setting
+type Var struct {
+	Env         string   // Environment variable, e.g. "LOG_LEVEL" (empty = config file only)
+	Key         string   // Config file key, e.g. "log_level" (empty = environment only)
+	Type        Type     // Type of the value (default string, or the field's type with Describe)
+	Default     string   // Default value as written in a .env file
+	Description string   // One line shown above the setting in generated files
+	Group       string   // Heading the setting is listed under
+	Values      []string // Allowed values (empty = any value of the type)
+	Required    bool     // Written uncommented in generated files
+	Secret      bool     // Default and example values are never written
+}
+
+// Spec is a set of settings in the order they were added
+type Spec struct {
+	vars []Var
+}
+
+// New creates a spec with the given settings
+func New(vars ...Var) *Spec {
+	s := &Spec{}
+	s.Add(vars...)
+	return s
+}
+
+// Add adds settings. A setting with the Env or Key of an existing one replaces it.
+func (s *Spec) Add(vars ...Var) {
+	for _, v := range vars {
+		if i := s.index(v); i >= 0 {
+			s.vars[i] = v
+		} else {
+			s.vars = append(s.vars, v)
+		}
+	}
+}
+
+func (s *Spec) index(v Var) int {
+	for i, existing := range s.vars {
+		if (v.Env != "" && existing.Env == v.Env) || (v.Env == "" && v.Key != "" && existing.Key == v.Key) {
+			return i
+		}
+	}
+	return -1
+}
+
+// Vars returns the settings in the order they were added
+func (s *Spec) Vars() []Var {
+	return append([]Var(nil), s.vars...)
+}
+
+// Lookup returns the setting read from an environment variable
+func (s *Spec) Lookup(env string) (Var, bool) {
+	for _, v := range s.vars {
+		if v.Env != "" && v.Env == env {
+			return v, true
+		}
+	}
+	return Var{}, false
+}
+
+// Describe sets the Type and Default of the settings with a Key from the
+// field with that json name in defaults, a struct or a pointer to one.
+// Types and defaults already set are kept. Keys without a field are an error,
+// so the spec cannot drift from the struct unnoticed.
+func (s *Spec) Describe(defaults any) error {
+	value := reflect.ValueOf(defaults)
+	for value.Kind() == reflect.Pointer {
+		value = value.Elem()
+	}
+	if value.Kind() != reflect.Struct {
+		return fmt.Errorf("defaults must be a struct, got %T", defaults)
+	}
+	fields := make(map[string]reflect.Value)
+	for i := 0; i < value.NumField(); i++ {
+		name, _, _ := strings.Cut(value.Type().Field(i).Tag.Get("json"), ",")
+		if name != "" && name != "-" && value.Type().Field(i).IsExported() {
+			fields[name] = value.Field(i)
+		}
+	}
+
+	var missing []string
+	for i, v := range s.vars {
+		if v.Key == "" {
+			continue
+		}
+		field, ok := fields[v.Key]
+		if !ok {
+			missing = append(missing, v.Key)
+			continue
+		}
+		typ, def, ok := describeValue(field)
+		if !ok {
+			return fmt.Errorf("field %s has type %s, which settings cannot hold", v.Key, field.Type())
+		}
+		if v.Type == "" {
+			s.vars[i].Type = typ
+		}
+		if v.Default == "" {
+			s.vars[i].Default = def
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("no fields for keys %s in %T", strings.Join(missing, ", "), defaults)
+	}
+	return nil
+}
+
+// describeValue returns the type of a field and its value written as a default
+func describeValue(v reflect.Value) (Type, string, bool) {
+	if d, ok := v.Interface().(time.Duration); ok {
+		return TypeDuration, FormatDuration(d), true
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return TypeString, v.String(), true
+	case reflect.Bool:
+		return TypeBool, strconv.FormatBool(v.Bool()), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return TypeInt, strconv.FormatInt(v.Int(), 10), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return TypeInt, strconv.FormatUint(v.Uint(), 10), true
+	case reflect.Float32, reflect.Float64:
+		return TypeFloat, strconv.FormatFloat(v.Float(), 'g', -1, 64), true
+	case reflect.Slice:
+		items := make([]string, v.Len())
+		for i := range items {
+			if _, item, ok := describeValue(v.Index(i)); ok {
+				items[i] = item
+			}
+		}
+		return TypeList, strings.Join(items, ","), v.Type().Elem().Kind() == reflect.String
+	case reflect.Map:
+		var pairs []string
+		for _, key := range v.MapKeys() {
+			_, item, _ := describeValue(v.MapIndex(key))
+			pairs = append(pairs, fmt.Sprintf("%v=%s", key.Interface(), item))
+		}
+		sort.Strings(pairs)
+		return TypeMap, strings.Join(pairs, ","), v.Type().Key().Kind() == reflect.String
+	}
+	return "", "", false
+}
+
+// FormatDuration writes a duration without zero units, e.g. "1h" instead of "1h0m0s"
+func FormatDuration(d time.Duration) string {
+	s := d.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = strings.TrimSuffix(s, "0s")
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+	return s
+}
+
+// typeOf returns the type of a setting, string if none is set
+func (v Var) typeOf() Type {
+	if v.Type == "" {
+		return TypeString
+	}
+	return v.Type
+}
+
+// CheckValue checks a value against the setting's type and allowed values
+func (v Var) CheckValue(value string) error {
+	if value == "" {
+		return nil
+	}
+	var err error
+	switch v.typeOf() {
+	case TypeInt:
+		_, err = strconv.ParseInt(value, 10, 64)
+	case TypeFloat:
+		_, err = strconv.ParseFloat(value, 64)
+	case TypeBool:
+		_, err = strconv.ParseBool(value)
+	case TypeDuration:
+		_, err = time.ParseDuration(value)
+	case TypeMap:
+		for _, pair := range strings.Split(value, ",") {
+			if key, _, ok := strings.Cut(pair, "="); !ok || strings.TrimSpace(key) == "" {
+				err = fmt.Errorf("%q is not key=value", pair)
+				break
+			}
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("%s: invalid %s %q", v.Env, v.typeOf(), value)
+	}
+	if len(v.Values) > 0 && !containsFold(v.Values, value) {
+		return fmt.Errorf("%s: invalid value %q (use %s)", v.Env, value, strings.Join(v.Values, ", "))
+	}
+	return nil
+}
+
+func containsFold(values []string, value string) bool {
+	for _, allowed := range values {
+		if strings.EqualFold(allowed, value) {
+			return true
+		}
+	}
+	return false
+}
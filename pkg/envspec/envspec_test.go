@@ -0,0 +1,205 @@
+package envspec
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+type testConfig struct {
+	Name         string                   `json:"name"`
+	Capabilities []string                 `json:"capabilities"`
+	RateLimit    int                      `json:"rate_limit_per_minute"`
+	Threshold    float64                  `json:"review_threshold"`
+	Enabled      bool                     `json:"redis_enabled"`
+	Delay        time.Duration            `json:"reconnect_delay"`
+	Timeouts     map[string]time.Duration `json:"capability_timeouts"`
+	PrivateKey   string                   `json:"private_key"`
+	LogLevel     string                   `json:"log_level"`
+}
+
+func testSpec(t *testing.T) *Spec {
+	t.Helper()
+	spec := New(
+		Var{Env: "AGENT_NAME", Key: "name", Description: "Agent name", Group: "Agent", Required: true},
+		Var{Env: "AGENT_CAPABILITIES", Key: "capabilities", Description: "Capabilities", Group: "Agent"},
+		Var{Env: "PRIVATE_KEY", Key: "private_key", Description: "Wallet key", Group: "Agent", Required: true, Secret: true},
+		Var{Env: "RATE_LIMIT_PER_MINUTE", Key: "rate_limit_per_minute", Description: "Tasks per minute", Group: "Limits"},
+		Var{Env: "REVIEW_THRESHOLD", Key: "review_threshold", Group: "Limits"},
+		Var{Env: "REDIS_ENABLED", Key: "redis_enabled", Group: "Limits"},
+		Var{Env: "RECONNECT_DELAY", Key: "reconnect_delay", Group: "Limits"},
+		Var{Env: "CAPABILITY_TIMEOUTS", Key: "capability_timeouts", Group: "Limits"},
+		Var{Env: "LOG_LEVEL", Key: "log_level", Values: []string{"debug", "info"}, Group: "Limits"},
+		Var{Env: "REDIS_URL", Description: "Alias of the Redis address", Group: "Limits"},
+	)
+	err := spec.Describe(&testConfig{
+		Name:         "Teneo Agent",
+		Capabilities: []string{"general", "text/summary"},
+		RateLimit:    60,
+		Delay:        time.Hour,
+		Timeouts:     map[string]time.Duration{"b": time.Minute, "a": 30 * time.Second},
+		PrivateKey:   "secret",
+		LogLevel:     "info",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return spec
+}
+
+func TestDescribe(t *testing.T) {
+	spec := testSpec(t)
+	want := map[string]struct {
+		typ Type
+		def string
+	}{
+		"AGENT_CAPABILITIES":    {TypeList, "general,text/summary"},
+		"RATE_LIMIT_PER_MINUTE": {TypeInt, "60"},
+		"REVIEW_THRESHOLD":      {TypeFloat, "0"},
+		"REDIS_ENABLED":         {TypeBool, "false"},
+		"RECONNECT_DELAY":       {TypeDuration, "1h"},
+		"CAPABILITY_TIMEOUTS":   {TypeMap, "a=30s,b=1m"},
+		"REDIS_URL":             {"", ""},
+	}
+	for env, w := range want {
+		v, ok := spec.Lookup(env)
+		if !ok || v.Type != w.typ || v.Default != w.def {
+			t.Errorf("%s: got %q %q, want %q %q", env, v.Type, v.Default, w.typ, w.def)
+		}
+	}
+
+	if err := New(Var{Env: "X", Key: "missing"}).Describe(testConfig{}); err == nil {
+		t.Error("key without a field accepted")
+	}
+}
+
+func TestParseEnv(t *testing.T) {
+	entries, err := ParseEnv(strings.NewReader(`
+# comment
+AGENT_NAME="Summarizer \"Pro\""
+export LOG_LEVEL=debug # trailing comment
+SYSTEM_PROMPT='Be brief # really'
+EMPTY=
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Entry{
+		{"AGENT_NAME", `Summarizer "Pro"`, 3},
+		{"LOG_LEVEL", "debug", 4},
+		{"SYSTEM_PROMPT", "Be brief # really", 5},
+		{"EMPTY", "", 6},
+	}
+	if len(entries) != len(want) {
+		t.Fatalf("got %+v", entries)
+	}
+	for i := range want {
+		if entries[i] != want[i] {
+			t.Errorf("entry %d = %+v, want %+v", i, entries[i], want[i])
+		}
+	}
+
+	for _, invalid := range []string{"no equals sign", `NAME="unterminated`, "=value"} {
+		if _, err := ParseEnv(strings.NewReader(invalid)); err == nil {
+			t.Errorf("accepted %q", invalid)
+		}
+	}
+}
+
+func TestCheck(t *testing.T) {
+	spec := testSpec(t)
+	entries, err := ParseEnv(strings.NewReader(`AGENT_NAME=Summarizer
+RATE_LIMT_PER_MINUTE=10
+RATE_LIMIT_PER_MINUTE=ten
+RECONNECT_DELAY=5s
+LOG_LEVEL=verbose
+CAPABILITY_TIMEOUTS=text/summary=1m,oops
+TOTALLY_UNRELATED=1
+AGENT_NAME=Again
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	problems := spec.Check(entries)
+	want := []string{
+		"line 2: unknown variable RATE_LIMT_PER_MINUTE (did you mean RATE_LIMIT_PER_MINUTE?)",
+		`line 3: RATE_LIMIT_PER_MINUTE: invalid int "ten"`,
+		`line 5: LOG_LEVEL: invalid value "verbose" (use debug, info)`,
+		`line 6: CAPABILITY_TIMEOUTS: invalid map "text/summary=1m,oops"`,
+		"line 7: unknown variable TOTALLY_UNRELATED",
+		"line 8: AGENT_NAME is already set on line 1",
+	}
+	if len(problems) != len(want) {
+		t.Fatalf("got %d problems: %v", len(problems), problems)
+	}
+	for i, problem := range problems {
+		if problem.Error() != want[i] {
+			t.Errorf("problem %d = %q, want %q", i, problem, want[i])
+		}
+	}
+}
+
+func TestWriteEnv(t *testing.T) {
+	var out strings.Builder
+	if err := testSpec(t).WriteEnv(&out); err != nil {
+		t.Fatal(err)
+	}
+	env := out.String()
+	for _, want := range []string{
+		"# ---- Agent ----\n",
+		"# Agent name (string; required)\nAGENT_NAME=\"Teneo Agent\"\n",
+		"# Wallet key (string; required)\nPRIVATE_KEY=\n",
+		"# Tasks per minute (int; default 60)\n# RATE_LIMIT_PER_MINUTE=60\n",
+		"# LOG_LEVEL (string; one of debug, info; default info)\n# LOG_LEVEL=info\n",
+		"# REDIS_URL=\n",
+	} {
+		if !strings.Contains(env, want) {
+			t.Errorf("missing %q in\n%s", want, env)
+		}
+	}
+	if strings.Contains(env, "secret") {
+		t.Error("secret default written")
+	}
+
+	// Every variable passes the check with its default once uncommented
+	var uncommented strings.Builder
+	for _, line := range strings.Split(env, "\n") {
+		if line = strings.TrimPrefix(line, "# "); strings.Contains(line, "=") && !strings.Contains(line, " (") {
+			uncommented.WriteString(line + "\n")
+		}
+	}
+	entries, err := ParseEnv(strings.NewReader(uncommented.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 10 {
+		t.Errorf("got %d variables", len(entries))
+	}
+	if problems := testSpec(t).Check(entries); len(problems) > 0 {
+		t.Errorf("example has problems: %v", problems)
+	}
+}
+
+func TestWriteYAML(t *testing.T) {
+	var out strings.Builder
+	if err := testSpec(t).WriteYAML(&out); err != nil {
+		t.Fatal(err)
+	}
+	yaml := out.String()
+	for _, want := range []string{
+		"name: \"Teneo Agent\"\n",
+		"private_key: ${PRIVATE_KEY}\n",
+		"# Capabilities (list; default general,text/summary; env AGENT_CAPABILITIES)\n# capabilities: [\"general\", \"text/summary\"]\n",
+		"# reconnect_delay: \"1h\"\n",
+		"# capability_timeouts: {\"a\": \"30s\", \"b\": \"1m\"}\n",
+		"# redis_enabled: false\n",
+	} {
+		if !strings.Contains(yaml, want) {
+			t.Errorf("missing %q in\n%s", want, yaml)
+		}
+	}
+	if strings.Contains(yaml, "REDIS_URL") {
+		t.Error("environment-only variable written to the config file")
+	}
+}
@@ -0,0 +1,146 @@
+package envspec
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// WriteEnv writes a .env.example listing every environment variable with
+// its description, type and default. Required variables are set; the others
+// are commented out with their default, so uncommenting one changes nothing
+// until its value is edited.
+func (s *Spec) WriteEnv(w io.Writer) error {
+	out := bufio.NewWriter(w)
+	s.eachGroup(out, func(v Var) {
+		if v.Env == "" {
+			return
+		}
+		fmt.Fprintf(out, "# %s\n", v.annotation(""))
+		prefix := "# "
+		if v.Required {
+			prefix = ""
+		}
+		value := v.Default
+		if v.Secret {
+			value = ""
+		}
+		fmt.Fprintf(out, "%s%s=%s\n\n", prefix, v.Env, envValue(value))
+	})
+	return out.Flush()
+}
+
+// WriteYAML writes an example config file listing every config file key with
+// its description, type, default and environment variable. Like WriteEnv,
+// only required keys are set. Secrets refer to their environment variable
+// instead of holding a value.
+func (s *Spec) WriteYAML(w io.Writer) error {
+	out := bufio.NewWriter(w)
+	s.eachGroup(out, func(v Var) {
+		if v.Key == "" {
+			return
+		}
+		fmt.Fprintf(out, "# %s\n", v.annotation(v.Env))
+		prefix := "# "
+		if v.Required {
+			prefix = ""
+		}
+		value := yamlValue(v.typeOf(), v.Default)
+		if v.Secret && v.Env != "" {
+			value = "${" + v.Env + "}"
+		} else if v.Secret {
+			value = `""`
+		}
+		fmt.Fprintf(out, "%s%s: %s\n\n", prefix, v.Key, value)
+	})
+	return out.Flush()
+}
+
+// eachGroup calls write for the settings of each group in turn, in the order
+// groups first appear, under a heading for the group
+func (s *Spec) eachGroup(out io.Writer, write func(Var)) {
+	var groups []string
+	byGroup := make(map[string][]Var)
+	for _, v := range s.vars {
+		if _, ok := byGroup[v.Group]; !ok {
+			groups = append(groups, v.Group)
+		}
+		byGroup[v.Group] = append(byGroup[v.Group], v)
+	}
+	for _, group := range groups {
+		if group != "" {
+			fmt.Fprintf(out, "# ---- %s ----\n\n", group)
+		}
+		for _, v := range byGroup[group] {
+			write(v)
+		}
+	}
+}
+
+// annotation is the comment above a setting: its description, type, allowed
+// values, default and, in config files, environment variable
+func (v Var) annotation(env string) string {
+	details := []string{string(v.typeOf())}
+	if len(v.Values) > 0 {
+		details = append(details, "one of "+strings.Join(v.Values, ", "))
+	}
+	if v.Required {
+		details = append(details, "required")
+	} else if v.Default != "" && !v.Secret {
+		details = append(details, "default "+v.Default)
+	}
+	if env != "" {
+		details = append(details, "env "+env)
+	}
+	description := v.Description
+	if description == "" {
+		description = v.Env
+	}
+	return fmt.Sprintf("%s (%s)", description, strings.Join(details, "; "))
+}
+
+// envValue quotes a value for a .env file if needed
+func envValue(value string) string {
+	if strings.ContainsAny(value, " #\"'\\\t\n") {
+		return strconv.Quote(value)
+	}
+	return value
+}
+
+// yamlValue writes a default in YAML flow syntax
+func yamlValue(typ Type, value string) string {
+	switch typ {
+	case TypeBool:
+		if value == "" {
+			return "false"
+		}
+		return value
+	case TypeInt, TypeFloat:
+		if value == "" {
+			return "0"
+		}
+		return value
+	case TypeList:
+		if value == "" {
+			return "[]"
+		}
+		items := strings.Split(value, ",")
+		for i, item := range items {
+			items[i] = strconv.Quote(strings.TrimSpace(item))
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	case TypeMap:
+		if value == "" {
+			return "{}"
+		}
+		pairs := strings.Split(value, ",")
+		for i, pair := range pairs {
+			key, item, _ := strings.Cut(pair, "=")
+			pairs[i] = strconv.Quote(strings.TrimSpace(key)) + ": " + strconv.Quote(strings.TrimSpace(item))
+		}
+		return "{" + strings.Join(pairs, ", ") + "}"
+	}
+	return strconv.Quote(value)
+}
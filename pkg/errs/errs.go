@@ -0,0 +1,223 @@
+// Package errs classifies errors so that retries, circuit breaking and task
+// error reporting all make the same decision for the same failure.
+package errs
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net"
+	"time"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/types"
+)
+
+// Kind classifies an error by how the SDK should react to it
+type Kind int
+
+const (
+	// KindUnknown is an unclassified error. It is retried and counts against the circuit breaker.
+	KindUnknown Kind = iota
+	// KindRetryable is a transient failure (timeouts, dropped connections) worth retrying
+	KindRetryable
+	// KindRateLimited means the remote side asked to slow down; retry after a delay
+	KindRateLimited
+	// KindUser is caused by the request itself (bad input, quota, permissions); retrying won't help
+	KindUser
+	// KindTerminal is a permanent failure of the agent or its dependencies; retrying won't help
+	KindTerminal
+)
+
+// String returns the string representation of the kind
+func (k Kind) String() string {
+	switch k {
+	case KindRetryable:
+		return "retryable"
+	case KindRateLimited:
+		return "rate_limited"
+	case KindUser:
+		return "user"
+	case KindTerminal:
+		return "terminal"
+	default:
+		return "unknown"
+	}
+}
+
+// Error is an error with a classification
+type Error struct {
+	Kind       Kind
+	Err        error
+	RetryAfter time.Duration // Suggested delay before retrying (rate limited errors)
+}
+
+// Error implements the error interface
+func (e *Error) Error() string {
+	if e.Err == nil {
+		return e.Kind.String() + " error"
+	}
+	return e.Err.Error()
+}
+
+// Unwrap returns the wrapped error
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Retryable marks err as a transient failure
+func Retryable(err error) error {
+	return wrap(KindRetryable, err, 0)
+}
+
+// RateLimited marks err as a rate limit, optionally with the delay before retrying
+func RateLimited(err error, retryAfter time.Duration) error {
+	return wrap(KindRateLimited, err, retryAfter)
+}
+
+// User marks err as caused by the request
+func User(err error) error {
+	return wrap(KindUser, err, 0)
+}
+
+// Terminal marks err as a permanent failure
+func Terminal(err error) error {
+	return wrap(KindTerminal, err, 0)
+}
+
+// wrap classifies err, returning nil for a nil error
+func wrap(kind Kind, err error, retryAfter time.Duration) error {
+	if err == nil {
+		return nil
+	}
+	return &Error{Kind: kind, Err: err, RetryAfter: retryAfter}
+}
+
+// KindOf returns the classification of err. Explicitly classified errors win;
+// otherwise well-known SDK and standard library errors are recognized.
+func KindOf(err error) Kind {
+	if err == nil {
+		return KindUnknown
+	}
+
+	var classified *Error
+	if errors.As(err, &classified) {
+		return classified.Kind
+	}
+
+	// Task errors returned by handlers classify themselves, except retryable
+	// internal errors, which are classified by their cause
+	if taskErr := types.AsTaskError(err); taskErr != nil {
+		switch taskErr.Code {
+		case types.ErrorCodeRateLimited:
+			return KindRateLimited
+		case types.ErrorCodeTimeout:
+			return KindRetryable
+		case types.ErrorCodeInvalidInput, types.ErrorCodeUnauthorized, types.ErrorCodeCapabilityUnsupported, types.ErrorCodeBudgetExhausted:
+			return KindUser
+		case types.ErrorCodeInternal:
+			if !taskErr.Retryable {
+				return KindTerminal
+			}
+		}
+	}
+
+	switch {
+	case errors.Is(err, types.ErrQuotaExceeded):
+		return KindRateLimited
+	case errors.Is(err, types.ErrInvalidTask),
+		errors.Is(err, types.ErrConsumerBlocked),
+		errors.Is(err, types.ErrInsufficientPermissions),
+		errors.Is(err, types.ErrResponseRejected),
+		errors.Is(err, types.ErrContentBlocked),
+		errors.Is(err, types.ErrPaymentRequired),
+		errors.Is(err, types.ErrPaymentInvalid),
+		errors.Is(err, types.ErrAccessDenied),
+		errors.Is(err, types.ErrBudgetExhausted):
+		return KindUser
+	case errors.Is(err, types.ErrAuthenticationFailed),
+		errors.Is(err, types.ErrSignatureInvalid),
+		errors.Is(err, types.ErrInvalidConfig),
+		errors.Is(err, types.ErrNotImplemented),
+		errors.Is(err, context.Canceled):
+		return KindTerminal
+	case errors.Is(err, types.ErrNetworkError),
+		errors.Is(err, types.ErrTaskTimeout),
+		errors.Is(err, context.DeadlineExceeded):
+		return KindRetryable
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return KindRetryable
+	}
+
+	return KindUnknown
+}
+
+// IsRetryable reports whether the operation that failed with err may be retried
+func IsRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+	switch KindOf(err) {
+	case KindUser, KindTerminal:
+		return false
+	default:
+		return true
+	}
+}
+
+// IsFailure reports whether err indicates the agent or its connection is unhealthy.
+// Circuit breakers count these; errors caused by the request or by rate limits don't trip them.
+func IsFailure(err error) bool {
+	if err == nil {
+		return false
+	}
+	switch KindOf(err) {
+	case KindUser, KindRateLimited:
+		return false
+	default:
+		return true
+	}
+}
+
+// RetryAfter returns the suggested delay before retrying err (0 when none was given)
+func RetryAfter(err error) time.Duration {
+	var classified *Error
+	if errors.As(err, &classified) {
+		return classified.RetryAfter
+	}
+	return 0
+}
+
+// TaskErrorOf returns how a task that failed with err is reported: the
+// *types.TaskError in its chain, or one classified from err
+func TaskErrorOf(err error) *types.TaskError {
+	if taskErr := types.AsTaskError(err); taskErr != nil {
+		return taskErr
+	}
+
+	code := types.ErrorCodeInternal
+	switch {
+	case errors.Is(err, types.ErrInvalidTask), errors.Is(err, types.ErrContentBlocked):
+		code = types.ErrorCodeInvalidInput
+	case errors.Is(err, types.ErrInsufficientPermissions),
+		errors.Is(err, types.ErrConsumerBlocked),
+		errors.Is(err, types.ErrPaymentRequired),
+		errors.Is(err, types.ErrPaymentInvalid),
+		errors.Is(err, types.ErrAccessDenied):
+		code = types.ErrorCodeUnauthorized
+	case errors.Is(err, types.ErrBudgetExhausted):
+		code = types.ErrorCodeBudgetExhausted
+	case errors.Is(err, types.ErrTaskTimeout), errors.Is(err, context.DeadlineExceeded):
+		code = types.ErrorCodeTimeout
+	case KindOf(err) == KindRateLimited:
+		code = types.ErrorCodeRateLimited
+	}
+
+	taskErr := &types.TaskError{Code: code, Message: err.Error(), Retryable: IsRetryable(err), Err: err}
+	if retryAfter := RetryAfter(err); retryAfter > 0 {
+		taskErr.WithDetail("retry_after", int(math.Ceil(retryAfter.Seconds())))
+	}
+	return taskErr
+}
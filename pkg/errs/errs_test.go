@@ -0,0 +1,108 @@
+package errs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/types"
+)
+
+func TestClassification(t *testing.T) {
+	base := errors.New("boom")
+
+	tests := []struct {
+		name      string
+		err       error
+		kind      Kind
+		retryable bool
+		failure   bool
+	}{
+		{"unclassified", base, KindUnknown, true, true},
+		{"retryable", Retryable(base), KindRetryable, true, true},
+		{"wrapped retryable", fmt.Errorf("send failed: %w", Retryable(base)), KindRetryable, true, true},
+		{"rate limited", RateLimited(base, time.Second), KindRateLimited, true, false},
+		{"user", User(base), KindUser, false, false},
+		{"terminal", Terminal(base), KindTerminal, false, true},
+		{"deadline", fmt.Errorf("handler: %w", context.DeadlineExceeded), KindRetryable, true, true},
+		{"canceled", context.Canceled, KindTerminal, false, true},
+		{"quota", types.ErrQuotaExceeded, KindRateLimited, true, false},
+		{"invalid task", types.ErrInvalidTask, KindUser, false, false},
+		{"auth failed", types.ErrAuthenticationFailed, KindTerminal, false, true},
+		{"explicit wins over sentinel", Terminal(types.ErrNetworkError), KindTerminal, false, true},
+		{"task error", types.InvalidInput("bad url"), KindUser, false, false},
+		{"rate limited task error", types.RateLimited(time.Second, "slow down"), KindRateLimited, true, false},
+		{"retryable internal task error", types.Internal(fmt.Errorf("upstream: %w", context.DeadlineExceeded)), KindRetryable, true, true},
+		{"terminal internal task error", &types.TaskError{Code: types.ErrorCodeInternal, Message: "broken"}, KindTerminal, false, true},
+		{"budget exhausted", types.NewBudget(types.TaskBudget{MaxLLMTokens: 10}).UseTokens(11), KindUser, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := KindOf(tt.err); got != tt.kind {
+				t.Errorf("KindOf = %v, want %v", got, tt.kind)
+			}
+			if got := IsRetryable(tt.err); got != tt.retryable {
+				t.Errorf("IsRetryable = %v, want %v", got, tt.retryable)
+			}
+			if got := IsFailure(tt.err); got != tt.failure {
+				t.Errorf("IsFailure = %v, want %v", got, tt.failure)
+			}
+		})
+	}
+}
+
+func TestNilErrors(t *testing.T) {
+	if Retryable(nil) != nil || User(nil) != nil {
+		t.Error("wrapping nil should return nil")
+	}
+	if IsRetryable(nil) || IsFailure(nil) {
+		t.Error("nil error should be neither retryable nor a failure")
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	err := fmt.Errorf("api: %w", RateLimited(errors.New("429"), 5*time.Second))
+	if got := RetryAfter(err); got != 5*time.Second {
+		t.Errorf("RetryAfter = %v, want 5s", got)
+	}
+	if got := RetryAfter(errors.New("plain")); got != 0 {
+		t.Errorf("RetryAfter = %v, want 0", got)
+	}
+}
+
+func TestTaskErrorOf(t *testing.T) {
+	tests := []struct {
+		name      string
+		err       error
+		code      types.ErrorCode
+		retryable bool
+	}{
+		{"handler task error", fmt.Errorf("wrapped: %w", types.Unauthorized("no access")), types.ErrorCodeUnauthorized, false},
+		{"invalid task", types.ErrInvalidTask, types.ErrorCodeInvalidInput, false},
+		{"deadline", fmt.Errorf("llm: %w", context.DeadlineExceeded), types.ErrorCodeTimeout, true},
+		{"quota", types.ErrQuotaExceeded, types.ErrorCodeRateLimited, true},
+		{"rate limited upstream", RateLimited(errors.New("429"), 3*time.Second), types.ErrorCodeRateLimited, true},
+		{"payment", types.ErrPaymentRequired, types.ErrorCodeUnauthorized, false},
+		{"budget", fmt.Errorf("llm: %w", types.ErrBudgetExhausted), types.ErrorCodeBudgetExhausted, false},
+		{"unclassified", errors.New("boom"), types.ErrorCodeInternal, true},
+		{"terminal", Terminal(errors.New("bad key")), types.ErrorCodeInternal, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			taskErr := TaskErrorOf(tt.err)
+			if taskErr.Code != tt.code || taskErr.Retryable != tt.retryable {
+				t.Errorf("TaskErrorOf = %s retryable %v, want %s retryable %v", taskErr.Code, taskErr.Retryable, tt.code, tt.retryable)
+			}
+			if taskErr.Error() == "" {
+				t.Error("task error has no message")
+			}
+		})
+	}
+
+	if got := TaskErrorOf(RateLimited(errors.New("429"), 3*time.Second)).Details["retry_after"]; got != 3 {
+		t.Errorf("retry_after = %v, want 3", got)
+	}
+}
@@ -0,0 +1,156 @@
+package events
+
+import (
+	"slices"
+	"sync"
+	"sync/atomic"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/logging"
+)
+
+// DefaultBuffer is how many events a subscriber may fall behind before events are dropped for it
+const DefaultBuffer = 64
+
+// Handler receives published events
+type Handler func(Event)
+
+// Subscription identifies a subscription for Unsubscribe
+type Subscription uint64
+
+// Bus delivers published events to subscribers. Each subscriber has its
+// own goroutine and receives events in the order they were published;
+// publishing never blocks, events are dropped for subscribers that fall
+// behind. A nil *Bus discards everything published to it.
+type Bus struct {
+	mu          sync.RWMutex
+	subscribers map[Subscription]*subscriber
+	nextID      Subscription
+	buffer      int
+	dropped     atomic.Int64
+}
+
+type subscriber struct {
+	handler Handler
+	types   []Type // Types delivered to the handler, all if empty
+	queue   chan Event
+	done    chan struct{}
+}
+
+// NewBus creates an event bus. buffer is how many events each subscriber
+// may fall behind (0 = DefaultBuffer).
+func NewBus(buffer int) *Bus {
+	if buffer <= 0 {
+		buffer = DefaultBuffer
+	}
+	return &Bus{
+		subscribers: make(map[Subscription]*subscriber),
+		buffer:      buffer,
+	}
+}
+
+// Subscribe calls handler for every published event of the given types, or
+// of every type if none are given, until Unsubscribe
+func (b *Bus) Subscribe(handler Handler, types ...Type) Subscription {
+	sub := &subscriber{
+		handler: handler,
+		types:   types,
+		queue:   make(chan Event, b.buffer),
+		done:    make(chan struct{}),
+	}
+
+	b.mu.Lock()
+	b.nextID++
+	id := b.nextID
+	b.subscribers[id] = sub
+	b.mu.Unlock()
+
+	go sub.run()
+	return id
+}
+
+// On calls fn for every published event of type T until Unsubscribe
+func On[T Event](b *Bus, fn func(T)) Subscription {
+	var zero T
+	return b.Subscribe(func(e Event) {
+		if event, ok := e.(T); ok {
+			fn(event)
+		}
+	}, zero.Type())
+}
+
+// Unsubscribe ends a subscription. Events still queued for it are discarded.
+// It reports whether the subscription existed.
+func (b *Bus) Unsubscribe(id Subscription) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	sub, ok := b.subscribers[id]
+	if !ok {
+		return false
+	}
+	delete(b.subscribers, id)
+	close(sub.done)
+	return true
+}
+
+// Close ends all subscriptions
+func (b *Bus) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for id, sub := range b.subscribers {
+		delete(b.subscribers, id)
+		close(sub.done)
+	}
+}
+
+// Publish delivers an event to the subscribers of its type
+func (b *Bus) Publish(event Event) {
+	if b == nil {
+		return
+	}
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for _, sub := range b.subscribers {
+		if len(sub.types) > 0 && !slices.Contains(sub.types, event.Type()) {
+			continue
+		}
+		select {
+		case sub.queue <- event:
+		default:
+			b.dropped.Add(1)
+			logging.Warn("dropping event for slow subscriber", "event", event.Type())
+		}
+	}
+}
+
+// Dropped returns how many events were dropped for slow subscribers
+func (b *Bus) Dropped() int64 {
+	return b.dropped.Load()
+}
+
+// run delivers queued events to the handler until the subscription ends
+func (s *subscriber) run() {
+	for {
+		select {
+		case <-s.done:
+			return
+		case event := <-s.queue:
+			select {
+			case <-s.done:
+				return
+			default:
+			}
+			s.deliver(event)
+		}
+	}
+}
+
+// deliver calls the handler, recovering from panics so one bad event does
+// not end the subscription
+func (s *subscriber) deliver(event Event) {
+	defer func() {
+		if r := recover(); r != nil {
+			logging.Error("panic in event handler", "event", event.Type(), "panic", r)
+		}
+	}()
+	s.handler(event)
+}
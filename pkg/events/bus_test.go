@@ -0,0 +1,128 @@
+package events
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func receive[T any](t *testing.T, ch <-chan T) T {
+	t.Helper()
+	select {
+	case v := <-ch:
+		return v
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for event")
+	}
+	var zero T
+	return zero
+}
+
+func TestBusDeliversInOrder(t *testing.T) {
+	bus := NewBus(0)
+	received := make(chan Event, 10)
+	bus.Subscribe(func(e Event) { received <- e })
+
+	bus.Publish(Connected{URL: "wss://example.com"})
+	bus.Publish(Registered{})
+	bus.Publish(TaskStarted{TaskID: "t1"})
+
+	want := []Type{TypeConnected, TypeRegistered, TypeTaskStarted}
+	for _, w := range want {
+		if got := receive(t, received); got.Type() != w {
+			t.Fatalf("got %s, want %s", got.Type(), w)
+		}
+	}
+}
+
+func TestBusFiltersByType(t *testing.T) {
+	bus := NewBus(0)
+	finished := make(chan TaskFinished, 10)
+	On(bus, func(e TaskFinished) { finished <- e })
+	circuit := make(chan Event, 10)
+	bus.Subscribe(func(e Event) { circuit <- e }, TypeCircuitChanged, TypeDisconnected)
+
+	bus.Publish(TaskStarted{TaskID: "t1"})
+	bus.Publish(TaskFinished{TaskID: "t1", Status: "error", Err: errors.New("boom")})
+	bus.Publish(CircuitChanged{From: "closed", To: "open"})
+
+	if got := receive(t, finished); got.TaskID != "t1" || got.Err == nil {
+		t.Errorf("got %+v", got)
+	}
+	if got := receive(t, circuit); got != (CircuitChanged{From: "closed", To: "open"}) {
+		t.Errorf("got %+v", got)
+	}
+	select {
+	case e := <-finished:
+		t.Errorf("unexpected event %+v", e)
+	case e := <-circuit:
+		t.Errorf("unexpected event %+v", e)
+	case <-time.After(20 * time.Millisecond):
+	}
+}
+
+func TestBusUnsubscribe(t *testing.T) {
+	bus := NewBus(0)
+	received := make(chan Event, 10)
+	sub := bus.Subscribe(func(e Event) { received <- e })
+
+	if !bus.Unsubscribe(sub) {
+		t.Fatal("expected the subscription to exist")
+	}
+	if bus.Unsubscribe(sub) {
+		t.Error("expected the second unsubscribe to report false")
+	}
+	bus.Publish(Registered{})
+	select {
+	case e := <-received:
+		t.Errorf("unexpected event after unsubscribe: %+v", e)
+	case <-time.After(20 * time.Millisecond):
+	}
+}
+
+func TestBusDropsForSlowSubscribers(t *testing.T) {
+	bus := NewBus(1)
+	block := make(chan struct{})
+	defer close(block)
+	started := make(chan struct{}, 1)
+	bus.Subscribe(func(e Event) {
+		started <- struct{}{}
+		<-block
+	})
+	fast := make(chan Event, 10)
+	bus.Subscribe(func(e Event) { fast <- e })
+
+	// Each event is delivered to the fast subscriber before the next is
+	// published, so only the slow subscriber's one-event buffer can overflow
+	bus.Publish(Registered{})
+	receive(t, started)
+	receive(t, fast)
+	// One event fits the slow subscriber's buffer, the next is dropped
+	bus.Publish(Registered{})
+	receive(t, fast)
+	bus.Publish(Registered{})
+	receive(t, fast)
+	if bus.Dropped() != 1 {
+		t.Errorf("expected 1 dropped event, got %d", bus.Dropped())
+	}
+}
+
+func TestBusRecoversFromPanics(t *testing.T) {
+	bus := NewBus(0)
+	received := make(chan Event, 10)
+	bus.Subscribe(func(e Event) {
+		if _, ok := e.(Connected); ok {
+			panic("bad handler")
+		}
+		received <- e
+	})
+
+	bus.Publish(Connected{})
+	bus.Publish(Registered{})
+	if got := receive(t, received); got.Type() != TypeRegistered {
+		t.Errorf("got %s", got.Type())
+	}
+
+	var nilBus *Bus
+	nilBus.Publish(Registered{}) // must not panic
+}
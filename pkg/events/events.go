@@ -0,0 +1,184 @@
+// Package events publishes agent lifecycle events, such as connection
+// changes, authentication and task execution, to subscribers:
+//
+//	bus := enhancedAgent.Events()
+//	sub := events.On(bus, func(e events.TaskFinished) {
+//		log.Printf("task %s finished in %s: %s", e.TaskID, e.Duration, e.Status)
+//	})
+//	defer bus.Unsubscribe(sub)
+package events
+
+import (
+	"time"
+)
+
+// Type identifies the kind of an event
+type Type string
+
+const (
+	TypeConnected           Type = "connected"
+	TypeDisconnected        Type = "disconnected"
+	TypeReconnecting        Type = "reconnecting"
+	TypeReconnected         Type = "reconnected"
+	TypeAuthenticated       Type = "authenticated"
+	TypeAuthFailed          Type = "auth_failed"
+	TypeSessionExpired      Type = "session_expired"
+	TypeRegistered          Type = "registered"
+	TypeTaskStarted         Type = "task_started"
+	TypeTaskFinished        Type = "task_finished"
+	TypeDeadlineAtRisk      Type = "deadline_at_risk"
+	TypeCircuitChanged      Type = "circuit_changed"
+	TypeDuplicateConnection Type = "duplicate_connection"
+	TypeOperatorCommand     Type = "operator_command"
+	TypeCapabilitiesChanged Type = "capabilities_changed"
+	TypeOwnershipChanged    Type = "ownership_changed"
+	TypeMessageDeadLettered Type = "message_dead_lettered"
+)
+
+// Event is a lifecycle event. The concrete types are the structs in this package.
+type Event interface {
+	Type() Type
+}
+
+// Connected is published when the agent has connected to the network
+type Connected struct {
+	URL string
+}
+
+// Disconnected is published when the connection is closed or lost
+type Disconnected struct {
+	Err          error // Why the connection was lost, nil when the agent disconnected itself
+	Reconnecting bool  // The agent is going to reconnect
+}
+
+// Reconnecting is published before every reconnection attempt
+type Reconnecting struct {
+	Attempt     int
+	MaxAttempts int           // 0 = unlimited
+	Delay       time.Duration // Wait before the attempt
+	GaveUp      bool          // No attempts are left; the agent stays disconnected
+}
+
+// Reconnected is published when a lost connection has been re-established.
+// The agent authenticates again before it receives tasks.
+type Reconnected struct {
+	Attempts int
+}
+
+// Authenticated is published when the agent has a session, also after a refresh
+type Authenticated struct {
+	ExpiresAt time.Time // Zero if the session has no known expiry
+}
+
+// AuthFailed is published when the server rejects the agent's authentication
+type AuthFailed struct {
+	Reason string
+}
+
+// SessionExpired is published when the session expired or the server no longer accepts it
+type SessionExpired struct {
+	Reason string
+}
+
+// Registered is published when the agent is registered and can receive tasks
+type Registered struct{}
+
+// TaskStarted is published when the agent starts executing a task
+type TaskStarted struct {
+	TaskID string
+	Room   string
+	Sender string
+}
+
+// TaskFinished is published when a task has finished executing
+type TaskFinished struct {
+	TaskID   string
+	Room     string
+	Status   string // "success", "error", "rejected" or "preempted" (the task runs again later)
+	Duration time.Duration
+	Err      error // The handler's error when Status is "error"
+}
+
+// DeadlineAtRisk is published when a task is not expected to finish before
+// the deadline set by the server, based on how long recent tasks took
+type DeadlineAtRisk struct {
+	TaskID    string
+	Room      string
+	Deadline  time.Time
+	Estimated time.Duration // Expected run time of the task
+}
+
+// CircuitChanged is published when the circuit breaker of a message class
+// changes state: "closed", "open" or "half-open"
+type CircuitChanged struct {
+	Breaker string // Message class, e.g. "auth" or "task_response"
+	From    string
+	To      string
+}
+
+// DuplicateConnection is published when another process connects with the
+// agent's wallet or NFT, reported by the server or seen in the agents list
+type DuplicateConnection struct {
+	Source        string // "server" or "presence"
+	Detail        string // The server's message
+	OtherInstance string // Instance ID of the other process, if known
+	Policy        string // What the agent does: "alert", "yield" or "takeover"
+}
+
+// OperatorCommand is published when a command signed by an operator wallet
+// was run, e.g. to audit remote log level changes
+type OperatorCommand struct {
+	Command  string
+	Operator string // Address of the wallet that signed the command
+	Err      error  // Why the command failed, nil on success
+}
+
+// CapabilitiesChanged is published when capabilities are added or removed at
+// runtime. Tasks still running that require a removed capability are listed
+// in Orphaned so they can be finished or cancelled before the capability's
+// backend goes away.
+type CapabilitiesChanged struct {
+	Added    []string
+	Removed  []string
+	Orphaned []string // IDs of running tasks no remaining capability can serve
+}
+
+// OwnershipChanged is published when the agent's NFT is transferred, or
+// found owned by another wallet on start. An agent that lost its NFT keeps
+// the credentials of its wallet, which the network may no longer accept.
+type OwnershipChanged struct {
+	TokenID string
+	From    string // Previous owner, empty when found on start
+	To      string // New owner, the zero address when the NFT was burned
+	TxHash  string // Transaction of the transfer, empty when found on start
+	Lost    bool   // The agent's wallet no longer owns the NFT
+	Policy  string // What the agent does: "alert", "stop" or "reregister"
+}
+
+// MessageDeadLettered is published when the retry queue gives up on a
+// message, e.g. a task response, and moves it to the dead-letter queue
+type MessageDeadLettered struct {
+	ID          string // Dead letter ID, to inspect or re-enqueue it
+	MessageType string
+	TaskID      string
+	Reason      string // "max_retries" or "not_retryable"
+	Err         error  // Last send error
+}
+
+func (Connected) Type() Type           { return TypeConnected }
+func (Disconnected) Type() Type        { return TypeDisconnected }
+func (Reconnecting) Type() Type        { return TypeReconnecting }
+func (Reconnected) Type() Type         { return TypeReconnected }
+func (Authenticated) Type() Type       { return TypeAuthenticated }
+func (AuthFailed) Type() Type          { return TypeAuthFailed }
+func (SessionExpired) Type() Type      { return TypeSessionExpired }
+func (Registered) Type() Type          { return TypeRegistered }
+func (TaskStarted) Type() Type         { return TypeTaskStarted }
+func (TaskFinished) Type() Type        { return TypeTaskFinished }
+func (DeadlineAtRisk) Type() Type      { return TypeDeadlineAtRisk }
+func (CircuitChanged) Type() Type      { return TypeCircuitChanged }
+func (DuplicateConnection) Type() Type { return TypeDuplicateConnection }
+func (OperatorCommand) Type() Type     { return TypeOperatorCommand }
+func (CapabilitiesChanged) Type() Type { return TypeCapabilitiesChanged }
+func (OwnershipChanged) Type() Type    { return TypeOwnershipChanged }
+func (MessageDeadLettered) Type() Type { return TypeMessageDeadLettered }
@@ -0,0 +1,141 @@
+package format
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"strings"
+)
+
+// CSVOptions configures reading and writing CSV
+type CSVOptions struct {
+	Delimiter      rune // Field delimiter (0 = detect among , ; tab and | when reading, comma when writing)
+	NoHeader       bool // There is no header row; columns are named column_1, column_2, ...
+	EscapeFormulas bool // Prefix values starting with = + - @ with a quote when writing, so spreadsheets don't run them
+}
+
+// ParseCSV parses CSV into a table of strings. Rows may have different
+// lengths; the table is as wide as the longest one. opts may be nil.
+func ParseCSV(input string, opts *CSVOptions) (*Table, error) {
+	if opts == nil {
+		opts = &CSVOptions{}
+	}
+	reader := csv.NewReader(strings.NewReader(input))
+	reader.Comma = opts.Delimiter
+	if reader.Comma == 0 {
+		reader.Comma = DetectDelimiter(input)
+	}
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	reader.LazyQuotes = true
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV: %w", err)
+	}
+	if len(records) == 0 {
+		return nil, ErrEmpty
+	}
+
+	width := 0
+	for _, record := range records {
+		width = max(width, len(record))
+	}
+	table := &Table{}
+	if opts.NoHeader {
+		table.Columns = columnNames(nil, width)
+	} else {
+		table.Columns = columnNames(records[0], width)
+		records = records[1:]
+	}
+	table.Rows = make([][]any, len(records))
+	for r, record := range records {
+		table.Rows[r] = make([]any, width)
+		for i := range width {
+			value := ""
+			if i < len(record) {
+				value = strings.TrimSpace(record[i])
+			}
+			table.Rows[r][i] = value
+		}
+	}
+	return table, nil
+}
+
+// CSV renders the table as CSV. Values are quoted where needed and empty
+// values are written as empty fields. opts may be nil.
+func (t *Table) CSV(opts *CSVOptions) string {
+	if opts == nil {
+		opts = &CSVOptions{}
+	}
+	var out bytes.Buffer
+	writer := csv.NewWriter(&out)
+	if opts.Delimiter != 0 {
+		writer.Comma = opts.Delimiter
+	}
+	record := make([]string, t.Width())
+	if !opts.NoHeader {
+		for i, name := range t.Columns {
+			record[i] = csvField(name, opts.EscapeFormulas)
+		}
+		writer.Write(record)
+	}
+	for row := range t.Rows {
+		for column := range record {
+			text, _ := cellText(t.Cell(row, column))
+			record[column] = csvField(text, opts.EscapeFormulas)
+		}
+		writer.Write(record)
+	}
+	writer.Flush()
+	return out.String()
+}
+
+// csvField escapes a value that a spreadsheet would run as a formula
+func csvField(value string, escapeFormulas bool) string {
+	if escapeFormulas && value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) && !isNumberText(value) {
+		return "'" + value
+	}
+	return value
+}
+
+// DetectDelimiter picks the delimiter among , ; tab and | that splits the
+// first lines of a CSV most consistently, comma if none does
+func DetectDelimiter(input string) rune {
+	lines := strings.SplitN(input, "\n", 6)
+	if len(lines) > 5 {
+		lines = lines[:5]
+	}
+	best, bestScore := ',', 0
+	for _, candidate := range []rune{',', ';', '\t', '|'} {
+		count := countUnquoted(lines[0], candidate)
+		if count == 0 {
+			continue
+		}
+		score := count
+		for _, line := range lines[1:] {
+			if strings.TrimSpace(line) != "" && countUnquoted(line, candidate) != count {
+				score = 0
+				break
+			}
+		}
+		if score > bestScore {
+			best, bestScore = candidate, score
+		}
+	}
+	return best
+}
+
+// countUnquoted counts the occurrences of r outside double-quoted fields
+func countUnquoted(line string, r rune) int {
+	count, quoted := 0, false
+	for _, c := range line {
+		switch {
+		case c == '"':
+			quoted = !quoted
+		case c == r && !quoted:
+			count++
+		}
+	}
+	return count
+}
@@ -0,0 +1,135 @@
+package format
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/types"
+)
+
+// Format is a tabular data format
+type Format string
+
+const (
+	JSON     Format = "json"
+	CSV      Format = "csv"
+	Markdown Format = "markdown"
+)
+
+// ErrEmpty is returned when the input holds no data
+var ErrEmpty = errors.New("no data given")
+
+// ParseFormat returns the format with the given name. Besides the format
+// names it accepts "md", "table" for markdown and "tsv" for CSV.
+func ParseFormat(name string) (Format, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "json":
+		return JSON, nil
+	case "csv", "tsv":
+		return CSV, nil
+	case "markdown", "md", "table":
+		return Markdown, nil
+	}
+	return "", fmt.Errorf("unknown format %q, expected json, csv or markdown", name)
+}
+
+// ContentType returns the message content type to send data in the format with
+func (f Format) ContentType() string {
+	switch f {
+	case JSON:
+		return types.StandardMessageTypeJSON
+	case CSV:
+		return types.StandardMessageTypeCSV
+	case Markdown:
+		return types.StandardMessageTypeTable
+	}
+	return types.StandardMessageTypeString
+}
+
+// Detect guesses the format of the input: JSON if it starts like a JSON
+// array or object, markdown if it contains a pipe table, CSV otherwise
+func Detect(input string) Format {
+	input = StripCodeFence(input)
+	if strings.HasPrefix(input, "[") || strings.HasPrefix(input, "{") {
+		return JSON
+	}
+	lines := strings.Split(input, "\n")
+	for i := 0; i+1 < len(lines); i++ {
+		if isTableRow(lines[i]) && isDelimiterRow(lines[i+1]) {
+			return Markdown
+		}
+	}
+	return CSV
+}
+
+// Parse parses the input in the given format, detecting it if from is empty.
+// A code fence around the input is removed first.
+func Parse(input string, from Format) (*Table, error) {
+	input = StripCodeFence(input)
+	if input == "" {
+		return nil, ErrEmpty
+	}
+	if from == "" {
+		from = Detect(input)
+	}
+
+	var table *Table
+	var err error
+	switch from {
+	case JSON:
+		table, err = ParseJSON(input)
+	case CSV:
+		table, err = ParseCSV(input, nil)
+	case Markdown:
+		table, err = ParseMarkdown(input)
+	default:
+		return nil, fmt.Errorf("unknown format %q", from)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if table.Width() == 0 {
+		return nil, ErrEmpty
+	}
+	return table, nil
+}
+
+// Render renders the table in the given format
+func (t *Table) Render(to Format) (string, error) {
+	switch to {
+	case JSON:
+		return t.JSON(), nil
+	case CSV:
+		return t.CSV(nil), nil
+	case Markdown:
+		return t.Markdown(), nil
+	}
+	return "", fmt.Errorf("unknown format %q", to)
+}
+
+// Convert converts tabular data from one format to another, detecting the
+// input format if from is empty. Column types are inferred, so numbers and
+// booleans from CSV or markdown become JSON numbers and booleans.
+func Convert(input string, from, to Format) (string, error) {
+	table, err := Parse(input, from)
+	if err != nil {
+		return "", err
+	}
+	return table.Typed().Render(to)
+}
+
+// StripCodeFence removes a markdown code fence around the input, as chat
+// clients often add one when pasting data
+func StripCodeFence(input string) string {
+	input = strings.TrimSpace(input)
+	if !strings.HasPrefix(input, "```") {
+		return input
+	}
+	if newline := strings.IndexByte(input, '\n'); newline >= 0 {
+		input = input[newline+1:]
+	} else {
+		input = strings.TrimPrefix(input, "```")
+	}
+	return strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(input), "```"))
+}
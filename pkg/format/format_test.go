@@ -0,0 +1,182 @@
+package format
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/types"
+)
+
+func TestSchema(t *testing.T) {
+	tests := []struct {
+		values []any
+		want   ColumnType
+	}{
+		{[]any{"1", "-2", ""}, TypeInteger},
+		{[]any{"1", "2.5", "1e3"}, TypeNumber},
+		{[]any{"007"}, TypeString},
+		{[]any{"12345678901234567890"}, TypeString},
+		{[]any{".5"}, TypeString},
+		{[]any{" 5"}, TypeString},
+		{[]any{"True", "false"}, TypeBoolean},
+		{[]any{true, "false", nil}, TypeBoolean},
+		{[]any{"1", "yes"}, TypeString},
+		{[]any{json.Number("3"), "4"}, TypeInteger},
+		{[]any{"", nil}, TypeNull},
+	}
+	for _, tt := range tests {
+		table := &Table{Columns: []string{"a"}}
+		for _, value := range tt.values {
+			table.Rows = append(table.Rows, []any{value})
+		}
+		if got := table.Schema()[0]; got != tt.want {
+			t.Errorf("%q: got %s, want %s", tt.values, got, tt.want)
+		}
+	}
+}
+
+func TestParseJSON(t *testing.T) {
+	table, err := ParseJSON(`[{"name":"Ada","tags":["math"],"age":36},{"name":"Alan","born":1912,"age":null}]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"name", "tags", "age", "born"}; !reflect.DeepEqual(table.Columns, want) {
+		t.Errorf("columns: got %v, want %v", table.Columns, want)
+	}
+	if got := table.Cell(0, 1); got != `["math"]` {
+		t.Errorf("nested values should be compact JSON, got %v", got)
+	}
+	if got := table.Cell(1, 2); got != nil {
+		t.Errorf("null: got %v", got)
+	}
+	if got := table.Cell(0, 3); got != nil {
+		t.Errorf("missing key: got %v", got)
+	}
+
+	table, err = ParseJSON(`[["id","ok"],[1,true],[2]]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(table.Rows) != 2 || table.Columns[1] != "ok" || table.Cell(0, 1) != true || table.Cell(1, 1) != nil {
+		t.Errorf("array of arrays: got %v %v", table.Columns, table.Rows)
+	}
+
+	table, err = ParseJSON(`[1, 2.5]`)
+	if err != nil || table.Columns[0] != "value" || table.Cell(1, 0) != json.Number("2.5") {
+		t.Errorf("array of scalars: got %v, %v", table, err)
+	}
+
+	for _, input := range []string{`"text"`, `[1, {"a": 1}]`, `[{"a": 1}, 2]`, `[`, `[]`} {
+		if _, err := ParseJSON(input); err == nil {
+			t.Errorf("%s: expected error", input)
+		}
+	}
+}
+
+func TestParseMarkdown(t *testing.T) {
+	input := "Here are the results:\n\n| Name | Note |\n|:-----|-----:|\n| Ada | a \\| b |\n| Alan |\n\nThat's all."
+	table, err := ParseMarkdown(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]any{{"Ada", "a | b"}, {"Alan", ""}}
+	if !reflect.DeepEqual(table.Rows, want) || !reflect.DeepEqual(table.Columns, []string{"Name", "Note"}) {
+		t.Errorf("got %v %v", table.Columns, table.Rows)
+	}
+
+	if _, err := ParseMarkdown("a | b\nc | d"); err == nil {
+		t.Error("expected error without a delimiter row")
+	}
+}
+
+func TestMarkdown(t *testing.T) {
+	table := &Table{
+		Columns: []string{"item", "qty"},
+		Rows:    [][]any{{"pipe|and\nnewline", "12"}, {"x", "3"}},
+	}
+	want := "| item                 | qty |\n" +
+		"| -------------------- | --: |\n" +
+		"| pipe\\|and<br>newline |  12 |\n" +
+		"| x                    |   3 |"
+	if got := table.Markdown(); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+
+	parsed, err := ParseMarkdown(want)
+	if err != nil || !reflect.DeepEqual(parsed.Rows, table.Rows) {
+		t.Errorf("round trip: got %v, %v", parsed, err)
+	}
+}
+
+func TestCSV(t *testing.T) {
+	table := &Table{
+		Columns: []string{"formula", "text", "n"},
+		Rows:    [][]any{{"=SUM(A1:A2)", "a, \"b\"", json.Number("-1")}, {"@cmd", nil, true}},
+	}
+	want := "formula,text,n\n'=SUM(A1:A2),\"a, \"\"b\"\"\",-1\n'@cmd,,true\n"
+	if got := table.CSV(&CSVOptions{EscapeFormulas: true}); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if got := table.CSV(&CSVOptions{Delimiter: ';', NoHeader: true}); got != "=SUM(A1:A2);\"a, \"\"b\"\"\";-1\n@cmd;;true\n" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestConvert(t *testing.T) {
+	tests := []struct {
+		input    string
+		from, to Format
+		want     string
+	}{
+		{"```csv\nb,a\n1,x\n```", "", JSON, "[\n  {\n    \"b\": 1,\n    \"a\": \"x\"\n  }\n]"},
+		{`[{"city":"Paris","pop":2.1}]`, "", CSV, "city,pop\nParis,2.1\n"},
+		{"| on | n |\n|---|---|\n| TRUE | 5 |", "", JSON, "[\n  {\n    \"on\": true,\n    \"n\": 5\n  }\n]"},
+		{`{"a": "<b>"}`, JSON, Markdown, "| a   |\n| --- |\n| <b> |"},
+	}
+	for _, tt := range tests {
+		got, err := Convert(tt.input, tt.from, tt.to)
+		if err != nil {
+			t.Errorf("%q: %v", tt.input, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%q: got %q, want %q", tt.input, got, tt.want)
+		}
+	}
+
+	if _, err := Convert(" ", "", JSON); err != ErrEmpty {
+		t.Errorf("expected ErrEmpty, got %v", err)
+	}
+	if _, err := Convert(`[{}]`, JSON, CSV); err != ErrEmpty {
+		t.Errorf("expected ErrEmpty for a table without columns, got %v", err)
+	}
+}
+
+func TestDetectAndParseFormat(t *testing.T) {
+	tests := map[string]Format{
+		`[{"a":1}]`:           JSON,
+		" {\"a\":1}":          JSON,
+		"a|b\n-|-\n1|2":       Markdown,
+		"a;b\n1;2":            CSV,
+		"```\n[1]\n```":       JSON,
+		"just a line of text": CSV,
+	}
+	for input, want := range tests {
+		if got := Detect(input); got != want {
+			t.Errorf("%q: got %s, want %s", input, got, want)
+		}
+	}
+
+	for name, want := range map[string]Format{"JSON": JSON, "md": Markdown, "table": Markdown, "tsv": CSV} {
+		if got, err := ParseFormat(name); err != nil || got != want {
+			t.Errorf("%s: got %s, %v", name, got, err)
+		}
+	}
+	if _, err := ParseFormat("xml"); err == nil {
+		t.Error("expected error for unknown format")
+	}
+	if Markdown.ContentType() != types.StandardMessageTypeTable || CSV.ContentType() != types.StandardMessageTypeCSV {
+		t.Error("unexpected content types")
+	}
+}
@@ -0,0 +1,202 @@
+package format
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// ParseJSON parses JSON into a table. It accepts an array of objects, whose
+// keys become the columns in the order they are first seen, an array of
+// arrays, whose first array is the header, an array of scalars, which becomes
+// a single "value" column, or a single object, which becomes one row. Nested
+// objects and arrays are kept as compact JSON text.
+func ParseJSON(input string) (*Table, error) {
+	var raw json.RawMessage
+	if err := json.Unmarshal([]byte(input), &raw); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+
+	switch firstByte(raw) {
+	case '{':
+		table := &Table{}
+		if err := table.addObject(raw, map[string]int{}); err != nil {
+			return nil, err
+		}
+		return table, nil
+	case '[':
+	default:
+		return nil, fmt.Errorf("JSON must be an array or an object to form a table")
+	}
+
+	var items []json.RawMessage
+	if err := json.Unmarshal(raw, &items); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+	if len(items) == 0 {
+		return nil, ErrEmpty
+	}
+
+	table := &Table{}
+	switch firstByte(items[0]) {
+	case '{':
+		columns := map[string]int{}
+		for i, item := range items {
+			if firstByte(item) != '{' {
+				return nil, fmt.Errorf("item %d is not an object", i+1)
+			}
+			if err := table.addObject(item, columns); err != nil {
+				return nil, err
+			}
+		}
+	case '[':
+		var rows [][]json.RawMessage
+		if err := json.Unmarshal(raw, &rows); err != nil {
+			return nil, fmt.Errorf("array of arrays mixed with other values: %w", err)
+		}
+		header := make([]string, len(rows[0]))
+		for i, value := range rows[0] {
+			text, _ := cellText(jsonValue(value))
+			header[i] = text
+		}
+		width := 0
+		for _, row := range rows {
+			width = max(width, len(row))
+		}
+		table.Columns = columnNames(header, width)
+		for _, row := range rows[1:] {
+			cells := make([]any, width)
+			for i, value := range row {
+				cells[i] = jsonValue(value)
+			}
+			table.Rows = append(table.Rows, cells)
+		}
+	default:
+		table.Columns = []string{"value"}
+		for i, item := range items {
+			if b := firstByte(item); b == '{' || b == '[' {
+				return nil, fmt.Errorf("item %d is not a scalar like the first one", i+1)
+			}
+			table.Rows = append(table.Rows, []any{jsonValue(item)})
+		}
+	}
+	return table, nil
+}
+
+// addObject appends an object as a row, adding a column for every new key.
+// columns maps keys to their column and is shared between rows.
+func (t *Table) addObject(raw json.RawMessage, columns map[string]int) error {
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	if _, err := decoder.Token(); err != nil {
+		return fmt.Errorf("invalid JSON: %w", err)
+	}
+
+	row := make([]any, len(t.Columns))
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return fmt.Errorf("invalid JSON: %w", err)
+		}
+		key := token.(string)
+		var value json.RawMessage
+		if err := decoder.Decode(&value); err != nil {
+			return fmt.Errorf("invalid JSON: %w", err)
+		}
+
+		column, ok := columns[key]
+		if !ok {
+			column = len(t.Columns)
+			columns[key] = column
+			t.Columns = append(t.Columns, key)
+			row = append(row, nil)
+		}
+		row[column] = jsonValue(value)
+	}
+	t.Rows = append(t.Rows, row)
+	return nil
+}
+
+// jsonValue converts a JSON value to a cell
+func jsonValue(raw json.RawMessage) any {
+	switch b := firstByte(raw); {
+	case b == 'n':
+		return nil
+	case b == 't' || b == 'f':
+		return b == 't'
+	case b == '"':
+		var s string
+		json.Unmarshal(raw, &s)
+		return s
+	case b == '{' || b == '[':
+		var compact bytes.Buffer
+		json.Compact(&compact, raw)
+		return compact.String()
+	}
+	return json.Number(bytes.TrimSpace(raw))
+}
+
+func firstByte(raw json.RawMessage) byte {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 {
+		return 0
+	}
+	return raw[0]
+}
+
+// JSON renders the table as an indented JSON array with one object per row,
+// keeping the columns in order. Empty values are written as null.
+func (t *Table) JSON() string {
+	if len(t.Rows) == 0 {
+		return "[]"
+	}
+	var out strings.Builder
+	out.WriteString("[")
+	for row := range t.Rows {
+		if row > 0 {
+			out.WriteString(",")
+		}
+		out.WriteString("\n  {")
+		for column, name := range t.Columns {
+			if column > 0 {
+				out.WriteString(",")
+			}
+			out.WriteString("\n    ")
+			out.WriteString(jsonString(name))
+			out.WriteString(": ")
+			out.WriteString(encodeCell(t.Cell(row, column)))
+		}
+		out.WriteString("\n  }")
+	}
+	out.WriteString("\n]")
+	return out.String()
+}
+
+// encodeCell returns a cell as a JSON value
+func encodeCell(value any) string {
+	if isEmpty(value) {
+		return "null"
+	}
+	switch v := value.(type) {
+	case bool, json.Number:
+		text, _ := cellText(v)
+		return text
+	case string:
+		return jsonString(v)
+	}
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return jsonString(fmt.Sprint(value))
+	}
+	return string(encoded)
+}
+
+// jsonString quotes s as a JSON string without escaping HTML characters
+func jsonString(s string) string {
+	var out bytes.Buffer
+	encoder := json.NewEncoder(&out)
+	encoder.SetEscapeHTML(false)
+	encoder.Encode(s)
+	return strings.TrimSuffix(out.String(), "\n")
+}
@@ -0,0 +1,158 @@
+package format
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// ParseMarkdown parses a markdown pipe table into a table of strings. Text
+// around the table is ignored, so a table can be taken from a longer reply.
+// Escaped pipes (\|) are unescaped and <br> becomes a newline.
+func ParseMarkdown(input string) (*Table, error) {
+	lines := strings.Split(input, "\n")
+	start := -1
+	for i := 0; i+1 < len(lines); i++ {
+		if isTableRow(lines[i]) && isDelimiterRow(lines[i+1]) {
+			start = i
+			break
+		}
+	}
+	if start < 0 {
+		return nil, fmt.Errorf("no markdown table found, expected a header row followed by a |---| row")
+	}
+
+	header := splitRow(lines[start])
+	table := &Table{Columns: columnNames(header, len(header))}
+	for _, line := range lines[start+2:] {
+		if !isTableRow(line) {
+			break
+		}
+		cells := splitRow(line)
+		row := make([]any, table.Width())
+		for i := range row {
+			row[i] = ""
+			if i < len(cells) {
+				row[i] = cells[i]
+			}
+		}
+		table.Rows = append(table.Rows, row)
+	}
+	return table, nil
+}
+
+func isTableRow(line string) bool {
+	return strings.Contains(strings.TrimSpace(line), "|")
+}
+
+// isDelimiterRow reports whether line is the row of dashes under the header
+func isDelimiterRow(line string) bool {
+	cells := splitRow(line)
+	if len(cells) == 0 {
+		return false
+	}
+	for _, cell := range cells {
+		cell = strings.TrimSuffix(strings.TrimPrefix(cell, ":"), ":")
+		if cell == "" || strings.Trim(cell, "-") != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// splitRow splits a table row into its trimmed cells
+func splitRow(line string) []string {
+	line = strings.TrimSpace(line)
+	line = strings.TrimPrefix(line, "|")
+	if strings.HasSuffix(line, "|") && !strings.HasSuffix(line, `\|`) {
+		line = line[:len(line)-1]
+	}
+
+	var cells []string
+	var cell strings.Builder
+	for i := 0; i < len(line); i++ {
+		switch {
+		case line[i] == '\\' && i+1 < len(line) && line[i+1] == '|':
+			cell.WriteByte('|')
+			i++
+		case line[i] == '|':
+			cells = append(cells, unescapeCell(cell.String()))
+			cell.Reset()
+		default:
+			cell.WriteByte(line[i])
+		}
+	}
+	return append(cells, unescapeCell(cell.String()))
+}
+
+func unescapeCell(cell string) string {
+	cell = strings.TrimSpace(cell)
+	for _, br := range []string{"<br>", "<br/>", "<br />"} {
+		cell = strings.ReplaceAll(cell, br, "\n")
+	}
+	return cell
+}
+
+// Markdown renders the table as a markdown pipe table with padded columns.
+// Numeric columns are right-aligned, pipes are escaped and newlines in values
+// become <br> so every row stays on one line.
+func (t *Table) Markdown() string {
+	schema := t.Schema()
+	cells := make([][]string, len(t.Rows)+1)
+	cells[0] = make([]string, t.Width())
+	for i, name := range t.Columns {
+		cells[0][i] = escapeCell(name)
+	}
+	for row := range t.Rows {
+		cells[row+1] = make([]string, t.Width())
+		for column := range t.Columns {
+			text, _ := cellText(t.Cell(row, column))
+			cells[row+1][column] = escapeCell(text)
+		}
+	}
+
+	widths := make([]int, t.Width())
+	for _, row := range cells {
+		for i, cell := range row {
+			widths[i] = max(widths[i], utf8.RuneCountInString(cell), 3)
+		}
+	}
+
+	var out strings.Builder
+	writeRow := func(row []string) {
+		out.WriteString("|")
+		for i, cell := range row {
+			padding := strings.Repeat(" ", widths[i]-utf8.RuneCountInString(cell))
+			out.WriteString(" ")
+			if schema[i] == TypeInteger || schema[i] == TypeNumber {
+				out.WriteString(padding + cell)
+			} else {
+				out.WriteString(cell + padding)
+			}
+			out.WriteString(" |")
+		}
+		out.WriteString("\n")
+	}
+
+	writeRow(cells[0])
+	out.WriteString("|")
+	for i, width := range widths {
+		if schema[i] == TypeInteger || schema[i] == TypeNumber {
+			out.WriteString(" " + strings.Repeat("-", width-1) + ": |")
+		} else {
+			out.WriteString(" " + strings.Repeat("-", width) + " |")
+		}
+	}
+	out.WriteString("\n")
+	for _, row := range cells[1:] {
+		writeRow(row)
+	}
+	return strings.TrimSuffix(out.String(), "\n")
+}
+
+// escapeCell makes a value safe to put in a table cell
+func escapeCell(text string) string {
+	text = strings.ReplaceAll(text, "|", `\|`)
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	return strings.ReplaceAll(strings.TrimSpace(text), "\n", "<br>")
+}
@@ -0,0 +1,231 @@
+// Package format converts tabular data between JSON, CSV and markdown
+// tables. Input is parsed into a Table, whose column types are inferred from
+// the values, and rendered in any of the formats:
+//
+//	table, err := format.Parse(input, format.CSV)
+//	markdown := table.Typed().Markdown()
+//
+// Convert does both in one step and Detect guesses the format of an input.
+// The results can be sent with the TABLE and CSV content types of
+// types.TabularSender.
+package format
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// ColumnType is the inferred type of a column
+type ColumnType string
+
+const (
+	TypeString  ColumnType = "string"
+	TypeInteger ColumnType = "integer"
+	TypeNumber  ColumnType = "number"
+	TypeBoolean ColumnType = "boolean"
+	TypeNull    ColumnType = "null" // Every value is empty
+)
+
+// maxIntegerDigits is the longest integer kept as a number; longer ones lose
+// precision in JSON parsers that use floats and stay strings
+const maxIntegerDigits = 15
+
+// Table is tabular data. Cells hold nil, a string, a bool or a json.Number;
+// cells parsed from CSV and markdown are strings until Typed converts them.
+type Table struct {
+	Columns []string
+	Rows    [][]any
+}
+
+// Width returns the number of columns
+func (t *Table) Width() int {
+	return len(t.Columns)
+}
+
+// Cell returns the value at a row and column, nil for cells missing from short rows
+func (t *Table) Cell(row, column int) any {
+	if column >= len(t.Rows[row]) {
+		return nil
+	}
+	return t.Rows[row][column]
+}
+
+// Schema infers the type of every column. A column is numeric or boolean
+// only if every non-empty value in it is; integers with leading zeros, such
+// as zip codes and IDs, count as strings.
+func (t *Table) Schema() []ColumnType {
+	schema := make([]ColumnType, t.Width())
+	for column := range schema {
+		schema[column] = t.inferColumn(column)
+	}
+	return schema
+}
+
+func (t *Table) inferColumn(column int) ColumnType {
+	isInt, isNumber, isBool, seen := true, true, true, false
+	for row := range t.Rows {
+		value := t.Cell(row, column)
+		if isEmpty(value) {
+			continue
+		}
+		seen = true
+		text, isText := cellText(value)
+		_, isBoolValue := value.(bool)
+		if !isBoolValue && !(isText && isBoolText(text)) {
+			isBool = false
+		}
+		if isBoolValue {
+			isInt, isNumber = false, false
+			continue
+		}
+		if _, isString := value.(string); isString && strings.TrimSpace(text) != text {
+			isInt, isNumber = false, false
+			continue
+		}
+		if isInt && !isIntegerText(text) {
+			isInt = false
+		}
+		if isNumber && !isNumberText(text) {
+			isNumber = false
+		}
+	}
+
+	switch {
+	case !seen:
+		return TypeNull
+	case isInt:
+		return TypeInteger
+	case isNumber:
+		return TypeNumber
+	case isBool:
+		return TypeBoolean
+	}
+	return TypeString
+}
+
+// Typed returns a copy of the table with values converted to their column's
+// inferred type: numbers become json.Number, booleans bool and empty values nil.
+// Values in string columns are left as they are.
+func (t *Table) Typed() *Table {
+	schema := t.Schema()
+	typed := &Table{Columns: append([]string(nil), t.Columns...), Rows: make([][]any, len(t.Rows))}
+	for row := range t.Rows {
+		typed.Rows[row] = make([]any, t.Width())
+		for column, columnType := range schema {
+			typed.Rows[row][column] = convertCell(t.Cell(row, column), columnType)
+		}
+	}
+	return typed
+}
+
+func convertCell(value any, columnType ColumnType) any {
+	if isEmpty(value) {
+		return nil
+	}
+	text, _ := cellText(value)
+	switch columnType {
+	case TypeInteger, TypeNumber:
+		return json.Number(text)
+	case TypeBoolean:
+		if b, ok := value.(bool); ok {
+			return b
+		}
+		return strings.EqualFold(text, "true")
+	case TypeString:
+		if _, ok := value.(string); !ok {
+			return text
+		}
+	}
+	return value
+}
+
+// isEmpty reports whether a cell has no value
+func isEmpty(value any) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case string:
+		return strings.TrimSpace(v) == ""
+	}
+	return false
+}
+
+// cellText returns a cell as text and whether it is a scalar
+func cellText(value any) (string, bool) {
+	switch v := value.(type) {
+	case nil:
+		return "", true
+	case string:
+		return v, true
+	case bool:
+		if v {
+			return "true", true
+		}
+		return "false", true
+	case json.Number:
+		return v.String(), true
+	}
+	return fmt.Sprint(value), false
+}
+
+func isBoolText(s string) bool {
+	return strings.EqualFold(s, "true") || strings.EqualFold(s, "false")
+}
+
+// isIntegerText reports whether s is an integer that is valid JSON as written
+func isIntegerText(s string) bool {
+	digits := strings.TrimPrefix(s, "-")
+	if digits == "" || (len(digits) > 1 && digits[0] == '0') || len(digits) > maxIntegerDigits {
+		return false
+	}
+	for _, r := range digits {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// isNumberText reports whether s is a number that is valid JSON as written
+func isNumberText(s string) bool {
+	if isIntegerText(s) {
+		return true
+	}
+	digits := strings.TrimPrefix(s, "-")
+	if !strings.ContainsAny(digits, ".eE") {
+		// An integer too long to keep its precision
+		return false
+	}
+	if digits == "" || digits[0] < '0' || digits[0] > '9' {
+		return false
+	}
+	if len(digits) > 1 && digits[0] == '0' && digits[1] != '.' && digits[1] != 'e' && digits[1] != 'E' {
+		return false
+	}
+	var number float64
+	return json.Unmarshal([]byte(s), &number) == nil
+}
+
+// columnNames returns a name for every column, filling in empty names and
+// making duplicates unique
+func columnNames(header []string, width int) []string {
+	names := make([]string, width)
+	used := make(map[string]bool, width)
+	for i := range names {
+		name := ""
+		if i < len(header) {
+			name = strings.TrimSpace(header[i])
+		}
+		if name == "" {
+			name = fmt.Sprintf("column_%d", i+1)
+		}
+		unique := name
+		for n := 2; used[unique]; n++ {
+			unique = fmt.Sprintf("%s_%d", name, n)
+		}
+		used[unique] = true
+		names[i] = unique
+	}
+	return names
+}
@@ -0,0 +1,194 @@
+// Package gas prices transactions with EIP-1559 fees: it pads estimated gas
+// limits, derives the fee cap from the base fee of the latest block, refuses
+// transactions that could cost more than a limit, and raises the fees of
+// transactions that stay unmined so they can be replaced.
+package gas
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"time"
+)
+
+// ErrFeeTooHigh is returned when a transaction could cost more than
+// Config.MaxCost, or needs a higher fee per gas than Config.MaxFeePerGas
+var ErrFeeTooHigh = errors.New("transaction fee above the limit")
+
+// minBumpPercent is the least fee increase nodes accept for a replacement
+const minBumpPercent = 10
+
+// Gwei is 10^9 wei
+var Gwei = big.NewInt(1_000_000_000)
+
+// Config configures the fees of transactions. The zero Config uses the
+// defaults and does not limit the cost.
+type Config struct {
+	GasMultiplier        float64       // Applied to the estimated gas limit (default 1.2)
+	MaxFeePerGas         *big.Int      // Fee cap in wei, base fee included (nil = twice the base fee plus the tip)
+	MaxPriorityFeePerGas *big.Int      // Tip in wei (nil = the node's suggestion)
+	MaxCost              *big.Int      // Most a transaction may cost in wei, gas limit × fee cap (nil = unlimited)
+	BumpPercent          int           // Fee increase of a replacement (default 12, at least 10)
+	MaxBumps             int           // Replacements of a stuck transaction before giving up (default 3)
+	StuckAfter           time.Duration // Time without a receipt before a transaction is replaced (default 2m)
+}
+
+// WithDefaults returns the config with defaults for unset fields
+func (c Config) WithDefaults() Config {
+	if c.GasMultiplier <= 0 {
+		c.GasMultiplier = 1.2
+	}
+	if c.BumpPercent <= 0 {
+		c.BumpPercent = 12
+	}
+	c.BumpPercent = max(c.BumpPercent, minBumpPercent)
+	if c.MaxBumps <= 0 {
+		c.MaxBumps = 3
+	}
+	if c.StuckAfter <= 0 {
+		c.StuckAfter = 2 * time.Minute
+	}
+	return c
+}
+
+// Fees are the gas limit and EIP-1559 fees of a transaction
+type Fees struct {
+	GasLimit uint64
+	FeeCap   *big.Int // maxFeePerGas in wei
+	TipCap   *big.Int // maxPriorityFeePerGas in wei
+}
+
+// MaxCost returns the most the transaction can cost: GasLimit × FeeCap
+func (f Fees) MaxCost() *big.Int {
+	return new(big.Int).Mul(new(big.Int).SetUint64(f.GasLimit), f.FeeCap)
+}
+
+// String formats the fees in gwei
+func (f Fees) String() string {
+	return fmt.Sprintf("gas %d, max fee %s gwei, tip %s gwei", f.GasLimit, FormatGwei(f.FeeCap), FormatGwei(f.TipCap))
+}
+
+// Plan prices a transaction from its estimated gas, the base fee of the
+// latest block and the tip the node suggests. The tip is capped by the fee
+// cap. A base fee above MaxFeePerGas fails with ErrFeeTooHigh, since the
+// transaction could not be included.
+func (c Config) Plan(estimatedGas uint64, baseFee, suggestedTip *big.Int) (Fees, error) {
+	c = c.WithDefaults()
+	if baseFee == nil {
+		baseFee = new(big.Int)
+	}
+	if c.MaxFeePerGas != nil && baseFee.Cmp(c.MaxFeePerGas) > 0 {
+		return Fees{}, fmt.Errorf("%w: base fee is %s gwei, limit is %s gwei", ErrFeeTooHigh, FormatGwei(baseFee), FormatGwei(c.MaxFeePerGas))
+	}
+
+	tip := c.MaxPriorityFeePerGas
+	if tip == nil {
+		tip = suggestedTip
+	}
+	if tip == nil {
+		tip = new(big.Int)
+	}
+	feeCap := c.MaxFeePerGas
+	if feeCap == nil {
+		feeCap = new(big.Int).Add(new(big.Int).Mul(baseFee, big.NewInt(2)), tip)
+	}
+
+	fees := Fees{
+		GasLimit: padGas(estimatedGas, c.GasMultiplier),
+		FeeCap:   new(big.Int).Set(feeCap),
+		TipCap:   minInt(tip, feeCap),
+	}
+	return fees, c.checkCost(fees)
+}
+
+// PlanLegacy prices a transaction for chains without EIP-1559, where the
+// gas price the node suggests is both the fee cap and the tip
+func (c Config) PlanLegacy(estimatedGas uint64, gasPrice *big.Int) (Fees, error) {
+	c = c.WithDefaults()
+	if c.MaxFeePerGas != nil && gasPrice.Cmp(c.MaxFeePerGas) > 0 {
+		return Fees{}, fmt.Errorf("%w: gas price is %s gwei, limit is %s gwei", ErrFeeTooHigh, FormatGwei(gasPrice), FormatGwei(c.MaxFeePerGas))
+	}
+	fees := Fees{
+		GasLimit: padGas(estimatedGas, c.GasMultiplier),
+		FeeCap:   new(big.Int).Set(gasPrice),
+		TipCap:   new(big.Int).Set(gasPrice),
+	}
+	return fees, c.checkCost(fees)
+}
+
+// PadGas returns the gas limit for an estimate, multiplied by GasMultiplier
+func (c Config) PadGas(estimatedGas uint64) uint64 {
+	return padGas(estimatedGas, c.WithDefaults().GasMultiplier)
+}
+
+// Bump returns the fees of a replacement for a stuck transaction: both caps
+// raised by BumpPercent. An explicit MaxFeePerGas is not exceeded, so once
+// the fee cap reaches it the replacement fails with ErrFeeTooHigh.
+func (c Config) Bump(fees Fees) (Fees, error) {
+	c = c.WithDefaults()
+	bumped := Fees{
+		GasLimit: fees.GasLimit,
+		FeeCap:   raise(fees.FeeCap, c.BumpPercent),
+		TipCap:   raise(fees.TipCap, c.BumpPercent),
+	}
+	if c.MaxFeePerGas != nil && bumped.FeeCap.Cmp(c.MaxFeePerGas) > 0 {
+		return fees, fmt.Errorf("%w: replacement needs a max fee of %s gwei, limit is %s gwei", ErrFeeTooHigh, FormatGwei(bumped.FeeCap), FormatGwei(c.MaxFeePerGas))
+	}
+	return bumped, c.checkCost(bumped)
+}
+
+func (c Config) checkCost(fees Fees) error {
+	if c.MaxCost == nil || fees.MaxCost().Cmp(c.MaxCost) <= 0 {
+		return nil
+	}
+	return fmt.Errorf("%w: up to %s gwei, limit is %s gwei", ErrFeeTooHigh, FormatGwei(fees.MaxCost()), FormatGwei(c.MaxCost))
+}
+
+// FromGwei converts an amount in gwei to wei (0 or less = nil)
+func FromGwei(gwei float64) *big.Int {
+	if gwei <= 0 {
+		return nil
+	}
+	wei, _ := new(big.Float).Mul(big.NewFloat(gwei), new(big.Float).SetInt(Gwei)).Int(nil)
+	return wei
+}
+
+// FormatGwei formats an amount in wei as gwei
+func FormatGwei(wei *big.Int) string {
+	if wei == nil {
+		return "0"
+	}
+	gwei := new(big.Float).Quo(new(big.Float).SetInt(wei), new(big.Float).SetInt(Gwei))
+	return gwei.Text('f', -1)
+}
+
+// padGas multiplies the estimated gas in whole percent, rounding up
+func padGas(estimated uint64, multiplier float64) uint64 {
+	padded := new(big.Int).SetUint64(estimated)
+	padded.Mul(padded, big.NewInt(int64(math.Round(multiplier*100))))
+	padded.Add(padded, big.NewInt(99))
+	padded.Div(padded, big.NewInt(100))
+	if !padded.IsUint64() {
+		return math.MaxUint64
+	}
+	return padded.Uint64()
+}
+
+// raise increases an amount by percent, rounding up, and by at least 1 wei
+func raise(amount *big.Int, percent int) *big.Int {
+	raised := new(big.Int).Mul(amount, big.NewInt(int64(100+percent)))
+	raised.Add(raised, big.NewInt(99))
+	raised.Div(raised, big.NewInt(100))
+	if raised.Cmp(amount) <= 0 {
+		raised.Add(amount, big.NewInt(1))
+	}
+	return raised
+}
+
+func minInt(a, b *big.Int) *big.Int {
+	if a.Cmp(b) < 0 {
+		return new(big.Int).Set(a)
+	}
+	return new(big.Int).Set(b)
+}
@@ -0,0 +1,96 @@
+package gas
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+)
+
+func gwei(n int64) *big.Int {
+	return new(big.Int).Mul(big.NewInt(n), Gwei)
+}
+
+func TestPlan(t *testing.T) {
+	fees, err := Config{}.Plan(100_000, gwei(10), gwei(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fees.GasLimit != 120_000 {
+		t.Errorf("gas limit = %d, want 120000", fees.GasLimit)
+	}
+	if fees.FeeCap.Cmp(gwei(22)) != 0 || fees.TipCap.Cmp(gwei(2)) != 0 {
+		t.Errorf("fees = %s, want max fee 22 gwei, tip 2 gwei", fees)
+	}
+
+	// Configured fees take precedence; the tip never exceeds the fee cap
+	config := Config{MaxFeePerGas: gwei(15), MaxPriorityFeePerGas: gwei(20), GasMultiplier: 1}
+	fees, err = config.Plan(100_000, gwei(10), gwei(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fees.GasLimit != 100_000 || fees.FeeCap.Cmp(gwei(15)) != 0 || fees.TipCap.Cmp(gwei(15)) != 0 {
+		t.Errorf("fees = %s", fees)
+	}
+}
+
+func TestPlanRejects(t *testing.T) {
+	// Base fee above the configured cap
+	if _, err := (Config{MaxFeePerGas: gwei(5)}).Plan(21_000, gwei(10), gwei(1)); !errors.Is(err, ErrFeeTooHigh) {
+		t.Errorf("got %v, want ErrFeeTooHigh", err)
+	}
+
+	// 120000 gas × 22 gwei = 2640000 gwei
+	config := Config{MaxCost: gwei(2_000_000)}
+	if _, err := config.Plan(100_000, gwei(10), gwei(2)); !errors.Is(err, ErrFeeTooHigh) {
+		t.Errorf("got %v, want ErrFeeTooHigh", err)
+	}
+	config.MaxCost = gwei(3_000_000)
+	if _, err := config.Plan(100_000, gwei(10), gwei(2)); err != nil {
+		t.Errorf("cost within the limit rejected: %v", err)
+	}
+}
+
+func TestPlanLegacy(t *testing.T) {
+	fees, err := Config{GasMultiplier: 1.5}.PlanLegacy(20_000, gwei(3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fees.GasLimit != 30_000 || fees.FeeCap.Cmp(gwei(3)) != 0 || fees.TipCap.Cmp(gwei(3)) != 0 {
+		t.Errorf("fees = %s", fees)
+	}
+	if _, err := (Config{MaxFeePerGas: gwei(2)}).PlanLegacy(20_000, gwei(3)); !errors.Is(err, ErrFeeTooHigh) {
+		t.Errorf("got %v, want ErrFeeTooHigh", err)
+	}
+}
+
+func TestBump(t *testing.T) {
+	fees := Fees{GasLimit: 50_000, FeeCap: gwei(100), TipCap: big.NewInt(1)}
+	bumped, err := Config{}.Bump(fees)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bumped.FeeCap.Cmp(gwei(112)) != 0 || bumped.TipCap.Cmp(big.NewInt(2)) != 0 || bumped.GasLimit != 50_000 {
+		t.Errorf("bumped = %+v", bumped)
+	}
+
+	// Bumps below what nodes accept are raised to 10%
+	if bumped, _ := (Config{BumpPercent: 5}).Bump(fees); bumped.FeeCap.Cmp(gwei(110)) != 0 {
+		t.Errorf("fee cap = %s, want 110 gwei", FormatGwei(bumped.FeeCap))
+	}
+
+	if _, err := (Config{MaxFeePerGas: gwei(105)}).Bump(fees); !errors.Is(err, ErrFeeTooHigh) {
+		t.Errorf("got %v, want ErrFeeTooHigh", err)
+	}
+}
+
+func TestGweiConversion(t *testing.T) {
+	if wei := FromGwei(1.5); wei.Cmp(big.NewInt(1_500_000_000)) != 0 {
+		t.Errorf("FromGwei(1.5) = %s", wei)
+	}
+	if FromGwei(0) != nil {
+		t.Error("FromGwei(0) should be nil")
+	}
+	if got := FormatGwei(big.NewInt(2_500_000_000)); got != "2.5" {
+		t.Errorf("FormatGwei = %s", got)
+	}
+}
@@ -0,0 +1,163 @@
+// Package guardrail provides content guardrails for agent tasks: rules of
+// keywords and regular expressions, the OpenAI moderation API, and chains of
+// guardrails. The task coordinator checks task input and responses with the
+// guardrail it is given, see network.TaskCoordinator.SetGuardrail.
+package guardrail
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/types"
+)
+
+// RedactionMark replaces the parts of content a rule matched
+const RedactionMark = "[redacted]"
+
+// Rule flags content matching a pattern under a category
+type Rule struct {
+	Category string
+	Pattern  *regexp.Regexp
+}
+
+// Keywords returns a rule matching any of the words or phrases, ignoring case.
+// Words are matched whole: "ass" does not match "class".
+func Keywords(category string, words ...string) Rule {
+	alternatives := make([]string, 0, len(words))
+	for _, word := range words {
+		word = strings.TrimSpace(word)
+		if word == "" {
+			continue
+		}
+		alt := regexp.QuoteMeta(word)
+		if isWordChar(word[0]) {
+			alt = `\b` + alt
+		}
+		if isWordChar(word[len(word)-1]) {
+			alt += `\b`
+		}
+		alternatives = append(alternatives, alt)
+	}
+	if len(alternatives) == 0 {
+		// Matches nothing
+		return Rule{Category: category, Pattern: regexp.MustCompile(`[^\s\S]`)}
+	}
+	return Rule{Category: category, Pattern: regexp.MustCompile(`(?i)(?:` + strings.Join(alternatives, "|") + `)`)}
+}
+
+// isWordChar reports whether \b treats c as part of a word
+func isWordChar(c byte) bool {
+	return c == '_' || ('0' <= c && c <= '9') || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+}
+
+// Pattern returns a rule matching a regular expression
+func Pattern(category, expr string) (Rule, error) {
+	pattern, err := regexp.Compile(expr)
+	if err != nil {
+		return Rule{}, fmt.Errorf("invalid guardrail pattern %q: %w", expr, err)
+	}
+	return Rule{Category: category, Pattern: pattern}, nil
+}
+
+// Patterns flags content matching any of its rules, and can redact the
+// matches. Input and responses are checked against the same rules.
+type Patterns struct {
+	rules []Rule
+}
+
+// NewPatterns creates a guardrail from rules
+func NewPatterns(rules ...Rule) *Patterns {
+	return &Patterns{rules: rules}
+}
+
+// CheckInput implements types.Guardrail
+func (p *Patterns) CheckInput(ctx context.Context, content string) (types.GuardrailVerdict, error) {
+	return p.Check(content), nil
+}
+
+// CheckOutput implements types.Guardrail
+func (p *Patterns) CheckOutput(ctx context.Context, content string) (types.GuardrailVerdict, error) {
+	return p.Check(content), nil
+}
+
+// Check returns the verdict on content, with the matches replaced by
+// RedactionMark if any rule matched
+func (p *Patterns) Check(content string) types.GuardrailVerdict {
+	var verdict types.GuardrailVerdict
+	redacted := content
+	for _, rule := range p.rules {
+		if !rule.Pattern.MatchString(redacted) {
+			continue
+		}
+		verdict.Flagged = true
+		if !slices.Contains(verdict.Categories, rule.Category) {
+			verdict.Categories = append(verdict.Categories, rule.Category)
+		}
+		redacted = rule.Pattern.ReplaceAllLiteralString(redacted, RedactionMark)
+	}
+	if verdict.Flagged {
+		verdict.Reason = "matched " + strings.Join(verdict.Categories, ", ")
+		verdict.Redacted = redacted
+	}
+	return verdict
+}
+
+// Chain runs guardrails in order. Content is flagged if any guardrail flags
+// it, and the categories of all are reported. Redactions are applied in
+// turn, so the content can be redacted only if every guardrail that flagged
+// it could redact it.
+type Chain []types.Guardrail
+
+// CheckInput implements types.Guardrail
+func (c Chain) CheckInput(ctx context.Context, content string) (types.GuardrailVerdict, error) {
+	return c.check(content, func(g types.Guardrail, content string) (types.GuardrailVerdict, error) {
+		return g.CheckInput(ctx, content)
+	})
+}
+
+// CheckOutput implements types.Guardrail
+func (c Chain) CheckOutput(ctx context.Context, content string) (types.GuardrailVerdict, error) {
+	return c.check(content, func(g types.Guardrail, content string) (types.GuardrailVerdict, error) {
+		return g.CheckOutput(ctx, content)
+	})
+}
+
+// check combines the verdicts of the chain's guardrails
+func (c Chain) check(content string, check func(types.Guardrail, string) (types.GuardrailVerdict, error)) (types.GuardrailVerdict, error) {
+	var combined types.GuardrailVerdict
+	var reasons []string
+	redacted, canRedact := content, true
+	for _, g := range c {
+		verdict, err := check(g, redacted)
+		if err != nil {
+			return types.GuardrailVerdict{}, err
+		}
+		if !verdict.Flagged {
+			continue
+		}
+		combined.Flagged = true
+		for _, category := range verdict.Categories {
+			if !slices.Contains(combined.Categories, category) {
+				combined.Categories = append(combined.Categories, category)
+			}
+		}
+		if verdict.Reason != "" {
+			reasons = append(reasons, verdict.Reason)
+		}
+		if verdict.Redacted == "" {
+			canRedact = false
+		} else if canRedact {
+			redacted = verdict.Redacted
+		}
+	}
+	if combined.Flagged {
+		combined.Reason = strings.Join(reasons, "; ")
+		if canRedact {
+			combined.Redacted = redacted
+		}
+	}
+	return combined, nil
+}
@@ -0,0 +1,129 @@
+package guardrail
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/types"
+)
+
+func TestKeywords(t *testing.T) {
+	g := NewPatterns(Keywords("profanity", "darn", "heck"))
+
+	verdict := g.Check("Well DARN, that is a class act")
+	if !verdict.Flagged || !slices.Equal(verdict.Categories, []string{"profanity"}) {
+		t.Fatalf("verdict = %+v", verdict)
+	}
+	if verdict.Redacted != "Well [redacted], that is a class act" {
+		t.Errorf("Redacted = %q", verdict.Redacted)
+	}
+	if verdict := g.Check("darning socks is fine"); verdict.Flagged {
+		t.Errorf("partial word flagged: %+v", verdict)
+	}
+}
+
+func TestPatterns(t *testing.T) {
+	rule, err := Pattern("secret", `sk-[A-Za-z0-9]{8,}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := NewPatterns(rule, Keywords("c++", "c++"))
+
+	verdict, err := g.CheckOutput(context.Background(), "key sk-abcdef123456 for c++")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(verdict.Categories, []string{"secret", "c++"}) {
+		t.Errorf("Categories = %v", verdict.Categories)
+	}
+	if verdict.Redacted != "key [redacted] for [redacted]" {
+		t.Errorf("Redacted = %q", verdict.Redacted)
+	}
+	if _, err := Pattern("bad", "("); err == nil {
+		t.Error("expected an error for an invalid pattern")
+	}
+}
+
+// verdictGuardrail returns a fixed verdict or error
+type verdictGuardrail struct {
+	verdict types.GuardrailVerdict
+	err     error
+}
+
+func (g verdictGuardrail) CheckInput(ctx context.Context, content string) (types.GuardrailVerdict, error) {
+	return g.verdict, g.err
+}
+
+func (g verdictGuardrail) CheckOutput(ctx context.Context, content string) (types.GuardrailVerdict, error) {
+	return g.verdict, g.err
+}
+
+func TestChain(t *testing.T) {
+	keywords := NewPatterns(Keywords("profanity", "darn"))
+	ctx := context.Background()
+
+	verdict, err := Chain{keywords, verdictGuardrail{}}.CheckInput(ctx, "darn it")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !verdict.Flagged || verdict.Redacted != "[redacted] it" {
+		t.Errorf("verdict = %+v", verdict)
+	}
+
+	// A guardrail that cannot redact makes the content unredactable
+	moderation := verdictGuardrail{verdict: types.GuardrailVerdict{Flagged: true, Categories: []string{"violence"}, Reason: "moderation flagged violence"}}
+	verdict, _ = Chain{keywords, moderation}.CheckInput(ctx, "darn it")
+	if !slices.Equal(verdict.Categories, []string{"profanity", "violence"}) || verdict.Redacted != "" {
+		t.Errorf("verdict = %+v", verdict)
+	}
+
+	if _, err := (Chain{keywords, verdictGuardrail{err: errors.New("down")}}).CheckOutput(ctx, "fine"); err == nil {
+		t.Error("expected the error of a failing guardrail")
+	}
+}
+
+func TestModeration(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Input string `json:"input"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		flagged := req.Input == "threat"
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"id":    "modr-1",
+			"model": DefaultModerationModel,
+			"results": []map[string]interface{}{{
+				"flagged":    flagged,
+				"categories": map[string]bool{"violence": flagged, "harassment/threatening": flagged},
+			}},
+		})
+	}))
+	defer server.Close()
+	ctx := context.Background()
+
+	m := NewModeration(&ModerationConfig{APIKey: "test", BaseURL: server.URL + "/v1"})
+	verdict, err := m.CheckInput(ctx, "threat")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !verdict.Flagged || !slices.Equal(verdict.Categories, []string{"harassment/threatening", "violence"}) || verdict.Redacted != "" {
+		t.Errorf("verdict = %+v", verdict)
+	}
+	if verdict, _ := m.CheckInput(ctx, "hello"); verdict.Flagged {
+		t.Errorf("harmless input flagged: %+v", verdict)
+	}
+
+	// Only the configured categories flag content
+	m = NewModeration(&ModerationConfig{APIKey: "test", BaseURL: server.URL + "/v1", Categories: []string{"sexual"}, SkipOutput: true})
+	if verdict, _ := m.CheckInput(ctx, "threat"); verdict.Flagged {
+		t.Errorf("unconfigured category flagged: %+v", verdict)
+	}
+	if verdict, _ := m.CheckOutput(ctx, "threat"); verdict.Flagged {
+		t.Errorf("output checked despite SkipOutput: %+v", verdict)
+	}
+}
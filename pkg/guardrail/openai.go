@@ -0,0 +1,121 @@
+package guardrail
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/types"
+	"github.com/sashabaranov/go-openai"
+)
+
+// DefaultModerationModel is the OpenAI moderation model used when none is configured
+const DefaultModerationModel = openai.ModerationOmniLatest
+
+// ModerationConfig configures a Moderation guardrail
+type ModerationConfig struct {
+	APIKey     string   // API key
+	Model      string   // Moderation model (default DefaultModerationModel)
+	BaseURL    string   // Custom endpoint for OpenAI-compatible services
+	Categories []string // Categories that flag content, e.g. "violence" or "self-harm" (empty = any the API flags)
+	SkipOutput bool     // Check only task input, e.g. to save a request per response
+}
+
+// Moderation flags content with the OpenAI moderation API. It cannot redact:
+// content it flags is blocked when redaction is configured.
+type Moderation struct {
+	client     *openai.Client
+	model      string
+	categories []string
+	skipOutput bool
+}
+
+// NewModeration creates a guardrail backed by the OpenAI moderation API
+func NewModeration(config *ModerationConfig) *Moderation {
+	clientConfig := openai.DefaultConfig(config.APIKey)
+	if config.BaseURL != "" {
+		clientConfig.BaseURL = strings.TrimRight(config.BaseURL, "/")
+	}
+	model := config.Model
+	if model == "" {
+		model = DefaultModerationModel
+	}
+	return &Moderation{
+		client:     openai.NewClientWithConfig(clientConfig),
+		model:      model,
+		categories: config.Categories,
+		skipOutput: config.SkipOutput,
+	}
+}
+
+// CheckInput implements types.Guardrail
+func (m *Moderation) CheckInput(ctx context.Context, content string) (types.GuardrailVerdict, error) {
+	return m.moderate(ctx, content)
+}
+
+// CheckOutput implements types.Guardrail
+func (m *Moderation) CheckOutput(ctx context.Context, content string) (types.GuardrailVerdict, error) {
+	if m.skipOutput {
+		return types.GuardrailVerdict{}, nil
+	}
+	return m.moderate(ctx, content)
+}
+
+// moderate asks the moderation API about content
+func (m *Moderation) moderate(ctx context.Context, content string) (types.GuardrailVerdict, error) {
+	if strings.TrimSpace(content) == "" {
+		return types.GuardrailVerdict{}, nil
+	}
+	resp, err := m.client.Moderations(ctx, openai.ModerationRequest{Input: content, Model: m.model})
+	if err != nil {
+		return types.GuardrailVerdict{}, fmt.Errorf("openai moderation error: %w", err)
+	}
+
+	var verdict types.GuardrailVerdict
+	for _, result := range resp.Results {
+		if !result.Flagged {
+			continue
+		}
+		flagged, err := flaggedCategories(result.Categories)
+		if err != nil {
+			return types.GuardrailVerdict{}, err
+		}
+		for _, category := range flagged {
+			if len(m.categories) > 0 && !slices.Contains(m.categories, category) {
+				continue
+			}
+			if !slices.Contains(verdict.Categories, category) {
+				verdict.Categories = append(verdict.Categories, category)
+			}
+		}
+	}
+	if len(verdict.Categories) > 0 {
+		verdict.Flagged = true
+		verdict.Reason = "moderation flagged " + strings.Join(verdict.Categories, ", ")
+	}
+	return verdict, nil
+}
+
+// flaggedCategories returns the names of the categories set in a moderation
+// result, as the API spells them (e.g. "hate/threatening")
+func flaggedCategories(categories openai.ResultCategories) ([]string, error) {
+	data, err := json.Marshal(categories)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read moderation categories: %w", err)
+	}
+	var set map[string]bool
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("failed to read moderation categories: %w", err)
+	}
+	var names []string
+	for name, flagged := range set {
+		if flagged {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
@@ -0,0 +1,301 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/errs"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/logging"
+)
+
+// DefaultReportTimeout bounds generating and delivering one report when no timeout is configured
+const DefaultReportTimeout = time.Minute
+
+// Schedule is a parsed cron expression
+type Schedule struct {
+	expr    string
+	minute  uint64 // Bit n set = minute n matches
+	hour    uint64
+	dom     uint64
+	month   uint64
+	dow     uint64
+	domStar bool // Day of month was "*", so only day of week restricts days
+	dowStar bool
+}
+
+var cronDescriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+var monthNames = []string{"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}
+
+var dayNames = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+// ParseSchedule parses a standard five-field cron expression (minute, hour,
+// day of month, month, day of week) or one of the descriptors @hourly,
+// @daily, @weekly, @monthly and @yearly. Fields accept *, lists, ranges,
+// steps and month and day names; 7 is Sunday like 0.
+func ParseSchedule(expr string) (*Schedule, error) {
+	spec := strings.TrimSpace(expr)
+	if descriptor, ok := cronDescriptors[strings.ToLower(spec)]; ok {
+		spec = descriptor
+	}
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("invalid schedule %q: expected 5 fields, got %d", expr, len(fields))
+	}
+
+	s := &Schedule{expr: strings.TrimSpace(expr)}
+	var err error
+	if s.minute, err = parseCronField(fields[0], 0, 59, nil); err != nil {
+		return nil, fmt.Errorf("invalid schedule %q: minute: %w", expr, err)
+	}
+	if s.hour, err = parseCronField(fields[1], 0, 23, nil); err != nil {
+		return nil, fmt.Errorf("invalid schedule %q: hour: %w", expr, err)
+	}
+	if s.dom, err = parseCronField(fields[2], 1, 31, nil); err != nil {
+		return nil, fmt.Errorf("invalid schedule %q: day of month: %w", expr, err)
+	}
+	if s.month, err = parseCronField(fields[3], 1, 12, monthNames); err != nil {
+		return nil, fmt.Errorf("invalid schedule %q: month: %w", expr, err)
+	}
+	if s.dow, err = parseCronField(fields[4], 0, 7, dayNames); err != nil {
+		return nil, fmt.Errorf("invalid schedule %q: day of week: %w", expr, err)
+	}
+	if s.dow&(1<<7) != 0 {
+		s.dow |= 1
+	}
+	s.domStar = strings.HasPrefix(fields[2], "*")
+	s.dowStar = strings.HasPrefix(fields[4], "*")
+	return s, nil
+}
+
+// parseCronField parses one field into a bit set of the values it matches
+func parseCronField(field string, min, max int, names []string) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepPart)
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("invalid step %q", stepPart)
+			}
+			step = n
+		}
+
+		var low, high int
+		switch {
+		case rangePart == "*":
+			low, high = min, max
+		case strings.Contains(rangePart, "-"):
+			from, to, _ := strings.Cut(rangePart, "-")
+			var err error
+			if low, err = parseCronValue(from, min, max, names); err != nil {
+				return 0, err
+			}
+			if high, err = parseCronValue(to, min, max, names); err != nil {
+				return 0, err
+			}
+			if low > high {
+				return 0, fmt.Errorf("invalid range %q", rangePart)
+			}
+		default:
+			value, err := parseCronValue(rangePart, min, max, names)
+			if err != nil {
+				return 0, err
+			}
+			low, high = value, value
+			if hasStep {
+				high = max
+			}
+		}
+
+		for v := low; v <= high; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+// parseCronValue parses a number or name within a field's bounds
+func parseCronValue(value string, min, max int, names []string) (int, error) {
+	for i, name := range names {
+		if strings.EqualFold(value, name) {
+			if min == 1 {
+				return i + 1, nil
+			}
+			return i, nil
+		}
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value %q", value)
+	}
+	if n < min || n > max {
+		return 0, fmt.Errorf("value %d out of range %d-%d", n, min, max)
+	}
+	return n, nil
+}
+
+// String returns the expression the schedule was parsed from
+func (s *Schedule) String() string {
+	return s.expr
+}
+
+// Next returns the first time after the given time that matches the
+// schedule, in the given time's location. It returns the zero time if
+// nothing matches within five years (e.g. "0 0 30 2 *").
+func (s *Schedule) Next(after time.Time) time.Time {
+	t := after.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+		if s.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !s.matchesDay(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if s.hour&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+		if s.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+// matchesDay applies cron's day rule: if both day of month and day of week
+// are restricted, a day matching either one matches
+func (s *Schedule) matchesDay(t time.Time) bool {
+	dom := s.dom&(1<<uint(t.Day())) != 0
+	dow := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.domStar || s.dowStar {
+		return dom && dow
+	}
+	return dom || dow
+}
+
+// CronReportConfig configures a CronReport
+type CronReportConfig struct {
+	Schedule string                                         // Cron expression or descriptor, e.g. "0 9 * * mon-fri" or "@daily" (required)
+	Generate func(ctx context.Context) (string, error)      // Produces the report (required)
+	Deliver  func(ctx context.Context, report string) error // Publishes a scheduled report, e.g. to a webhook or chat (optional)
+	Location *time.Location                                 // Time zone the schedule is evaluated in (default time.Local)
+	Timeout  time.Duration                                  // Bounds one Generate plus Deliver (default DefaultReportTimeout)
+}
+
+// CronReport generates a report on a schedule and serves the latest one on
+// request. Tasks "now" or "run" generate a fresh report, "next" tells when
+// the next one is due and anything else returns the latest report.
+type CronReport struct {
+	config   CronReportConfig
+	schedule *Schedule
+
+	mu        sync.Mutex
+	report    string
+	generated time.Time
+}
+
+// NewCronReport creates a scheduled report. Call Run to start the schedule.
+func NewCronReport(config *CronReportConfig) (*CronReport, error) {
+	if config == nil || config.Generate == nil {
+		return nil, fmt.Errorf("cron report requires a Generate function")
+	}
+	schedule, err := ParseSchedule(config.Schedule)
+	if err != nil {
+		return nil, err
+	}
+	c := &CronReport{config: *config, schedule: schedule}
+	if c.config.Location == nil {
+		c.config.Location = time.Local
+	}
+	if c.config.Timeout <= 0 {
+		c.config.Timeout = DefaultReportTimeout
+	}
+	return c, nil
+}
+
+// Next returns when the next scheduled report is due
+func (c *CronReport) Next() time.Time {
+	return c.schedule.Next(time.Now().In(c.config.Location))
+}
+
+// Run generates and delivers reports on the schedule until ctx is cancelled.
+// Failed runs are logged and the schedule continues.
+func (c *CronReport) Run(ctx context.Context) error {
+	for {
+		next := c.Next()
+		if next.IsZero() {
+			return fmt.Errorf("schedule %q never matches", c.schedule)
+		}
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+
+		runCtx, cancel := context.WithTimeout(ctx, c.config.Timeout)
+		report, err := c.generate(runCtx)
+		if err == nil && c.config.Deliver != nil {
+			err = c.config.Deliver(runCtx, report)
+		}
+		cancel()
+		if err != nil {
+			logging.Error("scheduled report failed", "schedule", c.schedule.String(), "error", err)
+		}
+	}
+}
+
+// ProcessTask implements types.AgentHandler
+func (c *CronReport) ProcessTask(ctx context.Context, task string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(task)) {
+	case "now", "run":
+		return c.generate(ctx)
+	case "next":
+		next := c.Next()
+		if next.IsZero() {
+			return "", errs.User(fmt.Errorf("schedule %q never matches", c.schedule))
+		}
+		return fmt.Sprintf("Next report: %s", next.Format("Mon, 02 Jan 2006 15:04 MST")), nil
+	}
+
+	c.mu.Lock()
+	report, generated := c.report, c.generated
+	c.mu.Unlock()
+	if generated.IsZero() {
+		return c.generate(ctx)
+	}
+	return fmt.Sprintf("%s\n\n(generated %s)", report, generated.In(c.config.Location).Format("Mon, 02 Jan 2006 15:04 MST")), nil
+}
+
+// generate produces a report and keeps it as the latest
+func (c *CronReport) generate(ctx context.Context) (string, error) {
+	report, err := c.config.Generate(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate report: %w", err)
+	}
+	c.mu.Lock()
+	c.report, c.generated = report, time.Now()
+	c.mu.Unlock()
+	return report, nil
+}
@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestScheduleNext(t *testing.T) {
+	// 2025-01-15 is a Wednesday
+	from := time.Date(2025, 1, 15, 10, 30, 20, 0, time.UTC)
+	tests := []struct {
+		expr string
+		want time.Time
+	}{
+		{"* * * * *", time.Date(2025, 1, 15, 10, 31, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2025, 1, 15, 11, 0, 0, 0, time.UTC)},
+		{"@daily", time.Date(2025, 1, 16, 0, 0, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2025, 1, 15, 10, 45, 0, 0, time.UTC)},
+		{"0 9 * * mon-fri", time.Date(2025, 1, 16, 9, 0, 0, 0, time.UTC)},
+		{"0 9 * * 7", time.Date(2025, 1, 19, 9, 0, 0, 0, time.UTC)},
+		{"0 0 1 feb *", time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"30 10,12 * * *", time.Date(2025, 1, 15, 12, 30, 0, 0, time.UTC)},
+		// Day of month and day of week both restricted: either matches
+		{"0 0 20 * fri", time.Date(2025, 1, 17, 0, 0, 0, 0, time.UTC)},
+		// Day of month restricted, day of week a step: both must match
+		{"0 0 1-10 * */7", time.Date(2025, 2, 2, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		schedule, err := ParseSchedule(tt.expr)
+		if err != nil {
+			t.Fatalf("%q: %v", tt.expr, err)
+		}
+		if got := schedule.Next(from); !got.Equal(tt.want) {
+			t.Errorf("%q: got %v, want %v", tt.expr, got, tt.want)
+		}
+	}
+
+	never, _ := ParseSchedule("0 0 30 2 *")
+	if got := never.Next(from); !got.IsZero() {
+		t.Errorf("impossible schedule: got %v", got)
+	}
+}
+
+func TestScheduleNextInLocation(t *testing.T) {
+	tz := time.FixedZone("UTC+2", 2*60*60)
+	schedule, _ := ParseSchedule("0 9 * * *")
+	got := schedule.Next(time.Date(2025, 1, 15, 8, 0, 0, 0, time.UTC).In(tz))
+	if want := time.Date(2025, 1, 16, 9, 0, 0, 0, tz); !got.Equal(want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestParseScheduleErrors(t *testing.T) {
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "* * * 13 *", "* * * * 8", "*/0 * * * *", "5-1 * * * *", "@often", "* * * * funday"} {
+		if _, err := ParseSchedule(expr); err == nil {
+			t.Errorf("%q: expected error", expr)
+		}
+	}
+}
+
+func TestCronReportProcessTask(t *testing.T) {
+	var runs atomic.Int32
+	report, err := NewCronReport(&CronReportConfig{
+		Schedule: "@daily",
+		Generate: func(ctx context.Context) (string, error) {
+			if runs.Add(1) == 3 {
+				return "", errors.New("source unavailable")
+			}
+			return "all systems normal", nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Without a previous report, one is generated
+	if got, err := report.ProcessTask(context.Background(), ""); err != nil || got != "all systems normal" {
+		t.Fatalf("got %q, %v", got, err)
+	}
+	// Later requests return the latest report
+	if got, _ := report.ProcessTask(context.Background(), "status"); !strings.HasPrefix(got, "all systems normal\n\n(generated ") {
+		t.Errorf("got %q", got)
+	}
+	if runs.Load() != 1 {
+		t.Errorf("expected the latest report to be reused, got %d runs", runs.Load())
+	}
+
+	if _, err := report.ProcessTask(context.Background(), "now"); err != nil || runs.Load() != 2 {
+		t.Errorf("now should regenerate, got %v after %d runs", err, runs.Load())
+	}
+	if _, err := report.ProcessTask(context.Background(), "run"); err == nil {
+		t.Error("expected the generate error")
+	}
+	if got, _ := report.ProcessTask(context.Background(), "next"); !strings.HasPrefix(got, "Next report: ") {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestCronReportRun(t *testing.T) {
+	report, err := NewCronReport(&CronReportConfig{
+		Schedule: "* * * * *",
+		Generate: func(ctx context.Context) (string, error) { return "tick", nil },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := report.Run(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected Run to stop with the context, got %v", err)
+	}
+	if next := report.Next(); next.Sub(time.Now()) > time.Minute {
+		t.Errorf("next run %v is too far away", next)
+	}
+
+	if _, err := NewCronReport(&CronReportConfig{Schedule: "@daily"}); err == nil {
+		t.Error("expected error without Generate")
+	}
+	if _, err := NewCronReport(&CronReportConfig{Schedule: "daily", Generate: func(ctx context.Context) (string, error) { return "", nil }}); err == nil {
+		t.Error("expected error for invalid schedule")
+	}
+}
@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/errs"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/format"
+)
+
+// DefaultCSVMaxRows is how many data rows are converted when no limit is configured
+const DefaultCSVMaxRows = 10000
+
+// CSVToJSONConfig configures a CSVToJSON handler
+type CSVToJSONConfig struct {
+	Delimiter   rune // Field delimiter (0 = detect among , ; tab and |)
+	NoHeader    bool // The first row is data; columns are named column_1, column_2, ...
+	KeepStrings bool // Don't infer numbers and booleans, keep every value a string
+	MaxRows     int  // Data rows converted at most (default DefaultCSVMaxRows)
+}
+
+// CSVToJSON converts the CSV in the task to a JSON array with one object per
+// row. Column types are inferred: a column becomes numbers or booleans only if
+// every non-empty value in it parses as such, and empty cells become null.
+type CSVToJSON struct {
+	config CSVToJSONConfig
+}
+
+// NewCSVToJSON creates a CSV to JSON converter. config may be nil for the defaults.
+func NewCSVToJSON(config *CSVToJSONConfig) *CSVToJSON {
+	c := &CSVToJSON{}
+	if config != nil {
+		c.config = *config
+	}
+	if c.config.MaxRows <= 0 {
+		c.config.MaxRows = DefaultCSVMaxRows
+	}
+	return c
+}
+
+// ProcessTask implements types.AgentHandler
+func (c *CSVToJSON) ProcessTask(ctx context.Context, task string) (string, error) {
+	input := format.StripCodeFence(task)
+	if input == "" {
+		return "", errs.User(fmt.Errorf("no CSV given, send the CSV after the command"))
+	}
+
+	table, err := format.ParseCSV(input, &format.CSVOptions{Delimiter: c.config.Delimiter, NoHeader: c.config.NoHeader})
+	if errors.Is(err, format.ErrEmpty) {
+		return "", errs.User(fmt.Errorf("no CSV given, send the CSV after the command"))
+	}
+	if err != nil {
+		return "", errs.User(err)
+	}
+	if len(table.Rows) > c.config.MaxRows {
+		return "", errs.User(fmt.Errorf("CSV has %d rows, at most %d can be converted", len(table.Rows), c.config.MaxRows))
+	}
+
+	if !c.config.KeepStrings {
+		table = table.Typed()
+	}
+	return table.JSON(), nil
+}
@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/errs"
+)
+
+func convertCSV(t *testing.T, config *CSVToJSONConfig, input string) []map[string]any {
+	t.Helper()
+	out, err := NewCSVToJSON(config).ProcessTask(context.Background(), input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rows []map[string]any
+	if err := json.Unmarshal([]byte(out), &rows); err != nil {
+		t.Fatalf("invalid JSON %q: %v", out, err)
+	}
+	return rows
+}
+
+func TestCSVToJSONInfersTypes(t *testing.T) {
+	rows := convertCSV(t, nil, "name,age,score,active,zip\nAda,36,9.5,true,01234\nAlan,41,,FALSE,90210\n")
+	want := []map[string]any{
+		{"name": "Ada", "age": 36.0, "score": 9.5, "active": true, "zip": "01234"},
+		{"name": "Alan", "age": 41.0, "score": nil, "active": false, "zip": "90210"},
+	}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("got %v, want %v", rows, want)
+	}
+}
+
+func TestCSVToJSONKeepsColumnOrder(t *testing.T) {
+	out, err := NewCSVToJSON(nil).ProcessTask(context.Background(), "b,a\n1,2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "[\n  {\n    \"b\": 1,\n    \"a\": 2\n  }\n]"
+	if out != want {
+		t.Errorf("got %q, want %q", out, want)
+	}
+}
+
+func TestCSVToJSONDelimitersAndFences(t *testing.T) {
+	rows := convertCSV(t, nil, "```csv\ncity;country\n\"Paris; Left Bank\";France\n```")
+	if len(rows) != 1 || rows[0]["city"] != "Paris; Left Bank" || rows[0]["country"] != "France" {
+		t.Errorf("semicolons: got %v", rows)
+	}
+
+	rows = convertCSV(t, nil, "a\tb\n1\t2")
+	if len(rows) != 1 || rows[0]["b"] != 2.0 {
+		t.Errorf("tabs: got %v", rows)
+	}
+}
+
+func TestCSVToJSONHeaders(t *testing.T) {
+	rows := convertCSV(t, nil, "id,,id\n1,2,3,4")
+	want := map[string]any{"id": 1.0, "column_2": 2.0, "id_2": 3.0, "column_4": 4.0}
+	if !reflect.DeepEqual(rows[0], want) {
+		t.Errorf("got %v, want %v", rows[0], want)
+	}
+
+	rows = convertCSV(t, &CSVToJSONConfig{NoHeader: true, KeepStrings: true}, "1,2\n3,4")
+	if len(rows) != 2 || rows[1]["column_1"] != "3" {
+		t.Errorf("no header: got %v", rows)
+	}
+}
+
+func TestCSVToJSONLimits(t *testing.T) {
+	converter := NewCSVToJSON(&CSVToJSONConfig{MaxRows: 1})
+	if _, err := converter.ProcessTask(context.Background(), "a\n1\n2"); errs.KindOf(err) != errs.KindUser {
+		t.Errorf("expected user error for too many rows, got %v", err)
+	}
+	if _, err := converter.ProcessTask(context.Background(), " "); errs.KindOf(err) != errs.KindUser {
+		t.Errorf("expected user error for empty input, got %v", err)
+	}
+}
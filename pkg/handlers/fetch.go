@@ -0,0 +1,254 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/errs"
+)
+
+// DefaultFetchMaxBytes is how much of a response body is read when no limit is configured
+const DefaultFetchMaxBytes = 1 << 20
+
+// DefaultFetchMaxChars is how long the returned text may get when no limit is configured
+const DefaultFetchMaxChars = 8000
+
+// maxRedirects is how many redirects are followed before giving up
+const maxRedirects = 5
+
+// URLFetcherConfig configures a URLFetcher
+type URLFetcherConfig struct {
+	Client       *http.Client // Client to fetch with; its transport must enforce AllowPrivate itself (default: a client with a 15s timeout)
+	MaxBytes     int64        // Response bytes read at most (default DefaultFetchMaxBytes)
+	MaxChars     int          // Returned text is truncated to this many characters (default DefaultFetchMaxChars)
+	AllowPrivate bool         // Allow loopback, private and link-local addresses (off: only public hosts can be fetched)
+	UserAgent    string       // User-Agent header (default "teneo-agent-sdk")
+}
+
+// URLFetcher fetches the URL given in the task and returns the page as text:
+// HTML is reduced to its readable text, JSON is indented and other text is
+// returned as is. Hosts on private networks are refused unless allowed, so
+// users can't make the agent probe its own infrastructure.
+type URLFetcher struct {
+	client *http.Client
+	config URLFetcherConfig
+}
+
+// NewURLFetcher creates a URL fetcher. config may be nil for the defaults.
+func NewURLFetcher(config *URLFetcherConfig) *URLFetcher {
+	f := &URLFetcher{}
+	if config != nil {
+		f.config = *config
+	}
+	if f.config.MaxBytes <= 0 {
+		f.config.MaxBytes = DefaultFetchMaxBytes
+	}
+	if f.config.MaxChars <= 0 {
+		f.config.MaxChars = DefaultFetchMaxChars
+	}
+	if f.config.UserAgent == "" {
+		f.config.UserAgent = "teneo-agent-sdk"
+	}
+
+	if f.config.Client != nil {
+		client := *f.config.Client
+		f.client = &client
+	} else {
+		dialer := &net.Dialer{Timeout: 10 * time.Second}
+		if !f.config.AllowPrivate {
+			dialer.Control = refusePrivate
+		}
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.Proxy = nil
+		transport.DialContext = dialer.DialContext
+		f.client = &http.Client{Transport: transport, Timeout: 15 * time.Second}
+	}
+	f.client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if len(via) >= maxRedirects {
+			return fmt.Errorf("stopped after %d redirects", maxRedirects)
+		}
+		return checkURL(req.URL)
+	}
+	return f
+}
+
+// ProcessTask implements types.AgentHandler
+func (f *URLFetcher) ProcessTask(ctx context.Context, task string) (string, error) {
+	fields := strings.Fields(task)
+	if len(fields) == 0 {
+		return "", errs.User(fmt.Errorf("no URL given, send e.g. \"https://example.com\""))
+	}
+	raw := fields[0]
+	if !strings.Contains(raw, "://") {
+		raw = "https://" + raw
+	}
+	target, err := url.Parse(raw)
+	if err != nil {
+		return "", errs.User(fmt.Errorf("invalid URL %q", fields[0]))
+	}
+	if err := checkURL(target); err != nil {
+		return "", errs.User(err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target.String(), nil)
+	if err != nil {
+		return "", errs.User(fmt.Errorf("invalid URL %q", fields[0]))
+	}
+	req.Header.Set("User-Agent", f.config.UserAgent)
+	req.Header.Set("Accept", "text/html, application/json;q=0.9, text/*;q=0.8")
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		if errors.Is(err, errPrivateAddress) {
+			return "", errs.User(fmt.Errorf("%s resolves to a private address", target.Hostname()))
+		}
+		return "", errs.Retryable(fmt.Errorf("failed to fetch %s: %w", target.Host, err))
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests:
+		return "", errs.RateLimited(fmt.Errorf("%s is rate limiting requests", target.Host), retryAfter(resp.Header.Get("Retry-After")))
+	case resp.StatusCode >= 500:
+		return "", errs.Retryable(fmt.Errorf("%s returned %s", target.Host, resp.Status))
+	case resp.StatusCode >= 400:
+		return "", errs.User(fmt.Errorf("%s returned %s", target.Host, resp.Status))
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, f.config.MaxBytes))
+	if err != nil {
+		return "", errs.Retryable(fmt.Errorf("failed to read response from %s: %w", target.Host, err))
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType == "" {
+		mediaType = http.DetectContentType(body)
+		mediaType, _, _ = mime.ParseMediaType(mediaType)
+	}
+
+	var text string
+	switch {
+	case mediaType == "text/html" || mediaType == "application/xhtml+xml":
+		text = htmlToText(string(body))
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		var indented bytes.Buffer
+		if json.Indent(&indented, body, "", "  ") == nil {
+			text = indented.String()
+		} else {
+			text = string(body)
+		}
+	case strings.HasPrefix(mediaType, "text/") || mediaType == "application/xml":
+		text = string(body)
+	default:
+		return "", errs.User(fmt.Errorf("%s returned %s content, which can't be shown as text", target.Host, mediaType))
+	}
+
+	return truncate(strings.TrimSpace(text), f.config.MaxChars), nil
+}
+
+// checkURL rejects URLs that can't or shouldn't be fetched
+func checkURL(u *url.URL) error {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("only http and https URLs can be fetched")
+	}
+	if u.Hostname() == "" {
+		return fmt.Errorf("URL %q has no host", u.String())
+	}
+	return nil
+}
+
+var errPrivateAddress = errors.New("refusing to connect to a private address")
+
+// refusePrivate is a net.Dialer Control function that refuses to connect to
+// non-public addresses. It runs after DNS resolution, so hostnames pointing at
+// internal addresses are caught as well.
+func refusePrivate(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() || ip.IsMulticast() {
+		return errPrivateAddress
+	}
+	return nil
+}
+
+// retryAfter parses a Retry-After header given in seconds
+func retryAfter(value string) time.Duration {
+	seconds, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil || seconds < 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+var (
+	htmlComment  = regexp.MustCompile(`(?s)<!--.*?-->`)
+	htmlTitle    = regexp.MustCompile(`(?is)<title\b[^>]*>(.*?)</title\s*>`)
+	htmlBlock    = regexp.MustCompile(`(?i)</?(p|div|br|li|ul|ol|tr|table|section|article|header|footer|h[1-6]|pre|blockquote)\b[^>]*>`)
+	htmlTag      = regexp.MustCompile(`(?s)<[^>]*>`)
+	spaceRuns    = regexp.MustCompile(`[ \t\r\f\v]+`)
+	newlineRuns  = regexp.MustCompile(`\n\s*\n\s*`)
+	spaceNewline = regexp.MustCompile(` *\n *`)
+)
+
+// htmlDropped matches elements whose content isn't readable text. RE2 has no
+// backreferences, so there is one expression per element.
+var htmlDropped = func() []*regexp.Regexp {
+	var dropped []*regexp.Regexp
+	for _, tag := range []string{"head", "script", "style", "noscript", "template", "svg"} {
+		dropped = append(dropped, regexp.MustCompile(`(?is)<`+tag+`\b.*?</`+tag+`\s*>`))
+	}
+	return dropped
+}()
+
+// htmlToText reduces an HTML document to its title and readable text
+func htmlToText(doc string) string {
+	var title string
+	if m := htmlTitle.FindStringSubmatch(doc); m != nil {
+		title = strings.TrimSpace(spaceRuns.ReplaceAllString(html.UnescapeString(htmlTag.ReplaceAllString(m[1], "")), " "))
+	}
+
+	text := htmlComment.ReplaceAllString(doc, "")
+	for _, dropped := range htmlDropped {
+		text = dropped.ReplaceAllString(text, "")
+	}
+	text = htmlBlock.ReplaceAllString(text, "\n")
+	text = htmlTag.ReplaceAllString(text, "")
+	text = html.UnescapeString(text)
+	text = strings.ReplaceAll(text, "\u00a0", " ")
+	text = spaceRuns.ReplaceAllString(text, " ")
+	text = spaceNewline.ReplaceAllString(text, "\n")
+	text = newlineRuns.ReplaceAllString(text, "\n\n")
+	text = strings.TrimSpace(text)
+
+	if title != "" && !strings.HasPrefix(text, title) {
+		return title + "\n\n" + text
+	}
+	return text
+}
+
+// truncate shortens text to at most max characters, marking the cut
+func truncate(text string, max int) string {
+	runes := []rune(text)
+	if len(runes) <= max {
+		return text
+	}
+	return strings.TrimSpace(string(runes[:max])) + "\n\n[truncated]"
+}
@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/errs"
+)
+
+func TestURLFetcherHTML(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprint(w, `<html><head><title>Release &amp; Notes</title><style>p { color: red }</style></head>
+<body><script>alert("x")</script><!-- hidden --><h1>Release &amp; Notes</h1>
+<p>Version <b>2.0</b> is out.</p><ul><li>Faster</li><li>Smaller</li></ul></body></html>`)
+	}))
+	defer server.Close()
+
+	fetcher := NewURLFetcher(&URLFetcherConfig{AllowPrivate: true})
+	got, err := fetcher.ProcessTask(context.Background(), server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "Release & Notes\n\nVersion 2.0 is out.\n\nFaster\n\nSmaller"
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestURLFetcherJSONAndTruncation(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/json":
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, `{"ok":true}`)
+		case "/long":
+			w.Header().Set("Content-Type", "text/plain")
+			fmt.Fprint(w, strings.Repeat("x", 100))
+		case "/image":
+			w.Header().Set("Content-Type", "image/png")
+			w.Write([]byte{0x89, 'P', 'N', 'G'})
+		}
+	}))
+	defer server.Close()
+
+	fetcher := NewURLFetcher(&URLFetcherConfig{AllowPrivate: true})
+	if got, err := fetcher.ProcessTask(context.Background(), server.URL+"/json"); err != nil || got != "{\n  \"ok\": true\n}" {
+		t.Errorf("json: got %q, %v", got, err)
+	}
+
+	fetcher = NewURLFetcher(&URLFetcherConfig{AllowPrivate: true, MaxChars: 10})
+	if got, err := fetcher.ProcessTask(context.Background(), server.URL+"/long"); err != nil || got != "xxxxxxxxxx\n\n[truncated]" {
+		t.Errorf("long: got %q, %v", got, err)
+	}
+	if _, err := fetcher.ProcessTask(context.Background(), server.URL+"/image"); errs.KindOf(err) != errs.KindUser {
+		t.Errorf("image: expected user error, got %v", err)
+	}
+}
+
+func TestURLFetcherStatusErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/missing":
+			w.WriteHeader(http.StatusNotFound)
+		case "/down":
+			w.WriteHeader(http.StatusBadGateway)
+		case "/busy":
+			w.Header().Set("Retry-After", "30")
+			w.WriteHeader(http.StatusTooManyRequests)
+		case "/loop":
+			http.Redirect(w, r, "/loop", http.StatusFound)
+		}
+	}))
+	defer server.Close()
+
+	fetcher := NewURLFetcher(&URLFetcherConfig{AllowPrivate: true})
+	tests := map[string]errs.Kind{
+		"/missing": errs.KindUser,
+		"/down":    errs.KindRetryable,
+		"/busy":    errs.KindRateLimited,
+		"/loop":    errs.KindRetryable,
+	}
+	for path, want := range tests {
+		_, err := fetcher.ProcessTask(context.Background(), server.URL+path)
+		if errs.KindOf(err) != want {
+			t.Errorf("%s: expected %s error, got %v", path, want, err)
+		}
+	}
+
+	_, err := fetcher.ProcessTask(context.Background(), server.URL+"/busy")
+	if errs.RetryAfter(err) != 30*time.Second {
+		t.Errorf("expected Retry-After to be kept, got %v", errs.RetryAfter(err))
+	}
+}
+
+func TestURLFetcherRefusesPrivateAddresses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "internal")
+	}))
+	defer server.Close()
+
+	fetcher := NewURLFetcher(nil)
+	_, err := fetcher.ProcessTask(context.Background(), server.URL)
+	if errs.KindOf(err) != errs.KindUser || !strings.Contains(err.Error(), "private address") {
+		t.Errorf("expected private address to be refused, got %v", err)
+	}
+
+	for _, task := range []string{"", "file:///etc/passwd", "ftp://example.com"} {
+		if _, err := fetcher.ProcessTask(context.Background(), task); errs.KindOf(err) != errs.KindUser {
+			t.Errorf("%q: expected user error, got %v", task, err)
+		}
+	}
+}
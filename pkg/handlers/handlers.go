@@ -0,0 +1,231 @@
+// Package handlers provides ready-made task handlers that can be mounted
+// behind capabilities: summarization and translation with a language model,
+// fetching web pages, converting CSV to JSON and scheduled reports.
+//
+// A Router combines them into one agent handler. Tasks are routed by the
+// capability they require or by a leading command word:
+//
+//	router := handlers.NewRouter()
+//	router.Mount("text/summarization", handlers.NewSummarizer(provider, nil), "summarize", "tldr")
+//	router.Mount("web/fetch", handlers.NewURLFetcher(nil), "fetch")
+//	router.Mount("data/conversion:csv-json", handlers.NewCSVToJSON(nil), "csv2json")
+//
+//	config.Capabilities = router.Capabilities()
+//	enhancedAgent, err := agent.NewEnhancedAgent(&agent.EnhancedAgentConfig{Config: config, AgentHandler: router})
+//
+// Every handler implements types.AgentHandler, so they can also be used on
+// their own or wrapped in custom handlers.
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/errs"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/schema"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/types"
+)
+
+// HandlerFunc adapts a function to types.AgentHandler
+type HandlerFunc func(ctx context.Context, task string) (string, error)
+
+// ProcessTask implements types.AgentHandler
+func (f HandlerFunc) ProcessTask(ctx context.Context, task string) (string, error) {
+	return f(ctx, task)
+}
+
+// route is a handler mounted behind a capability
+type route struct {
+	capability string
+	commands   []string
+	handler    types.AgentHandler
+	schema     json.RawMessage // JSON Schema of the task input, nil if not declared
+}
+
+// Router dispatches tasks to the handlers mounted behind capabilities
+type Router struct {
+	mu       sync.RWMutex
+	routes   []*route
+	fallback types.AgentHandler
+}
+
+// Verify interface compliance
+var (
+	_ types.AgentHandler        = (*Router)(nil)
+	_ types.InputSchemaProvider = (*Router)(nil)
+)
+
+// NewRouter creates an empty router
+func NewRouter() *Router {
+	return &Router{}
+}
+
+// Mount serves a capability with a handler. Tasks that require the capability
+// are routed to it, as are tasks starting with one of the commands, e.g.
+// "summarize <text>". The command is removed before the handler sees the task.
+func (r *Router) Mount(capability string, handler types.AgentHandler, commands ...string) error {
+	if strings.TrimSpace(capability) == "" {
+		return fmt.Errorf("capability is empty")
+	}
+	if handler == nil {
+		return fmt.Errorf("handler for %s is nil", capability)
+	}
+	if err := types.ValidateCapabilities([]string{capability}); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	normalized := make([]string, 0, len(commands))
+	for _, command := range commands {
+		command = normalizeCommand(command)
+		if command == "" {
+			continue
+		}
+		for _, existing := range r.routes {
+			for _, taken := range existing.commands {
+				if taken == command {
+					return fmt.Errorf("command %q is already mounted for %s", command, existing.capability)
+				}
+			}
+		}
+		normalized = append(normalized, command)
+	}
+	for _, existing := range r.routes {
+		if existing.capability == capability {
+			return fmt.Errorf("capability %s is already mounted", capability)
+		}
+	}
+
+	r.routes = append(r.routes, &route{capability: capability, commands: normalized, handler: handler})
+	return nil
+}
+
+// SetInputSchema declares the JSON Schema of the task input of a mounted
+// capability. Tasks requiring the capability are validated against it before
+// they reach the handler, and the schema is published with the capabilities.
+func (r *Router) SetInputSchema(capability string, inputSchema json.RawMessage) error {
+	if _, err := schema.Compile(inputSchema); err != nil {
+		return fmt.Errorf("input schema for %s: %w", capability, err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, route := range r.routes {
+		if route.capability == capability {
+			route.schema = inputSchema
+			return nil
+		}
+	}
+	return fmt.Errorf("capability %s is not mounted", capability)
+}
+
+// InputSchemas implements types.InputSchemaProvider
+func (r *Router) InputSchemas() map[string]json.RawMessage {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	schemas := make(map[string]json.RawMessage)
+	for _, route := range r.routes {
+		if route.schema != nil {
+			schemas[route.capability] = route.schema
+		}
+	}
+	return schemas
+}
+
+// Fallback sets the handler for tasks no mounted handler matches. Without
+// one, such tasks fail with a list of the available commands.
+func (r *Router) Fallback(handler types.AgentHandler) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.fallback = handler
+}
+
+// Capabilities returns the mounted capabilities, to advertise in the agent config
+func (r *Router) Capabilities() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	capabilities := make([]string, len(r.routes))
+	for i, route := range r.routes {
+		capabilities[i] = route.capability
+	}
+	return capabilities
+}
+
+// ProcessTask implements types.AgentHandler
+func (r *Router) ProcessTask(ctx context.Context, task string) (string, error) {
+	handler, input := r.match(ctx, task)
+	if handler == nil {
+		return "", errs.User(fmt.Errorf("unknown command, available commands: %s", strings.Join(r.commands(), ", ")))
+	}
+	return handler.ProcessTask(ctx, input)
+}
+
+// match returns the handler for a task and the input to pass to it
+func (r *Router) match(ctx context.Context, task string) (types.AgentHandler, string) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	// A capability the task requires takes precedence over its wording
+	if info, ok := types.TaskInfoFromContext(ctx); ok {
+		for _, required := range info.Capabilities {
+			for _, route := range r.routes {
+				if types.CapabilityMatches(route.capability, required) {
+					return route.handler, stripCommand(task, route.commands)
+				}
+			}
+		}
+	}
+
+	word, rest := splitCommand(task)
+	for _, route := range r.routes {
+		for _, command := range route.commands {
+			if word == command {
+				return route.handler, rest
+			}
+		}
+	}
+	return r.fallback, task
+}
+
+// commands returns the mounted commands in alphabetical order
+func (r *Router) commands() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var commands []string
+	for _, route := range r.routes {
+		commands = append(commands, route.commands...)
+	}
+	sort.Strings(commands)
+	return commands
+}
+
+// normalizeCommand lowercases a command and removes a leading slash
+func normalizeCommand(command string) string {
+	return strings.ToLower(strings.TrimPrefix(strings.TrimSpace(command), "/"))
+}
+
+// splitCommand splits a task into its normalized first word and the rest
+func splitCommand(task string) (string, string) {
+	task = strings.TrimSpace(task)
+	end := strings.IndexAny(task, " \t\r\n")
+	if end < 0 {
+		return normalizeCommand(strings.TrimSuffix(task, ":")), ""
+	}
+	return normalizeCommand(strings.TrimSuffix(task[:end], ":")), strings.TrimSpace(task[end:])
+}
+
+// stripCommand removes a leading command from a task routed by capability
+func stripCommand(task string, commands []string) string {
+	word, rest := splitCommand(task)
+	for _, command := range commands {
+		if word == command {
+			return rest
+		}
+	}
+	return strings.TrimSpace(task)
+}
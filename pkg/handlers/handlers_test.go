@@ -0,0 +1,275 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/errs"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/llm"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/types"
+)
+
+func echo(name string) HandlerFunc {
+	return func(ctx context.Context, task string) (string, error) {
+		return name + ":" + task, nil
+	}
+}
+
+func TestRouterRoutesByCommand(t *testing.T) {
+	router := NewRouter()
+	if err := router.Mount("text/summarization", echo("summarize"), "summarize", "/tldr"); err != nil {
+		t.Fatal(err)
+	}
+	if err := router.Mount("web/fetch", echo("fetch"), "fetch"); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]string{
+		"summarize some text":  "summarize:some text",
+		"TLDR: some text":      "summarize:some text",
+		"/tldr some text":      "summarize:some text",
+		"fetch https://a.test": "fetch:https://a.test",
+		"fetch":                "fetch:",
+	}
+	for task, want := range tests {
+		got, err := router.ProcessTask(context.Background(), task)
+		if err != nil {
+			t.Fatalf("%q: %v", task, err)
+		}
+		if got != want {
+			t.Errorf("%q: got %q, want %q", task, got, want)
+		}
+	}
+
+	if got := router.Capabilities(); len(got) != 2 || got[0] != "text/summarization" || got[1] != "web/fetch" {
+		t.Errorf("Capabilities() = %v", got)
+	}
+}
+
+func TestRouterPrefersRequiredCapability(t *testing.T) {
+	router := NewRouter()
+	router.Mount("text/summarization", echo("summarize"), "summarize")
+	router.Mount("web/fetch", echo("fetch"), "fetch")
+
+	ctx := types.WithTaskInfo(context.Background(), types.TaskInfo{Capabilities: []string{"web/fetch"}})
+	got, err := router.ProcessTask(ctx, "summarize https://a.test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "fetch:summarize https://a.test" {
+		t.Errorf("got %q", got)
+	}
+
+	got, _ = router.ProcessTask(ctx, "fetch https://a.test")
+	if got != "fetch:https://a.test" {
+		t.Errorf("command of the matched capability should be stripped, got %q", got)
+	}
+}
+
+func TestRouterUnknownCommand(t *testing.T) {
+	router := NewRouter()
+	router.Mount("web/fetch", echo("fetch"), "fetch")
+	router.Mount("text/summarization", echo("summarize"), "summarize")
+
+	_, err := router.ProcessTask(context.Background(), "dance")
+	if errs.KindOf(err) != errs.KindUser {
+		t.Fatalf("expected user error, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "fetch, summarize") {
+		t.Errorf("error should list the commands: %v", err)
+	}
+
+	router.Fallback(echo("fallback"))
+	if got, _ := router.ProcessTask(context.Background(), "dance"); got != "fallback:dance" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestRouterMountErrors(t *testing.T) {
+	router := NewRouter()
+	if err := router.Mount("web/fetch", nil); err == nil {
+		t.Error("expected error for nil handler")
+	}
+	if err := router.Mount("web/Not Valid!", echo("x")); err == nil {
+		t.Error("expected error for invalid capability")
+	}
+	if err := router.Mount(" ", echo("x")); err == nil {
+		t.Error("expected error for empty capability")
+	}
+	if err := router.Mount("web/fetch", echo("fetch"), "fetch"); err != nil {
+		t.Fatal(err)
+	}
+	if err := router.Mount("web/fetch", echo("again")); err == nil {
+		t.Error("expected error for duplicate capability")
+	}
+	if err := router.Mount("web/scrape", echo("scrape"), "FETCH"); err == nil {
+		t.Error("expected error for duplicate command")
+	}
+}
+
+func TestRouterInputSchemas(t *testing.T) {
+	router := NewRouter()
+	router.Mount("web/fetch", echo("fetch"), "fetch")
+	router.Mount("text/summarization", echo("summarize"), "summarize")
+
+	fetchSchema := json.RawMessage(`{"type": "string", "format": "uri"}`)
+	if err := router.SetInputSchema("web/fetch", fetchSchema); err != nil {
+		t.Fatal(err)
+	}
+	if err := router.SetInputSchema("web/scrape", fetchSchema); err == nil {
+		t.Error("expected error for a capability that is not mounted")
+	}
+	if err := router.SetInputSchema("text/summarization", json.RawMessage(`{"type": "text"}`)); err == nil {
+		t.Error("expected error for an invalid schema")
+	}
+
+	schemas := router.InputSchemas()
+	if len(schemas) != 1 || string(schemas["web/fetch"]) != string(fetchSchema) {
+		t.Errorf("InputSchemas() = %v", schemas)
+	}
+}
+
+// fakeProvider answers every request with a canned response and records the requests
+type fakeProvider struct {
+	mu       sync.Mutex
+	requests []*llm.Request
+	reply    func(req *llm.Request) (string, error)
+}
+
+func (p *fakeProvider) Name() string { return "fake" }
+
+func (p *fakeProvider) Complete(ctx context.Context, req *llm.Request) (*llm.Response, error) {
+	p.mu.Lock()
+	p.requests = append(p.requests, req)
+	p.mu.Unlock()
+	content, err := p.reply(req)
+	if err != nil {
+		return nil, err
+	}
+	return &llm.Response{Content: content}, nil
+}
+
+func (p *fakeProvider) Stream(ctx context.Context, req *llm.Request, handler llm.StreamHandler) error {
+	resp, err := p.Complete(ctx, req)
+	if err != nil {
+		return err
+	}
+	return handler(resp.Content)
+}
+
+func (p *fakeProvider) CountTokens(text string) int {
+	return len(strings.Fields(text))
+}
+
+func userMessage(req *llm.Request) string {
+	return req.Messages[len(req.Messages)-1].Content
+}
+
+func TestSummarizer(t *testing.T) {
+	provider := &fakeProvider{reply: func(req *llm.Request) (string, error) { return " short ", nil }}
+	summarizer := NewSummarizer(provider, &SummarizerConfig{MaxWords: 42, Model: "small"})
+
+	got, err := summarizer.ProcessTask(context.Background(), "a long text")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "short" {
+		t.Errorf("got %q", got)
+	}
+	req := provider.requests[0]
+	if req.Model != "small" || !strings.Contains(req.Messages[0].Content, "42 words") || userMessage(req) != "a long text" {
+		t.Errorf("unexpected request %+v", req)
+	}
+
+	if _, err := summarizer.ProcessTask(context.Background(), "  "); errs.KindOf(err) != errs.KindUser {
+		t.Errorf("expected user error for empty input, got %v", err)
+	}
+}
+
+func TestSummarizerChunksLongText(t *testing.T) {
+	provider := &fakeProvider{reply: func(req *llm.Request) (string, error) {
+		return "summary of " + strings.Fields(userMessage(req))[0], nil
+	}}
+	summarizer := NewSummarizer(provider, &SummarizerConfig{ChunkTokens: 10})
+
+	text := "one two three four five six. seven eight nine ten.\n\nalpha beta gamma delta epsilon zeta eta theta."
+	got, err := summarizer.ProcessTask(context.Background(), text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(provider.requests) != 3 {
+		t.Fatalf("expected 2 parts and 1 combining request, got %d requests", len(provider.requests))
+	}
+	if userMessage(provider.requests[0]) != "one two three four five six. seven eight nine ten." {
+		t.Errorf("first part %q", userMessage(provider.requests[0]))
+	}
+	if userMessage(provider.requests[2]) != "summary of one\n\nsummary of alpha" {
+		t.Errorf("combining request %q", userMessage(provider.requests[2]))
+	}
+	if got != "summary of summary" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestSummarizerError(t *testing.T) {
+	provider := &fakeProvider{reply: func(req *llm.Request) (string, error) { return "", errs.Retryable(errors.New("overloaded")) }}
+	_, err := NewSummarizer(provider, nil).ProcessTask(context.Background(), "text")
+	if errs.KindOf(err) != errs.KindRetryable {
+		t.Errorf("provider error kind should be kept, got %v", err)
+	}
+}
+
+func TestSplitText(t *testing.T) {
+	count := func(s string) int { return len(strings.Fields(s)) }
+	chunks := splitText("a b c d e f g h", 3, count)
+	if strings.Join(chunks, "|") != "a b c|d e f|g h" {
+		t.Errorf("got %q", chunks)
+	}
+	if chunks := splitText("short text", 3, count); len(chunks) != 1 {
+		t.Errorf("got %q", chunks)
+	}
+}
+
+func TestTranslator(t *testing.T) {
+	provider := &fakeProvider{reply: func(req *llm.Request) (string, error) { return "Bonjour", nil }}
+	translator := NewTranslator(provider, nil)
+
+	got, err := translator.ProcessTask(context.Background(), "to French: Good morning")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "Bonjour" {
+		t.Errorf("got %q", got)
+	}
+	req := provider.requests[0]
+	if !strings.Contains(req.Messages[0].Content, "into French") || userMessage(req) != "Good morning" {
+		t.Errorf("unexpected request %+v", req)
+	}
+
+	if _, err := translator.ProcessTask(context.Background(), "into German:"); errs.KindOf(err) != errs.KindUser {
+		t.Errorf("expected user error for empty text, got %v", err)
+	}
+}
+
+func TestParseTranslation(t *testing.T) {
+	tests := []struct {
+		task, language, text string
+	}{
+		{"to French: Good morning", "French", "Good morning"},
+		{"into pt-BR: Good morning", "pt-BR", "Good morning"},
+		{"Brazilian Portuguese: Good morning", "Brazilian Portuguese", "Good morning"},
+		{"Good morning", "", "Good morning"},
+		{"The time is 10:30", "", "The time is 10:30"},
+		{"Please read this carefully and then: reply", "", "Please read this carefully and then: reply"},
+	}
+	for _, tt := range tests {
+		language, text := parseTranslation(tt.task)
+		if language != tt.language || text != tt.text {
+			t.Errorf("%q: got (%q, %q), want (%q, %q)", tt.task, language, text, tt.language, tt.text)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/errs"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/llm"
+)
+
+// DefaultSummaryWords is the target length of a summary when none is configured
+const DefaultSummaryWords = 150
+
+// DefaultChunkTokens is the size of the parts long texts are summarized in when none is configured
+const DefaultChunkTokens = 3000
+
+// maxCombineRounds bounds how often partial summaries are summarized again
+const maxCombineRounds = 3
+
+// SummarizerConfig configures a Summarizer
+type SummarizerConfig struct {
+	MaxWords     int    // Target summary length in words (default DefaultSummaryWords)
+	ChunkTokens  int    // Longer texts are summarized in parts of about this many tokens, then combined (default DefaultChunkTokens)
+	SystemPrompt string // Replaces the default instructions; %d is replaced with MaxWords
+	Model        string // Overrides the provider's default model
+}
+
+// Summarizer summarizes the task text with a language model. Texts longer than
+// the model should see at once are split at paragraph and sentence boundaries,
+// summarized part by part and the partial summaries combined.
+type Summarizer struct {
+	provider llm.LLMProvider
+	config   SummarizerConfig
+}
+
+// NewSummarizer creates a summarizer. config may be nil for the defaults.
+func NewSummarizer(provider llm.LLMProvider, config *SummarizerConfig) *Summarizer {
+	s := &Summarizer{provider: provider}
+	if config != nil {
+		s.config = *config
+	}
+	if s.config.MaxWords <= 0 {
+		s.config.MaxWords = DefaultSummaryWords
+	}
+	if s.config.ChunkTokens <= 0 {
+		s.config.ChunkTokens = DefaultChunkTokens
+	}
+	if s.config.SystemPrompt == "" {
+		s.config.SystemPrompt = "Summarize the text you are given in at most %d words. Keep names, numbers and conclusions, " +
+			"leave out repetition and filler, and do not add information that is not in the text. Reply with the summary only."
+	}
+	return s
+}
+
+// ProcessTask implements types.AgentHandler
+func (s *Summarizer) ProcessTask(ctx context.Context, task string) (string, error) {
+	text := strings.TrimSpace(task)
+	if text == "" {
+		return "", errs.User(fmt.Errorf("nothing to summarize, send the text after the command"))
+	}
+
+	chunks := splitText(text, s.config.ChunkTokens, s.provider.CountTokens)
+	for round := 0; ; round++ {
+		summaries := make([]string, 0, len(chunks))
+		for i, chunk := range chunks {
+			summary, err := s.summarize(ctx, chunk)
+			if err != nil {
+				return "", fmt.Errorf("failed to summarize part %d of %d: %w", i+1, len(chunks), err)
+			}
+			summaries = append(summaries, summary)
+		}
+		if len(summaries) == 1 {
+			return summaries[0], nil
+		}
+
+		// Summarize the partial summaries until they fit into one request
+		combined := strings.Join(summaries, "\n\n")
+		if round == maxCombineRounds-1 {
+			return s.summarize(ctx, combined)
+		}
+		chunks = splitText(combined, s.config.ChunkTokens, s.provider.CountTokens)
+	}
+}
+
+func (s *Summarizer) summarize(ctx context.Context, text string) (string, error) {
+	prompt := s.config.SystemPrompt
+	if strings.Contains(prompt, "%d") {
+		prompt = fmt.Sprintf(prompt, s.config.MaxWords)
+	}
+	req := llm.NewRequest(prompt, text)
+	req.Model = s.config.Model
+	resp, err := s.provider.Complete(ctx, req)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(resp.Content), nil
+}
+
+// splitText splits text into parts of at most maxTokens tokens, preferring
+// paragraph, then sentence, then word boundaries
+func splitText(text string, maxTokens int, countTokens func(string) int) []string {
+	if countTokens(text) <= maxTokens {
+		return []string{text}
+	}
+
+	var chunks []string
+	var current strings.Builder
+	flush := func() {
+		if current.Len() > 0 {
+			chunks = append(chunks, strings.TrimSpace(current.String()))
+			current.Reset()
+		}
+	}
+	add := func(piece, separator string) {
+		if current.Len() > 0 && countTokens(current.String()+separator+piece) > maxTokens {
+			flush()
+		}
+		if current.Len() > 0 {
+			current.WriteString(separator)
+		}
+		current.WriteString(piece)
+	}
+
+	for _, paragraph := range strings.Split(text, "\n\n") {
+		paragraph = strings.TrimSpace(paragraph)
+		if paragraph == "" {
+			continue
+		}
+		if countTokens(paragraph) <= maxTokens {
+			add(paragraph, "\n\n")
+			continue
+		}
+		for _, sentence := range splitSentences(paragraph) {
+			if countTokens(sentence) <= maxTokens {
+				add(sentence, " ")
+				continue
+			}
+			for _, word := range strings.Fields(sentence) {
+				add(word, " ")
+			}
+		}
+	}
+	flush()
+	return chunks
+}
+
+// splitSentences splits a paragraph after sentence-ending punctuation
+func splitSentences(paragraph string) []string {
+	var sentences []string
+	start := 0
+	for i := 0; i < len(paragraph); i++ {
+		switch paragraph[i] {
+		case '.', '!', '?':
+			if i+1 == len(paragraph) || paragraph[i+1] == ' ' || paragraph[i+1] == '\n' {
+				sentences = append(sentences, strings.TrimSpace(paragraph[start:i+1]))
+				start = i + 1
+			}
+		}
+	}
+	if rest := strings.TrimSpace(paragraph[start:]); rest != "" {
+		sentences = append(sentences, rest)
+	}
+	return sentences
+}
@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/errs"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/llm"
+)
+
+// DefaultTargetLanguage is the language texts are translated into when the task names none
+const DefaultTargetLanguage = "English"
+
+// TranslatorConfig configures a Translator
+type TranslatorConfig struct {
+	DefaultLanguage string // Target language when the task names none (default DefaultTargetLanguage)
+	Model           string // Overrides the provider's default model
+}
+
+// Translator translates the task text with a language model. The target
+// language is taken from the start of the task, e.g. "to French: Good
+// morning", "into pt-BR: Good morning" or "German: Good morning".
+type Translator struct {
+	provider llm.LLMProvider
+	config   TranslatorConfig
+}
+
+// NewTranslator creates a translator. config may be nil for the defaults.
+func NewTranslator(provider llm.LLMProvider, config *TranslatorConfig) *Translator {
+	t := &Translator{provider: provider}
+	if config != nil {
+		t.config = *config
+	}
+	if t.config.DefaultLanguage == "" {
+		t.config.DefaultLanguage = DefaultTargetLanguage
+	}
+	return t
+}
+
+// ProcessTask implements types.AgentHandler
+func (t *Translator) ProcessTask(ctx context.Context, task string) (string, error) {
+	language, text := parseTranslation(task)
+	if language == "" {
+		language = t.config.DefaultLanguage
+	}
+	if text == "" {
+		return "", errs.User(fmt.Errorf("nothing to translate, send e.g. \"to French: Good morning\""))
+	}
+
+	prompt := fmt.Sprintf("You are a professional translator. Translate the text you are given into %s. "+
+		"Keep the meaning, tone and formatting, leave names, code and URLs unchanged, and reply with the translation only.", language)
+	req := llm.NewRequest(prompt, text)
+	req.Model = t.config.Model
+	resp, err := t.provider.Complete(ctx, req)
+	if err != nil {
+		return "", fmt.Errorf("failed to translate: %w", err)
+	}
+	return strings.TrimSpace(resp.Content), nil
+}
+
+// parseTranslation splits a task into the target language and the text
+func parseTranslation(task string) (string, string) {
+	task = strings.TrimSpace(task)
+	prefix, text, ok := strings.Cut(task, ":")
+	if !ok {
+		return "", task
+	}
+
+	prefix = strings.TrimSpace(prefix)
+	lower := strings.ToLower(prefix)
+	for _, word := range []string{"to ", "into "} {
+		if strings.HasPrefix(lower, word) {
+			prefix = strings.TrimSpace(prefix[len(word):])
+			break
+		}
+	}
+	if !isLanguageName(prefix) {
+		return "", task
+	}
+	return prefix, strings.TrimSpace(text)
+}
+
+// isLanguageName reports whether s looks like a language name or code,
+// e.g. "French", "Brazilian Portuguese" or "pt-BR"
+func isLanguageName(s string) bool {
+	words := strings.Fields(s)
+	if len(words) == 0 || len(words) > 3 {
+		return false
+	}
+	for _, r := range s {
+		if !unicode.IsLetter(r) && r != ' ' && r != '-' && r != '_' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,315 @@
+package health
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/types"
+)
+
+// MetricsNamespace is the prefix of every exported metric name
+const MetricsNamespace = "teneo_agent"
+
+// DefaultLatencyBuckets are the task latency histogram buckets in seconds
+var DefaultLatencyBuckets = []float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60}
+
+// Metrics collects agent metrics and exports them in the Prometheus text format.
+// It implements the types.MetricsRecorder, types.DeadlineRecorder,
+// types.PanicRecorder, types.LLMUsageRecorder, types.GuardrailRecorder and
+// types.AccessRecorder interfaces.
+type Metrics struct {
+	mu            sync.Mutex
+	tasks         map[string]uint64 // Completed tasks by status
+	rejected      map[string]uint64 // Rejected tasks by reason
+	deadlines     map[string]uint64 // Deadline outcomes of tasks with a server deadline
+	panics        map[string]uint64 // Panics recovered from handlers by handler type
+	llmTokens     map[string]uint64 // LLM tokens used by tasks by kind (prompt or completion)
+	llmCost       float64           // Cost of the LLM tokens used by tasks
+	guardrail     map[string]uint64 // Content flagged by the guardrail by direction (input or output)
+	accessDenied  map[string]uint64 // Tasks denied by access control by reason
+	buckets       []float64
+	bucketCounts  []uint64
+	durationSum   float64
+	durationCount uint64
+	funcs         []funcMetric
+	labels        string // Labels of every sample, set with SetLabel
+}
+
+// funcMetric is a counter or gauge whose value is read at scrape time;
+// labeled counters read a value per label value instead
+type funcMetric struct {
+	name    string
+	help    string
+	kind    string
+	valueF  func() float64
+	label   string
+	valuesF func() map[string]uint64
+}
+
+// NewMetrics creates a new metrics collector
+func NewMetrics() *Metrics {
+	return &Metrics{
+		tasks:        make(map[string]uint64),
+		rejected:     make(map[string]uint64),
+		deadlines:    make(map[string]uint64),
+		panics:       make(map[string]uint64),
+		llmTokens:    make(map[string]uint64),
+		guardrail:    make(map[string]uint64),
+		accessDenied: make(map[string]uint64),
+		buckets:      DefaultLatencyBuckets,
+		bucketCounts: make([]uint64, len(DefaultLatencyBuckets)),
+	}
+}
+
+// ObserveTask records a finished task with its status and latency
+func (m *Metrics) ObserveTask(status string, duration time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.tasks[status]++
+
+	seconds := duration.Seconds()
+	m.durationSum += seconds
+	m.durationCount++
+	for i, bound := range m.buckets {
+		if seconds <= bound {
+			m.bucketCounts[i]++
+		}
+	}
+}
+
+// RecordTaskRejected records a task rejected before execution (rate limit, quota, ...)
+func (m *Metrics) RecordTaskRejected(reason string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.rejected[reason]++
+}
+
+// RecordDeadline records whether a task with a deadline was done in time
+// ("met", "missed", "expired" or "at_risk")
+func (m *Metrics) RecordDeadline(outcome string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.deadlines[outcome]++
+}
+
+// RecordHandlerPanic records a panic recovered from an agent handler
+func (m *Metrics) RecordHandlerPanic(handler string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.panics[handler]++
+}
+
+// RecordLLMUsage records the LLM tokens a task used and their cost. The room
+// is not a label, per-room usage is reported by the usage ledger.
+func (m *Metrics) RecordLLMUsage(room string, usage types.LLMUsage) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.llmTokens["prompt"] += uint64(usage.PromptTokens)
+	m.llmTokens["completion"] += uint64(usage.CompletionTokens)
+	m.llmCost += usage.Cost
+}
+
+// RecordGuardrailFlag records content flagged by the guardrail ("input" or "output")
+func (m *Metrics) RecordGuardrailFlag(direction string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.guardrail[direction]++
+}
+
+// RecordAccessDenied records a task denied by access control, by reason
+func (m *Metrics) RecordAccessDenied(reason string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.accessDenied[reason]++
+}
+
+// RegisterCounterFunc exports a counter whose value is read from fn on every scrape
+func (m *Metrics) RegisterCounterFunc(name, help string, fn func() float64) {
+	m.registerFunc(name, help, "counter", fn)
+}
+
+// RegisterGaugeFunc exports a gauge whose value is read from fn on every scrape
+func (m *Metrics) RegisterGaugeFunc(name, help string, fn func() float64) {
+	m.registerFunc(name, help, "gauge", fn)
+}
+
+// RegisterLabeledCounterFunc exports a counter with one label whose values
+// are read from fn on every scrape
+func (m *Metrics) RegisterLabeledCounterFunc(name, help, label string, fn func() map[string]uint64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.funcs = append(m.funcs, funcMetric{name: name, help: help, kind: "counter", label: label, valuesF: fn})
+}
+
+// registerFunc adds a scrape-time metric
+func (m *Metrics) registerFunc(name, help, kind string, fn func() float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.funcs = append(m.funcs, funcMetric{name: name, help: help, kind: kind, valueF: fn})
+}
+
+// SetLabel adds a label to every sample, e.g. the agent name when the
+// metrics of several agents are served together. It must be called before
+// the metrics are scraped.
+func (m *Metrics) SetLabel(name, value string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.labels != "" {
+		m.labels += ","
+	}
+	m.labels += fmt.Sprintf(`%s="%s"`, name, escapeLabel(value))
+}
+
+// family is a metric with its samples in the text format
+type family struct {
+	name    string
+	help    string
+	kind    string
+	samples []string
+}
+
+// WriteTo writes all metrics in the Prometheus text exposition format
+func (m *Metrics) WriteTo(w io.Writer) (int64, error) {
+	return writeFamilies(w, m.families())
+}
+
+// WriteAll writes the metrics of several collectors as one exposition,
+// merging the samples of metrics with the same name. The collectors should
+// be told apart by a label set with SetLabel.
+func WriteAll(w io.Writer, collectors ...*Metrics) (int64, error) {
+	var merged []family
+	index := make(map[string]int)
+	for _, m := range collectors {
+		for _, f := range m.families() {
+			if i, ok := index[f.name]; ok {
+				merged[i].samples = append(merged[i].samples, f.samples...)
+				continue
+			}
+			index[f.name] = len(merged)
+			merged = append(merged, f)
+		}
+	}
+	return writeFamilies(w, merged)
+}
+
+// families reads the current value of every metric
+func (m *Metrics) families() []family {
+	m.mu.Lock()
+	labels := m.labels
+	families := []family{
+		labeledFamily("tasks_total", "Tasks processed by status", labels, "status", m.tasks),
+		labeledFamily("tasks_rejected_total", "Tasks rejected before execution by reason", labels, "reason", m.rejected),
+		labeledFamily("task_deadlines_total", "Outcomes of tasks with a server deadline", labels, "outcome", m.deadlines),
+		labeledFamily("handler_panics_total", "Panics recovered from the agent handler by handler type", labels, "handler", m.panics),
+		labeledFamily("llm_tokens_total", "LLM tokens used by tasks by kind", labels, "kind", m.llmTokens),
+		labeledFamily("guardrail_flags_total", "Content flagged by the guardrail by direction", labels, "direction", m.guardrail),
+		labeledFamily("access_denied_total", "Tasks denied by access control by reason", labels, "reason", m.accessDenied),
+	}
+	cost := MetricsNamespace + "_llm_cost_total"
+	families = append(families, family{name: cost, help: "Cost of the LLM tokens used by tasks", kind: "counter", samples: []string{sample(cost, labels, "", formatFloat(m.llmCost))}})
+
+	name := MetricsNamespace + "_task_duration_seconds"
+	histogram := family{name: name, help: "Task execution latency in seconds", kind: "histogram"}
+	for i, bound := range m.buckets {
+		histogram.samples = append(histogram.samples, sample(name+"_bucket", labels, fmt.Sprintf(`le="%s"`, formatFloat(bound)), strconv.FormatUint(m.bucketCounts[i], 10)))
+	}
+	histogram.samples = append(histogram.samples,
+		sample(name+"_bucket", labels, `le="+Inf"`, strconv.FormatUint(m.durationCount, 10)),
+		sample(name+"_sum", labels, "", formatFloat(m.durationSum)),
+		sample(name+"_count", labels, "", strconv.FormatUint(m.durationCount, 10)))
+	families = append(families, histogram)
+
+	funcs := make([]funcMetric, len(m.funcs))
+	copy(funcs, m.funcs)
+	m.mu.Unlock()
+
+	// Read scrape-time values without holding the lock
+	for _, f := range funcs {
+		if f.valuesF != nil {
+			families = append(families, labeledFamily(f.name, f.help, labels, f.label, f.valuesF()))
+			continue
+		}
+		name := MetricsNamespace + "_" + f.name
+		families = append(families, family{name: name, help: f.help, kind: f.kind, samples: []string{sample(name, labels, "", formatFloat(f.valueF()))}})
+	}
+	return families
+}
+
+// Handler returns an HTTP handler serving the metrics
+func (m *Metrics) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		m.WriteTo(w)
+	})
+}
+
+// GroupHandler returns an HTTP handler serving the metrics of the collectors
+// returned by collectors, merged with WriteAll
+func GroupHandler(collectors func() []*Metrics) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		WriteAll(w, collectors()...)
+	})
+}
+
+// writeFamilies writes metrics in the text exposition format
+func writeFamilies(w io.Writer, families []family) (int64, error) {
+	var b strings.Builder
+	for _, f := range families {
+		fmt.Fprintf(&b, "# HELP %s %s\n", f.name, f.help)
+		fmt.Fprintf(&b, "# TYPE %s %s\n", f.name, f.kind)
+		for _, s := range f.samples {
+			b.WriteString(s)
+			b.WriteByte('\n')
+		}
+	}
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+// labeledFamily returns a counter with a single label, sorted by label value
+func labeledFamily(metric, help, labels, label string, values map[string]uint64) family {
+	f := family{name: MetricsNamespace + "_" + metric, help: help, kind: "counter"}
+
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		f.samples = append(f.samples, sample(f.name, labels, fmt.Sprintf(`%s="%s"`, label, escapeLabel(key)), strconv.FormatUint(values[key], 10)))
+	}
+	return f
+}
+
+// sample formats a sample line with the collector's labels followed by its own
+func sample(name, labels, own, value string) string {
+	switch {
+	case labels != "" && own != "":
+		labels += "," + own
+	case own != "":
+		labels = own
+	}
+	if labels == "" {
+		return name + " " + value
+	}
+	return name + "{" + labels + "} " + value
+}
+
+// escapeLabel escapes a label value for the text format
+func escapeLabel(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
+
+// formatFloat formats a sample value for the text format
+func formatFloat(v float64) string {
+	return fmt.Sprintf("%g", v)
+}
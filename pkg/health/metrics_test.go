@@ -0,0 +1,109 @@
+package health
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/types"
+)
+
+func TestMetricsExport(t *testing.T) {
+	m := NewMetrics()
+	m.ObserveTask("success", 200*time.Millisecond)
+	m.ObserveTask("success", 3*time.Second)
+	m.ObserveTask("error", 50*time.Millisecond)
+	m.RecordTaskRejected("rate_limit_exceeded")
+	m.RecordDeadline("met")
+	m.RecordDeadline("met")
+	m.RecordDeadline("missed")
+	m.RecordHandlerPanic("streaming")
+	m.RecordLLMUsage("room-1", types.LLMUsage{PromptTokens: 120, CompletionTokens: 30, Cost: 0.25})
+	m.RecordLLMUsage("room-2", types.LLMUsage{PromptTokens: 80, CompletionTokens: 20, Cost: 0.5})
+	m.RecordGuardrailFlag("input")
+	m.RecordAccessDenied("sender_denied")
+	m.RegisterGaugeFunc("retry_queue_size", "Messages waiting in the retry queue", func() float64 { return 4 })
+	m.RegisterCounterFunc("reconnects_total", "Successful reconnections", func() float64 { return 2 })
+	m.RegisterLabeledCounterFunc("room_sent_bytes_total", "Bytes sent by room", "room", func() map[string]uint64 {
+		return map[string]uint64{"room-1": 512}
+	})
+
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body := rec.Body.String()
+
+	tests := []struct {
+		name string
+		line string
+	}{
+		{"tasks by status", `teneo_agent_tasks_total{status="success"} 2`},
+		{"failed tasks", `teneo_agent_tasks_total{status="error"} 1`},
+		{"rejections", `teneo_agent_tasks_rejected_total{reason="rate_limit_exceeded"} 1`},
+		{"deadlines met", `teneo_agent_task_deadlines_total{outcome="met"} 2`},
+		{"deadlines missed", `teneo_agent_task_deadlines_total{outcome="missed"} 1`},
+		{"handler panics", `teneo_agent_handler_panics_total{handler="streaming"} 1`},
+		{"prompt tokens", `teneo_agent_llm_tokens_total{kind="prompt"} 200`},
+		{"completion tokens", `teneo_agent_llm_tokens_total{kind="completion"} 50`},
+		{"llm cost", `teneo_agent_llm_cost_total 0.75`},
+		{"guardrail flags", `teneo_agent_guardrail_flags_total{direction="input"} 1`},
+		{"access denials", `teneo_agent_access_denied_total{reason="sender_denied"} 1`},
+		{"bucket below first observation", `teneo_agent_task_duration_seconds_bucket{le="0.1"} 1`},
+		{"cumulative bucket", `teneo_agent_task_duration_seconds_bucket{le="5"} 3`},
+		{"inf bucket", `teneo_agent_task_duration_seconds_bucket{le="+Inf"} 3`},
+		{"histogram count", `teneo_agent_task_duration_seconds_count 3`},
+		{"gauge func", `teneo_agent_retry_queue_size 4`},
+		{"counter func type", `# TYPE teneo_agent_reconnects_total counter`},
+		{"labeled counter func", `teneo_agent_room_sent_bytes_total{room="room-1"} 512`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !strings.Contains(body, tt.line+"\n") {
+				t.Errorf("expected line %q in output:\n%s", tt.line, body)
+			}
+		})
+	}
+}
+
+func TestServerMetricsRoute(t *testing.T) {
+	server := NewServer(0, &AgentInfo{Name: "test"}, nil)
+	if _, ok := server.handlers["/metrics"]; ok {
+		t.Fatal("metrics route registered before SetMetrics")
+	}
+
+	server.SetMetrics(NewMetrics())
+	if _, ok := server.handlers["/metrics"]; !ok {
+		t.Error("expected /metrics route after SetMetrics")
+	}
+}
+
+func TestWriteAll(t *testing.T) {
+	first, second := NewMetrics(), NewMetrics()
+	first.SetLabel("agent", "alpha")
+	second.SetLabel("agent", "beta")
+	first.ObserveTask("success", time.Second)
+	second.RegisterGaugeFunc("active_tasks", "Tasks currently executing", func() float64 { return 2 })
+	first.RegisterGaugeFunc("active_tasks", "Tasks currently executing", func() float64 { return 1 })
+
+	var b strings.Builder
+	if _, err := WriteAll(&b, first, second); err != nil {
+		t.Fatal(err)
+	}
+	body := b.String()
+
+	for _, line := range []string{
+		`teneo_agent_tasks_total{agent="alpha",status="success"} 1`,
+		`teneo_agent_task_duration_seconds_count{agent="beta"} 0`,
+		`teneo_agent_active_tasks{agent="alpha"} 1`,
+		`teneo_agent_active_tasks{agent="beta"} 2`,
+	} {
+		if !strings.Contains(body, line+"\n") {
+			t.Errorf("expected line %q in output:\n%s", line, body)
+		}
+	}
+	if n := strings.Count(body, "# TYPE teneo_agent_active_tasks gauge"); n != 1 {
+		t.Errorf("expected one TYPE line per metric, got %d", n)
+	}
+}
@@ -0,0 +1,159 @@
+package health
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Check is a condition the agent must meet to be ready for traffic
+type Check string
+
+const (
+	CheckConnected     Check = "connected"     // The WebSocket connection is up
+	CheckAuthenticated Check = "authenticated" // The session is authenticated
+	CheckRegistered    Check = "registered"    // The server accepted the registration
+	CheckCircuits      Check = "circuits"      // No circuit breaker is open
+)
+
+// AllChecks returns every readiness check, the default criteria
+func AllChecks() []Check {
+	return []Check{CheckConnected, CheckAuthenticated, CheckRegistered, CheckCircuits}
+}
+
+// ParseChecks parses a comma-separated list of checks ("all" or empty means every check)
+func ParseChecks(list string) ([]Check, error) {
+	list = strings.TrimSpace(list)
+	if list == "" || strings.EqualFold(list, "all") {
+		return AllChecks(), nil
+	}
+
+	var checks []Check
+	for _, name := range strings.Split(list, ",") {
+		check := Check(strings.ToLower(strings.TrimSpace(name)))
+		if !isCheck(check) {
+			return nil, fmt.Errorf("unknown readiness check %q", name)
+		}
+		checks = append(checks, check)
+	}
+	return checks, nil
+}
+
+// isCheck reports whether check is a known check
+func isCheck(check Check) bool {
+	for _, known := range AllChecks() {
+		if check == known {
+			return true
+		}
+	}
+	return false
+}
+
+// ReadinessGetter is optionally implemented by a StatusGetter to report
+// registration and circuit breakers. Without it, registered follows
+// authenticated and circuits always pass.
+type ReadinessGetter interface {
+	IsRegistered() bool     // Registered with the server now
+	HasRegistered() bool    // Registered at least once since the agent started
+	OpenCircuits() []string // Message classes whose circuit breaker is open
+}
+
+// ProbeResult is the response of /readyz and /startupz
+type ProbeResult struct {
+	Ready     bool           `json:"ready"`
+	Checks    map[Check]bool `json:"checks"`
+	Failed    []Check        `json:"failed,omitempty"`
+	Circuits  []string       `json:"open_circuits,omitempty"`
+	Timestamp time.Time      `json:"timestamp"`
+}
+
+// SetReadinessChecks sets the checks /readyz requires (default AllChecks).
+// It must be called before Start.
+func (s *Server) SetReadinessChecks(checks []Check) {
+	s.readinessChecks = checks
+}
+
+// Ready runs the readiness checks
+func (s *Server) Ready() *ProbeResult {
+	checks := s.readinessChecks
+	if checks == nil {
+		checks = AllChecks()
+	}
+	return s.probe(checks, false)
+}
+
+// Started reports whether the agent has registered once since it started,
+// so a startup probe can hold off the liveness probe until then
+func (s *Server) Started() *ProbeResult {
+	return s.probe([]Check{CheckRegistered}, true)
+}
+
+// probe runs checks; once makes registered pass if the agent ever registered
+func (s *Server) probe(checks []Check, once bool) *ProbeResult {
+	getter, _ := s.statusGetter.(ReadinessGetter)
+	result := &ProbeResult{Ready: true, Checks: make(map[Check]bool, len(checks)), Timestamp: time.Now()}
+	for _, check := range checks {
+		var passed bool
+		switch check {
+		case CheckConnected:
+			passed = s.statusGetter.IsConnected()
+		case CheckAuthenticated:
+			passed = s.statusGetter.IsAuthenticated()
+		case CheckRegistered:
+			switch {
+			case getter == nil:
+				passed = s.statusGetter.IsAuthenticated()
+			case once:
+				passed = getter.HasRegistered()
+			default:
+				passed = getter.IsRegistered()
+			}
+		case CheckCircuits:
+			if getter != nil {
+				result.Circuits = getter.OpenCircuits()
+			}
+			passed = len(result.Circuits) == 0
+		}
+		result.Checks[check] = passed
+		if !passed {
+			result.Ready = false
+			result.Failed = append(result.Failed, check)
+		}
+	}
+	return result
+}
+
+// livenessHandler reports that the process is up and serving requests. It
+// does not depend on the network, so a lost connection doesn't get the
+// container restarted while the agent reconnects.
+func (s *Server) livenessHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":    "alive",
+		"uptime":    s.statusGetter.GetUptime().String(),
+		"timestamp": time.Now(),
+	})
+}
+
+// readinessHandler answers 200 when every readiness check passes and 503 otherwise
+func (s *Server) readinessHandler(w http.ResponseWriter, r *http.Request) {
+	writeProbe(w, s.Ready())
+}
+
+// startupHandler answers 200 once the agent has registered and 503 before
+func (s *Server) startupHandler(w http.ResponseWriter, r *http.Request) {
+	writeProbe(w, s.Started())
+}
+
+func writeProbe(w http.ResponseWriter, result *ProbeResult) {
+	w.Header().Set("Content-Type", "application/json")
+	if result.Ready {
+		w.WriteHeader(http.StatusOK)
+	} else {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(result)
+}
@@ -0,0 +1,100 @@
+package health
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type fakeReadiness struct {
+	connected, authenticated bool
+	registered, started      bool
+	open                     []string
+}
+
+func (f *fakeReadiness) IsConnected() bool        { return f.connected }
+func (f *fakeReadiness) IsAuthenticated() bool    { return f.authenticated }
+func (f *fakeReadiness) GetActiveTaskCount() int  { return 0 }
+func (f *fakeReadiness) GetUptime() time.Duration { return time.Minute }
+func (f *fakeReadiness) IsRegistered() bool       { return f.registered }
+func (f *fakeReadiness) HasRegistered() bool      { return f.started }
+func (f *fakeReadiness) OpenCircuits() []string   { return f.open }
+
+func probe(t *testing.T, handler http.HandlerFunc) (int, ProbeResult) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	handler(rec, httptest.NewRequest("GET", "/", nil))
+	var result ProbeResult
+	if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	return rec.Code, result
+}
+
+func TestReadiness(t *testing.T) {
+	status := &fakeReadiness{connected: true, authenticated: true}
+	server := NewServer(0, &AgentInfo{Name: "test-agent"}, status)
+
+	code, result := probe(t, server.readinessHandler)
+	if code != http.StatusServiceUnavailable || result.Ready {
+		t.Fatalf("expected not ready before registration, got %d %+v", code, result)
+	}
+	if len(result.Failed) != 1 || result.Failed[0] != CheckRegistered {
+		t.Errorf("expected only registered to fail, got %v", result.Failed)
+	}
+
+	status.registered, status.started = true, true
+	if code, result = probe(t, server.readinessHandler); code != http.StatusOK || !result.Ready {
+		t.Fatalf("expected ready, got %d %+v", code, result)
+	}
+
+	status.open = []string{"task_response"}
+	code, result = probe(t, server.readinessHandler)
+	if code != http.StatusServiceUnavailable || len(result.Circuits) != 1 || !result.Checks[CheckConnected] || result.Checks[CheckCircuits] {
+		t.Errorf("expected open circuit to fail readiness, got %d %+v", code, result)
+	}
+
+	// Only the configured checks count
+	server.SetReadinessChecks([]Check{CheckConnected, CheckAuthenticated})
+	if code, _ = probe(t, server.readinessHandler); code != http.StatusOK {
+		t.Errorf("expected ready without the circuits check, got %d", code)
+	}
+}
+
+func TestStartupAndLiveness(t *testing.T) {
+	status := &fakeReadiness{}
+	server := NewServer(0, &AgentInfo{Name: "test-agent"}, status)
+
+	if code, _ := probe(t, server.startupHandler); code != http.StatusServiceUnavailable {
+		t.Errorf("expected startup probe to fail before the first registration, got %d", code)
+	}
+	rec := httptest.NewRecorder()
+	server.livenessHandler(rec, httptest.NewRequest("GET", "/livez", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("expected liveness while disconnected, got %d", rec.Code)
+	}
+
+	// A later disconnect fails readiness but not the startup probe
+	status.started = true
+	if code, _ := probe(t, server.startupHandler); code != http.StatusOK {
+		t.Errorf("expected startup probe to pass after registering once, got %d", code)
+	}
+	if code, _ := probe(t, server.readinessHandler); code != http.StatusServiceUnavailable {
+		t.Errorf("expected disconnected agent not to be ready, got %d", code)
+	}
+}
+
+func TestParseChecks(t *testing.T) {
+	checks, err := ParseChecks(" Connected, registered ")
+	if err != nil || len(checks) != 2 || checks[0] != CheckConnected || checks[1] != CheckRegistered {
+		t.Errorf("unexpected checks %v, %v", checks, err)
+	}
+	if checks, _ := ParseChecks(""); len(checks) != len(AllChecks()) {
+		t.Errorf("expected every check by default, got %v", checks)
+	}
+	if _, err := ParseChecks("connected,healthy"); err == nil {
+		t.Error("expected an error for an unknown check")
+	}
+}
@@ -3,10 +3,12 @@ package health
 import (
 	"encoding/json"
 	"fmt"
-	"log"
 	"net/http"
 	"strings"
 	"time"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/logging"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/types"
 )
 
 // Server provides health monitoring endpoints
@@ -15,6 +17,9 @@ type Server struct {
 	agentInfo    *AgentInfo
 	statusGetter StatusGetter
 	server       *http.Server
+	handlers     map[string]http.Handler
+
+	readinessChecks []Check
 }
 
 // AgentInfo contains basic agent information
@@ -24,6 +29,10 @@ type AgentInfo struct {
 	Wallet       string   `json:"wallet"`
 	Capabilities []string `json:"capabilities"`
 	Description  string   `json:"description"`
+	IdentityMode string   `json:"identity_mode,omitempty"` // "nft", "wallet-only" or "anonymous"
+
+	Resources *types.ComputeResources `json:"resources,omitempty"`
+	Manifest  []types.AgentCapability `json:"manifest,omitempty"`
 }
 
 // StatusGetter interface for getting agent status
@@ -34,6 +43,23 @@ type StatusGetter interface {
 	GetUptime() time.Duration
 }
 
+// ProgressGetter is optionally implemented by a StatusGetter to report the progress of running tasks
+type ProgressGetter interface {
+	GetTaskProgress() []types.TaskProgress
+}
+
+// MetadataDriftGetter is optionally implemented by a StatusGetter to report
+// whether the agent's NFT metadata matches its config
+type MetadataDriftGetter interface {
+	GetMetadataDrift() *types.MetadataDrift // nil when not checked
+}
+
+// ProgressSummary reports the progress of running tasks
+type ProgressSummary struct {
+	Overall float64              `json:"overall"` // Mean percent across tasks that reported progress
+	Tasks   []types.TaskProgress `json:"tasks"`
+}
+
 // HealthStatus represents the agent's health status
 type HealthStatus struct {
 	Status        string    `json:"status"`
@@ -43,6 +69,12 @@ type HealthStatus struct {
 	Uptime        string    `json:"uptime"`
 	Timestamp     time.Time `json:"timestamp"`
 	Agent         AgentInfo `json:"agent"`
+
+	// Progress of running tasks (omitted when no task reported progress)
+	Progress *ProgressSummary `json:"progress,omitempty"`
+
+	// Last comparison of the NFT metadata with the config (omitted when not checked)
+	MetadataDrift *types.MetadataDrift `json:"metadata_drift,omitempty"`
 }
 
 // NewServer creates a new health monitoring server
@@ -51,9 +83,22 @@ func NewServer(port int, agentInfo *AgentInfo, statusGetter StatusGetter) *Serve
 		port:         port,
 		agentInfo:    agentInfo,
 		statusGetter: statusGetter,
+		handlers:     make(map[string]http.Handler),
 	}
 }
 
+// Handle registers an additional handler on the health server.
+// It must be called before Start.
+func (s *Server) Handle(pattern string, handler http.Handler) {
+	s.handlers[pattern] = handler
+}
+
+// SetMetrics exposes the metrics collector on /metrics.
+// It must be called before Start.
+func (s *Server) SetMetrics(metrics *Metrics) {
+	s.handlers["/metrics"] = metrics.Handler()
+}
+
 // Start starts the health monitoring server
 func (s *Server) Start() error {
 	mux := http.NewServeMux()
@@ -64,12 +109,22 @@ func (s *Server) Start() error {
 	mux.HandleFunc("/status", s.statusHandler)
 	mux.HandleFunc("/info", s.infoHandler)
 
+	// Probes for container orchestrators
+	mux.HandleFunc("/livez", s.livenessHandler)
+	mux.HandleFunc("/readyz", s.readinessHandler)
+	mux.HandleFunc("/startupz", s.startupHandler)
+
+	// Additional endpoints registered by other components
+	for pattern, handler := range s.handlers {
+		mux.Handle(pattern, handler)
+	}
+
 	s.server = &http.Server{
 		Addr:    fmt.Sprintf(":%d", s.port),
 		Handler: mux,
 	}
 
-	log.Printf("🌐 Starting health server on port %d...", s.port)
+	logging.Info("starting health server", "port", s.port)
 	return s.server.ListenAndServe()
 }
 
@@ -98,6 +153,12 @@ func (s *Server) rootHandler(w http.ResponseWriter, r *http.Request) {
 	fmt.Fprintf(w, "  /health - Health check\n")
 	fmt.Fprintf(w, "  /status - Detailed status (JSON)\n")
 	fmt.Fprintf(w, "  /info   - Agent information (JSON)\n")
+	fmt.Fprintf(w, "  /livez   - Liveness probe\n")
+	fmt.Fprintf(w, "  /readyz  - Readiness probe\n")
+	fmt.Fprintf(w, "  /startupz - Startup probe\n")
+	if _, ok := s.handlers["/metrics"]; ok {
+		fmt.Fprintf(w, "  /metrics - Prometheus metrics\n")
+	}
 }
 
 // healthHandler provides a simple health check
@@ -157,11 +218,36 @@ func (s *Server) statusHandler(w http.ResponseWriter, r *http.Request) {
 		Uptime:        s.statusGetter.GetUptime().String(),
 		Timestamp:     time.Now(),
 		Agent:         *s.agentInfo,
+		Progress:      s.progressSummary(),
+	}
+	if getter, ok := s.statusGetter.(MetadataDriftGetter); ok {
+		healthStatus.MetadataDrift = getter.GetMetadataDrift()
 	}
 
 	json.NewEncoder(w).Encode(healthStatus)
 }
 
+// progressSummary aggregates task progress if the status getter reports it
+func (s *Server) progressSummary() *ProgressSummary {
+	getter, ok := s.statusGetter.(ProgressGetter)
+	if !ok {
+		return nil
+	}
+	tasks := getter.GetTaskProgress()
+	if len(tasks) == 0 {
+		return nil
+	}
+
+	var total float64
+	for _, task := range tasks {
+		total += task.Percent
+	}
+	return &ProgressSummary{
+		Overall: total / float64(len(tasks)),
+		Tasks:   tasks,
+	}
+}
+
 // infoHandler provides agent information
 func (s *Server) infoHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
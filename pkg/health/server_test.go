@@ -0,0 +1,84 @@
+package health
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/types"
+)
+
+type fakeStatus struct {
+	progress []types.TaskProgress
+	drift    *types.MetadataDrift
+}
+
+func (f *fakeStatus) IsConnected() bool                      { return true }
+func (f *fakeStatus) IsAuthenticated() bool                  { return true }
+func (f *fakeStatus) GetActiveTaskCount() int                { return len(f.progress) }
+func (f *fakeStatus) GetUptime() time.Duration               { return time.Minute }
+func (f *fakeStatus) GetTaskProgress() []types.TaskProgress  { return f.progress }
+func (f *fakeStatus) GetMetadataDrift() *types.MetadataDrift { return f.drift }
+
+func TestStatusProgress(t *testing.T) {
+	status := &fakeStatus{progress: []types.TaskProgress{
+		{TaskID: "task-1", Percent: 25, Stage: "fetching", ETASeconds: 30},
+		{TaskID: "task-2", Percent: 75, Stage: "summarizing"},
+	}}
+	server := NewServer(0, &AgentInfo{Name: "test-agent"}, status)
+
+	rec := httptest.NewRecorder()
+	server.statusHandler(rec, httptest.NewRequest("GET", "/status", nil))
+
+	var got HealthStatus
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if got.Progress == nil {
+		t.Fatal("expected progress in status")
+	}
+	if got.Progress.Overall != 50 || len(got.Progress.Tasks) != 2 || got.Progress.Tasks[0].Stage != "fetching" {
+		t.Errorf("unexpected progress: %+v", got.Progress)
+	}
+
+	status.progress = nil
+	rec = httptest.NewRecorder()
+	server.statusHandler(rec, httptest.NewRequest("GET", "/status", nil))
+	var raw map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &raw); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if _, ok := raw["progress"]; ok {
+		t.Error("progress should be omitted when no task reported any")
+	}
+}
+
+func TestStatusMetadataDrift(t *testing.T) {
+	status := &fakeStatus{}
+	server := NewServer(0, &AgentInfo{Name: "test-agent"}, status)
+
+	rec := httptest.NewRecorder()
+	server.statusHandler(rec, httptest.NewRequest("GET", "/status", nil))
+	var raw map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &raw); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if _, ok := raw["metadata_drift"]; ok {
+		t.Error("metadata drift should be omitted before the first check")
+	}
+
+	status.drift = &types.MetadataDrift{
+		Drifted: true,
+		Changes: []types.MetadataChange{{Field: types.MetadataFieldVersion, Current: "1.0.0", Desired: "1.1.0"}},
+	}
+	rec = httptest.NewRecorder()
+	server.statusHandler(rec, httptest.NewRequest("GET", "/status", nil))
+	var got HealthStatus
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if got.MetadataDrift == nil || !got.MetadataDrift.Drifted || len(got.MetadataDrift.Changes) != 1 {
+		t.Errorf("unexpected metadata drift: %+v", got.MetadataDrift)
+	}
+}
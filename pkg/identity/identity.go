@@ -0,0 +1,95 @@
+// Package identity keeps what an agent learned about itself on its first
+// registration in a small JSON file: its agent ID, NFT token ID, wallet address
+// and when it registered. Agents that mint their NFT on the first run load the
+// file on later boots instead of minting again.
+package identity
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// DefaultPath is where the identity is kept when no path is configured
+const DefaultPath = ".teneo/identity.json"
+
+// ErrAddressMismatch is returned by Check for an identity of another wallet
+var ErrAddressMismatch = errors.New("identity belongs to another wallet")
+
+// Identity is the persistent identity of an agent
+type Identity struct {
+	AgentID           string    `json:"agent_id"`
+	TokenID           uint64    `json:"token_id"`
+	Address           string    `json:"address"` // Wallet derived from the agent's private key
+	MintedAt          time.Time `json:"minted_at,omitempty"`
+	FirstRegisteredAt time.Time `json:"first_registered_at,omitempty"`
+	LastRegisteredAt  time.Time `json:"last_registered_at,omitempty"`
+}
+
+// Load reads an identity file. A missing file is reported with an error
+// matching os.ErrNotExist.
+func Load(path string) (*Identity, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var id Identity
+	if err := json.Unmarshal(data, &id); err != nil {
+		return nil, fmt.Errorf("failed to parse identity file %s: %w", path, err)
+	}
+	return &id, nil
+}
+
+// Save writes the identity file, creating its directory. The file is replaced
+// atomically so a crash never leaves a partial identity behind.
+func (id *Identity) Save(path string) error {
+	data, err := json.MarshalIndent(id, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal identity: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("failed to create identity directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".identity-*")
+	if err != nil {
+		return fmt.Errorf("failed to write identity file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write identity file: %w", err)
+	}
+	if err := tmp.Chmod(0o600); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write identity file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write identity file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write identity file: %w", err)
+	}
+	return nil
+}
+
+// Check returns ErrAddressMismatch if the identity was written for another
+// wallet, e.g. after the private key was changed
+func (id *Identity) Check(address string) error {
+	if id.Address != "" && !strings.EqualFold(id.Address, address) {
+		return fmt.Errorf("%w: %s, agent wallet is %s", ErrAddressMismatch, id.Address, address)
+	}
+	return nil
+}
+
+// Registered records a successful registration at t
+func (id *Identity) Registered(t time.Time) {
+	if id.FirstRegisteredAt.IsZero() {
+		id.FirstRegisteredAt = t
+	}
+	id.LastRegisteredAt = t
+}
@@ -0,0 +1,56 @@
+package identity
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSaveAndLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "identity.json")
+	if _, err := Load(path); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("got %v, want os.ErrNotExist", err)
+	}
+
+	first := time.Date(2026, 3, 4, 10, 0, 0, 0, time.UTC)
+	id := &Identity{AgentID: "my-agent", TokenID: 42, Address: "0xAbC0000000000000000000000000000000000001", MintedAt: first}
+	id.Registered(first)
+	id.Registered(first.Add(time.Hour))
+	if err := id.Save(path); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *loaded != *id {
+		t.Errorf("got %+v, want %+v", loaded, id)
+	}
+	if !loaded.FirstRegisteredAt.Equal(first) || !loaded.LastRegisteredAt.Equal(first.Add(time.Hour)) {
+		t.Errorf("unexpected registration times %v, %v", loaded.FirstRegisteredAt, loaded.LastRegisteredAt)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0o600 {
+		t.Errorf("identity file mode %v, %v, want 0600", info.Mode().Perm(), err)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	id := &Identity{Address: "0xAbC0000000000000000000000000000000000001"}
+	if err := id.Check("0xabc0000000000000000000000000000000000001"); err != nil {
+		t.Errorf("same wallet: %v", err)
+	}
+	if err := id.Check("0x0000000000000000000000000000000000000002"); !errors.Is(err, ErrAddressMismatch) {
+		t.Errorf("got %v, want ErrAddressMismatch", err)
+	}
+}
+
+func TestLoadInvalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "identity.json")
+	os.WriteFile(path, []byte("{"), 0o600)
+	if _, err := Load(path); err == nil {
+		t.Error("expected error for invalid file")
+	}
+}
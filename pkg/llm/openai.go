@@ -0,0 +1,243 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// Base URLs of common OpenAI-compatible services
+const (
+	GroqBaseURL    = "https://api.groq.com/openai/v1"
+	MistralBaseURL = "https://api.mistral.ai/v1"
+)
+
+// OpenAIProvider implements LLMProvider for OpenAI and OpenAI-compatible APIs
+type OpenAIProvider struct {
+	client      *openai.Client
+	name        string
+	model       string
+	temperature float32
+	maxTokens   int
+}
+
+// OpenAIConfig holds configuration for the OpenAI provider
+type OpenAIConfig struct {
+	APIKey       string  // API key
+	Model        string  // Default model (defaults to "gpt-5"); the deployment name for Azure
+	BaseURL      string  // Custom endpoint for OpenAI-compatible services (Groq, Mistral, ...)
+	Organization string  // Optional OpenAI organization ID
+	Temperature  float32 // Default temperature (defaults to 0.7)
+	MaxTokens    int     // Default max tokens (defaults to 1000)
+
+	// Azure OpenAI settings; AzureEndpoint switches the client to Azure mode
+	AzureEndpoint   string // e.g. "https://my-resource.openai.azure.com"
+	AzureAPIVersion string // Defaults to the client library default
+}
+
+// NewOpenAIProvider creates a provider backed by the OpenAI API or a compatible endpoint
+func NewOpenAIProvider(config *OpenAIConfig) *OpenAIProvider {
+	if config.Model == "" {
+		config.Model = openai.GPT5
+	}
+	if config.Temperature == 0 {
+		config.Temperature = 0.7
+	}
+	if config.MaxTokens == 0 {
+		config.MaxTokens = 1000
+	}
+
+	var clientConfig openai.ClientConfig
+	name := "openai"
+	switch {
+	case config.AzureEndpoint != "":
+		clientConfig = openai.DefaultAzureConfig(config.APIKey, config.AzureEndpoint)
+		if config.AzureAPIVersion != "" {
+			clientConfig.APIVersion = config.AzureAPIVersion
+		}
+		name = "azure-openai"
+	default:
+		clientConfig = openai.DefaultConfig(config.APIKey)
+		if config.BaseURL != "" {
+			clientConfig.BaseURL = strings.TrimRight(config.BaseURL, "/")
+			name = "openai-compatible"
+		}
+	}
+	if config.Organization != "" {
+		clientConfig.OrgID = config.Organization
+	}
+
+	return &OpenAIProvider{
+		client:      openai.NewClientWithConfig(clientConfig),
+		name:        name,
+		model:       config.Model,
+		temperature: config.Temperature,
+		maxTokens:   config.MaxTokens,
+	}
+}
+
+// NewGroqProvider creates a provider for the Groq OpenAI-compatible API
+func NewGroqProvider(apiKey, model string) *OpenAIProvider {
+	return NewOpenAIProvider(&OpenAIConfig{APIKey: apiKey, Model: model, BaseURL: GroqBaseURL})
+}
+
+// NewMistralProvider creates a provider for the Mistral OpenAI-compatible API
+func NewMistralProvider(apiKey, model string) *OpenAIProvider {
+	return NewOpenAIProvider(&OpenAIConfig{APIKey: apiKey, Model: model, BaseURL: MistralBaseURL})
+}
+
+// NewAzureOpenAIProvider creates a provider for an Azure OpenAI deployment
+func NewAzureOpenAIProvider(apiKey, endpoint, deployment string) *OpenAIProvider {
+	return NewOpenAIProvider(&OpenAIConfig{APIKey: apiKey, Model: deployment, AzureEndpoint: endpoint})
+}
+
+// Name implements the LLMProvider interface
+func (p *OpenAIProvider) Name() string {
+	return p.name
+}
+
+// Model returns the default model
+func (p *OpenAIProvider) Model() string {
+	return p.model
+}
+
+// Complete implements the LLMProvider interface
+func (p *OpenAIProvider) Complete(ctx context.Context, req *Request) (*Response, error) {
+	chatReq, err := p.buildRequest(req, false)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := p.client.CreateChatCompletion(ctx, chatReq)
+	if err != nil {
+		return nil, fmt.Errorf("%s API error: %w", p.name, err)
+	}
+
+	if len(resp.Choices) == 0 {
+		return nil, ErrNoResponse
+	}
+
+	return &Response{
+		Content: resp.Choices[0].Message.Content,
+		Model:   resp.Model,
+		Usage: Usage{
+			PromptTokens:     resp.Usage.PromptTokens,
+			CompletionTokens: resp.Usage.CompletionTokens,
+			TotalTokens:      resp.Usage.TotalTokens,
+		},
+	}, nil
+}
+
+// Stream implements the LLMProvider interface
+func (p *OpenAIProvider) Stream(ctx context.Context, req *Request, handler StreamHandler) error {
+	chatReq, err := p.buildRequest(req, true)
+	if err != nil {
+		return err
+	}
+
+	stream, err := p.client.CreateChatCompletionStream(ctx, chatReq)
+	if err != nil {
+		return fmt.Errorf("failed to create stream: %w", err)
+	}
+	defer stream.Close()
+
+	for {
+		response, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("stream error: %w", err)
+		}
+
+		if len(response.Choices) == 0 {
+			continue
+		}
+
+		if delta := response.Choices[0].Delta.Content; delta != "" {
+			if err := handler(delta); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// CountTokens implements the LLMProvider interface using an estimate
+func (p *OpenAIProvider) CountTokens(text string) int {
+	return EstimateTokens(text)
+}
+
+// buildRequest converts a provider request into an OpenAI chat request
+func (p *OpenAIProvider) buildRequest(req *Request, stream bool) (openai.ChatCompletionRequest, error) {
+	if req == nil || len(req.Messages) == 0 {
+		return openai.ChatCompletionRequest{}, ErrEmptyRequest
+	}
+
+	model := req.Model
+	if model == "" {
+		model = p.model
+	}
+	temperature := req.Temperature
+	if temperature == 0 {
+		temperature = p.temperature
+	}
+	maxTokens := req.MaxTokens
+	if maxTokens == 0 {
+		maxTokens = p.maxTokens
+	}
+
+	betaModel := isBetaModel(model)
+
+	var messages []openai.ChatCompletionMessage
+	var systemPrompt string
+	for _, msg := range req.Messages {
+		// Beta models (O1, O3, GPT-5) don't support system prompts
+		// Merge them into the first user message instead
+		if betaModel && msg.Role == RoleSystem {
+			systemPrompt += msg.Content + "\n\n"
+			continue
+		}
+		content := msg.Content
+		if systemPrompt != "" && msg.Role == RoleUser {
+			content = systemPrompt + content
+			systemPrompt = ""
+		}
+		messages = append(messages, openai.ChatCompletionMessage{
+			Role:    msg.Role,
+			Content: content,
+		})
+	}
+
+	chatReq := openai.ChatCompletionRequest{
+		Model:    model,
+		Messages: messages,
+		Stream:   stream,
+	}
+
+	// Beta models have fixed parameters - don't set temperature for them
+	if !betaModel {
+		chatReq.Temperature = temperature
+	}
+
+	// Use MaxCompletionTokens for newer models (GPT-4, GPT-5, O1, O3)
+	// Use MaxTokens for older models and compatible APIs
+	if betaModel || strings.Contains(strings.ToLower(model), "gpt-4") {
+		chatReq.MaxCompletionTokens = maxTokens
+	} else {
+		chatReq.MaxTokens = maxTokens
+	}
+
+	return chatReq, nil
+}
+
+// isBetaModel reports whether the model has fixed parameters and no system prompt support
+func isBetaModel(model string) bool {
+	modelLower := strings.ToLower(model)
+	return strings.Contains(modelLower, "gpt-5") ||
+		strings.Contains(modelLower, "o1") ||
+		strings.Contains(modelLower, "o3")
+}
@@ -0,0 +1,73 @@
+package llm
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestBuildRequest(t *testing.T) {
+	provider := NewOpenAIProvider(&OpenAIConfig{APIKey: "test", Model: "gpt-4o"})
+
+	tests := []struct {
+		name          string
+		model         string
+		wantMessages  int
+		wantFirstRole string
+		wantTemp      bool
+	}{
+		{"standard model keeps system prompt", "gpt-4o", 2, RoleSystem, true},
+		{"beta model merges system prompt", "gpt-5", 1, RoleUser, false},
+		{"o1 model merges system prompt", "o1-mini", 1, RoleUser, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := NewRequest("be brief", "hello")
+			req.Model = tt.model
+
+			chatReq, err := provider.buildRequest(req, false)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(chatReq.Messages) != tt.wantMessages {
+				t.Fatalf("expected %d messages, got %d", tt.wantMessages, len(chatReq.Messages))
+			}
+			if chatReq.Messages[0].Role != tt.wantFirstRole {
+				t.Errorf("expected first role %s, got %s", tt.wantFirstRole, chatReq.Messages[0].Role)
+			}
+			if (chatReq.Temperature != 0) != tt.wantTemp {
+				t.Errorf("unexpected temperature %v for model %s", chatReq.Temperature, tt.model)
+			}
+			if tt.wantMessages == 1 && chatReq.Messages[0].Content != "be brief\n\nhello" {
+				t.Errorf("system prompt not merged: %q", chatReq.Messages[0].Content)
+			}
+		})
+	}
+}
+
+func TestBuildRequestEmpty(t *testing.T) {
+	provider := NewOpenAIProvider(&OpenAIConfig{APIKey: "test"})
+
+	if _, err := provider.buildRequest(&Request{}, false); !errors.Is(err, ErrEmptyRequest) {
+		t.Errorf("expected ErrEmptyRequest, got %v", err)
+	}
+}
+
+func TestEstimateTokens(t *testing.T) {
+	tests := []struct {
+		text string
+		want int
+	}{
+		{"", 0},
+		{"a", 1},
+		{"abcd", 1},
+		{"abcde", 2},
+		{"hello world!", 3},
+	}
+
+	for _, tt := range tests {
+		if got := EstimateTokens(tt.text); got != tt.want {
+			t.Errorf("EstimateTokens(%q) = %d, want %d", tt.text, got, tt.want)
+		}
+	}
+}
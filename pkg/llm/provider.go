@@ -0,0 +1,85 @@
+package llm
+
+import (
+	"context"
+	"errors"
+)
+
+// Message roles used in chat requests
+const (
+	RoleSystem    = "system"
+	RoleUser      = "user"
+	RoleAssistant = "assistant"
+)
+
+var (
+	ErrNoResponse   = errors.New("no response from provider")
+	ErrEmptyRequest = errors.New("request has no messages")
+)
+
+// Message represents a single chat message sent to a provider
+type Message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// Request represents a chat completion request
+type Request struct {
+	Messages    []Message `json:"messages"`
+	Model       string    `json:"model,omitempty"` // Overrides the provider's default model when set
+	Temperature float32   `json:"temperature,omitempty"`
+	MaxTokens   int       `json:"max_tokens,omitempty"`
+}
+
+// Usage reports token consumption for a completion
+type Usage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+// Response represents a chat completion result
+type Response struct {
+	Content string `json:"content"`
+	Model   string `json:"model"`
+	Usage   Usage  `json:"usage"`
+}
+
+// StreamHandler receives generated text as it arrives.
+// Returning an error stops the stream.
+type StreamHandler func(delta string) error
+
+// LLMProvider is implemented by language model backends
+type LLMProvider interface {
+	// Name returns a short identifier for the provider (e.g. "openai")
+	Name() string
+
+	// Complete runs a request and returns the full response
+	Complete(ctx context.Context, req *Request) (*Response, error)
+
+	// Stream runs a request and passes each generated delta to the handler
+	Stream(ctx context.Context, req *Request, handler StreamHandler) error
+
+	// CountTokens returns the number of tokens the text uses for the provider's model
+	CountTokens(text string) int
+}
+
+// NewRequest builds a request with an optional system prompt followed by a user message
+func NewRequest(systemPrompt, userMessage string) *Request {
+	req := &Request{}
+	if systemPrompt != "" {
+		req.Messages = append(req.Messages, Message{Role: RoleSystem, Content: systemPrompt})
+	}
+	req.Messages = append(req.Messages, Message{Role: RoleUser, Content: userMessage})
+	return req
+}
+
+// EstimateTokens approximates the token count of a text.
+// It uses the common heuristic of roughly four characters per token and is
+// meant for providers that do not expose a tokenizer.
+func EstimateTokens(text string) int {
+	if text == "" {
+		return 0
+	}
+	return (len([]rune(text)) + 3) / 4
+}
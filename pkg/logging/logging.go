@@ -0,0 +1,322 @@
+// Package logging provides the leveled, structured logger used throughout the SDK.
+//
+// Log calls take a message followed by alternating key/value fields:
+//
+//	logging.Info("task completed", "task_id", taskID, "duration", d)
+//
+// The default logger writes human-readable text to stderr at info level.
+// Replace it with SetDefault, either with New (slog-based, text or JSON)
+// or with any implementation of Logger.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync/atomic"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/output"
+)
+
+// Logger is a leveled, structured logger
+type Logger interface {
+	Debug(msg string, fields ...any)
+	Info(msg string, fields ...any)
+	Warn(msg string, fields ...any)
+	Error(msg string, fields ...any)
+
+	// With returns a logger that adds fields to every entry
+	With(fields ...any) Logger
+}
+
+// Output formats
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// Config holds configuration for the default slog-based logger
+type Config struct {
+	Level  string    // "debug", "info" (default), "warn" or "error"
+	Format string    // "text" (default) or "json"
+	Output io.Writer // Defaults to os.Stderr
+
+	// Style renders emoji in messages and string fields (default: kept as-is)
+	Style output.Style
+
+	// Scrub rewrites messages and string fields before they are written,
+	// e.g. to redact personal data (nil = disabled)
+	Scrub func(string) string
+}
+
+// New creates a slog-based logger from the configuration
+func New(config *Config) (Logger, error) {
+	if config == nil {
+		config = &Config{}
+	}
+
+	level, err := ParseLevel(config.Level)
+	if err != nil {
+		return nil, err
+	}
+
+	writer := config.Output
+	if writer == nil {
+		writer = os.Stderr
+	}
+
+	levelVar := new(slog.LevelVar)
+	levelVar.Set(level)
+	options := &slog.HandlerOptions{Level: levelVar}
+	var handler slog.Handler
+	switch strings.ToLower(config.Format) {
+	case "", FormatText:
+		handler = slog.NewTextHandler(writer, options)
+	case FormatJSON:
+		handler = slog.NewJSONHandler(writer, options)
+	default:
+		return nil, fmt.Errorf("invalid log format %q (use %q or %q)", config.Format, FormatText, FormatJSON)
+	}
+
+	var rewrites []func(string) string
+	if config.Scrub != nil {
+		rewrites = append(rewrites, config.Scrub)
+	}
+	if config.Style != "" && config.Style != output.StyleEmoji {
+		style := config.Style
+		rewrites = append(rewrites, func(s string) string { return output.Format(style, s) })
+	}
+	if len(rewrites) > 0 {
+		handler = &rewriteHandler{Handler: handler, rewrites: rewrites}
+	}
+	return &SlogLogger{logger: slog.New(handler), level: levelVar}, nil
+}
+
+// rewriteHandler rewrites the message and string attributes of each record,
+// e.g. to apply an output style or redact personal data
+type rewriteHandler struct {
+	slog.Handler
+	rewrites []func(string) string
+}
+
+// rewrite applies all rewrites to s
+func (h *rewriteHandler) rewrite(s string) string {
+	for _, rewrite := range h.rewrites {
+		s = rewrite(s)
+	}
+	return s
+}
+
+// Handle implements slog.Handler
+func (h *rewriteHandler) Handle(ctx context.Context, record slog.Record) error {
+	rewritten := slog.NewRecord(record.Time, record.Level, h.rewrite(record.Message), record.PC)
+	record.Attrs(func(attr slog.Attr) bool {
+		rewritten.AddAttrs(h.rewriteAttr(attr))
+		return true
+	})
+	return h.Handler.Handle(ctx, rewritten)
+}
+
+// WithAttrs implements slog.Handler
+func (h *rewriteHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	rewritten := make([]slog.Attr, len(attrs))
+	for i, attr := range attrs {
+		rewritten[i] = h.rewriteAttr(attr)
+	}
+	return &rewriteHandler{Handler: h.Handler.WithAttrs(rewritten), rewrites: h.rewrites}
+}
+
+// WithGroup implements slog.Handler
+func (h *rewriteHandler) WithGroup(name string) slog.Handler {
+	return &rewriteHandler{Handler: h.Handler.WithGroup(name), rewrites: h.rewrites}
+}
+
+// rewriteAttr rewrites string values, including those nested in groups
+func (h *rewriteHandler) rewriteAttr(attr slog.Attr) slog.Attr {
+	value := attr.Value.Resolve()
+	switch value.Kind() {
+	case slog.KindString:
+		return slog.String(attr.Key, h.rewrite(value.String()))
+	case slog.KindGroup:
+		group := value.Group()
+		rewritten := make([]any, len(group))
+		for i, member := range group {
+			rewritten[i] = h.rewriteAttr(member)
+		}
+		return slog.Group(attr.Key, rewritten...)
+	default:
+		return attr
+	}
+}
+
+// ParseLevel parses a level name (empty means info)
+func ParseLevel(level string) (slog.Level, error) {
+	switch strings.ToLower(level) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "", "info":
+		return slog.LevelInfo, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	default:
+		return slog.LevelInfo, fmt.Errorf("invalid log level %q (use debug, info, warn or error)", level)
+	}
+}
+
+// SlogLogger adapts a *slog.Logger to the Logger interface
+type SlogLogger struct {
+	logger *slog.Logger
+	level  *slog.LevelVar // Set for loggers created by New; nil for wrapped loggers
+}
+
+// NewSlogLogger wraps a *slog.Logger
+func NewSlogLogger(logger *slog.Logger) *SlogLogger {
+	return &SlogLogger{logger: logger}
+}
+
+// Debug implements the Logger interface
+func (l *SlogLogger) Debug(msg string, fields ...any) {
+	l.logger.Log(context.Background(), slog.LevelDebug, msg, fields...)
+}
+
+// Info implements the Logger interface
+func (l *SlogLogger) Info(msg string, fields ...any) {
+	l.logger.Log(context.Background(), slog.LevelInfo, msg, fields...)
+}
+
+// Warn implements the Logger interface
+func (l *SlogLogger) Warn(msg string, fields ...any) {
+	l.logger.Log(context.Background(), slog.LevelWarn, msg, fields...)
+}
+
+// Error implements the Logger interface
+func (l *SlogLogger) Error(msg string, fields ...any) {
+	l.logger.Log(context.Background(), slog.LevelError, msg, fields...)
+}
+
+// With implements the Logger interface
+func (l *SlogLogger) With(fields ...any) Logger {
+	return &SlogLogger{logger: l.logger.With(fields...), level: l.level}
+}
+
+// SetLevel changes the minimum level of a logger created by New, including
+// loggers derived from it with With. It reports false for wrapped loggers,
+// whose level is controlled by their own handler.
+func (l *SlogLogger) SetLevel(level slog.Level) bool {
+	if l.level == nil {
+		return false
+	}
+	l.level.Set(level)
+	return true
+}
+
+// Level returns the minimum level of a logger created by New, false for
+// wrapped loggers
+func (l *SlogLogger) Level() (slog.Level, bool) {
+	if l.level == nil {
+		return 0, false
+	}
+	return l.level.Level(), true
+}
+
+// Slog returns the underlying *slog.Logger
+func (l *SlogLogger) Slog() *slog.Logger {
+	return l.logger
+}
+
+// nopLogger discards every entry
+type nopLogger struct{}
+
+func (nopLogger) Debug(string, ...any) {}
+func (nopLogger) Info(string, ...any)  {}
+func (nopLogger) Warn(string, ...any)  {}
+func (nopLogger) Error(string, ...any) {}
+func (n nopLogger) With(...any) Logger { return n }
+
+// Nop returns a logger that discards every entry
+func Nop() Logger {
+	return nopLogger{}
+}
+
+// loggerHolder lets atomic.Value store different Logger implementations
+type loggerHolder struct {
+	logger Logger
+}
+
+var defaultLogger atomic.Value
+
+func init() {
+	logger, _ := New(nil)
+	defaultLogger.Store(loggerHolder{logger})
+}
+
+// Default returns the logger used by the SDK
+func Default() Logger {
+	return defaultLogger.Load().(loggerHolder).logger
+}
+
+// SetDefault replaces the logger used by the SDK (nil discards all logs)
+func SetDefault(logger Logger) {
+	if logger == nil {
+		logger = Nop()
+	}
+	defaultLogger.Store(loggerHolder{logger})
+}
+
+// SetLevel changes the level of the default logger at runtime, e.g. when the
+// configuration is reloaded. It fails if the default logger was not created by New.
+func SetLevel(level string) error {
+	parsed, err := ParseLevel(level)
+	if err != nil {
+		return err
+	}
+	logger, ok := Default().(interface{ SetLevel(slog.Level) bool })
+	if !ok || !logger.SetLevel(parsed) {
+		return fmt.Errorf("default logger does not support changing the level")
+	}
+	return nil
+}
+
+// GetLevel returns the level name of the default logger ("debug", "info",
+// "warn" or "error"). It fails if the default logger was not created by New.
+func GetLevel() (string, error) {
+	logger, ok := Default().(interface{ Level() (slog.Level, bool) })
+	if !ok {
+		return "", fmt.Errorf("default logger does not report its level")
+	}
+	level, ok := logger.Level()
+	if !ok {
+		return "", fmt.Errorf("default logger does not report its level")
+	}
+	return strings.ToLower(level.String()), nil
+}
+
+// Debug logs at debug level with the default logger
+func Debug(msg string, fields ...any) {
+	Default().Debug(msg, fields...)
+}
+
+// Info logs at info level with the default logger
+func Info(msg string, fields ...any) {
+	Default().Info(msg, fields...)
+}
+
+// Warn logs at warn level with the default logger
+func Warn(msg string, fields ...any) {
+	Default().Warn(msg, fields...)
+}
+
+// Error logs at error level with the default logger
+func Error(msg string, fields ...any) {
+	Default().Error(msg, fields...)
+}
+
+// With returns the default logger with fields added to every entry
+func With(fields ...any) Logger {
+	return Default().With(fields...)
+}
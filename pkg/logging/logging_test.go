@@ -0,0 +1,173 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/output"
+)
+
+func TestNewLevels(t *testing.T) {
+	tests := []struct {
+		level string
+		want  []string
+	}{
+		{"debug", []string{"DEBUG", "INFO", "WARN", "ERROR"}},
+		{"", []string{"INFO", "WARN", "ERROR"}},
+		{"warn", []string{"WARN", "ERROR"}},
+		{"ERROR", []string{"ERROR"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.level, func(t *testing.T) {
+			var buf bytes.Buffer
+			logger, err := New(&Config{Level: tt.level, Format: FormatJSON, Output: &buf})
+			if err != nil {
+				t.Fatalf("New: %v", err)
+			}
+
+			logger.Debug("d")
+			logger.Info("i")
+			logger.Warn("w")
+			logger.Error("e")
+
+			var got []string
+			for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+				var entry map[string]any
+				if err := json.Unmarshal([]byte(line), &entry); err != nil {
+					t.Fatalf("invalid JSON line %q: %v", line, err)
+				}
+				got = append(got, entry["level"].(string))
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("levels = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFields(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := New(&Config{Format: FormatJSON, Output: &buf})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	logger.With("agent", "test-agent").Info("task completed", "task_id", "task-1", "attempts", 2)
+
+	var entry map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if entry["msg"] != "task completed" || entry["agent"] != "test-agent" || entry["task_id"] != "task-1" || entry["attempts"] != float64(2) {
+		t.Errorf("unexpected entry: %v", entry)
+	}
+}
+
+func TestTextFormat(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := New(&Config{Format: FormatText, Output: &buf})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	logger.Warn("rate limit exceeded", "task_id", "task-1")
+	if got := buf.String(); !strings.Contains(got, "level=WARN") || !strings.Contains(got, `msg="rate limit exceeded" task_id=task-1`) {
+		t.Errorf("unexpected output: %q", got)
+	}
+}
+
+func TestPlainStyle(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := New(&Config{Format: FormatJSON, Output: &buf, Style: output.StylePlain})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	logger.With("agent", "🤖 bot").Info("✅ task completed", "reply", "🎉 done", "attempts", 2)
+
+	var entry map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if entry["msg"] != "task completed" || entry["agent"] != "bot" || entry["reply"] != "done" || entry["attempts"] != float64(2) {
+		t.Errorf("unexpected entry: %v", entry)
+	}
+}
+
+func TestScrub(t *testing.T) {
+	var buf bytes.Buffer
+	scrub := func(s string) string { return strings.ReplaceAll(s, "secret", "***") }
+	logger, err := New(&Config{Format: FormatText, Output: &buf, Scrub: scrub})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	logger.Info("got secret", "content", "my secret value")
+	if got := buf.String(); strings.Contains(got, "secret") || !strings.Contains(got, `content="my *** value"`) {
+		t.Errorf("unexpected output: %q", got)
+	}
+}
+
+func TestNewInvalidConfig(t *testing.T) {
+	if _, err := New(&Config{Level: "verbose"}); err == nil {
+		t.Error("expected error for invalid level")
+	}
+	if _, err := New(&Config{Format: "xml"}); err == nil {
+		t.Error("expected error for invalid format")
+	}
+}
+
+func TestSetDefault(t *testing.T) {
+	original := Default()
+	defer SetDefault(original)
+
+	var buf bytes.Buffer
+	logger, _ := New(&Config{Output: &buf})
+	SetDefault(logger)
+	Info("hello", "key", "value")
+	if !strings.Contains(buf.String(), "key=value") {
+		t.Errorf("package-level Info did not use the default logger: %q", buf.String())
+	}
+
+	SetDefault(nil)
+	Error("discarded")
+	if strings.Contains(buf.String(), "discarded") {
+		t.Error("nil default should discard entries")
+	}
+}
+
+func TestSetLevel(t *testing.T) {
+	original := Default()
+	defer SetDefault(original)
+
+	var buf bytes.Buffer
+	logger, _ := New(&Config{Output: &buf})
+	SetDefault(logger)
+	child := With("component", "test")
+
+	child.Debug("hidden")
+	if err := SetLevel("debug"); err != nil {
+		t.Fatalf("SetLevel: %v", err)
+	}
+	child.Debug("shown")
+	if strings.Contains(buf.String(), "hidden") || !strings.Contains(buf.String(), "shown") {
+		t.Errorf("level change not applied to derived logger: %q", buf.String())
+	}
+	if level, err := GetLevel(); err != nil || level != "debug" {
+		t.Errorf("GetLevel = %q, %v, want debug", level, err)
+	}
+
+	if err := SetLevel("verbose"); err == nil {
+		t.Error("expected error for invalid level")
+	}
+	SetDefault(Nop())
+	if err := SetLevel("info"); err == nil {
+		t.Error("expected error for logger without a level")
+	}
+	if _, err := GetLevel(); err == nil {
+		t.Error("expected error for logger without a level")
+	}
+}
@@ -0,0 +1,35 @@
+package memory
+
+import (
+	"context"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/types"
+)
+
+// contextKey is the type used for conversation values stored in a context
+type contextKey struct{}
+
+// Conversation is the room history attached to a task's context
+type Conversation struct {
+	Room    string
+	History []types.ConversationMessage
+}
+
+// WithConversation returns a context carrying the room's conversation history
+func WithConversation(ctx context.Context, room string, history []types.ConversationMessage) context.Context {
+	return context.WithValue(ctx, contextKey{}, &Conversation{Room: room, History: history})
+}
+
+// FromContext returns the conversation attached to the context, if any
+func FromContext(ctx context.Context) (*Conversation, bool) {
+	conversation, ok := ctx.Value(contextKey{}).(*Conversation)
+	return conversation, ok
+}
+
+// HistoryFromContext returns the conversation history attached to the context (nil when absent)
+func HistoryFromContext(ctx context.Context) []types.ConversationMessage {
+	if conversation, ok := FromContext(ctx); ok {
+		return conversation.History
+	}
+	return nil
+}
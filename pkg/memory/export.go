@@ -0,0 +1,145 @@
+package memory
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/types"
+)
+
+// ExportFormat is the output format of a conversation export
+type ExportFormat string
+
+// Export formats
+const (
+	FormatJSONL    ExportFormat = "jsonl"    // One JSON object per turn, e.g. for fine-tuning datasets
+	FormatMarkdown ExportFormat = "markdown" // A readable transcript, e.g. for compliance requests
+)
+
+// ExportOptions selects the turns of a conversation export and how they are written
+type ExportOptions struct {
+	Format ExportFormat // Output format (default FormatJSONL)
+	Sender string       // Only export this sender's turns and the replies to them (empty = everyone)
+	Since  time.Time    // Only export turns at or after this time (zero = no limit)
+	Until  time.Time    // Only export turns before this time (zero = no limit)
+}
+
+// ExportRecord is one line of a JSONL export
+type ExportRecord struct {
+	Room string `json:"room"`
+	types.ConversationMessage
+}
+
+// ParseExportFormat parses an export format name ("jsonl" or "markdown")
+func ParseExportFormat(name string) (ExportFormat, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "", "jsonl", "json":
+		return FormatJSONL, nil
+	case "markdown", "md":
+		return FormatMarkdown, nil
+	default:
+		return "", fmt.Errorf("unknown export format %q", name)
+	}
+}
+
+// Export writes the stored conversation of a room to w and returns the number of turns written
+func Export(ctx context.Context, mem types.ConversationMemory, w io.Writer, room string, opts *ExportOptions) (int, error) {
+	if opts == nil {
+		opts = &ExportOptions{}
+	}
+
+	history, err := mem.History(ctx, room)
+	if err != nil {
+		return 0, fmt.Errorf("failed to load conversation for export: %w", err)
+	}
+	turns := opts.filter(history)
+
+	bw := bufio.NewWriter(w)
+	switch opts.Format {
+	case FormatJSONL, "":
+		err = writeJSONL(bw, room, turns)
+	case FormatMarkdown:
+		err = writeMarkdown(bw, room, turns)
+	default:
+		return 0, fmt.Errorf("unknown export format %q", opts.Format)
+	}
+	if err == nil {
+		err = bw.Flush()
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to write conversation export: %w", err)
+	}
+	return len(turns), nil
+}
+
+// Export writes the stored conversation of a room to w and returns the number of turns written
+func (s *Store) Export(ctx context.Context, w io.Writer, room string, opts *ExportOptions) (int, error) {
+	return Export(ctx, s, w, room, opts)
+}
+
+// filter returns the turns selected by the options
+func (o *ExportOptions) filter(history []types.ConversationMessage) []types.ConversationMessage {
+	var turns []types.ConversationMessage
+	fromSender := false
+	for _, turn := range history {
+		if !o.Since.IsZero() && turn.Timestamp.Before(o.Since) {
+			continue
+		}
+		if !o.Until.IsZero() && !turn.Timestamp.Before(o.Until) {
+			continue
+		}
+
+		// Assistant turns belong to the sender of the user turn they answer
+		if turn.Role != RoleAssistant {
+			fromSender = strings.EqualFold(turn.Sender, o.Sender)
+		}
+		if o.Sender != "" && !fromSender {
+			continue
+		}
+		turns = append(turns, turn)
+	}
+	return turns
+}
+
+// writeJSONL writes one ExportRecord per line
+func writeJSONL(w io.Writer, room string, turns []types.ConversationMessage) error {
+	encoder := json.NewEncoder(w)
+	for _, turn := range turns {
+		if err := encoder.Encode(ExportRecord{Room: room, ConversationMessage: turn}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeMarkdown writes the turns as a transcript with a heading per turn
+func writeMarkdown(w io.Writer, room string, turns []types.ConversationMessage) error {
+	if room == "" {
+		room = "default"
+	}
+	if _, err := fmt.Fprintf(w, "# Conversation in %s\n\n%d messages\n", room, len(turns)); err != nil {
+		return err
+	}
+
+	for _, turn := range turns {
+		heading := "User"
+		if turn.Role == RoleAssistant {
+			heading = "Assistant"
+		}
+		if turn.Sender != "" {
+			heading += " (" + turn.Sender + ")"
+		}
+		if !turn.Timestamp.IsZero() {
+			heading += " - " + turn.Timestamp.UTC().Format(time.RFC3339)
+		}
+		if _, err := fmt.Fprintf(w, "\n## %s\n\n%s\n", heading, strings.TrimSpace(turn.Content)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package memory
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/types"
+)
+
+func exportStore(t *testing.T) *Store {
+	t.Helper()
+	start := time.Date(2025, 1, 15, 10, 0, 0, 0, time.UTC)
+	store := NewStore(&Config{})
+	err := store.Append(context.Background(), "room-1",
+		types.ConversationMessage{Role: RoleUser, Content: "hello", Sender: "0xAlice", Timestamp: start},
+		types.ConversationMessage{Role: RoleAssistant, Content: "hi alice", Timestamp: start.Add(time.Second)},
+		types.ConversationMessage{Role: RoleUser, Content: "price of ETH?", Sender: "0xBob", Timestamp: start.Add(time.Minute)},
+		types.ConversationMessage{Role: RoleAssistant, Content: "3,512 USD", Timestamp: start.Add(time.Minute + time.Second)},
+	)
+	if err != nil {
+		t.Fatalf("append failed: %v", err)
+	}
+	return store
+}
+
+func TestExportJSONL(t *testing.T) {
+	var buf bytes.Buffer
+	n, err := exportStore(t).Export(context.Background(), &buf, "room-1", nil)
+	if err != nil || n != 4 {
+		t.Fatalf("Export = %d, %v", n, err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("expected 4 lines, got %d", len(lines))
+	}
+	var record ExportRecord
+	if err := json.Unmarshal([]byte(lines[2]), &record); err != nil {
+		t.Fatalf("invalid JSON line: %v", err)
+	}
+	if record.Room != "room-1" || record.Role != RoleUser || record.Sender != "0xBob" || record.Content != "price of ETH?" {
+		t.Errorf("unexpected record: %+v", record)
+	}
+}
+
+func TestExportFilters(t *testing.T) {
+	start := time.Date(2025, 1, 15, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		opts ExportOptions
+		want []string
+	}{
+		{"sender with replies", ExportOptions{Sender: "0xbob"}, []string{"price of ETH?", "3,512 USD"}},
+		{"since", ExportOptions{Since: start.Add(time.Minute)}, []string{"price of ETH?", "3,512 USD"}},
+		{"until", ExportOptions{Until: start.Add(time.Minute)}, []string{"hello", "hi alice"}},
+		{"unknown sender", ExportOptions{Sender: "0xCarol"}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			n, err := exportStore(t).Export(context.Background(), &buf, "room-1", &tt.opts)
+			if err != nil || n != len(tt.want) {
+				t.Fatalf("Export = %d, %v, want %d turns", n, err, len(tt.want))
+			}
+			for _, content := range tt.want {
+				if !strings.Contains(buf.String(), content) {
+					t.Errorf("expected %q in export:\n%s", content, buf.String())
+				}
+			}
+		})
+	}
+}
+
+func TestExportMarkdown(t *testing.T) {
+	var buf bytes.Buffer
+	_, err := exportStore(t).Export(context.Background(), &buf, "room-1", &ExportOptions{Format: FormatMarkdown, Sender: "0xAlice"})
+	if err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+
+	want := "# Conversation in room-1\n\n2 messages\n" +
+		"\n## User (0xAlice) - 2025-01-15T10:00:00Z\n\nhello\n" +
+		"\n## Assistant - 2025-01-15T10:00:01Z\n\nhi alice\n"
+	if buf.String() != want {
+		t.Errorf("unexpected markdown:\n%s", buf.String())
+	}
+}
+
+func TestParseExportFormat(t *testing.T) {
+	if format, err := ParseExportFormat("md"); err != nil || format != FormatMarkdown {
+		t.Errorf("ParseExportFormat(md) = %q, %v", format, err)
+	}
+	if _, err := ParseExportFormat("csv"); err == nil {
+		t.Error("expected error for unknown format")
+	}
+}
@@ -0,0 +1,177 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/cache"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/llm"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/types"
+)
+
+// Message roles stored in the history
+const (
+	RoleUser      = llm.RoleUser
+	RoleAssistant = llm.RoleAssistant
+)
+
+// Config holds configuration for the conversation store
+type Config struct {
+	MaxMessages  int                   // Maximum turns kept per room (0 = unlimited)
+	MaxTokens    int                   // Maximum tokens kept per room (0 = unlimited)
+	TTL          time.Duration         // How long an idle room's history is kept in the cache (0 = forever)
+	Cache        cache.AgentCache      // Optional persistence (e.g. Redis); history is kept in memory when nil
+	TokenCounter func(text string) int // Counts tokens for MaxTokens (defaults to llm.EstimateTokens)
+}
+
+// DefaultConfig returns a configuration keeping the last 20 turns or 4000 tokens per room
+func DefaultConfig() *Config {
+	return &Config{
+		MaxMessages: 20,
+		MaxTokens:   4000,
+		TTL:         24 * time.Hour,
+	}
+}
+
+// Store keeps bounded conversation history per room
+type Store struct {
+	config *Config
+	rooms  map[string][]types.ConversationMessage
+	mu     sync.RWMutex
+}
+
+// NewStore creates a new conversation store
+func NewStore(config *Config) *Store {
+	if config == nil {
+		config = DefaultConfig()
+	}
+	if config.TokenCounter == nil {
+		config.TokenCounter = llm.EstimateTokens
+	}
+	if config.Cache != nil {
+		if _, noop := config.Cache.(*cache.NoOpCache); noop {
+			config.Cache = nil
+		}
+	}
+
+	return &Store{
+		config: config,
+		rooms:  make(map[string][]types.ConversationMessage),
+	}
+}
+
+// History implements the types.ConversationMemory interface
+func (s *Store) History(ctx context.Context, room string) ([]types.ConversationMessage, error) {
+	if s.config.Cache != nil {
+		return s.load(ctx, room)
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	history := s.rooms[room]
+	result := make([]types.ConversationMessage, len(history))
+	copy(result, history)
+	return result, nil
+}
+
+// Append implements the types.ConversationMemory interface
+func (s *Store) Append(ctx context.Context, room string, messages ...types.ConversationMessage) error {
+	now := time.Now()
+	for i := range messages {
+		if messages[i].Timestamp.IsZero() {
+			messages[i].Timestamp = now
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.config.Cache != nil {
+		history, err := s.load(ctx, room)
+		if err != nil {
+			return err
+		}
+		return s.save(ctx, room, s.trim(append(history, messages...)))
+	}
+
+	s.rooms[room] = s.trim(append(s.rooms[room], messages...))
+	return nil
+}
+
+// Clear removes a room's history
+func (s *Store) Clear(ctx context.Context, room string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.rooms, room)
+	if s.config.Cache != nil {
+		return s.config.Cache.Delete(ctx, roomKey(room))
+	}
+	return nil
+}
+
+// trim drops the oldest turns until the history fits the configured bounds.
+// The newest turn is always kept.
+func (s *Store) trim(history []types.ConversationMessage) []types.ConversationMessage {
+	if s.config.MaxMessages > 0 && len(history) > s.config.MaxMessages {
+		history = history[len(history)-s.config.MaxMessages:]
+	}
+
+	if s.config.MaxTokens > 0 {
+		total := 0
+		for _, msg := range history {
+			total += s.config.TokenCounter(msg.Content)
+		}
+		for total > s.config.MaxTokens && len(history) > 1 {
+			total -= s.config.TokenCounter(history[0].Content)
+			history = history[1:]
+		}
+	}
+
+	// Copy so the backing array doesn't keep trimmed turns alive
+	result := make([]types.ConversationMessage, len(history))
+	copy(result, history)
+	return result
+}
+
+// load reads a room's history from the cache
+func (s *Store) load(ctx context.Context, room string) ([]types.ConversationMessage, error) {
+	data, err := s.config.Cache.GetBytes(ctx, roomKey(room))
+	if errors.Is(err, cache.ErrCacheKeyNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load conversation for room %s: %w", room, err)
+	}
+
+	var history []types.ConversationMessage
+	if err := json.Unmarshal(data, &history); err != nil {
+		return nil, fmt.Errorf("failed to decode conversation for room %s: %w", room, err)
+	}
+	return history, nil
+}
+
+// save writes a room's history to the cache
+func (s *Store) save(ctx context.Context, room string, history []types.ConversationMessage) error {
+	data, err := json.Marshal(history)
+	if err != nil {
+		return fmt.Errorf("failed to encode conversation: %w", err)
+	}
+	if err := s.config.Cache.Set(ctx, roomKey(room), data, s.config.TTL); err != nil {
+		return fmt.Errorf("failed to store conversation for room %s: %w", room, err)
+	}
+	return nil
+}
+
+// roomKey returns the cache key for a room's history
+func roomKey(room string) string {
+	if room == "" {
+		room = "default"
+	}
+	return "memory:room:" + room
+}
@@ -0,0 +1,88 @@
+package memory
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/types"
+)
+
+func turn(role, content string) types.ConversationMessage {
+	return types.ConversationMessage{Role: role, Content: content}
+}
+
+func TestStoreBounds(t *testing.T) {
+	tests := []struct {
+		name        string
+		maxMessages int
+		maxTokens   int
+		turns       []string
+		want        []string
+	}{
+		{"unbounded", 0, 0, []string{"a", "b", "c"}, []string{"a", "b", "c"}},
+		{"message limit", 2, 0, []string{"a", "b", "c"}, []string{"b", "c"}},
+		{"token limit", 0, 2, []string{"aaaa", "bbbb", "cccc"}, []string{"bbbb", "cccc"}},
+		{"newest turn always kept", 0, 1, []string{"aaaa", strings.Repeat("b", 40)}, []string{strings.Repeat("b", 40)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			store := NewStore(&Config{MaxMessages: tt.maxMessages, MaxTokens: tt.maxTokens})
+
+			for _, content := range tt.turns {
+				if err := store.Append(ctx, "room-1", turn(RoleUser, content)); err != nil {
+					t.Fatalf("append failed: %v", err)
+				}
+			}
+
+			history, err := store.History(ctx, "room-1")
+			if err != nil {
+				t.Fatalf("history failed: %v", err)
+			}
+			if len(history) != len(tt.want) {
+				t.Fatalf("expected %d turns, got %d", len(tt.want), len(history))
+			}
+			for i, content := range tt.want {
+				if history[i].Content != content {
+					t.Errorf("turn %d: expected %q, got %q", i, content, history[i].Content)
+				}
+			}
+		})
+	}
+}
+
+func TestStoreRoomsAreIsolated(t *testing.T) {
+	ctx := context.Background()
+	store := NewStore(nil)
+
+	store.Append(ctx, "room-1", turn(RoleUser, "hello"), turn(RoleAssistant, "hi"))
+	store.Append(ctx, "room-2", turn(RoleUser, "other"))
+
+	if history, _ := store.History(ctx, "room-1"); len(history) != 2 {
+		t.Errorf("expected 2 turns in room-1, got %d", len(history))
+	}
+
+	if err := store.Clear(ctx, "room-1"); err != nil {
+		t.Fatalf("clear failed: %v", err)
+	}
+	if history, _ := store.History(ctx, "room-1"); len(history) != 0 {
+		t.Errorf("expected room-1 to be empty, got %d turns", len(history))
+	}
+	if history, _ := store.History(ctx, "room-2"); len(history) != 1 {
+		t.Errorf("expected 1 turn in room-2, got %d", len(history))
+	}
+}
+
+func TestHistoryFromContext(t *testing.T) {
+	if history := HistoryFromContext(context.Background()); history != nil {
+		t.Errorf("expected no history, got %v", history)
+	}
+
+	ctx := WithConversation(context.Background(), "room-1", []types.ConversationMessage{turn(RoleUser, "hello")})
+	conversation, ok := FromContext(ctx)
+	if !ok || conversation.Room != "room-1" || len(conversation.History) != 1 {
+		t.Errorf("unexpected conversation: %+v", conversation)
+	}
+}
@@ -0,0 +1,25 @@
+package metering
+
+import (
+	"net/http"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/internal/httputil"
+)
+
+// PathPrefix is the path under which usage reports are served
+const PathPrefix = "/usage"
+
+// Handler serves usage reports to requests carrying the bearer token:
+//
+//	GET  /usage[?sender=0x...]  report of the current period
+//	POST /usage/reset           report of the current period, starting a new one
+func (m *Meter) Handler(token string) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET "+PathPrefix, func(w http.ResponseWriter, req *http.Request) {
+		httputil.WriteJSON(w, http.StatusOK, m.Report(req.URL.Query().Get("sender")))
+	})
+	mux.HandleFunc("POST "+PathPrefix+"/reset", func(w http.ResponseWriter, req *http.Request) {
+		httputil.WriteJSON(w, http.StatusOK, m.Reset())
+	})
+	return httputil.RequireToken(token, mux)
+}
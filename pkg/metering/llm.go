@@ -0,0 +1,122 @@
+package metering
+
+import (
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/internal/httputil"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/types"
+)
+
+// LLMPath is the path under which LLM usage reports are served
+const LLMPath = PathPrefix + "/llm"
+
+// DefaultLLMRetention is how many days of LLM usage a ledger keeps
+const DefaultLLMRetention = 30
+
+// dayFormat formats the UTC day LLM usage is aggregated by
+const dayFormat = "2006-01-02"
+
+// LLMUsage is the LLM usage of one room on one day
+type LLMUsage struct {
+	Room string `json:"room"`
+	Day  string `json:"day"` // UTC, e.g. "2024-05-01"
+	types.LLMUsage
+	Tasks int64 `json:"tasks"`
+}
+
+// LLMReport is the LLM usage recorded by a ledger
+type LLMReport struct {
+	types.LLMUsage
+	Tasks int64      `json:"tasks"`
+	Usage []LLMUsage `json:"usage"` // By day, then room
+}
+
+// llmKey identifies a room's usage on a day
+type llmKey struct {
+	room string
+	day  string
+}
+
+// LLMLedger aggregates the LLM usage of tasks per room and per day. It
+// implements the types.LLMUsageRecorder interface and keeps the usage of
+// the last days in memory.
+type LLMLedger struct {
+	retention int
+	mu        sync.Mutex
+	usage     map[llmKey]*LLMUsage
+	now       func() time.Time
+}
+
+// NewLLMLedger creates a ledger keeping the given number of days (0 = DefaultLLMRetention)
+func NewLLMLedger(retention int) *LLMLedger {
+	if retention <= 0 {
+		retention = DefaultLLMRetention
+	}
+	return &LLMLedger{retention: retention, usage: make(map[llmKey]*LLMUsage), now: time.Now}
+}
+
+// RecordLLMUsage records what a task in room consumed today
+func (l *LLMLedger) RecordLLMUsage(room string, usage types.LLMUsage) {
+	now := l.now().UTC()
+	key := llmKey{room: room, day: now.Format(dayFormat)}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	entry := l.usage[key]
+	if entry == nil {
+		entry = &LLMUsage{Room: room, Day: key.day}
+		l.usage[key] = entry
+		l.prune(now)
+	}
+	entry.LLMUsage = entry.LLMUsage.Add(usage)
+	entry.Tasks++
+}
+
+// prune drops the days past the retention; the caller holds mu
+func (l *LLMLedger) prune(now time.Time) {
+	oldest := now.AddDate(0, 0, -(l.retention - 1)).Format(dayFormat)
+	for key := range l.usage {
+		if key.day < oldest {
+			delete(l.usage, key)
+		}
+	}
+}
+
+// Report returns the usage of one room and one day, either being all if empty
+func (l *LLMLedger) Report(room, day string) *LLMReport {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	report := &LLMReport{Usage: []LLMUsage{}}
+	for _, usage := range l.usage {
+		if (room != "" && usage.Room != room) || (day != "" && usage.Day != day) {
+			continue
+		}
+		report.Usage = append(report.Usage, *usage)
+		report.LLMUsage = report.LLMUsage.Add(usage.LLMUsage)
+		report.Tasks += usage.Tasks
+	}
+	sort.Slice(report.Usage, func(i, j int) bool {
+		a, b := report.Usage[i], report.Usage[j]
+		if a.Day != b.Day {
+			return a.Day < b.Day
+		}
+		return a.Room < b.Room
+	})
+	return report
+}
+
+// Handler serves LLM usage reports to requests carrying the bearer token:
+//
+//	GET /usage/llm[?room=...][&day=YYYY-MM-DD]
+func (l *LLMLedger) Handler(token string) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET "+LLMPath, func(w http.ResponseWriter, req *http.Request) {
+		query := req.URL.Query()
+		httputil.WriteJSON(w, http.StatusOK, l.Report(query.Get("room"), query.Get("day")))
+	})
+	return httputil.RequireToken(token, mux)
+}
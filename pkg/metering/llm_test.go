@@ -0,0 +1,50 @@
+package metering
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/types"
+)
+
+func TestLLMLedger(t *testing.T) {
+	ledger := NewLLMLedger(2)
+	day := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	ledger.now = func() time.Time { return day }
+
+	ledger.RecordLLMUsage("room-a", types.LLMUsage{PromptTokens: 100, CompletionTokens: 20, Cost: 0.5})
+	ledger.RecordLLMUsage("room-a", types.LLMUsage{PromptTokens: 50, CompletionTokens: 10})
+	ledger.RecordLLMUsage("room-b", types.LLMUsage{PromptTokens: 10, CompletionTokens: 5})
+	day = day.AddDate(0, 0, 1)
+	ledger.RecordLLMUsage("room-a", types.LLMUsage{PromptTokens: 1, CompletionTokens: 1})
+
+	report := ledger.Report("room-a", "2024-05-01")
+	if report.Tasks != 2 || report.PromptTokens != 150 || report.CompletionTokens != 30 || report.Cost != 0.5 {
+		t.Errorf("room-a on 2024-05-01 = %+v", report)
+	}
+	if report := ledger.Report("", ""); len(report.Usage) != 3 || report.Usage[2].Day != "2024-05-02" || report.Tasks != 4 {
+		t.Errorf("all usage = %+v", report)
+	}
+
+	// Days past the retention are dropped with the next new entry
+	day = day.AddDate(0, 0, 1)
+	ledger.RecordLLMUsage("room-b", types.LLMUsage{PromptTokens: 1})
+	if report := ledger.Report("", "2024-05-01"); len(report.Usage) != 0 {
+		t.Errorf("expected 2024-05-01 to be dropped, got %+v", report.Usage)
+	}
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, LLMPath+"?room=room-b", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	ledger.Handler("secret").ServeHTTP(rec, req)
+	var served LLMReport
+	if err := json.NewDecoder(rec.Body).Decode(&served); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("GET %s: status %d, error %v", LLMPath, rec.Code, err)
+	}
+	if served.Tasks != 1 || served.PromptTokens != 1 {
+		t.Errorf("served report = %+v", served)
+	}
+}
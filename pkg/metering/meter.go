@@ -0,0 +1,249 @@
+// Package metering prices the tasks an agent completes by capability,
+// accumulates usage per sender and capability, and issues signed receipts.
+package metering
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/auth"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/types"
+)
+
+// ErrInvalidReceipt is returned when a receipt's signature does not match its agent
+var ErrInvalidReceipt = errors.New("invalid usage receipt")
+
+// Signer signs receipts with the agent's wallet, e.g. an auth.Manager
+type Signer interface {
+	SignMessage(message string) (string, error)
+	GetAddress() string
+}
+
+// Config configures a Meter
+type Config struct {
+	Prices   map[string]Amount // Price per task by capability
+	Default  Amount            // Price of tasks without a priced capability
+	Currency string            // e.g. "USD"
+	Signer   Signer            // Signs receipts (nil = no receipts)
+}
+
+// Usage is what one sender used of one capability
+type Usage struct {
+	Sender     string `json:"sender"`
+	Capability string `json:"capability"` // Empty for tasks billed at the default price
+	Tasks      int64  `json:"tasks"`
+	Amount     Amount `json:"amount"`
+}
+
+// Report is the usage recorded in a period
+type Report struct {
+	Since    time.Time `json:"since"`
+	Until    time.Time `json:"until"`
+	Currency string    `json:"currency,omitempty"`
+	Tasks    int64     `json:"tasks"`
+	Amount   Amount    `json:"amount"`
+	Usage    []Usage   `json:"usage"` // By sender, then capability
+}
+
+// usageKey identifies a sender's usage of a capability
+type usageKey struct {
+	sender     string
+	capability string
+}
+
+// Meter records completed tasks and what they cost. It implements the
+// types.UsageMeter interface. Usage is kept in memory until Reset.
+type Meter struct {
+	config *Config
+	mu     sync.Mutex
+	usage  map[usageKey]*Usage
+	since  time.Time
+	totals map[string]uint64 // Tasks by capability since the meter was created
+}
+
+// New creates a meter
+func New(config *Config) *Meter {
+	if config == nil {
+		config = &Config{}
+	}
+	return &Meter{config: config, usage: make(map[usageKey]*Usage), since: time.Now(), totals: make(map[string]uint64)}
+}
+
+// Price returns the price of a task requiring the given capabilities of an
+// agent offering the given ones and the capability it is billed for, as
+// PriceOffered does, so usage is billed as payments are charged
+func (m *Meter) Price(required, offered []string) (string, Amount) {
+	return PriceOffered(m.config.Prices, m.config.Default, required, offered)
+}
+
+// PriceOffered returns the price of a task and the capability it is charged
+// for, judged by the capabilities the agent offers rather than those the
+// requester states: the highest price of the offered capabilities that meet
+// a stated requirement, matched with types.CapabilityMatches. A task whose
+// requirements meet no offered capability, or that states none, may be
+// routed to any of them and costs the highest price of all. An offered
+// capability is priced by its own entry or the entries it meets; the task
+// costs fallback, billed for no capability, only if none of them has a
+// price. Without offered capabilities the priced ones are used.
+func PriceOffered(prices map[string]Amount, fallback Amount, required, offered []string) (string, Amount) {
+	if len(offered) == 0 {
+		for capability := range prices {
+			offered = append(offered, capability)
+		}
+		sort.Strings(offered)
+	}
+
+	var matched []string
+	for _, capability := range offered {
+		for _, requirement := range required {
+			if types.CapabilityMatches(capability, requirement) {
+				matched = append(matched, capability)
+				break
+			}
+		}
+	}
+	if len(matched) == 0 {
+		matched = offered
+	}
+
+	billed, price, found := "", Amount(0), false
+	for _, capability := range matched {
+		if p, ok := offeredPrice(prices, capability); ok && (!found || p > price) {
+			billed, price, found = capability, p, true
+		}
+	}
+	if !found {
+		return "", fallback
+	}
+	return billed, price
+}
+
+// offeredPrice returns the price of an offered capability: its own entry, or
+// else the highest of the entries it meets. It reports false without one.
+func offeredPrice(prices map[string]Amount, capability string) (Amount, bool) {
+	if price, ok := prices[capability]; ok {
+		return price, true
+	}
+	var price Amount
+	found := false
+	for entry, p := range prices {
+		if types.CapabilityMatches(capability, entry) && (!found || p > price) {
+			price, found = p, true
+		}
+	}
+	return price, found
+}
+
+// RecordUsage records a completed task and returns its signed receipt, or
+// nil without a signer
+func (m *Meter) RecordUsage(ctx context.Context, task types.TaskInfo) (*types.UsageReceipt, error) {
+	capability, price := m.Price(task.Capabilities, task.Offered)
+
+	m.mu.Lock()
+	key := usageKey{sender: task.Sender, capability: capability}
+	usage := m.usage[key]
+	if usage == nil {
+		usage = &Usage{Sender: task.Sender, Capability: capability}
+		m.usage[key] = usage
+	}
+	usage.Tasks++
+	usage.Amount += price
+	m.totals[capability]++
+	m.mu.Unlock()
+
+	if m.config.Signer == nil {
+		return nil, nil
+	}
+	receipt := &types.UsageReceipt{
+		TaskID:     task.ID,
+		Agent:      m.config.Signer.GetAddress(),
+		Sender:     task.Sender,
+		Capability: capability,
+		Amount:     price.String(),
+		Currency:   m.config.Currency,
+		Timestamp:  time.Now().UTC(),
+	}
+	payload, err := receipt.SigningPayload()
+	if err != nil {
+		return nil, err
+	}
+	if receipt.Signature, err = m.config.Signer.SignMessage(string(payload)); err != nil {
+		return nil, fmt.Errorf("failed to sign usage receipt: %w", err)
+	}
+	return receipt, nil
+}
+
+// Report returns the usage since the meter was created or last reset,
+// of one sender or of all if sender is empty
+func (m *Meter) Report(sender string) *Report {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.report(sender)
+}
+
+// Reset returns the report of all usage and starts a new period
+func (m *Meter) Reset() *Report {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	report := m.report("")
+	m.usage = make(map[usageKey]*Usage)
+	m.since = report.Until
+	return report
+}
+
+// report builds a report; the caller holds mu
+func (m *Meter) report(sender string) *Report {
+	report := &Report{Since: m.since, Until: time.Now(), Currency: m.config.Currency, Usage: []Usage{}}
+	for _, usage := range m.usage {
+		if sender != "" && usage.Sender != sender {
+			continue
+		}
+		report.Usage = append(report.Usage, *usage)
+		report.Tasks += usage.Tasks
+		report.Amount += usage.Amount
+	}
+	sort.Slice(report.Usage, func(i, j int) bool {
+		a, b := report.Usage[i], report.Usage[j]
+		if a.Sender != b.Sender {
+			return a.Sender < b.Sender
+		}
+		return a.Capability < b.Capability
+	})
+	return report
+}
+
+// TasksByCapability returns the tasks recorded by capability since the meter
+// was created, unaffected by Reset, for metrics
+func (m *Meter) TasksByCapability() map[string]uint64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	tasks := make(map[string]uint64, len(m.totals))
+	for capability, n := range m.totals {
+		tasks[capability] = n
+	}
+	return tasks
+}
+
+// VerifyReceipt checks that a receipt was signed by the agent it names
+func VerifyReceipt(receipt *types.UsageReceipt) error {
+	if receipt.Signature == "" {
+		return fmt.Errorf("%w: not signed", ErrInvalidReceipt)
+	}
+	payload, err := receipt.SigningPayload()
+	if err != nil {
+		return err
+	}
+	signer, err := auth.RecoverSigner(string(payload), receipt.Signature)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidReceipt, err)
+	}
+	if !strings.EqualFold(signer.Hex(), receipt.Agent) {
+		return fmt.Errorf("%w: signed by %s", ErrInvalidReceipt, signer.Hex())
+	}
+	return nil
+}
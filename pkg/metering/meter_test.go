@@ -0,0 +1,189 @@
+package metering
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/auth"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/types"
+)
+
+func TestParseAmount(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+		err  bool
+	}{
+		{"0.002", "0.002", false},
+		{"1", "1", false},
+		{"1.50", "1.5", false},
+		{".5", "0.5", false},
+		{"0.000000001", "0.000000001", false},
+		{"0.0000000001", "", true},
+		{"-1", "", true},
+		{"abc", "", true},
+		{"", "", true},
+	}
+	for _, tt := range tests {
+		amount, err := ParseAmount(tt.in)
+		if (err != nil) != tt.err {
+			t.Errorf("ParseAmount(%q) error = %v, want error %v", tt.in, err, tt.err)
+			continue
+		}
+		if !tt.err && amount.String() != tt.want {
+			t.Errorf("ParseAmount(%q) = %s, want %s", tt.in, amount, tt.want)
+		}
+	}
+
+	// Sums stay exact
+	a, _ := ParseAmount("0.1")
+	b, _ := ParseAmount("0.2")
+	if (a + b).String() != "0.3" {
+		t.Errorf("0.1 + 0.2 = %s", a+b)
+	}
+}
+
+func TestMeterReportAndReceipts(t *testing.T) {
+	privateKey, _, err := auth.GeneratePrivateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := auth.NewManager(privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	prices, err := ParsePrices("web_scrape=0.01, text/summarization=0.002")
+	if err != nil {
+		t.Fatal(err)
+	}
+	meter := New(&Config{Prices: prices, Default: 1_000_000, Currency: "USD", Signer: signer})
+
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		meter.RecordUsage(ctx, types.TaskInfo{ID: "t", Sender: "0xalice", Capabilities: []string{"web_scrape"}})
+	}
+	meter.RecordUsage(ctx, types.TaskInfo{ID: "t", Sender: "0xbob", Capabilities: []string{"text/chat"}, Offered: []string{"text/chat", "web_scrape"}})
+	receipt, err := meter.RecordUsage(ctx, types.TaskInfo{ID: "t-5", Sender: "0xbob", Capabilities: []string{"other", "text/summarization"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if receipt.Capability != "text/summarization" || receipt.Amount != "0.002" || receipt.Currency != "USD" {
+		t.Errorf("unexpected receipt %+v", receipt)
+	}
+	if err := VerifyReceipt(receipt); err != nil {
+		t.Errorf("expected a valid receipt: %v", err)
+	}
+	receipt.Amount = "0"
+	if err := VerifyReceipt(receipt); !errors.Is(err, ErrInvalidReceipt) {
+		t.Errorf("expected a tampered receipt to fail, got %v", err)
+	}
+
+	report := meter.Report("")
+	if report.Tasks != 5 || report.Amount.String() != "0.033" || len(report.Usage) != 3 {
+		t.Errorf("unexpected report %+v", report)
+	}
+	if bob := meter.Report("0xbob"); bob.Tasks != 2 || bob.Amount.String() != "0.003" {
+		t.Errorf("unexpected report of one sender %+v", bob)
+	}
+
+	meter.Reset()
+	if report := meter.Report(""); report.Tasks != 0 {
+		t.Errorf("expected an empty period after reset, got %+v", report)
+	}
+	if tasks := meter.TasksByCapability(); tasks["web_scrape"] != 3 {
+		t.Errorf("expected totals to survive a reset, got %v", tasks)
+	}
+}
+
+func TestMeterPricesAsPayments(t *testing.T) {
+	prices := map[string]Amount{"text/summarize": 9}
+	meter := New(&Config{Prices: prices})
+	offered := []string{"text/summarize", "text/chat"}
+
+	tests := []struct {
+		name     string
+		required []string
+	}{
+		{"wildcard requirement", []string{"text/*"}},
+		{"no requirement", nil},
+		{"fewer stated capabilities", []string{"other"}},
+	}
+	for _, tt := range tests {
+		meter.Reset()
+		meter.RecordUsage(context.Background(), types.TaskInfo{Sender: "0xalice", Capabilities: tt.required, Offered: offered})
+		_, paid := PriceOffered(prices, 0, tt.required, offered)
+		if report := meter.Report(""); report.Amount != paid || paid != 9 {
+			t.Errorf("%s: billed %s, paid %s, want 9", tt.name, report.Amount, paid)
+		}
+	}
+}
+
+func TestHandler(t *testing.T) {
+	meter := New(&Config{Default: 1})
+	meter.RecordUsage(context.Background(), types.TaskInfo{Sender: "0xalice"})
+	handler := meter.Handler("secret")
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/usage", nil))
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 without a token, got %d", rec.Code)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/usage?sender=0xalice", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"tasks":1`) {
+		t.Errorf("unexpected report %d %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestPricesFromManifest(t *testing.T) {
+	prices := PricesFromManifest([]types.AgentCapability{
+		{Name: "web_scrape", Pricing: &types.PricingHint{Price: "0.01", Currency: "usd"}},
+		{Name: "chat", Pricing: &types.PricingHint{Price: "0.002", Currency: "USD", Unit: "1k_tokens"}},
+		{Name: "translate", Pricing: &types.PricingHint{Price: "1", Currency: "TENEO"}},
+	}, "USD")
+	if len(prices) != 1 || prices["web_scrape"].String() != "0.01" {
+		t.Errorf("unexpected prices %v", prices)
+	}
+}
+
+func TestPriceOffered(t *testing.T) {
+	prices := map[string]Amount{"web/scrape": 10, "text/summarize@2.0.0": 5}
+	offered := []string{"web/scrape@1.2.0", "text/summarize@2.0.0", "text/chat"}
+
+	tests := []struct {
+		name       string
+		required   []string
+		offered    []string
+		capability string
+		price      Amount
+	}{
+		{"exact requirement", []string{"text/summarize"}, offered, "text/summarize@2.0.0", 5},
+		{"requirement met by a priced entry", []string{"web/scrape"}, offered, "web/scrape@1.2.0", 10},
+		{"unpriced capability", []string{"text/chat"}, offered, "", 1},
+		{"wildcard takes the highest match", []string{"text/*"}, offered, "text/summarize@2.0.0", 5},
+		{"version constraint", []string{"web/scrape>=1.0.0"}, offered, "web/scrape@1.2.0", 10},
+		{"no requirement", nil, offered, "web/scrape@1.2.0", 10},
+		{"unmet requirement", []string{"web/scrape>=2.0.0"}, offered, "web/scrape@1.2.0", 10},
+		{"nothing offered", []string{"text/chat"}, nil, "web/scrape", 10},
+	}
+	for _, tt := range tests {
+		capability, price := PriceOffered(prices, 1, tt.required, tt.offered)
+		if capability != tt.capability || price != tt.price {
+			t.Errorf("%s: PriceOffered = %q, %d, want %q, %d", tt.name, capability, price, tt.capability, tt.price)
+		}
+	}
+
+	// A priced capability costs its price even below the default
+	capability, price := PriceOffered(map[string]Amount{"text/summarize": 1}, 5, []string{"text/summarize"}, []string{"text/summarize", "text/chat"})
+	if capability != "text/summarize" || price != 1 {
+		t.Errorf("PriceOffered below the default = %q, %d, want %q, 1", capability, price, "text/summarize")
+	}
+}
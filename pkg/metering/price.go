@@ -0,0 +1,124 @@
+package metering
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/logging"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/types"
+)
+
+// amountDecimals is the number of decimals an Amount keeps
+const amountDecimals = 9
+
+// amountScale is one unit of currency as an Amount
+const amountScale = 1_000_000_000
+
+// Amount is an amount of money in billionths of the currency unit, so that
+// sums of prices stay exact
+type Amount int64
+
+// ParseAmount parses a non-negative decimal amount such as "0.002"
+func ParseAmount(s string) (Amount, error) {
+	s = strings.TrimSpace(s)
+	whole, fraction, _ := strings.Cut(s, ".")
+	if whole == "" && fraction == "" || strings.HasPrefix(whole, "-") || strings.HasPrefix(whole, "+") {
+		return 0, fmt.Errorf("invalid amount %q", s)
+	}
+	if len(fraction) > amountDecimals {
+		return 0, fmt.Errorf("amount %q has more than %d decimals", s, amountDecimals)
+	}
+
+	var units int64
+	if whole != "" {
+		n, err := strconv.ParseInt(whole, 10, 64)
+		if err != nil || n > (1<<63-1)/amountScale {
+			return 0, fmt.Errorf("invalid amount %q", s)
+		}
+		units = n * amountScale
+	}
+	if fraction != "" {
+		n, err := strconv.ParseInt(fraction+strings.Repeat("0", amountDecimals-len(fraction)), 10, 64)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid amount %q", s)
+		}
+		units += n
+	}
+	return Amount(units), nil
+}
+
+// String formats the amount as a decimal without trailing zeros
+func (a Amount) String() string {
+	whole, fraction := int64(a)/amountScale, int64(a)%amountScale
+	if fraction == 0 {
+		return strconv.FormatInt(whole, 10)
+	}
+	return strings.TrimRight(fmt.Sprintf("%d.%09d", whole, fraction), "0")
+}
+
+// MarshalJSON encodes the amount as a decimal string
+func (a Amount) MarshalJSON() ([]byte, error) {
+	return json.Marshal(a.String())
+}
+
+// UnmarshalJSON decodes an amount from a decimal string
+func (a *Amount) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	parsed, err := ParseAmount(s)
+	if err != nil {
+		return err
+	}
+	*a = parsed
+	return nil
+}
+
+// ParsePrices parses "capability=price" pairs separated by commas, e.g.
+// "web_scrape=0.01,text/summarization=0.002"
+func ParsePrices(s string) (map[string]Amount, error) {
+	prices := make(map[string]Amount)
+	for _, pair := range strings.Split(s, ",") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		}
+		capability, value, ok := strings.Cut(pair, "=")
+		capability = strings.TrimSpace(capability)
+		if !ok || capability == "" {
+			return nil, fmt.Errorf("expected capability=price, got %q", pair)
+		}
+		price, err := ParseAmount(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid price for %s: %w", capability, err)
+		}
+		prices[capability] = price
+	}
+	return prices, nil
+}
+
+// PricesFromManifest returns the per-task prices of the manifest's pricing
+// hints in the given currency. Hints priced per another unit, such as
+// tokens, or in another currency are left out.
+func PricesFromManifest(manifest []types.AgentCapability, currency string) map[string]Amount {
+	prices := make(map[string]Amount)
+	for _, capability := range manifest {
+		hint := capability.Pricing
+		if hint == nil {
+			continue
+		}
+		if hint.Unit != "" && hint.Unit != "task" || !strings.EqualFold(hint.Currency, currency) {
+			logging.Debug("not metering pricing hint", "capability", capability.ID(), "unit", hint.Unit, "currency", hint.Currency)
+			continue
+		}
+		price, err := ParseAmount(hint.Price)
+		if err != nil {
+			logging.Warn("invalid price in capability manifest", "capability", capability.ID(), "error", err)
+			continue
+		}
+		prices[capability.ID()] = price
+	}
+	return prices
+}
@@ -0,0 +1,225 @@
+// Package migrate moves an agent's business card to a new NFT contract
+// version in one guided operation: mint a card on the new contract with the
+// old card's metadata, update the agent's registration and deactivate the old
+// card. Every step checks whether it already happened, so an interrupted
+// migration can simply be run again, and a dry run reports the plan without
+// sending a transaction.
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/logging"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/types"
+)
+
+// Steps of a migration, in the order they run
+const (
+	StepRead       = "read_old_card"
+	StepMint       = "mint_new_card"
+	StepCopy       = "copy_metadata"
+	StepRegister   = "update_registration"
+	StepDeactivate = "deactivate_old_card"
+)
+
+// StepStatus is the outcome of a migration step
+type StepStatus string
+
+const (
+	StepPlanned StepStatus = "planned" // Would run; only in a dry run
+	StepDone    StepStatus = "done"
+	StepSkipped StepStatus = "skipped" // Already done or not needed
+	StepFailed  StepStatus = "failed"
+)
+
+// Step reports one step of a migration
+type Step struct {
+	Name   string     `json:"name"`
+	Status StepStatus `json:"status"`
+	Detail string     `json:"detail,omitempty"`
+}
+
+// Reader reads business cards from a contract; nft.BusinessCardManager
+// implements it. A missing card is reported with types.ErrNFTNotFound.
+type Reader interface {
+	GetAgentByOwner(ctx context.Context, ownerAddress string) (*types.BusinessCard, error)
+	GetContractAddress() string
+}
+
+// Source is the contract the card moves from
+type Source interface {
+	Reader
+	SetAgentActive(ctx context.Context, active bool) error
+}
+
+// Target is the contract the card moves to
+type Target interface {
+	Reader
+	MintAgentCard(ctx context.Context, request *types.MintRequest) (*types.BusinessCard, error)
+	UpdateAgentMetadata(ctx context.Context, description, contactInfo, pricingModel, version string) error
+}
+
+// Config configures a migration
+type Config struct {
+	Owner string // Wallet owning the cards
+	From  Source
+	To    Target
+
+	// Update changes the copied metadata before the new card is minted, e.g.
+	// to set the SDK version (optional)
+	Update func(request *types.MintRequest)
+
+	// Register points the agent at the new card, e.g. by saving its token ID
+	// in the identity file (optional)
+	Register func(ctx context.Context, card *types.BusinessCard) error
+
+	KeepOldActive bool // Leave the old card active, e.g. to deactivate it later
+	DryRun        bool // Report the plan without sending transactions
+}
+
+// Result reports a migration
+type Result struct {
+	DryRun  bool                `json:"dry_run"`
+	Old     *types.BusinessCard `json:"old,omitempty"`
+	New     *types.BusinessCard `json:"new,omitempty"` // nil in a dry run until the card is minted
+	Request types.MintRequest   `json:"request"`       // Metadata of the new card
+	Steps   []Step              `json:"steps"`
+}
+
+func (r *Result) step(name string, status StepStatus, detail string, args ...interface{}) {
+	if len(args) > 0 {
+		detail = fmt.Sprintf(detail, args...)
+	}
+	r.Steps = append(r.Steps, Step{Name: name, Status: status, Detail: detail})
+	logging.Info("migration step", "step", name, "status", status, "detail", detail, "dry_run", r.DryRun)
+}
+
+// fail records a failed step and returns its error
+func (r *Result) fail(name string, err error) error {
+	r.step(name, StepFailed, err.Error())
+	return fmt.Errorf("migration failed at %s: %w", name, err)
+}
+
+// Run migrates the owner's card from config.From to config.To. The new card
+// is registered before the old one is deactivated, so the agent always has an
+// active card. On failure the result reports the steps up to the failed one.
+func Run(ctx context.Context, config *Config) (*Result, error) {
+	if config == nil || config.From == nil || config.To == nil || config.Owner == "" {
+		return nil, errors.New("migration needs an owner, a source and a target contract")
+	}
+	from, to := config.From.GetContractAddress(), config.To.GetContractAddress()
+	if strings.EqualFold(from, to) {
+		return nil, fmt.Errorf("source and target contract are the same (%s)", from)
+	}
+	result := &Result{DryRun: config.DryRun}
+
+	old, err := config.From.GetAgentByOwner(ctx, config.Owner)
+	if err != nil {
+		return result, result.fail(StepRead, err)
+	}
+	result.Old = old
+	result.step(StepRead, StepDone, "token %s on %s", old.TokenID, from)
+
+	result.Request = mintRequest(old.Metadata)
+	if config.Update != nil {
+		config.Update(&result.Request)
+	}
+	if validation := result.Request.Validate(); !validation.IsValid {
+		return result, result.fail(StepMint, fmt.Errorf("invalid metadata for the new card: %s", strings.Join(validation.Errors, ", ")))
+	}
+
+	// A card already on the target is from an earlier, interrupted run
+	existing, err := config.To.GetAgentByOwner(ctx, config.Owner)
+	switch {
+	case err == nil:
+		result.New = existing
+		result.step(StepMint, StepSkipped, "already minted token %s on %s", existing.TokenID, to)
+		if err := copyMetadata(ctx, config, result); err != nil {
+			return result, err
+		}
+	case errors.Is(err, types.ErrNFTNotFound):
+		if config.DryRun {
+			result.step(StepMint, StepPlanned, "mint %q on %s", result.Request.Name, to)
+		} else {
+			card, err := config.To.MintAgentCard(ctx, &result.Request)
+			if err != nil {
+				return result, result.fail(StepMint, err)
+			}
+			result.New = card
+			result.step(StepMint, StepDone, "minted token %s on %s", card.TokenID, to)
+		}
+		result.step(StepCopy, StepSkipped, "copied when minting")
+	default:
+		return result, result.fail(StepMint, fmt.Errorf("failed to check the target contract: %w", err))
+	}
+
+	switch {
+	case config.Register == nil:
+		result.step(StepRegister, StepSkipped, "no registration to update")
+	case config.DryRun:
+		result.step(StepRegister, StepPlanned, "register the new card")
+	default:
+		if err := config.Register(ctx, result.New); err != nil {
+			return result, result.fail(StepRegister, err)
+		}
+		result.step(StepRegister, StepDone, "registered token %s", result.New.TokenID)
+	}
+
+	switch {
+	case config.KeepOldActive:
+		result.step(StepDeactivate, StepSkipped, "kept active")
+	case !old.Metadata.IsActive:
+		result.step(StepDeactivate, StepSkipped, "already inactive")
+	case config.DryRun:
+		result.step(StepDeactivate, StepPlanned, "deactivate token %s on %s", old.TokenID, from)
+	default:
+		if err := config.From.SetAgentActive(ctx, false); err != nil {
+			return result, result.fail(StepDeactivate, err)
+		}
+		result.step(StepDeactivate, StepDone, "deactivated token %s on %s", old.TokenID, from)
+	}
+	return result, nil
+}
+
+// copyMetadata brings the updatable metadata of an existing new card in line
+// with the old card
+func copyMetadata(ctx context.Context, config *Config, result *Result) error {
+	want, have := result.Request, result.New.Metadata
+	if want.Description == have.Description && want.ContactInfo == have.ContactInfo &&
+		want.PricingModel == have.PricingModel && want.Version == have.Version {
+		result.step(StepCopy, StepSkipped, "metadata matches")
+		return nil
+	}
+	if config.DryRun {
+		result.step(StepCopy, StepPlanned, "update description, contact, pricing and version")
+		return nil
+	}
+	if err := config.To.UpdateAgentMetadata(ctx, want.Description, want.ContactInfo, want.PricingModel, want.Version); err != nil {
+		return result.fail(StepCopy, err)
+	}
+	result.New.Metadata.Description = want.Description
+	result.New.Metadata.ContactInfo = want.ContactInfo
+	result.New.Metadata.PricingModel = want.PricingModel
+	result.New.Metadata.Version = want.Version
+	result.step(StepCopy, StepDone, "updated description, contact, pricing and version")
+	return nil
+}
+
+// mintRequest returns the request minting a card with the given metadata
+func mintRequest(m types.AgentMetadata) types.MintRequest {
+	return types.MintRequest{
+		Name:           m.Name,
+		Description:    m.Description,
+		Capabilities:   append([]string(nil), m.Capabilities...),
+		ContactInfo:    m.ContactInfo,
+		PricingModel:   m.PricingModel,
+		InterfaceType:  m.InterfaceType,
+		ResponseFormat: m.ResponseFormat,
+		Version:        m.Version,
+		SDKVersion:     m.SDKVersion,
+		ImageURI:       m.ImageURI,
+	}
+}
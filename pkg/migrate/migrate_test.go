@@ -0,0 +1,169 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/types"
+)
+
+const owner = "0x1111111111111111111111111111111111111111"
+
+// fakeContract keeps one card per owner and records the transactions sent
+type fakeContract struct {
+	address string
+	card    *types.BusinessCard
+	sent    []string
+	fail    error // Returned by every transaction
+}
+
+func (f *fakeContract) GetAgentByOwner(ctx context.Context, ownerAddress string) (*types.BusinessCard, error) {
+	if f.card == nil {
+		return nil, types.ErrNFTNotFound
+	}
+	card := *f.card
+	return &card, nil
+}
+
+func (f *fakeContract) GetContractAddress() string {
+	return f.address
+}
+
+func (f *fakeContract) SetAgentActive(ctx context.Context, active bool) error {
+	f.sent = append(f.sent, "set_active")
+	if f.fail != nil {
+		return f.fail
+	}
+	f.card.Metadata.IsActive = active
+	return nil
+}
+
+func (f *fakeContract) MintAgentCard(ctx context.Context, r *types.MintRequest) (*types.BusinessCard, error) {
+	f.sent = append(f.sent, "mint")
+	if f.fail != nil {
+		return nil, f.fail
+	}
+	f.card = &types.BusinessCard{TokenID: big.NewInt(7), Owner: owner, ContractAddr: f.address, Metadata: types.AgentMetadata{
+		Name: r.Name, Description: r.Description, Capabilities: r.Capabilities, Version: r.Version, SDKVersion: r.SDKVersion, IsActive: true,
+	}}
+	card := *f.card
+	return &card, nil
+}
+
+func (f *fakeContract) UpdateAgentMetadata(ctx context.Context, description, contactInfo, pricingModel, version string) error {
+	f.sent = append(f.sent, "update")
+	f.card.Metadata.Description = description
+	f.card.Metadata.Version = version
+	return nil
+}
+
+func contracts() (*fakeContract, *fakeContract) {
+	from := &fakeContract{address: "0xaaaa", card: &types.BusinessCard{TokenID: big.NewInt(3), Owner: owner, Metadata: types.AgentMetadata{
+		Name: "Weather", Description: "Forecasts", Capabilities: []string{"weather"}, Version: "1.0.0", SDKVersion: "1.0.0", IsActive: true,
+	}}}
+	return from, &fakeContract{address: "0xbbbb"}
+}
+
+func statuses(r *Result) []StepStatus {
+	var s []StepStatus
+	for _, step := range r.Steps {
+		s = append(s, step.Status)
+	}
+	return s
+}
+
+func TestRun(t *testing.T) {
+	from, to := contracts()
+	var registered *types.BusinessCard
+	result, err := Run(context.Background(), &Config{
+		Owner:    owner,
+		From:     from,
+		To:       to,
+		Update:   func(r *types.MintRequest) { r.SDKVersion = "2.1.0" },
+		Register: func(ctx context.Context, card *types.BusinessCard) error { registered = card; return nil },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []StepStatus{StepDone, StepDone, StepSkipped, StepDone, StepDone}
+	if got := statuses(result); !reflect.DeepEqual(got, want) {
+		t.Errorf("got steps %v, want %v", got, want)
+	}
+	if to.card.Metadata.Name != "Weather" || to.card.Metadata.SDKVersion != "2.1.0" {
+		t.Errorf("new card has metadata %+v", to.card.Metadata)
+	}
+	if registered == nil || registered.TokenID.Int64() != 7 {
+		t.Errorf("registered %+v, want token 7", registered)
+	}
+	if from.card.Metadata.IsActive {
+		t.Error("old card is still active")
+	}
+}
+
+func TestDryRunSendsNothing(t *testing.T) {
+	from, to := contracts()
+	result, err := Run(context.Background(), &Config{
+		Owner: owner,
+		From:  from,
+		To:    to,
+		Register: func(ctx context.Context, card *types.BusinessCard) error {
+			t.Error("registered in a dry run")
+			return nil
+		},
+		DryRun: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(from.sent) > 0 || len(to.sent) > 0 {
+		t.Errorf("sent %v and %v in a dry run", from.sent, to.sent)
+	}
+	want := []StepStatus{StepDone, StepPlanned, StepSkipped, StepPlanned, StepPlanned}
+	if got := statuses(result); !reflect.DeepEqual(got, want) {
+		t.Errorf("got steps %v, want %v", got, want)
+	}
+}
+
+func TestResumeAfterInterruption(t *testing.T) {
+	from, to := contracts()
+	// The first run minted the card but failed to deactivate the old one
+	from.fail = errors.New("out of gas")
+	if _, err := Run(context.Background(), &Config{Owner: owner, From: from, To: to}); err == nil {
+		t.Fatal("expected the deactivation to fail")
+	}
+	to.card.Metadata.Description = "Outdated"
+
+	from.fail = nil
+	to.sent = nil
+	result, err := Run(context.Background(), &Config{Owner: owner, From: from, To: to})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"update"}; !reflect.DeepEqual(to.sent, want) {
+		t.Errorf("sent %v to the new contract, want %v", to.sent, want)
+	}
+	want := []StepStatus{StepDone, StepSkipped, StepDone, StepSkipped, StepDone}
+	if got := statuses(result); !reflect.DeepEqual(got, want) {
+		t.Errorf("got steps %v, want %v", got, want)
+	}
+}
+
+func TestRunRejectsInvalidSetups(t *testing.T) {
+	from, _ := contracts()
+	if _, err := Run(context.Background(), &Config{Owner: owner, From: from, To: &fakeContract{address: "0xAAAA"}}); err == nil {
+		t.Error("expected an error for the same contract")
+	}
+
+	_, to := contracts()
+	result, err := Run(context.Background(), &Config{Owner: owner, From: &fakeContract{address: "0xaaaa"}, To: to})
+	if !errors.Is(err, types.ErrNFTNotFound) {
+		t.Errorf("got %v, want ErrNFTNotFound", err)
+	}
+	if got := statuses(result); !reflect.DeepEqual(got, []StepStatus{StepFailed}) {
+		t.Errorf("got steps %v", got)
+	}
+}
@@ -0,0 +1,62 @@
+package network
+
+import (
+	"context"
+	"errors"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/logging"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/output"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/types"
+)
+
+// SetAccessChecker sets the checker that decides which senders and rooms
+// tasks are accepted from (nil accepts every task)
+func (t *TaskCoordinator) SetAccessChecker(checker types.AccessChecker) {
+	t.rateLimitMu.Lock()
+	defer t.rateLimitMu.Unlock()
+	t.accessChecker = checker
+}
+
+// getAccessChecker returns the configured access checker
+func (t *TaskCoordinator) getAccessChecker() types.AccessChecker {
+	t.rateLimitMu.Lock()
+	defer t.rateLimitMu.Unlock()
+	return t.accessChecker
+}
+
+// checkAccess rejects tasks from senders or rooms the access checker denies,
+// with the reason in the rejection details. The sender is the one carried by
+// ctx, see WithSender. Returns the rejection code, or ""
+// if the task can be processed.
+func (t *TaskCoordinator) checkAccess(ctx context.Context, msg *types.Message, taskID string) string {
+	checker := t.getAccessChecker()
+	if checker == nil {
+		return ""
+	}
+
+	sender := SenderFromContext(ctx)
+	err := checker.CheckAccess(ctx, sender, msg.Room)
+	if err == nil {
+		return ""
+	}
+
+	content, errorCode := "⚠️ Access to this agent could not be verified. Please try again later.", "access_unverified"
+	details := map[string]interface{}{"reason": err.Error()}
+	if errors.Is(err, types.ErrAccessDenied) {
+		content, errorCode = "⚠️ You are not allowed to use this agent.", "access_denied"
+		reason := "denied"
+		var accessErr *types.AccessError
+		if errors.As(err, &accessErr) {
+			details = map[string]interface{}{"access": accessErr}
+			reason = accessErr.Reason
+		}
+		if recorder, ok := t.getMetricsRecorder().(types.AccessRecorder); ok {
+			recorder.RecordAccessDenied(reason)
+		}
+	}
+
+	logging.Warn("access check rejected task", "task_id", taskID, "sender", sender, "room", msg.Room, "code", errorCode, "error", err)
+	t.recordRejection(errorCode)
+	t.protocolHandler.SendTaskRejection(ctx, taskID, output.Clean(content), errorCode, msg.Room, details)
+	return errorCode
+}
@@ -0,0 +1,64 @@
+package network
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/types"
+)
+
+// denySenders denies the listed senders and allows everyone else
+type denySenders map[string]bool
+
+func (d denySenders) CheckAccess(ctx context.Context, sender, room string) error {
+	if d[sender] {
+		return &types.AccessError{Reason: "sender_denied", Message: "denied"}
+	}
+	return nil
+}
+
+func TestUserMessageAccessKeyedOnSender(t *testing.T) {
+	denied := "0x00000000000000000000000000000000000000aa"
+	allowed := "0x00000000000000000000000000000000000000bb"
+
+	handler := &standardHandler{reply: "ok"}
+	coordinator := newTestCoordinator(handler)
+	coordinator.SetAccessChecker(denySenders{denied: true})
+	outbound := captureOutbound(coordinator)
+
+	// The denied sender claims an allowed address in the message data
+	msg := userMessage("hello")
+	msg.From = denied
+	msg.Data, _ = json.Marshal(map[string]interface{}{"user_address": allowed})
+	if err := coordinator.HandleUserMessage(msg); err != nil {
+		t.Fatal(err)
+	}
+	sent := outbound.take()
+	if len(sent) != 1 || responseError(t, sent[0]) != "access_denied" {
+		t.Fatalf("sent %+v, want one access_denied rejection", sent)
+	}
+	if handler.calls.Load() != 0 {
+		t.Fatal("handler ran for a denied sender")
+	}
+}
+
+func TestCoordinatorTaskAccessKeyedOnRequester(t *testing.T) {
+	denied := "0x00000000000000000000000000000000000000aa"
+
+	handler := &standardHandler{reply: "ok"}
+	coordinator := newTestCoordinator(handler)
+	coordinator.SetAccessChecker(denySenders{denied: true})
+	recorder := &responseRecorder{}
+
+	// The coordinator relays the requester in the task data
+	msg := taskMessage("task-1", "hello")
+	msg.From = "coordinator"
+	msg.Data, _ = json.Marshal(map[string]interface{}{"task_id": "task-1", "user_address": denied})
+	if status := coordinator.RunTask(context.Background(), msg, recorder.respond); status != "access_denied" {
+		t.Fatalf("status = %q, want access_denied", status)
+	}
+	if handler.calls.Load() != 0 {
+		t.Fatal("handler ran for a denied requester")
+	}
+}
@@ -0,0 +1,106 @@
+package network
+
+import (
+	"encoding/json"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/events"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/logging"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/types"
+)
+
+// Message classes with a circuit breaker of their own, so failures sending
+// one kind of message don't block the others: a flood of failing task
+// responses doesn't keep the agent from authenticating
+const (
+	BreakerAuth         = "auth"          // Challenge requests and authentication
+	BreakerTaskResponse = "task_response" // Task responses and updates
+	BreakerCapabilities = "capabilities"  // Registration and capabilities
+	BreakerDefault      = "default"       // Everything else, e.g. pings
+)
+
+// BreakerClasses are the message classes, in the order stats are reported
+var BreakerClasses = []string{BreakerAuth, BreakerTaskResponse, BreakerCapabilities, BreakerDefault}
+
+// BreakerClass returns the class of circuit breaker a message type is sent through
+func BreakerClass(msgType string) string {
+	switch msgType {
+	case types.MessageTypeAuth, types.MessageTypeRequestChallenge:
+		return BreakerAuth
+	case types.MessageTypeTaskResponse, types.MessageTypeTaskResult:
+		return BreakerTaskResponse
+	case types.MessageTypeRegister, types.MessageTypeRegistration, types.MessageTypeCapabilities:
+		return BreakerCapabilities
+	default:
+		return BreakerDefault
+	}
+}
+
+// newCircuitBreakers creates a breaker per message class from the client's
+// config: CircuitBreakers overrides CircuitBreaker for a class
+func (c *NetworkClient) newCircuitBreakers(config *Config) {
+	defaults := config.CircuitBreaker.withDefaults(DefaultCircuitBreakerConfig())
+	c.circuitBreakers = make(map[string]*CircuitBreaker, len(BreakerClasses))
+	for _, class := range BreakerClasses {
+		class := class
+		breaker := NewCircuitBreakerWithConfig(config.CircuitBreakers[class].withDefaults(defaults))
+		breaker.SetStateChangeHandler(func(from, to CircuitState) {
+			logging.Info("circuit breaker state changed", "breaker", class, "from", from, "to", to)
+			c.eventBus.Publish(events.CircuitChanged{Breaker: class, From: from.String(), To: to.String()})
+		})
+		c.circuitBreakers[class] = breaker
+	}
+	for class := range config.CircuitBreakers {
+		if _, ok := c.circuitBreakers[class]; !ok {
+			logging.Warn("ignoring circuit breaker of unknown message class", "breaker", class)
+		}
+	}
+}
+
+// circuitBreaker returns the breaker a message type is sent through
+func (c *NetworkClient) circuitBreaker(msgType string) *CircuitBreaker {
+	return c.circuitBreakers[BreakerClass(msgType)]
+}
+
+// rawMessageType returns the type of a raw JSON message, empty if it has none
+func rawMessageType(data []byte) string {
+	var head struct {
+		Type string `json:"type"`
+	}
+	json.Unmarshal(data, &head)
+	return head.Type
+}
+
+// GetCircuitBreakerStats returns the statistics of the circuit breaker in the
+// worst state: open, then half-open, then the one with the most failures
+func (c *NetworkClient) GetCircuitBreakerStats() CircuitBreakerStats {
+	var worst CircuitBreakerStats
+	for i, class := range BreakerClasses {
+		stats := c.circuitBreakers[class].GetStats()
+		if i == 0 || breakerSeverity(stats) > breakerSeverity(worst) {
+			worst = stats
+		}
+	}
+	return worst
+}
+
+// GetCircuitBreakerStatsByClass returns the statistics of the circuit
+// breaker of each message class
+func (c *NetworkClient) GetCircuitBreakerStatsByClass() map[string]CircuitBreakerStats {
+	stats := make(map[string]CircuitBreakerStats, len(c.circuitBreakers))
+	for class, breaker := range c.circuitBreakers {
+		stats[class] = breaker.GetStats()
+	}
+	return stats
+}
+
+// breakerSeverity orders breaker stats from healthy to broken
+func breakerSeverity(stats CircuitBreakerStats) int {
+	severity := stats.Failures
+	switch stats.State {
+	case CircuitOpen:
+		severity += 2 << 20
+	case CircuitHalfOpen:
+		severity += 1 << 20
+	}
+	return severity
+}
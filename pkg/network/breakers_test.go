@@ -0,0 +1,71 @@
+package network
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/types"
+)
+
+func TestBreakerClass(t *testing.T) {
+	tests := map[string]string{
+		types.MessageTypeAuth:             BreakerAuth,
+		types.MessageTypeRequestChallenge: BreakerAuth,
+		types.MessageTypeTaskResponse:     BreakerTaskResponse,
+		types.MessageTypeTaskResult:       BreakerTaskResponse,
+		types.MessageTypeRegister:         BreakerCapabilities,
+		types.MessageTypeCapabilities:     BreakerCapabilities,
+		types.MessageTypePing:             BreakerDefault,
+		"":                                BreakerDefault,
+	}
+	for msgType, want := range tests {
+		if got := BreakerClass(msgType); got != want {
+			t.Errorf("BreakerClass(%q) = %q, want %q", msgType, got, want)
+		}
+	}
+}
+
+func TestTrippedBreakerLeavesOtherClassesClosed(t *testing.T) {
+	client := NewNetworkClient(&Config{
+		WebSocketURL:    "ws://localhost",
+		CircuitBreaker:  CircuitBreakerConfig{MaxFailures: 5},
+		CircuitBreakers: map[string]CircuitBreakerConfig{BreakerTaskResponse: {MaxFailures: 2}},
+	})
+
+	// Task responses fail until their breaker opens, at its own threshold
+	failure := errors.New("send failed")
+	for range 2 {
+		client.circuitBreaker(types.MessageTypeTaskResponse).Call(func() error { return failure })
+	}
+	if state := client.circuitBreaker(types.MessageTypeTaskResponse).GetState(); state != CircuitOpen {
+		t.Fatalf("task response breaker is %s, want open", state)
+	}
+	if err := client.circuitBreaker(types.MessageTypeTaskResult).Call(func() error { return nil }); err == nil {
+		t.Error("open task response breaker let a task result through")
+	}
+
+	// Authentication and the other classes still go through
+	for _, msgType := range []string{types.MessageTypeAuth, types.MessageTypeCapabilities, types.MessageTypePing} {
+		called := false
+		if err := client.circuitBreaker(msgType).Call(func() error { called = true; return nil }); err != nil || !called {
+			t.Errorf("%s: Call() = %v, called = %v, want it to go through", msgType, err, called)
+		}
+	}
+
+	stats := client.GetCircuitBreakerStatsByClass()
+	for _, class := range BreakerClasses {
+		want := CircuitClosed
+		if class == BreakerTaskResponse {
+			want = CircuitOpen
+		}
+		if stats[class].State != want {
+			t.Errorf("%s breaker is %s, want %s", class, stats[class].State, want)
+		}
+	}
+	if worst := client.GetCircuitBreakerStats(); worst.State != CircuitOpen {
+		t.Errorf("worst breaker is %s, want open", worst.State)
+	}
+	if got := client.circuitBreaker(types.MessageTypeAuth).Config().MaxFailures; got != 5 {
+		t.Errorf("auth breaker opens after %d failures, want the default 5", got)
+	}
+}
@@ -0,0 +1,54 @@
+package network
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/types"
+)
+
+// SetTaskBudget sets what each task may consume: wall time, LLM tokens and
+// outbound messages (nil = unlimited). The budget is attached to the task's
+// context, see types.BudgetFromContext.
+func (t *TaskCoordinator) SetTaskBudget(budget *types.TaskBudget) {
+	t.rateLimitMu.Lock()
+	defer t.rateLimitMu.Unlock()
+	t.budget = budget
+}
+
+// GetTaskBudget returns the budget of each task, nil if unlimited
+func (t *TaskCoordinator) GetTaskBudget() *types.TaskBudget {
+	t.rateLimitMu.Lock()
+	defer t.rateLimitMu.Unlock()
+	return t.budget
+}
+
+// newBudget starts tracking the budget of a task, nil if tasks are unlimited
+func (t *TaskCoordinator) newBudget() *types.Budget {
+	limits := t.GetTaskBudget()
+	if limits == nil || limits.IsZero() {
+		return nil
+	}
+	return types.NewBudget(*limits)
+}
+
+// budgetError reports a task that ran past its deadline because its budget
+// ran out of wall time as BUDGET_EXHAUSTED rather than as a timeout
+func budgetError(ctx context.Context, budget *types.Budget, err error) error {
+	if err == nil || budget == nil || !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return err
+	}
+	if exhausted := budget.CheckWallTime(); exhausted != nil {
+		return exhausted
+	}
+	return err
+}
+
+// earlier returns the earlier of two times, a zero time counting as no limit
+func earlier(a, b time.Time) time.Time {
+	if a.IsZero() || (!b.IsZero() && b.Before(a)) {
+		return b
+	}
+	return a
+}
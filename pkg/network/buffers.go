@@ -0,0 +1,178 @@
+package network
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/errs"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/logging"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/types"
+)
+
+// DefaultBufferSize is the number of messages the send and receive buffers hold
+// when no size is configured
+const DefaultBufferSize = 100
+
+// sendBufferTimeout is how long a send waits for room in a full buffer with OverflowBlock
+const sendBufferTimeout = 5 * time.Second
+
+// ErrBufferFull is returned for a message sent while the send buffer is full
+// with OverflowError, or still full after waiting with OverflowBlock. It is
+// retryable, so the message moves to the retry queue.
+var ErrBufferFull = errors.New("send buffer full")
+
+// OverflowPolicy decides what happens to a message that finds its buffer full
+type OverflowPolicy string
+
+const (
+	// OverflowBlock waits for room: a send fails with ErrBufferFull after 5s,
+	// a received message holds up reading the connection (default)
+	OverflowBlock OverflowPolicy = "block"
+	// OverflowDropOldest makes room by discarding the oldest buffered message.
+	// Receive buffer only: outgoing messages, such as final task responses and
+	// the parts of a chunked one, are never discarded, so a send buffer set to
+	// it blocks instead.
+	OverflowDropOldest OverflowPolicy = "drop_oldest"
+	// OverflowError fails a send with ErrBufferFull right away and discards a
+	// received message
+	OverflowError OverflowPolicy = "error"
+)
+
+// ParseOverflowPolicy parses a buffer overflow policy ("" = block)
+func ParseOverflowPolicy(s string) (OverflowPolicy, error) {
+	switch policy := OverflowPolicy(strings.ToLower(strings.TrimSpace(s))); policy {
+	case "":
+		return OverflowBlock, nil
+	case OverflowBlock, OverflowDropOldest, OverflowError:
+		return policy, nil
+	case "drop-oldest":
+		return OverflowDropOldest, nil
+	}
+	return "", fmt.Errorf("invalid buffer overflow policy %q (use \"block\", \"drop_oldest\" or \"error\")", s)
+}
+
+// BufferStats reports how full the send and receive buffers are and how
+// often they overflowed, for tuning their sizes
+type BufferStats struct {
+	SendBuffered    int   `json:"send_buffered"`    // Messages waiting to be written
+	SendCapacity    int   `json:"send_capacity"`    // Size of the send buffer
+	SendFull        int64 `json:"send_full"`        // Sends that found the buffer full
+	SendDropped     int64 `json:"send_dropped"`     // Messages discarded or failed because the buffer was full
+	ReceiveBuffered int   `json:"receive_buffered"` // Messages waiting to be handled
+	ReceiveCapacity int   `json:"receive_capacity"` // Size of the receive buffer
+	ReceiveFull     int64 `json:"receive_full"`     // Received messages that found the buffer full
+	ReceiveDropped  int64 `json:"receive_dropped"`  // Received messages discarded because the buffer was full
+}
+
+// SendUtilization returns the fraction of the send buffer in use
+func (s BufferStats) SendUtilization() float64 {
+	if s.SendCapacity == 0 {
+		return 0
+	}
+	return float64(s.SendBuffered) / float64(s.SendCapacity)
+}
+
+// ReceiveUtilization returns the fraction of the receive buffer in use
+func (s BufferStats) ReceiveUtilization() float64 {
+	if s.ReceiveCapacity == 0 {
+		return 0
+	}
+	return float64(s.ReceiveBuffered) / float64(s.ReceiveCapacity)
+}
+
+// buffer is a channel of messages with an overflow policy
+type buffer struct {
+	ch      chan *types.Message
+	policy  OverflowPolicy
+	full    atomic.Int64
+	dropped atomic.Int64
+}
+
+// newSendBuffer creates the buffer of outgoing messages, which blocks in
+// place of OverflowDropOldest
+func newSendBuffer(size int, policy OverflowPolicy) *buffer {
+	if policy == OverflowDropOldest {
+		logging.Warn("drop_oldest is not supported for the send buffer, blocking instead")
+		policy = OverflowBlock
+	}
+	return newBuffer(size, policy)
+}
+
+// newBuffer creates a buffer of size messages (0 = DefaultBufferSize)
+func newBuffer(size int, policy OverflowPolicy) *buffer {
+	if size <= 0 {
+		size = DefaultBufferSize
+	}
+	if policy == "" {
+		policy = OverflowBlock
+	}
+	return &buffer{ch: make(chan *types.Message, size), policy: policy}
+}
+
+// put adds a message to the buffer according to its overflow policy. wait
+// bounds how long OverflowBlock waits for room (0 = until ctx is done).
+func (b *buffer) put(ctx context.Context, msg *types.Message, wait time.Duration, direction string) error {
+	select {
+	case b.ch <- msg:
+		return nil
+	default:
+	}
+	b.full.Add(1)
+
+	switch b.policy {
+	case OverflowDropOldest:
+		// Discard only while the buffer is still full, so each message evicts
+		// at most the one message it needs room for
+		for {
+			select {
+			case b.ch <- msg:
+				return nil
+			default:
+			}
+			select {
+			case oldest := <-b.ch:
+				b.dropped.Add(1)
+				logging.Warn("buffer full, dropped oldest message", "buffer", direction, "type", oldest.Type, "task_id", oldest.TaskID)
+			default:
+			}
+		}
+	case OverflowError:
+		b.dropped.Add(1)
+		return errs.Retryable(ErrBufferFull)
+	}
+
+	var timeout <-chan time.Time
+	if wait > 0 {
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	select {
+	case b.ch <- msg:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("client is shutting down")
+	case <-timeout:
+		b.dropped.Add(1)
+		return errs.Retryable(fmt.Errorf("send timeout: %w", ErrBufferFull))
+	}
+}
+
+// BufferStats returns the fill level and overflow counts of the send and
+// receive buffers
+func (c *NetworkClient) BufferStats() BufferStats {
+	return BufferStats{
+		SendBuffered:    len(c.sendBuf.ch),
+		SendCapacity:    cap(c.sendBuf.ch),
+		SendFull:        c.sendBuf.full.Load(),
+		SendDropped:     c.sendBuf.dropped.Load(),
+		ReceiveBuffered: len(c.receiveBuf.ch),
+		ReceiveCapacity: cap(c.receiveBuf.ch),
+		ReceiveFull:     c.receiveBuf.full.Load(),
+		ReceiveDropped:  c.receiveBuf.dropped.Load(),
+	}
+}
@@ -0,0 +1,58 @@
+package network
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/types"
+)
+
+func TestReceiveBufferDropsOldest(t *testing.T) {
+	buf := newBuffer(2, OverflowDropOldest)
+	for _, id := range []string{"1", "2", "3"} {
+		if err := buf.put(context.Background(), &types.Message{ID: id}, 0, "receive"); err != nil {
+			t.Fatalf("put %s: %v", id, err)
+		}
+	}
+	if first := (<-buf.ch).ID; first != "2" {
+		t.Errorf("oldest buffered message = %s, want 2", first)
+	}
+	if dropped := buf.dropped.Load(); dropped != 1 {
+		t.Errorf("dropped = %d, want 1", dropped)
+	}
+}
+
+func TestSendBufferNeverDropsOldest(t *testing.T) {
+	client := NewNetworkClient(&Config{WebSocketURL: "ws://localhost", SendBufferSize: 1, SendOverflow: OverflowDropOldest, ReceiveOverflow: OverflowDropOldest})
+	if client.sendBuf.policy != OverflowBlock {
+		t.Fatalf("send buffer policy = %s, want %s", client.sendBuf.policy, OverflowBlock)
+	}
+	if client.receiveBuf.policy != OverflowDropOldest {
+		t.Fatalf("receive buffer policy = %s, want %s", client.receiveBuf.policy, OverflowDropOldest)
+	}
+
+	// A full send buffer keeps the buffered final response and fails the new send
+	final := &types.Message{Type: "task_response", TaskID: "task-1", Content: "result"}
+	if err := client.sendBuf.put(context.Background(), final, 0, "send"); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := client.sendBuf.put(ctx, &types.Message{Type: "task_response", TaskID: "task-2"}, 0, "send"); err == nil {
+		t.Fatal("send into a full buffer succeeded")
+	}
+	if buffered := <-client.sendBuf.ch; buffered != final {
+		t.Errorf("buffered message = %+v, want the final response", buffered)
+	}
+}
+
+func TestSendBufferOverflowError(t *testing.T) {
+	buf := newSendBuffer(1, OverflowError)
+	if err := buf.put(context.Background(), &types.Message{ID: "1"}, 0, "send"); err != nil {
+		t.Fatal(err)
+	}
+	if err := buf.put(context.Background(), &types.Message{ID: "2"}, 0, "send"); !errors.Is(err, ErrBufferFull) {
+		t.Fatalf("error = %v, want ErrBufferFull", err)
+	}
+}
@@ -0,0 +1,95 @@
+package network
+
+import (
+	"slices"
+	"sort"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/events"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/logging"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/types"
+)
+
+// Capabilities returns the capabilities the agent advertises
+func (t *TaskCoordinator) Capabilities() []string {
+	t.capabilitiesMu.RLock()
+	defer t.capabilitiesMu.RUnlock()
+	return t.capabilities
+}
+
+// UpdateCapabilities replaces the agent's capabilities. New tasks are matched
+// against them right away; running tasks continue. When capabilities were
+// added or removed, a CapabilitiesChanged event lists the running tasks that
+// no remaining capability can serve. The change is announced to the server
+// with ProtocolHandler.SendCapabilities.
+func (t *TaskCoordinator) UpdateCapabilities(capabilities []string) {
+	capabilities = slices.Clone(capabilities)
+
+	t.capabilitiesMu.Lock()
+	previous := t.capabilities
+	t.capabilities = capabilities
+	t.capabilitiesMu.Unlock()
+	t.protocolHandler.UpdateCapabilities(capabilities)
+
+	added, removed := diffCapabilities(previous, capabilities)
+	if len(added) == 0 && len(removed) == 0 {
+		return
+	}
+	orphaned := t.orphanedTasks(capabilities)
+	if len(orphaned) > 0 {
+		logging.Warn("running tasks require removed capabilities", "removed", removed, "tasks", orphaned)
+	}
+	if bus := t.getEventBus(); bus != nil {
+		bus.Publish(events.CapabilitiesChanged{Added: added, Removed: removed, Orphaned: orphaned})
+	}
+}
+
+// TasksRequiring returns the IDs of running tasks that require a capability
+// matched by one of capabilities
+func (t *TaskCoordinator) TasksRequiring(capabilities []string) []string {
+	var ids []string
+	t.activeTasksMu.RLock()
+	for id, execution := range t.activeTasks {
+		for _, required := range execution.Capabilities {
+			if types.AnyCapabilityMatches(capabilities, required) {
+				ids = append(ids, id)
+				break
+			}
+		}
+	}
+	t.activeTasksMu.RUnlock()
+	sort.Strings(ids)
+	return ids
+}
+
+// orphanedTasks returns the IDs of running tasks with a required capability
+// that none of capabilities matches
+func (t *TaskCoordinator) orphanedTasks(capabilities []string) []string {
+	var ids []string
+	t.activeTasksMu.RLock()
+	for id, execution := range t.activeTasks {
+		for _, required := range execution.Capabilities {
+			if !types.AnyCapabilityMatches(capabilities, required) {
+				ids = append(ids, id)
+				break
+			}
+		}
+	}
+	t.activeTasksMu.RUnlock()
+	sort.Strings(ids)
+	return ids
+}
+
+// diffCapabilities returns the capabilities only in updated and only in previous
+func diffCapabilities(previous, updated []string) (added, removed []string) {
+	for _, capability := range updated {
+		if !slices.Contains(previous, capability) {
+			added = append(added, capability)
+		}
+	}
+	for _, capability := range previous {
+		if !slices.Contains(updated, capability) {
+			removed = append(removed, capability)
+		}
+	}
+	return added, removed
+}
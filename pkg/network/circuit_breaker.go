@@ -1,12 +1,18 @@
 package network
 
 import (
-	"fmt"
+	"errors"
 	"sync"
 	"sync/atomic"
 	"time"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/errs"
 )
 
+// ErrCircuitOpen is returned when a call is blocked by an open circuit.
+// It is retryable: the circuit half-opens after the reset timeout.
+var ErrCircuitOpen = errs.Retryable(errors.New("circuit breaker is open"))
+
 // CircuitState represents the state of the circuit breaker
 type CircuitState int32
 
@@ -33,6 +39,41 @@ func (s CircuitState) String() string {
 	}
 }
 
+// CircuitBreakerConfig configures a circuit breaker. Zero fields take the
+// defaults of DefaultCircuitBreakerConfig.
+type CircuitBreakerConfig struct {
+	MaxFailures  int           // Consecutive failures that open the circuit (default 3)
+	ResetTimeout time.Duration // How long the circuit stays open before it half-opens (default 30s)
+
+	// HalfOpenRequests is the number of probe requests let through while the
+	// circuit is half-open; it closes once they all succeed and opens again
+	// on the first failure (default 1)
+	HalfOpenRequests int
+}
+
+// DefaultCircuitBreakerConfig returns the default circuit breaker configuration
+func DefaultCircuitBreakerConfig() CircuitBreakerConfig {
+	return CircuitBreakerConfig{
+		MaxFailures:      3,
+		ResetTimeout:     30 * time.Second,
+		HalfOpenRequests: 1,
+	}
+}
+
+// withDefaults returns the config with zero fields taken from defaults
+func (c CircuitBreakerConfig) withDefaults(defaults CircuitBreakerConfig) CircuitBreakerConfig {
+	if c.MaxFailures <= 0 {
+		c.MaxFailures = defaults.MaxFailures
+	}
+	if c.ResetTimeout <= 0 {
+		c.ResetTimeout = defaults.ResetTimeout
+	}
+	if c.HalfOpenRequests <= 0 {
+		c.HalfOpenRequests = defaults.HalfOpenRequests
+	}
+	return c
+}
+
 // CircuitBreaker implements the circuit breaker pattern for connection failures
 type CircuitBreaker struct {
 	maxFailures      int
@@ -51,11 +92,26 @@ type CircuitBreaker struct {
 
 // NewCircuitBreaker creates a new circuit breaker
 func NewCircuitBreaker(maxFailures int, resetTimeout time.Duration) *CircuitBreaker {
+	return NewCircuitBreakerWithConfig(CircuitBreakerConfig{MaxFailures: maxFailures, ResetTimeout: resetTimeout})
+}
+
+// NewCircuitBreakerWithConfig creates a new circuit breaker from a configuration
+func NewCircuitBreakerWithConfig(config CircuitBreakerConfig) *CircuitBreaker {
+	config = config.withDefaults(DefaultCircuitBreakerConfig())
 	return &CircuitBreaker{
-		maxFailures:      maxFailures,
-		resetTimeout:     resetTimeout,
-		halfOpenRequests: 1, // Allow one request in half-open state
-		state:           int32(CircuitClosed),
+		maxFailures:      config.MaxFailures,
+		resetTimeout:     config.ResetTimeout,
+		halfOpenRequests: config.HalfOpenRequests,
+		state:            int32(CircuitClosed),
+	}
+}
+
+// Config returns the configuration of the circuit breaker
+func (cb *CircuitBreaker) Config() CircuitBreakerConfig {
+	return CircuitBreakerConfig{
+		MaxFailures:      cb.maxFailures,
+		ResetTimeout:     cb.resetTimeout,
+		HalfOpenRequests: cb.halfOpenRequests,
 	}
 }
 
@@ -69,7 +125,7 @@ func (cb *CircuitBreaker) SetStateChangeHandler(handler func(from, to CircuitSta
 // Call executes the given function if the circuit allows it
 func (cb *CircuitBreaker) Call(fn func() error) error {
 	if !cb.CanAttempt() {
-		return fmt.Errorf("circuit breaker is open")
+		return ErrCircuitOpen
 	}
 	
 	err := fn()
@@ -90,32 +146,42 @@ func (cb *CircuitBreaker) CanAttempt() bool {
 		shouldReset := time.Since(cb.lastFailTime) > cb.resetTimeout
 		cb.mu.RUnlock()
 		
-		if shouldReset {
-			cb.transitionTo(CircuitHalfOpen)
-			return true
+		if !shouldReset {
+			return false
 		}
-		return false
+		cb.transitionTo(CircuitHalfOpen)
+		return cb.takeProbe()
 		
 	case CircuitHalfOpen:
-		cb.mu.Lock()
-		defer cb.mu.Unlock()
-		
-		if cb.halfOpenAttempts < cb.halfOpenRequests {
-			cb.halfOpenAttempts++
-			return true
-		}
-		return false
+		return cb.takeProbe()
 		
 	default:
 		return false
 	}
 }
 
-// RecordResult records the result of an attempt
+// takeProbe lets a request through while half-open, up to halfOpenRequests
+func (cb *CircuitBreaker) takeProbe() bool {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	
+	if CircuitState(atomic.LoadInt32(&cb.state)) != CircuitHalfOpen {
+		// Another request closed or reopened the circuit meanwhile
+		return CircuitState(atomic.LoadInt32(&cb.state)) == CircuitClosed
+	}
+	if cb.halfOpenAttempts < cb.halfOpenRequests {
+		cb.halfOpenAttempts++
+		return true
+	}
+	return false
+}
+
+// RecordResult records the result of an attempt.
+// Only errors classified as failures count; user and rate limit errors don't trip the circuit.
 func (cb *CircuitBreaker) RecordResult(err error) {
 	state := CircuitState(atomic.LoadInt32(&cb.state))
 	
-	if err != nil {
+	if errs.IsFailure(err) {
 		cb.recordFailure(state)
 	} else {
 		cb.recordSuccess(state)
@@ -2,38 +2,73 @@ package network
 
 import (
 	"context"
+	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
 	"sync"
 	"sync/atomic"
 	"time"
 
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/bandwidth"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/dial"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/errs"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/events"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/logging"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/recording"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/retrystore"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/tracing"
 	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/types"
 	"github.com/gorilla/websocket"
 )
 
+// maxMessageBytes bounds a message read from the server; larger ones close
+// the connection
+const maxMessageBytes = 48 << 20
+
 // NetworkClient handles WebSocket communication for Teneo agents
 type NetworkClient struct {
 	conn            *websocket.Conn
 	url             string
 	messageHandlers map[string]MessageHandler
+	verifiers       map[string]func(*types.Message) error // Check received messages by type as they were sent, see VerifyReceived
 	reconnector     *ReconnectionManager
 	authenticated   bool
 	running         bool
 	reconnecting    int32 // atomic flag for reconnection state
+	enableDeflate   bool
+	compressAbove   int
+	compressContent atomic.Bool // Set once the server accepts compressed content
+	chunkSize       int
+	chunks          *types.ChunkAssembler // Reassembles incoming split messages
+	congestedAt     int                   // Queue depth at which the connection counts as congested
+	inbound         []Middleware
+	outbound        []Middleware
+	data            *dataChannel // Optional connection for task output, nil if not configured
+	netDialer       *dial.Dialer // Dials every address of the backend and remembers the one that answered
+	tlsConfig       *tls.Config  // TLS of wss:// connections, nil = Go's defaults
+	bandwidth       *bandwidth.Meter
+	recorder        atomic.Pointer[recording.Recorder] // Records the messages on the wire, nil if not recording
+	recordFile      *recording.Recorder                // Opened for Config.RecordFile, closed on Disconnect
+	reconnectedMu   sync.Mutex
+	onReconnected   []func()           // Run after the connection is re-established
+	reconnectHooks  []reconnectHook    // Run in order after the connection is re-established
+	cancelHooks     context.CancelFunc // Stops the running reconnect hooks, nil if none
+	deadLetterMu    sync.Mutex
+	onDeadLetter    []func(DeadLetter) // Run when a message is moved to the dead-letter queue
+	eventBus        *events.Bus
 	mu              sync.RWMutex
 	ctx             context.Context
 	cancel          context.CancelFunc
-	sendChan        chan *types.Message
-	receiveChan     chan *types.Message
+	sendBuf         *buffer        // Outgoing messages waiting to be written
+	receiveBuf      *buffer        // Incoming messages waiting to be handled
 	wg              sync.WaitGroup // For goroutine lifecycle management
 
 	// Resilience components
-	circuitBreaker *CircuitBreaker
-	retryQueue     *MessageRetryQueue
-	healthMonitor  *HealthMonitor
-	supervisor     *GoroutineSupervisor
+	circuitBreakers map[string]*CircuitBreaker // By message class, see BreakerClass
+	retryQueue      *MessageRetryQueue
+	healthMonitor   *HealthMonitor
+	supervisor      *GoroutineSupervisor
 }
 
 // MessageHandler defines the function signature for message handlers
@@ -48,6 +83,56 @@ type Config struct {
 	MessageTimeout   time.Duration
 	PingInterval     time.Duration
 	HandshakeTimeout time.Duration
+	EnableDeflate    bool // Negotiate permessage-deflate on the WebSocket connection
+	CompressAbove    int  // Compress task response content of at least this many bytes (0 = never)
+	ChunkSize        int  // Split task response content larger than this many bytes into parts (0 = never)
+
+	// TLS configures wss:// connections, including the data channel: trusted
+	// CAs, a client certificate for mutual TLS, the minimum version and the
+	// SNI server name (nil = Go's defaults)
+	TLS *tls.Config
+
+	// Sizes of the buffers of outgoing and incoming messages (0 = DefaultBufferSize)
+	// and what happens to a message that finds its buffer full (empty = OverflowBlock,
+	// OverflowDropOldest applies to the receive buffer only)
+	SendBufferSize    int
+	ReceiveBufferSize int
+	SendOverflow      OverflowPolicy
+	ReceiveOverflow   OverflowPolicy
+
+	// CongestionThreshold is the number of queued outgoing messages at which the
+	// connection counts as congested (0 = half the send buffer)
+	CongestionThreshold int
+
+	// Reconnection backoff: exponential with full jitter from ReconnectDelay up to
+	// ReconnectMaxDelay, unless ReconnectBackoff is set. Reconnecting stops after
+	// MaxReconnects attempts or ReconnectMaxElapsed (0 = no time limit).
+	ReconnectMaxDelay   time.Duration
+	ReconnectMaxElapsed time.Duration
+	ReconnectBackoff    BackoffStrategy
+
+	// DataChannelURL is the WebSocket URL of an optional second connection that carries
+	// task output (empty = task output shares the primary connection)
+	DataChannelURL string
+
+	// RoomBandwidth caps the bytes exchanged per room; tasks from a room over
+	// its ceiling are rejected until it refills (zero = unlimited)
+	RoomBandwidth bandwidth.Ceiling
+
+	// RecordFile is a JSONL file every message sent and received is appended
+	// to, for replaying the session later (empty = no recording)
+	RecordFile string
+
+	// CircuitBreaker configures the circuit breakers messages are sent
+	// through, one per message class (zero fields = defaults).
+	// CircuitBreakers overrides it for a class, e.g. BreakerAuth.
+	CircuitBreaker  CircuitBreakerConfig
+	CircuitBreakers map[string]CircuitBreakerConfig
+
+	// Ordering selects the messages delivered in the order they were sent:
+	// while one waits for a retry, later ones with the same task (or room)
+	// are held behind it (empty = OrderByTask)
+	Ordering Ordering
 }
 
 // DefaultNetworkConfig returns default network configuration
@@ -60,6 +145,8 @@ func DefaultNetworkConfig() *Config {
 		MessageTimeout:   30 * time.Second,
 		PingInterval:     30 * time.Second,
 		HandshakeTimeout: 10 * time.Second,
+
+		ReconnectMaxDelay: 60 * time.Second,
 	}
 }
 
@@ -70,33 +157,71 @@ func NewNetworkClient(config *Config) *NetworkClient {
 	client := &NetworkClient{
 		url:             config.WebSocketURL,
 		messageHandlers: make(map[string]MessageHandler),
+		verifiers:       make(map[string]func(*types.Message) error),
 		authenticated:   false,
 		running:         false,
 		ctx:             ctx,
 		cancel:          cancel,
-		sendChan:        make(chan *types.Message, 100),
-		receiveChan:     make(chan *types.Message, 100),
+		sendBuf:         newSendBuffer(config.SendBufferSize, config.SendOverflow),
+		receiveBuf:      newBuffer(config.ReceiveBufferSize, config.ReceiveOverflow),
+		enableDeflate:   config.EnableDeflate,
+		compressAbove:   config.CompressAbove,
+		chunkSize:       config.ChunkSize,
+		chunks:          types.NewChunkAssembler(0),
+		congestedAt:     config.CongestionThreshold,
+		netDialer:       dial.New(nil),
+		tlsConfig:       config.TLS,
+		bandwidth:       bandwidth.NewMeter(bandwidth.Config{RoomCeiling: config.RoomBandwidth}),
+	}
+	if config.DataChannelURL != "" {
+		client.data = &dataChannel{
+			url:      config.DataChannelURL,
+			sendChan: make(chan *types.Message, cap(client.sendBuf.ch)),
+		}
+	}
+	if client.congestedAt <= 0 {
+		client.congestedAt = cap(client.sendBuf.ch) / 2
+	}
+	if config.RecordFile != "" {
+		recorder, err := recording.Open(config.RecordFile)
+		if err != nil {
+			logging.Warn("not recording messages", "error", err)
+		} else {
+			logging.Info("recording messages", "file", config.RecordFile)
+			client.recorder.Store(recorder)
+			client.recordFile = recorder
+		}
 	}
 
+	backoff := config.ReconnectBackoff
+	if backoff == nil {
+		exponential := DefaultExponentialBackoff()
+		if config.ReconnectDelay > 0 {
+			exponential.InitialDelay = config.ReconnectDelay
+		}
+		if config.ReconnectMaxDelay > 0 {
+			exponential.MaxDelay = config.ReconnectMaxDelay
+		}
+		backoff = exponential
+	}
 	client.reconnector = &ReconnectionManager{
 		enabled:     config.ReconnectEnabled,
 		maxAttempts: config.MaxReconnects,
-		delay:       config.ReconnectDelay,
-		backoffFunc: exponentialBackoff,
+		maxElapsed:  config.ReconnectMaxElapsed,
+		backoff:     backoff,
 	}
 
 	// Initialize resilience components
-	client.circuitBreaker = NewCircuitBreaker(3, 30*time.Second)
-	client.circuitBreaker.SetStateChangeHandler(func(from, to CircuitState) {
-		log.Printf("🔌 Circuit breaker state changed: %s → %s", from, to)
-	})
+	client.newCircuitBreakers(config)
 
 	client.retryQueue = NewMessageRetryQueue(DefaultRetryPolicy(), client.sendMessageDirect)
+	client.retryQueue.SetDeadLetterHandler(client.deadLettered)
+	client.retryQueue.SetOrdering(config.Ordering)
 
 	client.healthMonitor = NewHealthMonitor(10 * time.Second)
 	client.healthMonitor.SetHealthCheckFunc(client.healthCheck)
 	client.healthMonitor.SetStatusChangeHandler(func(old, new HealthStatus) {
-		log.Printf("🏥 Health status changed: %s → %s", old, new)
+		logging.Info("health status changed", "from", old, "to", new)
 	})
 
 	client.supervisor = NewGoroutineSupervisor(ctx)
@@ -113,10 +238,7 @@ func (c *NetworkClient) Connect() error {
 		return fmt.Errorf("client is already running")
 	}
 
-	dialer := websocket.DefaultDialer
-	dialer.HandshakeTimeout = 10 * time.Second
-
-	conn, _, err := dialer.Dial(c.url, nil)
+	conn, _, err := c.dialer().Dial(c.url, nil)
 	if err != nil {
 		return fmt.Errorf("failed to connect to WebSocket: %w", err)
 	}
@@ -124,17 +246,10 @@ func (c *NetworkClient) Connect() error {
 	c.conn = conn
 	c.running = true
 	c.authenticated = false
+	c.compressContent.Store(false)
 
-	// Set up pong handler to respond to server pings
-	c.conn.SetPongHandler(func(appData string) error {
-		log.Printf("🏓 Pong received from server")
-		// Reset read deadline when we receive a pong
-		c.conn.SetReadDeadline(time.Now().Add(60 * time.Second))
-		return nil
-	})
-
-	// Set initial read deadline
-	c.conn.SetReadDeadline(time.Now().Add(60 * time.Second))
+	conn.SetReadLimit(maxMessageBytes)
+	c.watchPongs(conn)
 
 	// Register and start supervised goroutines
 	c.registerGoroutines()
@@ -147,7 +262,8 @@ func (c *NetworkClient) Connect() error {
 	c.healthMonitor.Start()
 	c.healthMonitor.RecordConnectionEstablished()
 
-	log.Printf("🔗 Connected to WebSocket server: %s", c.url)
+	logging.Info("connected to WebSocket server", "url", c.url)
+	c.eventBus.Publish(events.Connected{URL: c.url})
 	return nil
 }
 
@@ -165,21 +281,31 @@ func (c *NetworkClient) Disconnect() error {
 	c.conn = nil
 	c.mu.Unlock()
 
-	// Stop resilience components
-	c.supervisor.Stop()
-	c.retryQueue.Stop()
-	c.healthMonitor.Stop()
-	c.healthMonitor.RecordConnectionLost()
+	c.closeDataChannel()
+	c.cancelReconnectHooks()
 
-	// Send close message
+	// Stop writing before sending the close message, the connection allows one writer
+	c.supervisor.StopGoroutine("write-messages")
+
+	// Send close message, closing the connection also ends a pending read
 	if oldConn != nil {
 		oldConn.WriteMessage(websocket.CloseMessage,
 			websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
 		oldConn.Close()
 	}
 
+	// Stop resilience components
+	c.supervisor.Stop()
+	c.retryQueue.Stop()
+	c.healthMonitor.Stop()
+	c.healthMonitor.RecordConnectionLost()
+
 	// Cancel context and wait for goroutines
 	c.cancel()
+	if c.recordFile != nil {
+		c.recorder.CompareAndSwap(c.recordFile, nil)
+		c.recordFile.Close()
+	}
 
 	done := make(chan struct{})
 	go func() {
@@ -189,19 +315,34 @@ func (c *NetworkClient) Disconnect() error {
 
 	select {
 	case <-done:
-		log.Println("✅ All goroutines stopped gracefully")
+		logging.Info("all goroutines stopped gracefully")
 	case <-time.After(5 * time.Second):
-		log.Println("⚠️ Timeout waiting for goroutines to stop")
+		logging.Warn("timeout waiting for goroutines to stop")
 	}
 
-	log.Println("🔌 Disconnected from WebSocket server")
+	logging.Info("disconnected from WebSocket server")
+	c.eventBus.Publish(events.Disconnected{})
 	return nil
 }
 
-// SendMessage sends a message through the WebSocket connection with retry support
+// SendMessage sends a message through the WebSocket connection with retry support.
+// The message passes through the outbound middleware first.
 func (c *NetworkClient) SendMessage(msg *types.Message) error {
-	// Use circuit breaker
-	return c.circuitBreaker.Call(func() error {
+	c.mu.RLock()
+	send := chainMiddleware(c.sendMessage, c.outbound)
+	c.mu.RUnlock()
+	return send(msg)
+}
+
+// sendMessage sends a message through the circuit breaker, queueing it for retry on failure
+func (c *NetworkClient) sendMessage(msg *types.Message) error {
+	// Don't overtake earlier messages of the task waiting for a retry
+	if c.retryQueue.Hold(msg) {
+		return nil
+	}
+
+	// Use the circuit breaker of the message's class
+	return c.circuitBreaker(msg.Type).Call(func() error {
 		err := c.sendMessageDirect(msg)
 		if err != nil {
 			// Queue for retry if failed
@@ -212,28 +353,67 @@ func (c *NetworkClient) SendMessage(msg *types.Message) error {
 	})
 }
 
+// SendMessageContext sends a message like SendMessage, recording a span and
+// propagating the trace context of ctx in the message envelope
+func (c *NetworkClient) SendMessageContext(ctx context.Context, msg *types.Message) error {
+	ctx, span := tracing.Start(ctx, tracing.SpanMessageSend,
+		tracing.AttrMessageType.String(msg.Type),
+		tracing.AttrTaskID.String(msg.TaskID),
+		tracing.AttrRoom.String(msg.Room),
+	)
+	tracing.Inject(ctx, msg)
+
+	err := c.SendMessage(msg)
+	tracing.End(span, err)
+	return err
+}
+
 // sendMessageDirect sends a message directly without retry logic
 func (c *NetworkClient) sendMessageDirect(msg *types.Message) error {
 	c.mu.RLock()
 	if !c.running {
 		c.mu.RUnlock()
-		return fmt.Errorf("client is not running")
+		return errs.Retryable(fmt.Errorf("client is not running"))
 	}
 	c.mu.RUnlock()
 
-	select {
-	case c.sendChan <- msg:
+	if c.sendOnDataChannel(msg) {
 		c.healthMonitor.RecordMessageSent()
 		return nil
-	case <-c.ctx.Done():
-		return fmt.Errorf("client is shutting down")
-	case <-time.After(5 * time.Second):
-		return fmt.Errorf("send timeout")
 	}
+
+	if err := c.sendBuf.put(c.ctx, msg, sendBufferTimeout, "send"); err != nil {
+		return err
+	}
+	c.healthMonitor.RecordMessageSent()
+	return nil
+}
+
+// QueueDepth returns the number of outgoing messages waiting to be written,
+// including messages waiting in the retry queue
+func (c *NetworkClient) QueueDepth() int {
+	depth := len(c.sendBuf.ch) + c.retryQueue.GetQueueSize()
+	if c.data != nil {
+		depth += len(c.data.sendChan)
+	}
+	return depth
+}
+
+// IsCongested returns whether the outgoing queue has reached the congestion threshold
+func (c *NetworkClient) IsCongested() bool {
+	return c.QueueDepth() >= c.congestedAt
 }
 
-// SendRawData sends raw JSON data directly via WebSocket (for compatibility with server expectations)
+// SendRawData sends raw JSON data directly via WebSocket (for compatibility with server expectations).
+// It goes through the circuit breaker of the message's "type".
 func (c *NetworkClient) SendRawData(data []byte) error {
+	return c.circuitBreaker(rawMessageType(data)).Call(func() error {
+		return c.sendRawData(data)
+	})
+}
+
+// sendRawData writes raw data to the connection
+func (c *NetworkClient) sendRawData(data []byte) error {
 	c.mu.RLock()
 	if !c.running || c.conn == nil {
 		c.mu.RUnlock()
@@ -242,7 +422,11 @@ func (c *NetworkClient) SendRawData(data []byte) error {
 	conn := c.conn
 	c.mu.RUnlock()
 
-	return conn.WriteMessage(1, data) // 1 = TextMessage
+	if err := conn.WriteMessage(1, data); err != nil { // 1 = TextMessage
+		return err
+	}
+	c.recordWire(bandwidth.Sent, data)
+	return nil
 }
 
 // RegisterHandler registers a message handler for a specific message type
@@ -252,6 +436,33 @@ func (c *NetworkClient) RegisterHandler(msgType string, handler MessageHandler)
 	c.messageHandlers[msgType] = handler
 }
 
+// VerifyReceived checks every received message of msgType with verify in the
+// form it was signed in: each part of a split message on its own, before
+// the parts are joined and compressed content is decoded. Messages failing
+// the check are dropped; the others reach their handler marked Verified.
+func (c *NetworkClient) VerifyReceived(msgType string, verify func(*types.Message) error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.verifiers[msgType] = verify
+}
+
+// verifyReceived runs the check of a received message's type, if any,
+// and reports whether the message passed it
+func (c *NetworkClient) verifyReceived(msg *types.Message) bool {
+	c.mu.RLock()
+	verify := c.verifiers[msg.Type]
+	c.mu.RUnlock()
+	if verify == nil {
+		return true
+	}
+	if err := verify(msg); err != nil {
+		logging.Warn("dropped message that failed verification", "type", msg.Type, "from", msg.From, "task_id", msg.TaskID, "error", err)
+		return false
+	}
+	msg.Verified = true
+	return true
+}
+
 // IsConnected returns whether the client is connected
 func (c *NetworkClient) IsConnected() bool {
 	c.mu.RLock()
@@ -273,254 +484,396 @@ func (c *NetworkClient) SetAuthenticated(authenticated bool) {
 	c.authenticated = authenticated
 }
 
-// readMessages reads messages from WebSocket connection
-func (c *NetworkClient) readMessages() {
-	defer func() {
-		if r := recover(); r != nil {
-			log.Printf("❌ Panic in readMessages: %v", r)
-		}
-	}()
+// dialer returns the WebSocket dialer for this client
+func (c *NetworkClient) dialer() *websocket.Dialer {
+	dialer := *websocket.DefaultDialer
+	dialer.HandshakeTimeout = 10 * time.Second
+	dialer.EnableCompression = c.enableDeflate
+	dialer.NetDialContext = c.netDialer.DialContext
+	if c.tlsConfig != nil {
+		dialer.TLSClientConfig = c.tlsConfig.Clone()
+	}
+	return &dialer
+}
 
-	for {
-		select {
-		case <-c.ctx.Done():
-			return
-		default:
-			if c.conn == nil {
-				time.Sleep(100 * time.Millisecond)
-				continue
-			}
+// SetContentCompression records whether the server accepts compressed message content
+func (c *NetworkClient) SetContentCompression(enabled bool) {
+	c.compressContent.Store(enabled && c.compressAbove > 0)
+}
+
+// ContentCompressionThreshold returns the content size at which messages are compressed,
+// or 0 when content compression is disabled or not accepted by the server
+func (c *NetworkClient) ContentCompressionThreshold() int {
+	if !c.compressContent.Load() {
+		return 0
+	}
+	return c.compressAbove
+}
 
-			// Set read deadline before reading
-			c.conn.SetReadDeadline(time.Now().Add(60 * time.Second))
+// ResponseChunkSize returns the content size above which task responses are
+// split into parts, or 0 when they are never split
+func (c *NetworkClient) ResponseChunkSize() int {
+	return c.chunkSize
+}
 
-			_, messageData, err := c.conn.ReadMessage()
-			if err != nil {
-				log.Printf("❌ Read error: %v", err)
-				if c.reconnector.enabled && atomic.CompareAndSwapInt32(&c.reconnecting, 0, 1) {
-					go c.attemptReconnection()
-				}
-				return
-			}
+// Bandwidth returns the meter accounting for the bytes exchanged per room and counterparty
+func (c *NetworkClient) Bandwidth() *bandwidth.Meter {
+	return c.bandwidth
+}
 
-			var msg types.Message
-			if err := json.Unmarshal(messageData, &msg); err != nil {
-				log.Printf("❌ Failed to unmarshal message: %v", err)
-				continue
+// recordTraffic accounts for a message written to or read from the wire as
+// data, and adds it to the recording if one is made
+func (c *NetworkClient) recordTraffic(direction bandwidth.Direction, msg *types.Message, data []byte) {
+	peer := msg.From
+	if direction == bandwidth.Sent {
+		peer = msg.To
+	}
+	c.bandwidth.Record(direction, msg.Room, peer, msg.TaskID, len(data))
+	c.recordWire(direction, data)
+}
+
+// recordWire adds data written to or read from the wire to the recording
+func (c *NetworkClient) recordWire(direction bandwidth.Direction, data []byte) {
+	recorder := c.recorder.Load()
+	if recorder == nil {
+		return
+	}
+	dir := recording.Inbound
+	if direction == bandwidth.Sent {
+		dir = recording.Outbound
+	}
+	if err := recorder.Record(dir, data); err != nil {
+		logging.Warn("failed to record message, recording stopped", "error", err)
+		c.recorder.CompareAndSwap(recorder, nil)
+	}
+}
+
+// SetRecorder records every message sent and received from now on with
+// recorder, replacing the recorder of Config.RecordFile (nil = stop recording).
+// The caller closes a recorder it set.
+func (c *NetworkClient) SetRecorder(recorder *recording.Recorder) {
+	c.recorder.Store(recorder)
+}
+
+// reassemble returns a received message ready for its handler: it is
+// verified as it came off the wire, split messages are held until all parts
+// arrived, then joined and decoded. It returns nil while parts are missing
+// or the message is unusable.
+func (c *NetworkClient) reassemble(msg *types.Message) *types.Message {
+	if !c.verifyReceived(msg) {
+		return nil
+	}
+	msg, err := c.chunks.Add(msg)
+	if err != nil {
+		logging.Error("failed to reassemble message", "error", err)
+		return nil
+	}
+	if msg == nil {
+		return nil
+	}
+	if err := msg.DecodeContent(); err != nil {
+		logging.Error("failed to decode message content", "type", msg.Type, "error", err)
+		return nil
+	}
+	return msg
+}
+
+// readMessages reads messages from conn until ctx is canceled or the
+// connection fails
+func (c *NetworkClient) readMessages(ctx context.Context, conn *websocket.Conn) error {
+	for {
+		// Set read deadline before reading
+		conn.SetReadDeadline(time.Now().Add(60 * time.Second))
+
+		_, messageData, err := conn.ReadMessage()
+		if err != nil {
+			if c.closedOnPurpose(ctx, conn) {
+				return nil
+			}
+			logging.Error("read error", "error", err)
+			if retryAfter, ok := retryAfterFromClose(err); ok {
+				logging.Info("server requested reconnect delay", "retry_after", retryAfter)
+				c.reconnector.SetRetryAfter(retryAfter)
 			}
+			c.connectionLost(err)
+			return nil
+		}
 
-			// Record successful message receipt
-			c.healthMonitor.RecordMessageReceived()
+		var received types.Message
+		if err := json.Unmarshal(messageData, &received); err != nil {
+			logging.Error("failed to unmarshal message", "error", err)
+			continue
+		}
 
-			select {
-			case c.receiveChan <- &msg:
-			case <-c.ctx.Done():
-				return
+		// Record successful message receipt
+		c.healthMonitor.RecordMessageReceived()
+		c.recordTraffic(bandwidth.Received, &received, messageData)
+
+		msg := c.reassemble(&received)
+		if msg == nil {
+			continue
+		}
+
+		if err := c.receiveBuf.put(ctx, msg, 0, "receive"); err != nil {
+			if ctx.Err() != nil {
+				return nil
 			}
+			logging.Warn("receive buffer full, dropped message", "type", msg.Type, "task_id", msg.TaskID)
 		}
 	}
 }
 
-// writeMessages writes messages to WebSocket connection
-func (c *NetworkClient) writeMessages() {
-	defer func() {
-		if r := recover(); r != nil {
-			log.Printf("❌ Panic in writeMessages: %v", r)
-		}
-	}()
-
+// writeMessages writes queued messages to conn until ctx is canceled or the
+// connection fails
+func (c *NetworkClient) writeMessages(ctx context.Context, conn *websocket.Conn) error {
 	for {
 		select {
-		case <-c.ctx.Done():
-			return
-		case msg := <-c.sendChan:
-			if c.conn == nil {
-				continue
-			}
-
+		case <-ctx.Done():
+			return nil
+		case msg := <-c.sendBuf.ch:
 			data, err := json.Marshal(msg)
 			if err != nil {
-				log.Printf("❌ Failed to marshal message: %v", err)
+				logging.Error("failed to marshal message", "error", err)
 				continue
 			}
 
 			// Add debug logging to see what we're actually sending over WebSocket
-			log.Printf("🐛 DEBUG: Sending WebSocket message: %s", string(data))
+			logging.Debug("sending WebSocket message", "data", string(data))
 
-			if err := c.conn.WriteMessage(websocket.TextMessage, data); err != nil {
-				log.Printf("❌ Write error: %v", err)
-				if c.reconnector.enabled && atomic.CompareAndSwapInt32(&c.reconnecting, 0, 1) {
-					go c.attemptReconnection()
+			if err := conn.WriteMessage(websocket.TextMessage, data); err != nil {
+				// The message was taken off the send buffer but never sent
+				c.retryQueue.requeueUnwritten(msg, errs.Retryable(fmt.Errorf("write failed: %w", err)))
+				if c.closedOnPurpose(ctx, conn) {
+					return nil
 				}
-				return
+				logging.Error("write error", "error", err)
+				c.connectionLost(err)
+				return nil
 			}
+			c.recordTraffic(bandwidth.Sent, msg, data)
 		}
 	}
 }
 
-// processMessages processes incoming messages
-func (c *NetworkClient) processMessages() {
-	defer func() {
-		if r := recover(); r != nil {
-			log.Printf("❌ Panic in processMessages: %v", r)
-		}
-	}()
-
+// processMessages processes incoming messages until ctx is canceled. It
+// outlives connections, messages received before a reconnect are still handled.
+func (c *NetworkClient) processMessages(ctx context.Context) error {
 	for {
 		select {
-		case <-c.ctx.Done():
-			return
-		case msg := <-c.receiveChan:
-			if handler, exists := c.messageHandlers[msg.Type]; exists {
-				if err := handler(msg); err != nil {
-					log.Printf("❌ Handler error for message type %s: %v", msg.Type, err)
-				}
-			} else {
-				log.Printf("⚠️  No handler for message type: %s", msg.Type)
+		case <-ctx.Done():
+			return nil
+		case msg := <-c.receiveBuf.ch:
+			c.mu.RLock()
+			dispatch := chainMiddleware(c.dispatchMessage, c.inbound)
+			c.mu.RUnlock()
+			if err := dispatch(msg); err != nil {
+				logging.Error("message handler failed", "type", msg.Type, "error", err)
 			}
 		}
 	}
 }
 
+// dispatchMessage passes an incoming message to the handler registered for its type
+func (c *NetworkClient) dispatchMessage(msg *types.Message) error {
+	c.mu.RLock()
+	handler, exists := c.messageHandlers[msg.Type]
+	c.mu.RUnlock()
+	if !exists {
+		logging.Warn("no handler for message type", "type", msg.Type)
+		return nil
+	}
+	return handler(msg)
+}
+
+// closedOnPurpose reports whether a goroutine's failure on conn comes from
+// it being stopped or the connection being closed by Disconnect or reconnect
+func (c *NetworkClient) closedOnPurpose(ctx context.Context, conn *websocket.Conn) bool {
+	return ctx.Err() != nil || c.getConn() != conn
+}
+
+// connectionLost starts reconnecting after a read or write on the connection failed
+func (c *NetworkClient) connectionLost(err error) {
+	if !c.reconnector.IsEnabled() {
+		c.eventBus.Publish(events.Disconnected{Err: err})
+		return
+	}
+	if atomic.CompareAndSwapInt32(&c.reconnecting, 0, 1) {
+		c.cancelReconnectHooks()
+		c.retryQueue.Pause()
+		c.eventBus.Publish(events.Disconnected{Err: err, Reconnecting: true})
+		go c.attemptReconnection()
+	}
+}
+
 // attemptReconnection attempts to reconnect to the WebSocket server
 func (c *NetworkClient) attemptReconnection() {
 	defer atomic.StoreInt32(&c.reconnecting, 0) // Reset flag when done
 
-	// Check without holding lock
 	if !c.reconnector.ShouldReconnect() {
-		log.Printf("❌ Max reconnection attempts reached, giving up")
+		logging.Error("reconnection attempts exhausted, giving up", "attempts", c.reconnector.GetAttempts())
 		c.healthMonitor.RecordReconnectAttempt(false)
+		c.eventBus.Publish(events.Reconnecting{Attempt: c.reconnector.GetAttempts(), MaxAttempts: c.reconnector.GetMaxAttempts(), GaveUp: true})
+		c.retryQueue.Resume() // Let queued messages run out of retries into the dead-letter queue
 		return
 	}
 
-	// Increment attempts (minimal lock time)
-	c.mu.Lock()
-	c.reconnector.attempts++
+	attempt := c.reconnector.IncrementAttempts()
 	backoff := c.reconnector.NextBackoff()
-	c.mu.Unlock()
 
-	log.Printf("🔄 Reconnection attempt %d/%d in %v...",
-		c.reconnector.attempts, c.reconnector.maxAttempts, backoff)
+	logging.Info("reconnecting", "attempt", attempt, "max_attempts", c.reconnector.GetMaxAttempts(), "backoff", backoff)
+	c.eventBus.Publish(events.Reconnecting{Attempt: attempt, MaxAttempts: c.reconnector.GetMaxAttempts(), Delay: backoff})
+
+	_, span := tracing.Start(context.Background(), tracing.SpanReconnect,
+		tracing.AttrReconnectAttempt.Int(attempt),
+	)
 
 	// Sleep without holding lock
 	time.Sleep(backoff)
 
 	// Attempt reconnection
-	if err := c.reconnect(); err != nil {
-		log.Printf("❌ Reconnection failed: %v", err)
+	err := c.reconnect()
+	tracing.End(span, err)
+	if err != nil {
+		logging.Error("reconnection failed", "error", err)
 		c.healthMonitor.RecordReconnectAttempt(false)
 
 		// Try again if we haven't exceeded max attempts
 		if c.reconnector.ShouldReconnect() {
 			atomic.StoreInt32(&c.reconnecting, 0) // Reset flag before next attempt
 			go c.attemptReconnection()
+		} else {
+			logging.Error("reconnection attempts exhausted, giving up", "attempts", attempt)
+			c.eventBus.Publish(events.Reconnecting{Attempt: attempt, MaxAttempts: c.reconnector.GetMaxAttempts(), GaveUp: true})
+			c.retryQueue.Resume()
 		}
 	} else {
-		log.Printf("✅ Reconnected successfully")
+		logging.Info("reconnected successfully")
+		c.eventBus.Publish(events.Reconnected{Attempts: attempt})
 		c.reconnector.Reset()
 		c.healthMonitor.RecordReconnectAttempt(true)
 		c.healthMonitor.RecordConnectionEstablished()
+		c.notifyReconnected()
+		go c.runReconnectHooks()
+	}
+}
+
+// SetEventBus sets the bus connection events are published to (nil = none).
+// It must be called before Connect.
+func (c *NetworkClient) SetEventBus(bus *events.Bus) {
+	c.eventBus = bus
+}
+
+// OnReconnected adds a callback run in its own goroutine whenever the client
+// has re-established a dropped connection. The new connection is not
+// authenticated yet.
+func (c *NetworkClient) OnReconnected(fn func()) {
+	c.reconnectedMu.Lock()
+	defer c.reconnectedMu.Unlock()
+	c.onReconnected = append(c.onReconnected, fn)
+}
+
+// notifyReconnected runs the OnReconnected callbacks
+func (c *NetworkClient) notifyReconnected() {
+	c.reconnectedMu.Lock()
+	callbacks := append([]func(){}, c.onReconnected...)
+	c.reconnectedMu.Unlock()
+
+	for _, fn := range callbacks {
+		go fn()
 	}
 }
 
 // reconnect performs the actual reconnection
 func (c *NetworkClient) reconnect() error {
-	// Close existing connection
+	// The data channel belongs to the old session, it is reopened after registration
+	c.closeDataChannel()
+
+	// Close existing connection, which also ends a pending read
+	c.mu.Lock()
 	if c.conn != nil {
 		c.conn.Close()
 		c.conn = nil
 	}
-
 	c.running = false
 	c.authenticated = false
+	c.mu.Unlock()
 
-	// Cancel existing context and create new one for fresh goroutines
-	c.cancel()
-	c.ctx, c.cancel = context.WithCancel(context.Background())
+	// Stop the goroutines bound to the old connection
+	for _, id := range connectionGoroutines {
+		if err := c.supervisor.StopGoroutine(id); err != nil {
+			logging.Warn("failed to stop goroutine", "goroutine", id, "error", err)
+		}
+	}
 
 	// Establish new connection
-	dialer := websocket.DefaultDialer
-	dialer.HandshakeTimeout = 10 * time.Second
-
-	conn, _, err := dialer.Dial(c.url, nil)
+	conn, resp, err := c.dialer().Dial(c.url, nil)
 	if err != nil {
+		if retryAfter, ok := retryAfterFromResponse(resp); ok {
+			c.reconnector.SetRetryAfter(retryAfter)
+		}
 		return fmt.Errorf("failed to reconnect to WebSocket: %w", err)
 	}
 
+	conn.SetReadLimit(maxMessageBytes)
+	c.watchPongs(conn)
+
+	c.mu.Lock()
 	c.conn = conn
 	c.running = true
 	c.authenticated = false
+	c.mu.Unlock()
+	c.compressContent.Store(false)
+
+	// Start fresh goroutines against the new connection, with their restart counts reset
+	for _, id := range connectionGoroutines {
+		if err := c.supervisor.RestartGoroutine(id); err != nil {
+			return fmt.Errorf("failed to restart %s: %w", id, err)
+		}
+	}
 
-	// Set up pong handler to respond to server pings
-	c.conn.SetPongHandler(func(appData string) error {
-		log.Printf("🏓 Pong received from server")
+	logging.Info("reconnected to WebSocket server", "url", c.url)
+	return nil
+}
+
+// watchPongs extends conn's read deadline whenever the server answers a ping
+func (c *NetworkClient) watchPongs(conn *websocket.Conn) {
+	conn.SetPongHandler(func(appData string) error {
+		logging.Debug("pong received from server")
 		// Reset read deadline when we receive a pong
-		c.conn.SetReadDeadline(time.Now().Add(60 * time.Second))
+		conn.SetReadDeadline(time.Now().Add(60 * time.Second))
 		return nil
 	})
 
 	// Set initial read deadline
-	c.conn.SetReadDeadline(time.Now().Add(60 * time.Second))
-
-	// Restart message processing goroutines
-	go c.readMessages()
-	go c.writeMessages()
-	go c.processMessages()
-	go c.pingPongHandler()
-
-	log.Printf("🔗 Reconnected to WebSocket server: %s", c.url)
-	return nil
+	conn.SetReadDeadline(time.Now().Add(60 * time.Second))
 }
 
-// pingPongHandler handles WebSocket ping/pong to keep connection alive
-func (c *NetworkClient) pingPongHandler() {
-	defer func() {
-		if r := recover(); r != nil {
-			log.Printf("❌ Panic in pingPongHandler: %v", r)
-		}
-	}()
-
+// pingPongHandler pings the server over conn to keep the connection alive
+// until ctx is canceled or a ping fails
+func (c *NetworkClient) pingPongHandler(ctx context.Context, conn *websocket.Conn) error {
 	pingInterval := 25 * time.Second
 	ticker := time.NewTicker(pingInterval)
 	defer ticker.Stop()
 
 	for {
 		select {
-		case <-c.ctx.Done():
-			return
+		case <-ctx.Done():
+			return nil
 		case <-ticker.C:
-			c.mu.RLock()
-			conn := c.conn
-			running := c.running
-			c.mu.RUnlock()
-
-			if !running || conn == nil {
-				continue
-			}
-
 			// Send ping message
 			if err := conn.WriteControl(websocket.PingMessage, []byte{}, time.Now().Add(10*time.Second)); err != nil {
-				log.Printf("⚠️ Ping failed: %v", err)
-				// Trigger reconnection if ping fails
-				if c.reconnector.enabled && atomic.CompareAndSwapInt32(&c.reconnecting, 0, 1) {
-					go c.attemptReconnection()
+				if c.closedOnPurpose(ctx, conn) {
+					return nil
 				}
-				return
+				logging.Warn("ping failed", "error", err)
+				c.connectionLost(err)
+				return nil
 			}
-			log.Printf("🏓 Ping sent successfully")
+			logging.Debug("ping sent successfully")
 		}
 	}
 }
 
-// exponentialBackoff calculates exponential backoff delay
-func exponentialBackoff(attempt int) time.Duration {
-	delay := time.Duration(attempt) * 5 * time.Second
-	if delay > 60*time.Second {
-		delay = 60 * time.Second
-	}
-	return delay
-}
-
 // getConn returns the connection safely
 func (c *NetworkClient) getConn() *websocket.Conn {
 	c.mu.RLock()
@@ -551,45 +904,45 @@ func (c *NetworkClient) healthCheck() error {
 	return nil
 }
 
+// connectionGoroutines are the supervised goroutines bound to a connection.
+// They end when it fails and are started again against the next one.
+var connectionGoroutines = []string{"read-messages", "write-messages", "ping-pong"}
+
 // registerGoroutines registers all goroutines with the supervisor
 func (c *NetworkClient) registerGoroutines() {
 	policy := DefaultRestartPolicy()
 
 	// Register read messages goroutine
-	c.supervisor.Register("read-messages", "Message Reader",
-		func(ctx context.Context) error {
-			c.wg.Add(1)
-			defer c.wg.Done()
-			c.readMessages()
-			return nil
-		}, policy)
+	c.supervisor.Register("read-messages", "Message Reader", c.onConnection(c.readMessages), policy)
 
 	// Register write messages goroutine
-	c.supervisor.Register("write-messages", "Message Writer",
-		func(ctx context.Context) error {
-			c.wg.Add(1)
-			defer c.wg.Done()
-			c.writeMessages()
-			return nil
-		}, policy)
+	c.supervisor.Register("write-messages", "Message Writer", c.onConnection(c.writeMessages), policy)
 
 	// Register process messages goroutine
 	c.supervisor.Register("process-messages", "Message Processor",
 		func(ctx context.Context) error {
 			c.wg.Add(1)
 			defer c.wg.Done()
-			c.processMessages()
-			return nil
+			return c.processMessages(ctx)
 		}, policy)
 
 	// Register ping/pong handler
-	c.supervisor.Register("ping-pong", "Ping/Pong Handler",
-		func(ctx context.Context) error {
-			c.wg.Add(1)
-			defer c.wg.Done()
-			c.pingPongHandler()
+	c.supervisor.Register("ping-pong", "Ping/Pong Handler", c.onConnection(c.pingPongHandler), policy)
+}
+
+// onConnection adapts a goroutine bound to a connection for the supervisor.
+// Each run, including restarts after a failure, uses the current connection;
+// without one the goroutine ends until reconnect starts it again.
+func (c *NetworkClient) onConnection(fn func(ctx context.Context, conn *websocket.Conn) error) GoroutineFunc {
+	return func(ctx context.Context) error {
+		c.wg.Add(1)
+		defer c.wg.Done()
+		conn := c.getConn()
+		if conn == nil {
 			return nil
-		}, policy)
+		}
+		return fn(ctx, conn)
+	}
 }
 
 // GetHealthReport returns a health report for the connection
@@ -597,9 +950,68 @@ func (c *NetworkClient) GetHealthReport() string {
 	return c.healthMonitor.GetHealthReport()
 }
 
-// GetCircuitBreakerStats returns circuit breaker statistics
-func (c *NetworkClient) GetCircuitBreakerStats() CircuitBreakerStats {
-	return c.circuitBreaker.GetStats()
+// GetConnectionMetrics returns connection metrics (messages, reconnects, latency)
+func (c *NetworkClient) GetConnectionMetrics() ConnectionMetrics {
+	return c.healthMonitor.GetMetrics()
+}
+
+// SetRetryStore persists the retry queue in store, so messages waiting for a
+// retry survive a restart, and loads the messages saved by an earlier run.
+// Call it before Connect.
+func (c *NetworkClient) SetRetryStore(ctx context.Context, store retrystore.Store) error {
+	return c.retryQueue.SetStore(ctx, store)
+}
+
+// OnDeadLetter registers a callback run when the retry queue gives up on a
+// message, e.g. to alert on lost task responses. Callbacks run in their own
+// goroutine.
+func (c *NetworkClient) OnDeadLetter(fn func(DeadLetter)) {
+	c.deadLetterMu.Lock()
+	defer c.deadLetterMu.Unlock()
+	c.onDeadLetter = append(c.onDeadLetter, fn)
+}
+
+// deadLettered publishes a message moved to the dead-letter queue
+func (c *NetworkClient) deadLettered(letter DeadLetter) {
+	var lastErr error
+	if letter.Error != "" {
+		lastErr = errors.New(letter.Error)
+	}
+	c.eventBus.Publish(events.MessageDeadLettered{
+		ID:          letter.ID,
+		MessageType: letter.Message.Type,
+		TaskID:      letter.Message.TaskID,
+		Reason:      letter.Reason,
+		Err:         lastErr,
+	})
+
+	c.deadLetterMu.Lock()
+	callbacks := append([]func(DeadLetter){}, c.onDeadLetter...)
+	c.deadLetterMu.Unlock()
+	for _, fn := range callbacks {
+		fn(letter)
+	}
+}
+
+// DeadLetters returns the messages the retry queue gave up on, oldest first
+func (c *NetworkClient) DeadLetters() []DeadLetter {
+	return c.retryQueue.DeadLetters()
+}
+
+// DeadLetter returns the dead letter with the ID
+func (c *NetworkClient) DeadLetter(id string) (DeadLetter, bool) {
+	return c.retryQueue.DeadLetter(id)
+}
+
+// RequeueDeadLetter moves a dead letter back to the retry queue to send it again
+func (c *NetworkClient) RequeueDeadLetter(id string) error {
+	return c.retryQueue.Requeue(id)
+}
+
+// PurgeDeadLetters deletes the dead letters with the IDs, or all of them
+// when no ID is given, and returns how many were deleted
+func (c *NetworkClient) PurgeDeadLetters(ids ...string) int {
+	return c.retryQueue.PurgeDeadLetters(ids...)
 }
 
 // GetRetryQueueMetrics returns retry queue metrics
@@ -0,0 +1,266 @@
+package network
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/errs"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/types"
+	"github.com/gorilla/websocket"
+)
+
+// fakeServer accepts WebSocket connections and collects the messages it receives
+type fakeServer struct {
+	*httptest.Server
+	conns    chan *websocket.Conn
+	received chan *types.Message
+}
+
+func newFakeServer(t *testing.T) *fakeServer {
+	t.Helper()
+	s := &fakeServer{conns: make(chan *websocket.Conn, 10), received: make(chan *types.Message, 100)}
+	upgrader := websocket.Upgrader{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ws, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer ws.Close()
+		s.conns <- ws
+		for {
+			var msg types.Message
+			if err := ws.ReadJSON(&msg); err != nil {
+				return
+			}
+			s.received <- &msg
+		}
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *fakeServer) url() string {
+	return "ws" + strings.TrimPrefix(s.URL, "http")
+}
+
+// accept waits for the next connection
+func (s *fakeServer) accept(t *testing.T) *websocket.Conn {
+	t.Helper()
+	select {
+	case ws := <-s.conns:
+		return ws
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for a connection")
+		return nil
+	}
+}
+
+// receive waits for the next message of the given type
+func (s *fakeServer) receive(t *testing.T, msgType string) *types.Message {
+	t.Helper()
+	timeout := time.After(2 * time.Second)
+	for {
+		select {
+		case msg := <-s.received:
+			if msg.Type == msgType {
+				return msg
+			}
+		case <-timeout:
+			t.Fatalf("timed out waiting for a %s message", msgType)
+			return nil
+		}
+	}
+}
+
+func TestClientReconnectsAfterDrop(t *testing.T) {
+	server := newFakeServer(t)
+	client := NewNetworkClient(&Config{
+		WebSocketURL:     server.url(),
+		ReconnectEnabled: true,
+		MaxReconnects:    10,
+		ReconnectBackoff: BackoffFunc(func(int) time.Duration { return 10 * time.Millisecond }),
+	})
+
+	handled := make(chan *types.Message, 10)
+	client.RegisterHandler("ping_test", func(msg *types.Message) error {
+		handled <- msg
+		return nil
+	})
+
+	// The hooks record their order; the first one queues a message while
+	// retries are paused, the last flushes the retry queue
+	var mu sync.Mutex
+	var steps []string
+	hooksDone := make(chan struct{}, 10)
+	step := func(name string, fn func()) {
+		client.AddReconnectHook(name, func(ctx context.Context) error {
+			mu.Lock()
+			steps = append(steps, name)
+			mu.Unlock()
+			if fn != nil {
+				fn()
+			}
+			return nil
+		})
+	}
+	step("queue", func() {
+		msg := &types.Message{Type: "pending", Content: time.Now().String()}
+		client.retryQueue.Enqueue(msg, errs.Retryable(errors.New("connection lost")))
+	})
+	step("reregister", nil)
+	step(HookFlushPending, func() {
+		client.FlushRetryQueue()
+		hooksDone <- struct{}{}
+	})
+
+	reconnected := make(chan struct{}, 10)
+	client.OnReconnected(func() { reconnected <- struct{}{} })
+
+	if err := client.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { client.Disconnect() })
+	ws := server.accept(t)
+
+	// Let the goroutines of the first connection settle before counting them
+	time.Sleep(50 * time.Millisecond)
+	goroutines := runtime.NumGoroutine()
+
+	for i := 1; i <= 3; i++ {
+		mu.Lock()
+		steps = nil
+		mu.Unlock()
+
+		ws.Close()
+		ws = server.accept(t)
+		select {
+		case <-hooksDone:
+		case <-time.After(2 * time.Second):
+			t.Fatalf("reconnect %d: hooks did not run", i)
+		}
+		select {
+		case <-reconnected:
+		case <-time.After(2 * time.Second):
+			t.Fatalf("reconnect %d: OnReconnected not called", i)
+		}
+
+		mu.Lock()
+		got := strings.Join(steps, ",")
+		mu.Unlock()
+		if want := "queue,reregister," + HookFlushPending; got != want {
+			t.Errorf("reconnect %d: hooks ran as %s, want %s", i, got, want)
+		}
+
+		// The message queued during the reconnect went out on the new connection
+		server.receive(t, "pending")
+		if size := client.retryQueue.GetQueueSize(); size != 0 {
+			t.Errorf("reconnect %d: retry queue holds %d messages after the flush", i, size)
+		}
+
+		// The new connection carries messages both ways
+		if err := client.SendMessage(&types.Message{Type: "outbound_test"}); err != nil {
+			t.Fatal(err)
+		}
+		server.receive(t, "outbound_test")
+		if err := ws.WriteJSON(&types.Message{Type: "ping_test"}); err != nil {
+			t.Fatal(err)
+		}
+		select {
+		case <-handled:
+		case <-time.After(2 * time.Second):
+			t.Fatalf("reconnect %d: message from the server not handled", i)
+		}
+
+		for _, id := range append([]string{"process-messages"}, connectionGoroutines...) {
+			if state := client.GetSupervisorStatus()[id].State; state != GoroutineRunning {
+				t.Errorf("reconnect %d: %s is %s", i, id, state)
+			}
+		}
+	}
+
+	// One reader, writer and pinger per connection: reconnecting leaves no
+	// goroutines of the old connections behind
+	deadline := time.Now().Add(2 * time.Second)
+	for runtime.NumGoroutine() > goroutines {
+		if time.Now().After(deadline) {
+			t.Fatalf("%d goroutines after reconnecting, %d before", runtime.NumGoroutine(), goroutines)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestFailedWriteIsRedelivered(t *testing.T) {
+	server := newFakeServer(t)
+	client := NewNetworkClient(&Config{WebSocketURL: server.url()})
+
+	// A connection that fails the first write
+	broken, _, err := websocket.DefaultDialer.Dial(server.url(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server.accept(t)
+	broken.UnderlyingConn().Close()
+	setConn(client, broken)
+
+	client.sendBuf.ch <- &types.Message{Type: "unwritten_test", Content: "hello"}
+	client.writeMessages(context.Background(), broken)
+	if size := client.retryQueue.GetQueueSize(); size != 1 {
+		t.Fatalf("retry queue holds %d messages after the failed write, want 1", size)
+	}
+
+	setConn(client, nil)
+	if err := client.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { client.Disconnect() })
+	server.accept(t)
+	client.FlushRetryQueue()
+	if msg := server.receive(t, "unwritten_test"); msg.Content != "hello" {
+		t.Errorf("redelivered content %q, want hello", msg.Content)
+	}
+}
+
+func TestUnwrittenRetryKeepsItsAttempts(t *testing.T) {
+	queue := NewMessageRetryQueue(&RetryPolicy{MaxRetries: 3, InitialDelay: time.Millisecond, MaxDelay: time.Millisecond, BackoffFactor: 1, RetryableError: errs.IsRetryable}, func(*types.Message) error { return nil })
+	writeErr := errs.Retryable(errors.New("write failed"))
+
+	// Delivered by its second retry, then lost before it was written
+	msg := &types.Message{Type: "task_response", TaskID: "task-1", Content: "a"}
+	key := messageKey(msg)
+	queue.mu.Lock()
+	queue.markSentLocked(key)
+	queue.attempts[key] = 2
+	queue.mu.Unlock()
+
+	queue.requeueUnwritten(msg, writeErr)
+	if size := queue.GetQueueSize(); size != 1 {
+		t.Fatalf("retry queue holds %d messages, want 1", size)
+	}
+	queue.mu.Lock()
+	retries := queue.queue[0].RetryCount
+	queue.mu.Unlock()
+	if retries != 2 {
+		t.Errorf("requeued with %d retries, want 2", retries)
+	}
+
+	// With its retries used up it is dead-lettered
+	used := &types.Message{Type: "task_response", TaskID: "task-2", Content: "b"}
+	key = messageKey(used)
+	queue.mu.Lock()
+	queue.markSentLocked(key)
+	queue.attempts[key] = 3
+	queue.mu.Unlock()
+
+	queue.requeueUnwritten(used, writeErr)
+	letter, ok := queue.DeadLetter(key)
+	if !ok || letter.Reason != DeadLetterMaxRetries || letter.Attempts != 3 {
+		t.Errorf("dead letter = %+v, %v, want one after 3 attempts", letter, ok)
+	}
+}
@@ -1,57 +1,237 @@
 package network
 
 import (
+	"errors"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
+
+	"github.com/gorilla/websocket"
 )
 
+// BackoffStrategy computes the delay before a reconnection attempt
+type BackoffStrategy interface {
+	// Backoff returns the delay before the given attempt (1-based)
+	Backoff(attempt int) time.Duration
+}
+
+// BackoffFunc adapts a function to the BackoffStrategy interface
+type BackoffFunc func(attempt int) time.Duration
+
+// Backoff implements the BackoffStrategy interface
+func (f BackoffFunc) Backoff(attempt int) time.Duration {
+	return f(attempt)
+}
+
+// ExponentialBackoff doubles (by Multiplier) the delay after each attempt, up to MaxDelay.
+// With Jitter set, the delay is drawn uniformly between 0 and the exponential delay
+// ("full jitter"), so agents disconnected together do not reconnect together.
+type ExponentialBackoff struct {
+	InitialDelay time.Duration
+	MaxDelay     time.Duration
+	Multiplier   float64
+	Jitter       bool
+}
+
+// DefaultExponentialBackoff returns exponential backoff with full jitter from 5s up to 60s
+func DefaultExponentialBackoff() *ExponentialBackoff {
+	return &ExponentialBackoff{
+		InitialDelay: 5 * time.Second,
+		MaxDelay:     60 * time.Second,
+		Multiplier:   2.0,
+		Jitter:       true,
+	}
+}
+
+// Backoff implements the BackoffStrategy interface
+func (b *ExponentialBackoff) Backoff(attempt int) time.Duration {
+	delay := float64(b.InitialDelay)
+	for i := 1; i < attempt && (b.MaxDelay <= 0 || delay < float64(b.MaxDelay)); i++ {
+		delay *= b.Multiplier
+	}
+	if b.MaxDelay > 0 && delay > float64(b.MaxDelay) {
+		delay = float64(b.MaxDelay)
+	}
+
+	if b.Jitter && delay > 0 {
+		return time.Duration(rand.Int64N(int64(delay) + 1))
+	}
+	return time.Duration(delay)
+}
+
+// ConstantBackoff waits the same delay before every attempt
+func ConstantBackoff(delay time.Duration) BackoffFunc {
+	return func(int) time.Duration {
+		return delay
+	}
+}
+
+// LinearBackoff waits step times the attempt number, up to maxDelay
+func LinearBackoff(step, maxDelay time.Duration) BackoffFunc {
+	return func(attempt int) time.Duration {
+		delay := time.Duration(attempt) * step
+		if maxDelay > 0 && delay > maxDelay {
+			delay = maxDelay
+		}
+		return delay
+	}
+}
+
 // ReconnectionManager handles automatic reconnection logic
 type ReconnectionManager struct {
+	mu          sync.Mutex
 	enabled     bool
 	attempts    int
 	maxAttempts int
-	delay       time.Duration
-	backoffFunc func(int) time.Duration
+	maxElapsed  time.Duration // Time budget for reconnecting after a disconnect (0 = unlimited)
+	startedAt   time.Time     // First attempt since the last successful connection
+	retryAfter  time.Duration // Minimum delay requested by the server for the next attempt
+	backoff     BackoffStrategy
 }
 
 // ShouldReconnect returns whether reconnection should be attempted
 func (r *ReconnectionManager) ShouldReconnect() bool {
-	return r.enabled && r.attempts < r.maxAttempts
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.enabled || r.attempts >= r.maxAttempts {
+		return false
+	}
+	return r.maxElapsed <= 0 || r.startedAt.IsZero() || time.Since(r.startedAt) < r.maxElapsed
 }
 
-// NextBackoff calculates the next backoff delay
+// NextBackoff calculates the delay before the current attempt. A Retry-After
+// hint from the server raises the delay and is used once.
 func (r *ReconnectionManager) NextBackoff() time.Duration {
-	if r.backoffFunc != nil {
-		return r.backoffFunc(r.attempts)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var delay time.Duration
+	if r.backoff != nil {
+		delay = r.backoff.Backoff(max(r.attempts, 1))
 	}
-	return r.delay
+	if r.retryAfter > delay {
+		delay = r.retryAfter
+	}
+	r.retryAfter = 0
+	return delay
+}
+
+// SetRetryAfter records the server's minimum delay before the next attempt
+func (r *ReconnectionManager) SetRetryAfter(delay time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.retryAfter = delay
+}
+
+// SetBackoffStrategy replaces the strategy computing the delay between attempts
+func (r *ReconnectionManager) SetBackoffStrategy(strategy BackoffStrategy) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.backoff = strategy
+}
+
+// SetMaxElapsedTime sets how long reconnection is attempted after a disconnect (0 = unlimited)
+func (r *ReconnectionManager) SetMaxElapsedTime(maxElapsed time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.maxElapsed = maxElapsed
 }
 
 // Reset resets the reconnection attempts counter
 func (r *ReconnectionManager) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 	r.attempts = 0
+	r.startedAt = time.Time{}
+	r.retryAfter = 0
 }
 
 // GetAttempts returns the current number of attempts
 func (r *ReconnectionManager) GetAttempts() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 	return r.attempts
 }
 
 // GetMaxAttempts returns the maximum number of attempts
 func (r *ReconnectionManager) GetMaxAttempts() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 	return r.maxAttempts
 }
 
 // IsEnabled returns whether reconnection is enabled
 func (r *ReconnectionManager) IsEnabled() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 	return r.enabled
 }
 
 // SetEnabled enables or disables reconnection
 func (r *ReconnectionManager) SetEnabled(enabled bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 	r.enabled = enabled
 }
 
-// IncrementAttempts increments the attempts counter
-func (r *ReconnectionManager) IncrementAttempts() {
+// IncrementAttempts increments the attempts counter and returns the new count
+func (r *ReconnectionManager) IncrementAttempts() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.attempts == 0 {
+		r.startedAt = time.Now()
+	}
 	r.attempts++
+	return r.attempts
+}
+
+// retryAfterFromClose extracts a Retry-After hint from the reason of a close frame,
+// e.g. "overloaded; retry-after=30" (seconds) or "retry-after: 1m30s"
+func retryAfterFromClose(err error) (time.Duration, bool) {
+	var closeErr *websocket.CloseError
+	if !errors.As(err, &closeErr) {
+		return 0, false
+	}
+
+	reason := strings.ToLower(closeErr.Text)
+	idx := strings.Index(reason, "retry-after")
+	if idx < 0 {
+		return 0, false
+	}
+
+	value := strings.TrimLeft(reason[idx+len("retry-after"):], " :=")
+	if end := strings.IndexAny(value, " ;,"); end >= 0 {
+		value = value[:end]
+	}
+	return parseRetryAfter(value)
+}
+
+// retryAfterFromResponse extracts the Retry-After header of a rejected handshake
+func retryAfterFromResponse(resp *http.Response) (time.Duration, bool) {
+	if resp == nil {
+		return 0, false
+	}
+	return parseRetryAfter(resp.Header.Get("Retry-After"))
+}
+
+// parseRetryAfter parses a delay in seconds, a Go duration or an HTTP date
+func parseRetryAfter(value string) (time.Duration, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if d, err := time.ParseDuration(value); err == nil && d >= 0 {
+		return d, true
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return max(time.Until(at), 0), true
+	}
+	return 0, false
 }
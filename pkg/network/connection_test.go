@@ -0,0 +1,162 @@
+package network
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestExponentialBackoff(t *testing.T) {
+	backoff := &ExponentialBackoff{InitialDelay: time.Second, MaxDelay: 10 * time.Second, Multiplier: 2}
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{4, 8 * time.Second},
+		{5, 10 * time.Second},
+		{1000, 10 * time.Second},
+	}
+	for _, tt := range tests {
+		if got := backoff.Backoff(tt.attempt); got != tt.want {
+			t.Errorf("Backoff(%d) = %v, want %v", tt.attempt, got, tt.want)
+		}
+	}
+}
+
+func TestExponentialBackoffJitter(t *testing.T) {
+	backoff := &ExponentialBackoff{InitialDelay: time.Second, MaxDelay: 10 * time.Second, Multiplier: 2, Jitter: true}
+	tests := []struct {
+		attempt int
+		ceiling time.Duration
+	}{
+		{1, time.Second},
+		{3, 4 * time.Second},
+		{1000, 10 * time.Second},
+	}
+	for _, tt := range tests {
+		var lowest, highest time.Duration = tt.ceiling, 0
+		for range 1000 {
+			got := backoff.Backoff(tt.attempt)
+			if got < 0 || got > tt.ceiling {
+				t.Fatalf("Backoff(%d) = %v, want between 0 and %v", tt.attempt, got, tt.ceiling)
+			}
+			lowest, highest = min(lowest, got), max(highest, got)
+		}
+		// Full jitter spreads the delays over the whole range
+		if lowest > tt.ceiling/4 || highest < tt.ceiling*3/4 {
+			t.Errorf("Backoff(%d) ranged over [%v, %v], want most of [0, %v]", tt.attempt, lowest, highest, tt.ceiling)
+		}
+	}
+}
+
+func TestReconnectionMaxElapsed(t *testing.T) {
+	r := &ReconnectionManager{enabled: true, maxAttempts: 10, maxElapsed: time.Minute}
+	if !r.ShouldReconnect() {
+		t.Fatal("no reconnect before the first attempt")
+	}
+	r.IncrementAttempts()
+	if !r.ShouldReconnect() {
+		t.Fatal("no reconnect within the budget")
+	}
+
+	// The budget counts from the first attempt since the last connection
+	r.mu.Lock()
+	r.startedAt = time.Now().Add(-2 * time.Minute)
+	r.mu.Unlock()
+	if r.ShouldReconnect() {
+		t.Fatal("reconnect after the budget was spent")
+	}
+
+	r.Reset()
+	if !r.ShouldReconnect() {
+		t.Error("no reconnect after a reset")
+	}
+
+	r.SetMaxElapsedTime(0)
+	for range 10 {
+		r.IncrementAttempts()
+	}
+	if r.ShouldReconnect() {
+		t.Error("reconnect after the maximum attempts")
+	}
+}
+
+func TestNextBackoffHonorsRetryAfterOnce(t *testing.T) {
+	r := &ReconnectionManager{enabled: true, maxAttempts: 10, backoff: ConstantBackoff(time.Second)}
+	r.SetRetryAfter(30 * time.Second)
+	if got := r.NextBackoff(); got != 30*time.Second {
+		t.Errorf("NextBackoff() = %v, want the server's 30s", got)
+	}
+	if got := r.NextBackoff(); got != time.Second {
+		t.Errorf("NextBackoff() = %v, want the strategy's 1s", got)
+	}
+
+	// A hint shorter than the strategy's delay does not shorten it
+	r.SetRetryAfter(time.Millisecond)
+	if got := r.NextBackoff(); got != time.Second {
+		t.Errorf("NextBackoff() = %v, want 1s", got)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	tests := []struct {
+		value string
+		want  time.Duration
+		ok    bool
+	}{
+		{"30", 30 * time.Second, true},
+		{" 5 ", 5 * time.Second, true},
+		{"0", 0, true},
+		{"1m30s", 90 * time.Second, true},
+		{time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat), 0, true},
+		{"-1", 0, false},
+		{"-5s", 0, false},
+		{"", 0, false},
+		{"soon", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := parseRetryAfter(tt.value)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("parseRetryAfter(%q) = %v, %v, want %v, %v", tt.value, got, ok, tt.want, tt.ok)
+		}
+	}
+
+	// An HTTP date is the time left until then, to the second
+	got, ok := parseRetryAfter(time.Now().Add(2 * time.Minute).UTC().Format(http.TimeFormat))
+	if !ok || got <= 118*time.Second || got > 2*time.Minute {
+		t.Errorf("parseRetryAfter(date in 2 minutes) = %v, %v", got, ok)
+	}
+}
+
+func TestRetryAfterFromClose(t *testing.T) {
+	tests := []struct {
+		err  error
+		want time.Duration
+		ok   bool
+	}{
+		{&websocket.CloseError{Code: websocket.CloseTryAgainLater, Text: "overloaded; retry-after=30"}, 30 * time.Second, true},
+		{&websocket.CloseError{Code: websocket.CloseTryAgainLater, Text: "Retry-After: 1m30s, please wait"}, 90 * time.Second, true},
+		{&websocket.CloseError{Code: websocket.CloseGoingAway, Text: "restarting"}, 0, false},
+		{&websocket.CloseError{Code: websocket.CloseTryAgainLater, Text: "retry-after=later"}, 0, false},
+		{errors.New("retry-after=30"), 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := retryAfterFromClose(tt.err)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("retryAfterFromClose(%v) = %v, %v, want %v, %v", tt.err, got, ok, tt.want, tt.ok)
+		}
+	}
+
+	resp := &http.Response{Header: http.Header{"Retry-After": []string{"12"}}}
+	if got, ok := retryAfterFromResponse(resp); !ok || got != 12*time.Second {
+		t.Errorf("retryAfterFromResponse() = %v, %v, want 12s", got, ok)
+	}
+	if _, ok := retryAfterFromResponse(nil); ok {
+		t.Error("retryAfterFromResponse(nil) found a delay")
+	}
+}
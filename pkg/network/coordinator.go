@@ -3,173 +3,1022 @@ package network
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
+	"math"
+	"slices"
+	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/errs"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/events"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/logging"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/memory"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/output"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/ratelimit"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/recording"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/scheduler"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/schema"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/taskctx"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/tracing"
 	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/types"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // TaskCoordinator manages task execution and coordination
 type TaskCoordinator struct {
-	agentHandler      types.AgentHandler
-	protocolHandler   *ProtocolHandler
-	activeTasksMu     sync.RWMutex
-	activeTasks       map[string]*TaskExecution
-	capabilities      []string
-	rateLimitPerMin   int
-	rateLimitMu       sync.Mutex
-	requestTimestamps []time.Time
+	agentHandler    types.AgentHandler
+	protocolHandler *ProtocolHandler
+	activeTasksMu   sync.RWMutex
+	activeTasks     map[string]*TaskExecution
+	capabilities    []string // Guarded by capabilitiesMu
+	capabilitiesMu  sync.RWMutex
+	rateLimiter     *ratelimit.Limiter
+	rateLimitMu     sync.Mutex
+	quotaChecker    types.QuotaChecker
+	memory          types.ConversationMemory
+	guards          *TaskGuards
+	metrics         types.MetricsRecorder
+	retryPolicy     *RetryPolicy
+	reviewer        types.ResponseReviewer
+	dedup           *TaskDeduplicator
+	scrubInput      func(string) string
+	progress        map[string]types.TaskProgress // Last progress of active tasks, guarded by activeTasksMu
+	backpressure    backpressureCounters
+	eventBus        *events.Bus
+	inputSchemas    map[string]*schema.Schema // Task input schema per capability
+	durations       durationEstimator         // Recent task durations for deadline predictions
+	scheduler       *scheduler.Scheduler      // Queues tasks by priority, nil = tasks start when they arrive
+	timeouts        *TaskTimeouts             // Timeouts of tasks without a deadline, nil = 30 seconds
+	budget          *types.TaskBudget         // What each task may consume, nil = unlimited
+
+	heartbeatInterval time.Duration                        // Interval of task_alive messages, 0 = no heartbeats
+	usageMeter        types.UsageMeter                     // Records completed tasks for billing, nil = no metering
+	paymentVerifier   types.PaymentVerifier                // Checks tasks were paid for before they run, nil = no checks
+	accessChecker     types.AccessChecker                  // Decides which senders and rooms tasks are accepted from, nil = all
+	userSignatures    *UserSignatures                      // Verifies signed user messages, nil = not checked
+	onPanic           func(context.Context, *HandlerPanic) // Called after a handler panicked, nil = none
+	llmUsageRecorder  types.LLMUsageRecorder               // Aggregates the LLM usage of tasks, nil = only metrics
+	llmUsageReport    string                               // How the LLM usage is added to responses, see LLMUsageReportField
+	guardrail         *GuardrailPolicy                     // Checks task input and responses, nil = no checks
+}
+
+// maxPendingUpdateBytes bounds the updates held back while the connection is congested.
+// Beyond it the pending updates are sent even if the connection is still congested.
+const maxPendingUpdateBytes = 64 * 1024
+
+// Typing indicator timing: the indicator is refreshed every typingKeepalive
+// and hidden after typingTimeout if the handler never stops it
+const (
+	typingKeepalive = 5 * time.Second
+	typingTimeout   = 2 * time.Minute
+)
+
+// backpressureCounters counts task updates held back while the connection was congested
+type backpressureCounters struct {
+	coalesced atomic.Int64 // Updates merged into a later message
+	dropped   atomic.Int64 // Updates never sent because the task ended with an error
+}
+
+// BackpressureStats reports how task updates were affected by a congested connection
+type BackpressureStats struct {
+	CoalescedUpdates int64 `json:"coalesced_updates"`
+	DroppedUpdates   int64 `json:"dropped_updates"`
 }
 
 // TaskExecution represents an active task execution
 type TaskExecution struct {
-	ID        string
-	StartTime time.Time
-	Cancel    context.CancelFunc
-	Context   context.Context
+	ID           string
+	StartTime    time.Time
+	Cancel       context.CancelFunc
+	Context      context.Context
+	Capabilities []string // Capabilities the task requires
+
+	cancel context.CancelCauseFunc // Cancels the task with a cause handlers can read with taskctx.CancelReason
+}
+
+// stop cancels the task with a cause
+func (e *TaskExecution) stop(cause error) {
+	if e.cancel == nil {
+		e.Cancel()
+		return
+	}
+	e.cancel(cause)
 }
 
 // TaskMessageSender implements the MessageSender interface for streaming tasks
 type TaskMessageSender struct {
+	ctx             context.Context
 	taskID          string
 	protocolHandler *ProtocolHandler
 	room            string
+	guards          *TaskGuards
+	mu              sync.Mutex
+	messagesSent    int
+	bytesSent       int
+	limitReached    bool
+	transcript      strings.Builder // Text sent for this task, recorded for conversation memory
+	sent            []taskResponse  // Messages sent for this task, replayed to duplicates
+	onProgress      func(types.TaskProgress)
+
+	// Backpressure: while congested returns true, task updates are held back
+	// and sent as one message once the connection drains
+	congested      func() bool
+	backpressure   *backpressureCounters
+	pendingUpdate  strings.Builder
+	pendingUpdates int
+
+	// Typing indicator: closing typingStop ends the keepalive loop
+	typingMu   sync.Mutex
+	typingStop chan struct{}
+
+	extendDeadline func(time.Duration) error // nil when the messages do not belong to a task
+
+	// Checks a message before it is sent, nil = no guardrail
+	guardrail func(content string, structured bool) guardrailCheck
 }
 
 // SendMessage sends a message with content (backward compatibility - STRING type)
 func (s *TaskMessageSender) SendMessage(content string) error {
-	return s.sendStandardizedMessage(types.StandardMessageTypeString, content)
+	s.stopTypingKeepalive()
+	if err := s.flushUpdates(); err != nil {
+		return err
+	}
+	sent, err := s.sendStandardizedMessage(types.StandardizedMessage{ContentType: types.StandardMessageTypeString, Content: content})
+	if err != nil {
+		return err
+	}
+	s.record(sent, true)
+	return nil
 }
 
-// SendTaskUpdate sends a progress update for the current task
+// SendTaskUpdate sends a progress update for the current task.
+// While the connection is congested, updates are coalesced and sent as one
+// message, one update per line, once it drains or before the next final
+// message.
 func (s *TaskMessageSender) SendTaskUpdate(content string) error {
-	updateContent := fmt.Sprintf("🔄 Update: %s", content)
-	return s.sendStandardizedMessage(types.StandardMessageTypeString, updateContent)
+	s.mu.Lock()
+	if s.pendingUpdates > 0 {
+		s.pendingUpdate.WriteByte('\n')
+	}
+	s.pendingUpdate.WriteString(content)
+	s.pendingUpdates++
+	hold := s.congested != nil && s.pendingUpdate.Len() < maxPendingUpdateBytes && s.congested()
+	s.mu.Unlock()
+
+	if hold {
+		return nil
+	}
+	return s.flushUpdates()
+}
+
+// flushUpdates sends the pending task updates as a single message
+func (s *TaskMessageSender) flushUpdates() error {
+	s.mu.Lock()
+	content := s.pendingUpdate.String()
+	count := s.pendingUpdates
+	s.pendingUpdate.Reset()
+	s.pendingUpdates = 0
+	s.mu.Unlock()
+
+	if count == 0 {
+		return nil
+	}
+	if count > 1 && s.backpressure != nil {
+		s.backpressure.coalesced.Add(int64(count - 1))
+		logging.Debug("coalesced task updates", "task_id", s.taskID, "updates", count)
+	}
+
+	prefix := output.Clean("🔄 Update: ")
+	sent, err := s.sendStandardizedMessage(types.StandardizedMessage{ContentType: types.StandardMessageTypeString, Content: prefix + content})
+	if err != nil {
+		return err
+	}
+	s.record(strings.TrimPrefix(sent, prefix), false)
+	return nil
 }
 
-// SendMessageAsJSON sends structured JSON data
+// discardUpdates drops the pending task updates
+func (s *TaskMessageSender) discardUpdates() {
+	s.mu.Lock()
+	count := s.pendingUpdates
+	s.pendingUpdate.Reset()
+	s.pendingUpdates = 0
+	s.mu.Unlock()
+
+	if count > 0 && s.backpressure != nil {
+		s.backpressure.dropped.Add(int64(count))
+		logging.Debug("dropped pending task updates", "task_id", s.taskID, "updates", count)
+	}
+}
+
+// SendMessageAsJSON sends structured JSON data: any value that marshals to
+// JSON, or a string or json.RawMessage already holding JSON
 func (s *TaskMessageSender) SendMessageAsJSON(content interface{}) error {
-	return s.sendStandardizedMessage(types.StandardMessageTypeJSON, content)
+	return s.sendStructured(types.StandardMessageTypeJSON, content)
 }
 
 // SendMessageAsMD sends markdown formatted text
 func (s *TaskMessageSender) SendMessageAsMD(content string) error {
-	return s.sendStandardizedMessage(types.StandardMessageTypeMD, content)
+	return s.sendText(types.StandardMessageTypeMD, content)
 }
 
-// SendMessageAsArray sends array/list data
+// SendMessageAsArray sends array/list data as a JSON array
 func (s *TaskMessageSender) SendMessageAsArray(content []interface{}) error {
-	return s.sendStandardizedMessage(types.StandardMessageTypeArray, content)
+	return s.sendStructured(types.StandardMessageTypeArray, content)
+}
+
+// SendMessageAsTable sends a markdown pipe table
+func (s *TaskMessageSender) SendMessageAsTable(content string) error {
+	return s.sendText(types.StandardMessageTypeTable, content)
+}
+
+// SendMessageAsCSV sends CSV data
+func (s *TaskMessageSender) SendMessageAsCSV(content string) error {
+	return s.sendText(types.StandardMessageTypeCSV, content)
+}
+
+// SendMessageAsImage sends an image; an empty mimeType is detected from the data
+func (s *TaskMessageSender) SendMessageAsImage(data []byte, mimeType, alt string) error {
+	media, err := types.NewImageContent(data, mimeType, alt)
+	if err != nil {
+		return err
+	}
+	return s.sendMedia(types.StandardMessageTypeImage, media)
+}
+
+// SendMessageAsAudio sends audio; an empty mimeType is detected from the data
+func (s *TaskMessageSender) SendMessageAsAudio(data []byte, mimeType, transcript string) error {
+	media, err := types.NewAudioContent(data, mimeType, transcript)
+	if err != nil {
+		return err
+	}
+	return s.sendMedia(types.StandardMessageTypeAudio, media)
+}
+
+// SendMessageAsHTML sends an HTML fragment
+func (s *TaskMessageSender) SendMessageAsHTML(content string) error {
+	if err := types.ValidateHTML(content); err != nil {
+		return err
+	}
+	return s.sendText(types.StandardMessageTypeHTML, content)
+}
+
+// sendMedia sends encoded media. Media is never truncated: content over the
+// task's output limit is rejected. The transcript records the text alternative.
+func (s *TaskMessageSender) sendMedia(msgType string, media *types.MediaContent) error {
+	s.stopTypingKeepalive()
+	if err := s.flushUpdates(); err != nil {
+		return err
+	}
+	if _, err := s.sendStandardizedMessage(types.StandardizedMessage{ContentType: msgType, Content: media}); err != nil {
+		return err
+	}
+
+	alt := media.Alt
+	if alt == "" {
+		alt = "[" + strings.ToLower(msgType) + "]"
+	}
+	s.record(alt, true)
+	return nil
+}
+
+// sendText sends text content of the given type and records it in the transcript
+func (s *TaskMessageSender) sendText(msgType string, content string) error {
+	s.stopTypingKeepalive()
+	if err := s.flushUpdates(); err != nil {
+		return err
+	}
+	sent, err := s.sendStandardizedMessage(types.StandardizedMessage{ContentType: msgType, Content: content})
+	if err != nil {
+		return err
+	}
+	s.record(sent, true)
+	return nil
+}
+
+// sendStructured sends JSON content and records it in the transcript
+func (s *TaskMessageSender) sendStructured(msgType string, content interface{}) error {
+	s.stopTypingKeepalive()
+	if err := s.flushUpdates(); err != nil {
+		return err
+	}
+	sent, err := s.sendStandardizedMessage(types.StandardizedMessage{ContentType: msgType, Content: content})
+	if err != nil {
+		return err
+	}
+	s.record(sent, true)
+	return nil
+}
+
+// SendProgress sends a structured progress update for the current task.
+// Progress messages do not count towards the task's output limits.
+func (s *TaskMessageSender) SendProgress(percent float64, stage string, etaSeconds int) error {
+	if math.IsNaN(percent) {
+		return fmt.Errorf("invalid progress percentage: %v", percent)
+	}
+
+	progress := types.TaskProgress{
+		TaskID:     s.taskID,
+		Percent:    math.Min(math.Max(percent, 0), 100),
+		Stage:      stage,
+		ETASeconds: max(etaSeconds, 0),
+		UpdatedAt:  time.Now(),
+	}
+
+	content, err := json.Marshal(progress)
+	if err != nil {
+		return fmt.Errorf("failed to marshal progress: %w", err)
+	}
+	if err := s.protocolHandler.SendTaskResponseToRoomContext(s.ctx, s.taskID, string(content), types.StandardMessageTypeProgress, true, "", s.room); err != nil {
+		return err
+	}
+
+	if s.onProgress != nil {
+		s.onProgress(progress)
+	}
+	return nil
+}
+
+// ExtendDeadline keeps a long-running task alive for d from now. The deadline
+// never moves past the server's deadline or the maximum task duration.
+func (s *TaskMessageSender) ExtendDeadline(d time.Duration) error {
+	if s.extendDeadline == nil {
+		return ErrDeadlineNotExtendable
+	}
+	if err := s.extendDeadline(d); err != nil {
+		return err
+	}
+	logging.Debug("task deadline extended", "task_id", s.taskID, "by", d)
+	return nil
+}
+
+// SendTyping shows a typing indicator for the current task. The indicator is
+// refreshed until StopTyping is called, a message is sent, the task ends or
+// typingTimeout passes. Status messages do not count towards the task's output limits.
+func (s *TaskMessageSender) SendTyping() error {
+	s.stopTypingKeepalive()
+	if err := s.sendStatus(types.TaskStatusTyping); err != nil {
+		return err
+	}
+
+	stop := make(chan struct{})
+	s.typingMu.Lock()
+	s.typingStop = stop
+	s.typingMu.Unlock()
+
+	go s.typingKeepalive(stop)
+	return nil
+}
+
+// StopTyping hides the typing indicator if it is shown
+func (s *TaskMessageSender) StopTyping() error {
+	if !s.stopTypingKeepalive() {
+		return nil
+	}
+	return s.sendStatus(types.TaskStatusIdle)
+}
+
+// typingKeepalive refreshes the typing indicator until stop is closed or the indicator times out
+func (s *TaskMessageSender) typingKeepalive(stop chan struct{}) {
+	ticker := time.NewTicker(typingKeepalive)
+	defer ticker.Stop()
+	timeout := time.NewTimer(typingTimeout)
+	defer timeout.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if s.congested != nil && s.congested() {
+				continue
+			}
+			if err := s.sendStatus(types.TaskStatusTyping); err != nil {
+				logging.Debug("failed to refresh typing indicator", "task_id", s.taskID, "error", err)
+			}
+		case <-timeout.C:
+			logging.Debug("typing indicator timed out", "task_id", s.taskID)
+			s.typingMu.Lock()
+			if s.typingStop != stop {
+				s.typingMu.Unlock()
+				return
+			}
+			s.typingStop = nil
+			s.typingMu.Unlock()
+			if err := s.sendStatus(types.TaskStatusIdle); err != nil {
+				logging.Debug("failed to hide typing indicator", "task_id", s.taskID, "error", err)
+			}
+			return
+		case <-stop:
+			return
+		case <-s.ctx.Done():
+			return
+		}
+	}
+}
+
+// stopTypingKeepalive ends the typing keepalive loop and reports whether the indicator was shown
+func (s *TaskMessageSender) stopTypingKeepalive() bool {
+	s.typingMu.Lock()
+	defer s.typingMu.Unlock()
+	if s.typingStop == nil {
+		return false
+	}
+	close(s.typingStop)
+	s.typingStop = nil
+	return true
+}
+
+// sendStatus sends a status message for the current task
+func (s *TaskMessageSender) sendStatus(status string) error {
+	taskStatus := types.TaskStatus{TaskID: s.taskID, Status: status}
+	if status == types.TaskStatusTyping {
+		taskStatus.ExpiresIn = int(2 * typingKeepalive / time.Second)
+	}
+	content, err := json.Marshal(taskStatus)
+	if err != nil {
+		return fmt.Errorf("failed to marshal status: %w", err)
+	}
+	return s.protocolHandler.SendTaskResponseToRoomContext(s.ctx, s.taskID, string(content), types.StandardMessageTypeStatus, true, "", s.room)
+}
+
+// sendStandardizedMessage encodes a message of any content type and sends it,
+// returning the content sent. Text over the task's output limit is truncated;
+// structured content is rejected instead, since a cut JSON document cannot be
+// decoded. Content flagged by the guardrail is redacted or rejected.
+func (s *TaskMessageSender) sendStandardizedMessage(message types.StandardizedMessage) (string, error) {
+	content, err := message.Encode()
+	if err != nil {
+		return "", err
+	}
+	if s.guardrail != nil && !types.IsBinaryContentType(message.ContentType) {
+		checked := s.guardrail(content, types.IsStructuredContentType(message.ContentType))
+		if checked.blocked {
+			return "", fmt.Errorf("%w: %s message", types.ErrContentBlocked, strings.ToLower(message.ContentType))
+		}
+		content = checked.content
+	}
+	guarded, err := s.applyGuards(content)
+	if err != nil {
+		return "", err
+	}
+	if guarded != content && types.IsStructuredContentType(message.ContentType) {
+		return "", fmt.Errorf("%w: %s content of %d bytes cannot be truncated", ErrTaskOutputTooLarge, strings.ToLower(message.ContentType), len(content))
+	}
+	if err := s.protocolHandler.SendTaskResponseToRoomContext(s.ctx, s.taskID, guarded, message.ContentType, true, "", s.room); err != nil {
+		return "", err
+	}
+	s.mu.Lock()
+	s.sent = append(s.sent, taskResponse{Content: guarded, ContentType: message.ContentType})
+	s.mu.Unlock()
+	return guarded, nil
+}
+
+// applyGuards enforces the per-task message and output limits and the task's
+// budget before a message is sent
+func (s *TaskMessageSender) applyGuards(text string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.limitReached {
+		return "", ErrTaskMessageLimit
+	}
+
+	guarded := text
+	if s.guards != nil {
+		if s.guards.MaxMessagesPerTask > 0 && s.messagesSent >= s.guards.MaxMessagesPerTask {
+			s.limitReached = true
+			return "", fmt.Errorf("%w: %d messages", ErrTaskMessageLimit, s.guards.MaxMessagesPerTask)
+		}
+
+		var err error
+		guarded, err = s.guards.checkOutput(text, s.bytesSent)
+		if err != nil {
+			s.limitReached = true
+			return "", err
+		}
+	}
+	if budget := types.BudgetFromContext(s.ctx); budget != nil {
+		if err := budget.UseMessage(); err != nil {
+			return "", err
+		}
+	}
+	if guarded != text {
+		// Output was truncated, nothing more may be sent for this task
+		s.limitReached = true
+	}
+
+	s.messagesSent++
+	s.bytesSent += len(guarded)
+	return guarded, nil
+}
+
+// record appends sent text to the task transcript.
+// Streamed updates are joined directly; whole messages are separated by a newline.
+func (s *TaskMessageSender) record(content string, separate bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if separate && s.transcript.Len() > 0 {
+		s.transcript.WriteString("\n")
+	}
+	s.transcript.WriteString(content)
+}
+
+// sentText returns the text sent for this task
+func (s *TaskMessageSender) sentText() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.transcript.String()
 }
 
-// sendStandardizedMessage sends a message in standardized format
-func (s *TaskMessageSender) sendStandardizedMessage(msgType string, content interface{}) error {
-	return s.protocolHandler.SendTaskResponseToRoom(s.taskID, content.(string), msgType, true, "", s.room)
+// sentMessages returns the messages sent for this task with their content types
+func (s *TaskMessageSender) sentMessages() []taskResponse {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.sent)
 }
 
 // NewTaskCoordinator creates a new task coordinator
 func NewTaskCoordinator(agentHandler types.AgentHandler, protocolHandler *ProtocolHandler, capabilities []string) *TaskCoordinator {
 	coordinator := &TaskCoordinator{
-		agentHandler:      agentHandler,
-		protocolHandler:   protocolHandler,
-		activeTasks:       make(map[string]*TaskExecution),
-		capabilities:      capabilities,
-		rateLimitPerMin:   0, // Will be set by SetRateLimit
-		requestTimestamps: make([]time.Time, 0),
+		agentHandler:    agentHandler,
+		protocolHandler: protocolHandler,
+		activeTasks:     make(map[string]*TaskExecution),
+		capabilities:    capabilities,
+		rateLimiter:     ratelimit.New(ratelimit.Config{}), // Unlimited until SetRateLimits
+		progress:        make(map[string]types.TaskProgress),
 	}
 
 	// Register task handler
-	protocolHandler.client.RegisterHandler("task", coordinator.HandleIncomingTask)
+	protocolHandler.client.RegisterHandler("task", protocolHandler.VerifyTasks(coordinator.HandleIncomingTask))
 	protocolHandler.client.RegisterHandler("message", coordinator.HandleUserMessage)
 
 	return coordinator
 }
 
-// SetRateLimit sets the rate limit for task processing (tasks per minute)
-// Set to 0 for unlimited
+// SetRateLimit sets the global rate limit for task processing (tasks per
+// minute), keeping the configured burst. Set to 0 for unlimited.
 func (t *TaskCoordinator) SetRateLimit(tasksPerMinute int) {
+	limits := t.rateLimiter.Config()
+	limits.Global.PerMinute = tasksPerMinute
+	t.SetRateLimits(limits)
+}
+
+// GetRateLimit returns the global rate limit in tasks per minute (0 = unlimited)
+func (t *TaskCoordinator) GetRateLimit() int {
+	return t.rateLimiter.Config().Global.PerMinute
+}
+
+// SetRateLimits sets the global, per-room and per-sender rate limits. Each is
+// a token bucket refilled at its per-minute rate that allows up to its burst
+// at once; a task must pass all of them.
+func (t *TaskCoordinator) SetRateLimits(limits ratelimit.Config) {
+	t.rateLimiter.SetConfig(limits)
+	logging.Info("rate limits set",
+		"tasks_per_minute", limits.Global.PerMinute, "burst", limits.Global.Burst,
+		"room_tasks_per_minute", limits.PerRoom.PerMinute, "room_burst", limits.PerRoom.Burst,
+		"sender_tasks_per_minute", limits.PerSender.PerMinute, "sender_burst", limits.PerSender.Burst)
+}
+
+// GetRateLimits returns the global, per-room and per-sender rate limits
+func (t *TaskCoordinator) GetRateLimits() ratelimit.Config {
+	return t.rateLimiter.Config()
+}
+
+// checkRateLimit takes a token for the task from the global, room and sender
+// buckets and answers rejected tasks with when to retry. The sender is the
+// one carried by ctx, so each requester behind the coordinator has its own
+// bucket. Returns true if the task can be processed.
+func (t *TaskCoordinator) checkRateLimit(ctx context.Context, msg *types.Message, taskID string) bool {
+	sender := SenderFromContext(ctx)
+	decision := t.rateLimiter.Allow(msg.Room, sender)
+	if decision.Allowed {
+		return true
+	}
+
+	retryAfter := int(math.Ceil(decision.RetryAfter.Seconds()))
+	logging.Warn("rate limit exceeded, rejecting task", "task_id", taskID, "scope", decision.Scope, "sender", sender, "room", msg.Room, "retry_after_seconds", retryAfter)
+	t.recordRejection("rate_limit_exceeded")
+	t.protocolHandler.SendTaskRejection(ctx, taskID, output.Clean(rateLimitMessage(decision.Scope, retryAfter)), "rate_limit_exceeded", msg.Room, map[string]interface{}{
+		"retry_after":      retryAfter,
+		"rate_limit_scope": string(decision.Scope),
+	})
+	return false
+}
+
+// checkBandwidth rejects tasks from a room that exchanged more bytes than its
+// bandwidth ceiling allows, telling the requester when to retry.
+// Returns true if the task can be processed.
+func (t *TaskCoordinator) checkBandwidth(ctx context.Context, msg *types.Message, taskID string) bool {
+	allowed, wait := t.protocolHandler.client.Bandwidth().Allow(msg.Room)
+	if allowed {
+		return true
+	}
+
+	retryAfter := int(math.Ceil(wait.Seconds()))
+	logging.Warn("room bandwidth ceiling exceeded, rejecting task", "task_id", taskID, "from", msg.From, "room", msg.Room, "retry_after_seconds", retryAfter)
+	t.recordRejection("bandwidth_exceeded")
+	content := fmt.Sprintf("⚠️ This room has exceeded its bandwidth allowance. Please try again in %d seconds.", retryAfter)
+	t.protocolHandler.SendTaskRejection(ctx, taskID, output.Clean(content), "bandwidth_exceeded", msg.Room, map[string]interface{}{
+		"retry_after": retryAfter,
+	})
+	return false
+}
+
+// rateLimitMessage tells the requester which limit was hit and when to retry
+func rateLimitMessage(scope ratelimit.Scope, retryAfter int) string {
+	wait := fmt.Sprintf("%d seconds", retryAfter)
+	if retryAfter == 1 {
+		wait = "1 second"
+	}
+	switch scope {
+	case ratelimit.ScopeSender:
+		return fmt.Sprintf("⚠️ You are sending requests too quickly. Please try again in %s.", wait)
+	case ratelimit.ScopeRoom:
+		return fmt.Sprintf("⚠️ Rate limit exceeded for this room. Please try again in %s.", wait)
+	}
+	return fmt.Sprintf("⚠️ Agent rate limit exceeded. This agent has reached its maximum request capacity. Please try again in %s.", wait)
+}
+
+// SetQuotaChecker sets the per-consumer quota checker (nil disables quota checks)
+func (t *TaskCoordinator) SetQuotaChecker(checker types.QuotaChecker) {
+	t.rateLimitMu.Lock()
+	defer t.rateLimitMu.Unlock()
+	t.quotaChecker = checker
+}
+
+// SetConversationMemory sets the store used to keep per-room conversation history (nil disables memory)
+func (t *TaskCoordinator) SetConversationMemory(mem types.ConversationMemory) {
+	t.rateLimitMu.Lock()
+	defer t.rateLimitMu.Unlock()
+	t.memory = mem
+}
+
+// SetTaskGuards sets the input/output limits applied to every task (nil disables guards)
+func (t *TaskCoordinator) SetTaskGuards(guards *TaskGuards) {
+	t.rateLimitMu.Lock()
+	defer t.rateLimitMu.Unlock()
+	t.guards = guards
+}
+
+// SetMetricsRecorder sets the recorder receiving task metrics (nil disables metrics)
+func (t *TaskCoordinator) SetMetricsRecorder(recorder types.MetricsRecorder) {
+	t.rateLimitMu.Lock()
+	defer t.rateLimitMu.Unlock()
+	t.metrics = recorder
+}
+
+// SetEventBus sets the bus task events are published to (nil = none)
+func (t *TaskCoordinator) SetEventBus(bus *events.Bus) {
+	t.rateLimitMu.Lock()
+	defer t.rateLimitMu.Unlock()
+	t.eventBus = bus
+}
+
+// getEventBus returns the event bus, nil if none is set
+func (t *TaskCoordinator) getEventBus() *events.Bus {
+	t.rateLimitMu.Lock()
+	defer t.rateLimitMu.Unlock()
+	return t.eventBus
+}
+
+// SetResponseReviewer sets the reviewer that can hold responses before they are sent (nil disables review)
+func (t *TaskCoordinator) SetResponseReviewer(reviewer types.ResponseReviewer) {
+	t.rateLimitMu.Lock()
+	defer t.rateLimitMu.Unlock()
+	t.reviewer = reviewer
+}
+
+// getResponseReviewer returns the configured response reviewer
+func (t *TaskCoordinator) getResponseReviewer() types.ResponseReviewer {
+	t.rateLimitMu.Lock()
+	defer t.rateLimitMu.Unlock()
+	return t.reviewer
+}
+
+// SetTaskRetryPolicy sets the policy for retrying failed handler calls (nil disables retries).
+// Only non-streaming handlers are retried, since a streaming handler may already have sent output.
+func (t *TaskCoordinator) SetTaskRetryPolicy(policy *RetryPolicy) {
+	t.rateLimitMu.Lock()
+	defer t.rateLimitMu.Unlock()
+	t.retryPolicy = policy
+}
+
+// getTaskRetryPolicy returns the configured task retry policy
+func (t *TaskCoordinator) getTaskRetryPolicy() *RetryPolicy {
+	t.rateLimitMu.Lock()
+	defer t.rateLimitMu.Unlock()
+	return t.retryPolicy
+}
+
+// SetInputScrubber sets a function applied to task content before it reaches the handler,
+// and to the exchange before it is stored, e.g. to redact personal data (nil disables scrubbing)
+func (t *TaskCoordinator) SetInputScrubber(scrub func(string) string) {
+	t.rateLimitMu.Lock()
+	defer t.rateLimitMu.Unlock()
+	t.scrubInput = scrub
+}
+
+// getInputScrubber returns the configured input scrubber
+func (t *TaskCoordinator) getInputScrubber() func(string) string {
+	t.rateLimitMu.Lock()
+	defer t.rateLimitMu.Unlock()
+	return t.scrubInput
+}
+
+// SetTaskDeduplicator sets the deduplicator that keeps redelivered tasks from being executed twice (nil disables deduplication)
+func (t *TaskCoordinator) SetTaskDeduplicator(dedup *TaskDeduplicator) {
+	t.rateLimitMu.Lock()
+	defer t.rateLimitMu.Unlock()
+	t.dedup = dedup
+}
+
+// getTaskDeduplicator returns the configured task deduplicator
+func (t *TaskCoordinator) getTaskDeduplicator() *TaskDeduplicator {
+	t.rateLimitMu.Lock()
+	defer t.rateLimitMu.Unlock()
+	return t.dedup
+}
+
+// getMetricsRecorder returns the configured metrics recorder
+func (t *TaskCoordinator) getMetricsRecorder() types.MetricsRecorder {
 	t.rateLimitMu.Lock()
 	defer t.rateLimitMu.Unlock()
-	t.rateLimitPerMin = tasksPerMinute
-	log.Printf("⚙️ Rate limit set to: %d tasks/minute", tasksPerMinute)
+	return t.metrics
 }
 
-// checkRateLimit checks if the rate limit allows processing a new task
-// Returns true if task can be processed, false if rate limit exceeded
-func (t *TaskCoordinator) checkRateLimit() bool {
+// recordRejection records a task rejected before execution
+func (t *TaskCoordinator) recordRejection(reason string) {
+	if recorder := t.getMetricsRecorder(); recorder != nil {
+		recorder.RecordTaskRejected(reason)
+	}
+}
+
+// getTaskGuards returns the configured task guards
+func (t *TaskCoordinator) getTaskGuards() *TaskGuards {
 	t.rateLimitMu.Lock()
 	defer t.rateLimitMu.Unlock()
+	return t.guards
+}
 
-	// No rate limit (0 = unlimited)
-	if t.rateLimitPerMin == 0 {
+// getConversationMemory returns the configured conversation memory
+func (t *TaskCoordinator) getConversationMemory() types.ConversationMemory {
+	t.rateLimitMu.Lock()
+	defer t.rateLimitMu.Unlock()
+	return t.memory
+}
+
+// checkQuota checks the consumer quota and sends a rejection when it is exhausted
+// Returns true if task can be processed
+func (t *TaskCoordinator) checkQuota(ctx context.Context, msg *types.Message, taskID string) bool {
+	t.rateLimitMu.Lock()
+	checker := t.quotaChecker
+	t.rateLimitMu.Unlock()
+
+	if checker == nil {
 		return true
 	}
 
-	now := time.Now()
-	oneMinuteAgo := now.Add(-1 * time.Minute)
+	consumerID := SenderFromContext(ctx)
+	if consumerID == "" {
+		return true
+	}
 
-	// Remove timestamps older than 1 minute
-	validTimestamps := make([]time.Time, 0)
-	for _, ts := range t.requestTimestamps {
-		if ts.After(oneMinuteAgo) {
-			validTimestamps = append(validTimestamps, ts)
-		}
+	err := checker.CheckQuota(ctx, consumerID)
+	if err == nil {
+		return true
 	}
-	t.requestTimestamps = validTimestamps
 
-	// Check if we've exceeded the limit
-	if len(t.requestTimestamps) >= t.rateLimitPerMin {
-		return false
+	content := output.Clean("⚠️ Your request quota for this agent has been used up. Please try again later or upgrade your plan.")
+	errorCode := "quota_exceeded"
+	if errors.Is(err, types.ErrConsumerBlocked) {
+		content = output.Clean("⚠️ Your access to this agent has been suspended.")
+		errorCode = "consumer_blocked"
 	}
 
-	// Add current timestamp
-	t.requestTimestamps = append(t.requestTimestamps, now)
-	return true
+	logging.Warn("quota check rejected task", "task_id", taskID, "consumer_id", consumerID, "error", err)
+	t.recordRejection(errorCode)
+	t.protocolHandler.SendTaskResponseToRoomContext(ctx, taskID, content, types.StandardMessageTypeString, false, errorCode, msg.Room)
+	return false
 }
 
 // HandleIncomingTask handles incoming tasks from the coordinator
 func (t *TaskCoordinator) HandleIncomingTask(msg *types.Message) error {
-	log.Printf("📋 Received task from %s: %s", msg.From, msg.Content)
+	logging.Info("received task", "from", msg.From, "content", msg.Content)
 
 	// Prevent feedback loops
 	if t.isResponseMessage(msg.Content) {
-		log.Printf("⚠️ Ignoring response message to prevent feedback loop")
+		logging.Warn("ignoring response message to prevent feedback loop")
 		return nil
 	}
 
 	// Only handle tasks from coordinator
 	if msg.From != "coordinator" {
-		log.Printf("⚠️ Ignoring task from non-coordinator: %s", msg.From)
+		logging.Warn("ignoring task from non-coordinator", "from", msg.From)
 		return nil
 	}
 
-	// Extract task ID
+	t.handleTask(context.Background(), msg, false)
+	return nil
+}
+
+// RunTask runs a task received outside the network connection, e.g. from a
+// message queue, through the same checks and execution as network tasks and
+// returns once it is done. Every response, including rejections, is passed to
+// respond instead of being sent over the connection. It returns the task
+// status: success, error, rejected, or the reason the task was not run
+// (duplicate_task, task_too_large, invalid_encoding, unsupported_content_type,
+// access_denied, access_unverified, unsupported_capability, invalid_input,
+// rate_limit_exceeded, quota_exceeded, payment_required, payment_invalid,
+// payment_unverified).
+func (t *TaskCoordinator) RunTask(ctx context.Context, msg *types.Message, respond func(context.Context, *types.Message) error) string {
+	return t.handleTask(withResponder(ctx, respond), msg, true)
+}
+
+// Replay runs the tasks of a recorded session one after the other, in the
+// recorded order, through the same checks and execution as RunTask. The
+// responses are written to out as outbound entries, for comparing them with
+// the recorded ones (nil = discarded). Tasks whose IDs the agent already
+// handled are skipped as duplicates, so replay into an agent without a
+// persistent task store. It returns the number of tasks replayed.
+func (t *TaskCoordinator) Replay(ctx context.Context, entries []recording.Entry, options recording.ReplayOptions, out *recording.Recorder) (int, error) {
+	options.Types = []string{types.MessageTypeTask}
+	return recording.Replay(ctx, entries, options, func(ctx context.Context, msg *types.Message) error {
+		status := t.RunTask(ctx, msg, func(_ context.Context, response *types.Message) error {
+			if out == nil {
+				return nil
+			}
+			return out.RecordMessage(recording.Outbound, response)
+		})
+		logging.Info("replayed task", "task_id", t.extractTaskID(msg), "room", msg.Room, "status", status)
+		return nil
+	})
+}
+
+// handleTask checks and executes a task as part of the trace carried by parent,
+// in the background unless wait is set, and returns its status
+func (t *TaskCoordinator) handleTask(parent context.Context, msg *types.Message, wait bool) string {
+	// Extract task ID. Only tasks carrying their own ID can be deduplicated.
+	dedup := t.getTaskDeduplicator()
 	taskID := t.extractTaskID(msg)
 	if taskID == "" {
 		taskID = fmt.Sprintf("task-%d", time.Now().Unix())
+		dedup = nil
 	}
 
-	// Check rate limit
-	if !t.checkRateLimit() {
-		log.Printf("⚠️ Rate limit exceeded, rejecting task %s", taskID)
-		t.protocolHandler.SendTaskResponseToRoom(
+	received := time.Now()
+	ctx, span := t.startReceiveSpan(parent, msg, taskID)
+	defer span.End()
+
+	// Decrypt encrypted content first, so a task that cannot be decrypted is
+	// not recorded as received
+	ctx, code := t.decryptTask(ctx, msg, taskID)
+	if code != "" {
+		span.SetAttributes(tracing.AttrTaskStatus.String(code))
+		return code
+	}
+
+	// Skip tasks that were already received, e.g. redelivered after a reconnect
+	started := false
+	if dedup != nil {
+		execute, record := dedup.claim(ctx, taskID)
+		if !execute {
+			span.SetAttributes(tracing.AttrTaskStatus.String("duplicate_task"))
+			t.handleDuplicateTask(ctx, taskID, record, msg.Room)
+			return "duplicate_task"
+		}
+		// A task rejected below is forgotten so a redelivery is checked again
+		defer func() {
+			if !started {
+				dedup.release(context.WithoutCancel(ctx), taskID)
+			}
+		}()
+	}
+
+	// Check the size, encoding and content type before anything reads the content
+	if code := t.checkTaskPayload(ctx, msg, taskID); code != "" {
+		span.SetAttributes(tracing.AttrTaskStatus.String(code))
+		return code
+	}
+
+	// Check the sender and room may use the agent. The coordinator relays the
+	// requester in the task data.
+	ctx = WithSender(ctx, t.extractConsumerID(msg))
+	if code := t.checkAccess(ctx, msg, taskID); code != "" {
+		span.SetAttributes(tracing.AttrTaskStatus.String(code))
+		return code
+	}
+
+	// Check the capabilities the task requires
+	if missing := t.missingCapabilities(msg); len(missing) > 0 {
+		logging.Warn("task requires unsupported capabilities, rejecting task", "task_id", taskID, "required", missing)
+		t.recordRejection("unsupported_capability")
+		span.SetAttributes(tracing.AttrTaskStatus.String("unsupported_capability"))
+		t.protocolHandler.SendTaskResponseToRoomContext(
+			ctx,
 			taskID,
-			"⚠️ Agent rate limit exceeded. This agent has reached its maximum request capacity. Please try again in a moment.",
+			output.Clean(fmt.Sprintf("⚠️ This agent does not support the required capabilities: %s", strings.Join(missing, ", "))),
 			types.StandardMessageTypeString,
 			false,
-			"rate_limit_exceeded",
+			"unsupported_capability",
 			msg.Room,
 		)
-		return nil
+		return "unsupported_capability"
+	}
+
+	// Check the input against the schema of the task's capability
+	if !t.checkInputSchema(ctx, msg, taskID) {
+		span.SetAttributes(tracing.AttrTaskStatus.String("invalid_input"))
+		return "invalid_input"
+	}
+
+	// Check rate limit
+	if !t.checkRateLimit(ctx, msg, taskID) {
+		span.SetAttributes(tracing.AttrTaskStatus.String("rate_limit_exceeded"))
+		return "rate_limit_exceeded"
+	}
+
+	// Check the room's bandwidth ceiling
+	if !t.checkBandwidth(ctx, msg, taskID) {
+		span.SetAttributes(tracing.AttrTaskStatus.String("bandwidth_exceeded"))
+		return "bandwidth_exceeded"
+	}
+
+	// Check consumer quota
+	if !t.checkQuota(ctx, msg, taskID) {
+		return "quota_exceeded"
+	}
+
+	// Check the task was paid for
+	if code := t.checkPayment(ctx, msg, taskID); code != "" {
+		span.SetAttributes(tracing.AttrTaskStatus.String(code))
+		return code
+	}
+
+	// Check the deadline set by the server
+	deadline := t.extractDeadline(msg, received)
+	if !t.checkDeadline(ctx, msg, taskID, deadline) {
+		span.SetAttributes(tracing.AttrTaskStatus.String("deadline_exceeded"))
+		return "deadline_exceeded"
+	}
+
+	ctx = types.WithTaskInfo(ctx, types.TaskInfo{Capabilities: t.extractRequiredCapabilities(msg), Deadline: deadline})
+	run := func(ctx context.Context) string {
+		_, sent, status := t.executeTask(ctx, taskID, msg.Content, msg.Room)
+		if dedup == nil || status == "preempted" {
+			return status
+		}
+		if status == "success" {
+			// The answer to an encrypted task is not kept in the shared cache
+			// in plaintext, so its duplicates are not answered again
+			if replyKeyFromContext(ctx) != nil {
+				sent = nil
+			}
+			dedup.complete(context.WithoutCancel(ctx), taskID, sent)
+		} else {
+			dedup.release(context.WithoutCancel(ctx), taskID)
+		}
+		return status
+	}
+
+	// Queue the task by priority if the agent has a scheduler
+	if sched := t.GetTaskScheduler(); sched != nil {
+		status := t.scheduleTask(ctx, sched, msg, taskID, run, wait)
+		started = status != "queue_full" && status != "agent_stopping"
+		return status
+	}
+
+	started = true
+	if wait {
+		return run(ctx)
 	}
 
 	// Execute task in goroutine
-	go t.ExecuteTask(taskID, msg.Content, msg.Room)
+	go run(ctx)
+	return "started"
+}
 
-	return nil
+// handleDuplicateTask answers a task that was already received: the recorded
+// responses of a completed task are sent again with their original content
+// types, a task still running is ignored
+func (t *TaskCoordinator) handleDuplicateTask(ctx context.Context, taskID string, record *taskRecord, room string) {
+	t.recordRejection("duplicate_task")
+
+	responses := record.Responses
+	if record.State != taskStateCompleted || len(responses) == 0 {
+		logging.Info("ignoring duplicate task", "task_id", taskID, "state", record.State)
+		return
+	}
+
+	logging.Info("replaying response to duplicate task", "task_id", taskID, "messages", len(responses))
+	for _, response := range responses {
+		if err := t.protocolHandler.SendTaskResponseToRoomContext(ctx, taskID, response.Content, response.ContentType, true, "", room); err != nil {
+			logging.Error("failed to replay task response", "task_id", taskID, "error", err)
+			return
+		}
+	}
 }
 
 // HandleUserMessage handles direct user messages
@@ -179,42 +1028,209 @@ func (t *TaskCoordinator) HandleUserMessage(msg *types.Message) error {
 		return nil
 	}
 
-	log.Printf("💬 Received user message from %s: %s", msg.From, msg.Content)
+	logging.Info("received user message", "from", msg.From, "content", msg.Content)
 
 	// Treat user messages as tasks
 	taskID := fmt.Sprintf("user-msg-%d", time.Now().Unix())
 
+	ctx, span := t.startReceiveSpan(context.Background(), msg, taskID)
+	defer span.End()
+
+	// Decrypt encrypted content; the sender signs the plaintext
+	ctx, code := t.decryptTask(ctx, msg, taskID)
+	if code != "" {
+		span.SetAttributes(tracing.AttrTaskStatus.String(code))
+		return nil
+	}
+
+	// Check the size, encoding and content type before anything reads the content
+	if code := t.checkTaskPayload(ctx, msg, taskID); code != "" {
+		span.SetAttributes(tracing.AttrTaskStatus.String(code))
+		return nil
+	}
+
+	// Check the message was signed by its sender
+	verified, code := t.checkUserSignature(ctx, msg, taskID)
+	if code != "" {
+		span.SetAttributes(tracing.AttrTaskStatus.String(code))
+		return nil
+	}
+
+	// Check the sender and room may use the agent. The signature vouches for
+	// the sender, not for addresses in the message data.
+	ctx = WithSender(ctx, msg.From)
+	if code := t.checkAccess(ctx, msg, taskID); code != "" {
+		span.SetAttributes(tracing.AttrTaskStatus.String(code))
+		return nil
+	}
+
 	// Check rate limit
-	if !t.checkRateLimit() {
-		log.Printf("⚠️ Rate limit exceeded, rejecting message from %s", msg.From)
-		t.protocolHandler.SendTaskResponseToRoom(
-			taskID,
-			"⚠️ Agent rate limit exceeded. This agent has reached its maximum request capacity. Please try again in a moment.",
-			types.StandardMessageTypeString,
-			false,
-			"rate_limit_exceeded",
-			msg.Room,
-		)
+	if !t.checkRateLimit(ctx, msg, taskID) {
+		span.SetAttributes(tracing.AttrTaskStatus.String("rate_limit_exceeded"))
+		return nil
+	}
+
+	// Check the room's bandwidth ceiling
+	if !t.checkBandwidth(ctx, msg, taskID) {
+		span.SetAttributes(tracing.AttrTaskStatus.String("bandwidth_exceeded"))
+		return nil
+	}
+
+	// Check consumer quota
+	if !t.checkQuota(ctx, msg, taskID) {
+		return nil
+	}
+
+	// Check the message was paid for
+	if code := t.checkPayment(ctx, msg, taskID); code != "" {
+		span.SetAttributes(tracing.AttrTaskStatus.String(code))
 		return nil
 	}
 
-	go t.ExecuteTask(taskID, msg.Content, msg.Room)
+	ctx = types.WithTaskInfo(ctx, types.TaskInfo{Capabilities: t.extractRequiredCapabilities(msg), SenderVerified: verified})
+	go t.executeTask(ctx, taskID, msg.Content, msg.Room)
 
 	return nil
 }
 
 // ExecuteTask executes a task using the agent handler
 func (t *TaskCoordinator) ExecuteTask(taskID, content, room string) {
-	// Create context with timeout
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
+	t.executeTask(withSequence(context.Background()), taskID, content, room)
+}
+
+// startReceiveSpan starts the span for a received task, continuing the trace
+// propagated in the message envelope. The task's responses sent with the
+// returned context are numbered in order.
+func (t *TaskCoordinator) startReceiveSpan(parent context.Context, msg *types.Message, taskID string) (context.Context, trace.Span) {
+	return tracing.Start(withSequence(tracing.Extract(parent, msg)), tracing.SpanTaskReceive,
+		tracing.AttrTaskID.String(taskID),
+		tracing.AttrFrom.String(msg.From),
+		tracing.AttrRoom.String(msg.Room),
+	)
+}
+
+// executeTask executes a task as part of the trace carried by parent.
+// It returns the text sent to the room, the messages sent with their content
+// types and the task status (success, error, rejected or preempted).
+func (t *TaskCoordinator) executeTask(parent context.Context, taskID, content, room string) (reply string, sent []taskResponse, status string) {
+	startTime := time.Now()
+	status = "success"
+	var spanErr error // The handler's error, if it failed
+	if recorder := t.getMetricsRecorder(); recorder != nil {
+		defer func() {
+			recorder.ObserveTask(status, time.Since(startTime))
+		}()
+	}
+	defer func() {
+		if status == "success" {
+			t.durations.observe(time.Since(startTime))
+		}
+	}()
+
+	if bus := t.getEventBus(); bus != nil {
+		bus.Publish(events.TaskStarted{TaskID: taskID, Room: room, Sender: SenderFromContext(parent)})
+		defer func() {
+			bus.Publish(events.TaskFinished{TaskID: taskID, Room: room, Status: status, Duration: time.Since(startTime), Err: spanErr})
+		}()
+	}
+
+	spanCtx, span := tracing.Start(parent, tracing.SpanTaskExecute,
+		tracing.AttrTaskID.String(taskID),
+		tracing.AttrRoom.String(room),
+	)
+	defer func() {
+		span.SetAttributes(tracing.AttrTaskStatus.String(status))
+		tracing.End(span, spanErr)
+	}()
+
+	// Scrub the input before it reaches the handler, memory or third-party services
+	scrub := t.getInputScrubber()
+	if scrub != nil {
+		content = scrub(content)
+	}
+
+	// Apply input guard before doing any work
+	guards := t.getTaskGuards()
+	content, err := guards.checkInput(content)
+	if err != nil {
+		logging.Warn("rejecting task", "task_id", taskID, "error", err)
+		status = "rejected"
+		t.protocolHandler.SendTaskResponseToRoomContext(spanCtx, taskID, output.Clean(fmt.Sprintf("⚠️ Request too large. Please keep requests under %d characters.", guards.MaxInputChars)), types.StandardMessageTypeString, false, "input_too_large", room)
+		return
+	}
+
+	// Check the input against the guardrail before the handler sees it
+	guardrail := t.getGuardrail()
+	inputCheck := t.checkGuardrail(spanCtx, guardrail, guardrailInput, content, false)
+	if inputCheck.blocked {
+		logging.Warn("rejecting task blocked by guardrail", "task_id", taskID)
+		status = "rejected"
+		t.recordRejection("content_blocked")
+		t.protocolHandler.SendTaskResponseToRoomContext(spanCtx, taskID, output.Clean("⚠️ This request was blocked by the agent's content policy."), types.StandardMessageTypeString, false, "content_blocked", room)
+		return
+	}
+	content = inputCheck.content
+
+	// Run until the server's deadline, or for the capability's timeout without
+	// one. The handler may extend the deadline up to the server's deadline or
+	// the maximum task duration.
+	info, _ := types.TaskInfoFromContext(spanCtx)
+	deadline, limit := info.Deadline, info.Deadline
+	if deadline.IsZero() {
+		timeout, maxDuration := t.taskTimeout(info.Capabilities)
+		deadline = startTime.Add(timeout)
+		if maxDuration > 0 {
+			limit = startTime.Add(maxDuration)
+		}
+	} else {
+		defer func() {
+			if status == "preempted" {
+				return
+			}
+			if time.Now().After(info.Deadline) {
+				t.recordDeadline("missed")
+			} else {
+				t.recordDeadline("met")
+			}
+		}()
+	}
+
+	// The task's budget caps its wall time, extensions included
+	budget := t.newBudget()
+	if budget != nil {
+		if end, ok := budget.Deadline(); ok {
+			deadline = earlier(deadline, end)
+			limit = earlier(limit, end)
+		}
+	}
+	ctx, extendDeadline, cancel := withExtendableDeadline(spanCtx, deadline, limit)
+	defer cancel(nil)
+	info.ID = taskID
+	info.Room = room
+	info.Sender = SenderFromContext(ctx)
+	info.Offered = t.Capabilities()
+	info.Input = content
+	info.StartTime = startTime
+	ctx = types.WithTaskInfo(ctx, info)
+	if budget != nil {
+		ctx = types.WithBudget(ctx, budget)
+	}
+
+	// Collect the LLM usage of the handler and account for it when the task ends
+	llmUsage := &types.LLMUsageTracker{}
+	ctx = types.WithLLMUsageTracker(ctx, llmUsage)
+	defer func() {
+		t.recordLLMUsage(room, llmUsage.Usage())
+	}()
 
 	// Track active task
 	execution := &TaskExecution{
-		ID:        taskID,
-		StartTime: time.Now(),
-		Cancel:    cancel,
-		Context:   ctx,
+		ID:           taskID,
+		StartTime:    startTime,
+		Cancel:       func() { cancel(taskctx.ErrCancelled) },
+		Context:      ctx,
+		Capabilities: info.Capabilities,
+		cancel:       cancel,
 	}
 
 	t.activeTasksMu.Lock()
@@ -225,59 +1241,240 @@ func (t *TaskCoordinator) ExecuteTask(taskID, content, room string) {
 	defer func() {
 		t.activeTasksMu.Lock()
 		delete(t.activeTasks, taskID)
+		delete(t.progress, taskID)
 		t.activeTasksMu.Unlock()
 	}()
 
-	log.Printf("🔄 Executing task %s: %s", taskID, content)
+	// Tell the coordinator the task is alive until it ends
+	stopHeartbeat := t.startHeartbeat(ctx, taskID, room, startTime)
+	defer stopHeartbeat()
+
+	// The content of an end-to-end encrypted task stays out of the logs
+	if replyKeyFromContext(ctx) != nil {
+		logging.Info("executing encrypted task", "task_id", taskID, "content_bytes", len(content))
+	} else {
+		logging.Info("executing task", "task_id", taskID, "content", content)
+	}
+	if !info.Deadline.IsZero() {
+		t.reportDeadlineAtRisk(ctx, taskID, room, info.Deadline)
+	}
+
+	// Load the room's conversation history and attach it to the task context
+	mem := t.getConversationMemory()
+	var history []types.ConversationMessage
+	if mem != nil {
+		history, err = mem.History(ctx, room)
+		if err != nil {
+			logging.Warn("failed to load conversation history", "room", room, "error", err)
+		}
+		ctx = memory.WithConversation(ctx, room, history)
+	}
 
 	// Check if agent supports streaming task handling
 	if streamingHandler, ok := t.agentHandler.(types.StreamingTaskHandler); ok {
-		log.Printf("📡 Using streaming task handler for task %s", taskID)
+		logging.Info("using streaming task handler", "task_id", taskID)
+
+		handlerCtx, handlerSpan := tracing.Start(ctx, tracing.SpanHandlerProcess, tracing.AttrHandlerType.String("streaming"))
 
 		// Create message sender for this task
 		messageSender := &TaskMessageSender{
+			ctx:             handlerCtx,
 			taskID:          taskID,
 			protocolHandler: t.protocolHandler,
 			room:            room,
+			guards:          guards,
+			onProgress:      t.setTaskProgress,
+			congested:       t.protocolHandler.client.IsCongested,
+			backpressure:    &t.backpressure,
+			extendDeadline:  extendDeadline,
+		}
+		if guardrail != nil {
+			messageSender.guardrail = func(content string, structured bool) guardrailCheck {
+				return t.checkGuardrail(handlerCtx, guardrail, guardrailOutput, content, structured)
+			}
 		}
 
 		// Process the task with streaming capability
-		if err := streamingHandler.ProcessTaskWithStreaming(ctx, content, room, messageSender); err != nil {
-			log.Printf("❌ Streaming task %s failed: %v", taskID, err)
-			t.protocolHandler.SendTaskResponseToRoom(taskID, fmt.Sprintf("❌ Error: %v", err), types.StandardMessageTypeString, false, err.Error(), room)
+		err := t.callHandler(handlerCtx, taskID, "streaming", func() error {
+			return streamingHandler.ProcessTaskWithStreaming(handlerCtx, content, room, messageSender)
+		})
+		if stopErr := messageSender.StopTyping(); stopErr != nil {
+			logging.Debug("failed to hide typing indicator", "task_id", taskID, "error", stopErr)
+		}
+		if err == nil {
+			// Updates held back by backpressure are part of the result
+			err = messageSender.flushUpdates()
+		} else {
+			messageSender.discardUpdates()
+		}
+		err = budgetError(ctx, budget, err)
+		tracing.End(handlerSpan, err)
+		switch {
+		case err == nil:
+			logging.Info("streaming task completed successfully", "task_id", taskID)
+		case errors.Is(err, ErrTaskMessageLimit) || (errors.Is(err, ErrTaskOutputTooLarge) && guards.OutputPolicy != GuardPolicyReject):
+			// Output was cut by a guard, the room already received what fit within the limits
+			logging.Warn("streaming task stopped by output guard", "task_id", taskID, "error", err)
+		case errors.Is(err, ErrTaskOutputTooLarge):
+			logging.Warn("streaming task rejected by output guard", "task_id", taskID, "error", err)
+			status = "rejected"
+			t.protocolHandler.SendTaskResponseToRoomContext(ctx, taskID, output.Clean("⚠️ Response exceeded the size limit for this agent."), types.StandardMessageTypeString, false, "output_too_large", room)
+			return
+		case errors.Is(err, types.ErrContentBlocked):
+			logging.Warn("streaming task response blocked by guardrail", "task_id", taskID, "error", err)
+			status = "rejected"
+			t.protocolHandler.SendTaskResponseToRoomContext(ctx, taskID, output.Clean("⚠️ This response was blocked by the agent's content policy."), types.StandardMessageTypeString, false, "content_blocked", room)
+			return
+		case preempted(ctx):
+			logging.Info("streaming task preempted, it runs again later", "task_id", taskID)
+			status = "preempted"
+			return
+		default:
+			logging.Error("streaming task failed", "task_id", taskID, "error", err)
+			status = "error"
+			spanErr = err
+			t.protocolHandler.SendTaskFailure(ctx, taskID, err, room)
 			return
 		}
 
-		log.Printf("✅ Streaming task %s completed successfully", taskID)
+		reply = messageSender.sentText()
+		sent = messageSender.sentMessages()
+
+		// Bill the task and send its receipt after the last message
+		if receipt := t.recordUsage(ctx); receipt != nil {
+			if err := t.protocolHandler.SendUsageReceipt(ctx, room, receipt); err != nil {
+				logging.Warn("failed to send usage receipt", "task_id", taskID, "error", err)
+			}
+		}
 
 		// Send final completion message if needed
 		// Note: The agent should send its own completion message using the MessageSender
 
 	} else {
-		log.Printf("📄 Using standard task handler for task %s", taskID)
+		// Process the task using standard method unless the handler wants the conversation
+		handlerType := "standard"
+		process := func(handlerCtx context.Context) (string, error) {
+			return t.agentHandler.ProcessTask(handlerCtx, content)
+		}
+		if conversationHandler, ok := t.agentHandler.(types.ConversationAwareHandler); ok {
+			logging.Info("using conversation-aware task handler", "task_id", taskID, "history_turns", len(history))
+			handlerType = "conversation"
+			process = func(handlerCtx context.Context) (string, error) {
+				return conversationHandler.ProcessTaskWithHistory(handlerCtx, content, room, history)
+			}
+		} else {
+			logging.Info("using standard task handler", "task_id", taskID)
+		}
 
-		// Process the task using standard method
-		result, err := t.agentHandler.ProcessTask(ctx, content)
+		result, err := t.runHandler(ctx, taskID, handlerType, process)
+		err = budgetError(ctx, budget, err)
+		if err != nil && preempted(ctx) {
+			logging.Info("task preempted, it runs again later", "task_id", taskID)
+			status = "preempted"
+			return
+		}
 		if err != nil {
-			log.Printf("❌ Task %s failed: %v", taskID, err)
-			t.protocolHandler.SendTaskResponseToRoom(taskID, fmt.Sprintf("❌ Error: %v", err), types.StandardMessageTypeString, false, err.Error(), room)
+			logging.Error("task failed", "task_id", taskID, "error", err)
+			status = "error"
+			spanErr = err
+			t.protocolHandler.SendTaskFailure(ctx, taskID, err, room)
 			return
 		}
 
-		log.Printf("✅ Task %s completed successfully", taskID)
+		logging.Info("task completed successfully", "task_id", taskID)
+
+		// Check the response against the guardrail
+		outputCheck := t.checkGuardrail(ctx, guardrail, guardrailOutput, result, false)
+		if outputCheck.blocked {
+			logging.Warn("task response blocked by guardrail", "task_id", taskID)
+			status = "rejected"
+			t.protocolHandler.SendTaskResponseToRoomContext(ctx, taskID, output.Clean("⚠️ This response was blocked by the agent's content policy."), types.StandardMessageTypeString, false, "content_blocked", room)
+			return
+		}
+		result = outputCheck.content
+
+		// Apply output guard
+		result, err = guards.checkOutput(result, 0)
+		if err != nil {
+			logging.Warn("task rejected by output guard", "task_id", taskID, "error", err)
+			status = "rejected"
+			t.protocolHandler.SendTaskResponseToRoomContext(ctx, taskID, output.Clean("⚠️ Response exceeded the size limit for this agent."), types.StandardMessageTypeString, false, "output_too_large", room)
+			return
+		}
+
+		// Hold the response for review if required. The task context has a short
+		// deadline, so the review waits on its own timeout instead.
+		if reviewer := t.getResponseReviewer(); reviewer != nil {
+			result, err = reviewer.ReviewResponse(context.WithoutCancel(ctx), taskID, room, content, result)
+			if err != nil {
+				logging.Warn("response withheld", "task_id", taskID, "error", err)
+				status = "rejected"
+				t.protocolHandler.SendTaskResponseToRoomContext(ctx, taskID, output.Clean("⚠️ This response was withheld by the agent operator."), types.StandardMessageTypeString, false, "response_rejected", room)
+				return
+			}
+		}
+		reply = result
+
+		// Bill the task and send the response with its receipt, LLM usage and guardrail verdicts
+		receipt := t.recordUsage(ctx)
+		result, details := t.reportLLMUsage(result, receiptDetails(receipt), llmUsage.Usage())
+		details = guardrailDetails(details, inputCheck.verdict, outputCheck.verdict)
+		if err := t.protocolHandler.sendTaskResponse(ctx, taskID, result, types.StandardMessageTypeString, true, "", room, details); err != nil {
+			logging.Error("failed to send task response", "error", err)
+		}
+		sent = []taskResponse{{Content: result, ContentType: types.StandardMessageTypeString}}
+	}
+
+	if scrub != nil {
+		reply = scrub(reply)
+	}
 
-		// Send response
-		if err := t.protocolHandler.SendTaskResponseToRoom(taskID, result, types.StandardMessageTypeString, true, "", room); err != nil {
-			log.Printf("❌ Failed to send task response: %v", err)
+	// Record the exchange in the room's conversation history
+	if mem != nil {
+		turns := []types.ConversationMessage{{Role: "user", Content: content, Sender: SenderFromContext(ctx)}}
+		if reply != "" {
+			turns = append(turns, types.ConversationMessage{Role: "assistant", Content: reply})
+		}
+		if err := mem.Append(ctx, room, turns...); err != nil {
+			logging.Warn("failed to store conversation history", "room", room, "error", err)
 		}
 	}
 
 	// Handle task result if handler supports it (works for both streaming and standard)
 	if resultHandler, ok := t.agentHandler.(types.TaskResultHandler); ok {
-		// For streaming tasks, we don't have a single result, so we pass the task content
-		result := content
-		if err := resultHandler.HandleTaskResult(ctx, taskID, result); err != nil {
-			log.Printf("⚠️ Failed to handle task result: %v", err)
+		// For streaming tasks the result is the text sent to the room
+		if err := resultHandler.HandleTaskResult(ctx, taskID, reply); err != nil {
+			logging.Warn("failed to handle task result", "error", err)
+		}
+	}
+
+	return reply, sent, status
+}
+
+// runHandler calls the handler, retrying errors the task retry policy classifies as retryable
+func (t *TaskCoordinator) runHandler(ctx context.Context, taskID, handlerType string, process func(context.Context) (string, error)) (string, error) {
+	policy := t.getTaskRetryPolicy()
+
+	for attempt := 1; ; attempt++ {
+		handlerCtx, span := tracing.Start(ctx, tracing.SpanHandlerProcess, tracing.AttrHandlerType.String(handlerType))
+		var result string
+		err := t.callHandler(handlerCtx, taskID, handlerType, func() (err error) {
+			result, err = process(handlerCtx)
+			return err
+		})
+		tracing.End(span, err)
+
+		if err == nil || policy == nil || attempt > policy.MaxRetries || !policy.RetryableError(err) {
+			return result, err
+		}
+
+		delay := policy.delayFor(attempt, err)
+		logging.Warn("task failed, retrying", "task_id", taskID, "kind", errs.KindOf(err), "delay", delay, "attempt", attempt, "max_retries", policy.MaxRetries, "error", err)
+
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return "", err
 		}
 	}
 }
@@ -300,6 +1497,60 @@ func (t *TaskCoordinator) extractTaskID(msg *types.Message) string {
 	return ""
 }
 
+// extractRequiredCapabilities returns the capabilities listed in the task metadata,
+// from "required_capabilities" (a list) or "capability" (a single requirement)
+func (t *TaskCoordinator) extractRequiredCapabilities(msg *types.Message) []string {
+	if msg.Data == nil {
+		return nil
+	}
+
+	var taskData struct {
+		RequiredCapabilities []string `json:"required_capabilities"`
+		Capability           string   `json:"capability"`
+	}
+	if err := json.Unmarshal(msg.Data, &taskData); err != nil {
+		return nil
+	}
+
+	required := taskData.RequiredCapabilities
+	if taskData.Capability != "" {
+		required = append(required, taskData.Capability)
+	}
+	return required
+}
+
+// missingCapabilities returns the task's required capabilities this agent cannot handle
+func (t *TaskCoordinator) missingCapabilities(msg *types.Message) []string {
+	var missing []string
+	for _, required := range t.extractRequiredCapabilities(msg) {
+		if !t.CanHandleCapability(required) {
+			missing = append(missing, required)
+		}
+	}
+	return missing
+}
+
+// extractConsumerID returns the wallet or user that requested a coordinator
+// task, carried in the message data. Direct user messages are attributed to
+// their sender instead, since anyone can put an address in their data.
+func (t *TaskCoordinator) extractConsumerID(msg *types.Message) string {
+	if msg.Data != nil {
+		var taskData map[string]interface{}
+		if err := json.Unmarshal(msg.Data, &taskData); err == nil {
+			for _, field := range []string{"user_address", "wallet_address", "requester", "user_id", "from"} {
+				if id, ok := taskData[field].(string); ok && id != "" {
+					return id
+				}
+			}
+		}
+	}
+
+	if msg.From == "coordinator" || msg.From == "system" {
+		return ""
+	}
+	return msg.From
+}
+
 // isResponseMessage checks if content looks like a response to prevent feedback loops
 func (t *TaskCoordinator) isResponseMessage(content string) bool {
 	contentLower := strings.ToLower(content)
@@ -353,9 +1604,10 @@ func (t *TaskCoordinator) CancelTask(taskID string) bool {
 	defer t.activeTasksMu.Unlock()
 
 	if execution, exists := t.activeTasks[taskID]; exists {
-		execution.Cancel()
+		execution.stop(taskctx.ErrCancelled)
 		delete(t.activeTasks, taskID)
-		log.Printf("🛑 Cancelled task: %s", taskID)
+		delete(t.progress, taskID)
+		logging.Info("cancelled task", "task_id", taskID)
 		return true
 	}
 
@@ -368,26 +1620,50 @@ func (t *TaskCoordinator) CancelAllTasks() {
 	defer t.activeTasksMu.Unlock()
 
 	for taskID, execution := range t.activeTasks {
-		execution.Cancel()
-		log.Printf("🛑 Cancelled task: %s", taskID)
+		execution.stop(taskctx.ErrShutdown)
+		logging.Info("cancelled task", "task_id", taskID)
 	}
 
-	// Clear the map
+	// Clear the maps
 	t.activeTasks = make(map[string]*TaskExecution)
+	t.progress = make(map[string]types.TaskProgress)
 }
 
-// CanHandleCapability checks if the agent can handle a specific capability
-func (t *TaskCoordinator) CanHandleCapability(capability string) bool {
-	for _, cap := range t.capabilities {
-		if cap == capability {
-			return true
-		}
+// setTaskProgress records the latest progress of an active task
+func (t *TaskCoordinator) setTaskProgress(progress types.TaskProgress) {
+	t.activeTasksMu.Lock()
+	defer t.activeTasksMu.Unlock()
+
+	if _, active := t.activeTasks[progress.TaskID]; active {
+		t.progress[progress.TaskID] = progress
 	}
-	return false
 }
 
-// UpdateCapabilities updates the agent's capabilities
-func (t *TaskCoordinator) UpdateCapabilities(capabilities []string) {
-	t.capabilities = capabilities
-	t.protocolHandler.UpdateCapabilities(capabilities)
+// GetBackpressureStats returns how many task updates were coalesced or dropped
+// because the connection was congested
+func (t *TaskCoordinator) GetBackpressureStats() BackpressureStats {
+	return BackpressureStats{
+		CoalescedUpdates: t.backpressure.coalesced.Load(),
+		DroppedUpdates:   t.backpressure.dropped.Load(),
+	}
+}
+
+// GetTaskProgress returns the latest progress of active tasks that reported any, ordered by task ID
+func (t *TaskCoordinator) GetTaskProgress() []types.TaskProgress {
+	t.activeTasksMu.RLock()
+	defer t.activeTasksMu.RUnlock()
+
+	result := make([]types.TaskProgress, 0, len(t.progress))
+	for _, progress := range t.progress {
+		result = append(result, progress)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].TaskID < result[j].TaskID })
+	return result
+}
+
+// CanHandleCapability checks if the agent can handle a specific capability.
+// The requirement may use namespace wildcards and version ranges, e.g. "text/*"
+// or "math>=2" (see types.CapabilityRequirement).
+func (t *TaskCoordinator) CanHandleCapability(capability string) bool {
+	return types.AnyCapabilityMatches(t.Capabilities(), capability)
 }
@@ -0,0 +1,326 @@
+package network
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/output"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/ratelimit"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/types"
+)
+
+// newTestCoordinator returns a coordinator that is not connected
+func newTestCoordinator(handler types.AgentHandler, capabilities ...string) *TaskCoordinator {
+	client := NewNetworkClient(&Config{WebSocketURL: "ws://localhost"})
+	protocol := NewProtocolHandler(client, nil, "test-agent", capabilities, "0x1", "", "room-1")
+	return NewTaskCoordinator(handler, protocol, capabilities)
+}
+
+// captureOutbound collects the messages the coordinator sends over its
+// connection, such as the answers to user messages, instead of sending them
+func captureOutbound(coordinator *TaskCoordinator) *responseRecorder {
+	recorder := &responseRecorder{}
+	coordinator.protocolHandler.client.UseOutbound(func(next MessageHandler) MessageHandler {
+		return func(msg *types.Message) error {
+			return recorder.respond(context.Background(), msg)
+		}
+	})
+	return recorder
+}
+
+// taskMessage returns a task message with the given ID and content
+func taskMessage(taskID, content string) *types.Message {
+	data, _ := json.Marshal(map[string]interface{}{"task_id": taskID})
+	return &types.Message{Type: "task", From: "0xuser", Room: "room-1", Content: content, Data: data}
+}
+
+// responseRecorder collects the responses passed to RunTask or sent
+type responseRecorder struct {
+	mu        sync.Mutex
+	responses []*types.Message
+}
+
+func (r *responseRecorder) respond(ctx context.Context, msg *types.Message) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.responses = append(r.responses, msg)
+	return nil
+}
+
+func (r *responseRecorder) take() []*types.Message {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	responses := r.responses
+	r.responses = nil
+	return responses
+}
+
+// responseSucceeded reports the "success" flag of a task response
+func responseSucceeded(t *testing.T, msg *types.Message) bool {
+	t.Helper()
+	success, _ := responseData(t, msg)["success"].(bool)
+	return success
+}
+
+// responseError returns the error code of a task response
+func responseError(t *testing.T, msg *types.Message) string {
+	t.Helper()
+	code, _ := responseData(t, msg)["error"].(string)
+	return code
+}
+
+func responseData(t *testing.T, msg *types.Message) map[string]interface{} {
+	t.Helper()
+	var data map[string]interface{}
+	if err := json.Unmarshal(msg.Data, &data); err != nil {
+		t.Fatalf("response data: %v", err)
+	}
+	return data
+}
+
+// standardHandler answers every task with a fixed text
+type standardHandler struct {
+	calls atomic.Int32
+	reply string
+}
+
+func (h *standardHandler) ProcessTask(ctx context.Context, task string) (string, error) {
+	h.calls.Add(1)
+	return h.reply, nil
+}
+
+// multiTypeHandler streams a JSON message followed by a markdown message
+type multiTypeHandler struct {
+	standardHandler
+}
+
+func (h *multiTypeHandler) ProcessTaskWithStreaming(ctx context.Context, task, room string, sender types.MessageSender) error {
+	h.calls.Add(1)
+	if err := sender.SendMessageAsJSON(map[string]interface{}{"price": 42}); err != nil {
+		return err
+	}
+	return sender.SendMessageAsMD("**done**")
+}
+
+func TestDuplicateTaskReplaysContentTypes(t *testing.T) {
+	handler := &multiTypeHandler{}
+	coordinator := newTestCoordinator(handler)
+	coordinator.SetTaskDeduplicator(NewTaskDeduplicator(nil))
+
+	recorder := &responseRecorder{}
+	if status := coordinator.RunTask(context.Background(), taskMessage("task-1", "price?"), recorder.respond); status != "success" {
+		t.Fatalf("status = %q, want success", status)
+	}
+	original := recorder.take()
+	if len(original) != 2 {
+		t.Fatalf("sent %d responses, want 2", len(original))
+	}
+
+	if status := coordinator.RunTask(context.Background(), taskMessage("task-1", "price?"), recorder.respond); status != "duplicate_task" {
+		t.Fatalf("status = %q, want duplicate_task", status)
+	}
+	if calls := handler.calls.Load(); calls != 1 {
+		t.Errorf("handler called %d times, want 1", calls)
+	}
+
+	replayed := recorder.take()
+	if len(replayed) != len(original) {
+		t.Fatalf("replayed %d responses, want %d", len(replayed), len(original))
+	}
+	for i, msg := range replayed {
+		if msg.ContentType != original[i].ContentType || msg.Content != original[i].Content {
+			t.Errorf("replay %d = %s %q, want %s %q", i, msg.ContentType, msg.Content, original[i].ContentType, original[i].Content)
+		}
+		if msg.TaskID != "task-1" || !responseSucceeded(t, msg) {
+			t.Errorf("replay %d is not a successful response to task-1", i)
+		}
+	}
+	if replayed[0].ContentType != types.StandardMessageTypeJSON {
+		t.Errorf("first replay content type = %s, want %s", replayed[0].ContentType, types.StandardMessageTypeJSON)
+	}
+}
+
+func TestDuplicateTaskStillRunningIsIgnored(t *testing.T) {
+	handler := &standardHandler{reply: "hello"}
+	coordinator := newTestCoordinator(handler)
+	dedup := NewTaskDeduplicator(nil)
+	coordinator.SetTaskDeduplicator(dedup)
+
+	ctx := context.Background()
+	if execute, _ := dedup.claim(ctx, "task-1"); !execute {
+		t.Fatal("first claim was refused")
+	}
+
+	recorder := &responseRecorder{}
+	if status := coordinator.RunTask(ctx, taskMessage("task-1", "hi"), recorder.respond); status != "duplicate_task" {
+		t.Fatalf("status = %q, want duplicate_task", status)
+	}
+	if replayed := recorder.take(); len(replayed) != 0 {
+		t.Errorf("replayed %d responses for a running task, want 0", len(replayed))
+	}
+}
+
+// payloadRejections are tasks rejected by the payload checks, each with a
+// corrected version that is accepted
+var payloadRejections = []struct {
+	name      string
+	code      string
+	rejected  func(*types.Message)
+	corrected func(*types.Message)
+}{
+	{
+		name:      "oversize",
+		code:      "task_too_large",
+		rejected:  func(msg *types.Message) { msg.Content = strings.Repeat("x", 17) },
+		corrected: func(msg *types.Message) { msg.Content = "short" },
+	},
+	{
+		name:      "not UTF-8",
+		code:      "invalid_encoding",
+		rejected:  func(msg *types.Message) { msg.Content = "caf\xe9" },
+		corrected: func(msg *types.Message) { msg.Content = "café" },
+	},
+	{
+		name:      "content type not accepted",
+		code:      "unsupported_content_type",
+		rejected:  func(msg *types.Message) { msg.Content, msg.ContentType = `{"a":1}`, types.StandardMessageTypeJSON },
+		corrected: func(msg *types.Message) { msg.Content, msg.ContentType = "a is 1", "" },
+	},
+}
+
+// payloadGuards accept tasks of up to 16 bytes of STRING content
+func payloadGuards() *TaskGuards {
+	guards := DefaultTaskGuards()
+	guards.MaxTaskBytes = 16
+	guards.AllowedContentTypes = []string{types.StandardMessageTypeString}
+	return guards
+}
+
+func TestTaskPayloadRejections(t *testing.T) {
+	for _, tt := range payloadRejections {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := &standardHandler{reply: "ok"}
+			coordinator := newTestCoordinator(handler)
+			coordinator.SetTaskGuards(payloadGuards())
+			coordinator.SetTaskDeduplicator(NewTaskDeduplicator(nil))
+			recorder := &responseRecorder{}
+
+			msg := taskMessage("task-1", "")
+			tt.rejected(msg)
+			if status := coordinator.RunTask(context.Background(), msg, recorder.respond); status != tt.code {
+				t.Fatalf("status = %q, want %q", status, tt.code)
+			}
+			responses := recorder.take()
+			if len(responses) != 1 || responseSucceeded(t, responses[0]) || responseError(t, responses[0]) != tt.code {
+				t.Fatalf("responses = %+v, want one %s rejection", responses, tt.code)
+			}
+			if handler.calls.Load() != 0 {
+				t.Fatal("handler ran for a rejected task")
+			}
+
+			// The rejection released the task ID: a corrected resend runs
+			// instead of being answered as a duplicate
+			msg = taskMessage("task-1", "")
+			tt.corrected(msg)
+			if status := coordinator.RunTask(context.Background(), msg, recorder.respond); status != "success" {
+				t.Fatalf("corrected resend status = %q, want success", status)
+			}
+			if responses := recorder.take(); len(responses) != 1 || responses[0].Content != "ok" {
+				t.Fatalf("corrected resend responses = %+v, want the handler's reply", responses)
+			}
+			if calls := handler.calls.Load(); calls != 1 {
+				t.Errorf("handler called %d times, want 1", calls)
+			}
+		})
+	}
+}
+
+func TestUserMessagePayloadRejections(t *testing.T) {
+	for _, tt := range payloadRejections {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := &standardHandler{reply: "ok"}
+			coordinator := newTestCoordinator(handler)
+			coordinator.SetTaskGuards(payloadGuards())
+
+			outbound := captureOutbound(coordinator)
+
+			msg := &types.Message{Type: "message", From: "0xuser", Room: "room-1"}
+			tt.rejected(msg)
+			if err := coordinator.HandleUserMessage(msg); err != nil {
+				t.Fatal(err)
+			}
+
+			sent := outbound.take()
+			if len(sent) != 1 || sent[0].Type != "task_response" || responseError(t, sent[0]) != tt.code {
+				t.Fatalf("sent %+v, want one %s rejection", sent, tt.code)
+			}
+			if handler.calls.Load() != 0 {
+				t.Error("handler ran for a rejected message")
+			}
+		})
+	}
+}
+
+func TestRateLimitPerRequesterBehindCoordinator(t *testing.T) {
+	handler := &standardHandler{reply: "ok"}
+	coordinator := newTestCoordinator(handler)
+	coordinator.SetRateLimits(ratelimit.Config{PerSender: ratelimit.Limit{PerMinute: 1}})
+	recorder := &responseRecorder{}
+
+	task := func(taskID, requester string) *types.Message {
+		msg := taskMessage(taskID, "hello")
+		msg.From = "coordinator"
+		msg.Data, _ = json.Marshal(map[string]interface{}{"task_id": taskID, "user_address": requester})
+		return msg
+	}
+	alice := "0x00000000000000000000000000000000000000aa"
+	bob := "0x00000000000000000000000000000000000000bb"
+
+	if status := coordinator.RunTask(context.Background(), task("task-1", alice), recorder.respond); status != "success" {
+		t.Fatalf("first task of alice: status = %q, want success", status)
+	}
+	if status := coordinator.RunTask(context.Background(), task("task-2", alice), recorder.respond); status != "rate_limit_exceeded" {
+		t.Fatalf("second task of alice: status = %q, want rate_limit_exceeded", status)
+	}
+	if status := coordinator.RunTask(context.Background(), task("task-3", bob), recorder.respond); status != "success" {
+		t.Fatalf("first task of bob: status = %q, want success", status)
+	}
+}
+
+func TestCoalescedTaskUpdatesAreSeparated(t *testing.T) {
+	coordinator := newTestCoordinator(&standardHandler{})
+	recorder := &responseRecorder{}
+	sender := coordinator.RoomSender(withResponder(context.Background(), recorder.respond), "task-1", "room-1").(*TaskMessageSender)
+	var congested atomic.Bool
+	sender.congested = congested.Load
+
+	congested.Store(true)
+	for _, update := range []string{"step 1", "step 2"} {
+		if err := sender.SendTaskUpdate(update); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if sent := recorder.take(); len(sent) != 0 {
+		t.Fatalf("sent %d messages while congested, want 0", len(sent))
+	}
+
+	// The connection drained: the held updates go out with the next one
+	congested.Store(false)
+	if err := sender.SendTaskUpdate("step 3"); err != nil {
+		t.Fatal(err)
+	}
+	sent := recorder.take()
+	if len(sent) != 1 {
+		t.Fatalf("sent %d messages, want 1", len(sent))
+	}
+	if want := output.Clean("🔄 Update: ") + "step 1\nstep 2\nstep 3"; sent[0].Content != want {
+		t.Errorf("content = %q, want %q", sent[0].Content, want)
+	}
+	if stats := coordinator.GetBackpressureStats(); stats.CoalescedUpdates != 2 {
+		t.Errorf("coalesced updates = %d, want 2", stats.CoalescedUpdates)
+	}
+}
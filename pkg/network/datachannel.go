@@ -0,0 +1,273 @@
+package network
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/bandwidth"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/logging"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/types"
+	"github.com/gorilla/websocket"
+)
+
+// dataChannelHandshakeTimeout is how long the server has to accept a data channel
+const dataChannelHandshakeTimeout = 5 * time.Second
+
+// dataChannel is an optional second WebSocket connection dedicated to task output,
+// so large streaming responses do not delay auth, pings and registration on the
+// primary connection. While it is not open, task output uses the primary connection.
+type dataChannel struct {
+	url      string
+	mu       sync.Mutex
+	conn     *websocket.Conn
+	sendChan chan *types.Message
+	cancel   context.CancelFunc
+}
+
+// DataChannelActive returns whether task output is currently sent over the data channel
+func (c *NetworkClient) DataChannelActive() bool {
+	if c.data == nil {
+		return false
+	}
+	c.data.mu.Lock()
+	defer c.data.mu.Unlock()
+	return c.data.conn != nil
+}
+
+// OpenDataChannel opens the data channel and sends hello, which identifies the
+// agent's session to the server. It fails if no data channel URL is configured
+// or the server does not accept the channel, in which case task output keeps
+// using the primary connection.
+func (c *NetworkClient) OpenDataChannel(hello *types.Message) error {
+	if c.data == nil {
+		return fmt.Errorf("no data channel configured")
+	}
+	c.closeDataChannel()
+
+	conn, _, err := c.dialer().Dial(c.data.url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to connect data channel: %w", err)
+	}
+	conn.SetReadLimit(maxMessageBytes)
+
+	if err := c.dataChannelHandshake(conn, hello); err != nil {
+		conn.Close()
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(c.ctx)
+	c.data.mu.Lock()
+	c.data.conn = conn
+	c.data.cancel = cancel
+	c.data.mu.Unlock()
+
+	go c.writeDataChannel(ctx, conn)
+	go c.readDataChannel(ctx, conn)
+
+	logging.Info("data channel opened", "url", c.data.url)
+	return nil
+}
+
+// dataChannelHandshake sends hello and waits for the server to accept the channel
+func (c *NetworkClient) dataChannelHandshake(conn *websocket.Conn, hello *types.Message) error {
+	data, err := json.Marshal(hello)
+	if err != nil {
+		return fmt.Errorf("failed to marshal data channel hello: %w", err)
+	}
+
+	conn.SetWriteDeadline(time.Now().Add(dataChannelHandshakeTimeout))
+	if err := conn.WriteMessage(websocket.TextMessage, data); err != nil {
+		return fmt.Errorf("failed to send data channel hello: %w", err)
+	}
+	conn.SetWriteDeadline(time.Time{})
+
+	conn.SetReadDeadline(time.Now().Add(dataChannelHandshakeTimeout))
+	_, reply, err := conn.ReadMessage()
+	if err != nil {
+		return fmt.Errorf("data channel not accepted: %w", err)
+	}
+	conn.SetReadDeadline(time.Time{})
+
+	var msg types.Message
+	if err := json.Unmarshal(reply, &msg); err != nil {
+		return fmt.Errorf("failed to unmarshal data channel reply: %w", err)
+	}
+	if msg.Type != types.MessageTypeDataChannelReady {
+		return fmt.Errorf("data channel not accepted: server replied with %q", msg.Type)
+	}
+	return nil
+}
+
+// writeDataChannel writes queued task output to the data channel
+func (c *NetworkClient) writeDataChannel(ctx context.Context, conn *websocket.Conn) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg := <-c.data.sendChan:
+			data, err := json.Marshal(msg)
+			if err != nil {
+				logging.Error("failed to marshal message", "error", err)
+				continue
+			}
+			if err := conn.WriteMessage(websocket.TextMessage, data); err != nil {
+				c.failoverDataChannel(conn, err, msg)
+				return
+			}
+			c.recordTraffic(bandwidth.Sent, msg, data)
+		}
+	}
+}
+
+// readDataChannel passes messages the server sends on the data channel to the
+// regular handlers and detects when the channel closes
+func (c *NetworkClient) readDataChannel(ctx context.Context, conn *websocket.Conn) {
+	for {
+		_, messageData, err := conn.ReadMessage()
+		if err != nil {
+			if ctx.Err() == nil {
+				c.failoverDataChannel(conn, err, nil)
+			}
+			return
+		}
+
+		var received types.Message
+		if err := json.Unmarshal(messageData, &received); err != nil {
+			logging.Error("failed to unmarshal message", "error", err)
+			continue
+		}
+		c.recordTraffic(bandwidth.Received, &received, messageData)
+		msg := c.reassemble(&received)
+		if msg == nil {
+			continue
+		}
+
+		if err := c.receiveBuf.put(ctx, msg, 0, "receive"); err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			logging.Warn("receive buffer full, dropped message", "type", msg.Type, "task_id", msg.TaskID)
+		}
+	}
+}
+
+// failoverDataChannel closes a broken data channel and moves its unsent task
+// output, including failed if set, back to the primary connection
+func (c *NetworkClient) failoverDataChannel(conn *websocket.Conn, err error, failed *types.Message) {
+	c.data.mu.Lock()
+	if c.data.conn != conn {
+		// Already closed or replaced
+		c.data.mu.Unlock()
+		return
+	}
+	c.data.conn = nil
+	c.data.cancel()
+	c.data.mu.Unlock()
+	conn.Close()
+
+	logging.Warn("data channel lost, sending task output over the primary connection", "error", err)
+
+	if failed != nil {
+		c.sendOnPrimary(failed)
+	}
+	c.drainDataChannel()
+}
+
+// closeDataChannel closes the data channel; task output falls back to the primary connection
+func (c *NetworkClient) closeDataChannel() {
+	if c.data == nil {
+		return
+	}
+
+	c.data.mu.Lock()
+	conn := c.data.conn
+	c.data.conn = nil
+	if c.data.cancel != nil {
+		c.data.cancel()
+	}
+	c.data.mu.Unlock()
+
+	if conn != nil {
+		conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+		conn.Close()
+		logging.Info("data channel closed")
+	}
+	c.drainDataChannel()
+}
+
+// drainDataChannel moves task output still queued for a closed data channel to the primary connection
+func (c *NetworkClient) drainDataChannel() {
+	for {
+		select {
+		case msg := <-c.data.sendChan:
+			c.sendOnPrimary(msg)
+		default:
+			return
+		}
+	}
+}
+
+// sendOnPrimary queues msg on the primary connection, retrying later if that fails
+func (c *NetworkClient) sendOnPrimary(msg *types.Message) {
+	if err := c.sendMessageDirect(msg); err != nil {
+		c.retryQueue.Enqueue(msg, err)
+	}
+}
+
+// sendOnDataChannel queues task output on the data channel. It returns false when
+// msg must use the primary connection: it is not task output, the channel is not
+// open, or its queue is full.
+func (c *NetworkClient) sendOnDataChannel(msg *types.Message) bool {
+	if c.data == nil || msg.Type != types.MessageTypeTaskResponse {
+		return false
+	}
+
+	// Holding the lock while queueing ensures no message is queued after a failover drained the queue
+	c.data.mu.Lock()
+	defer c.data.mu.Unlock()
+	if c.data.conn == nil {
+		return false
+	}
+	select {
+	case c.data.sendChan <- msg:
+		return true
+	default:
+		return false
+	}
+}
+
+// openDataChannel opens the data channel after registration, identifying the
+// session with a signature of the agent's wallet
+func (p *ProtocolHandler) openDataChannel() {
+	timestamp := time.Now().Unix()
+	signature, err := p.auth.SignMessage(fmt.Sprintf("teneo-data-channel:%s:%d", p.walletAddr, timestamp))
+	if err != nil {
+		logging.Warn("failed to sign data channel request", "error", err)
+		return
+	}
+
+	data, err := json.Marshal(map[string]interface{}{
+		"wallet_address": p.walletAddr,
+		"agent_name":     p.agentName,
+		"nft_token_id":   p.nftTokenID,
+		"timestamp":      timestamp,
+		"signature":      signature,
+	})
+	if err != nil {
+		logging.Warn("failed to marshal data channel request", "error", err)
+		return
+	}
+
+	hello := &types.Message{
+		Type:      types.MessageTypeDataChannelOpen,
+		From:      p.walletAddr,
+		Data:      data,
+		Timestamp: time.Now(),
+	}
+	if err := p.client.OpenDataChannel(hello); err != nil {
+		logging.Warn("data channel unavailable, sending task output over the primary connection", "error", err)
+	}
+}
@@ -0,0 +1,210 @@
+package network
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/logging"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/retrystore"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/types"
+)
+
+// ErrDeadLetterNotFound is returned for a dead letter ID that is not kept
+var ErrDeadLetterNotFound = errors.New("dead letter not found")
+
+// MaxDeadLetters is the number of dead letters kept; the oldest are purged beyond it
+const MaxDeadLetters = 1000
+
+// Reasons a message is dead-lettered
+const (
+	DeadLetterMaxRetries   = "max_retries"   // Still failing after RetryPolicy.MaxRetries retries
+	DeadLetterNotRetryable = "not_retryable" // Failed with an error that is not retried
+)
+
+// DeadLetter is a message the retry queue gave up on. It is kept until it is
+// re-enqueued or purged, and with a retry store across restarts.
+type DeadLetter struct {
+	ID       string         `json:"id"`
+	Message  *types.Message `json:"message"`
+	Reason   string         `json:"reason"` // DeadLetterMaxRetries or DeadLetterNotRetryable
+	Error    string         `json:"error"`  // Last send error
+	Attempts int            `json:"attempts"`
+	QueuedAt time.Time      `json:"queued_at"`
+	DeadAt   time.Time      `json:"dead_at"`
+}
+
+// SetDeadLetterHandler sets a callback run for every message moved to the
+// dead-letter queue
+func (q *MessageRetryQueue) SetDeadLetterHandler(handler func(DeadLetter)) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.onDeadLetter = handler
+}
+
+// deadLetterLocked moves a message the queue gives up on to the dead-letter
+// queue (must hold lock)
+func (q *MessageRetryQueue) deadLetterLocked(retryMsg *RetryableMessage, reason string, err error) {
+	letter := &DeadLetter{
+		ID:       retryMsg.Key,
+		Message:  retryMsg.Message,
+		Reason:   reason,
+		Attempts: retryMsg.RetryCount,
+		QueuedAt: retryMsg.QueuedAt,
+		DeadAt:   time.Now(),
+	}
+	if err != nil {
+		letter.Error = err.Error()
+	}
+	q.addDeadLetterLocked(letter)
+	q.updateMetricsLocked(func(m *RetryMetrics) {
+		m.DeadLettered++
+	})
+
+	if q.onDeadLetter != nil {
+		// Call handler without holding lock to prevent deadlock
+		go q.onDeadLetter(*letter)
+	}
+}
+
+// addDeadLetterLocked keeps a dead letter, purging the oldest beyond
+// MaxDeadLetters (must hold lock)
+func (q *MessageRetryQueue) addDeadLetterLocked(letter *DeadLetter) {
+	if i := q.deadLetterIndexLocked(letter.ID); i >= 0 {
+		// The same message died again
+		q.dead = append(q.dead[:i], q.dead[i+1:]...)
+	}
+	q.dead = append(q.dead, letter)
+	if len(q.dead) > MaxDeadLetters {
+		q.dead = append([]*DeadLetter(nil), q.dead[len(q.dead)-MaxDeadLetters:]...)
+	}
+	q.updateMetricsLocked(func(m *RetryMetrics) {
+		m.DeadLetters = len(q.dead)
+	})
+}
+
+// DeadLetters returns the dead letters, oldest first
+func (q *MessageRetryQueue) DeadLetters() []DeadLetter {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	letters := make([]DeadLetter, len(q.dead))
+	for i, letter := range q.dead {
+		letters[i] = *letter
+	}
+	return letters
+}
+
+// DeadLetter returns the dead letter with the ID
+func (q *MessageRetryQueue) DeadLetter(id string) (DeadLetter, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if i := q.deadLetterIndexLocked(id); i >= 0 {
+		return *q.dead[i], true
+	}
+	return DeadLetter{}, false
+}
+
+// Requeue moves a dead letter back to the retry queue, where it is sent
+// again right away with a fresh retry budget
+func (q *MessageRetryQueue) Requeue(id string) error {
+	q.mu.Lock()
+	i := q.deadLetterIndexLocked(id)
+	if i < 0 {
+		q.mu.Unlock()
+		return fmt.Errorf("%w: %s", ErrDeadLetterNotFound, id)
+	}
+	letter := q.dead[i]
+	q.dead = append(q.dead[:i], q.dead[i+1:]...)
+	if !q.knownLocked(letter.ID) {
+		q.insertLocked(&RetryableMessage{
+			Message:   letter.Message,
+			Key:       letter.ID,
+			QueuedAt:  time.Now(),
+			NextRetry: time.Now(),
+		})
+	}
+	q.updateMetricsLocked(func(m *RetryMetrics) {
+		m.DeadLetters = len(q.dead)
+		m.CurrentQueueSize = len(q.queue)
+	})
+	q.mu.Unlock()
+
+	q.persist()
+	logging.Info("dead letter re-enqueued", "id", id, "type", letter.Message.Type, "task_id", letter.Message.TaskID)
+	return nil
+}
+
+// PurgeDeadLetters deletes the dead letters with the IDs, or all of them
+// when no ID is given, and returns how many were deleted
+func (q *MessageRetryQueue) PurgeDeadLetters(ids ...string) int {
+	q.mu.Lock()
+	purged := 0
+	if len(ids) == 0 {
+		purged = len(q.dead)
+		q.dead = nil
+	} else {
+		for _, id := range ids {
+			if i := q.deadLetterIndexLocked(id); i >= 0 {
+				q.dead = append(q.dead[:i], q.dead[i+1:]...)
+				purged++
+			}
+		}
+	}
+	q.updateMetricsLocked(func(m *RetryMetrics) {
+		m.DeadLetters = len(q.dead)
+	})
+	q.mu.Unlock()
+
+	if purged > 0 {
+		q.persist()
+		logging.Info("dead letters purged", "purged", purged)
+	}
+	return purged
+}
+
+// deadLetterIndexLocked returns the index of the dead letter with the ID,
+// -1 if it is not kept (must hold lock)
+func (q *MessageRetryQueue) deadLetterIndexLocked(id string) int {
+	for i, letter := range q.dead {
+		if letter.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// entry returns the dead letter as it is persisted
+func (l *DeadLetter) entry() retrystore.DeadEntry {
+	data, _ := json.Marshal(l.Message)
+	return retrystore.DeadEntry{
+		Entry: retrystore.Entry{
+			Key:        l.ID,
+			Message:    data,
+			RetryCount: l.Attempts,
+			QueuedAt:   l.QueuedAt,
+			LastError:  l.Error,
+		},
+		Reason: l.Reason,
+		DeadAt: l.DeadAt,
+	}
+}
+
+// deadLetterFromEntry returns a persisted dead letter
+func deadLetterFromEntry(entry retrystore.DeadEntry) (*DeadLetter, error) {
+	var msg types.Message
+	if err := json.Unmarshal(entry.Message, &msg); err != nil {
+		return nil, err
+	}
+	return &DeadLetter{
+		ID:       entry.Key,
+		Message:  &msg,
+		Reason:   entry.Reason,
+		Error:    entry.LastError,
+		Attempts: entry.RetryCount,
+		QueuedAt: entry.QueuedAt,
+		DeadAt:   entry.DeadAt,
+	}, nil
+}
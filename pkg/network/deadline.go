@@ -0,0 +1,147 @@
+package network
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/events"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/logging"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/output"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/types"
+)
+
+// Deadline prediction: a task is at risk when the 90th percentile of recent
+// task durations does not fit before its deadline
+const (
+	durationSamples    = 50
+	minDurationSamples = 5
+	durationPercentile = 0.9
+)
+
+// defaultTaskTimeout bounds tasks that come without a deadline
+const defaultTaskTimeout = 30 * time.Second
+
+// durationEstimator predicts how long a task takes from recent successful tasks
+type durationEstimator struct {
+	mu      sync.Mutex
+	samples []time.Duration // Ring buffer of the last durationSamples durations
+	next    int
+}
+
+// observe records the duration of a successful task
+func (e *durationEstimator) observe(d time.Duration) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if len(e.samples) < durationSamples {
+		e.samples = append(e.samples, d)
+		return
+	}
+	e.samples[e.next] = d
+	e.next = (e.next + 1) % durationSamples
+}
+
+// estimate returns the expected task duration, false until enough tasks finished
+func (e *durationEstimator) estimate() (time.Duration, bool) {
+	e.mu.Lock()
+	sorted := slices.Clone(e.samples)
+	e.mu.Unlock()
+	if len(sorted) < minDurationSamples {
+		return 0, false
+	}
+	slices.Sort(sorted)
+	index := int(math.Ceil(durationPercentile*float64(len(sorted)))) - 1
+	return sorted[index], true
+}
+
+// extractDeadline returns the deadline in the task metadata, zero if there is
+// none. "deadline" is an RFC 3339 time or a Unix timestamp in seconds or
+// milliseconds; "timeout_ms" is relative to when the task was received.
+func (t *TaskCoordinator) extractDeadline(msg *types.Message, received time.Time) time.Time {
+	if msg.Data == nil {
+		return time.Time{}
+	}
+
+	var taskData map[string]interface{}
+	if err := json.Unmarshal(msg.Data, &taskData); err != nil {
+		return time.Time{}
+	}
+
+	switch deadline := taskData["deadline"].(type) {
+	case string:
+		if parsed, err := time.Parse(time.RFC3339, deadline); err == nil {
+			return parsed
+		}
+		logging.Warn("ignoring invalid task deadline", "deadline", deadline)
+	case float64:
+		// Timestamps beyond the year 33658 in seconds are milliseconds
+		if deadline > 1e12 {
+			return time.UnixMilli(int64(deadline))
+		}
+		if deadline > 0 {
+			return time.Unix(int64(deadline), 0)
+		}
+	}
+	if timeout, ok := taskData["timeout_ms"].(float64); ok && timeout > 0 {
+		return received.Add(time.Duration(timeout) * time.Millisecond)
+	}
+	return time.Time{}
+}
+
+// checkDeadline answers tasks whose deadline passed before they could start.
+// Returns true if the task can be processed.
+func (t *TaskCoordinator) checkDeadline(ctx context.Context, msg *types.Message, taskID string, deadline time.Time) bool {
+	if deadline.IsZero() || time.Now().Before(deadline) {
+		return true
+	}
+
+	logging.Warn("task deadline already passed, rejecting task", "task_id", taskID, "deadline", deadline)
+	t.recordRejection("deadline_exceeded")
+	t.recordDeadline("expired")
+	t.protocolHandler.SendTaskRejection(ctx, taskID, output.Clean("⚠️ This request expired before the agent could start it."), "deadline_exceeded", msg.Room, map[string]interface{}{
+		"deadline": deadline.UTC().Format(time.RFC3339Nano),
+	})
+	return false
+}
+
+// reportDeadlineAtRisk tells the room, the event bus and the metrics early when
+// a task is expected to miss its deadline. The task still runs; the server may
+// reassign it.
+func (t *TaskCoordinator) reportDeadlineAtRisk(ctx context.Context, taskID, room string, deadline time.Time) {
+	estimated, ok := t.durations.estimate()
+	if !ok || time.Until(deadline) >= estimated {
+		return
+	}
+
+	logging.Warn("task is expected to miss its deadline", "task_id", taskID, "deadline", deadline, "estimated_duration", estimated)
+	t.recordDeadline("at_risk")
+	if bus := t.getEventBus(); bus != nil {
+		bus.Publish(events.DeadlineAtRisk{TaskID: taskID, Room: room, Deadline: deadline, Estimated: estimated})
+	}
+
+	progress := types.TaskProgress{
+		TaskID:     taskID,
+		Stage:      "deadline_at_risk",
+		ETASeconds: int(math.Ceil(estimated.Seconds())),
+		UpdatedAt:  time.Now(),
+	}
+	content, err := json.Marshal(progress)
+	if err != nil {
+		return
+	}
+	if err := t.protocolHandler.SendTaskResponseToRoomContext(ctx, taskID, string(content), types.StandardMessageTypeProgress, true, "", room); err != nil {
+		logging.Debug("failed to report deadline risk", "task_id", taskID, "error", err)
+		return
+	}
+	t.setTaskProgress(progress)
+}
+
+// recordDeadline records a deadline outcome if the metrics recorder tracks them
+func (t *TaskCoordinator) recordDeadline(outcome string) {
+	if recorder, ok := t.getMetricsRecorder().(types.DeadlineRecorder); ok {
+		recorder.RecordDeadline(outcome)
+	}
+}
@@ -0,0 +1,116 @@
+package network
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/cache"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/logging"
+)
+
+// taskDedupPrefix prefixes the cache keys of processed task IDs
+const taskDedupPrefix = "task:"
+
+// Task states recorded by the TaskDeduplicator
+const (
+	taskStateProcessing = "processing"
+	taskStateCompleted  = "completed"
+)
+
+// DedupConfig configures a TaskDeduplicator
+type DedupConfig struct {
+	TTL   time.Duration    // How long processed task IDs are remembered
+	Cache cache.AgentCache // Shared storage (e.g. Redis); task IDs are kept in process memory when nil or NoOpCache
+}
+
+// DefaultDedupConfig returns the default task deduplication configuration
+func DefaultDedupConfig() *DedupConfig {
+	return &DedupConfig{
+		TTL: 10 * time.Minute,
+	}
+}
+
+// TaskDeduplicator records the IDs of received tasks so a task redelivered by the
+// coordinator (e.g. after a reconnect) is not executed twice. The responses of a
+// completed task are kept so they can be sent again for the duplicate.
+type TaskDeduplicator struct {
+	cache cache.AgentCache
+	ttl   time.Duration
+}
+
+// taskRecord is the cached state of a received task
+type taskRecord struct {
+	State     string         `json:"state"`
+	Responses []taskResponse `json:"responses,omitempty"` // Messages sent for a completed task, in order
+}
+
+// taskResponse is a message sent for a task, kept with its content type so
+// it is replayed as it was sent
+type taskResponse struct {
+	Content     string `json:"content"`
+	ContentType string `json:"content_type"`
+}
+
+// NewTaskDeduplicator creates a task deduplicator
+func NewTaskDeduplicator(config *DedupConfig) *TaskDeduplicator {
+	if config == nil {
+		config = DefaultDedupConfig()
+	}
+
+	agentCache := config.Cache
+	if agentCache != nil {
+		if _, noop := agentCache.(*cache.NoOpCache); noop {
+			agentCache = nil
+		}
+	}
+	if agentCache == nil {
+		agentCache = cache.NewMemoryCache(nil)
+	}
+
+	return &TaskDeduplicator{
+		cache: agentCache,
+		ttl:   config.TTL,
+	}
+}
+
+// claim records taskID as processing. It returns true if the task was not seen
+// before and should be executed; otherwise it returns the task's recorded state.
+// Tasks are executed when the cache cannot be reached.
+func (d *TaskDeduplicator) claim(ctx context.Context, taskID string) (bool, *taskRecord) {
+	claimed, err := d.cache.SetIfNotExists(ctx, taskDedupPrefix+taskID, &taskRecord{State: taskStateProcessing}, d.ttl)
+	if err != nil {
+		logging.Warn("failed to record task for deduplication", "task_id", taskID, "error", err)
+		return true, nil
+	}
+	if claimed {
+		return true, nil
+	}
+
+	record := &taskRecord{State: taskStateProcessing}
+	data, err := d.cache.GetBytes(ctx, taskDedupPrefix+taskID)
+	if err != nil {
+		// The record expired between the two calls
+		logging.Debug("failed to read task deduplication record", "task_id", taskID, "error", err)
+		return false, record
+	}
+	if err := json.Unmarshal(data, record); err != nil {
+		logging.Debug("failed to unmarshal task deduplication record", "task_id", taskID, "error", err)
+	}
+	return false, record
+}
+
+// complete records the messages sent for a successfully executed task
+func (d *TaskDeduplicator) complete(ctx context.Context, taskID string, responses []taskResponse) {
+	record := &taskRecord{State: taskStateCompleted, Responses: responses}
+	if err := d.cache.Set(ctx, taskDedupPrefix+taskID, record, d.ttl); err != nil {
+		logging.Warn("failed to record completed task", "task_id", taskID, "error", err)
+	}
+}
+
+// release forgets taskID so a redelivery of a failed task is executed again
+func (d *TaskDeduplicator) release(ctx context.Context, taskID string) {
+	if err := d.cache.Delete(ctx, taskDedupPrefix+taskID); err != nil {
+		logging.Warn("failed to release task", "task_id", taskID, "error", err)
+	}
+}
@@ -0,0 +1,317 @@
+package network
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/errs"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/logging"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/types"
+)
+
+var (
+	// ErrNoDelegateAgent is returned when no other agent offers the requested capability
+	ErrNoDelegateAgent = errors.New("no agent found with capability")
+
+	// ErrDelegationTimeout is returned when a delegated task gets no response in time
+	ErrDelegationTimeout = errs.Retryable(errors.New("delegated task timed out"))
+
+	// ErrDelegationFailed is returned when the delegate reports that the task failed
+	ErrDelegationFailed = errors.New("delegated task failed")
+)
+
+// CapabilityDirectory looks up agent NFTs by capability.
+// nft.BusinessCardManager implements it.
+type CapabilityDirectory interface {
+	GetAgentsByCapability(ctx context.Context, capability string) ([]*big.Int, error)
+}
+
+// DelegationConfig configures a DelegationClient
+type DelegationConfig struct {
+	Timeout          time.Duration // How long to wait for a delegated task's response
+	DiscoveryTimeout time.Duration // How long to wait for the agent list when discovering agents
+}
+
+// DefaultDelegationConfig returns the default delegation configuration
+func DefaultDelegationConfig() *DelegationConfig {
+	return &DelegationConfig{
+		Timeout:          60 * time.Second,
+		DiscoveryTimeout: 5 * time.Second,
+	}
+}
+
+// DelegationResult is the response of a delegated task
+type DelegationResult struct {
+	TaskID      string        `json:"task_id"`
+	Agent       string        `json:"agent"`
+	Content     string        `json:"content"`
+	ContentType string        `json:"content_type"`
+	Duration    time.Duration `json:"duration"`
+}
+
+// DelegationClient lets an agent hand sub-tasks to other agents on the network.
+// Agents are discovered by capability from the server's agent list and,
+// optionally, the NFT contract. Each delegated task carries a correlation ID
+// that the delegate echoes back as the task ID of its response.
+type DelegationClient struct {
+	protocol  *ProtocolHandler
+	config    *DelegationConfig
+	mu        sync.Mutex
+	directory CapabilityDirectory
+	pending   map[string]*pendingDelegation
+}
+
+// pendingDelegation is a delegated task waiting for its response
+type pendingDelegation struct {
+	responses  chan *types.Message
+	onProgress func(types.TaskProgress)
+}
+
+// NewDelegationClient creates a delegation client and starts listening for task responses
+func NewDelegationClient(protocol *ProtocolHandler, config *DelegationConfig) *DelegationClient {
+	if config == nil {
+		config = DefaultDelegationConfig()
+	}
+
+	client := &DelegationClient{
+		protocol: protocol,
+		config:   config,
+		pending:  make(map[string]*pendingDelegation),
+	}
+	protocol.client.RegisterHandler(types.MessageTypeTaskResponse, client.handleTaskResponse)
+	return client
+}
+
+// SetDirectory enables NFT-based discovery in addition to the server's agent list
+func (d *DelegationClient) SetDirectory(directory CapabilityDirectory) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.directory = directory
+}
+
+// FindAgents returns the other agents offering capability. The agent list is
+// refreshed from the server; if the server does not answer within
+// DiscoveryTimeout the last known list is used.
+func (d *DelegationClient) FindAgents(ctx context.Context, capability string) ([]types.AgentStatus, error) {
+	discoveryCtx, cancel := context.WithTimeout(ctx, d.config.DiscoveryTimeout)
+	agents, err := d.protocol.RefreshAgents(discoveryCtx)
+	cancel()
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		logging.Warn("failed to refresh agent list, using last known agents", "error", err)
+		agents = d.protocol.Agents()
+	}
+
+	tokenIDs, err := d.directoryTokenIDs(ctx, capability)
+	if err != nil {
+		logging.Warn("failed to look up agents by capability on-chain", "capability", capability, "error", err)
+	}
+
+	var matches []types.AgentStatus
+	for _, agent := range agents {
+		if agent.Name == d.protocol.agentName {
+			continue
+		}
+		if types.AnyCapabilityMatches(agent.Capabilities, capability) || (agent.NFTTokenID != "" && tokenIDs[agent.NFTTokenID]) {
+			matches = append(matches, agent)
+		}
+	}
+	return matches, nil
+}
+
+// directoryTokenIDs returns the NFT token IDs registered with capability, if a directory is set
+func (d *DelegationClient) directoryTokenIDs(ctx context.Context, capability string) (map[string]bool, error) {
+	d.mu.Lock()
+	directory := d.directory
+	d.mu.Unlock()
+	if directory == nil {
+		return nil, nil
+	}
+
+	ids, err := directory.GetAgentsByCapability(ctx, capability)
+	if err != nil {
+		return nil, err
+	}
+	tokenIDs := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		tokenIDs[id.String()] = true
+	}
+	return tokenIDs, nil
+}
+
+// Delegate sends task to the agent and waits for its response.
+// onProgress, if not nil, receives the delegate's progress updates.
+func (d *DelegationClient) Delegate(ctx context.Context, agent, task string, onProgress func(types.TaskProgress)) (*DelegationResult, error) {
+	taskID := "delegated-" + newCorrelationID()
+	pending := &pendingDelegation{
+		responses:  make(chan *types.Message, 16),
+		onProgress: onProgress,
+	}
+
+	d.mu.Lock()
+	d.pending[taskID] = pending
+	d.mu.Unlock()
+	defer func() {
+		d.mu.Lock()
+		delete(d.pending, taskID)
+		d.mu.Unlock()
+	}()
+
+	data, err := json.Marshal(map[string]interface{}{
+		"task_id":      taskID,
+		"content":      task,
+		"delegated_by": d.protocol.agentName,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal delegated task: %w", err)
+	}
+
+	msg := &types.Message{
+		Type:      types.MessageTypeTask,
+		From:      d.protocol.agentName,
+		To:        agent,
+		Room:      d.protocol.room,
+		Content:   task,
+		TaskID:    taskID,
+		Data:      data,
+		Timestamp: time.Now(),
+	}
+
+	start := time.Now()
+	if err := d.protocol.client.SendMessageContext(ctx, msg); err != nil {
+		return nil, fmt.Errorf("failed to send delegated task: %w", err)
+	}
+	logging.Info("delegated task", "task_id", taskID, "agent", agent)
+
+	timer := time.NewTimer(d.config.Timeout)
+	defer timer.Stop()
+
+	for {
+		select {
+		case response := <-pending.responses:
+			if response.ContentType == types.StandardMessageTypeProgress {
+				d.reportProgress(pending, response)
+				continue
+			}
+
+			success, errorMsg := parseTaskResponseStatus(response)
+			if !success {
+				return nil, fmt.Errorf("%w: %s: %s", ErrDelegationFailed, agent, errorMsg)
+			}
+
+			logging.Info("delegated task completed", "task_id", taskID, "agent", agent, "duration", time.Since(start))
+			return &DelegationResult{
+				TaskID:      taskID,
+				Agent:       agent,
+				Content:     response.Content,
+				ContentType: response.ContentType,
+				Duration:    time.Since(start),
+			}, nil
+		case <-timer.C:
+			return nil, fmt.Errorf("%w: no response from %s after %s", ErrDelegationTimeout, agent, d.config.Timeout)
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// DelegateToCapability delegates task to an agent offering capability,
+// trying the next candidate when a delegate times out or fails
+func (d *DelegationClient) DelegateToCapability(ctx context.Context, capability, task string) (*DelegationResult, error) {
+	agents, err := d.FindAgents(ctx, capability)
+	if err != nil {
+		return nil, err
+	}
+	if len(agents) == 0 {
+		return nil, fmt.Errorf("%w %q", ErrNoDelegateAgent, capability)
+	}
+
+	var lastErr error
+	for _, agent := range agents {
+		name := agent.ID
+		if name == "" {
+			name = agent.Name
+		}
+
+		result, err := d.Delegate(ctx, name, task, nil)
+		if err == nil {
+			return result, nil
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		logging.Warn("delegation failed, trying next agent", "agent", name, "capability", capability, "error", err)
+		lastErr = err
+	}
+	return nil, lastErr
+}
+
+// handleTaskResponse routes task responses to the delegated task waiting for them
+func (d *DelegationClient) handleTaskResponse(msg *types.Message) error {
+	taskID := msg.TaskID
+	if taskID == "" {
+		var data struct {
+			TaskID string `json:"task_id"`
+		}
+		if err := json.Unmarshal(msg.Data, &data); err == nil {
+			taskID = data.TaskID
+		}
+	}
+
+	d.mu.Lock()
+	pending, ok := d.pending[taskID]
+	d.mu.Unlock()
+	if !ok {
+		return nil
+	}
+
+	select {
+	case pending.responses <- msg:
+	default:
+		logging.Warn("dropping delegated task response, too many pending", "task_id", taskID)
+	}
+	return nil
+}
+
+// reportProgress passes a delegate's progress update to the caller
+func (d *DelegationClient) reportProgress(pending *pendingDelegation, msg *types.Message) {
+	if pending.onProgress == nil {
+		return
+	}
+	var progress types.TaskProgress
+	if err := json.Unmarshal([]byte(msg.Content), &progress); err != nil {
+		logging.Debug("ignoring malformed progress from delegate", "task_id", msg.TaskID, "error", err)
+		return
+	}
+	pending.onProgress(progress)
+}
+
+// parseTaskResponseStatus reads the success flag and error of a task response
+func parseTaskResponseStatus(msg *types.Message) (bool, string) {
+	status := struct {
+		Success *bool  `json:"success"`
+		Error   string `json:"error"`
+	}{}
+	if len(msg.Data) == 0 || json.Unmarshal(msg.Data, &status) != nil || status.Success == nil {
+		return true, ""
+	}
+	return *status.Success, status.Error
+}
+
+// newCorrelationID returns a random identifier for a delegated task
+func newCorrelationID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return time.Now().Format("20060102150405.000000000")
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,192 @@
+package network
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/events"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/logging"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/types"
+)
+
+// DuplicatePolicy is what the agent does when another process connects with
+// the same wallet or NFT
+type DuplicatePolicy string
+
+const (
+	// DuplicatePolicyAlert keeps the agent running and reports the conflict (default)
+	DuplicatePolicyAlert DuplicatePolicy = "alert"
+	// DuplicatePolicyYield disconnects and leaves the network to the other process
+	DuplicatePolicyYield DuplicatePolicy = "yield"
+	// DuplicatePolicyTakeover registers again, asking the server to drop the other connection
+	DuplicatePolicyTakeover DuplicatePolicy = "takeover"
+)
+
+// Takeovers are limited so two instances both set to take over do not replace
+// each other forever; beyond the limit the agent yields
+const (
+	maxTakeovers   = 3
+	takeoverWindow = 10 * time.Minute
+)
+
+// Message types the server may send when another connection uses the agent's identity
+var duplicateMessageTypes = []string{"duplicate_connection", "session_replaced", "connection_replaced"}
+
+// ParseDuplicatePolicy parses a duplicate-connection policy ("" = alert)
+func ParseDuplicatePolicy(s string) (DuplicatePolicy, error) {
+	switch policy := DuplicatePolicy(strings.ToLower(strings.TrimSpace(s))); policy {
+	case "":
+		return DuplicatePolicyAlert, nil
+	case DuplicatePolicyAlert, DuplicatePolicyYield, DuplicatePolicyTakeover:
+		return policy, nil
+	}
+	return "", fmt.Errorf("invalid duplicate connection policy %q (use \"alert\", \"yield\" or \"takeover\")", s)
+}
+
+// duplicateGuard holds the identity of this process and the duplicate-connection policy
+type duplicateGuard struct {
+	mu         sync.Mutex
+	instanceID string // Random ID of this process, sent with the registration
+	policy     DuplicatePolicy
+	takeover   bool        // The next registration asks the server to drop other connections
+	takeovers  []time.Time // Recent takeovers, for the flapping limit
+	yielded    bool
+}
+
+func newDuplicateGuard() *duplicateGuard {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return &duplicateGuard{instanceID: fmt.Sprintf("%x", time.Now().UnixNano()), policy: DuplicatePolicyAlert}
+	}
+	return &duplicateGuard{instanceID: hex.EncodeToString(b), policy: DuplicatePolicyAlert}
+}
+
+// SetDuplicatePolicy sets what the agent does when another process connects
+// with the same wallet or NFT
+func (p *ProtocolHandler) SetDuplicatePolicy(policy DuplicatePolicy) {
+	p.duplicates.mu.Lock()
+	defer p.duplicates.mu.Unlock()
+	p.duplicates.policy = policy
+}
+
+// DuplicatePolicy returns the duplicate-connection policy
+func (p *ProtocolHandler) DuplicatePolicy() DuplicatePolicy {
+	p.duplicates.mu.Lock()
+	defer p.duplicates.mu.Unlock()
+	return p.duplicates.policy
+}
+
+// InstanceID returns the random ID identifying this process to the server
+func (p *ProtocolHandler) InstanceID() string {
+	return p.duplicates.instanceID
+}
+
+// takeoverRequested reports whether the next authentication and registration
+// ask the server to drop other connections. Registering clears the request.
+func (p *ProtocolHandler) takeoverRequested(consume bool) bool {
+	p.duplicates.mu.Lock()
+	defer p.duplicates.mu.Unlock()
+	takeover := p.duplicates.takeover
+	if consume {
+		p.duplicates.takeover = false
+	}
+	return takeover
+}
+
+// yielded reports whether the agent left the network to another process
+func (p *ProtocolHandler) yielded() bool {
+	p.duplicates.mu.Lock()
+	defer p.duplicates.mu.Unlock()
+	return p.duplicates.yielded
+}
+
+// HandleDuplicateConnection handles the server's notice that another
+// connection uses the agent's identity
+func (p *ProtocolHandler) HandleDuplicateConnection(msg *types.Message) error {
+	p.duplicateConnection("server", msg.Content, "")
+	return nil
+}
+
+// isDuplicateConnectionError reports whether a server error means another
+// connection uses the agent's identity
+func isDuplicateConnectionError(content string) bool {
+	content = strings.ToLower(content)
+	for _, phrase := range []string{"duplicate connection", "already connected", "connected from another", "session replaced", "connection replaced", "logged in elsewhere"} {
+		if strings.Contains(content, phrase) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkPresence looks for another online process with the agent's NFT or
+// wallet in an agents list
+func (p *ProtocolHandler) checkPresence(agents []types.AgentStatus) {
+	for _, agent := range agents {
+		sameIdentity := (p.nftTokenID != "" && agent.NFTTokenID == p.nftTokenID) || strings.EqualFold(agent.ID, p.walletAddr)
+		if sameIdentity && agent.IsOnline && agent.InstanceID != "" && agent.InstanceID != p.InstanceID() {
+			p.duplicateConnection("presence", "agent is online from another process", agent.InstanceID)
+			return
+		}
+	}
+}
+
+// duplicateConnection applies the duplicate-connection policy
+func (p *ProtocolHandler) duplicateConnection(source, detail, otherInstance string) {
+	g := p.duplicates
+	g.mu.Lock()
+	if g.yielded {
+		g.mu.Unlock()
+		return
+	}
+	policy := g.policy
+	if policy == DuplicatePolicyTakeover {
+		now := time.Now()
+		recent := g.takeovers[:0]
+		for _, at := range g.takeovers {
+			if now.Sub(at) < takeoverWindow {
+				recent = append(recent, at)
+			}
+		}
+		g.takeovers = recent
+		if len(g.takeovers) >= maxTakeovers {
+			logging.Error("another process keeps taking the connection back, yielding", "takeovers", len(g.takeovers), "window", takeoverWindow)
+			policy = DuplicatePolicyYield
+		} else {
+			g.takeovers = append(g.takeovers, now)
+			g.takeover = true
+		}
+	}
+	if policy == DuplicatePolicyYield {
+		g.yielded = true
+	}
+	g.mu.Unlock()
+
+	p.session.mu.Lock()
+	bus := p.session.bus
+	p.session.mu.Unlock()
+	bus.Publish(events.DuplicateConnection{Source: source, Detail: detail, OtherInstance: otherInstance, Policy: string(policy)})
+
+	switch policy {
+	case DuplicatePolicyYield:
+		logging.Error("another process is connected with this agent's identity, disconnecting", "source", source, "detail", detail, "other_instance", otherInstance)
+		// Disconnect waits for the message loop, which is running this handler
+		p.client.reconnector.SetEnabled(false)
+		go func() {
+			if err := p.client.Disconnect(); err != nil {
+				logging.Warn("failed to disconnect", "error", err)
+			}
+		}()
+	case DuplicatePolicyTakeover:
+		logging.Warn("another process is connected with this agent's identity, taking over", "source", source, "detail", detail, "other_instance", otherInstance)
+		if err := p.StartAuthentication(); err != nil {
+			logging.Error("failed to re-authenticate to take over", "error", err)
+		}
+	default:
+		logging.Error("another process is connected with this agent's identity; both may process the same tasks", "source", source, "detail", detail, "other_instance", otherInstance)
+	}
+}
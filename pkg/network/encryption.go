@@ -0,0 +1,137 @@
+package network
+
+import (
+	"context"
+	"crypto/ecdh"
+	"errors"
+	"fmt"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/e2e"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/logging"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/output"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/types"
+)
+
+// EncryptionConfig configures end-to-end encryption of task content
+type EncryptionConfig struct {
+	Keyring  *e2e.Keyring // Keys published to users and used to decrypt their tasks
+	Required bool         // Reject tasks that are not encrypted, instead of answering them in plaintext
+}
+
+// SetEncryption enables end-to-end encryption of task content (nil disables
+// it). The public keys are published in the registration and capabilities
+// messages.
+func (p *ProtocolHandler) SetEncryption(config *EncryptionConfig) {
+	if config != nil && config.Keyring == nil {
+		config = nil
+	}
+
+	p.signingMu.Lock()
+	defer p.signingMu.Unlock()
+	p.encryption = config
+}
+
+// getEncryption returns the encryption configuration, nil if disabled
+func (p *ProtocolHandler) getEncryption() *EncryptionConfig {
+	p.signingMu.RLock()
+	defer p.signingMu.RUnlock()
+	return p.encryption
+}
+
+// EncryptionKeys returns the published encryption keys, the one to use first
+// (nil if encryption is disabled)
+func (p *ProtocolHandler) EncryptionKeys() []types.EncryptionKey {
+	config := p.getEncryption()
+	if config == nil {
+		return nil
+	}
+	return config.Keyring.Public()
+}
+
+// RotateEncryptionKey makes a fresh encryption key the one users encrypt
+// to and, once authenticated, announces it to the server in a capabilities
+// message. Tasks encrypted to a retained older key are still accepted.
+func (p *ProtocolHandler) RotateEncryptionKey() (*e2e.Key, error) {
+	config := p.getEncryption()
+	if config == nil {
+		return nil, errors.New("encryption is not enabled")
+	}
+
+	key, err := config.Keyring.Rotate()
+	if err != nil {
+		return nil, fmt.Errorf("failed to rotate encryption key: %w", err)
+	}
+	logging.Info("rotated encryption key", "key_id", key.ID)
+
+	if !p.client.IsAuthenticated() {
+		return key, nil
+	}
+	return key, p.SendCapabilities()
+}
+
+// replyKeyKey is the context key of the public key task responses are sealed to
+type replyKeyKey struct{}
+
+// withReplyKey returns a context whose task responses are sealed to key
+func withReplyKey(ctx context.Context, key *ecdh.PublicKey) context.Context {
+	return context.WithValue(ctx, replyKeyKey{}, key)
+}
+
+// replyKeyFromContext returns the key task responses are sealed to (nil = plaintext)
+func replyKeyFromContext(ctx context.Context) *ecdh.PublicKey {
+	key, _ := ctx.Value(replyKeyKey{}).(*ecdh.PublicKey)
+	return key
+}
+
+// decryptTask decrypts the content of an encrypted task in place, returning
+// a context whose responses are sealed to the sender's reply key. Plaintext
+// tasks are accepted unless encryption is required, and encrypted tasks are
+// rejected if encryption is disabled. Returns the rejection code, or "" if
+// the task can be processed.
+func (t *TaskCoordinator) decryptTask(ctx context.Context, msg *types.Message, taskID string) (context.Context, string) {
+	config := t.protocolHandler.getEncryption()
+	encrypted := msg.Encoding == types.ContentEncodingE2E
+	if config == nil && encrypted {
+		// The handler must not be given ciphertext as the prompt
+		const errorCode = "encryption_not_supported"
+		logging.Warn("rejecting encrypted task, encryption is not enabled", "task_id", taskID)
+		t.recordRejection(errorCode)
+		t.protocolHandler.SendTaskRejection(ctx, taskID, output.Clean("⚠️ This agent does not accept encrypted requests. Please send your request in plaintext."), errorCode, msg.Room, map[string]interface{}{
+			"reason": "encryption is not enabled",
+		})
+		return ctx, errorCode
+	}
+	if config == nil || (!encrypted && !config.Required) {
+		return ctx, ""
+	}
+
+	var content, errorCode string
+	var err error
+	if encrypted {
+		var plaintext string
+		var reply *ecdh.PublicKey
+		plaintext, reply, err = config.Keyring.Open(msg.Content)
+		switch {
+		case err != nil:
+			content, errorCode = "⚠️ Your request could not be decrypted. Please encrypt it to one of this agent's current keys.", "decryption_failed"
+		case reply == nil:
+			err = errors.New("encrypted task has no reply key")
+			content, errorCode = "⚠️ Encrypted requests must include a reply key to encrypt the answer to.", "encryption_required"
+		default:
+			msg.Content = plaintext
+			msg.Encoding = ""
+			return withReplyKey(ctx, reply), ""
+		}
+	} else {
+		err = errors.New("task is not encrypted")
+		content, errorCode = "⚠️ This agent only accepts encrypted requests. Please encrypt your request to one of its keys.", "encryption_required"
+	}
+
+	logging.Warn("rejecting task that could not be decrypted", "task_id", taskID, "code", errorCode, "error", err)
+	t.recordRejection(errorCode)
+	t.protocolHandler.SendTaskRejection(ctx, taskID, output.Clean(content), errorCode, msg.Room, map[string]interface{}{
+		"reason":          err.Error(),
+		"encryption_keys": config.Keyring.Public(),
+	})
+	return ctx, errorCode
+}
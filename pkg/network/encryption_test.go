@@ -0,0 +1,112 @@
+package network
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/cache"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/e2e"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/logging"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/types"
+)
+
+// lockedBuffer is a buffer that log handlers on several goroutines can write to
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestEncryptedTaskPlaintextIsNotKept(t *testing.T) {
+	logs := &lockedBuffer{}
+	original := logging.Default()
+	logging.SetDefault(logging.NewSlogLogger(slog.New(slog.NewTextHandler(logs, &slog.HandlerOptions{Level: slog.LevelDebug}))))
+	defer logging.SetDefault(original)
+
+	keyring, err := e2e.NewKeyring(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reply, err := e2e.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	handler := &standardHandler{reply: "the secret answer"}
+	coordinator := newTestCoordinator(handler)
+	coordinator.protocolHandler.SetEncryption(&EncryptionConfig{Keyring: keyring})
+	dedupCache := cache.NewMemoryCache(nil)
+	coordinator.SetTaskDeduplicator(NewTaskDeduplicator(&DedupConfig{TTL: time.Minute, Cache: dedupCache}))
+	recorder := &responseRecorder{}
+
+	encryptedTask := func() *types.Message {
+		sealed, err := e2e.Seal(keyring.Primary().PublicKey(), "the secret question", reply.PublicKey())
+		if err != nil {
+			t.Fatal(err)
+		}
+		msg := taskMessage("task-1", sealed)
+		msg.Encoding = types.ContentEncodingE2E
+		return msg
+	}
+
+	if status := coordinator.RunTask(context.Background(), encryptedTask(), recorder.respond); status != "success" {
+		t.Fatalf("status = %q, want success", status)
+	}
+	sent := recorder.take()
+	if len(sent) != 1 || sent[0].Encoding != types.ContentEncodingE2E || strings.Contains(sent[0].Content, "secret") {
+		t.Fatalf("sent %+v, want one encrypted response", sent)
+	}
+
+	record, err := dedupCache.GetBytes(context.Background(), taskDedupPrefix+"task-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(record), "secret") {
+		t.Errorf("deduplication record keeps the plaintext answer: %s", record)
+	}
+	if strings.Contains(logs.String(), "secret") {
+		t.Errorf("logs contain the plaintext:\n%s", logs.String())
+	}
+
+	// A redelivery is not answered from the record
+	if status := coordinator.RunTask(context.Background(), encryptedTask(), recorder.respond); status != "duplicate_task" {
+		t.Fatalf("redelivery status = %q, want duplicate_task", status)
+	}
+	if sent := recorder.take(); len(sent) != 0 {
+		t.Errorf("redelivery answered with %+v", sent)
+	}
+	if calls := handler.calls.Load(); calls != 1 {
+		t.Errorf("handler called %d times, want 1", calls)
+	}
+}
+
+func TestEncryptedTaskRejectedWithoutEncryption(t *testing.T) {
+	handler := &standardHandler{reply: "ok"}
+	coordinator := newTestCoordinator(handler)
+	recorder := &responseRecorder{}
+
+	msg := taskMessage("task-1", "ciphertext")
+	msg.Encoding = types.ContentEncodingE2E
+	if status := coordinator.RunTask(context.Background(), msg, recorder.respond); status != "encryption_not_supported" {
+		t.Fatalf("status = %q, want encryption_not_supported", status)
+	}
+	if handler.calls.Load() != 0 {
+		t.Fatal("handler ran with the ciphertext as the prompt")
+	}
+}
@@ -0,0 +1,118 @@
+package network
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/logging"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/types"
+)
+
+// GuardrailPolicy configures how the coordinator applies a guardrail
+type GuardrailPolicy struct {
+	Guardrail    types.Guardrail
+	InputAction  string // Action on flagged task input: types.GuardrailActionBlock (default), ...Redact or ...Annotate
+	OutputAction string // Action on flagged responses (default types.GuardrailActionBlock)
+	FailClosed   bool   // Treat content the guardrail fails to check as flagged instead of letting it pass
+}
+
+// Directions of guardrail checks, reported to the metrics recorder
+const (
+	guardrailInput  = "input"
+	guardrailOutput = "output"
+)
+
+// SetGuardrail sets the guardrail checking task input before the handler
+// runs and responses before they are sent (nil disables the checks).
+// Streamed messages are checked one by one; annotations are only added to
+// single responses.
+func (t *TaskCoordinator) SetGuardrail(policy *GuardrailPolicy) {
+	if policy != nil && policy.Guardrail == nil {
+		policy = nil
+	}
+	t.rateLimitMu.Lock()
+	defer t.rateLimitMu.Unlock()
+	t.guardrail = policy
+}
+
+// getGuardrail returns the configured guardrail policy
+func (t *TaskCoordinator) getGuardrail() *GuardrailPolicy {
+	t.rateLimitMu.Lock()
+	defer t.rateLimitMu.Unlock()
+	return t.guardrail
+}
+
+// guardrailCheck is the outcome of checking content against a guardrail
+type guardrailCheck struct {
+	content string                  // Content to use, redacted if required
+	blocked bool                    // The content must not be used
+	verdict *types.GuardrailVerdict // Verdict on flagged content to annotate the response with, nil if none
+}
+
+// checkGuardrail runs the guardrail of a policy on content in a direction and
+// applies the policy's action to flagged content. Structured content cannot
+// be redacted and is blocked instead.
+func (t *TaskCoordinator) checkGuardrail(ctx context.Context, p *GuardrailPolicy, direction, content string, structured bool) guardrailCheck {
+	result := guardrailCheck{content: content}
+	if p == nil {
+		return result
+	}
+
+	check, action := p.Guardrail.CheckInput, p.InputAction
+	if direction == guardrailOutput {
+		check, action = p.Guardrail.CheckOutput, p.OutputAction
+	}
+	verdict, err := check(ctx, content)
+	if err != nil {
+		if !p.FailClosed {
+			logging.Warn("guardrail check failed, letting content pass", "direction", direction, "error", err)
+			return result
+		}
+		logging.Warn("guardrail check failed, blocking content", "direction", direction, "error", err)
+		verdict = types.GuardrailVerdict{Flagged: true, Reason: fmt.Sprintf("guardrail unavailable: %v", err)}
+		action = types.GuardrailActionBlock
+	}
+	if !verdict.Flagged {
+		return result
+	}
+
+	if recorder, ok := t.getMetricsRecorder().(types.GuardrailRecorder); ok {
+		recorder.RecordGuardrailFlag(direction)
+	}
+	logging.Warn("guardrail flagged content", "direction", direction, "action", action, "categories", verdict.Categories, "reason", verdict.Reason)
+
+	switch action {
+	case types.GuardrailActionAnnotate:
+		result.verdict = &verdict
+	case types.GuardrailActionRedact:
+		if verdict.Redacted != "" && !structured {
+			result.content = verdict.Redacted
+			result.verdict = &verdict
+			break
+		}
+		result.blocked = true
+	default:
+		result.blocked = true
+	}
+	return result
+}
+
+// guardrailDetails adds the verdicts on a task's input and response to the
+// response data, under "guardrail"
+func guardrailDetails(details map[string]interface{}, input, output *types.GuardrailVerdict) map[string]interface{} {
+	if input == nil && output == nil {
+		return details
+	}
+	if details == nil {
+		details = make(map[string]interface{}, 1)
+	}
+	verdicts := make(map[string]*types.GuardrailVerdict, 2)
+	if input != nil {
+		verdicts[guardrailInput] = input
+	}
+	if output != nil {
+		verdicts[guardrailOutput] = output
+	}
+	details["guardrail"] = verdicts
+	return details
+}
@@ -0,0 +1,164 @@
+package network
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/logging"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/output"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/types"
+)
+
+// GuardPolicy decides what happens when a task exceeds a guard limit
+type GuardPolicy string
+
+const (
+	// GuardPolicyTruncate cuts the content down to the limit and continues
+	GuardPolicyTruncate GuardPolicy = "truncate"
+	// GuardPolicyReject fails the task with an error response
+	GuardPolicyReject GuardPolicy = "reject"
+)
+
+// DefaultMaxTaskBytes is the default limit of a received task's content
+const DefaultMaxTaskBytes = 1 << 20
+
+// truncationNotice is appended to content that was cut by a guard
+const truncationNotice = "\n\n… [truncated]"
+
+var (
+	// ErrTaskInputTooLarge is returned when a task's input exceeds MaxInputChars
+	ErrTaskInputTooLarge = errors.New("task input too large")
+
+	// ErrTaskOutputTooLarge is returned when a task's output exceeds MaxOutputBytes
+	ErrTaskOutputTooLarge = errors.New("task output too large")
+
+	// ErrTaskMessageLimit is returned by the message sender once a task has sent MaxMessagesPerTask messages
+	ErrTaskMessageLimit = errors.New("task message limit reached")
+)
+
+// TaskGuards limits how much a single task may consume or produce
+type TaskGuards struct {
+	MaxTaskBytes        int      // Maximum bytes of a received task's content, checked before any other work (0 = unlimited)
+	AllowedContentTypes []string // Content types of the tasks accepted, a task without one counting as STRING (empty = any)
+
+	MaxInputChars      int         // Maximum characters in the task input (0 = unlimited)
+	InputPolicy        GuardPolicy // Policy when the input is too large (default: reject)
+	MaxOutputBytes     int         // Maximum bytes sent for a task, across all messages (0 = unlimited)
+	OutputPolicy       GuardPolicy // Policy when the output is too large (default: truncate)
+	MaxMessagesPerTask int         // Maximum messages a streaming task may send (0 = unlimited)
+}
+
+// DefaultTaskGuards returns guards with all limits disabled
+func DefaultTaskGuards() *TaskGuards {
+	return &TaskGuards{
+		InputPolicy:  GuardPolicyReject,
+		OutputPolicy: GuardPolicyTruncate,
+	}
+}
+
+// checkPayload checks the content of a received task: its size, its
+// encoding and its content type. It returns the reason to reject the task
+// for, with details for the requester, or "" if the task is acceptable.
+// Content must be valid UTF-8 even without guards.
+func (g *TaskGuards) checkPayload(msg *types.Message) (string, map[string]interface{}) {
+	if g != nil && g.MaxTaskBytes > 0 && len(msg.Content) > g.MaxTaskBytes {
+		return "task_too_large", map[string]interface{}{"size": len(msg.Content), "limit": g.MaxTaskBytes}
+	}
+	if !utf8.ValidString(msg.Content) {
+		return "invalid_encoding", map[string]interface{}{"offset": invalidUTF8Offset(msg.Content)}
+	}
+	if g != nil && len(g.AllowedContentTypes) > 0 {
+		contentType := msg.ContentType
+		if contentType == "" {
+			contentType = types.StandardMessageTypeString
+		}
+		if !slices.Contains(g.AllowedContentTypes, contentType) {
+			return "unsupported_content_type", map[string]interface{}{"content_type": contentType, "allowed": g.AllowedContentTypes}
+		}
+	}
+	return "", nil
+}
+
+// invalidUTF8Offset returns the byte offset of the first invalid UTF-8 sequence in s
+func invalidUTF8Offset(s string) int {
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			return i
+		}
+		i += size
+	}
+	return -1
+}
+
+// checkTaskPayload rejects a task whose content is too large, not UTF-8 or
+// of a content type the agent does not accept, before any other check reads
+// it. Returns the reason the task was rejected for, "" if it can be processed.
+func (t *TaskCoordinator) checkTaskPayload(ctx context.Context, msg *types.Message, taskID string) string {
+	reason, details := t.getTaskGuards().checkPayload(msg)
+	if reason == "" {
+		return ""
+	}
+
+	var content string
+	switch reason {
+	case "task_too_large":
+		content = fmt.Sprintf("⚠️ Request too large: %d bytes. Please keep requests under %d bytes.", details["size"], details["limit"])
+	case "invalid_encoding":
+		content = "⚠️ Request rejected: its content is not valid UTF-8 text."
+	default:
+		content = fmt.Sprintf("⚠️ Request rejected: content type %s is not accepted. Accepted: %s.", details["content_type"], strings.Join(details["allowed"].([]string), ", "))
+	}
+	logging.Warn("rejecting task with invalid payload", "task_id", taskID, "reason", reason, "bytes", len(msg.Content), "content_type", msg.ContentType)
+	t.recordRejection(reason)
+	t.protocolHandler.SendTaskRejection(ctx, taskID, output.Clean(content), reason, msg.Room, details)
+	return reason
+}
+
+// checkInput applies the input limit, returning the content to process
+func (g *TaskGuards) checkInput(content string) (string, error) {
+	if g == nil || g.MaxInputChars <= 0 || utf8.RuneCountInString(content) <= g.MaxInputChars {
+		return content, nil
+	}
+
+	if g.InputPolicy == GuardPolicyTruncate {
+		return string([]rune(content)[:g.MaxInputChars]), nil
+	}
+	return "", fmt.Errorf("%w: %d characters (limit %d)", ErrTaskInputTooLarge, utf8.RuneCountInString(content), g.MaxInputChars)
+}
+
+// checkOutput applies the output limit to content given the bytes already sent for the task
+func (g *TaskGuards) checkOutput(content string, alreadySent int) (string, error) {
+	if g == nil || g.MaxOutputBytes <= 0 || alreadySent+len(content) <= g.MaxOutputBytes {
+		return content, nil
+	}
+
+	if g.OutputPolicy == GuardPolicyReject {
+		return "", fmt.Errorf("%w: limit is %d bytes", ErrTaskOutputTooLarge, g.MaxOutputBytes)
+	}
+
+	remaining := g.MaxOutputBytes - alreadySent
+	if remaining <= 0 {
+		return "", ErrTaskOutputTooLarge
+	}
+	// The notice counts towards the limit; without room for it the content is cut silently
+	if remaining <= len(truncationNotice) {
+		return truncateBytes(content, remaining), nil
+	}
+	return truncateBytes(content, remaining-len(truncationNotice)) + truncationNotice, nil
+}
+
+// truncateBytes cuts a string to at most n bytes without splitting a UTF-8 character
+func truncateBytes(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
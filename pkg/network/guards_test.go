@@ -0,0 +1,43 @@
+package network
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestCheckOutputStaysWithinLimit(t *testing.T) {
+	guards := &TaskGuards{MaxOutputBytes: 40, OutputPolicy: GuardPolicyTruncate}
+	long := strings.Repeat("é", 100)
+
+	tests := []struct {
+		name        string
+		alreadySent int
+		wantNotice  bool
+	}{
+		{"first message", 0, true},
+		{"room for part of the notice", 30, false},
+		{"exactly the notice", 40 - len(truncationNotice), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := guards.checkOutput(long, tt.alreadySent)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.alreadySent+len(got) > guards.MaxOutputBytes {
+				t.Errorf("sent %d bytes, limit is %d", tt.alreadySent+len(got), guards.MaxOutputBytes)
+			}
+			if strings.HasSuffix(got, truncationNotice) != tt.wantNotice {
+				t.Errorf("checkOutput() = %q, notice = %v", got, tt.wantNotice)
+			}
+			if !strings.HasPrefix(long, strings.TrimSuffix(got, truncationNotice)) {
+				t.Errorf("checkOutput() = %q, split a character", got)
+			}
+		})
+	}
+
+	if _, err := guards.checkOutput(long, 40); !errors.Is(err, ErrTaskOutputTooLarge) {
+		t.Errorf("checkOutput() at the limit = %v, want ErrTaskOutputTooLarge", err)
+	}
+}
@@ -3,10 +3,11 @@ package network
 import (
 	"context"
 	"fmt"
-	"log"
 	"sync"
 	"sync/atomic"
 	"time"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/logging"
 )
 
 // HealthStatus represents the health status of a connection
@@ -111,14 +112,14 @@ func NewHealthMonitor(checkInterval time.Duration) *HealthMonitor {
 func (hm *HealthMonitor) Start() {
 	hm.wg.Add(1)
 	go hm.monitorHealth()
-	log.Println("🏥 Health monitor started")
+	logging.Info("health monitor started")
 }
 
 // Stop stops health monitoring
 func (hm *HealthMonitor) Stop() {
 	hm.cancel()
 	hm.wg.Wait()
-	log.Println("🏥 Health monitor stopped")
+	logging.Info("health monitor stopped")
 }
 
 // SetHealthCheckFunc sets the function used to check health
@@ -192,7 +193,7 @@ func (hm *HealthMonitor) updateStatus(newStatus HealthStatus) {
 	
 	atomic.StoreInt32(&hm.status, int32(newStatus))
 	
-	log.Printf("🏥 Health status changed: %s → %s", oldStatus, newStatus)
+	logging.Info("health status changed", "from", oldStatus, "to", newStatus)
 	
 	if hm.onStatusChange != nil {
 		go hm.onStatusChange(oldStatus, newStatus)
@@ -0,0 +1,100 @@
+package network
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/logging"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/types"
+)
+
+// SetHeartbeatInterval sets how often a running task reports that it is
+// alive with a task_alive message (0 disables heartbeats). The first
+// heartbeat is sent one interval after the task started.
+func (t *TaskCoordinator) SetHeartbeatInterval(interval time.Duration) {
+	t.rateLimitMu.Lock()
+	defer t.rateLimitMu.Unlock()
+	t.heartbeatInterval = interval
+}
+
+// getHeartbeatInterval returns the configured heartbeat interval
+func (t *TaskCoordinator) getHeartbeatInterval() time.Duration {
+	t.rateLimitMu.Lock()
+	defer t.rateLimitMu.Unlock()
+	return t.heartbeatInterval
+}
+
+// startHeartbeat sends heartbeats for a task until ctx is done or the
+// returned function is called. The function returns once the last heartbeat
+// was sent, so none follows the task's final response.
+func (t *TaskCoordinator) startHeartbeat(ctx context.Context, taskID, room string, startTime time.Time) func() {
+	interval := t.getHeartbeatInterval()
+	if interval <= 0 {
+		return func() {}
+	}
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				t.sendHeartbeat(ctx, taskID, room, startTime)
+			case <-stop:
+				return
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return func() {
+		close(stop)
+		<-done
+	}
+}
+
+// sendHeartbeat sends a task_alive message with the task's elapsed time and
+// last reported progress. Heartbeats are skipped while disconnected or
+// congested, since a late one says nothing about the task.
+func (t *TaskCoordinator) sendHeartbeat(ctx context.Context, taskID, room string, startTime time.Time) {
+	if client := t.protocolHandler.client; !client.IsConnected() || client.IsCongested() {
+		return
+	}
+
+	alive := types.TaskAlive{
+		TaskID:         taskID,
+		ElapsedSeconds: int(time.Since(startTime).Seconds()),
+		Timestamp:      time.Now(),
+	}
+	t.activeTasksMu.RLock()
+	if progress, ok := t.progress[taskID]; ok {
+		alive.Stage = progress.Stage
+		alive.Percent = progress.Percent
+	}
+	t.activeTasksMu.RUnlock()
+
+	if err := t.protocolHandler.SendTaskAlive(ctx, room, alive); err != nil {
+		logging.Debug("failed to send task heartbeat", "task_id", taskID, "error", err)
+	}
+}
+
+// SendTaskAlive tells the coordinator that a task is still running
+func (p *ProtocolHandler) SendTaskAlive(ctx context.Context, room string, alive types.TaskAlive) error {
+	data, err := json.Marshal(alive)
+	if err != nil {
+		return fmt.Errorf("failed to marshal task heartbeat: %w", err)
+	}
+	return p.client.SendMessageContext(ctx, &types.Message{
+		Type:      types.MessageTypeTaskAlive,
+		From:      p.walletAddr,
+		TaskID:    alive.TaskID,
+		Room:      room,
+		Data:      data,
+		Timestamp: alive.Timestamp,
+	})
+}
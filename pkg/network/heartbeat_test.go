@@ -0,0 +1,211 @@
+package network
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/types"
+	"github.com/gorilla/websocket"
+)
+
+// connectTo marks the coordinator's client as connected to server without
+// running its read and ping loops, so only the heartbeats are sent
+func connectTo(t *testing.T, coordinator *TaskCoordinator, server *fakeServer) *websocket.Conn {
+	t.Helper()
+	conn, _, err := websocket.DefaultDialer.Dial(server.url(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	setConn(coordinator.protocolHandler.client, conn)
+	return conn
+}
+
+// setConn sets the client's connection, nil meaning disconnected
+func setConn(client *NetworkClient, conn *websocket.Conn) {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	client.conn = conn
+	client.running = conn != nil
+}
+
+// heartbeats returns the task_alive messages among msgs
+func heartbeats(t *testing.T, msgs []*types.Message) []types.TaskAlive {
+	t.Helper()
+	var alive []types.TaskAlive
+	for _, msg := range msgs {
+		if msg.Type != types.MessageTypeTaskAlive {
+			continue
+		}
+		var data types.TaskAlive
+		if err := json.Unmarshal(msg.Data, &data); err != nil {
+			t.Fatalf("task_alive data: %v", err)
+		}
+		if msg.TaskID != data.TaskID || msg.Room != "room-1" {
+			t.Errorf("task_alive for task %q in room %q, data for task %q", msg.TaskID, msg.Room, data.TaskID)
+		}
+		alive = append(alive, data)
+	}
+	return alive
+}
+
+// blockingHandler runs until it is released
+type blockingHandler struct {
+	started chan struct{}
+	release chan struct{}
+}
+
+func (h *blockingHandler) ProcessTask(ctx context.Context, task string) (string, error) {
+	close(h.started)
+	<-h.release
+	return "done", nil
+}
+
+func TestHeartbeatsWhileTaskRuns(t *testing.T) {
+	handler := &blockingHandler{started: make(chan struct{}), release: make(chan struct{})}
+	coordinator := newTestCoordinator(handler)
+	coordinator.SetHeartbeatInterval(5 * time.Millisecond)
+	connectTo(t, coordinator, newFakeServer(t))
+	outbound := captureOutbound(coordinator)
+
+	recorder := &responseRecorder{}
+	done := make(chan string)
+	go func() {
+		done <- coordinator.RunTask(context.Background(), taskMessage("task-1", "work"), recorder.respond)
+	}()
+	<-handler.started
+
+	var sent []types.TaskAlive
+	eventually(t, "two heartbeats", func() bool {
+		sent = append(sent, heartbeats(t, outbound.take())...)
+		return len(sent) >= 2
+	})
+	for _, alive := range sent {
+		if alive.TaskID != "task-1" {
+			t.Errorf("heartbeat for task %q, want task-1", alive.TaskID)
+		}
+	}
+
+	close(handler.release)
+	if status := <-done; status != "success" {
+		t.Fatalf("status = %q, want success", status)
+	}
+	outbound.take()
+	time.Sleep(25 * time.Millisecond)
+	if late := heartbeats(t, outbound.take()); len(late) != 0 {
+		t.Errorf("%d heartbeats after the task ended", len(late))
+	}
+}
+
+func TestHeartbeatReportsProgress(t *testing.T) {
+	coordinator := newTestCoordinator(&standardHandler{})
+	coordinator.SetHeartbeatInterval(5 * time.Millisecond)
+	connectTo(t, coordinator, newFakeServer(t))
+	outbound := captureOutbound(coordinator)
+
+	coordinator.activeTasksMu.Lock()
+	coordinator.progress["task-1"] = types.TaskProgress{Percent: 40, Stage: "fetching"}
+	coordinator.activeTasksMu.Unlock()
+
+	stop := coordinator.startHeartbeat(context.Background(), "task-1", "room-1", time.Now().Add(-3*time.Second))
+	defer stop()
+
+	var sent []types.TaskAlive
+	eventually(t, "a heartbeat", func() bool {
+		sent = append(sent, heartbeats(t, outbound.take())...)
+		return len(sent) > 0
+	})
+	if alive := sent[0]; alive.Stage != "fetching" || alive.Percent != 40 || alive.ElapsedSeconds < 3 {
+		t.Errorf("heartbeat = %+v, want stage fetching at 40%% after at least 3s", alive)
+	}
+}
+
+func TestHeartbeatStops(t *testing.T) {
+	tests := []struct {
+		name string
+		stop func(stop func(), cancel context.CancelFunc)
+	}{
+		{"stop", func(stop func(), cancel context.CancelFunc) { stop() }},
+		{"context", func(stop func(), cancel context.CancelFunc) {
+			cancel()
+			stop() // Waits for the heartbeat goroutine to see the cancellation
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			coordinator := newTestCoordinator(&standardHandler{})
+			coordinator.SetHeartbeatInterval(5 * time.Millisecond)
+			connectTo(t, coordinator, newFakeServer(t))
+			outbound := captureOutbound(coordinator)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			stop := coordinator.startHeartbeat(ctx, "task-1", "room-1", time.Now())
+			eventually(t, "a heartbeat", func() bool { return len(heartbeats(t, outbound.take())) > 0 })
+
+			tt.stop(stop, cancel)
+			outbound.take()
+			time.Sleep(25 * time.Millisecond)
+			if late := heartbeats(t, outbound.take()); len(late) != 0 {
+				t.Errorf("%d heartbeats after stopping", len(late))
+			}
+		})
+	}
+}
+
+func TestHeartbeatDisabled(t *testing.T) {
+	coordinator := newTestCoordinator(&standardHandler{})
+	coordinator.SetHeartbeatInterval(0)
+	connectTo(t, coordinator, newFakeServer(t))
+	outbound := captureOutbound(coordinator)
+
+	stop := coordinator.startHeartbeat(context.Background(), "task-1", "room-1", time.Now())
+	time.Sleep(25 * time.Millisecond)
+	stop()
+	if sent := heartbeats(t, outbound.take()); len(sent) != 0 {
+		t.Errorf("%d heartbeats with heartbeats disabled", len(sent))
+	}
+}
+
+// A connection that stopped answering pings is dropped; the task's
+// heartbeats pause until the client is connected again
+func TestHeartbeatPausesWhileDisconnected(t *testing.T) {
+	coordinator := newTestCoordinator(&standardHandler{})
+	coordinator.SetHeartbeatInterval(5 * time.Millisecond)
+	client := coordinator.protocolHandler.client
+	conn := connectTo(t, coordinator, newFakeServer(t))
+	outbound := captureOutbound(coordinator)
+
+	stop := coordinator.startHeartbeat(context.Background(), "task-1", "room-1", time.Now())
+	defer stop()
+	eventually(t, "a heartbeat", func() bool { return len(heartbeats(t, outbound.take())) > 0 })
+
+	setConn(client, nil)
+	time.Sleep(10 * time.Millisecond) // Let a heartbeat in flight finish
+	outbound.take()
+	time.Sleep(25 * time.Millisecond)
+	if sent := heartbeats(t, outbound.take()); len(sent) != 0 {
+		t.Errorf("%d heartbeats while disconnected", len(sent))
+	}
+
+	setConn(client, conn)
+	eventually(t, "a heartbeat after reconnecting", func() bool { return len(heartbeats(t, outbound.take())) > 0 })
+}
+
+func TestHeartbeatSkippedWhileCongested(t *testing.T) {
+	coordinator := newTestCoordinator(&standardHandler{})
+	coordinator.SetHeartbeatInterval(5 * time.Millisecond)
+	client := coordinator.protocolHandler.client
+	client.congestedAt = 0 // Any queue depth counts as congested
+	connectTo(t, coordinator, newFakeServer(t))
+	outbound := captureOutbound(coordinator)
+
+	stop := coordinator.startHeartbeat(context.Background(), "task-1", "room-1", time.Now())
+	time.Sleep(25 * time.Millisecond)
+	stop()
+	if sent := heartbeats(t, outbound.take()); len(sent) != 0 {
+		t.Errorf("%d heartbeats while congested", len(sent))
+	}
+}
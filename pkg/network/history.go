@@ -0,0 +1,85 @@
+package network
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/logging"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/types"
+)
+
+// maxRoomHistoryPage is the most messages requested from the server at once
+const maxRoomHistoryPage = 100
+
+// RoomHistoryPage is one page of a room's message history, oldest message first
+type RoomHistoryPage struct {
+	Room     string          `json:"room"`
+	Messages []types.Message `json:"messages"`
+	HasMore  bool            `json:"has_more"`         // Older messages are available
+	Before   string          `json:"before,omitempty"` // Cursor for the next older page
+	Error    string          `json:"error,omitempty"`  // Set when the server could not serve the request
+}
+
+// FetchRoomHistory requests one page of up to limit messages of room, older than
+// the before cursor (empty for the most recent messages), and waits for the server's response
+func (p *ProtocolHandler) FetchRoomHistory(ctx context.Context, room string, limit int, before string) (*RoomHistoryPage, error) {
+	if limit <= 0 || limit > maxRoomHistoryPage {
+		limit = maxRoomHistoryPage
+	}
+
+	response, err := p.request(ctx, types.MessageTypeRoomHistory, room, map[string]interface{}{
+		"room":   room,
+		"limit":  limit,
+		"before": before,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch room history: %w", err)
+	}
+
+	var page RoomHistoryPage
+	if err := json.Unmarshal(response.Data, &page); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal room history response: %w", err)
+	}
+	if page.Error != "" {
+		return nil, fmt.Errorf("server failed to return room history: %s", page.Error)
+	}
+	for i := range page.Messages {
+		if err := page.Messages[i].DecodeContent(); err != nil {
+			logging.Debug("failed to decode room history message", "room", page.Room, "error", err)
+		}
+	}
+	return &page, nil
+}
+
+// GetRoomHistory returns up to n of the most recent messages of room, oldest first,
+// fetching as many pages as needed
+func (p *ProtocolHandler) GetRoomHistory(ctx context.Context, room string, n int) ([]types.Message, error) {
+	var pages [][]types.Message
+	total := 0
+	before := ""
+
+	for total < n {
+		page, err := p.FetchRoomHistory(ctx, room, n-total, before)
+		if err != nil {
+			return nil, err
+		}
+		pages = append(pages, page.Messages)
+		total += len(page.Messages)
+
+		if !page.HasMore || page.Before == "" || len(page.Messages) == 0 {
+			break
+		}
+		before = page.Before
+	}
+
+	// Pages arrive newest first, messages within a page oldest first
+	history := make([]types.Message, 0, total)
+	for i := len(pages) - 1; i >= 0; i-- {
+		history = append(history, pages[i]...)
+	}
+	if len(history) > n {
+		history = history[len(history)-n:]
+	}
+	return history, nil
+}
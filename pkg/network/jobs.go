@@ -0,0 +1,22 @@
+package network
+
+import (
+	"context"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/types"
+)
+
+// RoomSender returns a MessageSender for messages to room that do not answer a
+// task, e.g. the output of scheduled jobs. id takes the place of the task ID
+// in the messages; the output guards apply as for tasks.
+func (t *TaskCoordinator) RoomSender(ctx context.Context, id, room string) types.MessageSender {
+	return &TaskMessageSender{
+		ctx:             withSequence(ctx),
+		taskID:          id,
+		protocolHandler: t.protocolHandler,
+		room:            room,
+		guards:          t.getTaskGuards(),
+		congested:       t.protocolHandler.client.IsCongested,
+		backpressure:    &t.backpressure,
+	}
+}
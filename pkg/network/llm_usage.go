@@ -0,0 +1,85 @@
+package network
+
+import (
+	"fmt"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/types"
+)
+
+// How the LLM usage of a task is added to its response
+const (
+	LLMUsageReportNone   = ""       // Not added
+	LLMUsageReportField  = "field"  // As the "llm_usage" field of the response data
+	LLMUsageReportFooter = "footer" // As a line appended to the response text
+)
+
+// SetLLMUsageRecorder sets the ledger receiving the LLM usage of finished
+// tasks (nil = only metrics). The metrics recorder receives it too if it
+// implements types.LLMUsageRecorder.
+func (t *TaskCoordinator) SetLLMUsageRecorder(recorder types.LLMUsageRecorder) {
+	t.rateLimitMu.Lock()
+	defer t.rateLimitMu.Unlock()
+	t.llmUsageRecorder = recorder
+}
+
+// getLLMUsageRecorder returns the configured LLM usage ledger
+func (t *TaskCoordinator) getLLMUsageRecorder() types.LLMUsageRecorder {
+	t.rateLimitMu.Lock()
+	defer t.rateLimitMu.Unlock()
+	return t.llmUsageRecorder
+}
+
+// SetLLMUsageReport sets how the LLM usage of a task is added to its
+// response: LLMUsageReportNone, LLMUsageReportField or LLMUsageReportFooter.
+// Only single responses carry it; streamed ones are sent as they are produced.
+func (t *TaskCoordinator) SetLLMUsageReport(mode string) {
+	t.rateLimitMu.Lock()
+	defer t.rateLimitMu.Unlock()
+	t.llmUsageReport = mode
+}
+
+// getLLMUsageReport returns how the LLM usage is added to responses
+func (t *TaskCoordinator) getLLMUsageReport() string {
+	t.rateLimitMu.Lock()
+	defer t.rateLimitMu.Unlock()
+	return t.llmUsageReport
+}
+
+// recordLLMUsage accounts for the LLM usage of a finished task, whatever its outcome
+func (t *TaskCoordinator) recordLLMUsage(room string, usage types.LLMUsage) {
+	if usage.IsZero() {
+		return
+	}
+	if recorder, ok := t.getMetricsRecorder().(types.LLMUsageRecorder); ok {
+		recorder.RecordLLMUsage(room, usage)
+	}
+	if recorder := t.getLLMUsageRecorder(); recorder != nil {
+		recorder.RecordLLMUsage(room, usage)
+	}
+}
+
+// reportLLMUsage adds a task's LLM usage to its response as configured
+func (t *TaskCoordinator) reportLLMUsage(result string, details map[string]interface{}, usage types.LLMUsage) (string, map[string]interface{}) {
+	if usage.IsZero() {
+		return result, details
+	}
+	switch t.getLLMUsageReport() {
+	case LLMUsageReportField:
+		if details == nil {
+			details = make(map[string]interface{}, 1)
+		}
+		details["llm_usage"] = usage
+	case LLMUsageReportFooter:
+		result += "\n\n" + usageFooter(usage)
+	}
+	return result, details
+}
+
+// usageFooter describes LLM usage in a line of text
+func usageFooter(usage types.LLMUsage) string {
+	footer := fmt.Sprintf("_Tokens: %d (%d prompt, %d completion)", usage.TotalTokens(), usage.PromptTokens, usage.CompletionTokens)
+	if usage.Cost > 0 {
+		footer += fmt.Sprintf(", cost: %.6g", usage.Cost)
+	}
+	return footer + "_"
+}
@@ -0,0 +1,24 @@
+package network
+
+import (
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/types"
+)
+
+// SetManifest sets the capability manifest sent in the registration and
+// capabilities messages (nil stops sending it)
+func (p *ProtocolHandler) SetManifest(manifest []types.AgentCapability) {
+	if len(manifest) == 0 {
+		manifest = nil
+	}
+
+	p.resourcesMu.Lock()
+	defer p.resourcesMu.Unlock()
+	p.manifest = manifest
+}
+
+// Manifest returns the capability manifest sent to the server
+func (p *ProtocolHandler) Manifest() []types.AgentCapability {
+	p.resourcesMu.RLock()
+	defer p.resourcesMu.RUnlock()
+	return p.manifest
+}
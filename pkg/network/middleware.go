@@ -0,0 +1,67 @@
+package network
+
+import (
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/types"
+)
+
+// Middleware wraps a MessageHandler with custom logic such as audit logging,
+// payload scrubbing or metrics. It may modify the message before calling next,
+// or return an error without calling next to stop the message.
+type Middleware func(next MessageHandler) MessageHandler
+
+// Use adds middleware that runs for both incoming and outgoing messages.
+// Middleware runs in the order it was added, the first added being the outermost.
+func (c *NetworkClient) Use(middleware ...Middleware) {
+	c.UseInbound(middleware...)
+	c.UseOutbound(middleware...)
+}
+
+// UseInbound adds middleware that runs before incoming messages reach their handler.
+// It sees every incoming message, including those without a registered handler.
+func (c *NetworkClient) UseInbound(middleware ...Middleware) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.inbound = append(c.inbound, middleware...)
+}
+
+// UseOutbound adds middleware that runs before messages are sent with SendMessage.
+// Retries of failed sends and SendRawData do not pass through it.
+func (c *NetworkClient) UseOutbound(middleware ...Middleware) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.outbound = append(c.outbound, middleware...)
+}
+
+// Use adds middleware around the coordinator's task traffic: incoming tasks and
+// user messages, and outgoing task responses. Other messages bypass it.
+func (t *TaskCoordinator) Use(middleware ...Middleware) {
+	for _, mw := range middleware {
+		t.protocolHandler.client.Use(ForMessageTypes(mw, types.MessageTypeTask, types.MessageTypeMessage, types.MessageTypeTaskResponse))
+	}
+}
+
+// ForMessageTypes restricts middleware to messages of the given types
+func ForMessageTypes(middleware Middleware, messageTypes ...string) Middleware {
+	matches := make(map[string]bool, len(messageTypes))
+	for _, messageType := range messageTypes {
+		matches[messageType] = true
+	}
+
+	return func(next MessageHandler) MessageHandler {
+		wrapped := middleware(next)
+		return func(msg *types.Message) error {
+			if matches[msg.Type] {
+				return wrapped(msg)
+			}
+			return next(msg)
+		}
+	}
+}
+
+// chainMiddleware wraps handler in middleware, the first middleware being the outermost
+func chainMiddleware(handler MessageHandler, middleware []Middleware) MessageHandler {
+	for i := len(middleware) - 1; i >= 0; i-- {
+		handler = middleware[i](handler)
+	}
+	return handler
+}
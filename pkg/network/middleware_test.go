@@ -0,0 +1,145 @@
+package network
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/types"
+)
+
+// callLog records the calls of middleware and handlers in order
+type callLog struct {
+	mu    sync.Mutex
+	calls []string
+}
+
+func (l *callLog) add(call string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.calls = append(l.calls, call)
+}
+
+func (l *callLog) take() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	calls := l.calls
+	l.calls = nil
+	return calls
+}
+
+// middleware returns middleware that records its name before calling the next handler
+func (l *callLog) middleware(name string) Middleware {
+	return func(next MessageHandler) MessageHandler {
+		return func(msg *types.Message) error {
+			l.add(name + " " + msg.Type)
+			return next(msg)
+		}
+	}
+}
+
+// handler returns a handler that records its name
+func (l *callLog) handler(name string) MessageHandler {
+	return func(msg *types.Message) error {
+		l.add(name + " " + msg.Type)
+		return nil
+	}
+}
+
+func TestMiddlewareOrderAndDirection(t *testing.T) {
+	log := &callLog{}
+	client := NewNetworkClient(&Config{WebSocketURL: "ws://localhost"})
+	client.Use(log.middleware("both-1"), log.middleware("both-2"))
+	client.UseInbound(log.middleware("in"))
+	client.UseOutbound(log.middleware("out"))
+	// Stands in for the connection
+	client.UseOutbound(func(MessageHandler) MessageHandler { return log.handler("send") })
+	client.RegisterHandler("task", log.handler("handle"))
+
+	if err := client.SendMessage(&types.Message{Type: "task_response"}); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := log.take(), []string{"both-1 task_response", "both-2 task_response", "out task_response", "send task_response"}; !slices.Equal(got, want) {
+		t.Errorf("outgoing calls = %v, want %v", got, want)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go client.processMessages(ctx)
+	client.receiveBuf.ch <- &types.Message{Type: "task"}
+	eventually(t, "incoming message handled", func() bool {
+		log.mu.Lock()
+		defer log.mu.Unlock()
+		return len(log.calls) == 4
+	})
+	if got, want := log.take(), []string{"both-1 task", "both-2 task", "in task", "handle task"}; !slices.Equal(got, want) {
+		t.Errorf("incoming calls = %v, want %v", got, want)
+	}
+}
+
+func TestMiddlewareShortCircuits(t *testing.T) {
+	log := &callLog{}
+	client := NewNetworkClient(&Config{WebSocketURL: "ws://localhost"})
+	blocked := errors.New("blocked")
+	client.UseOutbound(log.middleware("first"))
+	client.UseOutbound(func(next MessageHandler) MessageHandler {
+		return func(msg *types.Message) error {
+			if msg.Content == "secret" {
+				return blocked
+			}
+			return next(msg)
+		}
+	})
+	client.UseOutbound(log.middleware("last"))
+	client.UseOutbound(func(MessageHandler) MessageHandler { return log.handler("send") })
+
+	if err := client.SendMessage(&types.Message{Type: "task_response", Content: "secret"}); !errors.Is(err, blocked) {
+		t.Fatalf("SendMessage() = %v, want %v", err, blocked)
+	}
+	if got, want := log.take(), []string{"first task_response"}; !slices.Equal(got, want) {
+		t.Errorf("calls = %v, want %v", got, want)
+	}
+
+	if err := client.SendMessage(&types.Message{Type: "task_response", Content: "public"}); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := log.take(), []string{"first task_response", "last task_response", "send task_response"}; !slices.Equal(got, want) {
+		t.Errorf("calls = %v, want %v", got, want)
+	}
+}
+
+func TestForMessageTypes(t *testing.T) {
+	log := &callLog{}
+	handler := chainMiddleware(log.handler("handle"), []Middleware{
+		ForMessageTypes(log.middleware("tasks"), "task", "message"),
+	})
+
+	for _, messageType := range []string{"task", "ping", "message"} {
+		if err := handler(&types.Message{Type: messageType}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := []string{"tasks task", "handle task", "handle ping", "tasks message", "handle message"}
+	if got := log.take(); !slices.Equal(got, want) {
+		t.Errorf("calls = %v, want %v", got, want)
+	}
+}
+
+func TestCoordinatorMiddlewareSeesTaskTraffic(t *testing.T) {
+	log := &callLog{}
+	coordinator := newTestCoordinator(&standardHandler{})
+	coordinator.Use(log.middleware("task-traffic"))
+	coordinator.protocolHandler.client.UseOutbound(func(MessageHandler) MessageHandler { return log.handler("send") })
+
+	for _, messageType := range []string{types.MessageTypeTaskResponse, types.MessageTypePing} {
+		if err := coordinator.protocolHandler.client.SendMessage(&types.Message{Type: messageType}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := []string{"task-traffic task_response", "send task_response", "send ping"}
+	if got := log.take(); !slices.Equal(got, want) {
+		t.Errorf("calls = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,321 @@
+package network
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"runtime"
+	"runtime/pprof"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/auth"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/events"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/logging"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/types"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Built-in operator commands
+const (
+	OperatorSetLogLevel    = "set_log_level"   // {"level": "debug", "duration": "15m"}; duration reverts the change
+	OperatorDumpGoroutines = "dump_goroutines" // Stack traces of all goroutines
+	OperatorHealthSnapshot = "health_snapshot" // Registered by the agent
+)
+
+// maxGoroutineDump bounds the stack traces sent in one message
+const maxGoroutineDump = 256 << 10
+
+var (
+	// ErrOperatorCommandsDisabled is returned for commands arriving while no operator is configured
+	ErrOperatorCommandsDisabled = errors.New("operator commands are disabled")
+
+	// ErrUnknownOperator is returned for commands signed by a wallet that is not an operator
+	ErrUnknownOperator = fmt.Errorf("%w: not signed by an operator", types.ErrSignatureInvalid)
+
+	// ErrReplayedCommand is returned for a command that was already run
+	ErrReplayedCommand = errors.New("operator command already run")
+)
+
+// OperatorCommandFunc runs an operator command with its JSON arguments and
+// returns the result sent back to the operator
+type OperatorCommandFunc func(args json.RawMessage) (interface{}, error)
+
+// OperatorConfig configures remote operator commands
+type OperatorConfig struct {
+	Operators    []string      // Wallet addresses allowed to send commands, e.g. the owner wallet
+	MaxClockSkew time.Duration // Reject commands whose timestamp differs more than this from local time (default 5m)
+}
+
+// operatorCommand is the data of an operator_command message
+type operatorCommand struct {
+	Command string          `json:"command"`
+	Args    json.RawMessage `json:"args,omitempty"`
+}
+
+// operatorResult is the data of an operator_result message
+type operatorResult struct {
+	Command string      `json:"command"`
+	Success bool        `json:"success"`
+	Result  interface{} `json:"result,omitempty"`
+	Error   string      `json:"error,omitempty"`
+}
+
+// operatorControl verifies and runs operator commands
+type operatorControl struct {
+	mu        sync.Mutex
+	operators map[common.Address]bool // Empty while operator commands are disabled
+	maxSkew   time.Duration
+	commands  map[string]OperatorCommandFunc
+	seen      map[string]time.Time // Signer and payload hash of commands already run, to reject replays
+
+	revert      *time.Timer // Restores the log level after a temporary change
+	revertLevel string      // Level the pending revert restores
+}
+
+func newOperatorControl() *operatorControl {
+	o := &operatorControl{
+		maxSkew:  5 * time.Minute,
+		commands: make(map[string]OperatorCommandFunc),
+		seen:     make(map[string]time.Time),
+	}
+	o.commands[OperatorSetLogLevel] = o.setLogLevel
+	o.commands[OperatorDumpGoroutines] = dumpGoroutines
+	return o
+}
+
+// EnableOperatorCommands accepts operator_command messages signed by one of
+// the operator wallets. Without operators (the default) commands are ignored.
+func (p *ProtocolHandler) EnableOperatorCommands(config *OperatorConfig) error {
+	operators := make(map[common.Address]bool)
+	maxSkew := 5 * time.Minute
+	if config != nil {
+		for _, operator := range config.Operators {
+			operator = strings.TrimSpace(operator)
+			if operator == "" {
+				continue
+			}
+			if !common.IsHexAddress(operator) {
+				return fmt.Errorf("invalid operator address %q", operator)
+			}
+			operators[common.HexToAddress(operator)] = true
+		}
+		if config.MaxClockSkew > 0 {
+			maxSkew = config.MaxClockSkew
+		}
+	}
+
+	p.operator.mu.Lock()
+	p.operator.operators = operators
+	p.operator.maxSkew = maxSkew
+	p.operator.mu.Unlock()
+	if len(operators) > 0 {
+		logging.Info("operator commands enabled", "operators", len(operators))
+	}
+	return nil
+}
+
+// HandleOperatorCommand registers an operator command, replacing a built-in
+// command of the same name
+func (p *ProtocolHandler) HandleOperatorCommand(name string, fn OperatorCommandFunc) {
+	p.operator.mu.Lock()
+	defer p.operator.mu.Unlock()
+	p.operator.commands[name] = fn
+}
+
+// OperatorCommands returns the names of the registered operator commands
+func (p *ProtocolHandler) OperatorCommands() []string {
+	p.operator.mu.Lock()
+	defer p.operator.mu.Unlock()
+	names := make([]string, 0, len(p.operator.commands))
+	for name := range p.operator.commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// HandleOperatorMessage runs a signed operator command and sends its result
+// back to the sender. Commands that fail verification are logged and dropped.
+func (p *ProtocolHandler) HandleOperatorMessage(msg *types.Message) error {
+	operator, err := p.operator.verify(msg)
+	if err != nil {
+		logging.Warn("rejected operator command", "from", msg.From, "error", err)
+		return nil
+	}
+
+	var command operatorCommand
+	if err := json.Unmarshal(msg.Data, &command); err != nil || command.Command == "" {
+		return p.sendOperatorResult(msg, operatorResult{Command: command.Command, Error: "invalid operator command"})
+	}
+
+	p.operator.mu.Lock()
+	fn := p.operator.commands[command.Command]
+	p.operator.mu.Unlock()
+
+	result := operatorResult{Command: command.Command}
+	if fn == nil {
+		err = fmt.Errorf("unknown operator command %q", command.Command)
+	} else {
+		result.Result, err = fn(command.Args)
+	}
+	if err != nil {
+		logging.Warn("operator command failed", "command", command.Command, "operator", operator.Hex(), "error", err)
+		result.Error = err.Error()
+	} else {
+		logging.Info("operator command run", "command", command.Command, "operator", operator.Hex())
+		result.Success = true
+	}
+
+	p.session.mu.Lock()
+	bus := p.session.bus
+	p.session.mu.Unlock()
+	bus.Publish(events.OperatorCommand{Command: command.Command, Operator: operator.Hex(), Err: err})
+
+	return p.sendOperatorResult(msg, result)
+}
+
+// sendOperatorResult answers an operator command
+func (p *ProtocolHandler) sendOperatorResult(command *types.Message, result operatorResult) error {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return fmt.Errorf("failed to marshal operator result: %w", err)
+	}
+
+	msg := &types.Message{
+		Type:      types.MessageTypeOperatorResult,
+		From:      p.walletAddr,
+		To:        command.From,
+		ReplyTo:   command.ID,
+		Room:      command.Room,
+		Data:      data,
+		Timestamp: time.Now(),
+	}
+	if err := p.signMessage(msg); err != nil {
+		return err
+	}
+	return p.client.SendMessage(msg)
+}
+
+// verify checks that a command is signed by an operator, recent and not
+// replayed, and returns the operator's address
+func (o *operatorControl) verify(msg *types.Message) (common.Address, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if len(o.operators) == 0 {
+		return common.Address{}, ErrOperatorCommandsDisabled
+	}
+	if msg.Signature == "" {
+		return common.Address{}, fmt.Errorf("%w: command is not signed", types.ErrSignatureInvalid)
+	}
+	if age := time.Since(msg.Timestamp); age > o.maxSkew || age < -o.maxSkew {
+		return common.Address{}, fmt.Errorf("command timestamp %s outside allowed clock skew", msg.Timestamp.Format(time.RFC3339))
+	}
+
+	payload, err := msg.SigningPayload()
+	if err != nil {
+		return common.Address{}, err
+	}
+	signer, err := auth.RecoverSigner(string(payload), msg.Signature)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("%w: %v", types.ErrSignatureInvalid, err)
+	}
+	if !o.operators[signer] {
+		return common.Address{}, fmt.Errorf("%w: signed by %s", ErrUnknownOperator, signer.Hex())
+	}
+
+	// Commands older than the clock skew are rejected anyway, so only those
+	// within it need to be remembered
+	now := time.Now()
+	for key, at := range o.seen {
+		if now.Sub(at) > 2*o.maxSkew {
+			delete(o.seen, key)
+		}
+	}
+
+	// Replays are recognized by what was signed rather than by the signature
+	// text, which can be re-encoded (prefix, hex case, recovery ID) and still verify
+	hash := sha256.Sum256(payload)
+	key := signer.Hex() + ":" + hex.EncodeToString(hash[:])
+	if _, ok := o.seen[key]; ok {
+		return common.Address{}, ErrReplayedCommand
+	}
+	o.seen[key] = now
+	return signer, nil
+}
+
+// setLogLevel changes the log level, for a limited time if a duration is given
+func (o *operatorControl) setLogLevel(args json.RawMessage) (interface{}, error) {
+	var request struct {
+		Level    string `json:"level"`
+		Duration string `json:"duration"`
+	}
+	if err := json.Unmarshal(args, &request); err != nil || request.Level == "" {
+		return nil, fmt.Errorf("expected {\"level\": \"debug|info|warn|error\", \"duration\": \"15m\"}")
+	}
+	var duration time.Duration
+	if request.Duration != "" {
+		d, err := time.ParseDuration(request.Duration)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("invalid duration %q", request.Duration)
+		}
+		duration = d
+	}
+
+	previous, err := logging.GetLevel()
+	if err != nil {
+		return nil, err
+	}
+	if err := logging.SetLevel(request.Level); err != nil {
+		return nil, err
+	}
+	level, _ := logging.GetLevel()
+	result := map[string]interface{}{"level": level, "previous": previous}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	restore := previous
+	if o.revert != nil {
+		// Consecutive temporary changes restore the level from before the first
+		if o.revert.Stop() {
+			restore = o.revertLevel
+		}
+		o.revert = nil
+	}
+	if duration > 0 {
+		o.revertLevel = restore
+		o.revert = time.AfterFunc(duration, func() {
+			if err := logging.SetLevel(restore); err != nil {
+				logging.Warn("failed to restore log level", "level", restore, "error", err)
+				return
+			}
+			logging.Info("restored log level", "level", restore)
+		})
+		result["reverts_at"] = time.Now().Add(duration).UTC()
+	}
+	return result, nil
+}
+
+// dumpGoroutines returns the stack traces of all goroutines
+func dumpGoroutines(json.RawMessage) (interface{}, error) {
+	var buf bytes.Buffer
+	if err := pprof.Lookup("goroutine").WriteTo(&buf, 2); err != nil {
+		return nil, fmt.Errorf("failed to dump goroutines: %w", err)
+	}
+	stacks := buf.String()
+	truncated := len(stacks) > maxGoroutineDump
+	if truncated {
+		stacks = stacks[:maxGoroutineDump]
+	}
+	return map[string]interface{}{
+		"goroutines": runtime.NumGoroutine(),
+		"stacks":     stacks,
+		"truncated":  truncated,
+	}, nil
+}
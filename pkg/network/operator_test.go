@@ -0,0 +1,91 @@
+package network
+
+import (
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/auth"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/types"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// signedOperatorCommand returns an operator command signed by a new operator wallet
+func signedOperatorCommand(t *testing.T) (*operatorControl, *types.Message) {
+	t.Helper()
+	key, address, err := auth.GeneratePrivateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	manager, err := auth.NewManager(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	control := newOperatorControl()
+	control.operators = map[common.Address]bool{common.HexToAddress(address): true}
+
+	msg := &types.Message{
+		Type:      types.MessageTypeOperatorCommand,
+		From:      address,
+		Data:      []byte(`{"command":"dump_goroutines"}`),
+		Timestamp: time.Now(),
+	}
+	payload, err := msg.SigningPayload()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg.Signature, err = manager.SignMessage(string(payload)); err != nil {
+		t.Fatal(err)
+	}
+	return control, msg
+}
+
+func TestOperatorCommandReplayRejected(t *testing.T) {
+	control, msg := signedOperatorCommand(t)
+	if _, err := control.verify(msg); err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	if _, err := control.verify(msg); !errors.Is(err, ErrReplayedCommand) {
+		t.Fatalf("replay error = %v, want ErrReplayedCommand", err)
+	}
+}
+
+func TestOperatorCommandReplayWithReencodedSignature(t *testing.T) {
+	control, msg := signedOperatorCommand(t)
+	if _, err := control.verify(msg); err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+
+	sig, err := hex.DecodeString(strings.TrimPrefix(msg.Signature, "0x"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	lowV := append([]byte(nil), sig...)
+	lowV[64] -= 27
+
+	encodings := map[string]string{
+		"without prefix":  strings.TrimPrefix(msg.Signature, "0x"),
+		"upper case":      "0x" + strings.ToUpper(hex.EncodeToString(sig)),
+		"recovery id 0/1": "0x" + hex.EncodeToString(lowV),
+	}
+	for name, signature := range encodings {
+		t.Run(name, func(t *testing.T) {
+			replay := *msg
+			replay.Signature = signature
+			if _, err := control.verify(&replay); !errors.Is(err, ErrReplayedCommand) {
+				t.Fatalf("error = %v, want ErrReplayedCommand", err)
+			}
+		})
+	}
+}
+
+func TestOperatorCommandFromUnknownWallet(t *testing.T) {
+	control, msg := signedOperatorCommand(t)
+	control.operators = map[common.Address]bool{common.HexToAddress("0x0000000000000000000000000000000000000001"): true}
+	if _, err := control.verify(msg); !errors.Is(err, ErrUnknownOperator) {
+		t.Fatalf("error = %v, want ErrUnknownOperator", err)
+	}
+}
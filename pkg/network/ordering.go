@@ -0,0 +1,80 @@
+package network
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/types"
+)
+
+// Ordering selects the outgoing messages that are delivered in the order
+// they were sent. While a message waits in the retry queue, later messages
+// with the same ordering key are queued behind it instead of overtaking it,
+// so a task's progress updates never arrive after its final result.
+type Ordering string
+
+const (
+	// OrderByTask keeps the messages of each task in order (default)
+	OrderByTask Ordering = "task"
+	// OrderByRoom keeps the messages to each room in order
+	OrderByRoom Ordering = "room"
+	// OrderNone lets a message overtake the earlier ones waiting for a retry
+	OrderNone Ordering = "none"
+)
+
+// ParseOrdering parses an ordering ("" = task)
+func ParseOrdering(s string) (Ordering, error) {
+	switch ordering := Ordering(strings.ToLower(strings.TrimSpace(s))); ordering {
+	case "":
+		return OrderByTask, nil
+	case OrderByTask, OrderByRoom, OrderNone:
+		return ordering, nil
+	}
+	return "", fmt.Errorf("invalid send ordering %q (use \"task\", \"room\" or \"none\")", s)
+}
+
+// key returns the ordering key of a message, empty if it is not ordered
+func (o Ordering) key(msg *types.Message) string {
+	switch o {
+	case OrderByRoom:
+		return msg.Room
+	case OrderNone:
+		return ""
+	default:
+		return msg.TaskID
+	}
+}
+
+// sequence numbers the task responses sent for a task. Holding it while a
+// response is numbered and sent keeps responses sent concurrently, e.g. a
+// progress update and the result, in the order of their numbers.
+type sequence struct {
+	mu   sync.Mutex
+	last uint64
+}
+
+// next returns the number of the next response, 0 for a nil sequence (must
+// hold mu)
+func (s *sequence) next() uint64 {
+	if s == nil {
+		return 0
+	}
+	s.last++
+	return s.last
+}
+
+// sequenceKey is the context key of the sequence of a task's responses
+type sequenceKey struct{}
+
+// withSequence returns a context whose task responses are numbered from 1
+func withSequence(ctx context.Context) context.Context {
+	return context.WithValue(ctx, sequenceKey{}, &sequence{})
+}
+
+// sequenceFromContext returns the sequence of a task's responses (nil = not numbered)
+func sequenceFromContext(ctx context.Context) *sequence {
+	seq, _ := ctx.Value(sequenceKey{}).(*sequence)
+	return seq
+}
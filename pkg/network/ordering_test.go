@@ -0,0 +1,207 @@
+package network
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/types"
+)
+
+var errSendFailed = errors.New("connection lost")
+
+// scriptedSender records the messages sent and fails those whose content
+// is listed in failing
+type scriptedSender struct {
+	mu      sync.Mutex
+	failing map[string]bool
+	sent    []string
+}
+
+func (s *scriptedSender) send(msg *types.Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.failing[msg.Content] {
+		return errSendFailed
+	}
+	s.sent = append(s.sent, msg.Content)
+	return nil
+}
+
+func (s *scriptedSender) setFailing(content string, failing bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failing[content] = failing
+}
+
+func (s *scriptedSender) take() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sent := s.sent
+	s.sent = nil
+	return sent
+}
+
+// newOrderingQueue returns a retry queue whose retries only happen on Flush
+func newOrderingQueue(maxRetries int) (*MessageRetryQueue, *scriptedSender) {
+	sender := &scriptedSender{failing: make(map[string]bool)}
+	queue := NewMessageRetryQueue(&RetryPolicy{
+		MaxRetries:     maxRetries,
+		InitialDelay:   time.Hour,
+		MaxDelay:       time.Hour,
+		BackoffFactor:  1,
+		RetryableError: func(error) bool { return true },
+	}, sender.send)
+	return queue, sender
+}
+
+func orderedMessage(taskID, room, content string) *types.Message {
+	return &types.Message{Type: "task_response", TaskID: taskID, Room: room, Content: content}
+}
+
+func equalSent(t *testing.T, got []string, want ...string) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("sent %q, want %q", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("sent %q, want %q", got, want)
+		}
+	}
+}
+
+func TestLaterMessageHeldUntilEarlierDelivered(t *testing.T) {
+	queue, sender := newOrderingQueue(5)
+	sender.setFailing("update", true)
+
+	update := orderedMessage("task-1", "room-1", "update")
+	queue.Enqueue(update, errSendFailed)
+	if !queue.Hold(orderedMessage("task-1", "room-1", "result")) {
+		t.Fatal("result was not held behind the queued update")
+	}
+
+	// The update fails again: the result stays behind it
+	queue.Flush()
+	equalSent(t, sender.take())
+	if size := queue.GetQueueSize(); size != 2 {
+		t.Fatalf("queue size = %d, want 2", size)
+	}
+
+	// Once the update is delivered the result follows in the same pass
+	sender.setFailing("update", false)
+	queue.Flush()
+	equalSent(t, sender.take(), "update", "result")
+	if size := queue.GetQueueSize(); size != 0 {
+		t.Fatalf("queue size = %d, want 0", size)
+	}
+}
+
+func TestHeldMessageReleasedWhenEarlierDeadLettered(t *testing.T) {
+	queue, sender := newOrderingQueue(1)
+	sender.setFailing("update", true)
+
+	queue.Enqueue(orderedMessage("task-1", "room-1", "update"), errSendFailed)
+	if !queue.Hold(orderedMessage("task-1", "room-1", "result")) {
+		t.Fatal("result was not held behind the queued update")
+	}
+
+	// The update runs out of retries and is dead-lettered; the result is sent
+	queue.Flush()
+	equalSent(t, sender.take(), "result")
+	if letters := queue.DeadLetters(); len(letters) != 1 || letters[0].Message.Content != "update" {
+		t.Fatalf("dead letters = %+v, want the update", letters)
+	}
+
+	// Nothing is pending for the task any more
+	if queue.Hold(orderedMessage("task-1", "room-1", "late")) {
+		t.Error("message held although nothing is pending for its task")
+	}
+}
+
+func TestHeldMessageReleasedWhenEarlierNotRetryable(t *testing.T) {
+	queue, _ := newOrderingQueue(5)
+	queue.policy.RetryableError = func(error) bool { return false }
+
+	// A message that cannot be retried goes straight to the dead-letter queue
+	queue.Enqueue(orderedMessage("task-1", "room-1", "update"), errSendFailed)
+	if queue.Hold(orderedMessage("task-1", "room-1", "result")) {
+		t.Error("result held behind a dead-lettered message")
+	}
+}
+
+func TestIndependentKeysDoNotBlock(t *testing.T) {
+	queue, sender := newOrderingQueue(5)
+	sender.setFailing("task-1 update", true)
+
+	queue.Enqueue(orderedMessage("task-1", "room-1", "task-1 update"), errSendFailed)
+	if queue.Hold(orderedMessage("task-2", "room-1", "task-2 result")) {
+		t.Fatal("message of another task held")
+	}
+
+	queue.Enqueue(orderedMessage("task-2", "room-1", "task-2 update"), errSendFailed)
+	queue.Flush()
+	equalSent(t, sender.take(), "task-2 update")
+	if size := queue.GetQueueSize(); size != 1 {
+		t.Fatalf("queue size = %d, want 1", size)
+	}
+}
+
+func TestOrderByRoomAndNone(t *testing.T) {
+	queue, _ := newOrderingQueue(5)
+	queue.SetOrdering(OrderByRoom)
+	queue.Enqueue(orderedMessage("task-1", "room-1", "update"), errSendFailed)
+	if !queue.Hold(orderedMessage("task-2", "room-1", "other task, same room")) {
+		t.Error("message to the same room not held with room ordering")
+	}
+	if queue.Hold(orderedMessage("task-1", "room-2", "other room")) {
+		t.Error("message to another room held with room ordering")
+	}
+
+	queue, _ = newOrderingQueue(5)
+	queue.SetOrdering(OrderNone)
+	queue.Enqueue(orderedMessage("task-1", "room-1", "update"), errSendFailed)
+	if queue.Hold(orderedMessage("task-1", "room-1", "unordered")) {
+		t.Error("message held without ordering")
+	}
+}
+
+func TestParseOrdering(t *testing.T) {
+	tests := map[string]Ordering{"": OrderByTask, "task": OrderByTask, " Room ": OrderByRoom, "none": OrderNone}
+	for input, want := range tests {
+		got, err := ParseOrdering(input)
+		if err != nil || got != want {
+			t.Errorf("ParseOrdering(%q) = %q, %v, want %q", input, got, err, want)
+		}
+	}
+	if _, err := ParseOrdering("priority"); err == nil {
+		t.Error("ParseOrdering accepted an unknown ordering")
+	}
+}
+
+func TestTaskResponsesNumberedInOrder(t *testing.T) {
+	protocol := newTestCoordinator(&standardHandler{}).protocolHandler
+	recorder := &responseRecorder{}
+
+	ctx := withResponder(withSequence(context.Background()), recorder.respond)
+	for _, content := range []string{"first", "second", "third"} {
+		if err := protocol.SendTaskResponseToRoomContext(ctx, "task-1", content, types.StandardMessageTypeString, true, "", "room-1"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i, msg := range recorder.take() {
+		if msg.Sequence != uint64(i+1) {
+			t.Errorf("response %q has sequence %d, want %d", msg.Content, msg.Sequence, i+1)
+		}
+	}
+
+	// Responses outside a task are not numbered
+	if err := protocol.SendTaskResponseToRoomContext(withResponder(context.Background(), recorder.respond), "task-2", "alone", types.StandardMessageTypeString, true, "", "room-1"); err != nil {
+		t.Fatal(err)
+	}
+	if msg := recorder.take()[0]; msg.Sequence != 0 {
+		t.Errorf("unnumbered response has sequence %d", msg.Sequence)
+	}
+}
@@ -0,0 +1,70 @@
+package network
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/logging"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/types"
+)
+
+// ErrHandlerPanic is wrapped by the errors of tasks whose handler panicked
+var ErrHandlerPanic = errors.New("agent handler panicked")
+
+// HandlerPanic is a panic recovered from an agent handler
+type HandlerPanic struct {
+	TaskID  string
+	Handler string // "standard", "conversation" or "streaming"
+	Value   interface{}
+	Stack   []byte
+}
+
+// Error implements the error interface
+func (p *HandlerPanic) Error() string {
+	return fmt.Sprintf("%v: %v", ErrHandlerPanic, p.Value)
+}
+
+// Unwrap returns ErrHandlerPanic
+func (p *HandlerPanic) Unwrap() error {
+	return ErrHandlerPanic
+}
+
+// SetPanicHandler sets a function called after a handler panicked, e.g. to
+// reinitialize the handler (nil = only log and report the failure). It runs
+// on the task's goroutine after the task failed.
+func (t *TaskCoordinator) SetPanicHandler(fn func(ctx context.Context, p *HandlerPanic)) {
+	t.rateLimitMu.Lock()
+	defer t.rateLimitMu.Unlock()
+	t.onPanic = fn
+}
+
+// getPanicHandler returns the configured panic handler
+func (t *TaskCoordinator) getPanicHandler() func(ctx context.Context, p *HandlerPanic) {
+	t.rateLimitMu.Lock()
+	defer t.rateLimitMu.Unlock()
+	return t.onPanic
+}
+
+// callHandler calls the agent handler through fn, turning a panic into an
+// internal task error that is not retried. The panic's value stays out of
+// the response; it is logged with the stack trace.
+func (t *TaskCoordinator) callHandler(ctx context.Context, taskID, handlerType string, fn func() error) (err error) {
+	defer func() {
+		r := recover()
+		if r == nil {
+			return
+		}
+		p := &HandlerPanic{TaskID: taskID, Handler: handlerType, Value: r, Stack: debug.Stack()}
+		logging.Error("agent handler panicked", "task_id", taskID, "handler", handlerType, "panic", r, "stack", string(p.Stack))
+		if recorder, ok := t.getMetricsRecorder().(types.PanicRecorder); ok {
+			recorder.RecordHandlerPanic(handlerType)
+		}
+		err = &types.TaskError{Code: types.ErrorCodeInternal, Message: "the agent failed unexpectedly", Err: p}
+		if onPanic := t.getPanicHandler(); onPanic != nil {
+			onPanic(context.WithoutCancel(ctx), p)
+		}
+	}()
+	return fn()
+}
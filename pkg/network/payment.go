@@ -0,0 +1,87 @@
+package network
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/logging"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/output"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/types"
+)
+
+// SetPaymentVerifier sets the verifier that checks a task was paid for
+// before it runs (nil disables payment checks)
+func (t *TaskCoordinator) SetPaymentVerifier(verifier types.PaymentVerifier) {
+	t.rateLimitMu.Lock()
+	defer t.rateLimitMu.Unlock()
+	t.paymentVerifier = verifier
+}
+
+// getPaymentVerifier returns the configured payment verifier
+func (t *TaskCoordinator) getPaymentVerifier() types.PaymentVerifier {
+	t.rateLimitMu.Lock()
+	defer t.rateLimitMu.Unlock()
+	return t.paymentVerifier
+}
+
+// checkPayment verifies the task's payment and rejects unpaid tasks with the
+// payment error in the rejection details. Returns the rejection code, or ""
+// if the task can be processed.
+func (t *TaskCoordinator) checkPayment(ctx context.Context, msg *types.Message, taskID string) string {
+	verifier := t.getPaymentVerifier()
+	if verifier == nil {
+		return ""
+	}
+
+	err := verifier.VerifyPayment(ctx, types.PaymentInfo{
+		TaskID:       taskID,
+		Sender:       SenderFromContext(ctx),
+		Capabilities: t.extractRequiredCapabilities(msg),
+		Offered:      t.Capabilities(),
+		Proof:        extractPaymentProof(msg),
+	})
+	if err == nil {
+		return ""
+	}
+
+	var content, errorCode string
+	switch {
+	case errors.Is(err, types.ErrPaymentRequired):
+		content, errorCode = "⚠️ This task requires payment.", "payment_required"
+	case errors.Is(err, types.ErrPaymentInvalid):
+		content, errorCode = "⚠️ The payment for this task was not accepted.", "payment_invalid"
+	default:
+		content, errorCode = "⚠️ The payment for this task could not be verified. Please try again later.", "payment_unverified"
+	}
+
+	details := map[string]interface{}{"reason": err.Error()}
+	var paymentErr *types.PaymentError
+	if errors.As(err, &paymentErr) {
+		details = map[string]interface{}{"payment": paymentErr}
+		if paymentErr.Amount != "" && paymentErr.Payee != "" {
+			content += fmt.Sprintf(" Send %s %s to %s and include the transaction hash with the task.", paymentErr.Amount, paymentErr.Currency, paymentErr.Payee)
+		}
+	}
+
+	logging.Warn("payment check rejected task", "task_id", taskID, "code", errorCode, "error", err)
+	t.recordRejection(errorCode)
+	t.protocolHandler.SendTaskRejection(ctx, taskID, output.Clean(content), errorCode, msg.Room, details)
+	return errorCode
+}
+
+// extractPaymentProof returns the "payment" field of the task data
+func extractPaymentProof(msg *types.Message) json.RawMessage {
+	if msg.Data == nil {
+		return nil
+	}
+
+	var taskData struct {
+		Payment json.RawMessage `json:"payment"`
+	}
+	if err := json.Unmarshal(msg.Data, &taskData); err != nil {
+		return nil
+	}
+	return taskData.Payment
+}
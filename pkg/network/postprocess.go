@@ -0,0 +1,202 @@
+package network
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/types"
+)
+
+// PostProcessor transforms the content of an outgoing task response, e.g. to redact
+// personal data, filter words or trim its length. Returning an error stops the
+// response from being sent.
+type PostProcessor func(ctx context.Context, contentType, content string) (string, error)
+
+// PostProcessorPipeline applies post-processors to task responses in the order they were added
+type PostProcessorPipeline struct {
+	mu     sync.RWMutex
+	stages []postProcessorStage
+}
+
+// postProcessorStage is a named post-processor and the content types it applies to
+type postProcessorStage struct {
+	name         string
+	processor    PostProcessor
+	contentTypes map[string]bool // Empty = every text content type
+}
+
+// NewPostProcessorPipeline creates an empty post-processor pipeline
+func NewPostProcessorPipeline() *PostProcessorPipeline {
+	return &PostProcessorPipeline{}
+}
+
+// Add appends a post-processor applied to the given content types
+// (types.StandardMessageTypeString, ...), or to every content type if none are given.
+// Images and audio only go through post-processors added for their type.
+// A post-processor added under an existing name replaces it in place.
+func (p *PostProcessorPipeline) Add(name string, processor PostProcessor, contentTypes ...string) {
+	stage := postProcessorStage{name: name, processor: processor}
+	if len(contentTypes) > 0 {
+		stage.contentTypes = make(map[string]bool, len(contentTypes))
+		for _, contentType := range contentTypes {
+			stage.contentTypes[contentType] = true
+		}
+	}
+
+	// Stages are copied on write, so Process can run without holding the lock
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	stages := append([]postProcessorStage(nil), p.stages...)
+	for i := range stages {
+		if stages[i].name == name {
+			stages[i] = stage
+			p.stages = stages
+			return
+		}
+	}
+	p.stages = append(stages, stage)
+}
+
+// Remove removes the post-processor with the given name and reports whether it existed
+func (p *PostProcessorPipeline) Remove(name string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for i := range p.stages {
+		if p.stages[i].name == name {
+			stages := make([]postProcessorStage, 0, len(p.stages)-1)
+			stages = append(stages, p.stages[:i]...)
+			p.stages = append(stages, p.stages[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Names returns the names of the post-processors in the order they run
+func (p *PostProcessorPipeline) Names() []string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	names := make([]string, len(p.stages))
+	for i, stage := range p.stages {
+		names[i] = stage.name
+	}
+	return names
+}
+
+// Process runs content through the post-processors registered for its content type.
+// Progress and status updates are SDK control messages and are not processed.
+func (p *PostProcessorPipeline) Process(ctx context.Context, contentType, content string) (string, error) {
+	if contentType == types.StandardMessageTypeProgress || contentType == types.StandardMessageTypeStatus {
+		return content, nil
+	}
+
+	p.mu.RLock()
+	stages := p.stages
+	p.mu.RUnlock()
+
+	binary := types.IsBinaryContentType(contentType)
+	for _, stage := range stages {
+		if stage.contentTypes != nil && !stage.contentTypes[contentType] {
+			continue
+		}
+		// Encoded media only goes through processors added for its type
+		if stage.contentTypes == nil && binary {
+			continue
+		}
+		processed, err := stage.processor(ctx, contentType, content)
+		if err != nil {
+			return "", fmt.Errorf("post-processor %s failed: %w", stage.name, err)
+		}
+		content = processed
+	}
+	return content, nil
+}
+
+// TrimLength returns a post-processor that cuts content to at most maxChars
+// characters, ending it with suffix (e.g. "…") when it was cut
+func TrimLength(maxChars int, suffix string) PostProcessor {
+	return func(ctx context.Context, contentType, content string) (string, error) {
+		if maxChars <= 0 || utf8.RuneCountInString(content) <= maxChars {
+			return content, nil
+		}
+		keep := max(maxChars-utf8.RuneCountInString(suffix), 0)
+		runes := []rune(content)
+		return string(runes[:keep]) + suffix, nil
+	}
+}
+
+// ReplaceWords returns a post-processor that replaces whole words, ignoring case,
+// with replacement (e.g. "***"). It can serve as a simple profanity filter.
+func ReplaceWords(words []string, replacement string) PostProcessor {
+	if len(words) == 0 {
+		return func(ctx context.Context, contentType, content string) (string, error) {
+			return content, nil
+		}
+	}
+
+	quoted := make([]string, len(words))
+	for i, word := range words {
+		quoted[i] = regexp.QuoteMeta(word)
+	}
+	pattern := regexp.MustCompile(`(?i)\b(?:` + strings.Join(quoted, "|") + `)\b`)
+
+	return func(ctx context.Context, contentType, content string) (string, error) {
+		return pattern.ReplaceAllLiteralString(content, replacement), nil
+	}
+}
+
+// NormalizeMarkdown returns a post-processor that tidies markdown: it strips
+// trailing whitespace, collapses runs of blank lines and closes an unterminated
+// code fence so the rest of the chat is not rendered as code
+func NormalizeMarkdown() PostProcessor {
+	return func(ctx context.Context, contentType, content string) (string, error) {
+		lines := strings.Split(content, "\n")
+		normalized := make([]string, 0, len(lines))
+		inFence := false
+		blank := 0
+
+		for _, line := range lines {
+			if strings.HasPrefix(strings.TrimSpace(line), "```") {
+				inFence = !inFence
+			}
+			if !inFence {
+				line = strings.TrimRight(line, " \t")
+			}
+
+			if line == "" && !inFence {
+				blank++
+				if blank > 1 {
+					continue
+				}
+			} else {
+				blank = 0
+			}
+			normalized = append(normalized, line)
+		}
+
+		if inFence {
+			normalized = append(normalized, "```")
+		}
+		return strings.Trim(strings.Join(normalized, "\n"), "\n"), nil
+	}
+}
+
+// PostProcessors returns the pipeline applied to the content of every task response
+func (p *ProtocolHandler) PostProcessors() *PostProcessorPipeline {
+	return p.postProcessors
+}
+
+// AddPostProcessor appends a post-processor applied to the task responses of the given
+// content types, or of every content type if none are given
+func (t *TaskCoordinator) AddPostProcessor(name string, processor PostProcessor, contentTypes ...string) {
+	t.protocolHandler.postProcessors.Add(name, processor, contentTypes...)
+}
+
+// RemovePostProcessor removes the post-processor with the given name and reports whether it existed
+func (t *TaskCoordinator) RemovePostProcessor(name string) bool {
+	return t.protocolHandler.postProcessors.Remove(name)
+}
@@ -0,0 +1,100 @@
+package network
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/logging"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/output"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/scheduler"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/types"
+)
+
+// SetTaskScheduler queues tasks on the scheduler's workers by priority (nil =
+// every task starts when it arrives). A replaced scheduler is closed; tasks
+// still waiting in it are answered as not processed.
+func (t *TaskCoordinator) SetTaskScheduler(s *scheduler.Scheduler) {
+	t.rateLimitMu.Lock()
+	previous := t.scheduler
+	t.scheduler = s
+	t.rateLimitMu.Unlock()
+
+	if previous != nil && previous != s {
+		previous.Close()
+	}
+}
+
+// GetTaskScheduler returns the task scheduler, nil if tasks are not queued
+func (t *TaskCoordinator) GetTaskScheduler() *scheduler.Scheduler {
+	t.rateLimitMu.Lock()
+	defer t.rateLimitMu.Unlock()
+	return t.scheduler
+}
+
+// extractPriority returns the priority in the task metadata, normal if it states none
+func (t *TaskCoordinator) extractPriority(msg *types.Message) scheduler.Priority {
+	if msg.Data == nil {
+		return scheduler.PriorityNormal
+	}
+
+	var taskData map[string]interface{}
+	if err := json.Unmarshal(msg.Data, &taskData); err != nil {
+		return scheduler.PriorityNormal
+	}
+
+	priority, err := scheduler.ParsePriority(taskData["priority"])
+	if err != nil {
+		logging.Warn("ignoring invalid task priority", "error", err)
+	}
+	return priority
+}
+
+// scheduleTask queues a checked task and returns its status once it ran if
+// wait is set, "queued" otherwise. run executes the task and returns
+// "preempted" if it was stopped for a higher-priority task, which runs it again later.
+func (t *TaskCoordinator) scheduleTask(ctx context.Context, s *scheduler.Scheduler, msg *types.Message, taskID string, run func(context.Context) string, wait bool) string {
+	priority := t.extractPriority(msg)
+	result := make(chan string, 1)
+
+	err := s.Submit(ctx, scheduler.Task{ID: taskID, Priority: priority, Run: func(runCtx context.Context) {
+		if errors.Is(context.Cause(runCtx), scheduler.ErrClosed) {
+			logging.Warn("agent stopped before the queued task ran", "task_id", taskID)
+			t.recordRejection("agent_stopping")
+			t.protocolHandler.SendTaskResponseToRoomContext(ctx, taskID, output.Clean("⚠️ The agent stopped before it could process this request. Please try again."), types.StandardMessageTypeString, false, "agent_stopping", msg.Room)
+			result <- "agent_stopping"
+			return
+		}
+		if status := run(runCtx); status != "preempted" {
+			result <- status
+		}
+	}})
+	if err != nil {
+		status := "queue_full"
+		content := "⚠️ This agent is busy. Please try again in a moment."
+		if errors.Is(err, scheduler.ErrClosed) {
+			status = "agent_stopping"
+			content = "⚠️ The agent is stopping and cannot process this request. Please try again."
+		}
+		logging.Warn("could not queue task, rejecting task", "task_id", taskID, "priority", priority, "error", err)
+		t.recordRejection(status)
+		t.protocolHandler.SendTaskResponseToRoomContext(ctx, taskID, output.Clean(content), types.StandardMessageTypeString, false, status, msg.Room)
+		return status
+	}
+
+	logging.Debug("task queued", "task_id", taskID, "priority", priority)
+	if !wait {
+		return "queued"
+	}
+	select {
+	case status := <-result:
+		return status
+	case <-ctx.Done():
+		return "cancelled"
+	}
+}
+
+// preempted reports whether the task of ctx was stopped for a higher-priority task
+func preempted(ctx context.Context) bool {
+	return errors.Is(context.Cause(ctx), scheduler.ErrPreempted)
+}
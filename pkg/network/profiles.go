@@ -0,0 +1,227 @@
+package network
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/cache"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/logging"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/types"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// ErrProfileNotFound is returned when the backend has no profile for an address
+var ErrProfileNotFound = errors.New("user profile not found")
+
+// profileCachePrefix prefixes the cache keys of resolved profiles
+const profileCachePrefix = "profile:"
+
+// ProfileConfig configures a ProfileResolver
+type ProfileConfig struct {
+	TTL         time.Duration // How long resolved profiles are cached
+	NotFoundTTL time.Duration // How long addresses without a profile are cached
+	Timeout     time.Duration // How long to wait for the server's response
+}
+
+// DefaultProfileConfig returns the default profile resolver configuration
+func DefaultProfileConfig() *ProfileConfig {
+	return &ProfileConfig{
+		TTL:         time.Hour,
+		NotFoundTTL: 5 * time.Minute,
+		Timeout:     10 * time.Second,
+	}
+}
+
+// ProfileResolver resolves sender wallet addresses into user profiles.
+// Profiles are requested from the server in batches and cached in the agent cache.
+type ProfileResolver struct {
+	protocol *ProtocolHandler
+	cache    cache.AgentCache
+	config   *ProfileConfig
+}
+
+// NewProfileResolver creates a profile resolver caching profiles in agentCache
+func NewProfileResolver(protocol *ProtocolHandler, agentCache cache.AgentCache, config *ProfileConfig) *ProfileResolver {
+	if config == nil {
+		config = DefaultProfileConfig()
+	}
+	if agentCache == nil {
+		agentCache = &cache.NoOpCache{}
+	}
+
+	return &ProfileResolver{
+		protocol: protocol,
+		cache:    agentCache,
+		config:   config,
+	}
+}
+
+// Resolve returns the profile of address, or ErrProfileNotFound if the backend has none
+func (r *ProfileResolver) Resolve(ctx context.Context, address string) (*types.UserProfile, error) {
+	profiles, err := r.ResolveMany(ctx, []string{address})
+	if err != nil {
+		return nil, err
+	}
+	profile, ok := profiles[normalizeProfileAddress(address)]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrProfileNotFound, address)
+	}
+	return profile, nil
+}
+
+// ResolveMany returns the profiles of addresses, keyed by address (lowercased
+// for hex addresses). Addresses without a profile are left out.
+func (r *ProfileResolver) ResolveMany(ctx context.Context, addresses []string) (map[string]*types.UserProfile, error) {
+	profiles := make(map[string]*types.UserProfile, len(addresses))
+	var missing []string
+	seen := make(map[string]bool, len(addresses))
+
+	for _, address := range addresses {
+		address = normalizeProfileAddress(address)
+		if address == "" || seen[address] {
+			continue
+		}
+		seen[address] = true
+
+		profile, cached := r.cached(ctx, address)
+		switch {
+		case !cached:
+			missing = append(missing, address)
+		case profile != nil:
+			profiles[address] = profile
+		}
+	}
+
+	if len(missing) == 0 {
+		return profiles, nil
+	}
+
+	fetched, err := r.fetch(ctx, missing)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, address := range missing {
+		profile := fetched[address]
+		r.store(ctx, address, profile)
+		if profile != nil {
+			profiles[address] = profile
+		}
+	}
+	return profiles, nil
+}
+
+// DisplayName returns a name to address the user by: the profile's display name
+// or username, or a shortened address when no profile is available
+func (r *ProfileResolver) DisplayName(ctx context.Context, address string) string {
+	profile, err := r.Resolve(ctx, address)
+	if err != nil {
+		if !errors.Is(err, ErrProfileNotFound) {
+			logging.Debug("failed to resolve user profile", "address", address, "error", err)
+		}
+		return shortAddress(address)
+	}
+
+	switch {
+	case profile.DisplayName != "":
+		return profile.DisplayName
+	case profile.Username != "":
+		return profile.Username
+	default:
+		return shortAddress(address)
+	}
+}
+
+// cached returns the cached profile of address. A nil profile with cached set
+// means the backend is known to have no profile for the address.
+func (r *ProfileResolver) cached(ctx context.Context, address string) (profile *types.UserProfile, cached bool) {
+	data, err := r.cache.GetBytes(ctx, profileCachePrefix+address)
+	if err != nil {
+		return nil, false
+	}
+	if err := json.Unmarshal(data, &profile); err != nil {
+		return nil, false
+	}
+	return profile, true
+}
+
+// store caches the profile of address, or that it has none when profile is nil
+func (r *ProfileResolver) store(ctx context.Context, address string, profile *types.UserProfile) {
+	ttl := r.config.TTL
+	if profile == nil {
+		ttl = r.config.NotFoundTTL
+	}
+	if ttl <= 0 {
+		return
+	}
+
+	if err := r.cache.Set(ctx, profileCachePrefix+address, profile, ttl); err != nil {
+		logging.Debug("failed to cache user profile", "address", address, "error", err)
+	}
+}
+
+// fetch requests the profiles of addresses from the server
+func (r *ProfileResolver) fetch(ctx context.Context, addresses []string) (map[string]*types.UserProfile, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.config.Timeout)
+	defer cancel()
+
+	response, err := r.protocol.request(ctx, types.MessageTypeUserProfiles, r.protocol.room, map[string]interface{}{
+		"addresses": addresses,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch user profiles: %w", err)
+	}
+
+	var result struct {
+		Profiles []types.UserProfile `json:"profiles"`
+		Error    string              `json:"error"`
+	}
+	if err := json.Unmarshal(response.Data, &result); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal user profiles response: %w", err)
+	}
+	if result.Error != "" {
+		return nil, fmt.Errorf("server failed to return user profiles: %s", result.Error)
+	}
+
+	profiles := make(map[string]*types.UserProfile, len(result.Profiles))
+	for i := range result.Profiles {
+		profile := &result.Profiles[i]
+		profiles[normalizeProfileAddress(profile.Address)] = profile
+	}
+	return profiles, nil
+}
+
+// normalizeProfileAddress lowercases hex addresses; other user identifiers are kept as-is
+func normalizeProfileAddress(address string) string {
+	address = strings.TrimSpace(address)
+	if common.IsHexAddress(address) {
+		return strings.ToLower(address)
+	}
+	return address
+}
+
+// shortAddress shortens a hex address to its first and last four digits, e.g. 0x1234…abcd
+func shortAddress(address string) string {
+	if common.IsHexAddress(address) && strings.HasPrefix(address, "0x") {
+		return address[:6] + "…" + address[len(address)-4:]
+	}
+	return address
+}
+
+// senderKey is the context key of the task sender
+type senderKey struct{}
+
+// WithSender returns a context carrying the address of the user who sent the task
+func WithSender(ctx context.Context, sender string) context.Context {
+	return context.WithValue(ctx, senderKey{}, sender)
+}
+
+// SenderFromContext returns the address of the user who sent the task ("" when unknown)
+func SenderFromContext(ctx context.Context) string {
+	sender, _ := ctx.Value(senderKey{}).(string)
+	return sender
+}
@@ -1,13 +1,16 @@
 package network
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
-	"log"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/auth"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/e2e"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/logging"
 	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/types"
 )
 
@@ -16,12 +19,30 @@ type ProtocolHandler struct {
 	client                 *NetworkClient
 	auth                   *auth.Manager
 	agentName              string
-	capabilities           []string
+	capabilities           []string // Guarded by resourcesMu
 	walletAddr             string
 	nftTokenID             string
 	room                   string
 	lastChallenge          string
 	lastChallengeSignature string
+	agentsMu               sync.RWMutex
+	agents                 []types.AgentStatus // Agents from the last agents response
+	agentsUpdated          chan struct{}       // Closed and replaced when a new agents response arrives
+	signingMu              sync.RWMutex
+	signer                 *messageSigner    // Task verification and response signing, nil until SetMessageSigning
+	encryption             *EncryptionConfig // End-to-end encryption of task content, guarded by signingMu, nil = disabled
+	requestsMu             sync.Mutex
+	requests               map[string]chan *types.Message // Requests waiting for a response, by request ID
+	registeredMu           sync.Mutex
+	onRegistered           []func()
+	postProcessors         *PostProcessorPipeline
+	resourcesMu            sync.RWMutex
+	resources              *types.ComputeResources    // Hardware advertised to the server, nil if not advertised
+	inputSchemas           map[string]json.RawMessage // Task input schema per capability, guarded by resourcesMu
+	manifest               []types.AgentCapability    // Capability manifest, guarded by resourcesMu
+	session                *session                   // Authentication state, session expiry and refresh timers
+	duplicates             *duplicateGuard            // Instance ID and duplicate-connection policy
+	operator               *operatorControl           // Operator wallets and commands
 }
 
 // NewProtocolHandler creates a new protocol handler
@@ -36,11 +57,25 @@ func NewProtocolHandler(client *NetworkClient, authManager *auth.Manager, agentN
 		room:                   room,
 		lastChallenge:          "",
 		lastChallengeSignature: "",
+		agentsUpdated:          make(chan struct{}),
+		requests:               make(map[string]chan *types.Message),
+		postProcessors:         NewPostProcessorPipeline(),
+		session:                newSession(),
+		duplicates:             newDuplicateGuard(),
+		operator:               newOperatorControl(),
 	}
 
 	// Register message handlers
 	handler.registerHandlers()
 
+	// Task output moves to the data channel once the session is registered
+	if client.data != nil {
+		handler.OnRegistered(handler.openDataChannel)
+	}
+
+	// A new connection needs a new session
+	handler.addReconnectHooks()
+
 	return handler
 }
 
@@ -58,17 +93,28 @@ func (p *ProtocolHandler) registerHandlers() {
 	p.client.RegisterHandler("capabilities", p.HandleCapabilitiesResponse)
 	p.client.RegisterHandler("register", p.HandleRegisterResponse)
 	p.client.RegisterHandler("agents", p.HandleAgentsResponse)
+	p.client.RegisterHandler(types.MessageTypeRoomHistory, p.HandleResponse)
+	p.client.RegisterHandler(types.MessageTypeUserProfiles, p.HandleResponse)
+
+	// Another process using the agent's identity
+	for _, msgType := range duplicateMessageTypes {
+		p.client.RegisterHandler(msgType, p.HandleDuplicateConnection)
+	}
+
+	// Log level and diagnostics requested by the agent's operator
+	p.client.RegisterHandler(types.MessageTypeOperatorCommand, p.HandleOperatorMessage)
 
 	// Add task handling
-	p.client.RegisterHandler("task", p.HandleTask)
+	p.client.RegisterHandler("task", p.VerifyTasks(p.HandleTask))
 }
 
 // StartAuthentication initiates the authentication process
 func (p *ProtocolHandler) StartAuthentication() error {
-	log.Println("🔐 Starting authentication process...")
+	logging.Info("starting authentication process")
 	// Clear any previous authentication state
 	p.lastChallenge = ""
 	p.lastChallengeSignature = ""
+	p.beginAuthentication()
 	return p.RequestChallenge()
 }
 
@@ -81,13 +127,13 @@ func (p *ProtocolHandler) RequestChallenge() error {
 		Timestamp: time.Now(),
 	}
 
-	log.Println("🔍 Requesting authentication challenge...")
+	logging.Info("requesting authentication challenge")
 	return p.client.SendMessage(msg)
 }
 
 // HandleChallenge handles incoming authentication challenges
 func (p *ProtocolHandler) HandleChallenge(msg *types.Message) error {
-	log.Printf("🔐 Received challenge from server")
+	logging.Info("received challenge from server")
 
 	var challengeData map[string]interface{}
 	if err := json.Unmarshal(msg.Data, &challengeData); err != nil {
@@ -101,13 +147,18 @@ func (p *ProtocolHandler) HandleChallenge(msg *types.Message) error {
 
 	// Store the challenge for later use in registration
 	p.lastChallenge = challenge
+	p.trackChallenge(msg.Data)
+	if p.challengeExpired() {
+		logging.Warn("received challenge has already expired, requesting a new one")
+		return p.RequestChallenge()
+	}
 
 	return p.Authenticate(challenge)
 }
 
 // Authenticate responds to an authentication challenge
 func (p *ProtocolHandler) Authenticate(challenge string) error {
-	log.Printf("🔐 Signing authentication challenge...")
+	logging.Info("signing authentication challenge")
 
 	// Create the message to sign
 	messageToSign := fmt.Sprintf("Teneo authentication challenge: %s", challenge)
@@ -129,6 +180,7 @@ func (p *ProtocolHandler) Authenticate(challenge string) error {
 		UserType:   "agent",
 		AgentName:  p.agentName,
 		NFTTokenID: p.nftTokenID,
+		Takeover:   p.takeoverRequested(false),
 	}
 
 	authDataJson, err := json.Marshal(authData)
@@ -137,8 +189,12 @@ func (p *ProtocolHandler) Authenticate(challenge string) error {
 	}
 
 	// Add debug logging to see what we're actually sending
-	log.Printf("🐛 DEBUG: Auth data being sent: %s", string(authDataJson))
-	log.Printf("🔑 Authenticating with NFT Token ID: %s", p.nftTokenID)
+	logging.Debug("auth data being sent", "auth_data", string(authDataJson))
+	if p.nftTokenID == "" {
+		logging.Info("authenticating with wallet signature", "wallet", p.walletAddr)
+	} else {
+		logging.Info("authenticating with NFT token ID", "token_id", p.nftTokenID)
+	}
 
 	msg := &types.Message{
 		Type:      "auth",
@@ -148,76 +204,114 @@ func (p *ProtocolHandler) Authenticate(challenge string) error {
 		Timestamp: time.Now(),
 	}
 
-	log.Printf("📤 Sending authentication response...")
-	return p.client.SendMessage(msg)
+	logging.Info("sending authentication response")
+	if err := p.client.SendMessage(msg); err != nil {
+		return err
+	}
+	p.awaitAuthResponse()
+	return nil
 }
 
 // HandleAuthResponse handles authentication responses
 func (p *ProtocolHandler) HandleAuthResponse(msg *types.Message) error {
-	log.Printf("🐛 DEBUG: Received auth response - Type: %s, Content: %s", msg.Type, msg.Content)
+	logging.Debug("received auth response", "type", msg.Type, "content", msg.Content)
 	if len(msg.Data) > 0 {
-		log.Printf("🐛 DEBUG: Auth response data: %s", string(msg.Data))
+		logging.Debug("auth response data", "data", string(msg.Data))
 	}
 
 	if strings.Contains(msg.Content, "successful") {
 		p.client.SetAuthenticated(true)
-		log.Printf("✅ Authentication successful! Agent connected to Teneo network")
+		p.startSession(msg.Data)
+		logging.Info("authentication successful, agent connected to Teneo network")
 		// Send registration message with NFT token ID
-		log.Printf("🐛 DEBUG: About to send registration...")
+		logging.Debug("about to send registration")
 		return p.SendRegistration()
 	} else {
-		log.Printf("❌ Authentication failed: %s", msg.Content)
-		p.client.SetAuthenticated(false)
+		logging.Error("authentication failed", "error", msg.Content)
+		p.authFailed(msg.Content)
 	}
 	return nil
 }
 
 // HandleAuthSuccess handles authentication success messages
 func (p *ProtocolHandler) HandleAuthSuccess(msg *types.Message) error {
-	log.Printf("🐛 DEBUG: Received auth success - Type: %s, Content: %s", msg.Type, msg.Content)
+	logging.Debug("received auth success", "type", msg.Type, "content", msg.Content)
 	if len(msg.Data) > 0 {
-		log.Printf("🐛 DEBUG: Auth success data: %s", string(msg.Data))
+		logging.Debug("auth success data", "data", string(msg.Data))
 	}
 
-	log.Printf("✅ Authentication successful! Agent connected to Teneo network")
+	logging.Info("authentication successful, agent connected to Teneo network")
 	p.client.SetAuthenticated(true)
+	p.startSession(msg.Data)
 	// Send registration message with NFT token ID
-	log.Printf("🐛 DEBUG: About to send registration...")
+	logging.Debug("about to send registration")
 	return p.SendRegistration()
 }
 
 // HandleAuthError handles authentication error messages
 func (p *ProtocolHandler) HandleAuthError(msg *types.Message) error {
-	log.Printf("❌ Authentication failed: %s", msg.Content)
-	p.client.SetAuthenticated(false)
+	logging.Error("authentication failed", "error", msg.Content)
+	p.authFailed(msg.Content)
+	if isDuplicateConnectionError(msg.Content) {
+		p.duplicateConnection("server", msg.Content, "")
+	}
 	return nil
 }
 
 // HandleRegistrationSuccess handles successful agent registration
 func (p *ProtocolHandler) HandleRegistrationSuccess(msg *types.Message) error {
-	log.Printf("✅ Agent registered successfully with capabilities: %v", p.capabilities)
+	logging.Info("agent registered successfully", "capabilities", p.GetCapabilities())
+	p.notifyRegistered()
 	return nil
 }
 
+// OnRegistered adds a callback run in its own goroutine whenever the agent
+// has registered with the server, i.e. joined its room
+func (p *ProtocolHandler) OnRegistered(fn func()) {
+	p.registeredMu.Lock()
+	defer p.registeredMu.Unlock()
+	p.onRegistered = append(p.onRegistered, fn)
+}
+
+// notifyRegistered runs the OnRegistered callbacks
+func (p *ProtocolHandler) notifyRegistered() {
+	p.markRegistered()
+
+	p.registeredMu.Lock()
+	callbacks := append([]func(){}, p.onRegistered...)
+	p.registeredMu.Unlock()
+
+	for _, fn := range callbacks {
+		go fn()
+	}
+}
+
 // HandleError handles error messages from the server
 func (p *ProtocolHandler) HandleError(msg *types.Message) error {
-	log.Printf("❌ Error from server: %s", msg.Content)
+	logging.Error("error from server", "content", msg.Content)
+	if isDuplicateConnectionError(msg.Content) {
+		p.duplicateConnection("server", msg.Content, "")
+		return nil
+	}
+	if isSessionError(msg.Content) && p.client.IsAuthenticated() {
+		p.expireSession(msg.Content)
+	}
 	return nil
 }
 
 // HandlePong handles pong responses
 func (p *ProtocolHandler) HandlePong(msg *types.Message) error {
-	log.Printf("🏓 Received pong: %s", msg.Content)
+	logging.Debug("received pong", "content", msg.Content)
 	return nil
 }
 
 // HandleCapabilitiesResponse handles capabilities responses from the server
 func (p *ProtocolHandler) HandleCapabilitiesResponse(msg *types.Message) error {
-	log.Printf("📋 Received capabilities response from server: %s", msg.Content)
+	logging.Info("received capabilities response from server", "content", msg.Content)
 
 	// Check if the response indicates success based on content
 	if strings.Contains(msg.Content, "updated") || strings.Contains(msg.Content, "successful") {
-		log.Printf("✅ Capabilities acknowledged by server")
+		logging.Info("capabilities acknowledged by server")
 		return nil
 	}
 
@@ -225,14 +319,14 @@ func (p *ProtocolHandler) HandleCapabilitiesResponse(msg *types.Message) error {
 	if len(msg.Data) > 0 {
 		var capabilities map[string]interface{}
 		if err := json.Unmarshal(msg.Data, &capabilities); err != nil {
-			log.Printf("⚠️ Could not parse capabilities data, but response indicates success: %v", err)
+			logging.Warn("could not parse capabilities data, but response indicates success", "error", err)
 			return nil // Don't fail on JSON parse errors if content indicates success
 		}
 
 		// Process capabilities if present
 		if capData, ok := capabilities["capabilities"].([]interface{}); ok {
 			p.UpdateCapabilities(convertInterfaceSliceToStringSlice(capData))
-			log.Printf("Updated capabilities: %v", p.capabilities)
+			logging.Info("updated capabilities", "capabilities", p.GetCapabilities())
 		}
 	}
 
@@ -241,11 +335,13 @@ func (p *ProtocolHandler) HandleCapabilitiesResponse(msg *types.Message) error {
 
 // HandleRegisterResponse handles register responses from the server
 func (p *ProtocolHandler) HandleRegisterResponse(msg *types.Message) error {
-	log.Printf("📝 Received register response from server: %s", msg.Content)
+	logging.Info("received register response from server", "content", msg.Content)
+	p.negotiateCompression(msg)
 
 	// Check if registration was successful based on content message
 	if strings.Contains(msg.Content, "successful") || strings.Contains(msg.Content, "Registration successful") {
-		log.Printf("✅ Agent registered successfully with server")
+		logging.Info("agent registered successfully with server")
+		p.notifyRegistered()
 		return nil
 	}
 
@@ -253,7 +349,7 @@ func (p *ProtocolHandler) HandleRegisterResponse(msg *types.Message) error {
 	if len(msg.Data) > 0 {
 		var responseData map[string]interface{}
 		if err := json.Unmarshal(msg.Data, &responseData); err != nil {
-			log.Printf("⚠️ Could not parse registration data: %v", err)
+			logging.Warn("could not parse registration data", "error", err)
 			// If content indicates success, don't fail on JSON parse errors
 			if strings.Contains(msg.Content, "successful") {
 				return nil
@@ -263,41 +359,106 @@ func (p *ProtocolHandler) HandleRegisterResponse(msg *types.Message) error {
 
 		// Check for explicit success field
 		if success, ok := responseData["success"].(bool); ok && success {
-			log.Printf("✅ Agent registered successfully with server")
+			logging.Info("agent registered successfully with server")
+			p.notifyRegistered()
 			return nil
 		}
 
 		// Check if this is actually a user registration confirmation (not for us)
 		if userType, ok := responseData["type"].(string); ok && userType == "user" {
-			log.Printf("📝 Received user registration confirmation (not for this agent)")
+			logging.Info("received user registration confirmation (not for this agent)")
 			return nil
 		}
 
-		log.Printf("❌ Agent registration may have failed: %v", responseData)
+		logging.Error("agent registration may have failed", "response", responseData)
 	}
 
 	return nil // Don't fail, just log
 }
 
+// negotiateCompression enables compressed task responses when the server
+// echoes the offered content encoding in its register response
+func (p *ProtocolHandler) negotiateCompression(msg *types.Message) {
+	if p.client.compressAbove <= 0 || len(msg.Data) == 0 {
+		return
+	}
+
+	var response struct {
+		Compression string `json:"compression"`
+	}
+	if err := json.Unmarshal(msg.Data, &response); err != nil {
+		return
+	}
+
+	enabled := response.Compression == types.ContentEncodingGzipBase64
+	p.client.SetContentCompression(enabled)
+	if enabled {
+		logging.Info("server accepted compressed task responses", "encoding", response.Compression, "threshold", p.client.compressAbove)
+	}
+}
+
 // HandleAgentsResponse handles agents responses from the server
 func (p *ProtocolHandler) HandleAgentsResponse(msg *types.Message) error {
-	log.Printf("👥 Received agents response from server: %s", msg.Content)
-	var agents []map[string]interface{}
+	logging.Info("received agents response from server", "content", msg.Content)
+	var agents []types.AgentStatus
 	if err := json.Unmarshal(msg.Data, &agents); err != nil {
 		return fmt.Errorf("failed to unmarshal agents response: %w", err)
 	}
-	log.Printf("Current agents on network: %v", agents)
-	// TODO: Implement logic to update local agent list based on this response
+	logging.Info("current agents on network", "count", len(agents))
+	p.checkPresence(agents)
+
+	p.agentsMu.Lock()
+	p.agents = agents
+	close(p.agentsUpdated)
+	p.agentsUpdated = make(chan struct{})
+	p.agentsMu.Unlock()
 	return nil
 }
 
+// RequestAgents asks the server for the agents on the network.
+// The list arrives asynchronously in an agents response.
+func (p *ProtocolHandler) RequestAgents() error {
+	msg := &types.Message{
+		Type:      types.MessageTypeAgents,
+		From:      p.walletAddr,
+		Room:      p.room,
+		Timestamp: time.Now(),
+	}
+	return p.client.SendMessage(msg)
+}
+
+// Agents returns the agents from the last agents response
+func (p *ProtocolHandler) Agents() []types.AgentStatus {
+	p.agentsMu.RLock()
+	defer p.agentsMu.RUnlock()
+	return append([]types.AgentStatus(nil), p.agents...)
+}
+
+// RefreshAgents requests the agent list and waits for the server's response
+func (p *ProtocolHandler) RefreshAgents(ctx context.Context) ([]types.AgentStatus, error) {
+	p.agentsMu.RLock()
+	updated := p.agentsUpdated
+	p.agentsMu.RUnlock()
+
+	if err := p.RequestAgents(); err != nil {
+		return nil, fmt.Errorf("failed to request agents: %w", err)
+	}
+
+	select {
+	case <-updated:
+		return p.Agents(), nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
 // HandleTask handles incoming task requests from users
 func (p *ProtocolHandler) HandleTask(msg *types.Message) error {
-	log.Printf("📋 Received task from %s: %s", msg.From, msg.Content)
+	logging.Info("received task", "from", msg.From, "content", msg.Content)
 
 	var taskData map[string]interface{}
 	if err := json.Unmarshal(msg.Data, &taskData); err != nil {
-		log.Printf("⚠️ Could not parse task data: %v", err)
+		logging.Warn("could not parse task data", "error", err)
 		// Use message content as task if data parsing fails
 		return p.processTask(msg.From, msg.Content, "", msg.Room)
 	}
@@ -314,7 +475,7 @@ func (p *ProtocolHandler) HandleTask(msg *types.Message) error {
 
 // processTask processes a task and sends a response
 func (p *ProtocolHandler) processTask(from, content, taskID, room string) error {
-	log.Printf("🔄 Processing task: %s", content)
+	logging.Info("processing task", "content", content)
 
 	// Simple demonstration response - in a real agent this would be more sophisticated
 	response := fmt.Sprintf("Hello! I'm %s, a Teneo network agent. I received your message: \"%s\"\n\nI can help with:\n- Text processing\n- Data analysis\n- Conversation\n- Demonstrations\n\nHow can I assist you further?", p.agentName, content)
@@ -344,7 +505,11 @@ func (p *ProtocolHandler) processTask(from, content, taskID, room string) error
 		Timestamp: time.Now(),
 	}
 
-	log.Printf("📤 Sending task response to %s", from)
+	if err := p.signMessage(msg); err != nil {
+		return err
+	}
+
+	logging.Info("sending task response", "to", from)
 	return p.client.SendMessage(msg)
 }
 
@@ -353,16 +518,28 @@ func (p *ProtocolHandler) SendCapabilities() error {
 	// Send capabilities in the same format as x-agent (simple JSON, not wrapped in Message)
 	capMsg := map[string]interface{}{
 		"type":         "capabilities",
-		"capabilities": p.capabilities,
+		"capabilities": p.GetCapabilities(),
 		"room":         p.room,
 	}
+	if resources := p.Resources(); resources != nil {
+		capMsg["resources"] = resources
+	}
+	if schemas := p.InputSchemas(); len(schemas) > 0 {
+		capMsg["input_schemas"] = schemas
+	}
+	if manifest := p.Manifest(); manifest != nil {
+		capMsg["manifest"] = manifest
+	}
+	if keys := p.EncryptionKeys(); len(keys) > 0 {
+		capMsg["encryption_keys"] = keys
+	}
 
 	data, err := json.Marshal(capMsg)
 	if err != nil {
 		return fmt.Errorf("failed to marshal capabilities: %w", err)
 	}
 
-	log.Printf("📋 Sending capabilities: %v", p.capabilities)
+	logging.Info("sending capabilities", "capabilities", capMsg["capabilities"])
 
 	// Send directly via WebSocket using the new SendRawData method
 	return p.client.SendRawData(data)
@@ -382,10 +559,17 @@ func (p *ProtocolHandler) SendPing() error {
 
 // RegisterAgent registers the agent with the server
 func (p *ProtocolHandler) RegisterAgent() error {
-	registerData, err := json.Marshal(map[string]interface{}{
-		"capabilities": p.capabilities,
+	register := map[string]interface{}{
+		"capabilities": p.GetCapabilities(),
 		"description":  fmt.Sprintf("%s - Teneo network agent", p.agentName),
-	})
+	}
+	if schemas := p.InputSchemas(); len(schemas) > 0 {
+		register["input_schemas"] = schemas
+	}
+	if manifest := p.Manifest(); manifest != nil {
+		register["manifest"] = manifest
+	}
+	registerData, err := json.Marshal(register)
 	if err != nil {
 		return fmt.Errorf("failed to marshal register data: %w", err)
 	}
@@ -399,21 +583,57 @@ func (p *ProtocolHandler) RegisterAgent() error {
 		Timestamp: time.Now(),
 	}
 
-	log.Printf("📝 Registering agent: %s", p.agentName)
+	logging.Info("registering agent", "agent", p.agentName)
 	return p.client.SendMessage(msg)
 }
 
 // SendTaskResponseToRoom sends a task response back to the coordinator using a specific room
 func (p *ProtocolHandler) SendTaskResponseToRoom(taskID, content string, contentType string, success bool, errorMsg, room string) error {
+	return p.SendTaskResponseToRoomContext(context.Background(), taskID, content, contentType, success, errorMsg, room)
+}
+
+// SendTaskResponseToRoomContext sends a task response like SendTaskResponseToRoom,
+// propagating the trace context of ctx so the response links to the task's trace
+func (p *ProtocolHandler) SendTaskResponseToRoomContext(ctx context.Context, taskID, content string, contentType string, success bool, errorMsg, room string) error {
+	return p.sendTaskResponse(ctx, taskID, content, contentType, success, errorMsg, room, nil)
+}
+
+// SendTaskRejection answers a task that was not processed with an error code
+// and machine-readable details, e.g. "retry_after", added to the response data.
+// The rejection is classified in the data under "error_info".
+func (p *ProtocolHandler) SendTaskRejection(ctx context.Context, taskID, content, errorCode, room string, details map[string]interface{}) error {
+	return p.sendTaskResponse(ctx, taskID, content, types.StandardMessageTypeString, false, errorCode, room, details)
+}
+
+func (p *ProtocolHandler) sendTaskResponse(ctx context.Context, taskID, content string, contentType string, success bool, errorMsg, room string, details map[string]interface{}) error {
+	content, err := p.postProcessors.Process(ctx, contentType, content)
+	if err != nil {
+		return fmt.Errorf("failed to post-process task response: %w", err)
+	}
+
+	// Responses to encrypted tasks are sealed to the sender's reply key
+	encoding := ""
+	if reply := replyKeyFromContext(ctx); reply != nil {
+		if content, err = e2e.Seal(reply, content, nil); err != nil {
+			return fmt.Errorf("failed to encrypt task response: %w", err)
+		}
+		encoding = types.ContentEncodingE2E
+	}
+
 	// Create response data for the Data field
-	responseData := map[string]interface{}{
-		"task_id": taskID,
-		"success": success,
+	responseData := make(map[string]interface{}, len(details)+3)
+	for key, value := range details {
+		responseData[key] = value
 	}
+	responseData["task_id"] = taskID
+	responseData["success"] = success
 
 	if errorMsg != "" {
 		responseData["error"] = errorMsg
 	}
+	if !success && errorMsg != "" && responseData["error_info"] == nil {
+		responseData["error_info"] = rejectionError(errorMsg, details)
+	}
 
 	data, err := json.Marshal(responseData)
 	if err != nil {
@@ -428,34 +648,91 @@ func (p *ProtocolHandler) SendTaskResponseToRoom(taskID, content string, content
 		DataRoom:      room,        // Client expected field #1
 		MessageRoomId: room,        // Client expected field #2
 		Content:       content,
+		Encoding:      encoding,
 		ContentType:   contentType,
 		TaskID:        taskID,
 		Data:          data,
 		Timestamp:     time.Now(),
 	}
 
+	// Responses of a task are numbered and sent one at a time, in order
+	seq := sequenceFromContext(ctx)
+	if seq != nil {
+		seq.mu.Lock()
+		defer seq.mu.Unlock()
+	}
+
+	// Responses to tasks from outside the connection go back where the task came from
+	respond := responderFromContext(ctx)
+	if respond != nil {
+		msg.Sequence = seq.next()
+		if err := p.signMessage(msg); err != nil {
+			return err
+		}
+		return respond(ctx, msg)
+	}
+
+	if compressed, err := msg.CompressContent(p.client.ContentCompressionThreshold()); err != nil {
+		logging.Warn("failed to compress task response, sending uncompressed", "task_id", taskID, "error", err)
+	} else if compressed {
+		logging.Debug("compressed task response", "task_id", taskID, "original_bytes", len(content), "compressed_bytes", len(msg.Content))
+	}
+
+	// Content too large for one server message goes in parts
+	parts := msg.SplitContent(p.client.ResponseChunkSize())
+	if len(parts) > 1 {
+		logging.Debug("splitting task response", "task_id", taskID, "bytes", len(msg.Content), "parts", len(parts))
+	}
+
 	// Log for debugging
-	log.Printf("🐛 DEBUG: Sending task response with room context - Room: %s, TaskID: %s, Agent: %s",
-		room, taskID, p.agentName)
+	logging.Debug("sending task response with room context", "room", room, "task_id", taskID, "agent", p.agentName)
 
 	// Send via WebSocket with room context preserved
-	return p.client.SendMessage(msg)
+	for _, part := range parts {
+		part.Sequence = seq.next()
+		if err := p.signMessage(part); err != nil {
+			return err
+		}
+		if err := p.client.SendMessageContext(ctx, part); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// responderKey is the context key of the function receiving task responses
+type responderKey struct{}
+
+// withResponder returns a context whose task responses are passed to respond
+// instead of being sent over the connection
+func withResponder(ctx context.Context, respond func(context.Context, *types.Message) error) context.Context {
+	return context.WithValue(ctx, responderKey{}, respond)
+}
+
+// responderFromContext returns the function receiving task responses (nil = the connection)
+func responderFromContext(ctx context.Context) func(context.Context, *types.Message) error {
+	respond, _ := ctx.Value(responderKey{}).(func(context.Context, *types.Message) error)
+	return respond
 }
 
 // UpdateCapabilities updates the agent's capabilities
 func (p *ProtocolHandler) UpdateCapabilities(capabilities []string) {
+	p.resourcesMu.Lock()
+	defer p.resourcesMu.Unlock()
 	p.capabilities = capabilities
 }
 
 // GetCapabilities returns the current capabilities
 func (p *ProtocolHandler) GetCapabilities() []string {
+	p.resourcesMu.RLock()
+	defer p.resourcesMu.RUnlock()
 	return p.capabilities
 }
 
 // SendRegistration sends agent registration with NFT token ID
 func (p *ProtocolHandler) SendRegistration() error {
-	log.Printf("🐛 DEBUG: About to create registration with challenge: %s", p.lastChallenge)
-	log.Printf("🐛 DEBUG: About to create registration with signature: %s", p.lastChallengeSignature)
+	logging.Debug("about to create registration with challenge", "challenge", p.lastChallenge)
+	logging.Debug("about to create registration with signature", "signature", p.lastChallengeSignature)
 
 	// Create registration message in the new format
 	registrationMsg := &types.RegistrationMessage{
@@ -465,6 +742,15 @@ func (p *ProtocolHandler) SendRegistration() error {
 		Challenge:         p.lastChallenge,
 		ChallengeResponse: p.lastChallengeSignature,
 		Room:              p.room,
+		InstanceID:        p.InstanceID(),
+		Takeover:          p.takeoverRequested(true),
+	}
+	registrationMsg.Resources = p.Resources()
+	registrationMsg.Manifest = p.Manifest()
+	registrationMsg.EncryptionKeys = p.EncryptionKeys()
+	if p.client.compressAbove > 0 {
+		// Offer compressed task responses; the server opts in by echoing the encoding
+		registrationMsg.Compression = types.ContentEncodingGzipBase64
 	}
 
 	// Marshal the registration data
@@ -474,7 +760,7 @@ func (p *ProtocolHandler) SendRegistration() error {
 	}
 
 	// Add debug logging to see what we're actually sending
-	log.Printf("🐛 DEBUG: Registration data being sent: %s", string(registrationData))
+	logging.Debug("registration data being sent", "registration_data", string(registrationData))
 
 	// Create message
 	msg := &types.Message{
@@ -486,7 +772,11 @@ func (p *ProtocolHandler) SendRegistration() error {
 		Timestamp: time.Now(),
 	}
 
-	log.Printf("📝 Sending agent registration with NFT Token ID: %s", p.nftTokenID)
+	if p.nftTokenID == "" {
+		logging.Info("sending agent registration without NFT", "wallet", p.walletAddr)
+	} else {
+		logging.Info("sending agent registration with NFT token ID", "token_id", p.nftTokenID)
+	}
 	return p.client.SendMessage(msg)
 }
 
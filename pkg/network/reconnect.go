@@ -0,0 +1,141 @@
+package network
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/logging"
+)
+
+// reconnectHookTimeout bounds each step of the reconnect hook chain
+const reconnectHookTimeout = time.Minute
+
+// Steps ProtocolHandler adds to the reconnect hook chain, in this order
+const (
+	HookReauthenticate = "reauthenticate" // Start a new session and wait until it is established
+	HookReregister     = "reregister"     // Wait until the server accepted the registration
+	HookRejoinRoom     = "rejoin_room"    // Announce the capabilities to the room again
+	HookFlushPending   = "flush_pending"  // Send the messages that waited for the connection
+)
+
+// reconnectHook is a step of restoring the session on a new connection
+type reconnectHook struct {
+	name string
+	run  func(ctx context.Context) error
+}
+
+// AddReconnectHook appends a step to the chain run after the client has
+// re-established a dropped connection. The steps run one after another in
+// the order they were added, each for at most a minute. The chain stops at
+// the first step that fails and is canceled when the connection drops again.
+// Retries of queued messages are paused from the disconnect until the chain
+// is done.
+func (c *NetworkClient) AddReconnectHook(name string, fn func(ctx context.Context) error) {
+	c.reconnectedMu.Lock()
+	defer c.reconnectedMu.Unlock()
+	c.reconnectHooks = append(c.reconnectHooks, reconnectHook{name: name, run: fn})
+}
+
+// runReconnectHooks runs the reconnect hook chain on the new connection
+func (c *NetworkClient) runReconnectHooks() {
+	ctx, cancel := context.WithCancel(c.ctx)
+	c.reconnectedMu.Lock()
+	if c.cancelHooks != nil {
+		c.cancelHooks()
+	}
+	c.cancelHooks = cancel
+	hooks := append([]reconnectHook{}, c.reconnectHooks...)
+	c.reconnectedMu.Unlock()
+	defer cancel()
+
+	// Queued messages are retried even if restoring the session failed, so they are not held forever
+	defer c.retryQueue.Resume()
+
+	for _, hook := range hooks {
+		hookCtx, hookCancel := context.WithTimeout(ctx, reconnectHookTimeout)
+		started := time.Now()
+		err := hook.run(hookCtx)
+		hookCancel()
+		if ctx.Err() != nil {
+			logging.Info("reconnect hooks canceled", "hook", hook.name)
+			return
+		}
+		if err != nil {
+			logging.Error("reconnect hook failed, skipping the rest", "hook", hook.name, "error", err)
+			return
+		}
+		logging.Debug("reconnect hook done", "hook", hook.name, "duration", time.Since(started))
+	}
+}
+
+// cancelReconnectHooks stops a running reconnect hook chain
+func (c *NetworkClient) cancelReconnectHooks() {
+	c.reconnectedMu.Lock()
+	defer c.reconnectedMu.Unlock()
+	if c.cancelHooks != nil {
+		c.cancelHooks()
+		c.cancelHooks = nil
+	}
+}
+
+// FlushRetryQueue retries every queued message right away
+func (c *NetworkClient) FlushRetryQueue() {
+	c.retryQueue.Flush()
+}
+
+// addReconnectHooks restores the agent's session after a reconnect:
+// authentication, registration, the room's view of the agent and the
+// responses that could not be sent while disconnected
+func (p *ProtocolHandler) addReconnectHooks() {
+	p.client.AddReconnectHook(HookReauthenticate, p.reauthenticateAfterReconnect)
+	p.client.AddReconnectHook(HookReregister, func(ctx context.Context) error {
+		return p.awaitAuthState(ctx, AuthStateRegistered)
+	})
+	p.client.AddReconnectHook(HookRejoinRoom, p.rejoinRoom)
+	p.client.AddReconnectHook(HookFlushPending, func(ctx context.Context) error {
+		p.client.FlushRetryQueue()
+		return nil
+	})
+}
+
+// rejoinRoom announces the capabilities, resources and input schemas to the
+// room again, including changes made while disconnected
+func (p *ProtocolHandler) rejoinRoom(ctx context.Context) error {
+	logging.Info("rejoining room after reconnect", "room", p.room)
+	return p.SendCapabilities()
+}
+
+// awaitAuthState waits until the authentication reaches one of states. It
+// fails if the server rejects the authentication.
+func (p *ProtocolHandler) awaitAuthState(ctx context.Context, states ...AuthState) error {
+	authEvents, unsubscribe := p.SubscribeAuthEvents()
+	defer unsubscribe()
+
+	reached := func(state AuthState) bool {
+		for _, s := range states {
+			if state == s {
+				return true
+			}
+		}
+		return false
+	}
+	if reached(p.AuthState()) {
+		return nil
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("waiting for %v: %w", states, ctx.Err())
+		case event := <-authEvents:
+			if reached(event.State) {
+				return nil
+			}
+			if event.State == AuthStateFailed {
+				return errors.New("authentication failed: " + event.Reason)
+			}
+		}
+	}
+}
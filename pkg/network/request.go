@@ -0,0 +1,81 @@
+package network
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/logging"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/types"
+)
+
+// request sends a message of msgType whose data is payload plus a generated
+// request_id, and waits for the server's response carrying the same request ID
+// (in reply_to or data.request_id). Responses are routed by HandleResponse,
+// which must be registered for msgType.
+func (p *ProtocolHandler) request(ctx context.Context, msgType, room string, payload map[string]interface{}) (*types.Message, error) {
+	requestID := msgType + "-" + newCorrelationID()
+	payload["request_id"] = requestID
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal %s request: %w", msgType, err)
+	}
+
+	response := make(chan *types.Message, 1)
+	p.requestsMu.Lock()
+	p.requests[requestID] = response
+	p.requestsMu.Unlock()
+	defer func() {
+		p.requestsMu.Lock()
+		delete(p.requests, requestID)
+		p.requestsMu.Unlock()
+	}()
+
+	msg := &types.Message{
+		ID:        requestID,
+		Type:      msgType,
+		From:      p.walletAddr,
+		Room:      room,
+		Data:      data,
+		Timestamp: time.Now(),
+	}
+	if err := p.client.SendMessageContext(ctx, msg); err != nil {
+		return nil, fmt.Errorf("failed to send %s request: %w", msgType, err)
+	}
+
+	select {
+	case msg := <-response:
+		return msg, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// HandleResponse routes a response to the request waiting for it
+func (p *ProtocolHandler) HandleResponse(msg *types.Message) error {
+	requestID := msg.ReplyTo
+	if requestID == "" {
+		var data struct {
+			RequestID string `json:"request_id"`
+		}
+		if err := json.Unmarshal(msg.Data, &data); err == nil {
+			requestID = data.RequestID
+		}
+	}
+
+	p.requestsMu.Lock()
+	response, ok := p.requests[requestID]
+	p.requestsMu.Unlock()
+	if !ok {
+		logging.Debug("ignoring response without pending request", "type", msg.Type, "request_id", requestID)
+		return nil
+	}
+
+	select {
+	case response <- msg:
+	default:
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package network
+
+import (
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/logging"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/types"
+)
+
+// SetResources sets the hardware advertised in the registration and capabilities
+// messages (nil stops advertising it). Call UpdateResources to also announce
+// the change while connected.
+func (p *ProtocolHandler) SetResources(resources *types.ComputeResources) {
+	if resources != nil && resources.IsZero() {
+		resources = nil
+	}
+
+	p.resourcesMu.Lock()
+	defer p.resourcesMu.Unlock()
+	p.resources = resources
+}
+
+// Resources returns the advertised hardware (nil if not advertised)
+func (p *ProtocolHandler) Resources() *types.ComputeResources {
+	p.resourcesMu.RLock()
+	defer p.resourcesMu.RUnlock()
+	return p.resources
+}
+
+// UpdateResources sets the advertised hardware and, once authenticated, sends it
+// to the server in a capabilities message so coordinators route jobs accordingly
+func (p *ProtocolHandler) UpdateResources(resources *types.ComputeResources) error {
+	p.SetResources(resources)
+	if !p.client.IsAuthenticated() {
+		return nil
+	}
+
+	logging.Info("announcing updated resources", "resources", p.Resources())
+	return p.SendCapabilities()
+}
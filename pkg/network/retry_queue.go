@@ -2,11 +2,16 @@ package network
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
+	"sort"
 	"sync"
 	"time"
 
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/errs"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/logging"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/retrystore"
 	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/types"
 )
 
@@ -22,25 +27,50 @@ type RetryPolicy struct {
 // DefaultRetryPolicy returns a default retry policy
 func DefaultRetryPolicy() *RetryPolicy {
 	return &RetryPolicy{
-		MaxRetries:    3,
-		InitialDelay:  1 * time.Second,
-		MaxDelay:      30 * time.Second,
-		BackoffFactor: 2.0,
-		RetryableError: func(err error) bool {
-			// By default, all errors are retriable
-			// Can be customized to check for specific error types
-			return true
-		},
+		MaxRetries:     3,
+		InitialDelay:   1 * time.Second,
+		MaxDelay:       30 * time.Second,
+		BackoffFactor:  2.0,
+		RetryableError: errs.IsRetryable,
+	}
+}
+
+// Backoff returns the delay before the given retry attempt (1-based)
+func (p *RetryPolicy) Backoff(retryCount int) time.Duration {
+	delay := float64(p.InitialDelay)
+
+	for i := 1; i < retryCount; i++ {
+		delay *= p.BackoffFactor
+	}
+
+	if time.Duration(delay) > p.MaxDelay {
+		return p.MaxDelay
+	}
+
+	return time.Duration(delay)
+}
+
+// delayFor returns the backoff for a retry attempt, extended to the delay requested by err
+func (p *RetryPolicy) delayFor(retryCount int, err error) time.Duration {
+	delay := p.Backoff(retryCount)
+	if retryAfter := errs.RetryAfter(err); retryAfter > delay {
+		return retryAfter
 	}
+	return delay
 }
 
 // RetryableMessage represents a message that can be retried
 type RetryableMessage struct {
 	Message     *types.Message
+	Key         string // Identifies the message for deduplication, see retrystore.Key
 	RetryCount  int
+	QueuedAt    time.Time
 	LastAttempt time.Time
 	NextRetry   time.Time
 	Error       error
+
+	seq      uint64 // Position in the queue, so a message requeued after a failure keeps its place
+	orderKey string // Messages with the same key are sent in order, see Ordering
 }
 
 // MessageRetryQueue manages failed messages for retry
@@ -53,7 +83,22 @@ type MessageRetryQueue struct {
 	cancel     context.CancelFunc
 	wg         sync.WaitGroup
 	processing bool
+	paused     bool // No retries while the connection is down, see Pause
 	metrics    *RetryMetrics
+
+	// Persistence, nil if the queue is kept in memory only
+	store     retrystore.Store
+	storeMu   sync.Mutex                   // Orders saves
+	inflight  map[string]*RetryableMessage // Taken off the queue for a retry, by key
+	sent      map[string]bool              // Keys of recently delivered messages
+	sentOrder []string                     // The keys in sent, oldest first
+	attempts  map[string]int               // Retries it took to deliver the messages in sent, if any
+
+	dead         []*DeadLetter // Messages given up on, oldest first
+	onDeadLetter func(DeadLetter)
+
+	ordering Ordering
+	nextSeq  uint64
 }
 
 // RetryMetrics tracks retry queue statistics
@@ -62,7 +107,10 @@ type RetryMetrics struct {
 	SuccessfulRetries int64
 	FailedRetries     int64
 	DroppedMessages   int64
+	Duplicates        int64 // Messages not queued or retried because they were already queued or delivered
+	DeadLettered      int64 // Messages moved to the dead-letter queue
 	CurrentQueueSize  int
+	DeadLetters       int // Messages in the dead-letter queue
 	mu                sync.RWMutex
 }
 
@@ -81,7 +129,166 @@ func NewMessageRetryQueue(policy *RetryPolicy, sendFunc func(*types.Message) err
 		ctx:      ctx,
 		cancel:   cancel,
 		metrics:  &RetryMetrics{},
+		inflight: make(map[string]*RetryableMessage),
+		sent:     make(map[string]bool),
+		attempts: make(map[string]int),
+	}
+}
+
+// SetStore persists the queue in store and loads the messages it holds from
+// an earlier run, which are retried once the queue is started. Messages that
+// are already queued, or were delivered before the restart, are skipped.
+func (q *MessageRetryQueue) SetStore(ctx context.Context, store retrystore.Store) error {
+	snapshot, err := store.Load(ctx)
+	if err != nil {
+		return err
+	}
+
+	q.mu.Lock()
+	q.store = store
+	for _, key := range snapshot.Sent {
+		q.markSentLocked(key)
+	}
+	loaded := 0
+	for _, entry := range snapshot.Pending {
+		var msg types.Message
+		if err := json.Unmarshal(entry.Message, &msg); err != nil {
+			logging.Warn("dropping unreadable message from retry queue", "key", entry.Key, "error", err)
+			continue
+		}
+		if q.knownLocked(entry.Key) {
+			continue
+		}
+		retryMsg := &RetryableMessage{
+			Message:    &msg,
+			Key:        entry.Key,
+			RetryCount: entry.RetryCount,
+			QueuedAt:   entry.QueuedAt,
+			NextRetry:  entry.NextRetry,
+		}
+		if entry.LastError != "" {
+			retryMsg.Error = errors.New(entry.LastError)
+		}
+		q.insertLocked(retryMsg)
+		loaded++
 	}
+	for _, entry := range snapshot.Dead {
+		letter, err := deadLetterFromEntry(entry)
+		if err != nil {
+			logging.Warn("dropping unreadable dead letter", "id", entry.Key, "error", err)
+			continue
+		}
+		q.addDeadLetterLocked(letter)
+	}
+	q.updateMetricsLocked(func(m *RetryMetrics) {
+		m.CurrentQueueSize = len(q.queue)
+	})
+	q.mu.Unlock()
+
+	if loaded > 0 {
+		logging.Info("loaded messages into retry queue", "messages", loaded)
+	}
+	q.persist()
+	return nil
+}
+
+// knownLocked reports whether the message with the key is queued, being
+// retried or was delivered (must hold lock)
+func (q *MessageRetryQueue) knownLocked(key string) bool {
+	if q.sent[key] || q.inflight[key] != nil {
+		return true
+	}
+	for _, queued := range q.queue {
+		if queued.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// markSentLocked remembers a delivered message, up to retrystore.MaxSent (must hold lock)
+func (q *MessageRetryQueue) markSentLocked(key string) {
+	if q.sent[key] {
+		return
+	}
+	q.sent[key] = true
+	q.sentOrder = append(q.sentOrder, key)
+	if len(q.sentOrder) > retrystore.MaxSent {
+		delete(q.sent, q.sentOrder[0])
+		delete(q.attempts, q.sentOrder[0])
+		q.sentOrder = q.sentOrder[1:]
+	}
+}
+
+// forgetSentLocked forgets that a message was delivered and returns the
+// retries it took (must hold lock)
+func (q *MessageRetryQueue) forgetSentLocked(key string) int {
+	attempts := q.attempts[key]
+	if !q.sent[key] {
+		return attempts
+	}
+	delete(q.sent, key)
+	delete(q.attempts, key)
+	for i, sent := range q.sentOrder {
+		if sent == key {
+			q.sentOrder = append(q.sentOrder[:i], q.sentOrder[i+1:]...)
+			break
+		}
+	}
+	return attempts
+}
+
+// persist saves the queue, including the messages being retried, to the store
+func (q *MessageRetryQueue) persist() {
+	q.storeMu.Lock()
+	defer q.storeMu.Unlock()
+
+	q.mu.Lock()
+	store := q.store
+	if store == nil {
+		q.mu.Unlock()
+		return
+	}
+	// Pending messages are saved in queue order, so they are reloaded in it
+	snapshot := &retrystore.Snapshot{Sent: append([]string(nil), q.sentOrder...)}
+	pendingSeq := make(map[string]uint64, len(q.inflight)+len(q.queue))
+	for _, retryMsg := range q.inflight {
+		snapshot.Pending = append(snapshot.Pending, retryMsg.entry())
+		pendingSeq[retryMsg.Key] = retryMsg.seq
+	}
+	for _, retryMsg := range q.queue {
+		snapshot.Pending = append(snapshot.Pending, retryMsg.entry())
+		pendingSeq[retryMsg.Key] = retryMsg.seq
+	}
+	for _, letter := range q.dead {
+		snapshot.Dead = append(snapshot.Dead, letter.entry())
+	}
+	q.mu.Unlock()
+	sort.SliceStable(snapshot.Pending, func(i, j int) bool {
+		return pendingSeq[snapshot.Pending[i].Key] < pendingSeq[snapshot.Pending[j].Key]
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := store.Save(ctx, snapshot); err != nil {
+		logging.Warn("failed to persist retry queue", "error", err)
+	}
+}
+
+// entry returns the message as it is persisted
+func (m *RetryableMessage) entry() retrystore.Entry {
+	data, _ := json.Marshal(m.Message)
+	entry := retrystore.Entry{
+		Key:        m.Key,
+		Message:    data,
+		RetryCount: m.RetryCount,
+		QueuedAt:   m.QueuedAt,
+		NextRetry:  m.NextRetry,
+	}
+	if m.Error != nil {
+		entry.LastError = m.Error.Error()
+	}
+	return entry
 }
 
 // Start begins processing the retry queue
@@ -97,7 +304,7 @@ func (q *MessageRetryQueue) Start() {
 	q.wg.Add(1)
 	go q.processQueue()
 
-	log.Println("📮 Message retry queue started")
+	logging.Info("message retry queue started")
 }
 
 // Stop stops processing the retry queue
@@ -113,37 +320,210 @@ func (q *MessageRetryQueue) Stop() {
 	q.cancel()
 	q.wg.Wait()
 
-	log.Printf("📮 Message retry queue stopped. Dropped %d messages", len(q.queue))
+	logging.Info("message retry queue stopped", "dropped", len(q.queue))
 }
 
-// Enqueue adds a failed message to the retry queue
-func (q *MessageRetryQueue) Enqueue(msg *types.Message, err error) {
+// Pause stops retrying until Resume or Flush, so queued messages don't use
+// up their retries while the connection is down
+func (q *MessageRetryQueue) Pause() {
 	q.mu.Lock()
 	defer q.mu.Unlock()
+	q.paused = true
+}
+
+// Resume retries queued messages again after Pause
+func (q *MessageRetryQueue) Resume() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.paused = false
+}
+
+// Flush resumes the queue and retries every queued message right away
+// instead of after its backoff
+func (q *MessageRetryQueue) Flush() {
+	q.mu.Lock()
+	q.paused = false
+	now := time.Now()
+	for _, msg := range q.queue {
+		msg.NextRetry = now
+	}
+	size := len(q.queue)
+	q.mu.Unlock()
+
+	if size > 0 {
+		logging.Info("flushing retry queue", "queue_size", size)
+		q.processReadyMessages()
+	}
+}
+
+// Enqueue adds a failed message to the retry queue. A message that is
+// already queued or was delivered is not queued again.
+func (q *MessageRetryQueue) Enqueue(msg *types.Message, err error) {
+	key := messageKey(msg)
+	now := time.Now()
+	q.mu.Lock()
 
 	// Check if error is retriable
 	if !q.policy.RetryableError(err) {
-		log.Printf("⚠️ Message not retriable: %v", err)
+		q.deadLetterLocked(&RetryableMessage{Message: msg, Key: key, QueuedAt: now}, DeadLetterNotRetryable, err)
+		q.mu.Unlock()
+		logging.Warn("message not retriable, moved to dead-letter queue", "id", key, "error", err)
 		q.updateMetrics(func(m *RetryMetrics) {
 			m.DroppedMessages++
 		})
+		q.persist()
+		return
+	}
+
+	if q.knownLocked(key) {
+		q.mu.Unlock()
+		logging.Debug("duplicate message not queued for retry", "key", key)
+		q.updateMetrics(func(m *RetryMetrics) {
+			m.Duplicates++
+		})
 		return
 	}
 
 	retryMsg := &RetryableMessage{
 		Message:     msg,
+		Key:         key,
 		RetryCount:  0,
-		LastAttempt: time.Now(),
-		NextRetry:   time.Now().Add(q.policy.InitialDelay),
+		QueuedAt:    now,
+		LastAttempt: now,
+		NextRetry:   now.Add(q.policy.delayFor(1, err)),
 		Error:       err,
 	}
 
-	q.queue = append(q.queue, retryMsg)
-	q.updateMetrics(func(m *RetryMetrics) {
+	q.insertLocked(retryMsg)
+	queueSize := len(q.queue)
+	q.updateMetricsLocked(func(m *RetryMetrics) {
+		m.CurrentQueueSize = queueSize
+	})
+	q.mu.Unlock()
+
+	q.persist()
+	logging.Info("message queued for retry", "queue_size", queueSize)
+}
+
+// requeueUnwritten takes back a message that was handed to the connection but
+// never written, also when it was counted as delivered by a retry. It is
+// retried with the attempts it already used, or moved to the dead-letter
+// queue once they are used up.
+func (q *MessageRetryQueue) requeueUnwritten(msg *types.Message, err error) {
+	key := messageKey(msg)
+	q.mu.Lock()
+	attempts := q.forgetSentLocked(key)
+	if attempts == 0 {
+		q.mu.Unlock()
+		q.Enqueue(msg, err)
+		return
+	}
+	defer q.persist()
+	defer q.mu.Unlock()
+
+	now := time.Now()
+	retryMsg := &RetryableMessage{Message: msg, Key: key, RetryCount: attempts, QueuedAt: now, LastAttempt: now, Error: err}
+	if attempts >= q.policy.MaxRetries {
+		q.deadLetterLocked(retryMsg, DeadLetterMaxRetries, err)
+		logging.Error("unwritten message moved to dead-letter queue", "id", key, "attempts", attempts, "error", err)
+		q.updateMetricsLocked(func(m *RetryMetrics) {
+			m.FailedRetries++
+			m.DroppedMessages++
+		})
+		return
+	}
+	retryMsg.NextRetry = now.Add(q.policy.delayFor(attempts+1, err))
+	q.insertLocked(retryMsg)
+	q.updateMetricsLocked(func(m *RetryMetrics) {
 		m.CurrentQueueSize = len(q.queue)
 	})
+	logging.Info("unwritten message re-queued for retry", "id", key, "attempts", attempts)
+}
+
+// SetOrdering sets which messages are kept in order (default OrderByTask)
+func (q *MessageRetryQueue) SetOrdering(ordering Ordering) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.ordering = ordering
+}
+
+// Hold queues a message that would overtake earlier messages with the same
+// ordering key still waiting for a retry, to be sent right after them, and
+// reports whether it did. A message that is not held is sent right away.
+func (q *MessageRetryQueue) Hold(msg *types.Message) bool {
+	q.mu.Lock()
+	orderKey := q.ordering.key(msg)
+	if orderKey == "" || !q.pendingLocked(orderKey) {
+		q.mu.Unlock()
+		return false
+	}
+
+	key := messageKey(msg)
+	if q.knownLocked(key) {
+		q.mu.Unlock()
+		logging.Debug("duplicate message not queued for retry", "key", key)
+		q.updateMetrics(func(m *RetryMetrics) {
+			m.Duplicates++
+		})
+		return true
+	}
+	now := time.Now()
+	q.insertLocked(&RetryableMessage{
+		Message:   msg,
+		Key:       key,
+		QueuedAt:  now,
+		NextRetry: now,
+	})
+	queueSize := len(q.queue)
+	q.updateMetricsLocked(func(m *RetryMetrics) {
+		m.CurrentQueueSize = queueSize
+	})
+	q.mu.Unlock()
 
-	log.Printf("📮 Message queued for retry (queue size: %d)", len(q.queue))
+	q.persist()
+	logging.Debug("message held behind earlier messages", "type", msg.Type, "order_key", orderKey, "queue_size", queueSize)
+	return true
+}
+
+// pendingLocked reports whether messages with the ordering key are queued or
+// being retried (must hold lock)
+func (q *MessageRetryQueue) pendingLocked(orderKey string) bool {
+	for _, retryMsg := range q.inflight {
+		if retryMsg.orderKey == orderKey {
+			return true
+		}
+	}
+	for _, retryMsg := range q.queue {
+		if retryMsg.orderKey == orderKey {
+			return true
+		}
+	}
+	return false
+}
+
+// insertLocked adds a message to the queue in order: a new message goes to
+// the back, one requeued after a failure back to its place (must hold lock)
+func (q *MessageRetryQueue) insertLocked(retryMsg *RetryableMessage) {
+	if retryMsg.seq == 0 {
+		q.nextSeq++
+		retryMsg.seq = q.nextSeq
+		retryMsg.orderKey = q.ordering.key(retryMsg.Message)
+	}
+	i := sort.Search(len(q.queue), func(i int) bool {
+		return q.queue[i].seq > retryMsg.seq
+	})
+	q.queue = append(q.queue, nil)
+	copy(q.queue[i+1:], q.queue[i:])
+	q.queue[i] = retryMsg
+}
+
+// messageKey returns the deduplication key of a message
+func messageKey(msg *types.Message) string {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return msg.ID
+	}
+	return retrystore.Key(data)
 }
 
 // processQueue continuously processes messages in the retry queue
@@ -164,18 +544,59 @@ func (q *MessageRetryQueue) processQueue() {
 	}
 }
 
-// processReadyMessages processes messages that are ready for retry
+// processReadyMessages processes messages that are ready for retry. Of the
+// messages with the same ordering key only the first is sent at a time; once
+// it is delivered or given up on, the ones behind it are sent right after.
 func (q *MessageRetryQueue) processReadyMessages() {
+	for q.ctx.Err() == nil {
+		readyMessages := q.takeReadyMessages()
+		if len(readyMessages) == 0 {
+			return
+		}
+
+		// Process ready messages without holding the lock
+		released := false
+		for _, retryMsg := range readyMessages {
+			if q.retryMessage(retryMsg) {
+				released = true
+			}
+		}
+		if !released {
+			return
+		}
+	}
+}
+
+// takeReadyMessages moves the messages ready for retry, and not behind an
+// earlier message with the same ordering key, from the queue to inflight
+func (q *MessageRetryQueue) takeReadyMessages() []*RetryableMessage {
 	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.paused {
+		return nil
+	}
 
 	now := time.Now()
 	readyMessages := make([]*RetryableMessage, 0)
 	remainingMessages := make([]*RetryableMessage, 0)
 
+	blocked := make(map[string]bool)
+	for _, msg := range q.inflight {
+		blocked[msg.orderKey] = true
+	}
+
 	// Separate ready messages from those still waiting
 	for _, msg := range q.queue {
-		if now.After(msg.NextRetry) {
+		if msg.orderKey != "" && blocked[msg.orderKey] {
+			remainingMessages = append(remainingMessages, msg)
+			continue
+		}
+		if msg.orderKey != "" {
+			blocked[msg.orderKey] = true
+		}
+		if !now.Before(msg.NextRetry) {
 			readyMessages = append(readyMessages, msg)
+			q.inflight[msg.Key] = msg
 		} else {
 			remainingMessages = append(remainingMessages, msg)
 		}
@@ -185,78 +606,90 @@ func (q *MessageRetryQueue) processReadyMessages() {
 	q.updateMetricsLocked(func(m *RetryMetrics) {
 		m.CurrentQueueSize = len(q.queue)
 	})
-	q.mu.Unlock()
+	return readyMessages
+}
 
-	// Process ready messages without holding the lock
-	for _, retryMsg := range readyMessages {
-		q.retryMessage(retryMsg)
+// retryMessage attempts to retry a single message and reports whether it
+// left the queue, delivered or moved to the dead-letter queue, releasing the
+// messages held behind it
+func (q *MessageRetryQueue) retryMessage(retryMsg *RetryableMessage) bool {
+	defer q.persist()
+
+	q.mu.Lock()
+	duplicate := q.sent[retryMsg.Key]
+	if duplicate {
+		delete(q.inflight, retryMsg.Key)
+	} else {
+		retryMsg.RetryCount++
+		retryMsg.LastAttempt = time.Now()
 	}
-}
+	attempt := retryMsg.RetryCount
+	q.mu.Unlock()
 
-// retryMessage attempts to retry a single message
-func (q *MessageRetryQueue) retryMessage(retryMsg *RetryableMessage) {
-	retryMsg.RetryCount++
-	retryMsg.LastAttempt = time.Now()
+	if duplicate {
+		logging.Info("skipping retry of delivered message", "key", retryMsg.Key)
+		q.updateMetrics(func(m *RetryMetrics) {
+			m.Duplicates++
+		})
+		return true
+	}
 
-	log.Printf("🔄 Retrying message (attempt %d/%d)", retryMsg.RetryCount, q.policy.MaxRetries)
+	logging.Info("retrying message", "attempt", attempt, "max_retries", q.policy.MaxRetries)
 
 	// Attempt to send the message
 	err := q.sendFunc(retryMsg.Message)
 
 	if err == nil {
 		// Success!
-		log.Printf("✅ Message retry successful after %d attempts", retryMsg.RetryCount)
+		q.mu.Lock()
+		delete(q.inflight, retryMsg.Key)
+		q.markSentLocked(retryMsg.Key)
+		q.attempts[retryMsg.Key] = attempt
+		q.mu.Unlock()
+		logging.Info("message retry successful", "attempts", attempt)
 		q.updateMetrics(func(m *RetryMetrics) {
 			m.SuccessfulRetries++
 			m.TotalRetries++
 		})
-		return
+		return true
 	}
 
 	// Failed again
-	retryMsg.Error = err
 	q.updateMetrics(func(m *RetryMetrics) {
 		m.TotalRetries++
 	})
 
+	q.mu.Lock()
+	retryMsg.Error = err
+	delete(q.inflight, retryMsg.Key)
+
 	// Check if we should retry again
-	if retryMsg.RetryCount >= q.policy.MaxRetries {
-		log.Printf("❌ Message dropped after %d retries: %v", retryMsg.RetryCount, err)
+	if attempt >= q.policy.MaxRetries || !q.policy.RetryableError(err) {
+		reason := DeadLetterMaxRetries
+		if !q.policy.RetryableError(err) {
+			reason = DeadLetterNotRetryable
+		}
+		q.deadLetterLocked(retryMsg, reason, err)
+		q.mu.Unlock()
+		logging.Error("message moved to dead-letter queue", "id", retryMsg.Key, "reason", reason, "attempts", attempt, "error", err)
 		q.updateMetrics(func(m *RetryMetrics) {
 			m.FailedRetries++
 			m.DroppedMessages++
 		})
-		return
+		return true
 	}
 
-	// Calculate next retry time with exponential backoff
-	delay := q.calculateBackoff(retryMsg.RetryCount)
+	// Calculate next retry time with exponential backoff and re-queue the message
+	delay := q.policy.delayFor(attempt, err)
 	retryMsg.NextRetry = time.Now().Add(delay)
-
-	// Re-queue the message
-	q.mu.Lock()
-	q.queue = append(q.queue, retryMsg)
+	q.insertLocked(retryMsg)
 	q.updateMetricsLocked(func(m *RetryMetrics) {
 		m.CurrentQueueSize = len(q.queue)
 	})
 	q.mu.Unlock()
 
-	log.Printf("📮 Message re-queued for retry in %v", delay)
-}
-
-// calculateBackoff calculates the backoff delay for a retry attempt
-func (q *MessageRetryQueue) calculateBackoff(retryCount int) time.Duration {
-	delay := float64(q.policy.InitialDelay)
-
-	for i := 1; i < retryCount; i++ {
-		delay *= q.policy.BackoffFactor
-	}
-
-	if time.Duration(delay) > q.policy.MaxDelay {
-		return q.policy.MaxDelay
-	}
-
-	return time.Duration(delay)
+	logging.Info("message re-queued for retry", "delay", delay)
+	return false
 }
 
 // GetMetrics returns current retry queue metrics
@@ -269,7 +702,10 @@ func (q *MessageRetryQueue) GetMetrics() RetryMetrics {
 		SuccessfulRetries: q.metrics.SuccessfulRetries,
 		FailedRetries:     q.metrics.FailedRetries,
 		DroppedMessages:   q.metrics.DroppedMessages,
+		Duplicates:        q.metrics.Duplicates,
+		DeadLettered:      q.metrics.DeadLettered,
 		CurrentQueueSize:  q.metrics.CurrentQueueSize,
+		DeadLetters:       q.metrics.DeadLetters,
 	}
 }
 
@@ -294,11 +730,9 @@ func (q *MessageRetryQueue) GetQueueSize() int {
 	return len(q.queue)
 }
 
-// Clear removes all messages from the retry queue
+// Clear removes all messages from the retry queue, and from its store
 func (q *MessageRetryQueue) Clear() {
 	q.mu.Lock()
-	defer q.mu.Unlock()
-
 	dropped := len(q.queue)
 	q.queue = make([]*RetryableMessage, 0)
 
@@ -306,8 +740,10 @@ func (q *MessageRetryQueue) Clear() {
 		m.DroppedMessages += int64(dropped)
 		m.CurrentQueueSize = 0
 	})
+	q.mu.Unlock()
 
-	log.Printf("📮 Retry queue cleared. Dropped %d messages", dropped)
+	q.persist()
+	logging.Info("retry queue cleared", "dropped", dropped)
 }
 
 // String returns a string representation of metrics
@@ -0,0 +1,125 @@
+package network
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/logging"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/output"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/schema"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/types"
+)
+
+// SetInputSchemas sets the task input schemas published in the registration
+// and capabilities messages (nil stops publishing them)
+func (p *ProtocolHandler) SetInputSchemas(schemas map[string]json.RawMessage) {
+	p.resourcesMu.Lock()
+	defer p.resourcesMu.Unlock()
+	p.inputSchemas = schemas
+}
+
+// InputSchemas returns the published task input schemas by capability
+func (p *ProtocolHandler) InputSchemas() map[string]json.RawMessage {
+	p.resourcesMu.RLock()
+	defer p.resourcesMu.RUnlock()
+	return p.inputSchemas
+}
+
+// SetInputSchemas sets the JSON Schema of the task input per capability (nil
+// disables validation). Tasks requiring a capability with a schema are
+// validated before they run; with a single capability its schema applies to
+// every task. The schemas are also published with the capabilities.
+func (t *TaskCoordinator) SetInputSchemas(schemas map[string]json.RawMessage) error {
+	compiled := make(map[string]*schema.Schema, len(schemas))
+	for capability, raw := range schemas {
+		s, err := schema.Compile(raw)
+		if err != nil {
+			return fmt.Errorf("input schema for %s: %w", capability, err)
+		}
+		if !t.CanHandleCapability(capability) {
+			logging.Warn("input schema declared for a capability the agent does not advertise", "capability", capability)
+		}
+		compiled[capability] = s
+	}
+
+	t.rateLimitMu.Lock()
+	t.inputSchemas = compiled
+	t.rateLimitMu.Unlock()
+	if len(schemas) == 0 {
+		schemas = nil
+	}
+	t.protocolHandler.SetInputSchemas(schemas)
+	return nil
+}
+
+// inputSchemaFor returns the capability and schema a task's input must match,
+// nil if there is none
+func (t *TaskCoordinator) inputSchemaFor(msg *types.Message) (string, *schema.Schema) {
+	t.rateLimitMu.Lock()
+	schemas := t.inputSchemas
+	t.rateLimitMu.Unlock()
+	if len(schemas) == 0 {
+		return "", nil
+	}
+
+	required := t.extractRequiredCapabilities(msg)
+	if capabilities := t.Capabilities(); len(required) == 0 && len(capabilities) == 1 {
+		required = capabilities
+	}
+
+	// Check capabilities in a fixed order so a task matching several always gets the same schema
+	capabilities := make([]string, 0, len(schemas))
+	for capability := range schemas {
+		capabilities = append(capabilities, capability)
+	}
+	sort.Strings(capabilities)
+	for _, requirement := range required {
+		for _, capability := range capabilities {
+			if types.CapabilityMatches(capability, requirement) {
+				return capability, schemas[capability]
+			}
+		}
+	}
+	return "", nil
+}
+
+// checkInputSchema validates a task's input against the schema of its
+// capability and answers invalid tasks with what is wrong.
+// Returns true if the task can be processed.
+func (t *TaskCoordinator) checkInputSchema(ctx context.Context, msg *types.Message, taskID string) bool {
+	capability, inputSchema := t.inputSchemaFor(msg)
+	if inputSchema == nil {
+		return true
+	}
+	err := inputSchema.ValidateInput(msg.Content)
+	if err == nil {
+		return true
+	}
+
+	logging.Warn("task input does not match the capability's schema, rejecting task", "task_id", taskID, "capability", capability, "error", err)
+	t.recordRejection("invalid_input")
+	t.protocolHandler.SendTaskResponseToRoomContext(ctx, taskID, output.Clean(invalidInputMessage(capability, err)), types.StandardMessageTypeString, false, "invalid_input", msg.Room)
+	return false
+}
+
+// invalidInputMessage tells the requester what is wrong with the input and what is expected
+func invalidInputMessage(capability string, err error) string {
+	var validationErr *schema.ValidationError
+	if !errors.As(err, &validationErr) {
+		return fmt.Sprintf("⚠️ Invalid input for %s: %v", capability, err)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "⚠️ Invalid input for %s:", capability)
+	for _, problem := range validationErr.Problems {
+		b.WriteString("\n- " + problem.String())
+	}
+	if validationErr.Expected != "" {
+		b.WriteString("\n\nExpected " + validationErr.Expected + ".")
+	}
+	return b.String()
+}
@@ -0,0 +1,447 @@
+package network
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/events"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/logging"
+)
+
+// AuthState is a stage of the agent's authentication lifecycle
+type AuthState string
+
+const (
+	AuthStateUnauthenticated AuthState = "unauthenticated" // No session, e.g. before connecting or after the connection dropped
+	AuthStateAuthenticating  AuthState = "authenticating"  // Challenge requested or answered, waiting for the server
+	AuthStateAuthenticated   AuthState = "authenticated"   // Session established, registration sent
+	AuthStateRegistered      AuthState = "registered"      // Registered with the server; tasks reach the agent
+	AuthStateRefreshing      AuthState = "refreshing"      // Re-authenticating before the session expires; the current session stays in use
+	AuthStateExpired         AuthState = "expired"         // The session expired or the server no longer accepts it
+	AuthStateFailed          AuthState = "failed"          // The server rejected the authentication
+)
+
+// AuthEvent reports a change of the authentication state
+type AuthEvent struct {
+	State     AuthState
+	Previous  AuthState
+	Time      time.Time
+	ExpiresAt time.Time // When the session expires (zero = unknown or no session)
+	Reason    string    // Why the state changed, e.g. the server's error message
+}
+
+// authEventBuffer is how many events a slow subscriber may fall behind before events are dropped
+const authEventBuffer = 16
+
+// SessionConfig configures how sessions are kept alive
+type SessionConfig struct {
+	// RefreshBefore is how long before the session expires the agent
+	// re-authenticates. It is capped at half the session lifetime.
+	RefreshBefore time.Duration
+
+	// DefaultTTL is the session lifetime assumed when the server does not
+	// state one (0 = such sessions last until the connection drops)
+	DefaultTTL time.Duration
+
+	// ChallengeTTL is how long the agent waits for the server to accept an
+	// answered challenge before requesting a new one, unless the challenge
+	// states its own expiry
+	ChallengeTTL time.Duration
+}
+
+// DefaultSessionConfig returns the default session configuration
+func DefaultSessionConfig() *SessionConfig {
+	return &SessionConfig{
+		RefreshBefore: time.Minute,
+		ChallengeTTL:  2 * time.Minute,
+	}
+}
+
+// session tracks the authentication state and the timers that keep it alive
+type session struct {
+	mu                 sync.Mutex
+	config             SessionConfig
+	state              AuthState
+	expiresAt          time.Time // Session expiry, zero if unknown
+	challengeExpiresAt time.Time // Expiry stated by the server for the current challenge, zero if none
+	refreshTimer       *time.Timer
+	expiryTimer        *time.Timer
+	challengeTimer     *time.Timer
+	subscribers        map[int]chan AuthEvent
+	nextSubscriber     int
+	bus                *events.Bus
+}
+
+func newSession() *session {
+	return &session{
+		config:      *DefaultSessionConfig(),
+		state:       AuthStateUnauthenticated,
+		subscribers: make(map[int]chan AuthEvent),
+	}
+}
+
+// SetSessionConfig sets how sessions are kept alive (nil = defaults). It
+// applies from the next authentication.
+func (p *ProtocolHandler) SetSessionConfig(config *SessionConfig) {
+	if config == nil {
+		config = DefaultSessionConfig()
+	}
+	p.session.mu.Lock()
+	defer p.session.mu.Unlock()
+	p.session.config = *config
+}
+
+// SetEventBus sets the bus authentication events are published to (nil = none)
+func (p *ProtocolHandler) SetEventBus(bus *events.Bus) {
+	p.session.mu.Lock()
+	defer p.session.mu.Unlock()
+	p.session.bus = bus
+}
+
+// AuthState returns the current authentication state
+func (p *ProtocolHandler) AuthState() AuthState {
+	p.session.mu.Lock()
+	defer p.session.mu.Unlock()
+	return p.session.state
+}
+
+// SessionExpiresAt returns when the current session expires (zero if unknown or no session)
+func (p *ProtocolHandler) SessionExpiresAt() time.Time {
+	p.session.mu.Lock()
+	defer p.session.mu.Unlock()
+	return p.session.expiresAt
+}
+
+// SubscribeAuthEvents returns a channel receiving every authentication state
+// change and a function that ends the subscription. Events are dropped for
+// subscribers that fall behind, so read the channel promptly.
+func (p *ProtocolHandler) SubscribeAuthEvents() (<-chan AuthEvent, func()) {
+	s := p.session
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := s.nextSubscriber
+	s.nextSubscriber++
+	ch := make(chan AuthEvent, authEventBuffer)
+	s.subscribers[id] = ch
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			delete(s.subscribers, id)
+			close(ch)
+		})
+	}
+}
+
+// setAuthState changes the authentication state and notifies subscribers.
+// It must be called with the session lock held.
+func (s *session) setAuthState(state AuthState, reason string) {
+	if state == s.state && reason == "" {
+		return
+	}
+
+	event := AuthEvent{
+		State:     state,
+		Previous:  s.state,
+		Time:      time.Now(),
+		ExpiresAt: s.expiresAt,
+		Reason:    reason,
+	}
+	s.state = state
+	logging.Debug("auth state changed", "state", state, "previous", event.Previous, "reason", reason)
+
+	for _, subscriber := range s.subscribers {
+		select {
+		case subscriber <- event:
+		default:
+			logging.Warn("dropping auth event for slow subscriber", "state", state)
+		}
+	}
+
+	switch state {
+	case AuthStateAuthenticated:
+		s.bus.Publish(events.Authenticated{ExpiresAt: s.expiresAt})
+	case AuthStateRegistered:
+		// Returning to the current session after a failed refresh is not a new registration
+		if event.Previous != AuthStateFailed {
+			s.bus.Publish(events.Registered{})
+		}
+	case AuthStateFailed:
+		s.bus.Publish(events.AuthFailed{Reason: reason})
+	case AuthStateExpired:
+		s.bus.Publish(events.SessionExpired{Reason: reason})
+	}
+}
+
+// stopTimers stops the refresh, expiry and challenge timers.
+// It must be called with the session lock held.
+func (s *session) stopTimers() {
+	for _, timer := range []*time.Timer{s.refreshTimer, s.expiryTimer, s.challengeTimer} {
+		if timer != nil {
+			timer.Stop()
+		}
+	}
+	s.refreshTimer, s.expiryTimer, s.challengeTimer = nil, nil, nil
+}
+
+// beginAuthentication records that a challenge is being requested. A running
+// session stays in use while it is refreshed.
+func (p *ProtocolHandler) beginAuthentication() {
+	s := p.session
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.challengeExpiresAt = time.Time{}
+	if s.state != AuthStateRefreshing {
+		s.setAuthState(AuthStateAuthenticating, "")
+	}
+}
+
+// trackChallenge records the expiry the server states for a challenge, as
+// expires_at or expires_in
+func (p *ProtocolHandler) trackChallenge(data []byte) {
+	s := p.session
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.challengeExpiresAt = parseExpiry(data, time.Now())
+}
+
+// challengeExpired reports whether the current challenge is too old to answer
+func (p *ProtocolHandler) challengeExpired() bool {
+	s := p.session
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return !s.challengeExpiresAt.IsZero() && time.Now().After(s.challengeExpiresAt)
+}
+
+// awaitAuthResponse requests a new challenge if the server does not accept
+// the answered one before it expires
+func (p *ProtocolHandler) awaitAuthResponse() {
+	s := p.session
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	wait := s.config.ChallengeTTL
+	if !s.challengeExpiresAt.IsZero() {
+		wait = time.Until(s.challengeExpiresAt)
+	}
+	if s.challengeTimer != nil {
+		s.challengeTimer.Stop()
+		s.challengeTimer = nil
+	}
+	if wait <= 0 {
+		return
+	}
+
+	var timer *time.Timer
+	timer = time.AfterFunc(wait, func() {
+		s.mu.Lock()
+		waiting := s.challengeTimer == timer && (s.state == AuthStateAuthenticating || s.state == AuthStateRefreshing)
+		s.mu.Unlock()
+		if !waiting || !p.client.IsConnected() {
+			return
+		}
+
+		logging.Warn("no response to authentication before the challenge expired, requesting a new challenge")
+		if err := p.RequestChallenge(); err != nil {
+			logging.Error("failed to request new challenge", "error", err)
+		}
+	})
+	s.challengeTimer = timer
+}
+
+// startSession records an accepted authentication and schedules the refresh
+// before the session expires. data is the server's auth response, which may
+// state the expiry as expires_at (RFC 3339 or Unix seconds) or expires_in
+// (seconds).
+func (p *ProtocolHandler) startSession(data []byte) {
+	s := p.session
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	refreshed := s.state == AuthStateRefreshing
+	s.stopTimers()
+	s.challengeExpiresAt = time.Time{}
+	s.expiresAt = parseExpiry(data, now)
+	if s.expiresAt.IsZero() && s.config.DefaultTTL > 0 {
+		s.expiresAt = now.Add(s.config.DefaultTTL)
+	}
+
+	reason := ""
+	if refreshed {
+		reason = "session refreshed"
+	}
+	lifetime := s.expiresAt.Sub(now)
+	if !s.expiresAt.IsZero() && lifetime <= 0 {
+		// Most likely clock skew; refreshing right away would loop
+		logging.Warn("ignoring session expiry in the past", "expires_at", s.expiresAt.Format(time.RFC3339))
+		s.expiresAt = time.Time{}
+	}
+	s.setAuthState(AuthStateAuthenticated, reason)
+	if s.expiresAt.IsZero() {
+		return
+	}
+
+	margin := min(s.config.RefreshBefore, lifetime/2)
+	logging.Info("session established", "expires_at", s.expiresAt.Format(time.RFC3339), "refresh_in", (lifetime - margin).Round(time.Second))
+
+	expiresAt := s.expiresAt
+	s.refreshTimer = time.AfterFunc(lifetime-margin, func() { p.refreshSession(expiresAt) })
+	s.expiryTimer = time.AfterFunc(lifetime, func() { p.sessionExpired(expiresAt) })
+}
+
+// markRegistered records that the server accepted the registration
+func (p *ProtocolHandler) markRegistered() {
+	s := p.session
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.setAuthState(AuthStateRegistered, "")
+}
+
+// authFailed records a rejected authentication. A refresh that fails leaves
+// the current session in use until it expires.
+func (p *ProtocolHandler) authFailed(reason string) {
+	s := p.session
+	s.mu.Lock()
+	refreshing := s.state == AuthStateRefreshing && time.Now().Before(s.expiresAt)
+	if s.challengeTimer != nil {
+		s.challengeTimer.Stop()
+		s.challengeTimer = nil
+	}
+	s.setAuthState(AuthStateFailed, reason)
+	if refreshing {
+		// Back to the session that is still valid; it is re-established from scratch once it expires
+		s.setAuthState(AuthStateRegistered, "session refresh failed, keeping the current session")
+	}
+	s.mu.Unlock()
+
+	if !refreshing {
+		p.client.SetAuthenticated(false)
+	}
+}
+
+// refreshSession re-authenticates while the session is still valid, so the
+// agent stays registered without a gap
+func (p *ProtocolHandler) refreshSession(expiresAt time.Time) {
+	s := p.session
+	s.mu.Lock()
+	current := s.expiresAt.Equal(expiresAt) && (s.state == AuthStateAuthenticated || s.state == AuthStateRegistered)
+	if current {
+		s.setAuthState(AuthStateRefreshing, "session expires soon")
+	}
+	s.mu.Unlock()
+	if !current || !p.client.IsConnected() {
+		return
+	}
+
+	logging.Info("refreshing session before it expires", "expires_at", expiresAt.Format(time.RFC3339))
+	if err := p.StartAuthentication(); err != nil {
+		logging.Error("failed to refresh session", "error", err)
+	}
+}
+
+// sessionExpired drops a session that was not refreshed in time and
+// authenticates from scratch
+func (p *ProtocolHandler) sessionExpired(expiresAt time.Time) {
+	s := p.session
+	s.mu.Lock()
+	current := s.expiresAt.Equal(expiresAt)
+	s.mu.Unlock()
+	if current {
+		p.expireSession("session expired")
+	}
+}
+
+// expireSession marks the session as no longer valid and, while connected,
+// starts a new authentication
+func (p *ProtocolHandler) expireSession(reason string) {
+	s := p.session
+	s.mu.Lock()
+	s.stopTimers()
+	s.expiresAt = time.Time{}
+	s.setAuthState(AuthStateExpired, reason)
+	s.mu.Unlock()
+
+	p.client.SetAuthenticated(false)
+	if !p.client.IsConnected() {
+		return
+	}
+
+	logging.Warn("session no longer valid, re-authenticating", "reason", reason)
+	if err := p.StartAuthentication(); err != nil {
+		logging.Error("failed to re-authenticate", "error", err)
+	}
+}
+
+// reauthenticateAfterReconnect starts a new session on a re-established
+// connection and waits until it is established
+func (p *ProtocolHandler) reauthenticateAfterReconnect(ctx context.Context) error {
+	if p.yielded() {
+		return errors.New("not re-authenticating, the agent yielded to another process")
+	}
+
+	s := p.session
+	s.mu.Lock()
+	s.stopTimers()
+	s.expiresAt = time.Time{}
+	s.setAuthState(AuthStateUnauthenticated, "connection re-established")
+	s.mu.Unlock()
+
+	logging.Info("re-authenticating after reconnect")
+	if err := p.StartAuthentication(); err != nil {
+		return fmt.Errorf("failed to re-authenticate after reconnect: %w", err)
+	}
+	return p.awaitAuthState(ctx, AuthStateAuthenticated, AuthStateRegistered)
+}
+
+// isSessionError reports whether a server error means the session is gone
+func isSessionError(content string) bool {
+	content = strings.ToLower(content)
+	for _, phrase := range []string{"session expired", "session not found", "invalid session", "not authenticated", "unauthenticated", "token expired"} {
+		if strings.Contains(content, phrase) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseExpiry reads an expiry from a server message: expires_at as RFC 3339
+// or Unix seconds, or expires_in in seconds. It returns the zero time if the
+// message states none.
+func parseExpiry(data []byte, now time.Time) time.Time {
+	if len(data) == 0 {
+		return time.Time{}
+	}
+	var fields struct {
+		ExpiresAt json.RawMessage `json:"expires_at"`
+		ExpiresIn json.Number     `json:"expires_in"`
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return time.Time{}
+	}
+
+	if len(fields.ExpiresAt) > 0 {
+		var text string
+		if json.Unmarshal(fields.ExpiresAt, &text) == nil {
+			if t, err := time.Parse(time.RFC3339, text); err == nil {
+				return t
+			}
+		} else if seconds, err := strconv.ParseInt(string(fields.ExpiresAt), 10, 64); err == nil && seconds > 0 {
+			return time.Unix(seconds, 0)
+		}
+	}
+	if seconds, err := fields.ExpiresIn.Float64(); err == nil && seconds > 0 {
+		return now.Add(time.Duration(seconds * float64(time.Second)))
+	}
+	return time.Time{}
+}
@@ -0,0 +1,140 @@
+package network
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/auth"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/logging"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/types"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+var (
+	// ErrUnsignedTask is returned when a task without a signature arrives while verification is enabled
+	ErrUnsignedTask = fmt.Errorf("%w: task is not signed", types.ErrSignatureInvalid)
+
+	// ErrStaleTask is returned when a signed task's timestamp is outside the allowed clock skew
+	ErrStaleTask = errors.New("task timestamp outside allowed clock skew")
+)
+
+// MessageSigningConfig configures task envelope verification and response signing
+type MessageSigningConfig struct {
+	CoordinatorPublicKey string        // Hex public key tasks must be signed with (empty = tasks are not verified)
+	SignResponses        bool          // Sign task responses with the agent's private key
+	MaxClockSkew         time.Duration // Reject signed tasks whose timestamp differs more than this from local time (0 = no check)
+}
+
+// DefaultMessageSigningConfig returns the default signing configuration:
+// responses are signed, tasks are not verified
+func DefaultMessageSigningConfig() *MessageSigningConfig {
+	return &MessageSigningConfig{
+		SignResponses: true,
+		MaxClockSkew:  5 * time.Minute,
+	}
+}
+
+// messageSigner holds the parsed signing configuration
+type messageSigner struct {
+	config      *MessageSigningConfig
+	coordinator common.Address // Address of CoordinatorPublicKey, zero when tasks are not verified
+}
+
+// SetMessageSigning enables task verification and response signing
+func (p *ProtocolHandler) SetMessageSigning(config *MessageSigningConfig) error {
+	if config == nil {
+		config = DefaultMessageSigningConfig()
+	}
+
+	signer := &messageSigner{config: config}
+	if config.CoordinatorPublicKey != "" {
+		publicKey, err := auth.ParsePublicKey(config.CoordinatorPublicKey)
+		if err != nil {
+			return fmt.Errorf("invalid coordinator public key: %w", err)
+		}
+		signer.coordinator = crypto.PubkeyToAddress(*publicKey)
+		logging.Info("task signature verification enabled", "coordinator", signer.coordinator.Hex())
+	}
+
+	p.signingMu.Lock()
+	p.signer = signer
+	p.signingMu.Unlock()
+	return nil
+}
+
+// getSigner returns the signing configuration, or nil when signing is not configured
+func (p *ProtocolHandler) getSigner() *messageSigner {
+	p.signingMu.RLock()
+	defer p.signingMu.RUnlock()
+	return p.signer
+}
+
+// VerifyTasks wraps a task handler so that only tasks signed with the
+// coordinator's key reach it. Tasks received over the connection are checked
+// as they arrive, in the compressed and split form they were signed in;
+// tasks not Verified there are checked by the handler. Without a
+// coordinator public key configured, tasks pass through unchanged.
+func (p *ProtocolHandler) VerifyTasks(next MessageHandler) MessageHandler {
+	p.client.VerifyReceived(types.MessageTypeTask, p.verifyTask)
+	return func(msg *types.Message) error {
+		if msg.Verified {
+			return next(msg)
+		}
+		if err := p.verifyTask(msg); err != nil {
+			logging.Warn("rejected task with invalid signature", "from", msg.From, "task_id", msg.TaskID, "error", err)
+			return err
+		}
+		return next(msg)
+	}
+}
+
+// verifyTask checks a task envelope's signature against the coordinator's key
+func (p *ProtocolHandler) verifyTask(msg *types.Message) error {
+	signer := p.getSigner()
+	if signer == nil || signer.coordinator == (common.Address{}) {
+		return nil
+	}
+	if msg.Signature == "" {
+		return ErrUnsignedTask
+	}
+
+	if skew := signer.config.MaxClockSkew; skew > 0 {
+		if age := time.Since(msg.Timestamp); age > skew || age < -skew {
+			return fmt.Errorf("%w: %s", ErrStaleTask, msg.Timestamp.Format(time.RFC3339))
+		}
+	}
+
+	payload, err := msg.SigningPayload()
+	if err != nil {
+		return err
+	}
+	recovered, err := auth.RecoverSigner(string(payload), msg.Signature)
+	if err != nil {
+		return fmt.Errorf("%w: %v", types.ErrSignatureInvalid, err)
+	}
+	if recovered != signer.coordinator {
+		return fmt.Errorf("%w: signed by %s", types.ErrSignatureInvalid, recovered.Hex())
+	}
+	return nil
+}
+
+// signMessage signs an outgoing message with the agent's private key when response signing is enabled
+func (p *ProtocolHandler) signMessage(msg *types.Message) error {
+	signer := p.getSigner()
+	if signer == nil || !signer.config.SignResponses || p.auth == nil {
+		return nil
+	}
+
+	payload, err := msg.SigningPayload()
+	if err != nil {
+		return err
+	}
+	signature, err := p.auth.SignMessage(string(payload))
+	if err != nil {
+		return fmt.Errorf("failed to sign %s: %w", msg.Type, err)
+	}
+	msg.Signature = signature
+	return nil
+}
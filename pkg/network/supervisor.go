@@ -3,37 +3,55 @@ package network
 import (
 	"context"
 	"fmt"
-	"log"
+	"runtime/debug"
 	"sync"
 	"sync/atomic"
 	"time"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/logging"
 )
 
-// GoroutineFunc represents a function that runs in a goroutine
+// GoroutineFunc is the body of a supervised goroutine. It runs until ctx is
+// canceled. Returning nil ends the goroutine without a restart; returning an
+// error or panicking restarts it as its restart policy allows.
 type GoroutineFunc func(ctx context.Context) error
 
+// GoroutineState is the state of a supervised goroutine
+type GoroutineState string
+
+// Goroutine states
+const (
+	GoroutineRunning    GoroutineState = "running"
+	GoroutineRestarting GoroutineState = "restarting" // Waiting out the backoff after a failure
+	GoroutineStopped    GoroutineState = "stopped"    // Not started, stopped, or ended without error
+	GoroutineFailed     GoroutineState = "failed"     // Gave up after too many restarts
+)
+
 // SupervisedGoroutine represents a goroutine managed by the supervisor
 type SupervisedGoroutine struct {
 	ID            string
 	Name          string
 	Function      GoroutineFunc
 	RestartPolicy RestartPolicy
-	
-	// Runtime state
-	running       int32 // atomic
-	restartCount  int
+
+	// Runtime state, guarded by mu
+	mu            sync.Mutex
+	state         GoroutineState
+	restartCount  int // Restarts since the goroutine last ran stably
+	totalRestarts int
 	lastError     error
 	lastRestart   time.Time
-	ctx           context.Context
-	cancel        context.CancelFunc
+	cancel        context.CancelFunc // Stops the current instance, nil if none
+	done          chan struct{}      // Closed when the current instance has exited
 }
 
 // RestartPolicy defines how a goroutine should be restarted
 type RestartPolicy struct {
-	MaxRestarts     int
+	MaxRestarts     int // Restarts after failures before giving up (negative = unlimited)
 	RestartDelay    time.Duration
 	BackoffFactor   float64
 	MaxBackoffDelay time.Duration
+	StableAfter     time.Duration    // A run this long resets the restart count (0 = never)
 	OnFailure       func(error, int) // Called on failure with error and restart count
 }
 
@@ -44,15 +62,18 @@ func DefaultRestartPolicy() RestartPolicy {
 		RestartDelay:    1 * time.Second,
 		BackoffFactor:   2.0,
 		MaxBackoffDelay: 30 * time.Second,
+		StableAfter:     time.Minute,
 	}
 }
 
-// GoroutineSupervisor manages and supervises goroutines
+// GoroutineSupervisor manages and supervises goroutines. It can be started
+// again after Stop.
 type GoroutineSupervisor struct {
 	goroutines map[string]*SupervisedGoroutine
 	mu         sync.RWMutex
-	ctx        context.Context
-	cancel     context.CancelFunc
+	parent     context.Context
+	ctx        context.Context    // Context of the current run, nil while stopped; guarded by mu
+	cancel     context.CancelFunc // Ends the current run, guarded by mu
 	wg         sync.WaitGroup
 	running    int32 // atomic
 }
@@ -62,57 +83,56 @@ func NewGoroutineSupervisor(ctx context.Context) *GoroutineSupervisor {
 	if ctx == nil {
 		ctx = context.Background()
 	}
-	
-	supervisorCtx, cancel := context.WithCancel(ctx)
-	
+
 	return &GoroutineSupervisor{
 		goroutines: make(map[string]*SupervisedGoroutine),
-		ctx:        supervisorCtx,
-		cancel:     cancel,
+		parent:     ctx,
 	}
 }
 
-// Register registers a new goroutine with the supervisor
+// Register registers a new goroutine with the supervisor. It is started
+// right away if the supervisor is running.
 func (gs *GoroutineSupervisor) Register(id, name string, fn GoroutineFunc, policy RestartPolicy) error {
 	gs.mu.Lock()
-	defer gs.mu.Unlock()
-	
 	if _, exists := gs.goroutines[id]; exists {
+		gs.mu.Unlock()
 		return fmt.Errorf("goroutine with ID %s already registered", id)
 	}
-	
+
 	sg := &SupervisedGoroutine{
 		ID:            id,
 		Name:          name,
 		Function:      fn,
 		RestartPolicy: policy,
+		state:         GoroutineStopped,
 	}
-	
 	gs.goroutines[id] = sg
-	
-	log.Printf("👁️ Registered goroutine: %s (%s)", name, id)
+	gs.mu.Unlock()
+
+	logging.Info("registered goroutine", "name", name, "id", id)
+	if atomic.LoadInt32(&gs.running) == 1 {
+		gs.startGoroutine(sg)
+	}
 	return nil
 }
 
-// Start starts the supervisor and all registered goroutines
+// Start starts the supervisor and all registered goroutines, each run with
+// a fresh context so a stopped supervisor can be started again
 func (gs *GoroutineSupervisor) Start() error {
 	if !atomic.CompareAndSwapInt32(&gs.running, 0, 1) {
 		return fmt.Errorf("supervisor already running")
 	}
-	
-	gs.mu.RLock()
-	goroutines := make([]*SupervisedGoroutine, 0, len(gs.goroutines))
-	for _, sg := range gs.goroutines {
-		goroutines = append(goroutines, sg)
-	}
-	gs.mu.RUnlock()
-	
-	// Start all goroutines
+
+	gs.mu.Lock()
+	gs.ctx, gs.cancel = context.WithCancel(gs.parent)
+	gs.mu.Unlock()
+
+	goroutines := gs.all()
 	for _, sg := range goroutines {
 		gs.startGoroutine(sg)
 	}
-	
-	log.Printf("👁️ Supervisor started with %d goroutines", len(goroutines))
+
+	logging.Info("supervisor started", "goroutines", len(goroutines))
 	return nil
 }
 
@@ -121,225 +141,313 @@ func (gs *GoroutineSupervisor) Stop() {
 	if !atomic.CompareAndSwapInt32(&gs.running, 1, 0) {
 		return
 	}
-	
-	log.Println("👁️ Stopping supervisor...")
-	
+
+	logging.Info("stopping supervisor")
+
 	// Cancel context to signal all goroutines to stop
-	gs.cancel()
-	
-	// Cancel individual goroutine contexts
-	gs.mu.RLock()
-	for _, sg := range gs.goroutines {
-		if sg.cancel != nil {
-			sg.cancel()
-		}
-	}
-	gs.mu.RUnlock()
-	
+	gs.mu.Lock()
+	cancel := gs.cancel
+	gs.ctx, gs.cancel = nil, nil
+	gs.mu.Unlock()
+	cancel()
+
 	// Wait for all goroutines to finish
 	done := make(chan struct{})
 	go func() {
 		gs.wg.Wait()
 		close(done)
 	}()
-	
+
 	select {
 	case <-done:
-		log.Println("👁️ All goroutines stopped gracefully")
+		logging.Info("all goroutines stopped gracefully")
 	case <-time.After(10 * time.Second):
-		log.Println("⚠️ Timeout waiting for goroutines to stop")
+		logging.Warn("timeout waiting for goroutines to stop")
 	}
-	
-	log.Println("👁️ Supervisor stopped")
+
+	logging.Info("supervisor stopped")
 }
 
-// startGoroutine starts a supervised goroutine
+// all returns the registered goroutines
+func (gs *GoroutineSupervisor) all() []*SupervisedGoroutine {
+	gs.mu.RLock()
+	defer gs.mu.RUnlock()
+	goroutines := make([]*SupervisedGoroutine, 0, len(gs.goroutines))
+	for _, sg := range gs.goroutines {
+		goroutines = append(goroutines, sg)
+	}
+	return goroutines
+}
+
+// get returns a registered goroutine
+func (gs *GoroutineSupervisor) get(id string) (*SupervisedGoroutine, error) {
+	gs.mu.RLock()
+	defer gs.mu.RUnlock()
+	sg, exists := gs.goroutines[id]
+	if !exists {
+		return nil, fmt.Errorf("goroutine with ID %s not found", id)
+	}
+	return sg, nil
+}
+
+// startGoroutine starts an instance of a goroutine unless one is running or
+// the supervisor is stopped
 func (gs *GoroutineSupervisor) startGoroutine(sg *SupervisedGoroutine) {
-	if atomic.LoadInt32(&sg.running) == 1 {
+	gs.mu.RLock()
+	parent := gs.ctx
+	gs.mu.RUnlock()
+	if parent == nil {
+		return
+	}
+
+	sg.mu.Lock()
+	defer sg.mu.Unlock()
+	if sg.cancel != nil {
 		return
 	}
-	
-	// Create context for this goroutine
-	sg.ctx, sg.cancel = context.WithCancel(gs.ctx)
-	atomic.StoreInt32(&sg.running, 1)
-	
+
+	ctx, cancel := context.WithCancel(parent)
+	done := make(chan struct{})
+	sg.cancel = cancel
+	sg.done = done
+	sg.state = GoroutineRunning
+
 	gs.wg.Add(1)
-	go gs.runGoroutine(sg)
-	
-	log.Printf("▶️ Started goroutine: %s", sg.Name)
+	go gs.runGoroutine(sg, ctx, done)
+
+	logging.Debug("started goroutine", "goroutine", sg.Name)
+}
+
+// stopGoroutine stops the current instance of a goroutine and waits until it exited
+func (gs *GoroutineSupervisor) stopGoroutine(sg *SupervisedGoroutine) {
+	sg.mu.Lock()
+	cancel, done := sg.cancel, sg.done
+	sg.mu.Unlock()
+	if cancel == nil {
+		return
+	}
+	cancel()
+	<-done
 }
 
-// runGoroutine runs a goroutine with supervision
-func (gs *GoroutineSupervisor) runGoroutine(sg *SupervisedGoroutine) {
+// runGoroutine runs one instance of a goroutine, restarting it after
+// failures with backoff until it ends, is stopped, or runs out of restarts
+func (gs *GoroutineSupervisor) runGoroutine(sg *SupervisedGoroutine, ctx context.Context, done chan struct{}) {
 	defer gs.wg.Done()
-	defer atomic.StoreInt32(&sg.running, 0)
-	
+	defer close(done)
+	state := GoroutineStopped
+	defer func() {
+		sg.mu.Lock()
+		sg.state = state
+		sg.cancel = nil
+		sg.mu.Unlock()
+	}()
+
 	for {
-		// Check if supervisor is stopping
-		if atomic.LoadInt32(&gs.running) == 0 {
+		sg.setState(GoroutineRunning)
+		started := time.Now()
+		err := callGoroutine(sg, ctx)
+
+		if ctx.Err() != nil {
+			logging.Debug("goroutine stopped", "goroutine", sg.Name)
 			return
 		}
-		
-		// Run the goroutine function
-		err := sg.Function(sg.ctx)
-		
-		// Check if context was cancelled (normal shutdown)
-		select {
-		case <-sg.ctx.Done():
-			log.Printf("⏹️ Goroutine %s stopped (context cancelled)", sg.Name)
+		if err == nil {
+			logging.Info("goroutine completed", "goroutine", sg.Name)
 			return
-		default:
 		}
-		
-		// Handle error
-		if err != nil {
-			sg.lastError = err
-			sg.restartCount++
-			
-			log.Printf("❌ Goroutine %s failed (restart %d/%d): %v",
-				sg.Name, sg.restartCount, sg.RestartPolicy.MaxRestarts, err)
-			
-			// Call failure handler if provided
-			if sg.RestartPolicy.OnFailure != nil {
-				sg.RestartPolicy.OnFailure(err, sg.restartCount)
-			}
-			
-			// Check if we should restart
-			if sg.restartCount > sg.RestartPolicy.MaxRestarts {
-				log.Printf("💀 Goroutine %s exceeded max restarts, giving up", sg.Name)
-				return
-			}
-			
-			// Calculate backoff delay
-			delay := gs.calculateBackoff(sg)
-			sg.lastRestart = time.Now().Add(delay)
-			
-			log.Printf("🔄 Restarting goroutine %s in %v", sg.Name, delay)
-			
-			// Wait before restarting
-			select {
-			case <-time.After(delay):
-				// Continue loop to restart
-			case <-sg.ctx.Done():
-				return
-			}
-		} else {
-			// Goroutine exited normally without error
-			log.Printf("✅ Goroutine %s completed successfully", sg.Name)
+
+		restarts, giveUp := sg.recordFailure(err, time.Since(started))
+		logging.Error("goroutine failed", "goroutine", sg.Name, "restart", restarts, "max_restarts", sg.RestartPolicy.MaxRestarts, "error", err)
+		if sg.RestartPolicy.OnFailure != nil {
+			sg.RestartPolicy.OnFailure(err, restarts)
+		}
+		if giveUp {
+			logging.Error("goroutine exceeded max restarts, giving up", "goroutine", sg.Name)
+			state = GoroutineFailed
+			return
+		}
+
+		delay := sg.RestartPolicy.backoff(restarts)
+		sg.mu.Lock()
+		sg.state = GoroutineRestarting
+		sg.lastRestart = time.Now().Add(delay)
+		sg.mu.Unlock()
+		logging.Info("restarting goroutine", "goroutine", sg.Name, "delay", delay)
+
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
 			return
 		}
 	}
 }
 
-// calculateBackoff calculates the backoff delay for a restart
-func (gs *GoroutineSupervisor) calculateBackoff(sg *SupervisedGoroutine) time.Duration {
-	delay := sg.RestartPolicy.RestartDelay
-	
-	// Apply exponential backoff
-	for i := 1; i < sg.restartCount; i++ {
-		delay = time.Duration(float64(delay) * sg.RestartPolicy.BackoffFactor)
-		if delay > sg.RestartPolicy.MaxBackoffDelay {
-			delay = sg.RestartPolicy.MaxBackoffDelay
-			break
+// callGoroutine runs the goroutine's function, turning a panic into an error
+func callGoroutine(sg *SupervisedGoroutine, ctx context.Context) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			logging.Error("goroutine panicked", "goroutine", sg.Name, "panic", r, "stack", string(debug.Stack()))
+			err = fmt.Errorf("goroutine panicked: %v", r)
+		}
+	}()
+	return sg.Function(ctx)
+}
+
+// setState sets the goroutine's state
+func (sg *SupervisedGoroutine) setState(state GoroutineState) {
+	sg.mu.Lock()
+	defer sg.mu.Unlock()
+	sg.state = state
+}
+
+// recordFailure counts a failure after a run of the given length and returns
+// the restart count and whether the policy allows no more restarts
+func (sg *SupervisedGoroutine) recordFailure(err error, ran time.Duration) (int, bool) {
+	sg.mu.Lock()
+	defer sg.mu.Unlock()
+	if sg.RestartPolicy.StableAfter > 0 && ran >= sg.RestartPolicy.StableAfter {
+		sg.restartCount = 0
+	}
+	sg.restartCount++
+	sg.lastError = err
+	max := sg.RestartPolicy.MaxRestarts
+	if max >= 0 && sg.restartCount > max {
+		return sg.restartCount, true
+	}
+	sg.totalRestarts++
+	return sg.restartCount, false
+}
+
+// backoff returns the delay before the given restart
+func (p RestartPolicy) backoff(restart int) time.Duration {
+	delay := p.RestartDelay
+	for i := 1; i < restart; i++ {
+		delay = time.Duration(float64(delay) * p.BackoffFactor)
+		if p.MaxBackoffDelay > 0 && delay > p.MaxBackoffDelay {
+			return p.MaxBackoffDelay
 		}
 	}
-	
 	return delay
 }
 
-// RestartGoroutine manually restarts a specific goroutine
+// StartGoroutine starts a registered goroutine that is stopped or failed
+func (gs *GoroutineSupervisor) StartGoroutine(id string) error {
+	sg, err := gs.get(id)
+	if err != nil {
+		return err
+	}
+	if atomic.LoadInt32(&gs.running) == 0 {
+		return fmt.Errorf("supervisor is not running")
+	}
+	gs.startGoroutine(sg)
+	return nil
+}
+
+// StopGoroutine stops a goroutine and waits until it has exited. It stays
+// registered and can be started again.
+func (gs *GoroutineSupervisor) StopGoroutine(id string) error {
+	sg, err := gs.get(id)
+	if err != nil {
+		return err
+	}
+	gs.stopGoroutine(sg)
+	return nil
+}
+
+// RestartGoroutine stops a goroutine and starts a fresh instance with a new
+// context and a reset restart count
 func (gs *GoroutineSupervisor) RestartGoroutine(id string) error {
-	gs.mu.RLock()
-	sg, exists := gs.goroutines[id]
-	gs.mu.RUnlock()
-	
-	if !exists {
-		return fmt.Errorf("goroutine with ID %s not found", id)
+	sg, err := gs.get(id)
+	if err != nil {
+		return err
 	}
-	
-	// Stop the goroutine
-	if sg.cancel != nil {
-		sg.cancel()
+	if atomic.LoadInt32(&gs.running) == 0 {
+		return fmt.Errorf("supervisor is not running")
 	}
-	
-	// Wait a moment for it to stop
-	time.Sleep(100 * time.Millisecond)
-	
-	// Reset restart count for manual restart
+
+	gs.stopGoroutine(sg)
+	sg.mu.Lock()
 	sg.restartCount = 0
-	
-	// Start it again
+	sg.mu.Unlock()
 	gs.startGoroutine(sg)
-	
 	return nil
 }
 
 // GetStatus returns the status of all supervised goroutines
 func (gs *GoroutineSupervisor) GetStatus() map[string]GoroutineStatus {
-	gs.mu.RLock()
-	defer gs.mu.RUnlock()
-	
 	status := make(map[string]GoroutineStatus)
-	
-	for id, sg := range gs.goroutines {
-		status[id] = GoroutineStatus{
-			ID:           sg.ID,
-			Name:         sg.Name,
-			Running:      atomic.LoadInt32(&sg.running) == 1,
-			RestartCount: sg.restartCount,
-			LastError:    sg.lastError,
-			LastRestart:  sg.lastRestart,
+	for _, sg := range gs.all() {
+		sg.mu.Lock()
+		status[sg.ID] = GoroutineStatus{
+			ID:            sg.ID,
+			Name:          sg.Name,
+			State:         sg.state,
+			Running:       sg.state == GoroutineRunning,
+			RestartCount:  sg.restartCount,
+			TotalRestarts: sg.totalRestarts,
+			LastError:     sg.lastError,
+			LastRestart:   sg.lastRestart,
 		}
+		sg.mu.Unlock()
 	}
-	
 	return status
 }
 
 // GoroutineStatus represents the status of a supervised goroutine
 type GoroutineStatus struct {
-	ID           string
-	Name         string
-	Running      bool
-	RestartCount int
-	LastError    error
-	LastRestart  time.Time
+	ID            string
+	Name          string
+	State         GoroutineState
+	Running       bool
+	RestartCount  int // Restarts since the goroutine last ran stably
+	TotalRestarts int
+	LastError     error
+	LastRestart   time.Time // When the last restart happened or is due
 }
 
-// IsHealthy checks if all goroutines are healthy
+// IsHealthy checks if all goroutines are running without repeated failures
 func (gs *GoroutineSupervisor) IsHealthy() bool {
-	gs.mu.RLock()
-	defer gs.mu.RUnlock()
-	
-	for _, sg := range gs.goroutines {
-		if atomic.LoadInt32(&sg.running) == 0 {
+	for _, status := range gs.GetStatus() {
+		if !status.Running {
 			return false
 		}
-		if sg.restartCount > sg.RestartPolicy.MaxRestarts/2 {
+		if max := gs.policyOf(status.ID).MaxRestarts; max >= 0 && status.RestartCount > max/2 {
 			return false // Consider unhealthy if restarted too many times
 		}
 	}
-	
 	return true
 }
 
+// policyOf returns the restart policy of a goroutine
+func (gs *GoroutineSupervisor) policyOf(id string) RestartPolicy {
+	sg, err := gs.get(id)
+	if err != nil {
+		return RestartPolicy{}
+	}
+	return sg.RestartPolicy
+}
+
 // GetMetrics returns supervisor metrics
 func (gs *GoroutineSupervisor) GetMetrics() SupervisorMetrics {
-	gs.mu.RLock()
-	defer gs.mu.RUnlock()
-	
+	status := gs.GetStatus()
 	metrics := SupervisorMetrics{
-		TotalGoroutines: len(gs.goroutines),
+		TotalGoroutines: len(status),
 	}
-	
-	for _, sg := range gs.goroutines {
-		if atomic.LoadInt32(&sg.running) == 1 {
+
+	for _, s := range status {
+		switch s.State {
+		case GoroutineRunning:
 			metrics.RunningGoroutines++
-		} else {
+		case GoroutineFailed:
+			metrics.FailedGoroutines++
+			metrics.StoppedGoroutines++
+		default:
 			metrics.StoppedGoroutines++
 		}
-		metrics.TotalRestarts += sg.restartCount
+		metrics.TotalRestarts += s.TotalRestarts
 	}
-	
+
 	return metrics
 }
 
@@ -347,6 +455,7 @@ func (gs *GoroutineSupervisor) GetMetrics() SupervisorMetrics {
 type SupervisorMetrics struct {
 	TotalGoroutines   int
 	RunningGoroutines int
-	StoppedGoroutines int
+	StoppedGoroutines int // Not running, including failed goroutines
+	FailedGoroutines  int
 	TotalRestarts     int
-}
\ No newline at end of file
+}
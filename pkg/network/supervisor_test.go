@@ -0,0 +1,191 @@
+package network
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+var errGoroutineFailed = errors.New("goroutine failed")
+
+// testRestartPolicy restarts right away
+func testRestartPolicy(maxRestarts int) RestartPolicy {
+	return RestartPolicy{MaxRestarts: maxRestarts, RestartDelay: time.Millisecond, BackoffFactor: 1}
+}
+
+// eventually waits for cond to hold
+func eventually(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// startSupervisor starts a supervisor with one goroutine, stopped at the end of the test
+func startSupervisor(t *testing.T, fn GoroutineFunc, policy RestartPolicy) *GoroutineSupervisor {
+	t.Helper()
+	supervisor := NewGoroutineSupervisor(context.Background())
+	if err := supervisor.Register("worker", "worker", fn, policy); err != nil {
+		t.Fatal(err)
+	}
+	if err := supervisor.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(supervisor.Stop)
+	return supervisor
+}
+
+func statusOf(supervisor *GoroutineSupervisor) GoroutineStatus {
+	return supervisor.GetStatus()["worker"]
+}
+
+func TestSupervisorRestartsAfterPanic(t *testing.T) {
+	var calls atomic.Int32
+	supervisor := startSupervisor(t, func(ctx context.Context) error {
+		if calls.Add(1) == 1 {
+			panic("boom")
+		}
+		<-ctx.Done()
+		return nil
+	}, testRestartPolicy(3))
+
+	eventually(t, "restart after panic", func() bool {
+		return calls.Load() == 2 && statusOf(supervisor).State == GoroutineRunning
+	})
+	status := statusOf(supervisor)
+	if status.TotalRestarts != 1 || status.LastError == nil {
+		t.Errorf("status = %+v, want one restart with the panic as last error", status)
+	}
+}
+
+func TestSupervisorGivesUpAfterMaxRestarts(t *testing.T) {
+	var calls atomic.Int32
+	supervisor := startSupervisor(t, func(ctx context.Context) error {
+		calls.Add(1)
+		return errGoroutineFailed
+	}, testRestartPolicy(2))
+
+	eventually(t, "failed state", func() bool {
+		return statusOf(supervisor).State == GoroutineFailed
+	})
+	if n := calls.Load(); n != 3 {
+		t.Errorf("goroutine ran %d times, want 3 (first run and 2 restarts)", n)
+	}
+	if metrics := supervisor.GetMetrics(); metrics.FailedGoroutines != 1 || metrics.TotalRestarts != 2 {
+		t.Errorf("metrics = %+v, want 1 failed goroutine and 2 restarts", metrics)
+	}
+	if supervisor.IsHealthy() {
+		t.Error("supervisor healthy with a failed goroutine")
+	}
+}
+
+func TestSupervisorStableRunResetsRestartCount(t *testing.T) {
+	// With one restart allowed, the second failure ends the goroutine unless
+	// the run before it was stable
+	var calls atomic.Int32
+	policy := testRestartPolicy(1)
+	policy.StableAfter = 20 * time.Millisecond
+	supervisor := startSupervisor(t, func(ctx context.Context) error {
+		if calls.Add(1) == 2 {
+			time.Sleep(2 * policy.StableAfter)
+		}
+		return errGoroutineFailed
+	}, policy)
+
+	eventually(t, "failed state", func() bool {
+		return statusOf(supervisor).State == GoroutineFailed
+	})
+	if n := calls.Load(); n != 3 {
+		t.Errorf("goroutine ran %d times, want 3", n)
+	}
+}
+
+func TestRestartGoroutineResetsRestartCount(t *testing.T) {
+	var calls atomic.Int32
+	supervisor := startSupervisor(t, func(ctx context.Context) error {
+		if calls.Add(1) <= 2 {
+			return errGoroutineFailed
+		}
+		<-ctx.Done()
+		return nil
+	}, testRestartPolicy(5))
+
+	eventually(t, "running after two failures", func() bool {
+		return calls.Load() == 3 && statusOf(supervisor).State == GoroutineRunning
+	})
+	if count := statusOf(supervisor).RestartCount; count != 2 {
+		t.Fatalf("restart count = %d, want 2", count)
+	}
+
+	if err := supervisor.RestartGoroutine("worker"); err != nil {
+		t.Fatal(err)
+	}
+	eventually(t, "fresh instance", func() bool { return calls.Load() == 4 })
+	status := statusOf(supervisor)
+	if status.RestartCount != 0 || status.State != GoroutineRunning {
+		t.Errorf("status = %+v, want running with restart count 0", status)
+	}
+}
+
+func TestStopGoroutineWaitsForExit(t *testing.T) {
+	var started, exited atomic.Bool
+	supervisor := startSupervisor(t, func(ctx context.Context) error {
+		started.Store(true)
+		<-ctx.Done()
+		time.Sleep(20 * time.Millisecond)
+		exited.Store(true)
+		return nil
+	}, testRestartPolicy(0))
+
+	eventually(t, "goroutine start", started.Load)
+	if err := supervisor.StopGoroutine("worker"); err != nil {
+		t.Fatal(err)
+	}
+	if !exited.Load() {
+		t.Fatal("StopGoroutine returned before the goroutine exited")
+	}
+	if state := statusOf(supervisor).State; state != GoroutineStopped {
+		t.Errorf("state = %s, want %s", state, GoroutineStopped)
+	}
+
+	if err := supervisor.StartGoroutine("worker"); err != nil {
+		t.Fatal(err)
+	}
+	eventually(t, "running after StartGoroutine", func() bool {
+		return statusOf(supervisor).State == GoroutineRunning
+	})
+}
+
+func TestSupervisorStartAfterStop(t *testing.T) {
+	var calls atomic.Int32
+	supervisor := startSupervisor(t, func(ctx context.Context) error {
+		calls.Add(1)
+		<-ctx.Done()
+		return nil
+	}, testRestartPolicy(0))
+
+	eventually(t, "first run", func() bool { return calls.Load() == 1 })
+	supervisor.Stop()
+	if state := statusOf(supervisor).State; state != GoroutineStopped {
+		t.Fatalf("state after Stop = %s, want %s", state, GoroutineStopped)
+	}
+
+	if err := supervisor.Start(); err != nil {
+		t.Fatal(err)
+	}
+	eventually(t, "second run", func() bool {
+		return calls.Load() == 2 && statusOf(supervisor).State == GoroutineRunning
+	})
+
+	// The new run must not end right away on the first run's canceled context
+	time.Sleep(20 * time.Millisecond)
+	if state := statusOf(supervisor).State; state != GoroutineRunning {
+		t.Errorf("state after restart = %s, want %s", state, GoroutineRunning)
+	}
+}
@@ -0,0 +1,69 @@
+package network
+
+import (
+	"context"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/errs"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/output"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/types"
+)
+
+// rejectionCodes classifies the reasons the coordinator rejects tasks for.
+// Reasons not listed are internal errors.
+var rejectionCodes = map[string]types.ErrorCode{
+	"rate_limit_exceeded":      types.ErrorCodeRateLimited,
+	"bandwidth_exceeded":       types.ErrorCodeRateLimited,
+	"quota_exceeded":           types.ErrorCodeRateLimited,
+	"queue_full":               types.ErrorCodeRateLimited,
+	"deadline_exceeded":        types.ErrorCodeTimeout,
+	"unsupported_capability":   types.ErrorCodeCapabilityUnsupported,
+	"invalid_input":            types.ErrorCodeInvalidInput,
+	"input_too_large":          types.ErrorCodeInvalidInput,
+	"task_too_large":           types.ErrorCodeInvalidInput,
+	"invalid_encoding":         types.ErrorCodeInvalidInput,
+	"unsupported_content_type": types.ErrorCodeInvalidInput,
+	"encryption_required":      types.ErrorCodeInvalidInput,
+	"decryption_failed":        types.ErrorCodeInvalidInput,
+	"encryption_not_supported": types.ErrorCodeInvalidInput,
+	"content_blocked":          types.ErrorCodeInvalidInput,
+	"consumer_blocked":         types.ErrorCodeUnauthorized,
+	"access_denied":            types.ErrorCodeUnauthorized,
+	"signature_required":       types.ErrorCodeUnauthorized,
+	"signature_invalid":        types.ErrorCodeUnauthorized,
+	"signature_replayed":       types.ErrorCodeUnauthorized,
+	"payment_required":         types.ErrorCodeUnauthorized,
+	"payment_invalid":          types.ErrorCodeUnauthorized,
+}
+
+// retryableRejections are rejections that may succeed when the task is sent
+// again, beyond those retryable by their code
+var retryableRejections = map[string]bool{
+	"agent_stopping":       true,
+	"payment_unverified":   true,
+	"access_unverified":    true,
+	"signature_unverified": true,
+}
+
+// rejectionError classifies a task rejected for reason, with the
+// rejection's details
+func rejectionError(reason string, details map[string]interface{}) *types.TaskError {
+	code, ok := rejectionCodes[reason]
+	retryable := code.Retryable()
+	if !ok {
+		code, retryable = types.ErrorCodeInternal, retryableRejections[reason]
+	}
+
+	taskErr := &types.TaskError{Code: code, Reason: reason, Retryable: retryable}
+	for key, value := range details {
+		taskErr.WithDetail(key, value)
+	}
+	return taskErr
+}
+
+// SendTaskFailure answers a task whose handler failed with err. The
+// response data carries the error classified under "error_info".
+func (p *ProtocolHandler) SendTaskFailure(ctx context.Context, taskID string, err error, room string) error {
+	return p.sendTaskResponse(ctx, taskID, output.Clean("❌ Error: ")+err.Error(), types.StandardMessageTypeString, false, err.Error(), room, map[string]interface{}{
+		"error_info": errs.TaskErrorOf(err),
+	})
+}
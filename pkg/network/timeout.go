@@ -0,0 +1,187 @@
+package network
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/types"
+)
+
+// ErrDeadlineNotExtendable is returned by ExtendDeadline when the task's
+// deadline cannot move as far as asked
+var ErrDeadlineNotExtendable = errors.New("task deadline cannot be extended")
+
+// TaskTimeouts bounds how long tasks without a server deadline may run
+type TaskTimeouts struct {
+	Default       time.Duration            // Timeout of tasks (default 30s)
+	PerCapability map[string]time.Duration // Overrides by the capability the task requires, e.g. "web_scrape": 5m
+	MaxDuration   time.Duration            // Total run time ExtendDeadline may extend a task to (0 = no limit)
+}
+
+// SetTaskTimeouts sets the timeouts of tasks that come without a deadline (nil
+// = 30 seconds for every task). Tasks with a server deadline run until it.
+func (t *TaskCoordinator) SetTaskTimeouts(timeouts *TaskTimeouts) {
+	t.rateLimitMu.Lock()
+	defer t.rateLimitMu.Unlock()
+	t.timeouts = timeouts
+}
+
+// GetTaskTimeouts returns the task timeouts, nil if the default applies
+func (t *TaskCoordinator) GetTaskTimeouts() *TaskTimeouts {
+	t.rateLimitMu.Lock()
+	defer t.rateLimitMu.Unlock()
+	return t.timeouts
+}
+
+// taskTimeout returns the timeout of a task requiring the given capabilities
+// and the longest it may be extended to
+func (t *TaskCoordinator) taskTimeout(required []string) (timeout, maxDuration time.Duration) {
+	timeouts := t.GetTaskTimeouts()
+	if timeouts == nil {
+		return defaultTaskTimeout, 0
+	}
+	timeout = timeouts.Default
+	if timeout <= 0 {
+		timeout = defaultTaskTimeout
+	}
+	if len(timeouts.PerCapability) == 0 {
+		return timeout, timeouts.MaxDuration
+	}
+
+	if capabilities := t.Capabilities(); len(required) == 0 && len(capabilities) == 1 {
+		required = capabilities
+	}
+	// Check capabilities in a fixed order so a task matching several always gets the same timeout
+	capabilities := make([]string, 0, len(timeouts.PerCapability))
+	for capability := range timeouts.PerCapability {
+		capabilities = append(capabilities, capability)
+	}
+	sort.Strings(capabilities)
+	for _, requirement := range required {
+		for _, capability := range capabilities {
+			if d := timeouts.PerCapability[capability]; d > 0 && types.CapabilityMatches(capability, requirement) {
+				return d, timeouts.MaxDuration
+			}
+		}
+	}
+	return timeout, timeouts.MaxDuration
+}
+
+// extendableDeadline is a context whose deadline the task can move later
+// while it runs. It is wrapped in a regular cancel context so that
+// context.Cause and the child contexts of handlers behave as usual.
+type extendableDeadline struct {
+	parent context.Context
+	done   chan struct{}
+	stop   func() bool // Stops following the parent's cancellation
+
+	mu       sync.Mutex
+	deadline time.Time
+	limit    time.Time // Latest deadline an extension may set (zero = no limit)
+	timer    *time.Timer
+	err      error
+}
+
+// withExtendableDeadline returns a context that is done at deadline unless
+// extended, the function extending it and the function cancelling it with a
+// cause (see taskctx.CancelReason)
+func withExtendableDeadline(parent context.Context, deadline, limit time.Time) (context.Context, func(time.Duration) error, context.CancelCauseFunc) {
+	d := &extendableDeadline{
+		parent:   parent,
+		done:     make(chan struct{}),
+		deadline: deadline,
+		limit:    limit,
+	}
+	d.mu.Lock()
+	d.timer = time.AfterFunc(time.Until(deadline), d.expire)
+	d.mu.Unlock()
+	stop := context.AfterFunc(parent, func() { d.finish(parent.Err()) })
+	d.mu.Lock()
+	d.stop = stop
+	d.mu.Unlock()
+
+	ctx, cancel := context.WithCancelCause(d)
+	return ctx, d.extend, func(cause error) {
+		cancel(cause)
+		d.finish(context.Canceled)
+	}
+}
+
+func (d *extendableDeadline) Deadline() (time.Time, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if parent, ok := d.parent.Deadline(); ok && parent.Before(d.deadline) {
+		return parent, true
+	}
+	return d.deadline, true
+}
+
+func (d *extendableDeadline) Done() <-chan struct{} {
+	return d.done
+}
+
+func (d *extendableDeadline) Err() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.err
+}
+
+func (d *extendableDeadline) Value(key any) any {
+	return d.parent.Value(key)
+}
+
+// expire ends the context when the timer fires, unless an extension moved the deadline
+func (d *extendableDeadline) expire() {
+	d.mu.Lock()
+	if remaining := time.Until(d.deadline); remaining > 0 {
+		d.timer.Reset(remaining)
+		d.mu.Unlock()
+		return
+	}
+	d.mu.Unlock()
+	d.finish(context.DeadlineExceeded)
+}
+
+// finish ends the context with err, once
+func (d *extendableDeadline) finish(err error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.err != nil {
+		return
+	}
+	d.err = err
+	d.timer.Stop()
+	if d.stop != nil {
+		d.stop()
+	}
+	close(d.done)
+}
+
+// extend moves the deadline to by from now. A deadline already later is kept;
+// one past the limit is moved to the limit and reported.
+func (d *extendableDeadline) extend(by time.Duration) error {
+	if by <= 0 {
+		return fmt.Errorf("%w: extension must be positive", ErrDeadlineNotExtendable)
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.err != nil {
+		return fmt.Errorf("%w: task already ended", ErrDeadlineNotExtendable)
+	}
+	deadline := time.Now().Add(by)
+	var err error
+	if !d.limit.IsZero() && deadline.After(d.limit) {
+		deadline = d.limit
+		err = fmt.Errorf("%w past %s", ErrDeadlineNotExtendable, d.limit.UTC().Format(time.RFC3339))
+	}
+	if deadline.After(d.deadline) {
+		// The timer checks the new deadline when it fires and rearms itself
+		d.deadline = deadline
+	}
+	return err
+}
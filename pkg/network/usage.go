@@ -0,0 +1,70 @@
+package network
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/logging"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/types"
+)
+
+// SetUsageMeter sets the meter recording completed tasks for billing (nil disables metering)
+func (t *TaskCoordinator) SetUsageMeter(meter types.UsageMeter) {
+	t.rateLimitMu.Lock()
+	defer t.rateLimitMu.Unlock()
+	t.usageMeter = meter
+}
+
+// getUsageMeter returns the configured usage meter
+func (t *TaskCoordinator) getUsageMeter() types.UsageMeter {
+	t.rateLimitMu.Lock()
+	defer t.rateLimitMu.Unlock()
+	return t.usageMeter
+}
+
+// recordUsage meters a completed task and returns its receipt, nil without
+// a meter or receipt. A task is billed even if its receipt cannot be signed.
+func (t *TaskCoordinator) recordUsage(ctx context.Context) *types.UsageReceipt {
+	meter := t.getUsageMeter()
+	if meter == nil {
+		return nil
+	}
+	info, _ := types.TaskInfoFromContext(ctx)
+	receipt, err := meter.RecordUsage(ctx, info)
+	if err != nil {
+		logging.Warn("failed to issue usage receipt", "task_id", info.ID, "error", err)
+		return nil
+	}
+	return receipt
+}
+
+// receiptDetails returns the response data carrying a receipt, nil without one
+func receiptDetails(receipt *types.UsageReceipt) map[string]interface{} {
+	if receipt == nil {
+		return nil
+	}
+	return map[string]interface{}{"receipt": receipt}
+}
+
+// SendUsageReceipt sends the receipt of a task that answered with several
+// messages, after its last one
+func (p *ProtocolHandler) SendUsageReceipt(ctx context.Context, room string, receipt *types.UsageReceipt) error {
+	data, err := json.Marshal(receipt)
+	if err != nil {
+		return fmt.Errorf("failed to marshal usage receipt: %w", err)
+	}
+	msg := &types.Message{
+		Type:      types.MessageTypeUsageReceipt,
+		From:      p.walletAddr,
+		TaskID:    receipt.TaskID,
+		Room:      room,
+		Data:      data,
+		Timestamp: time.Now(),
+	}
+	if respond := responderFromContext(ctx); respond != nil {
+		return respond(ctx, msg)
+	}
+	return p.client.SendMessageContext(ctx, msg)
+}
@@ -0,0 +1,216 @@
+package network
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/auth"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/cache"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/logging"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/output"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/types"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// userNoncePrefix prefixes the cache keys of the nonces of signed user messages
+const userNoncePrefix = "user-nonce:"
+
+var (
+	// ErrUnsignedMessage is returned when a user message that must be signed is not
+	ErrUnsignedMessage = fmt.Errorf("%w: message is not signed", types.ErrSignatureInvalid)
+
+	// ErrNonceReused is returned when a signed user message reuses a nonce within the clock skew window
+	ErrNonceReused = fmt.Errorf("%w: nonce was already used", types.ErrSignatureInvalid)
+)
+
+// UserSignatureConfig configures the verification of signed user messages
+type UserSignatureConfig struct {
+	Required     bool             // Reject every unsigned user message
+	Capabilities []string         // Capabilities only signed messages may use; unsigned messages are rejected while the agent offers one
+	MaxClockSkew time.Duration    // Reject messages whose timestamp differs more than this from local time (0 = no check, nonces are kept forever)
+	Cache        cache.AgentCache // Shared storage (e.g. Redis); nonces are kept in process memory when nil or NoOpCache
+}
+
+// DefaultUserSignatureConfig returns the default configuration: signed
+// messages are verified, unsigned ones accepted
+func DefaultUserSignatureConfig() *UserSignatureConfig {
+	return &UserSignatureConfig{
+		MaxClockSkew: 5 * time.Minute,
+	}
+}
+
+// UserSignatures verifies that direct user messages were signed by their
+// sender. A user signs the message's UserSigningPayload with the wallet it
+// sends from, as an Ethereum personal message, and puts the signature in the
+// message's signature field. Each nonce is accepted once per sender, so a
+// captured message cannot be sent again.
+type UserSignatures struct {
+	config *UserSignatureConfig
+	cache  cache.AgentCache
+}
+
+// NewUserSignatures creates a user message verifier
+func NewUserSignatures(config *UserSignatureConfig) *UserSignatures {
+	if config == nil {
+		config = DefaultUserSignatureConfig()
+	}
+
+	agentCache := config.Cache
+	if agentCache != nil {
+		if _, noop := agentCache.(*cache.NoOpCache); noop {
+			agentCache = nil
+		}
+	}
+	if agentCache == nil {
+		// Unbounded, so that no nonce is evicted and accepted again
+		agentCache = cache.NewMemoryCache(&cache.MemoryConfig{CleanupInterval: time.Minute})
+	}
+
+	return &UserSignatures{config: config, cache: agentCache}
+}
+
+// requires reports whether an unsigned message must be signed. The required
+// capabilities are stated by the sender and a handler may route by the
+// message's wording instead, so every message must be signed once the agent
+// offers a protected capability. Otherwise a message must be signed if it
+// requires a protected capability, matched like task requirements.
+func (u *UserSignatures) requires(required, offered []string) bool {
+	if u.config.Required {
+		return true
+	}
+	for _, capability := range offered {
+		for _, entry := range u.config.Capabilities {
+			if types.CapabilityMatches(capability, entry) {
+				return true
+			}
+		}
+	}
+	for _, requirement := range required {
+		if types.AnyCapabilityMatches(u.config.Capabilities, requirement) {
+			return true
+		}
+	}
+	return false
+}
+
+// Verify checks the signature of a user message and records its nonce. It
+// returns ErrUnsignedMessage for an unsigned message and an error wrapping
+// types.ErrSignatureInvalid for a bad one.
+func (u *UserSignatures) Verify(ctx context.Context, msg *types.Message) error {
+	if msg.Signature == "" {
+		return ErrUnsignedMessage
+	}
+	if !common.IsHexAddress(msg.From) {
+		return fmt.Errorf("%w: sender %q is not a wallet address", types.ErrSignatureInvalid, msg.From)
+	}
+	if msg.Nonce == "" {
+		return fmt.Errorf("%w: message has no nonce", types.ErrSignatureInvalid)
+	}
+	if skew := u.config.MaxClockSkew; skew > 0 {
+		if age := time.Since(msg.Timestamp); age > skew || age < -skew {
+			return fmt.Errorf("%w: %s", ErrStaleTask, msg.Timestamp.Format(time.RFC3339))
+		}
+	}
+
+	payload, err := msg.UserSigningPayload()
+	if err != nil {
+		return err
+	}
+	recovered, err := auth.RecoverSigner(string(payload), msg.Signature)
+	if err != nil {
+		return fmt.Errorf("%w: %v", types.ErrSignatureInvalid, err)
+	}
+	sender := common.HexToAddress(msg.From)
+	if recovered != sender {
+		return fmt.Errorf("%w: signed by %s", types.ErrSignatureInvalid, recovered.Hex())
+	}
+
+	// Nonces are remembered as long as a message carrying them can be fresh
+	ttl := 2 * u.config.MaxClockSkew
+	fresh, err := u.cache.SetIfNotExists(ctx, userNoncePrefix+sender.Hex()+":"+msg.Nonce, msg.Timestamp.UnixMilli(), ttl)
+	if err != nil {
+		return fmt.Errorf("failed to record nonce: %w", err)
+	}
+	if !fresh {
+		return ErrNonceReused
+	}
+	return nil
+}
+
+// SignUserMessage signs a user message with a wallet as UserSignatures
+// expects, setting a random nonce and the current time if they are unset
+func SignUserMessage(msg *types.Message, wallet *auth.Manager) error {
+	if msg.Nonce == "" {
+		nonce, err := wallet.GenerateNonce()
+		if err != nil {
+			return err
+		}
+		msg.Nonce = nonce
+	}
+	if msg.Timestamp.IsZero() {
+		msg.Timestamp = time.Now()
+	}
+	msg.From = wallet.GetAddress()
+
+	payload, err := msg.UserSigningPayload()
+	if err != nil {
+		return err
+	}
+	signature, err := wallet.SignMessage(string(payload))
+	if err != nil {
+		return fmt.Errorf("failed to sign message: %w", err)
+	}
+	msg.Signature = signature
+	return nil
+}
+
+// SetUserSignatures sets the verifier of signed user messages (nil accepts
+// user messages without checking signatures)
+func (t *TaskCoordinator) SetUserSignatures(verifier *UserSignatures) {
+	t.rateLimitMu.Lock()
+	defer t.rateLimitMu.Unlock()
+	t.userSignatures = verifier
+}
+
+// getUserSignatures returns the configured user message verifier
+func (t *TaskCoordinator) getUserSignatures() *UserSignatures {
+	t.rateLimitMu.Lock()
+	defer t.rateLimitMu.Unlock()
+	return t.userSignatures
+}
+
+// checkUserSignature verifies the signature of a user message and rejects
+// unsigned messages that must be signed and messages with bad signatures.
+// It returns whether the sender was verified and the rejection code, or ""
+// if the message can be processed.
+func (t *TaskCoordinator) checkUserSignature(ctx context.Context, msg *types.Message, taskID string) (bool, string) {
+	verifier := t.getUserSignatures()
+	if verifier == nil {
+		return false, ""
+	}
+
+	err := verifier.Verify(ctx, msg)
+	if err == nil {
+		return true, ""
+	}
+	if errors.Is(err, ErrUnsignedMessage) && !verifier.requires(t.extractRequiredCapabilities(msg), t.Capabilities()) {
+		return false, ""
+	}
+
+	content, errorCode := "⚠️ Your message could not be authenticated. Please sign it with your wallet and send it again.", "signature_invalid"
+	switch {
+	case errors.Is(err, ErrUnsignedMessage):
+		content, errorCode = "⚠️ This agent requires messages signed with your wallet.", "signature_required"
+	case errors.Is(err, ErrNonceReused):
+		errorCode = "signature_replayed"
+	case !errors.Is(err, types.ErrSignatureInvalid) && !errors.Is(err, ErrStaleTask):
+		content, errorCode = "⚠️ Your message could not be authenticated. Please try again later.", "signature_unverified"
+	}
+
+	logging.Warn("user signature check rejected message", "task_id", taskID, "from", msg.From, "code", errorCode, "error", err)
+	t.recordRejection(errorCode)
+	t.protocolHandler.SendTaskRejection(ctx, taskID, output.Clean(content), errorCode, msg.Room, map[string]interface{}{"reason": err.Error()})
+	return false, errorCode
+}
@@ -0,0 +1,293 @@
+package network
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/auth"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/handlers"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/types"
+)
+
+func newWallet(t *testing.T) *auth.Manager {
+	t.Helper()
+	key, _, err := auth.GeneratePrivateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	wallet, err := auth.NewManager(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return wallet
+}
+
+// userMessage returns a direct message requiring the given capabilities
+func userMessage(content string, capabilities ...string) *types.Message {
+	msg := &types.Message{Type: "message", From: "0x00000000000000000000000000000000000000aa", Room: "room-1", Content: content}
+	if len(capabilities) > 0 {
+		msg.Data, _ = json.Marshal(map[string]interface{}{"required_capabilities": capabilities})
+	}
+	return msg
+}
+
+func signedUserMessage(t *testing.T, wallet *auth.Manager, content string, capabilities ...string) *types.Message {
+	t.Helper()
+	msg := userMessage(content, capabilities...)
+	if err := SignUserMessage(msg, wallet); err != nil {
+		t.Fatal(err)
+	}
+	return msg
+}
+
+// protectedCoordinator returns a coordinator offering capabilities whose
+// user messages must be signed to use "finance/trade"
+func protectedCoordinator(handler types.AgentHandler, capabilities ...string) (*TaskCoordinator, *responseRecorder) {
+	coordinator := newTestCoordinator(handler, capabilities...)
+	config := DefaultUserSignatureConfig()
+	config.Capabilities = []string{"finance/trade"}
+	coordinator.SetUserSignatures(NewUserSignatures(config))
+	return coordinator, captureOutbound(coordinator)
+}
+
+func TestUserSignatureChecks(t *testing.T) {
+	wallet := newWallet(t)
+	other := newWallet(t)
+	protected := []string{"finance/trade@1.2.0", "finance/quote@1.0.0"}
+	unprotected := []string{"finance/quote@1.0.0"}
+
+	tests := []struct {
+		name    string
+		offered []string
+		message func(t *testing.T) *types.Message
+		code    string // "" = accepted
+	}{
+		{
+			name:    "valid signature",
+			offered: protected,
+			message: func(t *testing.T) *types.Message { return signedUserMessage(t, wallet, "hello", "finance/trade") },
+		},
+		{
+			name:    "wrong signer",
+			offered: protected,
+			message: func(t *testing.T) *types.Message {
+				msg := signedUserMessage(t, other, "hello")
+				msg.From = wallet.GetAddress()
+				return msg
+			},
+			code: "signature_invalid",
+		},
+		{
+			name:    "stale timestamp",
+			offered: protected,
+			message: func(t *testing.T) *types.Message {
+				msg := userMessage("hello")
+				msg.Timestamp = time.Now().Add(-10 * time.Minute)
+				if err := SignUserMessage(msg, wallet); err != nil {
+					t.Fatal(err)
+				}
+				return msg
+			},
+			code: "signature_invalid",
+		},
+		{
+			name:    "unsigned, protected capability",
+			offered: protected,
+			message: func(t *testing.T) *types.Message { return userMessage("hello", "finance/trade") },
+			code:    "signature_required",
+		},
+		{
+			name:    "unsigned, other capability of an agent offering a protected one",
+			offered: protected,
+			message: func(t *testing.T) *types.Message { return userMessage("hello", "finance/quote") },
+			code:    "signature_required",
+		},
+		{
+			name:    "unsigned, no capability stated to an agent offering a protected one",
+			offered: protected,
+			message: func(t *testing.T) *types.Message { return userMessage("hello") },
+			code:    "signature_required",
+		},
+		{
+			name:    "unsigned, wildcard matching a protected capability",
+			offered: unprotected,
+			message: func(t *testing.T) *types.Message { return userMessage("hello", "finance/*") },
+			code:    "signature_required",
+		},
+		{
+			name:    "unsigned, agent offers no protected capability",
+			offered: unprotected,
+			message: func(t *testing.T) *types.Message { return userMessage("hello", "finance/quote") },
+		},
+		{
+			name:    "unsigned, no capability stated to an agent offering no protected one",
+			offered: unprotected,
+			message: func(t *testing.T) *types.Message { return userMessage("hello") },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := &standardHandler{reply: "ok"}
+			coordinator, outbound := protectedCoordinator(handler, tt.offered...)
+
+			if err := coordinator.HandleUserMessage(tt.message(t)); err != nil {
+				t.Fatal(err)
+			}
+
+			if tt.code == "" {
+				eventually(t, "handler call", func() bool { return handler.calls.Load() == 1 })
+				return
+			}
+			sent := outbound.take()
+			if len(sent) != 1 || responseError(t, sent[0]) != tt.code {
+				t.Fatalf("sent %+v, want one %s rejection", sent, tt.code)
+			}
+			if handler.calls.Load() != 0 {
+				t.Error("handler ran for a rejected message")
+			}
+		})
+	}
+}
+
+func TestUnsignedMessageCannotReachProtectedCommand(t *testing.T) {
+	trade, quote := &standardHandler{reply: "traded"}, &standardHandler{reply: "quoted"}
+	router := handlers.NewRouter()
+	router.Mount("finance/trade", trade, "trade")
+	router.Mount("finance/quote", quote, "quote")
+	coordinator, outbound := protectedCoordinator(router, router.Capabilities()...)
+
+	// No required capability is stated: the router would pick the handler by
+	// the first word
+	if err := coordinator.HandleUserMessage(userMessage("trade 10 ETH")); err != nil {
+		t.Fatal(err)
+	}
+	sent := outbound.take()
+	if len(sent) != 1 || responseError(t, sent[0]) != "signature_required" {
+		t.Fatalf("sent %+v, want one signature_required rejection", sent)
+	}
+	if trade.calls.Load() != 0 {
+		t.Fatal("unsigned message reached the protected handler")
+	}
+
+	// A signed message is routed by the capability it requires before its wording
+	wallet := newWallet(t)
+	if err := coordinator.HandleUserMessage(signedUserMessage(t, wallet, "quote ETH", "finance/trade")); err != nil {
+		t.Fatal(err)
+	}
+	eventually(t, "trade handler call", func() bool { return trade.calls.Load() == 1 })
+	if quote.calls.Load() != 0 {
+		t.Error("message requiring finance/trade routed by its first word")
+	}
+}
+
+func TestUserSignatureReplayedNonce(t *testing.T) {
+	handler := &standardHandler{reply: "ok"}
+	coordinator := newTestCoordinator(handler)
+	coordinator.SetUserSignatures(NewUserSignatures(nil))
+	outbound := captureOutbound(coordinator)
+
+	msg := signedUserMessage(t, newWallet(t), "hello")
+	replay := *msg
+	if err := coordinator.HandleUserMessage(msg); err != nil {
+		t.Fatal(err)
+	}
+	eventually(t, "handler call", func() bool { return handler.calls.Load() == 1 })
+	outbound.take()
+
+	if err := coordinator.HandleUserMessage(&replay); err != nil {
+		t.Fatal(err)
+	}
+	sent := outbound.take()
+	if len(sent) != 1 || responseError(t, sent[0]) != "signature_replayed" {
+		t.Fatalf("sent %+v, want one signature_replayed rejection", sent)
+	}
+	if calls := handler.calls.Load(); calls != 1 {
+		t.Errorf("handler called %d times, want 1", calls)
+	}
+}
+
+func TestUserSignaturesRequires(t *testing.T) {
+	signatures := &UserSignatures{config: &UserSignatureConfig{Capabilities: []string{"finance/trade", "legacy_trading"}}}
+
+	tests := []struct {
+		offered  []string
+		required []string
+		want     bool
+	}{
+		// Every message must be signed while a protected capability is offered
+		{[]string{"finance/trade@1.2.0", "finance/quote@1.0.0"}, nil, true},
+		{[]string{"finance/quote@1.0.0", "legacy_trading"}, []string{"finance/quote"}, true},
+
+		{[]string{"finance/quote@1.0.0"}, []string{"finance/trade"}, true},
+		{[]string{"finance/quote@1.0.0"}, []string{"finance/*"}, true},
+		{[]string{"finance/quote@1.0.0"}, []string{"finance/quote", "legacy_trading"}, true},
+		{[]string{"finance/quote@1.0.0"}, []string{"finance/quote"}, false},
+		{[]string{"finance/quote@1.0.0"}, []string{"legacy"}, false},
+		{[]string{"finance/quote@1.0.0"}, nil, false},
+		{nil, nil, false},
+	}
+	for _, tt := range tests {
+		if got := signatures.requires(tt.required, tt.offered); got != tt.want {
+			t.Errorf("requires(%q) offering %q = %v, want %v", tt.required, tt.offered, got, tt.want)
+		}
+	}
+
+	required := &UserSignatures{config: &UserSignatureConfig{Required: true}}
+	if !required.requires(nil, nil) {
+		t.Error("Required does not require signing every message")
+	}
+}
+
+// senderHandler records the sender and task info each task was run with
+type senderHandler struct {
+	mu      sync.Mutex
+	senders []string
+	infos   []types.TaskInfo
+}
+
+func (h *senderHandler) ProcessTask(ctx context.Context, task string) (string, error) {
+	info, _ := types.TaskInfoFromContext(ctx)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.senders = append(h.senders, SenderFromContext(ctx))
+	h.infos = append(h.infos, info)
+	return "ok", nil
+}
+
+func (h *senderHandler) calls() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.senders)
+}
+
+func TestSignedMessageSenderIsSigner(t *testing.T) {
+	handler := &senderHandler{}
+	coordinator := newTestCoordinator(handler)
+	coordinator.SetUserSignatures(NewUserSignatures(nil))
+	captureOutbound(coordinator)
+
+	// The signer claims another wallet in the message data
+	wallet := newWallet(t)
+	msg := userMessage("hello")
+	msg.Data, _ = json.Marshal(map[string]interface{}{"user_address": "0x00000000000000000000000000000000000000bb"})
+	if err := SignUserMessage(msg, wallet); err != nil {
+		t.Fatal(err)
+	}
+	if err := coordinator.HandleUserMessage(msg); err != nil {
+		t.Fatal(err)
+	}
+	eventually(t, "handler call", func() bool { return handler.calls() == 1 })
+
+	handler.mu.Lock()
+	defer handler.mu.Unlock()
+	if handler.senders[0] != wallet.GetAddress() {
+		t.Errorf("sender = %q, want the signer %s", handler.senders[0], wallet.GetAddress())
+	}
+	if info := handler.infos[0]; info.Sender != wallet.GetAddress() || !info.SenderVerified {
+		t.Errorf("task info sender = %q verified = %v, want the verified signer %s", info.Sender, info.SenderVerified, wallet.GetAddress())
+	}
+}
@@ -4,37 +4,52 @@ import (
 	"context"
 	"crypto/ecdsa"
 	"fmt"
-	"log"
 	"math/big"
 	"strings"
 	"time"
 
 	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/auth"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/gas"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/logging"
 	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/types"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
-	"github.com/ethereum/go-ethereum/ethclient"
 )
 
 // BusinessCardManager handles NFT business card operations
 type BusinessCardManager struct {
-	client            *ethclient.Client
+	client            *RPCPool
 	contract          *AgentBusinessCardV2
 	privateKey        *ecdsa.PrivateKey
 	fromAddress       common.Address
 	contractAddr      common.Address
 	foundationService *auth.FoundationSignatureService
+	relayer           *Relayer
+	fees              gas.Config
 }
 
-// NewBusinessCardManager creates a new business card manager
+// NewBusinessCardManager creates a new business card manager.
+// rpcURL may list several comma-separated endpoints; the first one is used for transactions.
 func NewBusinessCardManager(rpcURL, contractAddress, privateKey string) (*BusinessCardManager, error) {
 	// Connect to Ethereum client
-	client, err := ethclient.Dial(rpcURL)
+	client, err := NewRPCPoolFromURLs(rpcURL)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to Ethereum client: %w", err)
 	}
 
+	manager, err := NewBusinessCardManagerWithPool(client, contractAddress, privateKey)
+	if err != nil {
+		client.Close()
+		return nil, err
+	}
+	return manager, nil
+}
+
+// NewBusinessCardManagerWithPool creates a business card manager using an RPC pool.
+// The manager closes the pool when it is closed.
+func NewBusinessCardManagerWithPool(client *RPCPool, contractAddress, privateKey string) (*BusinessCardManager, error) {
 	// Parse private key
 	if strings.HasPrefix(privateKey, "0x") {
 		privateKey = privateKey[2:]
@@ -77,43 +92,36 @@ func NewBusinessCardManager(rpcURL, contractAddress, privateKey string) (*Busine
 
 // MintAgentCard mints a new agent business card NFT
 func (m *BusinessCardManager) MintAgentCard(ctx context.Context, request *types.MintRequest) (*types.BusinessCard, error) {
-	log.Printf("🎨 Minting NFT business card for agent: %s", request.Name)
+	logging.Info("minting NFT business card", "agent", request.Name)
 
 	// Validate request
 	if validation := request.Validate(); !validation.IsValid {
 		return nil, fmt.Errorf("invalid mint request: %v", validation.Errors)
 	}
 
-	// Create transaction options
-	auth, err := bind.NewKeyedTransactorWithChainID(m.privateKey, big.NewInt(3338)) // PEAQ mainnet
-	if err != nil {
-		return nil, fmt.Errorf("failed to create transactor: %w", err)
-	}
-
-	// Set gas limit
-	auth.GasLimit = uint64(500000)
-
 	// Execute mint transaction
-	tx, err := m.contract.MintAgentCard(
-		auth,
-		request.Name,
-		request.Description,
-		request.Capabilities,
-		request.ContactInfo,
-		request.PricingModel,
-		request.InterfaceType,
-		request.ResponseFormat,
-		request.Version,
-		request.SDKVersion,
-	)
+	txHash, err := m.transact(ctx, uint64(500000), func(auth *bind.TransactOpts) (*ethtypes.Transaction, error) {
+		return m.contract.MintAgentCard(
+			auth,
+			request.Name,
+			request.Description,
+			request.Capabilities,
+			request.ContactInfo,
+			request.PricingModel,
+			request.InterfaceType,
+			request.ResponseFormat,
+			request.Version,
+			request.SDKVersion,
+		)
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to execute mint transaction: %w", err)
 	}
 
-	log.Printf("🔄 Transaction sent: %s", tx.Hash().Hex())
+	logging.Info("transaction sent", "tx_hash", txHash.Hex())
 
 	// Wait for transaction receipt
-	receipt, err := bind.WaitMined(ctx, m.client, tx)
+	receipt, err := bind.WaitMinedHash(ctx, m.client, txHash)
 	if err != nil {
 		return nil, fmt.Errorf("failed to wait for transaction: %w", err)
 	}
@@ -122,7 +130,7 @@ func (m *BusinessCardManager) MintAgentCard(ctx context.Context, request *types.
 		return nil, fmt.Errorf("transaction failed")
 	}
 
-	log.Printf("✅ NFT minted successfully! Block: %d", receipt.BlockNumber.Uint64())
+	logging.Info("NFT minted successfully", "block", receipt.BlockNumber.Uint64())
 
 	// Get the minted token ID from the transaction receipt
 	tokenID := big.NewInt(0)
@@ -165,7 +173,7 @@ func (m *BusinessCardManager) MintAgentCard(ctx context.Context, request *types.
 
 // GetAgentByOwner retrieves an agent's business card by owner address
 func (m *BusinessCardManager) GetAgentByOwner(ctx context.Context, ownerAddress string) (*types.BusinessCard, error) {
-	log.Printf("📖 Reading NFT business card for owner: %s", ownerAddress)
+	logging.Info("reading NFT business card", "owner", ownerAddress)
 
 	owner := common.HexToAddress(ownerAddress)
 
@@ -176,7 +184,7 @@ func (m *BusinessCardManager) GetAgentByOwner(ctx context.Context, ownerAddress
 	}
 
 	if tokenID.Cmp(big.NewInt(0)) == 0 {
-		return nil, fmt.Errorf("no NFT found for owner %s", ownerAddress)
+		return nil, fmt.Errorf("%w for owner %s", types.ErrNFTNotFound, ownerAddress)
 	}
 
 	// Get agent metadata
@@ -210,32 +218,26 @@ func (m *BusinessCardManager) GetAgentByOwner(ctx context.Context, ownerAddress
 
 // UpdateAgentMetadata updates the agent's metadata
 func (m *BusinessCardManager) UpdateAgentMetadata(ctx context.Context, description, contactInfo, pricingModel, version string) error {
-	log.Printf("✏️ Updating agent metadata...")
-
-	// Create transaction options
-	auth, err := bind.NewKeyedTransactorWithChainID(m.privateKey, big.NewInt(3338))
-	if err != nil {
-		return fmt.Errorf("failed to create transactor: %w", err)
-	}
-
-	auth.GasLimit = uint64(200000)
+	logging.Info("updating agent metadata")
 
 	// Execute update transaction
-	tx, err := m.contract.UpdateAgentMetadata(
-		auth,
-		description,
-		contactInfo,
-		pricingModel,
-		version,
-	)
+	txHash, err := m.transact(ctx, uint64(200000), func(auth *bind.TransactOpts) (*ethtypes.Transaction, error) {
+		return m.contract.UpdateAgentMetadata(
+			auth,
+			description,
+			contactInfo,
+			pricingModel,
+			version,
+		)
+	})
 	if err != nil {
 		return fmt.Errorf("failed to execute update transaction: %w", err)
 	}
 
-	log.Printf("🔄 Update transaction sent: %s", tx.Hash().Hex())
+	logging.Info("update transaction sent", "tx_hash", txHash.Hex())
 
 	// Wait for transaction receipt
-	receipt, err := bind.WaitMined(ctx, m.client, tx)
+	receipt, err := bind.WaitMinedHash(ctx, m.client, txHash)
 	if err != nil {
 		return fmt.Errorf("failed to wait for transaction: %w", err)
 	}
@@ -244,32 +246,26 @@ func (m *BusinessCardManager) UpdateAgentMetadata(ctx context.Context, descripti
 		return fmt.Errorf("update transaction failed")
 	}
 
-	log.Printf("✅ Agent metadata updated successfully!")
+	logging.Info("agent metadata updated successfully")
 	return nil
 }
 
 // SetAgentActive sets the agent's active status
 func (m *BusinessCardManager) SetAgentActive(ctx context.Context, active bool) error {
-	log.Printf("🔄 Setting agent active status to: %v", active)
-
-	// Create transaction options
-	auth, err := bind.NewKeyedTransactorWithChainID(m.privateKey, big.NewInt(3338))
-	if err != nil {
-		return fmt.Errorf("failed to create transactor: %w", err)
-	}
-
-	auth.GasLimit = uint64(100000)
+	logging.Info("setting agent active status", "active", active)
 
 	// Execute set active transaction
-	tx, err := m.contract.SetAgentActive(auth, active)
+	txHash, err := m.transact(ctx, uint64(100000), func(auth *bind.TransactOpts) (*ethtypes.Transaction, error) {
+		return m.contract.SetAgentActive(auth, active)
+	})
 	if err != nil {
 		return fmt.Errorf("failed to execute set active transaction: %w", err)
 	}
 
-	log.Printf("🔄 Set active transaction sent: %s", tx.Hash().Hex())
+	logging.Info("set active transaction sent", "tx_hash", txHash.Hex())
 
 	// Wait for transaction receipt
-	receipt, err := bind.WaitMined(ctx, m.client, tx)
+	receipt, err := bind.WaitMinedHash(ctx, m.client, txHash)
 	if err != nil {
 		return fmt.Errorf("failed to wait for transaction: %w", err)
 	}
@@ -278,24 +274,67 @@ func (m *BusinessCardManager) SetAgentActive(ctx context.Context, active bool) e
 		return fmt.Errorf("set active transaction failed")
 	}
 
-	log.Printf("✅ Agent active status updated successfully!")
+	logging.Info("agent active status updated successfully")
 	return nil
 }
 
+// transact executes a contract call from the agent wallet with estimated gas
+// and EIP-1559 fees, or through the relayer when one is set, and returns the
+// hash of the mined transaction. gasLimit is used for relayed calls whose gas
+// cannot be estimated.
+func (m *BusinessCardManager) transact(ctx context.Context, gasLimit uint64, call func(*bind.TransactOpts) (*ethtypes.Transaction, error)) (common.Hash, error) {
+	chainID := big.NewInt(3338) // PEAQ mainnet
+
+	// Create transaction options
+	auth, err := bind.NewKeyedTransactorWithChainID(m.privateKey, chainID)
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("failed to create transactor: %w", err)
+	}
+	auth.Context = ctx
+	auth.GasLimit = gasLimit
+
+	// Build the transaction without sending it; it is priced and sent below
+	auth.NoSend = true
+	tx, err := call(auth)
+	if err != nil {
+		return common.Hash{}, err
+	}
+
+	if m.relayer == nil {
+		return sendWithFees(ctx, m.client, m.privateKey, chainID, m.fees, m.contractAddr, tx.Value(), tx.Data())
+	}
+	return m.relayer.Relay(ctx, m.client, m.privateKey, chainID, &ForwardRequest{
+		To:    m.contractAddr,
+		Value: tx.Value(),
+		Gas:   relayGas(ctx, m.client, m.fromAddress, m.contractAddr, tx.Value(), tx.Data(), m.fees, gasLimit),
+		Data:  tx.Data(),
+	})
+}
+
+// SetFeeConfig sets how transactions sent from the agent wallet are priced
+func (m *BusinessCardManager) SetFeeConfig(config gas.Config) {
+	m.fees = config
+}
+
+// SetRelayer routes transactions through a gas sponsor relayer (nil to send them directly)
+func (m *BusinessCardManager) SetRelayer(relayer *Relayer) {
+	m.relayer = relayer
+}
+
 // GetAgentsByCapability retrieves agents that have a specific capability
 func (m *BusinessCardManager) GetAgentsByCapability(ctx context.Context, capability string) ([]*big.Int, error) {
-	log.Printf("🔍 Searching for agents with capability: %s", capability)
+	logging.Info("searching for agents", "capability", capability)
 
 	tokenIDs, err := m.contract.GetAgentsByCapability(&bind.CallOpts{Context: ctx}, capability)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get agents by capability: %w", err)
 	}
 
-	log.Printf("✅ Found %d agents with capability '%s'", len(tokenIDs), capability)
+	logging.Info("found agents", "count", len(tokenIDs), "capability", capability)
 	return tokenIDs, nil
 }
 
-// Close closes the connection to the Ethereum client
+// Close closes the connections to the Ethereum RPC endpoints
 func (m *BusinessCardManager) Close() {
 	if m.client != nil {
 		m.client.Close()
@@ -336,7 +375,7 @@ func hexToBytes(hex string) ([]byte, error) {
 
 // SimulateFoundationApproval simulates the foundation approval process
 func (m *BusinessCardManager) SimulateFoundationApproval(request *types.MintRequest) (*types.FoundationApprovalResult, error) {
-	log.Printf("🔧 Simulating foundation approval process for agent: %s", request.Name)
+	logging.Info("simulating foundation approval process", "agent", request.Name)
 
 	// In a real implementation, this would:
 	// 1. Send request to foundation backend
@@ -0,0 +1,147 @@
+package nft
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/gas"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/logging"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// receiptPollInterval is how often receipts of sent transactions are checked
+const receiptPollInterval = 2 * time.Second
+
+// errNotMined is returned by waitMined when no transaction was mined in time
+var errNotMined = errors.New("transaction not mined in time")
+
+// sendWithFees sends a transaction from the wallet of key with estimated gas
+// and EIP-1559 fees (a gas price on chains without them) and waits until it
+// is mined. While it stays unmined it is replaced with higher fees, up to
+// config.MaxBumps times. It returns the hash of the transaction that was mined.
+func sendWithFees(ctx context.Context, client *RPCPool, key *ecdsa.PrivateKey, chainID *big.Int, config gas.Config, to common.Address, value *big.Int, data []byte) (common.Hash, error) {
+	config = config.WithDefaults()
+	from := crypto.PubkeyToAddress(key.PublicKey)
+
+	estimated, err := client.EstimateGas(ctx, ethereum.CallMsg{From: from, To: &to, Value: value, Data: data})
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("failed to estimate gas: %w", err)
+	}
+	fees, dynamic, err := planFees(ctx, client, config, estimated)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	nonce, err := client.PendingNonceAt(ctx, from)
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("failed to get account nonce: %w", err)
+	}
+
+	signer := types.LatestSignerForChainID(chainID)
+	var sent []common.Hash
+	for bumps := 0; ; bumps++ {
+		var txData types.TxData = &types.LegacyTx{Nonce: nonce, GasPrice: fees.FeeCap, Gas: fees.GasLimit, To: &to, Value: value, Data: data}
+		if dynamic {
+			txData = &types.DynamicFeeTx{ChainID: chainID, Nonce: nonce, GasTipCap: fees.TipCap, GasFeeCap: fees.FeeCap, Gas: fees.GasLimit, To: &to, Value: value, Data: data}
+		}
+		tx, err := types.SignNewTx(key, signer, txData)
+		if err != nil {
+			return common.Hash{}, fmt.Errorf("failed to sign transaction: %w", err)
+		}
+		if err := client.SendTransaction(ctx, tx); err != nil {
+			if len(sent) == 0 {
+				return common.Hash{}, fmt.Errorf("failed to send transaction: %w", err)
+			}
+			// The transaction being replaced may have been mined meanwhile
+			logging.Warn("failed to send replacement transaction", "nonce", nonce, "error", err)
+		} else {
+			sent = append(sent, tx.Hash())
+			logging.Info("transaction sent", "tx_hash", tx.Hash().Hex(), "nonce", nonce, "fees", fees.String())
+		}
+
+		hash, err := waitMined(ctx, client, sent, config.StuckAfter)
+		if !errors.Is(err, errNotMined) {
+			return hash, err
+		}
+		if bumps == config.MaxBumps {
+			return common.Hash{}, fmt.Errorf("transaction with nonce %d not mined after %d replacements", nonce, bumps)
+		}
+		if fees, err = config.Bump(fees); err != nil {
+			return common.Hash{}, fmt.Errorf("failed to replace stuck transaction: %w", err)
+		}
+		logging.Warn("transaction not mined, replacing it with higher fees", "nonce", nonce, "after", config.StuckAfter, "fees", fees.String())
+	}
+}
+
+// planFees prices a transaction with the base fee of the latest block, or
+// with the suggested gas price on chains without EIP-1559
+func planFees(ctx context.Context, client *RPCPool, config gas.Config, estimated uint64) (gas.Fees, bool, error) {
+	header, err := client.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return gas.Fees{}, false, fmt.Errorf("failed to get latest block: %w", err)
+	}
+	if header.BaseFee == nil {
+		price, err := client.SuggestGasPrice(ctx)
+		if err != nil {
+			return gas.Fees{}, false, fmt.Errorf("failed to get gas price: %w", err)
+		}
+		fees, err := config.PlanLegacy(estimated, price)
+		return fees, false, err
+	}
+
+	var tip *big.Int
+	if config.MaxPriorityFeePerGas == nil {
+		if tip, err = client.SuggestGasTipCap(ctx); err != nil {
+			return gas.Fees{}, false, fmt.Errorf("failed to get gas tip: %w", err)
+		}
+	}
+	fees, err := config.Plan(estimated, header.BaseFee, tip)
+	return fees, true, err
+}
+
+// waitMined waits up to timeout for one of the transactions, which share a
+// nonce, to be mined and returns its hash. A reverted transaction is an error.
+func waitMined(ctx context.Context, client *RPCPool, hashes []common.Hash, timeout time.Duration) (common.Hash, error) {
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+	ticker := time.NewTicker(receiptPollInterval)
+	defer ticker.Stop()
+	for {
+		for _, hash := range hashes {
+			receipt, err := client.TransactionReceipt(ctx, hash)
+			if err != nil {
+				continue
+			}
+			if receipt.Status != types.ReceiptStatusSuccessful {
+				return hash, fmt.Errorf("transaction %s reverted", hash.Hex())
+			}
+			return hash, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return common.Hash{}, ctx.Err()
+		case <-deadline.C:
+			return common.Hash{}, errNotMined
+		case <-ticker.C:
+		}
+	}
+}
+
+// relayGas returns the gas limit of a relayed call: the padded estimate, or
+// fallback when the call cannot be estimated from the wallet, e.g. because
+// the wallet cannot pay the call's value itself
+func relayGas(ctx context.Context, client *RPCPool, from, to common.Address, value *big.Int, data []byte, config gas.Config, fallback uint64) uint64 {
+	estimated, err := client.EstimateGas(ctx, ethereum.CallMsg{From: from, To: &to, Value: value, Data: data})
+	if err != nil {
+		logging.Debug("gas estimation failed, using default gas limit", "gas", fallback, "error", err)
+		return fallback
+	}
+	return config.PadGas(estimated)
+}
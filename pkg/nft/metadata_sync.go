@@ -0,0 +1,224 @@
+package nft
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/logging"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/types"
+)
+
+// MetadataStore reads and writes the metadata recorded for an agent's NFT
+type MetadataStore interface {
+	RecordedMetadata(ctx context.Context) (types.AgentMetadata, error)
+
+	// WriteMetadata records the fields of metadata that can change after minting
+	WriteMetadata(ctx context.Context, metadata types.AgentMetadata) error
+}
+
+// BusinessCardStore is the MetadataStore of the business card owned by a wallet
+type BusinessCardStore struct {
+	manager *BusinessCardManager
+	owner   string
+}
+
+// NewBusinessCardStore creates the store of the card owned by owner
+// (empty = the manager's wallet)
+func NewBusinessCardStore(manager *BusinessCardManager, owner string) *BusinessCardStore {
+	if owner == "" {
+		owner = manager.GetOwnerAddress()
+	}
+	return &BusinessCardStore{manager: manager, owner: owner}
+}
+
+// RecordedMetadata reads the metadata of the card
+func (s *BusinessCardStore) RecordedMetadata(ctx context.Context) (types.AgentMetadata, error) {
+	card, err := s.manager.GetAgentByOwner(ctx, s.owner)
+	if err != nil {
+		return types.AgentMetadata{}, err
+	}
+	return card.Metadata, nil
+}
+
+// WriteMetadata updates the description, contact info, pricing model and
+// version of the card in a single transaction
+func (s *BusinessCardStore) WriteMetadata(ctx context.Context, metadata types.AgentMetadata) error {
+	return s.manager.UpdateAgentMetadata(ctx, metadata.Description, metadata.ContactInfo, metadata.PricingModel, metadata.Version)
+}
+
+// MetadataSyncerConfig configures a MetadataSyncer
+type MetadataSyncerConfig struct {
+	Store      MetadataStore
+	Live       func() types.AgentMetadata // Metadata of the running agent, usually built from its config
+	Interval   time.Duration              // Time between checks in Run (default 1h)
+	AutoUpdate bool                       // Write drift of updatable fields on-chain when Run finds it
+}
+
+// MetadataUpdate is the outcome of UpdateIfChanged
+type MetadataUpdate struct {
+	Drift   types.MetadataDrift    // Drift found before the update
+	Applied []types.MetadataChange // Changes written on-chain
+	Skipped []types.MetadataChange // Changes to fields that are fixed at mint time
+}
+
+// MetadataSyncStats counts the work of a MetadataSyncer
+type MetadataSyncStats struct {
+	Checks   uint64 `json:"checks"`
+	Failures uint64 `json:"failures"` // Checks and updates that failed
+	Updates  uint64 `json:"updates"`  // Transactions sent
+}
+
+// MetadataSyncer reconciles the metadata of a running agent with its NFT:
+// it compares the name, description, capabilities and version the agent runs
+// with against the NFT's, reports drift, and writes the fields that can still
+// change after minting. It is safe for concurrent use.
+type MetadataSyncer struct {
+	config MetadataSyncerConfig
+
+	updateMu sync.Mutex // Serializes updates, so drift is never written twice
+	mu       sync.RWMutex
+	status   types.MetadataDrift
+
+	checks   atomic.Uint64
+	failures atomic.Uint64
+	updates  atomic.Uint64
+}
+
+// NewMetadataSyncer creates a syncer; call Run to check periodically
+func NewMetadataSyncer(config MetadataSyncerConfig) (*MetadataSyncer, error) {
+	if config.Store == nil || config.Live == nil {
+		return nil, errors.New("metadata syncer needs a store and the live metadata")
+	}
+	if config.Interval <= 0 {
+		config.Interval = time.Hour
+	}
+	return &MetadataSyncer{config: config}, nil
+}
+
+// Check compares the live metadata with the NFT's and records the result,
+// which Status returns until the next check
+func (s *MetadataSyncer) Check(ctx context.Context) (types.MetadataDrift, error) {
+	drift, _, err := s.check(ctx)
+	return drift, err
+}
+
+func (s *MetadataSyncer) check(ctx context.Context) (types.MetadataDrift, types.AgentMetadata, error) {
+	s.checks.Add(1)
+	recorded, err := s.config.Store.RecordedMetadata(ctx)
+	if err != nil {
+		s.failures.Add(1)
+		err = fmt.Errorf("failed to read NFT metadata: %w", err)
+		s.mu.Lock()
+		// Keep the last known drift, so a flaky RPC endpoint doesn't hide it
+		s.status.CheckedAt = time.Now()
+		s.status.Error = err.Error()
+		drift := s.status
+		s.mu.Unlock()
+		return drift, recorded, err
+	}
+
+	drift := types.CompareMetadata(recorded, s.config.Live())
+	s.mu.Lock()
+	s.status = drift
+	s.mu.Unlock()
+	if drift.Drifted {
+		logging.Warn("NFT metadata drifted from the config", "fields", changedFields(drift.Changes))
+	}
+	return drift, recorded, nil
+}
+
+// UpdateIfChanged checks for drift and writes the updatable fields that
+// drifted in a single transaction. Nothing is sent when the metadata matches.
+// Drift in fields that are fixed at mint time is reported in Skipped.
+func (s *MetadataSyncer) UpdateIfChanged(ctx context.Context) (*MetadataUpdate, error) {
+	s.updateMu.Lock()
+	defer s.updateMu.Unlock()
+
+	drift, recorded, err := s.check(ctx)
+	if err != nil {
+		return nil, err
+	}
+	update := &MetadataUpdate{Drift: drift}
+	for _, change := range drift.Changes {
+		if change.Updatable() {
+			update.Applied = append(update.Applied, change)
+		} else {
+			update.Skipped = append(update.Skipped, change)
+		}
+	}
+	if len(update.Applied) == 0 {
+		return update, nil
+	}
+
+	// Fields that aren't tracked keep their recorded value
+	live := s.config.Live()
+	next := recorded
+	next.Description = live.Description
+	next.Version = live.Version
+	if err := s.config.Store.WriteMetadata(ctx, next); err != nil {
+		s.failures.Add(1)
+		applied := update.Applied
+		update.Applied = nil
+		return update, fmt.Errorf("failed to update %v: %w", changedFields(applied), err)
+	}
+	s.updates.Add(1)
+	logging.Info("NFT metadata updated", "fields", changedFields(update.Applied))
+
+	// The written fields no longer drift
+	s.mu.Lock()
+	s.status = types.CompareMetadata(next, live)
+	s.mu.Unlock()
+	return update, nil
+}
+
+// Run checks for drift every Interval until ctx is done, starting right
+// away, and updates the NFT if AutoUpdate is set
+func (s *MetadataSyncer) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.config.Interval)
+	defer ticker.Stop()
+	for {
+		var err error
+		if s.config.AutoUpdate {
+			_, err = s.UpdateIfChanged(ctx)
+		} else {
+			_, err = s.Check(ctx)
+		}
+		if err != nil && ctx.Err() == nil {
+			logging.Warn("NFT metadata sync failed", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Status returns the result of the last check; CheckedAt is zero before the first
+func (s *MetadataSyncer) Status() types.MetadataDrift {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.status
+}
+
+// Stats returns the checks, failures and updates so far
+func (s *MetadataSyncer) Stats() MetadataSyncStats {
+	return MetadataSyncStats{
+		Checks:   s.checks.Load(),
+		Failures: s.failures.Load(),
+		Updates:  s.updates.Load(),
+	}
+}
+
+func changedFields(changes []types.MetadataChange) []string {
+	fields := make([]string, len(changes))
+	for i, change := range changes {
+		fields[i] = change.Field
+	}
+	return fields
+}
@@ -1,24 +1,22 @@
 package nft
 
 import (
-	"bytes"
 	"context"
 	"crypto/ecdsa"
 	"crypto/sha256"
 	"encoding/hex"
-	"encoding/json"
 	"fmt"
-	"io"
 	"math/big"
-	"net/http"
 	"strings"
-	"time"
 
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/auth"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/backend"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/gas"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/logging"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
-	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/ethereum/go-ethereum"
 )
 
@@ -41,38 +39,48 @@ type IPFSUploadResponse struct {
 }
 
 // MintSignatureRequest represents the request to get a mint signature
-type MintSignatureRequest struct {
-	To       string `json:"to"`
-	TokenURI string `json:"tokenURI"`
-	Nonce    uint64 `json:"nonce"`
-}
+type MintSignatureRequest = backend.MintSignatureRequest
 
 // MintSignatureResponse represents the response with mint signature
-type MintSignatureResponse struct {
-	Signature string `json:"signature"`
-	Nonce     uint64 `json:"nonce"`
-}
+type MintSignatureResponse = backend.MintSignature
 
 // ContractConfigResponse represents the contract configuration
-type ContractConfigResponse struct {
-	ContractAddress string `json:"contract_address"`
-	ChainID         string `json:"chain_id"`
-	NetworkName     string `json:"network_name"`
-}
+type ContractConfigResponse = backend.ContractConfig
 
 // NFTMinter handles NFT minting operations
 type NFTMinter struct {
-	client          *ethclient.Client
+	client          *RPCPool
 	contractAddress common.Address
-	backendURL      string
+	backend         *backend.Client
 	chainID         *big.Int
 	privateKey      *ecdsa.PrivateKey
 	address         common.Address
-	httpClient      *http.Client
+	relayer         *Relayer
+	fees            gas.Config
 }
 
-// NewNFTMinter creates a new NFT minter instance
+// NewNFTMinter creates a new NFT minter instance.
+// rpcEndpoint may list several comma-separated endpoints; the first one is used for transactions.
 func NewNFTMinter(backendURL, rpcEndpoint, privateKeyHex string) (*NFTMinter, error) {
+	// Create Ethereum client if RPC endpoint provided
+	var ethClient *RPCPool
+	if rpcEndpoint != "" {
+		var err error
+		ethClient, err = NewRPCPoolFromURLs(rpcEndpoint)
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to Ethereum node: %w", err)
+		}
+	}
+
+	minter, err := NewNFTMinterWithPool(backendURL, ethClient, privateKeyHex)
+	if err != nil && ethClient != nil {
+		ethClient.Close()
+	}
+	return minter, err
+}
+
+// NewNFTMinterWithPool creates an NFT minter using an RPC pool (nil for backend-only operations)
+func NewNFTMinterWithPool(backendURL string, ethClient *RPCPool, privateKeyHex string) (*NFTMinter, error) {
 	// Parse private key
 	privateKey, err := crypto.HexToECDSA(strings.TrimPrefix(privateKeyHex, "0x"))
 	if err != nil {
@@ -87,32 +95,29 @@ func NewNFTMinter(backendURL, rpcEndpoint, privateKeyHex string) (*NFTMinter, er
 	}
 	address := crypto.PubkeyToAddress(*publicKeyECDSA)
 
-	// Create HTTP client with timeout
-	httpClient := &http.Client{
-		Timeout: 30 * time.Second,
-	}
-
-	// Create Ethereum client if RPC endpoint provided
-	var ethClient *ethclient.Client
-	if rpcEndpoint != "" {
-		ethClient, err = ethclient.Dial(rpcEndpoint)
+	// Backend requests are signed with the agent wallet
+	var backendClient *backend.Client
+	if backendURL != "" {
+		signer, err := auth.NewManager(privateKeyHex)
 		if err != nil {
-			return nil, fmt.Errorf("failed to connect to Ethereum node: %w", err)
+			return nil, err
+		}
+		if backendClient, err = backend.New(&backend.Config{BaseURL: backendURL, Signer: signer}); err != nil {
+			return nil, err
 		}
 	}
 
 	return &NFTMinter{
 		client:     ethClient,
-		backendURL: backendURL,
+		backend:    backendClient,
 		privateKey: privateKey,
 		address:    address,
-		httpClient: httpClient,
 	}, nil
 }
 
 // MintAgent mints a new agent NFT
 func (m *NFTMinter) MintAgent(metadata AgentMetadata) (uint64, error) {
-	fmt.Println("   [Step 1/5] 🔍 Getting contract configuration...")
+	logging.Info("getting contract configuration")
 	// 1. Get contract configuration from backend
 	config, err := m.getContractConfig()
 	if err != nil {
@@ -121,7 +126,7 @@ func (m *NFTMinter) MintAgent(metadata AgentMetadata) (uint64, error) {
 
 	// Set contract address
 	m.contractAddress = common.HexToAddress(config.ContractAddress)
-	fmt.Printf("   ✅ Contract address: %s\n", config.ContractAddress)
+	logging.Info("got contract configuration", "contract", config.ContractAddress)
 	
 	// Set chain ID
 	chainID, ok := new(big.Int).SetString(config.ChainID, 10)
@@ -129,33 +134,33 @@ func (m *NFTMinter) MintAgent(metadata AgentMetadata) (uint64, error) {
 		return 0, fmt.Errorf("invalid chain ID: %s", config.ChainID)
 	}
 	m.chainID = chainID
-	fmt.Printf("   ✅ Chain ID: %s\n", config.ChainID)
+	logging.Debug("chain ID", "chain_id", config.ChainID)
 
-	fmt.Println("\n   [Step 2/5] 📤 Uploading metadata to IPFS...")
+	logging.Info("uploading metadata to IPFS")
 	// 2. Send metadata to backend (backend handles IPFS upload via Pinata)
 	ipfsHash, err := m.uploadMetadataToIPFS(metadata)
 	if err != nil {
 		return 0, fmt.Errorf("failed to send metadata to backend: %w", err)
 	}
-	fmt.Printf("   ✅ IPFS URI: %s\n", ipfsHash)
+	logging.Info("uploaded metadata", "token_uri", ipfsHash)
 
-	fmt.Println("\n   [Step 3/5] 🔢 Getting nonce from contract...")
+	logging.Info("getting nonce from contract")
 	// 3. Get current nonce from contract for this wallet
 	nonce, err := m.getNonce(m.address)
 	if err != nil {
 		return 0, fmt.Errorf("failed to get nonce: %w", err)
 	}
 
-	fmt.Printf("   ✅ Nonce: %d\n", nonce)
+	logging.Info("got nonce", "nonce", nonce)
 
-	fmt.Println("\n   [Step 4/5] 🔐 Requesting mint signature...")
+	logging.Info("requesting mint signature")
 	// 4. Request mint signature from backend (passing wallet address + IPFS URI + nonce)
 	signature, err := m.requestMintSignature(m.address.Hex(), ipfsHash, nonce)
 	if err != nil {
 		return 0, fmt.Errorf("failed to get mint signature: %w", err)
 	}
 
-	fmt.Println("\n   [Step 5/5] ⛓️  Executing blockchain transaction...")
+	logging.Info("executing blockchain transaction")
 	// 5. Execute mint transaction on-chain with the signature
 	tokenID, err := m.executeMint(signature)
 	if err != nil {
@@ -167,105 +172,20 @@ func (m *NFTMinter) MintAgent(metadata AgentMetadata) (uint64, error) {
 
 // uploadMetadataToIPFS sends agent metadata to backend which handles IPFS upload
 func (m *NFTMinter) uploadMetadataToIPFS(metadata AgentMetadata) (string, error) {
-	// The backend handles the actual IPFS upload via Pinata
-	// We just send the metadata to the backend endpoint
-	
-	// Prepare request body with agent metadata
-	body, err := json.Marshal(metadata)
-	if err != nil {
-		return "", fmt.Errorf("failed to marshal metadata: %w", err)
-	}
-
-	// Create request to backend
-	// Ensure backend URL doesn't have trailing slash
-	backendURL := strings.TrimRight(m.backendURL, "/")
-	req, err := http.NewRequest("POST", backendURL+"/api/ipfs/upload-metadata", bytes.NewBuffer(body))
-	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
-	}
-	req.Header.Set("Content-Type", "application/json")
-
-	// Send metadata to backend
-	resp, err := m.httpClient.Do(req)
-	if err != nil {
-		return "", fmt.Errorf("failed to send request to backend: %w", err)
+	if m.backend == nil {
+		return "", backend.ErrNoBaseURL
 	}
-	defer resp.Body.Close()
-
-	// Read response from backend
-	respBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", fmt.Errorf("failed to read backend response: %w", err)
-	}
-
-	// Parse response - backend returns IPFS hash after uploading via Pinata
-	var uploadResp IPFSUploadResponse
-	if err := json.Unmarshal(respBody, &uploadResp); err != nil {
-		return "", fmt.Errorf("failed to parse backend response: %w", err)
-	}
-
-	if !uploadResp.Success {
-		return "", fmt.Errorf("backend upload failed: %s", uploadResp.Error)
-	}
-
-	// Return IPFS URI that backend created
-	return fmt.Sprintf("ipfs://%s", uploadResp.IpfsHash), nil
+	// The backend handles the actual IPFS upload via Pinata
+	return m.backend.UploadMetadata(context.Background(), metadata)
 }
 
 // getContractConfig gets the contract configuration from backend
 func (m *NFTMinter) getContractConfig() (*ContractConfigResponse, error) {
-	// Ensure backend URL doesn't have trailing slash
-	backendURL := strings.TrimRight(m.backendURL, "/")
-	endpoint := backendURL + "/api/contract/config"
-	
-	fmt.Printf("   📡 Fetching contract config from: %s\n", endpoint)
-	
-	// Create request
-	req, err := http.NewRequest("GET", endpoint, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	// Send request
-	resp, err := m.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to send request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	// Check response status
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("backend returned status %d: %s", resp.StatusCode, string(body))
-	}
-
-	// Read response
-	respBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
-	}
-
-	// Log response for debugging if it looks like HTML
-	if len(respBody) > 0 && respBody[0] == '<' {
-		preview := string(respBody)
-		if len(preview) > 100 {
-			preview = preview[:100]
-		}
-		return nil, fmt.Errorf("backend returned HTML instead of JSON. Response starts with: %s", preview)
+	if m.backend == nil {
+		return nil, backend.ErrNoBaseURL
 	}
-
-	// Parse response
-	var config ContractConfigResponse
-	if err := json.Unmarshal(respBody, &config); err != nil {
-		// Include part of the response in error for debugging
-		preview := string(respBody)
-		if len(preview) > 100 {
-			preview = preview[:100] + "..."
-		}
-		return nil, fmt.Errorf("failed to parse response: %w. Response: %s", err, preview)
-	}
-
-	return &config, nil
+	logging.Debug("fetching contract config", "backend", m.backend.BaseURL())
+	return m.backend.ContractConfig(context.Background())
 }
 
 // getNonce gets the current nonce for an address from the contract
@@ -308,107 +228,21 @@ func (m *NFTMinter) getNonce(address common.Address) (uint64, error) {
 
 // requestMintSignature requests a mint signature from the backend
 func (m *NFTMinter) requestMintSignature(to string, tokenURI string, nonce uint64) (string, error) {
-	// Show progress
-	fmt.Printf("   📝 Requesting mint signature from backend...\n")
-	
-	// Prepare request
-	// Note: tokenURI is not used in signature generation but sent for compatibility
-	reqBody := MintSignatureRequest{
-		To:       to,
-		TokenURI: "", // Empty as per backend expectation
-		Nonce:    nonce,
+	if m.backend == nil {
+		return "", backend.ErrNoBaseURL
 	}
+	logging.Debug("requesting mint signature from backend", "to", to, "nonce", nonce)
 
-	body, err := json.Marshal(reqBody)
+	// Note: tokenURI is not used in signature generation; the backend expects it empty
+	sigResp, err := m.backend.MintSignature(context.Background(), MintSignatureRequest{
+		To:    to,
+		Nonce: nonce,
+	})
 	if err != nil {
-		return "", fmt.Errorf("failed to marshal request: %w", err)
+		return "", err
 	}
 
-	// Create request
-	// Ensure backend URL doesn't have trailing slash
-	backendURL := strings.TrimRight(m.backendURL, "/")
-	endpoint := backendURL + "/api/signature/generate-mint"
-	
-	fmt.Printf("   📡 Sending request to: %s\n", endpoint)
-	fmt.Printf("   📦 Request data: to=%s, nonce=%d, tokenURI=\"\" (not used in signature)\n", to, nonce)
-	
-	req, err := http.NewRequest("POST", endpoint, bytes.NewBuffer(body))
-	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
-	}
-	req.Header.Set("Content-Type", "application/json")
-
-	// Send request
-	resp, err := m.httpClient.Do(req)
-	if err != nil {
-		return "", fmt.Errorf("failed to send request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	// Read response
-	respBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", fmt.Errorf("failed to read response: %w", err)
-	}
-
-	// Check response status
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("backend returned status %d: %s", resp.StatusCode, string(respBody))
-	}
-
-	// Log the response status
-	fmt.Printf("   📨 Response status: %d\n", resp.StatusCode)
-	
-	// Check if response is HTML (error page)
-	contentType := resp.Header.Get("Content-Type")
-	if strings.Contains(contentType, "text/html") || (len(respBody) > 0 && respBody[0] == '<') {
-		preview := string(respBody)
-		if len(preview) > 500 {
-			preview = preview[:500] + "..."
-		}
-		
-		// Try to extract meaningful error from HTML
-		errorMsg := "Backend returned HTML instead of JSON. "
-		if strings.Contains(preview, "404") || strings.Contains(preview, "Not Found") {
-			errorMsg += "The mint signature endpoint may not be available at this URL. "
-		} else if strings.Contains(preview, "502") || strings.Contains(preview, "Bad Gateway") {
-			errorMsg += "The backend server may be down or unreachable. "
-		}
-		
-		fmt.Printf("   ❌ Error: %s\n", errorMsg)
-		fmt.Printf("   📄 HTML Response preview:\n%s\n", preview)
-		
-		return "", fmt.Errorf("%sPlease check the backend URL configuration", errorMsg)
-	}
-	
-	// Don't log raw response as it contains sensitive signature data
-
-	// Parse response
-	var sigResp MintSignatureResponse
-	if err := json.Unmarshal(respBody, &sigResp); err != nil {
-		// Check for common JSON parsing errors
-		if strings.Contains(err.Error(), "invalid character 'p'") {
-			// This often means we got a plain text error starting with 'p'
-			if strings.HasPrefix(string(respBody), "property") || strings.HasPrefix(string(respBody), "please") {
-				return "", fmt.Errorf("backend error: %s", string(respBody))
-			}
-		}
-		
-		// Include part of the response in error for debugging
-		preview := string(respBody)
-		if len(preview) > 200 {
-			preview = preview[:200] + "..."
-		}
-		return "", fmt.Errorf("failed to parse JSON response: %w. Response: %s", err, preview)
-	}
-
-	// Validate signature response
-	if sigResp.Signature == "" {
-		return "", fmt.Errorf("backend returned empty signature")
-	}
-	
-	fmt.Printf("   ✅ Received signature successfully\n")
-	fmt.Printf("   ✅ Nonce confirmed: %d\n", sigResp.Nonce)
+	logging.Info("received mint signature", "nonce", sigResp.Nonce)
 	return sigResp.Signature, nil
 }
 
@@ -436,44 +270,29 @@ func (m *NFTMinter) executeMint(signature string) (uint64, error) {
 		return 0, fmt.Errorf("failed to pack mint call: %w", err)
 	}
 
-	// Get the current gas price
-	gasPrice, err := m.client.SuggestGasPrice(context.Background())
-	if err != nil {
-		return 0, fmt.Errorf("failed to get gas price: %w", err)
-	}
-
-	// Get the nonce for the transaction
-	nonce, err := m.client.PendingNonceAt(context.Background(), m.address)
-	if err != nil {
-		return 0, fmt.Errorf("failed to get account nonce: %w", err)
-	}
-
-	// Create the transaction
-	tx := types.NewTransaction(
-		nonce,
-		m.contractAddress,
-		DefaultMintPrice(), // 0.01 ETH mint price
-		uint64(300000),     // Gas limit
-		gasPrice,
-		data,
-	)
-
-	// Sign the transaction
-	signedTx, err := types.SignTx(tx, types.NewEIP155Signer(m.chainID), m.privateKey)
-	if err != nil {
-		return 0, fmt.Errorf("failed to sign transaction: %w", err)
-	}
-
-	// Send the transaction
-	err = m.client.SendTransaction(context.Background(), signedTx)
-	if err != nil {
-		return 0, fmt.Errorf("failed to send transaction: %w", err)
+	var txHash common.Hash
+	if m.relayer != nil {
+		// The sponsor pays gas and the mint price; the forwarder calls mint on our behalf
+		txHash, err = m.relayer.Relay(context.Background(), m.client, m.privateKey, m.chainID, &ForwardRequest{
+			To:    m.contractAddress,
+			Value: DefaultMintPrice(),
+			Gas:   relayGas(context.Background(), m.client, m.address, m.contractAddress, DefaultMintPrice(), data, m.fees, 300000),
+			Data:  data,
+		})
+		if err != nil {
+			return 0, fmt.Errorf("failed to relay mint transaction: %w", err)
+		}
+	} else {
+		txHash, err = m.sendMint(data)
+		if err != nil {
+			return 0, err
+		}
 	}
 
-	fmt.Printf("Mint transaction sent: %s\n", signedTx.Hash().Hex())
+	logging.Info("mint transaction sent", "tx_hash", txHash.Hex(), "relayed", m.relayer != nil)
 
 	// Wait for transaction receipt
-	receipt, err := m.WaitForTransaction(context.Background(), signedTx)
+	receipt, err := m.WaitForTransactionHash(context.Background(), txHash)
 	if err != nil {
 		return 0, fmt.Errorf("failed to wait for transaction: %w", err)
 	}
@@ -496,6 +315,12 @@ func (m *NFTMinter) executeMint(signature string) (uint64, error) {
 	return 0, fmt.Errorf("could not extract token ID from transaction logs")
 }
 
+// sendMint sends the mint transaction from the agent wallet with estimated
+// gas and EIP-1559 fees and returns the hash of the mined transaction
+func (m *NFTMinter) sendMint(data []byte) (common.Hash, error) {
+	return sendWithFees(context.Background(), m.client, m.privateKey, m.chainID, m.fees, m.contractAddress, DefaultMintPrice(), data)
+}
+
 // GenerateMetadataHash generates a SHA256 hash of agent metadata
 func GenerateMetadataHash(metadata AgentMetadata) string {
 	// Create deterministic string representation
@@ -512,25 +337,37 @@ func GenerateMetadataHash(metadata AgentMetadata) string {
 
 // SendMetadataHashToBackend sends the metadata hash for an existing agent
 func (m *NFTMinter) SendMetadataHashToBackend(hash string, tokenID uint64, walletAddress string) error {
-	// TODO: Implement backend endpoint for metadata hash submission
-	// This would send the hash along with the token ID to verify ownership
-	
-	reqBody := map[string]interface{}{
-		"hash":          hash,
-		"tokenId":       tokenID,
-		"walletAddress": walletAddress,
-	}
+	if m.backend == nil {
+		return backend.ErrNoBaseURL
+	}
+	return m.backend.RegisterMetadata(context.Background(), backend.MetadataRegistration{
+		Hash:          hash,
+		TokenID:       tokenID,
+		WalletAddress: walletAddress,
+	})
+}
 
-	_, err := json.Marshal(reqBody)
-	if err != nil {
-		return fmt.Errorf("failed to marshal request: %w", err)
-	}
+// SetBackend sets the client of the backend's mint and metadata APIs,
+// replacing the one created for the backend URL
+func (m *NFTMinter) SetBackend(client *backend.Client) {
+	m.backend = client
+}
 
-	// For now, we'll just log this operation
-	// In production, this would make an actual HTTP request
-	fmt.Printf("Would send metadata hash: %s for token ID: %d\n", hash, tokenID)
-	
-	return nil
+// SetFeeConfig sets how mint transactions sent from the agent wallet are priced
+func (m *NFTMinter) SetFeeConfig(config gas.Config) {
+	m.fees = config
+}
+
+// SetRelayer routes mint transactions through a gas sponsor relayer (nil to send them directly)
+func (m *NFTMinter) SetRelayer(relayer *Relayer) {
+	m.relayer = relayer
+}
+
+// Close closes the connections to the Ethereum RPC endpoints
+func (m *NFTMinter) Close() {
+	if m.client != nil {
+		m.client.Close()
+	}
 }
 
 // GetAddress returns the address associated with the minter
@@ -540,8 +377,13 @@ func (m *NFTMinter) GetAddress() common.Address {
 
 // WaitForTransaction waits for a transaction to be confirmed
 func (m *NFTMinter) WaitForTransaction(ctx context.Context, tx *types.Transaction) (*types.Receipt, error) {
+	return m.WaitForTransactionHash(ctx, tx.Hash())
+}
+
+// WaitForTransactionHash waits for the transaction with the given hash to be confirmed
+func (m *NFTMinter) WaitForTransactionHash(ctx context.Context, hash common.Hash) (*types.Receipt, error) {
 	// Wait for transaction receipt
-	receipt, err := bind.WaitMined(ctx, m.client, tx)
+	receipt, err := bind.WaitMinedHash(ctx, m.client, hash)
 	if err != nil {
 		return nil, fmt.Errorf("failed to wait for transaction: %w", err)
 	}
@@ -0,0 +1,311 @@
+package nft
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/errs"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/logging"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// SigningScheme selects how relayed requests are signed
+type SigningScheme string
+
+const (
+	// SigningSchemeEIP712 signs an ERC-2771 ForwardRequest as EIP-712 typed data
+	// (OpenZeppelin MinimalForwarder and compatible forwarders)
+	SigningSchemeEIP712 SigningScheme = "eip712"
+	// SigningSchemePersonal signs the request hash with EIP-191 personal_sign
+	SigningSchemePersonal SigningScheme = "personal"
+)
+
+// ErrRelayerNotConfigured is returned when a relayer is created without an endpoint or forwarder
+var ErrRelayerNotConfigured = errors.New("relayer endpoint and forwarder are required")
+
+// RelayerConfig configures a gas sponsor relayer
+type RelayerConfig struct {
+	Endpoint      string        // URL relay requests are POSTed to
+	APIKey        string        // Sent as a Bearer token (optional)
+	Forwarder     string        // Trusted forwarder contract that executes relayed calls
+	Scheme        SigningScheme // How requests are signed (default eip712)
+	DomainName    string        // EIP-712 domain name of the forwarder
+	DomainVersion string        // EIP-712 domain version of the forwarder
+	Timeout       time.Duration // HTTP timeout of a relay request
+}
+
+// DefaultRelayerConfig returns the default relayer configuration
+func DefaultRelayerConfig() *RelayerConfig {
+	return &RelayerConfig{
+		Scheme:        SigningSchemeEIP712,
+		DomainName:    "MinimalForwarder",
+		DomainVersion: "0.0.1",
+		Timeout:       30 * time.Second,
+	}
+}
+
+// ForwardRequest is a call executed by the forwarder on behalf of From
+type ForwardRequest struct {
+	From  common.Address
+	To    common.Address
+	Value *big.Int
+	Gas   uint64
+	Nonce *big.Int
+	Data  []byte
+}
+
+// forwardRequestJSON is the wire format of a ForwardRequest
+type forwardRequestJSON struct {
+	From  common.Address `json:"from"`
+	To    common.Address `json:"to"`
+	Value string         `json:"value"`
+	Gas   string         `json:"gas"`
+	Nonce string         `json:"nonce"`
+	Data  hexutil.Bytes  `json:"data"`
+}
+
+// relayRequest is the body POSTed to the relayer
+type relayRequest struct {
+	ChainID   string             `json:"chain_id"`
+	Forwarder common.Address     `json:"forwarder"`
+	Scheme    SigningScheme      `json:"scheme"`
+	Request   forwardRequestJSON `json:"request"`
+	Signature hexutil.Bytes      `json:"signature"`
+}
+
+// relayResponse is the relayer's reply
+type relayResponse struct {
+	TxHash string `json:"tx_hash"`
+	Error  string `json:"error,omitempty"`
+}
+
+var (
+	forwardRequestTypeHash = crypto.Keccak256([]byte("ForwardRequest(address from,address to,uint256 value,uint256 gas,uint256 nonce,bytes data)"))
+	eip712DomainTypeHash   = crypto.Keccak256([]byte("EIP712Domain(string name,string version,uint256 chainId,address verifyingContract)"))
+	getNonceSelector       = crypto.Keccak256([]byte("getNonce(address)"))[:4]
+)
+
+// Relayer submits contract calls through a gas sponsor, so agents whose
+// wallets hold no native tokens can still mint and update their NFT.
+// Calls are signed by the agent as meta-transactions and executed by a
+// trusted forwarder contract; the relayer pays for gas and any call value.
+type Relayer struct {
+	config     *RelayerConfig
+	forwarder  common.Address
+	httpClient *http.Client
+}
+
+// NewRelayer creates a relayer
+func NewRelayer(config *RelayerConfig) (*Relayer, error) {
+	if config == nil || config.Endpoint == "" || config.Forwarder == "" {
+		return nil, ErrRelayerNotConfigured
+	}
+	if !common.IsHexAddress(config.Forwarder) {
+		return nil, fmt.Errorf("invalid forwarder address: %s", config.Forwarder)
+	}
+
+	defaults := DefaultRelayerConfig()
+	merged := *config
+	if merged.Scheme == "" {
+		merged.Scheme = defaults.Scheme
+	}
+	if merged.Scheme != SigningSchemeEIP712 && merged.Scheme != SigningSchemePersonal {
+		return nil, fmt.Errorf("invalid signing scheme %q (use %q or %q)", merged.Scheme, SigningSchemeEIP712, SigningSchemePersonal)
+	}
+	if merged.DomainName == "" {
+		merged.DomainName = defaults.DomainName
+	}
+	if merged.DomainVersion == "" {
+		merged.DomainVersion = defaults.DomainVersion
+	}
+	if merged.Timeout <= 0 {
+		merged.Timeout = defaults.Timeout
+	}
+
+	return &Relayer{
+		config:     &merged,
+		forwarder:  common.HexToAddress(merged.Forwarder),
+		httpClient: &http.Client{Timeout: merged.Timeout},
+	}, nil
+}
+
+// Relay signs req with key and submits it to the relayer, returning the hash of the
+// sponsored transaction. From is set from key; a nil Nonce is read from the forwarder.
+func (r *Relayer) Relay(ctx context.Context, caller bind.ContractCaller, key *ecdsa.PrivateKey, chainID *big.Int, req *ForwardRequest) (common.Hash, error) {
+	req.From = crypto.PubkeyToAddress(key.PublicKey)
+	if req.Value == nil {
+		req.Value = new(big.Int)
+	}
+	if req.Nonce == nil {
+		nonce, err := r.nonce(ctx, caller, req.From)
+		if err != nil {
+			return common.Hash{}, err
+		}
+		req.Nonce = nonce
+	}
+
+	signature, err := r.Sign(key, chainID, req)
+	if err != nil {
+		return common.Hash{}, err
+	}
+
+	body, err := json.Marshal(relayRequest{
+		ChainID:   chainID.String(),
+		Forwarder: r.forwarder,
+		Scheme:    r.config.Scheme,
+		Request: forwardRequestJSON{
+			From:  req.From,
+			To:    req.To,
+			Value: req.Value.String(),
+			Gas:   strconv.FormatUint(req.Gas, 10),
+			Nonce: req.Nonce.String(),
+			Data:  req.Data,
+		},
+		Signature: signature,
+	})
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("failed to marshal relay request: %w", err)
+	}
+
+	return r.submit(ctx, body)
+}
+
+// Sign signs req according to the configured signing scheme
+func (r *Relayer) Sign(key *ecdsa.PrivateKey, chainID *big.Int, req *ForwardRequest) ([]byte, error) {
+	var digest []byte
+	switch r.config.Scheme {
+	case SigningSchemePersonal:
+		digest = personalDigest(r.requestHash(chainID, req))
+	default:
+		digest = r.typedDataDigest(chainID, req)
+	}
+
+	signature, err := crypto.Sign(digest, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign relay request: %w", err)
+	}
+	signature[crypto.RecoveryIDOffset] += 27 // Ethereum-style V
+	return signature, nil
+}
+
+// typedDataDigest returns the EIP-712 digest of req for the forwarder's domain
+func (r *Relayer) typedDataDigest(chainID *big.Int, req *ForwardRequest) []byte {
+	domainSeparator := crypto.Keccak256(
+		eip712DomainTypeHash,
+		crypto.Keccak256([]byte(r.config.DomainName)),
+		crypto.Keccak256([]byte(r.config.DomainVersion)),
+		uint256Bytes(chainID),
+		common.LeftPadBytes(r.forwarder.Bytes(), 32),
+	)
+	structHash := crypto.Keccak256(
+		forwardRequestTypeHash,
+		common.LeftPadBytes(req.From.Bytes(), 32),
+		common.LeftPadBytes(req.To.Bytes(), 32),
+		uint256Bytes(req.Value),
+		uint256Bytes(new(big.Int).SetUint64(req.Gas)),
+		uint256Bytes(req.Nonce),
+		crypto.Keccak256(req.Data),
+	)
+	return crypto.Keccak256([]byte{0x19, 0x01}, domainSeparator, structHash)
+}
+
+// requestHash returns the hash of the ABI-packed request, bound to the chain and forwarder
+func (r *Relayer) requestHash(chainID *big.Int, req *ForwardRequest) []byte {
+	return crypto.Keccak256(
+		req.From.Bytes(),
+		req.To.Bytes(),
+		uint256Bytes(req.Value),
+		uint256Bytes(new(big.Int).SetUint64(req.Gas)),
+		uint256Bytes(req.Nonce),
+		crypto.Keccak256(req.Data),
+		uint256Bytes(chainID),
+		r.forwarder.Bytes(),
+	)
+}
+
+// personalDigest returns the EIP-191 personal_sign digest of hash
+func personalDigest(hash []byte) []byte {
+	return crypto.Keccak256([]byte(fmt.Sprintf("\x19Ethereum Signed Message:\n%d", len(hash))), hash)
+}
+
+// uint256Bytes encodes n as a 32-byte big-endian word
+func uint256Bytes(n *big.Int) []byte {
+	return common.LeftPadBytes(n.Bytes(), 32)
+}
+
+// nonce reads the forwarder nonce of from
+func (r *Relayer) nonce(ctx context.Context, caller bind.ContractCaller, from common.Address) (*big.Int, error) {
+	data := append(append([]byte{}, getNonceSelector...), common.LeftPadBytes(from.Bytes(), 32)...)
+	result, err := caller.CallContract(ctx, ethereum.CallMsg{To: &r.forwarder, Data: data}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get forwarder nonce: %w", err)
+	}
+	if len(result) != 32 {
+		return nil, fmt.Errorf("failed to get forwarder nonce: unexpected result length %d", len(result))
+	}
+	return new(big.Int).SetBytes(result), nil
+}
+
+// submit POSTs a relay request and returns the transaction hash
+func (r *Relayer) submit(ctx context.Context, body []byte) (common.Hash, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, r.config.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("failed to create relay request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if r.config.APIKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+r.config.APIKey)
+	}
+
+	resp, err := r.httpClient.Do(httpReq)
+	if err != nil {
+		return common.Hash{}, errs.Retryable(fmt.Errorf("failed to send relay request: %w", err))
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return common.Hash{}, errs.Retryable(fmt.Errorf("failed to read relay response: %w", err))
+	}
+
+	var result relayResponse
+	_ = json.Unmarshal(respBody, &result)
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusAccepted {
+		message := result.Error
+		if message == "" {
+			message = http.StatusText(resp.StatusCode)
+		}
+		err := fmt.Errorf("relayer returned status %d: %s", resp.StatusCode, message)
+		switch {
+		case resp.StatusCode == http.StatusTooManyRequests:
+			retryAfter, _ := strconv.Atoi(resp.Header.Get("Retry-After"))
+			return common.Hash{}, errs.RateLimited(err, time.Duration(retryAfter)*time.Second)
+		case resp.StatusCode >= 500:
+			return common.Hash{}, errs.Retryable(err)
+		default:
+			return common.Hash{}, errs.Terminal(err)
+		}
+	}
+
+	if len(common.FromHex(result.TxHash)) != common.HashLength {
+		return common.Hash{}, fmt.Errorf("relayer returned invalid transaction hash %q", result.TxHash)
+	}
+
+	hash := common.HexToHash(result.TxHash)
+	logging.Info("relayed transaction sent", "tx_hash", hash.Hex())
+	return hash, nil
+}
@@ -0,0 +1,219 @@
+package nft
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/errs"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+)
+
+// testForwarder is the verifying contract of the test domain
+const testForwarder = "0x5FbDB2315678afecb367f032d93F642f64180aa3"
+
+func newTestRelayer(t *testing.T, config *RelayerConfig) *Relayer {
+	t.Helper()
+	if config.Forwarder == "" {
+		config.Forwarder = testForwarder
+	}
+	if config.Endpoint == "" {
+		config.Endpoint = "http://relayer.invalid"
+	}
+	relayer, err := NewRelayer(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return relayer
+}
+
+func TestForwardRequestTypeHashes(t *testing.T) {
+	// The type hashes of OpenZeppelin's MinimalForwarder and of the EIP-712 domain
+	tests := []struct {
+		name string
+		got  []byte
+		want string
+	}{
+		{"ForwardRequest", forwardRequestTypeHash, "dd8f4b70b0f4393e889bd39128a30628a78b61816a9eb8199759e7a349657e48"},
+		{"EIP712Domain", eip712DomainTypeHash, "8b73c3c69bb8fe3d512ecc4cf759cc79239f7b179b0ffacaa9a75d522b39400f"},
+	}
+	for _, tt := range tests {
+		if got := hex.EncodeToString(tt.got); got != tt.want {
+			t.Errorf("%s type hash = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestTypedDataDigest(t *testing.T) {
+	relayer := newTestRelayer(t, &RelayerConfig{})
+	chainID := big.NewInt(3338)
+	req := &ForwardRequest{
+		From:  common.HexToAddress("0x70997970C51812dc3A010C7d01b50e0d17dc79C8"),
+		To:    common.HexToAddress("0xe7f1725E7734CE288F8367e1Bb143E90bb3F0512"),
+		Value: big.NewInt(0),
+		Gas:   100000,
+		Nonce: big.NewInt(7),
+		Data:  common.FromHex("0xa9059cbb000000000000000000000000000000000000000000000000000000000000002a"),
+	}
+
+	// The digest a wallet signs for eth_signTypedData_v4 with the MinimalForwarder types
+	typedData := apitypes.TypedData{
+		Types: apitypes.Types{
+			"EIP712Domain": {
+				{Name: "name", Type: "string"},
+				{Name: "version", Type: "string"},
+				{Name: "chainId", Type: "uint256"},
+				{Name: "verifyingContract", Type: "address"},
+			},
+			"ForwardRequest": {
+				{Name: "from", Type: "address"},
+				{Name: "to", Type: "address"},
+				{Name: "value", Type: "uint256"},
+				{Name: "gas", Type: "uint256"},
+				{Name: "nonce", Type: "uint256"},
+				{Name: "data", Type: "bytes"},
+			},
+		},
+		PrimaryType: "ForwardRequest",
+		Domain: apitypes.TypedDataDomain{
+			Name:              "MinimalForwarder",
+			Version:           "0.0.1",
+			ChainId:           (*math.HexOrDecimal256)(chainID),
+			VerifyingContract: testForwarder,
+		},
+		Message: apitypes.TypedDataMessage{
+			"from":  req.From.Hex(),
+			"to":    req.To.Hex(),
+			"value": "0",
+			"gas":   "100000",
+			"nonce": "7",
+			"data":  hexutil.Encode(req.Data),
+		},
+	}
+	want, _, err := apitypes.TypedDataAndHash(typedData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := relayer.typedDataDigest(chainID, req); !bytes.Equal(got, want) {
+		t.Fatalf("digest = %x, want %x", got, want)
+	}
+
+	// Another chain, forwarder or domain version gives another digest
+	other := newTestRelayer(t, &RelayerConfig{DomainVersion: "0.0.2"})
+	if bytes.Equal(other.typedDataDigest(chainID, req), want) {
+		t.Error("digest does not depend on the domain version")
+	}
+	if bytes.Equal(relayer.typedDataDigest(big.NewInt(1), req), want) {
+		t.Error("digest does not depend on the chain ID")
+	}
+}
+
+func TestSignRecoversToSigner(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	chainID := big.NewInt(3338)
+	req := &ForwardRequest{
+		From:  crypto.PubkeyToAddress(key.PublicKey),
+		To:    common.HexToAddress("0xe7f1725E7734CE288F8367e1Bb143E90bb3F0512"),
+		Value: big.NewInt(0),
+		Gas:   100000,
+		Nonce: big.NewInt(0),
+	}
+
+	for _, scheme := range []SigningScheme{SigningSchemeEIP712, SigningSchemePersonal} {
+		relayer := newTestRelayer(t, &RelayerConfig{Scheme: scheme})
+		signature, err := relayer.Sign(key, chainID, req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v := signature[crypto.RecoveryIDOffset]; v != 27 && v != 28 {
+			t.Errorf("%s: V = %d, want 27 or 28", scheme, v)
+		}
+
+		digest := relayer.typedDataDigest(chainID, req)
+		if scheme == SigningSchemePersonal {
+			digest = personalDigest(relayer.requestHash(chainID, req))
+		}
+		raw := append([]byte(nil), signature...)
+		raw[crypto.RecoveryIDOffset] -= 27
+		pub, err := crypto.SigToPub(digest, raw)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if crypto.PubkeyToAddress(*pub) != req.From {
+			t.Errorf("%s: signature recovers to %s, want %s", scheme, crypto.PubkeyToAddress(*pub).Hex(), req.From.Hex())
+		}
+	}
+}
+
+func TestRelayerSubmitStatuses(t *testing.T) {
+	txHash := "0x" + hex.EncodeToString(bytes.Repeat([]byte{0xab}, 32))
+	tests := []struct {
+		name       string
+		status     int
+		header     http.Header
+		body       string
+		kind       errs.Kind // Ignored when the request succeeds
+		ok         bool
+		retryAfter time.Duration
+	}{
+		{name: "ok", status: http.StatusOK, body: `{"tx_hash":"` + txHash + `"}`, ok: true},
+		{name: "accepted", status: http.StatusAccepted, body: `{"tx_hash":"` + txHash + `"}`, ok: true},
+		{name: "invalid hash", status: http.StatusOK, body: `{"tx_hash":"0x12"}`, kind: errs.KindUnknown},
+		{name: "rate limited", status: http.StatusTooManyRequests, header: http.Header{"Retry-After": {"7"}}, body: `{"error":"slow down"}`, kind: errs.KindRateLimited, retryAfter: 7 * time.Second},
+		{name: "server error", status: http.StatusBadGateway, kind: errs.KindRetryable},
+		{name: "rejected", status: http.StatusBadRequest, body: `{"error":"bad signature"}`, kind: errs.KindTerminal},
+		{name: "unauthorized", status: http.StatusUnauthorized, kind: errs.KindTerminal},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != http.MethodPost || r.Header.Get("Authorization") != "Bearer secret" || r.Header.Get("Content-Type") != "application/json" {
+					t.Errorf("unexpected request %s with headers %v", r.Method, r.Header)
+				}
+				for key, values := range tt.header {
+					w.Header()[key] = values
+				}
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			relayer := newTestRelayer(t, &RelayerConfig{Endpoint: server.URL, APIKey: "secret"})
+			hash, err := relayer.submit(context.Background(), []byte(`{}`))
+			if tt.ok {
+				if err != nil || hash != common.HexToHash(txHash) {
+					t.Fatalf("submit() = %s, %v, want %s", hash.Hex(), err, txHash)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("submit() succeeded, want an error")
+			}
+			if kind := errs.KindOf(err); kind != tt.kind {
+				t.Errorf("error kind = %s, want %s (%v)", kind, tt.kind, err)
+			}
+			if retryAfter := errs.RetryAfter(err); retryAfter != tt.retryAfter {
+				t.Errorf("retry after = %v, want %v", retryAfter, tt.retryAfter)
+			}
+		})
+	}
+
+	// A relayer that cannot be reached is worth retrying
+	relayer := newTestRelayer(t, &RelayerConfig{Endpoint: "http://127.0.0.1:1"})
+	if _, err := relayer.submit(context.Background(), []byte(`{}`)); !errs.IsRetryable(err) {
+		t.Errorf("unreachable relayer: error = %v, want a retryable error", err)
+	}
+}
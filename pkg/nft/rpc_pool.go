@@ -0,0 +1,406 @@
+package nft
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/logging"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// ErrNoRPCEndpoints is returned when an RPC pool is created without endpoints
+var ErrNoRPCEndpoints = errors.New("no RPC endpoints configured")
+
+// RPCPoolConfig configures an RPC pool
+type RPCPoolConfig struct {
+	WriteEndpoint       string        // Endpoint for transactions and nonces (defaults to the first read endpoint)
+	ReadEndpoints       []string      // Endpoints for calls and receipts, used round-robin with failover
+	RequestTimeout      time.Duration // Timeout of a single read attempt
+	HealthCheckInterval time.Duration // How often endpoints are probed (0 = only on failures)
+}
+
+// DefaultRPCPoolConfig returns the default RPC pool configuration
+func DefaultRPCPoolConfig() *RPCPoolConfig {
+	return &RPCPoolConfig{
+		RequestTimeout:      10 * time.Second,
+		HealthCheckInterval: 30 * time.Second,
+	}
+}
+
+// RPCEndpointStatus reports the health of a pool endpoint
+type RPCEndpointStatus struct {
+	URL       string    `json:"url"`
+	Write     bool      `json:"write"`
+	Read      bool      `json:"read"`
+	Healthy   bool      `json:"healthy"`
+	LastError string    `json:"last_error,omitempty"`
+	CheckedAt time.Time `json:"checked_at,omitempty"`
+}
+
+type rpcEndpoint struct {
+	url     string
+	client  *ethclient.Client
+	healthy atomic.Bool
+
+	mu        sync.Mutex
+	lastError string
+	checkedAt time.Time
+}
+
+func (e *rpcEndpoint) record(err error) {
+	e.healthy.Store(err == nil)
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.checkedAt = time.Now()
+	e.lastError = ""
+	if err != nil {
+		e.lastError = err.Error()
+	}
+}
+
+// RPCPool spreads reads across several RPC endpoints with health checks and
+// automatic failover, and sends all writes through a single designated endpoint
+// so nonces and pending transactions stay consistent.
+// It implements bind.ContractBackend and bind.DeployBackend.
+type RPCPool struct {
+	write   *rpcEndpoint
+	reads   []*rpcEndpoint
+	clients []*ethclient.Client
+	timeout time.Duration
+	next    atomic.Uint64
+
+	stopOnce sync.Once
+	stop     chan struct{}
+	wg       sync.WaitGroup
+}
+
+// ParseRPCEndpoints splits a comma-separated list of RPC URLs
+func ParseRPCEndpoints(endpoints string) []string {
+	var urls []string
+	for _, url := range strings.Split(endpoints, ",") {
+		if url = strings.TrimSpace(url); url != "" {
+			urls = append(urls, url)
+		}
+	}
+	return urls
+}
+
+// NewRPCPoolFromURLs creates a pool from a comma-separated list of RPC URLs.
+// The first URL is the write endpoint; all URLs serve reads.
+func NewRPCPoolFromURLs(endpoints string) (*RPCPool, error) {
+	config := DefaultRPCPoolConfig()
+	config.ReadEndpoints = ParseRPCEndpoints(endpoints)
+	return NewRPCPool(config)
+}
+
+// NewRPCPool creates an RPC pool and starts its health checks
+func NewRPCPool(config *RPCPoolConfig) (*RPCPool, error) {
+	if config == nil {
+		config = DefaultRPCPoolConfig()
+	}
+
+	readURLs := config.ReadEndpoints
+	writeURL := config.WriteEndpoint
+	if writeURL == "" && len(readURLs) > 0 {
+		writeURL = readURLs[0]
+	}
+	if writeURL == "" {
+		return nil, ErrNoRPCEndpoints
+	}
+	if len(readURLs) == 0 {
+		readURLs = []string{writeURL}
+	}
+
+	pool := &RPCPool{
+		timeout: config.RequestTimeout,
+		stop:    make(chan struct{}),
+	}
+	if pool.timeout <= 0 {
+		pool.timeout = DefaultRPCPoolConfig().RequestTimeout
+	}
+
+	// Endpoints listed more than once share a client
+	endpoints := make(map[string]*rpcEndpoint)
+	dial := func(url string) (*rpcEndpoint, error) {
+		if endpoint, ok := endpoints[url]; ok {
+			return endpoint, nil
+		}
+		client, err := ethclient.Dial(url)
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to RPC endpoint %s: %w", url, err)
+		}
+		endpoint := &rpcEndpoint{url: url, client: client}
+		endpoint.healthy.Store(true)
+		endpoints[url] = endpoint
+		pool.clients = append(pool.clients, client)
+		return endpoint, nil
+	}
+
+	var err error
+	if pool.write, err = dial(writeURL); err != nil {
+		pool.Close()
+		return nil, err
+	}
+	for _, url := range readURLs {
+		endpoint, err := dial(url)
+		if err != nil {
+			pool.Close()
+			return nil, err
+		}
+		pool.reads = append(pool.reads, endpoint)
+	}
+
+	if config.HealthCheckInterval > 0 {
+		pool.wg.Add(1)
+		go pool.healthCheckLoop(config.HealthCheckInterval)
+	}
+
+	return pool, nil
+}
+
+// Close stops the health checks and closes all endpoint connections
+func (p *RPCPool) Close() {
+	p.stopOnce.Do(func() {
+		close(p.stop)
+		p.wg.Wait()
+		for _, client := range p.clients {
+			client.Close()
+		}
+	})
+}
+
+// Status returns the health of every endpoint
+func (p *RPCPool) Status() []RPCEndpointStatus {
+	var statuses []RPCEndpointStatus
+	seen := make(map[*rpcEndpoint]int)
+
+	add := func(endpoint *rpcEndpoint) int {
+		if i, ok := seen[endpoint]; ok {
+			return i
+		}
+		endpoint.mu.Lock()
+		statuses = append(statuses, RPCEndpointStatus{
+			URL:       endpoint.url,
+			Healthy:   endpoint.healthy.Load(),
+			LastError: endpoint.lastError,
+			CheckedAt: endpoint.checkedAt,
+		})
+		endpoint.mu.Unlock()
+		seen[endpoint] = len(statuses) - 1
+		return len(statuses) - 1
+	}
+
+	statuses[add(p.write)].Write = true
+	for _, endpoint := range p.reads {
+		statuses[add(endpoint)].Read = true
+	}
+	return statuses
+}
+
+// healthCheckLoop probes all endpoints until the pool is closed
+func (p *RPCPool) healthCheckLoop(interval time.Duration) {
+	defer p.wg.Done()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.stop:
+			return
+		case <-ticker.C:
+			p.checkHealth()
+		}
+	}
+}
+
+// checkHealth probes each endpoint for its latest block number
+func (p *RPCPool) checkHealth() {
+	endpoints := append([]*rpcEndpoint{p.write}, p.reads...)
+	checked := make(map[*rpcEndpoint]bool)
+
+	for _, endpoint := range endpoints {
+		if checked[endpoint] {
+			continue
+		}
+		checked[endpoint] = true
+
+		ctx, cancel := context.WithTimeout(context.Background(), p.timeout)
+		_, err := endpoint.client.BlockNumber(ctx)
+		cancel()
+
+		wasHealthy := endpoint.healthy.Load()
+		endpoint.record(err)
+		if err != nil && wasHealthy {
+			logging.Warn("RPC endpoint unhealthy", "endpoint", endpoint.url, "error", err)
+		} else if err == nil && !wasHealthy {
+			logging.Info("RPC endpoint recovered", "endpoint", endpoint.url)
+		}
+	}
+}
+
+// readOrder returns the read endpoints to try: healthy ones round-robin,
+// followed by unhealthy ones as a last resort
+func (p *RPCPool) readOrder() []*rpcEndpoint {
+	start := int(p.next.Add(1)-1) % len(p.reads)
+
+	healthy := make([]*rpcEndpoint, 0, len(p.reads))
+	var unhealthy []*rpcEndpoint
+	for i := range p.reads {
+		endpoint := p.reads[(start+i)%len(p.reads)]
+		if endpoint.healthy.Load() {
+			healthy = append(healthy, endpoint)
+		} else {
+			unhealthy = append(unhealthy, endpoint)
+		}
+	}
+	return append(healthy, unhealthy...)
+}
+
+// read runs fn against the read endpoints until one succeeds
+func (p *RPCPool) read(ctx context.Context, op string, fn func(context.Context, *ethclient.Client) error) error {
+	var lastErr error
+	for _, endpoint := range p.readOrder() {
+		attemptCtx, cancel := context.WithTimeout(ctx, p.timeout)
+		err := fn(attemptCtx, endpoint.client)
+		cancel()
+
+		if err == nil {
+			if !endpoint.healthy.Load() {
+				endpoint.record(nil)
+			}
+			return nil
+		}
+		if ctx.Err() != nil || !isEndpointFailure(err) {
+			return err
+		}
+
+		endpoint.record(err)
+		logging.Warn("RPC read failed, trying next endpoint", "endpoint", endpoint.url, "op", op, "error", err)
+		lastErr = err
+	}
+	return fmt.Errorf("all RPC endpoints failed for %s: %w", op, lastErr)
+}
+
+// isEndpointFailure reports whether err means the endpoint itself failed,
+// as opposed to the node answering with an error (e.g. a reverted call)
+func isEndpointFailure(err error) bool {
+	var rpcErr rpc.Error
+	if errors.As(err, &rpcErr) {
+		return false
+	}
+	return !errors.Is(err, ethereum.NotFound)
+}
+
+// CallContract executes a contract call on a read endpoint
+func (p *RPCPool) CallContract(ctx context.Context, call ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	var result []byte
+	err := p.read(ctx, "eth_call", func(ctx context.Context, client *ethclient.Client) error {
+		var err error
+		result, err = client.CallContract(ctx, call, blockNumber)
+		return err
+	})
+	return result, err
+}
+
+// CodeAt returns the contract code at the given block from a read endpoint
+func (p *RPCPool) CodeAt(ctx context.Context, contract common.Address, blockNumber *big.Int) ([]byte, error) {
+	var code []byte
+	err := p.read(ctx, "eth_getCode", func(ctx context.Context, client *ethclient.Client) error {
+		var err error
+		code, err = client.CodeAt(ctx, contract, blockNumber)
+		return err
+	})
+	return code, err
+}
+
+// HeaderByNumber returns a block header from a read endpoint
+func (p *RPCPool) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	var header *types.Header
+	err := p.read(ctx, "eth_getBlockByNumber", func(ctx context.Context, client *ethclient.Client) error {
+		var err error
+		header, err = client.HeaderByNumber(ctx, number)
+		return err
+	})
+	return header, err
+}
+
+// FilterLogs returns matching logs from a read endpoint
+func (p *RPCPool) FilterLogs(ctx context.Context, query ethereum.FilterQuery) ([]types.Log, error) {
+	var logs []types.Log
+	err := p.read(ctx, "eth_getLogs", func(ctx context.Context, client *ethclient.Client) error {
+		var err error
+		logs, err = client.FilterLogs(ctx, query)
+		return err
+	})
+	return logs, err
+}
+
+// TransactionReceipt returns a transaction receipt from a read endpoint
+func (p *RPCPool) TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
+	var receipt *types.Receipt
+	err := p.read(ctx, "eth_getTransactionReceipt", func(ctx context.Context, client *ethclient.Client) error {
+		var err error
+		receipt, err = client.TransactionReceipt(ctx, txHash)
+		return err
+	})
+	return receipt, err
+}
+
+// TransactionByHash returns a transaction and whether it is pending from a read endpoint
+func (p *RPCPool) TransactionByHash(ctx context.Context, txHash common.Hash) (*types.Transaction, bool, error) {
+	var tx *types.Transaction
+	var pending bool
+	err := p.read(ctx, "eth_getTransactionByHash", func(ctx context.Context, client *ethclient.Client) error {
+		var err error
+		tx, pending, err = client.TransactionByHash(ctx, txHash)
+		return err
+	})
+	return tx, pending, err
+}
+
+// SubscribeFilterLogs subscribes to logs through the write endpoint
+func (p *RPCPool) SubscribeFilterLogs(ctx context.Context, query ethereum.FilterQuery, ch chan<- types.Log) (ethereum.Subscription, error) {
+	return p.write.client.SubscribeFilterLogs(ctx, query, ch)
+}
+
+// PendingCodeAt returns the code of an account in the pending state of the write endpoint
+func (p *RPCPool) PendingCodeAt(ctx context.Context, account common.Address) ([]byte, error) {
+	return p.write.client.PendingCodeAt(ctx, account)
+}
+
+// PendingNonceAt returns the pending nonce of an account from the write endpoint
+func (p *RPCPool) PendingNonceAt(ctx context.Context, account common.Address) (uint64, error) {
+	return p.write.client.PendingNonceAt(ctx, account)
+}
+
+// SuggestGasPrice returns the gas price suggested by the write endpoint
+func (p *RPCPool) SuggestGasPrice(ctx context.Context) (*big.Int, error) {
+	return p.write.client.SuggestGasPrice(ctx)
+}
+
+// SuggestGasTipCap returns the gas tip cap suggested by the write endpoint
+func (p *RPCPool) SuggestGasTipCap(ctx context.Context) (*big.Int, error) {
+	return p.write.client.SuggestGasTipCap(ctx)
+}
+
+// EstimateGas estimates the gas of a call on the write endpoint
+func (p *RPCPool) EstimateGas(ctx context.Context, call ethereum.CallMsg) (uint64, error) {
+	return p.write.client.EstimateGas(ctx, call)
+}
+
+// SendTransaction sends a signed transaction through the write endpoint
+func (p *RPCPool) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	return p.write.client.SendTransaction(ctx, tx)
+}
@@ -0,0 +1,96 @@
+package nft
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/logging"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+)
+
+// maxTransferBlockRange bounds the blocks of one Transfer log query; many RPC
+// providers reject larger ranges
+const maxTransferBlockRange = 5000
+
+// TokenTransfer is a Transfer event of a business card
+type TokenTransfer struct {
+	TokenID *big.Int
+	From    string // Zero address when the card was minted
+	To      string // Zero address when the card was burned
+	Block   uint64
+	TxHash  string
+}
+
+// OwnerOf returns the wallet that owns a card
+func (m *BusinessCardManager) OwnerOf(ctx context.Context, tokenID *big.Int) (string, error) {
+	owner, err := m.contract.OwnerOf(&bind.CallOpts{Context: ctx}, tokenID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get owner of token %s: %w", tokenID, err)
+	}
+	return owner.Hex(), nil
+}
+
+// WatchTransfers calls onTransfer for every transfer of a card until ctx is
+// done, polling the contract's Transfer events every interval (default 30s).
+// Only transfers after the call are reported; use OwnerOf to learn the
+// current owner. Failed polls are retried from the same block.
+func (m *BusinessCardManager) WatchTransfers(ctx context.Context, tokenID *big.Int, interval time.Duration, onTransfer func(TokenTransfer)) error {
+	if interval <= 0 {
+		interval = 30 * time.Second
+	}
+	head, err := m.client.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to get latest block: %w", err)
+	}
+	next := head.Number.Uint64() + 1
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+		if next, err = m.pollTransfers(ctx, tokenID, next, onTransfer); err != nil && ctx.Err() == nil {
+			logging.Warn("failed to poll NFT transfers", "token_id", tokenID, "error", err)
+		}
+	}
+}
+
+// pollTransfers reports the transfers of tokenID from block from to the
+// latest block and returns the block to continue with
+func (m *BusinessCardManager) pollTransfers(ctx context.Context, tokenID *big.Int, from uint64, onTransfer func(TokenTransfer)) (uint64, error) {
+	head, err := m.client.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return from, fmt.Errorf("failed to get latest block: %w", err)
+	}
+	latest := head.Number.Uint64()
+
+	for from <= latest {
+		end := min(latest, from+maxTransferBlockRange-1)
+		transfers, err := m.contract.FilterTransfer(&bind.FilterOpts{Start: from, End: &end, Context: ctx}, nil, nil, []*big.Int{tokenID})
+		if err != nil {
+			return from, fmt.Errorf("failed to filter transfers: %w", err)
+		}
+		for transfers.Next() {
+			event := transfers.Event
+			onTransfer(TokenTransfer{
+				TokenID: event.TokenId,
+				From:    event.From.Hex(),
+				To:      event.To.Hex(),
+				Block:   event.Raw.BlockNumber,
+				TxHash:  event.Raw.TxHash.Hex(),
+			})
+		}
+		err = transfers.Error()
+		transfers.Close()
+		if err != nil {
+			return from, fmt.Errorf("failed to read transfers: %w", err)
+		}
+		from = end + 1
+	}
+	return from, nil
+}
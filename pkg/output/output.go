@@ -0,0 +1,158 @@
+// Package output controls the decoration of text the SDK generates itself,
+// such as task rejections, error responses and log messages.
+//
+// By default SDK messages carry emoji ("⚠️ Agent rate limit exceeded...").
+// Deployments that feed agent output into terminals, text-to-speech or strict
+// enterprise UIs can select a plain style instead:
+//
+//	output.SetDefault(output.StylePlain)
+//	output.Clean("⚠️ Request too large") // "Request too large"
+package output
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"unicode/utf8"
+)
+
+// Style is how emoji and decorative characters in SDK-generated text are rendered
+type Style string
+
+// Output styles
+const (
+	StyleEmoji Style = "emoji" // Keep emoji and decorations (default)
+	StylePlain Style = "plain" // Remove emoji, draw boxes with ASCII
+	StyleText  Style = "text"  // Replace common emoji with text labels such as "[warning]", remove the rest
+)
+
+// ParseStyle parses a style name (empty means emoji)
+func ParseStyle(style string) (Style, error) {
+	switch Style(strings.ToLower(style)) {
+	case "", StyleEmoji:
+		return StyleEmoji, nil
+	case StylePlain:
+		return StylePlain, nil
+	case StyleText:
+		return StyleText, nil
+	default:
+		return StyleEmoji, fmt.Errorf("invalid output style %q (use %q, %q or %q)", style, StyleEmoji, StylePlain, StyleText)
+	}
+}
+
+// textLabels are the replacements of common emoji in the text style
+var textLabels = map[rune]string{
+	'⚠': "[warning]",
+	'❌': "[error]",
+	'✅': "[ok]",
+	'✔': "[ok]",
+	'🔄': "[update]",
+	'ℹ': "[info]",
+	'⏳': "[pending]",
+	'🚫': "[blocked]",
+}
+
+// boxDrawing maps box-drawing characters to ASCII
+var boxDrawing = map[rune]rune{
+	'─': '-', '━': '-', '═': '=',
+	'│': '|', '┃': '|', '║': '|',
+	'┌': '+', '┐': '+', '└': '+', '┘': '+',
+	'├': '+', '┤': '+', '┬': '+', '┴': '+', '┼': '+',
+	'╔': '+', '╗': '+', '╚': '+', '╝': '+',
+}
+
+// Format renders s in the given style
+func Format(style Style, s string) string {
+	if style == StyleEmoji || style == "" {
+		return s
+	}
+
+	var b strings.Builder
+	b.Grow(len(s))
+
+	// dropSpace removes the space following a removed emoji, so
+	// "⚠️ Request too large" becomes "Request too large"
+	dropSpace := false
+	for _, r := range s {
+		if isJoiner(r) {
+			continue
+		}
+		if r == ' ' && dropSpace {
+			dropSpace = false
+			continue
+		}
+		dropSpace = false
+
+		if ascii, ok := boxDrawing[r]; ok {
+			b.WriteRune(ascii)
+			continue
+		}
+		if !isDecoration(r) {
+			b.WriteRune(r)
+			continue
+		}
+
+		if label, ok := textLabels[r]; ok && style == StyleText {
+			b.WriteString(label)
+			continue
+		}
+		dropSpace = b.Len() == 0 || endsWithSpace(b.String())
+	}
+	return b.String()
+}
+
+// isDecoration reports whether r is an emoji or pictograph
+func isDecoration(r rune) bool {
+	switch {
+	case r >= 0x1F000 && r <= 0x1FAFF: // Emoji, pictographs, symbols
+		return true
+	case r >= 0x2600 && r <= 0x27BF: // Miscellaneous symbols, dingbats
+		return true
+	case r >= 0x2B00 && r <= 0x2BFF: // Arrows and stars used as emoji (⭐)
+		return true
+	case r >= 0x2300 && r <= 0x23FF: // Technical symbols used as emoji (⏳, ⌛)
+		return true
+	case r == 'ℹ':
+		return true
+	}
+	return false
+}
+
+// isJoiner reports whether r only modifies the emoji before it
+func isJoiner(r rune) bool {
+	return r == 0x200D || // Zero width joiner
+		(r >= 0xFE00 && r <= 0xFE0F) || // Variation selectors
+		r == 0x20E3 // Combining enclosing keycap
+}
+
+// endsWithSpace reports whether s ends with whitespace
+func endsWithSpace(s string) bool {
+	r, _ := utf8.DecodeLastRuneInString(s)
+	return r == ' ' || r == '\n' || r == '\t'
+}
+
+// styleHolder lets atomic.Value store the default style
+type styleHolder struct {
+	style Style
+}
+
+var defaultStyle atomic.Value
+
+func init() {
+	defaultStyle.Store(styleHolder{StyleEmoji})
+}
+
+// Default returns the style of SDK-generated text
+func Default() Style {
+	return defaultStyle.Load().(styleHolder).style
+}
+
+// SetDefault sets the style of SDK-generated text
+func SetDefault(style Style) {
+	defaultStyle.Store(styleHolder{style})
+}
+
+// Clean renders s in the default style
+func Clean(s string) string {
+	return Format(Default(), s)
+}
@@ -0,0 +1,51 @@
+package output
+
+import "testing"
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		style Style
+		in    string
+		want  string
+	}{
+		{StyleEmoji, "⚠️ Request too large", "⚠️ Request too large"},
+		{StylePlain, "⚠️ Request too large", "Request too large"},
+		{StylePlain, "❌ Error: timeout", "Error: timeout"},
+		{StylePlain, "Done 👍🏽 see you", "Done see you"},
+		{StylePlain, "family: 👨‍👩‍👧 ok", "family: ok"},
+		{StylePlain, "no emoji here", "no emoji here"},
+		{StylePlain, "┌──┐\n│ab│\n└──┘", "+--+\n|ab|\n+--+"},
+		{StyleText, "⚠️ Request too large", "[warning] Request too large"},
+		{StyleText, "🔄 Update: step 2", "[update] Update: step 2"},
+		{StyleText, "🎉 Welcome", "Welcome"},
+	}
+
+	for _, tt := range tests {
+		if got := Format(tt.style, tt.in); got != tt.want {
+			t.Errorf("Format(%s, %q) = %q, want %q", tt.style, tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseStyle(t *testing.T) {
+	for name, want := range map[string]Style{"": StyleEmoji, "emoji": StyleEmoji, "PLAIN": StylePlain, "text": StyleText} {
+		if got, err := ParseStyle(name); err != nil || got != want {
+			t.Errorf("ParseStyle(%q) = %q, %v", name, got, err)
+		}
+	}
+	if _, err := ParseStyle("fancy"); err == nil {
+		t.Error("expected error for unknown style")
+	}
+}
+
+func TestClean(t *testing.T) {
+	defer SetDefault(StyleEmoji)
+
+	if got := Clean("✅ ok"); got != "✅ ok" {
+		t.Errorf("Clean with default style = %q", got)
+	}
+	SetDefault(StylePlain)
+	if got := Clean("✅ ok"); got != "ok" {
+		t.Errorf("Clean with plain style = %q", got)
+	}
+}
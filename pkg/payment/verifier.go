@@ -0,0 +1,204 @@
+// Package payment verifies on chain that tasks were paid for before an
+// agent runs them.
+package payment
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/cache"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/metering"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/types"
+)
+
+// redeemedPrefix is the cache key prefix of transactions that paid for a task
+const redeemedPrefix = "payment:tx:"
+
+// weiPerAmount is the wei in one billionth of a coin, the unit of a metering.Amount
+var weiPerAmount = big.NewInt(1_000_000_000)
+
+// Chain is the part of an Ethereum client the verifier reads, e.g. an
+// nft.RPCPool or an ethclient.Client
+type Chain interface {
+	TransactionByHash(ctx context.Context, hash common.Hash) (*ethtypes.Transaction, bool, error)
+	TransactionReceipt(ctx context.Context, hash common.Hash) (*ethtypes.Receipt, error)
+	HeaderByNumber(ctx context.Context, number *big.Int) (*ethtypes.Header, error)
+}
+
+// Config configures a Verifier
+type Config struct {
+	Chain            Chain
+	Payee            common.Address             // Address tasks are paid to
+	Prices           map[string]metering.Amount // Price per task by capability, in the chain's native coin
+	Default          metering.Amount            // Price of tasks without a priced capability (0 = free)
+	Currency         string                     // Name of the native coin, for rejections
+	MinConfirmations uint64                     // Blocks a payment must be buried under, counting its own (0 = mined is enough)
+	Cache            cache.AgentCache           // Records redeemed transactions, e.g. Redis shared by replicas; kept in process memory when nil or NoOpCache
+	RedeemTTL        time.Duration              // How long redeemed transactions are remembered (0 = forever)
+}
+
+// Proof is the "payment" field of a task's data: the transaction that paid for it
+type Proof struct {
+	TxHash string `json:"tx_hash"`
+}
+
+// Verifier checks that a task was paid for by a native coin transfer to the
+// payee of at least the task's price. Each transaction pays for one task. It
+// implements the types.PaymentVerifier interface.
+type Verifier struct {
+	config *Config
+	cache  cache.AgentCache
+}
+
+// New creates a verifier
+func New(config *Config) (*Verifier, error) {
+	if config == nil || config.Chain == nil {
+		return nil, fmt.Errorf("a chain client is required")
+	}
+	if config.Payee == (common.Address{}) {
+		return nil, fmt.Errorf("a payee address is required")
+	}
+
+	agentCache := config.Cache
+	if agentCache != nil {
+		if _, noop := agentCache.(*cache.NoOpCache); noop {
+			agentCache = nil
+		}
+	}
+	if agentCache == nil {
+		// Unbounded, so that no redeemed transaction is evicted and paid with again
+		agentCache = cache.NewMemoryCache(&cache.MemoryConfig{CleanupInterval: time.Minute})
+	}
+	return &Verifier{config: config, cache: agentCache}, nil
+}
+
+// VerifyPayment checks the payment proof of a task. The task is priced by
+// the capabilities the agent offers, see metering.PriceOffered, and must be
+// paid by its sender. Tasks priced at zero run without a payment.
+func (v *Verifier) VerifyPayment(ctx context.Context, payment types.PaymentInfo) error {
+	_, price := metering.PriceOffered(v.config.Prices, v.config.Default, payment.Capabilities, payment.Offered)
+	if price <= 0 {
+		return nil
+	}
+
+	if len(payment.Proof) == 0 || string(payment.Proof) == "null" {
+		return v.reject(types.ErrPaymentRequired, price, "the task carries no payment")
+	}
+	var proof Proof
+	if err := json.Unmarshal(payment.Proof, &proof); err != nil || !isTxHash(proof.TxHash) {
+		return v.reject(types.ErrPaymentInvalid, price, "the payment must name the transaction hash that paid for the task")
+	}
+	hash := common.HexToHash(proof.TxHash)
+	// Anyone can see a payment on chain; only its sender may redeem it
+	if !common.IsHexAddress(payment.Sender) {
+		return v.reject(types.ErrPaymentInvalid, price, "the task's sender is not a wallet address the payment can be matched to")
+	}
+
+	tx, pending, err := v.config.Chain.TransactionByHash(ctx, hash)
+	if errors.Is(err, ethereum.NotFound) {
+		return v.reject(types.ErrPaymentInvalid, price, "transaction "+hash.Hex()+" was not found")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to fetch payment transaction: %w", err)
+	}
+	if pending {
+		return v.reject(types.ErrPaymentInvalid, price, "transaction "+hash.Hex()+" is not mined yet")
+	}
+	if tx.To() == nil || *tx.To() != v.config.Payee {
+		return v.reject(types.ErrPaymentInvalid, price, "transaction "+hash.Hex()+" does not pay "+v.config.Payee.Hex())
+	}
+	if tx.Value().Cmp(Wei(price)) < 0 {
+		return v.reject(types.ErrPaymentInvalid, price, fmt.Sprintf("transaction %s pays %s wei, less than the price", hash.Hex(), tx.Value()))
+	}
+	from, err := ethtypes.Sender(signerFor(tx), tx)
+	if err != nil || from != common.HexToAddress(payment.Sender) {
+		return v.reject(types.ErrPaymentInvalid, price, "transaction "+hash.Hex()+" was not sent by "+payment.Sender)
+	}
+
+	receipt, err := v.config.Chain.TransactionReceipt(ctx, hash)
+	if err != nil {
+		return fmt.Errorf("failed to fetch payment receipt: %w", err)
+	}
+	if receipt.Status != ethtypes.ReceiptStatusSuccessful {
+		return v.reject(types.ErrPaymentInvalid, price, "transaction "+hash.Hex()+" failed")
+	}
+	if v.config.MinConfirmations > 0 {
+		head, err := v.config.Chain.HeaderByNumber(ctx, nil)
+		if err != nil {
+			return fmt.Errorf("failed to fetch the latest block: %w", err)
+		}
+		// A lagging read endpoint may not have the payment's block yet
+		var confirmations uint64
+		if head.Number.Cmp(receipt.BlockNumber) >= 0 {
+			confirmations = new(big.Int).Sub(head.Number, receipt.BlockNumber).Uint64() + 1
+		}
+		if confirmations < v.config.MinConfirmations {
+			return v.reject(types.ErrPaymentInvalid, price, fmt.Sprintf("transaction %s has %d of %d confirmations", hash.Hex(), confirmations, v.config.MinConfirmations))
+		}
+	}
+
+	return v.redeem(ctx, hash, payment.TaskID, price)
+}
+
+// redeem records that the transaction paid for the task. A transaction that
+// already paid for another task is rejected; the same task, e.g. redelivered
+// after a rejection further on, may present it again.
+func (v *Verifier) redeem(ctx context.Context, hash common.Hash, taskID string, price metering.Amount) error {
+	key := redeemedPrefix + hash.Hex()
+	redeemed, err := v.cache.SetIfNotExists(ctx, key, taskID, v.config.RedeemTTL)
+	if err != nil {
+		return fmt.Errorf("failed to record payment: %w", err)
+	}
+	if redeemed {
+		return nil
+	}
+	if paidTask, err := v.cache.Get(ctx, key); err == nil && paidTask == taskID {
+		return nil
+	}
+	return v.reject(types.ErrPaymentInvalid, price, "transaction "+hash.Hex()+" already paid for another task")
+}
+
+// reject returns a payment error quoting the task's price
+func (v *Verifier) reject(err error, price metering.Amount, reason string) error {
+	return &types.PaymentError{
+		Err:      err,
+		Reason:   reason,
+		Amount:   price.String(),
+		Currency: v.config.Currency,
+		Payee:    v.config.Payee.Hex(),
+	}
+}
+
+// Wei converts an amount of the native coin to wei
+func Wei(amount metering.Amount) *big.Int {
+	return new(big.Int).Mul(big.NewInt(int64(amount)), weiPerAmount)
+}
+
+// signerFor returns the signer that recovers the sender of tx
+func signerFor(tx *ethtypes.Transaction) ethtypes.Signer {
+	if !tx.Protected() {
+		return ethtypes.HomesteadSigner{}
+	}
+	return ethtypes.LatestSignerForChainID(tx.ChainId())
+}
+
+// isTxHash reports whether s is a 0x-prefixed 32-byte hex hash
+func isTxHash(s string) bool {
+	if len(s) != 66 || s[:2] != "0x" && s[:2] != "0X" {
+		return false
+	}
+	for _, c := range s[2:] {
+		if !('0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,161 @@
+package payment
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/metering"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/types"
+)
+
+// fakeChain serves transactions mined in block 10 at head 12
+type fakeChain struct {
+	txs      map[common.Hash]*ethtypes.Transaction
+	receipts map[common.Hash]*ethtypes.Receipt
+	pending  map[common.Hash]bool
+}
+
+func (c *fakeChain) TransactionByHash(ctx context.Context, hash common.Hash) (*ethtypes.Transaction, bool, error) {
+	tx, ok := c.txs[hash]
+	if !ok {
+		return nil, false, ethereum.NotFound
+	}
+	return tx, c.pending[hash], nil
+}
+
+func (c *fakeChain) TransactionReceipt(ctx context.Context, hash common.Hash) (*ethtypes.Receipt, error) {
+	receipt, ok := c.receipts[hash]
+	if !ok {
+		return nil, ethereum.NotFound
+	}
+	return receipt, nil
+}
+
+func (c *fakeChain) HeaderByNumber(ctx context.Context, number *big.Int) (*ethtypes.Header, error) {
+	return &ethtypes.Header{Number: big.NewInt(12)}, nil
+}
+
+func TestVerifyPayment(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sender := crypto.PubkeyToAddress(key.PublicKey).Hex()
+	payee := common.HexToAddress("0x00000000000000000000000000000000000000aa")
+	other := common.HexToAddress("0x00000000000000000000000000000000000000bb")
+
+	chain := &fakeChain{
+		txs:      make(map[common.Hash]*ethtypes.Transaction),
+		receipts: make(map[common.Hash]*ethtypes.Receipt),
+		pending:  make(map[common.Hash]bool),
+	}
+	nonce := uint64(0)
+	pay := func(to common.Address, value *big.Int, status uint64) string {
+		tx, err := ethtypes.SignTx(ethtypes.NewTx(&ethtypes.LegacyTx{
+			Nonce:    nonce,
+			To:       &to,
+			Value:    value,
+			Gas:      21000,
+			GasPrice: big.NewInt(1),
+		}), ethtypes.LatestSignerForChainID(big.NewInt(3338)), key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		nonce++
+		chain.txs[tx.Hash()] = tx
+		chain.receipts[tx.Hash()] = &ethtypes.Receipt{Status: status, BlockNumber: big.NewInt(10)}
+		return tx.Hash().Hex()
+	}
+
+	price, _ := metering.ParseAmount("0.01")
+	offered := []string{"web/scrape@1.2.0", "text/chat"}
+	verifier, err := New(&Config{
+		Chain:            chain,
+		Payee:            payee,
+		Prices:           map[string]metering.Amount{"web/scrape": price},
+		Currency:         "PEAQ",
+		MinConfirmations: 3,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	paid := pay(payee, Wei(price), ethtypes.ReceiptStatusSuccessful)
+	underpaid := pay(payee, big.NewInt(1), ethtypes.ReceiptStatusSuccessful)
+	misdirected := pay(other, Wei(price), ethtypes.ReceiptStatusSuccessful)
+	failed := pay(payee, Wei(price), ethtypes.ReceiptStatusFailed)
+	pending := pay(payee, Wei(price), ethtypes.ReceiptStatusSuccessful)
+	chain.pending[common.HexToHash(pending)] = true
+
+	proof := func(hash string) json.RawMessage {
+		data, _ := json.Marshal(Proof{TxHash: hash})
+		return data
+	}
+	tests := []struct {
+		name    string
+		payment types.PaymentInfo
+		want    error
+	}{
+		{"free task", types.PaymentInfo{TaskID: "t1", Sender: sender, Capabilities: []string{"text/chat"}, Offered: offered}, nil},
+		{"no capability stated", types.PaymentInfo{TaskID: "t1", Sender: sender, Offered: offered}, types.ErrPaymentRequired},
+		{"wildcard requirement", types.PaymentInfo{TaskID: "t1", Sender: sender, Capabilities: []string{"web/*"}, Offered: offered}, types.ErrPaymentRequired},
+		{"version requirement", types.PaymentInfo{TaskID: "t1", Sender: sender, Capabilities: []string{"web/scrape>=1.0.0"}, Offered: offered}, types.ErrPaymentRequired},
+		{"unoffered requirement", types.PaymentInfo{TaskID: "t1", Sender: sender, Capabilities: []string{"image/generate"}, Offered: offered}, types.ErrPaymentRequired},
+		{"priced capabilities without offered ones", types.PaymentInfo{TaskID: "t1", Sender: sender}, types.ErrPaymentRequired},
+		{"no proof", types.PaymentInfo{TaskID: "t2", Sender: sender, Capabilities: []string{"web/scrape"}, Offered: offered}, types.ErrPaymentRequired},
+		{"malformed proof", types.PaymentInfo{TaskID: "t3", Sender: sender, Capabilities: []string{"web/scrape"}, Offered: offered, Proof: proof("0x12")}, types.ErrPaymentInvalid},
+		{"unknown transaction", types.PaymentInfo{TaskID: "t4", Sender: sender, Capabilities: []string{"web/scrape"}, Offered: offered, Proof: proof(common.Hash{1}.Hex())}, types.ErrPaymentInvalid},
+		{"underpaid", types.PaymentInfo{TaskID: "t5", Sender: sender, Capabilities: []string{"web/scrape"}, Offered: offered, Proof: proof(underpaid)}, types.ErrPaymentInvalid},
+		{"other payee", types.PaymentInfo{TaskID: "t6", Sender: sender, Capabilities: []string{"web/scrape"}, Offered: offered, Proof: proof(misdirected)}, types.ErrPaymentInvalid},
+		{"failed transaction", types.PaymentInfo{TaskID: "t7", Sender: sender, Capabilities: []string{"web/scrape"}, Offered: offered, Proof: proof(failed)}, types.ErrPaymentInvalid},
+		{"pending transaction", types.PaymentInfo{TaskID: "t8", Sender: sender, Capabilities: []string{"web/scrape"}, Offered: offered, Proof: proof(pending)}, types.ErrPaymentInvalid},
+		{"sender not a wallet", types.PaymentInfo{TaskID: "t9", Sender: "user-42", Capabilities: []string{"web/scrape"}, Offered: offered, Proof: proof(paid)}, types.ErrPaymentInvalid},
+		{"unknown sender", types.PaymentInfo{TaskID: "t9", Capabilities: []string{"web/scrape"}, Offered: offered, Proof: proof(paid)}, types.ErrPaymentInvalid},
+		{"other sender", types.PaymentInfo{TaskID: "t9", Sender: other.Hex(), Capabilities: []string{"web/scrape"}, Offered: offered, Proof: proof(paid)}, types.ErrPaymentInvalid},
+		{"paid", types.PaymentInfo{TaskID: "t10", Sender: sender, Capabilities: []string{"web/scrape"}, Offered: offered, Proof: proof(paid)}, nil},
+		{"same task again", types.PaymentInfo{TaskID: "t10", Sender: sender, Capabilities: []string{"web/scrape"}, Offered: offered, Proof: proof(paid)}, nil},
+		{"replayed", types.PaymentInfo{TaskID: "t11", Sender: sender, Capabilities: []string{"web/scrape"}, Offered: offered, Proof: proof(paid)}, types.ErrPaymentInvalid},
+	}
+	for _, tt := range tests {
+		err := verifier.VerifyPayment(context.Background(), tt.payment)
+		if tt.want == nil {
+			if err != nil {
+				t.Errorf("%s: unexpected error: %v", tt.name, err)
+			}
+			continue
+		}
+		if !errors.Is(err, tt.want) {
+			t.Errorf("%s: error = %v, want %v", tt.name, err, tt.want)
+			continue
+		}
+		var paymentErr *types.PaymentError
+		if !errors.As(err, &paymentErr) || paymentErr.Amount != "0.01" || paymentErr.Currency != "PEAQ" || paymentErr.Payee != payee.Hex() {
+			t.Errorf("%s: payment error = %+v", tt.name, paymentErr)
+		}
+	}
+
+	// Too few confirmations
+	strict, err := New(&Config{Chain: chain, Payee: payee, Default: price, MinConfirmations: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fresh := pay(payee, Wei(price), ethtypes.ReceiptStatusSuccessful)
+	if err := strict.VerifyPayment(context.Background(), types.PaymentInfo{TaskID: "t12", Sender: sender, Proof: proof(fresh)}); !errors.Is(err, types.ErrPaymentInvalid) {
+		t.Errorf("unconfirmed payment: error = %v, want %v", err, types.ErrPaymentInvalid)
+	}
+}
+
+func TestWei(t *testing.T) {
+	amount, _ := metering.ParseAmount("1.5")
+	if got := Wei(amount).String(); got != "1500000000000000000" {
+		t.Errorf("Wei(1.5) = %s", got)
+	}
+}
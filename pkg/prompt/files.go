@@ -0,0 +1,145 @@
+package prompt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/logging"
+	"github.com/fsnotify/fsnotify"
+)
+
+// Extension is the file extension of prompt templates in a directory
+const Extension = ".tmpl"
+
+// Layout of a prompt directory
+const (
+	DefaultFile     = "default" + Extension // Template of tasks without an override
+	RoomsDir        = "rooms"               // rooms/<room>.tmpl
+	CapabilitiesDir = "capabilities"        // capabilities/<capability>.tmpl, e.g. capabilities/text/summarization.tmpl
+)
+
+// watchDebounce is how long the directory must stay unchanged before it is
+// read again, so writing several files triggers one reload
+const watchDebounce = 500 * time.Millisecond
+
+// LoadDir replaces the library's templates with those in dir:
+//
+//	default.tmpl                          default template (the current one is kept without it)
+//	rooms/<room>.tmpl                     override for a room
+//	capabilities/<capability>.tmpl        override for a capability
+//
+// Files with other extensions are ignored. If a template is invalid the
+// library is left unchanged.
+func (l *Library) LoadDir(dir string) error {
+	loaded := templates{rooms: make(map[string]*Template), capabilities: make(map[string]*Template)}
+
+	text, err := os.ReadFile(filepath.Join(dir, DefaultFile))
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+	case err != nil:
+		return fmt.Errorf("failed to read prompt template: %w", err)
+	default:
+		if loaded.fallback, err = Parse("default", string(text)); err != nil {
+			return err
+		}
+	}
+	if err := loadOverrides(filepath.Join(dir, RoomsDir), kindRoom, loaded.rooms); err != nil {
+		return err
+	}
+	if err := loadOverrides(filepath.Join(dir, CapabilitiesDir), kindCapability, loaded.capabilities); err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if loaded.fallback == nil {
+		loaded.fallback = l.set.fallback
+	}
+	l.set = loaded
+	return nil
+}
+
+// loadOverrides reads the templates below dir, keyed by their path without
+// the extension; a missing dir has none
+func loadOverrides(dir, kind string, overrides map[string]*Template) error {
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() || filepath.Ext(path) != Extension {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		key := strings.TrimSuffix(filepath.ToSlash(rel), Extension)
+		text, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read prompt template: %w", err)
+		}
+		if overrides[key], err = Parse(kind+key, string(text)); err != nil {
+			return err
+		}
+		return nil
+	})
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// Watch reloads the templates from dir whenever a file in it changes, until
+// ctx is done. Invalid templates are logged and leave the library unchanged.
+func (l *Library) Watch(ctx context.Context, dir string) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to watch prompt templates: %w", err)
+	}
+	defer watcher.Close()
+
+	// fsnotify does not watch subdirectories, so every directory is added
+	// now and new ones as they appear
+	addDirs := func() {
+		filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+			if err == nil && entry.IsDir() {
+				if err := watcher.Add(path); err != nil {
+					logging.Warn("failed to watch prompt templates", "path", path, "error", err)
+				}
+			}
+			return nil
+		})
+	}
+	addDirs()
+	logging.Info("watching prompt templates for changes", "dir", dir)
+
+	debounce := time.NewTimer(watchDebounce)
+	debounce.Stop()
+	defer debounce.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event := <-watcher.Events:
+			if event.Has(fsnotify.Create) {
+				addDirs()
+			}
+			debounce.Reset(watchDebounce)
+		case err := <-watcher.Errors:
+			logging.Warn("prompt template watcher error", "error", err)
+		case <-debounce.C:
+			if err := l.LoadDir(dir); err != nil {
+				logging.Error("failed to reload prompt templates", "dir", dir, "error", err)
+				continue
+			}
+			logging.Info("reloaded prompt templates", "dir", dir)
+		}
+	}
+}
@@ -0,0 +1,319 @@
+// Package prompt renders system prompts from Go templates with the agent,
+// room and task they are used for, and picks the template by room or by the
+// capability a task requires, so one agent can behave differently per room.
+package prompt
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/cache"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/logging"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/types"
+)
+
+// ErrNoTemplate is returned when a library has no template for a task
+var ErrNoTemplate = errors.New("no prompt template")
+
+// Vars are the values a template can use, e.g. {{.AgentName}} or
+// {{join .Capabilities ", "}}
+type Vars struct {
+	AgentName         string            // Name of the agent
+	AgentCapabilities []string          // Capabilities the agent advertises
+	Room              string            // Room of the task
+	Sender            string            // Address of the user who sent the task
+	TaskID            string            // ID of the task
+	Capabilities      []string          // Capabilities the task requires
+	Capability        string            // First capability the task requires, "" if none
+	Time              time.Time         // When the prompt is rendered, in UTC
+	Values            map[string]string // Values set with Library.SetValue, e.g. {{.Values.company}}
+}
+
+// funcs are the functions templates can call besides the built-in ones
+var funcs = template.FuncMap{
+	"join":  strings.Join,
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+	"default": func(fallback, value string) string {
+		if value == "" {
+			return fallback
+		}
+		return value
+	},
+}
+
+// Template is a parsed prompt template
+type Template struct {
+	name string
+	text string
+	tmpl *template.Template
+}
+
+// Parse parses a prompt template. Referring to an unknown variable is an error.
+func Parse(name, text string) (*Template, error) {
+	tmpl, err := template.New(name).Funcs(funcs).Option("missingkey=zero").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid prompt template %s: %w", name, err)
+	}
+	// Catch references to unknown variables now rather than on the first task
+	if err := tmpl.Execute(new(bytes.Buffer), Vars{}); err != nil && strings.Contains(err.Error(), "can't evaluate field") {
+		return nil, fmt.Errorf("invalid prompt template %s: %w", name, err)
+	}
+	return &Template{name: name, text: text, tmpl: tmpl}, nil
+}
+
+// Name returns the template's name, e.g. "room:support"
+func (t *Template) Name() string {
+	return t.name
+}
+
+// Text returns the template's source
+func (t *Template) Text() string {
+	return t.text
+}
+
+// Render renders the template with vars
+func (t *Template) Render(vars Vars) (string, error) {
+	var b bytes.Buffer
+	if err := t.tmpl.Execute(&b, vars); err != nil {
+		return "", fmt.Errorf("failed to render prompt %s: %w", t.name, err)
+	}
+	return strings.TrimSpace(b.String()), nil
+}
+
+// templates is a library's set of templates, replaced as a whole on reload
+type templates struct {
+	fallback     *Template
+	rooms        map[string]*Template
+	capabilities map[string]*Template
+}
+
+// Library holds the default prompt template and overrides per room and per
+// capability. The template of a task is its room's, else that of the first
+// capability it requires with one, else the default. All methods are safe
+// for concurrent use, so templates can be replaced while tasks run.
+type Library struct {
+	mu        sync.RWMutex
+	set       templates
+	agentName string
+	agentCaps []string
+	values    map[string]string
+
+	cache    cache.AgentCache // Overrides set by operators, nil = none
+	prefix   string
+	cacheTTL time.Duration
+	cached   map[string]cachedTemplate
+	now      func() time.Time
+}
+
+// cachedTemplate is a template read from the cache, nil if the key is unset
+type cachedTemplate struct {
+	tmpl    *Template
+	expires time.Time
+}
+
+// NewLibrary creates a library without templates
+func NewLibrary() *Library {
+	return &Library{
+		set:    templates{rooms: make(map[string]*Template), capabilities: make(map[string]*Template)},
+		values: make(map[string]string),
+		cached: make(map[string]cachedTemplate),
+		now:    time.Now,
+	}
+}
+
+// SetDefault sets the template of tasks without an override; empty text removes it
+func (l *Library) SetDefault(text string) error {
+	var tmpl *Template
+	if text != "" {
+		var err error
+		if tmpl, err = Parse("default", text); err != nil {
+			return err
+		}
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.set.fallback = tmpl
+	return nil
+}
+
+// HasDefault reports whether the library has a default template
+func (l *Library) HasDefault() bool {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.set.fallback != nil
+}
+
+// SetRoom sets the template of tasks in a room; empty text removes it
+func (l *Library) SetRoom(room, text string) error {
+	return l.setOverride(kindRoom, room, text)
+}
+
+// SetCapability sets the template of tasks requiring a capability; empty text removes it
+func (l *Library) SetCapability(capability, text string) error {
+	return l.setOverride(kindCapability, capability, text)
+}
+
+// Kinds of overrides, prefixing their template names and cache keys
+const (
+	kindRoom       = "room:"
+	kindCapability = "capability:"
+)
+
+// setOverride parses and stores an override, or removes it for empty text
+func (l *Library) setOverride(kind, key, text string) error {
+	var tmpl *Template
+	if text != "" {
+		var err error
+		if tmpl, err = Parse(kind+key, text); err != nil {
+			return err
+		}
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	overrides := l.set.capabilities
+	if kind == kindRoom {
+		overrides = l.set.rooms
+	}
+	if tmpl == nil {
+		delete(overrides, key)
+	} else {
+		overrides[key] = tmpl
+	}
+	return nil
+}
+
+// SetAgent sets the agent's name and capabilities for the templates
+func (l *Library) SetAgent(name string, capabilities []string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.agentName = name
+	l.agentCaps = slices.Clone(capabilities)
+}
+
+// SetValue sets a value templates read as {{.Values.key}}
+func (l *Library) SetValue(key, value string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.values[key] = value
+}
+
+// SetCache makes the library look up overrides in the agent cache before its
+// own: keys prefix+"room:<room>" and prefix+"capability:<capability>". Lookups
+// are remembered for ttl, so a changed key applies within ttl.
+func (l *Library) SetCache(c cache.AgentCache, prefix string, ttl time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.cache, l.prefix, l.cacheTTL = c, prefix, ttl
+	l.cached = make(map[string]cachedTemplate)
+}
+
+// Resolve returns the template for a task in room requiring capabilities
+func (l *Library) Resolve(ctx context.Context, room string, capabilities []string) (*Template, error) {
+	if room != "" {
+		if tmpl := l.override(ctx, kindRoom, room); tmpl != nil {
+			return tmpl, nil
+		}
+	}
+	for _, capability := range capabilities {
+		if tmpl := l.override(ctx, kindCapability, capability); tmpl != nil {
+			return tmpl, nil
+		}
+	}
+
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	if l.set.fallback == nil {
+		return nil, ErrNoTemplate
+	}
+	return l.set.fallback, nil
+}
+
+// override returns the cached or local override of a room or capability
+func (l *Library) override(ctx context.Context, kind, key string) *Template {
+	if tmpl := l.cachedOverride(ctx, kind+key); tmpl != nil {
+		return tmpl
+	}
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	if kind == kindRoom {
+		return l.set.rooms[key]
+	}
+	return l.set.capabilities[key]
+}
+
+// cachedOverride reads an override from the agent cache, nil if none
+func (l *Library) cachedOverride(ctx context.Context, name string) *Template {
+	l.mu.RLock()
+	c, prefix := l.cache, l.prefix
+	entry, ok := l.cached[name]
+	l.mu.RUnlock()
+	if c == nil {
+		return nil
+	}
+	now := l.now()
+	if ok && now.Before(entry.expires) {
+		return entry.tmpl
+	}
+
+	var tmpl *Template
+	text, err := c.Get(ctx, prefix+name)
+	switch {
+	case errors.Is(err, cache.ErrCacheKeyNotFound):
+	case err != nil:
+		// Keep using what was read before while the cache is unavailable
+		logging.Warn("failed to read prompt override from cache", "key", prefix+name, "error", err)
+		return entry.tmpl
+	case text != "":
+		if tmpl, err = Parse(name, text); err != nil {
+			logging.Warn("ignoring invalid prompt override in cache", "key", prefix+name, "error", err)
+		}
+	}
+
+	l.mu.Lock()
+	l.cached[name] = cachedTemplate{tmpl: tmpl, expires: now.Add(l.cacheTTL)}
+	l.mu.Unlock()
+	return tmpl
+}
+
+// Vars returns the variables of the current task
+func (l *Library) Vars(ctx context.Context) Vars {
+	info, _ := types.TaskInfoFromContext(ctx)
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	vars := Vars{
+		AgentName:         l.agentName,
+		AgentCapabilities: l.agentCaps,
+		Room:              info.Room,
+		Sender:            info.Sender,
+		TaskID:            info.ID,
+		Capabilities:      info.Capabilities,
+		Time:              l.now().UTC(),
+		Values:            make(map[string]string, len(l.values)),
+	}
+	if len(info.Capabilities) > 0 {
+		vars.Capability = info.Capabilities[0]
+	}
+	for key, value := range l.values {
+		vars.Values[key] = value
+	}
+	return vars
+}
+
+// Render renders the prompt of the current task, using the task info the
+// SDK attaches to the context
+func (l *Library) Render(ctx context.Context) (string, error) {
+	vars := l.Vars(ctx)
+	tmpl, err := l.Resolve(ctx, vars.Room, vars.Capabilities)
+	if err != nil {
+		return "", err
+	}
+	return tmpl.Render(vars)
+}
@@ -0,0 +1,127 @@
+package prompt
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/cache"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/types"
+)
+
+func TestLibraryRender(t *testing.T) {
+	lib := NewLibrary()
+	lib.SetAgent("Helper", []string{"chat", "text/summarization"})
+	lib.SetValue("company", "Acme")
+	if err := lib.SetDefault(`You are {{.AgentName}} of {{.Values.company}}, able to {{join .AgentCapabilities ", "}}.`); err != nil {
+		t.Fatal(err)
+	}
+	if err := lib.SetRoom("support", `Support agent for room {{.Room}}, asked by {{.Sender | default "someone"}}.`); err != nil {
+		t.Fatal(err)
+	}
+	if err := lib.SetCapability("text/summarization", `Summarize ({{.Capability}}).`); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		info types.TaskInfo
+		want string
+	}{
+		{"default", types.TaskInfo{Room: "general"}, "You are Helper of Acme, able to chat, text/summarization."},
+		{"room override", types.TaskInfo{Room: "support", Capabilities: []string{"text/summarization"}}, "Support agent for room support, asked by someone."},
+		{"capability override", types.TaskInfo{Room: "general", Capabilities: []string{"web", "text/summarization"}}, "Summarize (web)."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := lib.Render(types.WithTaskInfo(context.Background(), tt.info))
+			if err != nil || got != tt.want {
+				t.Errorf("Render = %q, %v; want %q", got, err, tt.want)
+			}
+		})
+	}
+
+	if err := lib.SetDefault(`{{.Unknown}}`); err == nil {
+		t.Error("expected an unknown variable to be rejected")
+	}
+	if _, err := NewLibrary().Render(context.Background()); err != ErrNoTemplate {
+		t.Errorf("expected ErrNoTemplate, got %v", err)
+	}
+}
+
+func TestLibraryCacheOverride(t *testing.T) {
+	ctx := context.Background()
+	c := cache.NewMemoryCache(cache.DefaultMemoryConfig())
+	lib := NewLibrary()
+	lib.SetDefault("default")
+	now := time.Now()
+	lib.now = func() time.Time { return now }
+	lib.SetCache(c, "prompts:", time.Minute)
+
+	task := types.WithTaskInfo(ctx, types.TaskInfo{Room: "vip"})
+	if got, _ := lib.Render(task); got != "default" {
+		t.Errorf("without override = %q", got)
+	}
+	c.Set(ctx, "prompts:room:vip", "VIP room {{.Room}}", 0)
+	if got, _ := lib.Render(task); got != "default" {
+		t.Errorf("expected the lookup to be remembered, got %q", got)
+	}
+	now = now.Add(2 * time.Minute)
+	if got, _ := lib.Render(task); got != "VIP room vip" {
+		t.Errorf("with override = %q", got)
+	}
+}
+
+func TestLoadDir(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, text string) {
+		path := filepath.Join(dir, name)
+		os.MkdirAll(filepath.Dir(path), 0o755)
+		if err := os.WriteFile(path, []byte(text), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(DefaultFile, "default prompt")
+	write("rooms/support.tmpl", "support prompt")
+	write("capabilities/text/summarization.tmpl", "summary prompt")
+	write("rooms/notes.txt", "ignored")
+
+	lib := NewLibrary()
+	if err := lib.LoadDir(dir); err != nil {
+		t.Fatal(err)
+	}
+	render := func(info types.TaskInfo) string {
+		got, err := lib.Render(types.WithTaskInfo(context.Background(), info))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return got
+	}
+	if got := render(types.TaskInfo{Room: "support"}); got != "support prompt" {
+		t.Errorf("room = %q", got)
+	}
+	if got := render(types.TaskInfo{Capabilities: []string{"text/summarization"}}); got != "summary prompt" {
+		t.Errorf("capability = %q", got)
+	}
+
+	// An invalid template leaves the library unchanged
+	write("rooms/support.tmpl", "{{.Nope}}")
+	if err := lib.LoadDir(dir); err == nil || !strings.Contains(err.Error(), "room:support") {
+		t.Errorf("expected an error naming the template, got %v", err)
+	}
+	if got := render(types.TaskInfo{Room: "support"}); got != "support prompt" {
+		t.Errorf("room after failed reload = %q", got)
+	}
+
+	// Removed overrides are dropped on reload
+	os.Remove(filepath.Join(dir, "rooms/support.tmpl"))
+	if err := lib.LoadDir(dir); err != nil {
+		t.Fatal(err)
+	}
+	if got := render(types.TaskInfo{Room: "support"}); got != "default prompt" {
+		t.Errorf("room after removal = %q", got)
+	}
+}
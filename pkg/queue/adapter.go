@@ -0,0 +1,215 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/logging"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/types"
+)
+
+// receiveRetryDelay is how long a worker waits after a failed receive
+const receiveRetryDelay = time.Second
+
+// Config holds the configuration of a queue adapter
+type Config struct {
+	Consumer  Consumer
+	Publisher Publisher // nil = responses are discarded
+
+	// ResultSubject receives the responses to tasks without a reply-to
+	// (a NATS subject, RabbitMQ routing key or Kafka topic)
+	ResultSubject string
+
+	Concurrency int    // Tasks handled at once (default 1)
+	Room        string // Room of tasks that name none (default "queue")
+}
+
+// Stats counts the deliveries handled by an adapter
+type Stats struct {
+	Received      int64 `json:"received"`
+	Succeeded     int64 `json:"succeeded"`
+	Failed        int64 `json:"failed"`  // Handler errors and rejected tasks
+	Invalid       int64 `json:"invalid"` // Deliveries that are not valid tasks
+	PublishErrors int64 `json:"publish_errors"`
+}
+
+// Adapter consumes tasks from a broker, runs them through the agent's task
+// pipeline and publishes the responses
+type Adapter struct {
+	runner TaskRunner
+	config Config
+
+	received      atomic.Int64
+	succeeded     atomic.Int64
+	failed        atomic.Int64
+	invalid       atomic.Int64
+	publishErrors atomic.Int64
+}
+
+// NewAdapter creates an adapter feeding the consumer's tasks to runner
+func NewAdapter(runner TaskRunner, config *Config) (*Adapter, error) {
+	if runner == nil {
+		return nil, fmt.Errorf("task runner is required")
+	}
+	if config == nil || config.Consumer == nil {
+		return nil, fmt.Errorf("consumer is required")
+	}
+
+	adapter := &Adapter{runner: runner, config: *config}
+	if adapter.config.Concurrency <= 0 {
+		adapter.config.Concurrency = 1
+	}
+	if adapter.config.Room == "" {
+		adapter.config.Room = "queue"
+	}
+	return adapter, nil
+}
+
+// Run handles deliveries until ctx is done or the consumer is closed.
+// A delivery is acknowledged once its task finished and all responses were
+// published; if publishing failed it is given back to be delivered again.
+func (a *Adapter) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for i := 0; i < a.config.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			a.work(ctx)
+		}()
+	}
+	wg.Wait()
+}
+
+// work receives and handles deliveries one at a time
+func (a *Adapter) work(ctx context.Context) {
+	for {
+		delivery, err := a.config.Consumer.Receive(ctx)
+		switch {
+		case ctx.Err() != nil:
+			if delivery != nil {
+				a.settle(ctx, delivery, false, true)
+			}
+			return
+		case errors.Is(err, ErrClosed):
+			return
+		case err != nil:
+			logging.Warn("failed to receive queued task", "error", err)
+			select {
+			case <-time.After(receiveRetryDelay):
+				continue
+			case <-ctx.Done():
+				return
+			}
+		}
+
+		a.handle(ctx, delivery)
+	}
+}
+
+// handle runs one delivery's task and acknowledges it
+func (a *Adapter) handle(ctx context.Context, delivery *Delivery) {
+	a.received.Add(1)
+
+	msg, err := DecodeTask(delivery, a.config.Room)
+	if err != nil {
+		logging.Warn("discarding invalid queued task", "message_id", delivery.ID, "error", err)
+		a.invalid.Add(1)
+		a.settle(ctx, delivery, false, false)
+		return
+	}
+
+	var publishFailed atomic.Bool
+	respond := func(ctx context.Context, response *types.Message) error {
+		if err := a.publish(ctx, delivery, response); err != nil {
+			a.publishErrors.Add(1)
+			publishFailed.Store(true)
+			return err
+		}
+		return nil
+	}
+
+	status := a.runner.RunTask(ctx, msg, respond)
+	logging.Debug("queued task finished", "message_id", delivery.ID, "status", status)
+	if status == "success" {
+		a.succeeded.Add(1)
+	} else {
+		a.failed.Add(1)
+	}
+
+	failed := publishFailed.Load()
+	a.settle(ctx, delivery, !failed, failed)
+}
+
+// settle acknowledges a handled delivery. A delivery that was not handled is
+// given back to the broker, to be delivered again if requeue is set.
+func (a *Adapter) settle(ctx context.Context, delivery *Delivery, handled, requeue bool) {
+	ctx = context.WithoutCancel(ctx)
+	var err error
+	switch {
+	case handled && delivery.Ack != nil:
+		err = delivery.Ack(ctx)
+	case !handled && delivery.Nack != nil:
+		err = delivery.Nack(ctx, requeue)
+	}
+	if err != nil {
+		logging.Warn("failed to acknowledge queued task", "message_id", delivery.ID, "handled", handled, "error", err)
+	}
+}
+
+// publish sends a response to the delivery's reply-to or the result subject
+func (a *Adapter) publish(ctx context.Context, delivery *Delivery, response *types.Message) error {
+	subject := delivery.ReplyTo
+	if subject == "" {
+		subject = a.config.ResultSubject
+	}
+	if a.config.Publisher == nil || subject == "" {
+		return nil
+	}
+
+	body, err := json.Marshal(response)
+	if err != nil {
+		return fmt.Errorf("failed to marshal task response: %w", err)
+	}
+
+	result := &Result{
+		Subject:       subject,
+		TaskID:        response.TaskID,
+		CorrelationID: delivery.CorrelationID,
+		Body:          body,
+		Headers: map[string]string{
+			"task_id":      response.TaskID,
+			"content_type": response.ContentType,
+		},
+	}
+	if err := a.config.Publisher.Publish(ctx, result); err != nil {
+		return fmt.Errorf("failed to publish task response: %w", err)
+	}
+	return nil
+}
+
+// Stats returns the number of deliveries handled so far
+func (a *Adapter) Stats() Stats {
+	return Stats{
+		Received:      a.received.Load(),
+		Succeeded:     a.succeeded.Load(),
+		Failed:        a.failed.Load(),
+		Invalid:       a.invalid.Load(),
+		PublishErrors: a.publishErrors.Load(),
+	}
+}
+
+// Close closes the consumer and the publisher
+func (a *Adapter) Close() error {
+	err := a.config.Consumer.Close()
+	if a.config.Publisher != nil {
+		if pubErr := a.config.Publisher.Close(); err == nil {
+			err = pubErr
+		}
+	}
+	return err
+}
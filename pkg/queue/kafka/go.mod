@@ -0,0 +1,15 @@
+module github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/queue/kafka
+
+go 1.24.0
+
+require (
+	github.com/TeneoProtocolAI/teneo-agent-sdk v0.0.0-00010101000000-000000000000
+	github.com/segmentio/kafka-go v0.4.47
+)
+
+require (
+	github.com/klauspost/compress v1.17.0 // indirect
+	github.com/pierrec/lz4/v4 v4.1.15 // indirect
+)
+
+replace github.com/TeneoProtocolAI/teneo-agent-sdk => ../../../
@@ -0,0 +1,157 @@
+// Package kafka feeds tasks from a Kafka topic to a Teneo agent and publishes
+// the responses to a result topic. Agents in the same consumer group share the
+// topic's partitions. A task's offset is committed once it was handled and its
+// responses were written; tasks whose responses could not be written are
+// delivered again after a restart or rebalance.
+//
+//	q, err := kafka.Connect(&kafka.Config{Brokers: []string{"localhost:9092"}, Topic: "agent-tasks"})
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	enhancedAgent.ServeQueue(&queue.Config{Consumer: q, Publisher: q, ResultSubject: "agent-results"})
+//
+// Offsets are committed per partition, so with a Concurrency above 1 a crash
+// can skip tasks that were still running while later ones were committed.
+// Tasks carry their reply topic and correlation ID in the reply_to and
+// correlation_id headers.
+package kafka
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/queue"
+	kafkago "github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/sasl"
+)
+
+// DefaultGroupID is the consumer group used when none is configured
+const DefaultGroupID = "teneo-agents"
+
+// Headers carrying the reply topic and correlation ID of a task
+const (
+	HeaderReplyTo       = "reply_to"
+	HeaderCorrelationID = "correlation_id"
+)
+
+// Config holds the Kafka connection settings
+type Config struct {
+	Brokers []string       // e.g. []string{"localhost:9092"}
+	Topic   string         // Topic tasks are read from
+	GroupID string         // Agents in the same group share partitions (default DefaultGroupID)
+	TLS     *tls.Config    // Optional
+	SASL    sasl.Mechanism // Optional, e.g. plain.Mechanism or scram.Mechanism
+}
+
+// Queue reads tasks from and writes responses to Kafka.
+// It implements queue.Consumer and queue.Publisher.
+type Queue struct {
+	reader    *kafkago.Reader
+	writer    *kafkago.Writer
+	closeOnce sync.Once
+	closeErr  error
+}
+
+// Connect creates a consumer group reader for the task topic and a writer for responses
+func Connect(config *Config) (*Queue, error) {
+	if len(config.Brokers) == 0 {
+		return nil, fmt.Errorf("at least one broker is required")
+	}
+	if config.Topic == "" {
+		return nil, fmt.Errorf("topic is required")
+	}
+	groupID := config.GroupID
+	if groupID == "" {
+		groupID = DefaultGroupID
+	}
+
+	reader := kafkago.NewReader(kafkago.ReaderConfig{
+		Brokers: config.Brokers,
+		GroupID: groupID,
+		Topic:   config.Topic,
+		Dialer: &kafkago.Dialer{
+			Timeout:       10 * time.Second,
+			DualStack:     true,
+			TLS:           config.TLS,
+			SASLMechanism: config.SASL,
+		},
+	})
+	writer := &kafkago.Writer{
+		Addr:         kafkago.TCP(config.Brokers...),
+		Balancer:     &kafkago.Hash{},
+		RequiredAcks: kafkago.RequireAll,
+		Transport: &kafkago.Transport{
+			TLS:  config.TLS,
+			SASL: config.SASL,
+		},
+	}
+	return &Queue{reader: reader, writer: writer}, nil
+}
+
+// Receive implements queue.Consumer
+func (q *Queue) Receive(ctx context.Context) (*queue.Delivery, error) {
+	msg, err := q.reader.FetchMessage(ctx)
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, queue.ErrClosed
+		}
+		return nil, err
+	}
+
+	delivery := &queue.Delivery{
+		// Stable across redeliveries, so a task handled before a crash is recognized
+		ID:   fmt.Sprintf("%s-%d-%d", msg.Topic, msg.Partition, msg.Offset),
+		Body: msg.Value,
+		Ack: func(ctx context.Context) error {
+			return q.reader.CommitMessages(ctx, msg)
+		},
+	}
+	for _, header := range msg.Headers {
+		switch header.Key {
+		case HeaderReplyTo:
+			delivery.ReplyTo = string(header.Value)
+		case HeaderCorrelationID:
+			delivery.CorrelationID = string(header.Value)
+		default:
+			if delivery.Headers == nil {
+				delivery.Headers = make(map[string]string)
+			}
+			delivery.Headers[header.Key] = string(header.Value)
+		}
+	}
+	return delivery, nil
+}
+
+// Publish implements queue.Publisher. Responses are keyed by task ID, so all
+// responses to a task land on the same partition in order.
+func (q *Queue) Publish(ctx context.Context, result *queue.Result) error {
+	msg := kafkago.Message{
+		Topic: result.Subject,
+		Key:   []byte(result.TaskID),
+		Value: result.Body,
+	}
+	for key, value := range result.Headers {
+		msg.Headers = append(msg.Headers, kafkago.Header{Key: key, Value: []byte(value)})
+	}
+	if result.CorrelationID != "" {
+		msg.Headers = append(msg.Headers, kafkago.Header{Key: HeaderCorrelationID, Value: []byte(result.CorrelationID)})
+	}
+	return q.writer.WriteMessages(ctx, msg)
+}
+
+// Close closes the reader, leaving the consumer group, and flushes the writer
+func (q *Queue) Close() error {
+	q.closeOnce.Do(func() {
+		readErr := q.reader.Close()
+		q.closeErr = q.writer.Close()
+		if readErr != nil {
+			q.closeErr = readErr
+		}
+	})
+	return q.closeErr
+}
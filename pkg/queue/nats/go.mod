@@ -0,0 +1,18 @@
+module github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/queue/nats
+
+go 1.24.0
+
+require (
+	github.com/TeneoProtocolAI/teneo-agent-sdk v0.0.0-00010101000000-000000000000
+	github.com/nats-io/nats.go v1.37.0
+)
+
+require (
+	github.com/klauspost/compress v1.17.2 // indirect
+	github.com/nats-io/nkeys v0.4.7 // indirect
+	github.com/nats-io/nuid v1.0.1 // indirect
+	golang.org/x/crypto v0.43.0 // indirect
+	golang.org/x/sys v0.37.0 // indirect
+)
+
+replace github.com/TeneoProtocolAI/teneo-agent-sdk => ../../../
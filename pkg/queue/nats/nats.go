@@ -0,0 +1,115 @@
+// Package nats feeds tasks from NATS to a Teneo agent. Tasks are received on a
+// subject through a queue group, so agent instances in the same group share
+// the load, and responses are published to the request's reply inbox or to
+// the adapter's result subject.
+//
+//	q, err := nats.Connect(&nats.Config{URL: "nats://localhost:4222", Subject: "tasks.summarize"})
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	enhancedAgent.ServeQueue(&queue.Config{Consumer: q, Publisher: q, ResultSubject: "results.summarize"})
+//
+// Core NATS does not acknowledge messages: a task is lost if the agent stops
+// while handling it.
+package nats
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/queue"
+	natsgo "github.com/nats-io/nats.go"
+)
+
+// DefaultQueueGroup is the queue group used when none is configured
+const DefaultQueueGroup = "teneo-agents"
+
+// Config holds the NATS connection settings
+type Config struct {
+	URL        string          // Server URL (default "nats://127.0.0.1:4222")
+	Subject    string          // Subject tasks are published to
+	QueueGroup string          // Agents in the same group share tasks (default DefaultQueueGroup)
+	Options    []natsgo.Option // Credentials, TLS and reconnect behavior
+}
+
+// Queue receives tasks from and publishes responses to NATS.
+// It implements queue.Consumer and queue.Publisher.
+type Queue struct {
+	conn      *natsgo.Conn
+	sub       *natsgo.Subscription
+	closeOnce sync.Once
+	closeErr  error
+}
+
+// Connect connects to NATS and subscribes to the task subject
+func Connect(config *Config) (*Queue, error) {
+	if config.Subject == "" {
+		return nil, fmt.Errorf("subject is required")
+	}
+	url := config.URL
+	if url == "" {
+		url = natsgo.DefaultURL
+	}
+	group := config.QueueGroup
+	if group == "" {
+		group = DefaultQueueGroup
+	}
+
+	conn, err := natsgo.Connect(url, config.Options...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to NATS: %w", err)
+	}
+	sub, err := conn.QueueSubscribeSync(config.Subject, group)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to subscribe to %s: %w", config.Subject, err)
+	}
+	return &Queue{conn: conn, sub: sub}, nil
+}
+
+// Receive implements queue.Consumer
+func (q *Queue) Receive(ctx context.Context) (*queue.Delivery, error) {
+	msg, err := q.sub.NextMsgWithContext(ctx)
+	if err != nil {
+		if q.conn.IsClosed() || q.conn.IsDraining() || !q.sub.IsValid() {
+			return nil, queue.ErrClosed
+		}
+		return nil, err
+	}
+
+	delivery := &queue.Delivery{
+		ID:            msg.Header.Get(natsgo.MsgIdHdr),
+		Body:          msg.Data,
+		ReplyTo:       msg.Reply,
+		CorrelationID: msg.Header.Get("Correlation-Id"),
+	}
+	if len(msg.Header) > 0 {
+		delivery.Headers = make(map[string]string, len(msg.Header))
+		for key := range msg.Header {
+			delivery.Headers[key] = msg.Header.Get(key)
+		}
+	}
+	return delivery, nil
+}
+
+// Publish implements queue.Publisher
+func (q *Queue) Publish(ctx context.Context, result *queue.Result) error {
+	msg := natsgo.NewMsg(result.Subject)
+	msg.Data = result.Body
+	for key, value := range result.Headers {
+		msg.Header.Set(key, value)
+	}
+	if result.CorrelationID != "" {
+		msg.Header.Set("Correlation-Id", result.CorrelationID)
+	}
+	return q.conn.PublishMsg(msg)
+}
+
+// Close stops receiving tasks, flushes pending responses and closes the connection
+func (q *Queue) Close() error {
+	q.closeOnce.Do(func() {
+		q.closeErr = q.conn.Drain()
+	})
+	return q.closeErr
+}
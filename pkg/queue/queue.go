@@ -0,0 +1,135 @@
+// Package queue feeds tasks from message brokers such as NATS, RabbitMQ or
+// Kafka into an agent's task pipeline and publishes the responses back, so
+// existing queues can drive Teneo agents alongside the network connection.
+//
+// Tasks from a queue pass through the same checks as network tasks (required
+// capabilities, deduplication, rate limit, quotas, guards, review) and are
+// handled by the same agent handler.
+//
+// A broker client implements Consumer and Publisher. Adapters for NATS,
+// RabbitMQ and Kafka are in the nats, rabbitmq and kafka directories; each is
+// a separate module so the SDK itself does not depend on the broker clients.
+package queue
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/types"
+)
+
+// ErrClosed is returned by Consumer.Receive once the consumer is closed
+var ErrClosed = errors.New("queue closed")
+
+// Delivery is a task message received from a broker
+type Delivery struct {
+	ID            string            // Broker message ID, used as the task ID when the task carries none
+	Body          []byte            // JSON task (see DecodeTask) or plain text
+	ReplyTo       string            // Where responses are published (empty = Config.ResultSubject)
+	CorrelationID string            // Copied to every response
+	Headers       map[string]string // Added to the task metadata, e.g. traceparent
+
+	// Ack confirms the task was handled. Nack gives it back to the broker,
+	// to be delivered again if requeue is set. Either may be nil for brokers
+	// without acknowledgements.
+	Ack  func(ctx context.Context) error
+	Nack func(ctx context.Context, requeue bool) error
+}
+
+// Result is a task response to publish
+type Result struct {
+	Subject       string            // Delivery.ReplyTo or Config.ResultSubject
+	TaskID        string            // Suitable as message key
+	CorrelationID string            // Delivery.CorrelationID
+	Body          []byte            // JSON-encoded types.Message of type task_response
+	Headers       map[string]string // task_id and content_type
+}
+
+// Consumer receives task messages from a broker
+type Consumer interface {
+	// Receive blocks until the next delivery is available. It is called from
+	// several goroutines at once and returns ErrClosed once the consumer is closed.
+	Receive(ctx context.Context) (*Delivery, error)
+
+	Close() error
+}
+
+// Publisher sends task responses to a broker
+type Publisher interface {
+	Publish(ctx context.Context, result *Result) error
+	Close() error
+}
+
+// TaskRunner runs a task through the agent's task pipeline and returns once it
+// is done, passing every response to respond. It returns the task status.
+// *network.TaskCoordinator implements it.
+type TaskRunner interface {
+	RunTask(ctx context.Context, msg *types.Message, respond func(context.Context, *types.Message) error) string
+}
+
+// DecodeTask converts a delivery into a task message. The body is either a
+// JSON task in the network message format, e.g.
+//
+//	{"content": "summarize ...", "room": "support", "data": {"task_id": "42", "required_capabilities": ["text/summarization"]}}
+//
+// or plain text used as the task content. Tasks without an ID in their data
+// get the task_id field or the delivery ID, so redelivered messages are
+// recognized as duplicates. Tasks without a room get defaultRoom.
+func DecodeTask(delivery *Delivery, defaultRoom string) (*types.Message, error) {
+	body := bytes.TrimSpace(delivery.Body)
+	if len(body) == 0 {
+		return nil, fmt.Errorf("empty task")
+	}
+
+	msg := &types.Message{}
+	if body[0] == '{' {
+		if err := json.Unmarshal(body, msg); err != nil {
+			return nil, fmt.Errorf("invalid task: %w", err)
+		}
+		if msg.Content == "" {
+			return nil, fmt.Errorf("task has no content")
+		}
+	} else {
+		msg.Content = string(body)
+	}
+
+	if msg.Type == "" {
+		msg.Type = types.MessageTypeTask
+	}
+	if msg.Room == "" {
+		msg.Room = defaultRoom
+	}
+	for key, value := range delivery.Headers {
+		if _, ok := msg.Metadata[key]; ok {
+			continue
+		}
+		if msg.Metadata == nil {
+			msg.Metadata = make(map[string]string)
+		}
+		msg.Metadata[key] = value
+	}
+
+	data := make(map[string]interface{})
+	if len(msg.Data) > 0 {
+		if err := json.Unmarshal(msg.Data, &data); err != nil {
+			return nil, fmt.Errorf("invalid task data: %w", err)
+		}
+	}
+	if id, _ := data["task_id"].(string); id == "" {
+		if msg.TaskID == "" {
+			msg.TaskID = delivery.ID
+		}
+		if msg.TaskID != "" {
+			data["task_id"] = msg.TaskID
+		}
+	}
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode task data: %w", err)
+	}
+	msg.Data = encoded
+	return msg, nil
+}
@@ -0,0 +1,209 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/types"
+)
+
+// chanConsumer delivers from a channel and reports ErrClosed once it is drained
+type chanConsumer struct {
+	deliveries chan *Delivery
+}
+
+func (c *chanConsumer) Receive(ctx context.Context) (*Delivery, error) {
+	select {
+	case d, ok := <-c.deliveries:
+		if !ok {
+			return nil, ErrClosed
+		}
+		return d, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (c *chanConsumer) Close() error { return nil }
+
+type recordingPublisher struct {
+	mu      sync.Mutex
+	results []*Result
+	err     error
+}
+
+func (p *recordingPublisher) Publish(ctx context.Context, result *Result) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.err != nil {
+		return p.err
+	}
+	p.results = append(p.results, result)
+	return nil
+}
+
+func (p *recordingPublisher) Close() error { return nil }
+
+// echoRunner answers every task with its content
+type echoRunner struct {
+	mu    sync.Mutex
+	tasks []*types.Message
+}
+
+func (r *echoRunner) RunTask(ctx context.Context, msg *types.Message, respond func(context.Context, *types.Message) error) string {
+	r.mu.Lock()
+	r.tasks = append(r.tasks, msg)
+	r.mu.Unlock()
+
+	var data struct {
+		TaskID string `json:"task_id"`
+	}
+	json.Unmarshal(msg.Data, &data)
+	respond(ctx, &types.Message{Type: types.MessageTypeTaskResponse, TaskID: data.TaskID, Content: "echo: " + msg.Content, ContentType: "STRING"})
+	return "success"
+}
+
+// settlement records how a delivery was acknowledged
+type settlement struct {
+	mu      sync.Mutex
+	acked   bool
+	nacked  bool
+	requeue bool
+}
+
+func (s *settlement) delivery(id string, body string) *Delivery {
+	return &Delivery{
+		ID:   id,
+		Body: []byte(body),
+		Ack: func(ctx context.Context) error {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			s.acked = true
+			return nil
+		},
+		Nack: func(ctx context.Context, requeue bool) error {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			s.nacked, s.requeue = true, requeue
+			return nil
+		},
+	}
+}
+
+func runAdapter(t *testing.T, publisher *recordingPublisher, deliveries ...*Delivery) (*Adapter, *echoRunner) {
+	t.Helper()
+	consumer := &chanConsumer{deliveries: make(chan *Delivery, len(deliveries))}
+	for _, d := range deliveries {
+		consumer.deliveries <- d
+	}
+	close(consumer.deliveries)
+
+	runner := &echoRunner{}
+	adapter, err := NewAdapter(runner, &Config{Consumer: consumer, Publisher: publisher, ResultSubject: "results", Concurrency: 2})
+	if err != nil {
+		t.Fatalf("NewAdapter failed: %v", err)
+	}
+	adapter.Run(context.Background())
+	return adapter, runner
+}
+
+func TestDecodeTask(t *testing.T) {
+	msg, err := DecodeTask(&Delivery{
+		ID:      "msg-1",
+		Body:    []byte(`{"content":"summarize this","room":"support","data":{"required_capabilities":["text/summarization"]}}`),
+		Headers: map[string]string{"traceparent": "00-abc-def-01"},
+	}, "queue")
+	if err != nil {
+		t.Fatalf("DecodeTask failed: %v", err)
+	}
+	if msg.Type != types.MessageTypeTask || msg.Content != "summarize this" || msg.Room != "support" {
+		t.Errorf("unexpected task: %+v", msg)
+	}
+	if msg.Metadata["traceparent"] != "00-abc-def-01" {
+		t.Errorf("headers not copied to metadata: %v", msg.Metadata)
+	}
+
+	var data map[string]interface{}
+	json.Unmarshal(msg.Data, &data)
+	if data["task_id"] != "msg-1" || data["required_capabilities"] == nil {
+		t.Errorf("unexpected task data: %s", msg.Data)
+	}
+
+	plain, err := DecodeTask(&Delivery{Body: []byte("hello")}, "queue")
+	if err != nil || plain.Content != "hello" || plain.Room != "queue" {
+		t.Errorf("plain text task = %+v, %v", plain, err)
+	}
+
+	for _, body := range []string{"", "  ", `{"room":"support"}`, `{"content":"x","data":[1]}`} {
+		if _, err := DecodeTask(&Delivery{Body: []byte(body)}, "queue"); err == nil {
+			t.Errorf("DecodeTask(%q) should fail", body)
+		}
+	}
+}
+
+func TestAdapterPublishesAndAcks(t *testing.T) {
+	publisher := &recordingPublisher{}
+	first, second := &settlement{}, &settlement{}
+	replyTo := first.delivery("1", "first")
+	replyTo.ReplyTo = "inbox.42"
+	replyTo.CorrelationID = "corr-1"
+
+	adapter, runner := runAdapter(t, publisher, replyTo, second.delivery("2", `{"content":"second","data":{"task_id":"task-2"}}`))
+
+	if len(runner.tasks) != 2 || len(publisher.results) != 2 {
+		t.Fatalf("tasks = %d, results = %d", len(runner.tasks), len(publisher.results))
+	}
+	for _, result := range publisher.results {
+		switch result.TaskID {
+		case "1":
+			if result.Subject != "inbox.42" || result.CorrelationID != "corr-1" {
+				t.Errorf("reply not sent to reply-to: %+v", result)
+			}
+		case "task-2":
+			if result.Subject != "results" || result.Headers["task_id"] != "task-2" {
+				t.Errorf("unexpected result: %+v", result)
+			}
+		default:
+			t.Errorf("unexpected task ID %q", result.TaskID)
+		}
+	}
+	if !first.acked || !second.acked {
+		t.Error("handled deliveries should be acknowledged")
+	}
+	if stats := adapter.Stats(); stats.Received != 2 || stats.Succeeded != 2 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+}
+
+func TestAdapterRequeuesOnPublishFailure(t *testing.T) {
+	publisher := &recordingPublisher{err: errors.New("broker unavailable")}
+	s := &settlement{}
+
+	adapter, _ := runAdapter(t, publisher, s.delivery("1", "hello"))
+
+	if s.acked || !s.nacked || !s.requeue {
+		t.Errorf("delivery should be requeued: %+v", s)
+	}
+	if stats := adapter.Stats(); stats.PublishErrors != 1 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+}
+
+func TestAdapterRejectsInvalidTasks(t *testing.T) {
+	s := &settlement{}
+
+	adapter, runner := runAdapter(t, &recordingPublisher{}, s.delivery("1", `{"content":`))
+
+	if len(runner.tasks) != 0 {
+		t.Error("invalid task should not run")
+	}
+	if !s.nacked || s.requeue {
+		t.Errorf("invalid delivery should be rejected without requeue: %+v", s)
+	}
+	if stats := adapter.Stats(); stats.Invalid != 1 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+}
@@ -0,0 +1,10 @@
+module github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/queue/rabbitmq
+
+go 1.24.0
+
+require (
+	github.com/TeneoProtocolAI/teneo-agent-sdk v0.0.0-00010101000000-000000000000
+	github.com/rabbitmq/amqp091-go v1.10.0
+)
+
+replace github.com/TeneoProtocolAI/teneo-agent-sdk => ../../../
@@ -0,0 +1,103 @@
+package rag
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/logging"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/types"
+)
+
+// DefaultContextTemplate introduces the retrieved documents to the model;
+// the first %s is replaced with the documents and the second with the task
+const DefaultContextTemplate = `Answer using the context below. If the context does not contain the answer, say so rather than guessing.
+
+Context:
+%s
+
+Question: %s`
+
+// HandlerConfig configures a RetrievalAugmentedHandler
+type HandlerConfig struct {
+	Template        string // Prompt wrapping the documents and the task (default DefaultContextTemplate)
+	MaxContextChars int    // Documents beyond this many characters are left out (0 = all retrieved)
+	FailOpen        bool   // Answer without context when retrieval fails instead of failing the task
+}
+
+// RetrievalAugmentedHandler grounds another handler's answers in a knowledge
+// base: it retrieves the documents closest to the task and passes them to
+// the handler together with the task. Tasks without relevant documents are
+// passed on unchanged.
+type RetrievalAugmentedHandler struct {
+	next      types.AgentHandler
+	retriever *Retriever
+	config    HandlerConfig
+}
+
+// NewRetrievalAugmentedHandler wraps next, usually an LLM handler such as
+// agent.OpenAIAgent. config may be nil for the defaults.
+func NewRetrievalAugmentedHandler(next types.AgentHandler, retriever *Retriever, config *HandlerConfig) *RetrievalAugmentedHandler {
+	h := &RetrievalAugmentedHandler{next: next, retriever: retriever}
+	if config != nil {
+		h.config = *config
+	}
+	if h.config.Template == "" {
+		h.config.Template = DefaultContextTemplate
+	}
+	return h
+}
+
+// ProcessTask implements the AgentHandler interface
+func (h *RetrievalAugmentedHandler) ProcessTask(ctx context.Context, task string) (string, error) {
+	prompt, err := h.Augment(ctx, task)
+	if err != nil {
+		return "", err
+	}
+	return h.next.ProcessTask(ctx, prompt)
+}
+
+// ProcessTaskWithStreaming implements the StreamingTaskHandler interface,
+// streaming if the wrapped handler does
+func (h *RetrievalAugmentedHandler) ProcessTaskWithStreaming(ctx context.Context, task string, room string, sender types.MessageSender) error {
+	prompt, err := h.Augment(ctx, task)
+	if err != nil {
+		return err
+	}
+	if streamer, ok := h.next.(types.StreamingTaskHandler); ok {
+		return streamer.ProcessTaskWithStreaming(ctx, prompt, room, sender)
+	}
+
+	result, err := h.next.ProcessTask(ctx, prompt)
+	if err != nil {
+		return err
+	}
+	return sender.SendMessage(result)
+}
+
+// Augment returns the task with the documents relevant to it, or the task
+// alone if none are
+func (h *RetrievalAugmentedHandler) Augment(ctx context.Context, task string) (string, error) {
+	matches, err := h.retriever.Retrieve(ctx, task)
+	if err != nil {
+		if h.config.FailOpen {
+			logging.Warn("retrieval failed, answering without context", "error", err)
+			return task, nil
+		}
+		return "", fmt.Errorf("failed to retrieve context: %w", err)
+	}
+	if len(matches) == 0 {
+		return task, nil
+	}
+
+	var b strings.Builder
+	for i, match := range matches {
+		entry := fmt.Sprintf("[%d] %s\n", i+1, strings.TrimSpace(match.Content))
+		if h.config.MaxContextChars > 0 && b.Len()+len(entry) > h.config.MaxContextChars && i > 0 {
+			break
+		}
+		b.WriteString(entry)
+	}
+	logging.Debug("retrieved context", "documents", len(matches), "chars", b.Len())
+	return fmt.Sprintf(h.config.Template, strings.TrimRight(b.String(), "\n"), task), nil
+}
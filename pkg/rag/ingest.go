@@ -0,0 +1,160 @@
+package rag
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Defaults of IngestConfig
+const (
+	DefaultChunkSize    = 1000 // Characters
+	DefaultChunkOverlap = 100  // Characters
+	DefaultBatchSize    = 64   // Chunks per embedding request
+)
+
+// Metadata keys the ingester adds to every chunk
+const (
+	MetadataSource = "source" // ID of the document the chunk was cut from
+	MetadataChunk  = "chunk"  // Position of the chunk in the document, from 0
+)
+
+// IngestConfig configures an Ingester
+type IngestConfig struct {
+	ChunkSize    int // Maximum characters of a chunk (default DefaultChunkSize)
+	ChunkOverlap int // Characters a chunk repeats from the end of the previous one (default DefaultChunkOverlap, negative = none)
+	BatchSize    int // Chunks embedded per request (default DefaultBatchSize)
+}
+
+// Ingester splits documents into chunks, embeds them and stores them
+type Ingester struct {
+	embedder Embedder
+	store    VectorStore
+	config   IngestConfig
+}
+
+// NewIngester creates an ingester. config may be nil for the defaults.
+func NewIngester(embedder Embedder, store VectorStore, config *IngestConfig) *Ingester {
+	i := &Ingester{embedder: embedder, store: store}
+	if config != nil {
+		i.config = *config
+	}
+	if i.config.ChunkSize <= 0 {
+		i.config.ChunkSize = DefaultChunkSize
+	}
+	if i.config.ChunkOverlap == 0 {
+		i.config.ChunkOverlap = DefaultChunkOverlap
+	}
+	if i.config.ChunkOverlap < 0 || i.config.ChunkOverlap >= i.config.ChunkSize {
+		i.config.ChunkOverlap = 0
+	}
+	if i.config.BatchSize <= 0 {
+		i.config.BatchSize = DefaultBatchSize
+	}
+	return i
+}
+
+// Ingest stores the chunks of the documents and returns how many were stored.
+// Chunk IDs are the document ID followed by "#" and the chunk position, so
+// ingesting a document again overwrites its chunks.
+func (i *Ingester) Ingest(ctx context.Context, docs ...Document) (int, error) {
+	var chunks []Document
+	for _, doc := range docs {
+		for n, text := range SplitText(doc.Content, i.config.ChunkSize, i.config.ChunkOverlap) {
+			metadata := make(map[string]string, len(doc.Metadata)+2)
+			for key, value := range doc.Metadata {
+				metadata[key] = value
+			}
+			metadata[MetadataSource] = doc.ID
+			metadata[MetadataChunk] = strconv.Itoa(n)
+			chunks = append(chunks, Document{ID: doc.ID + "#" + strconv.Itoa(n), Content: text, Metadata: metadata})
+		}
+	}
+
+	stored := 0
+	for start := 0; start < len(chunks); start += i.config.BatchSize {
+		batch := chunks[start:min(start+i.config.BatchSize, len(chunks))]
+		texts := make([]string, len(batch))
+		for n, chunk := range batch {
+			texts[n] = chunk.Content
+		}
+		vectors, err := i.embedder.Embed(ctx, texts)
+		if err != nil {
+			return stored, fmt.Errorf("failed to embed chunks: %w", err)
+		}
+		if err := i.store.Upsert(ctx, batch, vectors); err != nil {
+			return stored, err
+		}
+		stored += len(batch)
+	}
+	return stored, nil
+}
+
+// IngestFiles stores the chunks of text files, identified by their base name
+func (i *Ingester) IngestFiles(ctx context.Context, paths ...string) (int, error) {
+	docs := make([]Document, 0, len(paths))
+	for _, path := range paths {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return 0, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		docs = append(docs, Document{ID: filepath.Base(path), Content: string(content), Metadata: map[string]string{"path": path}})
+	}
+	return i.Ingest(ctx, docs...)
+}
+
+// SplitText cuts a text into chunks of at most size characters, preferably
+// at paragraph, then sentence, then word boundaries. Every chunk but the
+// first starts with up to overlap characters from the end of the previous
+// one (at most half a chunk), so a passage cut in two is found in either chunk.
+func SplitText(text string, size, overlap int) []string {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil
+	}
+	if size <= 0 {
+		return []string{text}
+	}
+	overlap = min(overlap, size/2)
+
+	var chunks []string
+	runes := []rune(text)
+	for len(runes) > 0 {
+		if len(runes) <= size {
+			chunks = append(chunks, strings.TrimSpace(string(runes)))
+			break
+		}
+		end := cutPoint(runes[:size])
+		chunks = append(chunks, strings.TrimSpace(string(runes[:end])))
+
+		// Start the next chunk at a word boundary within the overlap
+		next := end
+		if overlap > 0 {
+			next = max(end-overlap, 1)
+			for next < end && !unicode.IsSpace(runes[next-1]) {
+				next++
+			}
+		}
+		runes = []rune(strings.TrimLeftFunc(string(runes[next:]), unicode.IsSpace))
+	}
+	return chunks
+}
+
+// cutPoint returns where to cut a window of text: after its last paragraph
+// break, sentence end or space in the second half, or at its end
+func cutPoint(window []rune) int {
+	half := len(window) / 2
+	text := string(window)
+	for _, sep := range []string{"\n\n", ". ", "! ", "? ", "\n", " "} {
+		if i := strings.LastIndex(text, sep); i >= 0 {
+			if end := len([]rune(text[:i+len(sep)])); end > half {
+				return end
+			}
+		}
+	}
+	return len(window)
+}
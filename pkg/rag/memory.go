@@ -0,0 +1,85 @@
+package rag
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// MemoryStore is a VectorStore kept in memory that compares the query with
+// every document. It suits knowledge bases of up to some ten thousand chunks
+// and tests.
+type MemoryStore struct {
+	mu      sync.RWMutex
+	dim     int
+	docs    map[string]Document
+	vectors map[string][]float32
+}
+
+// NewMemoryStore creates an empty in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{docs: make(map[string]Document), vectors: make(map[string][]float32)}
+}
+
+// Upsert implements VectorStore
+func (s *MemoryStore) Upsert(ctx context.Context, docs []Document, vectors [][]float32) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := checkVectors(docs, vectors, s.dim); err != nil {
+		return err
+	}
+	for i, doc := range docs {
+		if s.dim == 0 {
+			s.dim = len(vectors[i])
+		}
+		s.docs[doc.ID] = doc
+		s.vectors[doc.ID] = append([]float32(nil), vectors[i]...)
+	}
+	return nil
+}
+
+// Search implements VectorStore
+func (s *MemoryStore) Search(ctx context.Context, vector []float32, k int) ([]Match, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if len(s.docs) == 0 {
+		return nil, nil
+	}
+	if len(vector) != s.dim {
+		return nil, fmt.Errorf("%w: query has %d dimensions, want %d", ErrDimensionMismatch, len(vector), s.dim)
+	}
+
+	matches := make([]Match, 0, len(s.docs))
+	for id, doc := range s.docs {
+		matches = append(matches, Match{Document: doc, Score: cosine(vector, s.vectors[id])})
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].Score != matches[j].Score {
+			return matches[i].Score > matches[j].Score
+		}
+		return matches[i].ID < matches[j].ID
+	})
+	if k > 0 && len(matches) > k {
+		matches = matches[:k]
+	}
+	return matches, nil
+}
+
+// Delete implements VectorStore
+func (s *MemoryStore) Delete(ctx context.Context, ids ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, id := range ids {
+		delete(s.docs, id)
+		delete(s.vectors, id)
+	}
+	return nil
+}
+
+// Len returns the number of documents in the store
+func (s *MemoryStore) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.docs)
+}
@@ -0,0 +1,72 @@
+package rag
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/types"
+	"github.com/sashabaranov/go-openai"
+)
+
+// DefaultEmbeddingModel is the OpenAI embedding model used when none is configured
+const DefaultEmbeddingModel = string(openai.SmallEmbedding3)
+
+// OpenAIEmbedderConfig configures an OpenAIEmbedder
+type OpenAIEmbedderConfig struct {
+	APIKey     string // API key
+	Model      string // Embedding model (default DefaultEmbeddingModel)
+	BaseURL    string // Custom endpoint for OpenAI-compatible services
+	Dimensions int    // Shortens the vectors of text-embedding-3 models (0 = the model's size)
+}
+
+// OpenAIEmbedder embeds texts with the OpenAI embeddings API. The tokens it
+// uses during a task are reported as the task's LLM usage.
+type OpenAIEmbedder struct {
+	client     *openai.Client
+	model      string
+	dimensions int
+}
+
+// NewOpenAIEmbedder creates an embedder backed by the OpenAI API or a compatible endpoint
+func NewOpenAIEmbedder(config *OpenAIEmbedderConfig) *OpenAIEmbedder {
+	clientConfig := openai.DefaultConfig(config.APIKey)
+	if config.BaseURL != "" {
+		clientConfig.BaseURL = strings.TrimRight(config.BaseURL, "/")
+	}
+	model := config.Model
+	if model == "" {
+		model = DefaultEmbeddingModel
+	}
+	return &OpenAIEmbedder{client: openai.NewClientWithConfig(clientConfig), model: model, dimensions: config.Dimensions}
+}
+
+// Embed implements Embedder
+func (e *OpenAIEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	if len(texts) == 0 {
+		return nil, nil
+	}
+	resp, err := e.client.CreateEmbeddings(ctx, openai.EmbeddingRequest{
+		Input:      texts,
+		Model:      openai.EmbeddingModel(e.model),
+		Dimensions: e.dimensions,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("openai embeddings error: %w", err)
+	}
+	types.AddLLMUsage(ctx, types.LLMUsage{PromptTokens: resp.Usage.PromptTokens})
+
+	vectors := make([][]float32, len(texts))
+	for _, data := range resp.Data {
+		if data.Index < 0 || data.Index >= len(vectors) {
+			return nil, fmt.Errorf("openai embeddings returned index %d for %d texts", data.Index, len(texts))
+		}
+		vectors[data.Index] = data.Embedding
+	}
+	for i, vector := range vectors {
+		if vector == nil {
+			return nil, fmt.Errorf("%w for text %d", ErrEmptyEmbedding, i)
+		}
+	}
+	return vectors, nil
+}
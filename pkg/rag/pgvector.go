@@ -0,0 +1,141 @@
+package rag
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// DefaultPgVectorTable is the table documents are kept in when none is configured
+const DefaultPgVectorTable = "rag_documents"
+
+// tableName matches the table names a PgVectorStore accepts, optionally schema-qualified
+var tableName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)?$`)
+
+// PgVectorStore is a VectorStore on PostgreSQL with the pgvector extension.
+// It works with any database/sql driver for PostgreSQL (pgx, lib/pq), which
+// the application imports and opens the database with.
+type PgVectorStore struct {
+	db    *sql.DB
+	table string
+}
+
+// NewPgVectorStore creates a store keeping documents in table (empty = DefaultPgVectorTable)
+func NewPgVectorStore(db *sql.DB, table string) (*PgVectorStore, error) {
+	if table == "" {
+		table = DefaultPgVectorTable
+	}
+	if !tableName.MatchString(table) {
+		return nil, fmt.Errorf("invalid table name %q", table)
+	}
+	return &PgVectorStore{db: db, table: table}, nil
+}
+
+// Migrate creates the pgvector extension, the table for vectors of the given
+// dimension and its cosine HNSW index unless they exist
+func (s *PgVectorStore) Migrate(ctx context.Context, dim int) error {
+	index := strings.ReplaceAll(s.table, ".", "_") + "_embedding_idx"
+	statements := []string{
+		`CREATE EXTENSION IF NOT EXISTS vector`,
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+			id TEXT PRIMARY KEY,
+			content TEXT NOT NULL,
+			metadata JSONB,
+			embedding vector(%d) NOT NULL
+		)`, s.table, dim),
+		fmt.Sprintf(`CREATE INDEX IF NOT EXISTS %s ON %s USING hnsw (embedding vector_cosine_ops)`, index, s.table),
+	}
+	for _, statement := range statements {
+		if _, err := s.db.ExecContext(ctx, statement); err != nil {
+			return fmt.Errorf("failed to migrate %s: %w", s.table, err)
+		}
+	}
+	return nil
+}
+
+// Upsert implements VectorStore
+func (s *PgVectorStore) Upsert(ctx context.Context, docs []Document, vectors [][]float32) error {
+	if len(docs) == 0 {
+		return nil
+	}
+	if err := checkVectors(docs, vectors, 0); err != nil {
+		return err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := fmt.Sprintf(`INSERT INTO %s (id, content, metadata, embedding) VALUES ($1, $2, $3, $4::vector)
+		ON CONFLICT (id) DO UPDATE SET content = EXCLUDED.content, metadata = EXCLUDED.metadata, embedding = EXCLUDED.embedding`, s.table)
+	for i, doc := range docs {
+		metadata, err := json.Marshal(doc.Metadata)
+		if err != nil {
+			return fmt.Errorf("failed to marshal metadata of %q: %w", doc.ID, err)
+		}
+		if _, err := tx.ExecContext(ctx, query, doc.ID, doc.Content, string(metadata), formatVector(vectors[i])); err != nil {
+			return fmt.Errorf("failed to store %q: %w", doc.ID, err)
+		}
+	}
+	return tx.Commit()
+}
+
+// Search implements VectorStore
+func (s *PgVectorStore) Search(ctx context.Context, vector []float32, k int) ([]Match, error) {
+	if k <= 0 {
+		k = DefaultTopK
+	}
+	query := fmt.Sprintf(`SELECT id, content, metadata, 1 - (embedding <=> $1::vector) AS score
+		FROM %s ORDER BY embedding <=> $1::vector LIMIT $2`, s.table)
+	rows, err := s.db.QueryContext(ctx, query, formatVector(vector), k)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search %s: %w", s.table, err)
+	}
+	defer rows.Close()
+
+	var matches []Match
+	for rows.Next() {
+		var match Match
+		var metadata sql.NullString
+		if err := rows.Scan(&match.ID, &match.Content, &metadata, &match.Score); err != nil {
+			return nil, fmt.Errorf("failed to read match: %w", err)
+		}
+		if metadata.Valid && metadata.String != "null" {
+			if err := json.Unmarshal([]byte(metadata.String), &match.Metadata); err != nil {
+				return nil, fmt.Errorf("invalid metadata of %q: %w", match.ID, err)
+			}
+		}
+		matches = append(matches, match)
+	}
+	return matches, rows.Err()
+}
+
+// Delete implements VectorStore
+func (s *PgVectorStore) Delete(ctx context.Context, ids ...string) error {
+	for _, id := range ids {
+		if _, err := s.db.ExecContext(ctx, fmt.Sprintf(`DELETE FROM %s WHERE id = $1`, s.table), id); err != nil {
+			return fmt.Errorf("failed to delete %q: %w", id, err)
+		}
+	}
+	return nil
+}
+
+// formatVector formats a vector as a pgvector literal, e.g. "[1,2.5,3]"
+func formatVector(vector []float32) string {
+	var b strings.Builder
+	b.WriteByte('[')
+	for i, v := range vector {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(strconv.FormatFloat(float64(v), 'g', -1, 32))
+	}
+	b.WriteByte(']')
+	return b.String()
+}
@@ -0,0 +1,124 @@
+// Package rag grounds an agent's answers in a knowledge base: documents are
+// split into chunks, embedded and kept in a vector store, and the chunks
+// closest to a task are added to the prompt of the model answering it.
+package rag
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+)
+
+var (
+	// ErrDimensionMismatch is returned when a vector does not have the dimension of the store
+	ErrDimensionMismatch = errors.New("vector dimension mismatch")
+	// ErrEmptyEmbedding is returned when an embedder returns fewer vectors than texts
+	ErrEmptyEmbedding = errors.New("embedder returned no vector")
+)
+
+// Document is a text in the knowledge base, usually a chunk of a larger source
+type Document struct {
+	ID       string            `json:"id"`
+	Content  string            `json:"content"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// Match is a document found by a search with its similarity to the query,
+// from -1 to 1 with higher being closer
+type Match struct {
+	Document
+	Score float64 `json:"score"`
+}
+
+// Embedder turns texts into vectors whose distance reflects their difference in meaning
+type Embedder interface {
+	// Embed returns a vector for every text, in order
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+}
+
+// VectorStore keeps documents with their vectors and finds the nearest ones
+type VectorStore interface {
+	// Upsert adds documents with their vectors, replacing documents with the same ID
+	Upsert(ctx context.Context, docs []Document, vectors [][]float32) error
+
+	// Search returns the k documents closest to the vector, closest first
+	Search(ctx context.Context, vector []float32, k int) ([]Match, error)
+
+	// Delete removes documents by ID; unknown IDs are ignored
+	Delete(ctx context.Context, ids ...string) error
+}
+
+// Retriever finds the documents relevant to a text
+type Retriever struct {
+	embedder Embedder
+	store    VectorStore
+	k        int
+	minScore float64
+}
+
+// DefaultTopK is how many documents a retriever returns when not configured
+const DefaultTopK = 4
+
+// NewRetriever creates a retriever returning the k closest documents
+// (0 = DefaultTopK) scoring at least minScore
+func NewRetriever(embedder Embedder, store VectorStore, k int, minScore float64) *Retriever {
+	if k <= 0 {
+		k = DefaultTopK
+	}
+	return &Retriever{embedder: embedder, store: store, k: k, minScore: minScore}
+}
+
+// Retrieve returns the documents closest to the text, closest first
+func (r *Retriever) Retrieve(ctx context.Context, text string) ([]Match, error) {
+	vectors, err := r.embedder.Embed(ctx, []string{text})
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed query: %w", err)
+	}
+	if len(vectors) == 0 {
+		return nil, ErrEmptyEmbedding
+	}
+	matches, err := r.store.Search(ctx, vectors[0], r.k)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search vector store: %w", err)
+	}
+
+	relevant := matches[:0]
+	for _, match := range matches {
+		if match.Score >= r.minScore {
+			relevant = append(relevant, match)
+		}
+	}
+	return relevant, nil
+}
+
+// checkVectors verifies there is a vector of the given dimension for every
+// document; dim 0 accepts the dimension of the first vector
+func checkVectors(docs []Document, vectors [][]float32, dim int) error {
+	if len(docs) != len(vectors) {
+		return fmt.Errorf("%d documents but %d vectors", len(docs), len(vectors))
+	}
+	for i, vector := range vectors {
+		if dim == 0 {
+			dim = len(vector)
+		}
+		if len(vector) != dim || dim == 0 {
+			return fmt.Errorf("%w: document %q has %d dimensions, want %d", ErrDimensionMismatch, docs[i].ID, len(vector), dim)
+		}
+	}
+	return nil
+}
+
+// cosine returns the cosine similarity of two vectors of the same dimension
+func cosine(a, b []float32) float64 {
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
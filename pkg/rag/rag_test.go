@@ -0,0 +1,112 @@
+package rag
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/types"
+)
+
+// wordEmbedder embeds texts by counting a few words, enough to tell topics apart
+type wordEmbedder struct{}
+
+func (wordEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	words := []string{"cat", "dog", "rocket"}
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		vector := make([]float32, len(words))
+		for j, word := range words {
+			vector[j] = float32(strings.Count(strings.ToLower(text), word))
+		}
+		vectors[i] = vector
+	}
+	return vectors, nil
+}
+
+// echoHandler answers with the prompt it was given
+type echoHandler struct{}
+
+func (echoHandler) ProcessTask(ctx context.Context, task string) (string, error) {
+	return task, nil
+}
+
+func TestSplitText(t *testing.T) {
+	text := strings.Repeat("One sentence here. ", 20)
+	chunks := SplitText(text, 100, 20)
+	if len(chunks) < 4 {
+		t.Fatalf("expected several chunks, got %d", len(chunks))
+	}
+	for i, chunk := range chunks {
+		if len([]rune(chunk)) > 100 {
+			t.Errorf("chunk %d has %d characters", i, len(chunk))
+		}
+		if !strings.HasSuffix(chunk, ".") {
+			t.Errorf("chunk %d not cut at a sentence end: %q", i, chunk)
+		}
+	}
+	if got := SplitText("short", 100, 20); len(got) != 1 || got[0] != "short" {
+		t.Errorf("SplitText(short) = %q", got)
+	}
+	if got := SplitText("  ", 100, 20); got != nil {
+		t.Errorf("SplitText(blank) = %q", got)
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	docs := []Document{{ID: "a", Content: "cats"}, {ID: "b", Content: "dogs"}}
+	if err := store.Upsert(ctx, docs, [][]float32{{1, 0}, {0, 1}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Upsert(ctx, []Document{{ID: "c"}}, [][]float32{{1, 0, 0}}); err == nil {
+		t.Error("expected a dimension mismatch")
+	}
+
+	matches, err := store.Search(ctx, []float32{0.9, 0.1}, 1)
+	if err != nil || len(matches) != 1 || matches[0].ID != "a" {
+		t.Fatalf("Search = %+v, %v", matches, err)
+	}
+	store.Delete(ctx, "a")
+	if matches, _ := store.Search(ctx, []float32{1, 0}, 5); len(matches) != 1 || matches[0].ID != "b" {
+		t.Errorf("Search after delete = %+v", matches)
+	}
+}
+
+func TestRetrievalAugmentedHandler(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	ingester := NewIngester(wordEmbedder{}, store, &IngestConfig{ChunkSize: 200})
+	n, err := ingester.Ingest(ctx,
+		Document{ID: "pets", Content: "The cat sleeps all day.", Metadata: map[string]string{"lang": "en"}},
+		Document{ID: "space", Content: "The rocket launches at dawn."},
+	)
+	if err != nil || n != 2 {
+		t.Fatalf("Ingest = %d, %v", n, err)
+	}
+
+	handler := NewRetrievalAugmentedHandler(echoHandler{}, NewRetriever(wordEmbedder{}, store, 1, 0.5), nil)
+	prompt, err := handler.ProcessTask(ctx, "When does the rocket start?")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(prompt, "[1] The rocket launches at dawn.") || strings.Contains(prompt, "cat") {
+		t.Errorf("unexpected context in prompt:\n%s", prompt)
+	}
+	if !strings.HasSuffix(prompt, "Question: When does the rocket start?") {
+		t.Errorf("task missing from prompt:\n%s", prompt)
+	}
+
+	// Nothing relevant: the task is passed on unchanged
+	if prompt, _ := handler.ProcessTask(ctx, "What about dogs?"); prompt != "What about dogs?" {
+		t.Errorf("expected the task alone, got:\n%s", prompt)
+	}
+
+	matches, _ := store.Search(ctx, []float32{1, 0, 0}, 1)
+	if len(matches) != 1 || matches[0].ID != "pets#0" || matches[0].Metadata[MetadataSource] != "pets" || matches[0].Metadata["lang"] != "en" {
+		t.Errorf("unexpected chunk %+v", matches)
+	}
+}
+
+var _ types.StreamingTaskHandler = (*RetrievalAugmentedHandler)(nil)
@@ -0,0 +1,184 @@
+package rag
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisStoreConfig configures a RedisStore
+type RedisStoreConfig struct {
+	Index     string // Name of the search index (default "teneo:rag")
+	KeyPrefix string // Prefix of the document hashes (default "<Index>:doc:")
+}
+
+// RedisStore is a VectorStore on Redis with the search module (Redis Stack
+// or Redis 8). Documents are hashes indexed for cosine KNN queries; the
+// index is created with the dimension of the first vectors stored.
+//
+// FT.SEARCH replies are read in RESP2, so the client must be created with
+// redis.Options.Protocol set to 2.
+type RedisStore struct {
+	client    *redis.Client
+	index     string
+	keyPrefix string
+
+	mu      sync.Mutex
+	created bool
+}
+
+// Hash fields of a document
+const (
+	redisFieldContent   = "content"
+	redisFieldMetadata  = "metadata"
+	redisFieldEmbedding = "embedding"
+	redisFieldDistance  = "distance"
+)
+
+// NewRedisStore creates a store keeping documents in Redis
+func NewRedisStore(client *redis.Client, config *RedisStoreConfig) *RedisStore {
+	s := &RedisStore{client: client, index: "teneo:rag"}
+	if config != nil {
+		if config.Index != "" {
+			s.index = config.Index
+		}
+		s.keyPrefix = config.KeyPrefix
+	}
+	if s.keyPrefix == "" {
+		s.keyPrefix = s.index + ":doc:"
+	}
+	return s
+}
+
+// ensureIndex creates the search index for vectors of the given dimension
+// unless it exists
+func (s *RedisStore) ensureIndex(ctx context.Context, dim int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.created {
+		return nil
+	}
+
+	err := s.client.FTCreate(ctx, s.index,
+		&redis.FTCreateOptions{OnHash: true, Prefix: []interface{}{s.keyPrefix}},
+		&redis.FieldSchema{
+			FieldName: redisFieldEmbedding,
+			FieldType: redis.SearchFieldTypeVector,
+			VectorArgs: &redis.FTVectorArgs{HNSWOptions: &redis.FTHNSWOptions{
+				Type:           "FLOAT32",
+				Dim:            dim,
+				DistanceMetric: "COSINE",
+			}},
+		},
+	).Err()
+	if err != nil && !strings.Contains(err.Error(), "Index already exists") {
+		return fmt.Errorf("failed to create search index %s: %w", s.index, err)
+	}
+	s.created = true
+	return nil
+}
+
+// Upsert implements VectorStore
+func (s *RedisStore) Upsert(ctx context.Context, docs []Document, vectors [][]float32) error {
+	if len(docs) == 0 {
+		return nil
+	}
+	if err := checkVectors(docs, vectors, 0); err != nil {
+		return err
+	}
+	if err := s.ensureIndex(ctx, len(vectors[0])); err != nil {
+		return err
+	}
+
+	pipe := s.client.Pipeline()
+	for i, doc := range docs {
+		metadata, err := json.Marshal(doc.Metadata)
+		if err != nil {
+			return fmt.Errorf("failed to marshal metadata of %q: %w", doc.ID, err)
+		}
+		key := s.keyPrefix + doc.ID
+		pipe.Del(ctx, key)
+		pipe.HSet(ctx, key,
+			redisFieldContent, doc.Content,
+			redisFieldMetadata, metadata,
+			redisFieldEmbedding, encodeVector(vectors[i]),
+		)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to store documents: %w", err)
+	}
+	return nil
+}
+
+// Search implements VectorStore
+func (s *RedisStore) Search(ctx context.Context, vector []float32, k int) ([]Match, error) {
+	if k <= 0 {
+		k = DefaultTopK
+	}
+	result, err := s.client.FTSearchWithArgs(ctx, s.index,
+		fmt.Sprintf("*=>[KNN %d @%s $vec AS %s]", k, redisFieldEmbedding, redisFieldDistance),
+		&redis.FTSearchOptions{
+			Params:         map[string]interface{}{"vec": encodeVector(vector)},
+			DialectVersion: 2,
+			SortBy:         []redis.FTSearchSortBy{{FieldName: redisFieldDistance, Asc: true}},
+			Return: []redis.FTSearchReturn{
+				{FieldName: redisFieldContent},
+				{FieldName: redisFieldMetadata},
+				{FieldName: redisFieldDistance},
+			},
+			Limit: k,
+		},
+	).Result()
+	if err != nil {
+		if strings.Contains(err.Error(), "no such index") {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to search index %s: %w", s.index, err)
+	}
+
+	matches := make([]Match, 0, len(result.Docs))
+	for _, doc := range result.Docs {
+		match := Match{Document: Document{ID: strings.TrimPrefix(doc.ID, s.keyPrefix), Content: doc.Fields[redisFieldContent]}}
+		if metadata := doc.Fields[redisFieldMetadata]; metadata != "" && metadata != "null" {
+			if err := json.Unmarshal([]byte(metadata), &match.Metadata); err != nil {
+				return nil, fmt.Errorf("invalid metadata of %q: %w", match.ID, err)
+			}
+		}
+		// Cosine distance is 1 - similarity
+		distance, err := strconv.ParseFloat(doc.Fields[redisFieldDistance], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid distance of %q: %w", match.ID, err)
+		}
+		match.Score = 1 - distance
+		matches = append(matches, match)
+	}
+	return matches, nil
+}
+
+// Delete implements VectorStore
+func (s *RedisStore) Delete(ctx context.Context, ids ...string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		keys[i] = s.keyPrefix + id
+	}
+	return s.client.Del(ctx, keys...).Err()
+}
+
+// encodeVector encodes a vector as the little-endian FLOAT32 blob Redis indexes
+func encodeVector(vector []float32) []byte {
+	buf := make([]byte, 4*len(vector))
+	for i, v := range vector {
+		binary.LittleEndian.PutUint32(buf[4*i:], math.Float32bits(v))
+	}
+	return buf
+}
@@ -0,0 +1,212 @@
+// Package ratelimit limits incoming requests with token buckets. A request
+// must pass the global bucket as well as the bucket of its room and of its
+// sender; each bucket refills at its per-minute rate and holds up to its
+// burst, so idle clients can send a burst of requests at once.
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// sweepInterval is how often buckets of idle rooms and senders are removed
+const sweepInterval = time.Minute
+
+// Limit is the rate of one bucket
+type Limit struct {
+	PerMinute int `json:"per_minute"` // Sustained requests per minute (0 = unlimited)
+	Burst     int `json:"burst"`      // Requests allowed at once (default PerMinute)
+}
+
+// Unlimited reports whether the limit lets every request through
+func (l Limit) Unlimited() bool {
+	return l.PerMinute <= 0
+}
+
+// capacity returns how many tokens a full bucket holds
+func (l Limit) capacity() float64 {
+	if l.Burst > 0 {
+		return float64(l.Burst)
+	}
+	return float64(l.PerMinute)
+}
+
+// perSecond returns how many tokens are added per second
+func (l Limit) perSecond() float64 {
+	return float64(l.PerMinute) / 60
+}
+
+// Config sets the limits a request must pass
+type Config struct {
+	Global    Limit `json:"global"`     // All requests together
+	PerRoom   Limit `json:"per_room"`   // Requests from one room
+	PerSender Limit `json:"per_sender"` // Requests from one sender, across rooms
+}
+
+// Scope names the limit that rejected a request
+type Scope string
+
+const (
+	ScopeGlobal Scope = "global"
+	ScopeRoom   Scope = "room"
+	ScopeSender Scope = "sender"
+)
+
+// Decision is the outcome of Allow
+type Decision struct {
+	Allowed    bool
+	Scope      Scope         // The limit that was exceeded, empty if allowed
+	RetryAfter time.Duration // When the request would be allowed, zero if allowed
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// refill adds the tokens earned since the last update; a new bucket starts full
+func (b *bucket) refill(limit Limit, now time.Time) {
+	if b.last.IsZero() {
+		b.tokens = limit.capacity()
+	} else if elapsed := now.Sub(b.last).Seconds(); elapsed > 0 {
+		b.tokens += elapsed * limit.perSecond()
+	}
+	// Also caps buckets whose burst was lowered
+	b.tokens = math.Min(b.tokens, limit.capacity())
+	b.last = now
+}
+
+// wait returns how long until the bucket holds a whole token
+func (b *bucket) wait(limit Limit) time.Duration {
+	if b.tokens >= 1 {
+		return 0
+	}
+	return time.Duration((1 - b.tokens) / limit.perSecond() * float64(time.Second))
+}
+
+// Limiter decides whether requests may be processed. It is safe for concurrent use.
+type Limiter struct {
+	mu        sync.Mutex
+	config    Config
+	global    bucket
+	rooms     map[string]*bucket
+	senders   map[string]*bucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+// New creates a limiter; the zero Config lets every request through
+func New(config Config) *Limiter {
+	return &Limiter{
+		config:  config,
+		rooms:   make(map[string]*bucket),
+		senders: make(map[string]*bucket),
+		now:     time.Now,
+	}
+}
+
+// SetConfig changes the limits. Buckets keep their tokens, capped at the new burst.
+func (l *Limiter) SetConfig(config Config) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.config = config
+	if config.Global.Unlimited() {
+		l.global = bucket{}
+	}
+	if config.PerRoom.Unlimited() {
+		clear(l.rooms)
+	}
+	if config.PerSender.Unlimited() {
+		clear(l.senders)
+	}
+}
+
+// SetClock makes the limiter read the time from now instead of time.Now, so
+// tests can refill buckets without waiting
+func (l *Limiter) SetClock(now func() time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.now = now
+}
+
+// Config returns the current limits
+func (l *Limiter) Config() Config {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.config
+}
+
+// Allow takes a token for a request from sender in room if every applicable
+// bucket has one. An empty room or sender skips that limit. A rejected
+// request takes no tokens, so retrying after RetryAfter succeeds unless other
+// requests came first.
+func (l *Limiter) Allow(room, sender string) Decision {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	type check struct {
+		scope  Scope
+		limit  Limit
+		bucket *bucket
+	}
+	var checks []check
+	if !l.config.Global.Unlimited() {
+		checks = append(checks, check{ScopeGlobal, l.config.Global, &l.global})
+	}
+	if !l.config.PerRoom.Unlimited() && room != "" {
+		checks = append(checks, check{ScopeRoom, l.config.PerRoom, bucketFor(l.rooms, room)})
+	}
+	if !l.config.PerSender.Unlimited() && sender != "" {
+		checks = append(checks, check{ScopeSender, l.config.PerSender, bucketFor(l.senders, sender)})
+	}
+
+	// Report the limit that keeps the request waiting longest
+	decision := Decision{Allowed: true}
+	for _, c := range checks {
+		c.bucket.refill(c.limit, now)
+		if wait := c.bucket.wait(c.limit); wait > 0 && (decision.Allowed || wait > decision.RetryAfter) {
+			decision = Decision{Scope: c.scope, RetryAfter: wait}
+		}
+	}
+	if !decision.Allowed {
+		return decision
+	}
+	for _, c := range checks {
+		c.bucket.tokens--
+	}
+	return decision
+}
+
+func bucketFor(buckets map[string]*bucket, key string) *bucket {
+	b, ok := buckets[key]
+	if !ok {
+		b = &bucket{}
+		buckets[key] = b
+	}
+	return b
+}
+
+// sweep removes room and sender buckets that have refilled completely; they
+// behave like new buckets, so forgetting them bounds memory without changing
+// any decision
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < sweepInterval {
+		return
+	}
+	l.lastSweep = now
+	sweepFull(l.rooms, l.config.PerRoom, now)
+	sweepFull(l.senders, l.config.PerSender, now)
+}
+
+func sweepFull(buckets map[string]*bucket, limit Limit, now time.Time) {
+	for key, b := range buckets {
+		b.refill(limit, now)
+		if b.tokens >= limit.capacity() {
+			delete(buckets, key)
+		}
+	}
+}
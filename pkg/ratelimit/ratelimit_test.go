@@ -0,0 +1,129 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+type clock struct{ t time.Time }
+
+func (c *clock) now() time.Time          { return c.t }
+func (c *clock) advance(d time.Duration) { c.t = c.t.Add(d) }
+
+func newTestLimiter(config Config) (*Limiter, *clock) {
+	c := &clock{t: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}
+	l := New(config)
+	l.now = c.now
+	return l, c
+}
+
+func allowN(l *Limiter, n int, room, sender string) int {
+	allowed := 0
+	for i := 0; i < n; i++ {
+		if l.Allow(room, sender).Allowed {
+			allowed++
+		}
+	}
+	return allowed
+}
+
+func TestUnlimited(t *testing.T) {
+	l, _ := newTestLimiter(Config{})
+	if got := allowN(l, 1000, "room", "alice"); got != 1000 {
+		t.Errorf("allowed %d of 1000", got)
+	}
+}
+
+func TestBurstAndRefill(t *testing.T) {
+	l, c := newTestLimiter(Config{Global: Limit{PerMinute: 60, Burst: 5}})
+	if got := allowN(l, 10, "", ""); got != 5 {
+		t.Fatalf("burst: allowed %d, want 5", got)
+	}
+
+	d := l.Allow("", "")
+	if d.Allowed || d.Scope != ScopeGlobal || d.RetryAfter != time.Second {
+		t.Fatalf("got %+v, want global rejection retrying after 1s", d)
+	}
+
+	c.advance(time.Second)
+	if !l.Allow("", "").Allowed {
+		t.Error("expected a token after 1s")
+	}
+	c.advance(time.Hour)
+	if got := allowN(l, 10, "", ""); got != 5 {
+		t.Errorf("after idling: allowed %d, want the burst of 5", got)
+	}
+}
+
+func TestBurstDefaultsToPerMinute(t *testing.T) {
+	l, _ := newTestLimiter(Config{Global: Limit{PerMinute: 3}})
+	if got := allowN(l, 5, "", ""); got != 3 {
+		t.Errorf("allowed %d, want 3", got)
+	}
+}
+
+func TestPerRoomAndSender(t *testing.T) {
+	l, _ := newTestLimiter(Config{
+		PerRoom:   Limit{PerMinute: 60, Burst: 3},
+		PerSender: Limit{PerMinute: 60, Burst: 2},
+	})
+
+	if got := allowN(l, 5, "room-a", "alice"); got != 2 {
+		t.Errorf("alice: allowed %d, want 2", got)
+	}
+	if d := l.Allow("room-a", "alice"); d.Scope != ScopeSender {
+		t.Errorf("got scope %q, want sender", d.Scope)
+	}
+
+	// Bob still has tokens, but room-a has only one left
+	if got := allowN(l, 5, "room-a", "bob"); got != 1 {
+		t.Errorf("bob in room-a: allowed %d, want 1", got)
+	}
+	if d := l.Allow("room-a", "carol"); d.Scope != ScopeRoom {
+		t.Errorf("got scope %q, want room", d.Scope)
+	}
+
+	// Rejections took no tokens from bob's bucket
+	if got := allowN(l, 5, "room-b", "bob"); got != 1 {
+		t.Errorf("bob in room-b: allowed %d, want 1", got)
+	}
+}
+
+func TestRetryAfterReportsLongestWait(t *testing.T) {
+	l, _ := newTestLimiter(Config{
+		Global:    Limit{PerMinute: 60, Burst: 1},
+		PerSender: Limit{PerMinute: 6, Burst: 1},
+	})
+	l.Allow("", "alice")
+	d := l.Allow("", "alice")
+	if d.Scope != ScopeSender || d.RetryAfter != 10*time.Second {
+		t.Errorf("got %+v, want sender rejection retrying after 10s", d)
+	}
+}
+
+func TestSetConfig(t *testing.T) {
+	l, _ := newTestLimiter(Config{Global: Limit{PerMinute: 60, Burst: 10}})
+	allowN(l, 2, "", "")
+
+	l.SetConfig(Config{Global: Limit{PerMinute: 60, Burst: 3}})
+	if got := allowN(l, 10, "", ""); got != 3 {
+		t.Errorf("after lowering the burst: allowed %d, want 3", got)
+	}
+
+	l.SetConfig(Config{})
+	if got := allowN(l, 10, "", ""); got != 10 {
+		t.Errorf("after removing the limit: allowed %d, want 10", got)
+	}
+}
+
+func TestSweep(t *testing.T) {
+	l, c := newTestLimiter(Config{PerSender: Limit{PerMinute: 60, Burst: 1}})
+	for _, sender := range []string{"a", "b", "c"} {
+		l.Allow("", sender)
+	}
+	c.advance(sweepInterval)
+	l.Allow("", "d")
+	if len(l.senders) != 1 {
+		t.Errorf("kept %d sender buckets, want only the one just used", len(l.senders))
+	}
+}
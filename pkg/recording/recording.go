@@ -0,0 +1,240 @@
+// Package recording captures the messages an agent exchanges with the Teneo
+// network as JSON lines, one entry per message in the order it went over the
+// wire, and replays recorded sessions so a production problem can be
+// reproduced deterministically on a developer's machine.
+//
+// Recordings hold every message as sent, including task content from users
+// and authentication signatures; store and share them accordingly.
+package recording
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/types"
+)
+
+// maxEntrySize is the longest line Read accepts
+const maxEntrySize = 64 << 20
+
+// Direction is which way a message went
+type Direction string
+
+const (
+	Inbound  Direction = "in"  // Received from the network
+	Outbound Direction = "out" // Sent by the agent
+)
+
+// Entry is one recorded message
+type Entry struct {
+	Time      time.Time       `json:"time"`
+	Direction Direction       `json:"direction"`
+	Message   json.RawMessage `json:"message"` // The message as it went over the wire
+}
+
+// Decode returns the recorded message
+func (e *Entry) Decode() (*types.Message, error) {
+	var msg types.Message
+	if err := json.Unmarshal(e.Message, &msg); err != nil {
+		return nil, fmt.Errorf("failed to decode recorded message: %w", err)
+	}
+	return &msg, nil
+}
+
+// Recorder writes entries as JSON lines. Each entry is written through at
+// once, so a recording is complete up to the last message even if the
+// process crashes. It is safe for concurrent use.
+type Recorder struct {
+	mu      sync.Mutex
+	w       io.Writer
+	closer  io.Closer
+	entries int
+	err     error // First write error; later entries are dropped
+}
+
+// NewRecorder returns a recorder writing to w
+func NewRecorder(w io.Writer) *Recorder {
+	return &Recorder{w: w}
+}
+
+// Open returns a recorder appending to the file at path, which is created
+// if needed, so the sessions of several runs end up in one file
+func Open(path string) (*Recorder, error) {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open recording: %w", err)
+	}
+	return &Recorder{w: file, closer: file}, nil
+}
+
+// Record writes a message that went over the wire in the direction. data is
+// the message as sent or received; data that is not valid JSON is not
+// recorded.
+func (r *Recorder) Record(direction Direction, data []byte) error {
+	if !json.Valid(data) {
+		return errors.New("recorded message is not valid JSON")
+	}
+	line, err := json.Marshal(Entry{Time: time.Now().UTC(), Direction: direction, Message: data})
+	if err != nil {
+		return fmt.Errorf("failed to marshal recording entry: %w", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil {
+		return r.err
+	}
+	if _, err := r.w.Write(append(line, '\n')); err != nil {
+		r.err = fmt.Errorf("failed to write recording: %w", err)
+		return r.err
+	}
+	r.entries++
+	return nil
+}
+
+// RecordMessage writes a message that went in the direction
+func (r *Recorder) RecordMessage(direction Direction, msg *types.Message) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("failed to marshal recorded message: %w", err)
+	}
+	return r.Record(direction, data)
+}
+
+// Entries returns the number of entries written
+func (r *Recorder) Entries() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.entries
+}
+
+// Close closes the file of a recorder created with Open
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err == nil {
+		r.err = errors.New("recorder is closed")
+	}
+	if r.closer == nil {
+		return nil
+	}
+	closer := r.closer
+	r.closer = nil
+	return closer.Close()
+}
+
+// Read parses a recording. Blank lines are skipped.
+func Read(reader io.Reader) ([]Entry, error) {
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 64*1024), maxEntrySize)
+	var entries []Entry
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("line %d: invalid recording entry: %w", line, err)
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read recording: %w", err)
+	}
+	return entries, nil
+}
+
+// Load reads the recording at path
+func Load(path string) ([]Entry, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open recording: %w", err)
+	}
+	defer file.Close()
+	return Read(file)
+}
+
+// ReplayOptions configures Replay
+type ReplayOptions struct {
+	// Speed is how much faster than recorded the messages are replayed, e.g.
+	// 1 keeps the recorded pace and 10 is ten times faster (0 = no delays)
+	Speed float64
+	// Types are the message types replayed (empty = all)
+	Types []string
+}
+
+// Replay passes the inbound messages of a recording to fn, in the recorded
+// order and, with a Speed, at the recorded pace. Split messages are
+// reassembled and compressed content decoded first, as the network client
+// does. It stops at the first error of fn or when ctx ends, and returns the
+// number of messages passed to fn.
+func Replay(ctx context.Context, entries []Entry, options ReplayOptions, fn func(context.Context, *types.Message) error) (int, error) {
+	chunks := types.NewChunkAssembler(0)
+	replayed := 0
+	var previous time.Time
+	for i := range entries {
+		entry := &entries[i]
+		if entry.Direction != Inbound {
+			continue
+		}
+		if options.Speed > 0 && !previous.IsZero() {
+			if delay := time.Duration(float64(entry.Time.Sub(previous)) / options.Speed); delay > 0 {
+				timer := time.NewTimer(delay)
+				select {
+				case <-ctx.Done():
+					timer.Stop()
+					return replayed, ctx.Err()
+				case <-timer.C:
+				}
+			}
+		}
+		previous = entry.Time
+		if err := ctx.Err(); err != nil {
+			return replayed, err
+		}
+
+		msg, err := entry.Decode()
+		if err != nil {
+			return replayed, fmt.Errorf("entry %d: %w", i+1, err)
+		}
+		if !replayable(msg.Type, options.Types) {
+			continue
+		}
+		if msg, err = chunks.Add(msg); err != nil {
+			return replayed, fmt.Errorf("entry %d: %w", i+1, err)
+		}
+		if msg == nil {
+			// More parts to come
+			continue
+		}
+		if err := msg.DecodeContent(); err != nil {
+			return replayed, fmt.Errorf("entry %d: %w", i+1, err)
+		}
+
+		if err := fn(ctx, msg); err != nil {
+			return replayed, err
+		}
+		replayed++
+	}
+	return replayed, nil
+}
+
+// replayable reports whether messages of the type are replayed
+func replayable(msgType string, allowed []string) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+	for _, t := range allowed {
+		if t == msgType {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,118 @@
+package recording
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/types"
+)
+
+func task(id, content string) *types.Message {
+	data, _ := json.Marshal(map[string]string{"task_id": id, "content": content})
+	return &types.Message{Type: types.MessageTypeTask, From: "coordinator", Content: content, Data: data}
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	var buf bytes.Buffer
+	recorder := NewRecorder(&buf)
+	recorder.RecordMessage(Inbound, task("t1", "first"))
+	recorder.RecordMessage(Outbound, &types.Message{Type: types.MessageTypeTaskResponse, TaskID: "t1"})
+	recorder.RecordMessage(Inbound, &types.Message{Type: types.MessageTypePong})
+	recorder.RecordMessage(Inbound, task("t2", "second"))
+	if err := recorder.Record(Inbound, []byte("not json")); err == nil {
+		t.Error("invalid JSON recorded")
+	}
+	if recorder.Entries() != 4 {
+		t.Fatalf("%d entries, want 4", recorder.Entries())
+	}
+
+	entries, err := Read(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var replayed []string
+	n, err := Replay(context.Background(), entries, ReplayOptions{Types: []string{types.MessageTypeTask}}, func(ctx context.Context, msg *types.Message) error {
+		replayed = append(replayed, msg.Content)
+		return nil
+	})
+	if err != nil || n != 2 || strings.Join(replayed, ",") != "first,second" {
+		t.Errorf("replayed %d %q, err %v", n, replayed, err)
+	}
+}
+
+func TestReplayReassemblesChunks(t *testing.T) {
+	var buf bytes.Buffer
+	recorder := NewRecorder(&buf)
+	content := strings.Repeat("line of a long task\n", 50)
+	msg := task("t1", content)
+	msg.CompressContent(1)
+	for _, part := range msg.SplitContent(100) {
+		recorder.RecordMessage(Inbound, part)
+	}
+
+	entries, _ := Read(&buf)
+	var got []string
+	Replay(context.Background(), entries, ReplayOptions{}, func(ctx context.Context, msg *types.Message) error {
+		got = append(got, msg.Content)
+		return nil
+	})
+	if len(got) != 1 || got[0] != content {
+		t.Errorf("replayed %d messages, want the reassembled task", len(got))
+	}
+}
+
+func TestReplayKeepsPace(t *testing.T) {
+	start := time.Now()
+	entries := []Entry{
+		{Time: start, Direction: Inbound, Message: json.RawMessage(`{"type":"task"}`)},
+		{Time: start.Add(time.Second), Direction: Inbound, Message: json.RawMessage(`{"type":"task"}`)},
+	}
+
+	began := time.Now()
+	Replay(context.Background(), entries, ReplayOptions{Speed: 10}, func(context.Context, *types.Message) error { return nil })
+	if elapsed := time.Since(began); elapsed < 80*time.Millisecond {
+		t.Errorf("replay took %s, want about 100ms at 10x", elapsed)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	n, err := Replay(ctx, entries, ReplayOptions{Speed: 1}, func(context.Context, *types.Message) error { return nil })
+	if !errors.Is(err, context.DeadlineExceeded) || n != 1 {
+		t.Errorf("replayed %d, err %v; want to stop after the first message", n, err)
+	}
+}
+
+func TestOpenAppends(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.jsonl")
+	for i := 0; i < 2; i++ {
+		recorder, err := Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		recorder.RecordMessage(Inbound, task("t", "run"))
+		recorder.Close()
+		if err := recorder.RecordMessage(Inbound, task("t", "late")); err == nil {
+			t.Error("closed recorder wrote an entry")
+		}
+	}
+
+	entries, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Errorf("%d entries, want one per run", len(entries))
+	}
+}
+
+func TestReadRejectsGarbage(t *testing.T) {
+	if _, err := Read(strings.NewReader("{\"direction\":\"in\"}\n\nnope\n")); err == nil || !strings.Contains(err.Error(), "line 3") {
+		t.Errorf("got %v, want an error on line 3", err)
+	}
+}
@@ -0,0 +1,300 @@
+// Package redact detects and masks personal data and secrets in text: email
+// addresses, phone numbers, credit card numbers, wallet addresses and keys,
+// and whatever custom patterns the agent adds.
+//
+// A Redactor can scrub task input before it reaches handlers, caches, logs or
+// third-party LLMs, and its Process method can be added as a task response
+// post-processor:
+//
+//	redactor := redact.New(nil)
+//	clean := redactor.Redact("mail me at jane@example.com") // "mail me at [REDACTED_EMAIL]"
+//	coordinator.AddPostProcessor("redact", redactor.Process)
+package redact
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Kind is a category of sensitive data
+type Kind string
+
+// Kinds of sensitive data
+const (
+	KindSecret     Kind = "secret"      // Private keys, API keys and bearer tokens
+	KindWallet     Kind = "wallet"      // Ethereum addresses
+	KindEmail      Kind = "email"       // Email addresses
+	KindCreditCard Kind = "credit_card" // Card numbers passing the Luhn check
+	KindPhone      Kind = "phone"       // Phone numbers
+)
+
+// AllKinds returns every kind, in the order they are detected
+func AllKinds() []Kind {
+	return []Kind{KindSecret, KindWallet, KindEmail, KindCreditCard, KindPhone}
+}
+
+// Config configures a Redactor
+type Config struct {
+	// Kinds are the categories to redact (default: all)
+	Kinds []Kind
+
+	// Replacement is the text substituted for a match; %s is replaced with the
+	// upper-case kind (default "[REDACTED_%s]")
+	Replacement string
+
+	// Allow lists values that are never redacted, e.g. the agent's own wallet
+	// address or a public support email (compared case-insensitively)
+	Allow []string
+
+	// Patterns are custom detectors by kind, e.g. {"employee_id": EMP-\d{6}}.
+	// They run after the built-in kinds, in the order of their kinds' names,
+	// and are not restricted by Kinds.
+	Patterns map[Kind]*regexp.Regexp
+}
+
+// DefaultConfig returns a configuration redacting every kind
+func DefaultConfig() *Config {
+	return &Config{
+		Kinds:       AllKinds(),
+		Replacement: "[REDACTED_%s]",
+	}
+}
+
+// Match is a piece of sensitive data found in a text
+type Match struct {
+	Kind  Kind
+	Start int // Byte offset of the first character
+	End   int // Byte offset after the last character
+	Value string
+}
+
+// rule detects one kind of sensitive data
+type rule struct {
+	kind    Kind
+	pattern *regexp.Regexp
+	valid   func(string) bool // Optional check of a pattern match
+}
+
+// rules lists the detectors in priority order: earlier rules win overlapping matches
+var rules = []rule{
+	{kind: KindSecret, pattern: regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`)},
+	{kind: KindSecret, pattern: regexp.MustCompile(`\b(?:sk|pk|rk)-[A-Za-z0-9_-]{20,}`)},
+	{kind: KindSecret, pattern: regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{kind: KindSecret, pattern: regexp.MustCompile(`\bgh[pousr]_[A-Za-z0-9]{36,}\b`)},
+	{kind: KindSecret, pattern: regexp.MustCompile(`\bxox[abprs]-[A-Za-z0-9-]{10,}`)},
+	{kind: KindSecret, pattern: regexp.MustCompile(`(?i)\bbearer\s+[A-Za-z0-9._~+/-]{20,}=*`)},
+	{kind: KindSecret, pattern: regexp.MustCompile(`\b(?:0x)?[0-9a-fA-F]{64}\b`)},
+	{kind: KindWallet, pattern: regexp.MustCompile(`\b0x[0-9a-fA-F]{40}\b`)},
+	{kind: KindEmail, pattern: regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9-]+(?:\.[A-Za-z0-9-]+)*\.[A-Za-z]{2,}`)},
+	{kind: KindCreditCard, pattern: regexp.MustCompile(`\b\d(?:[ -]?\d){12,18}\b`), valid: luhnValid},
+	{kind: KindPhone, pattern: regexp.MustCompile(`\+\d{1,3}[\s.-]?(?:\(\d{1,4}\)[\s.-]?)?\d{1,4}(?:[\s.-]?\d{2,4}){1,4}\b|\(\d{3}\)\s?\d{3}[\s.-]\d{4}\b|\b\d{3}[\s.-]\d{3}[\s.-]\d{4}\b`), valid: phoneValid},
+}
+
+// Redactor finds and masks sensitive data
+type Redactor struct {
+	detectors   []rule // Built-in rules of the configured kinds, then the custom patterns
+	replacement string
+	allow       map[string]bool
+}
+
+// New creates a redactor
+func New(config *Config) *Redactor {
+	if config == nil {
+		config = DefaultConfig()
+	}
+
+	kinds := config.Kinds
+	if len(kinds) == 0 {
+		kinds = AllKinds()
+	}
+	replacement := config.Replacement
+	if replacement == "" {
+		replacement = "[REDACTED_%s]"
+	}
+
+	r := &Redactor{
+		replacement: replacement,
+		allow:       make(map[string]bool, len(config.Allow)),
+	}
+	enabled := make(map[Kind]bool, len(kinds))
+	for _, kind := range kinds {
+		enabled[kind] = true
+	}
+	for _, rule := range rules {
+		if enabled[rule.kind] {
+			r.detectors = append(r.detectors, rule)
+		}
+	}
+	custom := make([]rule, 0, len(config.Patterns))
+	for kind, pattern := range config.Patterns {
+		custom = append(custom, rule{kind: kind, pattern: pattern})
+	}
+	sort.Slice(custom, func(i, j int) bool {
+		return custom[i].kind < custom[j].kind
+	})
+	r.detectors = append(r.detectors, custom...)
+	for _, value := range config.Allow {
+		r.allow[strings.ToLower(value)] = true
+	}
+	return r
+}
+
+// ParseKinds parses a comma-separated list of kinds ("all" or empty means every kind)
+func ParseKinds(list string) ([]Kind, error) {
+	list = strings.TrimSpace(list)
+	if list == "" || strings.EqualFold(list, "all") {
+		return AllKinds(), nil
+	}
+
+	var kinds []Kind
+	for _, name := range strings.Split(list, ",") {
+		kind := Kind(strings.ToLower(strings.TrimSpace(name)))
+		if !isKind(kind) {
+			return nil, fmt.Errorf("unknown redaction kind %q", name)
+		}
+		kinds = append(kinds, kind)
+	}
+	return kinds, nil
+}
+
+// ParsePatterns parses custom patterns given as "kind=regexp" pairs separated
+// by semicolons, e.g. "employee_id=EMP-\d{6};ticket=TCK-[0-9]+"
+func ParsePatterns(list string) (map[Kind]*regexp.Regexp, error) {
+	patterns := make(map[Kind]*regexp.Regexp)
+	for _, pair := range strings.Split(list, ";") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		}
+		name, expr, ok := strings.Cut(pair, "=")
+		kind := Kind(strings.ToLower(strings.TrimSpace(name)))
+		if !ok || kind == "" || strings.TrimSpace(expr) == "" {
+			return nil, fmt.Errorf("expected kind=regexp, got %q", pair)
+		}
+		if isKind(kind) {
+			return nil, fmt.Errorf("custom redaction kind %q is built in", kind)
+		}
+		pattern, err := regexp.Compile(strings.TrimSpace(expr))
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern for %s: %w", kind, err)
+		}
+		patterns[kind] = pattern
+	}
+	return patterns, nil
+}
+
+// isKind reports whether kind is a known kind
+func isKind(kind Kind) bool {
+	for _, known := range AllKinds() {
+		if kind == known {
+			return true
+		}
+	}
+	return false
+}
+
+// Find returns the sensitive data in text, ordered by position.
+// Where matches overlap, the earlier detector wins.
+func (r *Redactor) Find(text string) []Match {
+	var matches []Match
+	taken := func(start, end int) bool {
+		for _, m := range matches {
+			if start < m.End && m.Start < end {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, rule := range r.detectors {
+		for _, loc := range rule.pattern.FindAllStringIndex(text, -1) {
+			value := text[loc[0]:loc[1]]
+			if rule.valid != nil && !rule.valid(value) {
+				continue
+			}
+			if r.allow[strings.ToLower(value)] || taken(loc[0], loc[1]) {
+				continue
+			}
+			matches = append(matches, Match{Kind: rule.kind, Start: loc[0], End: loc[1], Value: value})
+		}
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		return matches[i].Start < matches[j].Start
+	})
+	return matches
+}
+
+// Contains reports whether text contains sensitive data
+func (r *Redactor) Contains(text string) bool {
+	return len(r.Find(text)) > 0
+}
+
+// Redact replaces the sensitive data in text
+func (r *Redactor) Redact(text string) string {
+	matches := r.Find(text)
+	if len(matches) == 0 {
+		return text
+	}
+
+	var b strings.Builder
+	b.Grow(len(text))
+	last := 0
+	for _, m := range matches {
+		b.WriteString(text[last:m.Start])
+		b.WriteString(r.placeholder(m.Kind))
+		last = m.End
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
+
+// Process redacts content. Its signature matches network.PostProcessor, so it
+// can be added to the task response pipeline.
+func (r *Redactor) Process(ctx context.Context, contentType, content string) (string, error) {
+	return r.Redact(content), nil
+}
+
+// placeholder returns the replacement text of a kind
+func (r *Redactor) placeholder(kind Kind) string {
+	if !strings.Contains(r.replacement, "%s") {
+		return r.replacement
+	}
+	return strings.ReplaceAll(r.replacement, "%s", strings.ToUpper(string(kind)))
+}
+
+// luhnValid reports whether the digits of a card number pass the Luhn checksum
+func luhnValid(number string) bool {
+	sum, count := 0, 0
+	double := false
+	for i := len(number) - 1; i >= 0; i-- {
+		c := number[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		d := int(c - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+		count++
+	}
+	return count >= 13 && count <= 19 && sum%10 == 0
+}
+
+// phoneValid reports whether a phone number candidate has a plausible number of digits
+func phoneValid(number string) bool {
+	digits := 0
+	for _, c := range number {
+		if c >= '0' && c <= '9' {
+			digits++
+		}
+	}
+	return digits >= 7 && digits <= 15
+}
@@ -0,0 +1,188 @@
+// Package retrystore persists the messages waiting in the network client's
+// retry queue, so responses that failed to send are retried after the process
+// restarts instead of being lost with it. A store keeps a snapshot of the
+// queue in a local file or in the agent cache (Redis when enabled), together
+// with the keys of recently delivered messages so a message is not sent
+// twice when the queue is reloaded, and the dead letters the queue gave up on.
+package retrystore
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/cache"
+)
+
+// DefaultPath is where a File store keeps the queue when no path is configured
+const DefaultPath = ".teneo/retry-queue.json"
+
+// DefaultCacheKey is the cache key of a Cache store when none is configured
+const DefaultCacheKey = "retry_queue"
+
+// MaxSent is the number of delivered message keys a retry queue remembers
+const MaxSent = 1000
+
+// Entry is a message waiting to be sent again
+type Entry struct {
+	Key        string          `json:"key"`     // Identifies the message, see Key
+	Message    json.RawMessage `json:"message"` // The message as it is sent
+	RetryCount int             `json:"retry_count"`
+	QueuedAt   time.Time       `json:"queued_at"`
+	NextRetry  time.Time       `json:"next_retry"`
+	LastError  string          `json:"last_error,omitempty"`
+}
+
+// DeadEntry is a message the retry queue gave up on
+type DeadEntry struct {
+	Entry
+	Reason string    `json:"reason"`
+	DeadAt time.Time `json:"dead_at"`
+}
+
+// Snapshot is the persisted state of a retry queue
+type Snapshot struct {
+	Pending []Entry     `json:"pending"`
+	Sent    []string    `json:"sent,omitempty"` // Keys of delivered messages, oldest first
+	Dead    []DeadEntry `json:"dead,omitempty"` // Dead letters, oldest first
+}
+
+// Store loads and saves the snapshot of a retry queue. Implementations must
+// be safe for concurrent use.
+type Store interface {
+	// Load returns the saved snapshot, or an empty one if nothing was saved
+	Load(ctx context.Context) (*Snapshot, error)
+	// Save replaces the saved snapshot
+	Save(ctx context.Context, snapshot *Snapshot) error
+}
+
+// Key returns the key that identifies a message for deduplication: a hash
+// of the message as sent, so the same message queued twice, or reloaded
+// after it was delivered, has the same key
+func Key(message []byte) string {
+	sum := sha256.Sum256(message)
+	return hex.EncodeToString(sum[:16])
+}
+
+// File keeps the snapshot in a JSON file
+type File struct {
+	path string
+}
+
+// NewFile returns a store keeping the snapshot at path (empty = DefaultPath)
+func NewFile(path string) *File {
+	if path == "" {
+		path = DefaultPath
+	}
+	return &File{path: path}
+}
+
+// Path returns the file the snapshot is kept in
+func (f *File) Path() string {
+	return f.path
+}
+
+// Load reads the snapshot from the file
+func (f *File) Load(ctx context.Context) (*Snapshot, error) {
+	data, err := os.ReadFile(f.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &Snapshot{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read retry queue: %w", err)
+	}
+	return decode(data)
+}
+
+// Save replaces the file atomically, so a crash never leaves a partial queue
+// behind
+func (f *File) Save(ctx context.Context, snapshot *Snapshot) error {
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return fmt.Errorf("failed to marshal retry queue: %w", err)
+	}
+	dir := filepath.Dir(f.path)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return fmt.Errorf("failed to create retry queue directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(dir, ".retry-queue-*")
+	if err != nil {
+		return fmt.Errorf("failed to write retry queue: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write retry queue: %w", err)
+	}
+	if err := tmp.Chmod(0o600); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write retry queue: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write retry queue: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), f.path); err != nil {
+		return fmt.Errorf("failed to write retry queue: %w", err)
+	}
+	return nil
+}
+
+// Cache keeps the snapshot under a key of the agent cache, e.g. in Redis so
+// it survives the loss of the machine
+type Cache struct {
+	cache cache.AgentCache
+	key   string
+}
+
+// NewCache returns a store keeping the snapshot under key (empty =
+// DefaultCacheKey) in the cache
+func NewCache(agentCache cache.AgentCache, key string) *Cache {
+	if key == "" {
+		key = DefaultCacheKey
+	}
+	return &Cache{cache: agentCache, key: key}
+}
+
+// Load reads the snapshot from the cache
+func (c *Cache) Load(ctx context.Context) (*Snapshot, error) {
+	exists, err := c.cache.Exists(ctx, c.key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read retry queue: %w", err)
+	}
+	if !exists {
+		return &Snapshot{}, nil
+	}
+	data, err := c.cache.GetBytes(ctx, c.key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read retry queue: %w", err)
+	}
+	return decode(data)
+}
+
+// Save stores the snapshot in the cache, without expiry
+func (c *Cache) Save(ctx context.Context, snapshot *Snapshot) error {
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return fmt.Errorf("failed to marshal retry queue: %w", err)
+	}
+	if err := c.cache.Set(ctx, c.key, data, 0); err != nil {
+		return fmt.Errorf("failed to write retry queue: %w", err)
+	}
+	return nil
+}
+
+// decode parses a saved snapshot
+func decode(data []byte) (*Snapshot, error) {
+	var snapshot Snapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, fmt.Errorf("failed to parse retry queue: %w", err)
+	}
+	return &snapshot, nil
+}
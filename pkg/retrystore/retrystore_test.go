@@ -0,0 +1,105 @@
+package retrystore
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/cache"
+)
+
+func snapshot() *Snapshot {
+	message := json.RawMessage(`{"type":"task_response","task_id":"t1"}`)
+	return &Snapshot{
+		Pending: []Entry{{
+			Key:        Key(message),
+			Message:    message,
+			RetryCount: 2,
+			QueuedAt:   time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+			NextRetry:  time.Date(2025, 1, 1, 0, 0, 4, 0, time.UTC),
+			LastError:  "send timeout",
+		}},
+		Sent: []string{"delivered"},
+		Dead: []DeadEntry{{
+			Entry:  Entry{Key: "dead", Message: json.RawMessage(`{"type":"task_response","task_id":"t0"}`), RetryCount: 3},
+			Reason: "max_retries",
+			DeadAt: time.Date(2025, 1, 1, 0, 1, 0, 0, time.UTC),
+		}},
+	}
+}
+
+func roundTrip(t *testing.T, store Store) {
+	t.Helper()
+	ctx := context.Background()
+
+	empty, err := store.Load(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(empty.Pending) != 0 || len(empty.Sent) != 0 {
+		t.Errorf("new store holds %+v, want nothing", empty)
+	}
+
+	if err := store.Save(ctx, snapshot()); err != nil {
+		t.Fatal(err)
+	}
+	got, err := store.Load(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := snapshot()
+	if len(got.Pending) != 1 || got.Pending[0].Key != want.Pending[0].Key || got.Pending[0].RetryCount != 2 ||
+		!got.Pending[0].NextRetry.Equal(want.Pending[0].NextRetry) || got.Pending[0].LastError != "send timeout" {
+		t.Errorf("loaded %+v, want %+v", got.Pending, want.Pending)
+	}
+	if string(got.Pending[0].Message) != string(want.Pending[0].Message) {
+		t.Errorf("message %s, want %s", got.Pending[0].Message, want.Pending[0].Message)
+	}
+	if len(got.Sent) != 1 || got.Sent[0] != "delivered" {
+		t.Errorf("sent keys %v", got.Sent)
+	}
+	if len(got.Dead) != 1 || got.Dead[0].Key != "dead" || got.Dead[0].RetryCount != 3 || got.Dead[0].Reason != "max_retries" {
+		t.Errorf("dead letters %+v", got.Dead)
+	}
+}
+
+func TestFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "retry-queue.json")
+	roundTrip(t, NewFile(path))
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Errorf("file mode %v, want 0600", info.Mode().Perm())
+	}
+
+	os.WriteFile(path, []byte("{"), 0o600)
+	if _, err := NewFile(path).Load(context.Background()); err == nil {
+		t.Error("loaded a corrupt file")
+	}
+}
+
+func TestCache(t *testing.T) {
+	agentCache := cache.NewMemoryCache(nil)
+	defer agentCache.Close()
+	roundTrip(t, NewCache(agentCache, ""))
+
+	if exists, _ := agentCache.Exists(context.Background(), DefaultCacheKey); !exists {
+		t.Errorf("snapshot not stored under %q", DefaultCacheKey)
+	}
+}
+
+func TestKey(t *testing.T) {
+	a := Key([]byte(`{"type":"task_response","task_id":"t1"}`))
+	if a != Key([]byte(`{"type":"task_response","task_id":"t1"}`)) {
+		t.Error("same message has different keys")
+	}
+	if a == Key([]byte(`{"type":"task_response","task_id":"t2"}`)) {
+		t.Error("different messages have the same key")
+	}
+}
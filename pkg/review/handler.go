@@ -0,0 +1,87 @@
+package review
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/internal/httputil"
+)
+
+// PathPrefix is the path under which the review API is served
+const PathPrefix = "/review/"
+
+// decisionRequest is the request body for approving or rejecting a response
+type decisionRequest struct {
+	Content string `json:"content,omitempty"` // Replacement response (approve only)
+	Reason  string `json:"reason,omitempty"`  // Rejection reason (reject only)
+}
+
+// Handler returns an HTTP handler for inspecting and deciding pending responses.
+// Every request must carry "Authorization: Bearer <token>".
+//
+// Endpoints:
+//
+//	GET  /review/pending               - list pending responses
+//	GET  /review/pending/{id}          - a single pending response
+//	POST /review/pending/{id}/approve  - send the response (optional {"content": "..."} replaces it)
+//	POST /review/pending/{id}/reject   - withhold the response (optional {"reason": "..."})
+func (g *Gate) Handler(token string) http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /review/pending", func(w http.ResponseWriter, req *http.Request) {
+		httputil.WriteJSON(w, http.StatusOK, g.Pending())
+	})
+
+	mux.HandleFunc("GET /review/pending/{id}", func(w http.ResponseWriter, req *http.Request) {
+		item, ok := g.Get(req.PathValue("id"))
+		if !ok {
+			httputil.WriteError(w, http.StatusNotFound, ErrPendingNotFound.Error())
+			return
+		}
+		httputil.WriteJSON(w, http.StatusOK, item)
+	})
+
+	mux.HandleFunc("POST /review/pending/{id}/approve", func(w http.ResponseWriter, req *http.Request) {
+		body, ok := readDecision(w, req)
+		if !ok {
+			return
+		}
+		writeDecision(w, g.Approve(req.PathValue("id"), body.Content), "approved")
+	})
+
+	mux.HandleFunc("POST /review/pending/{id}/reject", func(w http.ResponseWriter, req *http.Request) {
+		body, ok := readDecision(w, req)
+		if !ok {
+			return
+		}
+		writeDecision(w, g.Reject(req.PathValue("id"), body.Reason), "rejected")
+	})
+
+	return httputil.RequireToken(token, mux)
+}
+
+// readDecision decodes an optional decision body
+func readDecision(w http.ResponseWriter, req *http.Request) (decisionRequest, bool) {
+	var body decisionRequest
+	if req.ContentLength == 0 {
+		return body, true
+	}
+	if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+		httputil.WriteError(w, http.StatusBadRequest, "invalid request body")
+		return body, false
+	}
+	return body, true
+}
+
+// writeDecision writes the result of an approve or reject call
+func writeDecision(w http.ResponseWriter, err error, status string) {
+	switch {
+	case err == nil:
+		httputil.WriteJSON(w, http.StatusOK, map[string]string{"status": status})
+	case errors.Is(err, ErrPendingNotFound):
+		httputil.WriteError(w, http.StatusNotFound, err.Error())
+	default:
+		httputil.WriteError(w, http.StatusInternalServerError, err.Error())
+	}
+}
@@ -0,0 +1,232 @@
+package review
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/logging"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/types"
+)
+
+// TimeoutAction decides what happens to a pending response nobody reviewed in time
+type TimeoutAction string
+
+const (
+	// TimeoutRelease sends the response unchanged when the review times out
+	TimeoutRelease TimeoutAction = "release"
+	// TimeoutReject withholds the response when the review times out
+	TimeoutReject TimeoutAction = "reject"
+)
+
+var (
+	// ErrRejected is returned when a reviewer rejects a response
+	ErrRejected = types.ErrResponseRejected
+
+	// ErrPendingNotFound is returned when approving or rejecting an unknown (or already decided) response
+	ErrPendingNotFound = errors.New("pending response not found")
+)
+
+// Config holds configuration for the review gate
+type Config struct {
+	Threshold     float64             // Responses scoring at or above this risk are held (0..1)
+	Timeout       time.Duration       // How long a response waits for review (0 = wait until the task is cancelled)
+	TimeoutAction TimeoutAction       // What happens on timeout (default: release)
+	Scorer        Scorer              // Risk scorer (defaults to a keyword scorer with DefaultRiskTerms)
+	OnPending     func(p PendingItem) // Optional callback invoked when a response is held
+}
+
+// DefaultConfig returns a configuration holding responses with a risk of 0.5 or more for up to 5 minutes
+func DefaultConfig() *Config {
+	return &Config{
+		Threshold:     0.5,
+		Timeout:       5 * time.Minute,
+		TimeoutAction: TimeoutRelease,
+	}
+}
+
+// PendingItem is a response waiting for review
+type PendingItem struct {
+	ID        string    `json:"id"`
+	TaskID    string    `json:"task_id"`
+	Room      string    `json:"room"`
+	Task      string    `json:"task"`
+	Response  string    `json:"response"`
+	Score     float64   `json:"score"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// decision is the outcome of a review
+type decision struct {
+	approved bool
+	content  string
+	reason   string
+}
+
+// pending is a held response and the channel its decision is delivered on
+type pending struct {
+	item     PendingItem
+	decision chan decision
+}
+
+// Gate holds risky responses until they are approved, rejected or time out.
+// It implements the types.ResponseReviewer interface.
+type Gate struct {
+	config  *Config
+	pending map[string]*pending
+	mu      sync.Mutex
+}
+
+// NewGate creates a new review gate
+func NewGate(config *Config) *Gate {
+	if config == nil {
+		config = DefaultConfig()
+	}
+	if config.Scorer == nil {
+		config.Scorer = NewKeywordScorer(DefaultRiskTerms)
+	}
+	if config.TimeoutAction == "" {
+		config.TimeoutAction = TimeoutRelease
+	}
+
+	return &Gate{
+		config:  config,
+		pending: make(map[string]*pending),
+	}
+}
+
+// ReviewResponse implements the types.ResponseReviewer interface.
+// Low-risk responses are returned immediately; risky ones block until a decision is made.
+func (g *Gate) ReviewResponse(ctx context.Context, taskID, room, task, response string) (string, error) {
+	score := g.config.Scorer.Score(task, response)
+	if score < g.config.Threshold {
+		return response, nil
+	}
+
+	p := &pending{
+		item: PendingItem{
+			ID:        newID(),
+			TaskID:    taskID,
+			Room:      room,
+			Task:      task,
+			Response:  response,
+			Score:     score,
+			CreatedAt: time.Now(),
+		},
+		decision: make(chan decision, 1),
+	}
+	if g.config.Timeout > 0 {
+		p.item.ExpiresAt = p.item.CreatedAt.Add(g.config.Timeout)
+	}
+
+	g.mu.Lock()
+	g.pending[p.item.ID] = p
+	g.mu.Unlock()
+	defer g.remove(p.item.ID)
+
+	logging.Info("holding response for review", "task_id", taskID, "review_id", p.item.ID, "risk", score)
+	if g.config.OnPending != nil {
+		g.config.OnPending(p.item)
+	}
+
+	var timeout <-chan time.Time
+	if g.config.Timeout > 0 {
+		timer := time.NewTimer(g.config.Timeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	select {
+	case d := <-p.decision:
+		if !d.approved {
+			logging.Warn("response rejected by reviewer", "task_id", taskID, "reason", d.reason)
+			return "", ErrRejected
+		}
+		logging.Info("response approved", "task_id", taskID)
+		if d.content != "" {
+			return d.content, nil
+		}
+		return response, nil
+	case <-timeout:
+		if g.config.TimeoutAction == TimeoutReject {
+			logging.Warn("review timed out, withholding response", "task_id", taskID)
+			return "", ErrRejected
+		}
+		logging.Warn("review timed out, releasing response", "task_id", taskID)
+		return response, nil
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+// Approve releases a pending response. A non-empty content replaces the original response.
+func (g *Gate) Approve(id, content string) error {
+	return g.decide(id, decision{approved: true, content: content})
+}
+
+// Reject withholds a pending response
+func (g *Gate) Reject(id, reason string) error {
+	return g.decide(id, decision{reason: reason})
+}
+
+// Pending returns the responses waiting for review, oldest first
+func (g *Gate) Pending() []PendingItem {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	items := make([]PendingItem, 0, len(g.pending))
+	for _, p := range g.pending {
+		items = append(items, p.item)
+	}
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].CreatedAt.Before(items[j].CreatedAt)
+	})
+	return items
+}
+
+// Get returns a pending response by ID
+func (g *Gate) Get(id string) (PendingItem, bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	p, ok := g.pending[id]
+	if !ok {
+		return PendingItem{}, false
+	}
+	return p.item, true
+}
+
+// decide delivers a decision and removes the response from the queue
+func (g *Gate) decide(id string, d decision) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	p, ok := g.pending[id]
+	if !ok {
+		return ErrPendingNotFound
+	}
+	delete(g.pending, id)
+	p.decision <- d
+	return nil
+}
+
+// remove drops a response from the queue
+func (g *Gate) remove(id string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	delete(g.pending, id)
+}
+
+// newID returns a random identifier for a pending response
+func newID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return time.Now().Format("20060102150405.000000000")
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,161 @@
+package review
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// riskyScorer scores every response containing "risky" as 1
+var riskyScorer = ScorerFunc(func(task, response string) float64 {
+	if strings.Contains(response, "risky") {
+		return 1
+	}
+	return 0
+})
+
+// newTestGate creates a gate that reports held responses on the returned channel
+func newTestGate(timeout time.Duration, action TimeoutAction) (*Gate, chan PendingItem) {
+	held := make(chan PendingItem, 1)
+	gate := NewGate(&Config{
+		Threshold:     0.5,
+		Timeout:       timeout,
+		TimeoutAction: action,
+		Scorer:        riskyScorer,
+		OnPending:     func(p PendingItem) { held <- p },
+	})
+	return gate, held
+}
+
+func TestReviewDecisions(t *testing.T) {
+	tests := []struct {
+		name     string
+		response string
+		decide   func(g *Gate, id string) error
+		want     string
+		wantErr  error
+	}{
+		{"low risk passes through", "safe", nil, "safe", nil},
+		{"approved", "risky", func(g *Gate, id string) error { return g.Approve(id, "") }, "risky", nil},
+		{"approved with edit", "risky", func(g *Gate, id string) error { return g.Approve(id, "edited") }, "edited", nil},
+		{"rejected", "risky", func(g *Gate, id string) error { return g.Reject(id, "no") }, "", ErrRejected},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gate, held := newTestGate(time.Minute, TimeoutRelease)
+			if tt.decide != nil {
+				go func() {
+					item := <-held
+					if err := tt.decide(gate, item.ID); err != nil {
+						t.Errorf("decision failed: %v", err)
+					}
+				}()
+			}
+
+			got, err := gate.ReviewResponse(context.Background(), "task-1", "room", "task", tt.response)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if got != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+			if len(gate.Pending()) != 0 {
+				t.Errorf("expected empty queue after decision")
+			}
+		})
+	}
+}
+
+func TestReviewTimeout(t *testing.T) {
+	tests := []struct {
+		name    string
+		action  TimeoutAction
+		want    string
+		wantErr error
+	}{
+		{"auto-release", TimeoutRelease, "risky", nil},
+		{"auto-reject", TimeoutReject, "", ErrRejected},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gate, _ := newTestGate(10*time.Millisecond, tt.action)
+
+			got, err := gate.ReviewResponse(context.Background(), "task-1", "room", "task", "risky")
+			if !errors.Is(err, tt.wantErr) || got != tt.want {
+				t.Errorf("expected (%q, %v), got (%q, %v)", tt.want, tt.wantErr, got, err)
+			}
+		})
+	}
+}
+
+func TestReviewHandler(t *testing.T) {
+	gate, held := newTestGate(time.Minute, TimeoutRelease)
+	handler := gate.Handler("secret")
+
+	result := make(chan string, 1)
+	go func() {
+		response, _ := gate.ReviewResponse(context.Background(), "task-1", "room", "task", "risky")
+		result <- response
+	}()
+	item := <-held
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		token      string
+		wantStatus int
+	}{
+		{"missing token", http.MethodGet, "/review/pending", "", "", http.StatusUnauthorized},
+		{"list pending", http.MethodGet, "/review/pending", "", "secret", http.StatusOK},
+		{"get pending", http.MethodGet, "/review/pending/" + item.ID, "", "secret", http.StatusOK},
+		{"unknown id", http.MethodPost, "/review/pending/nope/approve", "", "secret", http.StatusNotFound},
+		{"approve", http.MethodPost, "/review/pending/" + item.ID + "/approve", `{"content":"edited"}`, "secret", http.StatusOK},
+		{"already decided", http.MethodPost, "/review/pending/" + item.ID + "/reject", "", "secret", http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("expected status %d, got %d: %s", tt.wantStatus, rec.Code, rec.Body.String())
+			}
+		})
+	}
+
+	if response := <-result; response != "edited" {
+		t.Errorf("expected edited response, got %q", response)
+	}
+}
+
+func TestKeywordScorer(t *testing.T) {
+	scorer := NewKeywordScorer(map[string]float64{"Seed Phrase": 1, "transfer": 0.5})
+
+	tests := []struct {
+		response string
+		want     float64
+	}{
+		{"the weather is nice", 0},
+		{"I can transfer that for you", 0.5},
+		{"Please share your seed phrase to transfer", 1},
+	}
+
+	for _, tt := range tests {
+		if got := scorer.Score("", tt.response); got != tt.want {
+			t.Errorf("Score(%q) = %v, want %v", tt.response, got, tt.want)
+		}
+	}
+}
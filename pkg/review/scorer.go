@@ -0,0 +1,59 @@
+package review
+
+import "strings"
+
+// Scorer rates how risky a response is, from 0 (safe) to 1 (must be reviewed)
+type Scorer interface {
+	Score(task, response string) float64
+}
+
+// ScorerFunc adapts a function to the Scorer interface
+type ScorerFunc func(task, response string) float64
+
+// Score implements the Scorer interface
+func (f ScorerFunc) Score(task, response string) float64 {
+	return f(task, response)
+}
+
+// DefaultRiskTerms are phrases that commonly indicate a response needs a human look
+var DefaultRiskTerms = map[string]float64{
+	"private key":         1.0,
+	"seed phrase":         1.0,
+	"mnemonic":            0.8,
+	"password":            0.7,
+	"transfer":            0.5,
+	"send funds":          0.7,
+	"guaranteed return":   0.8,
+	"financial advice":    0.6,
+	"medical advice":      0.6,
+	"legal advice":        0.6,
+	"investment":          0.4,
+	"approve transaction": 0.8,
+}
+
+// KeywordScorer scores a response by the highest weighted term it contains
+type KeywordScorer struct {
+	terms map[string]float64
+}
+
+// NewKeywordScorer creates a scorer from terms and their weights (matching is case-insensitive)
+func NewKeywordScorer(terms map[string]float64) *KeywordScorer {
+	normalized := make(map[string]float64, len(terms))
+	for term, weight := range terms {
+		normalized[strings.ToLower(term)] = weight
+	}
+	return &KeywordScorer{terms: normalized}
+}
+
+// Score implements the Scorer interface
+func (s *KeywordScorer) Score(task, response string) float64 {
+	text := strings.ToLower(response)
+
+	score := 0.0
+	for term, weight := range s.terms {
+		if weight > score && strings.Contains(text, term) {
+			score = weight
+		}
+	}
+	return score
+}
@@ -0,0 +1,344 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/logging"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/types"
+)
+
+// CatchUp decides what happens to runs of a job missed while the agent was down
+type CatchUp string
+
+const (
+	// CatchUpSkip drops missed runs (default)
+	CatchUpSkip CatchUp = "skip"
+	// CatchUpOnce runs the job once if any run was missed
+	CatchUpOnce CatchUp = "once"
+	// CatchUpAll runs the job for every missed run, up to CronConfig.MaxCatchUp
+	CatchUpAll CatchUp = "all"
+)
+
+// maxMissedScan bounds the search for missed runs of frequent jobs after a long downtime
+const maxMissedScan = 100000
+
+// Job is a recurring job. Its output is sent to Room through the MessageSender
+// of each run.
+type Job struct {
+	Name     string        // Unique; the job's last run is stored under this name
+	Schedule Schedule      // See ParseSchedule and Every
+	Room     string        // Room the job's messages are sent to (empty = the agent's room)
+	CatchUp  CatchUp       // Runs missed while the agent was down (default skip)
+	Timeout  time.Duration // Cancels a run after this long (0 = no limit)
+
+	// Run executes the job. A run that takes longer than the interval delays
+	// the next one; runs never overlap.
+	Run func(ctx context.Context, run JobRun) error
+}
+
+// JobRun describes one run of a job
+type JobRun struct {
+	Job         string
+	ScheduledAt time.Time
+	CatchUp     bool                // The run was missed while the agent was down
+	Sender      types.MessageSender // Sends to the job's room; nil if the cron has no SenderFunc
+}
+
+// JobStatus reports the state of a job
+type JobStatus struct {
+	Name      string    `json:"name"`
+	Schedule  string    `json:"schedule"`
+	Room      string    `json:"room,omitempty"`
+	Next      time.Time `json:"next,omitempty"`
+	LastRun   time.Time `json:"last_run,omitempty"`
+	LastError string    `json:"last_error,omitempty"`
+	Running   bool      `json:"running"`
+}
+
+// SenderFunc returns the MessageSender of a job run; runID identifies the run
+// in the messages in place of a task ID
+type SenderFunc func(ctx context.Context, runID, room string) types.MessageSender
+
+// StateStore persists the last run of each job, e.g. cache.AgentCache
+type StateStore interface {
+	Get(ctx context.Context, key string) (string, error)
+	Set(ctx context.Context, key string, value interface{}, ttl time.Duration) error
+}
+
+// CronConfig configures a Cron
+type CronConfig struct {
+	Sender     SenderFunc     // Creates the MessageSender of each run (nil = runs get no sender)
+	Store      StateStore     // Keeps the last run of each job across restarts (nil = catch-up is disabled)
+	KeyPrefix  string         // Prefix of the store keys (default "scheduler:job:")
+	MaxCatchUp int            // Missed runs executed at most per job with CatchUpAll (default 10)
+	Location   *time.Location // Time zone of cron expressions (default local time)
+}
+
+// Cron runs recurring jobs. It is safe for concurrent use.
+type Cron struct {
+	mu      sync.Mutex
+	config  CronConfig
+	entries map[string]*cronEntry
+	ctx     context.Context // Set while started
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+	now     func() time.Time
+}
+
+type cronEntry struct {
+	job     Job
+	stop    chan struct{}
+	next    time.Time
+	lastRun time.Time
+	lastErr error
+	running bool
+}
+
+// NewCron creates a cron (nil config = no sender and no persistence)
+func NewCron(config *CronConfig) *Cron {
+	c := CronConfig{}
+	if config != nil {
+		c = *config
+	}
+	if c.KeyPrefix == "" {
+		c.KeyPrefix = "scheduler:job:"
+	}
+	if c.MaxCatchUp <= 0 {
+		c.MaxCatchUp = 10
+	}
+	if c.Location == nil {
+		c.Location = time.Local
+	}
+	return &Cron{config: c, entries: make(map[string]*cronEntry), now: time.Now}
+}
+
+// Add adds a job. Jobs added after Start are scheduled right away.
+func (c *Cron) Add(job Job) error {
+	if job.Name == "" || job.Schedule == nil || job.Run == nil {
+		return errors.New("job needs a name, a schedule and a run function")
+	}
+	switch job.CatchUp {
+	case "":
+		job.CatchUp = CatchUpSkip
+	case CatchUpSkip, CatchUpOnce, CatchUpAll:
+	default:
+		return fmt.Errorf("invalid catch-up policy %q (use \"skip\", \"once\" or \"all\")", job.CatchUp)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, exists := c.entries[job.Name]; exists {
+		return fmt.Errorf("job %q already exists", job.Name)
+	}
+	e := &cronEntry{job: job, stop: make(chan struct{})}
+	c.entries[job.Name] = e
+	if c.ctx != nil {
+		c.wg.Add(1)
+		go c.loop(c.ctx, e)
+	}
+	return nil
+}
+
+// Remove removes a job, letting a running run finish. It reports false if
+// there is no such job.
+func (c *Cron) Remove(name string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[name]
+	if !ok {
+		return false
+	}
+	close(e.stop)
+	delete(c.entries, name)
+	return true
+}
+
+// Start schedules the jobs, first catching up on runs missed while the agent
+// was down. Starting a started cron does nothing.
+func (c *Cron) Start(ctx context.Context) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.ctx != nil {
+		return
+	}
+	c.ctx, c.cancel = context.WithCancel(ctx)
+	for _, e := range c.entries {
+		c.wg.Add(1)
+		go c.loop(c.ctx, e)
+	}
+}
+
+// Stop stops scheduling jobs, cancels running runs and waits for them to return
+func (c *Cron) Stop() {
+	c.mu.Lock()
+	if c.ctx == nil {
+		c.mu.Unlock()
+		return
+	}
+	c.cancel()
+	c.ctx, c.cancel = nil, nil
+	c.mu.Unlock()
+	c.wg.Wait()
+}
+
+// Jobs returns the state of the jobs by name
+func (c *Cron) Jobs() []JobStatus {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	jobs := make([]JobStatus, 0, len(c.entries))
+	for _, e := range c.entries {
+		status := JobStatus{
+			Name:     e.job.Name,
+			Schedule: e.job.Schedule.String(),
+			Room:     e.job.Room,
+			Next:     e.next,
+			LastRun:  e.lastRun,
+			Running:  e.running,
+		}
+		if e.lastErr != nil {
+			status.LastError = e.lastErr.Error()
+		}
+		jobs = append(jobs, status)
+	}
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].Name < jobs[j].Name })
+	return jobs
+}
+
+// loop runs a job on its schedule until the cron stops or the job is removed
+func (c *Cron) loop(ctx context.Context, e *cronEntry) {
+	defer c.wg.Done()
+	c.catchUp(ctx, e)
+
+	for {
+		now := c.now()
+		next := e.job.Schedule.Next(now.In(c.config.Location))
+		if next.IsZero() {
+			logging.Warn("scheduled job has no further runs", "job", e.job.Name, "schedule", e.job.Schedule.String())
+			return
+		}
+		c.mu.Lock()
+		e.next = next
+		c.mu.Unlock()
+
+		timer := time.NewTimer(next.Sub(now))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-e.stop:
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		c.execute(ctx, e, next, false)
+	}
+}
+
+// catchUp runs a job for runs missed since its last stored run, following its catch-up policy
+func (c *Cron) catchUp(ctx context.Context, e *cronEntry) {
+	if e.job.CatchUp == CatchUpSkip || c.config.Store == nil {
+		return
+	}
+	last := c.loadLastRun(ctx, e.job.Name)
+	if last.IsZero() {
+		return
+	}
+
+	now := c.now()
+	var missed []time.Time
+	total := 0
+	for t := e.job.Schedule.Next(last.In(c.config.Location)); !t.IsZero() && !t.After(now) && total < maxMissedScan; t = e.job.Schedule.Next(t) {
+		total++
+		missed = append(missed, t)
+		if len(missed) > c.config.MaxCatchUp {
+			missed = missed[1:]
+		}
+	}
+	if total == 0 {
+		return
+	}
+	if e.job.CatchUp == CatchUpOnce {
+		missed = missed[len(missed)-1:]
+	}
+
+	logging.Info("catching up on missed job runs", "job", e.job.Name, "missed", total, "running", len(missed), "last_run", last)
+	for _, scheduledAt := range missed {
+		select {
+		case <-ctx.Done():
+			return
+		case <-e.stop:
+			return
+		default:
+		}
+		c.execute(ctx, e, scheduledAt, true)
+	}
+}
+
+// execute runs a job once and records the run
+func (c *Cron) execute(ctx context.Context, e *cronEntry, scheduledAt time.Time, catchUp bool) {
+	runCtx := ctx
+	if e.job.Timeout > 0 {
+		var cancel context.CancelFunc
+		runCtx, cancel = context.WithTimeout(ctx, e.job.Timeout)
+		defer cancel()
+	}
+
+	runID := fmt.Sprintf("job:%s:%d", e.job.Name, scheduledAt.Unix())
+	run := JobRun{Job: e.job.Name, ScheduledAt: scheduledAt, CatchUp: catchUp}
+	if c.config.Sender != nil {
+		run.Sender = c.config.Sender(runCtx, runID, e.job.Room)
+	}
+
+	c.mu.Lock()
+	e.running = true
+	c.mu.Unlock()
+
+	start := c.now()
+	err := runJob(runCtx, e.job, run)
+	if err != nil {
+		logging.Warn("scheduled job failed", "job", e.job.Name, "scheduled_at", scheduledAt, "error", err)
+	} else {
+		logging.Debug("scheduled job completed", "job", e.job.Name, "scheduled_at", scheduledAt, "duration", c.now().Sub(start))
+	}
+
+	c.mu.Lock()
+	e.running = false
+	e.lastRun = scheduledAt
+	e.lastErr = err
+	c.mu.Unlock()
+
+	if c.config.Store != nil {
+		if err := c.config.Store.Set(ctx, c.config.KeyPrefix+e.job.Name, scheduledAt.UTC().Format(time.RFC3339Nano), 0); err != nil {
+			logging.Warn("failed to store last job run", "job", e.job.Name, "error", err)
+		}
+	}
+}
+
+// runJob calls the job's run function, turning a panic into an error
+func runJob(ctx context.Context, job Job, run JobRun) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job panicked: %v", r)
+		}
+	}()
+	return job.Run(ctx, run)
+}
+
+// loadLastRun returns the stored last run of a job, zero if it never ran
+func (c *Cron) loadLastRun(ctx context.Context, name string) time.Time {
+	value, err := c.config.Store.Get(ctx, c.config.KeyPrefix+name)
+	if err != nil || value == "" {
+		return time.Time{}
+	}
+	last, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		logging.Warn("ignoring invalid last job run", "job", name, "value", value)
+		return time.Time{}
+	}
+	return last
+}
@@ -0,0 +1,136 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memoryStore is a StateStore backed by a map
+type memoryStore struct {
+	mu     sync.Mutex
+	values map[string]string
+}
+
+func (m *memoryStore) Get(ctx context.Context, key string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	value, ok := m.values[key]
+	if !ok {
+		return "", errors.New("not found")
+	}
+	return value, nil
+}
+
+func (m *memoryStore) Set(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.values[key] = value.(string)
+	return nil
+}
+
+// catchUpRuns starts a cron whose hourly job last ran five hours ago and
+// returns the runs made while catching up
+func catchUpRuns(t *testing.T, policy CatchUp, maxCatchUp int) []JobRun {
+	t.Helper()
+	now := time.Date(2026, 3, 4, 10, 30, 0, 0, time.UTC)
+	store := &memoryStore{values: map[string]string{
+		"scheduler:job:report": now.Add(-5 * time.Hour).Format(time.RFC3339Nano),
+	}}
+	c := NewCron(&CronConfig{Store: store, MaxCatchUp: maxCatchUp, Location: time.UTC})
+	c.now = func() time.Time { return now }
+
+	var mu sync.Mutex
+	var runs []JobRun
+	schedule, _ := ParseSchedule("@hourly")
+	if err := c.Add(Job{Name: "report", Schedule: schedule, CatchUp: policy, Run: func(ctx context.Context, run JobRun) error {
+		mu.Lock()
+		defer mu.Unlock()
+		runs = append(runs, run)
+		return nil
+	}}); err != nil {
+		t.Fatal(err)
+	}
+
+	c.Start(context.Background())
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		if jobs := c.Jobs(); !jobs[0].Next.IsZero() {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	c.Stop()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if policy != CatchUpSkip && len(runs) > 0 {
+		stored, _ := store.Get(context.Background(), "scheduler:job:report")
+		if want := runs[len(runs)-1].ScheduledAt.UTC().Format(time.RFC3339Nano); stored != want {
+			t.Errorf("stored last run %s, want %s", stored, want)
+		}
+	}
+	return runs
+}
+
+func TestCatchUp(t *testing.T) {
+	if runs := catchUpRuns(t, CatchUpSkip, 0); len(runs) != 0 {
+		t.Errorf("skip: got %d runs, want none", len(runs))
+	}
+
+	runs := catchUpRuns(t, CatchUpOnce, 0)
+	if len(runs) != 1 || !runs[0].CatchUp || runs[0].ScheduledAt.Hour() != 10 {
+		t.Errorf("once: got %+v, want the 10:00 run", runs)
+	}
+
+	// Missed 06:00 to 10:00, limited to the last three
+	runs = catchUpRuns(t, CatchUpAll, 3)
+	var hours []int
+	for _, run := range runs {
+		hours = append(hours, run.ScheduledAt.Hour())
+	}
+	if len(hours) != 3 || hours[0] != 8 || hours[2] != 10 {
+		t.Errorf("all: got runs at %v, want 8, 9, 10", hours)
+	}
+}
+
+func TestCronRunsJobs(t *testing.T) {
+	c := NewCron(nil)
+	ran := make(chan JobRun, 1)
+	if err := c.Add(Job{Name: "tick", Schedule: Every(time.Second), Run: func(ctx context.Context, run JobRun) error {
+		select {
+		case ran <- run:
+		default:
+		}
+		return errors.New("failed")
+	}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Add(Job{Name: "tick", Schedule: Every(time.Second), Run: func(context.Context, JobRun) error { return nil }}); err == nil {
+		t.Error("expected error for duplicate job name")
+	}
+
+	c.Start(context.Background())
+	defer c.Stop()
+	select {
+	case run := <-ran:
+		if run.Job != "tick" || run.CatchUp {
+			t.Errorf("unexpected run %+v", run)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("job did not run")
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for c.Jobs()[0].LastError == "" && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if status := c.Jobs()[0]; status.LastError != "failed" || status.Schedule != "@every 1s" {
+		t.Errorf("unexpected status %+v", status)
+	}
+	if !c.Remove("tick") || c.Remove("tick") {
+		t.Error("Remove should report the job once")
+	}
+}
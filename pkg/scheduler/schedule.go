@@ -0,0 +1,214 @@
+package scheduler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule decides when a recurring job runs
+type Schedule interface {
+	// Next returns the first run after the given time, zero if there is none
+	Next(after time.Time) time.Time
+	String() string
+}
+
+// Schedule descriptors and their cron expressions
+var descriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// ParseSchedule parses a five-field cron expression ("*/15 9-17 * * mon-fri"),
+// a descriptor (@hourly, @daily, @weekly, @monthly, @yearly) or an interval
+// ("@every 10m"). Cron expressions are evaluated in the time zone of the time
+// passed to Next.
+func ParseSchedule(spec string) (Schedule, error) {
+	spec = strings.TrimSpace(spec)
+	if interval, ok := strings.CutPrefix(spec, "@every "); ok {
+		d, err := time.ParseDuration(strings.TrimSpace(interval))
+		if err != nil || d < time.Second {
+			return nil, fmt.Errorf("invalid interval %q (use a duration of at least 1s)", interval)
+		}
+		return Every(d), nil
+	}
+	expression := spec
+	if strings.HasPrefix(spec, "@") {
+		var ok bool
+		if expression, ok = descriptors[strings.ToLower(spec)]; !ok {
+			return nil, fmt.Errorf("unknown schedule descriptor %q", spec)
+		}
+	}
+
+	fields := strings.Fields(expression)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("invalid cron expression %q (expected 5 fields: minute hour day month weekday)", spec)
+	}
+	s := &cronSchedule{spec: spec}
+	var err error
+	if s.minute, err = parseField(fields[0], cronFields[0]); err != nil {
+		return nil, err
+	}
+	if s.hour, err = parseField(fields[1], cronFields[1]); err != nil {
+		return nil, err
+	}
+	if s.day, err = parseField(fields[2], cronFields[2]); err != nil {
+		return nil, err
+	}
+	if s.month, err = parseField(fields[3], cronFields[3]); err != nil {
+		return nil, err
+	}
+	if s.weekday, err = parseField(fields[4], cronFields[4]); err != nil {
+		return nil, err
+	}
+	// 7 is Sunday too
+	if s.weekday&(1<<7) != 0 {
+		s.weekday |= 1
+	}
+	s.anyDay = fields[2] == "*"
+	s.anyWeekday = fields[4] == "*"
+	return s, nil
+}
+
+// Every returns a schedule that runs every d, counted from the previous run
+func Every(d time.Duration) Schedule {
+	return interval(d)
+}
+
+type interval time.Duration
+
+func (i interval) Next(after time.Time) time.Time {
+	return after.Add(time.Duration(i))
+}
+
+func (i interval) String() string {
+	return "@every " + time.Duration(i).String()
+}
+
+// cronSchedule holds the allowed values of each field as bit sets
+type cronSchedule struct {
+	spec                              string
+	minute, hour, day, month, weekday uint64
+	anyDay, anyWeekday                bool
+}
+
+// cronField describes the values a cron field accepts
+type cronField struct {
+	name     string
+	min, max int
+	names    []string // Names of the values from min, e.g. "jan" for month 1
+}
+
+var cronFields = [5]cronField{
+	{name: "minute", min: 0, max: 59},
+	{name: "hour", min: 0, max: 23},
+	{name: "day", min: 1, max: 31},
+	{name: "month", min: 1, max: 12, names: []string{"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}},
+	{name: "weekday", min: 0, max: 7, names: []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}},
+}
+
+// parseField parses a comma-separated list of values, ranges ("1-5") and
+// steps ("*/15", "10-50/20") into a bit set
+func parseField(field string, f cronField) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepPart)
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("invalid step %q in %s field", stepPart, f.name)
+			}
+			step = n
+		}
+
+		low, high := f.min, f.max
+		if rangePart != "*" {
+			from, to, isRange := strings.Cut(rangePart, "-")
+			var err error
+			if low, err = f.value(from); err != nil {
+				return 0, err
+			}
+			high = low
+			if isRange {
+				if high, err = f.value(to); err != nil {
+					return 0, err
+				}
+			} else if hasStep {
+				// "5/15" counts from 5 to the end of the range
+				high = f.max
+			}
+			if high < low {
+				return 0, fmt.Errorf("invalid range %q in %s field", rangePart, f.name)
+			}
+		}
+		for v := low; v <= high; v += step {
+			bits |= 1 << v
+		}
+	}
+	return bits, nil
+}
+
+// value parses a number or name of the field
+func (f cronField) value(s string) (int, error) {
+	for i, name := range f.names {
+		if strings.EqualFold(s, name) {
+			return f.min + i, nil
+		}
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n < f.min || n > f.max {
+		return 0, fmt.Errorf("invalid value %q in %s field (use %d-%d)", s, f.name, f.min, f.max)
+	}
+	return n, nil
+}
+
+// Next returns the first minute after the given time matching the expression,
+// searching up to five years ahead
+func (s *cronSchedule) Next(after time.Time) time.Time {
+	loc := after.Location()
+	t := after.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+		if s.month&(1<<int(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if !s.matchesDay(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if s.hour&(1<<t.Hour()) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+			continue
+		}
+		if s.minute&(1<<t.Minute()) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+// matchesDay applies the cron rule that a day matches either the day of the
+// month or the weekday when both are restricted
+func (s *cronSchedule) matchesDay(t time.Time) bool {
+	day := s.day&(1<<t.Day()) != 0
+	weekday := s.weekday&(1<<int(t.Weekday())) != 0
+	if s.anyDay || s.anyWeekday {
+		return day && weekday
+	}
+	return day || weekday
+}
+
+func (s *cronSchedule) String() string {
+	return s.spec
+}
@@ -0,0 +1,345 @@
+// Package scheduler runs tasks on a fixed number of workers, taking queued
+// tasks by priority. With strict priority the highest waiting priority always
+// runs next; with weighted fair scheduling every priority gets a share of the
+// workers in proportion to its weight, so low-priority tasks are not starved.
+// Optionally a high-priority task preempts the lowest-priority running task
+// when all workers are busy; the preempted task is queued again and restarts.
+//
+// Cron runs recurring jobs on cron expressions or intervals, keeping their
+// last run in a store so runs missed while the agent was down can be caught up.
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Priority orders tasks; higher runs first
+type Priority int
+
+const (
+	PriorityLow Priority = iota
+	PriorityNormal
+	PriorityHigh
+	PriorityCritical
+)
+
+const numPriorities = int(PriorityCritical) + 1
+
+var priorityNames = [numPriorities]string{"low", "normal", "high", "critical"}
+
+func (p Priority) String() string {
+	if p < PriorityLow || p > PriorityCritical {
+		return strconv.Itoa(int(p))
+	}
+	return priorityNames[p]
+}
+
+// ParsePriority reads a priority from task metadata: a name ("low", "normal",
+// "high", "critical") or a number from 0 (low) to 3 (critical). Numbers out
+// of range are clamped.
+func ParsePriority(value any) (Priority, error) {
+	switch v := value.(type) {
+	case nil:
+		return PriorityNormal, nil
+	case Priority:
+		return clamp(int(v)), nil
+	case int:
+		return clamp(v), nil
+	case float64:
+		return clamp(int(v)), nil
+	case string:
+		name := strings.ToLower(strings.TrimSpace(v))
+		for p, candidate := range priorityNames {
+			if name == candidate {
+				return Priority(p), nil
+			}
+		}
+		if n, err := strconv.Atoi(name); err == nil {
+			return clamp(n), nil
+		}
+	}
+	return PriorityNormal, fmt.Errorf("invalid priority %v (use low, normal, high, critical or 0-3)", value)
+}
+
+func clamp(n int) Priority {
+	return Priority(max(int(PriorityLow), min(n, int(PriorityCritical))))
+}
+
+// Policy decides which queued task runs next
+type Policy string
+
+const (
+	// PolicyStrict always runs the highest waiting priority first
+	PolicyStrict Policy = "strict"
+	// PolicyWeightedFair shares the workers between priorities by weight
+	PolicyWeightedFair Policy = "weighted"
+)
+
+// ParsePolicy parses a scheduling policy ("" = strict)
+func ParsePolicy(s string) (Policy, error) {
+	switch policy := Policy(strings.ToLower(strings.TrimSpace(s))); policy {
+	case "":
+		return PolicyStrict, nil
+	case PolicyStrict, PolicyWeightedFair:
+		return policy, nil
+	}
+	return "", fmt.Errorf("invalid scheduling policy %q (use \"strict\" or \"weighted\")", s)
+}
+
+// DefaultWeights are the weighted fair shares of the priorities
+var DefaultWeights = map[Priority]int{PriorityLow: 1, PriorityNormal: 2, PriorityHigh: 4, PriorityCritical: 8}
+
+var (
+	// ErrQueueFull is returned by Submit when MaxQueued tasks are waiting
+	ErrQueueFull = errors.New("task queue is full")
+	// ErrClosed is returned by Submit after Close, and is the cause of the
+	// context of tasks dropped by Close
+	ErrClosed = errors.New("scheduler closed")
+	// ErrPreempted is the cause of the context of a task stopped to make room
+	// for a higher-priority task
+	ErrPreempted = errors.New("task preempted by a higher-priority task")
+)
+
+// Config configures a Scheduler
+type Config struct {
+	Workers   int              // Tasks running at once (default 1)
+	Policy    Policy           // Strict (default) or weighted fair
+	Weights   map[Priority]int // Weighted fair shares (missing priorities use DefaultWeights)
+	Preempt   bool             // Stop the lowest-priority running task for a higher-priority one when all workers are busy
+	MaxQueued int              // Tasks waiting at most (0 = unlimited)
+}
+
+// Task is a unit of work
+type Task struct {
+	ID       string
+	Priority Priority
+
+	// Run executes the task. It is called once for every submitted task and
+	// again after the task was preempted, unless the context passed to Submit
+	// is done by then. A preempted task's context is cancelled with the cause
+	// ErrPreempted; tasks still queued when the scheduler is closed run with a
+	// context cancelled with the cause ErrClosed.
+	Run func(ctx context.Context)
+}
+
+// Stats reports the scheduler's load
+type Stats struct {
+	Running   int   `json:"running"`
+	Queued    int   `json:"queued"`
+	Preempted int64 `json:"preempted"` // Tasks preempted since the scheduler was created
+}
+
+type entry struct {
+	task      Task
+	parent    context.Context
+	ctx       context.Context
+	cancel    context.CancelCauseFunc
+	seq       uint64 // Start order, to preempt the most recently started task
+	preempted bool
+}
+
+// Scheduler runs submitted tasks on its workers. It is safe for concurrent use.
+type Scheduler struct {
+	mu        sync.Mutex
+	config    Config
+	queues    [numPriorities][]*entry
+	queued    int
+	running   map[*entry]struct{}
+	weights   [numPriorities]int
+	current   [numPriorities]int // Smooth weighted round-robin state
+	seq       uint64
+	preempted int64
+	closed    bool
+}
+
+// New creates a scheduler (nil config = one worker, strict priority)
+func New(config *Config) *Scheduler {
+	c := Config{}
+	if config != nil {
+		c = *config
+	}
+	if c.Workers <= 0 {
+		c.Workers = 1
+	}
+	if c.Policy == "" {
+		c.Policy = PolicyStrict
+	}
+	s := &Scheduler{config: c, running: make(map[*entry]struct{})}
+	for p := range s.weights {
+		weight, ok := c.Weights[Priority(p)]
+		if !ok {
+			weight = DefaultWeights[Priority(p)]
+		}
+		s.weights[p] = max(weight, 1)
+	}
+	return s
+}
+
+// Submit queues a task to run as part of ctx. The task starts right away if a
+// worker is free.
+func (s *Scheduler) Submit(ctx context.Context, task Task) error {
+	task.Priority = clamp(int(task.Priority))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return ErrClosed
+	}
+	if s.config.MaxQueued > 0 && s.queued >= s.config.MaxQueued {
+		return ErrQueueFull
+	}
+
+	s.push(&entry{task: task, parent: ctx}, false)
+	if len(s.running) >= s.config.Workers && s.config.Preempt {
+		s.preemptFor(task.Priority)
+	}
+	s.dispatch()
+	return nil
+}
+
+// Close stops starting queued tasks; their Run is called with a cancelled
+// context. Running tasks are not stopped.
+func (s *Scheduler) Close() {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return
+	}
+	s.closed = true
+	var dropped []*entry
+	for p := range s.queues {
+		dropped = append(dropped, s.queues[p]...)
+		s.queues[p] = nil
+	}
+	s.queued = 0
+	s.mu.Unlock()
+
+	for _, e := range dropped {
+		ctx, cancel := context.WithCancelCause(e.parent)
+		cancel(ErrClosed)
+		go e.task.Run(ctx)
+	}
+}
+
+// Stats returns the number of running and queued tasks
+func (s *Scheduler) Stats() Stats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return Stats{Running: len(s.running), Queued: s.queued, Preempted: s.preempted}
+}
+
+// Config returns the scheduler's configuration
+func (s *Scheduler) Config() Config {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.config
+}
+
+// push queues an entry, at the front of its priority if it was preempted
+func (s *Scheduler) push(e *entry, front bool) {
+	p := e.task.Priority
+	if front {
+		s.queues[p] = append([]*entry{e}, s.queues[p]...)
+	} else {
+		s.queues[p] = append(s.queues[p], e)
+	}
+	s.queued++
+}
+
+// preemptFor stops the most recently started of the lowest-priority running
+// tasks below priority. It must be called with the lock held.
+func (s *Scheduler) preemptFor(priority Priority) {
+	var victim *entry
+	for e := range s.running {
+		if e.preempted || e.task.Priority >= priority {
+			continue
+		}
+		if victim == nil || e.task.Priority < victim.task.Priority ||
+			(e.task.Priority == victim.task.Priority && e.seq > victim.seq) {
+			victim = e
+		}
+	}
+	if victim == nil {
+		return
+	}
+	victim.preempted = true
+	s.preempted++
+	victim.cancel(ErrPreempted)
+}
+
+// dispatch starts queued tasks while workers are free. It must be called with the lock held.
+func (s *Scheduler) dispatch() {
+	// Preempted tasks still hold their worker until Run returns
+	for len(s.running) < s.config.Workers && s.queued > 0 {
+		e := s.next()
+		e.ctx, e.cancel = context.WithCancelCause(e.parent)
+		e.preempted = false
+		s.seq++
+		e.seq = s.seq
+		s.running[e] = struct{}{}
+		go s.run(e)
+	}
+}
+
+func (s *Scheduler) run(e *entry) {
+	e.task.Run(e.ctx)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.running, e)
+	preempted := e.preempted && errors.Is(context.Cause(e.ctx), ErrPreempted)
+	e.cancel(nil)
+	if preempted && !s.closed && e.parent.Err() == nil {
+		s.push(e, true)
+	} else if preempted && s.closed {
+		// Close already dropped the queue; finish the task as dropped
+		ctx, cancel := context.WithCancelCause(e.parent)
+		cancel(ErrClosed)
+		go e.task.Run(ctx)
+	}
+	s.dispatch()
+}
+
+// next removes the task to run next. It must be called with the lock held and
+// at least one task queued.
+func (s *Scheduler) next() *entry {
+	p := s.pick()
+	e := s.queues[p][0]
+	s.queues[p][0] = nil
+	s.queues[p] = s.queues[p][1:]
+	s.queued--
+	return e
+}
+
+// pick returns the priority to take a task from
+func (s *Scheduler) pick() int {
+	if s.config.Policy != PolicyWeightedFair {
+		for p := numPriorities - 1; p >= 0; p-- {
+			if len(s.queues[p]) > 0 {
+				return p
+			}
+		}
+	}
+
+	// Smooth weighted round-robin over the priorities with waiting tasks
+	total, best := 0, -1
+	for p := numPriorities - 1; p >= 0; p-- {
+		if len(s.queues[p]) == 0 {
+			s.current[p] = 0
+			continue
+		}
+		weight := s.weights[p]
+		s.current[p] += weight
+		total += weight
+		if best < 0 || s.current[p] > s.current[best] {
+			best = p
+		}
+	}
+	s.current[best] -= total
+	return best
+}
@@ -0,0 +1,197 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recorder collects the order tasks start in
+type recorder struct {
+	mu      sync.Mutex
+	started []string
+}
+
+func (r *recorder) task(id string, priority Priority, release <-chan struct{}, done *sync.WaitGroup) Task {
+	done.Add(1)
+	return Task{ID: id, Priority: priority, Run: func(ctx context.Context) {
+		defer done.Done()
+		r.mu.Lock()
+		r.started = append(r.started, id)
+		r.mu.Unlock()
+		<-release
+	}}
+}
+
+func (r *recorder) order() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.started...)
+}
+
+// blocker occupies a worker until the returned function is called
+func blocker(t *testing.T, s *Scheduler, priority Priority) func() {
+	t.Helper()
+	started := make(chan struct{})
+	release := make(chan struct{})
+	if err := s.Submit(context.Background(), Task{ID: "blocker", Priority: priority, Run: func(ctx context.Context) {
+		close(started)
+		<-release
+	}}); err != nil {
+		t.Fatal(err)
+	}
+	<-started
+	return func() { close(release) }
+}
+
+func TestStrictPriority(t *testing.T) {
+	s := New(&Config{Workers: 1})
+	release := blocker(t, s, PriorityNormal)
+
+	r := &recorder{}
+	var done sync.WaitGroup
+	open := make(chan struct{})
+	close(open)
+	for _, task := range []Task{
+		r.task("low", PriorityLow, open, &done),
+		r.task("normal-1", PriorityNormal, open, &done),
+		r.task("critical", PriorityCritical, open, &done),
+		r.task("normal-2", PriorityNormal, open, &done),
+		r.task("high", PriorityHigh, open, &done),
+	} {
+		if err := s.Submit(context.Background(), task); err != nil {
+			t.Fatal(err)
+		}
+	}
+	release()
+	done.Wait()
+
+	want := []string{"critical", "high", "normal-1", "normal-2", "low"}
+	if got := r.order(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestWeightedFair(t *testing.T) {
+	s := New(&Config{Workers: 1, Policy: PolicyWeightedFair, Weights: map[Priority]int{PriorityHigh: 3, PriorityLow: 1}})
+	release := blocker(t, s, PriorityNormal)
+
+	r := &recorder{}
+	var done sync.WaitGroup
+	open := make(chan struct{})
+	close(open)
+	for i := 0; i < 4; i++ {
+		s.Submit(context.Background(), r.task("low", PriorityLow, open, &done))
+		s.Submit(context.Background(), r.task("high", PriorityHigh, open, &done))
+	}
+	release()
+	done.Wait()
+
+	// Low-priority tasks get every fourth turn instead of waiting for all high ones
+	want := []string{"high", "high", "low", "high", "high", "low", "low", "low"}
+	if got := r.order(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestPreemption(t *testing.T) {
+	s := New(&Config{Workers: 1, Preempt: true})
+
+	var mu sync.Mutex
+	var runs []string
+	lowDone := make(chan struct{})
+	lowStarted := make(chan struct{}, 2)
+	s.Submit(context.Background(), Task{ID: "low", Priority: PriorityLow, Run: func(ctx context.Context) {
+		mu.Lock()
+		runs = append(runs, "low")
+		mu.Unlock()
+		lowStarted <- struct{}{}
+		select {
+		case <-ctx.Done():
+			if !errors.Is(context.Cause(ctx), ErrPreempted) {
+				t.Errorf("got cause %v, want ErrPreempted", context.Cause(ctx))
+			}
+		case <-time.After(50 * time.Millisecond):
+			close(lowDone)
+		}
+	}})
+	<-lowStarted
+
+	s.Submit(context.Background(), Task{ID: "high", Priority: PriorityHigh, Run: func(ctx context.Context) {
+		mu.Lock()
+		runs = append(runs, "high")
+		mu.Unlock()
+	}})
+
+	select {
+	case <-lowDone:
+	case <-time.After(time.Second):
+		t.Fatal("preempted task was not run again")
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if want := []string{"low", "high", "low"}; !reflect.DeepEqual(runs, want) {
+		t.Errorf("got %v, want %v", runs, want)
+	}
+	if got := s.Stats().Preempted; got != 1 {
+		t.Errorf("preempted %d tasks, want 1", got)
+	}
+}
+
+func TestNoPreemptionOfEqualPriority(t *testing.T) {
+	s := New(&Config{Workers: 1, Preempt: true})
+	release := blocker(t, s, PriorityHigh)
+	defer release()
+
+	s.Submit(context.Background(), Task{ID: "high", Priority: PriorityHigh, Run: func(ctx context.Context) {}})
+	if stats := s.Stats(); stats.Preempted != 0 || stats.Queued != 1 {
+		t.Errorf("got %+v, want the task queued without preemption", stats)
+	}
+}
+
+func TestQueueFullAndClose(t *testing.T) {
+	s := New(&Config{Workers: 1, MaxQueued: 1})
+	release := blocker(t, s, PriorityNormal)
+	defer release()
+
+	dropped := make(chan error, 1)
+	if err := s.Submit(context.Background(), Task{Run: func(ctx context.Context) { dropped <- context.Cause(ctx) }}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Submit(context.Background(), Task{Run: func(ctx context.Context) {}}); !errors.Is(err, ErrQueueFull) {
+		t.Errorf("got %v, want ErrQueueFull", err)
+	}
+
+	s.Close()
+	if err := <-dropped; !errors.Is(err, ErrClosed) {
+		t.Errorf("queued task ran with cause %v, want ErrClosed", err)
+	}
+	if err := s.Submit(context.Background(), Task{Run: func(ctx context.Context) {}}); !errors.Is(err, ErrClosed) {
+		t.Errorf("got %v, want ErrClosed", err)
+	}
+}
+
+func TestParsePriority(t *testing.T) {
+	tests := []struct {
+		value any
+		want  Priority
+		ok    bool
+	}{
+		{nil, PriorityNormal, true},
+		{"HIGH", PriorityHigh, true},
+		{float64(3), PriorityCritical, true},
+		{float64(9), PriorityCritical, true},
+		{"0", PriorityLow, true},
+		{"urgent", PriorityNormal, false},
+		{true, PriorityNormal, false},
+	}
+	for _, tt := range tests {
+		got, err := ParsePriority(tt.value)
+		if got != tt.want || (err == nil) != tt.ok {
+			t.Errorf("%v: got %v, %v", tt.value, got, err)
+		}
+	}
+}
@@ -0,0 +1,325 @@
+// Package schema validates task input against JSON Schema. It supports the
+// keywords commonly used to describe input: type, enum, const, properties,
+// required, additionalProperties, items, minItems, maxItems, minLength,
+// maxLength, pattern, format, minimum, maximum, exclusiveMinimum,
+// exclusiveMaximum, multipleOf, anyOf, oneOf and allOf. Annotations like
+// title, description, default and examples are kept for error hints.
+// Schemas using references or conditionals are rejected by Compile instead
+// of being half-enforced.
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// unsupported are keywords that change validation but are not implemented
+var unsupported = []string{"$ref", "$dynamicRef", "not", "if", "then", "else", "patternProperties", "dependentSchemas", "dependentRequired", "propertyNames", "prefixItems", "contains", "unevaluatedProperties", "unevaluatedItems"}
+
+// Schema is a compiled JSON Schema
+type Schema struct {
+	raw json.RawMessage
+
+	always               *bool // true or false schema
+	types                []string
+	enum                 []any
+	constValue           any
+	hasConst             bool
+	properties           map[string]*Schema
+	propertyOrder        []string
+	required             []string
+	additionalProperties *Schema
+	items                *Schema
+	minItems, maxItems   *int
+	minLength, maxLength *int
+	pattern              *regexp.Regexp
+	format               string
+	minimum, maximum     *big.Rat
+	exclusiveMinimum     *big.Rat
+	exclusiveMaximum     *big.Rat
+	multipleOf           *big.Rat
+	anyOf, oneOf, allOf  []*Schema
+
+	title       string
+	description string
+	examples    []json.RawMessage
+}
+
+// Compile parses a JSON Schema document
+func Compile(raw []byte) (*Schema, error) {
+	s, err := compile(raw, "")
+	if err != nil {
+		return nil, fmt.Errorf("invalid schema: %w", err)
+	}
+	return s, nil
+}
+
+// MustCompile is like Compile but panics on an invalid schema
+func MustCompile(raw string) *Schema {
+	s, err := Compile([]byte(raw))
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// Raw returns the schema document as it was compiled
+func (s *Schema) Raw() json.RawMessage {
+	return s.raw
+}
+
+func compile(raw []byte, path string) (*Schema, error) {
+	raw = bytes.TrimSpace(raw)
+	s := &Schema{raw: json.RawMessage(raw)}
+
+	var b bool
+	if json.Unmarshal(raw, &b) == nil {
+		s.always = &b
+		return s, nil
+	}
+
+	var doc map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return nil, fmt.Errorf("%s: a schema must be an object or a boolean", pathOrRoot(path))
+	}
+	for _, keyword := range unsupported {
+		if _, ok := doc[keyword]; ok {
+			return nil, fmt.Errorf("%s: keyword %q is not supported", pathOrRoot(path), keyword)
+		}
+	}
+
+	c := compiler{doc: doc, path: path}
+	c.types()
+	c.values("enum", &s.enum)
+	if rawConst, ok := doc["const"]; ok {
+		s.hasConst = true
+		s.constValue, c.err = decode(rawConst)
+	}
+	c.schemaMap("properties", &s.properties, &s.propertyOrder)
+	c.unmarshal("required", &s.required)
+	s.additionalProperties = c.schema("additionalProperties")
+	s.items = c.schema("items")
+	s.minItems, s.maxItems = c.count("minItems"), c.count("maxItems")
+	s.minLength, s.maxLength = c.count("minLength"), c.count("maxLength")
+	s.minimum, s.maximum = c.number("minimum"), c.number("maximum")
+	s.exclusiveMinimum, s.exclusiveMaximum = c.number("exclusiveMinimum"), c.number("exclusiveMaximum")
+	s.multipleOf = c.number("multipleOf")
+	s.anyOf, s.oneOf, s.allOf = c.schemaList("anyOf"), c.schemaList("oneOf"), c.schemaList("allOf")
+	c.unmarshal("format", &s.format)
+	c.unmarshal("title", &s.title)
+	c.unmarshal("description", &s.description)
+	c.unmarshal("examples", &s.examples)
+
+	var pattern string
+	c.unmarshal("pattern", &pattern)
+	if pattern != "" && c.err == nil {
+		s.pattern, c.err = regexp.Compile(pattern)
+	}
+	s.types = c.typeList
+	if c.err != nil {
+		return nil, c.err
+	}
+	if s.multipleOf != nil && s.multipleOf.Sign() <= 0 {
+		return nil, fmt.Errorf("%s: multipleOf must be greater than 0", pathOrRoot(path))
+	}
+	return s, nil
+}
+
+// compiler reads the keywords of a schema object, keeping the first error
+type compiler struct {
+	doc      map[string]json.RawMessage
+	path     string
+	typeList []string
+	err      error
+}
+
+func (c *compiler) fail(keyword string, err error) {
+	if c.err == nil {
+		c.err = fmt.Errorf("%s: %s: %w", pathOrRoot(c.path), keyword, err)
+	}
+}
+
+func (c *compiler) unmarshal(keyword string, v any) {
+	if raw, ok := c.doc[keyword]; ok {
+		if err := json.Unmarshal(raw, v); err != nil {
+			c.fail(keyword, err)
+		}
+	}
+}
+
+func (c *compiler) types() {
+	raw, ok := c.doc["type"]
+	if !ok {
+		return
+	}
+	var single string
+	if json.Unmarshal(raw, &single) == nil {
+		c.typeList = []string{single}
+	} else {
+		c.unmarshal("type", &c.typeList)
+	}
+	for _, t := range c.typeList {
+		if !slices.Contains([]string{"object", "array", "string", "number", "integer", "boolean", "null"}, t) {
+			c.fail("type", fmt.Errorf("unknown type %q", t))
+		}
+	}
+}
+
+func (c *compiler) values(keyword string, out *[]any) {
+	var raws []json.RawMessage
+	c.unmarshal(keyword, &raws)
+	for _, raw := range raws {
+		value, err := decode(raw)
+		if err != nil {
+			c.fail(keyword, err)
+			return
+		}
+		*out = append(*out, value)
+	}
+}
+
+func (c *compiler) count(keyword string) *int {
+	raw, ok := c.doc[keyword]
+	if !ok {
+		return nil
+	}
+	var n int
+	if err := json.Unmarshal(raw, &n); err != nil || n < 0 {
+		c.fail(keyword, fmt.Errorf("must be a non-negative integer"))
+		return nil
+	}
+	return &n
+}
+
+func (c *compiler) number(keyword string) *big.Rat {
+	raw, ok := c.doc[keyword]
+	if !ok {
+		return nil
+	}
+	n, ok := new(big.Rat).SetString(string(bytes.TrimSpace(raw)))
+	if !ok {
+		c.fail(keyword, fmt.Errorf("must be a number"))
+		return nil
+	}
+	return n
+}
+
+func (c *compiler) schema(keyword string) *Schema {
+	raw, ok := c.doc[keyword]
+	if !ok || c.err != nil {
+		return nil
+	}
+	s, err := compile(raw, c.path+"/"+keyword)
+	if err != nil {
+		c.err = err
+	}
+	return s
+}
+
+func (c *compiler) schemaList(keyword string) []*Schema {
+	var raws []json.RawMessage
+	c.unmarshal(keyword, &raws)
+	var list []*Schema
+	for i, raw := range raws {
+		if c.err != nil {
+			return nil
+		}
+		s, err := compile(raw, fmt.Sprintf("%s/%s/%d", c.path, keyword, i))
+		if err != nil {
+			c.err = err
+			return nil
+		}
+		list = append(list, s)
+	}
+	return list
+}
+
+func (c *compiler) schemaMap(keyword string, out *map[string]*Schema, order *[]string) {
+	raw, ok := c.doc[keyword]
+	if !ok {
+		return
+	}
+	var raws map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &raws); err != nil {
+		c.fail(keyword, err)
+		return
+	}
+	*out = make(map[string]*Schema, len(raws))
+	for name, propertyRaw := range raws {
+		if c.err != nil {
+			return
+		}
+		s, err := compile(propertyRaw, c.path+"/"+keyword+"/"+name)
+		if err != nil {
+			c.err = err
+			return
+		}
+		(*out)[name] = s
+		*order = append(*order, name)
+	}
+	sort.Strings(*order)
+}
+
+// decode parses a JSON value keeping numbers exact
+func decode(raw []byte) (any, error) {
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	if decoder.More() {
+		return nil, fmt.Errorf("unexpected data after the JSON value")
+	}
+	return value, nil
+}
+
+func pathOrRoot(path string) string {
+	if path == "" {
+		return "schema"
+	}
+	return "schema" + path
+}
+
+// Describe returns a short description of the expected input for error
+// messages: the schema's first example if it has one, otherwise its fields
+// or type
+func (s *Schema) Describe() string {
+	if len(s.examples) > 0 {
+		var compact bytes.Buffer
+		if json.Compact(&compact, s.examples[0]) == nil {
+			return "for example " + compact.String()
+		}
+	}
+	if len(s.properties) > 0 {
+		fields := make([]string, 0, len(s.propertyOrder))
+		for _, name := range s.propertyOrder {
+			field := name
+			property := s.properties[name]
+			if len(property.types) > 0 {
+				field += " (" + strings.Join(property.types, " or ")
+				if slices.Contains(s.required, name) {
+					field += ", required"
+				}
+				field += ")"
+			} else if slices.Contains(s.required, name) {
+				field += " (required)"
+			}
+			fields = append(fields, field)
+		}
+		return "a JSON object with " + strings.Join(fields, ", ")
+	}
+	if s.description != "" {
+		return s.description
+	}
+	if len(s.types) > 0 {
+		return "a JSON " + strings.Join(s.types, " or ")
+	}
+	return ""
+}
@@ -0,0 +1,152 @@
+package schema
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const translateSchema = `{
+	"type": "object",
+	"properties": {
+		"text": {"type": "string", "minLength": 1},
+		"to": {"type": "string", "enum": ["en", "fr", "de"]},
+		"formality": {"type": "number", "minimum": 0, "maximum": 1},
+		"tags": {"type": "array", "items": {"type": "string", "pattern": "^[a-z]+$"}, "maxItems": 2}
+	},
+	"required": ["text", "to"],
+	"additionalProperties": false
+}`
+
+func problems(t *testing.T, err error) []string {
+	t.Helper()
+	if err == nil {
+		return nil
+	}
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("expected a *ValidationError, got %v", err)
+	}
+	var out []string
+	for _, problem := range validationErr.Problems {
+		out = append(out, problem.String())
+	}
+	return out
+}
+
+func TestValidateInput(t *testing.T) {
+	s := MustCompile(translateSchema)
+	tests := []struct {
+		input string
+		want  []string
+	}{
+		{`{"text": "hello", "to": "fr"}`, nil},
+		{`{"text": "hello", "to": "fr", "formality": 0.5, "tags": ["a", "b"]}`, nil},
+		{`{"to": "es"}`, []string{"text: is required", `to: must be one of "en", "fr", "de"`}},
+		{`{"text": "", "to": "en", "formality": 2, "extra": true}`, []string{
+			"extra: is not a known field",
+			"formality: must be at most 1",
+			"text: must not be empty",
+		}},
+		{`{"text": "x", "to": "en", "tags": ["ok", "Bad", "c"]}`, []string{
+			"tags: must have at most 2 items",
+			"tags[1]: must match the pattern ^[a-z]+$",
+		}},
+		{`translate hello to French`, []string{"input: must be an object, got a string"}},
+		{`[1, 2]`, []string{"input: must be an object, got an array"}},
+	}
+	for _, tt := range tests {
+		if got := problems(t, s.ValidateInput(tt.input)); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s:\ngot  %q\nwant %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestValidateScalars(t *testing.T) {
+	tests := []struct {
+		schema string
+		input  string
+		valid  bool
+	}{
+		{`{"type": "string", "maxLength": 5}`, `hello`, true},
+		{`{"type": "string", "maxLength": 5}`, `hello world`, false},
+		{`{"type": "string"}`, `42`, true}, // Plain text that parses as JSON
+		{`{"type": "integer", "exclusiveMinimum": 0}`, `3`, true},
+		{`{"type": "integer"}`, `3.0`, true},
+		{`{"type": "integer"}`, `3.5`, false},
+		{`{"type": "integer", "exclusiveMinimum": 0}`, `0`, false},
+		{`{"type": "number", "multipleOf": 0.1}`, `0.3`, true},
+		{`{"type": "number", "multipleOf": 0.1}`, `0.35`, false},
+		{`{"type": ["string", "null"]}`, `null`, true},
+		{`{"const": "ping"}`, `"ping"`, true},
+		{`{"type": "string", "format": "email"}`, `ada@example.com`, true},
+		{`{"type": "string", "format": "email"}`, `Ada <ada@example.com>`, false},
+		{`{"type": "string", "format": "date"}`, `2025-02-30`, false},
+		{`{"type": "string", "format": "uri"}`, `https://example.com/x`, true},
+		{`{"type": "string", "format": "uri"}`, `example.com`, false},
+		{`{"anyOf": [{"type": "integer"}, {"type": "string", "minLength": 3}]}`, `"ab"`, false},
+		{`{"oneOf": [{"type": "integer"}, {"type": "number"}]}`, `1`, false},
+		{`{"oneOf": [{"type": "integer"}, {"type": "number"}]}`, `1.5`, true},
+		{`{"allOf": [{"minLength": 2}, {"maxLength": 3}]}`, `"abcd"`, false},
+		{`true`, `anything`, true},
+		{`false`, `anything`, false},
+	}
+	for _, tt := range tests {
+		s, err := Compile([]byte(tt.schema))
+		if err != nil {
+			t.Fatalf("%s: %v", tt.schema, err)
+		}
+		if err := s.ValidateInput(tt.input); (err == nil) != tt.valid {
+			t.Errorf("%s with %s: valid=%v, got %v", tt.schema, tt.input, tt.valid, err)
+		}
+	}
+}
+
+func TestValidateGoValues(t *testing.T) {
+	s := MustCompile(`{"type": "object", "properties": {"n": {"type": "integer", "maximum": 10}}}`)
+	if err := s.Validate(map[string]any{"n": 3}); err != nil {
+		t.Errorf("int: %v", err)
+	}
+	if err := s.Validate(map[string]any{"n": 30.0}); err == nil {
+		t.Error("expected float64 above the maximum to fail")
+	}
+	if err := s.Validate(struct {
+		N int `json:"n"`
+	}{N: 11}); err == nil {
+		t.Error("expected struct above the maximum to fail")
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	for _, raw := range []string{
+		`"string"`,
+		`{"type": "text"}`,
+		`{"$ref": "#/$defs/user"}`,
+		`{"properties": {"a": {"not": {}}}}`,
+		`{"pattern": "("}`,
+		`{"minLength": -1}`,
+		`{"multipleOf": 0}`,
+		`{"minimum": "1"}`,
+	} {
+		if _, err := Compile([]byte(raw)); err == nil {
+			t.Errorf("%s: expected error", raw)
+		}
+	}
+}
+
+func TestDescribe(t *testing.T) {
+	if got := MustCompile(translateSchema).Describe(); got != "a JSON object with formality (number), tags (array), text (string, required), to (string, required)" {
+		t.Errorf("got %q", got)
+	}
+	withExample := MustCompile(`{"type": "object", "examples": [{"text": "hi", "to": "fr"}]}`)
+	if got := withExample.Describe(); got != `for example {"text":"hi","to":"fr"}` {
+		t.Errorf("got %q", got)
+	}
+
+	err := withExample.ValidateInput("hi")
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) || !strings.HasPrefix(validationErr.Expected, "for example") {
+		t.Errorf("expected the description with the error, got %v", err)
+	}
+}
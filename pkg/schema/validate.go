@@ -0,0 +1,404 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/mail"
+	"net/url"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// maxProblems is how many problems a validation reports at most
+const maxProblems = 10
+
+// Problem is a single reason a value does not match the schema
+type Problem struct {
+	Path    string // Where the problem is, e.g. "user.emails[1]"; "input" for the whole value
+	Message string
+}
+
+func (p Problem) String() string {
+	return p.Path + ": " + p.Message
+}
+
+// ValidationError lists why a value does not match the schema
+type ValidationError struct {
+	Problems []Problem
+	Expected string // Description of the expected input, may be empty
+}
+
+func (e *ValidationError) Error() string {
+	problems := make([]string, len(e.Problems))
+	for i, problem := range e.Problems {
+		problems[i] = problem.String()
+	}
+	return "invalid input: " + strings.Join(problems, "; ")
+}
+
+// Validate checks a decoded JSON value. Numbers may be json.Number or any
+// Go number type. It returns a *ValidationError if the value does not match.
+func (s *Schema) Validate(value any) error {
+	v := validation{}
+	v.check(s, normalize(value), "")
+	if len(v.problems) == 0 {
+		return nil
+	}
+	return &ValidationError{Problems: v.problems, Expected: s.Describe()}
+}
+
+// ValidateJSON checks a JSON document
+func (s *Schema) ValidateJSON(data []byte) error {
+	value, err := decode(data)
+	if err != nil {
+		return &ValidationError{
+			Problems: []Problem{{Path: "input", Message: "is not valid JSON: " + err.Error()}},
+			Expected: s.Describe(),
+		}
+	}
+	return s.Validate(value)
+}
+
+// ValidateInput checks task input. Input that is not JSON is checked as a
+// string, so schemas of type string accept plain text.
+func (s *Schema) ValidateInput(input string) error {
+	value, err := decode([]byte(input))
+	if err != nil {
+		value = input
+	}
+	if _, isString := value.(string); !isString && s.acceptsOnlyStrings() {
+		// Plain text that happens to be valid JSON, e.g. a number
+		value = input
+	}
+	return s.Validate(value)
+}
+
+func (s *Schema) acceptsOnlyStrings() bool {
+	return len(s.types) == 1 && s.types[0] == "string"
+}
+
+type validation struct {
+	problems []Problem
+}
+
+func (v *validation) add(path, format string, args ...any) {
+	if len(v.problems) < maxProblems {
+		if path == "" {
+			path = "input"
+		}
+		v.problems = append(v.problems, Problem{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+}
+
+// matches reports whether value matches s without recording problems
+func matches(s *Schema, value any, path string) bool {
+	v := validation{}
+	v.check(s, value, path)
+	return len(v.problems) == 0
+}
+
+func (v *validation) check(s *Schema, value any, path string) {
+	if s.always != nil {
+		if !*s.always {
+			v.add(path, "is not allowed")
+		}
+		return
+	}
+
+	if len(s.types) > 0 && !slices.ContainsFunc(s.types, func(t string) bool { return hasType(value, t) }) {
+		v.add(path, "must be %s, got %s", withArticle(s.types), typeName(value))
+		return
+	}
+	if len(s.enum) > 0 && !slices.ContainsFunc(s.enum, func(allowed any) bool { return equal(allowed, value) }) {
+		v.add(path, "must be one of %s", listValues(s.enum))
+	}
+	if s.hasConst && !equal(s.constValue, value) {
+		v.add(path, "must be %s", compactJSON(s.constValue))
+	}
+
+	switch value := value.(type) {
+	case map[string]any:
+		v.checkObject(s, value, path)
+	case []any:
+		v.checkArray(s, value, path)
+	case string:
+		v.checkString(s, value, path)
+	case json.Number:
+		v.checkNumber(s, value, path)
+	}
+
+	for _, sub := range s.allOf {
+		v.check(sub, value, path)
+	}
+	if len(s.anyOf) > 0 && !slices.ContainsFunc(s.anyOf, func(sub *Schema) bool { return matches(sub, value, path) }) {
+		v.add(path, "does not match any of the allowed forms")
+	}
+	if len(s.oneOf) > 0 {
+		matched := 0
+		for _, sub := range s.oneOf {
+			if matches(sub, value, path) {
+				matched++
+			}
+		}
+		if matched != 1 {
+			v.add(path, "must match exactly one of the allowed forms, matches %d", matched)
+		}
+	}
+}
+
+func (v *validation) checkObject(s *Schema, object map[string]any, path string) {
+	for _, name := range s.required {
+		if _, ok := object[name]; !ok {
+			v.add(join(path, name), "is required")
+		}
+	}
+
+	names := make([]string, 0, len(object))
+	for name := range object {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		if property, ok := s.properties[name]; ok {
+			v.check(property, object[name], join(path, name))
+			continue
+		}
+		if s.additionalProperties != nil {
+			if s.additionalProperties.always != nil && !*s.additionalProperties.always {
+				v.add(join(path, name), "is not a known field")
+				continue
+			}
+			v.check(s.additionalProperties, object[name], join(path, name))
+		}
+	}
+}
+
+func (v *validation) checkArray(s *Schema, array []any, path string) {
+	if s.minItems != nil && len(array) < *s.minItems {
+		v.add(path, "must have at least %d %s", *s.minItems, plural(*s.minItems, "item"))
+	}
+	if s.maxItems != nil && len(array) > *s.maxItems {
+		v.add(path, "must have at most %d %s", *s.maxItems, plural(*s.maxItems, "item"))
+	}
+	if s.items != nil {
+		for i, item := range array {
+			v.check(s.items, item, fmt.Sprintf("%s[%d]", pathOrInput(path), i))
+		}
+	}
+}
+
+func (v *validation) checkString(s *Schema, value, path string) {
+	length := utf8.RuneCountInString(value)
+	if s.minLength != nil && length < *s.minLength {
+		if *s.minLength == 1 {
+			v.add(path, "must not be empty")
+		} else {
+			v.add(path, "must be at least %d characters long", *s.minLength)
+		}
+	}
+	if s.maxLength != nil && length > *s.maxLength {
+		v.add(path, "must be at most %d characters long", *s.maxLength)
+	}
+	if s.pattern != nil && !s.pattern.MatchString(value) {
+		v.add(path, "must match the pattern %s", s.pattern)
+	}
+	if s.format != "" && !validFormat(s.format, value) {
+		v.add(path, "must be a valid %s", s.format)
+	}
+}
+
+func (v *validation) checkNumber(s *Schema, value json.Number, path string) {
+	n, ok := new(big.Rat).SetString(value.String())
+	if !ok {
+		return
+	}
+	if s.minimum != nil && n.Cmp(s.minimum) < 0 {
+		v.add(path, "must be at least %s", s.minimum.RatString())
+	}
+	if s.maximum != nil && n.Cmp(s.maximum) > 0 {
+		v.add(path, "must be at most %s", s.maximum.RatString())
+	}
+	if s.exclusiveMinimum != nil && n.Cmp(s.exclusiveMinimum) <= 0 {
+		v.add(path, "must be greater than %s", s.exclusiveMinimum.RatString())
+	}
+	if s.exclusiveMaximum != nil && n.Cmp(s.exclusiveMaximum) >= 0 {
+		v.add(path, "must be less than %s", s.exclusiveMaximum.RatString())
+	}
+	if s.multipleOf != nil && !new(big.Rat).Quo(n, s.multipleOf).IsInt() {
+		v.add(path, "must be a multiple of %s", s.multipleOf.RatString())
+	}
+}
+
+// validFormat checks the formats task input commonly uses; unknown formats pass
+func validFormat(format, value string) bool {
+	switch format {
+	case "date-time":
+		_, err := time.Parse(time.RFC3339, value)
+		return err == nil
+	case "date":
+		_, err := time.Parse(time.DateOnly, value)
+		return err == nil
+	case "email":
+		address, err := mail.ParseAddress(value)
+		return err == nil && address.Address == value
+	case "uri", "url":
+		u, err := url.Parse(value)
+		return err == nil && u.Scheme != "" && (u.Host != "" || u.Opaque != "")
+	case "uuid":
+		return len(value) == 36 && strings.Count(value, "-") == 4 && strings.Trim(strings.ReplaceAll(value, "-", ""), "0123456789abcdefABCDEF") == ""
+	}
+	return true
+}
+
+func hasType(value any, t string) bool {
+	switch t {
+	case "object":
+		_, ok := value.(map[string]any)
+		return ok
+	case "array":
+		_, ok := value.([]any)
+		return ok
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "null":
+		return value == nil
+	case "number":
+		_, ok := value.(json.Number)
+		return ok
+	case "integer":
+		n, ok := value.(json.Number)
+		if !ok {
+			return false
+		}
+		r, ok := new(big.Rat).SetString(n.String())
+		return ok && r.IsInt()
+	}
+	return false
+}
+
+func typeName(value any) string {
+	switch value.(type) {
+	case map[string]any:
+		return "an object"
+	case []any:
+		return "an array"
+	case string:
+		return "a string"
+	case bool:
+		return "a boolean"
+	case json.Number:
+		return "a number"
+	case nil:
+		return "null"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+func withArticle(types []string) string {
+	names := make([]string, len(types))
+	for i, t := range types {
+		switch t {
+		case "null":
+			names[i] = "null"
+		case "object", "array", "integer":
+			names[i] = "an " + t
+		default:
+			names[i] = "a " + t
+		}
+	}
+	return strings.Join(names, " or ")
+}
+
+// normalize converts Go values to the types produced by decode, so values
+// built in Go validate like decoded JSON
+func normalize(value any) any {
+	switch v := value.(type) {
+	case nil, bool, string, json.Number:
+		return v
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for key, item := range v {
+			out[key] = normalize(item)
+		}
+		return out
+	case []any:
+		out := make([]any, len(v))
+		for i, item := range v {
+			out[i] = normalize(item)
+		}
+		return out
+	case float64:
+		return json.Number(strconv.FormatFloat(v, 'g', -1, 64))
+	case float32:
+		return json.Number(strconv.FormatFloat(float64(v), 'g', -1, 32))
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return json.Number(fmt.Sprint(v))
+	}
+	// Structs, typed maps and slices: go through JSON
+	data, err := json.Marshal(value)
+	if err != nil {
+		return value
+	}
+	decoded, err := decode(data)
+	if err != nil {
+		return value
+	}
+	return decoded
+}
+
+func equal(a, b any) bool {
+	an, aIsNumber := a.(json.Number)
+	bn, bIsNumber := b.(json.Number)
+	if aIsNumber && bIsNumber {
+		ar, aok := new(big.Rat).SetString(an.String())
+		br, bok := new(big.Rat).SetString(bn.String())
+		return aok && bok && ar.Cmp(br) == 0
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+func listValues(values []any) string {
+	parts := make([]string, len(values))
+	for i, value := range values {
+		parts[i] = compactJSON(value)
+	}
+	return strings.Join(parts, ", ")
+}
+
+func compactJSON(value any) string {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(data)
+}
+
+func join(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+func pathOrInput(path string) string {
+	if path == "" {
+		return "input"
+	}
+	return path
+}
+
+func plural(n int, word string) string {
+	if n == 1 {
+		return word
+	}
+	return word + "s"
+}
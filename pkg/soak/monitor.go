@@ -0,0 +1,43 @@
+package soak
+
+import "runtime"
+
+// Sample is a measurement of the process's resources
+type Sample struct {
+	Goroutines int    `json:"goroutines"`
+	HeapBytes  uint64 `json:"heap_bytes"` // Live heap after a garbage collection
+}
+
+// Resources tracks the process's resources over a run
+type Resources struct {
+	Baseline Sample `json:"baseline"` // After the warmup
+	Peak     Sample `json:"peak"`     // Highest goroutine count and heap since the baseline, each on its own
+	Final    Sample `json:"final"`    // After the load drained
+}
+
+// measure collects garbage and samples the process
+func measure() Sample {
+	runtime.GC()
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	return Sample{Goroutines: runtime.NumGoroutine(), HeapBytes: mem.HeapAlloc}
+}
+
+// observe records a sample taken after the baseline
+func (r *Resources) observe(s Sample) {
+	r.Peak.Goroutines = max(r.Peak.Goroutines, s.Goroutines)
+	r.Peak.HeapBytes = max(r.Peak.HeapBytes, s.HeapBytes)
+}
+
+// goroutineGrowth is the number of goroutines the run left behind
+func (r *Resources) goroutineGrowth() int {
+	return r.Final.Goroutines - r.Baseline.Goroutines
+}
+
+// heapGrowth is how far the heap grew above the baseline at its peak
+func (r *Resources) heapGrowth() uint64 {
+	if r.Peak.HeapBytes < r.Baseline.HeapBytes {
+		return 0
+	}
+	return r.Peak.HeapBytes - r.Baseline.HeapBytes
+}
@@ -0,0 +1,320 @@
+// Package soak runs an agent against the local coordinator of package
+// devserver for hours to validate its stability before a release. The
+// coordinator sends a steady synthetic load while injecting faults (forced disconnects, latency, lost tasks,
+// rejected authentications, expiring sessions), and the run checks that
+//
+//   - every task is answered: tasks lost to a fault are redelivered, so a
+//     task still unanswered after the load drains is a lost response,
+//   - the goroutine count returns to where it was after the warmup, and
+//   - the live heap stays within a bound of the warmup baseline.
+//
+// The agent runs in the same process so its goroutines and heap are measured:
+//
+//	report, err := soak.Run(ctx, &soak.Config{
+//		Duration: 4 * time.Hour,
+//		NewAgent: func(server *devserver.Server) (soak.Agent, error) {
+//			config := agent.DefaultConfig()
+//			config.WebSocketURL = server.URL()
+//			...
+//			return agent.NewEnhancedAgent(&agent.EnhancedAgentConfig{Config: config, AgentHandler: &soak.Handler{}, BackendURL: server.HTTPURL(), TokenID: 1})
+//		},
+//	})
+//
+// The teneo-agent command runs a soak test of an agent config with
+// "teneo-agent soak".
+package soak
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	mathrand "math/rand"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/devserver"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/logging"
+)
+
+// Agent is the agent under test
+type Agent interface {
+	Start() error
+	Stop() error
+}
+
+// Config configures a soak run
+type Config struct {
+	Duration       time.Duration // Time under load (default 1h)
+	Warmup         time.Duration // Load before the resource baseline is taken (default 1m)
+	Grace          time.Duration // Time after the load for the last responses (default 2m)
+	SampleInterval time.Duration // Interval of resource samples and progress reports (default 10s)
+	RedeliverAfter time.Duration // Redelivery of unanswered tasks (default 30s)
+
+	Rate         float64 // Tasks per second (default 5)
+	Rooms        int     // Rooms the tasks are spread over (default 3)
+	PayloadBytes int     // Size of each task (default 256)
+
+	Faults devserver.Faults
+
+	MaxGoroutineGrowth int    // Goroutines the run may leave behind (default 25)
+	MaxHeapGrowth      uint64 // Bytes the live heap may grow above the baseline (default 64 MiB)
+
+	// NewAgent creates the agent connected to the server (required)
+	NewAgent func(server *devserver.Server) (Agent, error)
+
+	// Progress is called with the report so far after each sample (optional)
+	Progress func(*Report)
+}
+
+func (c *Config) withDefaults() Config {
+	config := *c
+	if config.Duration <= 0 {
+		config.Duration = time.Hour
+	}
+	if config.Warmup <= 0 {
+		config.Warmup = time.Minute
+	}
+	if config.Warmup >= config.Duration {
+		config.Warmup = config.Duration / 10
+	}
+	if config.Grace <= 0 {
+		config.Grace = 2 * time.Minute
+	}
+	if config.SampleInterval <= 0 {
+		config.SampleInterval = 10 * time.Second
+	}
+	if config.RedeliverAfter <= 0 {
+		config.RedeliverAfter = 30 * time.Second
+	}
+	if config.Rate <= 0 {
+		config.Rate = 5
+	}
+	if config.Rooms <= 0 {
+		config.Rooms = 3
+	}
+	if config.PayloadBytes <= 0 {
+		config.PayloadBytes = 256
+	}
+	if config.MaxGoroutineGrowth <= 0 {
+		config.MaxGoroutineGrowth = 25
+	}
+	if config.MaxHeapGrowth == 0 {
+		config.MaxHeapGrowth = 64 << 20
+	}
+	return config
+}
+
+// Report is the outcome of a soak run
+type Report struct {
+	Started    time.Time       `json:"started"`
+	Elapsed    time.Duration   `json:"elapsed"`
+	Stats      devserver.Stats `json:"stats"`
+	Resources  Resources       `json:"resources"`
+	Lost       []string        `json:"lost,omitempty"` // Tasks never answered, the first 100
+	Violations []string        `json:"violations,omitempty"`
+}
+
+// Passed reports whether the run held every invariant
+func (r *Report) Passed() bool {
+	return len(r.Violations) == 0
+}
+
+// Run runs a soak test and reports the invariants it violated. Cancelling ctx
+// ends the load early; the run still drains and reports. An error means the
+// run could not be carried out, e.g. because the agent never registered.
+func Run(ctx context.Context, config *Config) (*Report, error) {
+	if config == nil || config.NewAgent == nil {
+		return nil, errors.New("soak test needs a function creating the agent")
+	}
+	c := config.withDefaults()
+
+	// Answered tasks are forgotten so a long run does not grow the heap
+	server, err := devserver.New(&devserver.Config{RedeliverAfter: c.RedeliverAfter, Forget: true})
+	if err != nil {
+		return nil, err
+	}
+	defer server.Close()
+
+	agent, err := c.NewAgent(server)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create agent: %w", err)
+	}
+	if err := agent.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start agent: %w", err)
+	}
+	defer agent.Stop()
+
+	registerCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	if _, err := server.WaitForAgent(registerCtx, ""); err != nil {
+		return nil, fmt.Errorf("agent did not register with the soak server: %w", err)
+	}
+	logging.Info("soak test started", "duration", c.Duration, "rate", c.Rate, "rooms", c.Rooms)
+
+	report := &Report{Started: time.Now()}
+	server.SetFaults(c.Faults)
+
+	loadCtx, stopLoad := context.WithTimeout(ctx, c.Duration)
+	defer stopLoad()
+	var load sync.WaitGroup
+	load.Add(1)
+	go func() {
+		defer load.Done()
+		generate(loadCtx, server, &c)
+	}()
+
+	// Sample resources until the load ends
+	baselined := false
+	warmup := time.After(c.Warmup)
+	ticker := time.NewTicker(c.SampleInterval)
+	defer ticker.Stop()
+sampling:
+	for {
+		select {
+		case <-warmup:
+			report.Resources.Baseline = measure()
+			report.Resources.Peak = report.Resources.Baseline
+			baselined = true
+		case <-ticker.C:
+			if baselined {
+				report.Resources.observe(measure())
+			}
+			c.progress(report, server)
+		case <-loadCtx.Done():
+			break sampling
+		}
+	}
+	load.Wait()
+	if !baselined {
+		// Stopped during the warmup: compare with the end of the load
+		report.Resources.Baseline = measure()
+		report.Resources.Peak = report.Resources.Baseline
+	}
+
+	// Drain without faults: every task left must be answered
+	logging.Info("soak load finished, draining", "pending", server.Stats().Pending)
+	server.SetFaults(devserver.Faults{})
+	server.Redeliver()
+	waitFor(context.Background(), c.Grace, func() bool { return server.Stats().Pending == 0 })
+
+	// Goroutines of the last tasks and connections take a moment to end
+	report.Resources.Final = measure()
+	for settle := 0; settle < 20 && report.Resources.goroutineGrowth() > c.MaxGoroutineGrowth; settle++ {
+		time.Sleep(250 * time.Millisecond)
+		report.Resources.Final = measure()
+	}
+	report.Resources.observe(report.Resources.Final)
+
+	c.progress(report, server)
+	report.Lost = server.Unanswered()
+	if len(report.Lost) > maxLostReported {
+		report.Lost = report.Lost[:maxLostReported]
+	}
+	report.Violations = c.check(report)
+	logging.Info("soak test finished", "passed", report.Passed(), "tasks", report.Stats.Tasks, "violations", len(report.Violations))
+	return report, nil
+}
+
+const maxLostReported = 100
+
+// progress updates the report with the server's stats and reports it
+func (c *Config) progress(report *Report, server *devserver.Server) {
+	report.Elapsed = time.Since(report.Started)
+	report.Stats = server.Stats()
+	if c.Progress != nil {
+		c.Progress(report)
+	}
+}
+
+// check returns the invariants the run violated
+func (c *Config) check(report *Report) []string {
+	var violations []string
+	if len(report.Lost) > 0 {
+		violations = append(violations, fmt.Sprintf("%d of %d tasks never answered, e.g. %s", max(report.Stats.Pending, len(report.Lost)), report.Stats.Tasks, report.Lost[0]))
+	}
+	if growth := report.Resources.goroutineGrowth(); growth > c.MaxGoroutineGrowth {
+		violations = append(violations, fmt.Sprintf("goroutines grew by %d (from %d to %d), limit %d",
+			growth, report.Resources.Baseline.Goroutines, report.Resources.Final.Goroutines, c.MaxGoroutineGrowth))
+	}
+	if growth := report.Resources.heapGrowth(); growth > c.MaxHeapGrowth {
+		violations = append(violations, fmt.Sprintf("heap grew by %d bytes (from %d to %d), limit %d",
+			growth, report.Resources.Baseline.HeapBytes, report.Resources.Peak.HeapBytes, c.MaxHeapGrowth))
+	}
+	return violations
+}
+
+// generate sends tasks at the configured rate, round robin over the rooms
+func generate(ctx context.Context, server *devserver.Server, c *Config) {
+	ticker := time.NewTicker(time.Duration(float64(time.Second) / c.Rate))
+	defer ticker.Stop()
+	padding := strings.Repeat("x", c.PayloadBytes)
+	for n := 0; ; n++ {
+		select {
+		case <-ticker.C:
+			room := fmt.Sprintf("soak-room-%d", n%c.Rooms+1)
+			content := fmt.Sprintf("soak task %d ", n)
+			if pad := c.PayloadBytes - len(content); pad > 0 {
+				content += padding[:pad]
+			}
+			// Tasks wait for the agent while it is disconnected
+			server.SendTask(devserver.Task{Room: room, Content: content})
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// waitFor polls done until it returns true or the timeout passes
+func waitFor(ctx context.Context, timeout time.Duration, done func() bool) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	ticker := time.NewTicker(50 * time.Millisecond)
+	defer ticker.Stop()
+	for !done() {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// Handler is a synthetic task handler: it echoes the task after a random
+// delay and fails a fraction of tasks
+type Handler struct {
+	Latency   time.Duration // Maximum processing time (default 100ms)
+	ErrorRate float64       // Fraction of tasks that fail
+
+	mu  sync.Mutex
+	rng *mathrand.Rand
+}
+
+// ProcessTask implements types.AgentHandler
+func (h *Handler) ProcessTask(ctx context.Context, task string) (string, error) {
+	h.mu.Lock()
+	if h.rng == nil {
+		h.rng = mathrand.New(mathrand.NewSource(time.Now().UnixNano()))
+	}
+	latency := h.Latency
+	if latency <= 0 {
+		latency = 100 * time.Millisecond
+	}
+	delay := time.Duration(h.rng.Int63n(int64(latency)))
+	fail := h.rng.Float64() < h.ErrorRate
+	h.mu.Unlock()
+
+	select {
+	case <-time.After(delay):
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+	if fail {
+		return "", errors.New("synthetic failure")
+	}
+	if len(task) > 32 {
+		task = task[:32]
+	}
+	return "echo: " + task, nil
+}
@@ -0,0 +1,203 @@
+package soak
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/devserver"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/types"
+	"github.com/gorilla/websocket"
+)
+
+// fakeAgent speaks the agent side of the protocol: it authenticates,
+// registers, answers tasks and reconnects when the connection drops
+type fakeAgent struct {
+	url  string
+	skip int  // Never answer tasks whose number is a multiple of skip (0 = answer all)
+	leak bool // Keep a goroutine per task until the agent stops
+
+	stop chan struct{}
+	done chan struct{}
+
+	mu      sync.Mutex
+	ws      *websocket.Conn
+	stopped bool
+}
+
+func newFakeAgent(server *devserver.Server) *fakeAgent {
+	return &fakeAgent{url: server.URL(), stop: make(chan struct{}), done: make(chan struct{})}
+}
+
+func (a *fakeAgent) Start() error {
+	go a.run()
+	return nil
+}
+
+func (a *fakeAgent) Stop() error {
+	a.mu.Lock()
+	a.stopped = true
+	if a.ws != nil {
+		a.ws.Close()
+	}
+	a.mu.Unlock()
+	close(a.stop)
+	<-a.done
+	return nil
+}
+
+func (a *fakeAgent) run() {
+	defer close(a.done)
+	for {
+		ws, _, err := websocket.DefaultDialer.Dial(a.url, nil)
+		if err != nil {
+			select {
+			case <-time.After(20 * time.Millisecond):
+				continue
+			case <-a.stop:
+				return
+			}
+		}
+		a.mu.Lock()
+		if a.stopped {
+			a.mu.Unlock()
+			ws.Close()
+			return
+		}
+		a.ws = ws
+		a.mu.Unlock()
+
+		a.serve(ws)
+		ws.Close()
+	}
+}
+
+func (a *fakeAgent) serve(ws *websocket.Conn) {
+	send := func(msg *types.Message) { ws.WriteJSON(msg) }
+	send(&types.Message{Type: types.MessageTypeRequestChallenge})
+	for {
+		var msg types.Message
+		if err := ws.ReadJSON(&msg); err != nil {
+			return
+		}
+		switch msg.Type {
+		case types.MessageTypeChallenge:
+			var challenge types.ChallengeMessage
+			json.Unmarshal(msg.Data, &challenge)
+			auth, _ := json.Marshal(types.AuthMessage{Address: "0xsoak", Message: challenge.Challenge, Signature: "sig", AgentName: "soak"})
+			send(&types.Message{Type: types.MessageTypeAuth, Data: auth})
+		case types.MessageTypeAuthSuccess:
+			send(&types.Message{Type: types.MessageTypeRegister})
+		case types.MessageTypeError:
+			if strings.Contains(msg.Content, "session expired") {
+				send(&types.Message{Type: types.MessageTypeRequestChallenge})
+			}
+		case types.MessageTypeTask:
+			var data struct {
+				TaskID string `json:"task_id"`
+			}
+			json.Unmarshal(msg.Data, &data)
+			var n int
+			fmt.Sscanf(data.TaskID, "dev-%d", &n)
+			if a.skip > 0 && n%a.skip == 0 {
+				continue
+			}
+			if a.leak {
+				go func() { <-a.stop }()
+			}
+			send(&types.Message{Type: types.MessageTypeTaskResponse, TaskID: data.TaskID, Content: "done", ContentType: types.StandardMessageTypeString})
+		}
+	}
+}
+
+func testConfig(agent func(*fakeAgent)) *Config {
+	return &Config{
+		Duration:       2 * time.Second,
+		Warmup:         300 * time.Millisecond,
+		Grace:          3 * time.Second,
+		SampleInterval: 100 * time.Millisecond,
+		RedeliverAfter: 200 * time.Millisecond,
+		Rate:           100,
+		NewAgent: func(server *devserver.Server) (Agent, error) {
+			a := newFakeAgent(server)
+			if agent != nil {
+				agent(a)
+			}
+			return a, nil
+		},
+	}
+}
+
+func TestRunWithFaults(t *testing.T) {
+	config := testConfig(nil)
+	config.Faults = devserver.Faults{
+		DisconnectEvery: 300 * time.Millisecond,
+		Latency:         5 * time.Millisecond,
+		DropRate:        0.1,
+		AuthFailureRate: 0.2,
+		SessionTTL:      400 * time.Millisecond,
+	}
+	report, err := Run(context.Background(), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !report.Passed() {
+		t.Fatalf("violations: %v", report.Violations)
+	}
+
+	stats := report.Stats
+	if stats.Tasks < 100 || stats.Answered != stats.Tasks {
+		t.Errorf("answered %d of %d tasks", stats.Answered, stats.Tasks)
+	}
+	if stats.Disconnects == 0 || stats.Dropped == 0 || stats.Redeliveries == 0 || stats.SessionExpiries == 0 {
+		t.Errorf("faults were not injected: %+v", stats)
+	}
+}
+
+func TestRunReportsLostResponses(t *testing.T) {
+	config := testConfig(func(a *fakeAgent) { a.skip = 10 })
+	config.Grace = 500 * time.Millisecond
+	report, err := Run(context.Background(), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Passed() || len(report.Lost) == 0 {
+		t.Fatalf("expected lost responses, got %+v", report)
+	}
+	if !strings.Contains(report.Violations[0], "never answered") {
+		t.Errorf("got violations %v", report.Violations)
+	}
+}
+
+func TestRunReportsGoroutineGrowth(t *testing.T) {
+	config := testConfig(func(a *fakeAgent) { a.leak = true })
+	report, err := Run(context.Background(), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Violations) != 1 || !strings.Contains(report.Violations[0], "goroutines grew") {
+		t.Errorf("got violations %v", report.Violations)
+	}
+}
+
+func TestRunStopsEarly(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	config := testConfig(nil)
+	config.Duration = time.Hour
+	start := time.Now()
+	report, err := Run(ctx, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("run took %s after cancellation", elapsed)
+	}
+	if !report.Passed() {
+		t.Errorf("violations: %v", report.Violations)
+	}
+}
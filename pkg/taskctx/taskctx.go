@@ -0,0 +1,96 @@
+// Package taskctx tells handlers how much time their task has left and why it
+// was stopped, from the context the SDK passes them. Handlers use it to budget
+// sub-operations such as LLM or RPC calls, keeping time to send a result
+// instead of overrunning the task's deadline:
+//
+//	func (h *Handler) ProcessTask(ctx context.Context, task string) (string, error) {
+//		llmCtx, cancel := taskctx.WithBudget(ctx, 0.8, 2*time.Second)
+//		defer cancel()
+//		answer, err := h.llm.Complete(llmCtx, task)
+//		if err != nil && taskctx.CancelReason(ctx) == taskctx.ReasonNone {
+//			return "Taking a shortcut: " + h.quickAnswer(task), nil // The LLM ran out of budget, the task didn't
+//		}
+//		return answer, err
+//	}
+package taskctx
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/scheduler"
+)
+
+var (
+	// ErrCancelled is the cause of the context of a task cancelled by an
+	// operator, e.g. through the control API
+	ErrCancelled = errors.New("task cancelled")
+
+	// ErrShutdown is the cause of the context of tasks stopped because the agent stops
+	ErrShutdown = errors.New("agent shutting down")
+)
+
+// Reason is why a task's context ended
+type Reason string
+
+const (
+	ReasonNone      Reason = ""          // The context has not ended
+	ReasonDeadline  Reason = "deadline"  // The task ran out of time
+	ReasonCancelled Reason = "cancelled" // An operator cancelled the task, or the caller of the SDK cancelled the context
+	ReasonPreempted Reason = "preempted" // A higher-priority task took the worker; the task runs again later
+	ReasonShutdown  Reason = "shutdown"  // The agent is stopping
+)
+
+// Deadline returns when the task must be done, including extensions made with
+// ExtendDeadline; false if the task has no deadline
+func Deadline(ctx context.Context) (time.Time, bool) {
+	return ctx.Deadline()
+}
+
+// Remaining returns the time left until the task's deadline, 0 once it has
+// passed; false if the task has no deadline
+func Remaining(ctx context.Context) (time.Duration, bool) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return 0, false
+	}
+	return max(time.Until(deadline), 0), true
+}
+
+// WithBudget returns a context for a sub-operation that ends after a fraction
+// of the task's remaining time, and at the latest reserve before the task's
+// deadline so there is time left to send the result. A fraction outside
+// (0, 1] means all of the remaining time. Without a task deadline the
+// context only ends with ctx.
+func WithBudget(ctx context.Context, fraction float64, reserve time.Duration) (context.Context, context.CancelFunc) {
+	remaining, ok := Remaining(ctx)
+	if !ok {
+		return context.WithCancel(ctx)
+	}
+	budget := remaining - reserve
+	if fraction > 0 && fraction < 1 {
+		budget = min(budget, time.Duration(float64(remaining)*fraction))
+	}
+	return context.WithTimeout(ctx, budget)
+}
+
+// CancelReason returns why the task's context ended, ReasonNone while it runs
+func CancelReason(ctx context.Context) Reason {
+	err := ctx.Err()
+	if err == nil {
+		return ReasonNone
+	}
+	cause := context.Cause(ctx)
+	switch {
+	case errors.Is(cause, scheduler.ErrPreempted):
+		return ReasonPreempted
+	case errors.Is(cause, ErrShutdown), errors.Is(cause, scheduler.ErrClosed):
+		return ReasonShutdown
+	case errors.Is(cause, ErrCancelled):
+		return ReasonCancelled
+	case errors.Is(err, context.DeadlineExceeded):
+		return ReasonDeadline
+	}
+	return ReasonCancelled
+}
@@ -0,0 +1,95 @@
+package taskctx
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/scheduler"
+)
+
+func TestRemaining(t *testing.T) {
+	if _, ok := Remaining(context.Background()); ok {
+		t.Error("context without deadline has remaining time")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	if remaining, ok := Remaining(ctx); !ok || remaining <= 59*time.Second || remaining > time.Minute {
+		t.Errorf("remaining = %s, %v", remaining, ok)
+	}
+
+	past, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+	if remaining, _ := Remaining(past); remaining != 0 {
+		t.Errorf("remaining after the deadline = %s", remaining)
+	}
+}
+
+func TestWithBudget(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	tests := []struct {
+		fraction float64
+		reserve  time.Duration
+		want     time.Duration
+	}{
+		{0.5, time.Second, 5 * time.Second},
+		{0.95, 2 * time.Second, 8 * time.Second}, // The reserve wins
+		{0, time.Second, 9 * time.Second},
+	}
+	for _, tt := range tests {
+		budgeted, cancel := WithBudget(ctx, tt.fraction, tt.reserve)
+		remaining, ok := Remaining(budgeted)
+		cancel()
+		if !ok || remaining > tt.want || remaining < tt.want-100*time.Millisecond {
+			t.Errorf("WithBudget(%v, %s): remaining %s, want %s", tt.fraction, tt.reserve, remaining, tt.want)
+		}
+	}
+
+	// Nothing left after the reserve
+	budgeted, cancel := WithBudget(ctx, 1, time.Minute)
+	defer cancel()
+	if budgeted.Err() == nil {
+		t.Error("budget beyond the deadline is not done")
+	}
+
+	// No deadline, no budget
+	budgeted, cancel = WithBudget(context.Background(), 0.5, time.Second)
+	defer cancel()
+	if _, ok := budgeted.Deadline(); ok {
+		t.Error("budget without a task deadline has a deadline")
+	}
+}
+
+func TestCancelReason(t *testing.T) {
+	if reason := CancelReason(context.Background()); reason != ReasonNone {
+		t.Errorf("running context: %q", reason)
+	}
+
+	expired, cancel := context.WithTimeout(context.Background(), 0)
+	defer cancel()
+	<-expired.Done()
+
+	causes := map[error]Reason{
+		ErrCancelled:           ReasonCancelled,
+		ErrShutdown:            ReasonShutdown,
+		scheduler.ErrPreempted: ReasonPreempted,
+		fmt.Errorf("wrapped: %w", scheduler.ErrClosed): ReasonShutdown,
+		context.Canceled: ReasonCancelled,
+	}
+	for cause, want := range causes {
+		ctx, cancel := context.WithCancelCause(context.Background())
+		cancel(cause)
+		// The reason is visible through derived contexts
+		derived := context.WithValue(ctx, struct{}{}, "task")
+		if reason := CancelReason(derived); reason != want {
+			t.Errorf("cause %v: got %q, want %q", cause, reason, want)
+		}
+	}
+	if reason := CancelReason(expired); reason != ReasonDeadline {
+		t.Errorf("expired context: %q", reason)
+	}
+}
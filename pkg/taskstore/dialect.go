@@ -0,0 +1,86 @@
+package taskstore
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Dialect selects the SQL flavor of the database
+type Dialect string
+
+// Supported dialects
+const (
+	Postgres Dialect = "postgres"
+	MySQL    Dialect = "mysql" // Also MariaDB
+	SQLite   Dialect = "sqlite"
+)
+
+// ParseDialect parses a dialect or database/sql driver name,
+// e.g. "postgres", "pgx", "mysql", "sqlite" or "sqlite3"
+func ParseDialect(name string) (Dialect, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "postgres", "postgresql", "pgx":
+		return Postgres, nil
+	case "mysql", "mariadb":
+		return MySQL, nil
+	case "sqlite", "sqlite3":
+		return SQLite, nil
+	default:
+		return "", fmt.Errorf("unsupported SQL dialect %q (use postgres, mysql or sqlite)", name)
+	}
+}
+
+// placeholder returns the bind parameter for the n-th argument (1-based)
+func (d Dialect) placeholder(n int) string {
+	if d == Postgres {
+		return fmt.Sprintf("$%d", n)
+	}
+	return "?"
+}
+
+// placeholders returns the bind parameters for n arguments, comma separated
+func (d Dialect) placeholders(n int) string {
+	params := make([]string, n)
+	for i := range params {
+		params[i] = d.placeholder(i + 1)
+	}
+	return strings.Join(params, ", ")
+}
+
+// Column types. MySQL cannot index unbounded TEXT columns, so short values
+// use VARCHAR there, and TEXT is limited to 64 KB, so results use MEDIUMTEXT.
+func (d Dialect) idColumn() string {
+	switch d {
+	case Postgres:
+		return "BIGSERIAL PRIMARY KEY"
+	case MySQL:
+		return "BIGINT NOT NULL AUTO_INCREMENT PRIMARY KEY"
+	default:
+		return "INTEGER PRIMARY KEY AUTOINCREMENT"
+	}
+}
+
+func (d Dialect) keyType() string {
+	if d == MySQL {
+		return "VARCHAR(255)"
+	}
+	return "TEXT"
+}
+
+func (d Dialect) textType() string {
+	if d == MySQL {
+		return "MEDIUMTEXT"
+	}
+	return "TEXT"
+}
+
+func (d Dialect) timeType() string {
+	switch d {
+	case Postgres:
+		return "TIMESTAMPTZ"
+	case MySQL:
+		return "DATETIME(6)"
+	default:
+		return "TIMESTAMP"
+	}
+}
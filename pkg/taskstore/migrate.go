@@ -0,0 +1,142 @@
+package taskstore
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/logging"
+)
+
+// migration is one versioned change to the schema of the task table
+type migration struct {
+	version     int
+	description string
+	statements  func(d Dialect, table string) []string
+}
+
+// migrations in the order they are applied. Released migrations must not
+// change; schema changes are added as a new version.
+var migrations = []migration{
+	{version: 1, description: "create task results table", statements: createTaskTable},
+}
+
+func createTaskTable(d Dialect, table string) []string {
+	columns := fmt.Sprintf(`id %s,
+	task_id %s NOT NULL,
+	agent %s NOT NULL,
+	room %s NOT NULL,
+	sender %s NOT NULL,
+	input %s NOT NULL,
+	result %s NOT NULL,
+	started_at %s NULL,
+	completed_at %s NOT NULL,
+	duration_ms BIGINT NOT NULL`,
+		d.idColumn(), d.keyType(), d.keyType(), d.keyType(), d.keyType(),
+		d.textType(), d.textType(), d.timeType(), d.timeType())
+
+	// MySQL has no CREATE INDEX IF NOT EXISTS, so its indexes are part of the table
+	if d == MySQL {
+		return []string{
+			fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (\n\t%s,\n\tINDEX %s_task_id (task_id),\n\tINDEX %s_completed_at (completed_at)\n)", table, columns, table, table),
+		}
+	}
+	return []string{
+		fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (\n\t%s\n)", table, columns),
+		fmt.Sprintf("CREATE INDEX IF NOT EXISTS %s_task_id ON %s (task_id)", table, table),
+		fmt.Sprintf("CREATE INDEX IF NOT EXISTS %s_completed_at ON %s (completed_at)", table, table),
+	}
+}
+
+// Schema returns the statements creating the task table at the latest schema
+// version, for databases migrated by external tools instead of Store.Migrate
+func Schema(dialect Dialect, table string) ([]string, error) {
+	if _, err := ParseDialect(string(dialect)); err != nil {
+		return nil, err
+	}
+	if table == "" {
+		table = DefaultTable
+	}
+	if !validIdentifier.MatchString(table) {
+		return nil, fmt.Errorf("invalid table name %q", table)
+	}
+
+	var statements []string
+	for _, m := range migrations {
+		statements = append(statements, m.statements(dialect, table)...)
+	}
+	return statements, nil
+}
+
+// migrationsTable returns the table recording the applied schema versions
+func (s *Store) migrationsTable() string {
+	return s.table + "_migrations"
+}
+
+// Migrate creates the task table or brings it to the latest schema version.
+// Applied versions are recorded in the <table>_migrations table, so Migrate
+// is safe to call on every start.
+func (s *Store) Migrate(ctx context.Context) error {
+	create := fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (\n\tversion INTEGER NOT NULL PRIMARY KEY,\n\tdescription %s NOT NULL,\n\tapplied_at %s NOT NULL\n)",
+		s.migrationsTable(), s.dialect.keyType(), s.dialect.timeType())
+	if _, err := s.db.ExecContext(ctx, create); err != nil {
+		return fmt.Errorf("failed to create migrations table: %w", err)
+	}
+
+	current, err := s.SchemaVersion(ctx)
+	if err != nil {
+		return err
+	}
+	for _, m := range migrations {
+		if m.version <= current {
+			continue
+		}
+		if err := s.apply(ctx, m); err != nil {
+			// Another agent sharing the database may have applied it first
+			if version, versionErr := s.SchemaVersion(ctx); versionErr == nil && version >= m.version {
+				continue
+			}
+			return fmt.Errorf("failed to apply migration %d (%s): %w", m.version, m.description, err)
+		}
+		logging.Info("applied task store migration", "table", s.table, "version", m.version, "description", m.description)
+	}
+	return nil
+}
+
+// apply runs a migration and records its version in one transaction.
+// MySQL commits schema changes immediately, so there a failed migration
+// can leave its earlier statements applied.
+func (s *Store) apply(ctx context.Context, m migration) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, statement := range m.statements(s.dialect, s.table) {
+		if _, err := tx.ExecContext(ctx, statement); err != nil {
+			return err
+		}
+	}
+	record := fmt.Sprintf("INSERT INTO %s (version, description, applied_at) VALUES (%s)", s.migrationsTable(), s.dialect.placeholders(3))
+	if _, err := tx.ExecContext(ctx, record, m.version, m.description, time.Now().UTC()); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// SchemaVersion returns the latest schema version applied to the task table
+// (0 before the first migration)
+func (s *Store) SchemaVersion(ctx context.Context) (int, error) {
+	var version int
+	query := fmt.Sprintf("SELECT COALESCE(MAX(version), 0) FROM %s", s.migrationsTable())
+	if err := s.db.QueryRowContext(ctx, query).Scan(&version); err != nil {
+		return 0, fmt.Errorf("failed to read schema version: %w", err)
+	}
+	return version, nil
+}
+
+// LatestSchemaVersion returns the schema version Migrate brings the task table to
+func LatestSchemaVersion() int {
+	return migrations[len(migrations)-1].version
+}
@@ -0,0 +1,142 @@
+// Package taskstore records completed tasks in PostgreSQL, MySQL or SQLite
+// through database/sql. Store implements types.TaskResultHandler, so an agent
+// handler that embeds it stores every task it completes:
+//
+//	db, err := sql.Open("pgx", os.Getenv("DATABASE_URL"))
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	store, err := taskstore.New(&taskstore.Config{DB: db, Dialect: taskstore.Postgres, Agent: "my-agent"})
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	if err := store.Migrate(ctx); err != nil {
+//		log.Fatal(err)
+//	}
+//	handler := &MyAgent{Store: store}
+//
+// The SDK does not import a database driver; the application registers the
+// one it uses, e.g. github.com/jackc/pgx/v5/stdlib, github.com/go-sql-driver/mysql
+// (with parseTime=true) or modernc.org/sqlite.
+package taskstore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/types"
+)
+
+// DefaultTable is the table task records are written to when none is configured
+const DefaultTable = "teneo_task_results"
+
+// DefaultTimeout bounds writing a task record when no timeout is configured
+const DefaultTimeout = 5 * time.Second
+
+// validIdentifier matches table names that are safe to use unquoted in SQL
+var validIdentifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]{0,50}$`)
+
+// Config holds the task store settings
+type Config struct {
+	DB        *sql.DB       // Open database handle, owned by the caller
+	Dialect   Dialect       // SQL flavor of DB
+	Table     string        // Table name (default DefaultTable)
+	Agent     string        // Agent name recorded with each task, so agents can share a table
+	OmitInput bool          // Store an empty input instead of the task content
+	Timeout   time.Duration // Bound on writing a record (default DefaultTimeout)
+}
+
+// Store writes task records to a SQL database
+type Store struct {
+	db        *sql.DB
+	dialect   Dialect
+	table     string
+	agent     string
+	omitInput bool
+	timeout   time.Duration
+	insert    string
+}
+
+// Verify interface compliance
+var _ types.TaskResultHandler = (*Store)(nil)
+
+// New creates a task store. Call Migrate before the first task to create the table.
+func New(config *Config) (*Store, error) {
+	if config.DB == nil {
+		return nil, fmt.Errorf("database handle is required")
+	}
+	dialect, err := ParseDialect(string(config.Dialect))
+	if err != nil {
+		return nil, err
+	}
+	table := config.Table
+	if table == "" {
+		table = DefaultTable
+	}
+	if !validIdentifier.MatchString(table) {
+		return nil, fmt.Errorf("invalid table name %q", table)
+	}
+	timeout := config.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+
+	return &Store{
+		db:        config.DB,
+		dialect:   dialect,
+		table:     table,
+		agent:     config.Agent,
+		omitInput: config.OmitInput,
+		timeout:   timeout,
+		insert: fmt.Sprintf("INSERT INTO %s (task_id, agent, room, sender, input, result, started_at, completed_at, duration_ms) VALUES (%s)",
+			table, dialect.placeholders(9)),
+	}, nil
+}
+
+// Table returns the name of the task table
+func (s *Store) Table() string {
+	return s.table
+}
+
+// HandleTaskResult implements types.TaskResultHandler. The room, sender,
+// input and start time are taken from the task info on the context.
+func (s *Store) HandleTaskResult(ctx context.Context, taskID string, result string) error {
+	completedAt := time.Now().UTC()
+	info, _ := types.TaskInfoFromContext(ctx)
+
+	input := info.Input
+	if s.omitInput {
+		input = ""
+	}
+	var startedAt any
+	var duration time.Duration
+	if !info.StartTime.IsZero() {
+		startedAt = info.StartTime.UTC()
+		duration = completedAt.Sub(info.StartTime)
+	}
+
+	// The task's own deadline may be nearly spent, the record gets its own
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), s.timeout)
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctx, s.insert,
+		taskID, s.agent, info.Room, info.Sender, input, result, startedAt, completedAt, duration.Milliseconds())
+	if err != nil {
+		return fmt.Errorf("failed to store task result: %w", err)
+	}
+	return nil
+}
+
+// Prune deletes this agent's records of tasks completed before the given
+// time and returns how many were deleted
+func (s *Store) Prune(ctx context.Context, before time.Time) (int64, error) {
+	query := fmt.Sprintf("DELETE FROM %s WHERE agent = %s AND completed_at < %s", s.table, s.dialect.placeholder(1), s.dialect.placeholder(2))
+	res, err := s.db.ExecContext(ctx, query, s.agent, before.UTC())
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune task results: %w", err)
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,266 @@
+package taskstore
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/types"
+)
+
+// fakeDriver records statements instead of running them. It answers the
+// schema version query from the versions inserted into a migrations table.
+type fakeDriver struct {
+	mu       sync.Mutex
+	execs    []string
+	args     [][]driver.Value
+	versions []int64
+}
+
+var (
+	fakeMu    sync.Mutex
+	fakeCount int
+)
+
+func openFake(t *testing.T) (*sql.DB, *fakeDriver) {
+	t.Helper()
+	fakeMu.Lock()
+	fakeCount++
+	name := fmt.Sprintf("taskstore-fake-%d", fakeCount)
+	fakeMu.Unlock()
+
+	d := &fakeDriver{}
+	sql.Register(name, d)
+	db, err := sql.Open(name, "")
+	if err != nil {
+		t.Fatalf("sql.Open: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db, d
+}
+
+func (d *fakeDriver) Open(string) (driver.Conn, error) { return &fakeConn{d: d}, nil }
+
+func (d *fakeDriver) statements() []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]string(nil), d.execs...)
+}
+
+type fakeConn struct{ d *fakeDriver }
+
+func (c *fakeConn) Prepare(string) (driver.Stmt, error) {
+	return nil, fmt.Errorf("prepare not supported")
+}
+func (c *fakeConn) Close() error              { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error) { return fakeTx{}, nil }
+
+func (c *fakeConn) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	c.d.mu.Lock()
+	defer c.d.mu.Unlock()
+	values := make([]driver.Value, len(args))
+	for i, arg := range args {
+		values[i] = arg.Value
+	}
+	c.d.execs = append(c.d.execs, query)
+	c.d.args = append(c.d.args, values)
+	if strings.HasPrefix(query, "INSERT INTO") && strings.Contains(query, "_migrations") {
+		c.d.versions = append(c.d.versions, values[0].(int64))
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (c *fakeConn) QueryContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Rows, error) {
+	c.d.mu.Lock()
+	defer c.d.mu.Unlock()
+	var version int64
+	for _, v := range c.d.versions {
+		version = max(version, v)
+	}
+	return &fakeRows{values: []driver.Value{version}}, nil
+}
+
+type fakeTx struct{}
+
+func (fakeTx) Commit() error   { return nil }
+func (fakeTx) Rollback() error { return nil }
+
+type fakeRows struct {
+	values []driver.Value
+	done   bool
+}
+
+func (r *fakeRows) Columns() []string { return []string{"version"} }
+func (r *fakeRows) Close() error      { return nil }
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+	r.done = true
+	copy(dest, r.values)
+	return nil
+}
+
+func TestNewValidatesConfig(t *testing.T) {
+	db, _ := openFake(t)
+
+	if _, err := New(&Config{Dialect: Postgres}); err == nil {
+		t.Error("expected error without a database handle")
+	}
+	if _, err := New(&Config{DB: db, Dialect: "oracle"}); err == nil {
+		t.Error("expected error for an unsupported dialect")
+	}
+	if _, err := New(&Config{DB: db, Dialect: SQLite, Table: "results; DROP TABLE users"}); err == nil {
+		t.Error("expected error for an unsafe table name")
+	}
+
+	store, err := New(&Config{DB: db, Dialect: "sqlite3"})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if store.Table() != DefaultTable {
+		t.Errorf("Table() = %q, want %q", store.Table(), DefaultTable)
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	db, fake := openFake(t)
+	store, err := New(&Config{DB: db, Dialect: Postgres, Table: "results"})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	ctx := context.Background()
+
+	if err := store.Migrate(ctx); err != nil {
+		t.Fatalf("Migrate: %v", err)
+	}
+	version, err := store.SchemaVersion(ctx)
+	if err != nil {
+		t.Fatalf("SchemaVersion: %v", err)
+	}
+	if version != LatestSchemaVersion() {
+		t.Errorf("SchemaVersion() = %d, want %d", version, LatestSchemaVersion())
+	}
+
+	applied := strings.Join(fake.statements(), "\n")
+	for _, want := range []string{
+		"CREATE TABLE IF NOT EXISTS results_migrations",
+		"CREATE TABLE IF NOT EXISTS results",
+		"id BIGSERIAL PRIMARY KEY",
+		"CREATE INDEX IF NOT EXISTS results_task_id ON results (task_id)",
+		"INSERT INTO results_migrations (version, description, applied_at) VALUES ($1, $2, $3)",
+	} {
+		if !strings.Contains(applied, want) {
+			t.Errorf("migration statements missing %q:\n%s", want, applied)
+		}
+	}
+
+	// A second run only checks the version
+	before := len(fake.statements())
+	if err := store.Migrate(ctx); err != nil {
+		t.Fatalf("second Migrate: %v", err)
+	}
+	if got := len(fake.statements()) - before; got != 1 {
+		t.Errorf("second Migrate ran %d statements, want 1 (the migrations table check)", got)
+	}
+}
+
+func TestSchemaDialects(t *testing.T) {
+	mysql, err := Schema(MySQL, "")
+	if err != nil {
+		t.Fatalf("Schema(MySQL): %v", err)
+	}
+	if len(mysql) != 1 || !strings.Contains(mysql[0], "AUTO_INCREMENT") || !strings.Contains(mysql[0], "INDEX teneo_task_results_task_id (task_id)") {
+		t.Errorf("unexpected MySQL schema: %q", mysql)
+	}
+
+	sqlite, err := Schema(SQLite, "tasks")
+	if err != nil {
+		t.Fatalf("Schema(SQLite): %v", err)
+	}
+	if len(sqlite) != 3 || !strings.Contains(sqlite[0], "INTEGER PRIMARY KEY AUTOINCREMENT") {
+		t.Errorf("unexpected SQLite schema: %q", sqlite)
+	}
+
+	if _, err := Schema(Postgres, "bad-name"); err == nil {
+		t.Error("expected error for an invalid table name")
+	}
+}
+
+func TestHandleTaskResult(t *testing.T) {
+	db, fake := openFake(t)
+	store, err := New(&Config{DB: db, Dialect: MySQL, Agent: "summarizer"})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	start := time.Now().Add(-2 * time.Second)
+	ctx := types.WithTaskInfo(context.Background(), types.TaskInfo{
+		ID:        "task-1",
+		Room:      "room-1",
+		Sender:    "0xabc",
+		Input:     "summarize this",
+		StartTime: start,
+	})
+	if err := store.HandleTaskResult(ctx, "task-1", "summary"); err != nil {
+		t.Fatalf("HandleTaskResult: %v", err)
+	}
+
+	statements := fake.statements()
+	if len(statements) != 1 || !strings.HasPrefix(statements[0], "INSERT INTO teneo_task_results (task_id, agent, room, sender, input, result, started_at, completed_at, duration_ms) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)") {
+		t.Fatalf("unexpected statements: %q", statements)
+	}
+	args := fake.args[0]
+	for i, want := range []any{"task-1", "summarizer", "room-1", "0xabc", "summarize this", "summary"} {
+		if args[i] != want {
+			t.Errorf("arg %d = %v, want %v", i, args[i], want)
+		}
+	}
+	if started, ok := args[6].(time.Time); !ok || !started.Equal(start) {
+		t.Errorf("started_at = %v, want %v", args[6], start)
+	}
+	if duration := args[8].(int64); duration < 2000 {
+		t.Errorf("duration_ms = %d, want at least 2000", duration)
+	}
+}
+
+func TestHandleTaskResultWithoutTaskInfo(t *testing.T) {
+	db, fake := openFake(t)
+	store, err := New(&Config{DB: db, Dialect: SQLite, OmitInput: true})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	if err := store.HandleTaskResult(context.Background(), "task-2", "done"); err != nil {
+		t.Fatalf("HandleTaskResult: %v", err)
+	}
+	args := fake.args[0]
+	if args[4] != "" || args[6] != nil || args[8] != int64(0) {
+		t.Errorf("expected empty input, NULL start and zero duration, got %v", args)
+	}
+}
+
+func TestPrune(t *testing.T) {
+	db, fake := openFake(t)
+	store, err := New(&Config{DB: db, Dialect: Postgres, Agent: "summarizer"})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	deleted, err := store.Prune(context.Background(), time.Now().Add(-30*24*time.Hour))
+	if err != nil {
+		t.Fatalf("Prune: %v", err)
+	}
+	if deleted != 1 {
+		t.Errorf("Prune() = %d, want 1", deleted)
+	}
+	if want := "DELETE FROM teneo_task_results WHERE agent = $1 AND completed_at < $2"; fake.statements()[0] != want {
+		t.Errorf("statement = %q, want %q", fake.statements()[0], want)
+	}
+}
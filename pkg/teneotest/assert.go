@@ -0,0 +1,125 @@
+package teneotest
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/types"
+)
+
+// Responses returns the messages that are not progress or status messages
+func Responses(messages []types.StandardizedMessage) []types.StandardizedMessage {
+	var responses []types.StandardizedMessage
+	for _, msg := range messages {
+		if msg.ContentType != types.StandardMessageTypeProgress && msg.ContentType != types.StandardMessageTypeStatus {
+			responses = append(responses, msg)
+		}
+	}
+	return responses
+}
+
+// Text is a STRING message with the content
+func Text(content string) types.StandardizedMessage {
+	return types.StandardizedMessage{ContentType: types.StandardMessageTypeString, Content: content}
+}
+
+// JSON is a JSON message with content, any value that marshals to JSON
+func JSON(content interface{}) types.StandardizedMessage {
+	return types.StandardizedMessage{ContentType: types.StandardMessageTypeJSON, Content: content}
+}
+
+// AssertMessages fails the test unless got holds exactly the want messages,
+// in order. Messages are compared as they would be received, so content may
+// be any value that encodes to the same message, e.g. a struct for a JSON
+// message.
+func AssertMessages(t testing.TB, got []types.StandardizedMessage, want ...types.StandardizedMessage) {
+	t.Helper()
+	expected := make([]types.StandardizedMessage, len(want))
+	for i, msg := range want {
+		normalized, err := Normalize(msg)
+		if err != nil {
+			t.Fatalf("invalid expected message %d: %v", i+1, err)
+		}
+		expected[i] = normalized
+	}
+	got = slices.Clone(got)
+	for i, msg := range got {
+		if normalized, err := Normalize(msg); err == nil {
+			got[i] = normalized
+		}
+	}
+	if len(got) == len(expected) {
+		equal := true
+		for i := range got {
+			if !reflect.DeepEqual(got[i], expected[i]) {
+				equal = false
+				break
+			}
+		}
+		if equal {
+			return
+		}
+	}
+	t.Errorf("messages differ\ngot:\n%s\nwant:\n%s", describe(got), describe(expected))
+}
+
+// AssertContentTypes fails the test unless got holds messages of exactly the
+// content types, in order
+func AssertContentTypes(t testing.TB, got []types.StandardizedMessage, contentTypes ...string) {
+	t.Helper()
+	gotTypes := make([]string, len(got))
+	for i, msg := range got {
+		gotTypes[i] = msg.ContentType
+	}
+	if !slices.Equal(gotTypes, contentTypes) {
+		t.Errorf("content types = %v, want %v", gotTypes, contentTypes)
+	}
+}
+
+// AssertContains fails the test unless a message of the content type holds
+// the text (empty contentType = any type)
+func AssertContains(t testing.TB, got []types.StandardizedMessage, contentType, text string) {
+	t.Helper()
+	for _, msg := range got {
+		if (contentType == "" || msg.ContentType == contentType) && strings.Contains(contentText(msg), text) {
+			return
+		}
+	}
+	t.Errorf("no %s message contains %q\ngot:\n%s", describeType(contentType), text, describe(got))
+}
+
+// contentText returns the content of a message as text: strings as they
+// are, other content as JSON
+func contentText(msg types.StandardizedMessage) string {
+	if text, ok := msg.Content.(string); ok {
+		return text
+	}
+	data, err := json.Marshal(msg.Content)
+	if err != nil {
+		return fmt.Sprint(msg.Content)
+	}
+	return string(data)
+}
+
+// describe lists messages for failure output, one per line
+func describe(messages []types.StandardizedMessage) string {
+	if len(messages) == 0 {
+		return "  (none)"
+	}
+	lines := make([]string, len(messages))
+	for i, msg := range messages {
+		lines[i] = fmt.Sprintf("  %d. %s: %s", i+1, msg.ContentType, contentText(msg))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func describeType(contentType string) string {
+	if contentType == "" {
+		return "message"
+	}
+	return contentType + " message"
+}
@@ -0,0 +1,43 @@
+package teneotest
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock is a manual clock for tests: it stands still until it is advanced.
+// Pass its Now method wherever a clock function is taken, e.g. to
+// ratelimit.Limiter.SetClock. It is safe for concurrent use.
+type Clock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+// NewClock returns a clock set to start (zero = 2025-01-01 00:00 UTC)
+func NewClock(start time.Time) *Clock {
+	if start.IsZero() {
+		start = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	}
+	return &Clock{now: start}
+}
+
+// Now returns the clock's time
+func (c *Clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Advance moves the clock forward by d
+func (c *Clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// Set moves the clock to t
+func (c *Clock) Set(t time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = t
+}
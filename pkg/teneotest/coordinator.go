@@ -0,0 +1,149 @@
+// Package teneotest helps unit-test agent handlers without a network: a fake
+// MessageSender that records the typed messages a handler sends, a fake
+// coordinator that delivers tasks to a handler under rate limits, a manual
+// clock, and assertions on sequences of StandardizedMessages.
+//
+//	coordinator := teneotest.NewCoordinator(&MyAgent{}, nil)
+//	result := coordinator.Ask(ctx, "weather in Paris")
+//	teneotest.AssertMessages(t, result.Messages, teneotest.JSON(Forecast{City: "Paris"}))
+package teneotest
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/memory"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/output"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/ratelimit"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/types"
+)
+
+// Task statuses of a Result
+const (
+	StatusSuccess     = "success"
+	StatusError       = "error"
+	StatusRateLimited = "rate_limit_exceeded"
+)
+
+// DefaultRoom is the room of tasks sent without one
+const DefaultRoom = "test-room"
+
+// Task is a task the fake coordinator delivers to a handler
+type Task struct {
+	ID      string                      // Task ID (default "task-N")
+	Content string                      // What the user asked
+	Room    string                      // Room the task is asked in (default DefaultRoom)
+	From    string                      // Sender, counted by the per-sender rate limit
+	History []types.ConversationMessage // Earlier turns of the room's conversation
+}
+
+// Result is what a handler did with a task
+type Result struct {
+	TaskID     string
+	Status     string                      // StatusSuccess, StatusError or StatusRateLimited
+	Messages   []types.StandardizedMessage // Everything the room received, including error and rejection messages
+	Err        error                       // Error returned by the handler
+	RetryAfter time.Duration               // When a rate-limited task would be accepted
+	Sender     *Sender                     // The sender the handler was given
+}
+
+// Coordinator is a fake coordinator that delivers tasks straight to a
+// handler, without a network connection. It picks the handler's interface
+// like the agent does: ProcessTaskWithStreaming, then
+// ProcessTaskWithHistory, then ProcessTask, whose result is sent as a STRING
+// message. Tasks are checked against the rate limits on the coordinator's
+// clock, so tests can exhaust and refill the buckets without waiting. It is
+// safe for concurrent use.
+type Coordinator struct {
+	handler types.AgentHandler
+	clock   *Clock
+	limiter *ratelimit.Limiter
+
+	mu     sync.Mutex
+	nextID int
+}
+
+// NewCoordinator returns a coordinator for the handler. Rate limits and
+// progress reports read the time from clock (nil = a new Clock).
+func NewCoordinator(handler types.AgentHandler, clock *Clock) *Coordinator {
+	if clock == nil {
+		clock = NewClock(time.Time{})
+	}
+	limiter := ratelimit.New(ratelimit.Config{})
+	limiter.SetClock(clock.Now)
+	return &Coordinator{handler: handler, clock: clock, limiter: limiter}
+}
+
+// Clock returns the coordinator's clock
+func (c *Coordinator) Clock() *Clock {
+	return c.clock
+}
+
+// SetRateLimits changes the limits tasks are checked against (the zero
+// Config lets every task through, the default)
+func (c *Coordinator) SetRateLimits(limits ratelimit.Config) {
+	c.limiter.SetConfig(limits)
+}
+
+// Ask delivers a task with the content to the default room
+func (c *Coordinator) Ask(ctx context.Context, content string) *Result {
+	return c.Send(ctx, Task{Content: content})
+}
+
+// Send delivers a task to the handler and returns once the handler is done
+func (c *Coordinator) Send(ctx context.Context, task Task) *Result {
+	c.mu.Lock()
+	c.nextID++
+	if task.ID == "" {
+		task.ID = fmt.Sprintf("task-%d", c.nextID)
+	}
+	c.mu.Unlock()
+	if task.Room == "" {
+		task.Room = DefaultRoom
+	}
+
+	sender := NewSender(task.ID, c.clock)
+	result := &Result{TaskID: task.ID, Sender: sender}
+
+	if decision := c.limiter.Allow(task.Room, task.From); !decision.Allowed {
+		result.Status = StatusRateLimited
+		result.RetryAfter = decision.RetryAfter
+		retryAfter := int(math.Ceil(decision.RetryAfter.Seconds()))
+		sender.SendMessage(output.Clean(fmt.Sprintf("⚠️ Rate limit exceeded (%s). Please try again in %d seconds.", decision.Scope, retryAfter)))
+		result.Messages = sender.Messages()
+		return result
+	}
+
+	ctx = memory.WithConversation(ctx, task.Room, task.History)
+	var err error
+	switch handler := c.handler.(type) {
+	case types.StreamingTaskHandler:
+		err = handler.ProcessTaskWithStreaming(ctx, task.Content, task.Room, sender)
+		if stopErr := sender.StopTyping(); err == nil {
+			err = stopErr
+		}
+	case types.ConversationAwareHandler:
+		var reply string
+		if reply, err = handler.ProcessTaskWithHistory(ctx, task.Content, task.Room, task.History); err == nil {
+			err = sender.SendMessage(reply)
+		}
+	default:
+		var reply string
+		if reply, err = c.handler.ProcessTask(ctx, task.Content); err == nil {
+			err = sender.SendMessage(reply)
+		}
+	}
+
+	result.Status = StatusSuccess
+	if err != nil {
+		result.Status = StatusError
+		result.Err = err
+		sender.FailWith(nil)
+		sender.SendMessage(output.Clean("❌ Error: ") + err.Error())
+	}
+	result.Messages = sender.Messages()
+	return result
+}
@@ -0,0 +1,276 @@
+package teneotest
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/output"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/types"
+)
+
+var (
+	_ types.MessageSender    = (*Sender)(nil)
+	_ types.ProgressSender   = (*Sender)(nil)
+	_ types.TypingSender     = (*Sender)(nil)
+	_ types.DeadlineExtender = (*Sender)(nil)
+	_ types.TabularSender    = (*Sender)(nil)
+	_ types.MediaSender      = (*Sender)(nil)
+)
+
+// typingExpiresIn is the expiry of a typing indicator, as the network sends it
+const typingExpiresIn = 10
+
+// Sender is a fake MessageSender that records the messages a handler sends
+// instead of sending them. Messages are recorded as the room would receive
+// them: content is encoded like on the network and decoded again, so JSON
+// and ARRAY content holds generic values, and content the network would
+// reject is rejected with the same error. It is safe for concurrent use.
+type Sender struct {
+	taskID string
+	clock  *Clock
+
+	mu       sync.Mutex
+	messages []types.StandardizedMessage
+	updates  []string
+	typing   bool
+	deadline time.Time
+	err      error
+}
+
+// NewSender returns a sender for the task. Progress reports and deadline
+// extensions read the time from clock (nil = real time).
+func NewSender(taskID string, clock *Clock) *Sender {
+	return &Sender{taskID: taskID, clock: clock}
+}
+
+// FailWith makes later sends fail with err, e.g. to test how a handler deals
+// with a lost connection (nil = sends succeed again)
+func (s *Sender) FailWith(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.err = err
+}
+
+// Messages returns every message sent, in order, including progress and
+// status messages
+func (s *Sender) Messages() []types.StandardizedMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]types.StandardizedMessage(nil), s.messages...)
+}
+
+// Responses returns the messages sent, in order, without progress and status
+// messages
+func (s *Sender) Responses() []types.StandardizedMessage {
+	return Responses(s.Messages())
+}
+
+// Updates returns the contents passed to SendTaskUpdate, in order
+func (s *Sender) Updates() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.updates...)
+}
+
+// Progress returns the progress reports sent, in order
+func (s *Sender) Progress() []types.TaskProgress {
+	var reports []types.TaskProgress
+	for _, msg := range s.Messages() {
+		if msg.ContentType != types.StandardMessageTypeProgress {
+			continue
+		}
+		var progress types.TaskProgress
+		data, _ := json.Marshal(msg.Content)
+		json.Unmarshal(data, &progress)
+		reports = append(reports, progress)
+	}
+	return reports
+}
+
+// Typing reports whether the typing indicator is shown
+func (s *Sender) Typing() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.typing
+}
+
+// Deadline returns the deadline set by the last ExtendDeadline, or the zero
+// time if it was not called
+func (s *Sender) Deadline() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.deadline
+}
+
+// Reset forgets the messages sent so far
+func (s *Sender) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.messages = nil
+	s.updates = nil
+	s.typing = false
+	s.deadline = time.Time{}
+}
+
+// SendMessage sends a STRING message
+func (s *Sender) SendMessage(content string) error {
+	return s.send(types.StandardizedMessage{ContentType: types.StandardMessageTypeString, Content: content}, true)
+}
+
+// SendTaskUpdate sends a progress update as a STRING message
+func (s *Sender) SendTaskUpdate(content string) error {
+	if err := s.send(types.StandardizedMessage{ContentType: types.StandardMessageTypeString, Content: output.Clean("🔄 Update: ") + content}, true); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.updates = append(s.updates, content)
+	s.mu.Unlock()
+	return nil
+}
+
+// SendMessageAsJSON sends a JSON message
+func (s *Sender) SendMessageAsJSON(content interface{}) error {
+	return s.send(types.StandardizedMessage{ContentType: types.StandardMessageTypeJSON, Content: content}, true)
+}
+
+// SendMessageAsMD sends a markdown message
+func (s *Sender) SendMessageAsMD(content string) error {
+	return s.send(types.StandardizedMessage{ContentType: types.StandardMessageTypeMD, Content: content}, true)
+}
+
+// SendMessageAsArray sends an ARRAY message
+func (s *Sender) SendMessageAsArray(content []interface{}) error {
+	return s.send(types.StandardizedMessage{ContentType: types.StandardMessageTypeArray, Content: content}, true)
+}
+
+// SendMessageAsTable sends a TABLE message
+func (s *Sender) SendMessageAsTable(content string) error {
+	return s.send(types.StandardizedMessage{ContentType: types.StandardMessageTypeTable, Content: content}, true)
+}
+
+// SendMessageAsCSV sends a CSV message
+func (s *Sender) SendMessageAsCSV(content string) error {
+	return s.send(types.StandardizedMessage{ContentType: types.StandardMessageTypeCSV, Content: content}, true)
+}
+
+// SendMessageAsImage sends an IMAGE message; an empty mimeType is detected from the data
+func (s *Sender) SendMessageAsImage(data []byte, mimeType, alt string) error {
+	media, err := types.NewImageContent(data, mimeType, alt)
+	if err != nil {
+		return err
+	}
+	return s.send(types.StandardizedMessage{ContentType: types.StandardMessageTypeImage, Content: media}, true)
+}
+
+// SendMessageAsAudio sends an AUDIO message; an empty mimeType is detected from the data
+func (s *Sender) SendMessageAsAudio(data []byte, mimeType, transcript string) error {
+	media, err := types.NewAudioContent(data, mimeType, transcript)
+	if err != nil {
+		return err
+	}
+	return s.send(types.StandardizedMessage{ContentType: types.StandardMessageTypeAudio, Content: media}, true)
+}
+
+// SendMessageAsHTML sends an HTML message
+func (s *Sender) SendMessageAsHTML(content string) error {
+	if err := types.ValidateHTML(content); err != nil {
+		return err
+	}
+	return s.send(types.StandardizedMessage{ContentType: types.StandardMessageTypeHTML, Content: content}, true)
+}
+
+// SendProgress sends a PROGRESS message
+func (s *Sender) SendProgress(percent float64, stage string, etaSeconds int) error {
+	if math.IsNaN(percent) {
+		return fmt.Errorf("invalid progress percentage: %v", percent)
+	}
+	progress := types.TaskProgress{
+		TaskID:     s.taskID,
+		Percent:    math.Min(math.Max(percent, 0), 100),
+		Stage:      stage,
+		ETASeconds: max(etaSeconds, 0),
+		UpdatedAt:  s.now(),
+	}
+	return s.send(types.StandardizedMessage{ContentType: types.StandardMessageTypeProgress, Content: progress}, false)
+}
+
+// SendTyping shows the typing indicator with a STATUS message
+func (s *Sender) SendTyping() error {
+	status := types.TaskStatus{TaskID: s.taskID, Status: types.TaskStatusTyping, ExpiresIn: typingExpiresIn}
+	if err := s.send(types.StandardizedMessage{ContentType: types.StandardMessageTypeStatus, Content: status}, false); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.typing = true
+	s.mu.Unlock()
+	return nil
+}
+
+// StopTyping hides the typing indicator with a STATUS message if it is shown
+func (s *Sender) StopTyping() error {
+	s.mu.Lock()
+	typing := s.typing
+	s.typing = false
+	s.mu.Unlock()
+	if !typing {
+		return nil
+	}
+	status := types.TaskStatus{TaskID: s.taskID, Status: types.TaskStatusIdle}
+	return s.send(types.StandardizedMessage{ContentType: types.StandardMessageTypeStatus, Content: status}, false)
+}
+
+// ExtendDeadline records the new deadline, d from now
+func (s *Sender) ExtendDeadline(d time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return s.err
+	}
+	s.deadline = s.now().Add(d)
+	return nil
+}
+
+// send records a message as the room would receive it. Messages that are
+// not status updates hide the typing indicator, like on the network.
+func (s *Sender) send(msg types.StandardizedMessage, hidesTyping bool) error {
+	received, err := Normalize(msg)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return s.err
+	}
+	if hidesTyping {
+		s.typing = false
+	}
+	s.messages = append(s.messages, received)
+	return nil
+}
+
+// now returns the time of the sender's clock
+func (s *Sender) now() time.Time {
+	if s.clock == nil {
+		return time.Now()
+	}
+	return s.clock.Now()
+}
+
+// Normalize returns a message as it is received: its content encoded like on
+// the network and decoded again
+func Normalize(msg types.StandardizedMessage) (types.StandardizedMessage, error) {
+	content, err := msg.Encode()
+	if err != nil {
+		return types.StandardizedMessage{}, err
+	}
+	decoded, err := (&types.Message{ContentType: msg.ContentType, Content: content}).StandardizedContent()
+	if err != nil {
+		return types.StandardizedMessage{}, err
+	}
+	return *decoded, nil
+}
@@ -0,0 +1,177 @@
+package teneotest
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/memory"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/ratelimit"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/types"
+)
+
+type echoHandler struct{}
+
+func (echoHandler) ProcessTask(ctx context.Context, task string) (string, error) {
+	if task == "fail" {
+		return "", errors.New("cannot do that")
+	}
+	return "echo: " + task, nil
+}
+
+type forecast struct {
+	City  string `json:"city"`
+	TempC int    `json:"temp_c"`
+}
+
+type streamingHandler struct{ echoHandler }
+
+func (streamingHandler) ProcessTaskWithStreaming(ctx context.Context, task, room string, sender types.MessageSender) error {
+	if typing, ok := sender.(types.TypingSender); ok {
+		typing.SendTyping()
+	}
+	if progress, ok := sender.(types.ProgressSender); ok {
+		progress.SendProgress(50, "looking up", 2)
+	}
+	sender.SendTaskUpdate("almost there")
+	if err := sender.SendMessageAsJSON(forecast{City: task, TempC: 21}); err != nil {
+		return err
+	}
+	return sender.SendMessageAsMD("**done** in " + room)
+}
+
+type historyHandler struct{ echoHandler }
+
+func (historyHandler) ProcessTaskWithHistory(ctx context.Context, task, room string, history []types.ConversationMessage) (string, error) {
+	if len(memory.HistoryFromContext(ctx)) != len(history) {
+		return "", errors.New("history missing from context")
+	}
+	return strings.Repeat("turn ", len(history)) + task, nil
+}
+
+func TestStandardHandler(t *testing.T) {
+	coordinator := NewCoordinator(echoHandler{}, nil)
+
+	result := coordinator.Ask(context.Background(), "hello")
+	if result.Status != StatusSuccess {
+		t.Fatalf("status = %s: %v", result.Status, result.Err)
+	}
+	AssertMessages(t, result.Messages, Text("echo: hello"))
+
+	result = coordinator.Ask(context.Background(), "fail")
+	if result.Status != StatusError || result.Err == nil {
+		t.Fatalf("status = %s, want error", result.Status)
+	}
+	AssertContains(t, result.Messages, types.StandardMessageTypeString, "cannot do that")
+}
+
+func TestStreamingHandler(t *testing.T) {
+	clock := NewClock(time.Time{})
+	coordinator := NewCoordinator(streamingHandler{}, clock)
+
+	result := coordinator.Send(context.Background(), Task{Content: "Paris", Room: "weather"})
+	if result.Status != StatusSuccess {
+		t.Fatalf("status = %s: %v", result.Status, result.Err)
+	}
+	AssertContentTypes(t, result.Messages, "STATUS", "PROGRESS", "STRING", "JSON", "MD")
+	AssertMessages(t, Responses(result.Messages),
+		Text("🔄 Update: almost there"),
+		JSON(forecast{City: "Paris", TempC: 21}),
+		types.StandardizedMessage{ContentType: types.StandardMessageTypeMD, Content: "**done** in weather"},
+	)
+
+	progress := result.Sender.Progress()
+	if len(progress) != 1 || progress[0].Percent != 50 || !progress[0].UpdatedAt.Equal(clock.Now()) || progress[0].TaskID != result.TaskID {
+		t.Errorf("progress = %+v", progress)
+	}
+	if updates := result.Sender.Updates(); len(updates) != 1 || updates[0] != "almost there" {
+		t.Errorf("updates = %q", updates)
+	}
+	if result.Sender.Typing() {
+		t.Error("typing indicator still shown after a message")
+	}
+}
+
+func TestConversationHandler(t *testing.T) {
+	coordinator := NewCoordinator(historyHandler{}, nil)
+	history := []types.ConversationMessage{{Role: "user", Content: "hi"}, {Role: "assistant", Content: "hello"}}
+
+	result := coordinator.Send(context.Background(), Task{Content: "again", History: history})
+	AssertMessages(t, result.Messages, Text("turn turn again"))
+}
+
+func TestRateLimitWithClock(t *testing.T) {
+	coordinator := NewCoordinator(echoHandler{}, nil)
+	coordinator.SetRateLimits(ratelimit.Config{PerSender: ratelimit.Limit{PerMinute: 6, Burst: 2}})
+	ask := func() *Result {
+		return coordinator.Send(context.Background(), Task{Content: "hi", From: "alice"})
+	}
+
+	for i := 0; i < 2; i++ {
+		if result := ask(); result.Status != StatusSuccess {
+			t.Fatalf("task %d: status = %s", i, result.Status)
+		}
+	}
+	result := ask()
+	if result.Status != StatusRateLimited || result.RetryAfter != 10*time.Second {
+		t.Fatalf("status = %s, retry after %s; want rate limited for 10s", result.Status, result.RetryAfter)
+	}
+	AssertContains(t, result.Messages, "", "10 seconds")
+
+	coordinator.Clock().Advance(10 * time.Second)
+	if result := ask(); result.Status != StatusSuccess {
+		t.Errorf("status = %s after the bucket refilled", result.Status)
+	}
+}
+
+func TestSenderRejectsInvalidContent(t *testing.T) {
+	sender := NewSender("t1", nil)
+	if err := sender.SendMessageAsJSON(make(chan int)); !errors.Is(err, types.ErrInvalidContent) {
+		t.Errorf("got %v, want ErrInvalidContent", err)
+	}
+
+	lost := errors.New("connection lost")
+	sender.FailWith(lost)
+	if err := sender.SendMessage("hi"); !errors.Is(err, lost) {
+		t.Errorf("got %v, want the injected error", err)
+	}
+	if len(sender.Messages()) != 0 {
+		t.Errorf("recorded %d messages, want none", len(sender.Messages()))
+	}
+}
+
+func TestAssertionsFail(t *testing.T) {
+	got := []types.StandardizedMessage{Text("a"), JSON(map[string]int{"n": 1})}
+	for name, assert := range map[string]func(testing.TB){
+		"messages":      func(tb testing.TB) { AssertMessages(tb, got, Text("a"), JSON(map[string]int{"n": 2})) },
+		"length":        func(tb testing.TB) { AssertMessages(tb, got, Text("a")) },
+		"content types": func(tb testing.TB) { AssertContentTypes(tb, got, "STRING") },
+		"contains":      func(tb testing.TB) { AssertContains(tb, got, "JSON", "x") },
+	} {
+		recorder := &recordingTB{TB: t}
+		assert(recorder)
+		if !recorder.failed {
+			t.Errorf("%s: assertion passed", name)
+		}
+	}
+
+	recorder := &recordingTB{TB: t}
+	AssertMessages(recorder, got, Text("a"), JSON(struct {
+		N int `json:"n"`
+	}{1}))
+	if recorder.failed {
+		t.Error("equal messages reported as different")
+	}
+}
+
+// recordingTB records failures instead of failing the test
+type recordingTB struct {
+	testing.TB
+	failed bool
+}
+
+func (r *recordingTB) Helper()                           {}
+func (r *recordingTB) Errorf(format string, args ...any) { r.failed = true }
+func (r *recordingTB) Fatalf(format string, args ...any) { r.failed = true }
@@ -0,0 +1,103 @@
+// Package tlsconfig builds the TLS configuration of the agent's connections
+// to the network and the backend from files and settings: custom CA bundles,
+// a client certificate for mutual TLS, the minimum TLS version, the server
+// name sent in SNI and, for development only, skipping certificate checks.
+package tlsconfig
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// Config configures TLS connections. The zero value uses Go's defaults.
+type Config struct {
+	CAFile             string // PEM bundle of CAs trusted in addition to the system roots
+	CertFile           string // PEM client certificate presented for mutual TLS
+	KeyFile            string // PEM private key of CertFile
+	MinVersion         string // Lowest TLS version accepted: "1.0", "1.1", "1.2" or "1.3" (empty = "1.2")
+	ServerName         string // Server name sent in SNI and verified, instead of the host of the URL
+	InsecureSkipVerify bool   // Accept any server certificate; for development only
+}
+
+// Empty reports whether the configuration changes nothing
+func (c *Config) Empty() bool {
+	return c == nil || *c == Config{}
+}
+
+// Build returns the TLS configuration, or nil if it is empty
+func (c *Config) Build() (*tls.Config, error) {
+	if c.Empty() {
+		return nil, nil
+	}
+
+	config := &tls.Config{
+		ServerName:         c.ServerName,
+		InsecureSkipVerify: c.InsecureSkipVerify,
+	}
+
+	var err error
+	if config.MinVersion, err = ParseVersion(c.MinVersion); err != nil {
+		return nil, err
+	}
+
+	if c.CAFile != "" {
+		pem, err := os.ReadFile(c.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA bundle: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in CA bundle %s", c.CAFile)
+		}
+		config.RootCAs = pool
+	}
+
+	switch {
+	case c.CertFile != "" && c.KeyFile != "":
+		cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	case c.CertFile != "" || c.KeyFile != "":
+		return nil, fmt.Errorf("client certificate and key must be set together")
+	}
+
+	return config, nil
+}
+
+// ParseVersion parses a TLS version such as "1.2" (empty = TLS 1.2)
+func ParseVersion(version string) (uint16, error) {
+	switch strings.TrimPrefix(strings.ToLower(strings.TrimSpace(version)), "tls") {
+	case "1.0", "10":
+		return tls.VersionTLS10, nil
+	case "1.1", "11":
+		return tls.VersionTLS11, nil
+	case "", "1.2", "12":
+		return tls.VersionTLS12, nil
+	case "1.3", "13":
+		return tls.VersionTLS13, nil
+	default:
+		return 0, fmt.Errorf("unsupported TLS version %q (use 1.0, 1.1, 1.2 or 1.3)", version)
+	}
+}
+
+// HTTPClient returns an HTTP client with the TLS configuration and timeout
+// (nil config = Go's default transport)
+func HTTPClient(config *tls.Config, timeout time.Duration) *http.Client {
+	client := &http.Client{Timeout: timeout}
+	if config != nil {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.TLSClientConfig = config.Clone()
+		client.Transport = transport
+	}
+	return client
+}
@@ -0,0 +1,68 @@
+package tlsconfig
+
+import (
+	"crypto/tls"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestBuild(t *testing.T) {
+	if config, err := (&Config{}).Build(); config != nil || err != nil {
+		t.Errorf("empty config built %v, %v", config, err)
+	}
+
+	config, err := (&Config{MinVersion: "1.3", ServerName: "backend.internal"}).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.MinVersion != tls.VersionTLS13 || config.ServerName != "backend.internal" {
+		t.Errorf("got min version %x, server name %q", config.MinVersion, config.ServerName)
+	}
+
+	if _, err := (&Config{MinVersion: "2.0"}).Build(); err == nil {
+		t.Error("expected an error for an unknown TLS version")
+	}
+	if _, err := (&Config{CertFile: "client.pem"}).Build(); err == nil {
+		t.Error("expected an error for a certificate without a key")
+	}
+	if _, err := (&Config{CAFile: filepath.Join(t.TempDir(), "missing.pem")}).Build(); err == nil {
+		t.Error("expected an error for a missing CA bundle")
+	}
+}
+
+func TestCABundle(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	// The test server's certificate is not trusted by default
+	if _, err := HTTPClient(nil, time.Second).Get(server.URL); err == nil {
+		t.Fatal("untrusted certificate accepted")
+	}
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	// Its certificate is for example.com
+	config, err := (&Config{CAFile: caFile, ServerName: "example.com"}).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := HTTPClient(config, time.Second).Get(server.URL)
+	if err != nil {
+		t.Fatalf("trusted CA rejected: %v", err)
+	}
+	resp.Body.Close()
+
+	insecure, _ := (&Config{InsecureSkipVerify: true}).Build()
+	resp, err = HTTPClient(insecure, time.Second).Get(server.URL)
+	if err != nil {
+		t.Fatalf("insecure client rejected the certificate: %v", err)
+	}
+	resp.Body.Close()
+}
@@ -0,0 +1,149 @@
+// Package tracing provides optional OpenTelemetry instrumentation for the SDK.
+//
+// Spans are recorded through the global OpenTelemetry tracer provider, which is a
+// no-op until the application installs one (e.g. with otel.SetTracerProvider).
+package tracing
+
+import (
+	"context"
+	"sync"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/types"
+)
+
+// InstrumentationName identifies the SDK's tracer
+const InstrumentationName = "github.com/TeneoProtocolAI/teneo-agent-sdk"
+
+// Span names
+const (
+	SpanTaskReceive     = "teneo.task.receive"
+	SpanTaskExecute     = "teneo.task.execute"
+	SpanHandlerProcess  = "teneo.handler.process"
+	SpanMessageSend     = "teneo.message.send"
+	SpanReconnect       = "teneo.websocket.reconnect"
+	SpanNFTMint         = "teneo.nft.mint"
+	SpanNFTSyncMetadata = "teneo.nft.sync_metadata"
+)
+
+// Attribute keys
+const (
+	AttrTaskID           = attribute.Key("teneo.task.id")
+	AttrRoom             = attribute.Key("teneo.room")
+	AttrFrom             = attribute.Key("teneo.from")
+	AttrMessageType      = attribute.Key("teneo.message.type")
+	AttrTaskStatus       = attribute.Key("teneo.task.status")
+	AttrHandlerType      = attribute.Key("teneo.handler.type")
+	AttrReconnectAttempt = attribute.Key("teneo.reconnect.attempt")
+	AttrTokenID          = attribute.Key("teneo.nft.token_id")
+)
+
+var (
+	mu             sync.RWMutex
+	tracerProvider trace.TracerProvider
+	propagator     propagation.TextMapPropagator
+)
+
+// SetTracerProvider sets the tracer provider used by the SDK (nil falls back to the global provider)
+func SetTracerProvider(tp trace.TracerProvider) {
+	mu.Lock()
+	defer mu.Unlock()
+	tracerProvider = tp
+}
+
+// DefaultPropagator propagates W3C Trace Context (traceparent/tracestate) and W3C Baggage
+var DefaultPropagator propagation.TextMapPropagator = propagation.NewCompositeTextMapPropagator(
+	propagation.TraceContext{},
+	propagation.Baggage{},
+)
+
+// SetPropagator sets the propagator used for message trace context (nil restores DefaultPropagator)
+func SetPropagator(p propagation.TextMapPropagator) {
+	mu.Lock()
+	defer mu.Unlock()
+	propagator = p
+}
+
+// Tracer returns the SDK's tracer
+func Tracer() trace.Tracer {
+	mu.RLock()
+	tp := tracerProvider
+	mu.RUnlock()
+
+	if tp == nil {
+		tp = otel.GetTracerProvider()
+	}
+	return tp.Tracer(InstrumentationName)
+}
+
+// Propagator returns the propagator used for message trace context
+func Propagator() propagation.TextMapPropagator {
+	mu.RLock()
+	p := propagator
+	mu.RUnlock()
+
+	if p == nil {
+		p = DefaultPropagator
+	}
+	return p
+}
+
+// Start starts a span with the SDK's tracer
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return Tracer().Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// End records err on the span (if any) and ends it
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// Inject writes the trace context of ctx into the message metadata
+// (e.g. "traceparent" and "tracestate" with the default propagator)
+func Inject(ctx context.Context, msg *types.Message) {
+	Propagator().Inject(ctx, &MessageCarrier{Message: msg})
+}
+
+// Extract returns ctx carrying the trace context found in the message metadata
+func Extract(ctx context.Context, msg *types.Message) context.Context {
+	if len(msg.Metadata) == 0 {
+		return ctx
+	}
+	return Propagator().Extract(ctx, &MessageCarrier{Message: msg})
+}
+
+// MessageCarrier adapts a message's metadata to a propagation.TextMapCarrier
+type MessageCarrier struct {
+	Message *types.Message
+}
+
+// Get implements the propagation.TextMapCarrier interface
+func (c *MessageCarrier) Get(key string) string {
+	return c.Message.Metadata[key]
+}
+
+// Set implements the propagation.TextMapCarrier interface
+func (c *MessageCarrier) Set(key, value string) {
+	if c.Message.Metadata == nil {
+		c.Message.Metadata = make(map[string]string)
+	}
+	c.Message.Metadata[key] = value
+}
+
+// Keys implements the propagation.TextMapCarrier interface
+func (c *MessageCarrier) Keys() []string {
+	keys := make([]string, 0, len(c.Message.Metadata))
+	for key := range c.Message.Metadata {
+		keys = append(keys, key)
+	}
+	return keys
+}
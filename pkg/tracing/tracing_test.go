@@ -0,0 +1,75 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/types"
+)
+
+const (
+	testTraceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	testTracestate  = "vendor=abc"
+)
+
+func TestInjectWritesW3CHeaders(t *testing.T) {
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	state, _ := trace.ParseTraceState(testTracestate)
+	sc := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: trace.FlagsSampled,
+		TraceState: state,
+	})
+
+	msg := &types.Message{Type: types.MessageTypeTaskResponse}
+	Inject(trace.ContextWithSpanContext(context.Background(), sc), msg)
+
+	if got := msg.Metadata["traceparent"]; got != testTraceparent {
+		t.Errorf("unexpected traceparent %q", got)
+	}
+	if got := msg.Metadata["tracestate"]; got != testTracestate {
+		t.Errorf("unexpected tracestate %q", got)
+	}
+}
+
+func TestExtract(t *testing.T) {
+	msg := &types.Message{Metadata: map[string]string{"traceparent": testTraceparent, "tracestate": testTracestate}}
+	sc := trace.SpanContextFromContext(Extract(context.Background(), msg))
+	if sc.TraceID().String() != "4bf92f3577b34da6a3ce929d0e0e4736" || !sc.IsRemote() {
+		t.Errorf("unexpected span context: %+v", sc)
+	}
+	if sc.TraceState().String() != testTracestate {
+		t.Errorf("unexpected tracestate %q", sc.TraceState().String())
+	}
+}
+
+func TestTraceContinuesToResponse(t *testing.T) {
+	// Without a tracer provider spans don't record, but the trace still flows through
+	incoming := &types.Message{Metadata: map[string]string{"traceparent": testTraceparent, "tracestate": testTracestate}}
+	ctx, span := Start(Extract(context.Background(), incoming), SpanTaskReceive)
+	defer span.End()
+
+	response := &types.Message{Type: types.MessageTypeTaskResponse}
+	Inject(ctx, response)
+
+	if response.Metadata["traceparent"] != testTraceparent || response.Metadata["tracestate"] != testTracestate {
+		t.Errorf("trace context not propagated to response: %v", response.Metadata)
+	}
+}
+
+func TestInjectWithoutSpan(t *testing.T) {
+	msg := &types.Message{}
+	Inject(context.Background(), msg)
+	if len(msg.Metadata) != 0 {
+		t.Errorf("expected no metadata, got %v", msg.Metadata)
+	}
+
+	ctx := context.Background()
+	if Extract(ctx, msg) != ctx {
+		t.Error("expected context to be returned unchanged")
+	}
+}
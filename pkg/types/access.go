@@ -0,0 +1,35 @@
+package types
+
+import "context"
+
+// AccessChecker decides whether a sender may have tasks run in a room
+type AccessChecker interface {
+	// CheckAccess returns nil if the task may run. Denied senders are
+	// rejected with an error wrapping ErrAccessDenied, preferably an
+	// *AccessError; any other error means access could not be checked,
+	// e.g. because the chain was unreachable.
+	CheckAccess(ctx context.Context, sender, room string) error
+}
+
+// AccessError explains why a sender was denied. It is sent in the details
+// of the task's rejection.
+type AccessError struct {
+	Reason  string `json:"reason"` // Short code, e.g. "sender_denied" or "token_required"
+	Message string `json:"message"`
+}
+
+// Error implements the error interface
+func (e *AccessError) Error() string {
+	return ErrAccessDenied.Error() + ": " + e.Message
+}
+
+// Unwrap returns ErrAccessDenied
+func (e *AccessError) Unwrap() error {
+	return ErrAccessDenied
+}
+
+// AccessRecorder is implemented by metrics recorders that count the tasks
+// denied by access control, by the reason of the AccessError
+type AccessRecorder interface {
+	RecordAccessDenied(reason string)
+}
@@ -2,6 +2,7 @@ package types
 
 import (
 	"context"
+	"encoding/json"
 	"time"
 )
 
@@ -45,6 +46,53 @@ type MessageSender interface {
 	SendMessageAsArray(content []interface{}) error
 }
 
+// ProgressSender is an optional interface of a MessageSender that reports
+// structured progress, e.g. for a progress bar
+type ProgressSender interface {
+	// SendProgress reports percent complete (0-100), the current stage and
+	// the estimated seconds remaining (0 if unknown)
+	SendProgress(percent float64, stage string, etaSeconds int) error
+}
+
+// TypingSender is an optional interface of a MessageSender that shows a
+// typing indicator while the agent works
+type TypingSender interface {
+	// SendTyping shows a typing indicator until StopTyping, the next message or a timeout
+	SendTyping() error
+	// StopTyping hides the typing indicator
+	StopTyping() error
+}
+
+// DeadlineExtender is an optional interface of a MessageSender that can
+// keep a long-running task alive
+type DeadlineExtender interface {
+	// ExtendDeadline keeps the task alive for d from now, up to the server's
+	// deadline or the agent's maximum task duration
+	ExtendDeadline(d time.Duration) error
+}
+
+// TabularSender is an optional interface of a MessageSender that can send
+// tables; the content is rendered by pkg/format
+type TabularSender interface {
+	// SendMessageAsTable sends a markdown pipe table
+	SendMessageAsTable(content string) error
+	// SendMessageAsCSV sends CSV data
+	SendMessageAsCSV(content string) error
+}
+
+// MediaSender is an optional interface of a MessageSender that can send
+// images, audio and HTML; see NewImageContent and ValidateHTML for the limits
+type MediaSender interface {
+	// SendMessageAsImage sends a PNG, JPEG, GIF or WebP image with a text
+	// alternative; an empty mimeType is detected from the data
+	SendMessageAsImage(data []byte, mimeType, alt string) error
+	// SendMessageAsAudio sends audio with an optional transcript; an empty
+	// mimeType is detected from the data
+	SendMessageAsAudio(data []byte, mimeType, transcript string) error
+	// SendMessageAsHTML sends an HTML fragment
+	SendMessageAsHTML(content string) error
+}
+
 // StreamingTaskHandler is an optional interface for agents that need to send multiple messages during task execution
 type StreamingTaskHandler interface {
 	// ProcessTaskWithStreaming processes a task with the ability to send multiple messages
@@ -53,6 +101,43 @@ type StreamingTaskHandler interface {
 	ProcessTaskWithStreaming(ctx context.Context, task string, room string, sender MessageSender) error
 }
 
+// InputSchemaProvider is an optional interface for agents that declare the
+// task input they expect, as a JSON Schema per capability. Tasks for a
+// capability with a schema are validated before they reach the agent, and
+// the schemas are published with the agent's capabilities.
+type InputSchemaProvider interface {
+	InputSchemas() map[string]json.RawMessage
+}
+
+// QuotaChecker decides whether a consumer may run another task
+type QuotaChecker interface {
+	// CheckQuota records a request for the consumer and returns ErrQuotaExceeded
+	// or ErrConsumerBlocked when the request must be rejected
+	CheckQuota(ctx context.Context, consumerID string) error
+}
+
+// ConversationMessage is a single turn in a room's conversation history
+type ConversationMessage struct {
+	Role      string    `json:"role"` // "user" or "assistant"
+	Content   string    `json:"content"`
+	Sender    string    `json:"sender,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// ConversationAwareHandler is an optional interface for agents that use the room's conversation history
+type ConversationAwareHandler interface {
+	// ProcessTaskWithHistory processes a task with the previous turns of the room's conversation
+	ProcessTaskWithHistory(ctx context.Context, task string, room string, history []ConversationMessage) (string, error)
+}
+
+// ConversationMemory stores conversation history per room
+type ConversationMemory interface {
+	// History returns the stored turns for a room, oldest first
+	History(ctx context.Context, room string) ([]ConversationMessage, error)
+	// Append adds turns to a room's history
+	Append(ctx context.Context, room string, messages ...ConversationMessage) error
+}
+
 // DefaultAgentHandler provides default implementations for optional interfaces
 type DefaultAgentHandler struct{}
 
@@ -98,6 +183,8 @@ type AgentStatus struct {
 	LastSeen        time.Time         `json:"last_seen"`
 	Metadata        map[string]string `json:"metadata,omitempty"`
 	NFTTokenID      string            `json:"nft_token_id,omitempty"`
+	Resources       *ComputeResources `json:"resources,omitempty"`   // Hardware the agent advertises, if any
+	InstanceID      string            `json:"instance_id,omitempty"` // Process the agent is connected from, if the server reports it
 }
 
 // AgentMetrics represents performance metrics for an agent
@@ -111,14 +198,6 @@ type AgentMetrics struct {
 	LastUpdated         time.Time     `json:"last_updated"`
 }
 
-// AgentCapability represents a capability that an agent can perform
-type AgentCapability struct {
-	Name        string `json:"name"`
-	Description string `json:"description"`
-	Version     string `json:"version"`
-	Required    bool   `json:"required"`
-}
-
 // NetworkConfig represents configuration for connecting to the Teneo network
 type NetworkConfig struct {
 	WebSocketURL      string `json:"websocket_url"`
@@ -198,12 +277,57 @@ const (
 	StandardMessageTypeString = "STRING"
 	StandardMessageTypeArray  = "ARRAY"
 	StandardMessageTypeMD     = "MD"
+	StandardMessageTypeTable  = "TABLE" // Markdown pipe table
+	StandardMessageTypeCSV    = "CSV"
 )
 
+// StandardMessageTypeProgress marks a task progress message whose content is a TaskProgress JSON object
+const StandardMessageTypeProgress = "PROGRESS"
+
+// TaskProgress is a structured progress report for a running task
+type TaskProgress struct {
+	TaskID     string    `json:"task_id"`
+	Percent    float64   `json:"percent"`               // 0-100
+	Stage      string    `json:"stage,omitempty"`       // Current step, e.g. "fetching sources"
+	ETASeconds int       `json:"eta_seconds,omitempty"` // Estimated seconds remaining (0 = unknown)
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// StandardMessageTypeStatus marks a lightweight activity status message whose content is a TaskStatus JSON object
+const StandardMessageTypeStatus = "STATUS"
+
+// Activity states of a status message
+const (
+	TaskStatusTyping = "typing"
+	TaskStatusIdle   = "idle"
+)
+
+// TaskStatus is a lightweight activity indicator for a running task
+type TaskStatus struct {
+	TaskID    string `json:"task_id"`
+	Status    string `json:"status"`               // "typing" or "idle"
+	ExpiresIn int    `json:"expires_in,omitempty"` // Seconds after which clients should hide the indicator unless it is refreshed
+}
+
+// TaskAlive is the data of a task_alive message, sent periodically while a
+// task runs so the coordinator can tell a long workload from a hung agent
+type TaskAlive struct {
+	TaskID         string    `json:"task_id"`
+	ElapsedSeconds int       `json:"elapsed_seconds"`
+	Stage          string    `json:"stage,omitempty"`   // Last stage reported with SendProgress
+	Percent        float64   `json:"percent,omitempty"` // Last percentage reported with SendProgress
+	Timestamp      time.Time `json:"timestamp"`
+}
+
 // StandardizedMessage represents the standardized format for all agent messages
 type StandardizedMessage struct {
-	ContentType string      `json:"content_type"` // JSON|STRING|ARRAY|MD
+	ContentType string      `json:"content_type"` // JSON|STRING|ARRAY|MD|TABLE|CSV|IMAGE|AUDIO|HTML
 	Content     interface{} `json:"content"`      // actual content based on type
+
+	// Sequence is the position of a received message among those sent for
+	// its task, starting at 1, so receivers can restore the order (0 = not
+	// numbered). It is set by the SDK when the message is sent.
+	Sequence uint64 `json:"sequence,omitempty"`
 }
 
 // Constants for task types
@@ -227,14 +351,44 @@ const (
 
 // Common capabilities
 var StandardCapabilities = []string{
-	"web_scraping",
-	"data_analysis",
-	"ai_inference",
-	"file_processing",
-	"api_integration",
-	"natural_language_processing",
-	"image_processing",
-	"database_operations",
-	"workflow_automation",
-	"monitoring",
+	"web/scraping",
+	"data/analysis",
+	"ai/inference",
+	"file/processing",
+	"api/integration",
+	"text/processing",
+	"image/processing",
+	"database/operations",
+	"workflow/automation",
+	"system/monitoring",
+}
+
+// MetricsRecorder receives task metrics from the coordinator
+type MetricsRecorder interface {
+	// ObserveTask records a finished task with its status ("success", "error", "rejected") and latency
+	ObserveTask(status string, duration time.Duration)
+	// RecordTaskRejected records a task rejected before execution (e.g. "rate_limit_exceeded")
+	RecordTaskRejected(reason string)
+}
+
+// DeadlineRecorder is implemented by metrics recorders that track whether
+// tasks with a server deadline finish in time
+type DeadlineRecorder interface {
+	// RecordDeadline records a deadline outcome: "met", "missed", "expired"
+	// (passed before the task started) or "at_risk" (predicted to be missed)
+	RecordDeadline(outcome string)
+}
+
+// PanicRecorder is implemented by metrics recorders that count panics
+// recovered from agent handlers
+type PanicRecorder interface {
+	// RecordHandlerPanic records a panic of a handler: "standard", "conversation" or "streaming"
+	RecordHandlerPanic(handler string)
+}
+
+// ResponseReviewer inspects a task's response before it is sent
+type ResponseReviewer interface {
+	// ReviewResponse returns the response to send, or ErrResponseRejected when it must be withheld.
+	// It may block until a reviewer makes a decision.
+	ReviewResponse(ctx context.Context, taskID, room, task, response string) (string, error)
 }
@@ -0,0 +1,157 @@
+package types
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Resources a task budget limits, as reported in the "budget" detail of
+// BUDGET_EXHAUSTED errors
+const (
+	BudgetWallTime  = "wall_time"
+	BudgetLLMTokens = "llm_tokens"
+	BudgetMessages  = "messages"
+)
+
+// TaskBudget limits what a single task may consume. A task exceeding it
+// fails with a BUDGET_EXHAUSTED error.
+type TaskBudget struct {
+	MaxWallTime         time.Duration // Run time of the task, capping its timeout (0 = only the timeout applies)
+	MaxLLMTokens        int           // Prompt and completion tokens across the task's LLM calls (0 = unlimited)
+	MaxOutboundMessages int           // Messages a streaming task sends (0 = unlimited)
+}
+
+// IsZero reports whether the budget limits nothing
+func (b TaskBudget) IsZero() bool {
+	return b == TaskBudget{}
+}
+
+// Budget tracks a task's consumption against its TaskBudget. The SDK
+// attaches it to the context passed to the agent handler; LLM adapters
+// report the tokens they use through it.
+type Budget struct {
+	limits TaskBudget
+	start  time.Time
+
+	mu       sync.Mutex
+	tokens   int
+	messages int
+}
+
+// BudgetUsage is what a task consumed of its budget
+type BudgetUsage struct {
+	WallTime time.Duration `json:"wall_time"`
+	Tokens   int           `json:"llm_tokens"`
+	Messages int           `json:"messages"`
+}
+
+// NewBudget starts tracking a task's consumption against limits
+func NewBudget(limits TaskBudget) *Budget {
+	return &Budget{limits: limits, start: time.Now()}
+}
+
+// Limits returns the task's budget
+func (b *Budget) Limits() TaskBudget {
+	return b.limits
+}
+
+// Deadline returns when the task runs out of wall time; false if its wall
+// time is not limited
+func (b *Budget) Deadline() (time.Time, bool) {
+	if b.limits.MaxWallTime <= 0 {
+		return time.Time{}, false
+	}
+	return b.start.Add(b.limits.MaxWallTime), true
+}
+
+// CheckWallTime returns a BUDGET_EXHAUSTED error once the task has run out
+// of wall time. Its limit and used details are in seconds.
+func (b *Budget) CheckWallTime() error {
+	deadline, ok := b.Deadline()
+	if !ok || time.Now().Before(deadline) {
+		return nil
+	}
+	e := budgetExhausted(BudgetWallTime, b.limits.MaxWallTime.Seconds(), time.Since(b.start).Seconds())
+	e.Message = fmt.Sprintf("task budget exhausted: %s (limit %s)", BudgetWallTime, b.limits.MaxWallTime)
+	return e
+}
+
+// RemainingTokens returns how many LLM tokens the task may still use; false
+// if its tokens are not limited
+func (b *Budget) RemainingTokens() (int, bool) {
+	if b.limits.MaxLLMTokens <= 0 {
+		return 0, false
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return max(b.limits.MaxLLMTokens-b.tokens, 0), true
+}
+
+// CheckTokens returns a BUDGET_EXHAUSTED error if using n more LLM tokens
+// would exceed the task's token budget, without recording them
+func (b *Budget) CheckTokens(n int) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.limits.MaxLLMTokens > 0 && b.tokens+n > b.limits.MaxLLMTokens {
+		return budgetExhausted(BudgetLLMTokens, b.limits.MaxLLMTokens, b.tokens+n)
+	}
+	return nil
+}
+
+// UseTokens records LLM tokens used by the task. It returns a
+// BUDGET_EXHAUSTED error if they exceed the task's token budget.
+func (b *Budget) UseTokens(n int) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.tokens += n
+	if b.limits.MaxLLMTokens > 0 && b.tokens > b.limits.MaxLLMTokens {
+		return budgetExhausted(BudgetLLMTokens, b.limits.MaxLLMTokens, b.tokens)
+	}
+	return nil
+}
+
+// UseMessage records a message about to be sent for the task. It returns a
+// BUDGET_EXHAUSTED error instead if the task has sent all the messages its
+// budget allows.
+func (b *Budget) UseMessage() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.limits.MaxOutboundMessages > 0 && b.messages >= b.limits.MaxOutboundMessages {
+		return budgetExhausted(BudgetMessages, b.limits.MaxOutboundMessages, b.messages)
+	}
+	b.messages++
+	return nil
+}
+
+// Usage returns what the task consumed so far
+func (b *Budget) Usage() BudgetUsage {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return BudgetUsage{WallTime: time.Since(b.start), Tokens: b.tokens, Messages: b.messages}
+}
+
+// budgetExhausted reports a task that used up the named resource
+func budgetExhausted(resource string, limit, used interface{}) *TaskError {
+	e := &TaskError{
+		Code:    ErrorCodeBudgetExhausted,
+		Reason:  resource,
+		Message: fmt.Sprintf("task budget exhausted: %s (limit %v)", resource, limit),
+		Err:     ErrBudgetExhausted,
+	}
+	return e.WithDetail("budget", resource).WithDetail("limit", limit).WithDetail("used", used)
+}
+
+type budgetKey struct{}
+
+// WithBudget returns a context carrying the current task's budget
+func WithBudget(ctx context.Context, budget *Budget) context.Context {
+	return context.WithValue(ctx, budgetKey{}, budget)
+}
+
+// BudgetFromContext returns the current task's budget, nil if the task has none
+func BudgetFromContext(ctx context.Context) *Budget {
+	budget, _ := ctx.Value(budgetKey{}).(*Budget)
+	return budget
+}
@@ -0,0 +1,318 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidCapability is returned when a capability does not follow the taxonomy
+var ErrInvalidCapability = errors.New("invalid capability")
+
+// CapabilityWildcard matches any verb in a required capability, e.g. "text/*"
+const CapabilityWildcard = "*"
+
+// Capability is a structured capability of the form namespace/verb[:qualifier...][@version],
+// e.g. "text/analysis:detailed" or "math/calculations@2.1".
+// Segments are lowercase letters, digits, '_' and '-', starting with a letter or digit.
+// Versions are dot-separated numbers.
+type Capability struct {
+	Namespace  string   `json:"namespace"`
+	Verb       string   `json:"verb"`
+	Qualifiers []string `json:"qualifiers,omitempty"`
+	Version    string   `json:"version,omitempty"`
+}
+
+// ParseCapability parses a capability string
+func ParseCapability(s string) (Capability, error) {
+	name, version, _ := strings.Cut(strings.TrimSpace(s), "@")
+	namespace, rest, ok := strings.Cut(name, "/")
+	if !ok {
+		return Capability{}, fmt.Errorf("%w %q: expected namespace/verb", ErrInvalidCapability, s)
+	}
+
+	parts := strings.Split(rest, ":")
+	capability := Capability{Namespace: namespace, Verb: parts[0], Version: version}
+	if len(parts) > 1 {
+		capability.Qualifiers = parts[1:]
+	}
+	if strings.Contains(s, "@") && version == "" {
+		return Capability{}, fmt.Errorf("%w %q: empty version", ErrInvalidCapability, s)
+	}
+
+	if err := capability.Validate(); err != nil {
+		return Capability{}, err
+	}
+	return capability, nil
+}
+
+// MustParseCapability is like ParseCapability but panics on invalid input
+func MustParseCapability(s string) Capability {
+	capability, err := ParseCapability(s)
+	if err != nil {
+		panic(err)
+	}
+	return capability
+}
+
+// Validate checks that every segment of the capability is well formed
+func (c Capability) Validate() error {
+	if !validCapabilitySegment(c.Namespace) {
+		return fmt.Errorf("%w: invalid namespace %q", ErrInvalidCapability, c.Namespace)
+	}
+	if c.Verb != CapabilityWildcard && !validCapabilitySegment(c.Verb) {
+		return fmt.Errorf("%w: invalid verb %q", ErrInvalidCapability, c.Verb)
+	}
+	for _, qualifier := range c.Qualifiers {
+		if !validCapabilitySegment(qualifier) {
+			return fmt.Errorf("%w: invalid qualifier %q", ErrInvalidCapability, qualifier)
+		}
+	}
+	if c.Version != "" && !validCapabilityVersion(c.Version) {
+		return fmt.Errorf("%w: invalid version %q", ErrInvalidCapability, c.Version)
+	}
+	return nil
+}
+
+// String returns the canonical form of the capability
+func (c Capability) String() string {
+	s := c.Namespace + "/" + c.Verb
+	for _, qualifier := range c.Qualifiers {
+		s += ":" + qualifier
+	}
+	if c.Version != "" {
+		s += "@" + c.Version
+	}
+	return s
+}
+
+// Satisfies reports whether c can serve a task requiring required: the
+// namespace must match, the verb must match unless required uses the
+// wildcard, c must carry every qualifier of required, and if required
+// has a version c must have the same version.
+// "text/analysis:detailed" satisfies "text/analysis" but not the reverse.
+func (c Capability) Satisfies(required Capability) bool {
+	requirement := CapabilityRequirement{
+		Namespace:  required.Namespace,
+		Verb:       required.Verb,
+		Qualifiers: required.Qualifiers,
+		Version:    required.Version,
+	}
+	if required.Version != "" {
+		requirement.Operator = "="
+	}
+	return requirement.MatchedBy(c)
+}
+
+// CapabilityRequirement is a capability a task requires, of the form
+// namespace[/verb[:qualifier...]][operator version], e.g. "text/*",
+// "text/analysis:detailed" or "math>=2". A bare namespace or the "*" verb
+// matches any verb. Operators are =, >=, >, <= and <; "@" is the same as "=".
+type CapabilityRequirement struct {
+	Namespace  string   `json:"namespace"`
+	Verb       string   `json:"verb"`
+	Qualifiers []string `json:"qualifiers,omitempty"`
+	Operator   string   `json:"operator,omitempty"`
+	Version    string   `json:"version,omitempty"`
+}
+
+// ParseCapabilityRequirement parses a capability requirement string
+func ParseCapabilityRequirement(s string) (CapabilityRequirement, error) {
+	s = strings.TrimSpace(s)
+	name := s
+	var requirement CapabilityRequirement
+
+	if idx := strings.IndexAny(s, "<>=@"); idx >= 0 {
+		name = s[:idx]
+		constraint := s[idx:]
+		for _, operator := range []string{">=", "<=", ">", "<", "=", "@"} {
+			if strings.HasPrefix(constraint, operator) {
+				requirement.Operator = operator
+				requirement.Version = constraint[len(operator):]
+				break
+			}
+		}
+		if requirement.Operator == "@" {
+			requirement.Operator = "="
+		}
+		if !validCapabilityVersion(requirement.Version) {
+			return CapabilityRequirement{}, fmt.Errorf("%w %q: invalid version constraint", ErrInvalidCapability, s)
+		}
+	}
+
+	namespace, rest, ok := strings.Cut(name, "/")
+	requirement.Namespace = namespace
+	requirement.Verb = CapabilityWildcard
+	if ok {
+		parts := strings.Split(rest, ":")
+		requirement.Verb = parts[0]
+		if len(parts) > 1 {
+			requirement.Qualifiers = parts[1:]
+		}
+	}
+
+	capability := Capability{Namespace: requirement.Namespace, Verb: requirement.Verb, Qualifiers: requirement.Qualifiers}
+	if err := capability.Validate(); err != nil {
+		return CapabilityRequirement{}, err
+	}
+	return requirement, nil
+}
+
+// String returns the canonical form of the requirement
+func (r CapabilityRequirement) String() string {
+	s := r.Namespace + "/" + r.Verb
+	for _, qualifier := range r.Qualifiers {
+		s += ":" + qualifier
+	}
+	return s + r.Operator + r.Version
+}
+
+// MatchedBy reports whether capability meets the requirement.
+// A capability without a version never meets a version constraint.
+func (r CapabilityRequirement) MatchedBy(capability Capability) bool {
+	if capability.Namespace != r.Namespace {
+		return false
+	}
+	if r.Verb != CapabilityWildcard && capability.Verb != r.Verb {
+		return false
+	}
+	for _, qualifier := range r.Qualifiers {
+		if !capability.hasQualifier(qualifier) {
+			return false
+		}
+	}
+	if r.Operator == "" {
+		return true
+	}
+	if capability.Version == "" {
+		return false
+	}
+
+	cmp := compareCapabilityVersions(capability.Version, r.Version)
+	switch r.Operator {
+	case "=":
+		return cmp == 0
+	case ">=":
+		return cmp >= 0
+	case ">":
+		return cmp > 0
+	case "<=":
+		return cmp <= 0
+	case "<":
+		return cmp < 0
+	default:
+		return false
+	}
+}
+
+// hasQualifier reports whether the capability carries qualifier
+func (c Capability) hasQualifier(qualifier string) bool {
+	for _, q := range c.Qualifiers {
+		if q == qualifier {
+			return true
+		}
+	}
+	return false
+}
+
+// IsLegacyCapability reports whether s is a free-form capability name such as
+// "content_generation_poems" rather than a namespace/verb capability.
+// Legacy capabilities are still accepted but only match by exact name.
+func IsLegacyCapability(s string) bool {
+	return !strings.Contains(s, "/")
+}
+
+// ValidateCapabilities checks every structured capability in the list.
+// Legacy capabilities are not validated.
+func ValidateCapabilities(capabilities []string) error {
+	for _, s := range capabilities {
+		if IsLegacyCapability(s) {
+			continue
+		}
+		if _, err := ParseCapability(s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CapabilityMatches reports whether an offered capability meets a required one.
+// required is parsed with ParseCapabilityRequirement, so it may use wildcards and
+// version constraints. Legacy capabilities only match by exact name.
+func CapabilityMatches(offered, required string) bool {
+	if offered == required {
+		return true
+	}
+	if IsLegacyCapability(offered) {
+		return false
+	}
+
+	requirement, err := ParseCapabilityRequirement(required)
+	if err != nil {
+		return false
+	}
+	capability, err := ParseCapability(offered)
+	if err != nil {
+		return false
+	}
+	return requirement.MatchedBy(capability)
+}
+
+// AnyCapabilityMatches reports whether any offered capability satisfies required
+func AnyCapabilityMatches(offered []string, required string) bool {
+	for _, capability := range offered {
+		if CapabilityMatches(capability, required) {
+			return true
+		}
+	}
+	return false
+}
+
+// validCapabilitySegment reports whether s is a valid namespace, verb or qualifier
+func validCapabilitySegment(s string) bool {
+	if s == "" || s[0] == '_' || s[0] == '-' {
+		return false
+	}
+	for _, char := range s {
+		if (char < 'a' || char > 'z') && (char < '0' || char > '9') && char != '_' && char != '-' {
+			return false
+		}
+	}
+	return true
+}
+
+// validCapabilityVersion reports whether s is a dot-separated numeric version
+func validCapabilityVersion(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, part := range strings.Split(s, ".") {
+		if _, err := strconv.ParseUint(part, 10, 64); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// compareCapabilityVersions compares two valid versions part by part; missing parts count as 0
+func compareCapabilityVersions(a, b string) int {
+	aParts := strings.Split(a, ".")
+	bParts := strings.Split(b, ".")
+	for i := 0; i < max(len(aParts), len(bParts)); i++ {
+		var x, y uint64
+		if i < len(aParts) {
+			x, _ = strconv.ParseUint(aParts[i], 10, 64)
+		}
+		if i < len(bParts) {
+			y, _ = strconv.ParseUint(bParts[i], 10, 64)
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
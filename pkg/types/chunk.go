@@ -0,0 +1,255 @@
+package types
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"maps"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// ErrInvalidChunk is returned for message parts that cannot be reassembled
+var ErrInvalidChunk = errors.New("invalid message chunk")
+
+// maxChunkParts bounds the parts of one message an assembler accepts
+const maxChunkParts = 10000
+
+// Default limits of the incomplete messages an assembler holds, see SetLimits
+const (
+	DefaultMaxPendingChunked = 100      // Messages waiting for parts
+	DefaultMaxChunkedBytes   = 16 << 20 // Content bytes of their parts
+)
+
+// Chunk marks one part of a message whose content was split because it
+// exceeded the server's message size limit. The parts carry the message's
+// other fields unchanged and are sent in order; the last one is Final.
+type Chunk struct {
+	ID    string `json:"id"`              // Shared by all parts of a message
+	Index int    `json:"index"`           // Position of the part, from 0
+	Total int    `json:"total"`           // Number of parts
+	Final bool   `json:"final,omitempty"` // Set on the last part
+}
+
+// SplitContent splits the message into parts with at most size bytes of
+// content each, cutting at line breaks where possible and never inside a
+// UTF-8 character. A message that fits, or size <= 0, is returned as is.
+// Compressed content is split after compression; the parts carry its
+// encoding and are decoded once reassembled.
+func (m *Message) SplitContent(size int) []*Message {
+	if size <= 0 || len(m.Content) <= size {
+		return []*Message{m}
+	}
+
+	contents := splitText(m.Content, size)
+	id := newChunkID()
+	parts := make([]*Message, len(contents))
+	for i, content := range contents {
+		part := *m
+		part.Content = content
+		part.Metadata = maps.Clone(m.Metadata)
+		part.Chunk = &Chunk{ID: id, Index: i, Total: len(contents), Final: i == len(contents)-1}
+		parts[i] = &part
+	}
+	return parts
+}
+
+// splitText cuts s into pieces of at most size bytes
+func splitText(s string, size int) []string {
+	var pieces []string
+	for len(s) > size {
+		cut := size
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+		// Prefer a line break in the second half of the piece
+		if newline := strings.LastIndexByte(s[:cut], '\n'); newline >= cut/2 {
+			cut = newline + 1
+		}
+		if cut == 0 {
+			// size is smaller than the character
+			_, cut = utf8.DecodeRuneInString(s)
+		}
+		pieces = append(pieces, s[:cut])
+		s = s[cut:]
+	}
+	return append(pieces, s)
+}
+
+func newChunkID() string {
+	id := make([]byte, 8)
+	rand.Read(id)
+	return hex.EncodeToString(id)
+}
+
+// JoinChunks reassembles a message from all of its parts, in any order
+func JoinChunks(parts []*Message) (*Message, error) {
+	assembler := NewChunkAssembler(0)
+	for _, part := range parts {
+		msg, err := assembler.Add(part)
+		if err != nil {
+			return nil, err
+		}
+		if msg != nil {
+			return msg, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: missing parts", ErrInvalidChunk)
+}
+
+// ChunkAssembler reassembles messages split with SplitContent. It is safe for
+// concurrent use.
+type ChunkAssembler struct {
+	timeout    time.Duration
+	maxPending int
+	maxBytes   int
+
+	mu      sync.Mutex
+	pending map[string]*chunkedMessage
+	bytes   int // Content bytes of the pending parts
+}
+
+// chunkedMessage collects the parts of a message
+type chunkedMessage struct {
+	parts    []*Message
+	received int
+	bytes    int
+	started  time.Time
+}
+
+// NewChunkAssembler returns an assembler that drops incomplete messages whose
+// first part arrived more than timeout ago (default 5 minutes)
+func NewChunkAssembler(timeout time.Duration) *ChunkAssembler {
+	if timeout <= 0 {
+		timeout = 5 * time.Minute
+	}
+	return &ChunkAssembler{
+		timeout:    timeout,
+		maxPending: DefaultMaxPendingChunked,
+		maxBytes:   DefaultMaxChunkedBytes,
+		pending:    make(map[string]*chunkedMessage),
+	}
+}
+
+// SetLimits bounds the incomplete messages held at once and the content
+// bytes of their parts (0 = default). Over either limit the oldest incomplete
+// messages are dropped; a message larger than maxBytes on its own is rejected.
+func (a *ChunkAssembler) SetLimits(maxPending, maxBytes int) {
+	if maxPending <= 0 {
+		maxPending = DefaultMaxPendingChunked
+	}
+	if maxBytes <= 0 {
+		maxBytes = DefaultMaxChunkedBytes
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.maxPending, a.maxBytes = maxPending, maxBytes
+}
+
+// Add takes a received message. It returns a message that is not chunked as
+// is, nil while parts of a chunked message are missing, and the reassembled
+// message once its last part arrived. Parts may arrive in any order and
+// repeated parts are ignored. The reassembled message has no signature,
+// since the parts were signed separately; it is Verified if all parts were.
+func (a *ChunkAssembler) Add(msg *Message) (*Message, error) {
+	chunk := msg.Chunk
+	if chunk == nil {
+		return msg, nil
+	}
+	if chunk.ID == "" || chunk.Total <= 0 || chunk.Total > maxChunkParts || chunk.Index < 0 || chunk.Index >= chunk.Total {
+		return nil, fmt.Errorf("%w: part %d of %d", ErrInvalidChunk, chunk.Index, chunk.Total)
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.expire(time.Now())
+
+	pending, ok := a.pending[chunk.ID]
+	if !ok {
+		for len(a.pending) >= a.maxPending {
+			a.dropOldest("")
+		}
+		pending = &chunkedMessage{parts: make([]*Message, chunk.Total), started: time.Now()}
+		a.pending[chunk.ID] = pending
+	}
+	if len(pending.parts) != chunk.Total {
+		a.drop(chunk.ID)
+		return nil, fmt.Errorf("%w: part %d says %d parts, earlier parts said %d", ErrInvalidChunk, chunk.Index, chunk.Total, len(pending.parts))
+	}
+	if pending.parts[chunk.Index] != nil {
+		return nil, nil
+	}
+
+	size := len(msg.Content)
+	for a.bytes+size > a.maxBytes {
+		if !a.dropOldest(chunk.ID) {
+			a.drop(chunk.ID)
+			return nil, fmt.Errorf("%w: message %s exceeds %d bytes", ErrInvalidChunk, chunk.ID, a.maxBytes)
+		}
+	}
+	pending.parts[chunk.Index] = msg
+	pending.received++
+	pending.bytes += size
+	a.bytes += size
+	if pending.received < chunk.Total {
+		return nil, nil
+	}
+
+	a.drop(chunk.ID)
+	var content strings.Builder
+	verified := true
+	for _, part := range pending.parts {
+		content.WriteString(part.Content)
+		verified = verified && part.Verified
+	}
+	joined := *pending.parts[0]
+	joined.Content = content.String()
+	joined.Chunk = nil
+	joined.Signature = ""
+	joined.Verified = verified
+	return &joined, nil
+}
+
+// Pending returns the number of messages waiting for parts
+func (a *ChunkAssembler) Pending() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.expire(time.Now())
+	return len(a.pending)
+}
+
+// expire drops the incomplete messages that timed out
+func (a *ChunkAssembler) expire(now time.Time) {
+	for id, pending := range a.pending {
+		if now.Sub(pending.started) > a.timeout {
+			a.drop(id)
+		}
+	}
+}
+
+// dropOldest drops the incomplete message whose first part arrived first,
+// other than keep, and reports whether there was one
+func (a *ChunkAssembler) dropOldest(keep string) bool {
+	oldest := ""
+	for id, pending := range a.pending {
+		if id != keep && (oldest == "" || pending.started.Before(a.pending[oldest].started)) {
+			oldest = id
+		}
+	}
+	if oldest == "" {
+		return false
+	}
+	a.drop(oldest)
+	return true
+}
+
+// drop forgets an incomplete message and its parts
+func (a *ChunkAssembler) drop(id string) {
+	if pending, ok := a.pending[id]; ok {
+		a.bytes -= pending.bytes
+		delete(a.pending, id)
+	}
+}
@@ -0,0 +1,99 @@
+package types
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ContentEncodingGzipBase64 marks message content that is gzip-compressed and base64-encoded
+const ContentEncodingGzipBase64 = "gzip+base64"
+
+// MaxDecompressedBytes bounds the content DecompressContent restores, so a
+// small compressed message cannot expand beyond what memory allows
+const MaxDecompressedBytes = 32 << 20
+
+// ErrContentTooLarge is returned for compressed content that expands beyond MaxDecompressedBytes
+var ErrContentTooLarge = errors.New("decompressed content too large")
+
+// CompressContent gzips content and encodes it as base64
+func CompressContent(content string) (string, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write([]byte(content)); err != nil {
+		return "", fmt.Errorf("failed to compress content: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return "", fmt.Errorf("failed to compress content: %w", err)
+	}
+	return base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+}
+
+// DecompressContent reverses CompressContent. It fails with
+// ErrContentTooLarge once more than MaxDecompressedBytes were restored.
+func DecompressContent(encoded string) (string, error) {
+	compressed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode content: %w", err)
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return "", fmt.Errorf("failed to decompress content: %w", err)
+	}
+	defer zr.Close()
+
+	content, err := io.ReadAll(io.LimitReader(zr, MaxDecompressedBytes+1))
+	if err != nil {
+		return "", fmt.Errorf("failed to decompress content: %w", err)
+	}
+	if len(content) > MaxDecompressedBytes {
+		return "", fmt.Errorf("%w: more than %d bytes", ErrContentTooLarge, MaxDecompressedBytes)
+	}
+	return string(content), nil
+}
+
+// CompressContent replaces the message content with its gzip+base64 encoding
+// when it is at least threshold bytes and compression actually saves space.
+// It reports whether the content was compressed.
+func (m *Message) CompressContent(threshold int) (bool, error) {
+	if threshold <= 0 || len(m.Content) < threshold || m.Encoding != "" {
+		return false, nil
+	}
+
+	encoded, err := CompressContent(m.Content)
+	if err != nil {
+		return false, err
+	}
+	if len(encoded) >= len(m.Content) {
+		return false, nil
+	}
+
+	m.Content = encoded
+	m.Encoding = ContentEncodingGzipBase64
+	return true, nil
+}
+
+// DecodeContent restores compressed message content in place. Encrypted
+// content is left as it is.
+func (m *Message) DecodeContent() error {
+	switch m.Encoding {
+	case "":
+		return nil
+	case ContentEncodingGzipBase64:
+		content, err := DecompressContent(m.Content)
+		if err != nil {
+			return err
+		}
+		m.Content = content
+		m.Encoding = ""
+		return nil
+	case ContentEncodingE2E:
+		// Left for the recipient, who holds the key
+		return nil
+	default:
+		return fmt.Errorf("unsupported content encoding %q", m.Encoding)
+	}
+}
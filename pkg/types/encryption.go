@@ -0,0 +1,15 @@
+package types
+
+import "time"
+
+// ContentEncodingE2E marks message content sealed to the recipient's
+// encryption key (see package e2e). Only the recipient can decode it.
+const ContentEncodingE2E = "x25519+aes256gcm"
+
+// EncryptionKey is a public key users can encrypt task content to
+type EncryptionKey struct {
+	ID        string    `json:"id"`
+	Algorithm string    `json:"algorithm"`
+	PublicKey string    `json:"public_key"` // Base64
+	CreatedAt time.Time `json:"created_at"`
+}
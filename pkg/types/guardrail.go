@@ -0,0 +1,32 @@
+package types
+
+import "context"
+
+// What happens to content a guardrail flags
+const (
+	GuardrailActionBlock    = "block"    // The task is rejected or the response withheld
+	GuardrailActionRedact   = "redact"   // The flagged parts are replaced; blocked if the guardrail cannot redact
+	GuardrailActionAnnotate = "annotate" // The content passes and the verdict is reported with the response
+)
+
+// GuardrailVerdict is a guardrail's judgement of a piece of content
+type GuardrailVerdict struct {
+	Flagged    bool     `json:"flagged"`
+	Categories []string `json:"categories,omitempty"` // Why the content was flagged, e.g. "violence" or "profanity"
+	Reason     string   `json:"reason,omitempty"`
+	Redacted   string   `json:"-"` // The content with the flagged parts replaced, "" if the guardrail cannot redact it
+}
+
+// Guardrail checks the input of tasks before the handler sees it and the
+// responses before they reach the room. Implementations must be safe for
+// concurrent use.
+type Guardrail interface {
+	CheckInput(ctx context.Context, content string) (GuardrailVerdict, error)
+	CheckOutput(ctx context.Context, content string) (GuardrailVerdict, error)
+}
+
+// GuardrailRecorder is implemented by metrics recorders that count the
+// content flagged by guardrails ("input" or "output")
+type GuardrailRecorder interface {
+	RecordGuardrailFlag(direction string)
+}
@@ -0,0 +1,84 @@
+package types
+
+import (
+	"context"
+	"sync"
+)
+
+// LLMUsage is what LLM calls consumed: tokens, and their cost when the
+// model's prices are known
+type LLMUsage struct {
+	PromptTokens     int     `json:"prompt_tokens"`
+	CompletionTokens int     `json:"completion_tokens"`
+	Cost             float64 `json:"cost,omitempty"` // In the currency of the model's prices, 0 if unpriced
+}
+
+// TotalTokens returns the prompt and completion tokens
+func (u LLMUsage) TotalTokens() int {
+	return u.PromptTokens + u.CompletionTokens
+}
+
+// IsZero reports whether nothing was consumed
+func (u LLMUsage) IsZero() bool {
+	return u == LLMUsage{}
+}
+
+// Add returns the sum of two usages
+func (u LLMUsage) Add(other LLMUsage) LLMUsage {
+	return LLMUsage{
+		PromptTokens:     u.PromptTokens + other.PromptTokens,
+		CompletionTokens: u.CompletionTokens + other.CompletionTokens,
+		Cost:             u.Cost + other.Cost,
+	}
+}
+
+// LLMUsageTracker adds up the LLM usage of a task. The SDK attaches one to
+// the context passed to the agent handler; LLM adapters report every call
+// through it, see AddLLMUsage.
+type LLMUsageTracker struct {
+	mu    sync.Mutex
+	usage LLMUsage
+}
+
+// Add records the usage of an LLM call
+func (t *LLMUsageTracker) Add(usage LLMUsage) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.usage = t.usage.Add(usage)
+}
+
+// Usage returns the usage recorded so far
+func (t *LLMUsageTracker) Usage() LLMUsage {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.usage
+}
+
+// LLMUsageRecorder is implemented by metrics recorders and ledgers that
+// account for the LLM usage of finished tasks
+type LLMUsageRecorder interface {
+	// RecordLLMUsage records what a task in room consumed
+	RecordLLMUsage(room string, usage LLMUsage)
+}
+
+type llmUsageKey struct{}
+
+// WithLLMUsageTracker returns a context carrying the current task's usage tracker
+func WithLLMUsageTracker(ctx context.Context, tracker *LLMUsageTracker) context.Context {
+	return context.WithValue(ctx, llmUsageKey{}, tracker)
+}
+
+// LLMUsageTrackerFromContext returns the current task's usage tracker, nil
+// outside a task
+func LLMUsageTrackerFromContext(ctx context.Context) *LLMUsageTracker {
+	tracker, _ := ctx.Value(llmUsageKey{}).(*LLMUsageTracker)
+	return tracker
+}
+
+// AddLLMUsage records the usage of an LLM call made for the current task.
+// Handlers calling models themselves use it to have their usage reported.
+func AddLLMUsage(ctx context.Context, usage LLMUsage) {
+	if tracker := LLMUsageTrackerFromContext(ctx); tracker != nil {
+		tracker.Add(usage)
+	}
+}
@@ -0,0 +1,135 @@
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/schema"
+)
+
+// AgentCapability describes a capability in the agent's manifest: what it
+// does, which version of it the agent serves, the task input it accepts and
+// the output it returns. The manifest is sent with the registration so
+// coordinators and clients can build matching requests.
+type AgentCapability struct {
+	Name         string          `json:"name"`                    // Capability name, e.g. "text/translation"
+	Description  string          `json:"description,omitempty"`   // What the capability does
+	Version      string          `json:"version,omitempty"`       // Dot-separated numbers, e.g. "2.1"
+	Required     bool            `json:"required,omitempty"`      // Whether the agent cannot serve tasks without it
+	InputSchema  json.RawMessage `json:"input_schema,omitempty"`  // JSON Schema of the task input
+	OutputSchema json.RawMessage `json:"output_schema,omitempty"` // JSON Schema of JSON results
+	Pricing      *PricingHint    `json:"pricing,omitempty"`
+}
+
+// PricingHint is the indicative price of a capability. It is informational;
+// billing happens elsewhere.
+type PricingHint struct {
+	Price    string `json:"price"`          // Decimal amount, e.g. "0.002"
+	Currency string `json:"currency"`       // e.g. "USD" or "TENEO"
+	Unit     string `json:"unit,omitempty"` // What the price is for, e.g. "1k_tokens" (default per task)
+}
+
+// CapabilityManifestProvider is an optional interface for agents that
+// describe their capabilities in a manifest. The capabilities are advertised
+// in addition to the configured ones, and their input schemas are enforced
+// like those of an InputSchemaProvider.
+type CapabilityManifestProvider interface {
+	CapabilityManifest() []AgentCapability
+}
+
+// ID returns the capability as advertised: name@version for namespace/verb
+// capabilities with a version, otherwise the name
+func (c AgentCapability) ID() string {
+	if c.Version == "" || IsLegacyCapability(c.Name) || strings.Contains(c.Name, "@") {
+		return c.Name
+	}
+	return c.Name + "@" + c.Version
+}
+
+// Validate checks the name, version and schemas of the capability
+func (c AgentCapability) Validate() error {
+	if c.Name == "" {
+		return fmt.Errorf("%w: capability without a name", ErrInvalidCapability)
+	}
+	if !IsLegacyCapability(c.Name) {
+		if _, err := ParseCapability(c.ID()); err != nil {
+			return err
+		}
+	} else if c.Version != "" && !validCapabilityVersion(c.Version) {
+		return fmt.Errorf("%w: invalid version %q of %s", ErrInvalidCapability, c.Version, c.Name)
+	}
+	for name, raw := range map[string]json.RawMessage{"input": c.InputSchema, "output": c.OutputSchema} {
+		if len(raw) == 0 {
+			continue
+		}
+		if _, err := schema.Compile(raw); err != nil {
+			return fmt.Errorf("%w: %s schema of %s: %v", ErrInvalidCapability, name, c.ID(), err)
+		}
+	}
+	if p := c.Pricing; p != nil && (p.Price == "" || p.Currency == "") {
+		return fmt.Errorf("%w: pricing of %s needs a price and a currency", ErrInvalidCapability, c.ID())
+	}
+	return nil
+}
+
+// ValidateInput checks task input against the input schema; a capability
+// without one accepts any input. Input that isn't JSON is checked as a string.
+func (c AgentCapability) ValidateInput(input string) error {
+	return validateAgainst(c.InputSchema, input)
+}
+
+// ValidateOutput checks a JSON result against the output schema; a
+// capability without one accepts any output
+func (c AgentCapability) ValidateOutput(output string) error {
+	return validateAgainst(c.OutputSchema, output)
+}
+
+func validateAgainst(raw json.RawMessage, content string) error {
+	if len(raw) == 0 {
+		return nil
+	}
+	s, err := schema.Compile(raw)
+	if err != nil {
+		return err
+	}
+	return s.ValidateInput(content)
+}
+
+// ValidateManifest checks every capability of a manifest and that no
+// capability appears twice
+func ValidateManifest(manifest []AgentCapability) error {
+	seen := make(map[string]bool, len(manifest))
+	for _, capability := range manifest {
+		if err := capability.Validate(); err != nil {
+			return err
+		}
+		if seen[capability.ID()] {
+			return fmt.Errorf("%w: %s is declared twice", ErrInvalidCapability, capability.ID())
+		}
+		seen[capability.ID()] = true
+	}
+	return nil
+}
+
+// ManifestCapability returns the capability of the manifest serving a task
+// that requires required (see CapabilityMatches), false if there is none
+func ManifestCapability(manifest []AgentCapability, required string) (AgentCapability, bool) {
+	for _, capability := range manifest {
+		if CapabilityMatches(capability.ID(), required) {
+			return capability, true
+		}
+	}
+	return AgentCapability{}, false
+}
+
+// ManifestInputSchemas returns the input schemas of a manifest by advertised capability
+func ManifestInputSchemas(manifest []AgentCapability) map[string]json.RawMessage {
+	schemas := make(map[string]json.RawMessage)
+	for _, capability := range manifest {
+		if len(capability.InputSchema) > 0 {
+			schemas[capability.ID()] = capability.InputSchema
+		}
+	}
+	return schemas
+}
@@ -0,0 +1,120 @@
+package types
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+)
+
+// Standardized message types for media. IMAGE and AUDIO content is a
+// MediaContent JSON object carrying the data as base64, so it survives the
+// text protocol unchanged; HTML content is the markup itself.
+const (
+	StandardMessageTypeImage = "IMAGE"
+	StandardMessageTypeAudio = "AUDIO"
+	StandardMessageTypeHTML  = "HTML"
+)
+
+// Size limits of media messages, before base64 encoding
+const (
+	MaxImageBytes = 5 << 20  // 5 MiB
+	MaxAudioBytes = 10 << 20 // 10 MiB
+	MaxHTMLBytes  = 1 << 20  // 1 MiB
+)
+
+var (
+	// ErrMediaTooLarge is returned for media above its size limit
+	ErrMediaTooLarge = errors.New("media too large")
+
+	// ErrUnsupportedMedia is returned for media of a MIME type clients cannot show
+	ErrUnsupportedMedia = errors.New("unsupported media type")
+)
+
+// Image and audio formats clients can show. SVG is left out because it can
+// carry scripts.
+var (
+	imageMIMETypes = map[string]bool{"image/png": true, "image/jpeg": true, "image/gif": true, "image/webp": true}
+	audioMIMETypes = map[string]bool{
+		"audio/mpeg": true, "audio/wav": true, "audio/wave": true, "audio/x-wav": true, "audio/ogg": true,
+		"audio/webm": true, "audio/mp4": true, "audio/aac": true, "audio/flac": true,
+	}
+)
+
+// MediaContent is the content of IMAGE and AUDIO messages
+type MediaContent struct {
+	MimeType string `json:"mime_type"`
+	Data     string `json:"data"`          // Base64 (standard encoding with padding)
+	Size     int    `json:"size"`          // Bytes before encoding
+	Alt      string `json:"alt,omitempty"` // Text alternative, e.g. an image description or audio transcript
+}
+
+// IsBinaryContentType reports whether messages of the content type carry
+// encoded binary data that must not be changed as text
+func IsBinaryContentType(contentType string) bool {
+	return contentType == StandardMessageTypeImage || contentType == StandardMessageTypeAudio
+}
+
+// NewImageContent validates an image and encodes it for an IMAGE message.
+// An empty mimeType is detected from the data.
+func NewImageContent(data []byte, mimeType, alt string) (*MediaContent, error) {
+	return newMediaContent(data, mimeType, alt, imageMIMETypes, MaxImageBytes)
+}
+
+// NewAudioContent validates audio and encodes it for an AUDIO message.
+// An empty mimeType is detected from the data.
+func NewAudioContent(data []byte, mimeType, alt string) (*MediaContent, error) {
+	return newMediaContent(data, mimeType, alt, audioMIMETypes, MaxAudioBytes)
+}
+
+func newMediaContent(data []byte, mimeType, alt string, allowed map[string]bool, limit int) (*MediaContent, error) {
+	if len(data) == 0 {
+		return nil, fmt.Errorf("%w: no data", ErrUnsupportedMedia)
+	}
+	if len(data) > limit {
+		return nil, fmt.Errorf("%w: %d bytes, limit is %d", ErrMediaTooLarge, len(data), limit)
+	}
+	if mimeType == "" {
+		mimeType = http.DetectContentType(data)
+	}
+	if mediaType, _, err := mime.ParseMediaType(mimeType); err == nil {
+		mimeType = mediaType
+	}
+	if !allowed[mimeType] {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedMedia, mimeType)
+	}
+	return &MediaContent{
+		MimeType: mimeType,
+		Data:     base64.StdEncoding.EncodeToString(data),
+		Size:     len(data),
+		Alt:      alt,
+	}, nil
+}
+
+// Bytes decodes the media data
+func (m *MediaContent) Bytes() ([]byte, error) {
+	data, err := base64.StdEncoding.DecodeString(m.Data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode media data: %w", err)
+	}
+	return data, nil
+}
+
+// ValidateHTML checks the content of an HTML message: valid UTF-8 within
+// MaxHTMLBytes. The markup is not sanitized; clients must render it in a
+// sandbox.
+func ValidateHTML(content string) error {
+	if strings.TrimSpace(content) == "" {
+		return errors.New("HTML content is empty")
+	}
+	if len(content) > MaxHTMLBytes {
+		return fmt.Errorf("%w: %d bytes of HTML, limit is %d", ErrMediaTooLarge, len(content), MaxHTMLBytes)
+	}
+	if !utf8.ValidString(content) {
+		return errors.New("HTML content is not valid UTF-8")
+	}
+	return nil
+}
@@ -20,6 +20,14 @@ var (
 	ErrSignatureInvalid        = errors.New("invalid signature")
 	ErrNFTNotFound             = errors.New("NFT not found")
 	ErrAgentAlreadyRegistered  = errors.New("agent already registered")
+	ErrQuotaExceeded           = errors.New("consumer quota exceeded")
+	ErrConsumerBlocked         = errors.New("consumer is blocked")
+	ErrResponseRejected        = errors.New("response rejected by reviewer")
+	ErrPaymentRequired         = errors.New("payment required")
+	ErrPaymentInvalid          = errors.New("invalid payment")
+	ErrBudgetExhausted         = errors.New("task budget exhausted")
+	ErrContentBlocked          = errors.New("content blocked by guardrail")
+	ErrAccessDenied            = errors.New("access denied")
 )
 
 // Message represents a message in the Teneo network
@@ -30,10 +38,15 @@ type Message struct {
 	To            string            `json:"to,omitempty"`
 	ContentType   string            `json:"content_type,omitempty"`
 	Content       string            `json:"content,omitempty"`
+	Encoding      string            `json:"content_encoding,omitempty"` // Set when Content is compressed (see ContentEncodingGzipBase64)
+	Chunk         *Chunk            `json:"chunk,omitempty"`            // Set on the parts of a split message (see SplitContent)
 	Timestamp     time.Time         `json:"timestamp"`
 	Metadata      map[string]string `json:"metadata,omitempty"`
 	Signature     string            `json:"signature,omitempty"`
+	Verified      bool              `json:"-"`               // Set by the receiver once the signature was checked as received, never read from the wire
+	Nonce         string            `json:"nonce,omitempty"` // Set by users signing their messages, see UserSigningPayload
 	TaskID        string            `json:"task_id,omitempty"`
+	Sequence      uint64            `json:"sequence,omitempty"` // Position among the messages sent for the task, starting at 1
 	ReplyTo       string            `json:"reply_to,omitempty"`
 	Data          json.RawMessage   `json:"data,omitempty"`
 	Room          string            `json:"room,omitempty"`
@@ -48,6 +61,8 @@ const (
 	MessageTypeTaskResult   = "task_result"
 	MessageTypeTaskResponse = "task_response"
 	MessageTypeHeartbeat    = "heartbeat"
+	MessageTypeTaskAlive    = "task_alive"    // Sign of life of a running task, see TaskAlive
+	MessageTypeUsageReceipt = "usage_receipt" // Receipt of a streaming task, see UsageReceipt
 	MessageTypeRegistration = "registration"
 	MessageTypeAuth         = "auth"
 	MessageTypeError        = "error"
@@ -70,7 +85,13 @@ const (
 	MessageTypeLeave            = "leave"
 	MessageTypeAgents           = "agents"
 	MessageTypeRooms            = "rooms"
+	MessageTypeRoomHistory      = "room_history"
+	MessageTypeUserProfiles     = "user_profiles"
 	MessageTypeNick             = "nick"
+	MessageTypeDataChannelOpen  = "data_channel_open"
+	MessageTypeDataChannelReady = "data_channel_ready"
+	MessageTypeOperatorCommand  = "operator_command"
+	MessageTypeOperatorResult   = "operator_result"
 )
 
 // AuthMessage represents an authentication message
@@ -84,6 +105,7 @@ type AuthMessage struct {
 	AgentName  string `json:"agentName,omitempty"`
 	NFTTokenID string `json:"nft_token_id,omitempty"`
 	Timestamp  int64  `json:"timestamp"`
+	Takeover   bool   `json:"takeover,omitempty"` // Asks the server to drop other connections with the same identity
 }
 
 // ChallengeMessage represents an authentication challenge
@@ -100,6 +122,14 @@ type RegistrationMessage struct {
 	Challenge         string `json:"challenge"`
 	ChallengeResponse string `json:"challenge_response"`
 	Room              string `json:"room,omitempty"`
+	Compression       string `json:"compression,omitempty"` // Content encoding the agent offers for large task responses
+	InstanceID        string `json:"instance_id,omitempty"` // Random ID of the agent process, to tell apart processes with the same identity
+	Takeover          bool   `json:"takeover,omitempty"`    // Asks the server to drop other connections with the same identity
+
+	Resources *ComputeResources `json:"resources,omitempty"` // Hardware advertised for routing heavy jobs
+	Manifest  []AgentCapability `json:"manifest,omitempty"`  // Capabilities with their schemas and pricing
+
+	EncryptionKeys []EncryptionKey `json:"encryption_keys,omitempty"` // Keys task content can be encrypted to, the one to use first
 }
 
 // HeartbeatMessage represents a heartbeat message
@@ -174,6 +204,15 @@ type AgentInfo struct {
 	Status       string   `json:"status"`
 }
 
+// UserProfile is the public profile of a wallet address, as provided by the backend
+type UserProfile struct {
+	Address     string            `json:"address"`
+	DisplayName string            `json:"display_name,omitempty"`
+	Username    string            `json:"username,omitempty"`
+	AvatarURL   string            `json:"avatar_url,omitempty"`
+	Metadata    map[string]string `json:"metadata,omitempty"`
+}
+
 // AgentSelectedMessage represents an agent selection message
 type AgentSelectedMessage struct {
 	AgentID      string   `json:"agent_id"`
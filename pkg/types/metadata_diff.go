@@ -0,0 +1,70 @@
+package types
+
+import (
+	"sort"
+	"strings"
+)
+
+// Metadata fields compared by DiffAgentMetadata
+const (
+	MetadataFieldName         = "name"
+	MetadataFieldDescription  = "description"
+	MetadataFieldCapabilities = "capabilities"
+	MetadataFieldImage        = "image"
+	MetadataFieldContactInfo  = "contact_info"
+	MetadataFieldPricingModel = "pricing_model"
+	MetadataFieldVersion      = "version"
+)
+
+// MetadataChange is a metadata field whose current value differs from the desired one
+type MetadataChange struct {
+	Field   string `json:"field"`
+	Current string `json:"current"`
+	Desired string `json:"desired"`
+}
+
+// Updatable reports whether the field can be changed after minting.
+// Name, capabilities and image are fixed when the NFT is minted.
+func (c MetadataChange) Updatable() bool {
+	switch c.Field {
+	case MetadataFieldDescription, MetadataFieldContactInfo, MetadataFieldPricingModel, MetadataFieldVersion:
+		return true
+	default:
+		return false
+	}
+}
+
+// DiffAgentMetadata returns the fields of desired that differ from current.
+// Capabilities are compared as a set, ignoring order and duplicates.
+func DiffAgentMetadata(current, desired AgentMetadata) []MetadataChange {
+	var changes []MetadataChange
+	add := func(field, currentValue, desiredValue string) {
+		if currentValue != desiredValue {
+			changes = append(changes, MetadataChange{Field: field, Current: currentValue, Desired: desiredValue})
+		}
+	}
+
+	add(MetadataFieldName, current.Name, desired.Name)
+	add(MetadataFieldDescription, current.Description, desired.Description)
+	add(MetadataFieldCapabilities, capabilitySet(current.Capabilities), capabilitySet(desired.Capabilities))
+	add(MetadataFieldImage, current.ImageURI, desired.ImageURI)
+	add(MetadataFieldContactInfo, current.ContactInfo, desired.ContactInfo)
+	add(MetadataFieldPricingModel, current.PricingModel, desired.PricingModel)
+	add(MetadataFieldVersion, current.Version, desired.Version)
+
+	return changes
+}
+
+// capabilitySet returns the sorted, de-duplicated capabilities joined by commas
+func capabilitySet(capabilities []string) string {
+	seen := make(map[string]bool, len(capabilities))
+	set := make([]string, 0, len(capabilities))
+	for _, capability := range capabilities {
+		if !seen[capability] {
+			seen[capability] = true
+			set = append(set, capability)
+		}
+	}
+	sort.Strings(set)
+	return strings.Join(set, ",")
+}
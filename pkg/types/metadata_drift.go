@@ -0,0 +1,51 @@
+package types
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"slices"
+	"time"
+)
+
+// DriftFields are the metadata fields compared between a running agent and
+// its NFT to detect drift
+var DriftFields = []string{MetadataFieldName, MetadataFieldDescription, MetadataFieldCapabilities, MetadataFieldVersion}
+
+// MetadataDrift is the outcome of comparing the metadata of a running agent
+// with the metadata recorded for its NFT
+type MetadataDrift struct {
+	CheckedAt    time.Time        `json:"checked_at"`
+	Drifted      bool             `json:"drifted"`
+	LiveHash     string           `json:"live_hash,omitempty"`     // MetadataHash of the running agent
+	RecordedHash string           `json:"recorded_hash,omitempty"` // MetadataHash of the NFT
+	Changes      []MetadataChange `json:"changes,omitempty"`       // Drift fields that differ, Current being the NFT's value
+	Error        string           `json:"error,omitempty"`         // Why the last check failed
+}
+
+// MetadataHash returns the SHA-256 hash of the drift fields of the metadata.
+// Capabilities are hashed as a set, so their order does not matter.
+func MetadataHash(metadata AgentMetadata) string {
+	hash := sha256.New()
+	for _, value := range []string{metadata.Name, metadata.Description, capabilitySet(metadata.Capabilities), metadata.Version} {
+		hash.Write([]byte(value))
+		hash.Write([]byte{0})
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// CompareMetadata compares the metadata of the running agent with the
+// metadata recorded for its NFT, looking at the drift fields only
+func CompareMetadata(recorded, live AgentMetadata) MetadataDrift {
+	drift := MetadataDrift{
+		CheckedAt:    time.Now(),
+		LiveHash:     MetadataHash(live),
+		RecordedHash: MetadataHash(recorded),
+	}
+	drift.Drifted = drift.LiveHash != drift.RecordedHash
+	for _, change := range DiffAgentMetadata(recorded, live) {
+		if slices.Contains(DriftFields, change.Field) {
+			drift.Changes = append(drift.Changes, change)
+		}
+	}
+	return drift
+}
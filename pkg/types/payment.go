@@ -0,0 +1,44 @@
+package types
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// PaymentVerifier decides whether a task was paid for before it runs
+type PaymentVerifier interface {
+	// VerifyPayment returns nil if the task may run. Unpaid tasks are
+	// rejected with an error wrapping ErrPaymentRequired or ErrPaymentInvalid,
+	// preferably a *PaymentError; any other error means the payment could not
+	// be checked, e.g. because the chain was unreachable.
+	VerifyPayment(ctx context.Context, payment PaymentInfo) error
+}
+
+// PaymentInfo is what a payment verifier knows about a task
+type PaymentInfo struct {
+	TaskID       string
+	Sender       string          // Wallet that requested the task ("" when unknown)
+	Capabilities []string        // Capabilities the task states it requires
+	Offered      []string        // Capabilities the agent offers, which the task may reach
+	Proof        json.RawMessage // The "payment" field of the task data (nil = none)
+}
+
+// PaymentError explains why a task was not paid for and what it costs. It
+// is sent in the details of the task's rejection.
+type PaymentError struct {
+	Err      error  `json:"-"` // ErrPaymentRequired or ErrPaymentInvalid
+	Reason   string `json:"reason"`
+	Amount   string `json:"amount,omitempty"`   // Decimal price of the task
+	Currency string `json:"currency,omitempty"` // e.g. "PEAQ"
+	Payee    string `json:"payee,omitempty"`    // Address to pay
+}
+
+// Error implements the error interface
+func (e *PaymentError) Error() string {
+	return e.Err.Error() + ": " + e.Reason
+}
+
+// Unwrap returns ErrPaymentRequired or ErrPaymentInvalid
+func (e *PaymentError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,164 @@
+package types
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// GPU describes identical GPUs available to an agent
+type GPU struct {
+	Model  string `json:"model"`
+	VRAMMB int    `json:"vram_mb,omitempty"` // Memory of each GPU in MiB
+	Count  int    `json:"count,omitempty"`   // Number of GPUs of this model (0 = 1)
+}
+
+// ComputeResources describes the hardware an agent runs on, so coordinators and
+// peers can route heavy jobs to agents able to run them
+type ComputeResources struct {
+	GPUs             []GPU `json:"gpus,omitempty"`
+	CPUCores         int   `json:"cpu_cores,omitempty"`
+	MemoryMB         int   `json:"memory_mb,omitempty"`          // System memory in MiB
+	MaxContextTokens int   `json:"max_context_tokens,omitempty"` // Largest prompt the agent's model accepts
+}
+
+// IsZero reports whether no resources are described
+func (r ComputeResources) IsZero() bool {
+	return len(r.GPUs) == 0 && r.CPUCores == 0 && r.MemoryMB == 0 && r.MaxContextTokens == 0
+}
+
+// GPUCount returns the total number of GPUs
+func (r ComputeResources) GPUCount() int {
+	count := 0
+	for _, gpu := range r.GPUs {
+		count += max(gpu.Count, 1)
+	}
+	return count
+}
+
+// MaxGPUVRAMMB returns the memory of the largest GPU in MiB, which bounds the model size it can run
+func (r ComputeResources) MaxGPUVRAMMB() int {
+	vram := 0
+	for _, gpu := range r.GPUs {
+		vram = max(vram, gpu.VRAMMB)
+	}
+	return vram
+}
+
+// Meets reports whether the resources satisfy a requirement. Each field set in
+// required is a minimum; GPU requirements are compared by GPU count and the
+// memory of the largest GPU, and a required GPU model must be present
+// (matched case-insensitively as a substring, e.g. "A100").
+func (r ComputeResources) Meets(required ComputeResources) bool {
+	if r.CPUCores < required.CPUCores || r.MemoryMB < required.MemoryMB || r.MaxContextTokens < required.MaxContextTokens {
+		return false
+	}
+	if r.GPUCount() < required.GPUCount() || r.MaxGPUVRAMMB() < required.MaxGPUVRAMMB() {
+		return false
+	}
+	for _, want := range required.GPUs {
+		if want.Model != "" && !r.hasGPUModel(want.Model) {
+			return false
+		}
+	}
+	return true
+}
+
+// hasGPUModel reports whether one of the GPUs matches model
+func (r ComputeResources) hasGPUModel(model string) bool {
+	model = strings.ToLower(model)
+	for _, gpu := range r.GPUs {
+		if strings.Contains(strings.ToLower(gpu.Model), model) {
+			return true
+		}
+	}
+	return false
+}
+
+// String returns a short summary, e.g. "2x NVIDIA RTX 4090 (24GB), 16 cores, 64GB RAM, 128k context"
+func (r ComputeResources) String() string {
+	var parts []string
+	for _, gpu := range r.GPUs {
+		part := gpu.Model
+		if gpu.Count > 1 {
+			part = fmt.Sprintf("%dx %s", gpu.Count, part)
+		}
+		if gpu.VRAMMB > 0 {
+			part += " (" + formatMB(gpu.VRAMMB) + ")"
+		}
+		parts = append(parts, part)
+	}
+	if r.CPUCores > 0 {
+		parts = append(parts, fmt.Sprintf("%d cores", r.CPUCores))
+	}
+	if r.MemoryMB > 0 {
+		parts = append(parts, formatMB(r.MemoryMB)+" RAM")
+	}
+	if r.MaxContextTokens > 0 {
+		parts = append(parts, fmt.Sprintf("%dk context", r.MaxContextTokens/1000))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// ParseGPUs parses a comma-separated list of GPUs of the form "[<count>x ]<model>[:<vram>]",
+// e.g. "2x NVIDIA RTX 4090:24GB, NVIDIA A100:80GB". VRAM accepts GB or MB suffixes;
+// a bare number is in GB.
+func ParseGPUs(list string) ([]GPU, error) {
+	var gpus []GPU
+	for _, entry := range strings.Split(list, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		var gpu GPU
+		if count, rest, ok := strings.Cut(entry, "x "); ok {
+			if n, err := strconv.Atoi(strings.TrimSpace(count)); err == nil && n > 0 {
+				gpu.Count = n
+				entry = strings.TrimSpace(rest)
+			}
+		}
+
+		model, vram, hasVRAM := strings.Cut(entry, ":")
+		gpu.Model = strings.TrimSpace(model)
+		if gpu.Model == "" {
+			return nil, fmt.Errorf("invalid GPU %q: missing model", entry)
+		}
+		if hasVRAM {
+			mb, err := ParseMemoryMB(vram)
+			if err != nil {
+				return nil, fmt.Errorf("invalid GPU %q: %w", entry, err)
+			}
+			gpu.VRAMMB = mb
+		}
+		gpus = append(gpus, gpu)
+	}
+	return gpus, nil
+}
+
+// ParseMemoryMB parses a memory size such as "24GB", "512MB" or "24" (GB) into MiB
+func ParseMemoryMB(value string) (int, error) {
+	size := strings.ToUpper(strings.TrimSpace(value))
+	multiplier := 1024
+	switch {
+	case strings.HasSuffix(size, "GB"), strings.HasSuffix(size, "GIB"):
+		size = strings.TrimSuffix(strings.TrimSuffix(size, "GB"), "GIB")
+	case strings.HasSuffix(size, "MB"), strings.HasSuffix(size, "MIB"):
+		size = strings.TrimSuffix(strings.TrimSuffix(size, "MB"), "MIB")
+		multiplier = 1
+	}
+
+	amount, err := strconv.ParseFloat(strings.TrimSpace(size), 64)
+	if err != nil || amount < 0 {
+		return 0, fmt.Errorf("invalid memory size %q", value)
+	}
+	return int(amount * float64(multiplier)), nil
+}
+
+// formatMB formats a size in MiB, using GB for whole gigabytes
+func formatMB(mb int) string {
+	if mb >= 1024 && mb%1024 == 0 {
+		return fmt.Sprintf("%dGB", mb/1024)
+	}
+	return fmt.Sprintf("%dMB", mb)
+}
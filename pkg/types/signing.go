@@ -0,0 +1,72 @@
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// SigningPayload returns the canonical bytes covered by a message signature:
+// the routing fields, content and data as compact JSON in a fixed field order,
+// with the timestamp in Unix milliseconds.
+//
+// Room aliases, metadata (which carries trace headers) and the signature
+// itself are not covered. Content is signed as sent, i.e. after compression,
+// and each part of a split message is signed with its chunk position.
+func (m *Message) SigningPayload() ([]byte, error) {
+	payload := struct {
+		Type        string          `json:"type"`
+		From        string          `json:"from"`
+		To          string          `json:"to"`
+		TaskID      string          `json:"task_id"`
+		Room        string          `json:"room"`
+		ContentType string          `json:"content_type"`
+		Content     string          `json:"content"`
+		Encoding    string          `json:"content_encoding"`
+		Data        json.RawMessage `json:"data"`
+		Timestamp   int64           `json:"timestamp"`
+		Chunk       *Chunk          `json:"chunk,omitempty"`
+	}{
+		Type:        m.Type,
+		From:        m.From,
+		To:          m.To,
+		TaskID:      m.TaskID,
+		Room:        m.Room,
+		ContentType: m.ContentType,
+		Content:     m.Content,
+		Encoding:    m.Encoding,
+		Data:        m.Data,
+		Timestamp:   m.Timestamp.UnixMilli(),
+		Chunk:       m.Chunk,
+	}
+	if len(payload.Data) == 0 {
+		payload.Data = nil
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal signing payload: %w", err)
+	}
+	return data, nil
+}
+
+// UserSigningPayload returns the canonical bytes a user signs to
+// authenticate a direct message: the content, the timestamp in Unix
+// milliseconds and a nonce the user never reuses, as compact JSON. The
+// signer is the message's sender.
+func (m *Message) UserSigningPayload() ([]byte, error) {
+	payload := struct {
+		Content   string `json:"content"`
+		Timestamp int64  `json:"timestamp"`
+		Nonce     string `json:"nonce"`
+	}{
+		Content:   m.Content,
+		Timestamp: m.Timestamp.UnixMilli(),
+		Nonce:     m.Nonce,
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal user signing payload: %w", err)
+	}
+	return data, nil
+}
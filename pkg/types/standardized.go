@@ -0,0 +1,95 @@
+package types
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ErrInvalidContent is returned for content that does not fit its content type
+var ErrInvalidContent = errors.New("invalid message content")
+
+// IsStructuredContentType reports whether messages of the content type carry
+// a JSON document, which cannot be cut without breaking it
+func IsStructuredContentType(contentType string) bool {
+	switch contentType {
+	case StandardMessageTypeJSON, StandardMessageTypeArray, StandardMessageTypeProgress, StandardMessageTypeStatus:
+		return true
+	}
+	return IsBinaryContentType(contentType)
+}
+
+// Encode returns the content as it is sent in a message's Content, with
+// ContentType going in the message's ContentType. Text types take a string.
+// Structured types are marshaled to JSON; a string, []byte or
+// json.RawMessage already holding valid JSON is sent as is. ARRAY content
+// must be a JSON array, and a nil slice is sent as an empty one.
+func (s StandardizedMessage) Encode() (string, error) {
+	if !IsStructuredContentType(s.ContentType) {
+		text, ok := s.Content.(string)
+		if !ok {
+			return "", fmt.Errorf("%w: %s content must be a string, got %T", ErrInvalidContent, s.ContentType, s.Content)
+		}
+		return text, nil
+	}
+
+	var data []byte
+	switch content := s.Content.(type) {
+	case string:
+		data = []byte(content)
+	case []byte:
+		data = content
+	case json.RawMessage:
+		data = content
+	}
+	if data == nil || !json.Valid(data) {
+		var err error
+		if data, err = json.Marshal(s.Content); err != nil {
+			return "", fmt.Errorf("%w: failed to marshal %s content: %v", ErrInvalidContent, s.ContentType, err)
+		}
+	}
+
+	if s.ContentType == StandardMessageTypeArray {
+		data = bytes.TrimSpace(data)
+		if bytes.Equal(data, []byte("null")) {
+			data = []byte("[]")
+		}
+		if data[0] != '[' {
+			return "", fmt.Errorf("%w: ARRAY content must be a JSON array", ErrInvalidContent)
+		}
+	}
+	return string(data), nil
+}
+
+// StandardizedContent decodes the content of a message sent with a content
+// type: JSON and ARRAY content is unmarshaled into generic values, IMAGE and
+// AUDIO content into a *MediaContent, and other content is returned as the
+// string it is. Messages without a content type count as STRING. The
+// message's sequence number is kept.
+func (m *Message) StandardizedContent() (*StandardizedMessage, error) {
+	contentType := m.ContentType
+	if contentType == "" {
+		contentType = StandardMessageTypeString
+	}
+	decoded := &StandardizedMessage{ContentType: contentType, Content: m.Content, Sequence: m.Sequence}
+
+	var err error
+	switch {
+	case IsBinaryContentType(contentType):
+		var media MediaContent
+		err = json.Unmarshal([]byte(m.Content), &media)
+		decoded.Content = &media
+	case IsStructuredContentType(contentType):
+		var value interface{}
+		err = json.Unmarshal([]byte(m.Content), &value)
+		decoded.Content = value
+		if _, isArray := value.([]interface{}); err == nil && contentType == StandardMessageTypeArray && !isArray {
+			err = errors.New("not a JSON array")
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s content: %v", ErrInvalidContent, contentType, err)
+	}
+	return decoded, nil
+}
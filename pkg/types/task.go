@@ -0,0 +1,40 @@
+package types
+
+import (
+	"context"
+	"time"
+)
+
+// TaskInfo describes the task being handled. The SDK attaches it to the
+// context passed to the agent handler and to TaskResultHandler, so result
+// handlers can record more than the task ID and result.
+type TaskInfo struct {
+	ID        string    // Task ID
+	Room      string    // Room the task was sent in ("" outside the network)
+	Sender    string    // Address of the user who sent the task ("" when unknown)
+	Input     string    // Task content as passed to the handler
+	StartTime time.Time // When the SDK started handling the task
+	Deadline  time.Time // When the server needs the result (zero = no deadline)
+
+	// The sender signed the message, see network.UserSignatures
+	SenderVerified bool
+
+	// Capabilities the task requires, e.g. to route it to a handler (empty = none stated)
+	Capabilities []string
+
+	// Capabilities the agent offers, which the task may reach (empty = unknown)
+	Offered []string
+}
+
+type taskInfoKey struct{}
+
+// WithTaskInfo returns a context carrying info about the current task
+func WithTaskInfo(ctx context.Context, info TaskInfo) context.Context {
+	return context.WithValue(ctx, taskInfoKey{}, info)
+}
+
+// TaskInfoFromContext returns the current task's info, if the context carries it
+func TaskInfoFromContext(ctx context.Context) (TaskInfo, bool) {
+	info, ok := ctx.Value(taskInfoKey{}).(TaskInfo)
+	return info, ok
+}
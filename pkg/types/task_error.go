@@ -0,0 +1,125 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// ErrorCode classifies why a task failed, for consumers that react to
+// failures without parsing messages
+type ErrorCode string
+
+// Task error codes
+const (
+	ErrorCodeRateLimited           ErrorCode = "RATE_LIMITED"           // Too many requests; retry later
+	ErrorCodeTimeout               ErrorCode = "TIMEOUT"                // The task ran out of time
+	ErrorCodeInvalidInput          ErrorCode = "INVALID_INPUT"          // The request itself is wrong
+	ErrorCodeInternal              ErrorCode = "INTERNAL"               // The agent or one of its dependencies failed
+	ErrorCodeUnauthorized          ErrorCode = "UNAUTHORIZED"           // The requester may not run the task
+	ErrorCodeCapabilityUnsupported ErrorCode = "CAPABILITY_UNSUPPORTED" // The agent lacks a required capability
+	ErrorCodeBudgetExhausted       ErrorCode = "BUDGET_EXHAUSTED"       // The task used up its time, tokens or messages
+)
+
+// Retryable reports whether a task failing with the code may succeed when sent again
+func (c ErrorCode) Retryable() bool {
+	switch c {
+	case ErrorCodeRateLimited, ErrorCodeTimeout, ErrorCodeInternal:
+		return true
+	default:
+		return false
+	}
+}
+
+// TaskError is a task failure with a machine-readable classification. It is
+// sent in the data of the failed task's response under "error_info".
+// Handlers return one to control how a failure is reported, e.g.
+//
+//	return "", types.InvalidInput("url %q is not absolute", url)
+type TaskError struct {
+	Code      ErrorCode              `json:"code"`
+	Reason    string                 `json:"reason,omitempty"`  // Finer cause, e.g. "quota_exceeded"
+	Message   string                 `json:"message,omitempty"` // What went wrong, for people
+	Retryable bool                   `json:"retryable"`
+	Details   map[string]interface{} `json:"details,omitempty"`
+	Err       error                  `json:"-"` // Underlying error, if any
+}
+
+// NewTaskError creates a task error, retryable as its code is by default
+func NewTaskError(code ErrorCode, format string, args ...interface{}) *TaskError {
+	return &TaskError{Code: code, Message: fmt.Sprintf(format, args...), Retryable: code.Retryable()}
+}
+
+// Error implements the error interface
+func (e *TaskError) Error() string {
+	switch {
+	case e.Message != "":
+		return e.Message
+	case e.Err != nil:
+		return e.Err.Error()
+	case e.Reason != "":
+		return e.Reason
+	default:
+		return string(e.Code)
+	}
+}
+
+// Unwrap returns the underlying error
+func (e *TaskError) Unwrap() error {
+	return e.Err
+}
+
+// WithDetail adds a machine-readable detail and returns the error
+func (e *TaskError) WithDetail(key string, value interface{}) *TaskError {
+	if e.Details == nil {
+		e.Details = make(map[string]interface{})
+	}
+	e.Details[key] = value
+	return e
+}
+
+// InvalidInput reports a task that cannot be run as requested
+func InvalidInput(format string, args ...interface{}) *TaskError {
+	return NewTaskError(ErrorCodeInvalidInput, format, args...)
+}
+
+// Unauthorized reports a requester that may not run the task
+func Unauthorized(format string, args ...interface{}) *TaskError {
+	return NewTaskError(ErrorCodeUnauthorized, format, args...)
+}
+
+// RateLimited reports a task refused for load, to be retried after retryAfter (0 = unknown)
+func RateLimited(retryAfter time.Duration, format string, args ...interface{}) *TaskError {
+	e := NewTaskError(ErrorCodeRateLimited, format, args...)
+	if retryAfter > 0 {
+		e.WithDetail("retry_after", int(math.Ceil(retryAfter.Seconds())))
+	}
+	return e
+}
+
+// Timeout reports a task that ran out of time
+func Timeout(format string, args ...interface{}) *TaskError {
+	return NewTaskError(ErrorCodeTimeout, format, args...)
+}
+
+// CapabilityUnsupported reports capabilities the agent lacks
+func CapabilityUnsupported(capabilities ...string) *TaskError {
+	e := NewTaskError(ErrorCodeCapabilityUnsupported, "unsupported capabilities: %s", strings.Join(capabilities, ", "))
+	return e.WithDetail("capabilities", capabilities)
+}
+
+// Internal reports a failure of the agent or one of its dependencies
+func Internal(err error) *TaskError {
+	return &TaskError{Code: ErrorCodeInternal, Message: err.Error(), Retryable: true, Err: err}
+}
+
+// AsTaskError returns the task error in err's chain, or nil
+func AsTaskError(err error) *TaskError {
+	var taskErr *TaskError
+	if errors.As(err, &taskErr) {
+		return taskErr
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package types
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// UsageMeter records the tasks an agent completed, for billing
+type UsageMeter interface {
+	// RecordUsage records a completed task and returns the receipt to send
+	// with its response (nil = no receipt)
+	RecordUsage(ctx context.Context, task TaskInfo) (*UsageReceipt, error)
+}
+
+// UsageReceipt is the agent's signed statement of what a task cost. It is
+// sent in the data of the task's final response, or as a usage_receipt
+// message after a streaming task.
+type UsageReceipt struct {
+	TaskID     string    `json:"task_id"`
+	Agent      string    `json:"agent"`                // Wallet of the agent that signed the receipt
+	Sender     string    `json:"sender,omitempty"`     // Who the task was run for
+	Capability string    `json:"capability,omitempty"` // Capability the task was billed for (empty = default price)
+	Amount     string    `json:"amount"`               // Decimal price, e.g. "0.002"
+	Currency   string    `json:"currency,omitempty"`
+	Timestamp  time.Time `json:"timestamp"`
+	Signature  string    `json:"signature,omitempty"` // Agent's signature of SigningPayload
+}
+
+// SigningPayload returns the bytes covered by the receipt's signature: every
+// field but the signature as compact JSON, with the timestamp in Unix milliseconds
+func (r *UsageReceipt) SigningPayload() ([]byte, error) {
+	payload := struct {
+		TaskID     string `json:"task_id"`
+		Agent      string `json:"agent"`
+		Sender     string `json:"sender"`
+		Capability string `json:"capability"`
+		Amount     string `json:"amount"`
+		Currency   string `json:"currency"`
+		Timestamp  int64  `json:"timestamp"`
+	}{r.TaskID, r.Agent, r.Sender, r.Capability, r.Amount, r.Currency, r.Timestamp.UnixMilli()}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal receipt payload: %w", err)
+	}
+	return data, nil
+}
@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"runtime"
 	"time"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/output"
 )
 
 // Version information - using semantic versioning
@@ -126,7 +128,7 @@ func GetBanner() string {
 	banner += `
 └─────────────────────────────────────────────────────────────────┘`
 	
-	return banner
+	return output.Clean(banner)
 }
 
 // IsPreRelease returns true if this is a pre-release version
@@ -15,16 +15,20 @@ type MockProtocolHandler struct {
 	messages []string
 }
 
-func (m *MockProtocolHandler) SendTaskResponseToRoom(taskID, content string, success bool, errorMsg, room string) error {
-	message := fmt.Sprintf("TaskID: %s, Room: %s, Success: %t, Content: %s", taskID, room, success, content)
-	m.messages = append(m.messages, message)
-
-	// Parse and validate the standardized message format
-	var standardizedMsg types.StandardizedMessage
-	if err := json.Unmarshal([]byte(content), &standardizedMsg); err != nil {
+func (m *MockProtocolHandler) SendTaskResponseToRoom(taskID, content, contentType string, success bool, errorMsg, room string) error {
+	// Decode the content like a client, validating it against its content type
+	msg := &types.Message{ContentType: contentType, Content: content}
+	standardizedMsg, err := msg.StandardizedContent()
+	if err != nil {
 		return fmt.Errorf("invalid standardized message format: %w", err)
 	}
+	envelope, err := json.Marshal(standardizedMsg)
+	if err != nil {
+		return err
+	}
 
+	message := fmt.Sprintf("TaskID: %s, Room: %s, Success: %t, Content: %s", taskID, room, success, envelope)
+	m.messages = append(m.messages, message)
 	return nil
 }
 
@@ -72,18 +76,13 @@ func (t *TaskMessageSenderTest) SendMessageAsArray(content []interface{}) error
 }
 
 func (t *TaskMessageSenderTest) sendStandardizedMessage(msgType string, content interface{}) error {
-	standardizedMsg := types.StandardizedMessage{
-		ContentType: msgType,
-		Content:     content,
-	}
-
-	contentJSON, err := json.Marshal(standardizedMsg)
+	encoded, err := types.StandardizedMessage{ContentType: msgType, Content: content}.Encode()
 	if err != nil {
-		return fmt.Errorf("failed to marshal standardized message: %w", err)
+		return err
 	}
 
 	// This simulates what TaskMessageSender.sendStandardizedMessage does
-	return t.mockProtocol.SendTaskResponseToRoom(t.taskID, string(contentJSON), true, "", t.room)
+	return t.mockProtocol.SendTaskResponseToRoom(t.taskID, encoded, msgType, true, "", t.room)
 }
 
 // IntegrationTestAgent tests with the TaskMessageSender
@@ -0,0 +1,127 @@
+package unit
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/types"
+)
+
+func TestParseCapability(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+		valid bool
+	}{
+		{"text/analysis", "text/analysis", true},
+		{"text/analysis:detailed", "text/analysis:detailed", true},
+		{" content/generation:poems:short ", "content/generation:poems:short", true},
+		{"model/llama3_2", "model/llama3_2", true},
+		{"text/*", "text/*", true},
+		{"math/calculations:basic@2.1", "math/calculations:basic@2.1", true},
+		{"math/calculations@", "", false},
+		{"math/calculations@v2", "", false},
+		{"content_generation_poems", "", false},
+		{"Text/analysis", "", false},
+		{"text/", "", false},
+		{"/analysis", "", false},
+		{"text/analysis:", "", false},
+		{"text/analysis/detailed", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			capability, err := types.ParseCapability(tt.input)
+			if !tt.valid {
+				if !errors.Is(err, types.ErrInvalidCapability) {
+					t.Errorf("expected ErrInvalidCapability, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseCapability: %v", err)
+			}
+			if capability.String() != tt.want {
+				t.Errorf("String() = %q, want %q", capability.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestCapabilityMatches(t *testing.T) {
+	tests := []struct {
+		offered  string
+		required string
+		want     bool
+	}{
+		{"text/analysis", "text/analysis", true},
+		{"text/analysis:detailed", "text/analysis", true},
+		{"text/analysis", "text/analysis:detailed", false},
+		{"text/analysis:detailed:en", "text/analysis:en", true},
+		{"text/analysis", "text/*", true},
+		{"text/analysis", "code/*", false},
+		{"text/analysis", "text/summarization", false},
+		{"content_generation_poems", "content_generation_poems", true},
+		{"content_generation_poems", "content/generation:poems", false},
+		{"math/calculations@2.1", "math>=2", true},
+		{"math/calculations@1.9", "math>=2", false},
+		{"math/calculations", "math>=2", false},
+		{"math/calculations@2", "math/calculations<2.0.1", true},
+		{"math/calculations@2.0", "math/calculations@2", true},
+		{"math/calculations@3", "math/*>2", true},
+		{"math/calculations", "math", true},
+		{"math/calculations", "math>=x", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.offered+"->"+tt.required, func(t *testing.T) {
+			if got := types.CapabilityMatches(tt.offered, tt.required); got != tt.want {
+				t.Errorf("CapabilityMatches(%q, %q) = %v, want %v", tt.offered, tt.required, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateCapabilities(t *testing.T) {
+	if err := types.ValidateCapabilities([]string{"text/analysis:detailed", "general"}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := types.ValidateCapabilities([]string{"text/analysis", "Text/Bad"}); err == nil {
+		t.Error("expected error for malformed capability")
+	}
+}
+
+func TestParseCapabilityRequirement(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+		valid bool
+	}{
+		{"text/*", "text/*", true},
+		{"math", "math/*", true},
+		{"math>=2", "math/*>=2", true},
+		{"math/calculations:basic<3.1", "math/calculations:basic<3.1", true},
+		{"math@2", "math/*=2", true},
+		{"math>=", "", false},
+		{"math=>2", "", false},
+		{"Math>=2", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			requirement, err := types.ParseCapabilityRequirement(tt.input)
+			if !tt.valid {
+				if !errors.Is(err, types.ErrInvalidCapability) {
+					t.Errorf("expected ErrInvalidCapability, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseCapabilityRequirement: %v", err)
+			}
+			if requirement.String() != tt.want {
+				t.Errorf("String() = %q, want %q", requirement.String(), tt.want)
+			}
+		})
+	}
+}
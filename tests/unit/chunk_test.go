@@ -0,0 +1,203 @@
+package unit
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/types"
+)
+
+func TestMessageSplitContent(t *testing.T) {
+	report := strings.Repeat("| région | température |\n", 400)
+	msg := &types.Message{
+		Type:        types.MessageTypeTaskResponse,
+		ContentType: types.StandardMessageTypeMD,
+		Content:     report,
+		TaskID:      "task-1",
+		Metadata:    map[string]string{"traceparent": "00-abc"},
+	}
+
+	parts := msg.SplitContent(1000)
+	if len(parts) < 10 {
+		t.Fatalf("got %d parts", len(parts))
+	}
+	for i, part := range parts {
+		if len(part.Content) > 1000 || !utf8.ValidString(part.Content) {
+			t.Errorf("part %d has %d bytes, valid UTF-8 %v", i, len(part.Content), utf8.ValidString(part.Content))
+		}
+		if i < len(parts)-1 && !strings.HasSuffix(part.Content, "\n") {
+			t.Errorf("part %d not cut at a line break", i)
+		}
+		chunk := part.Chunk
+		if chunk == nil || chunk.ID != parts[0].Chunk.ID || chunk.Index != i || chunk.Total != len(parts) || chunk.Final != (i == len(parts)-1) {
+			t.Errorf("part %d has chunk %+v", i, chunk)
+		}
+		if part.TaskID != "task-1" || part.ContentType != types.StandardMessageTypeMD {
+			t.Errorf("part %d lost message fields: %+v", i, part)
+		}
+	}
+	if msg.Chunk != nil || msg.Content != report {
+		t.Error("original message modified")
+	}
+
+	if got := msg.SplitContent(0); len(got) != 1 || got[0] != msg {
+		t.Error("splitting disabled should return the message")
+	}
+	if got := msg.SplitContent(len(report)); len(got) != 1 || got[0].Chunk != nil {
+		t.Error("a message that fits should not be split")
+	}
+}
+
+func TestMessageSplitContentWithoutLineBreaks(t *testing.T) {
+	msg := &types.Message{Content: strings.Repeat("日本語", 100)}
+	parts := msg.SplitContent(10)
+	var joined strings.Builder
+	for _, part := range parts {
+		if len(part.Content) > 10 || !utf8.ValidString(part.Content) {
+			t.Fatalf("part %q", part.Content)
+		}
+		joined.WriteString(part.Content)
+	}
+	if joined.String() != msg.Content {
+		t.Error("parts do not add up to the content")
+	}
+}
+
+func TestChunkAssembler(t *testing.T) {
+	content := strings.Repeat("line of a long markdown report\n", 300)
+	msg := &types.Message{Type: types.MessageTypeTaskResponse, Content: content, TaskID: "task-1"}
+	if _, err := msg.CompressContent(1); err != nil {
+		t.Fatal(err)
+	}
+	parts := msg.SplitContent(16)
+	if len(parts) < 3 {
+		t.Fatalf("got %d parts", len(parts))
+	}
+
+	// Out of order, with a repeated part
+	assembler := types.NewChunkAssembler(0)
+	last := len(parts) - 1
+	order := append([]*types.Message{parts[last], parts[1], parts[1], parts[0]}, parts[2:last]...)
+	var joined *types.Message
+	for i, part := range order {
+		got, err := assembler.Add(part)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != nil {
+			if i != len(order)-1 {
+				t.Fatalf("reassembled after %d of %d parts", i+1, len(order))
+			}
+			joined = got
+		}
+	}
+	if joined == nil || joined.Chunk != nil || assembler.Pending() != 0 {
+		t.Fatalf("got %+v, %d pending", joined, assembler.Pending())
+	}
+	if err := joined.DecodeContent(); err != nil || joined.Content != content {
+		t.Errorf("reassembled content differs (err %v)", err)
+	}
+
+	// Messages that are not split pass through
+	plain := &types.Message{Content: "short"}
+	if got, err := assembler.Add(plain); err != nil || got != plain {
+		t.Errorf("got %v, %v", got, err)
+	}
+}
+
+func TestJoinChunks(t *testing.T) {
+	msg := &types.Message{Content: strings.Repeat("abc\n", 50)}
+	parts := msg.SplitContent(20)
+
+	joined, err := types.JoinChunks(parts)
+	if err != nil || joined.Content != msg.Content {
+		t.Fatalf("got %v, %v", joined, err)
+	}
+	if _, err := types.JoinChunks(parts[1:]); !errors.Is(err, types.ErrInvalidChunk) {
+		t.Errorf("missing part: got %v", err)
+	}
+
+	bad := *parts[0]
+	bad.Chunk = &types.Chunk{ID: parts[0].Chunk.ID, Index: 5, Total: 2}
+	if _, err := types.JoinChunks([]*types.Message{&bad}); !errors.Is(err, types.ErrInvalidChunk) {
+		t.Errorf("index out of range: got %v", err)
+	}
+}
+
+func TestChunkAssemblerVerified(t *testing.T) {
+	msg := &types.Message{Type: types.MessageTypeTask, Content: strings.Repeat("abc\n", 50)}
+
+	parts := msg.SplitContent(20)
+	for _, part := range parts {
+		part.Verified = true
+	}
+	joined, err := types.JoinChunks(parts)
+	if err != nil || !joined.Verified || joined.Signature != "" {
+		t.Errorf("all parts verified: got %+v, %v", joined, err)
+	}
+
+	parts = msg.SplitContent(20)
+	for _, part := range parts[1:] {
+		part.Verified = true
+	}
+	if joined, err := types.JoinChunks(parts); err != nil || joined.Verified {
+		t.Errorf("one part unverified: got %+v, %v", joined, err)
+	}
+}
+
+func TestChunkAssemblerLimits(t *testing.T) {
+	split := func(content string) []*types.Message {
+		return (&types.Message{Content: content}).SplitContent(10)
+	}
+
+	// Over the pending limit the oldest incomplete message is dropped
+	assembler := types.NewChunkAssembler(0)
+	assembler.SetLimits(2, 0)
+	first, second, third := split(strings.Repeat("a", 30)), split(strings.Repeat("b", 30)), split(strings.Repeat("c", 30))
+	for _, parts := range [][]*types.Message{first, second, third} {
+		if _, err := assembler.Add(parts[0]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := assembler.Pending(); n != 2 {
+		t.Errorf("%d pending, want 2", n)
+	}
+	for _, part := range first[1:] {
+		if got, _ := assembler.Add(part); got != nil {
+			t.Error("dropped message was reassembled")
+		}
+	}
+
+	// Over the byte limit the oldest incomplete message is dropped
+	assembler = types.NewChunkAssembler(0)
+	assembler.SetLimits(0, 35)
+	first, second = split(strings.Repeat("a", 30)), split(strings.Repeat("b", 30))
+	assembler.Add(first[0])
+	assembler.Add(first[1])
+	assembler.Add(second[0])
+	assembler.Add(second[1])
+	if n := assembler.Pending(); n != 1 {
+		t.Errorf("%d pending, want 1", n)
+	}
+	assembler.Add(second[2])
+	if n := assembler.Pending(); n != 0 {
+		t.Errorf("%d pending after the last part, want 0", n)
+	}
+
+	// A message larger than the byte limit on its own is rejected
+	large := split(strings.Repeat("d", 60))
+	var err error
+	for _, part := range large {
+		if _, err = assembler.Add(part); err != nil {
+			break
+		}
+	}
+	if !errors.Is(err, types.ErrInvalidChunk) {
+		t.Errorf("oversized message: got %v", err)
+	}
+	if n := assembler.Pending(); n != 0 {
+		t.Errorf("%d pending after rejecting an oversized message, want 0", n)
+	}
+}
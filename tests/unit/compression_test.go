@@ -0,0 +1,111 @@
+package unit
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/types"
+)
+
+func TestMessageCompressContent(t *testing.T) {
+	large := strings.Repeat("| column | value |\n", 200)
+
+	tests := []struct {
+		name       string
+		content    string
+		threshold  int
+		compressed bool
+	}{
+		{"disabled", large, 0, false},
+		{"below threshold", "short result", 1024, false},
+		{"above threshold", large, 1024, true},
+		{"incompressible", "a1", 1, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg := &types.Message{Type: types.MessageTypeTaskResponse, Content: tt.content}
+
+			compressed, err := msg.CompressContent(tt.threshold)
+			if err != nil {
+				t.Fatalf("CompressContent: %v", err)
+			}
+			if compressed != tt.compressed {
+				t.Fatalf("compressed = %v, want %v", compressed, tt.compressed)
+			}
+
+			if !compressed {
+				if msg.Encoding != "" || msg.Content != tt.content {
+					t.Errorf("message modified without compression: %+v", msg)
+				}
+				return
+			}
+
+			if msg.Encoding != types.ContentEncodingGzipBase64 || len(msg.Content) >= len(tt.content) {
+				t.Errorf("unexpected compressed message: encoding %q, %d bytes", msg.Encoding, len(msg.Content))
+			}
+			if err := msg.DecodeContent(); err != nil {
+				t.Fatalf("DecodeContent: %v", err)
+			}
+			if msg.Content != tt.content || msg.Encoding != "" {
+				t.Error("round trip did not restore the original content")
+			}
+		})
+	}
+}
+
+func TestMessageDecodeContentErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		msg  types.Message
+	}{
+		{"unknown encoding", types.Message{Content: "abc", Encoding: "br"}},
+		{"invalid base64", types.Message{Content: "not base64!", Encoding: types.ContentEncodingGzipBase64}},
+		{"invalid gzip", types.Message{Content: "aGVsbG8=", Encoding: types.ContentEncodingGzipBase64}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.msg.DecodeContent(); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
+
+func TestDecompressContentLimit(t *testing.T) {
+	// About 32 KiB of gzip expanding to more than MaxDecompressedBytes
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	zeros := make([]byte, 1<<20)
+	for written := 0; written <= types.MaxDecompressedBytes; written += len(zeros) {
+		if _, err := zw.Write(zeros); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	bomb := &types.Message{Content: base64.StdEncoding.EncodeToString(buf.Bytes()), Encoding: types.ContentEncodingGzipBase64}
+
+	if err := bomb.DecodeContent(); !errors.Is(err, types.ErrContentTooLarge) {
+		t.Errorf("DecodeContent of %d compressed bytes: got %v, want ErrContentTooLarge", buf.Len(), err)
+	}
+	if bomb.Encoding != types.ContentEncodingGzipBase64 {
+		t.Error("content replaced although it was rejected")
+	}
+
+	// Content up to the limit is restored
+	fits := strings.Repeat("a", types.MaxDecompressedBytes)
+	encoded, err := types.CompressContent(fits)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if content, err := types.DecompressContent(encoded); err != nil || len(content) != len(fits) {
+		t.Errorf("content of the maximum size: got %d bytes, %v", len(content), err)
+	}
+}
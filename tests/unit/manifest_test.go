@@ -0,0 +1,94 @@
+package unit
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/types"
+)
+
+var translation = types.AgentCapability{
+	Name:        "text/translation",
+	Description: "Translates text",
+	Version:     "2.1",
+	InputSchema: json.RawMessage(`{
+		"type": "object",
+		"required": ["text", "to"],
+		"properties": {"text": {"type": "string"}, "to": {"type": "string"}}
+	}`),
+	OutputSchema: json.RawMessage(`{"type": "object", "required": ["translation"]}`),
+	Pricing:      &types.PricingHint{Price: "0.002", Currency: "USD", Unit: "1k_tokens"},
+}
+
+func TestAgentCapabilityID(t *testing.T) {
+	tests := []struct {
+		capability types.AgentCapability
+		want       string
+	}{
+		{translation, "text/translation@2.1"},
+		{types.AgentCapability{Name: "text/summary"}, "text/summary"},
+		{types.AgentCapability{Name: "poems", Version: "1"}, "poems"},
+	}
+	for _, tt := range tests {
+		if got := tt.capability.ID(); got != tt.want {
+			t.Errorf("ID() = %q, want %q", got, tt.want)
+		}
+	}
+}
+
+func TestValidateManifest(t *testing.T) {
+	if err := types.ValidateManifest([]types.AgentCapability{translation, {Name: "poems"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	invalid := map[string]types.AgentCapability{
+		"no name":        {},
+		"bad name":       {Name: "Text/Translation"},
+		"bad version":    {Name: "text/translation", Version: "two"},
+		"legacy version": {Name: "poems", Version: "v1"},
+		"bad schema":     {Name: "text/summary", InputSchema: json.RawMessage(`{"type": 5}`)},
+		"bad pricing":    {Name: "text/summary", Pricing: &types.PricingHint{Price: "1"}},
+	}
+	for name, capability := range invalid {
+		if err := types.ValidateManifest([]types.AgentCapability{capability}); !errors.Is(err, types.ErrInvalidCapability) {
+			t.Errorf("%s: got %v", name, err)
+		}
+	}
+
+	if err := types.ValidateManifest([]types.AgentCapability{translation, translation}); err == nil {
+		t.Error("duplicate capability accepted")
+	}
+}
+
+func TestAgentCapabilityValidateInput(t *testing.T) {
+	if err := translation.ValidateInput(`{"text": "hallo", "to": "en"}`); err != nil {
+		t.Error(err)
+	}
+	if err := translation.ValidateInput(`{"text": "hallo"}`); err == nil {
+		t.Error("input without a required field accepted")
+	}
+	if err := translation.ValidateOutput(`{"translation": "hello"}`); err != nil {
+		t.Error(err)
+	}
+	if err := (types.AgentCapability{Name: "poems"}).ValidateInput("anything"); err != nil {
+		t.Errorf("capability without a schema rejected input: %v", err)
+	}
+}
+
+func TestManifestCapability(t *testing.T) {
+	manifest := []types.AgentCapability{{Name: "poems"}, translation}
+
+	capability, ok := types.ManifestCapability(manifest, "text/translation>=2")
+	if !ok || capability.Name != "text/translation" {
+		t.Errorf("got %+v, %v", capability, ok)
+	}
+	if _, ok := types.ManifestCapability(manifest, "text/translation>=3"); ok {
+		t.Error("matched a newer version than declared")
+	}
+
+	schemas := types.ManifestInputSchemas(manifest)
+	if len(schemas) != 1 || schemas["text/translation@2.1"] == nil {
+		t.Errorf("got schemas for %v", schemas)
+	}
+}
@@ -0,0 +1,81 @@
+package unit
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/types"
+)
+
+// pngHeader is enough of a PNG for MIME type detection
+var pngHeader = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+func TestNewImageContent(t *testing.T) {
+	media, err := types.NewImageContent(pngHeader, "", "A chart")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if media.MimeType != "image/png" || media.Size != len(pngHeader) || media.Alt != "A chart" {
+		t.Errorf("got %+v", media)
+	}
+	data, err := media.Bytes()
+	if err != nil || !bytes.Equal(data, pngHeader) {
+		t.Errorf("round trip failed: %v", err)
+	}
+
+	// Parameters are dropped from a given MIME type
+	if media, err := types.NewImageContent([]byte{0xff, 0xd8, 0xff}, "image/jpeg; q=1", ""); err != nil || media.MimeType != "image/jpeg" {
+		t.Errorf("got %+v, %v", media, err)
+	}
+}
+
+func TestNewImageContentRejects(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     []byte
+		mimeType string
+		want     error
+	}{
+		{"empty", nil, "image/png", types.ErrUnsupportedMedia},
+		{"svg", []byte("<svg></svg>"), "image/svg+xml", types.ErrUnsupportedMedia},
+		{"text", []byte("hello"), "", types.ErrUnsupportedMedia},
+		{"too large", make([]byte, types.MaxImageBytes+1), "image/png", types.ErrMediaTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := types.NewImageContent(tt.data, tt.mimeType, ""); !errors.Is(err, tt.want) {
+				t.Errorf("got %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewAudioContent(t *testing.T) {
+	mp3 := []byte("ID3\x03\x00\x00\x00\x00\x00\x00")
+	media, err := types.NewAudioContent(mp3, "", "Hello")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if media.MimeType != "audio/mpeg" {
+		t.Errorf("detected %s", media.MimeType)
+	}
+	if _, err := types.NewAudioContent(pngHeader, "", ""); !errors.Is(err, types.ErrUnsupportedMedia) {
+		t.Errorf("image accepted as audio: %v", err)
+	}
+}
+
+func TestValidateHTML(t *testing.T) {
+	if err := types.ValidateHTML("<p>Report</p>"); err != nil {
+		t.Error(err)
+	}
+	for _, content := range []string{"  ", "\xff<p>", strings.Repeat("a", types.MaxHTMLBytes+1)} {
+		if err := types.ValidateHTML(content); err == nil {
+			t.Errorf("accepted %.20q", content)
+		}
+	}
+	if err := types.ValidateHTML(strings.Repeat("a", types.MaxHTMLBytes+1)); !errors.Is(err, types.ErrMediaTooLarge) {
+		t.Errorf("got %v", err)
+	}
+}
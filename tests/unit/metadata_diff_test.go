@@ -0,0 +1,83 @@
+package unit
+
+import (
+	"testing"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/types"
+)
+
+func TestDiffAgentMetadata(t *testing.T) {
+	current := types.AgentMetadata{
+		Name:         "Security Agent",
+		Description:  "Audits smart contracts",
+		Capabilities: []string{"audit", "report"},
+		ContactInfo:  "ops@example.com",
+		PricingModel: "free",
+		Version:      "1.0.0",
+	}
+
+	t.Run("in sync", func(t *testing.T) {
+		desired := current
+		desired.Capabilities = []string{"report", "audit", "audit"}
+		if changes := types.DiffAgentMetadata(current, desired); len(changes) != 0 {
+			t.Errorf("expected no changes, got %+v", changes)
+		}
+	})
+
+	t.Run("changed fields", func(t *testing.T) {
+		desired := current
+		desired.Description = "Audits and monitors smart contracts"
+		desired.Version = "1.1.0"
+		desired.Capabilities = []string{"audit", "monitor"}
+
+		changes := types.DiffAgentMetadata(current, desired)
+		want := []types.MetadataChange{
+			{Field: types.MetadataFieldDescription, Current: "Audits smart contracts", Desired: "Audits and monitors smart contracts"},
+			{Field: types.MetadataFieldCapabilities, Current: "audit,report", Desired: "audit,monitor"},
+			{Field: types.MetadataFieldVersion, Current: "1.0.0", Desired: "1.1.0"},
+		}
+		if len(changes) != len(want) {
+			t.Fatalf("changes = %+v, want %+v", changes, want)
+		}
+		for i := range want {
+			if changes[i] != want[i] {
+				t.Errorf("change %d = %+v, want %+v", i, changes[i], want[i])
+			}
+		}
+
+		if !changes[0].Updatable() || changes[1].Updatable() || !changes[2].Updatable() {
+			t.Error("unexpected Updatable results")
+		}
+	})
+}
+
+func TestCompareMetadata(t *testing.T) {
+	recorded := types.AgentMetadata{
+		Name:         "Security Agent",
+		Description:  "Audits smart contracts",
+		Capabilities: []string{"audit", "report"},
+		ContactInfo:  "ops@example.com",
+		Version:      "1.0.0",
+	}
+
+	// Capability order and fields outside DriftFields don't count as drift
+	live := recorded
+	live.Capabilities = []string{"report", "audit"}
+	live.ContactInfo = ""
+	if drift := types.CompareMetadata(recorded, live); drift.Drifted || len(drift.Changes) != 0 || drift.LiveHash != drift.RecordedHash {
+		t.Errorf("unexpected drift %+v", drift)
+	}
+
+	live.Version = "1.1.0"
+	drift := types.CompareMetadata(recorded, live)
+	if !drift.Drifted || drift.LiveHash == drift.RecordedHash {
+		t.Fatalf("drift not detected: %+v", drift)
+	}
+	want := types.MetadataChange{Field: types.MetadataFieldVersion, Current: "1.0.0", Desired: "1.1.0"}
+	if len(drift.Changes) != 1 || drift.Changes[0] != want {
+		t.Errorf("changes = %+v, want %+v", drift.Changes, want)
+	}
+	if drift.LiveHash != types.MetadataHash(live) {
+		t.Error("live hash differs from MetadataHash")
+	}
+}
@@ -0,0 +1,90 @@
+package unit
+
+import (
+	"testing"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/types"
+)
+
+func TestParseGPUs(t *testing.T) {
+	gpus, err := types.ParseGPUs("2x NVIDIA RTX 4090:24GB, NVIDIA A100:81920MB, Apple M3 Max")
+	if err != nil {
+		t.Fatalf("ParseGPUs failed: %v", err)
+	}
+
+	want := []types.GPU{
+		{Model: "NVIDIA RTX 4090", VRAMMB: 24576, Count: 2},
+		{Model: "NVIDIA A100", VRAMMB: 81920},
+		{Model: "Apple M3 Max"},
+	}
+	if len(gpus) != len(want) {
+		t.Fatalf("gpus = %+v, want %+v", gpus, want)
+	}
+	for i := range want {
+		if gpus[i] != want[i] {
+			t.Errorf("gpu %d = %+v, want %+v", i, gpus[i], want[i])
+		}
+	}
+
+	for _, invalid := range []string{":24GB", "RTX 4090:lots"} {
+		if _, err := types.ParseGPUs(invalid); err == nil {
+			t.Errorf("ParseGPUs(%q) should fail", invalid)
+		}
+	}
+}
+
+func TestParseMemoryMB(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int
+	}{
+		{"64GB", 65536},
+		{"512mb", 512},
+		{"1.5GiB", 1536},
+		{"24", 24576},
+	}
+	for _, tt := range tests {
+		if got, err := types.ParseMemoryMB(tt.in); err != nil || got != tt.want {
+			t.Errorf("ParseMemoryMB(%q) = %d, %v, want %d", tt.in, got, err, tt.want)
+		}
+	}
+	if _, err := types.ParseMemoryMB("-1GB"); err == nil {
+		t.Error("expected error for negative size")
+	}
+}
+
+func TestComputeResourcesMeets(t *testing.T) {
+	resources := types.ComputeResources{
+		GPUs:             []types.GPU{{Model: "NVIDIA RTX 4090", VRAMMB: 24576, Count: 2}},
+		CPUCores:         16,
+		MemoryMB:         65536,
+		MaxContextTokens: 128000,
+	}
+
+	tests := []struct {
+		name     string
+		required types.ComputeResources
+		want     bool
+	}{
+		{"nothing required", types.ComputeResources{}, true},
+		{"enough vram", types.ComputeResources{GPUs: []types.GPU{{VRAMMB: 16384}}}, true},
+		{"too little vram", types.ComputeResources{GPUs: []types.GPU{{VRAMMB: 40960}}}, false},
+		{"gpu count", types.ComputeResources{GPUs: []types.GPU{{Count: 2}}}, true},
+		{"too many gpus", types.ComputeResources{GPUs: []types.GPU{{Count: 4}}}, false},
+		{"gpu model", types.ComputeResources{GPUs: []types.GPU{{Model: "rtx 4090"}}}, true},
+		{"missing gpu model", types.ComputeResources{GPUs: []types.GPU{{Model: "A100"}}}, false},
+		{"context", types.ComputeResources{MaxContextTokens: 200000}, false},
+		{"cpu and memory", types.ComputeResources{CPUCores: 8, MemoryMB: 32768}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := resources.Meets(tt.required); got != tt.want {
+				t.Errorf("Meets(%+v) = %v, want %v", tt.required, got, tt.want)
+			}
+		})
+	}
+
+	if got, want := resources.String(), "2x NVIDIA RTX 4090 (24GB), 16 cores, 64GB RAM, 128k context"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,59 @@
+package unit
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/types"
+)
+
+func TestSigningPayloadRoundTrip(t *testing.T) {
+	msg := &types.Message{
+		Type:      types.MessageTypeTask,
+		From:      "coordinator",
+		Room:      "room-1",
+		Content:   "summarize this",
+		TaskID:    "task-1",
+		Data:      json.RawMessage(`{"task_id": "task-1",  "content": "summarize this"}`),
+		Timestamp: time.Date(2025, 1, 1, 12, 0, 0, 123456789, time.UTC),
+		Metadata:  map[string]string{"traceparent": "00-abc"},
+	}
+
+	want, err := msg.SigningPayload()
+	if err != nil {
+		t.Fatalf("SigningPayload: %v", err)
+	}
+
+	wire, err := json.Marshal(msg)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	var received types.Message
+	if err := json.Unmarshal(wire, &received); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	received.Metadata = nil
+	received.Signature = "0xdeadbeef"
+
+	got, err := received.SigningPayload()
+	if err != nil {
+		t.Fatalf("SigningPayload: %v", err)
+	}
+	if string(got) != string(want) {
+		t.Errorf("payload changed in transit:\n got %s\nwant %s", got, want)
+	}
+
+	received.Content = "something else"
+	tampered, _ := received.SigningPayload()
+	if string(tampered) == string(want) {
+		t.Error("payload should cover the content")
+	}
+}
+
+func TestSigningPayloadWithoutData(t *testing.T) {
+	msg := &types.Message{Type: types.MessageTypeTaskResponse, Data: json.RawMessage{}}
+	if _, err := msg.SigningPayload(); err != nil {
+		t.Errorf("SigningPayload: %v", err)
+	}
+}
@@ -0,0 +1,85 @@
+package unit
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/types"
+)
+
+func TestStandardizedMessageEncode(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		content     interface{}
+		want        string
+	}{
+		{"string", types.StandardMessageTypeString, "hello", "hello"},
+		{"markdown", types.StandardMessageTypeMD, "# Report", "# Report"},
+		{"json object", types.StandardMessageTypeJSON, map[string]interface{}{"severity": "high", "count": 3}, `{"count":3,"severity":"high"}`},
+		{"json struct", types.StandardMessageTypeJSON, struct {
+			Name string `json:"name"`
+		}{"alice"}, `{"name":"alice"}`},
+		{"json already encoded", types.StandardMessageTypeJSON, `{"ok":true}`, `{"ok":true}`},
+		{"json raw message", types.StandardMessageTypeJSON, json.RawMessage(`[1,2]`), `[1,2]`},
+		{"json plain string", types.StandardMessageTypeJSON, "not json", `"not json"`},
+		{"array", types.StandardMessageTypeArray, []interface{}{"a", 1, map[string]interface{}{"b": true}}, `["a",1,{"b":true}]`},
+		{"nil array", types.StandardMessageTypeArray, []interface{}(nil), `[]`},
+		{"media", types.StandardMessageTypeImage, &types.MediaContent{MimeType: "image/png", Data: "AA==", Size: 1}, `{"mime_type":"image/png","data":"AA==","size":1}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := types.StandardizedMessage{ContentType: tt.contentType, Content: tt.content}.Encode()
+			if err != nil || got != tt.want {
+				t.Errorf("got %q, %v, want %q", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestStandardizedMessageEncodeRejects(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		content     interface{}
+	}{
+		{"markdown not a string", types.StandardMessageTypeMD, 42},
+		{"array not an array", types.StandardMessageTypeArray, `{"a":1}`},
+		{"unmarshalable", types.StandardMessageTypeJSON, map[string]interface{}{"f": func() {}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := (types.StandardizedMessage{ContentType: tt.contentType, Content: tt.content}).Encode(); !errors.Is(err, types.ErrInvalidContent) {
+				t.Errorf("got %v", err)
+			}
+		})
+	}
+}
+
+func TestMessageStandardizedContent(t *testing.T) {
+	msg := &types.Message{ContentType: types.StandardMessageTypeArray, Content: `[{"id":"VULN-001"}]`}
+	decoded, err := msg.StandardizedContent()
+	if err != nil {
+		t.Fatal(err)
+	}
+	items, ok := decoded.Content.([]interface{})
+	if !ok || len(items) != 1 || items[0].(map[string]interface{})["id"] != "VULN-001" {
+		t.Errorf("got %#v", decoded.Content)
+	}
+
+	image := &types.Message{ContentType: types.StandardMessageTypeImage, Content: `{"mime_type":"image/png","data":"AA==","size":1}`}
+	if decoded, err := image.StandardizedContent(); err != nil || decoded.Content.(*types.MediaContent).MimeType != "image/png" {
+		t.Errorf("got %+v, %v", decoded, err)
+	}
+
+	plain := &types.Message{Content: "hello"}
+	if decoded, err := plain.StandardizedContent(); err != nil || decoded.ContentType != types.StandardMessageTypeString || decoded.Content != "hello" {
+		t.Errorf("got %+v, %v", decoded, err)
+	}
+
+	broken := &types.Message{ContentType: types.StandardMessageTypeJSON, Content: `{"cut`}
+	if _, err := broken.StandardizedContent(); !errors.Is(err, types.ErrInvalidContent) {
+		t.Errorf("got %v", err)
+	}
+}